@@ -0,0 +1,114 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	"github.com/madhouselabs/goman/pkg/cluster"
+	"github.com/madhouselabs/goman/pkg/i18n"
+	"github.com/madhouselabs/goman/pkg/storage"
+	"github.com/spf13/cobra"
+)
+
+var (
+	adminStatsSince time.Duration
+	adminStatsJSON  bool
+)
+
+// adminCmd represents the admin command group
+var adminCmd = &cobra.Command{
+	Use:   "admin",
+	Short: i18n.T("cmd.admin.short"),
+	Long:  i18n.T("cmd.admin.long"),
+}
+
+// adminStatsCmd groups statistics
+var adminStatsCmd = &cobra.Command{
+	Use:   "stats",
+	Short: i18n.T("cmd.admin.stats.short"),
+}
+
+// adminStatsCreationsCmd shows cluster creation times
+var adminStatsCreationsCmd = &cobra.Command{
+	Use:   "creations",
+	Short: i18n.T("cmd.admin.stats.creations.short"),
+	Long:  i18n.T("cmd.admin.stats.creations.long"),
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if clusterManager == nil {
+			clusterManager = cluster.NewManager()
+		}
+
+		since := time.Now().Add(-adminStatsSince)
+		records, err := clusterManager.CreationRecords(since)
+		if err != nil {
+			return fmt.Errorf("failed to read creation records: %w", err)
+		}
+		summaries := storage.SummarizeCreations(records)
+
+		if adminStatsJSON {
+			if records == nil {
+				records = []storage.CreationRecord{}
+			}
+			if summaries == nil {
+				summaries = []storage.CreationSummary{}
+			}
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			return enc.Encode(struct {
+				Since     time.Time                 `json:"since"`
+				Summaries []storage.CreationSummary `json:"summaries"`
+				Records   []storage.CreationRecord  `json:"records"`
+			}{since, summaries, records})
+		}
+
+		if len(records) == 0 {
+			outf("No cluster creations since %s\n", since.Local().Format("2006-01-02 15:04"))
+			return nil
+		}
+
+		outf("Cluster creations since %s:\n\n", since.Local().Format("2006-01-02 15:04"))
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "MODE\tREGION\tCOUNT\tMEAN\tP50\tP90\tP95\tMAX\tWITHIN SLO")
+		for _, s := range summaries {
+			fmt.Fprintf(w, "%s\t%s\t%d\t%s\t%s\t%s\t%s\t%s\t%d (%.0f%%)\n",
+				s.Mode, s.Region, s.Count, roundDuration(s.Mean), roundDuration(s.P50), roundDuration(s.P90),
+				roundDuration(s.P95), roundDuration(s.Max), s.WithinSLO, 100*float64(s.WithinSLO)/float64(s.Count))
+		}
+		if err := w.Flush(); err != nil {
+			return err
+		}
+
+		slowest := append([]storage.CreationRecord(nil), records...)
+		sort.SliceStable(slowest, func(i, j int) bool { return slowest[i].Duration > slowest[j].Duration })
+		if len(slowest) > 5 {
+			slowest = slowest[:5]
+		}
+		outln("\nSlowest:")
+		for _, r := range slowest {
+			breach := ""
+			if !r.WithinSLO() {
+				breach = fmt.Sprintf(", over the %s objective", r.SLO)
+			}
+			outf("  %s (%s, %s, %s): %s on %s%s\n", r.Cluster, r.Mode, r.Region, r.InstanceType,
+				roundDuration(r.Duration), r.CreatedAt.Local().Format("2006-01-02 15:04"), breach)
+		}
+		return nil
+	},
+}
+
+// roundDuration shortens durations in statistics to whole seconds
+func roundDuration(d time.Duration) time.Duration {
+	return d.Round(time.Second)
+}
+
+func init() {
+	adminStatsCreationsCmd.Flags().DurationVar(&adminStatsSince, "since", 30*24*time.Hour, "Only include creations finished within this long")
+	adminStatsCreationsCmd.Flags().BoolVar(&adminStatsJSON, "json", false, "Print summaries and records as JSON")
+	adminStatsCmd.AddCommand(adminStatsCreationsCmd)
+	adminCmd.AddCommand(adminStatsCmd)
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/madhouselabs/goman/pkg/config"
+	"github.com/madhouselabs/goman/pkg/controller"
+	"github.com/madhouselabs/goman/pkg/i18n"
+	"github.com/madhouselabs/goman/pkg/provider"
+	"github.com/madhouselabs/goman/pkg/provider/registry"
+	"github.com/spf13/cobra"
+)
+
+var (
+	adminGCDryRun  bool
+	adminGCRegions []string
+	adminGCJSON    bool
+	adminGCLast    bool
+)
+
+// adminGCCmd deletes the cloud resources of clusters that no longer exist
+var adminGCCmd = &cobra.Command{
+	Use:   "gc",
+	Short: i18n.T("cmd.admin.gc.short"),
+	Long:  i18n.T("cmd.admin.gc.long"),
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := config.NewConfig()
+		if err != nil {
+			return fmt.Errorf("failed to load config: %w", err)
+		}
+		p, err := registry.GetConfiguredProvider(cfg.AWSProfile, cfg.AWSRegion)
+		if err != nil {
+			return fmt.Errorf("failed to get provider: %w", err)
+		}
+		ctx := context.Background()
+
+		var report *controller.OrphanReport
+		if adminGCLast {
+			data, err := p.GetStorageService().GetObject(ctx, controller.OrphanReportKey)
+			if errors.Is(err, provider.ErrNotFound) {
+				outln("The controller has not collected orphaned resources yet")
+				return nil
+			}
+			if err != nil {
+				return fmt.Errorf("failed to read the last report: %w", err)
+			}
+			report = &controller.OrphanReport{}
+			if err := json.Unmarshal(data, report); err != nil {
+				return fmt.Errorf("failed to parse the last report: %w", err)
+			}
+		} else {
+			reconciler, err := controller.NewReconciler(p, "goman-gc")
+			if err != nil {
+				return err
+			}
+			if err := reconciler.SetSettings(controller.LoadSettings(ctx, p.GetStorageService())); err != nil {
+				return fmt.Errorf("invalid controller settings: %w", err)
+			}
+			if report, err = reconciler.CollectOrphans(ctx, adminGCRegions, adminGCDryRun); err != nil {
+				return err
+			}
+		}
+
+		if adminGCJSON {
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			return enc.Encode(report)
+		}
+		return printOrphanReport(report)
+	},
+}
+
+// printOrphanReport prints the resources of an orphan collection as a table
+func printOrphanReport(report *controller.OrphanReport) error {
+	mode := ""
+	if report.DryRun {
+		mode = " (dry run)"
+	}
+	outf("Orphaned resources in %s on %s%s:\n\n", strings.Join(report.Regions, ", "),
+		report.StartedAt.Local().Format("2006-01-02 15:04"), mode)
+
+	if len(report.Resources) == 0 {
+		outln("  None found")
+	} else {
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "CLUSTER\tKIND\tID\tREGION\tACTION")
+		for _, res := range report.Resources {
+			action := res.Action
+			if res.Error != "" {
+				action += ": " + res.Error
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", res.Cluster, res.Kind, res.ID, res.Region, action)
+		}
+		if err := w.Flush(); err != nil {
+			return err
+		}
+	}
+
+	for _, e := range report.Errors {
+		outf("\n⚠️  Not searched: %s", e)
+	}
+	if len(report.Errors) > 0 {
+		outln("")
+	}
+	if report.DryRun && report.Count(controller.OrphanWouldDelete) > 0 {
+		outln("\n💡 Run without --dry-run to delete them")
+	}
+	return nil
+}
+
+func init() {
+	adminGCCmd.Flags().BoolVar(&adminGCDryRun, "dry-run", false, "List what would be deleted without deleting anything")
+	adminGCCmd.Flags().StringSliceVar(&adminGCRegions, "region", nil, "Also search this region (repeatable)")
+	adminGCCmd.Flags().BoolVar(&adminGCJSON, "json", false, "Print the report as JSON")
+	adminGCCmd.Flags().BoolVar(&adminGCLast, "last", false, "Show the report of the controller's last run")
+	adminCmd.AddCommand(adminGCCmd)
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/madhouselabs/goman/pkg/cluster"
+	"github.com/madhouselabs/goman/pkg/i18n"
+	"github.com/madhouselabs/goman/pkg/models"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+// adminPolicyCmd groups the organization policy commands
+var adminPolicyCmd = &cobra.Command{
+	Use:   "policy",
+	Short: i18n.T("cmd.admin.policy.short"),
+	Long:  i18n.T("cmd.admin.policy.long"),
+}
+
+// adminPolicyShowCmd prints the stored policy
+var adminPolicyShowCmd = &cobra.Command{
+	Use:   "show",
+	Short: i18n.T("cmd.admin.policy.show.short"),
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if clusterManager == nil {
+			clusterManager = cluster.NewManager()
+		}
+		policy, err := clusterManager.OrgPolicy()
+		if err != nil {
+			return err
+		}
+		if policy.Empty() {
+			outln("No organization policy is set")
+			return nil
+		}
+		data, err := yaml.Marshal(policy)
+		if err != nil {
+			return err
+		}
+		outf("%s", data)
+		return nil
+	},
+}
+
+// adminPolicySetCmd uploads a policy file
+var adminPolicySetCmd = &cobra.Command{
+	Use:   "set <policy-file>",
+	Short: i18n.T("cmd.admin.policy.set.short"),
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		data, err := os.ReadFile(args[0])
+		if err != nil {
+			return fmt.Errorf("failed to read policy file: %w", err)
+		}
+		var policy models.OrgPolicy
+		if err := yaml.Unmarshal(data, &policy); err != nil {
+			return fmt.Errorf("failed to parse policy file: %w", err)
+		}
+		if err := policy.Validate(); err != nil {
+			return err
+		}
+		if policy.Empty() {
+			return fmt.Errorf("policy file sets no rules; use 'goman admin policy clear' to remove the policy")
+		}
+		if clusterManager == nil {
+			clusterManager = cluster.NewManager()
+		}
+		if err := clusterManager.SetOrgPolicy(&policy); err != nil {
+			return err
+		}
+		outln("Organization policy updated")
+
+		// Point out clusters the new policy would reject on their next edit
+		var breaking []string
+		for _, c := range clusterManager.GetClusters() {
+			if err := policy.Check(c.Subject()); err != nil {
+				breaking = append(breaking, c.Name)
+			}
+		}
+		if len(breaking) > 0 {
+			outf("Existing clusters that break it and must comply when next edited: %s\n", strings.Join(breaking, ", "))
+		}
+		return nil
+	},
+}
+
+// adminPolicyClearCmd removes the policy
+var adminPolicyClearCmd = &cobra.Command{
+	Use:   "clear",
+	Short: i18n.T("cmd.admin.policy.clear.short"),
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if clusterManager == nil {
+			clusterManager = cluster.NewManager()
+		}
+		if err := clusterManager.SetOrgPolicy(nil); err != nil {
+			return err
+		}
+		outln("Organization policy removed")
+		return nil
+	},
+}
+
+func init() {
+	adminPolicyCmd.AddCommand(adminPolicyShowCmd)
+	adminPolicyCmd.AddCommand(adminPolicySetCmd)
+	adminPolicyCmd.AddCommand(adminPolicyClearCmd)
+	adminCmd.AddCommand(adminPolicyCmd)
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/madhouselabs/goman/pkg/config"
+	"github.com/madhouselabs/goman/pkg/controller"
+	"github.com/madhouselabs/goman/pkg/i18n"
+	"github.com/madhouselabs/goman/pkg/provider"
+	"github.com/madhouselabs/goman/pkg/provider/registry"
+	"github.com/spf13/cobra"
+)
+
+var (
+	adminPruneDryRun    bool
+	adminPruneRetention string
+	adminPruneJSON      bool
+)
+
+// adminPruneCmd deletes old artifacts from the state bucket
+var adminPruneCmd = &cobra.Command{
+	Use:   "prune",
+	Short: i18n.T("cmd.admin.prune.short"),
+	Long:  i18n.T("cmd.admin.prune.long"),
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := config.NewConfig()
+		if err != nil {
+			return fmt.Errorf("failed to load config: %w", err)
+		}
+		p, err := registry.GetConfiguredProvider(cfg.AWSProfile, cfg.AWSRegion)
+		if err != nil {
+			return fmt.Errorf("failed to get provider: %w", err)
+		}
+		ctx := context.Background()
+
+		retention, err := provider.LoadRetention(ctx, p.GetStorageService())
+		if err != nil {
+			return err
+		}
+		if retention, err = provider.ParseRetention(retention, adminPruneRetention); err != nil {
+			return err
+		}
+
+		reconciler, err := controller.NewReconciler(p, "goman-prune")
+		if err != nil {
+			return err
+		}
+		report, err := reconciler.PruneArtifacts(ctx, retention, adminPruneDryRun)
+		if err != nil {
+			return err
+		}
+
+		if adminPruneJSON {
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			return enc.Encode(report)
+		}
+		return printPruneReport(report)
+	},
+}
+
+// printPruneReport prints the objects of a pruning as a table
+func printPruneReport(report *controller.PruneReport) error {
+	mode := ""
+	if report.DryRun {
+		mode = " (dry run)"
+	}
+	outf("Objects past their retention (%s)%s:\n\n", report.Retention, mode)
+
+	if len(report.Objects) == 0 {
+		outln("  None found")
+	} else {
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "KIND\tKEY\tMODIFIED\tACTION")
+		for _, obj := range report.Objects {
+			action := obj.Action
+			if obj.Error != "" {
+				action += ": " + obj.Error
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", obj.Kind, obj.Key, obj.Modified.Local().Format("2006-01-02 15:04"), action)
+		}
+		if err := w.Flush(); err != nil {
+			return err
+		}
+	}
+
+	for _, e := range report.Errors {
+		outf("\n⚠️  Not pruned: %s", e)
+	}
+	if len(report.Errors) > 0 {
+		outln("")
+	}
+	if report.DryRun && report.Count(controller.OrphanWouldDelete) > 0 {
+		outln("\n💡 Run without --dry-run to delete them")
+	}
+	return nil
+}
+
+func init() {
+	adminPruneCmd.Flags().BoolVar(&adminPruneDryRun, "dry-run", false, "List what would be deleted without deleting anything")
+	adminPruneCmd.Flags().StringVar(&adminPruneRetention, "retention", "", "Days to keep for this run, e.g. diagnostics=7,logs=30,binaries=0")
+	adminPruneCmd.Flags().BoolVar(&adminPruneJSON, "json", false, "Print the report as JSON")
+	adminCmd.AddCommand(adminPruneCmd)
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/madhouselabs/goman/pkg/cluster"
+	"github.com/madhouselabs/goman/pkg/i18n"
+	"github.com/spf13/cobra"
+)
+
+// adminResyncCmd rebuilds a cluster's status from the live state
+var adminResyncCmd = &cobra.Command{
+	Use:   "resync <cluster-name>",
+	Short: i18n.T("cmd.admin.resync.short"),
+	Long:  i18n.T("cmd.admin.resync.long"),
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		clusterName := resolveClusterAlias(args[0])
+
+		if clusterManager == nil {
+			clusterManager = cluster.NewManager()
+		}
+
+		if err := clusterManager.RequestResync(clusterName); err != nil {
+			return fmt.Errorf("failed to request resync: %w", err)
+		}
+
+		outf("🔁 Status resync requested for cluster %s\n", clusterName)
+		outln("💡 Use 'goman cluster events " + clusterName + "' to see the result")
+		return nil
+	},
+}
+
+func init() {
+	adminCmd.AddCommand(adminResyncCmd)
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/madhouselabs/goman/pkg/i18n"
+	"github.com/madhouselabs/goman/pkg/models"
+	"github.com/spf13/cobra"
+)
+
+var clusterAnnotateJSON bool
+
+// clusterAnnotateCmd shows and edits the notes of a cluster
+var clusterAnnotateCmd = &cobra.Command{
+	Use:   "annotate <cluster-name> [key=value ...] [key- ...]",
+	Short: i18n.T("cmd.cluster.annotate.short"),
+	Long:  i18n.T("cmd.cluster.annotate.long"),
+	Args:  cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		clusterName := resolveClusterAlias(args[0])
+
+		set := make(map[string]string)
+		var remove []string
+		for _, arg := range args[1:] {
+			if key, value, ok := strings.Cut(arg, "="); ok {
+				set[key] = value
+			} else if key, ok := strings.CutSuffix(arg, "-"); ok {
+				remove = append(remove, key)
+			} else {
+				return fmt.Errorf("invalid note %q: use key=value to set it or key- to remove it", arg)
+			}
+		}
+
+		if len(set) > 0 || len(remove) > 0 {
+			if err := clusterManager.AnnotateCluster(clusterName, set, remove); err != nil {
+				return fmt.Errorf("failed to save notes: %w", err)
+			}
+		}
+
+		c, err := findCluster(clusterName)
+		if err != nil {
+			return err
+		}
+		annotations := c.Annotations
+
+		if clusterAnnotateJSON {
+			notes := make(map[string]string)
+			for _, key := range models.Notes(annotations) {
+				notes[key] = annotations[key]
+			}
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			return enc.Encode(notes)
+		}
+		printNotes(clusterName, annotations)
+		return nil
+	},
+}
+
+// printNotes prints the notes of a cluster, one per line
+func printNotes(clusterName string, annotations map[string]string) {
+	keys := models.Notes(annotations)
+	if len(keys) == 0 {
+		outf("No notes on cluster %s\n", clusterName)
+		outln("💡 Add one with 'goman cluster annotate " + clusterName + " owner=<name>'")
+		return
+	}
+	outf("📝 Notes on cluster %s:\n", clusterName)
+	for _, key := range keys {
+		outf("  %s: %s\n", key, annotations[key])
+	}
+}
+
+func init() {
+	clusterAnnotateCmd.Flags().BoolVar(&clusterAnnotateJSON, "json", false, "Print the notes as JSON")
+	clusterCmd.AddCommand(clusterAnnotateCmd)
+}
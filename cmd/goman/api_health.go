@@ -0,0 +1,111 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/madhouselabs/goman/pkg/connectivity"
+	"github.com/madhouselabs/goman/pkg/models"
+)
+
+// apiHealthInterval is how often running clusters' API servers are probed
+const apiHealthInterval = 15 * time.Second
+
+var apiHealthProber = connectivity.NewAPIHealthProber(5 * time.Second)
+
+// startAPIHealthProber probes API servers in the background and redraws
+// the cluster list with fresh badges
+func startAPIHealthProber() {
+	go func() {
+		ticker := time.NewTicker(apiHealthInterval)
+		defer ticker.Stop()
+		for {
+			probeClusterAPIs()
+			if pages.HasPage("clusters") {
+				app.QueueUpdateDraw(refreshClusters)
+			}
+			<-ticker.C
+		}
+	}()
+}
+
+// probeClusterAPIs probes every running cluster concurrently
+func probeClusterAPIs() {
+	if clusterManager.IsOffline() {
+		return
+	}
+
+	var wg sync.WaitGroup
+	for _, c := range clusterManager.GetClusters() {
+		if c.Status != models.StatusRunning {
+			apiHealthProber.Forget(c.Name)
+			continue
+		}
+		endpoint, via := apiProbeEndpoint(c)
+		wg.Add(1)
+		go func(name, endpoint, via string) {
+			defer wg.Done()
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+			apiHealthProber.Probe(ctx, name, endpoint, via)
+		}(c.Name, endpoint, via)
+	}
+	wg.Wait()
+}
+
+// apiProbeEndpoint picks how to reach a cluster's API server: the active
+// SSM tunnel if it belongs to the cluster, else a publicly routable endpoint
+func apiProbeEndpoint(c models.K3sCluster) (string, string) {
+	if tunnel := GetGlobalTunnelManager().GetTunnelInfo(c.Name); tunnel != nil {
+		port := tunnel.LocalPort
+		if port == 0 {
+			port = 6443
+		}
+		return fmt.Sprintf("https://127.0.0.1:%d", port), "tunnel"
+	}
+
+	if c.APIEndpoint != "" {
+		if u, err := url.Parse(c.APIEndpoint); err == nil {
+			if ip := net.ParseIP(u.Hostname()); ip == nil || !ip.IsPrivate() {
+				return c.APIEndpoint, "public"
+			}
+		}
+	}
+	return "", ""
+}
+
+// apiHealthBadge renders the API column for a cluster
+func apiHealthBadge(c models.K3sCluster) (string, tcell.Color) {
+	if c.Status != models.StatusRunning {
+		return "-", ColorMuted
+	}
+	health, ok := apiHealthProber.Get(c.Name)
+	if !ok {
+		return "…", ColorMuted
+	}
+
+	switch health.State {
+	case connectivity.APIHealthy:
+		return fmt.Sprintf("● %s", formatLatency(health.Latency)), ColorSuccess
+	case connectivity.APIDegraded:
+		return fmt.Sprintf("● %s", formatLatency(health.Latency)), ColorWarning
+	case connectivity.APIUnreachable:
+		return "✗ down", ColorDanger
+	default:
+		// Private endpoint without a tunnel - nothing to probe
+		return "○ no route", ColorMuted
+	}
+}
+
+// formatLatency renders a probe latency compactly
+func formatLatency(d time.Duration) string {
+	if d < time.Second {
+		return fmt.Sprintf("%dms", d.Milliseconds())
+	}
+	return fmt.Sprintf("%.1fs", d.Seconds())
+}
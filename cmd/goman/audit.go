@@ -0,0 +1,63 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/madhouselabs/goman/pkg/cluster"
+	"github.com/madhouselabs/goman/pkg/i18n"
+	"github.com/spf13/cobra"
+)
+
+var (
+	auditClusterFilter string
+	auditLimit         int
+)
+
+// auditCmd represents the audit command group
+var auditCmd = &cobra.Command{
+	Use:   "audit",
+	Short: i18n.T("cmd.audit.short"),
+	Long:  i18n.T("cmd.audit.long"),
+}
+
+// auditLogCmd lists audit entries
+var auditLogCmd = &cobra.Command{
+	Use:   "log",
+	Short: i18n.T("cmd.audit.log.short"),
+	Long:  i18n.T("cmd.audit.log.long"),
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if clusterManager == nil {
+			clusterManager = cluster.NewManager()
+		}
+
+		entries, err := clusterManager.AuditLog(auditClusterFilter, auditLimit)
+		if err != nil {
+			return fmt.Errorf("failed to read audit log: %w", err)
+		}
+
+		if len(entries) == 0 {
+			outln("No audit entries found")
+			return nil
+		}
+
+		for _, entry := range entries {
+			outf("%s  %-10s %-20s %s\n",
+				entry.Time.Local().Format("2006-01-02 15:04:05"), entry.Action, entry.Cluster, entry.User)
+			if entry.Principal != "" {
+				outf("    principal: %s\n", entry.Principal)
+			}
+			if len(entry.Changes) > 0 {
+				outf("    %s\n", strings.Join(entry.Changes, "\n    "))
+			}
+		}
+		return nil
+	},
+}
+
+func init() {
+	auditLogCmd.Flags().StringVar(&auditClusterFilter, "cluster", "", "Only show entries for this cluster")
+	auditLogCmd.Flags().IntVar(&auditLimit, "limit", 50, "Maximum number of entries to show (0 for all)")
+	auditCmd.AddCommand(auditLogCmd)
+}
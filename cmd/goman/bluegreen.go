@@ -0,0 +1,177 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/madhouselabs/goman/pkg/cluster"
+	"github.com/madhouselabs/goman/pkg/i18n"
+	"github.com/spf13/cobra"
+)
+
+var (
+	blueGreenName        string
+	blueGreenK3sVersion  string
+	blueGreenCutoverWait time.Duration
+	blueGreenCutoverYes  bool
+)
+
+// clusterBlueGreenCmd creates a green twin of a cluster
+var clusterBlueGreenCmd = &cobra.Command{
+	Use:   "bluegreen <cluster-name>",
+	Short: i18n.T("cmd.cluster.bluegreen.short"),
+	Long:  i18n.T("cmd.cluster.bluegreen.long"),
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		blueName := resolveClusterAlias(args[0])
+		greenName := blueGreenName
+		if greenName == "" {
+			greenName = blueName + "-green"
+		}
+
+		if clusterManager == nil {
+			clusterManager = cluster.NewManager()
+		}
+
+		pair, err := clusterManager.CreateBlueGreen(blueName, greenName, blueGreenK3sVersion)
+		if err != nil {
+			return fmt.Errorf("failed to create twin: %w", err)
+		}
+
+		outf("🟢 Cluster %s is being created as a twin of %s\n", pair.Green, pair.Blue)
+		if blueGreenK3sVersion != "" {
+			outf("   with K3s %s\n", blueGreenK3sVersion)
+		}
+		outln("💡 Use 'goman cluster bluegreen status " + pair.Blue + "' to compare the two clusters")
+		return nil
+	},
+}
+
+// clusterBlueGreenStatusCmd reports how a twin diverges from its cluster
+var clusterBlueGreenStatusCmd = &cobra.Command{
+	Use:   "status <cluster-name>",
+	Short: i18n.T("cmd.cluster.bluegreen.status.short"),
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		blueName := resolveClusterAlias(args[0])
+
+		if clusterManager == nil {
+			clusterManager = cluster.NewManager()
+		}
+
+		report, err := clusterManager.BlueGreenDivergence(blueName)
+		if err != nil {
+			return err
+		}
+
+		outf("Blue:      %s\n", report.Pair.Blue)
+		outf("Green:     %s (created %s)\n", report.Pair.Green, report.Pair.CreatedAt.Local().Format("2006-01-02 15:04"))
+		if report.Pair.CutoverAt != nil {
+			outf("Cutover:   %s (API endpoint %s)\n", report.Pair.CutoverAt.Local().Format("2006-01-02 15:04"), report.Pair.Endpoint)
+		}
+		outln("")
+		outf("%-12s %-22s %s\n", "", "BLUE", "GREEN")
+		outf("%-12s %-22s %s\n", "Status", report.Blue.Status, report.Green.Status)
+		outf("%-12s %-22s %s\n", "K3s", report.Blue.K3sVersion, report.Green.K3sVersion)
+		outf("%-12s %-22s %s\n", "Kubernetes", dashIfEmpty(report.Blue.KubeVersion), dashIfEmpty(report.Green.KubeVersion))
+		outf("%-12s %-22d %d\n", "Nodes", report.Blue.Nodes, report.Green.Nodes)
+		outf("%-12s %-22s %s\n", "Endpoint", dashIfEmpty(report.Blue.APIEndpoint), dashIfEmpty(report.Green.APIEndpoint))
+		outln("")
+		if report.Restore != nil {
+			outf("Restore:   %s\n", report.Restore)
+		} else {
+			outln("Restore:   waiting for the masters of " + report.Pair.Green)
+		}
+		outln("")
+		if len(report.Spec) == 0 {
+			outln("Spec: identical")
+			return nil
+		}
+		outln("Spec changes from blue to green:")
+		for _, change := range report.Spec {
+			outf("  %s\n", change)
+		}
+		return nil
+	},
+}
+
+// clusterBlueGreenCutoverCmd moves the API endpoint to the twin
+var clusterBlueGreenCutoverCmd = &cobra.Command{
+	Use:   "cutover <cluster-name>",
+	Short: i18n.T("cmd.cluster.bluegreen.cutover.short"),
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		blueName := resolveClusterAlias(args[0])
+
+		if clusterManager == nil {
+			clusterManager = cluster.NewManager()
+		}
+
+		pair, err := clusterManager.BlueGreen(blueName)
+		if err != nil {
+			return err
+		}
+		if !blueGreenCutoverYes {
+			outf("⚠️  Clients of %s lose the API endpoint until %s serves it.\n", pair.Blue, pair.Green)
+			outf("Type the cluster name (%s) to confirm the cutover: ", pair.Blue)
+			input, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+			if strings.TrimSpace(input) != pair.Blue {
+				return fmt.Errorf("cutover cancelled")
+			}
+		}
+
+		err = clusterManager.CutoverBlueGreen(blueName, blueGreenCutoverWait, func(step string) {
+			outf("🔀 %s\n", step)
+		})
+		if err != nil {
+			return fmt.Errorf("cutover failed: %w", err)
+		}
+		outf("✅ %s now serves the API endpoint of %s\n", pair.Green, pair.Blue)
+		outln("💡 Use 'goman kubeconfig get " + pair.Green + "' to get its credentials; delete " + pair.Blue + " once it is no longer needed")
+		return nil
+	},
+}
+
+// clusterBlueGreenAbandonCmd forgets a twin without cutting over
+var clusterBlueGreenAbandonCmd = &cobra.Command{
+	Use:   "abandon <cluster-name>",
+	Short: i18n.T("cmd.cluster.bluegreen.abandon.short"),
+	Long:  i18n.T("cmd.cluster.bluegreen.abandon.long"),
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		blueName := resolveClusterAlias(args[0])
+
+		if clusterManager == nil {
+			clusterManager = cluster.NewManager()
+		}
+
+		if err := clusterManager.AbandonBlueGreen(blueName); err != nil {
+			return err
+		}
+		outf("Cluster %s no longer has a green twin\n", blueName)
+		return nil
+	},
+}
+
+// dashIfEmpty shows a missing value as a dash
+func dashIfEmpty(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+func init() {
+	clusterBlueGreenCmd.Flags().StringVar(&blueGreenName, "name", "", "Name of the twin (default <cluster-name>-green)")
+	clusterBlueGreenCmd.Flags().StringVar(&blueGreenK3sVersion, "k3s-version", "", "K3s version of the twin (default the cluster's)")
+	clusterBlueGreenCutoverCmd.Flags().DurationVar(&blueGreenCutoverWait, "timeout", 10*time.Minute, "How long to wait for each cluster to serve its new endpoint before rolling back")
+	clusterBlueGreenCutoverCmd.Flags().BoolVarP(&blueGreenCutoverYes, "yes", "y", false, "Cut over without asking for confirmation")
+
+	clusterBlueGreenCmd.AddCommand(clusterBlueGreenStatusCmd)
+	clusterBlueGreenCmd.AddCommand(clusterBlueGreenCutoverCmd)
+	clusterBlueGreenCmd.AddCommand(clusterBlueGreenAbandonCmd)
+	clusterCmd.AddCommand(clusterBlueGreenCmd)
+}
@@ -3,6 +3,7 @@ package main
 import (
 	"context"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"strings"
@@ -14,6 +15,13 @@ import (
 	"github.com/aws/aws-sdk-go-v2/service/s3"
 	"github.com/aws/aws-sdk-go-v2/service/sts"
 	"github.com/madhouselabs/goman/pkg/cluster"
+	gomanconfig "github.com/madhouselabs/goman/pkg/config"
+	"github.com/madhouselabs/goman/pkg/i18n"
+	"github.com/madhouselabs/goman/pkg/models"
+	"github.com/madhouselabs/goman/pkg/provider"
+	"github.com/madhouselabs/goman/pkg/provider/registry"
+	"github.com/madhouselabs/goman/pkg/storage"
+	"github.com/madhouselabs/goman/pkg/version"
 	"github.com/spf13/cobra"
 	"gopkg.in/yaml.v2"
 )
@@ -21,21 +29,20 @@ import (
 // clusterCmd represents the cluster command group
 var clusterCmd = &cobra.Command{
 	Use:   "cluster",
-	Short: "Manage K3s clusters",
-	Long:  `Manage K3s cluster operations including create, list, delete, and connect.`,
+	Short: i18n.T("cmd.cluster.short"),
+	Long:  i18n.T("cmd.cluster.long"),
 }
 
 // clusterConnectCmd connects to a cluster
 var clusterConnectCmd = &cobra.Command{
 	Use:   "connect [cluster-name]",
-	Short: "Connect to a K3s cluster via SSM tunnel",
-	Long: `Establishes an SSM tunnel to the specified K3s cluster.
-This creates a secure connection without requiring public IPs.`,
-	Args: cobra.MaximumNArgs(1),
+	Short: i18n.T("cmd.cluster.connect.short"),
+	Long:  i18n.T("cmd.cluster.connect.long"),
+	Args:  cobra.MaximumNArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		var clusterName string
 		if len(args) > 0 {
-			clusterName = args[0]
+			clusterName = resolveClusterAlias(args[0])
 		} else {
 			// Use interactive selector
 			selected, err := getOrSelectCluster("", "connect to")
@@ -53,8 +60,8 @@ This creates a secure connection without requiring public IPs.`,
 // clusterDisconnectCmd disconnects from a cluster
 var clusterDisconnectCmd = &cobra.Command{
 	Use:   "disconnect [cluster-name]",
-	Short: "Disconnect from a K3s cluster",
-	Long:  `Closes the SSM tunnel to the specified K3s cluster.`,
+	Short: i18n.T("cmd.cluster.disconnect.short"),
+	Long:  i18n.T("cmd.cluster.disconnect.long"),
 	Args:  cobra.MaximumNArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		var clusterName string
@@ -69,8 +76,8 @@ var clusterDisconnectCmd = &cobra.Command{
 			clusterName = selected
 		}
 
-		// Use SingleTunnelManager
-		stm := GetGlobalSingleTunnelManager()
+		// Use the tunnel manager
+		stm := GetGlobalTunnelManager()
 		
 		// Check if tunnel exists
 		if !stm.IsConnected(clusterName) {
@@ -87,7 +94,7 @@ var clusterDisconnectCmd = &cobra.Command{
 			clearCurrentCluster()
 		}
 
-		fmt.Printf("✅ Disconnected from cluster %s\n", clusterName)
+		outf("✅ Disconnected from cluster %s\n", clusterName)
 		return nil
 	},
 }
@@ -95,13 +102,13 @@ var clusterDisconnectCmd = &cobra.Command{
 // clusterStatusCmd shows cluster progress and status
 var clusterStatusCmd = &cobra.Command{
 	Use:   "status [cluster-name]",
-	Short: "Show cluster progress and status",
-	Long:  `Shows detailed progress and status for K3s clusters including reconciliation progress, instance states, and recent activity.`,
+	Short: i18n.T("cmd.cluster.status.short"),
+	Long:  i18n.T("cmd.cluster.status.long"),
 	Args:  cobra.MaximumNArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		if len(args) > 0 {
 			// Show detailed status for specific cluster
-			return showClusterProgress(args[0])
+			return showClusterProgress(resolveClusterAlias(args[0]))
 		} else {
 			// Show all clusters with basic status
 			return showAllClustersProgress()
@@ -109,8 +116,73 @@ var clusterStatusCmd = &cobra.Command{
 	},
 }
 
+// clusterDownscaleCmd converts an HA cluster to a single-master dev cluster
+var clusterDownscaleCmd = &cobra.Command{
+	Use:   "downscale [cluster-name]",
+	Short: i18n.T("cmd.cluster.downscale.short"),
+	Long:  i18n.T("cmd.cluster.downscale.long"),
+	Args:  cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		var clusterName string
+		if len(args) > 0 {
+			clusterName = resolveClusterAlias(args[0])
+		} else {
+			// Use interactive selector
+			selected, err := getOrSelectCluster("", "downscale")
+			if err != nil {
+				return err
+			}
+			clusterName = selected
+		}
+
+		if clusterManager == nil {
+			clusterManager = cluster.NewManager()
+		}
+
+		if err := clusterManager.DownscaleToDev(clusterName); err != nil {
+			return fmt.Errorf("failed to downscale cluster: %w", err)
+		}
+
+		outf("✅ Cluster %s is being downscaled to dev mode\n", clusterName)
+		outln("💡 Use 'goman cluster status " + clusterName + "' to follow progress")
+		return nil
+	},
+}
+
+// clusterRenameCmd renames a cluster
+var clusterRenameCmd = &cobra.Command{
+	Use:   "rename <cluster-name> <new-name>",
+	Short: i18n.T("cmd.cluster.rename.short"),
+	Long:  i18n.T("cmd.cluster.rename.long"),
+	Args:  cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		oldName, newName := args[0], args[1]
+
+		if clusterManager == nil {
+			clusterManager = cluster.NewManager()
+		}
+
+		if err := clusterManager.RenameCluster(oldName, newName); err != nil {
+			return fmt.Errorf("failed to rename cluster: %w", err)
+		}
+
+		// The local kubeconfig is downloaded again under the new name
+		homeDir, _ := os.UserHomeDir()
+		os.Remove(filepath.Join(homeDir, ".kube", "goman", fmt.Sprintf("%s.yaml", oldName)))
+		if getCurrentCluster() == oldName {
+			saveCurrentCluster(newName)
+		}
+
+		outf("✅ Cluster %s renamed to %s\n", oldName, newName)
+		if GetGlobalTunnelManager().IsConnected(oldName) {
+			outln("💡 Reconnect with 'goman cluster connect " + newName + "' to use the new name")
+		}
+		return nil
+	},
+}
+
 func connectToClusterCLI(clusterName string) error {
-	fmt.Printf("🔄 Setting current cluster to %s...\n", clusterName)
+	outf("🔄 Setting current cluster to %s...\n", clusterName)
 
 	// Download kubeconfig if needed (reuse existing function)
 	homeDir, _ := os.UserHomeDir()
@@ -127,12 +199,17 @@ func connectToClusterCLI(clusterName string) error {
 	// Save as current cluster
 	saveCurrentCluster(clusterName)
 
-	fmt.Printf("✅ Selected cluster: %s\n", clusterName)
+	outf("✅ Selected cluster: %s\n", clusterName)
 
 	return nil
 }
 
 // showClusterProgress displays detailed progress for a specific cluster
+// s3PathStyle addresses buckets by path when a custom endpoint needs it
+func s3PathStyle(o *s3.Options) {
+	o.UsePathStyle = gomanconfig.S3UsePathStyle()
+}
+
 func showClusterProgress(clusterName string) error {
 	ctx := context.Background()
 	
@@ -153,12 +230,11 @@ func showClusterProgress(clusterName string) error {
 	accountID := *identity.Account
 	
 	// Try to get cluster config to determine actual region
-	bucketName := fmt.Sprintf("goman-%s", accountID)
-	configKey := fmt.Sprintf("clusters/%s/config.yaml", clusterName)
-	statusKey := fmt.Sprintf("clusters/%s/status.yaml", clusterName)
-	tokenKey := fmt.Sprintf("clusters/%s/k3s-server-token", clusterName)
+	bucketName := gomanconfig.GetStateBucket(accountID)
+	configKey := storage.ClusterConfigKey(clusterName)
+	statusKey := storage.ClusterStatusKey(clusterName)
 	
-	s3Client := s3.NewFromConfig(defaultCfg)
+	s3Client := s3.NewFromConfig(defaultCfg, s3PathStyle)
 	var mode, region, instanceType string
 	
 	// Get cluster region from config
@@ -171,10 +247,21 @@ func showClusterProgress(clusterName string) error {
 	}
 	
 	defer configResp.Body.Close()
-	configData := make([]byte, 2048)
-	n, _ := configResp.Body.Read(configData)
+	// Read all of it, notes can make the config large
+	configData, _ := io.ReadAll(configResp.Body)
+	n := len(configData)
 	configStr := string(configData[:n])
 	
+	// Spec generation, to tell whether the latest edit was acted upon, and
+	// the notes on the cluster
+	var configMeta struct {
+		Metadata struct {
+			Generation  int               `yaml:"generation"`
+			Annotations map[string]string `yaml:"annotations"`
+		} `yaml:"metadata"`
+	}
+	yaml.Unmarshal(configData[:n], &configMeta)
+	
 	// Parse YAML content to get the config values
 	var configYaml map[string]interface{}
 	if err := yaml.Unmarshal(configData[:n], &configYaml); err == nil {
@@ -226,7 +313,7 @@ func showClusterProgress(clusterName string) error {
 		if err != nil {
 			return fmt.Errorf("failed to load AWS config for region %s: %w", region, err)
 		}
-		s3Client = s3.NewFromConfig(cfg)
+		s3Client = s3.NewFromConfig(cfg, s3PathStyle)
 	} else {
 		cfg = defaultCfg
 		region = "ap-south-1" // Default region
@@ -234,7 +321,16 @@ func showClusterProgress(clusterName string) error {
 	
 	logsClient := cloudwatchlogs.NewFromConfig(cfg)
 	
-	fmt.Printf("=== CLUSTER PROGRESS: %s ===\n\n", clusterName)
+	outf("=== CLUSTER PROGRESS: %s ===\n\n", clusterName)
+
+	// Show the notes shared by everyone using the cluster
+	if keys := models.Notes(configMeta.Metadata.Annotations); len(keys) > 0 {
+		outln("📝 NOTES:")
+		for _, key := range keys {
+			outf("- %s: %s\n", key, configMeta.Metadata.Annotations[key])
+		}
+		outln()
+	}
 	
 	// Get status with progress metrics
 	statusResp, err := s3Client.GetObject(ctx, &s3.GetObjectInput{
@@ -244,7 +340,7 @@ func showClusterProgress(clusterName string) error {
 	var statusData []byte
 	
 	if err != nil {
-		fmt.Println("  ❌ No status file found")
+		outln("  ❌ No status file found")
 	} else {
 		defer statusResp.Body.Close()
 		statusData = make([]byte, 16384) // Increased buffer size for larger status files
@@ -267,16 +363,16 @@ func showClusterProgress(clusterName string) error {
 				}
 				
 				// Show basic cluster info in header
-				fmt.Printf("Cluster: %s [%s] - %s/%s (%s)\n", clusterName, mode, region, instanceType, phase)
-				fmt.Printf("Message: %v\n", message)
-				fmt.Printf("Last Reconciled: %v\n", lastReconciled)
-				fmt.Println()
+				outf("Cluster: %s [%s] - %s/%s (%s)\n", clusterName, mode, region, instanceType, phase)
+				outf("Message: %v\n", message)
+				outf("Last Reconciled: %v\n", lastReconciled)
+				outln()
 				
 				// Show progress metrics if available
 				if progressMetrics, ok := metadata["progress_metrics"].(map[interface{}]interface{}); ok {
-					fmt.Println("📈 PROGRESS MATRIX:")
+					outln("📈 PROGRESS MATRIX:")
 					if currentOp, ok := progressMetrics["currentoperation"]; ok {
-						fmt.Printf("%v", currentOp)
+						outf("%v", currentOp)
 					}
 					// Count steps directly from the steps array for accurate display
 					totalSteps := 0
@@ -291,7 +387,7 @@ func showClusterProgress(clusterName string) error {
 							}
 						}
 					}
-					fmt.Printf(" (%d/%d steps completed):\n", completedSteps, totalSteps)
+					outf(" (%d/%d steps completed):\n", completedSteps, totalSteps)
 					
 					if steps, ok := progressMetrics["steps"].([]interface{}); ok {
 						// Create ordered steps array for display
@@ -320,10 +416,10 @@ func showClusterProgress(clusterName string) error {
 							if step, ok := stepInterface.(map[interface{}]interface{}); ok {
 								name := step["name"]
 								stepStatus := step["status"]
-								fmt.Printf("- Step: %v [%v]\n", name, stepStatus)
+								outf("- Step: %v [%v]\n", name, stepStatus)
 								
 								if description, ok := step["description"]; ok {
-									fmt.Printf("  %v\n", description)
+									outf("  %v\n", description)
 								}
 								
 								if checks, ok := step["checks"].([]interface{}); ok {
@@ -346,13 +442,13 @@ func showClusterProgress(clusterName string) error {
 												}
 											}
 											
-											fmt.Printf("    - %v: %v", checkName, checkStatus)
+											outf("    - %v: %v", checkName, checkStatus)
 											
 											if details, ok := check["details"]; ok && details != "" {
-												fmt.Printf(" - %v", details)
+												outf(" - %v", details)
 											}
 											if errorMsg, ok := check["errormessage"]; ok && errorMsg != "" {
-												fmt.Printf(" (Error: %v)", errorMsg)
+												outf(" (Error: %v)", errorMsg)
 											}
 											
 											// Add retry timing info if applicable
@@ -360,12 +456,12 @@ func showClusterProgress(clusterName string) error {
 												if retryAfter, ok := check["retryafter"]; ok && retryAfter != nil {
 													if retryStr, ok := retryAfter.(string); ok && retryStr != "" {
 														// Parse the time string and show countdown
-														fmt.Printf(" [Retry scheduled]")
+														outf(" [Retry scheduled]")
 													}
 												}
 											}
 											
-											fmt.Println()
+											outln()
 										}
 									}
 								}
@@ -373,28 +469,253 @@ func showClusterProgress(clusterName string) error {
 						}
 					}
 				} else {
-					fmt.Println("📈 PROGRESS MATRIX:")
-					fmt.Println("❌ No progress metrics available")
-					fmt.Println("💡 Progress metrics require the new reconciler version")
-					fmt.Printf("💡 Last reconciled: %v (>4 hours ago - Lambda not running)\n", lastReconciled)
-					fmt.Println("💡 The cluster is stuck because Lambda reconciliation has stopped")
+					outln("📈 PROGRESS MATRIX:")
+					outln("❌ No progress metrics available")
+					outln("💡 Progress metrics require the new reconciler version")
+					outf("💡 Last reconciled: %v (>4 hours ago - Lambda not running)\n", lastReconciled)
+					outln("💡 The cluster is stuck because Lambda reconciliation has stopped")
 				}
 			}
 		}
 	}
 	
+	// Show why the last reconcile failed and what to do about it
+	if len(statusData) > 0 {
+		var failure struct {
+			Reason  string `yaml:"reason"`
+			Message string `yaml:"message"`
+		}
+		if err := yaml.Unmarshal(statusData[:n], &failure); err == nil && failure.Reason != "" {
+			category := provider.ErrorCategory(failure.Reason)
+			outf("\n❌ ERROR (%s): %s\n", category, failure.Message)
+			if guidance := category.Guidance(); guidance != "" {
+				outf("   💡 %s\n", guidance)
+			}
+		}
+	}
+
+	// Show the expected end of the setup or rollout in progress
+	if len(statusData) > 0 {
+		var etaStatus struct {
+			Phase               string                `yaml:"phase"`
+			EstimatedCompletion *time.Time            `yaml:"estimatedCompletion"`
+			Rollout             *models.RolloutStatus `yaml:"rollout"`
+		}
+		if err := yaml.Unmarshal(statusData[:n], &etaStatus); err == nil && etaStatus.EstimatedCompletion != nil {
+			what := etaStatus.Phase
+			if etaStatus.Rollout.Active() {
+				what = fmt.Sprintf("Rollout of pool %s", etaStatus.Rollout.Pool)
+			}
+			outf("\n⏱ ESTIMATE: %s — %s remaining (around %s)\n", what,
+				models.FormatRemaining(etaStatus.EstimatedCompletion, time.Now()),
+				etaStatus.EstimatedCompletion.Local().Format("15:04"))
+		}
+	}
+
+	// Show whether the controller has acted upon the latest spec edit
+	if len(statusData) > 0 && configMeta.Metadata.Generation > 0 {
+		var genStatus struct {
+			ObservedGeneration int `yaml:"observedGeneration"`
+		}
+		if err := yaml.Unmarshal(statusData[:n], &genStatus); err == nil {
+			if models.SpecPending(configMeta.Metadata.Generation, genStatus.ObservedGeneration) {
+				outf("\n📝 SPEC: changes pending (generation %d, last fully applied %d)\n",
+					configMeta.Metadata.Generation, genStatus.ObservedGeneration)
+			} else {
+				outf("\n📝 SPEC: up to date (generation %d)\n", configMeta.Metadata.Generation)
+			}
+		}
+	}
+
+	// Show the connectivity to linked clusters
+	if len(statusData) > 0 {
+		var linkStatus struct {
+			ClusterLinks []models.ClusterLinkStatus `yaml:"clusterLinks"`
+		}
+		if err := yaml.Unmarshal(statusData[:n], &linkStatus); err == nil && len(linkStatus.ClusterLinks) > 0 {
+			outln("\n🔗 CLUSTER LINKS:")
+			for _, link := range linkStatus.ClusterLinks {
+				if link.Ready {
+					outf("- %s (%s, %s): %s\n", link.Cluster, link.Region, link.Mode, strings.Join(link.Ports, ", "))
+				} else {
+					outf("- %s: not ready - %s\n", link.Cluster, link.Message)
+				}
+			}
+		}
+	}
+
+	// Show the installed addons
+	if len(statusData) > 0 {
+		var addonStatus struct {
+			Addons []models.AddonStatus `yaml:"addons"`
+		}
+		if err := yaml.Unmarshal(statusData[:n], &addonStatus); err == nil && len(addonStatus.Addons) > 0 {
+			outln("\n🧩 ADDONS:")
+			for _, addon := range addonStatus.Addons {
+				version := addon.Version
+				if version == "" {
+					version = "latest"
+				}
+				switch {
+				case addon.Message != "":
+					outf("- %s (%s): failed - %s\n", addon.Name, addon.Source, addon.Message)
+				case len(addon.Masters) == 0:
+					outf("- %s (%s): pending\n", addon.Name, addon.Source)
+				case addon.Chart:
+					outf("- %s: %s %s in %s\n", addon.Name, addon.Source, version, addon.Namespace)
+				default:
+					outf("- %s: %s\n", addon.Name, addon.Source)
+				}
+			}
+		}
+	}
+
+	// Show certificate expiry, k3s only renews certificates close to expiry on restart
+	if len(statusData) > 0 {
+		var certStatus struct {
+			Certificates *models.CertificateStatus `yaml:"certificates"`
+		}
+		if err := yaml.Unmarshal(statusData[:n], &certStatus); err == nil && certStatus.Certificates != nil {
+			certs := certStatus.Certificates
+			outln("\n🔐 CERTIFICATES:")
+			if certs.ExpiresAt != nil {
+				outf("- Server certificates expire: %s\n", certs.ExpiresAt.Format("2006-01-02 15:04"))
+			}
+			if certs.KubeconfigExpiresAt != nil {
+				outf("- Kubeconfig certificate expires: %s\n", certs.KubeconfigExpiresAt.Format("2006-01-02 15:04"))
+			}
+			if certs.RotatedAt != nil {
+				outf("- Last rotated: %s\n", certs.RotatedAt.Format("2006-01-02 15:04"))
+			}
+			if warning := models.CertificateWarning(certs.EarliestExpiry(), time.Now()); warning != "" {
+				outf("⚠️  %s - run 'goman cluster rotate-certs %s'\n", warning, clusterName)
+			}
+			if certs.Message != "" {
+				outf("⚠️  %s\n", certs.Message)
+			}
+		}
+	}
+
+	// Show when the read-only kubeconfig's token runs out
+	if len(statusData) > 0 {
+		var readOnly struct {
+			ExpiresAt *time.Time `yaml:"readOnlyKubeconfigExpiresAt"`
+		}
+		if err := yaml.Unmarshal(statusData[:n], &readOnly); err == nil && readOnly.ExpiresAt != nil {
+			outf("\n👀 READ-ONLY KUBECONFIG: valid until %s ('goman kubeconfig get %s')\n", readOnly.ExpiresAt.Format("2006-01-02 15:04"), clusterName)
+		}
+	}
+
+	// Show the current or last credential rotation
+	if len(statusData) > 0 {
+		var rotation struct {
+			CredentialRotation *models.CredentialRotationStatus `yaml:"credentialRotation"`
+		}
+		if err := yaml.Unmarshal(statusData[:n], &rotation); err == nil && rotation.CredentialRotation != nil {
+			st := rotation.CredentialRotation
+			switch {
+			case st.CompletedAt != nil:
+				outf("\n🔑 CREDENTIALS: rotated on %s\n", st.CompletedAt.Local().Format("2006-01-02 15:04"))
+			case st.Active():
+				outf("\n🔑 CREDENTIALS: rotation in progress (%s)\n", st.Phase)
+			default:
+				outf("\n🔑 CREDENTIALS: rotation %s\n", strings.ToLower(string(st.Phase)))
+			}
+			if st.Message != "" {
+				outf("⚠️  %s\n", st.Message)
+			}
+		}
+	}
+
+	// Show the last run of the smoke tests gating the Running phase
+	if len(statusData) > 0 {
+		var verification struct {
+			Verification *models.VerificationStatus `yaml:"verification"`
+		}
+		if err := yaml.Unmarshal(statusData[:n], &verification); err == nil && verification.Verification != nil {
+			v := verification.Verification
+			outf("\n🧪 SMOKE TESTS: %s on %s ('goman cluster verify %s')\n", v.Summary(), v.StartedAt.Local().Format("2006-01-02 15:04"), clusterName)
+		}
+	}
+
+	// Show which goman version last wrote the status, for support requests
+	if len(statusData) > 0 {
+		var writer struct {
+			WriterVersion string `yaml:"writerVersion"`
+		}
+		if err := yaml.Unmarshal(statusData[:n], &writer); err == nil && writer.WriterVersion != "" {
+			outf("\nStatus written by goman %s (this CLI: %s)\n", writer.WriterVersion, version.String())
+		}
+	}
+
+	// Show which controller invocation last acted on the spec, for reading
+	// its logs
+	if len(statusData) > 0 {
+		var intent struct {
+			LastIntent *models.IntentRecord `yaml:"lastIntent"`
+		}
+		if err := yaml.Unmarshal(statusData[:n], &intent); err == nil && intent.LastIntent != nil && intent.LastIntent.RequestID != "" {
+			outf("\nLast reconcile: %s, request %s ('goman cluster logs %s --request-id %s')\n",
+				intent.LastIntent.ActedAt.Local().Format("2006-01-02 15:04"), intent.LastIntent.RequestID, clusterName, intent.LastIntent.RequestID)
+		}
+	}
+
+	// Show what was changed outside goman
+	if len(statusData) > 0 {
+		var driftStatus struct {
+			Drift []models.DriftStatus `yaml:"drift"`
+		}
+		if err := yaml.Unmarshal(statusData[:n], &driftStatus); err == nil && len(driftStatus.Drift) > 0 {
+			outln("\n⚠ DRIFT:")
+			for _, d := range driftStatus.Drift {
+				outf("- %s (since %s)\n", d, d.DetectedAt.Format("2006-01-02 15:04"))
+			}
+		}
+	}
+
+	// Show etcd health of HA control planes and why the cluster or its cloud
+	// provider is degraded
+	if len(statusData) > 0 {
+		var etcdStatus struct {
+			Etcd           *models.EtcdStatus           `yaml:"etcd"`
+			QuorumRecovery *models.QuorumRecoveryStatus `yaml:"quorumRecovery"`
+			Conditions     []models.Condition           `yaml:"conditions"`
+		}
+		if err := yaml.Unmarshal(statusData[:n], &etcdStatus); err == nil {
+			if etcdStatus.Etcd != nil {
+				outf("\n🗄  ETCD: %s\n", etcdStatus.Etcd)
+			}
+			if rec := etcdStatus.QuorumRecovery; rec != nil && (!rec.Done() || time.Since(rec.StartedAt) < 24*time.Hour) {
+				outf("- Quorum recovery: %s\n", rec)
+			}
+			for _, c := range etcdStatus.Conditions {
+				if c.Type == models.ConditionDegraded && c.Status == "True" {
+					outf("\n⚠ DEGRADED (%s, since %s):\n%s\n", c.Reason, c.LastTransitionTime.Format("2006-01-02 15:04"), c.Message)
+				}
+				if c.Type == models.ConditionCloudProviderDegraded && c.Status == "True" {
+					outf("\n☁  CLOUD PROVIDER DEGRADED (%s, since %s):\n%s\n", c.Reason, c.LastTransitionTime.Format("2006-01-02 15:04"), c.Message)
+				}
+			}
+		}
+	}
+
 	// Show minimal summary
-	fmt.Println("\n💡 SUMMARY:")
+	outln("\n💡 SUMMARY:")
 	
-	// Check K3s token
-	_, err = s3Client.GetObject(ctx, &s3.GetObjectInput{
-		Bucket: &bucketName,
-		Key:    &tokenKey,
-	})
-	if err != nil {
-		fmt.Println("🔑 K3s Token: ❌ Missing from S3 (blocking HA cluster formation)")
+	// Check K3s token in the configured secret backend
+	profile := os.Getenv("AWS_PROFILE")
+	if profile == "" {
+		profile = "default"
+	}
+	if p, err := registry.GetProvider("aws", profile, "ap-south-1"); err != nil {
+		outf("🔑 K3s Token: ⚠️  Unable to check (%v)\n", err)
 	} else {
-		fmt.Println("🔑 K3s Token: ✅ Available in S3")
+		secretService := p.GetSecretService()
+		if _, err := secretService.GetSecret(ctx, clusterName, provider.SecretServerToken); err != nil {
+			outf("🔑 K3s Token: ❌ Missing from %s (blocking HA cluster formation)\n", secretService.Backend())
+		} else {
+			outf("🔑 K3s Token: ✅ Available in %s\n", secretService.Backend())
+		}
 	}
 	
 	// Check recent Lambda activity
@@ -409,10 +730,10 @@ func showClusterProgress(clusterName string) error {
 	
 	result, err := logsClient.FilterLogEvents(ctx, filterInput)
 	if err == nil && len(result.Events) > 0 {
-		fmt.Println("📝 Lambda Activity: ✅ Recent activity detected")
+		outln("📝 Lambda Activity: ✅ Recent activity detected")
 	} else {
-		fmt.Println("📝 Lambda Activity: ❌ No recent activity (Lambda not running)")
-		fmt.Println("   💡 Cluster is stuck - Lambda reconciliation has stopped")
+		outln("📝 Lambda Activity: ❌ No recent activity (Lambda not running)")
+		outln("   💡 Cluster is stuck - Lambda reconciliation has stopped")
 	}
 	
 	return nil
@@ -429,30 +750,30 @@ func showAllClustersProgress() error {
 	// Get all clusters
 	clusters := clusterManager.GetClusters()
 	if len(clusters) == 0 {
-		fmt.Println("No clusters found")
+		outln("No clusters found")
 		return nil
 	}
 	
-	fmt.Println("=== ALL CLUSTERS STATUS ===")
-	fmt.Println()
+	outln("=== ALL CLUSTERS STATUS ===")
+	outln()
 	
 	for _, cluster := range clusters {
-		fmt.Printf("🔧 %s:\n", cluster.Name)
-		fmt.Printf("  Mode: %s\n", cluster.Mode)
-		fmt.Printf("  Region: %s\n", cluster.Region)
-		fmt.Printf("  Status: %s\n", cluster.Status)
+		outf("🔧 %s:\n", cluster.Name)
+		outf("  Mode: %s\n", cluster.Mode)
+		outf("  Region: %s\n", cluster.Region)
+		outf("  Status: %s\n", cluster.Status)
 		
 		// Show connection status
-		stm := GetGlobalSingleTunnelManager()
+		stm := GetGlobalTunnelManager()
 		if stm.IsConnected(cluster.Name) {
-			fmt.Printf("  Connection: ✅ Connected\n")
+			outf("  Connection: ✅ Connected\n")
 		} else {
-			fmt.Printf("  Connection: ⭕ Not connected\n")
+			outf("  Connection: ⭕ Not connected\n")
 		}
-		fmt.Println()
+		outln()
 	}
 	
-	fmt.Println("💡 Use 'goman cluster status <cluster-name>' for detailed progress")
+	outln("💡 Use 'goman cluster status <cluster-name>' for detailed progress")
 	return nil
 }
 
@@ -460,4 +781,20 @@ func init() {
 	clusterCmd.AddCommand(clusterConnectCmd)
 	clusterCmd.AddCommand(clusterDisconnectCmd)
 	clusterCmd.AddCommand(clusterStatusCmd)
+	clusterCmd.AddCommand(clusterDownscaleCmd)
+	clusterCmd.AddCommand(clusterRenameCmd)
+	clusterCmd.AddCommand(clusterCreateCmd)
+	clusterCmd.AddCommand(clusterEditCmd)
+	clusterCmd.AddCommand(clusterStartCmd)
+	clusterCmd.AddCommand(clusterStopCmd)
+	clusterCmd.AddCommand(clusterScheduleCmd)
+	clusterCmd.AddCommand(clusterDeleteCmd)
+	clusterCmd.AddCommand(clusterReconcileCmd)
+	clusterCmd.AddCommand(clusterRetagCmd)
+	clusterCmd.AddCommand(clusterRotateCertsCmd)
+	clusterCmd.AddCommand(clusterRotateCredentialsCmd)
+	clusterCmd.AddCommand(clusterExportCmd)
+	clusterCmd.AddCommand(clusterApplyCmd)
+	clusterCmd.AddCommand(clusterCommandsCmd)
+	clusterCmd.AddCommand(clusterEventsCmd)
 }
\ No newline at end of file
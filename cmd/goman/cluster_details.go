@@ -7,6 +7,7 @@ import (
 
 	"github.com/gdamore/tcell/v2"
 	clusterPkg "github.com/madhouselabs/goman/pkg/cluster"
+	"github.com/madhouselabs/goman/pkg/i18n"
 	"github.com/madhouselabs/goman/pkg/logger"
 	"github.com/madhouselabs/goman/pkg/models"
 	"github.com/rivo/tview"
@@ -119,6 +120,13 @@ func createDetailsUI() {
 	contentDivider := createDivider()
 	contentFlex.AddItem(contentDivider, 1, 0, false)
 	
+	// Notes section, shared by everyone using the cluster
+	detailsState.notesText = tview.NewTextView().
+		SetDynamicColors(true).
+		SetWrap(true)
+	contentFlex.AddItem(detailsState.notesText, 2, 0, false)
+	contentFlex.AddItem(createDivider(), 1, 0, false)
+	
 	// Node pools section
 	poolsTitleFlex := tview.NewFlex().SetDirection(tview.FlexColumn)
 	
@@ -299,14 +307,17 @@ func createStatusBar() *tview.Flex {
 		SetDynamicColors(true).
 		SetTextAlign(tview.AlignLeft)
 	
-	shortcuts := fmt.Sprintf("%s%c%s Back  %sEnter%s Select  %sk%s Select  %se%s Edit  %ss%s Stop  %sa%s Start  %sr%s Refresh ",
-		TagPrimary, CharArrowLeft, TagReset,
-		TagPrimary, TagReset,
-		TagPrimary, TagReset,
-		TagPrimary, TagReset,
-		TagPrimary, TagReset,
-		TagPrimary, TagReset,
-		TagPrimary, TagReset)
+	shortcuts := shortcutHints(
+		actionHint(actionBack, "shortcut.back"),
+		actionHint(actionSelect, "shortcut.select"),
+		actionHint(actionEdit, "shortcut.edit"),
+		actionHint(actionStop, "shortcut.stop"),
+		actionHint(actionStart, "shortcut.start"),
+		actionHint(actionCommands, "shortcut.commands"),
+		actionHint(actionEvents, "shortcut.events"),
+		actionHint(actionNotes, "shortcut.notes"),
+		actionHint(actionRefresh, "shortcut.refresh"),
+		actionHint(actionHelp, "shortcut.help"))
 	statusRight := tview.NewTextView().
 		SetText(shortcuts).
 		SetDynamicColors(true).
@@ -354,6 +365,11 @@ func updateDetailsUI(cluster models.K3sCluster) {
 	
 	// Update node pools table
 	updateNodePoolsTableData(cluster)
+	
+	// Update notes
+	if detailsState.notesText != nil {
+		detailsState.notesText.SetText(fmt.Sprintf("  %s%s%s  %s", TagPrimary, i18n.T("details.notes"), TagReset, notesSummary(cluster)))
+	}
 }
 
 func updateClusterInfoTable(cluster models.K3sCluster) {
@@ -365,7 +381,22 @@ func updateClusterInfoTable(cluster models.K3sCluster) {
 	table.SetCell(1, 1, tview.NewTableCell(cluster.Name))
 	
 	statusColor := getStatusColor(string(cluster.Status))
-	table.SetCell(2, 1, tview.NewTableCell(string(cluster.Status)).SetTextColor(statusColor))
+	statusStr := string(cluster.Status)
+	if eta := models.FormatRemaining(cluster.EstimatedCompletion, time.Now()); eta != "" {
+		statusStr = i18n.T("status.eta", statusStr, eta)
+	}
+	if cluster.SpecPending() {
+		statusStr = i18n.T("status.spec_pending", statusStr)
+	}
+	if len(cluster.Drift) > 0 {
+		statusStr = i18n.T("status.drift_count", statusStr, len(cluster.Drift))
+		statusColor = ColorWarning
+	}
+	if warning := models.CertificateWarning(cluster.CertificatesExpireAt, time.Now()); warning != "" {
+		statusStr = i18n.T("status.certs", statusStr, warning)
+		statusColor = ColorWarning
+	}
+	table.SetCell(2, 1, tview.NewTableCell(statusStr).SetTextColor(statusColor))
 	
 	table.SetCell(3, 1, tview.NewTableCell(strings.ToUpper(string(cluster.Mode))))
 	table.SetCell(4, 1, tview.NewTableCell(cluster.Region))
@@ -556,6 +587,9 @@ func updateNodePoolsTableData(cluster models.K3sCluster) {
 	instanceType := cluster.InstanceType
 	if instanceType == "" {
 		instanceType = "t3.medium"
+		if preset, err := models.LookupPreset(cluster.Preset); cluster.Preset != "" && err == nil {
+			instanceType = preset.InstanceType
+		}
 	}
 	
 	table.SetCell(poolRow, 0, tview.NewTableCell("  control-plane").SetTextColor(ColorForeground))
@@ -592,8 +626,8 @@ func updateNodePoolsTableData(cluster models.K3sCluster) {
 }
 
 func handleDetailsInput(event *tcell.EventKey) *tcell.EventKey {
-	switch event.Key() {
-	case tcell.KeyEscape:
+	switch keys.action(viewDetails, event) {
+	case actionBack:
 		// Stop metrics refresh
 		if detailsState != nil {
 			detailsState.StopRefresh()
@@ -602,51 +636,66 @@ func handleDetailsInput(event *tcell.EventKey) *tcell.EventKey {
 		pages.SwitchToPage("clusters")
 		go refreshClustersAsync()
 		return nil
-	case tcell.KeyRune:
-		switch event.Rune() {
-		case 'r', 'R':
-			// Refresh cluster data
-			if detailsState != nil {
-				cluster := detailsState.GetCluster()
-				manager := clusterPkg.NewManager()
-				clusters := manager.GetClusters()
-				for _, c := range clusters {
-					if c.Name == cluster.Name {
-						detailsState.UpdateCluster(c)
-						updateDetailsUI(c)
-						// Also refresh metrics immediately
-						go fetchMetricsOnce()
-						break
-					}
+	case actionRefresh:
+		// Refresh cluster data
+		if detailsState != nil {
+			cluster := detailsState.GetCluster()
+			manager := clusterPkg.NewManager()
+			clusters := manager.GetClusters()
+			for _, c := range clusters {
+				if c.Name == cluster.Name {
+					detailsState.UpdateCluster(c)
+					updateDetailsUI(c)
+					// Also refresh metrics immediately
+					go fetchMetricsOnce()
+					break
 				}
 			}
-			return nil
-		case 'e', 'E':
-			if detailsState != nil {
-				editCluster(detailsState.GetCluster())
-			}
-			return nil
-		case 'd', 'D':
-			if detailsState != nil {
-				deleteCluster(detailsState.GetCluster())
-			}
-			return nil
-		case 'k', 'K':
-			if detailsState != nil {
-				switchToCluster(detailsState.GetCluster().Name)
-			}
-			return nil
-		case 's', 'S':
-			if detailsState != nil {
-				stopCluster(detailsState.GetCluster())
-			}
-			return nil
-		case 'a', 'A':
-			if detailsState != nil {
-				startCluster(detailsState.GetCluster())
-			}
-			return nil
 		}
+		return nil
+	case actionEdit:
+		if detailsState != nil {
+			editCluster(detailsState.GetCluster())
+		}
+		return nil
+	case actionDelete:
+		if detailsState != nil {
+			deleteCluster(detailsState.GetCluster())
+		}
+		return nil
+	case actionSelect:
+		if detailsState != nil {
+			switchToCluster(detailsState.GetCluster().Name)
+		}
+		return nil
+	case actionStop:
+		if detailsState != nil {
+			stopCluster(detailsState.GetCluster())
+		}
+		return nil
+	case actionStart:
+		if detailsState != nil {
+			startCluster(detailsState.GetCluster())
+		}
+		return nil
+	case actionCommands:
+		if detailsState != nil {
+			showCommandHistory(detailsState.GetCluster())
+		}
+		return nil
+	case actionEvents:
+		if detailsState != nil {
+			showClusterEvents(detailsState.GetCluster())
+		}
+		return nil
+	case actionNotes:
+		if detailsState != nil {
+			editNotes(detailsState.GetCluster())
+		}
+		return nil
+	case actionHelp:
+		showHelp(viewDetails)
+		return nil
 	}
 	return event
 }
@@ -20,6 +20,7 @@ type ClusterDetailsState struct {
 	resourcesTable   *tview.Table
 	metricsTable     *tview.Table
 	nodePoolsTable   *tview.Table
+	notesText        *tview.TextView
 	statusText       *tview.TextView
 }
 
@@ -0,0 +1,434 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/madhouselabs/goman/pkg/cluster"
+	"github.com/madhouselabs/goman/pkg/config"
+	"github.com/madhouselabs/goman/pkg/i18n"
+	"github.com/madhouselabs/goman/pkg/models"
+	"github.com/spf13/cobra"
+)
+
+var (
+	createMode         string
+	createRegion       string
+	createPreset       string
+	createInstanceType string
+	createDescription  string
+	createLowResource  bool
+	createPrivate      bool
+	createDedicatedVPC bool
+	createVpcCIDR      string
+	createAPILB        bool
+	createAPIAllowed   []string
+	createK3sVersion   string
+	createRootVolume   models.RootVolume
+	deleteYes          bool
+	rotateCredsYes     bool
+
+	scheduleStop     string
+	scheduleStart    string
+	scheduleTimezone string
+	scheduleClear    bool
+)
+
+// clusterCreateCmd creates a cluster without the TUI editor
+var clusterCreateCmd = &cobra.Command{
+	Use:   "create <cluster-name>",
+	Short: i18n.T("cmd.cluster.create.short"),
+	Long:  i18n.T("cmd.cluster.create.long"),
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		name := args[0]
+		if err := cluster.ValidateClusterName(name); err != nil {
+			return err
+		}
+		if createMode != "dev" && createMode != "ha" {
+			return fmt.Errorf("mode must be 'dev' or 'ha'")
+		}
+		if createPreset != "" {
+			if _, err := models.LookupPreset(createPreset); err != nil {
+				return err
+			}
+		}
+		if err := models.ValidateK3sVersion(createK3sVersion); err != nil {
+			return err
+		}
+		network := models.NetworkConfig{Private: createPrivate, DedicatedVPC: createDedicatedVPC, VpcCIDR: createVpcCIDR}
+		if err := models.ValidateNetwork(network); err != nil {
+			return err
+		}
+		var loadBalancer *models.LoadBalancerSpec
+		if createMode == "ha" && createAPILB {
+			loadBalancer = &models.LoadBalancerSpec{AllowedCIDRs: createAPIAllowed}
+			if err := models.ValidateLoadBalancer(loadBalancer, createMode, nil); err != nil {
+				return err
+			}
+		}
+		var rootVolume *models.RootVolume
+		if createRootVolume != (models.RootVolume{}) {
+			rootVolume = &createRootVolume
+			if err := models.ValidateRootVolume("cluster "+name, rootVolume); err != nil {
+				return err
+			}
+		}
+		instanceType := createInstanceType
+		if instanceType == "" && createPreset == "" {
+			instanceType = "t3.medium"
+		}
+		nodeCount := "1"
+		if createMode == "ha" {
+			nodeCount = "3"
+		}
+		description := createDescription
+		if description == "" {
+			description = "K3s cluster"
+		}
+
+		if clusterManager == nil {
+			clusterManager = cluster.NewManager()
+		}
+
+		c := newClusterModel(name, description, createMode, createRegion, createPreset, instanceType, nodeCount)
+		c.LowResource = createLowResource
+		c.PrivateNetwork = createPrivate
+		c.DedicatedVPC = createDedicatedVPC
+		c.VpcCIDR = createVpcCIDR
+		c.LoadBalancer = loadBalancer
+		c.K3sVersion = createK3sVersion
+		c.RootVolume = rootVolume
+		if _, err := clusterManager.CreateCluster(*c); err != nil {
+			return fmt.Errorf("failed to create cluster: %w", err)
+		}
+
+		outf("✅ Cluster %s is being created\n", name)
+		if models.IsSmallInstanceType(instanceType) && !createLowResource {
+			outf("💡 %s has little memory; --low-resource makes K3s on it more reliable\n", instanceType)
+		}
+		outln("💡 Use 'goman cluster status " + name + "' to follow progress")
+		return nil
+	},
+}
+
+// clusterEditCmd edits a cluster in $EDITOR, like the TUI's edit action
+var clusterEditCmd = &cobra.Command{
+	Use:   "edit <cluster-name>",
+	Short: i18n.T("cmd.cluster.edit.short"),
+	Long:  i18n.T("cmd.cluster.edit.long"),
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		c, err := findCluster(resolveClusterAlias(args[0]))
+		if err != nil {
+			return err
+		}
+		if c.Status == models.StatusDeleting {
+			return fmt.Errorf("%w: %s", cluster.ErrClusterDeleting, c.Name)
+		}
+
+		err = editClusterInEditor(*c)
+		if errors.Is(err, errEditorUnsaved) {
+			outln("No changes saved")
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to update cluster: %w", err)
+		}
+
+		outf("✅ Cluster %s updated\n", c.Name)
+		return nil
+	},
+}
+
+// clusterStartCmd starts a stopped cluster
+var clusterStartCmd = &cobra.Command{
+	Use:   "start <cluster-name>",
+	Short: i18n.T("cmd.cluster.start.short"),
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		c, err := findCluster(resolveClusterAlias(args[0]))
+		if err != nil {
+			return err
+		}
+		if c.Status != models.StatusStopped {
+			return fmt.Errorf("cannot start cluster %s - it is not stopped (status: %s)", c.Name, c.Status)
+		}
+
+		if err := clusterManager.StartCluster(c.Name); err != nil {
+			return fmt.Errorf("failed to start cluster: %w", err)
+		}
+
+		outf("✅ Cluster %s is starting\n", c.Name)
+		return nil
+	},
+}
+
+// clusterStopCmd stops a running cluster
+var clusterStopCmd = &cobra.Command{
+	Use:   "stop <cluster-name>",
+	Short: i18n.T("cmd.cluster.stop.short"),
+	Long:  i18n.T("cmd.cluster.stop.long"),
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		c, err := findCluster(resolveClusterAlias(args[0]))
+		if err != nil {
+			return err
+		}
+		if c.Status != models.StatusRunning {
+			return fmt.Errorf("cannot stop cluster %s - it is not running (status: %s)", c.Name, c.Status)
+		}
+
+		if err := clusterManager.StopCluster(c.Name); err != nil {
+			return fmt.Errorf("failed to stop cluster: %w", err)
+		}
+
+		outf("✅ Cluster %s is stopping\n", c.Name)
+		return nil
+	},
+}
+
+// clusterScheduleCmd shows or sets the times a cluster is stopped and started
+var clusterScheduleCmd = &cobra.Command{
+	Use:   "schedule <cluster-name>",
+	Short: i18n.T("cmd.cluster.schedule.short"),
+	Long:  i18n.T("cmd.cluster.schedule.long"),
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		c, err := findCluster(resolveClusterAlias(args[0]))
+		if err != nil {
+			return err
+		}
+
+		flags := cmd.Flags()
+		switch {
+		case scheduleClear:
+			c.Schedule = nil
+		case flags.Changed("stop") || flags.Changed("start") || flags.Changed("timezone"):
+			schedule := models.ScheduleSpec{}
+			if c.Schedule != nil {
+				schedule = *c.Schedule
+			}
+			if flags.Changed("stop") {
+				schedule.Stop = scheduleStop
+			}
+			if flags.Changed("start") {
+				schedule.Start = scheduleStart
+			}
+			if flags.Changed("timezone") {
+				schedule.Timezone = scheduleTimezone
+			}
+			if err := models.ValidateSchedule(&schedule); err != nil {
+				return err
+			}
+			c.Schedule = &schedule
+			if !c.Schedule.Enabled() {
+				c.Schedule = nil
+			}
+		default:
+			printSchedule(c.Schedule)
+			return nil
+		}
+
+		if _, err := clusterManager.UpdateCluster(*c); err != nil {
+			return fmt.Errorf("failed to update cluster: %w", err)
+		}
+		outf("✅ Schedule of cluster %s updated\n", c.Name)
+		printSchedule(c.Schedule)
+		return nil
+	},
+}
+
+// printSchedule shows a cluster schedule and its next action
+func printSchedule(schedule *models.ScheduleSpec) {
+	if !schedule.Enabled() {
+		outln("No schedule: the cluster is only stopped and started by hand")
+		return
+	}
+	tz := schedule.Timezone
+	if tz == "" {
+		tz = "UTC"
+	}
+	if schedule.Stop != "" {
+		outf("  Stop:     %s\n", schedule.Stop)
+	}
+	if schedule.Start != "" {
+		outf("  Start:    %s\n", schedule.Start)
+	}
+	outf("  Timezone: %s\n", tz)
+	if action, at := schedule.NextAction(time.Now()); action != "" {
+		verb := "start"
+		if action == "stopped" {
+			verb = "stop"
+		}
+		outf("  Next:     %s at %s\n", verb, at.Format("Mon 2006-01-02 15:04 MST"))
+	}
+}
+
+// clusterDeleteCmd deletes a cluster after confirmation
+var clusterDeleteCmd = &cobra.Command{
+	Use:   "delete <cluster-name>",
+	Short: i18n.T("cmd.cluster.delete.short"),
+	Long:  i18n.T("cmd.cluster.delete.long"),
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		c, err := findCluster(resolveClusterAlias(args[0]))
+		if err != nil {
+			return err
+		}
+
+		if !deleteYes {
+			outf("Type the cluster name (%s) to confirm deletion: ", c.Name)
+			input, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+			if strings.TrimSpace(input) != c.Name {
+				return fmt.Errorf("deletion cancelled")
+			}
+		}
+
+		if err := clusterManager.DeleteCluster(c.ID); err != nil {
+			return fmt.Errorf("failed to delete cluster: %w", err)
+		}
+
+		outf("✅ Cluster %s is being deleted\n", c.Name)
+		return nil
+	},
+}
+
+// clusterReconcileCmd forces the controller to re-process a cluster
+var clusterReconcileCmd = &cobra.Command{
+	Use:   "reconcile <cluster-name>",
+	Short: i18n.T("cmd.cluster.reconcile.short"),
+	Long:  i18n.T("cmd.cluster.reconcile.long"),
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		clusterName := resolveClusterAlias(args[0])
+		if err := updateClusterConfigForReconciliation(clusterName); err != nil {
+			return fmt.Errorf("failed to trigger reconciliation: %w", err)
+		}
+
+		outf("✅ Reconciliation triggered for cluster %s\n", clusterName)
+		outln("💡 Use 'goman cluster status " + clusterName + "' to follow progress")
+		return nil
+	},
+}
+
+// clusterRetagCmd re-applies a cluster's tags to all of its resources
+var clusterRetagCmd = &cobra.Command{
+	Use:   "retag <cluster-name>",
+	Short: i18n.T("cmd.cluster.retag.short"),
+	Long:  i18n.T("cmd.cluster.retag.long"),
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		clusterName := resolveClusterAlias(args[0])
+
+		if clusterManager == nil {
+			clusterManager = cluster.NewManager()
+		}
+
+		if err := clusterManager.RequestRetag(clusterName); err != nil {
+			return fmt.Errorf("failed to request retag: %w", err)
+		}
+
+		outf("🏷️  Retag requested for cluster %s\n", clusterName)
+		return nil
+	},
+}
+
+// clusterRotateCertsCmd rotates the K3s certificates on all masters
+var clusterRotateCertsCmd = &cobra.Command{
+	Use:   "rotate-certs <cluster-name>",
+	Short: i18n.T("cmd.cluster.rotate-certs.short"),
+	Long:  i18n.T("cmd.cluster.rotate-certs.long"),
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		clusterName := resolveClusterAlias(args[0])
+
+		if clusterManager == nil {
+			clusterManager = cluster.NewManager()
+		}
+
+		if err := clusterManager.RequestCertRotation(clusterName); err != nil {
+			return fmt.Errorf("failed to request certificate rotation: %w", err)
+		}
+
+		outf("🔐 Certificate rotation requested for cluster %s\n", clusterName)
+		outln("💡 Use 'goman cluster status " + clusterName + "' to follow progress")
+		return nil
+	},
+}
+
+// clusterRotateCredentialsCmd rotates the cluster's join token and client
+// credentials
+var clusterRotateCredentialsCmd = &cobra.Command{
+	Use:   "rotate-credentials <cluster-name>",
+	Short: i18n.T("cmd.cluster.rotate-credentials.short"),
+	Long:  i18n.T("cmd.cluster.rotate-credentials.long"),
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		clusterName := resolveClusterAlias(args[0])
+
+		c, err := findCluster(clusterName)
+		if err != nil {
+			return err
+		}
+
+		if !rotateCredsYes {
+			outf("Every node of %s restarts and all kubeconfigs stop working.\n", c.Name)
+			outf("Type the cluster name (%s) to confirm: ", c.Name)
+			input, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+			if strings.TrimSpace(input) != c.Name {
+				return fmt.Errorf("credential rotation cancelled")
+			}
+		}
+
+		if err := clusterManager.RequestCredentialRotation(c.Name); err != nil {
+			return fmt.Errorf("failed to request credential rotation: %w", err)
+		}
+
+		outf("🔑 Credential rotation requested for cluster %s\n", c.Name)
+		outln("💡 Use 'goman cluster status " + c.Name + "' to follow progress")
+		return nil
+	},
+}
+
+// findCluster looks up a cluster by name or ID
+func findCluster(name string) (*models.K3sCluster, error) {
+	if clusterManager == nil {
+		clusterManager = cluster.NewManager()
+	}
+	for _, c := range clusterManager.GetClusters() {
+		if c.Name == name || c.ID == name {
+			c := c
+			return &c, nil
+		}
+	}
+	return nil, fmt.Errorf("cluster not found: %s", name)
+}
+
+func init() {
+	clusterCreateCmd.Flags().StringVar(&createMode, "mode", "dev", "Cluster mode: dev (1 master) or ha (3 masters)")
+	clusterCreateCmd.Flags().StringVar(&createRegion, "region", config.GetDefaultRegion(), "Region to create the cluster in")
+	clusterCreateCmd.Flags().StringVar(&createPreset, "preset", "", "Sizing preset: "+presetHelp())
+	clusterCreateCmd.Flags().StringVar(&createInstanceType, "instance-type", "", "Instance type (overrides the preset's)")
+	clusterCreateCmd.Flags().StringVar(&createDescription, "description", "", "Cluster description")
+	clusterCreateCmd.Flags().BoolVar(&createPrivate, "private", false, "Launch nodes without public IPs behind a NAT gateway; the API server is only reachable through 'goman kube'")
+	clusterCreateCmd.Flags().BoolVar(&createDedicatedVPC, "dedicated-vpc", false, "Create a VPC for the cluster alone, with public and private subnets in up to three zones, deleted with the cluster")
+	clusterCreateCmd.Flags().StringVar(&createVpcCIDR, "vpc-cidr", "", "Address range of the dedicated VPC (default "+models.DefaultDedicatedVPCCIDR+"); ranges of peered clusters must not overlap")
+	clusterCreateCmd.Flags().BoolVar(&createAPILB, "api-load-balancer", true, "Serve the API of HA clusters from a network load balancer in front of all masters instead of the first master's address")
+	clusterCreateCmd.Flags().StringSliceVar(&createAPIAllowed, "api-allowed-cidrs", nil, "Address ranges allowed to reach the API load balancer (default: the VPC's range)")
+	clusterCreateCmd.Flags().BoolVar(&createLowResource, "low-resource", false, "Tune K3s for very small instances (t3.micro, t3.small): swap, smaller reservations, fewer components")
+	clusterCreateCmd.Flags().StringVar(&createK3sVersion, "k3s-version", "", "K3s release to install, e.g. "+models.DefaultK3sVersion+" (default)")
+	clusterCreateCmd.Flags().IntVar(&createRootVolume.Size, "root-volume-size", 0, "Root volume size of the nodes in GiB (default: the preset's, or 8)")
+	clusterCreateCmd.Flags().StringVar(&createRootVolume.Type, "root-volume-type", "", "Root volume type: gp3 (default), gp2, io1 or io2")
+	clusterCreateCmd.Flags().IntVar(&createRootVolume.IOPS, "root-volume-iops", 0, "Provisioned IOPS of the root volume (gp3, io1, io2)")
+	clusterDeleteCmd.Flags().BoolVarP(&deleteYes, "yes", "y", false, "Delete without asking for confirmation")
+	clusterRotateCredentialsCmd.Flags().BoolVarP(&rotateCredsYes, "yes", "y", false, "Rotate without asking for confirmation")
+	clusterScheduleCmd.Flags().StringVar(&scheduleStop, "stop", "", "Cron expression of the stops, e.g. \"0 20 * * *\" (empty to remove)")
+	clusterScheduleCmd.Flags().StringVar(&scheduleStart, "start", "", "Cron expression of the starts, e.g. \"0 8 * * 1-5\" (empty to remove)")
+	clusterScheduleCmd.Flags().StringVar(&scheduleTimezone, "timezone", "", "IANA time zone of the expressions, e.g. Europe/Berlin (default UTC)")
+	clusterScheduleCmd.Flags().BoolVar(&scheduleClear, "clear", false, "Remove the schedule")
+}
@@ -5,6 +5,8 @@ import (
 	"time"
 
 	"github.com/gdamore/tcell/v2"
+	"github.com/madhouselabs/goman/pkg/i18n"
+	"github.com/madhouselabs/goman/pkg/models"
 	"github.com/rivo/tview"
 )
 
@@ -16,8 +18,14 @@ var (
 	headerDivider *tview.TextView
 	footerDivider *tview.TextView
 	statusBarFlex *tview.Flex
+
+	// clusterList keeps order, selection and scroll across refreshes
+	clusterList = newClusterListModel("")
 )
 
+// clusterTableHeaders are the column titles of the cluster table
+var clusterTableHeaders = []string{"  Name", "Mode", "Region", "Status", "API", "Nodes", "Selected", "Created", "Synced"}
+
 func createClusterListView() {
 	// Create a flex layout for the main view
 	flex := tview.NewFlex().SetDirection(tview.FlexRow)
@@ -26,13 +34,13 @@ func createClusterListView() {
 	// Create header with title and provider info
 	headerFlex = tview.NewFlex().SetDirection(tview.FlexColumn)
 	
-	titleText := fmt.Sprintf(" [::b]K3s Cluster Manager[::-]")
+	titleText := fmt.Sprintf(" [::b]%s[::-]", i18n.T("status.title"))
 	title := tview.NewTextView().
 		SetText(titleText).
 		SetTextAlign(tview.AlignLeft).
 		SetDynamicColors(true)
 	
-	providerText := "[::d]Provider: AWS[::-] "
+	providerText := fmt.Sprintf("[::d]%s[::-] ", i18n.T("status.provider", "AWS"))
 	providerInfo := tview.NewTextView().
 		SetText(providerText).
 		SetTextAlign(tview.AlignRight).
@@ -47,6 +55,9 @@ func createClusterListView() {
 		SetText(string(CharDivider)).
 		SetTextColor(ColorMuted)
 	headerDivider.SetDrawFunc(func(screen tcell.Screen, x, y, width, height int) (int, int, int, int) {
+		if drawOfflineBanner(screen, x, y, width) {
+			return 0, 0, 0, 0
+		}
 		for i := x; i < x+width; i++ {
 			screen.SetContent(i, y, CharDivider, nil, StyleMuted)
 		}
@@ -59,13 +70,15 @@ func createClusterListView() {
 		SetSelectable(true, false).
 		SetSeparator(' ').
 		SetSelectedStyle(StyleHighlight)
+	clusterTable.SetSelectionChangedFunc(func(row, column int) {
+		clusterList.selectIndex(row - 1)
+	})
 
 	// Set headers with proper spacing
-	headers := []string{"  Name", "Mode", "Region", "Status", "Nodes", "Selected", "Created"}
-	for col, header := range headers {
+	for col, header := range clusterTableHeaders {
 		alignment := tview.AlignLeft
-		// Center align Status, Nodes, and Connected columns (columns 3, 4, 5)
-		if col == 3 || col == 4 || col == 5 {
+		// Center align Status, API, Nodes, and Connected columns (columns 3-6)
+		if col >= 3 && col <= 6 {
 			alignment = tview.AlignCenter
 		}
 		cell := tview.NewTableCell(header).
@@ -80,21 +93,23 @@ func createClusterListView() {
 	emptyPlaceholder = tview.NewTextView().
 		SetDynamicColors(true).
 		SetTextAlign(tview.AlignCenter).
-		SetText(`
+		SetText(fmt.Sprintf(`
 
 [::d]No clusters found[::-]
 
 [::b]Get Started:[::-]
 
-Press [#8be9fd]c[::-] to create your first cluster
-Press [#8be9fd]i[::-] to initialize infrastructure
+Press [#8be9fd]%s[::-] to create your first cluster
+Press [#8be9fd]%s[::-] to initialize infrastructure
 
 [::d]━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━[::-]
 
 [::d]K3s is a lightweight Kubernetes distribution
-perfect for edge, IoT, CI, and development[::-]`)
+perfect for edge, IoT, CI, and development[::-]`, tview.Escape(keys.label(actionCreate)), tview.Escape(keys.label(actionInit))))
 
-	// Load clusters and determine which view to show
+	// Load clusters and determine which view to show, starting on the
+	// cluster kubectl points at
+	clusterList = newClusterListModel(getCurrentCluster())
 	refreshClusters()
 	
 	// Determine initial content area
@@ -106,75 +121,79 @@ perfect for edge, IoT, CI, and development[::-]`)
 	}
 
 	// Set up key handlers for both table and placeholder
+	// Keys come from the keymap, see keymap.go
 	clusterTable.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
-		switch event.Key() {
-		case tcell.KeyEnter:
-			row, _ := clusterTable.GetSelection()
-			if row > 0 && row <= len(clusters) {
+		row, _ := clusterTable.GetSelection()
+		selected := row > 0 && row <= len(clusters)
+		switch action := keys.action(viewClusters, event); action {
+		case actionOpen:
+			if selected {
 				showClusterDetails(clusters[row-1])
 			}
-		case tcell.KeyRune:
-			switch event.Rune() {
-			case 'c', 'C':
-				openClusterEditor()
-			case 'e', 'E':
-				row, _ := clusterTable.GetSelection()
-				if row > 0 && row <= len(clusters) {
-					editCluster(clusters[row-1])
-				}
-			case 'd', 'D':
-				row, _ := clusterTable.GetSelection()
-				if row > 0 && row <= len(clusters) {
-					deleteCluster(clusters[row-1])
-				}
-			case 'k', 'K':
-				row, _ := clusterTable.GetSelection()
-				if row > 0 && row <= len(clusters) {
-					// Switch to the new cluster (handles tunnel management)
-					switchToCluster(clusters[row-1].Name)
-					// Refresh to update the Selected column
-					refreshClusters()
-				}
-			case 'r', 'R':
-				go refreshClustersAsync()
-			case 't', 'T':
-				row, _ := clusterTable.GetSelection()
-				if row > 0 && row <= len(clusters) {
-					triggerReconciliation(clusters[row-1])
-				}
-			case 's', 'S':
-				row, _ := clusterTable.GetSelection()
-				if row > 0 && row <= len(clusters) {
-					stopCluster(clusters[row-1])
-				}
-			case 'a', 'A':
-				row, _ := clusterTable.GetSelection()
-				if row > 0 && row <= len(clusters) {
-					startCluster(clusters[row-1])
-				}
-			case 'q', 'Q':
-				app.Stop()
+		case actionCreate:
+			openClusterEditor()
+		case actionEdit:
+			if selected {
+				editCluster(clusters[row-1])
+			}
+		case actionDelete:
+			if selected {
+				deleteCluster(clusters[row-1])
+			}
+		case actionSelect:
+			if selected {
+				// Switch to the new cluster (handles tunnel management)
+				switchToCluster(clusters[row-1].Name)
+				// Refresh to update the Selected column
+				refreshClusters()
+			}
+		case actionRefresh:
+			go refreshClustersAsync()
+		case actionSort:
+			clusterList.cycleSort()
+			refreshClusters()
+			statusText.SetText(" " + i18n.T("status.sorted", clusterList.sortName()))
+		case actionReconcile:
+			if selected {
+				triggerReconciliation(clusters[row-1])
 			}
+		case actionStop:
+			if selected {
+				stopCluster(clusters[row-1])
+			}
+		case actionStart:
+			if selected {
+				startCluster(clusters[row-1])
+			}
+		case actionHelp:
+			showHelp(viewClusters)
+		case actionQuit:
+			app.Stop()
+		case "":
+			return event
+		default:
+			return navigate(action)
 		}
-		return event
+		return nil
 	})
 	
 	// Set up key handlers for placeholder
 	emptyPlaceholder.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
-		switch event.Key() {
-		case tcell.KeyRune:
-			switch event.Rune() {
-			case 'c', 'C':
-				openClusterEditor()
-			case 'i', 'I':
-				initializeInfrastructure()
-			case 'r', 'R':
-				go refreshClustersAsync()
-			case 'q', 'Q':
-				app.Stop()
-			}
+		switch keys.action(viewEmpty, event) {
+		case actionCreate:
+			openClusterEditor()
+		case actionInit:
+			initializeInfrastructure()
+		case actionRefresh:
+			go refreshClustersAsync()
+		case actionHelp:
+			showHelp(viewEmpty)
+		case actionQuit:
+			app.Stop()
+		default:
+			return event
 		}
-		return event
+		return nil
 	})
 
 	// Footer divider
@@ -193,12 +212,23 @@ perfect for edge, IoT, CI, and development[::-]`)
 	
 	// Connection status (left) - will be updated dynamically
 	statusText = tview.NewTextView().
-		SetText(" [green]● AWS connected[::-]").
+		SetText(" [green]" + i18n.T("status.connected") + "[::-]").
 		SetDynamicColors(true).
 		SetTextAlign(tview.AlignLeft)
 	
 	// Shortcuts (right)
-	shortcuts := fmt.Sprintf("[#8be9fd]%c%c[::-] Navigate  [#8be9fd]Enter[::-] Details  [#8be9fd]k[::-] Select  [#8be9fd]c[::-] Create  [#8be9fd]t[::-] Reconcile  [#8be9fd]s[::-] Stop  [#8be9fd]a[::-] Start  [#8be9fd]r[::-] Refresh  [#8be9fd]q[::-] Quit ", CharArrowUp, CharArrowDown)
+	shortcuts := shortcutHints(
+		navigationHint(),
+		actionHint(actionOpen, "shortcut.details"),
+		actionHint(actionSelect, "shortcut.select"),
+		actionHint(actionCreate, "shortcut.create"),
+		actionHint(actionReconcile, "shortcut.reconcile"),
+		actionHint(actionStop, "shortcut.stop"),
+		actionHint(actionStart, "shortcut.start"),
+		actionHint(actionRefresh, "shortcut.refresh"),
+		actionHint(actionSort, "shortcut.sort"),
+		actionHint(actionHelp, "shortcut.help"),
+		actionHint(actionQuit, "shortcut.quit"))
 	statusRight := tview.NewTextView().
 		SetText(shortcuts).
 		SetDynamicColors(true).
@@ -220,16 +250,16 @@ perfect for edge, IoT, CI, and development[::-]`)
 	pages.AddPage("clusters", flex, true, true)
 }
 
+// refreshClusters updates the table from the cluster manager in place:
+// only changed cells are replaced, and the sort order, selected cluster and
+// scroll offset of clusterList are kept
 func refreshClusters() {
-	// Save current selection
-	selectedRow, _ := clusterTable.GetSelection()
-	
-	// Load clusters from storage
-	newClusters := clusterManager.GetClusters()
-	newCount := len(newClusters)
-	
-	// Update global clusters list
-	clusters = newClusters
+	// Remember how far the user scrolled
+	clusterList.offset, _ = clusterTable.GetOffset()
+
+	clusterList.update(clusterManager.GetClusters())
+	clusters = clusterList.clusters
+	newCount := len(clusters)
 	
 	// Switch between table and placeholder based on cluster count
 	if clusterListFlex != nil && clusterListFlex.GetItemCount() >= 5 {
@@ -273,73 +303,123 @@ func refreshClusters() {
 		}
 	}
 	
-	// Handle row count changes
-	currentRows := clusterTable.GetRowCount() - 1 // Exclude header
-	
-	if newCount < currentRows {
-		// Remove extra rows
-		for row := clusterTable.GetRowCount() - 1; row > newCount; row-- {
-			clusterTable.RemoveRow(row)
-		}
+	setClusterTableHeaders()
+
+	// Remove rows of clusters that are gone
+	for row := clusterTable.GetRowCount() - 1; row > newCount; row-- {
+		clusterTable.RemoveRow(row)
 	}
 	
 	// Update or add rows
+	currentCluster := getCurrentCluster()
 	for i, cluster := range clusters {
-		row := i + 1
-		
-		// Format creation time
-		created := cluster.CreatedAt.Format("2006-01-02 15:04")
-		if cluster.CreatedAt.IsZero() {
-			created = "-"
-		}
+		setClusterRow(i+1, clusterRowCells(cluster, currentCluster))
+	}
+	
+	// Restore the scroll position and select the same cluster again
+	clusterTable.SetOffset(clusterList.offset, 0)
+	if i := clusterList.selectedIndex(); i >= 0 {
+		clusterTable.Select(i+1, 0)
+	}
+}
 
-		// Count nodes
-		nodeCount := len(cluster.MasterNodes) + len(cluster.WorkerNodes)
-
-		// Status color
-		statusColor := ColorDanger
-		if cluster.Status == "running" {
-			statusColor = ColorSuccess
-		} else if cluster.Status == "stopped" {
-			statusColor = ColorMuted
-		} else if cluster.Status == "pending" || cluster.Status == "creating" {
-			statusColor = ColorWarning
-		} else if cluster.Status == "starting" || cluster.Status == "stopping" {
-			statusColor = ColorWarning
-		} else if cluster.Status == "deleting" {
-			statusColor = ColorDanger
+// setClusterTableHeaders marks the column the list is sorted by
+func setClusterTableHeaders() {
+	for col, header := range clusterTableHeaders {
+		if col == clusterList.sortColumn() {
+			header += " ▾"
 		}
-
-		// Check if this is the selected cluster
-		connectedText := "○"
-		connectedColor := ColorMuted
-		currentCluster := getCurrentCluster()
-		if currentCluster == cluster.Name {
-			connectedText = "●"
-			connectedColor = ColorSuccess
+		if cell := clusterTable.GetCell(0, col); cell.Text != header {
+			cell.SetText(header)
 		}
+	}
+}
 
-		// Update cells (this will either update existing or create new)
-		clusterTable.SetCell(row, 0, tview.NewTableCell("  "+cluster.Name).SetExpansion(2))
-		clusterTable.SetCell(row, 1, tview.NewTableCell(string(cluster.Mode)).SetAlign(tview.AlignLeft).SetExpansion(1))
-		clusterTable.SetCell(row, 2, tview.NewTableCell(cluster.Region).SetAlign(tview.AlignLeft).SetExpansion(1))
-		
-		// Debug: ensure status is not empty
-		statusText := string(cluster.Status)
-		if statusText == "" {
-			statusText = "unknown"
+// setClusterRow replaces the cells of a row that differ from the given ones,
+// so unchanged rows are not rebuilt on every refresh
+func setClusterRow(row int, cells []*tview.TableCell) {
+	for col, cell := range cells {
+		old := clusterTable.GetCell(row, col)
+		if old.Text == cell.Text && old.Color == cell.Color && old.Style == cell.Style &&
+			old.Align == cell.Align && old.Expansion == cell.Expansion {
+			continue
 		}
-		clusterTable.SetCell(row, 3, tview.NewTableCell(statusText).SetTextColor(statusColor).SetAlign(tview.AlignCenter).SetExpansion(1))
-		clusterTable.SetCell(row, 4, tview.NewTableCell(fmt.Sprintf("%d", nodeCount)).SetAlign(tview.AlignCenter).SetExpansion(1))
-		clusterTable.SetCell(row, 5, tview.NewTableCell(connectedText).SetTextColor(connectedColor).SetAlign(tview.AlignCenter).SetExpansion(1))
-		clusterTable.SetCell(row, 6, tview.NewTableCell(created).SetAlign(tview.AlignLeft).SetExpansion(2))
+		clusterTable.SetCell(row, col, cell)
 	}
-	
-	// Restore selection if valid
-	if selectedRow > 0 && selectedRow <= newCount {
-		clusterTable.Select(selectedRow, 0)
-	} else if newCount > 0 {
-		clusterTable.Select(1, 0)
+}
+
+// clusterRowCells renders a cluster as the cells of a table row
+func clusterRowCells(cluster models.K3sCluster, currentCluster string) []*tview.TableCell {
+	// Format creation time
+	created := cluster.CreatedAt.Format("2006-01-02 15:04")
+	if cluster.CreatedAt.IsZero() {
+		created = "-"
+	}
+
+	// Count nodes
+	nodeCount := len(cluster.MasterNodes) + len(cluster.WorkerNodes)
+
+	// Status color
+	statusColor := ColorDanger
+	if cluster.Status == "running" {
+		statusColor = ColorSuccess
+	} else if cluster.Status == "stopped" {
+		statusColor = ColorMuted
+	} else if cluster.Status == "pending" || cluster.Status == "creating" {
+		statusColor = ColorWarning
+	} else if cluster.Status == "starting" || cluster.Status == "stopping" {
+		statusColor = ColorWarning
+	} else if cluster.Status == "deleting" {
+		statusColor = ColorDanger
+	}
+
+	// Synced times go stale while offline
+	syncedColor := ColorForeground
+	if clusterManager.IsOffline() {
+		syncedColor = ColorOrange
+	}
+
+	// Check if this is the selected cluster
+	connectedText := "○"
+	connectedColor := ColorMuted
+	if currentCluster == cluster.Name {
+		connectedText = "●"
+		connectedColor = ColorSuccess
+	}
+
+	// Debug: ensure status is not empty
+	statusText := string(cluster.Status)
+	if statusText == "" {
+		statusText = "unknown"
+	}
+	if eta := models.FormatRemaining(cluster.EstimatedCompletion, time.Now()); eta != "" {
+		statusText = i18n.T("status.eta", statusText, eta)
+	}
+	if cluster.SpecPending() {
+		// The last edit has not been acted upon yet
+		statusText = i18n.T("status.spec_pending", statusText)
+	}
+	if len(cluster.Drift) > 0 {
+		// Instances were changed outside goman
+		statusText = i18n.T("status.drift", statusText)
+		statusColor = ColorWarning
+	}
+	if warning := models.CertificateWarning(cluster.CertificatesExpireAt, time.Now()); warning != "" {
+		statusText = i18n.T("status.certs", statusText, warning)
+		statusColor = ColorWarning
+	}
+	apiText, apiColor := apiHealthBadge(cluster)
+
+	return []*tview.TableCell{
+		tview.NewTableCell("  " + cluster.Name).SetExpansion(2),
+		tview.NewTableCell(string(cluster.Mode)).SetAlign(tview.AlignLeft).SetExpansion(1),
+		tview.NewTableCell(cluster.Region).SetAlign(tview.AlignLeft).SetExpansion(1),
+		tview.NewTableCell(statusText).SetTextColor(statusColor).SetAlign(tview.AlignCenter).SetExpansion(1),
+		tview.NewTableCell(apiText).SetTextColor(apiColor).SetAlign(tview.AlignCenter).SetExpansion(1),
+		tview.NewTableCell(fmt.Sprintf("%d", nodeCount)).SetAlign(tview.AlignCenter).SetExpansion(1),
+		tview.NewTableCell(connectedText).SetTextColor(connectedColor).SetAlign(tview.AlignCenter).SetExpansion(1),
+		tview.NewTableCell(created).SetAlign(tview.AlignLeft).SetExpansion(2),
+		tview.NewTableCell(formatLastSynced(cluster.Name)).SetTextColor(syncedColor).SetAlign(tview.AlignLeft).SetExpansion(1),
 	}
 }
 
@@ -363,7 +443,7 @@ func refreshClustersAsync() {
 	// Update status to show refreshing
 	app.QueueUpdateDraw(func() {
 		if statusText != nil {
-			statusText.SetText(" [yellow]↻ Refreshing...[::-]")
+			statusText.SetText(" [yellow]" + i18n.T("status.refreshing") + "[::-]")
 		}
 	})
 
@@ -398,16 +478,22 @@ func refreshClustersAsync() {
 
 		// Determine connection status to AWS
 		var statusMsg string
-		if refreshErr != nil {
+		credentialsExpired := clusterManager.CredentialsExpired()
+		offline := clusterManager.IsOffline()
+		if credentialsExpired {
+			statusMsg = " [red]" + i18n.T("status.session_expired") + "[::-]"
+		} else if offline {
+			statusMsg = " [#ffb86c]" + i18n.T("status.offline") + "[::-]"
+		} else if refreshErr != nil {
 			// Connection error
-			statusMsg = " [red]● AWS connection error[::-]"
+			statusMsg = " [red]" + i18n.T("status.connection_error") + "[::-]"
 			lastError = refreshErr
 		} else if duration > 3*time.Second {
 			// Slow connection
-			statusMsg = fmt.Sprintf(" [yellow]● Slow AWS connection[::-] (%.1fs)", duration.Seconds())
+			statusMsg = fmt.Sprintf(" [yellow]%s[::-] (%.1fs)", i18n.T("status.slow_connection"), duration.Seconds())
 		} else {
 			// Success
-			statusMsg = " [green]● AWS connected[::-]"
+			statusMsg = " [green]" + i18n.T("status.connected") + "[::-]"
 			lastError = nil
 		}
 
@@ -425,6 +511,15 @@ func refreshClustersAsync() {
 				if statusText != nil {
 					statusText.SetText(statusMsg)
 				}
+				if credentialsExpired {
+					promptCredentialRefresh()
+				} else if !offline {
+					promptQueuedIntents()
+					if lastError == nil && !intentPromptOpen && !draftsPrompted {
+						draftsPrompted = true
+						promptFormDrafts()
+					}
+				}
 			})
 		}
 	}()
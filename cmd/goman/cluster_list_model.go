@@ -0,0 +1,151 @@
+package main
+
+import (
+	"sort"
+
+	"github.com/madhouselabs/goman/pkg/models"
+)
+
+// clusterSortKey is a column the cluster list can be ordered by
+type clusterSortKey int
+
+const (
+	sortByName clusterSortKey = iota
+	sortByCreated
+	sortByStatus
+	sortByRegion
+)
+
+// clusterSortOrders describe each sort key, in the order the sort action
+// cycles through them. Column is the cluster table column showing it.
+var clusterSortOrders = []struct {
+	key    clusterSortKey
+	name   string
+	column int
+	less   func(a, b models.K3sCluster) bool
+}{
+	{sortByName, "name", 0, func(a, b models.K3sCluster) bool { return false }},
+	{sortByCreated, "created", 7, func(a, b models.K3sCluster) bool { return a.CreatedAt.After(b.CreatedAt) }},
+	{sortByStatus, "status", 3, func(a, b models.K3sCluster) bool { return a.Status < b.Status }},
+	{sortByRegion, "region", 2, func(a, b models.K3sCluster) bool { return a.Region < b.Region }},
+}
+
+// clusterListModel is the state of a cluster list that survives refreshes:
+// the sort order, the selected cluster and the scroll offset. Storage
+// returns clusters in no particular order, so rows are always sorted, with
+// the name breaking ties, and the selection follows the cluster rather than
+// the row it was on.
+type clusterListModel struct {
+	sortKey  clusterSortKey
+	clusters []models.K3sCluster
+
+	selected string // Name of the selected cluster
+	index    int    // Its row, to select a neighbour once it is gone
+	offset   int    // First row scrolled into view
+}
+
+// newClusterListModel returns a list sorted by name with the given cluster
+// selected, if any
+func newClusterListModel(selected string) *clusterListModel {
+	return &clusterListModel{selected: selected}
+}
+
+// update replaces the clusters of the list, keeping its order and selection
+func (m *clusterListModel) update(clusters []models.K3sCluster) {
+	m.clusters = m.sorted(clusters)
+	m.index = m.selectedIndex()
+	if m.index >= 0 {
+		m.selected = m.clusters[m.index].Name
+	}
+}
+
+// sorted returns a copy of clusters in the order of the list
+func (m *clusterListModel) sorted(clusters []models.K3sCluster) []models.K3sCluster {
+	less := clusterSortOrders[0].less
+	for _, order := range clusterSortOrders {
+		if order.key == m.sortKey {
+			less = order.less
+		}
+	}
+	result := append([]models.K3sCluster(nil), clusters...)
+	sort.SliceStable(result, func(i, j int) bool {
+		if less(result[i], result[j]) {
+			return true
+		}
+		if less(result[j], result[i]) {
+			return false
+		}
+		return result[i].Name < result[j].Name
+	})
+	return result
+}
+
+// selectedIndex returns the row of the selected cluster. A cluster that is
+// gone leaves the selection on the row it was on, or the last row; -1 means
+// the list is empty.
+func (m *clusterListModel) selectedIndex() int {
+	if len(m.clusters) == 0 {
+		return -1
+	}
+	for i, c := range m.clusters {
+		if c.Name == m.selected {
+			return i
+		}
+	}
+	if m.index < 0 {
+		return 0
+	}
+	if m.index >= len(m.clusters) {
+		return len(m.clusters) - 1
+	}
+	return m.index
+}
+
+// selectIndex records the row the user moved to
+func (m *clusterListModel) selectIndex(i int) {
+	if i < 0 || i >= len(m.clusters) {
+		return
+	}
+	m.index = i
+	m.selected = m.clusters[i].Name
+}
+
+// selectedCluster returns the selected cluster, if any
+func (m *clusterListModel) selectedCluster() (models.K3sCluster, bool) {
+	i := m.selectedIndex()
+	if i < 0 {
+		return models.K3sCluster{}, false
+	}
+	return m.clusters[i], true
+}
+
+// cycleSort orders the list by the next sort key, keeping the selection
+func (m *clusterListModel) cycleSort() {
+	for i, order := range clusterSortOrders {
+		if order.key == m.sortKey {
+			m.sortKey = clusterSortOrders[(i+1)%len(clusterSortOrders)].key
+			break
+		}
+	}
+	m.update(m.clusters)
+}
+
+// sortColumn returns the table column the list is sorted by
+func (m *clusterListModel) sortColumn() int {
+	for _, order := range clusterSortOrders {
+		if order.key == m.sortKey {
+			return order.column
+		}
+	}
+	return 0
+}
+
+// sortName names the sort key for the status bar and help
+func (m *clusterListModel) sortName() string {
+	for _, order := range clusterSortOrders {
+		if order.key == m.sortKey {
+			return order.name
+		}
+	}
+	return clusterSortOrders[0].name
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/madhouselabs/goman/pkg/cluster"
+	"github.com/madhouselabs/goman/pkg/i18n"
+	"github.com/madhouselabs/goman/pkg/provider"
+	"github.com/spf13/cobra"
+)
+
+// clusterLogsPollInterval is how often new lines are fetched with --follow
+const clusterLogsPollInterval = 5 * time.Second
+
+var (
+	clusterLogsSince     time.Duration
+	clusterLogsFollow    bool
+	clusterLogsRequestID string
+)
+
+// clusterLogsCmd shows the controller's logs for a cluster
+var clusterLogsCmd = &cobra.Command{
+	Use:   "logs <cluster-name>",
+	Short: i18n.T("cmd.cluster.logs.short"),
+	Long:  i18n.T("cmd.cluster.logs.long"),
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if clusterLogsSince <= 0 {
+			return fmt.Errorf("--since must be positive")
+		}
+		if clusterManager == nil {
+			clusterManager = cluster.NewManager()
+		}
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		query := provider.ControllerLogQuery{
+			Cluster:   args[0],
+			RequestID: clusterLogsRequestID,
+			Since:     time.Now().Add(-clusterLogsSince),
+		}
+		seen := make(map[string]bool)
+		printed := 0
+		for {
+			events, err := clusterManager.ControllerLogs(ctx, query)
+			if err != nil {
+				if ctx.Err() != nil {
+					return nil
+				}
+				return err
+			}
+			for _, e := range events {
+				if seen[e.ID] {
+					continue
+				}
+				seen[e.ID] = true
+				printed++
+				outf("%s  %s\n", e.Time.Local().Format("2006-01-02 15:04:05"), logLine(e.Message))
+			}
+
+			if !clusterLogsFollow {
+				if printed == 0 {
+					outf("No controller logs for cluster %s in the last %s\n", args[0], clusterLogsSince)
+				}
+				return nil
+			}
+			// Lines of an invocation are read again as it goes on; otherwise
+			// only lines from the newest one seen onwards
+			if query.RequestID == "" && len(events) > 0 {
+				query.Since = events[len(events)-1].Time
+			}
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-time.After(clusterLogsPollInterval):
+			}
+		}
+	},
+}
+
+// logLine renders a JSON record of the controller as its level, step,
+// message and other attributes; other lines, such as those of the Lambda
+// runtime, are shown as they are
+func logLine(message string) string {
+	if !strings.HasPrefix(message, "{") {
+		return message
+	}
+	var record map[string]any
+	if err := json.Unmarshal([]byte(message), &record); err != nil {
+		return message
+	}
+	msg, ok := record["msg"].(string)
+	if !ok {
+		return message
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "%-5v ", record["level"])
+	if step, ok := record["step"].(string); ok {
+		fmt.Fprintf(&b, "[%s] ", strings.ToUpper(step))
+	}
+	b.WriteString(msg)
+	var keys []string
+	for key := range record {
+		switch key {
+		case "time", "level", "msg", "step":
+		default:
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		fmt.Fprintf(&b, " %s=%v", key, record[key])
+	}
+	return b.String()
+}
+
+func init() {
+	clusterLogsCmd.Flags().DurationVar(&clusterLogsSince, "since", time.Hour, "Show lines logged within this long")
+	clusterLogsCmd.Flags().BoolVarP(&clusterLogsFollow, "follow", "f", false, "Keep showing new lines until interrupted")
+	clusterLogsCmd.Flags().StringVar(&clusterLogsRequestID, "request-id", "", "Show every line of this controller invocation")
+	clusterCmd.AddCommand(clusterLogsCmd)
+}
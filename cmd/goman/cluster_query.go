@@ -0,0 +1,123 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/madhouselabs/goman/pkg/client"
+	"github.com/madhouselabs/goman/pkg/config"
+	"github.com/madhouselabs/goman/pkg/i18n"
+	"github.com/madhouselabs/goman/pkg/models"
+	"github.com/madhouselabs/goman/pkg/provider/registry"
+	"github.com/spf13/cobra"
+)
+
+var (
+	clusterListRegion string
+	clusterListJSON   bool
+)
+
+// clusterListCmd lists cluster resources straight from the state storage
+var clusterListCmd = &cobra.Command{
+	Use:   "list",
+	Short: i18n.T("cmd.cluster.list.short"),
+	Long:  i18n.T("cmd.cluster.list.long"),
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		c, err := newResourceClient()
+		if err != nil {
+			return err
+		}
+
+		clusters, err := c.List(context.Background())
+		if err != nil {
+			return err
+		}
+
+		var filtered []*models.ClusterResource
+		for _, cluster := range clusters {
+			if clusterListRegion == "" || cluster.Spec.Region == clusterListRegion {
+				filtered = append(filtered, cluster)
+			}
+		}
+
+		if clusterListJSON {
+			if filtered == nil {
+				filtered = []*models.ClusterResource{}
+			}
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			return enc.Encode(filtered)
+		}
+
+		if len(filtered) == 0 {
+			outln("No clusters found")
+			return nil
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "NAME\tREGION\tMODE\tPHASE\tSPEC\tINSTANCES\tOWNER")
+		for _, cluster := range filtered {
+			phase := cluster.Status.Phase
+			if cluster.DeletionTimestamp != nil {
+				phase = models.ClusterPhaseDeleting
+			} else if phase == "" {
+				phase = models.ClusterPhasePending
+			}
+			if phase == models.ClusterPhaseFailed && cluster.Status.Reason != "" {
+				phase = fmt.Sprintf("%s (%s)", phase, cluster.Status.Reason)
+			}
+			phase = phaseWithETA(phase, &cluster.Status)
+			owner := cluster.Annotations[models.AnnotationOwner]
+			if owner == "" {
+				owner = "-"
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%d\t%s\n", cluster.Name, cluster.Spec.Region, cluster.Spec.Mode, phase, specState(cluster), len(cluster.Status.Instances), owner)
+		}
+		return w.Flush()
+	},
+}
+
+// phaseWithETA adds the time left for a cluster setup or rollout in
+// progress to its phase, e.g. "Installing — ~4m remaining"
+func phaseWithETA(phase string, status *models.ClusterResourceStatus) string {
+	eta := models.FormatRemaining(status.EstimatedCompletion, time.Now())
+	if eta == "" || phase == models.ClusterPhaseDeleting {
+		return phase
+	}
+	if phase == models.ClusterPhaseRunning && status.Rollout.Active() {
+		phase += ", rolling out"
+	}
+	return fmt.Sprintf("%s — %s remaining", phase, eta)
+}
+
+// specState tells whether the controller has acted upon the latest edit
+func specState(cluster *models.ClusterResource) string {
+	if cluster.SpecPending() {
+		return fmt.Sprintf("pending (%d/%d)", cluster.Status.ObservedGeneration, cluster.Generation)
+	}
+	return "up-to-date"
+}
+
+// newResourceClient creates a client for cluster resources in the state
+// storage of the configured account
+func newResourceClient() (*client.Client, error) {
+	cfg, err := config.NewConfig()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load config: %w", err)
+	}
+	p, err := registry.GetConfiguredProvider(cfg.AWSProfile, cfg.AWSRegion)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get AWS provider: %w", err)
+	}
+	return client.NewFromProvider(p), nil
+}
+
+func init() {
+	clusterListCmd.Flags().StringVar(&clusterListRegion, "region", "", "Only list clusters in this region")
+	clusterListCmd.Flags().BoolVar(&clusterListJSON, "json", false, "Print cluster resources as JSON")
+	clusterCmd.AddCommand(clusterListCmd)
+}
@@ -6,6 +6,7 @@ import (
 	"strings"
 
 	"github.com/gdamore/tcell/v2"
+	"github.com/madhouselabs/goman/pkg/cluster"
 	"github.com/madhouselabs/goman/pkg/models"
 	"github.com/madhouselabs/goman/pkg/provider/registry"
 	"github.com/madhouselabs/goman/pkg/storage"
@@ -41,13 +42,16 @@ func selectCluster(prompt string) (string, error) {
 		return "", fmt.Errorf("failed to load clusters: %w", err)
 	}
 	
-	// Extract clusters from states
+	// Extract clusters from states, ordered like the TUI list
 	var clusters []models.K3sCluster
 	for _, state := range clusterStates {
 		if state != nil {
 			clusters = append(clusters, state.Cluster)
 		}
 	}
+	model := newClusterListModel(getCurrentCluster())
+	model.update(clusters)
+	clusters = model.clusters
 
 	if len(clusters) == 0 {
 		return "", fmt.Errorf("no clusters found")
@@ -87,6 +91,11 @@ func selectCluster(prompt string) (string, error) {
 	
 	// Add quit option
 	list.AddItem("", "[dim]Press ESC or q to cancel[-]", 0, nil)
+
+	// Start on the current cluster
+	if i := model.selectedIndex(); i >= 0 {
+		list.SetCurrentItem(i)
+	}
 	
 	// Style the list
 	list.SetSelectedBackgroundColor(tcell.ColorDarkCyan)
@@ -156,22 +165,32 @@ func selectClusterSimple() (string, error) {
 		return "", fmt.Errorf("failed to load clusters: %w", err)
 	}
 	
-	// Extract clusters from states
+	// Extract clusters from states, ordered like the TUI list
 	var clusters []models.K3sCluster
 	for _, state := range clusterStates {
 		if state != nil {
 			clusters = append(clusters, state.Cluster)
 		}
 	}
+	model := newClusterListModel(getCurrentCluster())
+	model.update(clusters)
+	clusters = model.clusters
 
 	if len(clusters) == 0 {
 		return "", fmt.Errorf("no clusters found")
 	}
 
 	// Display clusters
-	fmt.Println("\nAvailable clusters:")
-	fmt.Println(strings.Repeat("─", 80))
+	outln("\nAvailable clusters:")
+	outln(strings.Repeat("─", 80))
 	for i, cluster := range clusters {
+		if plainMode() {
+			outf("  %d) %s, status %s, mode %s, region %s, %d nodes\n",
+				i+1, cluster.Name, cluster.Status, cluster.Mode, cluster.Region,
+				len(cluster.MasterNodes)+len(cluster.WorkerNodes))
+			continue
+		}
+
 		statusColor := "31" // red
 		if cluster.Status == "running" {
 			statusColor = "32" // green
@@ -179,12 +198,12 @@ func selectClusterSimple() (string, error) {
 			statusColor = "33" // yellow
 		}
 		
-		fmt.Printf("  %d) %-30s \033[%sm%-10s\033[0m %s %s %d nodes\n", 
+		outf("  %d) %-30s \033[%sm%-10s\033[0m %s %s %d nodes\n", 
 			i+1, cluster.Name, statusColor, cluster.Status, 
 			cluster.Mode, cluster.Region, 
 			len(cluster.MasterNodes)+len(cluster.WorkerNodes))
 	}
-	fmt.Println(strings.Repeat("─", 80))
+	outln(strings.Repeat("─", 80))
 	
 	// Get user selection
 	fmt.Print("Select cluster (1-", len(clusters), ") or q to quit: ")
@@ -214,8 +233,9 @@ func getOrSelectCluster(clusterName string, action string) (string, error) {
 		return clusterName, nil
 	}
 	
-	// Check if we're in a TTY for interactive selection
-	if fileInfo, _ := os.Stdin.Stat(); (fileInfo.Mode() & os.ModeCharDevice) != 0 {
+	// Check if we're in a TTY for interactive selection; plain mode always
+	// uses the numbered text prompt
+	if fileInfo, _ := os.Stdin.Stat(); !plainMode() && (fileInfo.Mode()&os.ModeCharDevice) != 0 {
 		prompt := fmt.Sprintf(" Select cluster to %s ", action)
 		return selectCluster(prompt)
 	}
@@ -232,4 +252,15 @@ type ClusterInfo struct {
 	Region       string
 	NodeCount    int
 	InstanceID   string // For SSM connection
-}
\ No newline at end of file
+}
+// resolveClusterAlias maps a former cluster name to its current name after a rename
+func resolveClusterAlias(clusterName string) string {
+	if clusterManager == nil {
+		clusterManager = cluster.NewManager()
+	}
+	current, renamed := clusterManager.ResolveClusterName(clusterName)
+	if renamed {
+		outf("💡 Cluster %s was renamed to %s\n", clusterName, current)
+	}
+	return current
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"strings"
+
+	"github.com/madhouselabs/goman/pkg/cluster"
+	"github.com/madhouselabs/goman/pkg/i18n"
+	"github.com/madhouselabs/goman/pkg/storage"
+	"github.com/spf13/cobra"
+)
+
+var (
+	commandsLimit  int
+	commandsOutput bool
+	commandsJSON   bool
+)
+
+// clusterCommandsCmd shows the node commands the controller ran on a cluster
+var clusterCommandsCmd = &cobra.Command{
+	Use:   "commands <cluster-name>",
+	Short: i18n.T("cmd.cluster.commands.short"),
+	Long:  i18n.T("cmd.cluster.commands.long"),
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		clusterName := resolveClusterAlias(args[0])
+		if clusterManager == nil {
+			clusterManager = cluster.NewManager()
+		}
+
+		records, err := clusterManager.CommandHistory(clusterName, commandsLimit)
+		if err != nil {
+			return err
+		}
+
+		if commandsJSON {
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			return enc.Encode(records)
+		}
+		if len(records) == 0 {
+			outf("No commands recorded for cluster %s\n", clusterName)
+			return nil
+		}
+
+		for _, record := range records {
+			outf("%s  %-24s %-8s %8s  %s\n",
+				record.Time.Local().Format("2006-01-02 15:04:05"), record.Purpose, record.Status,
+				record.Duration, strings.Join(record.Instances, ","))
+			if record.Error != "" {
+				outf("    error: %s\n", record.Error)
+			}
+			if commandsOutput {
+				printCommandOutput(record)
+			}
+		}
+		return nil
+	},
+}
+
+// printCommandOutput prints the stored output of a command, indented
+func printCommandOutput(record storage.CommandRecord) {
+	if record.OutputKey == "" {
+		return
+	}
+	output, err := clusterManager.CommandOutput(record)
+	if err != nil {
+		outf("    (%v)\n", err)
+		return
+	}
+	for _, line := range strings.Split(strings.TrimRight(output, "\n"), "\n") {
+		outf("    | %s\n", line)
+	}
+	if record.OutputTruncated {
+		outln("    | ... (output cut off)")
+	}
+}
+
+func init() {
+	clusterCommandsCmd.Flags().IntVar(&commandsLimit, "limit", 20, "Maximum number of commands to show (0 for all)")
+	clusterCommandsCmd.Flags().BoolVar(&commandsOutput, "output", false, "Print the output of each command")
+	clusterCommandsCmd.Flags().BoolVar(&commandsJSON, "json", false, "Print the command records as JSON")
+}
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/madhouselabs/goman/pkg/i18n"
+	"github.com/madhouselabs/goman/pkg/models"
+	"github.com/madhouselabs/goman/pkg/storage"
+	"github.com/rivo/tview"
+)
+
+// commandsViewLimit is how many recent commands the TUI shows
+const commandsViewLimit = 50
+
+// showCommandHistory opens a page listing the node commands the controller
+// ran on a cluster, with the output of the selected one below
+func showCommandHistory(c models.K3sCluster) {
+	title := tview.NewTextView().
+		SetDynamicColors(true).
+		SetText(fmt.Sprintf(" %s%sCommand History%s%s  %s", TagBold, TagPrimary, TagReset, TagReset, c.Name))
+
+	table := tview.NewTable().
+		SetBorders(false).
+		SetSelectable(true, false).
+		SetSeparator(' ').
+		SetSelectedStyle(StyleHighlight).
+		SetFixed(1, 0)
+	for col, header := range []string{"  Time", "Purpose", "Status", "Duration", "Instances"} {
+		table.SetCell(0, col, tview.NewTableCell(header).
+			SetTextColor(ColorPrimary).
+			SetSelectable(false).
+			SetExpansion(1))
+	}
+	table.SetCell(1, 0, tview.NewTableCell("  "+i18n.T("commands.loading")).SetTextColor(ColorMuted).SetSelectable(false))
+
+	output := tview.NewTextView().
+		SetDynamicColors(false).
+		SetScrollable(true).
+		SetWrap(false)
+	output.SetBorder(true).
+		SetTitle(" Output ").
+		SetBorderColor(ColorMuted)
+
+	footer := tview.NewTextView().
+		SetDynamicColors(true).
+		SetTextAlign(tview.AlignRight).
+		SetText(shortcutHints(
+			actionHint(actionBack, "shortcut.back"),
+			navigationHint(),
+			actionHint(actionSwitchPane, "shortcut.pane"),
+			actionHint(actionHelp, "shortcut.help")))
+
+	flex := tview.NewFlex().SetDirection(tview.FlexRow).
+		AddItem(title, 1, 0, false).
+		AddItem(createDivider(), 1, 0, false).
+		AddItem(table, 0, 1, true).
+		AddItem(output, 0, 1, false).
+		AddItem(footer, 1, 0, false)
+
+	var records []storage.CommandRecord
+	table.SetSelectionChangedFunc(func(row, column int) {
+		index := len(records) - row // newest first
+		if row < 1 || index < 0 || index >= len(records) {
+			return
+		}
+		record := records[index]
+		output.SetText(commandDetailText(record, i18n.T("commands.loading")))
+		go func() {
+			text, err := clusterManager.CommandOutput(record)
+			if err != nil {
+				text = err.Error()
+			}
+			app.QueueUpdateDraw(func() {
+				output.SetText(commandDetailText(record, text)).ScrollToBeginning()
+			})
+		}()
+	})
+
+	flex.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		switch action := keys.action(viewCommands, event); action {
+		case actionBack:
+			pages.RemovePage("commands")
+			pages.SwitchToPage("details")
+			return nil
+		case actionSwitchPane:
+			if table.HasFocus() {
+				app.SetFocus(output)
+			} else {
+				app.SetFocus(table)
+			}
+			return nil
+		case actionHelp:
+			showHelp(viewCommands)
+			return nil
+		case "":
+			return event
+		default:
+			return navigate(action)
+		}
+	})
+
+	pages.AddAndSwitchToPage("commands", flex, true)
+
+	go func() {
+		loaded, err := clusterManager.CommandHistory(c.Name, commandsViewLimit)
+		app.QueueUpdateDraw(func() {
+			table.RemoveRow(1)
+			if err != nil {
+				table.SetCell(1, 0, tview.NewTableCell("  "+err.Error()).SetTextColor(ColorDanger).SetSelectable(false))
+				return
+			}
+			if len(loaded) == 0 {
+				table.SetCell(1, 0, tview.NewTableCell("  "+i18n.T("commands.none")).SetTextColor(ColorMuted).SetSelectable(false))
+				return
+			}
+			records = loaded
+			for i := len(records) - 1; i >= 0; i-- {
+				record := records[i]
+				row := len(records) - i
+				statusColor := ColorSuccess
+				if record.Status != "Success" {
+					statusColor = ColorDanger
+				}
+				table.SetCell(row, 0, tview.NewTableCell("  "+record.Time.Local().Format("2006-01-02 15:04:05")).SetExpansion(1))
+				table.SetCell(row, 1, tview.NewTableCell(record.Purpose).SetExpansion(1))
+				table.SetCell(row, 2, tview.NewTableCell(record.Status).SetTextColor(statusColor).SetExpansion(1))
+				table.SetCell(row, 3, tview.NewTableCell(record.Duration.String()).SetExpansion(1))
+				table.SetCell(row, 4, tview.NewTableCell(strings.Join(record.Instances, ",")).SetExpansion(1))
+			}
+			table.Select(1, 0)
+		})
+	}()
+}
+
+// commandDetailText describes a command record followed by its output
+func commandDetailText(record storage.CommandRecord, output string) string {
+	var b strings.Builder
+	if record.CommandID != "" {
+		fmt.Fprintf(&b, "Command ID: %s\n", record.CommandID)
+	}
+	if record.Error != "" {
+		fmt.Fprintf(&b, "Error: %s\n", record.Error)
+	}
+	if record.OutputKey == "" {
+		b.WriteString("No output kept for this command\n")
+		return b.String()
+	}
+	b.WriteString("\n")
+	b.WriteString(output)
+	if record.OutputTruncated {
+		b.WriteString("\n... (output cut off)\n")
+	}
+	return b.String()
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/madhouselabs/goman/pkg/i18n"
+	"github.com/rivo/tview"
+)
+
+// credentialPromptOpen prevents stacking prompts while refreshes keep failing
+var credentialPromptOpen bool
+
+// promptCredentialRefresh asks the user to renew expired AWS credentials.
+// Must be called from the UI goroutine.
+func promptCredentialRefresh() {
+	if credentialPromptOpen {
+		return
+	}
+	credentialPromptOpen = true
+
+	modal := tview.NewModal().
+		SetText(dialogText("dialog.session_expired", i18n.T("dialog.session_expired.body"))).
+		AddButtons([]string{i18n.T("button.login"), i18n.T("button.later")}).
+		SetBackgroundColor(ColorBackground).
+		SetTextColor(ColorForeground).
+		SetButtonBackgroundColor(ColorBackground).
+		SetButtonTextColor(ColorForeground).
+		SetDoneFunc(func(buttonIndex int, buttonLabel string) {
+			pages.SwitchToPage("clusters")
+			pages.RemovePage("credentials")
+
+			if buttonLabel != i18n.T("button.login") {
+				credentialPromptOpen = false
+				return
+			}
+
+			// SSO login needs the terminal, so run it with the TUI suspended
+			var err error
+			app.Suspend(func() {
+				fmt.Print("\033[2J\033[H")
+				fmt.Println(i18n.T("dialog.refreshing_credentials"))
+
+				ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+				defer cancel()
+				err = clusterManager.RefreshCredentials(ctx)
+			})
+			credentialPromptOpen = false
+
+			if err != nil {
+				showError(i18n.Error("error.refresh_credentials", err))
+				return
+			}
+			statusText.SetText(" [green]" + i18n.T("status.credentials_refreshed") + "[::-]")
+			go refreshClustersAsync()
+		})
+
+	modal.SetBorder(false)
+	pages.AddAndSwitchToPage("credentials", modal, true)
+}
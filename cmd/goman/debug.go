@@ -0,0 +1,171 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/madhouselabs/goman/pkg/config"
+	"github.com/madhouselabs/goman/pkg/controller"
+	"github.com/madhouselabs/goman/pkg/i18n"
+	"github.com/madhouselabs/goman/pkg/provider"
+	"github.com/madhouselabs/goman/pkg/provider/debug"
+	"github.com/madhouselabs/goman/pkg/provider/registry"
+	"github.com/spf13/cobra"
+)
+
+var (
+	debugReconcilePhase       string
+	debugReconcileDryRun      bool
+	debugReconcileTrace       bool
+	debugReconcileSnapshotDir string
+)
+
+// debugCmd groups tools for debugging goman itself
+var debugCmd = &cobra.Command{
+	Use:   "debug",
+	Short: i18n.T("cmd.debug.short"),
+}
+
+// debugReconcileCmd runs one reconcile of a cluster on this machine
+var debugReconcileCmd = &cobra.Command{
+	Use:   "reconcile <cluster-name>",
+	Short: i18n.T("cmd.debug.reconcile.short"),
+	Long:  i18n.T("cmd.debug.reconcile.long"),
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		clusterName := resolveClusterAlias(args[0])
+		log.SetFlags(log.Ldate | log.Ltime | log.Lmicroseconds)
+
+		cfg, err := config.NewConfig()
+		if err != nil {
+			return fmt.Errorf("failed to load config: %w", err)
+		}
+		p, err := registry.GetConfiguredProvider(cfg.AWSProfile, cfg.AWSRegion)
+		if err != nil {
+			return fmt.Errorf("failed to get provider: %w", err)
+		}
+		wrapped := debug.Wrap(p, debug.Options{Trace: debugReconcileTrace, DryRun: debugReconcileDryRun})
+
+		ctx := context.Background()
+		reconciler, err := controller.NewReconciler(wrapped, "debug-"+clusterName)
+		if err != nil {
+			return err
+		}
+		if err := reconciler.SetSettings(controller.LoadSettings(ctx, wrapped.GetStorageService())); err != nil {
+			return fmt.Errorf("invalid controller settings: %w", err)
+		}
+		opts := controller.DebugOptions{Phase: debugReconcilePhase, DryRun: debugReconcileDryRun}
+		if err := reconciler.SetDebugOptions(opts); err != nil {
+			return err
+		}
+
+		var before map[string][]byte
+		if debugReconcileSnapshotDir != "" {
+			if before, err = snapshotCluster(ctx, wrapped.GetStorageService(), clusterName, filepath.Join(debugReconcileSnapshotDir, "before")); err != nil {
+				return err
+			}
+		}
+
+		mode := "live"
+		if debugReconcileDryRun {
+			mode = "dry run"
+		}
+		outf("🐞 Reconciling cluster %s in %s (%s)\n", clusterName, p.Region(), mode)
+		result, reconcileErr := reconciler.ReconcileClusterWithRequestID(ctx, clusterName, "debug")
+
+		if debugReconcileSnapshotDir != "" {
+			after, err := snapshotCluster(ctx, wrapped.GetStorageService(), clusterName, filepath.Join(debugReconcileSnapshotDir, "after"))
+			if err != nil {
+				return err
+			}
+			printSnapshotChanges(before, after, debugReconcileSnapshotDir)
+		}
+
+		if debugReconcileDryRun {
+			skipped := wrapped.Skipped()
+			outf("\nSkipped %d calls:\n", len(skipped))
+			for _, call := range skipped {
+				outf("  %s\n", call)
+			}
+		}
+
+		if reconcileErr != nil {
+			return fmt.Errorf("reconcile failed: %w", reconcileErr)
+		}
+		if result.Requeue {
+			outf("\n✅ Reconcile done, requeue after %s\n", result.RequeueAfter)
+		} else {
+			outln("\n✅ Reconcile done, no requeue")
+		}
+		return nil
+	},
+}
+
+// snapshotCluster saves the stored objects of a cluster into dir and returns
+// them by key
+func snapshotCluster(ctx context.Context, storage provider.StorageService, clusterName, dir string) (map[string][]byte, error) {
+	prefix := fmt.Sprintf("clusters/%s/", clusterName)
+	keys, err := storage.ListObjects(ctx, prefix)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list objects of cluster %s: %w", clusterName, err)
+	}
+	objects := make(map[string][]byte, len(keys))
+	for _, key := range keys {
+		data, err := storage.GetObject(ctx, key)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", key, err)
+		}
+		path := filepath.Join(dir, filepath.FromSlash(strings.TrimPrefix(key, prefix)))
+		if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+			return nil, err
+		}
+		if err := os.WriteFile(path, data, 0600); err != nil {
+			return nil, err
+		}
+		objects[strings.TrimPrefix(key, prefix)] = data
+	}
+	return objects, nil
+}
+
+// printSnapshotChanges lists the objects a reconcile wrote or deleted
+func printSnapshotChanges(before, after map[string][]byte, dir string) {
+	var changes []string
+	for name, data := range after {
+		old, ok := before[name]
+		switch {
+		case !ok:
+			changes = append(changes, "  + "+name)
+		case !bytes.Equal(old, data):
+			changes = append(changes, "  ~ "+name)
+		}
+	}
+	for name := range before {
+		if _, ok := after[name]; !ok {
+			changes = append(changes, "  - "+name)
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i][4:] < changes[j][4:] })
+
+	outf("\n📸 Snapshots saved in %s (before, after)\n", dir)
+	if len(changes) == 0 {
+		outln("No stored objects changed")
+		return
+	}
+	for _, change := range changes {
+		outln(change)
+	}
+}
+
+func init() {
+	debugReconcileCmd.Flags().StringVar(&debugReconcilePhase, "phase", "", "Run the step of this phase instead of the cluster's")
+	debugReconcileCmd.Flags().BoolVar(&debugReconcileDryRun, "dry-run", false, "Record changes instead of making them")
+	debugReconcileCmd.Flags().BoolVar(&debugReconcileTrace, "trace", false, "Log every cloud call")
+	debugReconcileCmd.Flags().StringVar(&debugReconcileSnapshotDir, "snapshot-dir", "", "Save the cluster's stored objects before and after the reconcile into this directory")
+	debugCmd.AddCommand(debugReconcileCmd)
+}
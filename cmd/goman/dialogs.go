@@ -3,12 +3,17 @@ package main
 import (
 	"context"
 	"fmt"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/gdamore/tcell/v2"
+	clusterpkg "github.com/madhouselabs/goman/pkg/cluster"
 	"github.com/madhouselabs/goman/pkg/config"
+	"github.com/madhouselabs/goman/pkg/i18n"
 	"github.com/madhouselabs/goman/pkg/models"
-	"github.com/madhouselabs/goman/pkg/provider/aws"
+	"github.com/madhouselabs/goman/pkg/provider/registry"
+	"github.com/madhouselabs/goman/pkg/storage"
 	"github.com/rivo/tview"
 	"gopkg.in/yaml.v3"
 )
@@ -16,62 +21,160 @@ import (
 // deleteCluster shows a confirmation dialog for cluster deletion
 // This preserves the exact original design from main.go
 func deleteCluster(cluster models.K3sCluster) {
-	// Confirmation modal with proper dark theme styling
+	if queueOfflineIntent(cluster, clusterpkg.IntentDelete) {
+		return
+	}
+
+	// Confirmation modal with an impact preview
+	confirmWithImpact("dialog.confirm_delete", i18n.T("dialog.confirm_delete.body", cluster.Name), i18n.T("button.delete"),
+		cluster, clusterpkg.DeleteImpact(cluster), func() {
+			// Delete in background
+			go func() {
+				err := clusterManager.DeleteCluster(cluster.ID)
+				app.QueueUpdateDraw(func() {
+					if err != nil {
+						errorModal := tview.NewModal().
+							SetText(errorText(i18n.Error("error.delete_cluster", err))).
+							AddButtons([]string{i18n.T("button.ok")}).
+							SetBackgroundColor(ColorBackground).
+							SetTextColor(ColorForeground).
+							SetButtonBackgroundColor(ColorBackground).
+							SetButtonTextColor(ColorForeground).
+							SetDoneFunc(func(buttonIndex int, buttonLabel string) {
+								pages.SwitchToPage("clusters")
+								pages.RemovePage("error")
+							})
+						errorModal.SetBorder(false)
+						pages.AddAndSwitchToPage("error", errorModal, true)
+					}
+					refreshClusters()
+				})
+			}()
+		}, nil)
+}
+
+// confirmWithImpact shows a confirmation dialog with an impact preview of a
+// destructive action: the instances terminated and the approximate cost
+// change right away, and the workloads on those instances once they have
+// been looked up on the cluster. onConfirm or onCancel runs after the
+// dialog closed; onCancel may be nil.
+func confirmWithImpact(titleKey, body, button string, cluster models.K3sCluster, impact *clusterpkg.Impact, onConfirm, onCancel func()) {
+	closed := false
 	modal := tview.NewModal().
-		SetText(fmt.Sprintf("[::b]Confirm Delete[::-]\n\nAre you sure you want to delete cluster '%s'?", cluster.Name)).
-		AddButtons([]string{"Delete", "Cancel"}).
+		AddButtons([]string{button, i18n.T("button.cancel")}).
 		SetBackgroundColor(ColorBackground).
 		SetTextColor(ColorForeground).
 		SetButtonBackgroundColor(ColorBackground).
 		SetButtonTextColor(ColorForeground).
 		SetDoneFunc(func(buttonIndex int, buttonLabel string) {
+			closed = true
 			// First switch back to clusters page, then remove the modal
 			pages.SwitchToPage("clusters")
 			pages.RemovePage("confirm")
-			
-			if buttonLabel == "Delete" {
-				// Delete in background
-				go func() {
-					err := clusterManager.DeleteCluster(cluster.ID)
-					app.QueueUpdateDraw(func() {
-						if err != nil {
-							errorModal := tview.NewModal().
-								SetText(fmt.Sprintf("[red][::b]Error[::-][white]\n\nError deleting cluster: %v", err)).
-								AddButtons([]string{"OK"}).
-								SetBackgroundColor(ColorBackground).
-								SetTextColor(ColorForeground).
-								SetButtonBackgroundColor(ColorBackground).
-								SetButtonTextColor(ColorForeground).
-								SetDoneFunc(func(buttonIndex int, buttonLabel string) {
-									pages.SwitchToPage("clusters")
-									pages.RemovePage("error")
-								})
-							errorModal.SetBorder(false)
-							pages.AddAndSwitchToPage("error", errorModal, true)
-						}
-						refreshClusters()
-					})
-				}()
+
+			if buttonLabel == button {
+				onConfirm()
+			} else if onCancel != nil {
+				onCancel()
 			}
 		})
-	
+
+	fetch := impact.Destructive() && cluster.Status == models.StatusRunning
+	modal.SetText(dialogText(titleKey, body+"\n\n"+impactText(impact, nil, nil, !fetch)))
+
 	// Remove border to avoid purple background
 	modal.SetBorder(false)
-	
 	pages.AddAndSwitchToPage("confirm", modal, true)
+
+	if fetch {
+		go func() {
+			workloads, err := clusterpkg.FetchWorkloads(cluster, impact.Nodes)
+			app.QueueUpdateDraw(func() {
+				if !closed {
+					modal.SetText(dialogText(titleKey, body+"\n\n"+impactText(impact, workloads, err, true)))
+				}
+			})
+		}()
+	}
+}
+
+// impactPreviewLines bounds the nodes and workloads listed in an impact preview
+const impactPreviewLines = 6
+
+// impactText renders an impact preview. Until loaded, the workloads are
+// reported as being looked up.
+func impactText(impact *clusterpkg.Impact, workloads map[string][]string, workloadsErr error, loaded bool) string {
+	var b strings.Builder
+	if impact.Destructive() {
+		b.WriteString(i18n.T("impact.terminated", len(impact.Nodes)))
+		for i, node := range impact.Nodes {
+			if i == impactPreviewLines {
+				b.WriteString("\n  " + i18n.T("impact.more", len(impact.Nodes)-i))
+				break
+			}
+			fmt.Fprintf(&b, "\n  %s (%s)", node.Name, node.InstanceType)
+		}
+		b.WriteString("\n\n")
+	}
+
+	b.WriteString(i18n.T("impact.cost", formatCostDelta(impact.MonthlyCostDelta)))
+	if len(impact.UnknownTypes) > 0 {
+		b.WriteString("\n" + i18n.T("impact.cost_unknown", strings.Join(impact.UnknownTypes, ", ")))
+	}
+
+	if !impact.Destructive() || (loaded && workloads == nil && workloadsErr == nil) {
+		return b.String()
+	}
+	b.WriteString("\n\n")
+	switch {
+	case !loaded:
+		b.WriteString(i18n.T("impact.workloads_loading"))
+	case workloadsErr != nil:
+		b.WriteString(i18n.T("impact.workloads_error", workloadsErr))
+	default:
+		var pods []string
+		for _, node := range impact.Nodes {
+			pods = append(pods, workloads[node.Name]...)
+		}
+		if len(pods) == 0 {
+			b.WriteString(i18n.T("impact.workloads_none"))
+			break
+		}
+		sort.Strings(pods)
+		b.WriteString(i18n.T("impact.workloads", len(pods)))
+		for i, pod := range pods {
+			if i == impactPreviewLines {
+				b.WriteString("\n  " + i18n.T("impact.more", len(pods)-i))
+				break
+			}
+			b.WriteString("\n  " + pod)
+		}
+	}
+	return b.String()
+}
+
+// formatCostDelta renders a monthly cost change in USD with its sign
+func formatCostDelta(delta float64) string {
+	if delta < 0 {
+		return fmt.Sprintf("-$%.2f", -delta)
+	}
+	return fmt.Sprintf("+$%.2f", delta)
 }
 
 // stopCluster stops a running cluster
 func stopCluster(cluster models.K3sCluster) {
 	if cluster.Status != "running" {
-		showError(fmt.Sprintf("Cannot stop cluster '%s' - it is not running (status: %s)", cluster.Name, cluster.Status))
+		showError(i18n.T("error.cannot_stop", cluster.Name, cluster.Status))
+		return
+	}
+	if queueOfflineIntent(cluster, clusterpkg.IntentStop) {
 		return
 	}
 	
 	// Confirmation modal
 	modal := tview.NewModal().
-		SetText(fmt.Sprintf("[::b]Confirm Stop[::-]\n\nStop cluster '%s'?\nThis will stop all EC2 instances.", cluster.Name)).
-		AddButtons([]string{"Stop", "Cancel"}).
+		SetText(dialogText("dialog.confirm_stop", i18n.T("dialog.confirm_stop.body", cluster.Name))).
+		AddButtons([]string{i18n.T("button.stop"), i18n.T("button.cancel")}).
 		SetBackgroundColor(ColorBackground).
 		SetTextColor(ColorForeground).
 		SetButtonBackgroundColor(ColorBackground).
@@ -80,13 +183,13 @@ func stopCluster(cluster models.K3sCluster) {
 			pages.SwitchToPage("clusters")
 			pages.RemovePage("confirm")
 			
-			if buttonLabel == "Stop" {
+			if buttonLabel == i18n.T("button.stop") {
 				// Stop in background
 				go func() {
 					err := clusterManager.StopCluster(cluster.Name)
 					app.QueueUpdateDraw(func() {
 						if err != nil {
-							showError(fmt.Sprintf("Error stopping cluster: %v", err))
+							showError(i18n.Error("error.stop_cluster", err))
 						}
 						refreshClusters()
 					})
@@ -101,14 +204,17 @@ func stopCluster(cluster models.K3sCluster) {
 // startCluster starts a stopped cluster
 func startCluster(cluster models.K3sCluster) {
 	if cluster.Status != "stopped" {
-		showError(fmt.Sprintf("Cannot start cluster '%s' - it is not stopped (status: %s)", cluster.Name, cluster.Status))
+		showError(i18n.T("error.cannot_start", cluster.Name, cluster.Status))
+		return
+	}
+	if queueOfflineIntent(cluster, clusterpkg.IntentStart) {
 		return
 	}
 	
 	// Confirmation modal
 	modal := tview.NewModal().
-		SetText(fmt.Sprintf("[::b]Confirm Start[::-]\n\nStart cluster '%s'?\nThis will start all EC2 instances.", cluster.Name)).
-		AddButtons([]string{"Start", "Cancel"}).
+		SetText(dialogText("dialog.confirm_start", i18n.T("dialog.confirm_start.body", cluster.Name))).
+		AddButtons([]string{i18n.T("button.start"), i18n.T("button.cancel")}).
 		SetBackgroundColor(ColorBackground).
 		SetTextColor(ColorForeground).
 		SetButtonBackgroundColor(ColorBackground).
@@ -117,13 +223,13 @@ func startCluster(cluster models.K3sCluster) {
 			pages.SwitchToPage("clusters")
 			pages.RemovePage("confirm")
 			
-			if buttonLabel == "Start" {
+			if buttonLabel == i18n.T("button.start") {
 				// Start in background
 				go func() {
 					err := clusterManager.StartCluster(cluster.Name)
 					app.QueueUpdateDraw(func() {
 						if err != nil {
-							showError(fmt.Sprintf("Error starting cluster: %v", err))
+							showError(i18n.Error("error.start_cluster", err))
 						}
 						refreshClusters()
 					})
@@ -135,11 +241,21 @@ func startCluster(cluster models.K3sCluster) {
 	pages.AddAndSwitchToPage("confirm", modal, true)
 }
 
+// dialogText formats a modal text with a bold localized title
+func dialogText(titleKey, body string) string {
+	return fmt.Sprintf("[::b]%s[::-]\n\n%s", i18n.T(titleKey), body)
+}
+
+// errorText formats an error modal text with a red localized title
+func errorText(message string) string {
+	return fmt.Sprintf("[red][::b]%s[::-][white]\n\n%s", i18n.T("dialog.error"), message)
+}
+
 // showError displays an error modal
 func showError(message string) {
 	modal := tview.NewModal().
-		SetText(fmt.Sprintf("[red][::b]Error[::-][white]\n\n%s", message)).
-		AddButtons([]string{"OK"}).
+		SetText(errorText(message)).
+		AddButtons([]string{i18n.T("button.ok")}).
 		SetBackgroundColor(ColorBackground).
 		SetTextColor(ColorForeground).
 		SetButtonBackgroundColor(ColorBackground).
@@ -155,8 +271,8 @@ func showError(message string) {
 // showProgressModal displays a progress modal with a message
 func showProgressModal(message string) {
 	modal := tview.NewModal().
-		SetText(fmt.Sprintf("[::b]Progress[::-]\n\n%s", message)).
-		AddButtons([]string{"OK"}).
+		SetText(dialogText("dialog.progress", message)).
+		AddButtons([]string{i18n.T("button.ok")}).
 		SetBackgroundColor(ColorBackground).
 		SetTextColor(ColorForeground).
 		SetButtonBackgroundColor(ColorBackground).
@@ -166,8 +282,8 @@ func showProgressModal(message string) {
 	pages.AddAndSwitchToPage("progress", modal, false)
 }
 
-// createNewClusterWithUI handles the UI flow for cluster creation
-func createNewClusterWithUI(name, description, mode, region, instanceType, nodeCountStr string, showUI bool) {
+// newClusterModel builds the cluster object for a create request
+func newClusterModel(name, description, mode, region, preset, instanceType, nodeCountStr string) *models.K3sCluster {
 	// Parse node count
 	nodeCount := 1
 	fmt.Sscanf(nodeCountStr, "%d", &nodeCount)
@@ -185,6 +301,7 @@ func createNewClusterWithUI(name, description, mode, region, instanceType, nodeC
 		Mode:         clusterMode,
 		Region:       region,
 		InstanceType: instanceType,
+		Preset:       preset,
 		Status:       "pending",
 	}
 
@@ -201,62 +318,15 @@ func createNewClusterWithUI(name, description, mode, region, instanceType, nodeC
 		}}
 	}
 
-	if !showUI {
-		// Called from editor, create synchronously
-		_, err := clusterManager.CreateCluster(*cluster)
-		if err != nil {
-			// Log the error so we can debug
-			fmt.Printf("\n[ERROR] Failed to create cluster: %v\n", err)
-			fmt.Printf("Press Enter to continue...")
-			fmt.Scanln()
-		}
-		return
-	}
-
-	// Show progress modal (when called from UI)
-	modal := tview.NewModal().
-		SetText(fmt.Sprintf("[::b]Creating Cluster[::-]\n\nCreating cluster '%s'...", name)).
-		AddButtons([]string{"OK"}).
-		SetBackgroundColor(ColorBackground).
-		SetTextColor(ColorForeground).
-		SetButtonBackgroundColor(ColorBackground).
-		SetButtonTextColor(ColorForeground)
-
-	modal.SetBorder(false)
-	pages.AddAndSwitchToPage("progress", modal, false)
-
-	// Create cluster in background
-	go func() {
-		_, err := clusterManager.CreateCluster(*cluster)
-		app.QueueUpdateDraw(func() {
-			pages.RemovePage("progress")
-			if err != nil {
-				errorModal := tview.NewModal().
-					SetText(fmt.Sprintf("[red][::b]Error[::-][white]\n\nError creating cluster: %v", err)).
-					AddButtons([]string{"OK"}).
-					SetBackgroundColor(ColorBackground).
-					SetTextColor(ColorForeground).
-					SetButtonBackgroundColor(ColorBackground).
-					SetButtonTextColor(ColorForeground).
-					SetDoneFunc(func(buttonIndex int, buttonLabel string) {
-						pages.RemovePage("error")
-						refreshClusters()
-					})
-				errorModal.SetBorder(false)
-				pages.AddAndSwitchToPage("error", errorModal, false)
-			} else {
-				refreshClusters()
-			}
-		})
-	}()
+	return cluster
 }
 
 // triggerReconciliation manually triggers reconciliation for a cluster
 func triggerReconciliation(cluster models.K3sCluster) {
 	// Show confirmation modal
 	modal := tview.NewModal().
-		SetText(fmt.Sprintf("[::b]Trigger Reconciliation[::-]\n\nTrigger reconciliation for cluster '%s'?\n\nThis will force the Lambda controller to re-process the cluster.", cluster.Name)).
-		AddButtons([]string{"Trigger", "Cancel"}).
+		SetText(dialogText("dialog.reconcile", i18n.T("dialog.reconcile.body", cluster.Name))).
+		AddButtons([]string{i18n.T("button.trigger"), i18n.T("button.cancel")}).
 		SetBackgroundColor(ColorBackground).
 		SetTextColor(ColorForeground).
 		SetButtonBackgroundColor(ColorBackground).
@@ -267,7 +337,7 @@ func triggerReconciliation(cluster models.K3sCluster) {
 
 	modal.SetDoneFunc(func(buttonIndex int, buttonLabel string) {
 		pages.RemovePage("reconcile")
-		if buttonLabel == "Trigger" {
+		if buttonLabel == i18n.T("button.trigger") {
 			go triggerClusterReconciliation(cluster.Name)
 		}
 	})
@@ -279,8 +349,8 @@ func triggerReconciliation(cluster models.K3sCluster) {
 func triggerClusterReconciliation(clusterName string) {
 	// Show progress modal
 	modal := tview.NewModal().
-		SetText(fmt.Sprintf("[::b]Triggering Reconciliation[::-]\n\nTriggering reconciliation for cluster '%s'...", clusterName)).
-		AddButtons([]string{"OK"}).
+		SetText(dialogText("dialog.reconciling", i18n.T("dialog.reconciling.body", clusterName))).
+		AddButtons([]string{i18n.T("button.ok")}).
 		SetBackgroundColor(ColorBackground).
 		SetTextColor(ColorForeground).
 		SetButtonBackgroundColor(ColorBackground).
@@ -299,8 +369,8 @@ func triggerClusterReconciliation(clusterName string) {
 		pages.RemovePage("progress")
 		if err != nil {
 			errorModal := tview.NewModal().
-				SetText(fmt.Sprintf("[red][::b]Error[::-][white]\n\nFailed to trigger reconciliation: %v", err)).
-				AddButtons([]string{"OK"}).
+				SetText(errorText(i18n.Error("error.trigger_reconcile", err))).
+				AddButtons([]string{i18n.T("button.ok")}).
 				SetBackgroundColor(ColorBackground).
 				SetTextColor(ColorForeground).
 				SetButtonBackgroundColor(ColorBackground).
@@ -316,8 +386,8 @@ func triggerClusterReconciliation(clusterName string) {
 			pages.AddAndSwitchToPage("error", errorModal, false)
 		} else {
 			successModal := tview.NewModal().
-				SetText(fmt.Sprintf("[green][::b]Success[::-][white]\n\nReconciliation triggered for cluster '%s'.\n\nCheck the cluster status for progress.", clusterName)).
-				AddButtons([]string{"OK"}).
+				SetText(fmt.Sprintf("[green][::b]%s[::-][white]\n\n%s", i18n.T("dialog.success"), i18n.T("dialog.reconciled.body", clusterName))).
+				AddButtons([]string{i18n.T("button.ok")}).
 				SetBackgroundColor(ColorBackground).
 				SetTextColor(ColorForeground).
 				SetButtonBackgroundColor(ColorBackground).
@@ -340,19 +410,19 @@ func triggerClusterReconciliation(clusterName string) {
 func updateClusterConfigForReconciliation(clusterName string) error {
 	ctx := context.Background()
 	
-	// Get AWS provider
+	// Get the configured provider
 	profile := config.GetAWSProfile()
 	region := config.GetAWSRegion()
-	provider, err := aws.GetCachedProvider(profile, region)
+	provider, err := registry.GetConfiguredProvider(profile, region)
 	if err != nil {
-		return fmt.Errorf("failed to get AWS provider: %w", err)
+		return fmt.Errorf("failed to get provider: %w", err)
 	}
 	
 	// Get storage service
 	storageService := provider.GetStorageService()
 	
 	// Read current config
-	configKey := fmt.Sprintf("clusters/%s/config.yaml", clusterName)
+	configKey := storage.ClusterConfigKey(clusterName)
 	configData, err := storageService.GetObject(ctx, configKey)
 	if err != nil {
 		return fmt.Errorf("failed to read cluster config: %w", err)
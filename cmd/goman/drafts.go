@@ -0,0 +1,208 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/madhouselabs/goman/pkg/i18n"
+	"github.com/rivo/tview"
+)
+
+// Draft kinds, the prefix of a draft's file name
+const (
+	draftCreate = "create"
+	draftEdit   = "edit"
+	draftNotes  = "notes"
+)
+
+// draftHeaderPrefix starts the comment added to resumed drafts; editYAML
+// strips it again before applying
+const draftHeaderPrefix = "# Resumed unsaved draft"
+
+// draftsPrompted is set once drafts were offered in this session, after the
+// first successful refresh so edit drafts can be matched to their clusters
+var draftsPrompted bool
+
+// formDraft is an editor form that was closed before it could be applied,
+// e.g. because the terminal went away or the content didn't validate
+type formDraft struct {
+	Name    string // <kind>-<cluster>, also the file name without .yaml
+	Kind    string
+	Cluster string
+	SavedAt time.Time
+	Content string
+}
+
+// draftsDir returns ~/.goman/drafts
+func draftsDir() string {
+	homeDir, _ := os.UserHomeDir()
+	return filepath.Join(homeDir, ".goman", "drafts")
+}
+
+// draftName returns the draft name of a form
+func draftName(kind, clusterName string) string {
+	return kind + "-" + clusterName
+}
+
+// saveDraft stores the content of a form. Drafts may hold cluster specs,
+// so they are private to the user.
+func saveDraft(name, content string) error {
+	if err := os.MkdirAll(draftsDir(), 0700); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filepath.Join(draftsDir(), name+".yaml"), []byte(content), 0600)
+}
+
+// removeDraft deletes a draft once it was applied or discarded
+func removeDraft(name string) {
+	os.Remove(filepath.Join(draftsDir(), name+".yaml"))
+}
+
+// loadDraft returns a stored draft by name
+func loadDraft(name string) (formDraft, bool) {
+	path := filepath.Join(draftsDir(), name+".yaml")
+	info, err := os.Stat(path)
+	if err != nil {
+		return formDraft{}, false
+	}
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return formDraft{}, false
+	}
+
+	d := formDraft{Name: name, SavedAt: info.ModTime()}
+	for _, kind := range []string{draftCreate, draftEdit, draftNotes} {
+		if strings.HasPrefix(name, kind+"-") {
+			d.Kind = kind
+			d.Cluster = strings.TrimPrefix(name, kind+"-")
+		}
+	}
+	if d.Kind == "" {
+		return formDraft{}, false
+	}
+
+	d.Content = fmt.Sprintf("%s from %s\n%s", draftHeaderPrefix, d.SavedAt.Format("2006-01-02 15:04"),
+		cleanEditorContent(string(data)))
+	return d, true
+}
+
+// loadDrafts returns all stored drafts, oldest first
+func loadDrafts() []formDraft {
+	entries, err := ioutil.ReadDir(draftsDir())
+	if err != nil {
+		return nil
+	}
+	var drafts []formDraft
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".yaml") {
+			continue
+		}
+		if d, ok := loadDraft(strings.TrimSuffix(entry.Name(), ".yaml")); ok {
+			drafts = append(drafts, d)
+		}
+	}
+	sort.Slice(drafts, func(i, j int) bool {
+		return drafts[i].SavedAt.Before(drafts[j].SavedAt)
+	})
+	return drafts
+}
+
+// watchEditorSignals keeps the editor file as a draft if goman is hung up or
+// terminated while the editor is open, then exits. Nothing is kept if the
+// file was never saved. Call the returned function to stop watching.
+func watchEditorSignals(path, draft string) func() {
+	var written time.Time
+	if info, err := os.Stat(path); err == nil {
+		written = info.ModTime()
+	}
+
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, syscall.SIGHUP, syscall.SIGTERM)
+	done := make(chan struct{})
+
+	go func() {
+		select {
+		case <-sigCh:
+			if info, err := os.Stat(path); err == nil && !info.ModTime().Equal(written) {
+				if data, err := ioutil.ReadFile(path); err == nil {
+					saveDraft(draft, cleanEditorContent(string(data)))
+				}
+			}
+			os.Remove(path)
+			os.Exit(1)
+		case <-done:
+		}
+	}()
+
+	return func() {
+		signal.Stop(sigCh)
+		close(done)
+	}
+}
+
+// promptFormDrafts offers to reopen forms left unsaved by an earlier
+// session, one at a time. "Later" keeps the remaining drafts for the next
+// launch. Must be called from the UI goroutine.
+func promptFormDrafts() {
+	drafts := loadDrafts()
+	if len(drafts) == 0 {
+		return
+	}
+	d := drafts[0]
+
+	modal := tview.NewModal().
+		SetText(dialogText("dialog.draft", i18n.T("dialog.draft.body",
+			i18n.T("draft."+d.Kind), d.Cluster, d.SavedAt.Format("2006-01-02 15:04")))).
+		AddButtons([]string{i18n.T("button.resume"), i18n.T("button.discard"), i18n.T("button.later")}).
+		SetBackgroundColor(ColorBackground).
+		SetTextColor(ColorForeground).
+		SetButtonBackgroundColor(ColorBackground).
+		SetButtonTextColor(ColorForeground).
+		SetDoneFunc(func(buttonIndex int, buttonLabel string) {
+			pages.SwitchToPage("clusters")
+			pages.RemovePage("draft")
+
+			switch buttonLabel {
+			case i18n.T("button.resume"):
+				resumeFormDraft(d)
+			case i18n.T("button.discard"):
+				removeDraft(d.Name)
+				promptFormDrafts()
+			}
+		})
+
+	modal.SetBorder(false)
+	pages.AddAndSwitchToPage("draft", modal, true)
+}
+
+// resumeFormDraft reopens a draft in the form it was saved from
+func resumeFormDraft(d formDraft) {
+	switch d.Kind {
+	case draftCreate:
+		openClusterEditorWith(d.Content, d.Name)
+	case draftEdit:
+		// editCluster picks up the stored draft itself
+		c, err := findCluster(d.Cluster)
+		if err != nil {
+			removeDraft(d.Name)
+			showError(i18n.T("error.draft_cluster_gone", d.Cluster))
+			return
+		}
+		editCluster(*c)
+	case draftNotes:
+		c, err := findCluster(d.Cluster)
+		if err != nil {
+			removeDraft(d.Name)
+			showError(i18n.T("error.draft_cluster_gone", d.Cluster))
+			return
+		}
+		editNotes(*c)
+	}
+}
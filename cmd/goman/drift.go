@@ -0,0 +1,110 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/madhouselabs/goman/pkg/cluster"
+	"github.com/madhouselabs/goman/pkg/config"
+	"github.com/madhouselabs/goman/pkg/controller"
+	"github.com/madhouselabs/goman/pkg/i18n"
+	"github.com/madhouselabs/goman/pkg/models"
+	"github.com/madhouselabs/goman/pkg/provider/registry"
+	"github.com/spf13/cobra"
+)
+
+var (
+	clusterDriftFix  bool
+	clusterDriftJSON bool
+)
+
+// clusterDriftCmd compares a cluster with its cloud resources
+var clusterDriftCmd = &cobra.Command{
+	Use:   "drift <cluster-name>",
+	Short: i18n.T("cmd.cluster.drift.short"),
+	Long:  i18n.T("cmd.cluster.drift.long"),
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		clusterName := resolveClusterAlias(args[0])
+
+		cfg, err := config.NewConfig()
+		if err != nil {
+			return fmt.Errorf("failed to load config: %w", err)
+		}
+		p, err := registry.GetConfiguredProvider(cfg.AWSProfile, cfg.AWSRegion)
+		if err != nil {
+			return fmt.Errorf("failed to get provider: %w", err)
+		}
+		reconciler, err := controller.NewReconciler(p, "goman-drift")
+		if err != nil {
+			return err
+		}
+		drift, err := reconciler.DetectDrift(context.Background(), clusterName)
+		if err != nil {
+			return fmt.Errorf("failed to check drift: %w", err)
+		}
+
+		if clusterDriftJSON {
+			if drift == nil {
+				drift = []models.DriftStatus{}
+			}
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			if err := enc.Encode(drift); err != nil {
+				return err
+			}
+		} else if err := printDrift(clusterName, drift); err != nil {
+			return err
+		}
+
+		if !clusterDriftFix || len(drift) == 0 {
+			return nil
+		}
+		if clusterManager == nil {
+			clusterManager = cluster.NewManager()
+		}
+		if err := clusterManager.RequestDriftFix(clusterName); err != nil {
+			return fmt.Errorf("failed to request drift fix: %w", err)
+		}
+		if !clusterDriftJSON {
+			outf("\n🔧 Drift fix requested for cluster %s\n", clusterName)
+			outln("💡 Use 'goman cluster events " + clusterName + "' to follow the revert")
+		}
+		return nil
+	},
+}
+
+// printDrift prints the drift of a cluster as a table
+func printDrift(clusterName string, drift []models.DriftStatus) error {
+	if len(drift) == 0 {
+		outf("✅ No drift: cluster %s matches its spec\n", clusterName)
+		return nil
+	}
+
+	outf("Drift of cluster %s:\n\n", clusterName)
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NODE\tID\tFIELD\tEXPECTED\tACTUAL\tPOLICY\tCHANGED BY")
+	for _, d := range drift {
+		changedBy := d.ChangedBy
+		if changedBy == "" {
+			changedBy = "-"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n", d.Node, d.InstanceID, d.Field, d.Expected, d.Actual, d.Policy, changedBy)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	if !clusterDriftFix {
+		outln("\n💡 Run with --fix to revert it")
+	}
+	return nil
+}
+
+func init() {
+	clusterDriftCmd.Flags().BoolVar(&clusterDriftFix, "fix", false, "Revert all drift once, whatever the drift policy")
+	clusterDriftCmd.Flags().BoolVar(&clusterDriftJSON, "json", false, "Print the drift as JSON")
+	clusterCmd.AddCommand(clusterDriftCmd)
+}
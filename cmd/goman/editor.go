@@ -1,61 +1,201 @@
 package main
 
 import (
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"os"
 	"os/exec"
+	"sort"
 	"strings"
 	"time"
 
+	clusterpkg "github.com/madhouselabs/goman/pkg/cluster"
+	"github.com/madhouselabs/goman/pkg/i18n"
 	"github.com/madhouselabs/goman/pkg/models"
 	"gopkg.in/yaml.v2"
 )
 
+// errEditorUnsaved is returned by editYAML when the user exits without saving
+var errEditorUnsaved = errors.New("editor closed without saving")
+
+// holdDestructiveEdits makes edits that terminate nodes return a heldEdit
+// instead of being saved, so the TUI can show their impact first
+var holdDestructiveEdits bool
+
+// heldEdit is an edit that terminates nodes, waiting for confirmation
+type heldEdit struct {
+	cluster models.K3sCluster
+	impact  *clusterpkg.Impact
+	content string // Edited form, kept as a draft if the edit is cancelled
+}
+
+func (h *heldEdit) Error() string {
+	return fmt.Sprintf("changes to %s terminate %d instance(s)", h.cluster.Name, len(h.impact.Nodes))
+}
+
 // editCluster opens vim editor to edit a cluster configuration
 func editCluster(cluster models.K3sCluster) {
+	// Spec changes are rejected once deletion has been requested
+	if cluster.Status == models.StatusDeleting {
+		statusText.SetText(fmt.Sprintf(" %s%s%s", TagDanger, i18n.T("status.edit_deleting", cluster.Name), TagReset))
+		return
+	}
+
 	// Show loading message before suspending
-	statusText.SetText(fmt.Sprintf(" %sOpening editor...%s", TagWarning, TagReset))
+	statusText.SetText(fmt.Sprintf(" %s%s%s", TagWarning, i18n.T("status.opening_editor"), TagReset))
 	app.ForceDraw()
 	
 	// Small delay for visual smoothness
 	time.Sleep(100 * time.Millisecond)
 	
+	// Set when the cluster turned out to be deleting while it was edited
+	var deletedErr error
+	// Set when the edit terminates nodes and waits for confirmation
+	var held *heldEdit
+	
 	// Suspend the TUI application temporarily
 	app.Suspend(func() {
 		// Clear and reset terminal for a clean editor experience
 		fmt.Print("\033[2J\033[H\033[?47l")
-		// Build nodepools YAML section
-		var nodePoolsYAML string
-		if len(cluster.NodePools) > 0 {
-			nodePoolsYAML = "nodePools:\n"
-			for _, np := range cluster.NodePools {
-				nodePoolsYAML += fmt.Sprintf("  - name: %s\n", np.Name)
-				nodePoolsYAML += fmt.Sprintf("    count: %d\n", np.Count)
-				nodePoolsYAML += fmt.Sprintf("    instanceType: %s\n", np.InstanceType)
-				
-				if len(np.Labels) > 0 {
-					nodePoolsYAML += "    labels:\n"
-					for k, v := range np.Labels {
-						nodePoolsYAML += fmt.Sprintf("      %s: %s\n", k, v)
+
+		holdDestructiveEdits = true
+		err := editClusterInEditor(cluster)
+		holdDestructiveEdits = false
+		if isClusterGoneError(err) {
+			deletedErr = err
+		}
+		errors.As(err, &held)
+		
+		// Restore terminal state before returning to TUI
+		fmt.Print("\033[?47h\033[2J\033[H")
+		time.Sleep(50 * time.Millisecond)
+	})
+	
+	// Restore status after returning
+	if deletedErr != nil {
+		statusText.SetText(fmt.Sprintf(" %s%s%s", TagDanger, deletedErr.Error(), TagReset))
+	} else if held != nil {
+		statusText.SetText(" [green]" + i18n.T("status.connected_short") + "[::-]")
+		confirmHeldEdit(held)
+	} else {
+		statusText.SetText(" [green]" + i18n.T("status.connected_short") + "[::-]")
+	}
+	
+	// The TUI will automatically resume after Suspend function completes
+	// Refresh the cluster list to show any updates
+	go refreshClustersAsync()
+}
+
+// editClusterInEditor lets the user edit a cluster in $EDITOR and applies the
+// result. Shared by the TUI and 'goman cluster edit'. An unsaved draft of an
+// earlier edit of the cluster is reopened instead of the current spec.
+func editClusterInEditor(cluster models.K3sCluster) error {
+	name := draftName(draftEdit, cluster.Name)
+	initial := clusterEditTemplate(cluster)
+	if d, ok := loadDraft(name); ok {
+		initial = d.Content
+	}
+	return editYAML(initial, fmt.Sprintf("goman-cluster-%s-*.yaml", cluster.Name), name, func(content string) error {
+		err := validateAndUpdateClusterFromEditor(cluster, content)
+		if isClusterGoneError(err) {
+			// The cluster was deleted while editing; retrying cannot succeed
+			return &editorAbort{err: err}
+		}
+		var held *heldEdit
+		if errors.As(err, &held) {
+			held.content = content
+			return &editorAbort{err: held}
+		}
+		return err
+	})
+}
+
+// confirmHeldEdit shows the impact of an edit that terminates nodes and
+// saves it once confirmed. A cancelled edit is kept as a draft, so reopening
+// the editor resumes it.
+func confirmHeldEdit(held *heldEdit) {
+	name := held.cluster.Name
+	confirmWithImpact("dialog.confirm_edit", i18n.T("dialog.confirm_edit.body", name), i18n.T("button.save"),
+		held.cluster, held.impact, func() {
+			go func() {
+				_, err := clusterManager.UpdateCluster(held.cluster)
+				app.QueueUpdateDraw(func() {
+					if err != nil {
+						showError(err.Error())
 					}
+					refreshClusters()
+				})
+			}()
+		}, func() {
+			saveDraft(draftName(draftEdit, name), held.content)
+			statusText.SetText(fmt.Sprintf(" %s%s%s", TagWarning, i18n.T("status.edit_kept_draft", name), TagReset))
+		})
+}
+
+// isClusterGoneError reports whether err means the cluster is deleting or deleted
+func isClusterGoneError(err error) bool {
+	return errors.Is(err, clusterpkg.ErrClusterDeleting) || errors.Is(err, clusterpkg.ErrClusterDeleted)
+}
+
+// clusterEditTemplate renders the editable fields of a cluster as YAML
+func clusterEditTemplate(cluster models.K3sCluster) string {
+	// Build nodepools YAML section
+	var nodePoolsYAML string
+	if len(cluster.NodePools) > 0 {
+		nodePoolsYAML = "nodePools:\n"
+		for _, np := range cluster.NodePools {
+			nodePoolsYAML += fmt.Sprintf("  - name: %s\n", np.Name)
+			nodePoolsYAML += fmt.Sprintf("    count: %d\n", np.Count)
+			nodePoolsYAML += fmt.Sprintf("    instanceType: %s\n", np.InstanceType)
+			if np.InstanceStore {
+				nodePoolsYAML += "    instanceStore: true\n"
+			}
+			if np.Paused {
+				nodePoolsYAML += "    paused: true\n"
+			}
+			if np.ScaleDownBehavior != "" {
+				nodePoolsYAML += fmt.Sprintf("    scaleDownBehavior: %s\n", np.ScaleDownBehavior)
+			}
+			nodePoolsYAML += rootVolumeYAML(np.RootVolume, "    ")
+			nodePoolsYAML += userDataYAML(np.UserData, "    ")
+			if len(np.AvailabilityZones) > 0 {
+				nodePoolsYAML += fmt.Sprintf("    availabilityZones: [%s]\n", strings.Join(np.AvailabilityZones, ", "))
+			}
+			
+			if len(np.Labels) > 0 {
+				nodePoolsYAML += "    labels:\n"
+				for k, v := range np.Labels {
+					nodePoolsYAML += fmt.Sprintf("      %s: %s\n", k, v)
 				}
-				
-				if len(np.Taints) > 0 {
-					nodePoolsYAML += "    taints:\n"
-					for _, t := range np.Taints {
-						nodePoolsYAML += fmt.Sprintf("      - key: %s\n", t.Key)
-						nodePoolsYAML += fmt.Sprintf("        value: \"%s\"\n", t.Value)
-						nodePoolsYAML += fmt.Sprintf("        effect: %s\n", t.Effect)
-					}
+			}
+			
+			if len(np.Taints) > 0 {
+				nodePoolsYAML += "    taints:\n"
+				for _, t := range np.Taints {
+					nodePoolsYAML += fmt.Sprintf("      - key: %s\n", t.Key)
+					nodePoolsYAML += fmt.Sprintf("        value: \"%s\"\n", t.Value)
+					nodePoolsYAML += fmt.Sprintf("        effect: %s\n", t.Effect)
+				}
+			}
+
+			if len(np.Volumes) > 0 {
+				nodePoolsYAML += "    volumes:\n"
+				for _, v := range np.Volumes {
+					nodePoolsYAML += fmt.Sprintf("      - size: %d\n", v.Size)
+					nodePoolsYAML += fmt.Sprintf("        type: %s\n", v.VolumeType())
+					nodePoolsYAML += fmt.Sprintf("        mountPoint: %s\n", v.MountPoint)
+					nodePoolsYAML += fmt.Sprintf("        filesystem: %s\n", v.FilesystemType())
 				}
 			}
-			// Add examples as comments even when nodepools exist
-			nodePoolsYAML += `
+		}
+		// Add examples as comments even when nodepools exist
+		nodePoolsYAML += `
 # Additional example configurations:
 #   - name: compute-intensive
 #     count: 1
 #     instanceType: t3.xlarge
+#     availabilityZones: [us-east-1a, us-east-1b]  # Spread evenly (default: one zone)
 #     labels:
 #       workload: compute
 #       tier: processing
@@ -68,9 +208,30 @@ func editCluster(cluster models.K3sCluster) {
 #     taints:
 #       - key: nvidia.com/gpu
 #         value: "true"
-#         effect: NoSchedule`
-		} else {
-			nodePoolsYAML = `nodePools: []
+#         effect: NoSchedule
+#   - name: storage
+#     count: 2
+#     instanceType: t3.large
+#     volumes:            # Attached to new nodes, deleted with the node
+#       - size: 100       # GiB
+#         type: gp3       # gp3, gp2, io1, io2, st1 or sc1
+#         mountPoint: /mnt/data
+#         filesystem: ext4 # ext4 or xfs
+#     rootVolume:         # Boot volume of the pool's nodes (default: the cluster's)
+#       size: 200
+#       type: io2
+#       iops: 10000
+#   - name: ci
+#     count: 2
+#     instanceType: m5d.xlarge
+#     instanceStore: true  # Local NVMe for images and emptyDir (i3, m5d, c6gd, ...)
+#     paused: true         # Keep the nodes as they are: no scaling, replacement or drift revert
+#     scaleDownBehavior: stop  # Stop removed workers and start them on the next scale-up (default: terminate)
+#     userData: |          # Script (#!) or #cloud-config run at each node's first boot, up to 8 KiB
+#       #!/bin/bash
+#       curl -fsSL https://example.com/install-agent.sh | bash`
+	} else {
+		nodePoolsYAML = `nodePools: []
 # Example configurations (remove the # to activate):
 #   - name: general
 #     count: 2
@@ -81,6 +242,7 @@ func editCluster(cluster models.K3sCluster) {
 #   - name: compute-intensive
 #     count: 1
 #     instanceType: t3.xlarge
+#     availabilityZones: [us-east-1a, us-east-1b]  # Spread evenly (default: one zone)
 #     labels:
 #       workload: compute
 #       tier: processing
@@ -93,135 +255,110 @@ func editCluster(cluster models.K3sCluster) {
 #     taints:
 #       - key: nvidia.com/gpu
 #         value: "true"
-#         effect: NoSchedule`
-		}
-		
-		// Convert cluster to YAML format for editing - only show editable fields
-		yamlContent := fmt.Sprintf(`# Editing: %s
-# Mode: %s | Status: %s | Created: %s
+#         effect: NoSchedule
+#   - name: storage
+#     count: 2
+#     instanceType: t3.large
+#     volumes:            # Attached to new nodes, deleted with the node
+#       - size: 100       # GiB
+#         type: gp3       # gp3, gp2, io1, io2, st1 or sc1
+#         mountPoint: /mnt/data
+#         filesystem: ext4 # ext4 or xfs
+#     rootVolume:         # Boot volume of the pool's nodes (default: the cluster's)
+#       size: 200
+#       type: io2
+#       iops: 10000
+#   - name: ci
+#     count: 2
+#     instanceType: m5d.xlarge
+#     instanceStore: true  # Local NVMe for images and emptyDir (i3, m5d, c6gd, ...)
+#     paused: true         # Keep the nodes as they are: no scaling, replacement or drift revert
+#     scaleDownBehavior: stop  # Stop removed workers and start them on the next scale-up (default: terminate)
+#     userData: |          # Script (#!) or #cloud-config run at each node's first boot, up to 8 KiB
+#       #!/bin/bash
+#       curl -fsSL https://example.com/install-agent.sh | bash`
+	}
+	
+	// Convert cluster to YAML format for editing - only show editable fields
+	return fmt.Sprintf(`# Editing: %s
+# Mode: %s | Preset: %s | Status: %s | Created: %s
 # 
-# Read-only: name, mode, k3s version, network settings
-# Editable: description, region, instanceType, nodePools
+# Read-only: name, mode, preset, k3s version, network settings
+# Editable: description, region, instanceType, rootVolume, tags, driftPolicy, dns, virtualIP, instanceProtection, clusterLinks, nodePools
 
 description: "%s"
 region: %s
 instanceType: %s
 
-# Node Pools - Worker node groups (optional)
-# Uncomment and modify the examples below to add worker nodes
-# Each pool creates a group of worker nodes with specified configuration
+# Boot volume of the masters and of node pools without their own; applies to
+# nodes created from now on, existing nodes keep their volume
+%s
 
+# AWS tags for all cluster resources (e.g. cost allocation); changes are applied
+# to existing instances, volumes and security groups as well
 %s
-`, cluster.Name, cluster.Mode, cluster.Status, cluster.CreatedAt.Format("2006-01-02"),
-			cluster.Description, 
-			cluster.Region,
-			cluster.InstanceType,
-			nodePoolsYAML)
-
-		// Create temporary file for editing
-		tmpFile, err := ioutil.TempFile("", fmt.Sprintf("goman-cluster-%s-*.yaml", cluster.Name))
-		if err != nil {
-			return
-		}
-		tmpFilePath := tmpFile.Name()
-		defer os.Remove(tmpFilePath)
 
-		// Write content to temp file
-		if _, err := tmpFile.WriteString(yamlContent); err != nil {
-			tmpFile.Close()
-			return
-		}
-		tmpFile.Close()
+# What to do when instances or security groups are changed outside goman (e.g. in
+# the AWS console): adopt keeps the change and shows it as drift, revert changes it
+# back (an instance type by stopping the instance); see 'goman cluster drift'
+%s
 
-		// Get file modification time before editing
-		statBefore, err := os.Stat(tmpFilePath)
-		if err != nil {
-			return
-		}
-		modTimeBefore := statBefore.ModTime()
+# Cluster DNS, applied by the controller (editing CoreDNS by hand is reverted by K3s)
+%s
+%s
 
-		// Determine which editor to use
-		editor := os.Getenv("EDITOR")
-		if editor == "" {
-			editor = "vim"
-		}
+# What happens when a node is shut down from inside the OS (stop keeps it for a
+# later start), and whether nodes can be stopped through the API
+%s
 
-		// Open the editor
-		cmd := exec.Command(editor, tmpFilePath)
-		cmd.Stdin = os.Stdin
-		cmd.Stdout = os.Stdout
-		cmd.Stderr = os.Stderr
+# Other goman clusters whose services this cluster reaches privately, and which
+# reach its services (NodePorts unless ports are listed)
+%s
 
-		if err := cmd.Run(); err != nil {
-			// User exited editor, silently return
-			return
-		}
+# Node Pools - Worker node groups (optional)
+# Uncomment and modify the examples below to add worker nodes
+# Each pool creates a group of worker nodes with specified configuration
 
-		// Check if file was modified
-		statAfter, err := os.Stat(tmpFilePath)
-		if err != nil {
-			return
-		}
-		
-		// If modification time hasn't changed, user didn't save
-		if modTimeBefore.Equal(statAfter.ModTime()) {
-			return
-		}
+%s
+`, cluster.Name, cluster.Mode, presetLabel(cluster.Preset), cluster.Status, cluster.CreatedAt.Format("2006-01-02"),
+		cluster.Description, 
+		cluster.Region,
+		cluster.InstanceType,
+		clusterRootVolumeYAML(cluster.RootVolume),
+		tagsYAML(cluster.Tags),
+		driftPolicyYAML(cluster.DriftPolicy),
+		dnsYAML(cluster.DNS),
+		virtualIPYAML(cluster.Mode, cluster.VirtualIP),
+		instanceProtectionYAML(cluster.InstanceProtection),
+		clusterLinksYAML(cluster.ClusterLinks),
+		nodePoolsYAML)
+}
 
-		// Read the edited content
-		content, err := ioutil.ReadFile(tmpFilePath)
-		if err != nil {
-			return
-		}
+// openClusterEditor opens vim editor to create a new cluster
+func openClusterEditor() {
+	// Generate unique cluster name
+	timestamp := time.Now().Unix()
+	uniqueName := fmt.Sprintf("k3s-cluster-%d", timestamp)
 
-		yamlContentEdited := string(content)
-		
-		// Validate and update cluster - keep retrying on errors
-		for {
-			if err := validateAndUpdateClusterFromEditor(cluster, yamlContentEdited); err != nil {
-				// Write validation error as comment at the top of the file
-				errorContent := fmt.Sprintf("# ERROR: %s\n# Please fix the error above and save again, or exit without saving to cancel.\n#\n%s", err.Error(), yamlContentEdited)
-				ioutil.WriteFile(tmpFilePath, []byte(errorContent), 0644)
-				
-				// Get file modification time before editing
-				statBefore, _ := os.Stat(tmpFilePath)
-				modTimeBefore := statBefore.ModTime()
-				
-				// Reopen editor with error message
-				cmd := exec.Command(editor, tmpFilePath)
-				cmd.Stdin = os.Stdin
-				cmd.Stdout = os.Stdout
-				cmd.Stderr = os.Stderr
-				cmd.Run()
-				
-				// Check if file was modified
-				statAfter, _ := os.Stat(tmpFilePath)
-				if modTimeBefore.Equal(statAfter.ModTime()) {
-					// User didn't save, exit the loop
-					break
-				}
-				
-				// Read the new content and try again
-				content, err = ioutil.ReadFile(tmpFilePath)
-				if err != nil {
-					break
-				}
-				yamlContentEdited = string(content)
-				
-				// Remove error comments before retrying
-				lines := strings.Split(yamlContentEdited, "\n")
-				var cleanLines []string
-				for _, line := range lines {
-					if !strings.HasPrefix(line, "# ERROR:") && !strings.HasPrefix(line, "# Please fix") {
-						cleanLines = append(cleanLines, line)
-					}
-				}
-				yamlContentEdited = strings.Join(cleanLines, "\n")
-			} else {
-				// Success, exit the loop
-				break
-			}
-		}
+	openClusterEditorWith(newClusterTemplate(uniqueName), draftName(draftCreate, uniqueName))
+}
+
+// openClusterEditorWith opens the create form with the given content,
+// either a new template or a resumed draft
+func openClusterEditorWith(content, draft string) {
+	// Show loading message before suspending
+	statusText.SetText(fmt.Sprintf(" %s%s%s", TagWarning, i18n.T("status.opening_editor"), TagReset))
+	app.ForceDraw()
+	
+	// Small delay for visual smoothness
+	time.Sleep(100 * time.Millisecond)
+	
+	// Suspend the TUI application temporarily
+	app.Suspend(func() {
+		// Clear and reset terminal for a clean editor experience
+		fmt.Print("\033[2J\033[H\033[?47l")
+
+		editYAML(content, "goman-cluster-*.yaml", draft, validateAndCreateClusterFromEditor)
 		
 		// Restore terminal state before returning to TUI
 		fmt.Print("\033[?47h\033[2J\033[H")
@@ -229,39 +366,29 @@ instanceType: %s
 	})
 	
 	// Restore status after returning
-	statusText.SetText(" [green]● Connected[::-]")
+	statusText.SetText(" [green]" + i18n.T("status.connected_short") + "[::-]")
 	
 	// The TUI will automatically resume after Suspend function completes
 	// Refresh the cluster list to show any updates
 	go refreshClustersAsync()
 }
 
-// openClusterEditor opens vim editor to create a new cluster
-func openClusterEditor() {
-	// Show loading message before suspending
-	statusText.SetText(fmt.Sprintf(" %sOpening editor...%s", TagWarning, TagReset))
-	app.ForceDraw()
-	
-	// Small delay for visual smoothness
-	time.Sleep(100 * time.Millisecond)
-	
-	// Generate unique cluster name
-	timestamp := time.Now().Unix()
-	uniqueName := fmt.Sprintf("k3s-cluster-%d", timestamp)
-	
-	// Suspend the TUI application temporarily
-	app.Suspend(func() {
-		// Clear and reset terminal for a clean editor experience
-		fmt.Print("\033[2J\033[H\033[?47l")
-		// Default YAML configuration template
-		defaultYAML := fmt.Sprintf(`# New K3s Cluster
+// newClusterTemplate renders the default YAML configuration for a new cluster
+func newClusterTemplate(name string) string {
+	return fmt.Sprintf(`# New K3s Cluster
 
 name: %s
 description: "Development cluster"
 mode: dev                # dev (1 master) or ha (3 masters)
 region: ap-south-1
-instanceType: t3.medium
+preset: dev              # %s
+# instanceType: t3.medium  # Optional: overrides the preset's instance type
 k3sVersion: latest
+lowResource: false       # true tunes K3s for t3.micro/t3.small (swap, smaller reservations, fewer components)
+# rootVolume:            # Boot volume of the masters and of pools without their own (default: preset size or 8 GiB)
+#   size: 100            # GiB
+#   type: gp3            # gp3, gp2, io1 or io2
+#   iops: 6000           # Provisioned IOPS: 3000-16000 for gp3, required for io1/io2
 
 # Node Pools (optional) - Add worker node groups
 # Uncomment and modify to add worker nodes
@@ -281,125 +408,121 @@ k3sVersion: latest
 #       - key: nvidia.com/gpu
 #         value: "true"
 #         effect: NoSchedule
-`, uniqueName)
+`, name, presetHelp())
+}
 
-		// Create temporary file for editing
-		tmpFile, err := ioutil.TempFile("", "goman-cluster-*.yaml")
-		if err != nil {
-			return
-		}
-		tmpFilePath := tmpFile.Name()
-		defer os.Remove(tmpFilePath)
+// editorAbort wraps an apply error that must not be retried in the editor
+type editorAbort struct {
+	err error
+}
 
-		// Write default content to temp file
-		if _, err := tmpFile.WriteString(defaultYAML); err != nil {
-			tmpFile.Close()
-			return
-		}
+func (e *editorAbort) Error() string { return e.err.Error() }
+func (e *editorAbort) Unwrap() error { return e.err }
+
+// editYAML opens content in $EDITOR and passes the saved result to apply.
+// Validation errors are written as comments at the top of the file and the
+// editor is reopened until apply succeeds or the user exits without saving.
+// Returns errEditorUnsaved if nothing was saved, or the error that ended
+// the loop.
+//
+// Content that was saved but not applied is kept as the named draft (see
+// drafts.go): after a validation error, and when goman is terminated while
+// the editor is open, e.g. because the terminal was closed. The draft is
+// removed once apply succeeds.
+func editYAML(content, pattern, draft string, apply func(string) error) error {
+	// Create temporary file for editing
+	tmpFile, err := ioutil.TempFile("", pattern)
+	if err != nil {
+		return err
+	}
+	tmpFilePath := tmpFile.Name()
+	defer os.Remove(tmpFilePath)
+
+	// Write content to temp file
+	if _, err := tmpFile.WriteString(content); err != nil {
 		tmpFile.Close()
+		return err
+	}
+	tmpFile.Close()
 
+	// Keep whatever the user saved if we are killed mid-edit
+	stopWatch := watchEditorSignals(tmpFilePath, draft)
+	defer stopWatch()
+
+	// Determine which editor to use
+	editor := os.Getenv("EDITOR")
+	if editor == "" {
+		editor = "vim"
+	}
+
+	var lastErr error
+	for {
 		// Get file modification time before editing
 		statBefore, err := os.Stat(tmpFilePath)
 		if err != nil {
-			return
+			return err
 		}
 		modTimeBefore := statBefore.ModTime()
 
-		// Determine which editor to use
-		editor := os.Getenv("EDITOR")
-		if editor == "" {
-			editor = "vim"
-		}
-
 		// Open the editor
 		cmd := exec.Command(editor, tmpFilePath)
 		cmd.Stdin = os.Stdin
 		cmd.Stdout = os.Stdout
 		cmd.Stderr = os.Stderr
-
 		if err := cmd.Run(); err != nil {
 			// User exited editor, silently return
-			return
+			return errEditorUnsaved
 		}
 
-		// Check if file was modified
+		// If modification time hasn't changed, user didn't save
 		statAfter, err := os.Stat(tmpFilePath)
 		if err != nil {
-			return
+			return err
 		}
-		
-		// If modification time hasn't changed, user didn't save
 		if modTimeBefore.Equal(statAfter.ModTime()) {
-			// User didn't save, don't create cluster
-			return
+			if lastErr != nil {
+				return lastErr
+			}
+			return errEditorUnsaved
 		}
 
-		// Read the edited content
-		content, err := ioutil.ReadFile(tmpFilePath)
+		// Read the edited content, removing error comments from earlier attempts
+		data, err := ioutil.ReadFile(tmpFilePath)
 		if err != nil {
-			return
+			return err
 		}
+		edited := cleanEditorContent(string(data))
 
-		yamlContent := string(content)
-		
-		// Validate and create cluster - keep retrying on errors
-		for {
-			if err := validateAndCreateClusterFromEditor(yamlContent); err != nil {
-				// Write validation error as comment at the top of the file
-				errorContent := fmt.Sprintf("# ERROR: %s\n# Please fix the error above and save again, or exit without saving to cancel.\n#\n%s", err.Error(), yamlContent)
-				ioutil.WriteFile(tmpFilePath, []byte(errorContent), 0644)
-				
-				// Get file modification time before editing
-				statBefore, _ := os.Stat(tmpFilePath)
-				modTimeBefore := statBefore.ModTime()
-				
-				// Reopen editor with error message
-				cmd := exec.Command(editor, tmpFilePath)
-				cmd.Stdin = os.Stdin
-				cmd.Stdout = os.Stdout
-				cmd.Stderr = os.Stderr
-				cmd.Run()
-				
-				// Check if file was modified
-				statAfter, _ := os.Stat(tmpFilePath)
-				if modTimeBefore.Equal(statAfter.ModTime()) {
-					// User didn't save, exit the loop
-					break
-				}
-				
-				// Read the new content and try again
-				content, err = ioutil.ReadFile(tmpFilePath)
-				if err != nil {
-					break
-				}
-				yamlContent = string(content)
-				
-				// Remove error comments before retrying
-				lines := strings.Split(yamlContent, "\n")
-				var cleanLines []string
-				for _, line := range lines {
-					if !strings.HasPrefix(line, "# ERROR:") && !strings.HasPrefix(line, "# Please fix") {
-						cleanLines = append(cleanLines, line)
-					}
-				}
-				yamlContent = strings.Join(cleanLines, "\n")
-			} else {
-				// Success, exit the loop
-				break
-			}
+		lastErr = apply(edited)
+		if lastErr == nil {
+			removeDraft(draft)
+			return nil
 		}
-		
-		// Restore terminal state before returning to TUI
-		fmt.Print("\033[?47h\033[2J\033[H")
-		time.Sleep(50 * time.Millisecond)
-	})
-	
-	// Restore status after returning
-	statusText.SetText(" [green]● Connected[::-]")
-	
-	// The TUI will automatically resume after Suspend function completes
-	// Refresh the cluster list to show any updates
-	go refreshClustersAsync()
+		var abort *editorAbort
+		if errors.As(lastErr, &abort) {
+			removeDraft(draft)
+			return abort.err
+		}
+		saveDraft(draft, edited)
+
+		// Write validation error as comment at the top of the file
+		errorContent := fmt.Sprintf("# ERROR: %s\n# Please fix the error above and save again, or exit without saving to cancel.\n#\n%s", lastErr.Error(), edited)
+		ioutil.WriteFile(tmpFilePath, []byte(errorContent), 0644)
+	}
+}
+
+// cleanEditorContent removes the comments editYAML adds to a form: error
+// messages of earlier attempts and the resumed-draft header
+func cleanEditorContent(content string) string {
+	lines := strings.Split(content, "\n")
+	var cleanLines []string
+	for _, line := range lines {
+		if !strings.HasPrefix(line, "# ERROR:") && !strings.HasPrefix(line, "# Please fix") &&
+			!strings.HasPrefix(line, draftHeaderPrefix) {
+			cleanLines = append(cleanLines, line)
+		}
+	}
+	return strings.Join(cleanLines, "\n")
 }
 
 // validateAndCreateClusterFromEditor parses YAML and creates a new cluster
@@ -426,8 +549,16 @@ func validateAndCreateClusterFromEditor(yamlContent string) error {
 		return fmt.Errorf("region is required")
 	}
 	
+	// A preset is expanded by the controller; only validate it here
+	preset, _ := config["preset"].(string)
+	if preset != "" {
+		if _, err := models.LookupPreset(preset); err != nil {
+			return err
+		}
+	}
+
 	instanceType, ok := config["instanceType"].(string)
-	if !ok || instanceType == "" {
+	if (!ok || instanceType == "") && preset == "" {
 		instanceType = "t3.medium"
 	}
 	
@@ -443,10 +574,14 @@ func validateAndCreateClusterFromEditor(yamlContent string) error {
 		description = "K3s cluster"
 	}
 	
+	lowResource, _ := config["lowResource"].(bool)
+	rootVolume, err := parseRootVolume(config["rootVolume"], "cluster "+name)
+	if err != nil {
+		return err
+	}
+
 	// Create the cluster without UI (we're in editor mode)
-	createNewClusterFromEditor(name, description, mode, region, instanceType, nodeCount)
-	
-	return nil
+	return createNewClusterFromEditor(name, description, mode, region, preset, instanceType, nodeCount, lowResource, rootVolume)
 }
 
 // validateAndUpdateClusterFromEditor parses YAML and updates an existing cluster
@@ -479,6 +614,12 @@ func validateAndUpdateClusterFromEditor(originalCluster models.K3sCluster, yamlC
 		description = originalCluster.Description
 	}
 	
+	// Extract rootVolume
+	rootVolume, err := parseRootVolume(config["rootVolume"], "cluster "+name)
+	if err != nil {
+		return err
+	}
+
 	// Extract nodePools
 	var nodePools []models.NodePool
 	if nodePoolsRaw, ok := config["nodePools"]; ok {
@@ -535,19 +676,170 @@ func validateAndUpdateClusterFromEditor(originalCluster models.K3sCluster, yamlC
 						}
 					}
 					
+					// Parse data volumes
+					if volumesRaw, ok := npMap["volumes"].([]interface{}); ok {
+						for _, v := range volumesRaw {
+							if volumeMap, ok := v.(map[interface{}]interface{}); ok {
+								volume := models.DataVolume{}
+								if size, ok := volumeMap["size"].(int); ok {
+									volume.Size = size
+								}
+								volume.Type, _ = volumeMap["type"].(string)
+								volume.MountPoint, _ = volumeMap["mountPoint"].(string)
+								volume.Filesystem, _ = volumeMap["filesystem"].(string)
+								nodePool.Volumes = append(nodePool.Volumes, volume)
+							}
+						}
+					}
+					nodePool.InstanceStore, _ = npMap["instanceStore"].(bool)
+					nodePool.Paused, _ = npMap["paused"].(bool)
+					nodePool.UserData, _ = npMap["userData"].(string)
+					if err := models.ValidateUserData(nodePool.Name, nodePool.UserData); err != nil {
+						return err
+					}
+					nodePool.ScaleDownBehavior, _ = npMap["scaleDownBehavior"].(string)
+					if err := models.ValidateScaleDownBehavior(nodePool.Name, nodePool.ScaleDownBehavior); err != nil {
+						return err
+					}
+					rootVolume, err := parseRootVolume(npMap["rootVolume"], "node pool "+nodePool.Name)
+					if err != nil {
+						return err
+					}
+					nodePool.RootVolume = rootVolume
+					if zones, ok := npMap["availabilityZones"].([]interface{}); ok {
+						for _, z := range zones {
+							zone, _ := z.(string)
+							nodePool.AvailabilityZones = append(nodePool.AvailabilityZones, zone)
+						}
+					}
+					if err := models.ValidateDataVolumes(nodePool.Name, nodePool.Volumes); err != nil {
+						return err
+					}
+					if err := models.ValidateAvailabilityZones(nodePool.Name, region, nodePool.AvailabilityZones); err != nil {
+						return err
+					}
+					if nodePool.InstanceStore && !models.HasInstanceStore(nodePool.InstanceType) {
+						return fmt.Errorf("node pool %s: instance type %s has no instance store", nodePool.Name, nodePool.InstanceType)
+					}
+
 					nodePools = append(nodePools, nodePool)
 				}
 			}
 		}
 	}
 	
-	// Update the cluster (description, region, instanceType, and nodePools can change)
-	return updateExistingClusterWithNodePools(originalCluster.Name, name, description, mode, region, instanceType, nodePools)
+	// Extract tags
+	tags := make(map[string]string)
+	if tagsRaw, ok := config["tags"].(map[interface{}]interface{}); ok {
+		for k, v := range tagsRaw {
+			value := ""
+			if v != nil {
+				value = fmt.Sprint(v)
+			}
+			tags[fmt.Sprint(k)] = value
+		}
+	}
+	if err := models.ValidateResourceTags(tags); err != nil {
+		return err
+	}
+
+	// Extract driftPolicy
+	driftPolicy := make(map[string]models.DriftPolicy)
+	if policiesRaw, ok := config["driftPolicy"].(map[interface{}]interface{}); ok {
+		for k, v := range policiesRaw {
+			field, _ := k.(string)
+			policy, _ := v.(string)
+			driftPolicy[field] = models.DriftPolicy(policy)
+		}
+	}
+	if err := models.ValidateDriftPolicies(driftPolicy); err != nil {
+		return err
+	}
+
+	// Extract dns
+	var dns *models.DNSSpec
+	if dnsRaw, ok := config["dns"]; ok && dnsRaw != nil {
+		data, err := yaml.Marshal(dnsRaw)
+		if err != nil {
+			return fmt.Errorf("invalid dns: %v", err)
+		}
+		dns = &models.DNSSpec{}
+		if err := yaml.Unmarshal(data, dns); err != nil {
+			return fmt.Errorf("invalid dns: %v", err)
+		}
+		if err := models.ValidateDNS(dns); err != nil {
+			return err
+		}
+		if models.DNSConfigHash(dns) == "" {
+			dns = nil
+		}
+	}
+
+	// Extract virtualIP; present (even without an address) enables it
+	var virtualIP *models.VirtualIPSpec
+	if vipRaw, ok := config["virtualIP"]; ok && vipRaw != nil {
+		data, err := yaml.Marshal(vipRaw)
+		if err != nil {
+			return fmt.Errorf("invalid virtualIP: %v", err)
+		}
+		virtualIP = &models.VirtualIPSpec{}
+		if err := yaml.Unmarshal(data, virtualIP); err != nil {
+			return fmt.Errorf("invalid virtualIP: %v", err)
+		}
+		if err := models.ValidateVirtualIP(virtualIP, mode); err != nil {
+			return err
+		}
+	}
+
+	// Extract instanceProtection
+	var protection *models.InstanceProtectionSpec
+	if protectionRaw, ok := config["instanceProtection"]; ok && protectionRaw != nil {
+		data, err := yaml.Marshal(protectionRaw)
+		if err != nil {
+			return fmt.Errorf("invalid instanceProtection: %v", err)
+		}
+		protection = &models.InstanceProtectionSpec{}
+		if err := yaml.Unmarshal(data, protection); err != nil {
+			return fmt.Errorf("invalid instanceProtection: %v", err)
+		}
+		if err := models.ValidateInstanceProtection(protection); err != nil {
+			return err
+		}
+		if *protection == (models.InstanceProtectionSpec{}) {
+			protection = nil
+		}
+	}
+
+	// Extract clusterLinks
+	var links []models.ClusterLink
+	if linksRaw, ok := config["clusterLinks"]; ok && linksRaw != nil {
+		data, err := yaml.Marshal(linksRaw)
+		if err != nil {
+			return fmt.Errorf("invalid clusterLinks: %v", err)
+		}
+		if err := yaml.Unmarshal(data, &links); err != nil {
+			return fmt.Errorf("invalid clusterLinks: %v", err)
+		}
+		if err := models.ValidateClusterLinks(name, links); err != nil {
+			return err
+		}
+	}
+
+	// Update the cluster (description, region, instanceType, rootVolume, tags, driftPolicy, dns, virtualIP, instanceProtection, clusterLinks and nodePools can change)
+	return updateExistingClusterWithNodePools(originalCluster.Name, name, description, mode, region, instanceType, rootVolume, models.FormatResourceTags(tags), driftPolicy, dns, virtualIP, protection, links, nodePools)
 }
 
-// createNewClusterFromEditor creates a cluster from editor without UI
-func createNewClusterFromEditor(name, description, mode, region, instanceType, nodeCountStr string) {
-	createNewClusterWithUI(name, description, mode, region, instanceType, nodeCountStr, false)
+// createNewClusterFromEditor creates a cluster from editor without UI.
+// Failures are returned to the editor, which reopens the form with the
+// error and keeps it as a draft.
+func createNewClusterFromEditor(name, description, mode, region, preset, instanceType, nodeCountStr string, lowResource bool, rootVolume *models.RootVolume) error {
+	cluster := newClusterModel(name, description, mode, region, preset, instanceType, nodeCountStr)
+	cluster.LowResource = lowResource
+	cluster.RootVolume = rootVolume
+	if _, err := clusterManager.CreateCluster(*cluster); err != nil {
+		return fmt.Errorf("failed to create cluster: %w", err)
+	}
+	return nil
 }
 
 // updateExistingCluster updates an existing cluster configuration
@@ -581,7 +873,7 @@ func updateExistingCluster(originalName, name, description, mode, region, instan
 }
 
 // updateExistingClusterWithNodePools updates an existing cluster configuration including nodepools
-func updateExistingClusterWithNodePools(originalName, name, description, mode, region, instanceType string, nodePools []models.NodePool) error {
+func updateExistingClusterWithNodePools(originalName, name, description, mode, region, instanceType string, rootVolume *models.RootVolume, tags []string, driftPolicy map[string]models.DriftPolicy, dns *models.DNSSpec, virtualIP *models.VirtualIPSpec, protection *models.InstanceProtectionSpec, links []models.ClusterLink, nodePools []models.NodePool) error {
 	// Load the existing cluster
 	existingClusters := clusterManager.GetClusters()
 	var existingCluster *models.K3sCluster
@@ -595,18 +887,230 @@ func updateExistingClusterWithNodePools(originalName, name, description, mode, r
 	if existingCluster == nil {
 		return fmt.Errorf("cluster not found")
 	}
+	before := *existingCluster
+	if err := models.ValidateLoadBalancer(existingCluster.LoadBalancer, string(existingCluster.Mode), virtualIP); err != nil {
+		return err
+	}
 	
 	// Update cluster fields
 	existingCluster.Name = name
 	existingCluster.Description = description
 	existingCluster.Region = region
 	existingCluster.InstanceType = instanceType
+	existingCluster.RootVolume = rootVolume
 	existingCluster.NodePools = nodePools
+	existingCluster.Tags = tags
+	existingCluster.DriftPolicy = driftPolicy
+	existingCluster.DNS = dns
+	existingCluster.VirtualIP = virtualIP
+	existingCluster.InstanceProtection = protection
+	existingCluster.ClusterLinks = links
 	
 	// Mode should NOT be updated - it's immutable
 	// Keep the existing mode
 	
+	// Scale downs and removed pools are confirmed in the TUI first
+	if holdDestructiveEdits {
+		if impact := clusterpkg.EditImpact(before, *existingCluster); impact.Destructive() {
+			return &heldEdit{cluster: *existingCluster, impact: impact}
+		}
+	}
+	
 	// Update the cluster
 	_, err := clusterManager.UpdateCluster(*existingCluster)
 	return err
-}
\ No newline at end of file
+}
+
+// clusterRootVolumeYAML renders the rootVolume section of the edit template
+func clusterRootVolumeYAML(v *models.RootVolume) string {
+	if v == nil {
+		return `# rootVolume:
+#   size: 100   # GiB (default: preset size or 8)
+#   type: gp3   # gp3, gp2, io1 or io2
+#   iops: 6000  # Provisioned IOPS: 3000-16000 for gp3, required for io1/io2`
+	}
+	return strings.TrimSuffix(rootVolumeYAML(v, ""), "\n")
+}
+
+// rootVolumeYAML renders a rootVolume block at the given indentation, empty
+// for nil
+func rootVolumeYAML(v *models.RootVolume, indent string) string {
+	if v == nil {
+		return ""
+	}
+	out := indent + "rootVolume:\n"
+	if v.Size > 0 {
+		out += fmt.Sprintf("%s  size: %d\n", indent, v.Size)
+	}
+	out += fmt.Sprintf("%s  type: %s\n", indent, v.VolumeType())
+	if v.IOPS > 0 {
+		out += fmt.Sprintf("%s  iops: %d\n", indent, v.IOPS)
+	}
+	return out
+}
+
+// userDataYAML renders the userData of a pool as a block of the edit form
+func userDataYAML(snippet, indent string) string {
+	if snippet == "" {
+		return ""
+	}
+	data, err := yaml.Marshal(map[string]string{"userData": snippet})
+	if err != nil {
+		return ""
+	}
+	var out string
+	for _, line := range strings.SplitAfter(strings.TrimSuffix(string(data), "\n"), "\n") {
+		out += indent + line
+	}
+	return out + "\n"
+}
+
+// parseRootVolume reads a rootVolume block of the edit form; nil when absent
+func parseRootVolume(raw interface{}, owner string) (*models.RootVolume, error) {
+	if raw == nil {
+		return nil, nil
+	}
+	data, err := yaml.Marshal(raw)
+	if err != nil {
+		return nil, fmt.Errorf("%s: invalid rootVolume: %v", owner, err)
+	}
+	v := &models.RootVolume{}
+	if err := yaml.UnmarshalStrict(data, v); err != nil {
+		return nil, fmt.Errorf("%s: invalid rootVolume: %v", owner, err)
+	}
+	if err := models.ValidateRootVolume(owner, v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// tagsYAML renders the tags section of the edit template
+func tagsYAML(stored []string) string {
+	tags := models.ParseResourceTags(stored)
+	if len(tags) == 0 {
+		return "tags: {}\n#   CostCenter: \"1234\""
+	}
+	out := "tags:"
+	for _, key := range models.SortedTagKeys(tags) {
+		out += fmt.Sprintf("\n  %s: %q", key, tags[key])
+	}
+	return out
+}
+
+// driftPolicyYAML renders the driftPolicy section of the edit template
+func driftPolicyYAML(policies map[string]models.DriftPolicy) string {
+	if len(policies) == 0 {
+		return "driftPolicy: {}\n#   instanceType: adopt        # adopt | revert\n#   tags: adopt\n#   securityGroupRules: adopt"
+	}
+	out := "driftPolicy:"
+	for _, field := range models.DriftFields {
+		if policy, ok := policies[field]; ok {
+			out += fmt.Sprintf("\n  %s: %s", field, policy)
+		}
+	}
+	return out
+}
+
+// virtualIPYAML renders the virtualIP section of the edit template; only HA
+// clusters have one
+func virtualIPYAML(mode models.ClusterMode, spec *models.VirtualIPSpec) string {
+	if mode != models.ModeHA {
+		return ""
+	}
+	if spec == nil {
+		return `
+# Floating API endpoint inside the VPC, served by the masters with kube-vip
+# virtualIP:
+#   address: ""  # Private IP in the masters' subnet; allocated if empty`
+	}
+	return fmt.Sprintf(`
+# Floating API endpoint inside the VPC, served by the masters with kube-vip
+virtualIP:
+  address: "%s"  # Private IP in the masters' subnet; allocated if empty`, spec.Address)
+}
+
+// instanceProtectionYAML renders the instanceProtection section of the edit
+// template
+func instanceProtectionYAML(spec *models.InstanceProtectionSpec) string {
+	if spec == nil {
+		return `instanceProtection: {}
+#   shutdownBehavior: stop    # stop | terminate
+#   stopProtection: false     # true for production clusters
+#   deletionProtection: true  # on for clusters tagged Environment=prod if unset`
+	}
+	out := fmt.Sprintf(`instanceProtection:
+  shutdownBehavior: %s  # stop | terminate
+  stopProtection: %t`, spec.Behavior(), spec.StopProtected())
+	if spec.DeletionProtection != nil {
+		out += fmt.Sprintf("\n  deletionProtection: %t", *spec.DeletionProtection)
+	} else {
+		out += "\n  # deletionProtection: true  # on for clusters tagged Environment=prod if unset"
+	}
+	return out
+}
+
+// clusterLinksYAML renders the clusterLinks section of the edit template
+func clusterLinksYAML(links []models.ClusterLink) string {
+	if len(links) == 0 {
+		return `clusterLinks: []
+#   - cluster: shared-services
+#     ports: ["443", "30000-32767/tcp"]
+#     peering: false  # true to peer the VPCs of clusters in different VPCs`
+	}
+	out := "clusterLinks:"
+	for _, link := range links {
+		out += fmt.Sprintf("\n  - cluster: %s", link.Cluster)
+		if len(link.Ports) > 0 {
+			quoted := make([]string, len(link.Ports))
+			for i, port := range link.Ports {
+				quoted[i] = fmt.Sprintf("%q", port)
+			}
+			out += fmt.Sprintf("\n    ports: [%s]", strings.Join(quoted, ", "))
+		}
+		if link.Peering {
+			out += "\n    peering: true"
+		}
+	}
+	return out
+}
+
+// dnsYAML renders the dns section of the edit template
+func dnsYAML(spec *models.DNSSpec) string {
+	if models.DNSConfigHash(spec) == "" {
+		return `dns: {}
+#   stubDomains:              # Resolvers for specific domains
+#     corp.example.com: [10.0.0.2, 10.0.0.3]
+#   upstreams: [1.1.1.1]      # Resolvers for other external names (default: VPC resolver)
+#   nodeLocalCache: false     # DNS cache on every node (NodeLocal DNSCache)`
+	}
+	out := "dns:"
+	if len(spec.StubDomains) > 0 {
+		out += "\n  stubDomains:"
+		domains := make([]string, 0, len(spec.StubDomains))
+		for domain := range spec.StubDomains {
+			domains = append(domains, domain)
+		}
+		sort.Strings(domains)
+		for _, domain := range domains {
+			out += fmt.Sprintf("\n    %s: [%s]", domain, strings.Join(spec.StubDomains[domain], ", "))
+		}
+	}
+	if len(spec.Upstreams) > 0 {
+		out += fmt.Sprintf("\n  upstreams: [%s]", strings.Join(spec.Upstreams, ", "))
+	}
+	out += fmt.Sprintf("\n  nodeLocalCache: %t", spec.NodeLocalCache)
+	return out
+}
+
+// presetHelp lists the sizing presets for the editor template
+func presetHelp() string {
+	return strings.Join(models.PresetNames(), " | ") + " (sets instance type, root volume, components)"
+}
+
+// presetLabel renders a cluster's preset for display
+func presetLabel(preset string) string {
+	if preset == "" {
+		return "custom"
+	}
+	return preset
+}
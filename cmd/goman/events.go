@@ -0,0 +1,96 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/madhouselabs/goman/pkg/cluster"
+	"github.com/madhouselabs/goman/pkg/i18n"
+	"github.com/madhouselabs/goman/pkg/models"
+	"github.com/spf13/cobra"
+)
+
+var (
+	eventsSince time.Duration
+	eventsLimit int
+	eventsType  string
+	eventsJSON  bool
+)
+
+// clusterEventsCmd lists what the controller recorded about a cluster
+var clusterEventsCmd = &cobra.Command{
+	Use:   "events <cluster-name>",
+	Short: i18n.T("cmd.cluster.events.short"),
+	Long:  i18n.T("cmd.cluster.events.long"),
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		clusterName := resolveClusterAlias(args[0])
+		var eventType models.EventType
+		switch strings.ToLower(eventsType) {
+		case "":
+		case "normal":
+			eventType = models.EventTypeNormal
+		case "warning":
+			eventType = models.EventTypeWarning
+		default:
+			return fmt.Errorf("--type must be Normal or Warning")
+		}
+		if clusterManager == nil {
+			clusterManager = cluster.NewManager()
+		}
+
+		var since time.Time
+		if eventsSince > 0 {
+			since = time.Now().Add(-eventsSince)
+		}
+		events, err := clusterManager.ClusterEvents(clusterName, since, eventsLimit)
+		if err != nil {
+			return err
+		}
+		if eventType != "" {
+			filtered := events[:0]
+			for _, e := range events {
+				if e.Type == eventType {
+					filtered = append(filtered, e)
+				}
+			}
+			events = filtered
+		}
+
+		if eventsJSON {
+			if events == nil {
+				events = []models.Event{}
+			}
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			return enc.Encode(events)
+		}
+		if len(events) == 0 {
+			outf("No events recorded for cluster %s\n", clusterName)
+			return nil
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "TIME\tTYPE\tREASON\tOBJECT\tMESSAGE")
+		for _, e := range events {
+			object := e.Object
+			if object == "" {
+				object = "cluster/" + clusterName
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n",
+				e.Timestamp.Local().Format("2006-01-02 15:04:05"), e.Type, e.Reason, object, e.Message)
+		}
+		return w.Flush()
+	},
+}
+
+func init() {
+	clusterEventsCmd.Flags().DurationVar(&eventsSince, "since", 0, "Only show events recorded within this long (0 for all)")
+	clusterEventsCmd.Flags().IntVar(&eventsLimit, "limit", 100, "Maximum number of events to show (0 for all)")
+	clusterEventsCmd.Flags().StringVar(&eventsType, "type", "", "Only show Normal or Warning events")
+	clusterEventsCmd.Flags().BoolVar(&eventsJSON, "json", false, "Print the events as JSON")
+}
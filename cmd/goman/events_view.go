@@ -0,0 +1,100 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/madhouselabs/goman/pkg/i18n"
+	"github.com/madhouselabs/goman/pkg/models"
+	"github.com/rivo/tview"
+)
+
+// eventsViewLimit is how many recent events the TUI shows
+const eventsViewLimit = 100
+
+// showClusterEvents opens a page listing the events the controller recorded
+// for a cluster, newest first
+func showClusterEvents(c models.K3sCluster) {
+	title := tview.NewTextView().
+		SetDynamicColors(true).
+		SetText(fmt.Sprintf(" %s%sEvents%s%s  %s", TagBold, TagPrimary, TagReset, TagReset, c.Name))
+
+	table := tview.NewTable().
+		SetBorders(false).
+		SetSelectable(true, false).
+		SetSeparator(' ').
+		SetSelectedStyle(StyleHighlight).
+		SetFixed(1, 0)
+	for col, header := range []string{"  Time", "Type", "Reason", "Object", "Message"} {
+		table.SetCell(0, col, tview.NewTableCell(header).
+			SetTextColor(ColorPrimary).
+			SetSelectable(false))
+	}
+	table.SetCell(1, 0, tview.NewTableCell("  "+i18n.T("commands.loading")).SetTextColor(ColorMuted).SetSelectable(false))
+
+	footer := tview.NewTextView().
+		SetDynamicColors(true).
+		SetTextAlign(tview.AlignRight).
+		SetText(shortcutHints(
+			actionHint(actionBack, "shortcut.back"),
+			navigationHint(),
+			actionHint(actionHelp, "shortcut.help")))
+
+	flex := tview.NewFlex().SetDirection(tview.FlexRow).
+		AddItem(title, 1, 0, false).
+		AddItem(createDivider(), 1, 0, false).
+		AddItem(table, 0, 1, true).
+		AddItem(footer, 1, 0, false)
+
+	flex.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		switch action := keys.action(viewEvents, event); action {
+		case actionBack:
+			pages.RemovePage("events")
+			pages.SwitchToPage("details")
+			return nil
+		case actionHelp:
+			showHelp(viewEvents)
+			return nil
+		case "":
+			return event
+		default:
+			return navigate(action)
+		}
+	})
+
+	pages.AddAndSwitchToPage("events", flex, true)
+
+	go func() {
+		events, err := clusterManager.ClusterEvents(c.Name, time.Time{}, eventsViewLimit)
+		app.QueueUpdateDraw(func() {
+			table.RemoveRow(1)
+			if err != nil {
+				table.SetCell(1, 0, tview.NewTableCell("  "+err.Error()).SetTextColor(ColorDanger).SetSelectable(false))
+				return
+			}
+			if len(events) == 0 {
+				table.SetCell(1, 0, tview.NewTableCell("  "+i18n.T("events.none")).SetTextColor(ColorMuted).SetSelectable(false))
+				return
+			}
+			for i := len(events) - 1; i >= 0; i-- {
+				e := events[i]
+				row := len(events) - i
+				typeColor := ColorSuccess
+				if e.Type == models.EventTypeWarning {
+					typeColor = ColorWarning
+				}
+				object := e.Object
+				if object == "" {
+					object = "cluster"
+				}
+				table.SetCell(row, 0, tview.NewTableCell("  "+e.Timestamp.Local().Format("2006-01-02 15:04:05")))
+				table.SetCell(row, 1, tview.NewTableCell(string(e.Type)).SetTextColor(typeColor))
+				table.SetCell(row, 2, tview.NewTableCell(e.Reason))
+				table.SetCell(row, 3, tview.NewTableCell(object))
+				table.SetCell(row, 4, tview.NewTableCell(tview.Escape(e.Message)).SetExpansion(1))
+			}
+			table.Select(1, 0)
+		})
+	}()
+}
@@ -0,0 +1,436 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/madhouselabs/goman/pkg/config"
+	"github.com/madhouselabs/goman/pkg/i18n"
+	"github.com/rivo/tview"
+	"gopkg.in/yaml.v2"
+)
+
+// keyAction is a TUI command that can be bound to keys
+type keyAction string
+
+const (
+	actionUp         keyAction = "up"
+	actionDown       keyAction = "down"
+	actionTop        keyAction = "top"
+	actionBottom     keyAction = "bottom"
+	actionOpen       keyAction = "open"
+	actionBack       keyAction = "back"
+	actionSelect     keyAction = "select"
+	actionCreate     keyAction = "create"
+	actionEdit       keyAction = "edit"
+	actionDelete     keyAction = "delete"
+	actionReconcile  keyAction = "reconcile"
+	actionStop       keyAction = "stop"
+	actionStart      keyAction = "start"
+	actionRefresh    keyAction = "refresh"
+	actionSort       keyAction = "sort"
+	actionCommands   keyAction = "commands"
+	actionEvents     keyAction = "events"
+	actionNotes      keyAction = "notes"
+	actionInit       keyAction = "init"
+	actionSwitchPane keyAction = "switch-pane"
+	actionHelp       keyAction = "help"
+	actionQuit       keyAction = "quit"
+)
+
+// Views with their own key handling
+const (
+	viewClusters = "clusters"
+	viewEmpty    = "empty"
+	viewDetails  = "details"
+	viewCommands = "commands"
+	viewEvents   = "events"
+)
+
+// keyViews lists the actions of each view in help order. Keys must be
+// unique within a view; the same key may do different things in different
+// views (c creates a cluster in the list and opens commands in details).
+var keyViews = []struct {
+	name    string
+	actions []keyAction
+}{
+	{viewClusters, []keyAction{actionUp, actionDown, actionTop, actionBottom, actionOpen, actionSelect, actionCreate, actionEdit, actionDelete, actionReconcile, actionStop, actionStart, actionRefresh, actionSort, actionHelp, actionQuit}},
+	{viewEmpty, []keyAction{actionCreate, actionInit, actionRefresh, actionHelp, actionQuit}},
+	{viewDetails, []keyAction{actionBack, actionSelect, actionEdit, actionDelete, actionStop, actionStart, actionCommands, actionEvents, actionNotes, actionRefresh, actionHelp}},
+	{viewCommands, []keyAction{actionBack, actionUp, actionDown, actionTop, actionBottom, actionSwitchPane, actionHelp}},
+	{viewEvents, []keyAction{actionBack, actionUp, actionDown, actionTop, actionBottom, actionHelp}},
+}
+
+// navigationKeys are the keys tview widgets handle natively, which the
+// navigation actions are translated to
+var navigationKeys = map[keyAction]tcell.Key{
+	actionUp:     tcell.KeyUp,
+	actionDown:   tcell.KeyDown,
+	actionTop:    tcell.KeyHome,
+	actionBottom: tcell.KeyEnd,
+}
+
+// defaultBindings is the arrows preset
+var defaultBindings = map[keyAction][]string{
+	actionUp:         {"Up"},
+	actionDown:       {"Down"},
+	actionTop:        {"Home"},
+	actionBottom:     {"End"},
+	actionOpen:       {"Enter"},
+	actionBack:       {"Esc"},
+	actionSelect:     {"k"},
+	actionCreate:     {"c"},
+	actionEdit:       {"e"},
+	actionDelete:     {"d"},
+	actionReconcile:  {"t"},
+	actionStop:       {"s"},
+	actionStart:      {"a"},
+	actionRefresh:    {"r"},
+	actionSort:       {"o"},
+	actionCommands:   {"c"},
+	actionEvents:     {"v"},
+	actionNotes:      {"n"},
+	actionInit:       {"i"},
+	actionSwitchPane: {"Tab"},
+	actionHelp:       {"?"},
+	actionQuit:       {"q"},
+}
+
+// keymapPresets change the defaults before the user's own bindings apply
+var keymapPresets = map[string]map[keyAction][]string{
+	"arrows": {},
+	"vim": {
+		actionUp:     {"k", "Up"},
+		actionDown:   {"j", "Down"},
+		actionTop:    {"g", "Home"},
+		actionBottom: {"G", "End"},
+		actionSelect: {"K"},
+	},
+}
+
+// keyBinding is a special key, or a rune for KeyRune
+type keyBinding struct {
+	key tcell.Key
+	ch  rune
+}
+
+// keymap maps actions to the keys bound to them
+type keymap struct {
+	bindings map[keyAction][]keyBinding
+}
+
+// keys is the active keymap, loaded from the config file when the TUI starts
+var keys = mustKeymap(nil)
+
+// keymapConfig is the keymap section of the config file:
+//
+//	keymap:
+//	  preset: vim          # arrows (default) or vim
+//	  delete: [x, Delete]  # one key or a list
+//	  refresh: F5
+type keymapConfig map[string]interface{}
+
+// loadKeymap reads the keymap section of the config file; a missing file
+// gives the default keymap
+func loadKeymap() (*keymap, error) {
+	path := config.FilePath()
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return newKeymap(nil)
+	}
+	if err != nil {
+		return nil, err
+	}
+	var file struct {
+		Keymap keymapConfig `yaml:"keymap"`
+	}
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	km, err := newKeymap(file.Keymap)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return km, nil
+}
+
+// newKeymap applies a keymap section over the defaults and checks that no
+// key does two things in one view
+func newKeymap(cfg keymapConfig) (*keymap, error) {
+	names := make(map[keyAction][]string, len(defaultBindings))
+	for action, keyNames := range defaultBindings {
+		names[action] = keyNames
+	}
+
+	if raw, ok := cfg["preset"]; ok {
+		preset, ok := keymapPresets[fmt.Sprint(raw)]
+		if !ok {
+			return nil, fmt.Errorf("unknown keymap preset %q (use arrows or vim)", raw)
+		}
+		for action, keyNames := range preset {
+			names[action] = keyNames
+		}
+	}
+
+	for name, raw := range cfg {
+		if name == "preset" {
+			continue
+		}
+		action := keyAction(name)
+		if _, ok := defaultBindings[action]; !ok {
+			return nil, fmt.Errorf("unknown keymap action %q", name)
+		}
+		switch v := raw.(type) {
+		case []interface{}:
+			names[action] = nil
+			for _, key := range v {
+				names[action] = append(names[action], fmt.Sprint(key))
+			}
+		case map[interface{}]interface{}, nil:
+			return nil, fmt.Errorf("keymap %s: expected a key or a list of keys", name)
+		default:
+			// A bare digit reads as a number
+			names[action] = []string{fmt.Sprint(v)}
+		}
+	}
+
+	km := &keymap{bindings: make(map[keyAction][]keyBinding, len(names))}
+	for action, keyNames := range names {
+		for _, name := range keyNames {
+			b, err := parseKey(name)
+			if err != nil {
+				return nil, fmt.Errorf("keymap %s: %w", action, err)
+			}
+			km.bindings[action] = append(km.bindings[action], b)
+		}
+	}
+
+	for _, view := range keyViews {
+		bound := make(map[keyBinding]keyAction)
+		for _, action := range view.actions {
+			for _, b := range km.bindings[action] {
+				if other, ok := bound[b]; ok {
+					return nil, fmt.Errorf("key %s is bound to both %s and %s in the %s view", b, other, action, view.name)
+				}
+				bound[b] = action
+			}
+		}
+	}
+	return km, nil
+}
+
+// mustKeymap is newKeymap for configurations known to be valid
+func mustKeymap(cfg keymapConfig) *keymap {
+	km, err := newKeymap(cfg)
+	if err != nil {
+		panic(err)
+	}
+	return km
+}
+
+// parseKey reads a key name: a single character, or a tcell key name such
+// as Enter, Esc, Delete, F5 or Ctrl-D (case-insensitive)
+func parseKey(name string) (keyBinding, error) {
+	if r := []rune(name); len(r) == 1 {
+		return keyBinding{key: tcell.KeyRune, ch: r[0]}, nil
+	}
+	switch strings.ToLower(name) {
+	case "space":
+		return keyBinding{key: tcell.KeyRune, ch: ' '}, nil
+	case "escape":
+		return keyBinding{key: tcell.KeyEscape}, nil
+	}
+	for key, keyName := range tcell.KeyNames {
+		if strings.EqualFold(keyName, name) {
+			return keyBinding{key: key}, nil
+		}
+	}
+	return keyBinding{}, fmt.Errorf("unknown key %q", name)
+}
+
+// String returns the key as shown in hints and help
+func (b keyBinding) String() string {
+	switch b.key {
+	case tcell.KeyRune:
+		if b.ch == ' ' {
+			return "Space"
+		}
+		return string(b.ch)
+	case tcell.KeyUp:
+		return string(CharArrowUp)
+	case tcell.KeyDown:
+		return string(CharArrowDown)
+	case tcell.KeyLeft:
+		return string(CharArrowLeft)
+	case tcell.KeyRight:
+		return string(CharArrowRight)
+	}
+	if name, ok := tcell.KeyNames[b.key]; ok {
+		return name
+	}
+	return fmt.Sprintf("key %d", b.key)
+}
+
+// action returns the action an event triggers in a view, empty if none.
+// Letters also match their lowercase binding unless the uppercase letter is
+// bound in the view itself, so C creates a cluster like c does.
+func (km *keymap) action(view string, event *tcell.EventKey) keyAction {
+	var actions []keyAction
+	for _, v := range keyViews {
+		if v.name == view {
+			actions = v.actions
+		}
+	}
+	find := func(b keyBinding) keyAction {
+		for _, action := range actions {
+			for _, bound := range km.bindings[action] {
+				if bound == b {
+					return action
+				}
+			}
+		}
+		return ""
+	}
+
+	if event.Key() != tcell.KeyRune {
+		return find(keyBinding{key: event.Key()})
+	}
+	if action := find(keyBinding{key: tcell.KeyRune, ch: event.Rune()}); action != "" {
+		return action
+	}
+	if lower := []rune(strings.ToLower(string(event.Rune()))); lower[0] != event.Rune() {
+		return find(keyBinding{key: tcell.KeyRune, ch: lower[0]})
+	}
+	return ""
+}
+
+// navigate translates navigation actions to the keys tview widgets handle,
+// returning nil for other actions
+func navigate(action keyAction) *tcell.EventKey {
+	if key, ok := navigationKeys[action]; ok {
+		return tcell.NewEventKey(key, 0, tcell.ModNone)
+	}
+	return nil
+}
+
+// label returns the keys of an action as shown in hints, e.g. "x/Delete"
+func (km *keymap) label(action keyAction) string {
+	var labels []string
+	for _, b := range km.bindings[action] {
+		labels = append(labels, b.String())
+	}
+	return strings.Join(labels, "/")
+}
+
+// hint is a shortcut shown in a status bar
+type hint struct {
+	label string
+	text  string
+}
+
+// shortcutHints renders status bar hints with the active key of each
+// action, first key only to keep the bar short
+func shortcutHints(hints ...hint) string {
+	var b strings.Builder
+	for _, h := range hints {
+		fmt.Fprintf(&b, "%s%s%s %s  ", TagPrimary, h.label, TagReset, h.text)
+	}
+	return strings.TrimSuffix(b.String(), " ")
+}
+
+// actionHint is the status bar hint of one action
+func actionHint(action keyAction, textKey string) hint {
+	label := ""
+	if bindings := keys.bindings[action]; len(bindings) > 0 {
+		label = bindings[0].String()
+	}
+	return hint{label: label, text: i18n.T(textKey)}
+}
+
+// navigationHint is the status bar hint for moving up and down
+func navigationHint() hint {
+	h := actionHint(actionUp, "shortcut.navigate")
+	if bindings := keys.bindings[actionDown]; len(bindings) > 0 {
+		h.label += bindings[0].String()
+	}
+	return h
+}
+
+// helpViewTitles are the i18n keys of the view titles in the help overlay
+var helpViewTitles = map[string]string{
+	viewClusters: "help.view.clusters",
+	viewEmpty:    "help.view.empty",
+	viewDetails:  "help.view.details",
+	viewCommands: "help.view.commands",
+	viewEvents:   "help.view.events",
+}
+
+// helpText lists the keys of every view, the current one first
+func helpText(current string) string {
+	views := append(keyViews[:0:0], keyViews...)
+	sort.SliceStable(views, func(i, j int) bool {
+		return views[i].name == current && views[j].name != current
+	})
+
+	var b strings.Builder
+	for _, view := range views {
+		fmt.Fprintf(&b, "[::b]%s[::-]\n", i18n.T(helpViewTitles[view.name]))
+		for _, action := range view.actions {
+			fmt.Fprintf(&b, "  %s%-12s%s %s\n", TagPrimary, tview.Escape(keys.label(action)), TagReset, i18n.T("action."+string(action)))
+		}
+		b.WriteString("\n")
+	}
+	b.WriteString(i18n.T("help.config", config.FilePath()))
+	return b.String()
+}
+
+// showHelp opens the keyboard shortcut overlay for a view. Back, help or
+// quit close it and return focus to where it was.
+func showHelp(view string) {
+	previous := app.GetFocus()
+
+	text := tview.NewTextView().
+		SetDynamicColors(true).
+		SetScrollable(true).
+		SetText(helpText(view))
+	text.SetBorder(true).
+		SetTitle(" " + i18n.T("help.title") + " ").
+		SetBorderColor(ColorMuted).
+		SetBackgroundColor(ColorBackground)
+
+	closeHelp := func() {
+		pages.RemovePage("help")
+		if previous != nil {
+			app.SetFocus(previous)
+		}
+	}
+	text.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		if event.Key() == tcell.KeyEscape {
+			closeHelp()
+			return nil
+		}
+		switch action := keys.action(view, event); action {
+		case actionBack, actionHelp, actionQuit:
+			closeHelp()
+			return nil
+		default:
+			if nav := navigate(action); nav != nil {
+				return nav
+			}
+		}
+		return event
+	})
+
+	overlay := tview.NewFlex().
+		AddItem(nil, 0, 1, false).
+		AddItem(tview.NewFlex().SetDirection(tview.FlexRow).
+			AddItem(nil, 0, 1, false).
+			AddItem(text, 0, 4, true).
+			AddItem(nil, 0, 1, false), 72, 0, true).
+		AddItem(nil, 0, 1, false)
+	pages.AddPage("help", overlay, true, true)
+	app.SetFocus(text)
+}
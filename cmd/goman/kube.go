@@ -6,10 +6,15 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"regexp"
+	"sort"
 	"strings"
 
 	"github.com/madhouselabs/goman/pkg/cluster"
+	"github.com/madhouselabs/goman/pkg/connectivity"
+	"github.com/madhouselabs/goman/pkg/i18n"
 	"github.com/madhouselabs/goman/pkg/models"
+	"github.com/madhouselabs/goman/pkg/provider"
 	"github.com/madhouselabs/goman/pkg/provider/registry"
 	"github.com/madhouselabs/goman/pkg/storage"
 	"github.com/spf13/cobra"
@@ -17,16 +22,9 @@ import (
 
 // kubeCmd represents the kube command
 var kubeCmd = &cobra.Command{
-	Use:   "kube [command]",
-	Short: "Run commands with KUBECONFIG configured",
-	Long: `Run any command with the KUBECONFIG environment variable set to the connected cluster.
-
-Examples:
-  goman kube kubectl get nodes       # Run kubectl commands
-  goman kube k9s                     # Launch k9s
-  goman kube bash                    # Open shell with KUBECONFIG set
-  goman kube helm install myapp .    # Run helm commands
-  goman kube --force-clean kubectl get nodes  # Force clean all tunnels before connecting`,
+	Use:                   "kube [command]",
+	Short:                 i18n.T("cmd.kube.short"),
+	Long:                  i18n.T("cmd.kube.long"),
 	Args:                  cobra.MinimumNArgs(1),
 	DisableFlagParsing:    true,
 	DisableFlagsInUseLine: true,
@@ -42,8 +40,9 @@ Examples:
 		}
 		
 		// Ensure SSM tunnel is established (connect on demand if needed)
-		fmt.Printf("🔄 Ensuring SSM tunnel to cluster %s...\n", clusterName)
-		if err := establishSSMTunnel(clusterName); err != nil {
+		outf("🔄 Ensuring SSM tunnel to cluster %s...\n", clusterName)
+		tunnel, private, err := establishSSMTunnel(clusterName)
+		if err != nil {
 			return fmt.Errorf("failed to establish tunnel: %w", err)
 		}
 
@@ -57,12 +56,20 @@ Examples:
 		
 		// Check if kubeconfig exists, download if missing
 		if _, err := os.Stat(kubeconfigPath); os.IsNotExist(err) {
-			fmt.Printf("📥 Downloading kubeconfig for cluster %s...\n", clusterName)
+			outf("📥 Downloading kubeconfig for cluster %s...\n", clusterName)
 			if err := downloadKubeconfig(clusterName); err != nil {
 				return fmt.Errorf("failed to download kubeconfig: %w", err)
 			}
 		}
 
+		// Masters of private clusters are only reachable through the tunnel
+		if private {
+			kubeconfigPath, err = writeTunnelKubeconfig(kubeconfigPath, tunnel)
+			if err != nil {
+				return err
+			}
+		}
+
 		// Prepare the command
 		cmdName := args[0]
 		cmdArgs := args[1:]
@@ -75,9 +82,9 @@ Examples:
 				shell = os.Getenv("SHELL")
 			}
 			
-			fmt.Printf("🚀 Opening shell with KUBECONFIG for cluster: %s\n", clusterName)
-			fmt.Println("Type 'exit' to return")
-			fmt.Println()
+			outf("🚀 Opening shell with KUBECONFIG for cluster: %s\n", clusterName)
+			outln("Type 'exit' to return")
+			outln()
 
 			// Create shell with custom prompt
 			shellCmd := exec.Command(shell)
@@ -115,8 +122,8 @@ Examples:
 
 		// Show what we're running (except for interactive tools)
 		if cmdName != "k9s" && cmdName != "kubectl" {
-			fmt.Printf("🚀 Running: %s %s\n", cmdName, strings.Join(cmdArgs, " "))
-			fmt.Printf("   Cluster: %s\n\n", clusterName)
+			outf("🚀 Running: %s %s\n", cmdName, strings.Join(cmdArgs, " "))
+			outf("   Cluster: %s\n\n", clusterName)
 		}
 
 		return runCmd.Run()
@@ -134,7 +141,7 @@ func findConnectedCluster() string {
 	}
 	
 	clusters := clusterManager.GetClusters()
-	stm := GetGlobalSingleTunnelManager()
+	stm := GetGlobalTunnelManager()
 	for _, cluster := range clusters {
 		if stm.IsConnected(cluster.Name) {
 			return cluster.Name
@@ -143,9 +150,9 @@ func findConnectedCluster() string {
 	return ""
 }
 
-// downloadKubeconfig downloads the kubeconfig from S3 if it doesn't exist locally
+// downloadKubeconfig downloads the kubeconfig from the secret backend if it doesn't exist locally
 func downloadKubeconfig(clusterName string) error {
-	// Initialize AWS provider and storage
+	// Initialize AWS provider and secret backend
 	profile := os.Getenv("AWS_PROFILE")
 	if profile == "" {
 		profile = "default"
@@ -155,19 +162,18 @@ func downloadKubeconfig(clusterName string) error {
 		region = "ap-south-1"
 	}
 
-	provider, err := registry.GetProvider("aws", profile, region)
+	p, err := registry.GetProvider("aws", profile, region)
 	if err != nil {
 		return fmt.Errorf("failed to initialize AWS provider: %w", err)
 	}
 
-	storageService := provider.GetStorageService()
+	secretService := p.GetSecretService()
 	ctx := context.Background()
 
-	// Download kubeconfig from S3
-	kubeconfigKey := fmt.Sprintf("clusters/%s/kubeconfig", clusterName)
-	kubeconfigData, err := storageService.GetObject(ctx, kubeconfigKey)
+	// Fetch the kubeconfig from the configured secret backend
+	kubeconfigData, err := secretService.GetSecret(ctx, clusterName, provider.SecretKubeconfig)
 	if err != nil {
-		return fmt.Errorf("failed to download kubeconfig from S3: %w", err)
+		return fmt.Errorf("failed to download kubeconfig from %s: %w", secretService.Backend(), err)
 	}
 
 	// Save kubeconfig to local filesystem
@@ -186,12 +192,38 @@ func downloadKubeconfig(clusterName string) error {
 		return fmt.Errorf("failed to save kubeconfig: %w", err)
 	}
 
-	fmt.Printf("✅ Downloaded kubeconfig for cluster %s\n", clusterName)
+	outf("✅ Downloaded kubeconfig for cluster %s\n", clusterName)
 	return nil
 }
 
-// establishSSMTunnel establishes an SSM tunnel to the cluster using SingleTunnelManager
-func establishSSMTunnel(clusterName string) error {
+// kubeconfigServerPattern matches the API server lines of a kubeconfig
+var kubeconfigServerPattern = regexp.MustCompile(`(?m)^(\s*server:\s*)\S+$`)
+
+// writeTunnelKubeconfig writes a copy of a kubeconfig whose API servers are
+// the local end of the cluster's SSM tunnel and returns its path. K3s always
+// serves 127.0.0.1 in its certificate.
+func writeTunnelKubeconfig(kubeconfigPath string, tunnel *connectivity.TunnelState) (string, error) {
+	kubeconfig, err := os.ReadFile(kubeconfigPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to read kubeconfig: %w", err)
+	}
+	port := tunnel.LocalPort
+	if port == 0 {
+		port = 6443
+	}
+	updated := kubeconfigServerPattern.ReplaceAll(kubeconfig, []byte(fmt.Sprintf("${1}https://127.0.0.1:%d", port)))
+
+	tunnelPath := strings.TrimSuffix(kubeconfigPath, ".yaml") + "-tunnel.yaml"
+	if err := os.WriteFile(tunnelPath, updated, 0600); err != nil {
+		return "", fmt.Errorf("failed to save tunnel kubeconfig: %w", err)
+	}
+	return tunnelPath, nil
+}
+
+// establishSSMTunnel establishes an SSM tunnel to the cluster using the
+// tunnel manager. It also reports whether the cluster is private, so its API
+// server is only reachable through the tunnel.
+func establishSSMTunnel(clusterName string) (*connectivity.TunnelState, bool, error) {
 	// Initialize cluster manager if needed
 	if clusterManager == nil {
 		clusterManager = cluster.NewManager()
@@ -208,16 +240,21 @@ func establishSSMTunnel(clusterName string) error {
 	}
 
 	if targetCluster == nil {
-		return fmt.Errorf("cluster %s not found", clusterName)
+		return nil, false, fmt.Errorf("cluster %s not found", clusterName)
 	}
 
-	// Get master instance ID
-	masterInstanceID := ""
-	if len(targetCluster.MasterNodes) > 0 {
-		masterInstanceID = targetCluster.MasterNodes[0].ID
+	// Every master of HA clusters, master-0 first, so the tunnel fails over
+	// to the next one
+	masters := append([]models.Node(nil), targetCluster.MasterNodes...)
+	sort.Slice(masters, func(i, j int) bool { return masters[i].Name < masters[j].Name })
+	var masterInstanceIDs []string
+	for _, node := range masters {
+		if node.ID != "" {
+			masterInstanceIDs = append(masterInstanceIDs, node.ID)
+		}
 	}
 
-	if masterInstanceID == "" {
+	if len(masterInstanceIDs) == 0 {
 		// Try to get from S3 state
 		profile := os.Getenv("AWS_PROFILE")
 		if profile == "" {
@@ -227,40 +264,35 @@ func establishSSMTunnel(clusterName string) error {
 		// Get provider first
 		provider, err := registry.GetProvider("aws", profile, targetCluster.Region)
 		if err != nil {
-			return fmt.Errorf("failed to initialize provider: %w", err)
+			return nil, false, fmt.Errorf("failed to initialize provider: %w", err)
 		}
 		
 		storageInstance, err := storage.NewStorageWithProvider(provider)
 		if err != nil {
-			return fmt.Errorf("failed to initialize storage: %w", err)
+			return nil, false, fmt.Errorf("failed to initialize storage: %w", err)
 		}
 		backend := storageInstance.GetBackend()
 
 		clusterState, err := backend.LoadClusterState(clusterName)
 		if err != nil {
-			return fmt.Errorf("failed to load cluster state: %w", err)
+			return nil, false, fmt.Errorf("failed to load cluster state: %w", err)
 		}
 
-		// For HA clusters, connect to master-0 specifically
-		for nodeName, instanceID := range clusterState.InstanceIDs {
-			if strings.Contains(nodeName, "master-0") {
-				masterInstanceID = instanceID
-				break
+		// Masters in name order, so master-0 is preferred
+		var masterNames []string
+		for nodeName := range clusterState.InstanceIDs {
+			if strings.Contains(nodeName, "master") {
+				masterNames = append(masterNames, nodeName)
 			}
 		}
-		// Fallback to any master if master-0 not found
-		if masterInstanceID == "" {
-			for nodeName, instanceID := range clusterState.InstanceIDs {
-				if strings.Contains(nodeName, "master") {
-					masterInstanceID = instanceID
-					break
-				}
-			}
+		sort.Strings(masterNames)
+		for _, nodeName := range masterNames {
+			masterInstanceIDs = append(masterInstanceIDs, clusterState.InstanceIDs[nodeName])
 		}
 	}
 
-	if masterInstanceID == "" {
-		return fmt.Errorf("no master instance found for cluster %s", clusterName)
+	if len(masterInstanceIDs) == 0 {
+		return nil, false, fmt.Errorf("no master instance found for cluster %s", clusterName)
 	}
 
 	// Get cluster region
@@ -272,11 +304,12 @@ func establishSSMTunnel(clusterName string) error {
 		}
 	}
 	
-	// Use SingleTunnelManager to ensure tunnel
-	stm := GetGlobalSingleTunnelManager()
-	if err := stm.EnsureTunnel(clusterName, masterInstanceID, region); err != nil {
-		return fmt.Errorf("failed to ensure SSM tunnel: %w", err)
+	// Each cluster gets its own local port, so tunnels to other clusters
+	// stay up
+	tunnel, err := GetGlobalTunnelManager().EnsureTunnel(clusterName, masterInstanceIDs, region)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to ensure SSM tunnel: %w", err)
 	}
 	
-	return nil
+	return tunnel, targetCluster.PrivateNetwork, nil
 }
\ No newline at end of file
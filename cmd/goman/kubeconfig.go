@@ -0,0 +1,96 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/madhouselabs/goman/pkg/i18n"
+	"github.com/spf13/cobra"
+)
+
+var (
+	shareTTL        time.Duration
+	kubeconfigAdmin bool
+	kubeconfigOut   string
+)
+
+// kubeconfigCmd groups kubeconfig operations
+var kubeconfigCmd = &cobra.Command{
+	Use:   "kubeconfig",
+	Short: i18n.T("cmd.kubeconfig.short"),
+}
+
+// kubeconfigShareCmd prints a time-limited kubeconfig download URL
+var kubeconfigShareCmd = &cobra.Command{
+	Use:   "share <cluster-name>",
+	Short: i18n.T("cmd.kubeconfig.share.short"),
+	Long:  i18n.T("cmd.kubeconfig.share.long"),
+	Example: `  goman kubeconfig share prod --ttl 1h
+  goman kubeconfig share prod --ttl 1h --admin
+  curl -so prod.yaml "$(goman kubeconfig share prod --plain)"`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		c, err := findCluster(resolveClusterAlias(args[0]))
+		if err != nil {
+			return err
+		}
+
+		share, err := clusterManager.ShareKubeconfig(c.Name, shareTTL, kubeconfigAdmin)
+		if err != nil {
+			return err
+		}
+
+		if plainMode() {
+			fmt.Println(share.URL)
+			return nil
+		}
+		access := "Read-only"
+		if kubeconfigAdmin {
+			access = "Admin"
+		}
+		outf("🔗 %s kubeconfig for %s, valid until %s:\n\n", access, c.Name, share.ExpiresAt.Local().Format("2006-01-02 15:04:05"))
+		outf("%s\n\n", share.URL)
+		outln("💡 Download with: curl -so " + c.Name + ".yaml '<url>'")
+		return nil
+	},
+}
+
+// kubeconfigGetCmd prints or saves a cluster kubeconfig
+var kubeconfigGetCmd = &cobra.Command{
+	Use:   "get <cluster-name>",
+	Short: i18n.T("cmd.kubeconfig.get.short"),
+	Long:  i18n.T("cmd.kubeconfig.get.long"),
+	Example: `  goman kubeconfig get prod -o prod-view.yaml
+  goman kubeconfig get prod --admin > prod-admin.yaml`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		c, err := findCluster(resolveClusterAlias(args[0]))
+		if err != nil {
+			return err
+		}
+
+		data, err := clusterManager.GetKubeconfig(c.Name, kubeconfigAdmin)
+		if err != nil {
+			return err
+		}
+		if kubeconfigOut == "" || kubeconfigOut == "-" {
+			_, err = os.Stdout.Write(data)
+			return err
+		}
+		if err := os.WriteFile(kubeconfigOut, data, 0600); err != nil {
+			return fmt.Errorf("failed to save kubeconfig: %w", err)
+		}
+		outf("✅ Kubeconfig for %s saved to %s\n", c.Name, kubeconfigOut)
+		return nil
+	},
+}
+
+func init() {
+	kubeconfigShareCmd.Flags().DurationVar(&shareTTL, "ttl", time.Hour, "How long the link stays valid (max 168h)")
+	kubeconfigShareCmd.Flags().BoolVar(&kubeconfigAdmin, "admin", false, "Share the admin kubeconfig instead of the read-only one")
+	kubeconfigGetCmd.Flags().BoolVar(&kubeconfigAdmin, "admin", false, "Get the admin kubeconfig instead of the read-only one")
+	kubeconfigGetCmd.Flags().StringVarP(&kubeconfigOut, "output", "o", "", "Write the kubeconfig to a file instead of stdout")
+	kubeconfigCmd.AddCommand(kubeconfigShareCmd)
+	kubeconfigCmd.AddCommand(kubeconfigGetCmd)
+}
@@ -7,22 +7,20 @@ import (
 	"path/filepath"
 	"time"
 
+	"github.com/madhouselabs/goman/pkg/i18n"
 	"github.com/madhouselabs/goman/pkg/provider/registry"
 	"github.com/madhouselabs/goman/pkg/storage"
 	"github.com/spf13/cobra"
 )
 
 // Use global single tunnel manager
-var singleTunnelManager = GetGlobalSingleTunnelManager()
+var tunnelManager = GetGlobalTunnelManager()
 
 // kubectlCmd represents the kubectl command group
 var kubectlCmd = &cobra.Command{
 	Use:   "kubectl",
-	Short: "Manage kubectl access to K3s clusters",
-	Long: `Connect to K3s clusters using secure SSM port forwarding.
-No public IPs or open security groups required.
-
-When run without subcommands, shows an interactive cluster selector.`,
+	Short: i18n.T("cmd.kubectl.short"),
+	Long:  i18n.T("cmd.kubectl.long"),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		// If no subcommand, show interactive selector
 		selected, err := getOrSelectCluster("", "manage")
@@ -30,12 +28,12 @@ When run without subcommands, shows an interactive cluster selector.`,
 			return err
 		}
 		
-		fmt.Printf("\nSelected cluster: %s\n\n", selected)
-		fmt.Println("Available commands:")
-		fmt.Printf("  goman kubectl connect %s         # Connect to cluster\n", selected)
-		fmt.Printf("  goman kubectl exec %s -- get nodes  # Execute kubectl command\n", selected)
-		fmt.Printf("  goman kubectl disconnect %s      # Disconnect from cluster\n", selected)
-		fmt.Printf("  goman kubectl status %s          # Show connection status\n", selected)
+		outf("\nSelected cluster: %s\n\n", selected)
+		outln("Available commands:")
+		outf("  goman kubectl connect %s         # Connect to cluster\n", selected)
+		outf("  goman kubectl exec %s -- get nodes  # Execute kubectl command\n", selected)
+		outf("  goman kubectl disconnect %s      # Disconnect from cluster\n", selected)
+		outf("  goman kubectl status %s          # Show connection status\n", selected)
 		
 		return nil
 	},
@@ -44,11 +42,9 @@ When run without subcommands, shows an interactive cluster selector.`,
 // connectCmd connects to a cluster
 var connectCmd = &cobra.Command{
 	Use:   "connect [cluster-name]",
-	Short: "Connect to a K3s cluster via SSM tunnel",
-	Long: `Establishes a secure SSM port forwarding session to the K3s API server.
-Downloads the kubeconfig and sets up kubectl context.
-If no cluster name is provided, shows an interactive selector.`,
-	Args: cobra.MaximumNArgs(1),
+	Short: i18n.T("cmd.kubectl.connect.short"),
+	Long:  i18n.T("cmd.kubectl.connect.long"),
+	Args:  cobra.MaximumNArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		var clusterName string
 		if len(args) > 0 {
@@ -60,7 +56,7 @@ If no cluster name is provided, shows an interactive selector.`,
 				return err
 			}
 			clusterName = selected
-			fmt.Printf("\nConnecting to cluster: %s\n", clusterName)
+			outf("\nConnecting to cluster: %s\n", clusterName)
 		}
 		return connectToClusterCLI(clusterName)
 	},
@@ -69,8 +65,8 @@ If no cluster name is provided, shows an interactive selector.`,
 // disconnectCmd disconnects from a cluster
 var disconnectCmd = &cobra.Command{
 	Use:   "disconnect [cluster-name]",
-	Short: "Disconnect from a K3s cluster",
-	Long:  "Stops the SSM tunnel for a connected cluster.\nIf no cluster name is provided, shows an interactive selector.",
+	Short: i18n.T("cmd.kubectl.disconnect.short"),
+	Long:  i18n.T("cmd.kubectl.disconnect.long"),
 	Args:  cobra.MaximumNArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		var clusterName string
@@ -83,7 +79,7 @@ var disconnectCmd = &cobra.Command{
 				return err
 			}
 			clusterName = selected
-			fmt.Printf("\nDisconnecting from cluster: %s\n", clusterName)
+			outf("\nDisconnecting from cluster: %s\n", clusterName)
 		}
 		return disconnectFromCluster(clusterName)
 	},
@@ -92,8 +88,8 @@ var disconnectCmd = &cobra.Command{
 // execCmd executes kubectl commands with automatic connection
 var execCmd = &cobra.Command{
 	Use:                "exec [cluster-name] -- [kubectl args]",
-	Short:              "Execute kubectl commands on a cluster",
-	Long:               "Execute kubectl commands on a cluster with automatic SSM tunnel setup.\nIf no cluster name is provided, shows an interactive selector.",
+	Short:              i18n.T("cmd.kubectl.exec.short"),
+	Long:               i18n.T("cmd.kubectl.exec.long"),
 	DisableFlagParsing: true,
 	Args:               cobra.MinimumNArgs(0),
 	RunE: func(cmd *cobra.Command, args []string) error {
@@ -118,8 +114,8 @@ var execCmd = &cobra.Command{
 					return err
 				}
 				clusterName = selected
-				fmt.Printf("\nSelected cluster: %s\n", clusterName)
-				fmt.Println("Now enter kubectl command (e.g., 'get nodes', 'get pods -A'):")
+				outf("\nSelected cluster: %s\n", clusterName)
+				outln("Now enter kubectl command (e.g., 'get nodes', 'get pods -A'):")
 				return fmt.Errorf("please run: goman kubectl exec %s -- <kubectl command>", clusterName)
 			}
 			return fmt.Errorf("usage: goman kubectl exec [cluster-name] -- [kubectl commands]")
@@ -134,7 +130,7 @@ var execCmd = &cobra.Command{
 			}
 			clusterName = selected
 			kubectlArgs = args[1:]
-			fmt.Printf("\nExecuting on cluster: %s\n", clusterName)
+			outf("\nExecuting on cluster: %s\n", clusterName)
 		} else {
 			// Format: "goman kubectl exec cluster-name -- get nodes"
 			clusterName = args[0]
@@ -152,7 +148,7 @@ var execCmd = &cobra.Command{
 // statusCmd shows connection status
 var statusCmd = &cobra.Command{
 	Use:   "status [cluster-name]",
-	Short: "Show connection status for a cluster",
+	Short: i18n.T("cmd.kubectl.status.short"),
 	Args:  cobra.MaximumNArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		if len(args) == 0 {
@@ -172,23 +168,23 @@ func init() {
 
 
 func disconnectFromCluster(clusterName string) error {
-	if !singleTunnelManager.IsConnected(clusterName) {
-		fmt.Printf("Not connected to cluster %s\n", clusterName)
+	if !tunnelManager.IsConnected(clusterName) {
+		outf("Not connected to cluster %s\n", clusterName)
 		return nil
 	}
 
-	if err := singleTunnelManager.StopTunnel(clusterName); err != nil {
+	if err := tunnelManager.StopTunnel(clusterName); err != nil {
 		return fmt.Errorf("failed to stop tunnel: %w", err)
 	}
 
-	fmt.Printf("Disconnected from cluster %s\n", clusterName)
+	outf("Disconnected from cluster %s\n", clusterName)
 	return nil
 }
 
 func executeKubectlCommand(clusterName string, kubectlArgs []string) error {
 	// Ensure connected
-	if !singleTunnelManager.IsConnected(clusterName) {
-		fmt.Printf("Connecting to cluster %s...\n", clusterName)
+	if !tunnelManager.IsConnected(clusterName) {
+		outf("Connecting to cluster %s...\n", clusterName)
 		if err := connectToClusterCLI(clusterName); err != nil {
 			return err
 		}
@@ -216,20 +212,20 @@ func executeKubectlCommand(clusterName string, kubectlArgs []string) error {
 }
 
 func showConnectionStatus(clusterName string) error {
-	if singleTunnelManager.IsConnected(clusterName) {
-		tunnel := singleTunnelManager.GetTunnelInfo(clusterName)
+	if tunnelManager.IsConnected(clusterName) {
+		tunnel := tunnelManager.GetTunnelInfo(clusterName)
 		if tunnel != nil {
-		fmt.Printf("Cluster: %s\n", clusterName)
-		fmt.Printf("Status: Connected\n")
-		fmt.Printf("Instance: %s\n", tunnel.InstanceID)
-			fmt.Printf("Port Forwarding: localhost:%d -> %d\n", tunnel.LocalPort, tunnel.RemotePort)
+		outf("Cluster: %s\n", clusterName)
+		outf("Status: Connected\n")
+		outf("Instance: %s\n", tunnel.InstanceID)
+			outf("Port Forwarding: localhost:%d -> %d\n", tunnel.LocalPort, tunnel.RemotePort)
 		} else {
-			fmt.Printf("Cluster: %s\n", clusterName)
-			fmt.Printf("Status: Not connected\n")
+			outf("Cluster: %s\n", clusterName)
+			outf("Status: Not connected\n")
 		}
 	} else {
-		fmt.Printf("Cluster: %s\n", clusterName)
-		fmt.Printf("Status: Not connected\n")
+		outf("Cluster: %s\n", clusterName)
+		outf("Status: Not connected\n")
 	}
 	return nil
 }
@@ -261,19 +257,19 @@ func showAllConnectionStatus() error {
 	}
 
 	if len(clusters) == 0 {
-		fmt.Println("No clusters found")
+		outln("No clusters found")
 		return nil
 	}
 
-	fmt.Println("Cluster Connection Status:")
-	fmt.Println("─────────────────────────")
+	outln("Cluster Connection Status:")
+	outln("─────────────────────────")
 	
 	for _, cluster := range clusters {
 		status := "Not connected"
-		if singleTunnelManager.IsConnected(cluster.Name) {
+		if tunnelManager.IsConnected(cluster.Name) {
 			status = "Connected"
 		}
-		fmt.Printf("%-20s %s\n", cluster.Name, status)
+		outf("%-20s %s\n", cluster.Name, status)
 	}
 
 	return nil
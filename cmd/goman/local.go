@@ -0,0 +1,58 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/madhouselabs/goman/pkg/config"
+	"github.com/madhouselabs/goman/pkg/i18n"
+	"github.com/madhouselabs/goman/pkg/logger"
+	"github.com/madhouselabs/goman/pkg/provider/local"
+	"github.com/spf13/cobra"
+)
+
+// localCmd represents the local provider command group
+var localCmd = &cobra.Command{
+	Use:   "local",
+	Short: i18n.T("cmd.local.short"),
+	Long:  i18n.T("cmd.local.long"),
+}
+
+// localControllerCmd runs the reconciler for local clusters
+var localControllerCmd = &cobra.Command{
+	Use:   "controller",
+	Short: i18n.T("cmd.local.controller.short"),
+	Long:  i18n.T("cmd.local.controller.long"),
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if config.GetProviderType() != "local" {
+			outln("Note: GOMAN_PROVIDER is not local; other goman commands will not see these clusters")
+		}
+		p, err := local.GetCachedProvider("")
+		if err != nil {
+			return err
+		}
+		controller, err := local.NewController(p)
+		if err != nil {
+			return err
+		}
+		// The controller's records are the output of this command
+		logger.SetSilent(false)
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		outf("Reconciling local clusters in %s (Ctrl+C to stop)\n", config.GetLocalDir())
+		if err := controller.Run(ctx); err != nil {
+			return fmt.Errorf("controller stopped: %w", err)
+		}
+		outln("Controller stopped")
+		return nil
+	},
+}
+
+func init() {
+	localCmd.AddCommand(localControllerCmd)
+}
@@ -1,13 +1,17 @@
 package main
 
 import (
-	"fmt"
 	"os"
+	"os/signal"
+	"syscall"
 	"time"
 
 	"github.com/madhouselabs/goman/pkg/cluster"
 	"github.com/madhouselabs/goman/pkg/config"
+	"github.com/madhouselabs/goman/pkg/i18n"
 	"github.com/madhouselabs/goman/pkg/models"
+	"github.com/madhouselabs/goman/pkg/provider"
+	"github.com/madhouselabs/goman/pkg/version"
 	"github.com/rivo/tview"
 	"github.com/spf13/cobra"
 )
@@ -28,17 +32,23 @@ var (
 
 func main() {
 	var rootCmd = &cobra.Command{
-		Use:   "goman",
-		Short: "Goman - Kubernetes Cluster Manager",
-		Long:  `Goman is a CLI tool for managing Kubernetes clusters on AWS.`,
+		Use:     "goman",
+		Short:   i18n.T("cmd.root.short"),
+		Long:    i18n.T("cmd.root.long"),
+		Version: version.String(),
 		Run: func(cmd *cobra.Command, args []string) {
+			if plainMode() {
+				printPlainClusters()
+				return
+			}
 			runTUI()
 		},
 	}
+	rootCmd.PersistentFlags().BoolVar(&plainOutput, "plain", false, "Screen-reader friendly output: linear text, no box drawing, icons or color")
 
 	var initCmd = &cobra.Command{
 		Use:   "init",
-		Short: "Initialize infrastructure",
+		Short: i18n.T("cmd.init.short"),
 		Run: func(cmd *cobra.Command, args []string) {
 			initializeInfrastructure()
 		},
@@ -46,7 +56,7 @@ func main() {
 
 	var cleanupCmd = &cobra.Command{
 		Use:    "cleanup [cluster-name]",
-		Short:  "Force cleanup a cluster (removes all resources)",
+		Short:  i18n.T("cmd.cleanup.short"),
 		Hidden: true, // Hidden command for troubleshooting
 		Args:   cobra.ExactArgs(1),
 		Run: func(cmd *cobra.Command, args []string) {
@@ -54,15 +64,27 @@ func main() {
 		},
 	}
 
+	initCmd.Flags().StringVar(&initSessionLogging, "session-logging", "", "Log remote access (SSM commands, tunnels) for compliance: s3, cloudwatch, s3,cloudwatch or off")
+	initCmd.Flags().StringVar(&initRetention, "retention", "", "Days artifacts are kept in the state bucket, e.g. diagnostics=30,logs=90,binaries=30, or off")
 	rootCmd.AddCommand(initCmd)
 	rootCmd.AddCommand(cleanupCmd)
 	rootCmd.AddCommand(kubectlCmd)
 	rootCmd.AddCommand(clusterCmd)
 	rootCmd.AddCommand(kubeCmd)
+	rootCmd.AddCommand(kubeconfigCmd)
 	rootCmd.AddCommand(tunnelCmd)
+	rootCmd.AddCommand(auditCmd)
+	rootCmd.AddCommand(adminCmd)
+	rootCmd.AddCommand(nodeCmd)
+	rootCmd.AddCommand(versionCmd)
+	rootCmd.AddCommand(localCmd)
+	rootCmd.AddCommand(debugCmd)
 
 	if err := rootCmd.Execute(); err != nil {
-		fmt.Println(err)
+		outln(err)
+		if category, ok := provider.CategoryOf(err); ok {
+			outf("%s: %s\n", category, category.Guidance())
+		}
 		os.Exit(1)
 	}
 }
@@ -72,7 +94,14 @@ func runTUI() {
 	var err error
 	cfg, err = config.NewConfig()
 	if err != nil {
-		fmt.Printf("Error loading config: %v\n", err)
+		outln(i18n.Error("error.load_config", err))
+		os.Exit(1)
+	}
+
+	// Key bindings from the config file
+	keys, err = loadKeymap()
+	if err != nil {
+		outln(i18n.Error("error.load_keymap", err))
 		os.Exit(1)
 	}
 
@@ -94,6 +123,12 @@ func runTUI() {
 	// Initial refresh when starting
 	go refreshClustersAsync()
 
+	// Probe cluster API servers for the health badge
+	startAPIHealthProber()
+
+	// Keep tunnels opened from the CLI up while the TUI runs
+	startTunnelWatcher()
+
 	// Start periodic refresh every 5 seconds
 	go func() {
 		ticker := time.NewTicker(5 * time.Second)
@@ -105,6 +140,15 @@ func runTUI() {
 		}
 	}()
 
+	// Stop cleanly when the terminal is closed or goman is terminated, so the
+	// terminal is restored. Open editor forms keep their drafts, see editYAML.
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, syscall.SIGHUP, syscall.SIGTERM)
+	go func() {
+		<-sigCh
+		app.Stop()
+	}()
+
 	// Set root and run
 	if err := app.SetRoot(pages, true).EnableMouse(true).Run(); err != nil {
 		panic(err)
@@ -0,0 +1,679 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/madhouselabs/goman/pkg/audit"
+	"github.com/madhouselabs/goman/pkg/cluster"
+	"github.com/madhouselabs/goman/pkg/i18n"
+	"github.com/madhouselabs/goman/pkg/models"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	manifestAPIVersion = "goman.io/v1"
+	manifestKind       = "Cluster"
+)
+
+// clusterManifest is the declarative form of a cluster's spec, meant to be
+// kept in git and re-applied with 'goman cluster apply -f'
+type clusterManifest struct {
+	APIVersion string           `json:"apiVersion" yaml:"apiVersion"`
+	Kind       string           `json:"kind" yaml:"kind"`
+	Metadata   manifestMetadata `json:"metadata" yaml:"metadata"`
+	Spec       manifestSpec     `json:"spec" yaml:"spec"`
+}
+
+type manifestMetadata struct {
+	Name string `json:"name" yaml:"name"`
+}
+
+// manifestSpec holds the user-owned fields of a cluster. Everything the
+// controller derives (nodes, IPs, status) and one-off requests are left out.
+type manifestSpec struct {
+	Description        string                         `json:"description,omitempty" yaml:"description,omitempty"`
+	Mode               string                         `json:"mode" yaml:"mode"`
+	Region             string                         `json:"region" yaml:"region"`
+	Preset             string                         `json:"preset,omitempty" yaml:"preset,omitempty"`
+	InstanceType       string                         `json:"instanceType,omitempty" yaml:"instanceType,omitempty"`
+	LowResource        bool                           `json:"lowResource,omitempty" yaml:"lowResource,omitempty"`
+	Architecture       string                         `json:"architecture,omitempty" yaml:"architecture,omitempty"`
+	ImageID            string                         `json:"imageID,omitempty" yaml:"imageID,omitempty"`
+	OSFamily           string                         `json:"osFamily,omitempty" yaml:"osFamily,omitempty"`
+	K3sVersion         string                         `json:"k3sVersion,omitempty" yaml:"k3sVersion,omitempty"`
+	RootVolume         *models.RootVolume             `json:"rootVolume,omitempty" yaml:"rootVolume,omitempty"`
+	Tags               map[string]string              `json:"tags,omitempty" yaml:"tags,omitempty"`
+	DriftPolicy        map[string]models.DriftPolicy  `json:"driftPolicy,omitempty" yaml:"driftPolicy,omitempty"`
+	DNS                *models.DNSSpec                `json:"dns,omitempty" yaml:"dns,omitempty"`
+	VirtualIP          *models.VirtualIPSpec          `json:"virtualIP,omitempty" yaml:"virtualIP,omitempty"`
+	LoadBalancer       *models.LoadBalancerSpec       `json:"loadBalancer,omitempty" yaml:"loadBalancer,omitempty"`
+	InstanceProtection *models.InstanceProtectionSpec `json:"instanceProtection,omitempty" yaml:"instanceProtection,omitempty"`
+	ClusterLinks       []models.ClusterLink           `json:"clusterLinks,omitempty" yaml:"clusterLinks,omitempty"`
+	Addons             []models.Addon                 `json:"addons,omitempty" yaml:"addons,omitempty"`
+	Notifications      *models.NotificationPolicy     `json:"notifications,omitempty" yaml:"notifications,omitempty"`
+	Proxy              *models.ProxySpec              `json:"proxy,omitempty" yaml:"proxy,omitempty"`
+	Bootstrap          *models.BootstrapSpec          `json:"bootstrap,omitempty" yaml:"bootstrap,omitempty"`
+	Registries         *models.RegistrySpec           `json:"registries,omitempty" yaml:"registries,omitempty"`
+	Schedule           *models.ScheduleSpec           `json:"schedule,omitempty" yaml:"schedule,omitempty"`
+	Network            *manifestNetwork               `json:"network,omitempty" yaml:"network,omitempty"`
+	NodePools          []manifestNodePool             `json:"nodePools,omitempty" yaml:"nodePools,omitempty"`
+}
+
+type manifestNodePool struct {
+	Name          string              `json:"name" yaml:"name"`
+	Count         int                 `json:"count" yaml:"count"`
+	InstanceType  string              `json:"instanceType" yaml:"instanceType"`
+	Labels        map[string]string   `json:"labels,omitempty" yaml:"labels,omitempty"`
+	Taints        []manifestTaint     `json:"taints,omitempty" yaml:"taints,omitempty"`
+	Volumes       []models.DataVolume `json:"volumes,omitempty" yaml:"volumes,omitempty"`
+	InstanceStore bool                `json:"instanceStore,omitempty" yaml:"instanceStore,omitempty"`
+	Paused        bool                `json:"paused,omitempty" yaml:"paused,omitempty"`
+	RootVolume    *models.RootVolume  `json:"rootVolume,omitempty" yaml:"rootVolume,omitempty"`
+	UserData      string              `json:"userData,omitempty" yaml:"userData,omitempty"`
+	Architecture  string              `json:"architecture,omitempty" yaml:"architecture,omitempty"`
+	ImageID       string              `json:"imageID,omitempty" yaml:"imageID,omitempty"`
+	OSFamily      string              `json:"osFamily,omitempty" yaml:"osFamily,omitempty"`
+
+	ScaleDownBehavior string   `json:"scaleDownBehavior,omitempty" yaml:"scaleDownBehavior,omitempty"`
+	AvailabilityZones []string `json:"availabilityZones,omitempty" yaml:"availabilityZones,omitempty"`
+}
+
+// manifestNetwork is fixed at creation
+type manifestNetwork struct {
+	Private          bool     `json:"private,omitempty" yaml:"private,omitempty"`
+	VpcID            string   `json:"vpcID,omitempty" yaml:"vpcID,omitempty"`
+	SubnetIDs        []string `json:"subnetIDs,omitempty" yaml:"subnetIDs,omitempty"`
+	SecurityGroupIDs []string `json:"securityGroupIDs,omitempty" yaml:"securityGroupIDs,omitempty"`
+	DedicatedVPC     bool     `json:"dedicatedVPC,omitempty" yaml:"dedicatedVPC,omitempty"`
+	VpcCIDR          string   `json:"vpcCIDR,omitempty" yaml:"vpcCIDR,omitempty"`
+}
+
+// network returns the network settings of the manifest, zero for the
+// default VPC
+func (s manifestSpec) network() models.NetworkConfig {
+	if s.Network == nil {
+		return models.NetworkConfig{}
+	}
+	return models.NetworkConfig{
+		Private:          s.Network.Private,
+		VpcID:            s.Network.VpcID,
+		SubnetIDs:        s.Network.SubnetIDs,
+		SecurityGroupIDs: s.Network.SecurityGroupIDs,
+		DedicatedVPC:     s.Network.DedicatedVPC,
+		VpcCIDR:          s.Network.VpcCIDR,
+	}
+}
+
+// clusterNetwork returns the network settings of a cluster
+func clusterNetwork(c models.K3sCluster) models.NetworkConfig {
+	return models.NetworkConfig{
+		Private:          c.PrivateNetwork,
+		VpcID:            c.VpcID,
+		SubnetIDs:        c.SubnetIDs,
+		SecurityGroupIDs: c.SecurityGroupIDs,
+		DedicatedVPC:     c.DedicatedVPC,
+		VpcCIDR:          c.VpcCIDR,
+	}
+}
+
+type manifestTaint struct {
+	Key    string `json:"key" yaml:"key"`
+	Value  string `json:"value" yaml:"value"`
+	Effect string `json:"effect" yaml:"effect"`
+}
+
+// exportManifest builds the manifest of an existing cluster
+func exportManifest(c models.K3sCluster) clusterManifest {
+	spec := manifestSpec{
+		Description:        c.Description,
+		Mode:               string(c.Mode),
+		Region:             c.Region,
+		Preset:             c.Preset,
+		InstanceType:       c.InstanceType,
+		LowResource:        c.LowResource,
+		Architecture:       c.Architecture,
+		ImageID:            c.ImageID,
+		OSFamily:           c.OSFamily,
+		K3sVersion:         c.K3sVersion,
+		RootVolume:         c.RootVolume,
+		DriftPolicy:        c.DriftPolicy,
+		DNS:                c.DNS,
+		VirtualIP:          c.VirtualIP,
+		LoadBalancer:       c.LoadBalancer,
+		InstanceProtection: c.InstanceProtection,
+		ClusterLinks:       c.ClusterLinks,
+		Addons:             c.Addons,
+		Notifications:      c.Notifications,
+		Proxy:              c.Proxy,
+		Bootstrap:          c.Bootstrap,
+		Registries:         c.Registries,
+		Schedule:           c.Schedule,
+	}
+	if tags := models.ParseResourceTags(c.Tags); len(tags) > 0 {
+		spec.Tags = tags
+	}
+	if c.PrivateNetwork || c.VpcID != "" || len(c.SecurityGroupIDs) > 0 || c.DedicatedVPC {
+		spec.Network = &manifestNetwork{
+			Private:          c.PrivateNetwork,
+			VpcID:            c.VpcID,
+			SubnetIDs:        c.SubnetIDs,
+			SecurityGroupIDs: c.SecurityGroupIDs,
+			DedicatedVPC:     c.DedicatedVPC,
+			VpcCIDR:          c.VpcCIDR,
+		}
+	}
+	for _, np := range c.NodePools {
+		pool := manifestNodePool{
+			Name:          np.Name,
+			Count:         np.Count,
+			InstanceType:  np.InstanceType,
+			Labels:        np.Labels,
+			Volumes:       np.Volumes,
+			InstanceStore: np.InstanceStore,
+			Paused:        np.Paused,
+			RootVolume:    np.RootVolume,
+			UserData:      np.UserData,
+			Architecture:  np.Architecture,
+			ImageID:       np.ImageID,
+			OSFamily:      np.OSFamily,
+
+			ScaleDownBehavior: np.ScaleDownBehavior,
+			AvailabilityZones: np.AvailabilityZones,
+		}
+		for _, t := range np.Taints {
+			pool.Taints = append(pool.Taints, manifestTaint{Key: t.Key, Value: t.Value, Effect: t.Effect})
+		}
+		spec.NodePools = append(spec.NodePools, pool)
+	}
+	return clusterManifest{
+		APIVersion: manifestAPIVersion,
+		Kind:       manifestKind,
+		Metadata:   manifestMetadata{Name: c.Name},
+		Spec:       spec,
+	}
+}
+
+// parseManifest decodes and validates a YAML or JSON manifest; unknown
+// fields are rejected so typos don't silently drop settings
+func parseManifest(data []byte) (*clusterManifest, error) {
+	var m clusterManifest
+	if bytes.HasPrefix(data, []byte("{")) {
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(&m); err != nil {
+			return nil, fmt.Errorf("invalid manifest: %v", err)
+		}
+	} else if err := yaml.UnmarshalStrict(data, &m); err != nil {
+		return nil, fmt.Errorf("invalid manifest: %v", err)
+	}
+	if m.APIVersion != manifestAPIVersion || m.Kind != manifestKind {
+		return nil, fmt.Errorf("unsupported manifest %s/%s, expected apiVersion %s and kind %s",
+			m.APIVersion, m.Kind, manifestAPIVersion, manifestKind)
+	}
+	if err := m.validate(); err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+// validate applies the same checks as the edit form
+func (m *clusterManifest) validate() error {
+	spec := m.Spec
+	if m.Metadata.Name == "" {
+		return fmt.Errorf("metadata.name is required")
+	}
+	if spec.Mode != string(models.ModeDev) && spec.Mode != string(models.ModeHA) {
+		return fmt.Errorf("spec.mode must be 'dev' or 'ha'")
+	}
+	if spec.Region == "" {
+		return fmt.Errorf("spec.region is required")
+	}
+	if spec.Preset != "" {
+		if _, err := models.LookupPreset(spec.Preset); err != nil {
+			return err
+		}
+	}
+	seen := make(map[string]bool)
+	for _, np := range spec.NodePools {
+		if np.Name == "" {
+			return fmt.Errorf("node pool name is required")
+		}
+		if seen[np.Name] {
+			return fmt.Errorf("duplicate node pool %s", np.Name)
+		}
+		seen[np.Name] = true
+		if np.Count < 0 {
+			return fmt.Errorf("node pool %s: count must not be negative", np.Name)
+		}
+		if err := models.ValidateDataVolumes(np.Name, np.Volumes); err != nil {
+			return err
+		}
+		if np.InstanceStore && !models.HasInstanceStore(np.InstanceType) {
+			return fmt.Errorf("node pool %s: instance type %s has no instance store", np.Name, np.InstanceType)
+		}
+		if err := models.ValidateRootVolume("node pool "+np.Name, np.RootVolume); err != nil {
+			return err
+		}
+		if err := models.ValidateUserData(np.Name, np.UserData); err != nil {
+			return err
+		}
+		if err := models.ValidateScaleDownBehavior(np.Name, np.ScaleDownBehavior); err != nil {
+			return err
+		}
+		if err := models.ValidateArchitecture("node pool "+np.Name, np.Architecture, np.InstanceType); err != nil {
+			return err
+		}
+		if err := models.ValidateImage("node pool "+np.Name, np.ImageID, np.OSFamily); err != nil {
+			return err
+		}
+		if err := models.ValidateAvailabilityZones(np.Name, spec.Region, np.AvailabilityZones); err != nil {
+			return err
+		}
+	}
+	if err := models.ValidateRootVolume("cluster "+m.Metadata.Name, spec.RootVolume); err != nil {
+		return err
+	}
+	if err := models.ValidateArchitecture("cluster "+m.Metadata.Name, spec.Architecture, spec.InstanceType); err != nil {
+		return err
+	}
+	if err := models.ValidateImage("cluster "+m.Metadata.Name, spec.ImageID, spec.OSFamily); err != nil {
+		return err
+	}
+	if err := models.ValidateK3sVersion(spec.K3sVersion); err != nil {
+		return err
+	}
+	if err := models.ValidateResourceTags(spec.Tags); err != nil {
+		return err
+	}
+	if err := models.ValidateDriftPolicies(spec.DriftPolicy); err != nil {
+		return err
+	}
+	if spec.DNS != nil {
+		if err := models.ValidateDNS(spec.DNS); err != nil {
+			return err
+		}
+	}
+	if spec.VirtualIP != nil {
+		if err := models.ValidateVirtualIP(spec.VirtualIP, spec.Mode); err != nil {
+			return err
+		}
+	}
+	if err := models.ValidateLoadBalancer(spec.LoadBalancer, spec.Mode, spec.VirtualIP); err != nil {
+		return err
+	}
+	if spec.InstanceProtection != nil {
+		if err := models.ValidateInstanceProtection(spec.InstanceProtection); err != nil {
+			return err
+		}
+	}
+	if err := models.ValidateNotifications(spec.Notifications); err != nil {
+		return err
+	}
+	if err := models.ValidateProxy(spec.Proxy); err != nil {
+		return err
+	}
+	if err := models.ValidateBootstrap(spec.Bootstrap); err != nil {
+		return err
+	}
+	if err := models.ValidateRegistries(m.Metadata.Name, spec.Registries, spec.Bootstrap); err != nil {
+		return err
+	}
+	if err := models.ValidateNetwork(spec.network()); err != nil {
+		return err
+	}
+	if err := models.ValidateSchedule(spec.Schedule); err != nil {
+		return err
+	}
+	if err := models.ValidateAddons(spec.Addons, spec.LowResource); err != nil {
+		return err
+	}
+	return models.ValidateClusterLinks(m.Metadata.Name, spec.ClusterLinks)
+}
+
+// applyTo returns the cluster with the manifest's spec applied
+func (m *clusterManifest) applyTo(c models.K3sCluster) models.K3sCluster {
+	spec := m.Spec
+	c.Description = spec.Description
+	c.Region = spec.Region
+	if spec.InstanceType != "" {
+		c.InstanceType = spec.InstanceType
+	}
+	c.LowResource = spec.LowResource
+	c.Architecture = spec.Architecture
+	c.ImageID = spec.ImageID
+	c.OSFamily = spec.OSFamily
+	network := spec.network()
+	c.PrivateNetwork = network.Private
+	c.VpcID = network.VpcID
+	c.SubnetIDs = network.SubnetIDs
+	c.SecurityGroupIDs = network.SecurityGroupIDs
+	c.DedicatedVPC = network.DedicatedVPC
+	c.VpcCIDR = network.VpcCIDR
+	c.K3sVersion = spec.K3sVersion
+	c.RootVolume = spec.RootVolume
+	c.Tags = models.FormatResourceTags(spec.Tags)
+	c.DriftPolicy = spec.DriftPolicy
+	c.DNS = spec.DNS
+	if models.DNSConfigHash(c.DNS) == "" {
+		c.DNS = nil
+	}
+	c.VirtualIP = spec.VirtualIP
+	c.LoadBalancer = spec.LoadBalancer
+	c.InstanceProtection = spec.InstanceProtection
+	if c.InstanceProtection != nil && *c.InstanceProtection == (models.InstanceProtectionSpec{}) {
+		c.InstanceProtection = nil
+	}
+	c.ClusterLinks = spec.ClusterLinks
+	c.Addons = spec.Addons
+	c.Notifications = spec.Notifications
+	if c.Notifications != nil && len(c.Notifications.Targets) == 0 {
+		c.Notifications = nil
+	}
+	c.Proxy = spec.Proxy
+	if !c.Proxy.Enabled() {
+		c.Proxy = nil
+	}
+	c.Bootstrap = spec.Bootstrap
+	if !c.Bootstrap.Enabled() {
+		c.Bootstrap = nil
+	}
+	c.Registries = spec.Registries
+	if !c.Registries.Enabled() {
+		c.Registries = nil
+	}
+	c.Schedule = spec.Schedule
+	if !c.Schedule.Enabled() {
+		c.Schedule = nil
+	}
+	c.NodePools = nil
+	for _, np := range spec.NodePools {
+		pool := models.NodePool{
+			Name:          np.Name,
+			Count:         np.Count,
+			InstanceType:  np.InstanceType,
+			Labels:        np.Labels,
+			Volumes:       np.Volumes,
+			InstanceStore: np.InstanceStore,
+			Paused:        np.Paused,
+			RootVolume:    np.RootVolume,
+			UserData:      np.UserData,
+			Architecture:  np.Architecture,
+			ImageID:       np.ImageID,
+			OSFamily:      np.OSFamily,
+
+			ScaleDownBehavior: np.ScaleDownBehavior,
+			AvailabilityZones: np.AvailabilityZones,
+		}
+		for _, t := range np.Taints {
+			pool.Taints = append(pool.Taints, models.Taint{Key: t.Key, Value: t.Value, Effect: t.Effect})
+		}
+		c.NodePools = append(c.NodePools, pool)
+	}
+	return c
+}
+
+// sameNetwork reports whether two clusters are placed alike
+func sameNetwork(a, b models.NetworkConfig) bool {
+	return a.Private == b.Private && a.VpcID == b.VpcID &&
+		a.DedicatedVPC == b.DedicatedVPC && a.VpcCIDR == b.VpcCIDR &&
+		slices.Equal(a.SubnetIDs, b.SubnetIDs) && slices.Equal(a.SecurityGroupIDs, b.SecurityGroupIDs)
+}
+
+// manifestPlan is what applying a manifest changes, computed against the
+// cluster as it is now
+type manifestPlan struct {
+	existing  *models.K3sCluster // nil when the cluster is created
+	desired   models.K3sCluster
+	unchanged bool
+	changes   []string // Spec changes of an existing cluster
+	pools     []cluster.PoolPlan
+	impact    *cluster.Impact
+}
+
+// planManifest computes the changes a manifest makes. Mode, preset and
+// network are fixed at creation and must match.
+func planManifest(m *clusterManifest) (*manifestPlan, error) {
+	existing, err := findCluster(m.Metadata.Name)
+	if err != nil {
+		description := m.Spec.Description
+		if description == "" {
+			description = "K3s cluster"
+		}
+		instanceType := m.Spec.InstanceType
+		if instanceType == "" && m.Spec.Preset == "" {
+			instanceType = "t3.medium"
+			if m.Spec.Architecture == models.ArchitectureARM64 {
+				instanceType = "t4g.medium"
+			}
+		}
+		nodeCount := "1"
+		if m.Spec.Mode == string(models.ModeHA) {
+			nodeCount = "3"
+		}
+		c := newClusterModel(m.Metadata.Name, description, m.Spec.Mode, m.Spec.Region, m.Spec.Preset, instanceType, nodeCount)
+		desired := m.applyTo(*c)
+		return &manifestPlan{
+			desired: desired,
+			pools:   cluster.PlanNodePools(models.K3sCluster{Name: desired.Name}, desired),
+		}, nil
+	}
+
+	if existing.Status == models.StatusDeleting {
+		return nil, fmt.Errorf("%w: %s", cluster.ErrClusterDeleting, existing.Name)
+	}
+	if string(existing.Mode) != m.Spec.Mode {
+		return nil, fmt.Errorf("spec.mode cannot be changed from %s to %s", existing.Mode, m.Spec.Mode)
+	}
+	if existing.Preset != m.Spec.Preset {
+		return nil, fmt.Errorf("spec.preset cannot be changed from %q to %q", existing.Preset, m.Spec.Preset)
+	}
+	if !sameNetwork(clusterNetwork(*existing), m.Spec.network()) {
+		return nil, fmt.Errorf("spec.network cannot be changed after creation")
+	}
+	desired := m.applyTo(*existing)
+	return &manifestPlan{
+		existing:  existing,
+		desired:   desired,
+		unchanged: reflect.DeepEqual(exportManifest(*existing), exportManifest(desired)),
+		changes:   audit.Diff(*existing, desired),
+		pools:     cluster.PlanNodePools(*existing, desired),
+		impact:    cluster.EditImpact(*existing, desired),
+	}, nil
+}
+
+// empty reports whether applying changes nothing
+func (p *manifestPlan) empty() bool {
+	return p.existing != nil && p.unchanged
+}
+
+// print shows the plan, like a diff: + created, ~ changed, - removed
+func (p *manifestPlan) print() {
+	name := p.desired.Name
+	if p.existing == nil {
+		masters := len(p.desired.MasterNodes)
+		outf("Cluster %s will be created (%s, %s, %d master(s))\n", name, p.desired.Mode, p.desired.Region, masters)
+	} else if p.empty() {
+		outf("Cluster %s is up to date\n", name)
+		return
+	} else {
+		outf("Cluster %s will be updated:\n", name)
+		for _, change := range p.changes {
+			outf("  ~ %s\n", change)
+		}
+		if len(p.changes) == 0 {
+			// The audit diff leaves out labels, taints and tuning flags
+			outln("  ~ node pool labels, taints or other settings")
+		}
+	}
+
+	var lines []string
+	for _, pool := range p.pools {
+		switch pool.Action {
+		case cluster.PoolActionCreate:
+			lines = append(lines, fmt.Sprintf("  + %s: %d x %s (create)", pool.Pool, pool.Desired, pool.InstanceType))
+		case cluster.PoolActionResize:
+			verb := "add"
+			if pool.Desired < pool.Current {
+				verb = "terminate"
+			}
+			lines = append(lines, fmt.Sprintf("  ~ %s: %d -> %d x %s (resize, %s %d)", pool.Pool, pool.Current, pool.Desired,
+				pool.InstanceType, verb, absInt(pool.Desired-pool.Current)))
+		case cluster.PoolActionRollout:
+			lines = append(lines, fmt.Sprintf("  ~ %s: %d x %s -> %d x %s (rollout, nodes replaced in batches)", pool.Pool,
+				pool.Current, pool.OldType, pool.Desired, pool.InstanceType))
+		case cluster.PoolActionDelete:
+			lines = append(lines, fmt.Sprintf("  - %s: %d x %s (delete)", pool.Pool, pool.Current, pool.InstanceType))
+		case cluster.PoolActionPaused:
+			lines = append(lines, fmt.Sprintf("  = %s: paused, left at %d node(s)", pool.Pool, pool.Current))
+		}
+	}
+	if len(lines) > 0 {
+		outln("Node pools:")
+		for _, line := range lines {
+			outln(line)
+		}
+	}
+
+	if p.impact != nil && p.impact.Destructive() {
+		outf("Nodes terminated (%d):\n", len(p.impact.Nodes))
+		for _, node := range p.impact.Nodes {
+			outf("  - %s (%s, %s)\n", node.Name, node.ID, node.InstanceType)
+		}
+	}
+	if p.impact != nil && p.impact.MonthlyCostDelta != 0 {
+		outf("Estimated monthly cost change: %s\n", formatCostDelta(p.impact.MonthlyCostDelta))
+	}
+}
+
+// apply creates or updates the cluster; the config upload triggers the
+// controller
+func (p *manifestPlan) apply() error {
+	if p.existing == nil {
+		if _, err := clusterManager.CreateCluster(p.desired); err != nil {
+			return fmt.Errorf("failed to create cluster: %w", err)
+		}
+		return nil
+	}
+	if _, err := clusterManager.UpdateCluster(p.desired); err != nil {
+		return fmt.Errorf("failed to update cluster: %w", err)
+	}
+	return nil
+}
+
+func absInt(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+var exportOutput string
+
+// clusterExportCmd prints a cluster's spec as a manifest
+var clusterExportCmd = &cobra.Command{
+	Use:   "export <cluster-name>",
+	Short: i18n.T("cmd.cluster.export.short"),
+	Long:  i18n.T("cmd.cluster.export.long"),
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		c, err := findCluster(resolveClusterAlias(args[0]))
+		if err != nil {
+			return err
+		}
+		data, err := yaml.Marshal(exportManifest(*c))
+		if err != nil {
+			return fmt.Errorf("failed to encode manifest: %w", err)
+		}
+		if exportOutput == "" || exportOutput == "-" {
+			_, err = os.Stdout.Write(data)
+			return err
+		}
+		if err := os.WriteFile(exportOutput, data, 0644); err != nil {
+			return fmt.Errorf("failed to write manifest: %w", err)
+		}
+		outf("✅ Cluster %s exported to %s\n", c.Name, exportOutput)
+		return nil
+	},
+}
+
+var (
+	applyFile   string
+	applyDryRun bool
+	applyYes    bool
+)
+
+// clusterApplyCmd creates or updates clusters from manifests
+var clusterApplyCmd = &cobra.Command{
+	Use:   "apply -f <manifest>",
+	Short: i18n.T("cmd.cluster.apply.short"),
+	Long:  i18n.T("cmd.cluster.apply.long"),
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if applyFile == "" {
+			return fmt.Errorf("a manifest is required (-f <file>)")
+		}
+		var data []byte
+		var err error
+		if applyFile == "-" {
+			data, err = io.ReadAll(os.Stdin)
+		} else {
+			data, err = os.ReadFile(applyFile)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read manifest: %w", err)
+		}
+
+		m, err := parseManifest(bytes.TrimSpace(data))
+		if err != nil {
+			return err
+		}
+		if clusterManager == nil {
+			clusterManager = cluster.NewManager()
+		}
+		plan, err := planManifest(m)
+		if err != nil {
+			return err
+		}
+		plan.print()
+		if applyDryRun || plan.empty() {
+			return nil
+		}
+
+		if !applyYes {
+			if applyFile == "-" {
+				return fmt.Errorf("the manifest was read from stdin, use --yes to apply it")
+			}
+			outf("\nApply these changes? [y/N]: ")
+			input, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+			if answer := strings.ToLower(strings.TrimSpace(input)); answer != "y" && answer != "yes" {
+				return fmt.Errorf("apply cancelled")
+			}
+		}
+
+		if err := plan.apply(); err != nil {
+			return err
+		}
+		if plan.existing == nil {
+			outf("✅ Cluster %s created\n", m.Metadata.Name)
+		} else {
+			outf("✅ Cluster %s configured\n", m.Metadata.Name)
+		}
+		return nil
+	},
+}
+
+func init() {
+	clusterExportCmd.Flags().StringVarP(&exportOutput, "output", "o", "", "Write the manifest to a file instead of stdout")
+	clusterApplyCmd.Flags().StringVarP(&applyFile, "filename", "f", "", "Manifest to apply ('-' for stdin)")
+	clusterApplyCmd.Flags().BoolVar(&applyDryRun, "dry-run", false, "Only show the changes")
+	clusterApplyCmd.Flags().BoolVarP(&applyYes, "yes", "y", false, "Apply without asking for confirmation")
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/madhouselabs/goman/pkg/cluster"
+	"github.com/madhouselabs/goman/pkg/i18n"
+	"github.com/madhouselabs/goman/pkg/models"
+	"github.com/spf13/cobra"
+)
+
+var (
+	nodeReplaceWait    bool
+	nodeReplaceTimeout time.Duration
+)
+
+// nodeCmd represents the node command group
+var nodeCmd = &cobra.Command{
+	Use:   "node",
+	Short: i18n.T("cmd.node.short"),
+	Long:  i18n.T("cmd.node.long"),
+}
+
+// nodeReplaceCmd replaces a worker node with a fresh instance
+var nodeReplaceCmd = &cobra.Command{
+	Use:   "replace <cluster-name> <node>",
+	Short: i18n.T("cmd.node.replace.short"),
+	Long:  i18n.T("cmd.node.replace.long"),
+	Args:  cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		clusterName, node := resolveClusterAlias(args[0]), args[1]
+
+		if clusterManager == nil {
+			clusterManager = cluster.NewManager()
+		}
+
+		req, err := clusterManager.ReplaceNode(clusterName, node)
+		if err != nil {
+			return fmt.Errorf("failed to replace node: %w", err)
+		}
+
+		outf("✅ Replacement of node %s in cluster %s requested\n", node, clusterName)
+		if !nodeReplaceWait {
+			outln("💡 Use 'goman cluster status " + clusterName + "' to follow progress")
+			return nil
+		}
+
+		return waitForNodeReplacement(clusterName, *req)
+	},
+}
+
+// waitForNodeReplacement polls the controller status until the replacement finishes
+func waitForNodeReplacement(clusterName string, req models.NodeReplacement) error {
+	deadline := time.Now().Add(nodeReplaceTimeout)
+	var lastPhase models.NodeReplacementPhase
+
+	for time.Now().Before(deadline) {
+		st, err := clusterManager.NodeReplacementStatus(clusterName, req)
+		if err != nil {
+			outf("⚠️  Failed to read status: %v\n", err)
+		} else if st != nil {
+			if st.Phase != lastPhase {
+				outf("🔄 %s\n", st.Phase)
+				lastPhase = st.Phase
+			}
+			switch st.Phase {
+			case models.NodeReplacementCompleted:
+				outf("✅ Node %s replaced (%s)\n", req.Node, st.Message)
+				return nil
+			case models.NodeReplacementFailed:
+				return fmt.Errorf("node replacement failed: %s", st.Message)
+			}
+		}
+		time.Sleep(10 * time.Second)
+	}
+	return fmt.Errorf("timed out waiting for node replacement after %s", nodeReplaceTimeout)
+}
+
+func init() {
+	nodeReplaceCmd.Flags().BoolVar(&nodeReplaceWait, "wait", false, "Wait for the replacement to finish")
+	nodeReplaceCmd.Flags().DurationVar(&nodeReplaceTimeout, "timeout", 30*time.Minute, "How long to wait with --wait")
+	nodeCmd.AddCommand(nodeReplaceCmd)
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/madhouselabs/goman/pkg/i18n"
+	"github.com/madhouselabs/goman/pkg/models"
+	"github.com/rivo/tview"
+	"gopkg.in/yaml.v3"
+)
+
+// editNotes lets the user edit the notes of a cluster in $EDITOR from the
+// details view. An unsaved draft of an earlier edit is reopened instead.
+func editNotes(cluster models.K3sCluster) {
+	statusText.SetText(fmt.Sprintf(" %s%s%s", TagWarning, i18n.T("status.opening_editor"), TagReset))
+	app.ForceDraw()
+	time.Sleep(100 * time.Millisecond)
+
+	var saveErr error
+	app.Suspend(func() {
+		fmt.Print("\033[2J\033[H\033[?47l")
+
+		name := draftName(draftNotes, cluster.Name)
+		initial := notesTemplate(cluster)
+		if d, ok := loadDraft(name); ok {
+			initial = d.Content
+		}
+		err := editYAML(initial, fmt.Sprintf("goman-notes-%s-*.yaml", cluster.Name), name, func(content string) error {
+			return applyNotes(cluster, content)
+		})
+		if err != nil && !errors.Is(err, errEditorUnsaved) {
+			saveErr = err
+		}
+
+		fmt.Print("\033[?47h\033[2J\033[H")
+		time.Sleep(50 * time.Millisecond)
+	})
+
+	if saveErr != nil {
+		statusText.SetText(fmt.Sprintf(" %s%s%s", TagDanger, saveErr.Error(), TagReset))
+	} else {
+		statusText.SetText(" [green]" + i18n.T("status.connected_short") + "[::-]")
+	}
+	if c, err := findCluster(cluster.Name); err == nil && detailsState != nil {
+		detailsState.UpdateCluster(*c)
+		updateDetailsUI(*c)
+	}
+}
+
+// notesTemplate renders the notes of a cluster for the editor. The
+// well-known notes are always listed so they are easy to fill in.
+func notesTemplate(cluster models.K3sCluster) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Notes on cluster %s, shown to everyone sharing the state bucket.\n", cluster.Name)
+	b.WriteString("# owner, purpose, link and note are shown first; other keys work too.\n")
+	b.WriteString("# Empty or deleted lines remove a note.\n")
+
+	notes := make(map[string]string)
+	for _, key := range models.NoteKeys {
+		notes[key] = ""
+	}
+	for _, key := range models.Notes(cluster.Annotations) {
+		notes[key] = cluster.Annotations[key]
+	}
+	for _, key := range models.Notes(notes) {
+		line, _ := yaml.Marshal(map[string]string{key: notes[key]})
+		b.Write(line)
+	}
+	return b.String()
+}
+
+// applyNotes saves the notes edited in notesTemplate
+func applyNotes(cluster models.K3sCluster, content string) error {
+	var edited map[string]string
+	if err := yaml.Unmarshal([]byte(content), &edited); err != nil {
+		return fmt.Errorf("invalid notes: %w", err)
+	}
+
+	set := make(map[string]string)
+	var remove []string
+	for key, value := range edited {
+		value = strings.TrimSpace(value)
+		if value != "" && value != cluster.Annotations[key] {
+			set[key] = value
+		}
+	}
+	for _, key := range models.Notes(cluster.Annotations) {
+		if strings.TrimSpace(edited[key]) == "" {
+			remove = append(remove, key)
+		}
+	}
+	if len(set) == 0 && len(remove) == 0 {
+		return nil
+	}
+	return clusterManager.AnnotateCluster(cluster.Name, set, remove)
+}
+
+// notesSummary formats the notes of a cluster for the details view
+func notesSummary(cluster models.K3sCluster) string {
+	noteKeys := models.Notes(cluster.Annotations)
+	if len(noteKeys) == 0 {
+		return fmt.Sprintf("%s%s%s", TagMuted, i18n.T("details.notes_none", keys.label(actionNotes)), TagReset)
+	}
+	parts := make([]string, len(noteKeys))
+	for i, key := range noteKeys {
+		value := strings.ReplaceAll(cluster.Annotations[key], "\n", " ")
+		parts[i] = fmt.Sprintf("%s%s:%s %s", TagMuted, key, TagReset, tview.Escape(value))
+	}
+	return strings.Join(parts, "   ")
+}
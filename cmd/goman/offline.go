@@ -0,0 +1,156 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/madhouselabs/goman/pkg/cluster"
+	"github.com/madhouselabs/goman/pkg/i18n"
+	"github.com/madhouselabs/goman/pkg/models"
+	"github.com/rivo/tview"
+)
+
+// StyleOfflineBanner highlights the offline banner across the header divider
+var StyleOfflineBanner = tcell.StyleDefault.Foreground(ColorBackground).Background(ColorOrange).Bold(true)
+
+// intentPromptOpen prevents stacking confirmations for queued intents
+var intentPromptOpen bool
+
+// offlineBannerText describes the cached state shown while offline
+func offlineBannerText() string {
+	synced := clusterManager.LastSyncTime()
+	text := " OFFLINE - AWS unreachable, showing cached state"
+	if !synced.IsZero() {
+		text += fmt.Sprintf(" from %s", synced.Format("2006-01-02 15:04"))
+	}
+	if pending := len(clusterManager.PendingIntents()); pending > 0 {
+		text += fmt.Sprintf(" - %d action(s) queued", pending)
+	}
+	return text + " "
+}
+
+// drawOfflineBanner draws the offline banner in place of a divider line.
+// Returns false when online so the caller draws the normal divider.
+func drawOfflineBanner(screen tcell.Screen, x, y, width int) bool {
+	if clusterManager == nil || !clusterManager.IsOffline() {
+		return false
+	}
+	text := []rune(offlineBannerText())
+	for i := 0; i < width; i++ {
+		ch := ' '
+		if i < len(text) {
+			ch = text[i]
+		}
+		screen.SetContent(x+i, y, ch, nil, StyleOfflineBanner)
+	}
+	return true
+}
+
+// formatLastSynced renders a cluster's last sync time relative to now
+func formatLastSynced(clusterName string) string {
+	synced := clusterManager.ClusterLastSynced(clusterName)
+	if synced.IsZero() {
+		return "never"
+	}
+	age := time.Since(synced)
+	switch {
+	case age < time.Minute:
+		return "just now"
+	case age < time.Hour:
+		return fmt.Sprintf("%dm ago", int(age.Minutes()))
+	case age < 24*time.Hour:
+		return fmt.Sprintf("%dh ago", int(age.Hours()))
+	default:
+		return synced.Format("2006-01-02")
+	}
+}
+
+// queueOfflineIntent records an action while offline instead of calling AWS.
+// Returns true when the action was queued.
+func queueOfflineIntent(c models.K3sCluster, action string) bool {
+	if !clusterManager.IsOffline() {
+		return false
+	}
+	clusterManager.QueueIntent(c, action)
+
+	modal := tview.NewModal().
+		SetText(dialogText("dialog.offline", i18n.T("dialog.offline.body", intentNoun(action), c.Name))).
+		AddButtons([]string{i18n.T("button.ok")}).
+		SetBackgroundColor(ColorBackground).
+		SetTextColor(ColorForeground).
+		SetButtonBackgroundColor(ColorBackground).
+		SetButtonTextColor(ColorForeground).
+		SetDoneFunc(func(buttonIndex int, buttonLabel string) {
+			pages.SwitchToPage("clusters")
+			pages.RemovePage("queued")
+			refreshClusters()
+		})
+	modal.SetBorder(false)
+	pages.AddAndSwitchToPage("queued", modal, true)
+	return true
+}
+
+// promptQueuedIntents asks the user to confirm actions queued while offline,
+// one at a time. Must be called from the UI goroutine.
+func promptQueuedIntents() {
+	if intentPromptOpen || clusterManager.IsOffline() {
+		return
+	}
+	intents := clusterManager.PendingIntents()
+	if len(intents) == 0 {
+		return
+	}
+	intentPromptOpen = true
+	intent := intents[0]
+
+	modal := tview.NewModal().
+		SetText(dialogText("dialog.queued", i18n.T("dialog.queued.body",
+			intent.QueuedAt.Format("15:04"), intentNoun(intent.Action), intent.ClusterName))).
+		AddButtons([]string{i18n.T("button.apply"), i18n.T("button.discard")}).
+		SetBackgroundColor(ColorBackground).
+		SetTextColor(ColorForeground).
+		SetButtonBackgroundColor(ColorBackground).
+		SetButtonTextColor(ColorForeground).
+		SetDoneFunc(func(buttonIndex int, buttonLabel string) {
+			pages.SwitchToPage("clusters")
+			pages.RemovePage("intent")
+
+			if buttonLabel != i18n.T("button.apply") {
+				clusterManager.DiscardIntent(intent.ID)
+				intentPromptOpen = false
+				promptQueuedIntents()
+				return
+			}
+
+			go func() {
+				err := clusterManager.ApplyIntent(intent.ID)
+				app.QueueUpdateDraw(func() {
+					intentPromptOpen = false
+					if err != nil {
+						showError(i18n.T("error.apply_intent", intentNoun(intent.Action), intent.ClusterName, err))
+						return
+					}
+					refreshClusters()
+					promptQueuedIntents()
+				})
+			}()
+		})
+
+	modal.SetBorder(false)
+	pages.AddAndSwitchToPage("intent", modal, true)
+}
+
+// intentNoun describes an intent action for prompts
+func intentNoun(action string) string {
+	switch action {
+	case cluster.IntentDelete:
+		return i18n.T("intent.delete")
+	case cluster.IntentStop:
+		return i18n.T("intent.stop")
+	case cluster.IntentStart:
+		return i18n.T("intent.start")
+	default:
+		return action
+	}
+}
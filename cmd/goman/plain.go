@@ -0,0 +1,117 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/madhouselabs/goman/pkg/cluster"
+)
+
+// plainOutput is set by the --plain flag
+var plainOutput bool
+
+// plainReplacer turns pictographs and box drawing into words or nothing, so
+// screen readers don't announce symbol names and the meaning of a line never
+// depends on an icon or its color
+var plainReplacer = strings.NewReplacer(
+	"✅ ", "OK: ",
+	"❌ ", "Error: ",
+	"⚠️  ", "Warning: ",
+	"⚠️ ", "Warning: ",
+	"💡 ", "Hint: ",
+	"⭕ ", "",
+	"🔄 ", "",
+	"📈 ", "",
+	"🔑 ", "",
+	"📝 ", "",
+	"📋 ", "",
+	"🚀 ", "",
+	"🔧 ", "",
+	"🔍 ", "",
+	"🔎 ", "",
+	"🔌 ", "",
+	"📊 ", "",
+	"🧹 ", "",
+	"🏥 ", "",
+	"📦 ", "",
+	"🗑️ ", "",
+	"📥 ", "",
+	"•", "-",
+	"─", "",
+)
+
+// plainMode reports whether output must be screen-reader friendly: linear
+// text without box drawing, pictographs or color. Enabled by --plain,
+// GOMAN_PLAIN=true, NO_COLOR, or a dumb terminal.
+func plainMode() bool {
+	if plainOutput {
+		return true
+	}
+	if v := os.Getenv("GOMAN_PLAIN"); v == "true" || v == "1" {
+		return true
+	}
+	return os.Getenv("NO_COLOR") != "" || os.Getenv("TERM") == "dumb"
+}
+
+// plainize rewrites s for plain mode; it is returned unchanged otherwise
+func plainize(s string) string {
+	if !plainMode() {
+		return s
+	}
+	return plainReplacer.Replace(s)
+}
+
+// outf prints formatted CLI output, honoring plain mode
+func outf(format string, a ...interface{}) {
+	fmt.Print(plainize(fmt.Sprintf(format, a...)))
+}
+
+// outln prints a line of CLI output, honoring plain mode
+func outln(a ...interface{}) {
+	fmt.Print(plainize(fmt.Sprintln(a...)))
+}
+
+// printPlainClusters renders the TUI cluster list as linear text, one
+// labeled line per field so nothing depends on column alignment
+func printPlainClusters() {
+	if clusterManager == nil {
+		clusterManager = cluster.NewManager()
+	}
+
+	clusters := clusterManager.GetClusters()
+	if clusterManager.IsOffline() {
+		outln("AWS is unreachable, showing cached data.")
+	}
+	outf("%d clusters.\n", len(clusters))
+
+	stm := GetGlobalTunnelManager()
+	for i, c := range clusters {
+		outln()
+		outf("Cluster %d of %d: %s\n", i+1, len(clusters), c.Name)
+		outf("Status: %s\n", c.Status)
+		outf("Mode: %s\n", c.Mode)
+		outf("Region: %s\n", c.Region)
+		outf("Instance type: %s\n", c.InstanceType)
+		outf("Nodes: %d masters, %d workers\n", len(c.MasterNodes), len(c.WorkerNodes))
+		if c.Description != "" {
+			outf("Description: %s\n", c.Description)
+		}
+		if stm.IsConnected(c.Name) {
+			outln("Connection: connected")
+		} else {
+			outln("Connection: not connected")
+		}
+	}
+
+	outln()
+	outln("Actions are available as commands:")
+	outln("  goman cluster status <name>      show details")
+	outln("  goman cluster create <name>      create a cluster")
+	outln("  goman cluster edit <name>        edit in $EDITOR")
+	outln("  goman cluster start <name>       start a stopped cluster")
+	outln("  goman cluster stop <name>        stop a running cluster")
+	outln("  goman cluster delete <name>      delete a cluster")
+	outln("  goman cluster reconcile <name>   trigger reconciliation")
+	outln("  goman cluster connect <name>     select and connect")
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/madhouselabs/goman/pkg/cluster"
+	"github.com/madhouselabs/goman/pkg/i18n"
+	"github.com/madhouselabs/goman/pkg/models"
+	"github.com/spf13/cobra"
+)
+
+var (
+	recoverQuorumRestore string
+	recoverQuorumYes     bool
+	recoverQuorumStatus  bool
+)
+
+// clusterRecoverQuorumCmd rebuilds the control plane of an HA cluster that
+// lost etcd quorum
+var clusterRecoverQuorumCmd = &cobra.Command{
+	Use:   "recover-quorum <cluster-name>",
+	Short: i18n.T("cmd.cluster.recover-quorum.short"),
+	Long:  i18n.T("cmd.cluster.recover-quorum.long"),
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		clusterName := resolveClusterAlias(args[0])
+
+		if clusterManager == nil {
+			clusterManager = cluster.NewManager()
+		}
+
+		current, err := clusterManager.QuorumRecoveryStatus(clusterName)
+		if err != nil && recoverQuorumStatus {
+			return err
+		}
+		if recoverQuorumStatus {
+			if current == nil {
+				outf("No quorum recoveries for cluster %s\n", clusterName)
+				return nil
+			}
+			printQuorumRecoveryStatus(current)
+			return nil
+		}
+		if current != nil && !current.Done() {
+			return fmt.Errorf("a quorum recovery of cluster %s is in progress: %s", clusterName, current)
+		}
+
+		if !recoverQuorumYes {
+			outf("Masters of %s other than the survivor will be terminated and replaced.\n", clusterName)
+			outf("Type the cluster name (%s) to confirm recovery: ", clusterName)
+			input, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+			if strings.TrimSpace(input) != clusterName {
+				return fmt.Errorf("recovery cancelled")
+			}
+		}
+
+		if err := clusterManager.RequestQuorumRecovery(clusterName, recoverQuorumRestore); err != nil {
+			return fmt.Errorf("failed to request quorum recovery: %w", err)
+		}
+
+		outf("🩺 Quorum recovery requested for cluster %s\n", clusterName)
+		outln("💡 Use 'goman cluster recover-quorum --status " + clusterName + "' to follow progress")
+		return nil
+	},
+}
+
+// printQuorumRecoveryStatus prints a quorum recovery and its masters
+func printQuorumRecoveryStatus(st *models.QuorumRecoveryStatus) {
+	outf("Phase:     %s\n", st.Phase)
+	if st.SurvivorName != "" {
+		outf("Survivor:  %s (%s)\n", st.SurvivorName, st.Survivor)
+	}
+	switch {
+	case st.Snapshot != "":
+		outf("Restored:  snapshot %s\n", st.Snapshot)
+	case st.Phase == models.QuorumRecoveryReplacing || st.Phase == models.QuorumRecoveryRejoining || st.Phase == models.QuorumRecoveryCompleted:
+		outln("Restored:  the survivor's own data")
+	}
+	if len(st.Replaced) > 0 {
+		outf("Replaced:  %s (%d/%d launched)\n", strings.Join(st.Replaced, ", "), len(st.Replacements), len(st.Replaced))
+	}
+	outf("Started:   %s\n", st.StartedAt.Format("2006-01-02 15:04:05"))
+	if st.CompletedAt != nil {
+		outf("Completed: %s\n", st.CompletedAt.Format("2006-01-02 15:04:05"))
+	}
+	if st.Message != "" {
+		outf("Message:   %s\n", st.Message)
+	}
+}
+
+func init() {
+	clusterRecoverQuorumCmd.Flags().StringVar(&recoverQuorumRestore, "restore", models.QuorumRestoreLatest, "Reset etcd from: latest (newest snapshot on the survivor) or none (the survivor's own data)")
+	clusterRecoverQuorumCmd.Flags().BoolVarP(&recoverQuorumYes, "yes", "y", false, "Recover without asking for confirmation")
+	clusterRecoverQuorumCmd.Flags().BoolVar(&recoverQuorumStatus, "status", false, "Show the current or last recovery instead of requesting one")
+	clusterCmd.AddCommand(clusterRecoverQuorumCmd)
+}
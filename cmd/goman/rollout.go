@@ -0,0 +1,129 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/madhouselabs/goman/pkg/cluster"
+	"github.com/madhouselabs/goman/pkg/i18n"
+	"github.com/madhouselabs/goman/pkg/models"
+	"github.com/spf13/cobra"
+)
+
+var rolloutResumeBatchSize int
+
+// clusterRolloutCmd groups commands for node pool rollouts
+var clusterRolloutCmd = &cobra.Command{
+	Use:   "rollout",
+	Short: i18n.T("cmd.cluster.rollout.short"),
+	Long:  i18n.T("cmd.cluster.rollout.long"),
+}
+
+// clusterRolloutPauseCmd pauses rollouts at the next batch boundary
+var clusterRolloutPauseCmd = &cobra.Command{
+	Use:   "pause <cluster-name>",
+	Short: i18n.T("cmd.cluster.rollout.pause.short"),
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		clusterName := resolveClusterAlias(args[0])
+
+		if clusterManager == nil {
+			clusterManager = cluster.NewManager()
+		}
+
+		if err := clusterManager.PauseRollout(clusterName); err != nil {
+			return fmt.Errorf("failed to pause rollout: %w", err)
+		}
+
+		outf("⏸️  Rollouts of cluster %s will pause after the current batch\n", clusterName)
+		outln("💡 Use 'goman cluster rollout status " + clusterName + "' to see when it is paused")
+		return nil
+	},
+}
+
+// clusterRolloutResumeCmd resumes paused or halted rollouts
+var clusterRolloutResumeCmd = &cobra.Command{
+	Use:   "resume <cluster-name>",
+	Short: i18n.T("cmd.cluster.rollout.resume.short"),
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		clusterName := resolveClusterAlias(args[0])
+		if rolloutResumeBatchSize < 0 {
+			return fmt.Errorf("--batch-size must not be negative")
+		}
+
+		if clusterManager == nil {
+			clusterManager = cluster.NewManager()
+		}
+
+		if err := clusterManager.ResumeRollout(clusterName, rolloutResumeBatchSize); err != nil {
+			return fmt.Errorf("failed to resume rollout: %w", err)
+		}
+
+		outf("▶️  Rollouts of cluster %s resumed\n", clusterName)
+		return nil
+	},
+}
+
+// clusterRolloutStatusCmd shows the current or last rollout
+var clusterRolloutStatusCmd = &cobra.Command{
+	Use:   "status <cluster-name>",
+	Short: i18n.T("cmd.cluster.rollout.status.short"),
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		clusterName := resolveClusterAlias(args[0])
+
+		if clusterManager == nil {
+			clusterManager = cluster.NewManager()
+		}
+
+		rollout, err := clusterManager.RolloutStatus(clusterName)
+		if err != nil {
+			return err
+		}
+		if rollout == nil {
+			outf("No rollouts for cluster %s\n", clusterName)
+			return nil
+		}
+		printRolloutStatus(rollout)
+		return nil
+	},
+}
+
+// printRolloutStatus prints a rollout and the replacements of its current batch
+func printRolloutStatus(rollout *models.RolloutStatus) {
+	outf("Pool:      %s\n", rollout.Pool)
+	if rollout.FromInstanceType != "" {
+		outf("Change:    %s -> %s\n", rollout.FromInstanceType, rollout.ToInstanceType)
+	} else {
+		outf("Change:    -> %s\n", rollout.ToInstanceType)
+	}
+	outf("Phase:     %s\n", rollout.Phase)
+	outf("Batch:     %d/%d (size %d)\n", rollout.BatchIndex, rollout.BatchCount, rollout.BatchSize)
+	outf("Replaced:  %d", rollout.Replaced)
+	if rollout.Failed > 0 {
+		outf(" (%d failed)", rollout.Failed)
+	}
+	outln()
+	outf("Started:   %s\n", rollout.StartedAt.Format("2006-01-02 15:04:05"))
+	if rollout.CompletedAt != nil {
+		outf("Completed: %s\n", rollout.CompletedAt.Format("2006-01-02 15:04:05"))
+	}
+	if rollout.Message != "" {
+		outf("Message:   %s\n", rollout.Message)
+	}
+	for _, st := range rollout.Batch {
+		outf("  - %s: %s", st.Node, st.Phase)
+		if st.Message != "" {
+			outf(" (%s)", st.Message)
+		}
+		outln()
+	}
+}
+
+func init() {
+	clusterRolloutResumeCmd.Flags().IntVar(&rolloutResumeBatchSize, "batch-size", 0, "Nodes to replace per batch from now on (0 keeps the current size)")
+	clusterRolloutCmd.AddCommand(clusterRolloutPauseCmd)
+	clusterRolloutCmd.AddCommand(clusterRolloutResumeCmd)
+	clusterRolloutCmd.AddCommand(clusterRolloutStatusCmd)
+	clusterCmd.AddCommand(clusterRolloutCmd)
+}
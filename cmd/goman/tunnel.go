@@ -4,91 +4,183 @@ import (
 	"fmt"
 	"os"
 	"os/exec"
+	"os/signal"
+	"strconv"
 	"strings"
+	"syscall"
+	"text/tabwriter"
 	"time"
 
+	"github.com/madhouselabs/goman/pkg/connectivity"
+	"github.com/madhouselabs/goman/pkg/i18n"
 	"github.com/spf13/cobra"
 )
 
+// tunnelWatchInterval is how often dead tunnels are looked for
+var tunnelWatchInterval time.Duration
+
 // tunnelCmd represents the tunnel command group
 var tunnelCmd = &cobra.Command{
 	Use:   "tunnel",
-	Short: "Manage SSM tunnels",
-	Long:  `Manage SSM tunnel operations including status, cleanup, and diagnostics.`,
+	Short: i18n.T("cmd.tunnel.short"),
+	Long:  i18n.T("cmd.tunnel.long"),
+}
+
+// tunnelListCmd lists tracked tunnels
+var tunnelListCmd = &cobra.Command{
+	Use:   "list",
+	Short: i18n.T("cmd.tunnel.list.short"),
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		tm := GetGlobalTunnelManager()
+		tunnels, err := tm.List()
+		if err != nil {
+			return fmt.Errorf("failed to load tunnels: %w", err)
+		}
+		if len(tunnels) == 0 {
+			outln("No tunnels")
+			return nil
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "CLUSTER\tLOCAL\tINSTANCE\tREGION\tPID\tSTATUS\tUPTIME\tRESTARTS")
+		for _, t := range tunnels {
+			uptime := "-"
+			if tm.IsProcessAlive(t.PID) {
+				uptime = formatDuration(time.Since(t.StartedAt))
+			}
+			fmt.Fprintf(w, "%s\t127.0.0.1:%d\t%s\t%s\t%d\t%s\t%s\t%d\n",
+				t.ClusterName, t.LocalPort, t.InstanceID, t.Region, t.PID, tunnelHealth(tm, t), uptime, t.Restarts)
+		}
+		return w.Flush()
+	},
+}
+
+// tunnelStopCmd stops the tunnel to one cluster
+var tunnelStopCmd = &cobra.Command{
+	Use:   "stop <cluster-name>",
+	Short: i18n.T("cmd.tunnel.stop.short"),
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := GetGlobalTunnelManager().StopTunnel(args[0]); err != nil {
+			return err
+		}
+		outf("✅ Stopped tunnel to cluster %s\n", args[0])
+		return nil
+	},
+}
+
+// tunnelStopAllCmd stops every tunnel
+var tunnelStopAllCmd = &cobra.Command{
+	Use:   "stop-all",
+	Short: i18n.T("cmd.tunnel.stop-all.short"),
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		stopped, err := GetGlobalTunnelManager().StopAll()
+		if err != nil {
+			return err
+		}
+		if len(stopped) == 0 {
+			outln("No tunnels")
+			return nil
+		}
+		outf("✅ Stopped tunnels to %s\n", strings.Join(stopped, ", "))
+		return nil
+	},
+}
+
+// tunnelWatchCmd keeps tunnels up in the foreground
+var tunnelWatchCmd = &cobra.Command{
+	Use:   "watch",
+	Short: i18n.T("cmd.tunnel.watch.short"),
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if tunnelWatchInterval < time.Second {
+			return fmt.Errorf("--interval must be at least 1s")
+		}
+		tm := GetGlobalTunnelManager()
+		stop := make(chan os.Signal, 1)
+		signal.Notify(stop, os.Interrupt, syscall.SIGTERM)
+		defer signal.Stop(stop)
+
+		outf("👀 Watching tunnels every %s, press Ctrl+C to stop\n", tunnelWatchInterval)
+		ticker := time.NewTicker(tunnelWatchInterval)
+		defer ticker.Stop()
+		for {
+			restarted, err := tm.RestartDead()
+			if err != nil {
+				outf("Warning: %v\n", err)
+			}
+			for _, name := range restarted {
+				outf("%s 🔄 Restarted tunnel to cluster %s\n", time.Now().Format("15:04:05"), name)
+			}
+			select {
+			case <-stop:
+				return nil
+			case <-ticker.C:
+			}
+		}
+	},
 }
 
 // tunnelStatusCmd shows tunnel status
 var tunnelStatusCmd = &cobra.Command{
 	Use:   "status",
-	Short: "Show tunnel status and diagnostics",
-	Long:  `Shows detailed status of the active SSM tunnel.`,
+	Short: i18n.T("cmd.tunnel.status.short"),
+	Long:  i18n.T("cmd.tunnel.status.long"),
 	RunE: func(cmd *cobra.Command, args []string) error {
-		stm := GetGlobalSingleTunnelManager()
-		
-		fmt.Println("🔍 SSM Tunnel Status")
-		fmt.Println("=" + strings.Repeat("=", 60))
-		
-		// Check active tunnel
-		activeTunnel, err := stm.GetActiveTunnel()
+		tm := GetGlobalTunnelManager()
+
+		outln("🔍 SSM Tunnel Status")
+		outln("=" + strings.Repeat("=", 60))
+
+		tunnels, err := tm.List()
 		if err != nil {
-			fmt.Printf("Error loading active tunnel: %v\n", err)
+			outf("Error loading tunnels: %v\n", err)
 		}
-		
 		currentCluster := getCurrentCluster()
-		
-		fmt.Println("\n📋 Active Tunnel:")
-		if activeTunnel != nil {
-			status := "❌ Dead"
-			if stm.IsProcessAlive(activeTunnel.PID) {
-				if stm.IsPortListening(6443) {
-					status = "✅ Healthy"
-				} else {
-					status = "⚠️  Process alive but port not listening"
-				}
-			}
-			
+
+		outln("\n📋 Tunnels:")
+		healthyCount := 0
+		for _, t := range tunnels {
 			current := ""
-			if activeTunnel.ClusterName == currentCluster {
+			if t.ClusterName == currentCluster {
 				current = " (current cluster)"
 			}
-			
-			fmt.Printf("  • Cluster: %s%s\n", activeTunnel.ClusterName, current)
-			fmt.Printf("  • Status: %s\n", status)
-			fmt.Printf("  • PID: %d\n", activeTunnel.PID)
-			fmt.Printf("  • Instance: %s\n", activeTunnel.InstanceID)
-			fmt.Printf("  • Region: %s\n", activeTunnel.Region)
-			fmt.Printf("  • Port: %d -> %d\n", activeTunnel.LocalPort, activeTunnel.RemotePort)
-			fmt.Printf("  • Started: %s ago\n", formatDuration(time.Since(activeTunnel.StartedAt)))
-		} else {
-			fmt.Println("  No active tunnel")
+			health := tunnelHealth(tm, t)
+			if health == "healthy" {
+				healthyCount++
+			}
+			outf("  • %s%s: %s\n", t.ClusterName, current, health)
+			outf("    PID %d, instance %s in %s, port %d -> %d, started %s ago\n",
+				t.PID, t.InstanceID, t.Region, t.LocalPort, t.RemotePort, formatDuration(time.Since(t.StartedAt)))
+			if t.LastError != "" {
+				outf("    Last restart failed: %s\n", t.LastError)
+			}
 		}
-		
+		if len(tunnels) == 0 {
+			outln("  No tunnels")
+		}
+
 		// Check for orphaned processes
-		fmt.Println("\n🔎 Orphaned Processes:")
-		orphanCount := checkOrphanedProcesses()
-		
-		// Check port status
-		fmt.Println("\n🔌 Port 6443 Status:")
-		checkPortStatus(6443)
-		
-		// Summary
-		fmt.Println("\n📊 Summary:")
-		trackedCount := 0
-		healthyCount := 0
-		if activeTunnel != nil {
-			trackedCount = 1
-			if stm.IsProcessAlive(activeTunnel.PID) && stm.IsPortListening(6443) {
-				healthyCount = 1
-			}
+		outln("\n🔎 Orphaned Processes:")
+		orphans := orphanedSSMProcesses(tm.TrackedPIDs())
+		for _, p := range orphans {
+			outf("  • PID %d: %s\n", p.pid, p.args)
 		}
-		fmt.Printf("  • Active tunnels: %d\n", trackedCount)
-		fmt.Printf("  • Healthy tunnels: %d\n", healthyCount)
-		fmt.Printf("  • Orphaned processes: %d\n", orphanCount)
-		
-		if orphanCount > 0 {
-			fmt.Println("\n⚠️  Found orphaned processes. Run 'goman tunnel cleanup' to clean them up.")
+		if len(orphans) == 0 {
+			outln("  None found")
+		}
+
+		// Summary
+		outln("\n📊 Summary:")
+		outf("  • Tunnels: %d\n", len(tunnels))
+		outf("  • Healthy tunnels: %d\n", healthyCount)
+		outf("  • Orphaned processes: %d\n", len(orphans))
+
+		if len(orphans) > 0 {
+			outln("\n⚠️  Found orphaned processes. Run 'goman tunnel cleanup' to clean them up.")
 		}
-		
 		return nil
 	},
 }
@@ -96,27 +188,25 @@ var tunnelStatusCmd = &cobra.Command{
 // tunnelCleanupCmd cleans up tunnels
 var tunnelCleanupCmd = &cobra.Command{
 	Use:   "cleanup",
-	Short: "Clean up the active SSM tunnel and orphaned processes",
-	Long:  `Forcefully cleans up the active SSM tunnel, orphaned processes, and state files.`,
+	Short: i18n.T("cmd.tunnel.cleanup.short"),
+	Long:  i18n.T("cmd.tunnel.cleanup.long"),
 	RunE: func(cmd *cobra.Command, args []string) error {
-		fmt.Println("🧹 Cleaning up SSM tunnels...")
-		
-		stm := GetGlobalSingleTunnelManager()
-		
-		// Stop active tunnel
-		if err := stm.StopActiveTunnel(); err != nil {
-			fmt.Printf("Warning: Error stopping active tunnel: %v\n", err)
+		outln("🧹 Cleaning up SSM tunnels...")
+
+		tm := GetGlobalTunnelManager()
+		stopped, err := tm.StopAll()
+		if err != nil {
+			outf("Warning: Error stopping tunnels: %v\n", err)
 		}
-		
-		// Clean up port 6443
-		if err := stm.CleanupPort(6443); err != nil {
-			fmt.Printf("Warning: Error cleaning port 6443: %v\n", err)
+		outf("  • Stopped %d tunnels\n", len(stopped))
+
+		orphans := orphanedSSMProcesses(tm.TrackedPIDs())
+		for _, p := range orphans {
+			syscall.Kill(p.pid, syscall.SIGTERM)
 		}
-		
-		// Kill all SSM processes
-		killAllSSMProcesses()
-		
-		fmt.Println("✅ Cleanup complete")
+		outf("  • Killed %d orphaned SSM processes\n", len(orphans))
+
+		outln("✅ Cleanup complete")
 		return nil
 	},
 }
@@ -124,8 +214,8 @@ var tunnelCleanupCmd = &cobra.Command{
 // tunnelHealthCmd checks tunnel health
 var tunnelHealthCmd = &cobra.Command{
 	Use:   "health [cluster-name]",
-	Short: "Check health of a specific tunnel",
-	Long:  `Performs a health check on a specific SSM tunnel.`,
+	Short: i18n.T("cmd.tunnel.health.short"),
+	Long:  i18n.T("cmd.tunnel.health.long"),
 	Args:  cobra.MaximumNArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		var clusterName string
@@ -137,148 +227,91 @@ var tunnelHealthCmd = &cobra.Command{
 				return fmt.Errorf("no cluster specified and no current cluster set")
 			}
 		}
-		
-		stm := GetGlobalSingleTunnelManager()
-		
-		fmt.Printf("🏥 Checking health of tunnel for cluster: %s\n", clusterName)
-		
-		if stm.IsConnected(clusterName) {
-			fmt.Println("✅ Tunnel is healthy")
+
+		tm := GetGlobalTunnelManager()
+
+		outf("🏥 Checking health of tunnel for cluster: %s\n", clusterName)
+
+		if tm.IsConnected(clusterName) {
+			outln("✅ Tunnel is healthy")
 			return nil
 		}
-		
-		fmt.Println("❌ Tunnel is unhealthy or not found")
-		fmt.Println("\nDiagnostics:")
-		
-		// Check if tunnel exists
-		activeTunnel, _ := stm.GetActiveTunnel()
-		if activeTunnel == nil {
-			fmt.Println("  • No active tunnel")
-		} else if activeTunnel.ClusterName != clusterName {
-			fmt.Printf("  • Active tunnel is for cluster %s, not %s\n", activeTunnel.ClusterName, clusterName)
-		} else if !stm.IsProcessAlive(activeTunnel.PID) {
-			fmt.Println("  • Tunnel process is dead")
+
+		outln("❌ Tunnel is unhealthy or not found")
+		outln("\nDiagnostics:")
+
+		var tunnel *connectivity.TunnelState
+		tunnels, _ := tm.List()
+		for i := range tunnels {
+			if tunnels[i].ClusterName == clusterName {
+				tunnel = &tunnels[i]
+			}
+		}
+		switch {
+		case tunnel == nil:
+			outln("  • No tunnel to this cluster")
+		case !tm.IsProcessAlive(tunnel.PID):
+			outln("  • Tunnel process is dead")
+		case !tm.IsPortListening(tunnel.LocalPort):
+			outf("  • Port %d is not open\n", tunnel.LocalPort)
 		}
-		
-		// Check port
-		if !isPortOpen(6443) {
-			fmt.Println("  • Port 6443 is not open")
+		if tunnel != nil && tunnel.LastError != "" {
+			outf("  • Last restart failed: %s\n", tunnel.LastError)
 		}
-		
-		// Check for processes
-		checkSSMProcesses()
-		
-		fmt.Println("\n💡 Try running: goman tunnel cleanup && goman kube kubectl get nodes")
-		
+
+		outln("\n💡 Try running: goman kube kubectl get nodes (restarts the tunnel)")
+
 		return nil
 	},
 }
 
 // Helper functions
 
-func checkOrphanedProcesses() int {
-	count := 0
-	
-	// Check for aws ssm processes
-	cmd := exec.Command("sh", "-c", "ps aux | grep 'aws.*ssm.*start-session' | grep -v grep")
-	if output, err := cmd.Output(); err == nil {
-		lines := strings.Split(strings.TrimSpace(string(output)), "\n")
-		for _, line := range lines {
-			if line != "" {
-				count++
-				fmt.Printf("  • AWS SSM process: %s\n", strings.Fields(line)[1])
-			}
-		}
-	}
-	
-	// Check for session-manager-plugin processes
-	cmd = exec.Command("sh", "-c", "ps aux | grep 'session-manager-plugin' | grep -v grep")
-	if output, err := cmd.Output(); err == nil {
-		lines := strings.Split(strings.TrimSpace(string(output)), "\n")
-		for _, line := range lines {
-			if line != "" {
-				count++
-				fmt.Printf("  • Session Manager plugin: %s\n", strings.Fields(line)[1])
-			}
-		}
-	}
-	
-	if count == 0 {
-		fmt.Println("  None found")
+// tunnelHealth describes the state of a tunnel for listings
+func tunnelHealth(tm *connectivity.TunnelManager, t connectivity.TunnelState) string {
+	switch {
+	case tm.Healthy(t):
+		return "healthy"
+	case tm.IsProcessAlive(t.PID):
+		return "port not listening"
+	case t.LastError != "":
+		return fmt.Sprintf("dead, %d restarts failed", t.Failures)
+	default:
+		return "dead"
 	}
-	
-	return count
 }
 
-func checkPortStatus(port int) {
-	// Check what's using the port
-	cmd := exec.Command("sh", "-c", fmt.Sprintf("lsof -i:%d", port))
-	if output, err := cmd.Output(); err == nil {
-		lines := strings.Split(strings.TrimSpace(string(output)), "\n")
-		if len(lines) > 1 { // Skip header
-			fmt.Printf("  Port %d is in use by:\n", port)
-			for i, line := range lines {
-				if i > 0 && line != "" { // Skip header
-					fields := strings.Fields(line)
-					if len(fields) > 1 {
-						fmt.Printf("    • Process: %s (PID: %s)\n", fields[0], fields[1])
-					}
-				}
-			}
-		} else {
-			fmt.Printf("  Port %d is free\n", port)
-		}
-	} else {
-		fmt.Printf("  Port %d is free\n", port)
-	}
+// ssmProcess is an SSM session process found on this machine
+type ssmProcess struct {
+	pid  int
+	args string
 }
 
-func checkSSMProcesses() {
-	// Check for SSM processes
-	cmd := exec.Command("sh", "-c", "ps aux | grep -E '(aws.*ssm|session-manager)' | grep -v grep")
-	if output, err := cmd.Output(); err == nil {
-		lines := strings.Split(strings.TrimSpace(string(output)), "\n")
-		if len(lines) > 0 && lines[0] != "" {
-			fmt.Println("  • Found SSM processes:")
-			for _, line := range lines {
-				if line != "" {
-					fields := strings.Fields(line)
-					if len(fields) > 10 {
-						fmt.Printf("    PID %s: %s\n", fields[1], strings.Join(fields[10:], " "))
-					}
-				}
-			}
-		} else {
-			fmt.Println("  • No SSM processes found")
+// orphanedSSMProcesses finds SSM port-forward processes that belong to no
+// tracked tunnel. Tunnels run in their own process group, led by the
+// tracked PID.
+func orphanedSSMProcesses(tracked map[int]bool) []ssmProcess {
+	output, err := exec.Command("ps", "-eo", "pid=,pgid=,args=").Output()
+	if err != nil {
+		return nil
+	}
+	var orphans []ssmProcess
+	for _, line := range strings.Split(string(output), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 3 {
+			continue
+		}
+		args := strings.Join(fields[2:], " ")
+		if !strings.Contains(args, "session-manager-plugin") && !(strings.Contains(args, "ssm") && strings.Contains(args, "start-session")) {
+			continue
+		}
+		pid, _ := strconv.Atoi(fields[0])
+		pgid, _ := strconv.Atoi(fields[1])
+		if pid > 0 && !tracked[pgid] {
+			orphans = append(orphans, ssmProcess{pid: pid, args: args})
 		}
-	} else {
-		fmt.Println("  • No SSM processes found")
 	}
-}
-
-func killAllSSMProcesses() {
-	// Kill aws ssm processes
-	exec.Command("sh", "-c", "pkill -f 'aws.*ssm.*start-session'").Run()
-	
-	// Kill session-manager-plugin
-	exec.Command("sh", "-c", "pkill -f 'session-manager-plugin'").Run()
-	
-	fmt.Println("  • Killed all SSM processes")
-}
-
-func cleanupStateFiles() {
-	homeDir, _ := os.UserHomeDir()
-	stateDir := fmt.Sprintf("%s/.goman/tunnels", homeDir)
-	
-	// Remove all state files
-	exec.Command("sh", "-c", fmt.Sprintf("rm -f %s/*.json", stateDir)).Run()
-	
-	fmt.Println("  • Cleaned up state files")
-}
-
-func isPortOpen(port int) bool {
-	cmd := exec.Command("sh", "-c", fmt.Sprintf("nc -z localhost %d", port))
-	return cmd.Run() == nil
+	return orphans
 }
 
 func formatDuration(d time.Duration) string {
@@ -292,7 +325,13 @@ func formatDuration(d time.Duration) string {
 }
 
 func init() {
+	tunnelWatchCmd.Flags().DurationVar(&tunnelWatchInterval, "interval", 30*time.Second, "How often to check the tunnels")
+
+	tunnelCmd.AddCommand(tunnelListCmd)
+	tunnelCmd.AddCommand(tunnelStopCmd)
+	tunnelCmd.AddCommand(tunnelStopAllCmd)
+	tunnelCmd.AddCommand(tunnelWatchCmd)
 	tunnelCmd.AddCommand(tunnelStatusCmd)
 	tunnelCmd.AddCommand(tunnelCleanupCmd)
 	tunnelCmd.AddCommand(tunnelHealthCmd)
-}
\ No newline at end of file
+}
@@ -1,25 +1,58 @@
 package main
 
 import (
+	"io"
 	"os"
 	"path/filepath"
 	"strings"
 	"sync"
+	"time"
 
+	"github.com/madhouselabs/goman/pkg/cluster"
 	"github.com/madhouselabs/goman/pkg/connectivity"
+	"github.com/madhouselabs/goman/pkg/logger"
 )
 
 var (
-	globalSingleTunnelManager *connectivity.SingleTunnelManager
-	tunnelManagerOnce         sync.Once
+	globalTunnelManager *connectivity.TunnelManager
+	tunnelManagerOnce   sync.Once
 )
 
-// GetGlobalSingleTunnelManager returns the singleton single tunnel manager
-func GetGlobalSingleTunnelManager() *connectivity.SingleTunnelManager {
+// GetGlobalTunnelManager returns the singleton tunnel manager
+func GetGlobalTunnelManager() *connectivity.TunnelManager {
 	tunnelManagerOnce.Do(func() {
-		globalSingleTunnelManager = connectivity.NewSingleTunnelManager()
+		globalTunnelManager = connectivity.NewTunnelManager()
+		globalTunnelManager.OnTunnelStarted = func(state connectivity.TunnelState) {
+			if clusterManager == nil {
+				clusterManager = cluster.NewManager()
+			}
+			clusterManager.RecordTunnel(state.ClusterName, state.InstanceID, state.Region)
+		}
 	})
-	return globalSingleTunnelManager
+	return globalTunnelManager
+}
+
+// tunnelRestartInterval is how often the TUI restarts tunnels that died
+const tunnelRestartInterval = 30 * time.Second
+
+// startTunnelWatcher restarts dead tunnels in the background while the TUI
+// runs; their progress messages would draw over it, so they are dropped
+func startTunnelWatcher() {
+	tm := GetGlobalTunnelManager()
+	tm.Out = io.Discard
+	go func() {
+		ticker := time.NewTicker(tunnelRestartInterval)
+		defer ticker.Stop()
+		for range ticker.C {
+			restarted, err := tm.RestartDead()
+			if err != nil {
+				logger.Printf("Failed to check tunnels: %v", err)
+			}
+			for _, name := range restarted {
+				logger.Printf("Restarted tunnel to cluster %s", name)
+			}
+		}
+	}()
 }
 
 // getCurrentClusterFile returns the path to the current cluster file
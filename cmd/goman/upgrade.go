@@ -0,0 +1,91 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/madhouselabs/goman/pkg/cluster"
+	"github.com/madhouselabs/goman/pkg/i18n"
+	"github.com/madhouselabs/goman/pkg/models"
+	"github.com/spf13/cobra"
+)
+
+// clusterUpgradeCmd groups commands for K3s version upgrades
+var clusterUpgradeCmd = &cobra.Command{
+	Use:   "upgrade",
+	Short: i18n.T("cmd.cluster.upgrade.short"),
+	Long:  i18n.T("cmd.cluster.upgrade.long"),
+}
+
+// clusterUpgradeStartCmd changes the K3s version of a cluster
+var clusterUpgradeStartCmd = &cobra.Command{
+	Use:   "start <cluster-name> <k3s-version>",
+	Short: i18n.T("cmd.cluster.upgrade.start.short"),
+	Args:  cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		clusterName := resolveClusterAlias(args[0])
+
+		if clusterManager == nil {
+			clusterManager = cluster.NewManager()
+		}
+
+		if err := clusterManager.UpgradeK3s(clusterName, args[1]); err != nil {
+			return fmt.Errorf("failed to upgrade cluster: %w", err)
+		}
+
+		outf("⬆️  Cluster %s will be upgraded to K3s %s node by node\n", clusterName, args[1])
+		outln("💡 Use 'goman cluster upgrade status " + clusterName + "' to follow progress")
+		return nil
+	},
+}
+
+// clusterUpgradeStatusCmd shows the current or last upgrade
+var clusterUpgradeStatusCmd = &cobra.Command{
+	Use:   "status <cluster-name>",
+	Short: i18n.T("cmd.cluster.upgrade.status.short"),
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		clusterName := resolveClusterAlias(args[0])
+
+		if clusterManager == nil {
+			clusterManager = cluster.NewManager()
+		}
+
+		upgrade, err := clusterManager.UpgradeStatus(clusterName)
+		if err != nil {
+			return err
+		}
+		if upgrade == nil {
+			outf("No K3s upgrades for cluster %s\n", clusterName)
+			return nil
+		}
+		printUpgradeStatus(upgrade)
+		return nil
+	},
+}
+
+// printUpgradeStatus prints an upgrade and the node being upgraded
+func printUpgradeStatus(upgrade *models.UpgradeStatus) {
+	if upgrade.FromVersion != "" {
+		outf("Change:    %s -> %s\n", upgrade.FromVersion, upgrade.ToVersion)
+	} else {
+		outf("Change:    -> %s\n", upgrade.ToVersion)
+	}
+	outf("Phase:     %s\n", upgrade.Phase)
+	outf("Upgraded:  %d/%d\n", upgrade.Upgraded, upgrade.Total)
+	if upgrade.Node != "" {
+		outf("Node:      %s (%s): %s\n", upgrade.NodeName, upgrade.Node, upgrade.NodePhase)
+	}
+	outf("Started:   %s\n", upgrade.StartedAt.Format("2006-01-02 15:04:05"))
+	if upgrade.CompletedAt != nil {
+		outf("Completed: %s\n", upgrade.CompletedAt.Format("2006-01-02 15:04:05"))
+	}
+	if upgrade.Message != "" {
+		outf("Message:   %s\n", upgrade.Message)
+	}
+}
+
+func init() {
+	clusterUpgradeCmd.AddCommand(clusterUpgradeStartCmd)
+	clusterUpgradeCmd.AddCommand(clusterUpgradeStatusCmd)
+	clusterCmd.AddCommand(clusterUpgradeCmd)
+}
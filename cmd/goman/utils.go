@@ -6,24 +6,25 @@ import (
 	"os"
 
 	"github.com/madhouselabs/goman/pkg/config"
+	"github.com/madhouselabs/goman/pkg/provider"
 	"github.com/madhouselabs/goman/pkg/provider/registry"
 )
 
 // initializeInfrastructure initializes AWS infrastructure
 func initializeInfrastructure() {
-	fmt.Println("Initializing AWS infrastructure...")
+	outln("Initializing AWS infrastructure...")
 	
 	// Load configuration
 	cfg, err := config.NewConfig()
 	if err != nil {
-		fmt.Printf("Error loading config: %v\n", err)
+		outf("Error loading config: %v\n", err)
 		os.Exit(1)
 	}
 
-	// Get AWS provider
-	provider, err := registry.GetProvider("aws", cfg.AWSProfile, cfg.AWSRegion)
+	// Get the configured provider
+	provider, err := registry.GetConfiguredProvider(cfg.AWSProfile, cfg.AWSRegion)
 	if err != nil {
-		fmt.Printf("Error getting AWS provider: %v\n", err)
+		outf("Error getting provider: %v\n", err)
 		os.Exit(1)
 	}
 
@@ -31,60 +32,146 @@ func initializeInfrastructure() {
 	ctx := context.Background()
 	result, err := provider.Initialize(ctx)
 	if err != nil {
-		fmt.Printf("❌ Error initializing infrastructure: %v\n", err)
+		outf("❌ Error initializing infrastructure: %v\n", err)
 		os.Exit(1)
 	}
 
 	// Show initialization result details
 	if result != nil {
-		fmt.Println("\n📋 Initialization Status:")
-		fmt.Printf("  Storage: %v\n", result.StorageReady)
-		fmt.Printf("  Lock Service: %v\n", result.LockServiceReady)
-		fmt.Printf("  Lambda Function: %v\n", result.FunctionReady)
-		fmt.Printf("  Notifications: %v\n", result.NotificationsReady)
+		outln("\n📋 Initialization Status:")
+		outf("  Storage: %v\n", result.StorageReady)
+		outf("  Lock Service: %v\n", result.LockServiceReady)
+		outf("  Lambda Function: %v\n", result.FunctionReady)
+		outf("  Notifications: %v\n", result.NotificationsReady)
 		
 		if len(result.Resources) > 0 {
-			fmt.Println("\n📦 Resources Created:")
+			outln("\n📦 Resources Created:")
 			for key, value := range result.Resources {
-				fmt.Printf("  %s: %s\n", key, value)
+				outf("  %s: %s\n", key, value)
 			}
 		}
 		
 		if len(result.Errors) > 0 {
-			fmt.Println("\n⚠️  Warnings:")
+			outln("\n⚠️  Warnings:")
 			for _, err := range result.Errors {
-				fmt.Printf("  - %s\n", err)
+				outf("  - %s\n", err)
 			}
 		}
 	}
 
-	fmt.Println("\n✅ Infrastructure initialized successfully!")
+	// Session logging is only changed when --session-logging is given
+	if initSessionLogging != "" {
+		if err := configureSessionLogging(ctx, provider, initSessionLogging); err != nil {
+			outf("❌ Error configuring session logging: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	if err := configureRetention(ctx, provider, initRetention); err != nil {
+		outf("❌ Error configuring retention: %v\n", err)
+		os.Exit(1)
+	}
+
+	outln("\n✅ Infrastructure initialized successfully!")
+}
+
+// initSessionLogging is set by 'goman init --session-logging'
+var initSessionLogging string
+
+// sessionLoggingConfigurer is implemented by providers that support
+// compliance logging of remote access
+type sessionLoggingConfigurer interface {
+	ConfigureSessionLogging(ctx context.Context, cfg provider.SessionLoggingConfig) error
+}
+
+// configureSessionLogging applies the --session-logging setting of init
+func configureSessionLogging(ctx context.Context, p interface{}, value string) error {
+	cfg, err := provider.ParseSessionLogging(value)
+	if err != nil {
+		return err
+	}
+	configurer, ok := p.(sessionLoggingConfigurer)
+	if !ok {
+		return fmt.Errorf("provider does not support session logging")
+	}
+	if err := configurer.ConfigureSessionLogging(ctx, cfg); err != nil {
+		return err
+	}
+
+	outf("\n📋 Session logging: %s\n", cfg)
+	if cfg.S3 {
+		outf("  Command output: s3://<goman bucket>/%s/<cluster>/\n", cfg.ClusterS3Prefix(""))
+	}
+	if cfg.CloudWatch {
+		outf("  Command output: CloudWatch log group %s\n", cfg.ClusterLogGroup("<cluster>"))
+	}
+	if cfg.Enabled() {
+		outln("  Tunnels: recorded in the audit trail ('goman audit log')")
+	}
+	return nil
+}
+
+// initRetention is set by 'goman init --retention'
+var initRetention string
+
+// retentionConfigurer is implemented by providers whose storage expires old
+// objects by itself
+type retentionConfigurer interface {
+	ConfigureRetention(ctx context.Context, cfg provider.RetentionConfig) error
+}
+
+// configureRetention applies the stored retention settings, changed by the
+// --retention setting of init, to the state bucket
+func configureRetention(ctx context.Context, p provider.Provider, value string) error {
+	configurer, ok := p.(retentionConfigurer)
+	if !ok {
+		if value != "" {
+			return fmt.Errorf("provider does not support retention settings")
+		}
+		return nil
+	}
+	cfg, err := provider.LoadRetention(ctx, p.GetStorageService())
+	if err != nil {
+		return err
+	}
+	if cfg, err = provider.ParseRetention(cfg, value); err != nil {
+		return err
+	}
+	if err := configurer.ConfigureRetention(ctx, cfg); err != nil {
+		return err
+	}
+
+	outf("\n🧹 Retention: %s\n", cfg)
+	if cfg.BinaryDays > 0 {
+		outln("  Unused K3s binaries are pruned by the controller ('goman admin prune' to prune now)")
+	}
+	return nil
 }
 
 // forceCleanupCluster removes all AWS resources for a cluster
 func forceCleanupCluster(clusterName string) {
-	fmt.Printf("🗑️  Force cleaning up cluster '%s'...\n", clusterName)
+	outf("🗑️  Force cleaning up cluster '%s'...\n", clusterName)
 	
 	// Load configuration
 	cfg, err := config.NewConfig()
 	if err != nil {
-		fmt.Printf("Error loading config: %v\n", err)
+		outf("Error loading config: %v\n", err)
 		os.Exit(1)
 	}
 
-	// Get AWS provider
-	provider, err := registry.GetProvider("aws", cfg.AWSProfile, cfg.AWSRegion)
+	// Get the configured provider
+	provider, err := registry.GetConfiguredProvider(cfg.AWSProfile, cfg.AWSRegion)
 	if err != nil {
-		fmt.Printf("Error getting AWS provider: %v\n", err)
+		outf("Error getting provider: %v\n", err)
 		os.Exit(1)
 	}
 
 	// Force cleanup all cluster resources
 	ctx := context.Background()
 	if err := provider.Cleanup(ctx); err != nil {
-		fmt.Printf("Error during force cleanup: %v\n", err)
+		outf("Error during force cleanup: %v\n", err)
 		os.Exit(1)
 	}
 
-	fmt.Printf("✅ Cluster '%s' forcefully cleaned up!\n", clusterName)
+	outf("✅ Cluster '%s' forcefully cleaned up!\n", clusterName)
 }
\ No newline at end of file
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/madhouselabs/goman/pkg/config"
+	"github.com/madhouselabs/goman/pkg/controller"
+	"github.com/madhouselabs/goman/pkg/i18n"
+	"github.com/madhouselabs/goman/pkg/models"
+	"github.com/madhouselabs/goman/pkg/provider/registry"
+	"github.com/spf13/cobra"
+)
+
+var clusterVerifyJSON bool
+
+// clusterVerifyCmd runs smoke tests against a cluster
+var clusterVerifyCmd = &cobra.Command{
+	Use:   "verify <cluster-name>",
+	Short: i18n.T("cmd.cluster.verify.short"),
+	Long:  i18n.T("cmd.cluster.verify.long"),
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		clusterName := resolveClusterAlias(args[0])
+
+		cfg, err := config.NewConfig()
+		if err != nil {
+			return fmt.Errorf("failed to load config: %w", err)
+		}
+		p, err := registry.GetConfiguredProvider(cfg.AWSProfile, cfg.AWSRegion)
+		if err != nil {
+			return fmt.Errorf("failed to get provider: %w", err)
+		}
+		reconciler, err := controller.NewReconciler(p, "goman-verify")
+		if err != nil {
+			return err
+		}
+
+		if !clusterVerifyJSON {
+			outf("🧪 Running smoke tests on cluster %s...\n\n", clusterName)
+		}
+		result, err := reconciler.VerifyCluster(context.Background(), clusterName)
+		if err != nil {
+			return err
+		}
+
+		if clusterVerifyJSON {
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			if err := enc.Encode(result); err != nil {
+				return err
+			}
+		} else if err := printVerification(result); err != nil {
+			return err
+		}
+
+		if failed := result.Failed(); len(failed) > 0 {
+			return fmt.Errorf("%d smoke test(s) failed", len(failed))
+		}
+		return nil
+	},
+}
+
+// printVerification prints the results of the smoke tests as a table
+func printVerification(result *models.VerificationStatus) error {
+	icons := map[string]string{models.VerifyPass: "✅", models.VerifyFail: "❌", models.VerifySkip: "➖"}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "TEST\tRESULT\tDETAIL")
+	for _, t := range result.Tests {
+		fmt.Fprintf(w, "%s\t%s %s\t%s\n", t.Name, icons[t.Result], t.Result, t.Detail)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	outf("\nFinished in %s: %s\n", result.Duration, result.Summary())
+	return nil
+}
+
+func init() {
+	clusterVerifyCmd.Flags().BoolVar(&clusterVerifyJSON, "json", false, "Print the results as JSON")
+	clusterCmd.AddCommand(clusterVerifyCmd)
+}
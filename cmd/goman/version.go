@@ -0,0 +1,63 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/madhouselabs/goman/pkg/i18n"
+	"github.com/madhouselabs/goman/pkg/version"
+	"github.com/spf13/cobra"
+)
+
+var (
+	versionCheck bool
+	versionJSON  bool
+)
+
+// versionCmd prints build information and optionally checks for updates
+var versionCmd = &cobra.Command{
+	Use:   "version",
+	Short: i18n.T("cmd.version.short"),
+	Long:  i18n.T("cmd.version.long"),
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		info := version.Get()
+		if versionJSON {
+			data, err := json.MarshalIndent(info, "", "  ")
+			if err != nil {
+				return err
+			}
+			outln(string(data))
+		} else {
+			outf("goman %s\n", info.Version)
+			outf("  commit:     %s\n", info.Commit)
+			outf("  built:      %s\n", info.Date)
+			outf("  go version: %s\n", info.GoVersion)
+			outf("  platform:   %s\n", info.Platform)
+		}
+
+		if !versionCheck {
+			return nil
+		}
+		if version.UpdateCheckDisabled() {
+			outf("Update check disabled by %s\n", version.NoUpdateCheckEnv)
+			return nil
+		}
+		release, err := version.LatestRelease(context.Background())
+		if err != nil {
+			return fmt.Errorf("failed to check for updates: %w", err)
+		}
+		if version.IsNewer(release.Version, info.Version) {
+			outf("A newer version is available: %s (%s)\n", release.Version, release.URL)
+		} else {
+			outf("goman is up to date (latest release %s)\n", release.Version)
+		}
+		return nil
+	},
+}
+
+func init() {
+	versionCmd.Flags().BoolVar(&versionCheck, "check", false, "Check GitHub for a newer release")
+	versionCmd.Flags().BoolVar(&versionJSON, "json", false, "Print build information as JSON")
+}
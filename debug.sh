@@ -1,13 +1,19 @@
 #!/bin/bash
 # Debug script for reconciler
+# Usage: ./debug.sh <cluster-name> [goman debug reconcile flags, e.g. --dry-run --phase Installing]
+
+if [ -z "$1" ]; then
+    echo "Usage: $0 <cluster-name> [--dry-run] [--phase <phase>] [--trace] [--snapshot-dir <dir>]"
+    exit 1
+fi
 
 echo "Starting debugger on port 2345..."
 echo ""
 echo "Set breakpoints at:"
-echo "  break pkg/controller/reconciler.go:176"
-echo "  break pkg/provider/aws/compute_service.go:384"
+echo "  break pkg/controller/reconciler.go:ReconcileClusterWithRequestID"
+echo "  break pkg/provider/aws/compute_service.go:CreateInstance"
 echo ""
 echo "Then type 'continue' to run"
 echo ""
 
-~/go/bin/dlv debug cmd/test-reconciler/main.go --headless --listen=:2345 --api-version=2 -- k3s-cluster-1756046400
\ No newline at end of file
+~/go/bin/dlv debug ./cmd/goman --headless --listen=:2345 --api-version=2 -- debug reconcile "$@"
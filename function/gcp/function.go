@@ -0,0 +1,15 @@
+// Package function is the source of the GCP controller function. Cloud
+// Functions builds it from the root of the uploaded source, so the
+// build:function-gcp task stages it with this module next to it.
+package function
+
+import (
+	"net/http"
+
+	"github.com/madhouselabs/goman/pkg/provider/gcp"
+)
+
+// Reconcile handles storage events and requeue tasks
+func Reconcile(w http.ResponseWriter, r *http.Request) {
+	gcp.Reconcile(w, r)
+}
@@ -4,13 +4,16 @@ import (
 	"fmt"
 	"log"
 	"os"
+	_ "time/tzdata" // Cluster schedules use IANA time zones; the runtime may have no zoneinfo
 
 	"github.com/madhouselabs/goman/pkg/provider/aws"
+	"github.com/madhouselabs/goman/pkg/version"
 )
 
 func main() {
 	// Log environment info for debugging
 	log.Println("=== Lambda Main Starting ===")
+	log.Printf("Controller version: %s", version.String())
 	log.Printf("AWS Region: %s", os.Getenv("AWS_REGION"))
 	log.Printf("Lambda Task Root: %s", os.Getenv("LAMBDA_TASK_ROOT"))
 	log.Printf("Lambda Runtime API: %s", os.Getenv("AWS_LAMBDA_RUNTIME_API"))
@@ -0,0 +1,387 @@
+package audit
+
+import (
+	"fmt"
+	"os"
+	"os/user"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/madhouselabs/goman/pkg/models"
+	"gopkg.in/yaml.v3"
+)
+
+// Audit actions
+const (
+	ActionCreate        = "create"
+	ActionUpdate        = "update"
+	ActionScale         = "scale"
+	ActionDelete        = "delete"
+	ActionStart         = "start"
+	ActionStop          = "stop"
+	ActionDownscale     = "downscale"
+	ActionReplaceNode   = "replace-node"
+	ActionRename        = "rename"
+	ActionTunnel        = "tunnel"
+	ActionShare         = "share"
+	ActionRollout       = "rollout"
+	ActionRetag         = "retag"
+	ActionRotateCerts   = "rotate-certs"
+	ActionRotateCreds   = "rotate-credentials"
+	ActionUpgrade       = "upgrade"
+	ActionRecoverQuorum = "recover-quorum"
+	ActionResync        = "resync"
+	ActionBlueGreen     = "bluegreen"
+	ActionCutover       = "cutover"
+	ActionFixDrift      = "fix-drift"
+	ActionAnnotate      = "annotate"
+)
+
+// auditPrefix is kept outside clusters/ so entries don't trigger reconciles
+const auditPrefix = "audit/"
+
+// Entry is a single audit record for a spec mutation
+type Entry struct {
+	Time      time.Time `yaml:"time"`
+	Cluster   string    `yaml:"cluster"`
+	Action    string    `yaml:"action"`
+	Principal string    `yaml:"principal,omitempty"` // Cloud identity (e.g., IAM ARN)
+	User      string    `yaml:"user,omitempty"`      // Local OS user and host
+	Changes   []string  `yaml:"changes,omitempty"`   // Human-readable diff summary
+}
+
+// ObjectStore is the subset of the storage backend used for audit entries
+type ObjectStore interface {
+	PutObject(key string, data []byte) error
+	GetObject(key string) ([]byte, error)
+	ListObjects(prefix string) ([]string, error)
+}
+
+// Recorder appends audit entries to object storage. Entries are written to
+// unique keys and never rewritten, so the log is append-only.
+type Recorder struct {
+	store     ObjectStore
+	principal func() string
+}
+
+// NewRecorder creates a recorder. principal resolves the cloud identity of
+// the caller and may be nil.
+func NewRecorder(store ObjectStore, principal func() string) *Recorder {
+	return &Recorder{
+		store:     store,
+		principal: principal,
+	}
+}
+
+// Record appends an entry for a cluster mutation
+func (r *Recorder) Record(cluster, action string, changes []string) error {
+	if r == nil || r.store == nil {
+		return nil
+	}
+
+	entry := Entry{
+		Time:    time.Now().UTC(),
+		Cluster: cluster,
+		Action:  action,
+		User:    localUser(),
+		Changes: changes,
+	}
+	if r.principal != nil {
+		entry.Principal = r.principal()
+	}
+
+	data, err := yaml.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to marshal audit entry: %w", err)
+	}
+
+	// Timestamp first so keys sort chronologically within a cluster
+	key := fmt.Sprintf("%s%s/%s-%s.yaml", auditPrefix, cluster, entry.Time.Format("20060102T150405.000000000Z"), action)
+	if err := r.store.PutObject(key, data); err != nil {
+		return fmt.Errorf("failed to write audit entry: %w", err)
+	}
+	return nil
+}
+
+// List returns audit entries, oldest first. An empty cluster lists all
+// clusters; limit <= 0 returns everything.
+func (r *Recorder) List(cluster string, limit int) ([]Entry, error) {
+	if r == nil || r.store == nil {
+		return nil, fmt.Errorf("audit storage not available")
+	}
+
+	prefix := auditPrefix
+	if cluster != "" {
+		prefix = fmt.Sprintf("%s%s/", auditPrefix, cluster)
+	}
+
+	keys, err := r.store.ListObjects(prefix)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list audit entries: %w", err)
+	}
+
+	var entries []Entry
+	for _, key := range keys {
+		if !strings.HasSuffix(key, ".yaml") {
+			continue
+		}
+		data, err := r.store.GetObject(key)
+		if err != nil {
+			continue
+		}
+		var entry Entry
+		if err := yaml.Unmarshal(data, &entry); err != nil {
+			continue
+		}
+		entries = append(entries, entry)
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Time.Before(entries[j].Time)
+	})
+
+	if limit > 0 && len(entries) > limit {
+		entries = entries[len(entries)-limit:]
+	}
+	return entries, nil
+}
+
+// Diff summarizes spec changes between two versions of a cluster
+func Diff(old, updated models.K3sCluster) []string {
+	var changes []string
+
+	field := func(name, before, after string) {
+		if before != after {
+			changes = append(changes, fmt.Sprintf("%s: %s -> %s", name, before, after))
+		}
+	}
+
+	field("name", old.Name, updated.Name)
+	field("description", old.Description, updated.Description)
+	field("mode", string(old.Mode), string(updated.Mode))
+	field("region", old.Region, updated.Region)
+	field("instanceType", old.InstanceType, updated.InstanceType)
+	field("architecture", old.Architecture, updated.Architecture)
+	field("imageID", old.ImageID, updated.ImageID)
+	field("osFamily", old.OSFamily, updated.OSFamily)
+	field("rootVolume", old.RootVolume.String(), updated.RootVolume.String())
+	field("k3sVersion", old.K3sVersion, updated.K3sVersion)
+	field("desiredState", old.DesiredState, updated.DesiredState)
+	for _, f := range models.DriftFields {
+		field("driftPolicy."+f, string(models.DriftPolicyFor(old.DriftPolicy, f)),
+			string(models.DriftPolicyFor(updated.DriftPolicy, f)))
+	}
+	field("dns", dnsSummary(old.DNS), dnsSummary(updated.DNS))
+	field("virtualIP", virtualIPSummary(old.VirtualIP), virtualIPSummary(updated.VirtualIP))
+	field("loadBalancer", loadBalancerSummary(old.LoadBalancer), loadBalancerSummary(updated.LoadBalancer))
+	field("instanceProtection", old.InstanceProtection.Key(), updated.InstanceProtection.Key())
+	field("clusterLinks", clusterLinksSummary(old.ClusterLinks), clusterLinksSummary(updated.ClusterLinks))
+	field("addons", addonsSummary(old.Addons), addonsSummary(updated.Addons))
+	field("notifications", old.Notifications.Key(), updated.Notifications.Key())
+	field("proxy", old.Proxy.Key(), updated.Proxy.Key())
+	field("bootstrap", old.Bootstrap.Key(), updated.Bootstrap.Key())
+	field("registries", old.Registries.Key(), updated.Registries.Key())
+	field("schedule", old.Schedule.Key(), updated.Schedule.Key())
+	field("tags", strings.Join(models.FormatResourceTags(models.ParseResourceTags(old.Tags)), ","),
+		strings.Join(models.FormatResourceTags(models.ParseResourceTags(updated.Tags)), ","))
+
+	oldPools := make(map[string]models.NodePool)
+	for _, pool := range old.NodePools {
+		oldPools[pool.Name] = pool
+	}
+	newPools := make(map[string]models.NodePool)
+	for _, pool := range updated.NodePools {
+		newPools[pool.Name] = pool
+		before, ok := oldPools[pool.Name]
+		if !ok {
+			changes = append(changes, fmt.Sprintf("nodePool %s added (%d x %s)", pool.Name, pool.Count, pool.InstanceType))
+			continue
+		}
+		if before.Count != pool.Count {
+			changes = append(changes, fmt.Sprintf("nodePool %s count: %d -> %d", pool.Name, before.Count, pool.Count))
+		}
+		if before.InstanceType != pool.InstanceType {
+			changes = append(changes, fmt.Sprintf("nodePool %s instanceType: %s -> %s", pool.Name, before.InstanceType, pool.InstanceType))
+		}
+		if before.Architecture != pool.Architecture {
+			changes = append(changes, fmt.Sprintf("nodePool %s architecture: %q -> %q", pool.Name, before.Architecture, pool.Architecture))
+		}
+		if before.ImageID != pool.ImageID || before.OSFamily != pool.OSFamily {
+			changes = append(changes, fmt.Sprintf("nodePool %s image: %q/%q -> %q/%q", pool.Name, before.ImageID, before.OSFamily, pool.ImageID, pool.OSFamily))
+		}
+		if a, b := volumesSummary(before.Volumes), volumesSummary(pool.Volumes); a != b {
+			changes = append(changes, fmt.Sprintf("nodePool %s volumes: %s -> %s", pool.Name, a, b))
+		}
+		if a, b := before.RootVolume.String(), pool.RootVolume.String(); a != b {
+			changes = append(changes, fmt.Sprintf("nodePool %s rootVolume: %s -> %s", pool.Name, a, b))
+		}
+		if a, b := strings.Join(before.AvailabilityZones, ","), strings.Join(pool.AvailabilityZones, ","); a != b {
+			changes = append(changes, fmt.Sprintf("nodePool %s availabilityZones: [%s] -> [%s]", pool.Name, a, b))
+		}
+		if before.InstanceStore != pool.InstanceStore {
+			changes = append(changes, fmt.Sprintf("nodePool %s instanceStore: %t -> %t", pool.Name, before.InstanceStore, pool.InstanceStore))
+		}
+		if before.UserData != pool.UserData {
+			changes = append(changes, fmt.Sprintf("nodePool %s userData: %d -> %d bytes", pool.Name, len(before.UserData), len(pool.UserData)))
+		}
+		if before.Paused != pool.Paused {
+			changes = append(changes, fmt.Sprintf("nodePool %s paused: %t -> %t", pool.Name, before.Paused, pool.Paused))
+		}
+		if before.ScaleDownBehavior != pool.ScaleDownBehavior {
+			changes = append(changes, fmt.Sprintf("nodePool %s scaleDownBehavior: %q -> %q", pool.Name, before.ScaleDownBehavior, pool.ScaleDownBehavior))
+		}
+	}
+	for _, pool := range old.NodePools {
+		if _, ok := newPools[pool.Name]; !ok {
+			changes = append(changes, fmt.Sprintf("nodePool %s removed", pool.Name))
+		}
+	}
+
+	return changes
+}
+
+// DiffNotes describes the notes added, changed and removed between two sets
+// of annotations, in key order. goman's own annotations are left out.
+func DiffNotes(old, updated map[string]string) []string {
+	var changes []string
+	for _, key := range models.Notes(updated) {
+		before, ok := old[key]
+		if !ok {
+			changes = append(changes, fmt.Sprintf("note %s added: %q", key, updated[key]))
+		} else if before != updated[key] {
+			changes = append(changes, fmt.Sprintf("note %s: %q -> %q", key, before, updated[key]))
+		}
+	}
+	for _, key := range models.Notes(old) {
+		if _, ok := updated[key]; !ok {
+			changes = append(changes, fmt.Sprintf("note %s removed", key))
+		}
+	}
+	return changes
+}
+
+// clusterLinksSummary describes the cluster links, in name order
+func clusterLinksSummary(links []models.ClusterLink) string {
+	parts := make([]string, len(links))
+	for i, link := range links {
+		parts[i] = fmt.Sprintf("%s(%s)", link.Cluster, link.Key())
+	}
+	sort.Strings(parts)
+	return strings.Join(parts, ";")
+}
+
+// addonsSummary describes addons by name and the source and version they
+// resolve to
+func addonsSummary(addons []models.Addon) string {
+	parts := make([]string, len(addons))
+	for i, addon := range addons {
+		resolved := addon.Resolve()
+		parts[i] = fmt.Sprintf("%s(%s@%s)", addon.Name, resolved.Source(), resolved.Key())
+	}
+	sort.Strings(parts)
+	return strings.Join(parts, ";")
+}
+
+// virtualIPSummary describes a virtual IP setting
+func virtualIPSummary(spec *models.VirtualIPSpec) string {
+	switch {
+	case spec == nil:
+		return "none"
+	case spec.Address == "":
+		return "allocated"
+	default:
+		return spec.Address
+	}
+}
+
+// loadBalancerSummary describes an API load balancer setting
+func loadBalancerSummary(spec *models.LoadBalancerSpec) string {
+	if spec == nil {
+		return "none"
+	}
+	summary := "internet-facing"
+	if spec.Internal {
+		summary = "internal"
+	}
+	if len(spec.AllowedCIDRs) > 0 {
+		summary += " from " + strings.Join(spec.AllowedCIDRs, ",")
+	}
+	if spec.Cluster != "" {
+		summary += " of " + spec.Cluster
+	}
+	return summary
+}
+
+// dnsSummary describes a DNS customization
+func dnsSummary(spec *models.DNSSpec) string {
+	if models.DNSConfigHash(spec) == "" {
+		return "default"
+	}
+	var parts []string
+	domains := make([]string, 0, len(spec.StubDomains))
+	for domain := range spec.StubDomains {
+		domains = append(domains, domain)
+	}
+	sort.Strings(domains)
+	for _, domain := range domains {
+		parts = append(parts, fmt.Sprintf("%s via %s", domain, strings.Join(spec.StubDomains[domain], ",")))
+	}
+	if len(spec.Upstreams) > 0 {
+		parts = append(parts, "upstreams "+strings.Join(spec.Upstreams, ","))
+	}
+	if spec.NodeLocalCache {
+		parts = append(parts, "nodelocal cache")
+	}
+	return strings.Join(parts, "; ")
+}
+
+// volumesSummary describes the data volumes of a node pool
+func volumesSummary(volumes []models.DataVolume) string {
+	if len(volumes) == 0 {
+		return "none"
+	}
+	var parts []string
+	for _, v := range volumes {
+		parts = append(parts, fmt.Sprintf("%dGiB %s %s at %s", v.Size, v.VolumeType(), v.FilesystemType(), v.MountPoint))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// Summary describes a new cluster for its create entry
+func Summary(cluster models.K3sCluster) []string {
+	changes := []string{
+		fmt.Sprintf("mode: %s", cluster.Mode),
+		fmt.Sprintf("region: %s", cluster.Region),
+		fmt.Sprintf("instanceType: %s", cluster.InstanceType),
+	}
+	for _, pool := range cluster.NodePools {
+		changes = append(changes, fmt.Sprintf("nodePool %s (%d x %s)", pool.Name, pool.Count, pool.InstanceType))
+	}
+	return changes
+}
+
+// IsScaleOnly reports whether a diff only changes node pool counts
+func IsScaleOnly(changes []string) bool {
+	if len(changes) == 0 {
+		return false
+	}
+	for _, change := range changes {
+		if !strings.HasPrefix(change, "nodePool ") || !strings.Contains(change, " count: ") {
+			return false
+		}
+	}
+	return true
+}
+
+// localUser returns "user@host" for the current OS user
+func localUser() string {
+	name := "unknown"
+	if u, err := user.Current(); err == nil {
+		name = u.Username
+	}
+	if host, err := os.Hostname(); err == nil {
+		return name + "@" + host
+	}
+	return name
+}
@@ -0,0 +1,335 @@
+// Package client provides typed access to goman cluster resources in the
+// state storage, for use by the goman CLI and TUI and by external programs.
+//
+// A cluster is stored as a spec (clusters/<name>/config.yaml), written by
+// clients, and a status (clusters/<name>/status.yaml), written only by the
+// controller. Writing a spec triggers reconciliation, so Create, Update and
+// Delete have the same effect as the corresponding goman commands.
+//
+// Writes use optimistic concurrency: every ClusterResource returned by Get
+// or List carries a ResourceVersion, and Update or Delete fail with
+// ErrConflict if the stored spec changed since it was read. Re-read the
+// cluster, reapply the change and retry:
+//
+//	p, err := registry.GetDefaultProvider()
+//	if err != nil {
+//		return err
+//	}
+//	c := client.NewFromProvider(p)
+//	for {
+//		cluster, err := c.Get(ctx, "dev")
+//		if err != nil {
+//			return err
+//		}
+//		cluster.Spec.NodePools[0].Count = 3
+//		if _, err := c.Update(ctx, cluster); !errors.Is(err, client.ErrConflict) {
+//			return err
+//		}
+//	}
+//
+// Versions are backed by conditional writes when the storage service
+// implements provider.VersionedStorage (S3 does). Otherwise the stored spec
+// is compared before writing, which narrows but does not close the window
+// for lost updates.
+package client
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/madhouselabs/goman/pkg/models"
+	"github.com/madhouselabs/goman/pkg/provider"
+	"github.com/madhouselabs/goman/pkg/storage"
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	// ErrNotFound is returned when a cluster does not exist
+	ErrNotFound = errors.New("cluster not found")
+	// ErrAlreadyExists is returned by Create when the name is taken, including
+	// by a cluster whose deletion is still being cleaned up
+	ErrAlreadyExists = errors.New("cluster already exists")
+	// ErrConflict is returned when the stored spec changed since it was read
+	ErrConflict = errors.New("cluster was modified concurrently")
+)
+
+// Client reads and writes cluster resources in the state storage
+type Client struct {
+	storage provider.StorageService
+}
+
+// New creates a client on top of a storage service
+func New(storageService provider.StorageService) *Client {
+	return &Client{storage: storageService}
+}
+
+// NewFromProvider creates a client using a provider's storage service
+func NewFromProvider(p provider.Provider) *Client {
+	return New(p.GetStorageService())
+}
+
+// List returns all clusters sorted by name
+func (c *Client) List(ctx context.Context) ([]*models.ClusterResource, error) {
+	keys, err := c.storage.ListObjects(ctx, "clusters/")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list clusters: %w", err)
+	}
+
+	var clusters []*models.ClusterResource
+	for _, key := range keys {
+		name, ok := storage.ClusterNameFromConfigKey(key)
+		if !ok {
+			continue
+		}
+		cluster, err := c.Get(ctx, name)
+		if errors.Is(err, ErrNotFound) {
+			// Deleted since listing
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		clusters = append(clusters, cluster)
+	}
+
+	sort.Slice(clusters, func(i, j int) bool { return clusters[i].Name < clusters[j].Name })
+	return clusters, nil
+}
+
+// Get returns a cluster with its spec and, once the controller has written
+// one, its status
+func (c *Client) Get(ctx context.Context, name string) (*models.ClusterResource, error) {
+	config, version, err := c.getConfig(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+
+	cluster := storage.ClusterResourceFromConfig(config)
+	cluster.Name = name
+	cluster.ResourceVersion = version
+
+	data, err := c.storage.GetObject(ctx, storage.ClusterStatusKey(name))
+	if err == nil {
+		if err := yaml.Unmarshal(data, &cluster.Status); err != nil {
+			return nil, fmt.Errorf("failed to parse status of %s: %w", name, err)
+		}
+		// Left by an earlier cluster of the same name until the controller replaces it
+		if !storage.StatusBelongsTo(cluster.Status.ClusterID, cluster.ClusterID) {
+			cluster.Status = models.ClusterResourceStatus{}
+		}
+	} else if !isNotFound(err) {
+		return nil, fmt.Errorf("failed to load status of %s: %w", name, err)
+	}
+
+	return cluster, nil
+}
+
+// Create stores the spec of a new cluster, which the controller then
+// provisions. The returned cluster carries the new ResourceVersion.
+func (c *Client) Create(ctx context.Context, cluster *models.ClusterResource) (*models.ClusterResource, error) {
+	if cluster.Name == "" {
+		return nil, fmt.Errorf("cluster name is required")
+	}
+
+	if data, err := c.storage.GetObject(ctx, storage.TombstoneKey(cluster.Name)); err == nil {
+		var tombstone storage.Tombstone
+		if err := yaml.Unmarshal(data, &tombstone); err == nil && !tombstone.Expired() {
+			return nil, fmt.Errorf("%w: %s is still being deleted", ErrAlreadyExists, cluster.Name)
+		}
+	}
+
+	created := *cluster
+	now := time.Now()
+	if created.ClusterID == "" {
+		created.ClusterID = fmt.Sprintf("k3s-%d", now.Unix())
+	}
+	if created.CreationTimestamp.IsZero() {
+		created.CreationTimestamp = now
+	}
+	created.DeletionTimestamp = nil
+	created.Status = models.ClusterResourceStatus{}
+
+	config := &storage.ClusterConfig{}
+	storage.ApplyClusterResource(config, &created)
+	config.Metadata.UpdatedAt = now
+	config.Metadata.Generation = 1
+
+	version, err := c.putConfig(ctx, config, "")
+	if errors.Is(err, ErrConflict) {
+		return nil, fmt.Errorf("%w: %s", ErrAlreadyExists, cluster.Name)
+	}
+	if err != nil {
+		return nil, err
+	}
+	created.ResourceVersion = version
+	created.Generation = config.Metadata.Generation
+	c.enqueueIntent(ctx, config)
+	return &created, nil
+}
+
+// Update replaces the spec, labels and annotations of a cluster. The cluster
+// must carry the ResourceVersion it was read with; the status is ignored as
+// only the controller writes it.
+func (c *Client) Update(ctx context.Context, cluster *models.ClusterResource) (*models.ClusterResource, error) {
+	if cluster.ResourceVersion == "" {
+		return nil, fmt.Errorf("resourceVersion is required to update %s", cluster.Name)
+	}
+
+	config, version, err := c.getConfig(ctx, cluster.Name)
+	if err != nil {
+		return nil, err
+	}
+	if version != cluster.ResourceVersion {
+		return nil, fmt.Errorf("%w: %s", ErrConflict, cluster.Name)
+	}
+	if config.Metadata.DeletionTimestamp != nil {
+		return nil, fmt.Errorf("cluster %s is being deleted", cluster.Name)
+	}
+
+	updated := *cluster
+	// Deletion goes through Delete so the name gets a tombstone
+	updated.DeletionTimestamp = nil
+	storage.ApplyClusterResource(config, &updated)
+	config.Metadata.UpdatedAt = time.Now()
+	config.Metadata.Generation++
+
+	version, err = c.putConfig(ctx, config, version)
+	if err != nil {
+		return nil, err
+	}
+	updated.ResourceVersion = version
+	updated.Generation = config.Metadata.Generation
+	c.enqueueIntent(ctx, config)
+	return &updated, nil
+}
+
+// enqueueIntent queues a written spec generation for the controller. The
+// spec is already stored, so a failure is not reported: the controller then
+// acts on the spec without coalescing it with other edits.
+func (c *Client) enqueueIntent(ctx context.Context, config *storage.ClusterConfig) {
+	intent := models.SpecIntent{
+		Generation:  config.Metadata.Generation,
+		RequestedAt: config.Metadata.UpdatedAt,
+		Source:      "client",
+	}
+	storage.UpdateIntentQueue(ctx, c.storage, config.Metadata.Name, func(q *models.IntentQueue) {
+		q.Enqueue(intent)
+	})
+}
+
+// Delete requests deletion of a cluster. The controller then removes its
+// cloud resources. A non-empty resourceVersion makes the deletion
+// conditional on the spec not having changed since it was read.
+func (c *Client) Delete(ctx context.Context, name, resourceVersion string) error {
+	config, version, err := c.getConfig(ctx, name)
+	if err != nil {
+		return err
+	}
+	if resourceVersion != "" && version != resourceVersion {
+		return fmt.Errorf("%w: %s", ErrConflict, name)
+	}
+	if config.Metadata.DeletionTimestamp != nil {
+		// Already being deleted
+		return nil
+	}
+
+	now := time.Now()
+	config.Metadata.DeletionTimestamp = &now
+	config.Metadata.UpdatedAt = now
+	if _, err := c.putConfig(ctx, config, version); err != nil {
+		return err
+	}
+
+	// Block reuse of the name until the controller has cleaned up
+	data, err := yaml.Marshal(&storage.Tombstone{
+		Cluster:   name,
+		ClusterID: config.Metadata.ID,
+		DeletedAt: now,
+	})
+	if err == nil {
+		err = c.storage.PutObject(ctx, storage.TombstoneKey(name), data)
+	}
+	if err != nil {
+		return fmt.Errorf("deletion of %s requested but its tombstone was not written: %w", name, err)
+	}
+	return nil
+}
+
+// getConfig loads the stored spec of a cluster and its version
+func (c *Client) getConfig(ctx context.Context, name string) (*storage.ClusterConfig, string, error) {
+	var data []byte
+	var version string
+	var err error
+	if vs, ok := c.storage.(provider.VersionedStorage); ok {
+		data, version, err = vs.GetObjectVersion(ctx, storage.ClusterConfigKey(name))
+	} else {
+		data, err = c.storage.GetObject(ctx, storage.ClusterConfigKey(name))
+		version = contentVersion(data)
+	}
+	if err != nil {
+		if isNotFound(err) {
+			return nil, "", fmt.Errorf("%w: %s", ErrNotFound, name)
+		}
+		return nil, "", fmt.Errorf("failed to load cluster %s: %w", name, err)
+	}
+
+	var config storage.ClusterConfig
+	if err := yaml.Unmarshal(data, &config); err != nil {
+		return nil, "", fmt.Errorf("failed to parse cluster %s: %w", name, err)
+	}
+	return &config, version, nil
+}
+
+// putConfig writes a spec if its stored version is still version (empty for
+// a new cluster) and returns the new version
+func (c *Client) putConfig(ctx context.Context, config *storage.ClusterConfig, version string) (string, error) {
+	name := config.Metadata.Name
+	data, err := yaml.Marshal(config)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal cluster %s: %w", name, err)
+	}
+
+	if vs, ok := c.storage.(provider.VersionedStorage); ok {
+		newVersion, err := vs.PutObjectIfMatch(ctx, storage.ClusterConfigKey(name), data, version)
+		if errors.Is(err, provider.ErrPreconditionFailed) {
+			return "", fmt.Errorf("%w: %s", ErrConflict, name)
+		}
+		if err != nil {
+			return "", fmt.Errorf("failed to save cluster %s: %w", name, err)
+		}
+		return newVersion, nil
+	}
+
+	// Best effort without conditional writes: compare, then write
+	current, err := c.storage.GetObject(ctx, storage.ClusterConfigKey(name))
+	switch {
+	case err == nil && contentVersion(current) != version:
+		return "", fmt.Errorf("%w: %s", ErrConflict, name)
+	case err != nil && !isNotFound(err):
+		return "", fmt.Errorf("failed to load cluster %s: %w", name, err)
+	case err != nil && version != "":
+		return "", fmt.Errorf("%w: %s", ErrNotFound, name)
+	}
+	if err := c.storage.PutObject(ctx, storage.ClusterConfigKey(name), data); err != nil {
+		return "", fmt.Errorf("failed to save cluster %s: %w", name, err)
+	}
+	return contentVersion(data), nil
+}
+
+// contentVersion derives a version from the stored bytes of an object
+func contentVersion(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:8])
+}
+
+// isNotFound reports whether a storage error means the object does not exist
+func isNotFound(err error) bool {
+	msg := err.Error()
+	return strings.Contains(msg, "not found") || strings.Contains(msg, "NoSuchKey")
+}
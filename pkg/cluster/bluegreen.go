@@ -0,0 +1,522 @@
+package cluster
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/madhouselabs/goman/pkg/audit"
+	"github.com/madhouselabs/goman/pkg/models"
+	"github.com/madhouselabs/goman/pkg/provider"
+	"github.com/madhouselabs/goman/pkg/storage"
+	"gopkg.in/yaml.v3"
+)
+
+// blueGreenPrefix is kept outside clusters/ so pairings don't trigger
+// reconciles
+const blueGreenPrefix = "bluegreen/"
+
+// blueGreenPollInterval is how often a cutover checks whether the clusters
+// serve the API endpoint they were given
+const blueGreenPollInterval = 10 * time.Second
+
+// BlueGreen pairs a cluster (blue) with a twin restored from an etcd
+// snapshot of it (green), to try a risky change on the twin before moving
+// clients over
+type BlueGreen struct {
+	Blue      string     `yaml:"blue"`
+	Green     string     `yaml:"green"`
+	CreatedAt time.Time  `yaml:"createdAt"`
+	Spec      []string   `yaml:"spec"` // Spec of blue when green was created, as audit.Summary lines
+	CutoverAt *time.Time `yaml:"cutoverAt,omitempty"`
+	Endpoint  string     `yaml:"endpoint,omitempty"` // API endpoint moved from blue to green at cutover
+}
+
+// BlueGreenReport is how far the two clusters of a pairing diverge
+type BlueGreenReport struct {
+	Pair BlueGreen
+	// Spec differences from blue to green; the name and description always
+	// differ and are left out
+	Spec []string
+	// State of each cluster: status, Kubernetes version and running nodes
+	Blue, Green BlueGreenSide
+	// Restore of green from the snapshot of blue, nil until the controller
+	// starts it
+	Restore *models.SnapshotRestoreStatus
+}
+
+// BlueGreenSide is the live state of one cluster of a pairing
+type BlueGreenSide struct {
+	Status      models.ClusterStatus
+	K3sVersion  string
+	KubeVersion string
+	Nodes       int
+	APIEndpoint string
+}
+
+func blueGreenKey(blue string) string {
+	return blueGreenPrefix + blue + ".yaml"
+}
+
+// CreateBlueGreen creates green as a twin of the running HA cluster blue: a
+// copy of its spec, with k3sVersion instead of blue's when given, that the
+// controller restores from an etcd snapshot of blue before its workers join.
+// Cluster links and the virtual IP are not copied: links would peer green
+// with blue's partners, and the virtual IP moves over at cutover. A load
+// balancer is, as green gets one of its own until cutover.
+func (m *Manager) CreateBlueGreen(blueName, greenName, k3sVersion string) (*BlueGreen, error) {
+	if m.storage == nil {
+		return nil, fmt.Errorf("storage not available")
+	}
+	if err := ValidateClusterName(greenName); err != nil {
+		return nil, err
+	}
+	if err := models.ValidateK3sVersion(k3sVersion); err != nil {
+		return nil, err
+	}
+	if existing, err := m.BlueGreen(blueName); err == nil && existing.CutoverAt == nil {
+		return nil, fmt.Errorf("cluster %s already has a green twin, %s; cut over or abandon it first", blueName, existing.Green)
+	}
+
+	blue, err := m.clusterByName(blueName)
+	if err != nil {
+		return nil, err
+	}
+	if blue.Status != models.StatusRunning {
+		return nil, fmt.Errorf("cluster %s must be running to create a twin (current: %s)", blue.Name, blue.Status)
+	}
+	if blue.Mode != models.ModeHA {
+		return nil, fmt.Errorf("cluster %s must be HA to create a twin: only HA clusters keep their data in etcd, which the twin is restored from", blue.Name)
+	}
+	if _, err := m.clusterByName(greenName); err == nil {
+		return nil, fmt.Errorf("cluster %s already exists", greenName)
+	}
+
+	green := blueGreenTwin(*blue, greenName)
+	if k3sVersion != "" {
+		green.K3sVersion = k3sVersion
+	}
+	if _, err := m.CreateCluster(green); err != nil {
+		return nil, fmt.Errorf("failed to create %s: %w", greenName, err)
+	}
+
+	pair := &BlueGreen{
+		Blue:      blue.Name,
+		Green:     greenName,
+		CreatedAt: time.Now().UTC(),
+		Spec:      audit.Summary(*blue),
+	}
+	if err := m.saveBlueGreen(pair); err != nil {
+		return nil, err
+	}
+	m.recordAudit(blue.Name, audit.ActionBlueGreen, []string{fmt.Sprintf("green twin %s created", greenName)})
+	return pair, nil
+}
+
+// blueGreenTwin copies the spec of blue for a new cluster named green,
+// restored from a snapshot of blue
+func blueGreenTwin(blue models.K3sCluster, green string) models.K3sCluster {
+	twin := models.K3sCluster{
+		Name:               green,
+		Description:        fmt.Sprintf("Green twin of %s", blue.Name),
+		Mode:               blue.Mode,
+		Region:             blue.Region,
+		InstanceType:       blue.InstanceType,
+		K3sVersion:         blue.K3sVersion,
+		Tags:               append([]string(nil), blue.Tags...),
+		NetworkCIDR:        blue.NetworkCIDR,
+		ServiceCIDR:        blue.ServiceCIDR,
+		ClusterDNS:         blue.ClusterDNS,
+		Features:           blue.Features,
+		Preset:             blue.Preset,
+		LowResource:        blue.LowResource,
+		Architecture:       blue.Architecture,
+		ImageID:            blue.ImageID,
+		OSFamily:           blue.OSFamily,
+		PrivateNetwork:     blue.PrivateNetwork,
+		VpcID:              blue.VpcID,
+		SubnetIDs:          blue.SubnetIDs,
+		SecurityGroupIDs:   blue.SecurityGroupIDs,
+		DedicatedVPC:       blue.DedicatedVPC,
+		VpcCIDR:            blue.VpcCIDR,
+		RootVolume:         blue.RootVolume,
+		DriftPolicy:        blue.DriftPolicy,
+		Rollout:            blue.Rollout,
+		DNS:                blue.DNS,
+		InstanceProtection: blue.InstanceProtection,
+		Addons:             blue.Addons,
+		Notifications:      blue.Notifications,
+		Proxy:              blue.Proxy,
+		Bootstrap:          blue.Bootstrap,
+		Registries:         blue.Registries,
+		Schedule:           blue.Schedule,
+		Restore:            &models.SnapshotRestoreRequest{Source: blue.Name, RequestedAt: time.Now().UTC()},
+	}
+	if blue.LoadBalancer != nil {
+		// A load balancer of its own, swapped with blue's at cutover
+		twin.LoadBalancer = loadBalancerOf(blue.LoadBalancer, green, green)
+	}
+	twin.MasterNodes = twinNodes(blue.MasterNodes, blue.Name, green)
+	twin.WorkerNodes = twinNodes(blue.WorkerNodes, blue.Name, green)
+	for _, pool := range blue.NodePools {
+		pool.Labels = copyStringMap(pool.Labels)
+		pool.Taints = append([]models.Taint(nil), pool.Taints...)
+		pool.Volumes = append([]models.DataVolume(nil), pool.Volumes...)
+		pool.AvailabilityZones = append([]string(nil), pool.AvailabilityZones...)
+		twin.NodePools = append(twin.NodePools, pool)
+	}
+	return twin
+}
+
+// twinNodes copies the sizing of nodes, without their instances
+func twinNodes(nodes []models.Node, blue, green string) []models.Node {
+	var twins []models.Node
+	for _, node := range nodes {
+		twins = append(twins, models.Node{
+			Name:         strings.Replace(node.Name, blue, green, 1),
+			Role:         node.Role,
+			CPU:          node.CPU,
+			MemoryGB:     node.MemoryGB,
+			StorageGB:    node.StorageGB,
+			Provider:     node.Provider,
+			InstanceType: node.InstanceType,
+			Region:       node.Region,
+		})
+	}
+	return twins
+}
+
+func copyStringMap(m map[string]string) map[string]string {
+	if m == nil {
+		return nil
+	}
+	c := make(map[string]string, len(m))
+	for k, v := range m {
+		c[k] = v
+	}
+	return c
+}
+
+// BlueGreen returns the pairing of a blue cluster
+func (m *Manager) BlueGreen(blueName string) (*BlueGreen, error) {
+	if m.storage == nil {
+		return nil, fmt.Errorf("storage not available")
+	}
+	data, err := m.storage.GetBackend().GetObject(blueGreenKey(blueName))
+	if err != nil {
+		if errors.Is(err, provider.ErrNotFound) {
+			return nil, fmt.Errorf("cluster %s has no green twin", blueName)
+		}
+		return nil, fmt.Errorf("failed to load blue/green pairing: %w", err)
+	}
+	var pair BlueGreen
+	if err := yaml.Unmarshal(data, &pair); err != nil {
+		return nil, fmt.Errorf("failed to parse blue/green pairing: %w", err)
+	}
+	return &pair, nil
+}
+
+func (m *Manager) saveBlueGreen(pair *BlueGreen) error {
+	data, err := yaml.Marshal(pair)
+	if err != nil {
+		return fmt.Errorf("failed to marshal blue/green pairing: %w", err)
+	}
+	if err := m.storage.GetBackend().PutObject(blueGreenKey(pair.Blue), data); err != nil {
+		return fmt.Errorf("failed to save blue/green pairing: %w", err)
+	}
+	return nil
+}
+
+// AbandonBlueGreen forgets the pairing of a blue cluster; the green cluster
+// is kept and can be deleted like any other
+func (m *Manager) AbandonBlueGreen(blueName string) error {
+	if _, err := m.BlueGreen(blueName); err != nil {
+		return err
+	}
+	if err := m.storage.GetBackend().DeleteObject(blueGreenKey(blueName)); err != nil {
+		return fmt.Errorf("failed to remove blue/green pairing: %w", err)
+	}
+	return nil
+}
+
+// BlueGreenDivergence reports how the twin differs from the blue cluster,
+// in spec and in live state
+func (m *Manager) BlueGreenDivergence(blueName string) (*BlueGreenReport, error) {
+	pair, err := m.BlueGreen(blueName)
+	if err != nil {
+		return nil, err
+	}
+	blue, err := m.clusterByName(pair.Blue)
+	if err != nil {
+		return nil, err
+	}
+	green, err := m.clusterByName(pair.Green)
+	if err != nil {
+		return nil, fmt.Errorf("green cluster %s: %w", pair.Green, err)
+	}
+
+	report := &BlueGreenReport{Pair: *pair, Blue: m.blueGreenSide(*blue), Green: m.blueGreenSide(*green)}
+	if report.Restore, err = m.restoreStatus(green.Name); err != nil {
+		return nil, err
+	}
+	for _, change := range audit.Diff(*blue, *green) {
+		if strings.HasPrefix(change, "name:") || strings.HasPrefix(change, "description:") {
+			continue
+		}
+		report.Spec = append(report.Spec, change)
+	}
+	return report, nil
+}
+
+// restoreStatus returns the snapshot restore of a cluster as reported by the
+// controller, or nil if it has not started
+func (m *Manager) restoreStatus(clusterName string) (*models.SnapshotRestoreStatus, error) {
+	data, err := m.storage.GetBackend().GetObject(storage.ClusterStatusKey(clusterName))
+	if err != nil {
+		if errors.Is(err, provider.ErrNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to load cluster status: %w", err)
+	}
+	var status struct {
+		Restore *models.SnapshotRestoreStatus `yaml:"restore"`
+	}
+	if err := yaml.Unmarshal(data, &status); err != nil {
+		return nil, fmt.Errorf("failed to parse cluster status: %w", err)
+	}
+	return status.Restore, nil
+}
+
+func (m *Manager) blueGreenSide(c models.K3sCluster) BlueGreenSide {
+	if state, err := m.GetClusterDetails(c.Name); err == nil {
+		c = state.Cluster
+	}
+	side := BlueGreenSide{Status: c.Status, K3sVersion: c.K3sVersion, KubeVersion: c.KubeVersion, APIEndpoint: c.APIEndpoint}
+	for _, node := range append(append([]models.Node(nil), c.MasterNodes...), c.WorkerNodes...) {
+		if node.Status == "running" {
+			side.Nodes++
+		}
+	}
+	return side
+}
+
+// clusterByName returns a copy of a cluster by name or ID
+func (m *Manager) clusterByName(name string) (*models.K3sCluster, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	for _, c := range m.clusters {
+		if c.ID == name || c.Name == name {
+			result := c
+			return &result, nil
+		}
+	}
+	return nil, fmt.Errorf("cluster not found: %s", name)
+}
+
+// CutoverBlueGreen moves the API endpoint clients of blue use to the
+// running green cluster, and moves it back if green does not serve it in
+// time. A virtual IP is released by blue first, then taken over by green;
+// EC2 only moves a private IP within its subnet, so green's masters must run
+// in the zone of blue's. Load balancers are swapped: green's masters serve
+// the load balancer of blue and blue's the one of green, so clients keep its
+// name. progress is told each step. Clients still need green's kubeconfig,
+// since green has its own CA.
+func (m *Manager) CutoverBlueGreen(blueName string, timeout time.Duration, progress func(string)) error {
+	pair, err := m.BlueGreen(blueName)
+	if err != nil {
+		return err
+	}
+	if pair.CutoverAt != nil {
+		return fmt.Errorf("cluster %s was already cut over to %s at %s", pair.Blue, pair.Green, pair.CutoverAt.Local().Format("2006-01-02 15:04"))
+	}
+	blue, err := m.clusterByName(pair.Blue)
+	if err != nil {
+		return err
+	}
+	green, err := m.clusterByName(pair.Green)
+	if err != nil {
+		return fmt.Errorf("green cluster %s: %w", pair.Green, err)
+	}
+	if green.Status != models.StatusRunning {
+		return fmt.Errorf("green cluster %s must be running to cut over (current: %s)", green.Name, green.Status)
+	}
+	if green.Mode != models.ModeHA {
+		return fmt.Errorf("green cluster %s must be HA to take over the API endpoint", green.Name)
+	}
+
+	c := &blueGreenCutover{
+		update: func(cluster models.K3sCluster) error {
+			_, err := m.UpdateCluster(cluster)
+			return err
+		},
+		endpoint: func(name string) (string, error) {
+			state, err := m.GetClusterDetails(name)
+			if err != nil {
+				return "", err
+			}
+			return state.Cluster.APIEndpoint, nil
+		},
+		interval: blueGreenPollInterval,
+		timeout:  timeout,
+		progress: progress,
+	}
+	var endpoint string
+	switch {
+	case blue.VirtualIP != nil:
+		endpoint, err = c.moveVirtualIP(*blue, *green)
+	case blue.LoadBalancer != nil:
+		endpoint, err = c.swapLoadBalancers(*blue, *green)
+	default:
+		return fmt.Errorf("cluster %s has no virtual IP or load balancer, the endpoints goman can move; point clients at the kubeconfig of %s instead", blue.Name, green.Name)
+	}
+	if err != nil {
+		return err
+	}
+
+	now := time.Now().UTC()
+	pair.CutoverAt = &now
+	pair.Endpoint = endpoint
+	if err := m.saveBlueGreen(pair); err != nil {
+		return err
+	}
+	change := []string{fmt.Sprintf("cut over from %s to %s (API endpoint %s)", pair.Blue, pair.Green, endpoint)}
+	m.recordAudit(pair.Blue, audit.ActionCutover, change)
+	m.recordAudit(pair.Green, audit.ActionCutover, change)
+	return nil
+}
+
+// blueGreenCutover moves an API endpoint between the clusters of a pairing
+// by updating their specs and waiting for the controller to serve them
+type blueGreenCutover struct {
+	update   func(models.K3sCluster) error
+	endpoint func(name string) (string, error) // API endpoint a cluster serves
+	interval time.Duration
+	timeout  time.Duration // For each cluster to serve its new spec
+	progress func(string)
+}
+
+// moveVirtualIP moves the virtual IP of blue to green and returns its
+// endpoint. The address is released before green takes it, since both
+// cannot hold it.
+func (c *blueGreenCutover) moveVirtualIP(blue, green models.K3sCluster) (string, error) {
+	served, err := c.endpoint(blue.Name)
+	if err != nil {
+		return "", err
+	}
+	address := blue.VirtualIP.Address
+	if address == "" {
+		// Allocated by the controller: it is in the API endpoint once served
+		address = endpointHost(served)
+	}
+	vipEndpoint := models.APIEndpointURL(address)
+	if address == "" || served != vipEndpoint {
+		return "", fmt.Errorf("the virtual IP of %s is not serving yet; try again once its API endpoint is the virtual IP", blue.Name)
+	}
+
+	c.progress(fmt.Sprintf("Releasing virtual IP %s from %s", address, blue.Name))
+	released := blue
+	released.VirtualIP = nil
+	if err := c.update(released); err != nil {
+		return "", fmt.Errorf("failed to release virtual IP from %s: %w", blue.Name, err)
+	}
+	if !c.waitFor(blue.Name, func(endpoint string) bool { return endpoint != vipEndpoint }) {
+		return "", c.rollback(fmt.Errorf("%s still serves %s after %s", blue.Name, address, c.timeout), blue)
+	}
+
+	c.progress(fmt.Sprintf("Moving virtual IP %s to %s", address, green.Name))
+	takeover := green
+	takeover.VirtualIP = &models.VirtualIPSpec{Address: address}
+	if err := c.update(takeover); err != nil {
+		return "", c.rollback(fmt.Errorf("failed to move virtual IP to %s: %w", green.Name, err), blue)
+	}
+	if !c.waitFor(green.Name, func(endpoint string) bool { return endpoint == vipEndpoint }) {
+		return "", c.rollback(fmt.Errorf("%s does not serve %s after %s", green.Name, address, c.timeout), green, blue)
+	}
+	return vipEndpoint, nil
+}
+
+// swapLoadBalancers serves the load balancer of blue from green's masters
+// and green's from blue's, and returns the endpoint of blue's. A target
+// group only takes instances of its VPC, so both run in one.
+func (c *blueGreenCutover) swapLoadBalancers(blue, green models.K3sCluster) (string, error) {
+	if green.LoadBalancer == nil {
+		return "", fmt.Errorf("green cluster %s has no load balancer to swap with the one of %s", green.Name, blue.Name)
+	}
+	if blue.DedicatedVPC || green.DedicatedVPC || blue.VpcID != green.VpcID {
+		return "", fmt.Errorf("the load balancers of %s and %s can only be swapped when both run in one shared VPC", blue.Name, green.Name)
+	}
+	blueEndpoint, err := c.endpoint(blue.Name)
+	if err != nil {
+		return "", err
+	}
+	if host := endpointHost(blueEndpoint); host == "" || net.ParseIP(host) != nil {
+		return "", fmt.Errorf("the load balancer of %s is not serving yet; try again once its API endpoint is the load balancer", blue.Name)
+	}
+
+	c.progress(fmt.Sprintf("Swapping the load balancers of %s and %s", blue.Name, green.Name))
+	blueOwner := blue.LoadBalancer.Owner(blue.Name)
+	greenOwner := green.LoadBalancer.Owner(green.Name)
+	takeover := green
+	takeover.LoadBalancer = loadBalancerOf(green.LoadBalancer, green.Name, blueOwner)
+	if err := c.update(takeover); err != nil {
+		return "", fmt.Errorf("failed to move the load balancer of %s to %s: %w", blue.Name, green.Name, err)
+	}
+	swapped := blue
+	swapped.LoadBalancer = loadBalancerOf(blue.LoadBalancer, blue.Name, greenOwner)
+	if err := c.update(swapped); err != nil {
+		return "", c.rollback(fmt.Errorf("failed to move the load balancer of %s to %s: %w", green.Name, blue.Name, err), green)
+	}
+	if !c.waitFor(green.Name, func(endpoint string) bool { return endpoint == blueEndpoint }) {
+		return "", c.rollback(fmt.Errorf("%s does not serve %s after %s", green.Name, blueEndpoint, c.timeout), green, blue)
+	}
+	return blueEndpoint, nil
+}
+
+// loadBalancerOf returns a copy of the load balancer spec of a cluster
+// serving the load balancer of owner
+func loadBalancerOf(spec *models.LoadBalancerSpec, cluster, owner string) *models.LoadBalancerSpec {
+	lb := *spec
+	lb.AllowedCIDRs = append([]string(nil), spec.AllowedCIDRs...)
+	lb.Cluster = owner
+	if owner == cluster {
+		lb.Cluster = ""
+	}
+	return &lb
+}
+
+// waitFor polls the API endpoint of a cluster until done accepts it,
+// returning false after the timeout
+func (c *blueGreenCutover) waitFor(name string, done func(endpoint string) bool) bool {
+	deadline := time.Now().Add(c.timeout)
+	for {
+		if endpoint, err := c.endpoint(name); err == nil && done(endpoint) {
+			return true
+		}
+		if time.Now().After(deadline) {
+			return false
+		}
+		time.Sleep(c.interval)
+	}
+}
+
+// rollback saves the specs the clusters had before the cutover, in order,
+// and returns why the cutover failed
+func (c *blueGreenCutover) rollback(cause error, specs ...models.K3sCluster) error {
+	for _, spec := range specs {
+		c.progress(fmt.Sprintf("Rolling back %s", spec.Name))
+		if err := c.update(spec); err != nil {
+			return fmt.Errorf("%w; rolling back %s failed too, restore its spec by hand: %v", cause, spec.Name, err)
+		}
+	}
+	return fmt.Errorf("%w; rolled back, %s keeps the API endpoint", cause, specs[len(specs)-1].Name)
+}
+
+// endpointHost returns the host of an API server URL
+func endpointHost(endpoint string) string {
+	host := strings.TrimPrefix(endpoint, "https://")
+	host, _, _ = strings.Cut(host, ":")
+	return host
+}
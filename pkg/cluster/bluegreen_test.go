@@ -0,0 +1,330 @@
+package cluster
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/madhouselabs/goman/pkg/audit"
+	"github.com/madhouselabs/goman/pkg/models"
+	"github.com/madhouselabs/goman/pkg/provider"
+	"github.com/madhouselabs/goman/pkg/storage"
+	"gopkg.in/yaml.v3"
+)
+
+// memStorage is a storage service keeping objects in memory
+type memStorage struct {
+	objects map[string][]byte
+}
+
+func (s *memStorage) Initialize(ctx context.Context) error { return nil }
+
+func (s *memStorage) PutObject(ctx context.Context, key string, data []byte) error {
+	s.objects[key] = data
+	return nil
+}
+
+func (s *memStorage) GetObject(ctx context.Context, key string) ([]byte, error) {
+	data, ok := s.objects[key]
+	if !ok {
+		return nil, provider.ErrNotFound
+	}
+	return data, nil
+}
+
+func (s *memStorage) DeleteObject(ctx context.Context, key string) error {
+	delete(s.objects, key)
+	return nil
+}
+
+func (s *memStorage) ListObjects(ctx context.Context, prefix string) ([]string, error) {
+	var keys []string
+	for key := range s.objects {
+		if strings.HasPrefix(key, prefix) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys, nil
+}
+
+type memProvider struct {
+	provider.Provider
+	storage *memStorage
+}
+
+func (p *memProvider) GetStorageService() provider.StorageService { return p.storage }
+
+// blueGreenManager returns a manager of clusters stored in memory
+func blueGreenManager(t *testing.T, clusters ...models.K3sCluster) (*Manager, *memStorage) {
+	t.Helper()
+	mem := &memStorage{objects: map[string][]byte{}}
+	st, err := storage.NewStorageWithProvider(&memProvider{storage: mem})
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := &Manager{storage: st, auditor: audit.NewRecorder(st.GetBackend(), func() string { return "test" })}
+	for _, c := range clusters {
+		if _, err := m.CreateCluster(c); err != nil {
+			t.Fatal(err)
+		}
+		m.clusters[len(m.clusters)-1].Status = c.Status
+	}
+	return m, mem
+}
+
+func blueCluster() models.K3sCluster {
+	return models.K3sCluster{
+		Name:         "blue",
+		Mode:         models.ModeHA,
+		Region:       "eu-west-1",
+		InstanceType: "t3.medium",
+		K3sVersion:   "v1.31.4+k3s1",
+		Status:       models.StatusRunning,
+		MasterNodes:  []models.Node{{Name: "blue-master-0", Role: models.RoleMaster, IP: "10.0.1.10"}},
+		NodePools:    []models.NodePool{{Name: "default", Count: 2, InstanceType: "t3.large", Labels: map[string]string{"tier": "web"}}},
+		VirtualIP:    &models.VirtualIPSpec{Address: "10.0.1.50"},
+		ClusterLinks: []models.ClusterLink{{Cluster: "partner"}},
+	}
+}
+
+func TestCreateBlueGreen(t *testing.T) {
+	m, mem := blueGreenManager(t, blueCluster())
+
+	pair, err := m.CreateBlueGreen("blue", "green", "v1.32.1+k3s1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pair.Blue != "blue" || pair.Green != "green" || len(pair.Spec) == 0 {
+		t.Errorf("pair = %+v", pair)
+	}
+	if stored, err := m.BlueGreen("blue"); err != nil || stored.Green != "green" {
+		t.Errorf("stored pair = %+v, %v", stored, err)
+	}
+
+	var config storage.ClusterConfig
+	if err := yaml.Unmarshal(mem.objects[storage.ClusterConfigKey("green")], &config); err != nil {
+		t.Fatal(err)
+	}
+	green := storage.ConvertFromClusterConfig(&config, nil)
+	if green.Restore == nil || green.Restore.Source != "blue" {
+		t.Errorf("green is not restored from blue: %+v", green.Restore)
+	}
+	if green.Mode != models.ModeHA || green.K3sVersion != "v1.32.1+k3s1" || green.InstanceType != "t3.medium" {
+		t.Errorf("green spec = mode %s, K3s %s, type %s", green.Mode, green.K3sVersion, green.InstanceType)
+	}
+	if green.VirtualIP != nil || len(green.ClusterLinks) > 0 {
+		t.Errorf("green copied the virtual IP %v or links %v", green.VirtualIP, green.ClusterLinks)
+	}
+	if len(green.MasterNodes) != 1 || green.MasterNodes[0].Name != "green-master-0" || green.MasterNodes[0].IP != "" {
+		t.Errorf("green masters = %+v", green.MasterNodes)
+	}
+	if len(green.NodePools) != 1 || green.NodePools[0].Count != 2 || green.NodePools[0].Labels["tier"] != "web" {
+		t.Errorf("green pools = %+v", green.NodePools)
+	}
+
+	// Changing the twin's copy leaves blue alone
+	blue, _ := m.clusterByName("blue")
+	twin := blueGreenTwin(*blue, "green")
+	twin.NodePools[0].Labels["tier"] = "changed"
+	if blue.NodePools[0].Labels["tier"] != "web" {
+		t.Error("twin shares the labels of blue")
+	}
+
+	if _, err := m.CreateBlueGreen("blue", "green-2", ""); err == nil {
+		t.Error("second twin created while the first is paired")
+	}
+}
+
+func TestCreateBlueGreenRefused(t *testing.T) {
+	dev := blueCluster()
+	dev.Mode = models.ModeDev
+	dev.VirtualIP = nil
+	stopped := blueCluster()
+	stopped.Status = models.StatusStopped
+
+	tests := []struct {
+		name  string
+		blue  models.K3sCluster
+		green string
+		want  string
+	}{
+		{"dev", dev, "green", "must be HA"},
+		{"stopped", stopped, "green", "must be running"},
+		{"taken name", blueCluster(), "blue", "already exists"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, mem := blueGreenManager(t, tt.blue)
+			_, err := m.CreateBlueGreen("blue", tt.green, "")
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("err = %v, want %q", err, tt.want)
+			}
+			if _, ok := mem.objects[blueGreenKey("blue")]; ok {
+				t.Error("pairing saved")
+			}
+		})
+	}
+}
+
+// cutoverController serves the API endpoints of the clusters it is given the
+// specs of, as the controller would; stuck clusters keep serving the old one
+func cutoverController(stuck string, specs ...models.K3sCluster) (*blueGreenCutover, map[string]*models.K3sCluster, *[]string) {
+	clusters := make(map[string]*models.K3sCluster)
+	served := make(map[string]string)
+	var updates []string
+	serve := func(c *models.K3sCluster) string {
+		switch {
+		case c.VirtualIP != nil:
+			return models.APIEndpointURL(c.VirtualIP.Address)
+		case c.LoadBalancer != nil:
+			return models.APIEndpointURL(c.LoadBalancer.Owner(c.Name) + "-api.elb.eu-west-1.amazonaws.com")
+		}
+		return models.APIEndpointURL(c.MasterNodes[0].IP)
+	}
+	for i := range specs {
+		clusters[specs[i].Name] = &specs[i]
+		served[specs[i].Name] = serve(&specs[i])
+	}
+	c := &blueGreenCutover{
+		update: func(cluster models.K3sCluster) error {
+			clusters[cluster.Name] = &cluster
+			updates = append(updates, cluster.Name)
+			return nil
+		},
+		endpoint: func(name string) (string, error) {
+			if name != stuck {
+				served[name] = serve(clusters[name])
+			}
+			return served[name], nil
+		},
+		interval: time.Millisecond,
+		timeout:  20 * time.Millisecond,
+		progress: func(string) {},
+	}
+	return c, clusters, &updates
+}
+
+func TestBlueGreenCutover(t *testing.T) {
+	vipBlue := blueCluster()
+	vipGreen := blueGreenTwin(vipBlue, "green")
+	vipGreen.MasterNodes[0].IP = "10.0.1.20"
+	lbBlue := blueCluster()
+	lbBlue.VirtualIP = nil
+	lbBlue.LoadBalancer = &models.LoadBalancerSpec{AllowedCIDRs: []string{"10.0.0.0/8"}}
+	lbGreen := blueGreenTwin(lbBlue, "green")
+	lbGreen.MasterNodes[0].IP = "10.0.1.20"
+	lbBlueEndpoint := "https://blue-api.elb.eu-west-1.amazonaws.com:6443"
+
+	tests := []struct {
+		name         string
+		blue, green  models.K3sCluster
+		lb           bool
+		stuck        string
+		wantEndpoint string // Empty if the cutover is rolled back
+		wantUpdates  string
+	}{
+		{name: "virtual IP moved", blue: vipBlue, green: vipGreen, wantEndpoint: "https://10.0.1.50:6443", wantUpdates: "blue green"},
+		{name: "blue keeps the virtual IP", blue: vipBlue, green: vipGreen, stuck: "blue", wantUpdates: "blue blue"},
+		{name: "green does not take the virtual IP", blue: vipBlue, green: vipGreen, stuck: "green", wantUpdates: "blue green green blue"},
+		{name: "load balancers swapped", blue: lbBlue, green: lbGreen, lb: true, wantEndpoint: lbBlueEndpoint, wantUpdates: "green blue"},
+		{name: "green does not serve the load balancer", blue: lbBlue, green: lbGreen, lb: true, stuck: "green", wantUpdates: "green blue green blue"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, clusters, updates := cutoverController(tt.stuck, tt.blue, tt.green)
+			var endpoint string
+			var err error
+			if tt.lb {
+				endpoint, err = c.swapLoadBalancers(tt.blue, tt.green)
+			} else {
+				endpoint, err = c.moveVirtualIP(tt.blue, tt.green)
+			}
+			if got := strings.Join(*updates, " "); got != tt.wantUpdates {
+				t.Errorf("updates = %s, want %s", got, tt.wantUpdates)
+			}
+			blue, green := clusters["blue"], clusters["green"]
+
+			if tt.wantEndpoint == "" {
+				if err == nil || !strings.Contains(err.Error(), "rolled back") {
+					t.Fatalf("err = %v, want a rollback", err)
+				}
+				if !reflect.DeepEqual(*blue, tt.blue) || !reflect.DeepEqual(*green, tt.green) {
+					t.Errorf("specs not rolled back:\nblue  %+v\ngreen %+v", blue, green)
+				}
+				return
+			}
+			if err != nil || endpoint != tt.wantEndpoint {
+				t.Fatalf("endpoint = %q, %v; want %s", endpoint, err, tt.wantEndpoint)
+			}
+			if tt.lb {
+				if blue.LoadBalancer.Cluster != "green" || green.LoadBalancer.Cluster != "blue" {
+					t.Errorf("load balancers of blue %+v, green %+v", blue.LoadBalancer, green.LoadBalancer)
+				}
+				if tt.blue.LoadBalancer.Cluster != "" {
+					t.Error("spec of blue changed in place")
+				}
+				return
+			}
+			if blue.VirtualIP != nil || green.VirtualIP == nil || green.VirtualIP.Address != "10.0.1.50" {
+				t.Errorf("virtual IP of blue %v, green %v", blue.VirtualIP, green.VirtualIP)
+			}
+		})
+	}
+}
+
+func TestBlueGreenCutoverRefused(t *testing.T) {
+	lbBlue := blueCluster()
+	lbBlue.VirtualIP = nil
+	lbBlue.LoadBalancer = &models.LoadBalancerSpec{}
+	lbGreen := blueGreenTwin(lbBlue, "green")
+	lbGreen.MasterNodes[0].IP = "10.0.1.20"
+	otherVPC := lbGreen
+	otherVPC.VpcID = "vpc-2"
+	noLB := lbGreen
+	noLB.LoadBalancer = nil
+
+	tests := []struct {
+		name          string
+		blue, green   models.K3sCluster
+		masterAddress bool // Blue still serves its master's address
+		want          string
+	}{
+		{"load balancer not serving", lbBlue, lbGreen, true, "not serving yet"},
+		{"other VPC", lbBlue, otherVPC, false, "one shared VPC"},
+		{"green without load balancer", lbBlue, noLB, false, "no load balancer"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, _, updates := cutoverController("", tt.blue, tt.green)
+			if tt.masterAddress {
+				c.endpoint = func(string) (string, error) { return models.APIEndpointURL(tt.blue.MasterNodes[0].IP), nil }
+			}
+			_, err := c.swapLoadBalancers(tt.blue, tt.green)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("err = %v, want %q", err, tt.want)
+			}
+			if len(*updates) > 0 {
+				t.Errorf("clusters updated: %v", *updates)
+			}
+		})
+	}
+
+	// Through the manager: blue has no endpoint goman can move
+	plain := blueCluster()
+	plain.VirtualIP = nil
+	m, _ := blueGreenManager(t, plain)
+	if _, err := m.CreateBlueGreen("blue", "green", ""); err != nil {
+		t.Fatal(err)
+	}
+	m.clusters[len(m.clusters)-1].Status = models.StatusRunning
+	if err := m.CutoverBlueGreen("blue", time.Second, func(string) {}); err == nil || !strings.Contains(err.Error(), "no virtual IP or load balancer") {
+		t.Errorf("err = %v", err)
+	}
+	if pair, _ := m.BlueGreen("blue"); pair.CutoverAt != nil {
+		t.Error("pairing marked as cut over")
+	}
+}
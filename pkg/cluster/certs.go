@@ -0,0 +1,37 @@
+package cluster
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/madhouselabs/goman/pkg/audit"
+	"github.com/madhouselabs/goman/pkg/models"
+)
+
+// RequestCertRotation asks the controller to rotate the K3s certificates on
+// all masters and to refresh the stored kubeconfig
+func (m *Manager) RequestCertRotation(clusterName string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for i := range m.clusters {
+		if m.clusters[i].ID == clusterName || m.clusters[i].Name == clusterName {
+			if m.clusters[i].Status != models.StatusRunning {
+				return fmt.Errorf("cluster is not in running state (current: %s)", m.clusters[i].Status)
+			}
+			now := time.Now().UTC().Truncate(time.Second)
+			m.clusters[i].CertRotationRequestedAt = &now
+			m.clusters[i].UpdatedAt = time.Now()
+
+			// Save config with the request to trigger Lambda
+			if m.storage != nil {
+				if err := m.saveClusterConfig(m.clusters[i]); err != nil {
+					return fmt.Errorf("failed to save cluster config: %w", err)
+				}
+				m.recordAudit(m.clusters[i].Name, audit.ActionRotateCerts, []string{"certificate rotation requested"})
+			}
+			return nil
+		}
+	}
+	return fmt.Errorf("cluster not found: %s", clusterName)
+}
@@ -0,0 +1,64 @@
+package cluster
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/madhouselabs/goman/pkg/storage"
+	"gopkg.in/yaml.v3"
+)
+
+// CommandHistory returns the node commands the controller ran on a cluster,
+// oldest first; limit <= 0 returns everything
+func (m *Manager) CommandHistory(clusterName string, limit int) ([]storage.CommandRecord, error) {
+	if m.storage == nil {
+		return nil, fmt.Errorf("storage not available")
+	}
+	backend := m.storage.GetBackend()
+
+	keys, err := backend.ListObjects(fmt.Sprintf("%s%s/", storage.CommandHistoryPrefix, clusterName))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list command history: %w", err)
+	}
+	var recordKeys []string
+	for _, key := range keys {
+		if strings.HasSuffix(key, ".yaml") {
+			recordKeys = append(recordKeys, key)
+		}
+	}
+	// Keys start with the timestamp, so only the newest need to be read
+	sort.Strings(recordKeys)
+	if limit > 0 && len(recordKeys) > limit {
+		recordKeys = recordKeys[len(recordKeys)-limit:]
+	}
+
+	var records []storage.CommandRecord
+	for _, key := range recordKeys {
+		data, err := backend.GetObject(key)
+		if err != nil {
+			continue
+		}
+		var record storage.CommandRecord
+		if err := yaml.Unmarshal(data, &record); err != nil {
+			continue
+		}
+		records = append(records, record)
+	}
+	return records, nil
+}
+
+// CommandOutput returns the stored output of a command record
+func (m *Manager) CommandOutput(record storage.CommandRecord) (string, error) {
+	if record.OutputKey == "" {
+		return "", fmt.Errorf("no output was kept for this command")
+	}
+	if m.storage == nil {
+		return "", fmt.Errorf("storage not available")
+	}
+	data, err := m.storage.GetBackend().GetObject(record.OutputKey)
+	if err != nil {
+		return "", fmt.Errorf("failed to read command output: %w", err)
+	}
+	return string(data), nil
+}
@@ -0,0 +1,28 @@
+package cluster
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/madhouselabs/goman/pkg/config"
+	"github.com/madhouselabs/goman/pkg/provider"
+	"github.com/madhouselabs/goman/pkg/provider/registry"
+)
+
+// ControllerLogs returns controller log lines about a cluster or from one
+// controller invocation, oldest first
+func (m *Manager) ControllerLogs(ctx context.Context, query provider.ControllerLogQuery) ([]provider.LogEvent, error) {
+	p, err := registry.GetConfiguredProvider(config.GetAWSProfile(), config.GetAWSRegion())
+	if err != nil {
+		return nil, fmt.Errorf("failed to get provider: %w", err)
+	}
+	reader, ok := p.(provider.ControllerLogReader)
+	if !ok {
+		return nil, fmt.Errorf("controller logs of the %s provider can't be read by goman", p.Name())
+	}
+	events, err := reader.ControllerLogs(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read controller logs: %w", err)
+	}
+	return events, nil
+}
@@ -0,0 +1,44 @@
+package cluster
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/madhouselabs/goman/pkg/storage"
+	"gopkg.in/yaml.v3"
+)
+
+// CreationRecords returns the cluster creations that finished since the
+// given time, oldest first
+func (m *Manager) CreationRecords(since time.Time) ([]storage.CreationRecord, error) {
+	if m.storage == nil {
+		return nil, fmt.Errorf("storage not available")
+	}
+	backend := m.storage.GetBackend()
+
+	keys, err := backend.ListObjects(storage.CreationStatsPrefix)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list creation records: %w", err)
+	}
+	sort.Strings(keys)
+
+	var records []storage.CreationRecord
+	for _, key := range keys {
+		// Keys start with the time, so older records aren't read
+		if at, ok := storage.CreationRecordTime(key); !ok || !strings.HasSuffix(key, ".yaml") || at.Before(since) {
+			continue
+		}
+		data, err := backend.GetObject(key)
+		if err != nil {
+			continue
+		}
+		var record storage.CreationRecord
+		if err := yaml.Unmarshal(data, &record); err != nil {
+			continue
+		}
+		records = append(records, record)
+	}
+	return records, nil
+}
@@ -0,0 +1,45 @@
+package cluster
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/madhouselabs/goman/pkg/audit"
+	"github.com/madhouselabs/goman/pkg/models"
+)
+
+// RequestCredentialRotation asks the controller to rotate the K3s token and
+// the client CA, restart every node with them and store new kubeconfigs, so
+// credentials handed out before stop working
+func (m *Manager) RequestCredentialRotation(clusterName string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for i := range m.clusters {
+		if m.clusters[i].ID == clusterName || m.clusters[i].Name == clusterName {
+			if m.clusters[i].Status != models.StatusRunning {
+				return fmt.Errorf("cluster is not in running state (current: %s)", m.clusters[i].Status)
+			}
+			version := m.clusters[i].K3sVersion
+			if version == "" {
+				version = models.DefaultK3sVersion
+			}
+			if err := models.CheckCredentialRotation(version); err != nil {
+				return err
+			}
+			now := time.Now().UTC().Truncate(time.Second)
+			m.clusters[i].CredentialRotationRequestedAt = &now
+			m.clusters[i].UpdatedAt = time.Now()
+
+			// Save config with the request to trigger Lambda
+			if m.storage != nil {
+				if err := m.saveClusterConfig(m.clusters[i]); err != nil {
+					return fmt.Errorf("failed to save cluster config: %w", err)
+				}
+				m.recordAudit(m.clusters[i].Name, audit.ActionRotateCreds, []string{"credential rotation requested"})
+			}
+			return nil
+		}
+	}
+	return fmt.Errorf("cluster not found: %s", clusterName)
+}
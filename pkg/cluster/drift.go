@@ -0,0 +1,37 @@
+package cluster
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/madhouselabs/goman/pkg/audit"
+	"github.com/madhouselabs/goman/pkg/models"
+)
+
+// RequestDriftFix asks the controller to change everything that drifted
+// from the spec back once, whatever the cluster's drift policy
+func (m *Manager) RequestDriftFix(clusterName string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for i := range m.clusters {
+		if m.clusters[i].ID == clusterName || m.clusters[i].Name == clusterName {
+			if m.clusters[i].Status == models.StatusDeleting {
+				return fmt.Errorf("cluster %s is being deleted", m.clusters[i].Name)
+			}
+			now := time.Now().UTC().Truncate(time.Second)
+			m.clusters[i].DriftFixRequestedAt = &now
+			m.clusters[i].UpdatedAt = time.Now()
+
+			// Save config with the request to trigger Lambda
+			if m.storage != nil {
+				if err := m.saveClusterConfig(m.clusters[i]); err != nil {
+					return fmt.Errorf("failed to save cluster config: %w", err)
+				}
+				m.recordAudit(m.clusters[i].Name, audit.ActionFixDrift, []string{"drift fix requested"})
+			}
+			return nil
+		}
+	}
+	return fmt.Errorf("cluster not found: %s", clusterName)
+}
@@ -0,0 +1,50 @@
+package cluster
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/madhouselabs/goman/pkg/models"
+	"github.com/madhouselabs/goman/pkg/storage"
+	"gopkg.in/yaml.v3"
+)
+
+// ClusterEvents returns the events the controller recorded for a cluster
+// since the given time, oldest first; limit <= 0 returns all of them
+func (m *Manager) ClusterEvents(clusterName string, since time.Time, limit int) ([]models.Event, error) {
+	if m.storage == nil {
+		return nil, fmt.Errorf("storage not available")
+	}
+	backend := m.storage.GetBackend()
+
+	keys, err := backend.ListObjects(storage.ClusterEventsPrefix(clusterName))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list events: %w", err)
+	}
+	var eventKeys []string
+	for _, key := range keys {
+		if t, ok := storage.EventTime(key); ok && !t.Before(since) {
+			eventKeys = append(eventKeys, key)
+		}
+	}
+	// Keys start with the timestamp, so only the newest need to be read
+	sort.Strings(eventKeys)
+	if limit > 0 && len(eventKeys) > limit {
+		eventKeys = eventKeys[len(eventKeys)-limit:]
+	}
+
+	var events []models.Event
+	for _, key := range eventKeys {
+		data, err := backend.GetObject(key)
+		if err != nil {
+			continue
+		}
+		var event models.Event
+		if err := yaml.Unmarshal(data, &event); err != nil {
+			continue
+		}
+		events = append(events, event)
+	}
+	return events, nil
+}
@@ -0,0 +1,234 @@
+package cluster
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/madhouselabs/goman/pkg/models"
+	"github.com/madhouselabs/goman/pkg/provider/registry"
+)
+
+// ImpactedNode is a node a destructive action terminates
+type ImpactedNode struct {
+	Name         string
+	ID           string
+	IP           string
+	InstanceType string
+	Pool         string // Empty for masters
+}
+
+// Impact summarizes what a destructive action does to a cluster, shown
+// before the user confirms it
+type Impact struct {
+	Cluster string
+	Nodes   []ImpactedNode // Nodes terminated
+
+	// Approximate change of the monthly cost, negative when it saves money.
+	// UnknownTypes lists instance types without a known price, which the
+	// estimate leaves out.
+	MonthlyCostDelta float64
+	UnknownTypes     []string
+}
+
+// DeleteImpact returns the impact of deleting a cluster: all its nodes
+func DeleteImpact(cluster models.K3sCluster) *Impact {
+	impact := &Impact{Cluster: cluster.Name}
+	for _, node := range cluster.MasterNodes {
+		impact.addNode(node, "", masterInstanceType(cluster))
+	}
+	for _, node := range cluster.WorkerNodes {
+		pool, _, _ := workerPoolIndex(cluster.Name, node.Name)
+		impact.addNode(node, pool, node.InstanceType)
+	}
+	for _, node := range impact.Nodes {
+		impact.addCost(node.InstanceType, -1)
+	}
+	return impact
+}
+
+// EditImpact returns the impact of changing the node pools of a cluster from
+// before to after: workers of removed pools, and the highest indexed workers
+// of scaled down pools, as the controller picks them. Paused pools are not
+// scaled, so they are left out.
+func EditImpact(before, after models.K3sCluster) *Impact {
+	impact := &Impact{Cluster: before.Name}
+
+	workers := make(map[string][]models.Node)
+	for _, node := range before.WorkerNodes {
+		if pool, _, ok := workerPoolIndex(before.Name, node.Name); ok {
+			workers[pool] = append(workers[pool], node)
+		}
+	}
+
+	newPools := make(map[string]models.NodePool)
+	for _, pool := range after.NodePools {
+		newPools[pool.Name] = pool
+	}
+
+	for _, old := range before.NodePools {
+		pool, kept := newPools[old.Name]
+		if kept && pool.Paused {
+			continue
+		}
+		nodes := workers[old.Name]
+		sort.Slice(nodes, func(i, j int) bool {
+			_, a, _ := workerPoolIndex(before.Name, nodes[i].Name)
+			_, b, _ := workerPoolIndex(before.Name, nodes[j].Name)
+			return a > b
+		})
+		remove := len(nodes)
+		if kept {
+			remove = len(nodes) - pool.Count
+		}
+		for i := 0; i < remove; i++ {
+			impact.addNode(nodes[i], old.Name, old.InstanceType)
+		}
+
+		impact.addCost(old.InstanceType, -old.Count)
+		if kept {
+			impact.addCost(pool.InstanceType, pool.Count)
+		}
+	}
+	for _, pool := range after.NodePools {
+		if !poolExists(before.NodePools, pool.Name) {
+			impact.addCost(pool.InstanceType, pool.Count)
+		}
+	}
+	return impact
+}
+
+// Destructive reports whether the action terminates any node
+func (i *Impact) Destructive() bool {
+	return len(i.Nodes) > 0
+}
+
+func (i *Impact) addNode(node models.Node, pool, instanceType string) {
+	if node.InstanceType != "" {
+		instanceType = node.InstanceType
+	}
+	i.Nodes = append(i.Nodes, ImpactedNode{
+		Name:         node.Name,
+		ID:           node.ID,
+		IP:           node.IP,
+		InstanceType: instanceType,
+		Pool:         pool,
+	})
+}
+
+func (i *Impact) addCost(instanceType string, count int) {
+	if count == 0 {
+		return
+	}
+	cost, ok := models.MonthlyCost(instanceType, count)
+	if !ok {
+		for _, t := range i.UnknownTypes {
+			if t == instanceType {
+				return
+			}
+		}
+		i.UnknownTypes = append(i.UnknownTypes, instanceType)
+		return
+	}
+	i.MonthlyCostDelta += cost
+}
+
+// FetchWorkloads lists the pods running on the impacted nodes, by node name,
+// leaving out pods of DaemonSets, which run on every node anyway. It runs
+// kubectl on a master and can take a few seconds.
+func FetchWorkloads(cluster models.K3sCluster, nodes []ImpactedNode) (map[string][]string, error) {
+	var masterInstanceID string
+	for _, node := range cluster.MasterNodes {
+		if node.ID != "" {
+			masterInstanceID = node.ID
+			break
+		}
+	}
+	if masterInstanceID == "" {
+		return nil, fmt.Errorf("no master node found")
+	}
+
+	provider, err := registry.GetDefaultProvider()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get provider: %w", err)
+	}
+
+	command := `export KUBECONFIG=/etc/rancher/k3s/k3s.yaml
+kubectl get pods --all-namespaces --no-headers --field-selector=status.phase=Running \
+  -o custom-columns=NS:.metadata.namespace,NAME:.metadata.name,HOST:.status.hostIP,OWNER:.metadata.ownerReferences[0].kind`
+	result, err := provider.GetComputeService().RunCommand(context.Background(), []string{masterInstanceID}, command)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pods: %w", err)
+	}
+	out, ok := result.Instances[masterInstanceID]
+	if !ok || out.ExitCode != 0 {
+		if out != nil && out.Error != "" {
+			return nil, fmt.Errorf("failed to list pods: %s", out.Error)
+		}
+		return nil, fmt.Errorf("failed to list pods: %s", result.Status)
+	}
+	return parseWorkloads(out.Output, nodes), nil
+}
+
+// parseWorkloads assigns the pods of the kubectl output to the impacted
+// nodes by host IP
+func parseWorkloads(output string, nodes []ImpactedNode) map[string][]string {
+	byIP := make(map[string]string)
+	for _, node := range nodes {
+		if node.IP != "" {
+			byIP[node.IP] = node.Name
+		}
+	}
+
+	workloads := make(map[string][]string)
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 4 || fields[3] == "DaemonSet" {
+			continue
+		}
+		if name, ok := byIP[fields[2]]; ok {
+			workloads[name] = append(workloads[name], fields[0]+"/"+fields[1])
+		}
+	}
+	return workloads
+}
+
+// workerPoolIndex splits a worker name (<cluster>-worker-<pool>-<index>) into
+// its pool and index
+func workerPoolIndex(clusterName, nodeName string) (string, int, bool) {
+	rest := strings.TrimPrefix(nodeName, clusterName+"-worker-")
+	if rest == nodeName {
+		return "", 0, false
+	}
+	idx := strings.LastIndex(rest, "-")
+	if idx <= 0 {
+		return "", 0, false
+	}
+	index, err := strconv.Atoi(rest[idx+1:])
+	if err != nil {
+		return "", 0, false
+	}
+	return rest[:idx], index, true
+}
+
+// masterInstanceType returns the instance type of the masters of a cluster
+func masterInstanceType(cluster models.K3sCluster) string {
+	if cluster.InstanceType != "" {
+		return cluster.InstanceType
+	}
+	if preset, err := models.LookupPreset(cluster.Preset); cluster.Preset != "" && err == nil {
+		return preset.InstanceType
+	}
+	return "t3.medium"
+}
+
+func poolExists(pools []models.NodePool, name string) bool {
+	for _, pool := range pools {
+		if pool.Name == name {
+			return true
+		}
+	}
+	return false
+}
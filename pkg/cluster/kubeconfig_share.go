@@ -0,0 +1,95 @@
+package cluster
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/madhouselabs/goman/pkg/audit"
+	"github.com/madhouselabs/goman/pkg/config"
+	"github.com/madhouselabs/goman/pkg/provider"
+	"github.com/madhouselabs/goman/pkg/provider/registry"
+)
+
+// KubeconfigShare is a time-limited download link for a cluster kubeconfig
+type KubeconfigShare struct {
+	URL       string
+	ExpiresAt time.Time
+}
+
+// kubeconfigSecret returns the secret holding the admin or the read-only
+// kubeconfig
+func kubeconfigSecret(admin bool) string {
+	if admin {
+		return provider.SecretKubeconfig
+	}
+	return provider.SecretKubeconfigReadOnly
+}
+
+// kubeconfigAccess describes the access a kubeconfig grants, for audit entries
+func kubeconfigAccess(admin bool) string {
+	if admin {
+		return "admin"
+	}
+	return "read-only"
+}
+
+// GetKubeconfig returns the admin or the read-only kubeconfig of a cluster.
+// The read-only one is issued by the controller once the cluster runs.
+func (m *Manager) GetKubeconfig(clusterName string, admin bool) ([]byte, error) {
+	p, err := registry.GetConfiguredProvider(config.GetAWSProfile(), config.GetAWSRegion())
+	if err != nil {
+		return nil, fmt.Errorf("failed to get provider: %w", err)
+	}
+	data, err := p.GetSecretService().GetSecret(context.Background(), clusterName, kubeconfigSecret(admin))
+	if err != nil {
+		if !admin && errors.Is(err, provider.ErrNotFound) {
+			return nil, fmt.Errorf("no read-only kubeconfig for %s yet, it is issued once the cluster is running", clusterName)
+		}
+		return nil, fmt.Errorf("failed to get kubeconfig: %w", err)
+	}
+	return data, nil
+}
+
+// ShareKubeconfig returns a URL that downloads the cluster's read-only, or
+// with admin the admin, kubeconfig without AWS credentials until ttl has
+// passed, or until the credentials signing it expire if that is sooner. Every share is recorded in the audit trail since the link grants
+// cluster access to whoever holds it.
+func (m *Manager) ShareKubeconfig(clusterName string, ttl time.Duration, admin bool) (*KubeconfigShare, error) {
+	if ttl <= 0 || ttl > provider.MaxSecretShareTTL {
+		return nil, fmt.Errorf("ttl must be between 1s and %s", provider.MaxSecretShareTTL)
+	}
+	if m.storage == nil {
+		return nil, fmt.Errorf("storage not available")
+	}
+
+	p, err := registry.GetConfiguredProvider(config.GetAWSProfile(), config.GetAWSRegion())
+	if err != nil {
+		return nil, fmt.Errorf("failed to get provider: %w", err)
+	}
+
+	secretService := p.GetSecretService()
+	sharer, ok := secretService.(provider.SecretSharer)
+	if !ok {
+		return nil, fmt.Errorf("the %s secret backend cannot share kubeconfigs, only %s can",
+			secretService.Backend(), provider.SecretBackendStorage)
+	}
+
+	url, expiresAt, err := sharer.ShareSecret(context.Background(), clusterName, kubeconfigSecret(admin), ttl)
+	if err != nil {
+		return nil, fmt.Errorf("failed to share kubeconfig: %w", err)
+	}
+
+	share := &KubeconfigShare{
+		URL:       url,
+		ExpiresAt: expiresAt,
+	}
+	// Unlike spec changes, a share that can't be audited is not handed out
+	if err := m.auditor.Record(clusterName, audit.ActionShare, []string{
+		fmt.Sprintf("%s kubeconfig download link valid until %s", kubeconfigAccess(admin), share.ExpiresAt.UTC().Format(time.RFC3339)),
+	}); err != nil {
+		return nil, fmt.Errorf("failed to record share in audit log: %w", err)
+	}
+	return share, nil
+}
@@ -2,15 +2,20 @@ package cluster
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"strings"
 	"sync"
 	"time"
 	"gopkg.in/yaml.v3"
 
+	"github.com/madhouselabs/goman/pkg/audit"
 	"github.com/madhouselabs/goman/pkg/config"
+	"github.com/madhouselabs/goman/pkg/logger"
 	"github.com/madhouselabs/goman/pkg/models"
+	"github.com/madhouselabs/goman/pkg/provider"
 	"github.com/madhouselabs/goman/pkg/provider/aws"
+	"github.com/madhouselabs/goman/pkg/provider/registry"
 	"github.com/madhouselabs/goman/pkg/setup"
 	"github.com/madhouselabs/goman/pkg/storage"
 )
@@ -27,6 +32,16 @@ type Manager struct {
 	clusters   []models.K3sCluster
 	storage    *storage.Storage
 	hasSynced  bool       // Track if we've done at least one sync
+	auditor    *audit.Recorder
+
+	// Set when a refresh failed because session credentials expired
+	credentialsExpired bool
+
+	// Offline mode: cached state is served while AWS is unreachable
+	offline    bool
+	lastSyncAt time.Time
+	lastSynced map[string]time.Time // Per-cluster last successful sync
+	intents    []Intent             // Actions queued while offline
 }
 
 // NewManager creates a new cluster manager
@@ -35,34 +50,93 @@ func NewManager() *Manager {
 	profile := config.GetAWSProfile()
 	region := config.GetAWSRegion()
 	
-	provider, err := aws.GetCachedProvider(profile, region)
+	manager := &Manager{
+		clusters:   []models.K3sCluster{},
+		lastSynced: make(map[string]time.Time),
+	}
+	manager.loadOfflineCache()
+
+	provider, err := registry.GetConfiguredProvider(profile, region)
 	if err != nil {
-		// Fallback to in-memory only if provider fails
-		return &Manager{
-			clusters: []models.K3sCluster{},
-		}
+		// Fallback to cached state if provider fails
+		manager.goOffline(err)
+		return manager
 	}
 	
-	storage, err := storage.NewStorageWithProvider(provider)
-	if err != nil {
-		// Fallback to in-memory only if storage fails
-		return &Manager{
-			clusters: []models.K3sCluster{},
-		}
+	if err := manager.bindProvider(provider); err != nil {
+		// Fallback to cached state if storage fails
+		manager.goOffline(err)
+		return manager
 	}
 
-	// Load initial clusters from storage
-	manager := &Manager{
-		clusters:   []models.K3sCluster{},
-		storage:    storage,
-	}
-	
 	// Do initial load synchronously
 	manager.loadClustersFromStorage()
 	
 	return manager
 }
 
+// bindProvider points storage and the audit recorder at a provider
+func (m *Manager) bindProvider(provider provider.Provider) error {
+	storage, err := storage.NewStorageWithProvider(provider)
+	if err != nil {
+		return err
+	}
+	identity, _ := provider.(interface {
+		CallerARN(ctx context.Context) (string, error)
+	})
+
+	// Resolve the caller identity lazily - only mutations need it
+	var principalOnce sync.Once
+	var principal string
+	resolvePrincipal := func() string {
+		principalOnce.Do(func() {
+			if identity == nil {
+				return
+			}
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+			arn, err := identity.CallerARN(ctx)
+			if err != nil {
+				logger.Printf("Failed to resolve caller identity for audit log: %v", err)
+				return
+			}
+			principal = arn
+		})
+		return principal
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.storage = storage
+	m.auditor = audit.NewRecorder(storage.GetBackend(), resolvePrincipal)
+	m.credentialsExpired = false
+	return nil
+}
+
+// CredentialsExpired reports whether the last refresh failed because the
+// AWS session (SSO token or assumed role) expired
+func (m *Manager) CredentialsExpired() bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.credentialsExpired
+}
+
+// RefreshCredentials renews expired AWS credentials (running the SSO login
+// flow when the profile uses SSO) and rebuilds the cached provider and
+// storage so a long-running session keeps working. It needs the terminal
+// for SSO login, so the TUI must be suspended while it runs.
+func (m *Manager) RefreshCredentials(ctx context.Context) error {
+	provider, err := aws.RefreshCredentials(ctx, config.GetAWSProfile(), config.GetAWSRegion())
+	if err != nil {
+		return err
+	}
+	if err := m.bindProvider(provider); err != nil {
+		return fmt.Errorf("failed to rebuild storage: %w", err)
+	}
+	m.loadClustersFromStorage()
+	return nil
+}
+
 // GetClusters returns all clusters
 func (m *Manager) GetClusters() []models.K3sCluster {
 	// Always return from in-memory list
@@ -84,6 +158,12 @@ func (m *Manager) loadClustersFromStorage() {
 	states, err := m.storage.LoadAllClusterStates()
 	if err != nil {
 		// Failed to load, but don't block
+		if aws.IsCredentialExpiredError(err) {
+			m.mu.Lock()
+			m.credentialsExpired = true
+			m.mu.Unlock()
+		}
+		m.goOffline(err)
 		return
 	}
 	
@@ -92,6 +172,7 @@ func (m *Manager) loadClustersFromStorage() {
 	
 	// Mark as synced
 	m.hasSynced = true
+	defer m.markSyncedLocked()
 	
 	// Remember which clusters are marked as deleting locally
 	// Only preserve this status if the cluster still exists in storage
@@ -207,31 +288,31 @@ func (m *Manager) CreateCluster(cluster models.K3sCluster) (*models.K3sCluster,
 	// Save initial state to storage FIRST before adding to memory
 	// Use the new separated file structure
 	if m.storage != nil {
-		// Clean up any leftover files from previous cluster with same name
-		// This prevents new clusters from picking up old "deleting" status
+		if err := m.CheckOrgPolicy(cluster); err != nil {
+			return nil, err
+		}
+
+		// Refuse to reuse a name while its previous cluster is still being deleted
+		if err := m.checkNameAvailable(cluster.Name); err != nil {
+			return nil, err
+		}
+
+		// Drop the spec and queued edits of a previous cluster with the same
+		// name. Its status is left to the controller, which replaces a status
+		// recorded for another cluster ID (see storage.StatusBelongsTo).
 		backend := m.storage.GetBackend()
-		
-		// Delete any existing config and status files
-		configKey := fmt.Sprintf("clusters/%s/config.yaml", cluster.Name)
-		statusKey := fmt.Sprintf("clusters/%s/status.yaml", cluster.Name)
-		
-		// Delete both files
-		backend.DeleteObject(configKey)
-		backend.DeleteObject(statusKey)
-		
-		// Wait a bit longer to ensure:
-		// 1. S3 eventual consistency catches up
-		// 2. Any in-flight Lambda processing of the old cluster completes
-		// This prevents the new cluster from picking up stale status
-		time.Sleep(500 * time.Millisecond)
+		backend.DeleteObject(storage.ClusterConfigKey(cluster.Name))
+		backend.DeleteObject(storage.IntentQueueKey(cluster.Name))
 		
 		// Save config file (user-controlled data)
-		if err := m.saveClusterConfig(cluster); err != nil {
+		if err := m.writeClusterConfig(cluster); err != nil {
 			return nil, fmt.Errorf("failed to save cluster config: %w", err)
 		}
 		
-		// The controller will create the status.yaml file when it reconciles
-		// We only manage config.yaml from the UI side
+		// The controller creates status.yaml when it reconciles; clients
+		// only ever write config.yaml
+
+		m.recordAudit(cluster.Name, audit.ActionCreate, audit.Summary(cluster))
 	}
 
 	// Add to cluster list only after successful save
@@ -252,25 +333,54 @@ func (m *Manager) CreateCluster(cluster models.K3sCluster) (*models.K3sCluster,
 
 // UpdateCluster updates an existing cluster
 func (m *Manager) UpdateCluster(cluster models.K3sCluster) (*models.K3sCluster, error) {
+	if err := m.CheckOrgPolicy(cluster); err != nil {
+		return nil, err
+	}
+
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
 	// Find and update the cluster in memory
 	found := false
+	var changes []string
 	for i := range m.clusters {
 		if m.clusters[i].ID == cluster.ID || m.clusters[i].Name == cluster.Name {
-			// Validate that mode is not being changed
+			if m.clusters[i].Status == models.StatusDeleting {
+				return nil, fmt.Errorf("%w: %s; changes were not saved", ErrClusterDeleting, m.clusters[i].Name)
+			}
+
+			// Validate that mode is not being changed; HA clusters are
+			// downscaled through DownscaleToDev
 			if m.clusters[i].Mode != cluster.Mode {
 				return nil, fmt.Errorf("cluster mode cannot be changed after creation (current: %s, attempted: %s)", 
 					m.clusters[i].Mode, cluster.Mode)
 			}
 			
+			changes = audit.Diff(m.clusters[i], cluster)
+
 			// Update fields
 			m.clusters[i].Name = cluster.Name
 			m.clusters[i].Description = cluster.Description
 			m.clusters[i].Region = cluster.Region
 			m.clusters[i].InstanceType = cluster.InstanceType
+			m.clusters[i].Architecture = cluster.Architecture
+			m.clusters[i].ImageID = cluster.ImageID
+			m.clusters[i].OSFamily = cluster.OSFamily
+			m.clusters[i].K3sVersion = cluster.K3sVersion
 			m.clusters[i].NodePools = cluster.NodePools  // Update NodePools
+			m.clusters[i].DriftPolicy = cluster.DriftPolicy
+			m.clusters[i].DNS = cluster.DNS
+			m.clusters[i].VirtualIP = cluster.VirtualIP
+			m.clusters[i].LoadBalancer = cluster.LoadBalancer
+			m.clusters[i].InstanceProtection = cluster.InstanceProtection
+			m.clusters[i].ClusterLinks = cluster.ClusterLinks
+			m.clusters[i].Addons = cluster.Addons
+			m.clusters[i].Notifications = cluster.Notifications
+			m.clusters[i].Proxy = cluster.Proxy
+			m.clusters[i].Bootstrap = cluster.Bootstrap
+			m.clusters[i].Registries = cluster.Registries
+			m.clusters[i].Schedule = cluster.Schedule
+			m.clusters[i].Tags = cluster.Tags
 			m.clusters[i].UpdatedAt = time.Now()
 			found = true
 			cluster = m.clusters[i]
@@ -287,6 +397,14 @@ func (m *Manager) UpdateCluster(cluster models.K3sCluster) (*models.K3sCluster,
 		if err := m.saveClusterConfig(cluster); err != nil {
 			return nil, fmt.Errorf("failed to save updated cluster config: %w", err)
 		}
+
+		if len(changes) > 0 {
+			action := audit.ActionUpdate
+			if audit.IsScaleOnly(changes) {
+				action = audit.ActionScale
+			}
+			m.recordAudit(cluster.Name, action, changes)
+		}
 	}
 
 	return &cluster, nil
@@ -310,15 +428,13 @@ func (m *Manager) DeleteCluster(clusterID string) error {
 
 			// Try to update config with deletion timestamp if it exists
 			if m.storage != nil {
-				configKey := fmt.Sprintf("clusters/%s/config.yaml", clusterName)
+				configKey := storage.ClusterConfigKey(clusterName)
 				backend := m.storage.GetBackend()
 				// Try to load existing config
 				configData, err := backend.GetObject(configKey)
 				if err != nil {
 						// If config doesn't exist, it's already deleted
-						if strings.Contains(err.Error(), "not found") {
-							// Clean up any remaining state files
-							m.storage.DeleteClusterState(clusterName)
+						if errors.Is(err, provider.ErrNotFound) {
 							// Remove from in-memory list since it's already gone
 							m.clusters = append(m.clusters[:i], m.clusters[i+1:]...)
 							return nil
@@ -355,6 +471,9 @@ func (m *Manager) DeleteCluster(clusterID string) error {
 					if err := backend.PutObject(configKey, updatedData); err != nil {
 						fmt.Printf("Warning: Could not save deletion config: %v\n", err)
 						// Still keep in list with deleting status
+					} else {
+						m.recordAudit(clusterName, audit.ActionDelete, nil)
+						m.writeTombstone(clusterName, m.clusters[i].ID)
 					}
 					
 					// DON'T remove from list - let it show as "deleting" until Lambda removes files
@@ -381,20 +500,18 @@ func (m *Manager) StartCluster(clusterID string) error {
 			}
 			
 			// Update status and desired state
+			now := time.Now()
 			m.clusters[i].Status = models.StatusStarting
 			m.clusters[i].DesiredState = "running"
-			m.clusters[i].UpdatedAt = time.Now()
+			m.clusters[i].DesiredStateSetAt = &now
+			m.clusters[i].UpdatedAt = now
 			
 			// Save config with desired state to trigger Lambda
 			if m.storage != nil {
 				if err := m.saveClusterConfig(m.clusters[i]); err != nil {
 					return fmt.Errorf("failed to save cluster config: %w", err)
 				}
-				// Also update status immediately so UI shows correct state
-				if err := m.saveClusterStatus(m.clusters[i]); err != nil {
-					// Log but don't fail - Lambda will update status anyway
-					fmt.Printf("Warning: failed to save immediate status update: %v\n", err)
-				}
+				m.recordAudit(m.clusters[i].Name, audit.ActionStart, []string{"desiredState: running"})
 			}
 			
 			return nil
@@ -413,22 +530,23 @@ func (m *Manager) StopCluster(clusterID string) error {
 			if m.clusters[i].Status != models.StatusRunning {
 				return fmt.Errorf("cluster is not in running state (current: %s)", m.clusters[i].Status)
 			}
+			if m.clusters[i].InstanceProtection.StopProtected() {
+				return provider.UserConfigErrorf("cluster %s has stop protection; turn off instanceProtection.stopProtection first", m.clusters[i].Name)
+			}
 			
 			// Update status and desired state
+			now := time.Now()
 			m.clusters[i].Status = models.StatusStopping
 			m.clusters[i].DesiredState = "stopped"
-			m.clusters[i].UpdatedAt = time.Now()
+			m.clusters[i].DesiredStateSetAt = &now
+			m.clusters[i].UpdatedAt = now
 			
 			// Save config with desired state to trigger Lambda
 			if m.storage != nil {
 				if err := m.saveClusterConfig(m.clusters[i]); err != nil {
 					return fmt.Errorf("failed to save cluster config: %w", err)
 				}
-				// Also update status immediately so UI shows correct state
-				if err := m.saveClusterStatus(m.clusters[i]); err != nil {
-					// Log but don't fail - Lambda will update status anyway
-					fmt.Printf("Warning: failed to save immediate status update: %v\n", err)
-				}
+				m.recordAudit(m.clusters[i].Name, audit.ActionStop, []string{"desiredState: stopped"})
 			}
 			
 			return nil
@@ -437,6 +555,113 @@ func (m *Manager) StopCluster(clusterID string) error {
 	return fmt.Errorf("cluster not found: %s", clusterID)
 }
 
+// DownscaleToDev converts an HA cluster to dev mode. The controller removes
+// the two extra masters one at a time and keeps the first master serving.
+func (m *Manager) DownscaleToDev(clusterID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for i := range m.clusters {
+		if m.clusters[i].ID == clusterID || m.clusters[i].Name == clusterID {
+			if m.clusters[i].Mode != models.ModeHA {
+				return fmt.Errorf("cluster is not in ha mode (current: %s)", m.clusters[i].Mode)
+			}
+			if m.clusters[i].Status != models.StatusRunning {
+				return fmt.Errorf("cluster must be running to downscale (current: %s)", m.clusters[i].Status)
+			}
+
+			m.clusters[i].Mode = models.ModeDev
+			m.clusters[i].Status = models.StatusUpdating
+			m.clusters[i].UpdatedAt = time.Now()
+
+			// Save config with the new mode to trigger Lambda
+			if m.storage != nil {
+				if err := m.saveClusterConfig(m.clusters[i]); err != nil {
+					return fmt.Errorf("failed to save cluster config: %w", err)
+				}
+				m.recordAudit(m.clusters[i].Name, audit.ActionDownscale, []string{"mode: ha -> dev"})
+			}
+
+			return nil
+		}
+	}
+	return fmt.Errorf("cluster not found: %s", clusterID)
+}
+
+// ReplaceNode requests replacement of a worker node (by instance name or ID).
+// The controller drains the node, provisions a replacement from the same pool,
+// waits for it to become Ready and then terminates the old instance.
+func (m *Manager) ReplaceNode(clusterID, node string) (*models.NodeReplacement, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for i := range m.clusters {
+		if m.clusters[i].ID == clusterID || m.clusters[i].Name == clusterID {
+			if m.clusters[i].Status != models.StatusRunning {
+				return nil, fmt.Errorf("cluster must be running to replace nodes (current: %s)", m.clusters[i].Status)
+			}
+
+			// Drop requests the controller has already finished
+			statuses, _ := m.loadNodeReplacementStatus(m.clusters[i].Name)
+			pending := []models.NodeReplacement{}
+			for _, req := range m.clusters[i].NodeReplacements {
+				if st := models.FindNodeReplacement(statuses, req); st != nil && st.Done() {
+					continue
+				}
+				if req.Node == node {
+					return nil, fmt.Errorf("node %s already has a pending replacement", node)
+				}
+				pending = append(pending, req)
+			}
+
+			req := models.NodeReplacement{Node: node, RequestedAt: time.Now().UTC().Truncate(time.Second)}
+			m.clusters[i].NodeReplacements = append(pending, req)
+			m.clusters[i].UpdatedAt = time.Now()
+
+			// Save config with the request to trigger Lambda
+			if m.storage != nil {
+				if err := m.saveClusterConfig(m.clusters[i]); err != nil {
+					return nil, fmt.Errorf("failed to save cluster config: %w", err)
+				}
+				m.recordAudit(m.clusters[i].Name, audit.ActionReplaceNode, []string{fmt.Sprintf("node: %s", node)})
+			}
+
+			return &req, nil
+		}
+	}
+	return nil, fmt.Errorf("cluster not found: %s", clusterID)
+}
+
+// NodeReplacementStatus returns the controller's progress for a replacement request.
+// It returns nil if the controller has not picked up the request yet.
+func (m *Manager) NodeReplacementStatus(clusterName string, req models.NodeReplacement) (*models.NodeReplacementStatus, error) {
+	statuses, err := m.loadNodeReplacementStatus(clusterName)
+	if err != nil {
+		return nil, err
+	}
+	return models.FindNodeReplacement(statuses, req), nil
+}
+
+// loadNodeReplacementStatus reads node replacement progress from status.yaml
+func (m *Manager) loadNodeReplacementStatus(clusterName string) ([]models.NodeReplacementStatus, error) {
+	if m.storage == nil {
+		return nil, fmt.Errorf("storage not available")
+	}
+
+	data, err := m.storage.GetBackend().GetObject(storage.ClusterStatusKey(clusterName))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load cluster status: %w", err)
+	}
+
+	var status struct {
+		NodeReplacements []models.NodeReplacementStatus `yaml:"nodeReplacements"`
+	}
+	if err := yaml.Unmarshal(data, &status); err != nil {
+		return nil, fmt.Errorf("failed to parse cluster status: %w", err)
+	}
+	return status.NodeReplacements, nil
+}
+
 // GetClusterByID returns a cluster by ID
 func (m *Manager) GetClusterByID(clusterID string) (*models.K3sCluster, error) {
 	m.mu.RLock()
@@ -522,11 +747,24 @@ func (m *Manager) SyncFromProvider() error {
 
 // RefreshClusterStatus refreshes cluster status from storage
 func (m *Manager) RefreshClusterStatus() {
+	// Retry provider setup if we started offline
+	if m.storage == nil && !m.reconnect() {
+		m.goOffline(fmt.Errorf("AWS provider unavailable"))
+		return
+	}
+
 	// Reload clusters from storage to get latest state
 	if m.storage != nil {
 		states, err := m.storage.LoadAllClusterStates()
 		if err != nil {
-			// Failed to refresh cluster status, continue silently
+			// Failed to refresh cluster status - serve cached state and
+			// flag expired sessions so the user can log in again
+			if aws.IsCredentialExpiredError(err) {
+				m.mu.Lock()
+				m.credentialsExpired = true
+				m.mu.Unlock()
+			}
+			m.goOffline(err)
 			return
 		}
 
@@ -545,6 +783,7 @@ func (m *Manager) RefreshClusterStatus() {
 			m.clusters = newClusters
 		}
 		m.hasSynced = true
+		m.markSyncedLocked()
 	}
 }
 
@@ -556,7 +795,7 @@ func (m *Manager) updateClusterStatusFromAWS(cluster *models.K3sCluster) {
 		return
 	}
 	
-	provider, err := aws.GetCachedProvider(cfg.AWSProfile, cfg.AWSRegion)
+	provider, err := registry.GetConfiguredProvider(cfg.AWSProfile, cfg.AWSRegion)
 	if err != nil {
 		return
 	}
@@ -652,85 +891,85 @@ func (m *Manager) updateClusterStatusFromAWS(cluster *models.K3sCluster) {
 	}
 }
 
-// saveClusterConfig saves cluster configuration (user-controlled data)
+// saveClusterConfig saves configuration of an existing cluster
+// (user-controlled data). Writes to deleting or deleted clusters are rejected.
 func (m *Manager) saveClusterConfig(cluster models.K3sCluster) error {
 	if m.storage == nil {
 		return nil
 	}
-
-	// Convert to proper config structure (without status)
-	config := storage.ConvertToClusterConfig(cluster)
-
-	// Save to config.yaml file
-	configKey := fmt.Sprintf("clusters/%s/config.yaml", cluster.Name)
-	
-	// Marshal as YAML
-	data, err := yaml.Marshal(config)
-	if err != nil {
-		return fmt.Errorf("failed to marshal config: %w", err)
+	if err := m.guardSpecWrite(cluster.Name); err != nil {
+		return err
 	}
-	
-	// Use the backend directly to save the raw data
-	backend := m.storage.GetBackend()
-	return backend.PutObject(configKey, data)
+	return m.writeClusterConfig(cluster)
 }
 
-// saveClusterStatus saves cluster status immediately (for UI responsiveness)
-func (m *Manager) saveClusterStatus(cluster models.K3sCluster) error {
+// writeClusterConfig writes config.yaml without checking the stored state
+func (m *Manager) writeClusterConfig(cluster models.K3sCluster) error {
 	if m.storage == nil {
 		return nil
 	}
 
-	// Create status structure in Lambda format
-	statusState := make(map[string]interface{})
-	
-	// Map status to Lambda format
-	var statusStr string
-	switch cluster.Status {
-	case models.StatusRunning:
-		statusStr = "running"
-	case models.StatusStopping:
-		statusStr = "stopping"
-	case models.StatusStopped:
-		statusStr = "stopped"
-	case models.StatusStarting:
-		statusStr = "starting"
-	case models.StatusCreating:
-		statusStr = "creating"
-	case models.StatusDeleting:
-		statusStr = "deleting"
-	case models.StatusError:
-		statusStr = "error"
-	default:
-		statusStr = "pending"
-	}
-	
-	statusState["cluster"] = map[string]interface{}{
-		"status":     statusStr,
-		"updated_at": time.Now().Format(time.RFC3339),
+	// Convert to proper config structure (without status)
+	config := storage.ConvertToClusterConfig(cluster)
+
+	// Save to config.yaml file
+	configKey := storage.ClusterConfigKey(cluster.Name)
+
+	// Every write is a new spec generation. The write is conditional, so two
+	// concurrent edits can't both claim the same generation.
+	var changes []string
+	err := m.storage.UpdateObject(configKey, func(data []byte) ([]byte, error) {
+		config.Metadata.Generation = 0
+		changes = nil
+		if data != nil {
+			var stored storage.ClusterConfig
+			if err := yaml.Unmarshal(data, &stored); err == nil {
+				config.Metadata.Generation = stored.Metadata.Generation
+				// Notes are changed by AnnotateCluster alone, never by spec writes
+				config.Metadata.Annotations = stored.Metadata.Annotations
+				changes = audit.Diff(storage.ConvertFromClusterConfig(&stored, nil), cluster)
+			}
+		}
+		config.Metadata.Generation++
+
+		// Marshal as YAML
+		out, err := yaml.Marshal(config)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal config: %w", err)
+		}
+		return out, nil
+	})
+	if err != nil {
+		return err
 	}
-	
-	// Add minimal metadata
-	statusState["metadata"] = map[string]interface{}{
-		"last_updated_by": "ui",
-		"message": fmt.Sprintf("Status updated by UI to %s", statusStr),
+
+	// Queue the change so the controller coalesces rapid edits and acts on
+	// the latest generation. Without the queue entry the controller still
+	// picks up the spec, just without coalescing.
+	intent := models.SpecIntent{
+		Generation:  config.Metadata.Generation,
+		RequestedAt: time.Now(),
+		Source:      "cli",
+		Changes:     changes,
 	}
-	
-	// Save to status.yaml file
-	statusKey := fmt.Sprintf("clusters/%s/status.yaml", cluster.Name)
-	
-	// Marshal as YAML
-	data, err := yaml.Marshal(statusState)
-	if err != nil {
-		return fmt.Errorf("failed to marshal status: %w", err)
+	if err := m.storage.EnqueueIntent(cluster.Name, intent); err != nil {
+		logger.Printf("Failed to queue spec change of %s: %v", cluster.Name, err)
 	}
-	
-	// Use the backend directly to save the raw data
-	backend := m.storage.GetBackend()
-	return backend.PutObject(statusKey, data)
+	return nil
 }
 
+// recordAudit appends an audit entry for a spec mutation. Failures are logged
+// but never block the operation itself.
+func (m *Manager) recordAudit(clusterName, action string, changes []string) {
+	if err := m.auditor.Record(clusterName, action, changes); err != nil {
+		logger.Printf("Warning: failed to record audit entry for %s %s: %v", action, clusterName, err)
+	}
+}
 
+// AuditLog returns recorded audit entries, optionally filtered by cluster
+func (m *Manager) AuditLog(clusterName string, limit int) ([]audit.Entry, error) {
+	return m.auditor.List(clusterName, limit)
+}
 
 // HasSyncedOnce returns true if at least one sync has been performed
 func (m *Manager) HasSyncedOnce() bool {
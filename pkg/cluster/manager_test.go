@@ -0,0 +1,31 @@
+package cluster
+
+import (
+	"testing"
+
+	"github.com/madhouselabs/goman/pkg/models"
+)
+
+func TestModeChangesOnlyThroughDownscale(t *testing.T) {
+	ha := models.K3sCluster{ID: "c1", Name: "prod", Mode: models.ModeHA, Status: models.StatusRunning}
+	m := &Manager{clusters: []models.K3sCluster{ha}}
+
+	dev := ha
+	dev.Mode = models.ModeDev
+	if _, err := m.UpdateCluster(dev); err == nil {
+		t.Error("UpdateCluster changed the mode from ha to dev")
+	}
+
+	m.clusters[0].Status = models.StatusUpdating
+	if err := m.DownscaleToDev("prod"); err == nil {
+		t.Error("DownscaleToDev downscaled a cluster that is not running")
+	}
+
+	m.clusters[0].Status = models.StatusRunning
+	if err := m.DownscaleToDev("prod"); err != nil {
+		t.Fatal(err)
+	}
+	if m.clusters[0].Mode != models.ModeDev {
+		t.Errorf("mode = %s after DownscaleToDev, want dev", m.clusters[0].Mode)
+	}
+}
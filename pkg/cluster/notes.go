@@ -0,0 +1,86 @@
+package cluster
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/madhouselabs/goman/pkg/audit"
+	"github.com/madhouselabs/goman/pkg/models"
+	"github.com/madhouselabs/goman/pkg/storage"
+	"gopkg.in/yaml.v3"
+)
+
+// AnnotateCluster sets and removes notes of a cluster, kept in the
+// annotations of its config. Notes are not part of the spec: the generation
+// is not bumped and the controller does not act on them. The write is
+// conditional, so notes edited by someone else meanwhile are kept.
+func (m *Manager) AnnotateCluster(clusterName string, set map[string]string, remove []string) error {
+	for key, value := range set {
+		if err := models.ValidateNote(key, value); err != nil {
+			return err
+		}
+	}
+	for _, key := range remove {
+		if err := models.ValidateNote(key, ""); err != nil {
+			return err
+		}
+	}
+	if m.storage == nil {
+		return fmt.Errorf("storage not initialized")
+	}
+	if err := m.guardSpecWrite(clusterName); err != nil {
+		return err
+	}
+
+	var annotations map[string]string
+	var changes []string
+	configKey := storage.ClusterConfigKey(clusterName)
+	err := m.storage.UpdateObject(configKey, func(data []byte) ([]byte, error) {
+		if data == nil {
+			return nil, fmt.Errorf("%w: %s no longer exists; notes were not saved", ErrClusterDeleted, clusterName)
+		}
+		var config storage.ClusterConfig
+		if err := yaml.Unmarshal(data, &config); err != nil {
+			return nil, fmt.Errorf("failed to parse cluster config: %w", err)
+		}
+
+		annotations = make(map[string]string, len(config.Metadata.Annotations)+len(set))
+		for key, value := range config.Metadata.Annotations {
+			annotations[key] = value
+		}
+		for key, value := range set {
+			annotations[key] = value
+		}
+		for _, key := range remove {
+			delete(annotations, key)
+		}
+		changes = audit.DiffNotes(config.Metadata.Annotations, annotations)
+		if len(changes) == 0 {
+			return nil, nil
+		}
+
+		if len(annotations) == 0 {
+			annotations = nil
+		}
+		config.Metadata.Annotations = annotations
+		config.Metadata.UpdatedAt = time.Now()
+		return yaml.Marshal(&config)
+	})
+	if err != nil {
+		return err
+	}
+	if len(changes) == 0 {
+		return nil
+	}
+
+	m.mu.Lock()
+	for i := range m.clusters {
+		if m.clusters[i].Name == clusterName {
+			m.clusters[i].Annotations = annotations
+		}
+	}
+	m.mu.Unlock()
+
+	m.recordAudit(clusterName, audit.ActionAnnotate, changes)
+	return nil
+}
@@ -0,0 +1,258 @@
+package cluster
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/madhouselabs/goman/pkg/config"
+	"github.com/madhouselabs/goman/pkg/logger"
+	"github.com/madhouselabs/goman/pkg/models"
+	"github.com/madhouselabs/goman/pkg/provider/registry"
+)
+
+// Intent actions that can be queued while offline
+const (
+	IntentDelete = "delete"
+	IntentStop   = "stop"
+	IntentStart  = "start"
+)
+
+// Intent is a user action requested while AWS was unreachable. Intents are
+// never applied automatically - the user confirms them once back online.
+type Intent struct {
+	ID          string    `json:"id"`
+	ClusterID   string    `json:"cluster_id"`
+	ClusterName string    `json:"cluster_name"`
+	Action      string    `json:"action"`
+	QueuedAt    time.Time `json:"queued_at"`
+}
+
+// offlineCache is the last successfully synced state, kept on disk so the
+// TUI can show clusters while AWS is unreachable
+type offlineCache struct {
+	SyncedAt   time.Time            `json:"synced_at"`
+	Clusters   []models.K3sCluster  `json:"clusters"`
+	LastSynced map[string]time.Time `json:"last_synced"`
+	Intents    []Intent             `json:"intents,omitempty"`
+}
+
+// offlineCachePath returns the local cache file
+func offlineCachePath() string {
+	homeDir, _ := os.UserHomeDir()
+	return filepath.Join(homeDir, ".goman", "cache", "clusters.json")
+}
+
+// loadOfflineCache restores sync times and queued intents from disk.
+// Cached clusters are only used once the manager goes offline.
+func (m *Manager) loadOfflineCache() *offlineCache {
+	data, err := os.ReadFile(offlineCachePath())
+	if err != nil {
+		return nil
+	}
+
+	var cache offlineCache
+	if err := json.Unmarshal(data, &cache); err != nil {
+		logger.Printf("Ignoring unreadable offline cache: %v", err)
+		return nil
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if cache.LastSynced != nil {
+		m.lastSynced = cache.LastSynced
+	}
+	m.intents = cache.Intents
+	return &cache
+}
+
+// saveOfflineCacheLocked writes the current state to disk. Caller holds m.mu.
+func (m *Manager) saveOfflineCacheLocked() {
+	cache := offlineCache{
+		SyncedAt:   m.lastSyncAt,
+		Clusters:   m.clusters,
+		LastSynced: m.lastSynced,
+		Intents:    m.intents,
+	}
+
+	data, err := json.MarshalIndent(cache, "", "  ")
+	if err != nil {
+		logger.Printf("Failed to encode offline cache: %v", err)
+		return
+	}
+
+	path := offlineCachePath()
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		logger.Printf("Failed to create cache directory: %v", err)
+		return
+	}
+	// Write then rename so a crash never leaves a truncated cache
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		logger.Printf("Failed to write offline cache: %v", err)
+		return
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		logger.Printf("Failed to write offline cache: %v", err)
+	}
+}
+
+// markSyncedLocked records a successful sync of the current cluster list.
+// Caller holds m.mu.
+func (m *Manager) markSyncedLocked() {
+	now := time.Now()
+	m.offline = false
+	m.credentialsExpired = false
+	m.lastSyncAt = now
+	if m.lastSynced == nil {
+		m.lastSynced = make(map[string]time.Time)
+	}
+	for _, c := range m.clusters {
+		m.lastSynced[c.Name] = now
+	}
+	m.saveOfflineCacheLocked()
+}
+
+// goOffline switches to serving cached state after a failed sync
+func (m *Manager) goOffline(err error) {
+	var cache *offlineCache
+	m.mu.RLock()
+	needCache := len(m.clusters) == 0
+	m.mu.RUnlock()
+	if needCache {
+		cache = m.loadOfflineCache()
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if !m.offline && err != nil {
+		logger.Printf("AWS unreachable, switching to offline mode: %v", err)
+	}
+	m.offline = true
+	if cache != nil && len(m.clusters) == 0 {
+		m.clusters = cache.Clusters
+		m.lastSyncAt = cache.SyncedAt
+	}
+}
+
+// reconnect retries provider setup when the manager started without one
+func (m *Manager) reconnect() bool {
+	provider, err := registry.GetConfiguredProvider(config.GetAWSProfile(), config.GetAWSRegion())
+	if err != nil {
+		return false
+	}
+	return m.bindProvider(provider) == nil
+}
+
+// IsOffline reports whether the last sync failed and cached state is shown
+func (m *Manager) IsOffline() bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.offline
+}
+
+// LastSyncTime returns when cluster state was last fetched from AWS
+func (m *Manager) LastSyncTime() time.Time {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.lastSyncAt
+}
+
+// ClusterLastSynced returns when a cluster's state was last fetched from AWS
+func (m *Manager) ClusterLastSynced(clusterName string) time.Time {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.lastSynced[clusterName]
+}
+
+// QueueIntent records an action to confirm once AWS is reachable again
+func (m *Manager) QueueIntent(cluster models.K3sCluster, action string) Intent {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	// Re-queuing the same action for a cluster replaces the earlier intent
+	for i, intent := range m.intents {
+		if intent.ClusterName == cluster.Name && intent.Action == action {
+			m.intents = append(m.intents[:i], m.intents[i+1:]...)
+			break
+		}
+	}
+
+	intent := Intent{
+		ID:          fmt.Sprintf("%s-%s-%d", cluster.Name, action, time.Now().UnixNano()),
+		ClusterID:   cluster.ID,
+		ClusterName: cluster.Name,
+		Action:      action,
+		QueuedAt:    time.Now(),
+	}
+	m.intents = append(m.intents, intent)
+	m.saveOfflineCacheLocked()
+	return intent
+}
+
+// PendingIntents returns intents waiting for confirmation, oldest first
+func (m *Manager) PendingIntents() []Intent {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	result := make([]Intent, len(m.intents))
+	copy(result, m.intents)
+	return result
+}
+
+// DiscardIntent drops a queued intent without applying it
+func (m *Manager) DiscardIntent(id string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.removeIntentLocked(id)
+	m.saveOfflineCacheLocked()
+}
+
+// ApplyIntent performs a queued intent. It fails while still offline.
+func (m *Manager) ApplyIntent(id string) error {
+	if m.IsOffline() {
+		return fmt.Errorf("still offline, cannot apply queued action")
+	}
+
+	var intent *Intent
+	for _, queued := range m.PendingIntents() {
+		if queued.ID == id {
+			queued := queued
+			intent = &queued
+			break
+		}
+	}
+	if intent == nil {
+		return fmt.Errorf("queued action %s not found", id)
+	}
+
+	var err error
+	switch intent.Action {
+	case IntentDelete:
+		err = m.DeleteCluster(intent.ClusterID)
+	case IntentStop:
+		err = m.StopCluster(intent.ClusterName)
+	case IntentStart:
+		err = m.StartCluster(intent.ClusterName)
+	default:
+		err = fmt.Errorf("unknown queued action %q", intent.Action)
+	}
+	if err != nil {
+		return err
+	}
+
+	m.DiscardIntent(id)
+	return nil
+}
+
+// removeIntentLocked removes an intent by ID. Caller holds m.mu.
+func (m *Manager) removeIntentLocked(id string) {
+	for i, intent := range m.intents {
+		if intent.ID == id {
+			m.intents = append(m.intents[:i], m.intents[i+1:]...)
+			return
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package cluster
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/madhouselabs/goman/pkg/models"
+	"github.com/madhouselabs/goman/pkg/provider"
+	"gopkg.in/yaml.v3"
+)
+
+// OrgPolicy returns the stored organization policy; nil when none is set
+func (m *Manager) OrgPolicy() (*models.OrgPolicy, error) {
+	if m.storage == nil {
+		return nil, nil
+	}
+	data, err := m.storage.GetBackend().GetObject(models.OrgPolicyKey)
+	if err != nil {
+		if errors.Is(err, provider.ErrNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to load organization policy: %w", err)
+	}
+	var policy models.OrgPolicy
+	if err := yaml.Unmarshal(data, &policy); err != nil {
+		return nil, fmt.Errorf("failed to parse organization policy: %w", err)
+	}
+	if err := policy.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid organization policy: %w", err)
+	}
+	return &policy, nil
+}
+
+// SetOrgPolicy stores the organization policy enforced by the CLI and the
+// controller. An empty policy removes it.
+func (m *Manager) SetOrgPolicy(policy *models.OrgPolicy) error {
+	if m.storage == nil {
+		return fmt.Errorf("storage not initialized")
+	}
+	backend := m.storage.GetBackend()
+	if policy.Empty() {
+		if err := backend.DeleteObject(models.OrgPolicyKey); err != nil && !errors.Is(err, provider.ErrNotFound) {
+			return fmt.Errorf("failed to remove organization policy: %w", err)
+		}
+		return nil
+	}
+	if err := policy.Validate(); err != nil {
+		return err
+	}
+	data, err := yaml.Marshal(policy)
+	if err != nil {
+		return fmt.Errorf("failed to marshal organization policy: %w", err)
+	}
+	if err := backend.PutObject(models.OrgPolicyKey, data); err != nil {
+		return fmt.Errorf("failed to save organization policy: %w", err)
+	}
+	return nil
+}
+
+// CheckOrgPolicy checks a cluster being created or edited against the
+// organization policy, before anything is written
+func (m *Manager) CheckOrgPolicy(cluster models.K3sCluster) error {
+	policy, err := m.OrgPolicy()
+	if err != nil {
+		return err
+	}
+	return policy.Check(cluster.Subject())
+}
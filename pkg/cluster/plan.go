@@ -0,0 +1,75 @@
+package cluster
+
+import "github.com/madhouselabs/goman/pkg/models"
+
+// Actions of a node pool plan
+const (
+	PoolActionCreate    = "create"    // New pool
+	PoolActionResize    = "resize"    // Workers added or removed
+	PoolActionRollout   = "rollout"   // Instance type changes, nodes are replaced in batches
+	PoolActionDelete    = "delete"    // Pool removed, all its workers terminated
+	PoolActionPaused    = "paused"    // Paused pools are left as they are
+	PoolActionUnchanged = "unchanged" // Nothing to do
+)
+
+// PoolPlan is what a spec change does to one node pool, compared to the
+// workers that exist now
+type PoolPlan struct {
+	Pool         string
+	Action       string
+	Current      int // Workers of the pool now
+	Desired      int
+	InstanceType string // Instance type after the change
+	OldType      string // Instance type before a rollout
+}
+
+// PlanNodePools compares the workers of before with the node pools of after,
+// in the order of after followed by removed pools
+func PlanNodePools(before, after models.K3sCluster) []PoolPlan {
+	current := make(map[string]int)
+	for _, node := range before.WorkerNodes {
+		if pool, _, ok := workerPoolIndex(before.Name, node.Name); ok {
+			current[pool]++
+		}
+	}
+	old := make(map[string]models.NodePool)
+	for _, pool := range before.NodePools {
+		old[pool.Name] = pool
+	}
+
+	var plans []PoolPlan
+	for _, pool := range after.NodePools {
+		plan := PoolPlan{
+			Pool:         pool.Name,
+			Current:      current[pool.Name],
+			Desired:      pool.Count,
+			InstanceType: pool.InstanceType,
+		}
+		prev, existed := old[pool.Name]
+		switch {
+		case !existed:
+			plan.Action = PoolActionCreate
+		case pool.Paused:
+			plan.Action = PoolActionPaused
+		case prev.InstanceType != pool.InstanceType:
+			plan.Action = PoolActionRollout
+			plan.OldType = prev.InstanceType
+		case plan.Current != plan.Desired:
+			plan.Action = PoolActionResize
+		default:
+			plan.Action = PoolActionUnchanged
+		}
+		plans = append(plans, plan)
+	}
+	for _, pool := range before.NodePools {
+		if !poolExists(after.NodePools, pool.Name) {
+			plans = append(plans, PoolPlan{
+				Pool:         pool.Name,
+				Action:       PoolActionDelete,
+				Current:      current[pool.Name],
+				InstanceType: pool.InstanceType,
+			})
+		}
+	}
+	return plans
+}
@@ -0,0 +1,74 @@
+package cluster
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/madhouselabs/goman/pkg/audit"
+	"github.com/madhouselabs/goman/pkg/models"
+	"github.com/madhouselabs/goman/pkg/storage"
+	"gopkg.in/yaml.v3"
+)
+
+// RequestQuorumRecovery asks the controller to rebuild the control plane of
+// an HA cluster that lost etcd quorum from a surviving master. restore is
+// one of models.QuorumRestore*, latest if empty.
+func (m *Manager) RequestQuorumRecovery(clusterName, restore string) error {
+	if err := models.ValidateQuorumRestore(restore); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for i := range m.clusters {
+		if m.clusters[i].ID == clusterName || m.clusters[i].Name == clusterName {
+			if m.clusters[i].Status == models.StatusDeleting {
+				return fmt.Errorf("%w: %s", ErrClusterDeleting, m.clusters[i].Name)
+			}
+			if m.clusters[i].Mode != models.ModeHA {
+				return fmt.Errorf("cluster %s has a single master; only HA clusters have an etcd quorum to recover", m.clusters[i].Name)
+			}
+			m.clusters[i].QuorumRecovery = &models.QuorumRecoveryRequest{
+				RequestedAt: time.Now().UTC().Truncate(time.Second),
+				Restore:     restore,
+			}
+			m.clusters[i].UpdatedAt = time.Now()
+
+			// Save config with the request to trigger Lambda
+			if m.storage != nil {
+				if err := m.saveClusterConfig(m.clusters[i]); err != nil {
+					return fmt.Errorf("failed to save cluster config: %w", err)
+				}
+				change := "etcd quorum recovery requested"
+				if restore != "" {
+					change += ", restore: " + restore
+				}
+				m.recordAudit(m.clusters[i].Name, audit.ActionRecoverQuorum, []string{change})
+			}
+			return nil
+		}
+	}
+	return fmt.Errorf("cluster not found: %s", clusterName)
+}
+
+// QuorumRecoveryStatus returns the current or last quorum recovery of a
+// cluster as reported by the controller, or nil if there has been none
+func (m *Manager) QuorumRecoveryStatus(clusterName string) (*models.QuorumRecoveryStatus, error) {
+	if m.storage == nil {
+		return nil, fmt.Errorf("storage not available")
+	}
+
+	data, err := m.storage.GetBackend().GetObject(storage.ClusterStatusKey(clusterName))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load cluster status: %w", err)
+	}
+
+	var status struct {
+		QuorumRecovery *models.QuorumRecoveryStatus `yaml:"quorumRecovery"`
+	}
+	if err := yaml.Unmarshal(data, &status); err != nil {
+		return nil, fmt.Errorf("failed to parse cluster status: %w", err)
+	}
+	return status.QuorumRecovery, nil
+}
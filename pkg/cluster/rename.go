@@ -0,0 +1,391 @@
+package cluster
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/madhouselabs/goman/pkg/audit"
+	"github.com/madhouselabs/goman/pkg/config"
+	"github.com/madhouselabs/goman/pkg/logger"
+	"github.com/madhouselabs/goman/pkg/models"
+	"github.com/madhouselabs/goman/pkg/provider"
+	"github.com/madhouselabs/goman/pkg/provider/registry"
+	"github.com/madhouselabs/goman/pkg/storage"
+	"gopkg.in/yaml.v3"
+)
+
+// aliasTTL is how long a former cluster name keeps resolving after a rename
+const aliasTTL = 7 * 24 * time.Hour
+
+// renameLockTTL bounds how long a rename holds the controller locks
+const renameLockTTL = 10 * time.Minute
+
+var clusterNamePattern = regexp.MustCompile(`^[a-z][a-z0-9-]{0,38}[a-z0-9]$`)
+
+// ClusterAlias maps a former cluster name to its current name
+type ClusterAlias struct {
+	From      string    `yaml:"from"`
+	To        string    `yaml:"to"`
+	RenamedAt time.Time `yaml:"renamedAt"`
+	ExpiresAt time.Time `yaml:"expiresAt"`
+}
+
+// ValidateClusterName checks that a name can be used in tags, security group
+// names and S3 keys
+func ValidateClusterName(name string) error {
+	if !clusterNamePattern.MatchString(name) {
+		return fmt.Errorf("invalid cluster name %q: use 2-40 lowercase letters, digits and hyphens, starting with a letter", name)
+	}
+	return nil
+}
+
+// RenameCluster renames a cluster. Everything keyed by the cluster name is
+// moved: instance tags and Name tags, security group tags (EC2 group names are
+// immutable, so the legacy name is recorded instead) and the clusters/<name>/
+// objects in S3. The node identity keeps its name and is tagged with the new
+// one; the former names recorded in the config keep it, and anything else
+// still tagged with them, from being collected as orphaned. The controller
+// locks for both names are held throughout, and config.yaml is written last
+// so the controller only sees a complete cluster. The old name keeps
+// resolving through an alias for aliasTTL.
+func (m *Manager) RenameCluster(oldName, newName string) error {
+	if err := ValidateClusterName(newName); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.storage == nil {
+		return fmt.Errorf("storage not available")
+	}
+
+	idx := -1
+	for i := range m.clusters {
+		if m.clusters[i].Name == newName {
+			return fmt.Errorf("cluster %s already exists", newName)
+		}
+		if m.clusters[i].ID == oldName || m.clusters[i].Name == oldName {
+			idx = i
+		}
+	}
+	if idx < 0 {
+		return fmt.Errorf("cluster not found: %s", oldName)
+	}
+	cluster := m.clusters[idx]
+	oldName = cluster.Name
+	if cluster.Status != models.StatusRunning && cluster.Status != models.StatusStopped {
+		return fmt.Errorf("cluster must be running or stopped to rename (current: %s)", cluster.Status)
+	}
+
+	if err := m.guardSpecWrite(oldName); err != nil {
+		return err
+	}
+	if err := m.checkNameAvailable(newName); err != nil {
+		return err
+	}
+
+	backend := m.storage.GetBackend()
+	if _, err := backend.GetObject(storage.ClusterConfigKey(newName)); err == nil {
+		return fmt.Errorf("cluster %s already exists in storage", newName)
+	}
+
+	p, err := registry.GetConfiguredProvider(config.GetAWSProfile(), config.GetAWSRegion())
+	if err != nil {
+		return fmt.Errorf("failed to get provider: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), renameLockTTL)
+	defer cancel()
+
+	r := &renamer{
+		ctx:      ctx,
+		backend:  backend,
+		provider: p,
+		oldName:  oldName,
+		newName:  newName,
+		region:   cluster.Region,
+	}
+	if err := r.rename(); err != nil {
+		return err
+	}
+
+	m.clusters[idx].Name = newName
+	m.clusters[idx].UpdatedAt = time.Now()
+	if t, ok := m.lastSynced[oldName]; ok {
+		m.lastSynced[newName] = t
+		delete(m.lastSynced, oldName)
+	}
+	for i := range m.intents {
+		if m.intents[i].ClusterName == oldName {
+			m.intents[i].ClusterName = newName
+		}
+	}
+	m.saveOfflineCacheLocked()
+
+	m.recordAudit(newName, audit.ActionRename, []string{fmt.Sprintf("name: %s -> %s", oldName, newName)})
+	return nil
+}
+
+// ResolveClusterName follows rename aliases so former names keep working.
+// It returns the current name and whether an alias was followed.
+func (m *Manager) ResolveClusterName(name string) (string, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	for _, c := range m.clusters {
+		if c.Name == name {
+			return name, false
+		}
+	}
+	if m.storage == nil {
+		return name, false
+	}
+
+	current := name
+	// Follow a short chain of renames (a -> b -> c)
+	for hop := 0; hop < 5; hop++ {
+		alias, err := loadAlias(m.storage.GetBackend(), current)
+		if err != nil || time.Now().After(alias.ExpiresAt) {
+			break
+		}
+		current = alias.To
+	}
+	return current, current != name
+}
+
+// loadAlias reads the alias for a former cluster name
+func loadAlias(backend storage.StorageBackend, name string) (*ClusterAlias, error) {
+	data, err := backend.GetObject(storage.AliasKey(name))
+	if err != nil {
+		return nil, err
+	}
+	var alias ClusterAlias
+	if err := yaml.Unmarshal(data, &alias); err != nil {
+		return nil, err
+	}
+	return &alias, nil
+}
+
+// renamer performs the steps of a rename and remembers what it changed so a
+// failure part way through can be undone
+type renamer struct {
+	ctx      context.Context
+	backend  storage.StorageBackend
+	provider provider.Provider
+	oldName  string
+	newName  string
+	region   string
+
+	copiedKeys     []string                     // Keys written under the new prefix
+	retagged       map[string]map[string]string // Instance ID -> original values of changed tags
+	retaggedGroups bool
+}
+
+// rename holds the controller locks of both names while it runs, and rolls
+// back on failure
+func (r *renamer) rename() error {
+	// Keep the controller away from both names while keys and tags move
+	lockService := r.provider.GetLockService()
+	for _, name := range []string{r.oldName, r.newName} {
+		resourceID := fmt.Sprintf("cluster-%s", name)
+		token, err := lockService.AcquireLock(r.ctx, resourceID, "goman-rename", renameLockTTL)
+		if err != nil {
+			return fmt.Errorf("cluster %s is being reconciled, try again shortly: %w", name, err)
+		}
+		defer lockService.ReleaseLock(context.Background(), resourceID, token)
+	}
+
+	if err := r.run(); err != nil {
+		r.rollback()
+		return err
+	}
+	return nil
+}
+
+func (r *renamer) run() error {
+	oldPrefix := fmt.Sprintf("clusters/%s/", r.oldName)
+	newPrefix := fmt.Sprintf("clusters/%s/", r.newName)
+
+	keys, err := r.backend.ListObjects(oldPrefix)
+	if err != nil {
+		return fmt.Errorf("failed to list cluster objects: %w", err)
+	}
+
+	// Copy everything except config.yaml, which is written last
+	configKey := storage.ClusterConfigKey(r.oldName)
+	for _, key := range keys {
+		if key == configKey {
+			continue
+		}
+		data, err := r.backend.GetObject(key)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", key, err)
+		}
+		if key == storage.ClusterStatusKey(r.oldName) {
+			if data, err = renameInstanceNames(data, r.oldName, r.newName); err != nil {
+				return fmt.Errorf("failed to rewrite cluster status: %w", err)
+			}
+		}
+		newKey := newPrefix + strings.TrimPrefix(key, oldPrefix)
+		if err := r.backend.PutObject(newKey, data); err != nil {
+			return fmt.Errorf("failed to write %s: %w", newKey, err)
+		}
+		r.copiedKeys = append(r.copiedKeys, newKey)
+	}
+
+	if err := r.retagInstances(); err != nil {
+		return err
+	}
+
+	// Providers without per-cluster groups or identities have nothing more
+	// to move
+	if cloud, ok := r.provider.(provider.ClusterRenamer); ok {
+		if _, err := cloud.RenameClusterSecurityGroups(r.ctx, r.region, r.oldName, r.newName); err != nil {
+			return err
+		}
+		r.retaggedGroups = true
+
+		if err := cloud.RenameClusterIdentity(r.ctx, r.oldName, r.newName); err != nil {
+			return err
+		}
+	}
+
+	// Write the config under the new name; this is the commit point
+	configData, err := r.backend.GetObject(configKey)
+	if err != nil {
+		return fmt.Errorf("failed to read cluster config: %w", err)
+	}
+	var cfg storage.ClusterConfig
+	if err := yaml.Unmarshal(configData, &cfg); err != nil {
+		return fmt.Errorf("failed to parse cluster config: %w", err)
+	}
+	cfg.Metadata.Name = r.newName
+	cfg.Metadata.UpdatedAt = time.Now()
+	if cfg.Metadata.Annotations == nil {
+		cfg.Metadata.Annotations = map[string]string{}
+	}
+	legacy := r.oldName
+	if prev := cfg.Metadata.Annotations[storage.LegacyNamesAnnotation]; prev != "" {
+		legacy = prev + "," + r.oldName
+	}
+	cfg.Metadata.Annotations[storage.LegacyNamesAnnotation] = legacy
+
+	configData, err = yaml.Marshal(&cfg)
+	if err != nil {
+		return fmt.Errorf("failed to marshal cluster config: %w", err)
+	}
+	if err := r.backend.PutObject(storage.ClusterConfigKey(r.newName), configData); err != nil {
+		return fmt.Errorf("failed to write cluster config: %w", err)
+	}
+
+	// Past the commit point failures are logged, not rolled back
+	now := time.Now()
+	aliasData, _ := yaml.Marshal(&ClusterAlias{From: r.oldName, To: r.newName, RenamedAt: now, ExpiresAt: now.Add(aliasTTL)})
+	if err := r.backend.PutObject(storage.AliasKey(r.oldName), aliasData); err != nil {
+		logger.Printf("Warning: failed to write alias %s -> %s: %v", r.oldName, r.newName, err)
+	}
+
+	// Remove the old config first so the old name stops reconciling
+	if err := r.backend.DeleteObject(configKey); err != nil {
+		logger.Printf("Warning: failed to delete %s: %v", configKey, err)
+	}
+	for _, key := range keys {
+		if key == configKey {
+			continue
+		}
+		if err := r.backend.DeleteObject(key); err != nil {
+			logger.Printf("Warning: failed to delete %s: %v", key, err)
+		}
+	}
+	return nil
+}
+
+// retagInstances moves the cluster's instances to the new name
+func (r *renamer) retagInstances() error {
+	compute := r.provider.GetComputeService()
+	instances, err := compute.ListInstances(r.ctx, map[string]string{
+		"tag:goman-cluster":   r.oldName,
+		"instance-state-name": "pending,running,stopping,stopped",
+		"region":              r.region,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to list cluster instances: %w", err)
+	}
+
+	r.retagged = make(map[string]map[string]string)
+	for _, inst := range instances {
+		tags := map[string]string{
+			"goman-cluster":        r.newName,
+			"goman-legacy-cluster": r.oldName,
+		}
+		original := map[string]string{"goman-cluster": r.oldName}
+		if v, ok := inst.Tags["Cluster"]; ok {
+			tags["Cluster"] = r.newName
+			original["Cluster"] = v
+		}
+		if strings.HasPrefix(inst.Name, r.oldName+"-") {
+			tags["Name"] = r.newName + strings.TrimPrefix(inst.Name, r.oldName)
+			original["Name"] = inst.Name
+		}
+		if err := compute.TagInstance(r.ctx, inst.ID, tags); err != nil {
+			return err
+		}
+		r.retagged[inst.ID] = original
+	}
+	return nil
+}
+
+// rollback undoes the steps completed before a failure
+func (r *renamer) rollback() {
+	logger.Printf("Rolling back rename of cluster %s to %s", r.oldName, r.newName)
+
+	if cloud, ok := r.provider.(provider.ClusterRenamer); ok && r.retaggedGroups {
+		if _, err := cloud.RenameClusterSecurityGroups(r.ctx, r.region, r.newName, r.oldName); err != nil {
+			logger.Printf("Warning: failed to restore security group tags: %v", err)
+		}
+	}
+
+	compute := r.provider.GetComputeService()
+	for id, tags := range r.retagged {
+		if err := compute.TagInstance(r.ctx, id, tags); err != nil {
+			logger.Printf("Warning: failed to restore tags on %s: %v", id, err)
+		}
+	}
+
+	for _, key := range r.copiedKeys {
+		if err := r.backend.DeleteObject(key); err != nil {
+			logger.Printf("Warning: failed to delete %s: %v", key, err)
+		}
+	}
+}
+
+// renameInstanceNames rewrites instance names (<cluster>-master-N,
+// <cluster>-worker-...) in a YAML document to the new cluster name
+func renameInstanceNames(data []byte, oldName, newName string) ([]byte, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+
+	var walk func(n *yaml.Node)
+	walk = func(n *yaml.Node) {
+		if n.Kind == yaml.ScalarNode {
+			for _, role := range []string{"-master-", "-worker-"} {
+				if strings.HasPrefix(n.Value, oldName+role) {
+					n.Value = newName + strings.TrimPrefix(n.Value, oldName)
+					break
+				}
+			}
+		}
+		for _, child := range n.Content {
+			walk(child)
+		}
+	}
+	walk(&doc)
+
+	return yaml.Marshal(&doc)
+}
@@ -0,0 +1,226 @@
+package cluster
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/madhouselabs/goman/pkg/provider"
+	"github.com/madhouselabs/goman/pkg/storage"
+	"gopkg.in/yaml.v3"
+)
+
+// renameLog records the calls of a rename in order
+type renameLog struct {
+	calls []string
+}
+
+func (l *renameLog) add(format string, args ...interface{}) {
+	l.calls = append(l.calls, fmt.Sprintf(format, args...))
+}
+
+// index returns the position of the first call, -1 if it was not made
+func (l *renameLog) index(call string) int {
+	return slices.Index(l.calls, call)
+}
+
+// renameBackend keeps objects in memory
+type renameBackend struct {
+	storage.StorageBackend
+	log     *renameLog
+	objects map[string][]byte
+}
+
+func (b *renameBackend) GetObject(key string) ([]byte, error) {
+	data, ok := b.objects[key]
+	if !ok {
+		return nil, fmt.Errorf("NoSuchKey: %s", key)
+	}
+	return data, nil
+}
+
+func (b *renameBackend) PutObject(key string, data []byte) error {
+	b.log.add("put %s", key)
+	b.objects[key] = data
+	return nil
+}
+
+func (b *renameBackend) DeleteObject(key string) error {
+	b.log.add("delete %s", key)
+	delete(b.objects, key)
+	return nil
+}
+
+func (b *renameBackend) ListObjects(prefix string) ([]string, error) {
+	var keys []string
+	for key := range b.objects {
+		if strings.HasPrefix(key, prefix) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys, nil
+}
+
+type renameLocks struct {
+	provider.LockService
+	log *renameLog
+}
+
+func (l *renameLocks) AcquireLock(ctx context.Context, resourceID, owner string, ttl time.Duration) (string, error) {
+	l.log.add("lock %s", resourceID)
+	return "token", nil
+}
+
+func (l *renameLocks) ReleaseLock(ctx context.Context, resourceID, token string) error {
+	l.log.add("unlock %s", resourceID)
+	return nil
+}
+
+type renameCompute struct {
+	provider.ComputeService
+	log  *renameLog
+	tags map[string]string
+}
+
+func (c *renameCompute) ListInstances(ctx context.Context, filters map[string]string) ([]*provider.Instance, error) {
+	if filters["tag:goman-cluster"] != c.tags["goman-cluster"] {
+		return nil, nil
+	}
+	return []*provider.Instance{{ID: "i-1", Name: c.tags["Name"], Tags: c.tags}}, nil
+}
+
+func (c *renameCompute) TagInstance(ctx context.Context, instanceID string, tags map[string]string) error {
+	c.log.add("tag %s %s", instanceID, tags["goman-cluster"])
+	for k, v := range tags {
+		c.tags[k] = v
+	}
+	return nil
+}
+
+// renameProvider moves groups and identities, failing the identity when
+// identityErr is set
+type renameProvider struct {
+	provider.Provider
+	log         *renameLog
+	compute     *renameCompute
+	identityErr error
+}
+
+func (p *renameProvider) GetComputeService() provider.ComputeService { return p.compute }
+func (p *renameProvider) GetLockService() provider.LockService       { return &renameLocks{log: p.log} }
+
+func (p *renameProvider) RenameClusterSecurityGroups(ctx context.Context, region, oldName, newName string) ([]string, error) {
+	p.log.add("groups %s -> %s", oldName, newName)
+	return []string{"sg-1"}, nil
+}
+
+func (p *renameProvider) RenameClusterIdentity(ctx context.Context, oldName, newName string) error {
+	p.log.add("identity %s -> %s", oldName, newName)
+	return p.identityErr
+}
+
+func TestRename(t *testing.T) {
+	tests := []struct {
+		name        string
+		identityErr error
+	}{
+		{"renamed", nil},
+		{"rolled back", errors.New("AccessDenied")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			log := &renameLog{}
+			backend := &renameBackend{log: log, objects: map[string][]byte{
+				"clusters/old/config.yaml":   []byte("metadata:\n  name: old\n  annotations:\n    goman.io/legacy-names: first\n"),
+				"clusters/old/status.yaml":   []byte("instances:\n  - name: old-master-0\n"),
+				"clusters/old/events.json":   []byte("[]"),
+				"clusters/other/config.yaml": []byte("metadata:\n  name: other\n"),
+			}}
+			compute := &renameCompute{log: log, tags: map[string]string{"goman-cluster": "old", "Name": "old-master-0"}}
+			r := &renamer{
+				ctx:      context.Background(),
+				backend:  backend,
+				provider: &renameProvider{log: log, compute: compute, identityErr: tt.identityErr},
+				oldName:  "old",
+				newName:  "new",
+				region:   "eu-west-1",
+			}
+
+			err := r.rename()
+			if (err != nil) != (tt.identityErr != nil) {
+				t.Fatalf("rename: %v", err)
+			}
+
+			// Both names are locked before anything moves and unlocked last
+			if log.index("lock cluster-old") != 0 || log.index("lock cluster-new") != 1 {
+				t.Errorf("locks not taken first: %v", log.calls)
+			}
+			if last := log.calls[len(log.calls)-2:]; !slices.Contains(last, "unlock cluster-old") || !slices.Contains(last, "unlock cluster-new") {
+				t.Errorf("locks not released last: %v", log.calls)
+			}
+
+			if tt.identityErr != nil {
+				if _, ok := backend.objects["clusters/new/config.yaml"]; ok {
+					t.Error("config written despite the failure")
+				}
+				for key := range backend.objects {
+					if strings.HasPrefix(key, "clusters/new/") || strings.HasPrefix(key, storage.AliasPrefix) {
+						t.Errorf("%s left behind", key)
+					}
+				}
+				if compute.tags["goman-cluster"] != "old" || compute.tags["Name"] != "old-master-0" {
+					t.Errorf("instance tags not restored: %v", compute.tags)
+				}
+				if log.index("groups new -> old") < 0 {
+					t.Errorf("groups not restored: %v", log.calls)
+				}
+				return
+			}
+
+			// The config is the commit point: written after every other
+			// object and the cloud resources, before the alias and deletes
+			configAt := log.index("put clusters/new/config.yaml")
+			for _, call := range []string{"put clusters/new/status.yaml", "put clusters/new/events.json", "tag i-1 new", "groups old -> new", "identity old -> new"} {
+				if at := log.index(call); at < 0 || at > configAt {
+					t.Errorf("%q not before the config: %v", call, log.calls)
+				}
+			}
+			for _, call := range []string{"put " + storage.AliasKey("old"), "delete clusters/old/config.yaml", "delete clusters/old/status.yaml"} {
+				if at := log.index(call); at < configAt {
+					t.Errorf("%q not after the config: %v", call, log.calls)
+				}
+			}
+			if log.index("delete clusters/old/config.yaml") > log.index("delete clusters/old/status.yaml") {
+				t.Errorf("old config not deleted first: %v", log.calls)
+			}
+
+			var cfg storage.ClusterConfig
+			if err := yaml.Unmarshal(backend.objects["clusters/new/config.yaml"], &cfg); err != nil {
+				t.Fatal(err)
+			}
+			if cfg.Metadata.Name != "new" || !slices.Equal(storage.LegacyNames(cfg.Metadata.Annotations), []string{"first", "old"}) {
+				t.Errorf("config metadata %+v", cfg.Metadata)
+			}
+			if !strings.Contains(string(backend.objects["clusters/new/status.yaml"]), "new-master-0") {
+				t.Errorf("status not rewritten: %s", backend.objects["clusters/new/status.yaml"])
+			}
+
+			var alias ClusterAlias
+			if err := yaml.Unmarshal(backend.objects[storage.AliasKey("old")], &alias); err != nil {
+				t.Fatal(err)
+			}
+			if alias.From != "old" || alias.To != "new" || !alias.ExpiresAt.After(time.Now()) {
+				t.Errorf("alias %+v", alias)
+			}
+			if _, ok := backend.objects["clusters/other/config.yaml"]; !ok {
+				t.Error("another cluster's config was touched")
+			}
+		})
+	}
+}
@@ -0,0 +1,38 @@
+package cluster
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/madhouselabs/goman/pkg/audit"
+	"github.com/madhouselabs/goman/pkg/models"
+)
+
+// RequestResync asks the controller to throw away the cluster's status and
+// rebuild it from the live cloud and K3s state, for when the stored status
+// got corrupted or out of sync
+func (m *Manager) RequestResync(clusterName string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for i := range m.clusters {
+		if m.clusters[i].ID == clusterName || m.clusters[i].Name == clusterName {
+			if m.clusters[i].Status == models.StatusDeleting {
+				return fmt.Errorf("cluster %s is being deleted", m.clusters[i].Name)
+			}
+			now := time.Now().UTC().Truncate(time.Second)
+			m.clusters[i].ResyncRequestedAt = &now
+			m.clusters[i].UpdatedAt = time.Now()
+
+			// Save config with the request to trigger Lambda
+			if m.storage != nil {
+				if err := m.saveClusterConfig(m.clusters[i]); err != nil {
+					return fmt.Errorf("failed to save cluster config: %w", err)
+				}
+				m.recordAudit(m.clusters[i].Name, audit.ActionResync, []string{"status resync requested"})
+			}
+			return nil
+		}
+	}
+	return fmt.Errorf("cluster not found: %s", clusterName)
+}
@@ -0,0 +1,81 @@
+package cluster
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/madhouselabs/goman/pkg/audit"
+	"github.com/madhouselabs/goman/pkg/models"
+	"github.com/madhouselabs/goman/pkg/storage"
+	"gopkg.in/yaml.v3"
+)
+
+// PauseRollout asks the controller to stop node pool rollouts of a cluster
+// once the current batch has finished
+func (m *Manager) PauseRollout(clusterName string) error {
+	return m.updateRolloutSpec(clusterName, "paused", func(spec *models.RolloutSpec) {
+		spec.Paused = true
+	})
+}
+
+// ResumeRollout continues a paused or halted rollout. A batchSize above zero
+// changes how many nodes are replaced at once from the next batch on.
+func (m *Manager) ResumeRollout(clusterName string, batchSize int) error {
+	return m.updateRolloutSpec(clusterName, "resumed", func(spec *models.RolloutSpec) {
+		now := time.Now().UTC().Truncate(time.Second)
+		spec.Paused = false
+		spec.ResumedAt = &now
+		if batchSize > 0 {
+			spec.BatchSize = batchSize
+		}
+	})
+}
+
+// updateRolloutSpec changes the rollout spec of a cluster and saves its config
+func (m *Manager) updateRolloutSpec(clusterName, change string, update func(spec *models.RolloutSpec)) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for i := range m.clusters {
+		if m.clusters[i].ID == clusterName || m.clusters[i].Name == clusterName {
+			spec := models.RolloutSpec{}
+			if m.clusters[i].Rollout != nil {
+				spec = *m.clusters[i].Rollout
+			}
+			update(&spec)
+			m.clusters[i].Rollout = &spec
+			m.clusters[i].UpdatedAt = time.Now()
+
+			// Save config with the new rollout spec to trigger Lambda
+			if m.storage != nil {
+				if err := m.saveClusterConfig(m.clusters[i]); err != nil {
+					return fmt.Errorf("failed to save cluster config: %w", err)
+				}
+				m.recordAudit(m.clusters[i].Name, audit.ActionRollout, []string{fmt.Sprintf("rollout: %s", change)})
+			}
+			return nil
+		}
+	}
+	return fmt.Errorf("cluster not found: %s", clusterName)
+}
+
+// RolloutStatus returns the current or last node pool rollout of a cluster
+// as reported by the controller, or nil if there has been none
+func (m *Manager) RolloutStatus(clusterName string) (*models.RolloutStatus, error) {
+	if m.storage == nil {
+		return nil, fmt.Errorf("storage not available")
+	}
+
+	data, err := m.storage.GetBackend().GetObject(storage.ClusterStatusKey(clusterName))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load cluster status: %w", err)
+	}
+
+	var status struct {
+		Rollout *models.RolloutStatus `yaml:"rollout"`
+	}
+	if err := yaml.Unmarshal(data, &status); err != nil {
+		return nil, fmt.Errorf("failed to parse cluster status: %w", err)
+	}
+	return status.Rollout, nil
+}
@@ -0,0 +1,38 @@
+package cluster
+
+import (
+	"fmt"
+
+	"github.com/madhouselabs/goman/pkg/audit"
+	"github.com/madhouselabs/goman/pkg/provider"
+	"gopkg.in/yaml.v3"
+)
+
+// SessionLogging returns the stored session logging settings; logging is
+// off when none were configured
+func (m *Manager) SessionLogging() provider.SessionLoggingConfig {
+	var cfg provider.SessionLoggingConfig
+	if m.storage == nil {
+		return cfg
+	}
+	data, err := m.storage.GetBackend().GetObject(provider.SessionLoggingKey)
+	if err != nil {
+		return cfg
+	}
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return provider.SessionLoggingConfig{}
+	}
+	return cfg
+}
+
+// RecordTunnel records a tunnel opened to a cluster in the audit trail when
+// session logging is enabled. SSM does not log the content of port
+// forwarding sessions, so this is the record of who connected and when.
+func (m *Manager) RecordTunnel(clusterName, instanceID, region string) {
+	if !m.SessionLogging().Enabled() {
+		return
+	}
+	m.recordAudit(clusterName, audit.ActionTunnel, []string{
+		fmt.Sprintf("port forwarding session to %s (%s)", instanceID, region),
+	})
+}
@@ -0,0 +1,33 @@
+package cluster
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/madhouselabs/goman/pkg/audit"
+)
+
+// RequestRetag asks the controller to re-apply the cluster's tags to all of
+// its existing resources, including ones tagged by hand in the meantime
+func (m *Manager) RequestRetag(clusterName string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for i := range m.clusters {
+		if m.clusters[i].ID == clusterName || m.clusters[i].Name == clusterName {
+			now := time.Now().UTC().Truncate(time.Second)
+			m.clusters[i].RetagRequestedAt = &now
+			m.clusters[i].UpdatedAt = time.Now()
+
+			// Save config with the request to trigger Lambda
+			if m.storage != nil {
+				if err := m.saveClusterConfig(m.clusters[i]); err != nil {
+					return fmt.Errorf("failed to save cluster config: %w", err)
+				}
+				m.recordAudit(m.clusters[i].Name, audit.ActionRetag, []string{fmt.Sprintf("retag requested (%d tags)", len(m.clusters[i].Tags))})
+			}
+			return nil
+		}
+	}
+	return fmt.Errorf("cluster not found: %s", clusterName)
+}
@@ -0,0 +1,64 @@
+package cluster
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/madhouselabs/goman/pkg/audit"
+	"github.com/madhouselabs/goman/pkg/models"
+	"github.com/madhouselabs/goman/pkg/storage"
+	"gopkg.in/yaml.v3"
+)
+
+// UpgradeK3s sets the K3s version of a cluster. The controller then upgrades
+// its nodes one at a time, masters first.
+func (m *Manager) UpgradeK3s(clusterName, version string) error {
+	if err := models.ValidateK3sVersion(version); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for i := range m.clusters {
+		if m.clusters[i].ID == clusterName || m.clusters[i].Name == clusterName {
+			if m.clusters[i].Status == models.StatusDeleting {
+				return fmt.Errorf("%w: %s", ErrClusterDeleting, m.clusters[i].Name)
+			}
+			before := m.clusters[i]
+			m.clusters[i].K3sVersion = version
+			m.clusters[i].UpdatedAt = time.Now()
+
+			// Save config with the new version to trigger Lambda
+			if m.storage != nil {
+				if err := m.saveClusterConfig(m.clusters[i]); err != nil {
+					return fmt.Errorf("failed to save cluster config: %w", err)
+				}
+				m.recordAudit(m.clusters[i].Name, audit.ActionUpgrade, audit.Diff(before, m.clusters[i]))
+			}
+			return nil
+		}
+	}
+	return fmt.Errorf("cluster not found: %s", clusterName)
+}
+
+// UpgradeStatus returns the current or last K3s upgrade of a cluster as
+// reported by the controller, or nil if there has been none
+func (m *Manager) UpgradeStatus(clusterName string) (*models.UpgradeStatus, error) {
+	if m.storage == nil {
+		return nil, fmt.Errorf("storage not available")
+	}
+
+	data, err := m.storage.GetBackend().GetObject(storage.ClusterStatusKey(clusterName))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load cluster status: %w", err)
+	}
+
+	var status struct {
+		Upgrade *models.UpgradeStatus `yaml:"upgrade"`
+	}
+	if err := yaml.Unmarshal(data, &status); err != nil {
+		return nil, fmt.Errorf("failed to parse cluster status: %w", err)
+	}
+	return status.Upgrade, nil
+}
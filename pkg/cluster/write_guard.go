@@ -0,0 +1,101 @@
+package cluster
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/madhouselabs/goman/pkg/logger"
+	"github.com/madhouselabs/goman/pkg/storage"
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	// ErrClusterDeleting is returned when a spec write targets a cluster
+	// whose deletion has been requested
+	ErrClusterDeleting = errors.New("cluster is being deleted")
+
+	// ErrClusterDeleted is returned when a spec write targets a cluster
+	// whose config no longer exists
+	ErrClusterDeleted = errors.New("cluster has been deleted")
+
+	// ErrNameInUse is returned when creating a cluster whose name is still
+	// held by a cluster that is being deleted
+	ErrNameInUse = errors.New("cluster name is still in use")
+)
+
+// guardSpecWrite checks the stored config before a write to an existing
+// cluster. Writes are rejected once deletion was requested, and when the
+// config is already gone, so a stale in-memory copy cannot resurrect a
+// cluster the controller is tearing down.
+func (m *Manager) guardSpecWrite(clusterName string) error {
+	if m.storage == nil {
+		return nil
+	}
+
+	data, err := m.storage.GetBackend().GetObject(storage.ClusterConfigKey(clusterName))
+	if err != nil {
+		if isNotFound(err) {
+			return fmt.Errorf("%w: %s no longer exists; changes were not saved", ErrClusterDeleted, clusterName)
+		}
+		return fmt.Errorf("failed to check cluster config: %w", err)
+	}
+
+	var config storage.ClusterConfig
+	if err := yaml.Unmarshal(data, &config); err != nil {
+		return fmt.Errorf("failed to parse cluster config: %w", err)
+	}
+	if config.Metadata.DeletionTimestamp != nil {
+		return fmt.Errorf("%w: %s was marked for deletion at %s; changes were not saved",
+			ErrClusterDeleting, clusterName, config.Metadata.DeletionTimestamp.Local().Format("15:04:05"))
+	}
+	return nil
+}
+
+// checkNameAvailable rejects creating a cluster while a previous cluster with
+// the same name is still being deleted
+func (m *Manager) checkNameAvailable(clusterName string) error {
+	if m.storage == nil {
+		return nil
+	}
+	backend := m.storage.GetBackend()
+
+	if data, err := backend.GetObject(storage.TombstoneKey(clusterName)); err == nil {
+		var tombstone storage.Tombstone
+		if err := yaml.Unmarshal(data, &tombstone); err == nil && !tombstone.Expired() {
+			return fmt.Errorf("%w: %s was deleted at %s and its resources are still being cleaned up",
+				ErrNameInUse, clusterName, tombstone.DeletedAt.Local().Format("15:04:05"))
+		}
+	}
+
+	if data, err := backend.GetObject(storage.ClusterConfigKey(clusterName)); err == nil {
+		var config storage.ClusterConfig
+		if err := yaml.Unmarshal(data, &config); err == nil && config.Metadata.DeletionTimestamp != nil {
+			return fmt.Errorf("%w: %s is still being deleted", ErrNameInUse, clusterName)
+		}
+	}
+	return nil
+}
+
+// writeTombstone records that a cluster name is being deleted. Failures are
+// logged; the deletion itself has already been requested.
+func (m *Manager) writeTombstone(clusterName, clusterID string) {
+	data, err := yaml.Marshal(&storage.Tombstone{
+		Cluster:   clusterName,
+		ClusterID: clusterID,
+		DeletedAt: time.Now(),
+	})
+	if err == nil {
+		err = m.storage.GetBackend().PutObject(storage.TombstoneKey(clusterName), data)
+	}
+	if err != nil {
+		logger.Printf("Warning: failed to write tombstone for %s: %v", clusterName, err)
+	}
+}
+
+// isNotFound reports whether a storage error means the object does not exist
+func isNotFound(err error) bool {
+	msg := err.Error()
+	return strings.Contains(msg, "not found") || strings.Contains(msg, "NoSuchKey")
+}
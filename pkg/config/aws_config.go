@@ -1,6 +1,11 @@
 package config
 
-import "os"
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
 
 const (
 	// DefaultAWSRegion is the standard region for all AWS operations (Mumbai, India)
@@ -52,3 +57,65 @@ func GetProviderCredentials(providerType string) string {
 func GetAWSProfile() string {
 	return GetProviderCredentials("aws")
 }
+
+// GetStateBucket returns the name of the goman state bucket for an account.
+// GOMAN_STATE_BUCKET selects a customer-provided bucket, e.g. one created
+// ahead of time to follow organization naming conventions or bucket policies.
+func GetStateBucket(accountID string) string {
+	if bucket := os.Getenv("GOMAN_STATE_BUCKET"); bucket != "" {
+		return bucket
+	}
+	return fmt.Sprintf("goman-%s", accountID)
+}
+
+// GetAWSPartition returns the ARN partition of a region: aws-us-gov for
+// GovCloud, aws-cn for China and aws everywhere else. GOMAN_AWS_PARTITION
+// overrides it, e.g. for emulators that use their own region names.
+func GetAWSPartition(region string) string {
+	if partition := os.Getenv("GOMAN_AWS_PARTITION"); partition != "" {
+		return partition
+	}
+	switch {
+	case strings.HasPrefix(region, "us-gov-"):
+		return "aws-us-gov"
+	case strings.HasPrefix(region, "cn-"):
+		return "aws-cn"
+	default:
+		return "aws"
+	}
+}
+
+// GetAWSEndpointURL returns the endpoint all AWS clients are pointed at, as
+// set by the SDK's AWS_ENDPOINT_URL (e.g. http://localhost:4566 for
+// localstack). Service-specific AWS_ENDPOINT_URL_<SERVICE> variables are
+// honored by the SDK as well.
+func GetAWSEndpointURL() string {
+	return os.Getenv("AWS_ENDPOINT_URL")
+}
+
+// S3UsePathStyle reports whether S3 is addressed as <endpoint>/<bucket>
+// rather than <bucket>.<endpoint>. Custom endpoints rarely serve
+// virtual-hosted buckets, so it defaults to on when one is set;
+// GOMAN_S3_PATH_STYLE=true/false decides explicitly.
+func S3UsePathStyle() bool {
+	if v, err := strconv.ParseBool(os.Getenv("GOMAN_S3_PATH_STYLE")); err == nil {
+		return v
+	}
+	return GetAWSEndpointURL() != "" || os.Getenv("AWS_ENDPOINT_URL_S3") != ""
+}
+
+// AWSEndpointEnvironment returns the endpoint and partition overrides that
+// are set, so they can be passed on to the controller function
+func AWSEndpointEnvironment() map[string]string {
+	env := make(map[string]string)
+	for _, kv := range os.Environ() {
+		key, value, _ := strings.Cut(kv, "=")
+		if value == "" {
+			continue
+		}
+		if strings.HasPrefix(key, "AWS_ENDPOINT_URL") || key == "GOMAN_AWS_PARTITION" || key == "GOMAN_S3_PATH_STYLE" {
+			env[key] = value
+		}
+	}
+	return env
+}
@@ -44,6 +44,27 @@ func (c *Config) Save(path string) error {
 	return nil
 }
 
+// FilePath returns the goman config file, ~/.goman/config.yaml unless
+// GOMAN_CONFIG is set. It holds TUI preferences such as the keymap; provider
+// settings come from the environment.
+func FilePath() string {
+	if path := os.Getenv("GOMAN_CONFIG"); path != "" {
+		return path
+	}
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return filepath.Join(".goman", "config.yaml")
+	}
+	return filepath.Join(homeDir, ".goman", "config.yaml")
+}
+
+// GetSecretBackend returns where cluster tokens and kubeconfigs are stored:
+// "s3" (the state bucket, default), "secretsmanager" or "ssm" (Parameter
+// Store SecureString). Set with GOMAN_SECRET_BACKEND.
+func GetSecretBackend() string {
+	return getEnvOrDefault("GOMAN_SECRET_BACKEND", "s3")
+}
+
 // getEnvOrDefault returns environment variable value or default
 func getEnvOrDefault(key, defaultValue string) string {
 	if value := os.Getenv(key); value != "" {
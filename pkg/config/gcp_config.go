@@ -0,0 +1,43 @@
+package config
+
+import (
+	"os"
+	"strings"
+)
+
+// DefaultGCPRegion is the GCP region used when none is configured (Mumbai)
+const DefaultGCPRegion = "asia-south1"
+
+// GetProviderType returns the cloud provider goman manages clusters on: aws
+// (default), gcp, or local for VMs on this machine. Set with GOMAN_PROVIDER,
+// or CLOUD_PROVIDER.
+func GetProviderType() string {
+	for _, key := range []string{"GOMAN_PROVIDER", "CLOUD_PROVIDER"} {
+		if provider := os.Getenv(key); provider != "" {
+			return strings.ToLower(provider)
+		}
+	}
+	return "aws"
+}
+
+// GetGCPProject returns the GCP project goman uses, as set for gcloud and
+// the Google client libraries. Empty means the project of the credentials.
+func GetGCPProject() string {
+	for _, key := range []string{"GOMAN_GCP_PROJECT", "GOOGLE_CLOUD_PROJECT", "GCP_PROJECT", "CLOUDSDK_CORE_PROJECT"} {
+		if project := os.Getenv(key); project != "" {
+			return project
+		}
+	}
+	return ""
+}
+
+// GetGCPRegion returns the GCP region for clusters, GOMAN_GCP_REGION or
+// CLOUDSDK_COMPUTE_REGION, defaulting to asia-south1
+func GetGCPRegion() string {
+	for _, key := range []string{"GOMAN_GCP_REGION", "CLOUDSDK_COMPUTE_REGION"} {
+		if region := os.Getenv(key); region != "" {
+			return region
+		}
+	}
+	return DefaultGCPRegion
+}
@@ -0,0 +1,34 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Drivers the local provider runs nodes with
+const (
+	LocalDriverMultipass = "multipass" // Ubuntu VMs managed by Multipass (default)
+	LocalDriverFake      = "fake"      // No VMs: nodes run instantly and every command succeeds
+)
+
+// GetLocalDir returns where the local provider keeps cluster state, locks and
+// its VM records: GOMAN_LOCAL_DIR, or ~/.goman/local
+func GetLocalDir() string {
+	if dir := os.Getenv("GOMAN_LOCAL_DIR"); dir != "" {
+		return dir
+	}
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return filepath.Join(".goman", "local")
+	}
+	return filepath.Join(homeDir, ".goman", "local")
+}
+
+// GetLocalDriver returns the driver of the local provider, GOMAN_LOCAL_DRIVER
+func GetLocalDriver() string {
+	if driver := os.Getenv("GOMAN_LOCAL_DRIVER"); driver != "" {
+		return strings.ToLower(driver)
+	}
+	return LocalDriverMultipass
+}
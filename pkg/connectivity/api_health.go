@@ -0,0 +1,122 @@
+package connectivity
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// API health states
+const (
+	APIHealthy     = "healthy"     // /readyz answered OK
+	APIDegraded    = "degraded"    // Reachable but not ready, or slow
+	APIUnreachable = "unreachable" // Connection failed or timed out
+	APIUnknown     = "unknown"     // No route to probe (no tunnel or public endpoint)
+)
+
+// SlowAPILatency marks a reachable API server as degraded
+const SlowAPILatency = 1 * time.Second
+
+// APIHealth is the result of probing a cluster's API server
+type APIHealth struct {
+	State     string
+	Latency   time.Duration
+	Via       string // "tunnel" or "public"
+	Error     string
+	CheckedAt time.Time
+}
+
+// APIHealthProber measures API server reachability and latency per cluster
+// and keeps the latest result for display
+type APIHealthProber struct {
+	client  *http.Client
+	mu      sync.RWMutex
+	results map[string]APIHealth
+}
+
+// NewAPIHealthProber creates a prober with the given per-probe timeout
+func NewAPIHealthProber(timeout time.Duration) *APIHealthProber {
+	return &APIHealthProber{
+		client: &http.Client{
+			Timeout: timeout,
+			Transport: &http.Transport{
+				// Only /readyz is requested and no credentials are sent, so
+				// the self-signed K3s serving cert doesn't need verifying
+				TLSClientConfig:   &tls.Config{InsecureSkipVerify: true},
+				DisableKeepAlives: true,
+			},
+		},
+		results: make(map[string]APIHealth),
+	}
+}
+
+// Probe checks an API server endpoint (e.g., https://127.0.0.1:6443) and
+// records the result for the cluster. An empty endpoint records APIUnknown.
+func (p *APIHealthProber) Probe(ctx context.Context, clusterName, endpoint, via string) APIHealth {
+	result := APIHealth{State: APIUnknown, Via: via, CheckedAt: time.Now()}
+	if endpoint != "" {
+		result = p.probe(ctx, endpoint, via)
+	}
+
+	p.mu.Lock()
+	p.results[clusterName] = result
+	p.mu.Unlock()
+	return result
+}
+
+// probe performs a single /readyz request
+func (p *APIHealthProber) probe(ctx context.Context, endpoint, via string) APIHealth {
+	result := APIHealth{Via: via, CheckedAt: time.Now()}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimRight(endpoint, "/")+"/readyz", nil)
+	if err != nil {
+		result.State = APIUnknown
+		result.Error = err.Error()
+		return result
+	}
+
+	start := time.Now()
+	resp, err := p.client.Do(req)
+	result.Latency = time.Since(start)
+	if err != nil {
+		result.State = APIUnreachable
+		result.Error = err.Error()
+		return result
+	}
+	resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusOK:
+		result.State = APIHealthy
+	case resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden:
+		// Anonymous health checks disabled - the server still answered
+		result.State = APIHealthy
+	default:
+		result.State = APIDegraded
+		result.Error = fmt.Sprintf("readyz returned %d", resp.StatusCode)
+	}
+	if result.State == APIHealthy && result.Latency > SlowAPILatency {
+		result.State = APIDegraded
+		result.Error = "slow response"
+	}
+	return result
+}
+
+// Get returns the latest probe result for a cluster
+func (p *APIHealthProber) Get(clusterName string) (APIHealth, bool) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	result, ok := p.results[clusterName]
+	return result, ok
+}
+
+// Forget drops the stored result for a cluster
+func (p *APIHealthProber) Forget(clusterName string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	delete(p.results, clusterName)
+}
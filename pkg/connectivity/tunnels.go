@@ -0,0 +1,525 @@
+package connectivity
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/madhouselabs/goman/pkg/config"
+)
+
+// Local ports tunnels are opened on. Each cluster hashes to a preferred port
+// in the range so it keeps the same one across runs, and the next free port
+// is taken if that one is in use.
+const (
+	TunnelPortBase  = 16443
+	TunnelPortRange = 1000
+)
+
+// APIServerPort is the K3s API server port tunnels forward to
+const APIServerPort = 6443
+
+// maxTunnelRestartFailures is how many restarts of a dead tunnel may fail in
+// a row before it is left down until used again
+const maxTunnelRestartFailures = 3
+
+// TunnelState is a port-forward session to a cluster's API server
+type TunnelState struct {
+	ClusterName string    `json:"cluster_name"`
+	InstanceID  string    `json:"instance_id"`
+	Fallbacks   []string  `json:"fallbacks,omitempty"` // Other masters to use when the instance is unreachable
+	Region      string    `json:"region"`
+	PID         int       `json:"pid"`
+	StartedAt   time.Time `json:"started_at"`
+	LocalPort   int       `json:"local_port"`
+	RemotePort  int       `json:"remote_port"`
+	Restarts    int       `json:"restarts,omitempty"`   // Times restarted after dying
+	Failures    int       `json:"failures,omitempty"`   // Restarts failed in a row
+	LastError   string    `json:"last_error,omitempty"` // Why the last restart failed
+}
+
+// TunnelManager tracks tunnels to any number of clusters in a local
+// state file, so tunnels started by one goman command are found, health
+// checked and stopped by the next
+type TunnelManager struct {
+	stateFile  string
+	legacyFile string
+	mu         sync.Mutex
+
+	// OnTunnelStarted, if set, is called after a new tunnel is established
+	// (not when an existing one is reused), e.g. to record access for audits
+	OnTunnelStarted func(state TunnelState)
+
+	// Out receives progress messages, stdout if nil
+	Out io.Writer
+
+	// startSession starts the port-forward process, StartBackgroundTunnel
+	// if nil
+	startSession func(instanceID, region string, localPort, remotePort int) (int, error)
+}
+
+// NewTunnelManager creates a tunnel manager keeping its state in ~/.goman
+func NewTunnelManager() *TunnelManager {
+	homeDir, _ := os.UserHomeDir()
+	dir := filepath.Join(homeDir, ".goman")
+
+	// Ensure directory exists
+	os.MkdirAll(dir, 0755)
+
+	return &TunnelManager{
+		stateFile:  filepath.Join(dir, "tunnels.json"),
+		legacyFile: filepath.Join(dir, "active-tunnel.json"),
+	}
+}
+
+// printf writes a progress message
+func (tm *TunnelManager) printf(format string, a ...interface{}) {
+	out := tm.Out
+	if out == nil {
+		out = os.Stdout
+	}
+	fmt.Fprintf(out, format, a...)
+}
+
+// load reads the tracked tunnels. The single tunnel of older goman versions
+// is picked up from its own file.
+func (tm *TunnelManager) load() (map[string]*TunnelState, error) {
+	tunnels := make(map[string]*TunnelState)
+	data, err := os.ReadFile(tm.stateFile)
+	if os.IsNotExist(err) {
+		if data, err := os.ReadFile(tm.legacyFile); err == nil {
+			var legacy TunnelState
+			if json.Unmarshal(data, &legacy) == nil && legacy.ClusterName != "" {
+				tunnels[legacy.ClusterName] = &legacy
+			}
+		}
+		return tunnels, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &tunnels); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", tm.stateFile, err)
+	}
+	return tunnels, nil
+}
+
+// save writes the tracked tunnels
+func (tm *TunnelManager) save(tunnels map[string]*TunnelState) error {
+	data, err := json.MarshalIndent(tunnels, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := tm.stateFile + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, tm.stateFile); err != nil {
+		return err
+	}
+	os.Remove(tm.legacyFile)
+	return nil
+}
+
+// List returns the tracked tunnels, dead ones included, sorted by cluster
+func (tm *TunnelManager) List() ([]TunnelState, error) {
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+
+	tunnels, err := tm.load()
+	if err != nil {
+		return nil, err
+	}
+	list := make([]TunnelState, 0, len(tunnels))
+	for _, t := range tunnels {
+		list = append(list, *t)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].ClusterName < list[j].ClusterName })
+	return list, nil
+}
+
+// IsProcessAlive checks if a process is still running
+func (tm *TunnelManager) IsProcessAlive(pid int) bool {
+	if pid <= 0 {
+		return false
+	}
+	return syscall.Kill(pid, syscall.Signal(0)) == nil
+}
+
+// IsPortListening checks if something accepts connections on a local port
+func (tm *TunnelManager) IsPortListening(port int) bool {
+	conn, err := net.DialTimeout("tcp", fmt.Sprintf("127.0.0.1:%d", port), 100*time.Millisecond)
+	if err != nil {
+		return false
+	}
+	conn.Close()
+	return true
+}
+
+// Healthy reports whether a tunnel's process runs and its port accepts
+// connections
+func (tm *TunnelManager) Healthy(t TunnelState) bool {
+	return tm.IsProcessAlive(t.PID) && tm.IsPortListening(t.LocalPort)
+}
+
+// KillProcess stops a tunnel process along with the session-manager-plugin
+// it started. Tunnels run in their own session, so their process group is
+// signalled, which leaves other clusters' tunnels alone.
+func (tm *TunnelManager) KillProcess(pid int) {
+	if pid <= 0 {
+		return
+	}
+	syscall.Kill(-pid, syscall.SIGTERM)
+	for i := 0; i < 10 && tm.IsProcessAlive(pid); i++ {
+		time.Sleep(100 * time.Millisecond)
+	}
+	if tm.IsProcessAlive(pid) {
+		syscall.Kill(-pid, syscall.SIGKILL)
+	}
+}
+
+// EnsureTunnel returns a healthy tunnel to the cluster, starting it or
+// restarting a dead one as needed. The masters of HA clusters are tried in
+// the order given until one is reachable, the first being preferred; a
+// healthy tunnel to any of them is reused.
+func (tm *TunnelManager) EnsureTunnel(clusterName string, instanceIDs []string, region string) (*TunnelState, error) {
+	if len(instanceIDs) == 0 {
+		return nil, fmt.Errorf("no instance to open a tunnel to for cluster %s", clusterName)
+	}
+
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+
+	tunnels, err := tm.load()
+	if err != nil {
+		tm.printf("Warning: Error loading tunnel state: %v\n", err)
+		tunnels = make(map[string]*TunnelState)
+	}
+
+	existing := tunnels[clusterName]
+	if existing != nil {
+		if slices.Contains(instanceIDs, existing.InstanceID) && tm.Healthy(*existing) {
+			existing.Fallbacks = otherInstances(instanceIDs, existing.InstanceID)
+			tm.printf("Reusing existing tunnel for cluster %s on port %d (PID: %d)\n", clusterName, existing.LocalPort, existing.PID)
+			return existing, nil
+		}
+		tm.printf("Existing tunnel for cluster %s is dead or points at another instance, restarting\n", clusterName)
+		tm.KillProcess(existing.PID)
+	}
+
+	state, err := tm.startAny(tunnels, clusterName, instanceIDs, region)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil && slices.Contains(instanceIDs, existing.InstanceID) {
+		state.Restarts++
+	}
+	state.Failures = 0
+	state.LastError = ""
+	if err := tm.save(tunnels); err != nil {
+		tm.printf("Warning: Failed to save tunnel state: %v\n", err)
+	}
+	return state, nil
+}
+
+// startAny opens a tunnel to the first of the instances that is reachable
+func (tm *TunnelManager) startAny(tunnels map[string]*TunnelState, clusterName string, instanceIDs []string, region string) (*TunnelState, error) {
+	var errs []error
+	for i, id := range instanceIDs {
+		state, err := tm.start(tunnels, clusterName, id, region)
+		if err == nil {
+			state.Fallbacks = otherInstances(instanceIDs, id)
+			return state, nil
+		}
+		errs = append(errs, fmt.Errorf("%s: %w", id, err))
+		if i < len(instanceIDs)-1 {
+			tm.printf("Master %s of cluster %s is unreachable (%v), trying the next one\n", id, clusterName, err)
+		}
+	}
+	return nil, errors.Join(errs...)
+}
+
+// otherInstances returns the instances but one, in order
+func otherInstances(instanceIDs []string, id string) []string {
+	var others []string
+	for _, other := range instanceIDs {
+		if other != id {
+			others = append(others, other)
+		}
+	}
+	return others
+}
+
+// start opens a tunnel for the cluster on its local port and records it in
+// tunnels. A previous tunnel to the cluster must already be stopped.
+func (tm *TunnelManager) start(tunnels map[string]*TunnelState, clusterName, instanceID, region string) (*TunnelState, error) {
+	state := &TunnelState{ClusterName: clusterName, RemotePort: APIServerPort}
+	if previous := tunnels[clusterName]; previous != nil {
+		*state = *previous
+	}
+	state.InstanceID = instanceID
+	state.Region = region
+
+	// Keep the port the cluster had, so clients pointed at it keep working
+	taken := make(map[int]bool)
+	for name, t := range tunnels {
+		if name != clusterName {
+			taken[t.LocalPort] = true
+		}
+	}
+	if state.LocalPort == 0 || taken[state.LocalPort] || !portAvailable(state.LocalPort) {
+		state.LocalPort = LocalTunnelPort(clusterName, taken, portAvailable)
+		if state.LocalPort == 0 {
+			return nil, fmt.Errorf("no free local port between %d and %d", TunnelPortBase, TunnelPortBase+TunnelPortRange-1)
+		}
+	}
+
+	tm.printf("Starting new tunnel for cluster %s on port %d...\n", clusterName, state.LocalPort)
+	startSession := tm.startSession
+	if startSession == nil {
+		startSession = tm.StartBackgroundTunnel
+	}
+	pid, err := startSession(instanceID, region, state.LocalPort, state.RemotePort)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start tunnel: %w", err)
+	}
+	state.PID = pid
+	state.StartedAt = time.Now()
+
+	// Wait for tunnel to be established
+	tm.printf("Waiting for tunnel to establish...")
+	for i := 0; i < 50; i++ {
+		if tm.IsPortListening(state.LocalPort) {
+			tm.printf("\n✅ Tunnel established for cluster %s on port %d (PID: %d)\n", clusterName, state.LocalPort, pid)
+			tunnels[clusterName] = state
+			if tm.OnTunnelStarted != nil {
+				tm.OnTunnelStarted(*state)
+			}
+			return state, nil
+		}
+		if i%5 == 0 {
+			tm.printf(".")
+		}
+		time.Sleep(200 * time.Millisecond)
+	}
+
+	// Tunnel failed to establish
+	tm.printf("\n")
+	tm.KillProcess(pid)
+	return nil, fmt.Errorf("tunnel failed to establish after 10 seconds")
+}
+
+// RestartDead restarts tracked tunnels whose process died or whose port
+// stopped answering, and returns the clusters whose tunnel was restarted.
+// A tunnel moves to another master of its cluster when its own is
+// unreachable. One whose restarts keep failing is left down until it is used
+// again.
+func (tm *TunnelManager) RestartDead() ([]string, error) {
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+
+	tunnels, err := tm.load()
+	if err != nil {
+		return nil, err
+	}
+
+	var restarted []string
+	changed := false
+	for name, t := range tunnels {
+		if t.Failures >= maxTunnelRestartFailures || tm.Healthy(*t) {
+			continue
+		}
+		tm.KillProcess(t.PID)
+		changed = true
+		if _, err := tm.startAny(tunnels, name, append([]string{t.InstanceID}, t.Fallbacks...), t.Region); err != nil {
+			t.PID = 0
+			t.Failures++
+			t.LastError = err.Error()
+			continue
+		}
+		tunnels[name].Restarts++
+		tunnels[name].Failures = 0
+		tunnels[name].LastError = ""
+		restarted = append(restarted, name)
+	}
+	if changed {
+		if err := tm.save(tunnels); err != nil {
+			return restarted, err
+		}
+	}
+	sort.Strings(restarted)
+	return restarted, nil
+}
+
+// StartBackgroundTunnel starts a port-forward session to an instance of the
+// configured provider as a background process in its own session, so it
+// outlives the goman command
+func (tm *TunnelManager) StartBackgroundTunnel(instanceID, region string, localPort, remotePort int) (int, error) {
+	cmd, err := tunnelCommand(config.GetProviderType(), instanceID, region, localPort, remotePort)
+	if err != nil {
+		return 0, err
+	}
+	cmd.SysProcAttr = &syscall.SysProcAttr{
+		Setsid: true, // Create new session (detach from terminal)
+	}
+
+	// Redirect output to /dev/null to prevent blocking
+	devNull, err := os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+	if err != nil {
+		return 0, fmt.Errorf("failed to open %s: %w", os.DevNull, err)
+	}
+	defer devNull.Close()
+	cmd.Stdout = devNull
+	cmd.Stderr = devNull
+
+	if err := cmd.Start(); err != nil {
+		return 0, fmt.Errorf("failed to start %s: %w", filepath.Base(cmd.Path), err)
+	}
+	// The process is checked by PID from now on; reap it when it exits so a
+	// long-running goman doesn't keep it as a zombie that looks alive
+	go cmd.Wait()
+	return cmd.Process.Pid, nil
+}
+
+// tunnelCommand returns the command forwarding localPort to remotePort of
+// an instance: an SSM session on AWS, an IAP tunnel on GCP, whose instance
+// IDs are <zone>/<name>
+func tunnelCommand(providerType, instanceID, region string, localPort, remotePort int) (*exec.Cmd, error) {
+	switch providerType {
+	case "aws":
+		args := []string{
+			"ssm", "start-session",
+			"--target", instanceID,
+			"--document-name", "AWS-StartPortForwardingSession",
+			"--parameters", fmt.Sprintf(`{"portNumber":["%d"],"localPortNumber":["%d"]}`, remotePort, localPort),
+		}
+		if region != "" {
+			args = append(args, "--region", region)
+		}
+		return exec.Command("aws", args...), nil
+	case "gcp":
+		zone, name, ok := strings.Cut(instanceID, "/")
+		if !ok || zone == "" || name == "" {
+			return nil, fmt.Errorf("invalid instance ID %q, expected <zone>/<name>", instanceID)
+		}
+		args := []string{
+			"compute", "start-iap-tunnel", name, fmt.Sprintf("%d", remotePort),
+			"--local-host-port", fmt.Sprintf("localhost:%d", localPort),
+			"--zone", zone,
+		}
+		if project := config.GetGCPProject(); project != "" {
+			args = append(args, "--project", project)
+		}
+		return exec.Command("gcloud", args...), nil
+	default:
+		return nil, fmt.Errorf("tunnels to %s instances are not supported", providerType)
+	}
+}
+
+// StopTunnel stops the tunnel to a cluster and forgets it
+func (tm *TunnelManager) StopTunnel(clusterName string) error {
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+
+	tunnels, err := tm.load()
+	if err != nil {
+		return err
+	}
+	t := tunnels[clusterName]
+	if t == nil {
+		return fmt.Errorf("no tunnel to cluster %s", clusterName)
+	}
+	tm.KillProcess(t.PID)
+	delete(tunnels, clusterName)
+	return tm.save(tunnels)
+}
+
+// StopAll stops every tracked tunnel and returns the clusters they were for
+func (tm *TunnelManager) StopAll() ([]string, error) {
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+
+	tunnels, err := tm.load()
+	if err != nil {
+		return nil, err
+	}
+	var stopped []string
+	for name, t := range tunnels {
+		tm.KillProcess(t.PID)
+		stopped = append(stopped, name)
+	}
+	sort.Strings(stopped)
+	return stopped, tm.save(map[string]*TunnelState{})
+}
+
+// IsConnected checks if there's a healthy tunnel to the cluster
+func (tm *TunnelManager) IsConnected(clusterName string) bool {
+	t := tm.GetTunnelInfo(clusterName)
+	return t != nil && tm.IsPortListening(t.LocalPort)
+}
+
+// GetTunnelInfo returns the cluster's tunnel if its process is alive
+func (tm *TunnelManager) GetTunnelInfo(clusterName string) *TunnelState {
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+
+	tunnels, err := tm.load()
+	if err != nil {
+		return nil
+	}
+	t := tunnels[clusterName]
+	if t == nil || !tm.IsProcessAlive(t.PID) {
+		return nil
+	}
+	return t
+}
+
+// TrackedPIDs returns the process IDs of the tracked tunnels
+func (tm *TunnelManager) TrackedPIDs() map[int]bool {
+	pids := make(map[int]bool)
+	tunnels, _ := tm.List()
+	for _, t := range tunnels {
+		if t.PID > 0 {
+			pids[t.PID] = true
+		}
+	}
+	return pids
+}
+
+// LocalTunnelPort picks the local port for a cluster's tunnel: the port the
+// cluster name hashes to, or the next one that is neither taken by another
+// tunnel nor in use. Returns 0 if the whole range is in use.
+func LocalTunnelPort(clusterName string, taken map[int]bool, available func(port int) bool) int {
+	h := fnv.New32a()
+	h.Write([]byte(clusterName))
+	start := int(h.Sum32() % TunnelPortRange)
+	for i := 0; i < TunnelPortRange; i++ {
+		port := TunnelPortBase + (start+i)%TunnelPortRange
+		if !taken[port] && available(port) {
+			return port
+		}
+	}
+	return 0
+}
+
+// portAvailable checks if a local port can be listened on
+func portAvailable(port int) bool {
+	l, err := net.Listen("tcp", fmt.Sprintf("127.0.0.1:%d", port))
+	if err != nil {
+		return false
+	}
+	l.Close()
+	return true
+}
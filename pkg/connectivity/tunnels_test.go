@@ -0,0 +1,167 @@
+package connectivity
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestLocalTunnelPort(t *testing.T) {
+	all := func(int) bool { return true }
+
+	port := LocalTunnelPort("prod", nil, all)
+	if port < TunnelPortBase || port >= TunnelPortBase+TunnelPortRange {
+		t.Fatalf("port %d outside %d-%d", port, TunnelPortBase, TunnelPortBase+TunnelPortRange-1)
+	}
+	if again := LocalTunnelPort("prod", nil, all); again != port {
+		t.Errorf("port changed between calls: %d, then %d", port, again)
+	}
+
+	// Taken or busy ports move on to the next one, wrapping around the range
+	if got := LocalTunnelPort("prod", map[int]bool{port: true}, all); got == port {
+		t.Errorf("got port %d taken by another tunnel", got)
+	}
+	busy := func(p int) bool { return p != port }
+	if got := LocalTunnelPort("prod", nil, busy); got == port {
+		t.Errorf("got busy port %d", got)
+	}
+	if got := LocalTunnelPort("prod", nil, func(int) bool { return false }); got != 0 {
+		t.Errorf("got port %d with the whole range in use", got)
+	}
+}
+
+func TestTunnelStateMigratesLegacyFile(t *testing.T) {
+	dir := t.TempDir()
+	tm := &TunnelManager{
+		stateFile:  filepath.Join(dir, "tunnels.json"),
+		legacyFile: filepath.Join(dir, "active-tunnel.json"),
+	}
+	legacy := `{"cluster_name":"dev","instance_id":"i-1","region":"ap-south-1","pid":0,"local_port":6443,"remote_port":6443}`
+	if err := os.WriteFile(tm.legacyFile, []byte(legacy), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tunnels, err := tm.load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tunnels["dev"] == nil || tunnels["dev"].LocalPort != 6443 {
+		t.Fatalf("legacy tunnel not loaded: %+v", tunnels)
+	}
+
+	tunnels["prod"] = &TunnelState{ClusterName: "prod", LocalPort: 16500, RemotePort: APIServerPort}
+	if err := tm.save(tunnels); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(tm.legacyFile); !os.IsNotExist(err) {
+		t.Errorf("legacy file kept after save: %v", err)
+	}
+	list, err := tm.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 2 || list[0].ClusterName != "dev" || list[1].ClusterName != "prod" {
+		t.Errorf("List() = %+v, want dev and prod", list)
+	}
+}
+
+func TestEnsureTunnelFailsOverToNextMaster(t *testing.T) {
+	dir := t.TempDir()
+	var tried []string
+	var listeners []net.Listener
+	defer func() {
+		for _, l := range listeners {
+			l.Close()
+		}
+	}()
+	tm := &TunnelManager{
+		stateFile:  filepath.Join(dir, "tunnels.json"),
+		legacyFile: filepath.Join(dir, "active-tunnel.json"),
+		Out:        io.Discard,
+		startSession: func(instanceID, region string, localPort, remotePort int) (int, error) {
+			tried = append(tried, instanceID)
+			if instanceID == "i-master-0" {
+				return 0, fmt.Errorf("target %s is not connected", instanceID)
+			}
+			l, err := net.Listen("tcp", fmt.Sprintf("127.0.0.1:%d", localPort))
+			if err != nil {
+				return 0, err
+			}
+			listeners = append(listeners, l)
+			// A live process that is never stopped by the test
+			return os.Getpid(), nil
+		},
+	}
+
+	state, err := tm.EnsureTunnel("prod", []string{"i-master-0", "i-master-1", "i-master-2"}, "ap-south-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if state.InstanceID != "i-master-1" || len(tried) != 2 {
+		t.Fatalf("tunnel to %s after trying %v, want i-master-1", state.InstanceID, tried)
+	}
+	if len(state.Fallbacks) != 2 || state.Fallbacks[0] != "i-master-0" || state.Fallbacks[1] != "i-master-2" {
+		t.Errorf("Fallbacks = %v", state.Fallbacks)
+	}
+
+	// The healthy tunnel is reused rather than moved back
+	tried = nil
+	again, err := tm.EnsureTunnel("prod", []string{"i-master-0", "i-master-1", "i-master-2"}, "ap-south-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again.InstanceID != "i-master-1" || len(tried) != 0 {
+		t.Errorf("tunnel to %s after trying %v, want the existing one", again.InstanceID, tried)
+	}
+
+	if _, err := tm.EnsureTunnel("dev", []string{"i-master-0"}, "ap-south-1"); err == nil {
+		t.Error("EnsureTunnel() = nil error with every master unreachable")
+	}
+}
+
+func TestTunnelCommand(t *testing.T) {
+	t.Setenv("GOMAN_GCP_PROJECT", "")
+	t.Setenv("GOOGLE_CLOUD_PROJECT", "")
+	t.Setenv("GCP_PROJECT", "")
+	t.Setenv("CLOUDSDK_CORE_PROJECT", "")
+
+	tests := []struct {
+		name       string
+		provider   string
+		instanceID string
+		region     string
+		want       []string // Command and arguments, nil for an error
+	}{
+		{"aws", "aws", "i-0abc", "eu-west-1", []string{"aws", "ssm", "start-session", "--target", "i-0abc",
+			"--document-name", "AWS-StartPortForwardingSession",
+			"--parameters", `{"portNumber":["6443"],"localPortNumber":["16500"]}`, "--region", "eu-west-1"}},
+		{"aws default region", "aws", "i-0abc", "", []string{"aws", "ssm", "start-session", "--target", "i-0abc",
+			"--document-name", "AWS-StartPortForwardingSession",
+			"--parameters", `{"portNumber":["6443"],"localPortNumber":["16500"]}`}},
+		{"gcp", "gcp", "europe-west1-b/prod-master-0", "europe-west1", []string{"gcloud", "compute", "start-iap-tunnel",
+			"prod-master-0", "6443", "--local-host-port", "localhost:16500", "--zone", "europe-west1-b"}},
+		{"gcp without zone", "gcp", "prod-master-0", "", nil},
+		{"unsupported provider", "azure", "vm-1", "", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd, err := tunnelCommand(tt.provider, tt.instanceID, tt.region, 16500, APIServerPort)
+			if tt.want == nil {
+				if err == nil {
+					t.Errorf("got %v, want an error", cmd.Args)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Equal(cmd.Args, tt.want) {
+				t.Errorf("got %q\nwant %q", cmd.Args, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,212 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/madhouselabs/goman/pkg/logger"
+	"github.com/madhouselabs/goman/pkg/models"
+	"gopkg.in/yaml.v3"
+)
+
+// addonManifestDir is the K3s directory whose manifests are applied to the
+// cluster and kept applied
+const addonManifestDir = "/var/lib/rancher/k3s/server/manifests"
+
+// addonManifestPath returns the manifest file of an addon on the masters
+func addonManifestPath(name string) string {
+	return fmt.Sprintf("%s/goman-addon-%s.yaml", addonManifestDir, name)
+}
+
+// helmChart is the HelmChart resource of the K3s Helm controller
+type helmChart struct {
+	APIVersion string `yaml:"apiVersion"`
+	Kind       string `yaml:"kind"`
+	Metadata   struct {
+		Name      string `yaml:"name"`
+		Namespace string `yaml:"namespace"`
+	} `yaml:"metadata"`
+	Spec struct {
+		Chart           string `yaml:"chart"`
+		Repo            string `yaml:"repo,omitempty"`
+		Version         string `yaml:"version,omitempty"`
+		TargetNamespace string `yaml:"targetNamespace,omitempty"`
+		CreateNamespace bool   `yaml:"createNamespace,omitempty"`
+		ValuesContent   string `yaml:"valuesContent,omitempty"`
+	} `yaml:"spec"`
+}
+
+// reconcileAddons installs the addons of the spec by writing their
+// manifests to the K3s manifests directory of every running master, again
+// after they changed, and removes those taken out of the spec. The masters
+// each addon was written to are kept in status, so masters joining later
+// get it too.
+func (r *Reconciler) reconcileAddons(ctx context.Context, cluster *models.ClusterResource) error {
+	if err := models.ValidateAddons(cluster.Spec.Addons, cluster.Spec.LowResource); err != nil {
+		return err
+	}
+	var masters []models.InstanceStatus
+	running := make(map[string]bool)
+	for _, inst := range cluster.Status.Instances {
+		if inst.Role == "master" && inst.State == "running" {
+			masters = append(masters, inst)
+			running[inst.InstanceID] = true
+		}
+	}
+	if len(masters) == 0 {
+		return nil
+	}
+
+	wanted := make(map[string]bool)
+	for _, addon := range cluster.Spec.Addons {
+		wanted[addon.Name] = true
+	}
+
+	// Remove addons taken out of the spec
+	var kept []models.AddonStatus
+	for _, st := range cluster.Status.Addons {
+		if wanted[st.Name] {
+			kept = append(kept, st)
+			continue
+		}
+		if err := r.removeAddon(ctx, masters, st); err != nil {
+			return fmt.Errorf("failed to remove addon %s: %w", st.Name, err)
+		}
+		logger.Infof(ctx, "[ADDONS] Removed addon %s from cluster %s", st.Name, cluster.Name)
+	}
+	cluster.Status.Addons = kept
+
+	var errs []error
+	for _, addon := range cluster.Spec.Addons {
+		resolved := addon.Resolve()
+		st := addonStatus(cluster, addon.Name)
+		if st == nil {
+			cluster.Status.Addons = append(cluster.Status.Addons, models.AddonStatus{Name: addon.Name})
+			st = &cluster.Status.Addons[len(cluster.Status.Addons)-1]
+		}
+		if st.Key != resolved.Key() {
+			*st = models.AddonStatus{
+				Name:      addon.Name,
+				Key:       resolved.Key(),
+				Chart:     resolved.IsChart(),
+				Source:    resolved.Source(),
+				Version:   resolved.Version,
+				Namespace: resolved.Namespace,
+			}
+		}
+
+		// Masters that are gone no longer hold the addon
+		var written []string
+		for _, id := range st.Masters {
+			if running[id] {
+				written = append(written, id)
+			}
+		}
+		st.Masters = written
+
+		script, err := addonScript(addon.Name, resolved)
+		if err != nil {
+			st.Message = err.Error()
+			errs = append(errs, fmt.Errorf("addon %s: %w", addon.Name, err))
+			continue
+		}
+		for _, m := range masters {
+			if st.HasMaster(m.InstanceID) {
+				continue
+			}
+			if _, err := r.runOnMaster(ctx, m, "addon-"+addon.Name, script, false); err != nil {
+				st.Message = err.Error()
+				errs = append(errs, fmt.Errorf("addon %s on %s: %w", addon.Name, m.Name, err))
+				continue
+			}
+			if len(st.Masters) == 0 {
+				logger.Infof(ctx, "[ADDONS] Installing addon %s (%s) in cluster %s", addon.Name, st.Source, cluster.Name)
+			}
+			now := time.Now()
+			st.Masters = append(st.Masters, m.InstanceID)
+			st.InstalledAt = &now
+			st.Message = ""
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// addonStatus returns the status of an addon, nil if it has none
+func addonStatus(cluster *models.ClusterResource, name string) *models.AddonStatus {
+	for i := range cluster.Status.Addons {
+		if cluster.Status.Addons[i].Name == name {
+			return &cluster.Status.Addons[i]
+		}
+	}
+	return nil
+}
+
+// addonScript returns the script writing the manifest of a resolved addon
+// to the manifests directory. The file is replaced at once, so K3s never
+// applies a partial one.
+func addonScript(name string, addon models.Addon) (string, error) {
+	path := addonManifestPath(name)
+	if !addon.IsChart() {
+		return fmt.Sprintf("set -e\nmkdir -p %s\ncurl -fsSL %s -o %s.tmp\nmv %s.tmp %s\n",
+			addonManifestDir, quoteArgs([]string{addon.ManifestURL}), path, path, path), nil
+	}
+	manifest, err := helmChartManifest(name, addon)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("set -e\nmkdir -p %s\ncat > %s.tmp <<'GOMAN_ADDON'\n%sGOMAN_ADDON\nmv %s.tmp %s\n",
+		addonManifestDir, path, manifest, path, path), nil
+}
+
+// helmChartManifest renders the HelmChart resource of a chart addon
+func helmChartManifest(name string, addon models.Addon) (string, error) {
+	chart := helmChart{APIVersion: "helm.cattle.io/v1", Kind: "HelmChart"}
+	chart.Metadata.Name = name
+	chart.Metadata.Namespace = "kube-system"
+	chart.Spec.Chart = addon.Chart
+	chart.Spec.Repo = addon.Repo
+	chart.Spec.Version = addon.Version
+	chart.Spec.TargetNamespace = addon.Namespace
+	chart.Spec.CreateNamespace = addon.Namespace != ""
+	if addon.Values != "" {
+		var values map[string]interface{}
+		if err := yaml.Unmarshal([]byte(addon.Values), &values); err != nil {
+			return "", fmt.Errorf("invalid values: %w", err)
+		}
+		chart.Spec.ValuesContent = strings.TrimSuffix(addon.Values, "\n") + "\n"
+	}
+	data, err := yaml.Marshal(chart)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// removeAddon deletes the manifest file of an addon from every running
+// master, then its resources from the cluster: the HelmChart of a chart,
+// uninstalling the release, or the manifests at the URL. Files go first so
+// K3s does not apply them again.
+func (r *Reconciler) removeAddon(ctx context.Context, masters []models.InstanceStatus, st models.AddonStatus) error {
+	if st.Key == "" {
+		return nil
+	}
+	for _, m := range masters {
+		if _, err := r.runOnMaster(ctx, m, "addon-remove-"+st.Name, "rm -f "+addonManifestPath(st.Name), false); err != nil {
+			return err
+		}
+	}
+	_, err := r.runOnMaster(ctx, masters[0], "addon-delete-"+st.Name, addonDeleteCmd(st), false)
+	return err
+}
+
+// addonDeleteCmd returns the kubectl command deleting the resources of an
+// installed addon
+func addonDeleteCmd(st models.AddonStatus) string {
+	if st.Chart {
+		return "kubectl -n kube-system delete helmchart " + quoteArgs([]string{st.Name}) + " --ignore-not-found"
+	}
+	return "kubectl delete -f " + quoteArgs([]string{st.Source}) + " --ignore-not-found"
+}
@@ -0,0 +1,131 @@
+package controller
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/madhouselabs/goman/pkg/models"
+	"github.com/madhouselabs/goman/pkg/provider"
+)
+
+// addonCompute records the scripts run on each master
+type addonCompute struct {
+	provider.ComputeService
+	scripts map[string][]string
+}
+
+func (c *addonCompute) RunCommand(ctx context.Context, ids []string, command string) (*provider.CommandResult, error) {
+	c.scripts[ids[0]] = append(c.scripts[ids[0]], command)
+	return &provider.CommandResult{Status: "Success", Instances: map[string]*provider.InstanceCommandResult{
+		ids[0]: {Status: "Success"},
+	}}, nil
+}
+
+func TestValidateAddons(t *testing.T) {
+	valid := []models.Addon{
+		{Name: "cert-manager", Version: "v1.15.0"},
+		{Name: "dashboard", Chart: "kubernetes-dashboard", Repo: "https://kubernetes.github.io/dashboard"},
+		{Name: "flux", ManifestURL: "https://github.com/fluxcd/flux2/releases/latest/download/install.yaml"},
+	}
+	if err := models.ValidateAddons(valid, false); err != nil {
+		t.Fatalf("valid addons rejected: %v", err)
+	}
+	if err := models.ValidateAddons(valid, true); err == nil {
+		t.Error("chart accepted without the Helm controller")
+	}
+	for _, addons := range [][]models.Addon{
+		{{Name: "unknown"}},
+		{{Name: "Bad_Name", ManifestURL: "https://example.com/a.yaml"}},
+		{{Name: "a", ManifestURL: "https://example.com/a.yaml"}, {Name: "a", ManifestURL: "https://example.com/b.yaml"}},
+		{{Name: "a", Chart: "a", ManifestURL: "https://example.com/a.yaml"}},
+		{{Name: "a", ManifestURL: "ftp://example.com/a.yaml"}},
+		{{Name: "a", ManifestURL: "https://example.com/a.yaml", Version: "1.0"}},
+	} {
+		if err := models.ValidateAddons(addons, false); err == nil {
+			t.Errorf("invalid addons %+v accepted", addons)
+		}
+	}
+
+	// Catalog addons keep the catalog's chart with the overrides
+	resolved := models.Addon{Name: "cert-manager", Version: "v1.15.0"}.Resolve()
+	if resolved.Chart != "cert-manager" || resolved.Version != "v1.15.0" || !strings.Contains(resolved.Values, "crds") {
+		t.Errorf("resolved cert-manager = %+v", resolved)
+	}
+}
+
+func TestHelmChartManifest(t *testing.T) {
+	addon := models.Addon{Name: "cert-manager"}.Resolve()
+	manifest, err := helmChartManifest("cert-manager", addon)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"kind: HelmChart",
+		"namespace: kube-system",
+		"repo: https://charts.jetstack.io",
+		"targetNamespace: cert-manager",
+		"createNamespace: true",
+		"valuesContent: |\n        crds:\n          enabled: true\n",
+	} {
+		if !strings.Contains(manifest, want) {
+			t.Errorf("manifest lacks %q:\n%s", want, manifest)
+		}
+	}
+
+	if _, err := helmChartManifest("bad", models.Addon{Chart: "bad", Values: "a: [1"}); err == nil {
+		t.Error("invalid values accepted")
+	}
+}
+
+func TestReconcileAddons(t *testing.T) {
+	compute := &addonCompute{scripts: make(map[string][]string)}
+	r := &Reconciler{provider: &fakeProvider{compute: compute}, settings: DefaultSettings()}
+	cluster := &models.ClusterResource{Name: "demo"}
+	cluster.Spec.Addons = []models.Addon{
+		{Name: "ingress-nginx"},
+		{Name: "flux", ManifestURL: "https://example.com/flux.yaml"},
+	}
+	cluster.Status.Instances = []models.InstanceStatus{
+		{InstanceID: "i-1", Name: "demo-master-0", Role: "master", State: "running"},
+		{InstanceID: "i-2", Name: "demo-master-1", Role: "master", State: "running"},
+		{InstanceID: "i-3", Name: "demo-worker-0", Role: "worker", State: "running"},
+	}
+	ctx := context.Background()
+
+	if err := r.reconcileAddons(ctx, cluster); err != nil {
+		t.Fatal(err)
+	}
+	if len(compute.scripts["i-1"]) != 2 || len(compute.scripts["i-2"]) != 2 || len(compute.scripts["i-3"]) != 0 {
+		t.Fatalf("scripts run: %v", compute.scripts)
+	}
+	if !strings.Contains(compute.scripts["i-1"][1], "curl -fsSL 'https://example.com/flux.yaml'") {
+		t.Errorf("manifest addon script:\n%s", compute.scripts["i-1"][1])
+	}
+	st := addonStatus(cluster, "ingress-nginx")
+	if st == nil || !st.Chart || st.Version != "4.11.3" || len(st.Masters) != 2 || st.InstalledAt == nil {
+		t.Fatalf("ingress-nginx status = %+v", st)
+	}
+
+	// Nothing to do once installed
+	compute.scripts = make(map[string][]string)
+	if err := r.reconcileAddons(ctx, cluster); err != nil || len(compute.scripts) != 0 {
+		t.Fatalf("second reconcile ran %v, %v", compute.scripts, err)
+	}
+
+	// A changed version is written again, a removed addon deleted
+	cluster.Spec.Addons = []models.Addon{{Name: "ingress-nginx", Version: "4.12.0"}}
+	if err := r.reconcileAddons(ctx, cluster); err != nil {
+		t.Fatal(err)
+	}
+	if st := addonStatus(cluster, "ingress-nginx"); st == nil || st.Version != "4.12.0" || len(st.Masters) != 2 {
+		t.Errorf("upgraded status = %+v", st)
+	}
+	if addonStatus(cluster, "flux") != nil {
+		t.Error("removed addon still in status")
+	}
+	deleted := strings.Join(compute.scripts["i-1"], "\n")
+	if !strings.Contains(deleted, "rm -f "+addonManifestPath("flux")) || !strings.Contains(deleted, "kubectl delete -f 'https://example.com/flux.yaml'") {
+		t.Errorf("removal scripts on the first master:\n%s", deleted)
+	}
+}
@@ -0,0 +1,149 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+
+	"github.com/madhouselabs/goman/pkg/logger"
+	"github.com/madhouselabs/goman/pkg/models"
+	"github.com/madhouselabs/goman/pkg/provider"
+	"gopkg.in/yaml.v3"
+)
+
+// reconcileAPIEndpoints lists every master of an HA cluster in the stored
+// kubeconfig, so clients can switch to another master when the one they use
+// is down. The first master keeps the kubeconfig's own cluster and context
+// names, the others follow as <name>-1, <name>-2 and so on. A cluster
+// served from a virtual IP or a load balancer needs no more than that.
+func (r *Reconciler) reconcileAPIEndpoints(ctx context.Context, cluster *models.ClusterResource) error {
+	endpoints := masterEndpoints(cluster)
+	if shared := sharedAPIEndpoint(cluster); shared != "" && cluster.Status.APIEndpoint == shared {
+		// Entries listed before the shared endpoint took over are dropped
+		if len(cluster.Status.APIEndpoints) == 0 {
+			return nil
+		}
+		endpoints = []string{cluster.Status.APIEndpoint}
+	} else if len(endpoints) < 2 || listsEndpoints(cluster.Status.APIEndpoints, endpoints) {
+		// Masters that are down stay listed for when they are back
+		return nil
+	}
+
+	secretService := r.provider.GetSecretService()
+	kubeconfig, err := secretService.GetSecret(ctx, cluster.Name, provider.SecretKubeconfig)
+	if err != nil {
+		return fmt.Errorf("failed to read kubeconfig: %w", err)
+	}
+	updated, err := kubeconfigWithEndpoints(kubeconfig, endpoints)
+	if err != nil {
+		return err
+	}
+	if err := secretService.PutSecret(ctx, cluster.Name, provider.SecretKubeconfig, updated); err != nil {
+		return fmt.Errorf("failed to update kubeconfig: %w", err)
+	}
+
+	cluster.Status.APIEndpoint = endpoints[0]
+	cluster.Status.APIEndpoints = endpoints
+	if len(endpoints) == 1 {
+		cluster.Status.APIEndpoints = nil
+	}
+	logger.Infof(ctx, "[KUBECONFIG] Kubeconfig of cluster %s lists API endpoints %v", cluster.Name, endpoints)
+	return nil
+}
+
+// masterEndpoints returns the API server URLs of the running masters. The
+// current API endpoint stays first so clients keep their master, the others
+// follow by index.
+func masterEndpoints(cluster *models.ClusterResource) []string {
+	var masters []models.InstanceStatus
+	for _, inst := range cluster.Status.Instances {
+		if inst.Role == "master" && inst.State == "running" && (inst.PublicIP != "" || inst.PrivateIP != "") {
+			masters = append(masters, inst)
+		}
+	}
+	sort.SliceStable(masters, func(i, j int) bool {
+		return extractWorkerIndex(masters[i].Name) < extractWorkerIndex(masters[j].Name)
+	})
+
+	var endpoints []string
+	for _, m := range masters {
+		// Same address each master adds to its certificate at bootstrap
+		host := m.PublicIP
+		if host == "" {
+			host = m.PrivateIP
+		}
+		endpoint := models.APIEndpointURL(host)
+		if endpoint == cluster.Status.APIEndpoint {
+			endpoints = append([]string{endpoint}, endpoints...)
+		} else {
+			endpoints = append(endpoints, endpoint)
+		}
+	}
+	return endpoints
+}
+
+// listsEndpoints reports whether every endpoint is already listed
+func listsEndpoints(listed, endpoints []string) bool {
+	for _, endpoint := range endpoints {
+		if !slices.Contains(listed, endpoint) {
+			return false
+		}
+	}
+	return true
+}
+
+// kubeconfigWithEndpoints rebuilds a kubeconfig with a cluster and context
+// per API endpoint, all sharing the first cluster's CA and the first
+// context's user. Any other entries are dropped.
+func kubeconfigWithEndpoints(kubeconfig []byte, endpoints []string) ([]byte, error) {
+	var doc map[string]interface{}
+	if err := yaml.Unmarshal(kubeconfig, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse kubeconfig: %w", err)
+	}
+	clusters, _ := doc["clusters"].([]interface{})
+	contexts, _ := doc["contexts"].([]interface{})
+	if len(clusters) == 0 || len(contexts) == 0 {
+		return nil, fmt.Errorf("kubeconfig has no cluster or context")
+	}
+	firstCluster, _ := clusters[0].(map[string]interface{})
+	firstContext, _ := contexts[0].(map[string]interface{})
+	clusterName, _ := firstCluster["name"].(string)
+	clusterData, _ := firstCluster["cluster"].(map[string]interface{})
+	contextName, _ := firstContext["name"].(string)
+	contextData, _ := firstContext["context"].(map[string]interface{})
+	if clusterName == "" || clusterData == nil || contextName == "" || contextData == nil {
+		return nil, fmt.Errorf("kubeconfig has an unnamed or empty cluster or context")
+	}
+
+	var newClusters, newContexts []interface{}
+	for i, endpoint := range endpoints {
+		name, ctxName := clusterName, contextName
+		if i > 0 {
+			name = fmt.Sprintf("%s-%d", clusterName, i)
+			ctxName = fmt.Sprintf("%s-%d", contextName, i)
+		}
+		c := make(map[string]interface{}, len(clusterData))
+		for k, v := range clusterData {
+			c[k] = v
+		}
+		c["server"] = endpoint
+		newClusters = append(newClusters, map[string]interface{}{"name": name, "cluster": c})
+
+		cc := make(map[string]interface{}, len(contextData))
+		for k, v := range contextData {
+			cc[k] = v
+		}
+		cc["cluster"] = name
+		newContexts = append(newContexts, map[string]interface{}{"name": ctxName, "context": cc})
+	}
+	doc["clusters"] = newClusters
+	doc["contexts"] = newContexts
+	doc["current-context"] = contextName
+
+	out, err := yaml.Marshal(doc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to write kubeconfig: %w", err)
+	}
+	return out, nil
+}
@@ -0,0 +1,113 @@
+package controller
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/madhouselabs/goman/pkg/models"
+	"github.com/madhouselabs/goman/pkg/provider"
+	"gopkg.in/yaml.v3"
+)
+
+const k3sKubeconfig = `apiVersion: v1
+clusters:
+- cluster:
+    certificate-authority-data: Q0E=
+    server: https://3.110.0.1:6443
+  name: default
+contexts:
+- context:
+    cluster: default
+    user: default
+  name: default
+current-context: default
+kind: Config
+preferences: {}
+users:
+- name: default
+  user:
+    client-certificate-data: Q0VSVA==
+    client-key-data: S0VZ
+`
+
+// kubeconfigServers returns the servers of a kubeconfig by cluster name
+// and its current context
+func kubeconfigServers(t *testing.T, data []byte) (map[string]string, string) {
+	t.Helper()
+	var kc struct {
+		Clusters []struct {
+			Name    string `yaml:"name"`
+			Cluster struct {
+				Server string `yaml:"server"`
+				CA     string `yaml:"certificate-authority-data"`
+			} `yaml:"cluster"`
+		} `yaml:"clusters"`
+		CurrentContext string `yaml:"current-context"`
+		Users          []struct {
+			Name string `yaml:"name"`
+		} `yaml:"users"`
+	}
+	if err := yaml.Unmarshal(data, &kc); err != nil {
+		t.Fatal(err)
+	}
+	if len(kc.Users) != 1 {
+		t.Fatalf("kubeconfig has %d users, want 1", len(kc.Users))
+	}
+	servers := make(map[string]string)
+	for _, c := range kc.Clusters {
+		if c.Cluster.CA != "Q0E=" {
+			t.Errorf("cluster %s lost its CA", c.Name)
+		}
+		servers[c.Name] = c.Cluster.Server
+	}
+	return servers, kc.CurrentContext
+}
+
+func TestReconcileAPIEndpoints(t *testing.T) {
+	secrets := &secretMap{secrets: map[string][]byte{"demo/" + provider.SecretKubeconfig: []byte(k3sKubeconfig)}}
+	r := &Reconciler{provider: &fakeProvider{secrets: secrets}, settings: DefaultSettings()}
+	cluster := &models.ClusterResource{Name: "demo"}
+	cluster.Spec.Mode = "ha"
+	cluster.Status.APIEndpoint = "https://3.110.0.2:6443"
+	cluster.Status.Instances = []models.InstanceStatus{
+		{InstanceID: "i-2", Name: "demo-master-2", Role: "master", State: "running", PublicIP: "3.110.0.3"},
+		{InstanceID: "i-0", Name: "demo-master-0", Role: "master", State: "running", PublicIP: "3.110.0.1"},
+		{InstanceID: "i-1", Name: "demo-master-1", Role: "master", State: "running", PublicIP: "3.110.0.2"},
+		{InstanceID: "i-w", Name: "demo-default-0", Role: "worker", State: "running", PublicIP: "3.110.0.9"},
+	}
+	ctx := context.Background()
+
+	if err := r.reconcileAPIEndpoints(ctx, cluster); err != nil {
+		t.Fatal(err)
+	}
+	// The master in use stays first, the others follow by index
+	want := []string{"https://3.110.0.2:6443", "https://3.110.0.1:6443", "https://3.110.0.3:6443"}
+	if !slices.Equal(cluster.Status.APIEndpoints, want) {
+		t.Fatalf("APIEndpoints = %v, want %v", cluster.Status.APIEndpoints, want)
+	}
+	servers, current := kubeconfigServers(t, secrets.secrets["demo/"+provider.SecretKubeconfig])
+	if current != "default" || servers["default"] != want[0] || servers["default-1"] != want[1] || servers["default-2"] != want[2] || len(servers) != 3 {
+		t.Errorf("kubeconfig servers %v, current context %s", servers, current)
+	}
+
+	// A master going down keeps its entry
+	cluster.Status.Instances[0].State = "stopped"
+	if err := r.reconcileAPIEndpoints(ctx, cluster); err != nil {
+		t.Fatal(err)
+	}
+	if len(cluster.Status.APIEndpoints) != 3 {
+		t.Errorf("APIEndpoints = %v with a master down", cluster.Status.APIEndpoints)
+	}
+
+	// A virtual IP replaces the list
+	cluster.Status.VirtualIP = &models.VirtualIPStatus{Address: "10.0.0.100"}
+	cluster.Status.APIEndpoint = models.APIEndpointURL("10.0.0.100")
+	if err := r.reconcileAPIEndpoints(ctx, cluster); err != nil {
+		t.Fatal(err)
+	}
+	servers, _ = kubeconfigServers(t, secrets.secrets["demo/"+provider.SecretKubeconfig])
+	if cluster.Status.APIEndpoints != nil || len(servers) != 1 || servers["default"] != cluster.Status.APIEndpoint {
+		t.Errorf("APIEndpoints %v, kubeconfig servers %v with a virtual IP", cluster.Status.APIEndpoints, servers)
+	}
+}
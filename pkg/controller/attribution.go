@@ -0,0 +1,170 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/madhouselabs/goman/pkg/logger"
+	"github.com/madhouselabs/goman/pkg/models"
+	"github.com/madhouselabs/goman/pkg/provider"
+)
+
+const (
+	// changeAttributionWindow is how long the author of a change is looked
+	// up; CloudTrail delivers calls within about 15 minutes
+	changeAttributionWindow = time.Hour
+
+	// changeLookupInterval spaces the lookups of one change
+	changeLookupInterval = 2 * time.Minute
+
+	// changeLookback is how long before its detection a change is searched
+	// for, since drift is only seen by the next reconcile
+	changeLookback = 24 * time.Hour
+
+	// maxUnattributedChanges bounds the changes looked up at once
+	maxUnattributedChanges = 20
+)
+
+// changeAttributor returns the provider's audit log when change attribution
+// is enabled and the provider keeps one
+func (r *Reconciler) changeAttributor() provider.ChangeAttributor {
+	if !r.settings.ChangeAttribution {
+		return nil
+	}
+	attributor, _ := r.provider.(provider.ChangeAttributor)
+	return attributor
+}
+
+// reportOutOfBandChange records a change made outside goman as a warning
+// event. With change attribution enabled, the event names who made it; if
+// the audit log does not have the call yet, the change is kept in status and
+// attributed by a later reconcile.
+func (r *Reconciler) reportOutOfBandChange(ctx context.Context, cluster *models.ClusterResource, change models.UnattributedChange) {
+	change.DetectedAt = time.Now()
+	logger.Infof(ctx, "[ATTRIBUTION] %s outside goman in cluster %s", change.Description, cluster.Name)
+
+	attributor := r.changeAttributor()
+	if attributor == nil {
+		r.events.Warning(ctx, cluster.Name, EventOutOfBandChange, change.Node, "%s outside goman", change.Description)
+		return
+	}
+	if record := r.lookupChange(ctx, attributor, &change); record != nil {
+		setDriftAuthor(cluster, change, record.Principal)
+		r.events.Warning(ctx, cluster.Name, EventOutOfBandChange, change.Node, "%s outside goman %s", change.Description, changeAuthor(record))
+		return
+	}
+	r.events.Warning(ctx, cluster.Name, EventOutOfBandChange, change.Node, "%s outside goman; looking up who made the change", change.Description)
+	if len(cluster.Status.UnattributedChanges) >= maxUnattributedChanges {
+		logger.Infof(ctx, "[ATTRIBUTION] Not looking up %s: %d changes are looked up already", change.ResourceID, maxUnattributedChanges)
+		return
+	}
+	cluster.Status.UnattributedChanges = append(cluster.Status.UnattributedChanges, change)
+}
+
+// attributeChanges looks up the authors of changes reported earlier, each
+// every few minutes until it is found or the attribution window has passed.
+// Found authors are recorded as events.
+func (r *Reconciler) attributeChanges(ctx context.Context, cluster *models.ClusterResource) {
+	if len(cluster.Status.UnattributedChanges) == 0 {
+		return
+	}
+	attributor := r.changeAttributor()
+	var pending []models.UnattributedChange
+	for _, change := range cluster.Status.UnattributedChanges {
+		if attributor == nil || time.Since(change.DetectedAt) > changeAttributionWindow {
+			logger.Infof(ctx, "[ATTRIBUTION] Gave up looking up who made a change to %s in cluster %s", change.ResourceID, cluster.Name)
+			continue
+		}
+		if time.Since(change.CheckedAt) < changeLookupInterval {
+			pending = append(pending, change)
+			continue
+		}
+		record := r.lookupChange(ctx, attributor, &change)
+		if record == nil {
+			pending = append(pending, change)
+			continue
+		}
+		setDriftAuthor(cluster, change, record.Principal)
+		r.events.Normal(ctx, cluster.Name, EventChangeAttributed, change.Node, "%s %s", change.Description, changeAuthor(record))
+	}
+	cluster.Status.UnattributedChanges = pending
+}
+
+// lookupChange asks the audit log for the latest call making a change up to
+// its detection, nil if there is none (yet)
+func (r *Reconciler) lookupChange(ctx context.Context, attributor provider.ChangeAttributor, change *models.UnattributedChange) *provider.ChangeRecord {
+	change.CheckedAt = time.Now()
+	record, err := attributor.LookupChange(ctx, change.ResourceID, change.EventNames, change.DetectedAt.Add(-changeLookback), change.DetectedAt)
+	if err != nil {
+		logger.Warnf(ctx, "[ATTRIBUTION] Could not look up changes to %s: %v", change.ResourceID, err)
+		return nil
+	}
+	if record != nil {
+		logger.Infof(ctx, "[ATTRIBUTION] %s by %s at %s", change.ResourceID, record.Principal, record.Time)
+	}
+	return record
+}
+
+// changeAuthor describes who made a change, e.g. "by arn:aws:iam::123:user/bob
+// (TerminateInstances at 2024-05-01 14:03:10 UTC)"
+func changeAuthor(record *provider.ChangeRecord) string {
+	return fmt.Sprintf("by %s (%s at %s)", record.Principal, record.EventName, record.Time.UTC().Format("2006-01-02 15:04:05 MST"))
+}
+
+// setDriftAuthor notes who made the change on the drift it caused
+func setDriftAuthor(cluster *models.ClusterResource, change models.UnattributedChange, principal string) {
+	if change.Field == "" {
+		return
+	}
+	for i := range cluster.Status.Drift {
+		d := &cluster.Status.Drift[i]
+		if d.InstanceID == change.ResourceID && d.Field == change.Field {
+			d.ChangedBy = principal
+		}
+	}
+}
+
+// reportVanishedWorkers reports workers that were running in status but are
+// no longer, because they were stopped or terminated outside goman. actual
+// holds the running and pending instances of the cluster; workers goman
+// stops or terminates itself leave the status when it does.
+func (r *Reconciler) reportVanishedWorkers(ctx context.Context, cluster *models.ClusterResource, actual map[string]*provider.Instance) {
+	computeService := r.provider.GetComputeService()
+	for _, st := range cluster.Status.Instances {
+		if st.Role != "worker" || (st.State != "running" && st.State != "pending") {
+			continue
+		}
+		if _, ok := actual[st.InstanceID]; ok {
+			continue
+		}
+
+		state := "terminated"
+		inst, err := computeService.GetInstance(ctx, st.InstanceID)
+		if err != nil && !errors.Is(err, provider.ErrNotFound) {
+			logger.Warnf(ctx, "[ATTRIBUTION] Could not get instance %s: %v", st.InstanceID, err)
+			continue
+		}
+		if err == nil {
+			if inst.Tags[parkedAtTag] != "" {
+				// Stopped for reuse by a scale-down
+				continue
+			}
+			state = inst.State
+		}
+
+		change := models.UnattributedChange{ResourceID: st.InstanceID, Node: st.Name}
+		switch state {
+		case "terminated", "shutting-down":
+			change.Description = fmt.Sprintf("Instance %s (%s) terminated", st.InstanceID, st.Name)
+			change.EventNames = []string{"TerminateInstances"}
+		case "stopped", "stopping":
+			change.Description = fmt.Sprintf("Instance %s (%s) stopped", st.InstanceID, st.Name)
+			change.EventNames = []string{"StopInstances"}
+		default:
+			continue
+		}
+		r.reportOutOfBandChange(ctx, cluster, change)
+	}
+}
@@ -0,0 +1,91 @@
+package controller
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/madhouselabs/goman/pkg/models"
+	"github.com/madhouselabs/goman/pkg/provider"
+)
+
+// trailProvider keeps an audit log of the calls it is given
+type trailProvider struct {
+	fakeProvider
+	records map[string]*provider.ChangeRecord
+	lookups int
+}
+
+func (p *trailProvider) LookupChange(ctx context.Context, resourceID string, eventNames []string, start, end time.Time) (*provider.ChangeRecord, error) {
+	p.lookups++
+	return p.records[resourceID], nil
+}
+
+func TestReportVanishedWorkers(t *testing.T) {
+	compute := &powerCompute{instances: map[string]*provider.Instance{
+		"i-1": {ID: "i-1", State: "running"},
+		"i-2": {ID: "i-2", State: "terminated"},
+		"i-3": {ID: "i-3", State: "stopped", Tags: map[string]string{parkedAtTag: "2024-05-01T00:00:00Z"}},
+	}}
+	p := &trailProvider{fakeProvider: fakeProvider{compute: compute}, records: map[string]*provider.ChangeRecord{}}
+	settings := DefaultSettings()
+	settings.ChangeAttribution = true
+	r := &Reconciler{provider: p, settings: settings}
+
+	cluster := &models.ClusterResource{Name: "demo"}
+	cluster.Status.Instances = []models.InstanceStatus{
+		{InstanceID: "i-1", Name: "demo-worker-default-0", Role: "worker", State: "running"},
+		{InstanceID: "i-2", Name: "demo-worker-default-1", Role: "worker", State: "running"},
+		{InstanceID: "i-3", Name: "demo-worker-default-2", Role: "worker", State: "running"},
+	}
+	ctx := context.Background()
+
+	// CloudTrail has no record of the termination yet
+	r.reportVanishedWorkers(ctx, cluster, map[string]*provider.Instance{"i-1": compute.instances["i-1"]})
+	changes := cluster.Status.UnattributedChanges
+	if len(changes) != 1 || changes[0].ResourceID != "i-2" || changes[0].EventNames[0] != "TerminateInstances" {
+		t.Fatalf("unattributed changes = %+v, want the termination of i-2", changes)
+	}
+
+	// Lookups are spaced out
+	r.attributeChanges(ctx, cluster)
+	if p.lookups != 1 || len(cluster.Status.UnattributedChanges) != 1 {
+		t.Fatalf("%d lookups, %d changes pending right after the first", p.lookups, len(cluster.Status.UnattributedChanges))
+	}
+
+	p.records["i-2"] = &provider.ChangeRecord{EventName: "TerminateInstances", Principal: "arn:aws:iam::123456789012:user/bob", Time: time.Now()}
+	cluster.Status.UnattributedChanges[0].CheckedAt = time.Now().Add(-changeLookupInterval)
+	r.attributeChanges(ctx, cluster)
+	if p.lookups != 2 || len(cluster.Status.UnattributedChanges) != 0 {
+		t.Errorf("%d lookups, %d changes pending once CloudTrail has the call", p.lookups, len(cluster.Status.UnattributedChanges))
+	}
+
+	// Changes that are never found are dropped after the window
+	cluster.Status.UnattributedChanges = []models.UnattributedChange{{ResourceID: "i-9", DetectedAt: time.Now().Add(-2 * changeAttributionWindow)}}
+	r.attributeChanges(ctx, cluster)
+	if len(cluster.Status.UnattributedChanges) != 0 || p.lookups != 2 {
+		t.Errorf("expired change kept or looked up: %+v", cluster.Status.UnattributedChanges)
+	}
+}
+
+func TestReportOutOfBandChangeDrift(t *testing.T) {
+	p := &trailProvider{records: map[string]*provider.ChangeRecord{
+		"i-1": {EventName: "ModifyInstanceAttribute", Principal: "arn:aws:iam::123456789012:user/bob", Time: time.Now()},
+	}}
+	cluster := &models.ClusterResource{Name: "demo"}
+	cluster.Status.Drift = []models.DriftStatus{{Field: models.DriftFieldInstanceType, Node: "demo-master-0", InstanceID: "i-1"}}
+	change := models.UnattributedChange{ResourceID: "i-1", Node: "demo-master-0", Field: models.DriftFieldInstanceType, EventNames: []string{"ModifyInstanceAttribute"}}
+
+	// Without the setting the audit log is not asked
+	r := &Reconciler{provider: p, settings: DefaultSettings()}
+	r.reportOutOfBandChange(context.Background(), cluster, change)
+	if p.lookups != 0 || cluster.Status.Drift[0].ChangedBy != "" || len(cluster.Status.UnattributedChanges) != 0 {
+		t.Fatalf("change attributed with the setting off")
+	}
+
+	r.settings.ChangeAttribution = true
+	r.reportOutOfBandChange(context.Background(), cluster, change)
+	if got := cluster.Status.Drift[0].ChangedBy; got != "arn:aws:iam::123456789012:user/bob" {
+		t.Errorf("drift changed by %q", got)
+	}
+}
@@ -0,0 +1,274 @@
+package controller
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/madhouselabs/goman/pkg/logger"
+	"github.com/madhouselabs/goman/pkg/models"
+	"github.com/madhouselabs/goman/pkg/provider"
+)
+
+// certificateScript prints the expiry date of the K3s leaf certificates
+// clients depend on, one "<name> <openssl date>" line each
+const certificateScript = `for name in client-admin serving-kube-apiserver; do
+    f=/var/lib/rancher/k3s/server/tls/$name.crt
+    [ -f "$f" ] && echo "$name $(openssl x509 -enddate -noout -in "$f" | cut -d= -f2)"
+done
+exit 0
+`
+
+// rotateCertificatesScript rotates the certificates of one master. K3s must
+// be stopped for the rotation and serves again once the API is ready.
+const rotateCertificatesScript = `set -e
+systemctl stop k3s
+k3s certificate rotate
+systemctl start k3s
+for i in $(seq 1 60); do
+    k3s kubectl get --raw=/readyz >/dev/null 2>&1 && exit 0
+    sleep 2
+done
+echo "API server not ready after certificate rotation" >&2
+exit 1
+`
+
+// kubeconfigScript prints the admin kubeconfig of a master, pointed at its
+// public IP like the kubeconfig stored at bootstrap
+const kubeconfigScript = `PUBLIC_IP=$(curl -sf http://169.254.169.254/latest/meta-data/public-ipv4 || curl -s http://169.254.169.254/latest/meta-data/local-ipv4)
+sed "s/127.0.0.1/$PUBLIC_IP/g" /etc/rancher/k3s/k3s.yaml
+`
+
+// opensslDateLayout is the date format of openssl x509 -enddate
+const opensslDateLayout = "Jan _2 15:04:05 2006 MST"
+
+var clientCertDataPattern = regexp.MustCompile(`client-certificate-data:\s*(\S+)`)
+
+// reconcileCertificates reads the certificate expiry dates from the masters
+// once per check interval. Certificates expiring within the renewal window,
+// or all of them when a rotation was requested, are rotated one master at a
+// time. The stored kubeconfig is refreshed whenever its client certificate
+// is older than the one on the masters, e.g. after K3s renewed it on start.
+func (r *Reconciler) reconcileCertificates(ctx context.Context, cluster *models.ClusterResource) error {
+	st := cluster.Status.Certificates
+	requested := cluster.Spec.CertRotationRequestedAt
+	rotate := requested != nil && (st == nil || st.RotatedAt == nil || st.RotatedAt.Before(*requested))
+	if !rotate && st != nil && time.Since(st.CheckedAt) < r.settings.CertCheckInterval {
+		return nil
+	}
+
+	var masters []models.InstanceStatus
+	for _, inst := range cluster.Status.Instances {
+		if inst.Role == "master" && inst.State == "running" {
+			masters = append(masters, inst)
+		}
+	}
+	if len(masters) == 0 {
+		return fmt.Errorf("no running master to check certificates on")
+	}
+
+	// Failed checks keep the last known dates and are retried on the next
+	// reconcile
+	next := &models.CertificateStatus{}
+	if st != nil {
+		*next = *st
+	}
+	next.Message = ""
+	cluster.Status.Certificates = next
+
+	certs, err := r.readCertificates(ctx, masters)
+	if err != nil {
+		next.Message = err.Error()
+		return err
+	}
+	expiresAt := earliestCertificate(certs)
+
+	if !rotate && expiresAt != nil && r.settings.CertRenewBefore > 0 && time.Until(*expiresAt) < r.settings.CertRenewBefore {
+		logger.Infof(ctx, "[CERTS] Certificates of cluster %s expire %s, rotating", cluster.Name, expiresAt.Format(time.RFC3339))
+		rotate = true
+	}
+	if rotate {
+		if err := r.rotateCertificates(ctx, masters); err != nil {
+			next.Message = err.Error()
+			return err
+		}
+		now := time.Now()
+		next.RotatedAt = &now
+		if certs, err = r.readCertificates(ctx, masters); err != nil {
+			next.Message = err.Error()
+			return err
+		}
+		expiresAt = earliestCertificate(certs)
+	}
+	next.Certificates = certs
+	next.ExpiresAt = expiresAt
+
+	kubeconfigExpiresAt, err := r.refreshKubeconfigIfStale(ctx, cluster, masters[0], certs)
+	if err != nil {
+		next.Message = err.Error()
+		return err
+	}
+	next.KubeconfigExpiresAt = kubeconfigExpiresAt
+	next.CheckedAt = time.Now()
+
+	if warning := models.CertificateWarning(next.EarliestExpiry(), time.Now()); warning != "" {
+		logger.Infof(ctx, "[CERTS] Cluster %s: %s", cluster.Name, warning)
+	}
+	return nil
+}
+
+// readCertificates returns the expiry dates of the certificates on each master
+func (r *Reconciler) readCertificates(ctx context.Context, masters []models.InstanceStatus) ([]models.CertificateExpiry, error) {
+	var certs []models.CertificateExpiry
+	for _, master := range masters {
+		output, err := r.runOnMaster(ctx, master, "read-certificates", certificateScript, false)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read certificates of %s: %w", master.Name, err)
+		}
+		parsed, err := parseCertificateExpiry(master.Name, output)
+		if err != nil {
+			return nil, err
+		}
+		certs = append(certs, parsed...)
+	}
+	return certs, nil
+}
+
+// rotateCertificates rotates the certificates one master at a time, so HA
+// clusters keep serving
+func (r *Reconciler) rotateCertificates(ctx context.Context, masters []models.InstanceStatus) error {
+	for _, master := range masters {
+		logger.Infof(ctx, "[CERTS] Rotating certificates on %s", master.Name)
+		if _, err := r.runOnMaster(ctx, master, "rotate-certificates", rotateCertificatesScript, false); err != nil {
+			return fmt.Errorf("failed to rotate certificates on %s: %w", master.Name, err)
+		}
+	}
+	return nil
+}
+
+// refreshKubeconfigIfStale replaces the stored kubeconfig with the one of
+// the master when its client certificate expires before the master's admin
+// certificate. Returns the expiry of the stored kubeconfig.
+func (r *Reconciler) refreshKubeconfigIfStale(ctx context.Context, cluster *models.ClusterResource, master models.InstanceStatus, certs []models.CertificateExpiry) (*time.Time, error) {
+	var current *time.Time
+	for _, cert := range certs {
+		if cert.Node == master.Name && cert.Name == "client-admin" {
+			expiresAt := cert.ExpiresAt
+			current = &expiresAt
+		}
+	}
+
+	secretService := r.provider.GetSecretService()
+	stored, err := secretService.GetSecret(ctx, cluster.Name, provider.SecretKubeconfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read kubeconfig: %w", err)
+	}
+	storedExpiresAt, err := kubeconfigExpiry(stored)
+	if err != nil {
+		logger.Infof(ctx, "[CERTS] Stored kubeconfig of %s has no readable client certificate: %v", cluster.Name, err)
+	}
+	if current == nil || (storedExpiresAt != nil && !storedExpiresAt.Before(*current)) {
+		return storedExpiresAt, nil
+	}
+
+	kubeconfig, err := r.runOnMaster(ctx, master, "read-kubeconfig", kubeconfigScript, true)
+	if err != nil {
+		return storedExpiresAt, fmt.Errorf("failed to read kubeconfig of %s: %w", master.Name, err)
+	}
+	updated := []byte(kubeconfig)
+	if cluster.Status.APIEndpoint != "" {
+		updated = kubeconfigServerPattern.ReplaceAll(updated, []byte("${1}"+cluster.Status.APIEndpoint))
+	}
+	if err := secretService.PutSecret(ctx, cluster.Name, provider.SecretKubeconfig, updated); err != nil {
+		return storedExpiresAt, fmt.Errorf("failed to save kubeconfig: %w", err)
+	}
+	logger.Infof(ctx, "[CERTS] Refreshed stored kubeconfig of cluster %s", cluster.Name)
+	// The fresh kubeconfig has a single server; the other masters are listed again
+	cluster.Status.APIEndpoints = nil
+
+	refreshed, err := kubeconfigExpiry(updated)
+	if err != nil {
+		return current, nil
+	}
+	return refreshed, nil
+}
+
+// runOnMaster runs a script on a master and returns its output. Scripts
+// printing credentials are recorded without their output.
+func (r *Reconciler) runOnMaster(ctx context.Context, master models.InstanceStatus, purpose, script string, secret bool) (string, error) {
+	run := r.runCommand
+	if secret {
+		run = r.runSecretCommand
+	}
+	result, err := run(ctx, purpose, []string{master.InstanceID}, script)
+	if err != nil {
+		return "", err
+	}
+	res := result.Instances[master.InstanceID]
+	if res == nil {
+		return "", fmt.Errorf("command returned no result")
+	}
+	if res.Status != "Success" {
+		return "", fmt.Errorf("command failed: %s", res.Error)
+	}
+	return res.Output, nil
+}
+
+// parseCertificateExpiry parses the output of certificateScript
+func parseCertificateExpiry(node, output string) ([]models.CertificateExpiry, error) {
+	var certs []models.CertificateExpiry
+	for _, line := range strings.Split(strings.TrimSpace(output), "\n") {
+		name, date, ok := strings.Cut(strings.TrimSpace(line), " ")
+		if !ok {
+			continue
+		}
+		expiresAt, err := time.Parse(opensslDateLayout, strings.TrimSpace(date))
+		if err != nil {
+			return nil, fmt.Errorf("unexpected expiry date of %s on %s: %q", name, node, date)
+		}
+		certs = append(certs, models.CertificateExpiry{Node: node, Name: name, ExpiresAt: expiresAt.UTC()})
+	}
+	if len(certs) == 0 {
+		return nil, fmt.Errorf("no certificates found on %s", node)
+	}
+	return certs, nil
+}
+
+// kubeconfigExpiry returns the expiry of the client certificate embedded in
+// a kubeconfig
+func kubeconfigExpiry(kubeconfig []byte) (*time.Time, error) {
+	m := clientCertDataPattern.FindSubmatch(kubeconfig)
+	if m == nil {
+		return nil, fmt.Errorf("no client certificate")
+	}
+	data, err := base64.StdEncoding.DecodeString(string(m[1]))
+	if err != nil {
+		return nil, fmt.Errorf("invalid client certificate: %w", err)
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("invalid client certificate: not PEM encoded")
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("invalid client certificate: %w", err)
+	}
+	expiresAt := cert.NotAfter.UTC()
+	return &expiresAt, nil
+}
+
+// earliestCertificate returns the first expiry among certs
+func earliestCertificate(certs []models.CertificateExpiry) *time.Time {
+	var earliest *time.Time
+	for i := range certs {
+		if earliest == nil || certs[i].ExpiresAt.Before(*earliest) {
+			earliest = &certs[i].ExpiresAt
+		}
+	}
+	return earliest
+}
@@ -0,0 +1,57 @@
+package controller
+
+import (
+	"testing"
+	"time"
+
+	"github.com/madhouselabs/goman/pkg/models"
+)
+
+func TestParseCertificateExpiry(t *testing.T) {
+	output := "client-admin Mar  5 10:20:30 2027 GMT\nserving-kube-apiserver Dec 24 08:00:00 2026 GMT\n"
+	certs, err := parseCertificateExpiry("demo-master-0", output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(certs) != 2 {
+		t.Fatalf("got %d certificates, want 2", len(certs))
+	}
+	want := time.Date(2027, 3, 5, 10, 20, 30, 0, time.UTC)
+	if certs[0].Name != "client-admin" || !certs[0].ExpiresAt.Equal(want) {
+		t.Errorf("unexpected first certificate: %+v", certs[0])
+	}
+
+	earliest := earliestCertificate(certs)
+	if earliest == nil || !earliest.Equal(time.Date(2026, 12, 24, 8, 0, 0, 0, time.UTC)) {
+		t.Errorf("earliest = %v, want the serving certificate", earliest)
+	}
+
+	if _, err := parseCertificateExpiry("demo-master-0", "\n"); err == nil {
+		t.Error("expected an error without certificates")
+	}
+}
+
+func TestCertificateWarning(t *testing.T) {
+	now := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	expired := now.Add(-time.Hour)
+	soon := now.Add(10 * 24 * time.Hour)
+	later := now.Add(200 * 24 * time.Hour)
+
+	if got := models.CertificateWarning(nil, now); got != "" {
+		t.Errorf("unknown expiry warned: %q", got)
+	}
+	if got := models.CertificateWarning(&later, now); got != "" {
+		t.Errorf("distant expiry warned: %q", got)
+	}
+	if got := models.CertificateWarning(&soon, now); got != "certificates expire in 10 day(s)" {
+		t.Errorf("soon: got %q", got)
+	}
+	if got := models.CertificateWarning(&expired, now); got != "certificates expired on 2026-09-30" {
+		t.Errorf("expired: got %q", got)
+	}
+
+	status := &models.CertificateStatus{ExpiresAt: &later, KubeconfigExpiresAt: &soon}
+	if got := status.EarliestExpiry(); got == nil || !got.Equal(soon) {
+		t.Errorf("EarliestExpiry = %v, want the kubeconfig expiry", got)
+	}
+}
@@ -0,0 +1,92 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/madhouselabs/goman/pkg/logger"
+	"github.com/madhouselabs/goman/pkg/models"
+	"github.com/madhouselabs/goman/pkg/provider"
+)
+
+// serviceHealthTTL is how long the health of a cloud service is reused
+const serviceHealthTTL = 5 * time.Minute
+
+// serviceHealth is the cached health of a cloud service
+type serviceHealth struct {
+	events    []provider.ServiceHealthEvent
+	checkedAt time.Time
+}
+
+// noteCloudFailure counts a reconcile that failed on cloud throttling or
+// service errors. Once they repeat, the cluster is marked
+// CloudProviderDegraded, cross-checked with the provider's service health
+// where it publishes it, and the condition is returned. Any other failure
+// starts the count over.
+func (r *Reconciler) noteCloudFailure(ctx context.Context, cluster *models.ClusterResource, err error) *models.Condition {
+	opErr, ok := provider.AsOperationError(err)
+	if !ok || (opErr.Class != provider.ErrorClassThrottling && opErr.Class != provider.ErrorClassTransient) {
+		clearCloudFailures(cluster)
+		return nil
+	}
+	cluster.Status.CloudFailures++
+	if cluster.Status.CloudFailures < r.settings.CloudDegradedAfter {
+		return nil
+	}
+
+	condition := models.Condition{
+		Type:   models.ConditionCloudProviderDegraded,
+		Status: "True",
+		Reason: models.ReasonCloudServiceErrors,
+		Message: fmt.Sprintf("%d reconciles in a row failed on %s errors from %s %s (%s); this looks like a problem on the %s side, goman retries automatically",
+			cluster.Status.CloudFailures, strings.ToLower(string(opErr.Class)), opErr.Service, opErr.Operation, opErr.Code, r.provider.Name()),
+	}
+	if events := r.serviceHealth(ctx, opErr.Service); len(events) > 0 {
+		var issues []string
+		for _, e := range events {
+			issues = append(issues, fmt.Sprintf("%s since %s", e.EventType, e.StartTime.Format("2006-01-02 15:04 MST")))
+		}
+		condition.Reason = models.ReasonCloudHealthEvent
+		condition.Message = fmt.Sprintf("%s reports an open issue with %s in %s: %s. %d reconciles in a row failed on %s %s (%s); goman retries automatically",
+			r.provider.Name(), opErr.Service, r.provider.Region(), strings.Join(issues, ", "),
+			cluster.Status.CloudFailures, opErr.Service, opErr.Operation, opErr.Code)
+	}
+	logger.Infof(ctx, "[RECONCILE] Cluster %s: %s", cluster.Name, condition.Message)
+	cluster.Status.SetCondition(condition)
+	return &condition
+}
+
+// clearCloudFailures drops the failure count and the CloudProviderDegraded
+// condition
+func clearCloudFailures(cluster *models.ClusterResource) {
+	cluster.Status.CloudFailures = 0
+	cluster.Status.RemoveCondition(models.ConditionCloudProviderDegraded,
+		models.ReasonCloudServiceErrors, models.ReasonCloudHealthEvent)
+}
+
+// serviceHealth returns the open issues the provider reports for a cloud
+// service, or nothing when it publishes no health or can't be asked
+func (r *Reconciler) serviceHealth(ctx context.Context, service string) []provider.ServiceHealthEvent {
+	checker, ok := r.provider.(provider.ServiceHealthChecker)
+	if !ok {
+		return nil
+	}
+
+	r.healthMu.Lock()
+	defer r.healthMu.Unlock()
+	if cached, ok := r.health[service]; ok && time.Since(cached.checkedAt) < serviceHealthTTL {
+		return cached.events
+	}
+
+	events, err := checker.ServiceHealth(ctx, []string{service})
+	if err != nil {
+		logger.Warnf(ctx, "[RECONCILE] Could not check the health of %s: %v", service, err)
+	}
+	if r.health == nil {
+		r.health = make(map[string]serviceHealth)
+	}
+	r.health[service] = serviceHealth{events: events, checkedAt: time.Now()}
+	return events
+}
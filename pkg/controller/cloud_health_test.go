@@ -0,0 +1,80 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/madhouselabs/goman/pkg/models"
+	"github.com/madhouselabs/goman/pkg/provider"
+)
+
+// healthProvider reports fixed service health; other provider methods are
+// not used by the code under test
+type healthProvider struct {
+	provider.Provider
+	events []provider.ServiceHealthEvent
+	calls  int
+}
+
+func (p *healthProvider) Name() string   { return "aws" }
+func (p *healthProvider) Region() string { return "ap-south-1" }
+
+func (p *healthProvider) ServiceHealth(ctx context.Context, services []string) ([]provider.ServiceHealthEvent, error) {
+	p.calls++
+	return p.events, nil
+}
+
+func cloudError(class provider.ErrorClass) error {
+	return fmt.Errorf("failed to create instance: %w", &provider.OperationError{
+		Service: "ec2", Operation: "RunInstances", Code: "InternalError", Class: class, Err: errors.New("boom"),
+	})
+}
+
+func TestNoteCloudFailure(t *testing.T) {
+	p := &healthProvider{}
+	r := &Reconciler{provider: p, settings: DefaultSettings()}
+	cluster := &models.ClusterResource{}
+
+	for i := 1; i < r.settings.CloudDegradedAfter; i++ {
+		if c := r.noteCloudFailure(context.Background(), cluster, cloudError(provider.ErrorClassTransient)); c != nil {
+			t.Fatalf("failure %d: degraded before %d failures", i, r.settings.CloudDegradedAfter)
+		}
+	}
+	c := r.noteCloudFailure(context.Background(), cluster, cloudError(provider.ErrorClassThrottling))
+	if c == nil || c.Reason != models.ReasonCloudServiceErrors {
+		t.Fatalf("condition = %+v, want reason %s", c, models.ReasonCloudServiceErrors)
+	}
+	if cluster.Status.GetCondition(models.ConditionCloudProviderDegraded) == nil {
+		t.Fatal("condition not set in status")
+	}
+
+	// Other failures start over
+	r.noteCloudFailure(context.Background(), cluster, errors.New("nil pointer"))
+	if cluster.Status.CloudFailures != 0 || cluster.Status.GetCondition(models.ConditionCloudProviderDegraded) != nil {
+		t.Errorf("failures = %d, conditions = %v after a non-cloud error", cluster.Status.CloudFailures, cluster.Status.Conditions)
+	}
+	if p.calls != 1 {
+		t.Errorf("service health checked %d times, want once at the threshold", p.calls)
+	}
+}
+
+func TestNoteCloudFailureHealthEvent(t *testing.T) {
+	p := &healthProvider{events: []provider.ServiceHealthEvent{{
+		Service: "EC2", Region: "ap-south-1", EventType: "AWS_EC2_OPERATIONAL_ISSUE", StartTime: time.Now().Add(-time.Hour),
+	}}}
+	r := &Reconciler{provider: p, settings: DefaultSettings()}
+	r.settings.CloudDegradedAfter = 1
+
+	for i := 0; i < 2; i++ {
+		c := r.noteCloudFailure(context.Background(), &models.ClusterResource{}, cloudError(provider.ErrorClassTransient))
+		if c == nil || c.Reason != models.ReasonCloudHealthEvent {
+			t.Fatalf("condition = %+v, want reason %s", c, models.ReasonCloudHealthEvent)
+		}
+	}
+	if p.calls != 1 {
+		t.Errorf("service health checked %d times, want 1 (cached)", p.calls)
+	}
+}
@@ -0,0 +1,140 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/madhouselabs/goman/pkg/logger"
+	"github.com/madhouselabs/goman/pkg/provider"
+	"github.com/madhouselabs/goman/pkg/storage"
+	"gopkg.in/yaml.v3"
+)
+
+type commandClusterKey struct{}
+
+// withCommandCluster tags a context with the cluster being reconciled, so
+// node commands run under it are recorded in that cluster's history
+func withCommandCluster(ctx context.Context, clusterName string) context.Context {
+	return context.WithValue(ctx, commandClusterKey{}, clusterName)
+}
+
+// runCommand runs a script on instances and records it in the command
+// history of the cluster
+func (r *Reconciler) runCommand(ctx context.Context, purpose string, instanceIDs []string, command string) (*provider.CommandResult, error) {
+	start := time.Now()
+	result, err := r.provider.GetComputeService().RunCommand(ctx, instanceIDs, command)
+	r.recordCommand(ctx, purpose, instanceIDs, start, result, err, true)
+	return result, err
+}
+
+// runSecretCommand is runCommand for scripts whose output contains
+// credentials, such as kubeconfigs and tokens; the output is not kept
+func (r *Reconciler) runSecretCommand(ctx context.Context, purpose string, instanceIDs []string, command string) (*provider.CommandResult, error) {
+	start := time.Now()
+	result, err := r.provider.GetComputeService().RunCommand(ctx, instanceIDs, command)
+	r.recordCommand(ctx, purpose, instanceIDs, start, result, err, false)
+	return result, err
+}
+
+// runOperation runs a named node operation and records it in the command
+// history of the cluster
+func (r *Reconciler) runOperation(ctx context.Context, instanceIDs []string, operation string, params map[string]string) (*provider.CommandResult, error) {
+	start := time.Now()
+	result, err := r.provider.GetComputeService().RunOperation(ctx, instanceIDs, operation, params)
+	r.recordCommand(ctx, operation, instanceIDs, start, result, err, true)
+	return result, err
+}
+
+// recordCommand stores a command record and its output. The history is
+// informational: failures to write it are logged, never returned.
+func (r *Reconciler) recordCommand(ctx context.Context, purpose string, instanceIDs []string, start time.Time, result *provider.CommandResult, runErr error, keepOutput bool) {
+	clusterName, _ := ctx.Value(commandClusterKey{}).(string)
+	if clusterName == "" {
+		return
+	}
+
+	record := storage.CommandRecord{
+		Time:      start.UTC(),
+		Cluster:   clusterName,
+		Purpose:   purpose,
+		Instances: instanceIDs,
+		Duration:  time.Since(start).Round(time.Millisecond),
+	}
+	output := ""
+	switch {
+	case runErr != nil:
+		record.Status = "Failed"
+		record.Error = runErr.Error()
+	case result != nil:
+		record.CommandID = result.CommandID
+		record.Status, record.Error, output = summarizeCommandResult(result)
+	}
+
+	key := storage.CommandRecordKey(clusterName, start, purpose)
+	storageService := r.provider.GetStorageService()
+	// The record outlives the reconcile's deadline if it just ran out
+	writeCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 10*time.Second)
+	defer cancel()
+
+	if keepOutput && output != "" {
+		if len(output) > storage.MaxCommandOutput {
+			output = output[:storage.MaxCommandOutput]
+			record.OutputTruncated = true
+		}
+		outputKey := storage.CommandOutputKey(key)
+		if err := storageService.PutObject(writeCtx, outputKey, []byte(output)); err != nil {
+			logger.Warnf(writeCtx, "[COMMANDS] Warning: Failed to store output of %s for %s: %v", purpose, clusterName, err)
+		} else {
+			record.OutputKey = outputKey
+		}
+	}
+
+	data, err := yaml.Marshal(record)
+	if err != nil {
+		logger.Warnf(writeCtx, "[COMMANDS] Warning: Failed to marshal command record: %v", err)
+		return
+	}
+	if err := storageService.PutObject(writeCtx, key, data); err != nil {
+		logger.Warnf(writeCtx, "[COMMANDS] Warning: Failed to record %s for %s: %v", purpose, clusterName, err)
+	}
+}
+
+// summarizeCommandResult returns the overall status, the errors and the
+// output of all instances, in instance order
+func summarizeCommandResult(result *provider.CommandResult) (status, errs, output string) {
+	ids := make([]string, 0, len(result.Instances))
+	for id := range result.Instances {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	status = result.Status
+	var messages []string
+	var b strings.Builder
+	for _, id := range ids {
+		res := result.Instances[id]
+		if res == nil {
+			continue
+		}
+		if res.Status != "Success" {
+			status = res.Status
+			if res.Error != "" {
+				messages = append(messages, fmt.Sprintf("%s: %s", id, res.Error))
+			}
+		}
+		if len(ids) > 1 {
+			fmt.Fprintf(&b, "=== %s (%s) ===\n", id, res.Status)
+		}
+		b.WriteString(res.Output)
+		if res.Output != "" && !strings.HasSuffix(res.Output, "\n") {
+			b.WriteString("\n")
+		}
+	}
+	if status == "" && len(ids) > 0 {
+		status = "Success"
+	}
+	return status, strings.Join(messages, "; "), b.String()
+}
@@ -0,0 +1,36 @@
+package controller
+
+import (
+	"testing"
+
+	"github.com/madhouselabs/goman/pkg/provider"
+)
+
+func TestSummarizeCommandResult(t *testing.T) {
+	result := &provider.CommandResult{
+		CommandID: "cmd-1",
+		Status:    "Success",
+		Instances: map[string]*provider.InstanceCommandResult{
+			"i-b": {InstanceID: "i-b", Status: "Failed", Output: "partial", Error: "exit status 1"},
+			"i-a": {InstanceID: "i-a", Status: "Success", Output: "ok\n"},
+		},
+	}
+
+	status, errs, output := summarizeCommandResult(result)
+	if status != "Failed" {
+		t.Errorf("status = %q, want Failed", status)
+	}
+	if errs != "i-b: exit status 1" {
+		t.Errorf("errors = %q", errs)
+	}
+	if want := "=== i-a (Success) ===\nok\n=== i-b (Failed) ===\npartial\n"; output != want {
+		t.Errorf("output = %q, want %q", output, want)
+	}
+
+	single := &provider.CommandResult{Instances: map[string]*provider.InstanceCommandResult{
+		"i-a": {InstanceID: "i-a", Status: "Success", Output: "done"},
+	}}
+	if status, _, output := summarizeCommandResult(single); status != "Success" || output != "done\n" {
+		t.Errorf("single instance: status %q output %q", status, output)
+	}
+}
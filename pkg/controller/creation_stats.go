@@ -0,0 +1,90 @@
+package controller
+
+import (
+	"context"
+	"time"
+
+	"github.com/madhouselabs/goman/pkg/logger"
+	"github.com/madhouselabs/goman/pkg/models"
+	"github.com/madhouselabs/goman/pkg/provider"
+	"github.com/madhouselabs/goman/pkg/storage"
+	"gopkg.in/yaml.v3"
+)
+
+// Metrics published for every cluster creation
+const (
+	MetricCreationTime   = "ClusterCreationTime"   // Seconds from creation to Running
+	MetricCreationBreach = "ClusterCreationBreach" // 1 if creationSLO was exceeded, else 0
+)
+
+// maxCreationTime is the longest time to Running recorded. Clusters created
+// before creation times were tracked become Running for the first time
+// when they are started again, which says nothing about provisioning.
+const maxCreationTime = 24 * time.Hour
+
+// recordCreation stores how long a new cluster took to become Running and
+// publishes it as a metric, so platform teams can alert on regressions
+func (r *Reconciler) recordCreation(ctx context.Context, cluster *models.ClusterResource, runningAt time.Time) {
+	record, ok := newCreationRecord(cluster, runningAt, r.settings.CreationSLO)
+	if !ok {
+		return
+	}
+
+	data, err := yaml.Marshal(record)
+	if err != nil {
+		logger.Warnf(ctx, "[SLO] Failed to marshal creation record: %v", err)
+		return
+	}
+	if err := r.provider.GetStorageService().PutObject(ctx, storage.CreationRecordKey(record), data); err != nil {
+		logger.Warnf(ctx, "[SLO] Failed to save creation record of cluster %s: %v", cluster.Name, err)
+	}
+	if record.WithinSLO() {
+		logger.Infof(ctx, "[SLO] Cluster %s became Running %s after creation", cluster.Name, record.Duration.Round(time.Second))
+	} else {
+		logger.Infof(ctx, "[SLO] Cluster %s took %s to become Running, over the creation objective of %s",
+			cluster.Name, record.Duration.Round(time.Second), record.SLO)
+	}
+
+	publisher, ok := r.provider.(provider.MetricPublisher)
+	if !ok {
+		return
+	}
+	dimensions := map[string]string{"Mode": record.Mode, "Region": record.Region}
+	breach := 0.0
+	if !record.WithinSLO() {
+		breach = 1
+	}
+	for _, metric := range []provider.Metric{
+		{Name: MetricCreationTime, Value: record.Duration.Seconds(), Unit: "Seconds", Dimensions: dimensions},
+		{Name: MetricCreationBreach, Value: breach, Unit: "Count", Dimensions: dimensions},
+	} {
+		if err := publisher.PublishMetric(ctx, metric); err != nil {
+			logger.Warnf(ctx, "[SLO] Failed to publish %s: %v", metric.Name, err)
+		}
+	}
+}
+
+// newCreationRecord describes the creation of a cluster that became Running
+// at runningAt. ok is false when the creation time is unknown or too long to
+// be a creation.
+func newCreationRecord(cluster *models.ClusterResource, runningAt time.Time, slo time.Duration) (storage.CreationRecord, bool) {
+	created := cluster.CreationTimestamp
+	d := runningAt.Sub(created)
+	if created.IsZero() || d <= 0 || d > maxCreationTime {
+		return storage.CreationRecord{}, false
+	}
+	mode := cluster.Spec.Mode
+	if mode != "ha" {
+		mode = "dev"
+	}
+	return storage.CreationRecord{
+		Cluster:      cluster.Name,
+		Mode:         mode,
+		Region:       cluster.Spec.Region,
+		InstanceType: cluster.Spec.InstanceType,
+		CreatedAt:    created,
+		RunningAt:    runningAt,
+		Duration:     d,
+		SLO:          slo,
+	}, true
+}
@@ -0,0 +1,37 @@
+package controller
+
+import (
+	"testing"
+	"time"
+
+	"github.com/madhouselabs/goman/pkg/models"
+)
+
+func TestNewCreationRecord(t *testing.T) {
+	created := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	cluster := &models.ClusterResource{}
+	cluster.Name = "prod"
+	cluster.CreationTimestamp = created
+	cluster.Spec.Mode = "developer"
+	cluster.Spec.Region = "ap-south-1"
+
+	record, ok := newCreationRecord(cluster, created.Add(12*time.Minute), 10*time.Minute)
+	if !ok {
+		t.Fatal("no record for a 12m creation")
+	}
+	if record.Duration != 12*time.Minute || record.Mode != "dev" || record.WithinSLO() {
+		t.Errorf("record = %+v, want 12m in dev mode over the SLO", record)
+	}
+
+	for name, runningAt := range map[string]time.Time{
+		"restarted old cluster": created.Add(48 * time.Hour),
+		"clock skew":            created.Add(-time.Minute),
+	} {
+		if _, ok := newCreationRecord(cluster, runningAt, 0); ok {
+			t.Errorf("%s: recorded a creation", name)
+		}
+	}
+	if _, ok := newCreationRecord(&models.ClusterResource{}, created, 0); ok {
+		t.Error("recorded a creation without a creation time")
+	}
+}
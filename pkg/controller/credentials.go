@@ -0,0 +1,220 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/madhouselabs/goman/pkg/logger"
+	"github.com/madhouselabs/goman/pkg/models"
+	"github.com/madhouselabs/goman/pkg/provider"
+)
+
+// rotateClientCAScript replaces the CA signing client certificates with a
+// new self-signed one. The other CAs are kept, so the CA hash in join
+// tokens stays the same. Servers load the new CA, and issue client
+// certificates signed by it, when they restart.
+const rotateClientCAScript = `set -e
+TLS=/var/lib/rancher/k3s/server/tls
+DIR=/var/lib/rancher/k3s/server/rotate-ca
+rm -rf $DIR
+mkdir -p $DIR
+cp -a $TLS/. $DIR/
+openssl ecparam -name prime256v1 -genkey -noout -out $DIR/client-ca.key
+openssl req -x509 -new -key $DIR/client-ca.key -sha256 -days 3650 -subj "/CN=k3s-client-ca@$(date +%s)" -out $DIR/client-ca.crt
+if [ -f $DIR/client-ca.nochain.crt ]; then cp $DIR/client-ca.crt $DIR/client-ca.nochain.crt; fi
+k3s certificate rotate-ca --path=$DIR --force
+rm -rf $DIR
+`
+
+// revokeReadOnlyScript deletes the ServiceAccount of the read-only
+// kubeconfig, which invalidates every token issued for it; it is created
+// again when the read-only kubeconfig is reissued
+const revokeReadOnlyScript = "k3s kubectl -n kube-system delete serviceaccount " + readOnlyAccount + " --ignore-not-found >/dev/null\n"
+
+// reconcileCredentialRotation rotates the cluster's credentials once
+// requested: the K3s token, then the client CA, then every master and
+// worker is restarted with them one per reconcile, and finally new admin
+// and read-only kubeconfigs are stored. Returns true while the rotation is
+// in progress, so nodes are not added meanwhile.
+func (r *Reconciler) reconcileCredentialRotation(ctx context.Context, cluster *models.ClusterResource) (bool, error) {
+	requested := cluster.Spec.CredentialRotationRequestedAt
+	if requested == nil {
+		return false, nil
+	}
+	st := cluster.Status.CredentialRotation
+	if st == nil || st.RequestedAt.Before(*requested) {
+		st = &models.CredentialRotationStatus{RequestedAt: *requested, Phase: models.CredentialRotationToken}
+		cluster.Status.CredentialRotation = st
+		if err := models.CheckCredentialRotation(cluster.Spec.TargetK3sVersion()); err != nil {
+			st.Phase = models.CredentialRotationRefused
+			st.Message = err.Error()
+			logger.Warnf(ctx, "[CREDENTIALS] Not rotating credentials of cluster %s: %v", cluster.Name, err)
+			return false, nil
+		}
+		logger.Infof(ctx, "[CREDENTIALS] Rotating credentials of cluster %s", cluster.Name)
+	}
+	if !st.Active() {
+		return false, nil
+	}
+
+	var masters, workers []models.InstanceStatus
+	for _, inst := range cluster.Status.Instances {
+		if inst.State != "running" {
+			continue
+		}
+		if inst.Role == "master" {
+			masters = append(masters, inst)
+		} else {
+			workers = append(workers, inst)
+		}
+	}
+	if len(masters) == 0 {
+		return true, fmt.Errorf("no running master to rotate credentials on")
+	}
+
+	if err := r.credentialRotationStep(ctx, cluster, st, masters, workers); err != nil {
+		st.Message = err.Error()
+		return true, fmt.Errorf("failed to rotate credentials: %w", err)
+	}
+	st.Message = ""
+	return st.Active(), nil
+}
+
+// credentialRotationStep runs the current phase of a rotation, or restarts
+// the next node of a restart phase, and moves on to the next phase
+func (r *Reconciler) credentialRotationStep(ctx context.Context, cluster *models.ClusterResource, st *models.CredentialRotationStatus, masters, workers []models.InstanceStatus) error {
+	switch st.Phase {
+	case models.CredentialRotationToken:
+		if err := r.rotateToken(ctx, cluster, masters[0]); err != nil {
+			return err
+		}
+		st.Phase = models.CredentialRotationClientCA
+
+	case models.CredentialRotationClientCA:
+		if _, err := r.runOnMaster(ctx, masters[0], "rotate-client-ca", rotateClientCAScript, false); err != nil {
+			return fmt.Errorf("failed to rotate the client CA on %s: %w", masters[0].Name, err)
+		}
+		logger.Infof(ctx, "[CREDENTIALS] Rotated the client CA of cluster %s", cluster.Name)
+		st.Phase = models.CredentialRotationServers
+
+	case models.CredentialRotationServers, models.CredentialRotationAgents:
+		nodes, next := masters, models.CredentialRotationAgents
+		if st.Phase == models.CredentialRotationAgents {
+			nodes, next = workers, models.CredentialRotationKubeconfig
+		}
+		for _, node := range nodes {
+			if st.HasNode(node.InstanceID) {
+				continue
+			}
+			if err := r.updateNodeToken(ctx, cluster, node); err != nil {
+				return err
+			}
+			st.Nodes = append(st.Nodes, node.InstanceID)
+			return nil
+		}
+		st.Phase = next
+		st.Nodes = nil
+
+	case models.CredentialRotationKubeconfig:
+		if err := r.storeRotatedKubeconfig(ctx, cluster, masters[0]); err != nil {
+			return err
+		}
+		now := time.Now()
+		st.Phase = models.CredentialRotationCompleted
+		st.CompletedAt = &now
+		logger.Infof(ctx, "[CREDENTIALS] Rotated credentials of cluster %s", cluster.Name)
+	}
+	return nil
+}
+
+// rotateToken moves the cluster to a new token with 'k3s token rotate' on a
+// master. The new token is stored before the rotation and only replaces the
+// server and agent tokens after it, so an interrupted rotation resumes with
+// the same one.
+func (r *Reconciler) rotateToken(ctx context.Context, cluster *models.ClusterResource, master models.InstanceStatus) error {
+	secretService := r.provider.GetSecretService()
+	next, err := secretService.GetSecret(ctx, cluster.Name, provider.SecretServerTokenNext)
+	if err != nil && !errors.Is(err, provider.ErrNotFound) {
+		return fmt.Errorf("failed to read the new token: %w", err)
+	}
+	token := strings.TrimSpace(string(next))
+	if token == "" {
+		if token, err = r.generateToken(); err != nil {
+			return fmt.Errorf("failed to generate K3s token: %w", err)
+		}
+		if err := secretService.PutSecret(ctx, cluster.Name, provider.SecretServerTokenNext, []byte(token)); err != nil {
+			return fmt.Errorf("failed to store the new token: %w", err)
+		}
+	}
+
+	if err := r.runTokenOperation(ctx, cluster, master, provider.OperationRotateToken); err != nil {
+		return fmt.Errorf("failed to rotate the token on %s: %w", master.Name, err)
+	}
+	if err := r.saveTokens(ctx, cluster.Name, token, token); err != nil {
+		return err
+	}
+	cluster.Status.K3sServerToken = token
+	cluster.Status.K3sAgentToken = token
+	if err := secretService.DeleteSecret(ctx, cluster.Name, provider.SecretServerTokenNext); err != nil && !errors.Is(err, provider.ErrNotFound) {
+		logger.Warnf(ctx, "[CREDENTIALS] Warning: Failed to delete the rotated token of cluster %s: %v", cluster.Name, err)
+	}
+	logger.Infof(ctx, "[CREDENTIALS] Rotated the K3s token of cluster %s", cluster.Name)
+	return nil
+}
+
+// updateNodeToken restarts the K3s server or agent of a node with the
+// current token, which also loads the new client CA
+func (r *Reconciler) updateNodeToken(ctx context.Context, cluster *models.ClusterResource, node models.InstanceStatus) error {
+	logger.Infof(ctx, "[CREDENTIALS] Restarting %s with the new credentials", node.Name)
+	if err := r.runTokenOperation(ctx, cluster, node, provider.OperationUpdateToken); err != nil {
+		return fmt.Errorf("failed to restart %s with the new credentials: %w", node.Name, err)
+	}
+	return nil
+}
+
+// runTokenOperation runs a token operation on a node, which reads the
+// tokens from the secret backend itself
+func (r *Reconciler) runTokenOperation(ctx context.Context, cluster *models.ClusterResource, node models.InstanceStatus, operation string) error {
+	result, err := r.runOperation(ctx, []string{node.InstanceID}, operation, map[string]string{
+		"ClusterName": cluster.Name,
+	})
+	if err != nil {
+		return err
+	}
+	res := result.Instances[node.InstanceID]
+	if res == nil {
+		return fmt.Errorf("command returned no result")
+	}
+	if res.Status != "Success" {
+		return fmt.Errorf("command failed: %s", strings.TrimSpace(res.Error+" "+res.Output))
+	}
+	return nil
+}
+
+// storeRotatedKubeconfig revokes the read-only kubeconfig's tokens and
+// stores the admin kubeconfig of a restarted master, signed by the new
+// client CA. The read-only kubeconfig is reissued by the next reconcile.
+func (r *Reconciler) storeRotatedKubeconfig(ctx context.Context, cluster *models.ClusterResource, master models.InstanceStatus) error {
+	kubeconfig, err := r.runOnMaster(ctx, master, "read-kubeconfig", revokeReadOnlyScript+kubeconfigScript, true)
+	if err != nil {
+		return fmt.Errorf("failed to read kubeconfig of %s: %w", master.Name, err)
+	}
+	updated := []byte(kubeconfig)
+	if cluster.Status.APIEndpoint != "" {
+		updated = kubeconfigServerPattern.ReplaceAll(updated, []byte("${1}"+cluster.Status.APIEndpoint))
+	}
+	if err := r.provider.GetSecretService().PutSecret(ctx, cluster.Name, provider.SecretKubeconfig, updated); err != nil {
+		return fmt.Errorf("failed to save kubeconfig: %w", err)
+	}
+	// The fresh kubeconfig has a single server; the other masters are listed
+	// again, and the certificate check reads its expiry
+	cluster.Status.APIEndpoints = nil
+	cluster.Status.ReadOnlyKubeconfigExpiresAt = nil
+	if cluster.Status.Certificates != nil {
+		cluster.Status.Certificates.CheckedAt = time.Time{}
+	}
+	return nil
+}
@@ -0,0 +1,156 @@
+package controller
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/madhouselabs/goman/pkg/models"
+	"github.com/madhouselabs/goman/pkg/provider"
+)
+
+// rotationCompute records the operations and scripts run on each node,
+// failing operations on the instances in fail
+type rotationCompute struct {
+	provider.ComputeService
+	steps []string
+	fail  map[string]bool
+}
+
+func (c *rotationCompute) result(id string, output string) *provider.CommandResult {
+	res := &provider.InstanceCommandResult{InstanceID: id, Status: "Success", Output: output}
+	if c.fail[id] {
+		res.Status, res.Error = "Failed", "k3s-agent not ready after the restart"
+	}
+	return &provider.CommandResult{Status: res.Status, Instances: map[string]*provider.InstanceCommandResult{id: res}}
+}
+
+func (c *rotationCompute) RunOperation(ctx context.Context, ids []string, operation string, params map[string]string) (*provider.CommandResult, error) {
+	c.steps = append(c.steps, operation+" "+ids[0]+" "+params["ClusterName"])
+	return c.result(ids[0], ""), nil
+}
+
+func (c *rotationCompute) RunCommand(ctx context.Context, ids []string, command string) (*provider.CommandResult, error) {
+	switch {
+	case strings.Contains(command, "k3s certificate rotate-ca"):
+		c.steps = append(c.steps, "rotate-ca "+ids[0])
+	case strings.Contains(command, "delete serviceaccount goman-viewer"):
+		c.steps = append(c.steps, "kubeconfig "+ids[0])
+	}
+	return c.result(ids[0], "apiVersion: v1\nclusters:\n- cluster:\n    server: https://10.0.0.1:6443\n"), nil
+}
+
+// rotationSecrets is secretMap with deletes
+type rotationSecrets struct {
+	secretMap
+}
+
+func (s *rotationSecrets) DeleteSecret(ctx context.Context, clusterName, name string) error {
+	delete(s.secrets, clusterName+"/"+name)
+	return nil
+}
+
+func (s *rotationSecrets) Backend() string { return provider.SecretBackendStorage }
+
+func TestReconcileCredentialRotation(t *testing.T) {
+	compute := &rotationCompute{fail: map[string]bool{"i-3": true}}
+	secrets := &rotationSecrets{secretMap{secrets: map[string][]byte{
+		"demo/" + provider.SecretServerToken: []byte("old"),
+		"demo/" + provider.SecretAgentToken:  []byte("old"),
+	}}}
+	r := &Reconciler{provider: &fakeProvider{compute: compute, secrets: secrets}, settings: DefaultSettings()}
+	cluster := &models.ClusterResource{Name: "demo"}
+	requested := time.Now().Add(-time.Minute)
+	cluster.Spec.CredentialRotationRequestedAt = &requested
+	cluster.Status.APIEndpoint = "https://demo.example.com:6443"
+	cluster.Status.APIEndpoints = []string{"https://10.0.0.1:6443", "https://10.0.0.2:6443"}
+	expiresAt := time.Now().Add(time.Hour)
+	cluster.Status.ReadOnlyKubeconfigExpiresAt = &expiresAt
+	cluster.Status.Instances = []models.InstanceStatus{
+		{InstanceID: "i-1", Name: "demo-master-0", Role: "master", State: "running"},
+		{InstanceID: "i-2", Name: "demo-master-1", Role: "master", State: "running"},
+		{InstanceID: "i-3", Name: "demo-default-0", Role: "worker", State: "running"},
+		{InstanceID: "i-4", Name: "demo-default-1", Role: "worker", State: "stopped"},
+	}
+	ctx := context.Background()
+
+	// A worker failing to restart stops the rotation until it succeeds
+	var err error
+	for i := 0; i < 10; i++ {
+		if _, err = r.reconcileCredentialRotation(ctx, cluster); err != nil {
+			break
+		}
+	}
+	st := cluster.Status.CredentialRotation
+	if err == nil || st.Phase != models.CredentialRotationAgents || !strings.Contains(st.Message, "demo-default-0") {
+		t.Fatalf("after the failure: %v, %+v", err, st)
+	}
+	delete(compute.fail, "i-3")
+	for i := 0; i < 10; i++ {
+		rotating, err := r.reconcileCredentialRotation(ctx, cluster)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !rotating {
+			break
+		}
+	}
+
+	want := []string{
+		"rotate-token i-1 demo",
+		"rotate-ca i-1",
+		"update-token i-1 demo",
+		"update-token i-2 demo",
+		"update-token i-3 demo",
+		"update-token i-3 demo",
+		"kubeconfig i-1",
+	}
+	if strings.Join(compute.steps, "\n") != strings.Join(want, "\n") {
+		t.Errorf("steps:\n%s", strings.Join(compute.steps, "\n"))
+	}
+	if st.Phase != models.CredentialRotationCompleted || st.CompletedAt == nil || st.Message != "" {
+		t.Errorf("status = %+v", st)
+	}
+
+	// The new token replaced both tokens and is no longer pending
+	token := cluster.Status.K3sServerToken
+	if token == "" || token == "old" || cluster.Status.K3sAgentToken != token ||
+		string(secrets.secrets["demo/"+provider.SecretServerToken]) != token || string(secrets.secrets["demo/"+provider.SecretAgentToken]) != token {
+		t.Errorf("tokens: status %q/%q, secrets %v", token, cluster.Status.K3sAgentToken, secrets.secrets)
+	}
+	if _, ok := secrets.secrets["demo/"+provider.SecretServerTokenNext]; ok {
+		t.Error("new token still stored for the rotation")
+	}
+	if kubeconfig := string(secrets.secrets["demo/"+provider.SecretKubeconfig]); !strings.Contains(kubeconfig, "server: https://demo.example.com:6443") {
+		t.Errorf("stored kubeconfig:\n%s", kubeconfig)
+	}
+	if cluster.Status.APIEndpoints != nil || cluster.Status.ReadOnlyKubeconfigExpiresAt != nil {
+		t.Error("kubeconfig endpoints or read-only kubeconfig kept")
+	}
+
+	// Nothing to do until rotated again
+	compute.steps = nil
+	if rotating, err := r.reconcileCredentialRotation(ctx, cluster); rotating || err != nil || len(compute.steps) != 0 {
+		t.Errorf("completed rotation ran %v, %v, %v", rotating, err, compute.steps)
+	}
+}
+
+func TestCredentialRotationRefused(t *testing.T) {
+	r := &Reconciler{settings: DefaultSettings()}
+	cluster := &models.ClusterResource{Name: "demo"}
+	requested := time.Now()
+	cluster.Spec.CredentialRotationRequestedAt = &requested
+	cluster.Spec.K3sVersion = "v1.27.9+k3s1"
+
+	rotating, err := r.reconcileCredentialRotation(context.Background(), cluster)
+	if rotating || err != nil {
+		t.Fatalf("reconcile = %v, %v", rotating, err)
+	}
+	if st := cluster.Status.CredentialRotation; st == nil || st.Phase != models.CredentialRotationRefused || !strings.Contains(st.Message, models.MinTokenRotationK3sVersion) {
+		t.Errorf("status = %+v", st)
+	}
+	if err := models.CheckCredentialRotation(models.DefaultK3sVersion); err != nil {
+		t.Errorf("default K3s version refused: %v", err)
+	}
+}
@@ -0,0 +1,63 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/madhouselabs/goman/pkg/logger"
+	"github.com/madhouselabs/goman/pkg/models"
+)
+
+// debugPhases are the phases a debug run can be forced into, those with a
+// step of their own in reconcileCluster
+var debugPhases = []string{
+	models.ClusterPhasePending,
+	models.ClusterPhaseProvisioning,
+	models.ClusterPhaseInstalling,
+	models.ClusterPhaseConfiguring,
+	models.ClusterPhaseRunning,
+}
+
+// DebugOptions change a reconcile for debugging it from a developer's
+// machine with goman debug reconcile
+type DebugOptions struct {
+	// Phase runs the step of this phase whatever the cluster's phase is
+	Phase string
+
+	// DryRun is set when the provider skips changes; notifications are not
+	// sent, as they would report changes that were not made
+	DryRun bool
+}
+
+// SetDebugOptions applies debug options to the following reconciles
+func (r *Reconciler) SetDebugOptions(opts DebugOptions) error {
+	if opts.Phase != "" {
+		valid := make([]string, 0, len(debugPhases))
+		found := false
+		for _, phase := range debugPhases {
+			valid = append(valid, phase)
+			if strings.EqualFold(opts.Phase, phase) {
+				opts.Phase = phase
+				found = true
+			}
+		}
+		if !found {
+			return fmt.Errorf("unknown phase %q, must be one of %s", opts.Phase, strings.Join(valid, ", "))
+		}
+	}
+	if opts.DryRun {
+		r.notifier = nil
+	}
+	r.debug = opts
+	return nil
+}
+
+// forceDebugPhase puts a cluster in the phase a debug run asked for
+func (r *Reconciler) forceDebugPhase(ctx context.Context, cluster *models.ClusterResource) {
+	if r.debug.Phase == "" || cluster.Status.Phase == r.debug.Phase {
+		return
+	}
+	logger.Debugf(ctx, "[DEBUG] Running phase %s of cluster %s (was %s)", r.debug.Phase, cluster.Name, cluster.Status.Phase)
+	cluster.Status.Phase = r.debug.Phase
+}
@@ -0,0 +1,41 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	"github.com/madhouselabs/goman/pkg/models"
+)
+
+func TestSetDebugOptions(t *testing.T) {
+	r := &Reconciler{notifier: NewNotifier(nil)}
+	if err := r.SetDebugOptions(DebugOptions{Phase: "Stopped"}); err == nil {
+		t.Error("phase without a step of its own accepted")
+	}
+	if err := r.SetDebugOptions(DebugOptions{Phase: "installing"}); err != nil {
+		t.Fatalf("SetDebugOptions: %v", err)
+	}
+	if r.debug.Phase != models.ClusterPhaseInstalling || r.notifier == nil {
+		t.Errorf("phase %q, notifier %v", r.debug.Phase, r.notifier)
+	}
+
+	cluster := &models.ClusterResource{Name: "demo"}
+	cluster.Status.Phase = models.ClusterPhaseRunning
+	r.forceDebugPhase(context.Background(), cluster)
+	if cluster.Status.Phase != models.ClusterPhaseInstalling {
+		t.Errorf("phase = %q, want Installing", cluster.Status.Phase)
+	}
+
+	// Dry runs send no notifications
+	if err := r.SetDebugOptions(DebugOptions{DryRun: true}); err != nil {
+		t.Fatalf("SetDebugOptions: %v", err)
+	}
+	if r.notifier != nil {
+		t.Error("notifier kept in a dry run")
+	}
+	cluster.Status.Phase = models.ClusterPhaseRunning
+	r.forceDebugPhase(context.Background(), cluster)
+	if cluster.Status.Phase != models.ClusterPhaseRunning {
+		t.Errorf("phase changed without a debug phase: %q", cluster.Status.Phase)
+	}
+}
@@ -0,0 +1,49 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/madhouselabs/goman/pkg/logger"
+	"github.com/madhouselabs/goman/pkg/models"
+	"github.com/madhouselabs/goman/pkg/provider"
+)
+
+// dedicatedNetworkDeleteTimeout bounds how long a deletion waits for the
+// dedicated VPC to be deleted before leaving it behind
+const dedicatedNetworkDeleteTimeout = 30 * time.Minute
+
+// deleteDedicatedNetwork deletes the dedicated VPC of a cluster being
+// deleted. It returns false while the VPC cannot be deleted yet, because its
+// instances are still terminating or its NAT gateway is being deleted, so
+// the deletion is requeued. Other failures are retried until
+// dedicatedNetworkDeleteTimeout, then the VPC is left for deletion by hand.
+func (r *Reconciler) deleteDedicatedNetwork(ctx context.Context, cluster *models.ClusterResource) bool {
+	if !cluster.Spec.Network.DedicatedVPC {
+		return true
+	}
+	networks, ok := r.provider.GetComputeService().(provider.DedicatedNetworkService)
+	if !ok {
+		return true
+	}
+
+	err := networks.DeleteDedicatedNetwork(ctx, cluster.Spec.Region, cluster.Name)
+	if err == nil {
+		logger.Infof(ctx, "[DELETE] Deleted dedicated VPC of cluster %s", cluster.Name)
+		return true
+	}
+	if cluster.DeletionTimestamp != nil && time.Since(*cluster.DeletionTimestamp) > dedicatedNetworkDeleteTimeout {
+		logger.Infof(ctx, "[DELETE] Giving up deleting the dedicated VPC of cluster %s: %v", cluster.Name, err)
+		r.events.Warning(ctx, cluster.Name, EventDeleting, "", "Dedicated VPC not deleted after %s, delete it by hand: %v", dedicatedNetworkDeleteTimeout, err)
+		return true
+	}
+	if errors.Is(err, provider.ErrNetworkInUse) {
+		logger.Infof(ctx, "[DELETE] Dedicated VPC of cluster %s not deleted yet: %v", cluster.Name, err)
+		cluster.Status.Message = "Waiting for the instances to terminate to delete the dedicated VPC"
+	} else {
+		logger.Warnf(ctx, "[DELETE] Failed to delete dedicated VPC of cluster %s: %v", cluster.Name, err)
+		cluster.Status.Message = "Failed to delete the dedicated VPC, retrying: " + err.Error()
+	}
+	return false
+}
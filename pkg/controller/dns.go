@@ -0,0 +1,139 @@
+package controller
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/madhouselabs/goman/pkg/logger"
+	"github.com/madhouselabs/goman/pkg/models"
+	"github.com/madhouselabs/goman/pkg/provider"
+)
+
+// reconcileDNS applies the DNS spec to running nodes that do not have the
+// current configuration yet. When the spec changes, one master applies the
+// cluster-wide part (coredns-custom and NodeLocal DNSCache) first; every
+// node then gets the managed resolv.conf with the upstream resolvers.
+// Removing the spec reverts to the K3s defaults once.
+func (r *Reconciler) reconcileDNS(ctx context.Context, cluster *models.ClusterResource) error {
+	spec := cluster.Spec.DNS
+	if err := models.ValidateDNS(spec); err != nil {
+		return fmt.Errorf("invalid dns spec: %w", err)
+	}
+	hash := models.DNSConfigHash(spec)
+	status := cluster.Status.DNS
+	if status == nil && hash == "" {
+		// DNS was never customized
+		return nil
+	}
+	changed := status == nil || status.ConfigHash != hash
+
+	var master string
+	var pending []string
+	known := make(map[string]bool)
+	for _, inst := range cluster.Status.Instances {
+		known[inst.InstanceID] = true
+		if inst.State != "running" {
+			continue
+		}
+		if inst.Role == "master" && master == "" {
+			master = inst.InstanceID
+		}
+		if changed || !status.HasNode(inst.InstanceID) {
+			pending = append(pending, inst.InstanceID)
+		}
+	}
+	if status != nil {
+		// Forget removed nodes
+		var nodes []string
+		for _, id := range status.Nodes {
+			if known[id] {
+				nodes = append(nodes, id)
+			}
+		}
+		status.Nodes = nodes
+	}
+	if master == "" || len(pending) == 0 {
+		return nil
+	}
+
+	params := map[string]string{
+		"Upstreams":      "",
+		"ClusterConfig":  "false",
+		"CorednsCustom":  "",
+		"NodeLocalCache": "false",
+	}
+	if spec != nil {
+		params["Upstreams"] = strings.Join(spec.Upstreams, " ")
+		params["NodeLocalCache"] = fmt.Sprintf("%t", spec.NodeLocalCache)
+		if manifest := models.CoreDNSCustomManifest(spec); manifest != "" {
+			params["CorednsCustom"] = base64.StdEncoding.EncodeToString([]byte(manifest))
+		}
+	}
+
+	if changed {
+		logger.Infof(ctx, "[DNS] Applying DNS configuration %s to cluster %s", hash, cluster.Name)
+		clusterParams := make(map[string]string, len(params))
+		for k, v := range params {
+			clusterParams[k] = v
+		}
+		clusterParams["ClusterConfig"] = "true"
+		result, err := r.runOperation(ctx, []string{master}, provider.OperationConfigureDNS, clusterParams)
+		if err != nil {
+			return fmt.Errorf("failed to apply DNS configuration: %w", err)
+		}
+		if res := result.Instances[master]; res == nil || res.Status != "Success" {
+			return fmt.Errorf("failed to apply DNS configuration on %s: %s", master, operationOutput(res))
+		}
+		now := time.Now()
+		status = &models.DNSStatus{ConfigHash: hash, Nodes: []string{master}, AppliedAt: &now}
+		cluster.Status.DNS = status
+	}
+
+	var nodes []string
+	for _, id := range pending {
+		if !status.HasNode(id) {
+			nodes = append(nodes, id)
+		}
+	}
+	if len(nodes) > 0 {
+		result, err := r.runOperation(ctx, nodes, provider.OperationConfigureDNS, params)
+		if err != nil {
+			return fmt.Errorf("failed to configure DNS on nodes: %w", err)
+		}
+		var failed []string
+		for _, id := range nodes {
+			if res := result.Instances[id]; res != nil && res.Status == "Success" {
+				status.Nodes = append(status.Nodes, id)
+			} else {
+				// Nodes still bootstrapping are retried on the next reconcile
+				failed = append(failed, id)
+			}
+		}
+		status.Message = ""
+		if len(failed) > 0 {
+			status.Message = fmt.Sprintf("pending on %s", strings.Join(failed, ", "))
+			logger.Infof(ctx, "[DNS] DNS configuration of cluster %s %s", cluster.Name, status.Message)
+		}
+	}
+
+	if hash == "" && status.Message == "" {
+		// Defaults restored everywhere
+		logger.Infof(ctx, "[DNS] Restored default DNS configuration of cluster %s", cluster.Name)
+		cluster.Status.DNS = nil
+	}
+	return nil
+}
+
+// operationOutput describes the result of a node operation for errors
+func operationOutput(res *provider.InstanceCommandResult) string {
+	if res == nil {
+		return "no result"
+	}
+	if res.Error != "" {
+		return fmt.Sprintf("%s: %s", res.Status, strings.TrimSpace(res.Error))
+	}
+	return fmt.Sprintf("%s: %s", res.Status, strings.TrimSpace(res.Output))
+}
@@ -0,0 +1,63 @@
+package controller
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/madhouselabs/goman/pkg/models"
+)
+
+func TestCoreDNSCustomManifest(t *testing.T) {
+	spec := &models.DNSSpec{
+		StubDomains: map[string][]string{
+			"corp.example.com": {"10.0.0.2", "10.0.0.3:5353"},
+			"a.example.com":    {"10.0.1.2"},
+		},
+	}
+
+	manifest := models.CoreDNSCustomManifest(spec)
+	if !strings.Contains(manifest, "name: coredns-custom") {
+		t.Errorf("manifest is not the coredns-custom ConfigMap:\n%s", manifest)
+	}
+	if !strings.Contains(manifest, "  corp.example.com.server: |\n    corp.example.com:53 {\n") ||
+		!strings.Contains(manifest, "forward . 10.0.0.2 10.0.0.3:5353\n") {
+		t.Errorf("missing server block for corp.example.com:\n%s", manifest)
+	}
+	if strings.Index(manifest, "a.example.com.server") > strings.Index(manifest, "corp.example.com.server") {
+		t.Errorf("server blocks are not sorted:\n%s", manifest)
+	}
+
+	if models.CoreDNSCustomManifest(&models.DNSSpec{Upstreams: []string{"1.1.1.1"}}) != "" {
+		t.Error("expected no manifest without stub domains")
+	}
+}
+
+func TestDNSConfigHashAndValidation(t *testing.T) {
+	if models.DNSConfigHash(nil) != "" || models.DNSConfigHash(&models.DNSSpec{}) != "" {
+		t.Error("expected an empty hash for default DNS")
+	}
+	a := &models.DNSSpec{Upstreams: []string{"1.1.1.1"}}
+	b := &models.DNSSpec{Upstreams: []string{"1.1.1.1"}, NodeLocalCache: true}
+	if models.DNSConfigHash(a) == models.DNSConfigHash(b) {
+		t.Error("expected different hashes for different configurations")
+	}
+
+	invalid := []*models.DNSSpec{
+		{Upstreams: []string{"1.1.1.1:53"}},
+		{Upstreams: []string{"dns.example.com"}},
+		{StubDomains: map[string][]string{"svc.cluster.local": {"10.0.0.2"}}},
+		{StubDomains: map[string][]string{"corp.example.com": nil}},
+		{StubDomains: map[string][]string{"Bad Domain": {"10.0.0.2"}}},
+	}
+	for _, spec := range invalid {
+		if err := models.ValidateDNS(spec); err == nil {
+			t.Errorf("expected %+v to be invalid", spec)
+		}
+	}
+	if err := models.ValidateDNS(&models.DNSSpec{
+		Upstreams:   []string{"1.1.1.1", "2606:4700:4700::1111"},
+		StubDomains: map[string][]string{"corp.example.com": {"10.0.0.2:5353"}},
+	}); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
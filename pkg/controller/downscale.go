@@ -0,0 +1,207 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/madhouselabs/goman/pkg/logger"
+	"github.com/madhouselabs/goman/pkg/models"
+	"github.com/madhouselabs/goman/pkg/provider"
+)
+
+// reconcileMasterCount shrinks the control plane when the spec asks for fewer
+// masters than are running (HA -> dev). Masters are removed one per reconcile
+// so etcd always shrinks from a healthy quorum, unhealthy members first; a
+// removal that would leave the remaining members without quorum waits.
+// Returns true while a downscale is still in progress.
+func (r *Reconciler) reconcileMasterCount(ctx context.Context, cluster *models.ClusterResource) (bool, error) {
+	if cluster.Spec.Mode != string(models.ModeDev) {
+		return false, nil
+	}
+
+	var masters []models.InstanceStatus
+	for _, inst := range cluster.Status.Instances {
+		if inst.Role == "master" {
+			masters = append(masters, inst)
+		}
+	}
+
+	if len(masters) <= 1 {
+		return false, nil
+	}
+
+	keeper := selectSurvivingMaster(masters, cluster.Status.PreferredMasterInstance)
+	if keeper == nil {
+		return false, fmt.Errorf("no running master available to keep during downscale")
+	}
+
+	// Remove unhealthy members first, then the highest indexed master
+	var surplus []models.InstanceStatus
+	for _, m := range masters {
+		if m.InstanceID != keeper.InstanceID {
+			surplus = append(surplus, m)
+		}
+	}
+	healthy := func(m models.InstanceStatus) bool {
+		return cluster.Status.Etcd == nil || cluster.Status.Etcd.IsHealthy(r.etcdMemberName(m))
+	}
+	sort.Slice(surplus, func(i, j int) bool {
+		if hi, hj := healthy(surplus[i]), healthy(surplus[j]); hi != hj {
+			return hj
+		}
+		return extractWorkerIndex(surplus[i].Name) > extractWorkerIndex(surplus[j].Name)
+	})
+	victim := surplus[0]
+
+	if err := cluster.Status.Etcd.CheckDisruption(r.etcdMemberName(victim), true); err != nil {
+		logger.Infof(ctx, "[DOWNSCALE] Not removing master %s from cluster %s: %v", victim.Name, cluster.Name, err)
+		cluster.Status.Message = fmt.Sprintf("Downscaling control plane: waiting for etcd, %v", err)
+		return true, nil
+	}
+
+	logger.Infof(ctx, "[DOWNSCALE] Removing master %s (%s) from cluster %s, keeping %s", victim.Name, victim.InstanceID, cluster.Name, keeper.Name)
+	cluster.Status.Message = fmt.Sprintf("Downscaling control plane: removing master %s", victim.Name)
+
+	if err := r.removeMaster(ctx, cluster, keeper, victim); err != nil {
+		return false, fmt.Errorf("failed to remove master %s: %w", victim.Name, err)
+	}
+
+	// Drop the removed master from status
+	remaining := []models.InstanceStatus{}
+	for _, inst := range cluster.Status.Instances {
+		if inst.InstanceID != victim.InstanceID {
+			remaining = append(remaining, inst)
+		}
+	}
+	cluster.Status.Instances = remaining
+
+	if len(surplus) > 1 {
+		// More masters to remove - let etcd settle before the next one
+		return true, nil
+	}
+
+	// Last surplus master is gone; make the keeper a standalone server
+	if err := r.promoteSingleMaster(ctx, cluster, keeper); err != nil {
+		logger.Warnf(ctx, "[DOWNSCALE] Warning: Failed to rewrite config on %s: %v", keeper.Name, err)
+	}
+
+	logger.Infof(ctx, "[DOWNSCALE] Cluster %s is now running with a single master %s", cluster.Name, keeper.Name)
+	return true, nil
+}
+
+// selectSurvivingMaster picks the master that keeps serving after a downscale.
+// The first master (index 0) is preferred since it bootstrapped etcd and
+// workers were joined against its IP.
+func selectSurvivingMaster(masters []models.InstanceStatus, preferredID string) *models.InstanceStatus {
+	var preferred, first *models.InstanceStatus
+	for i := range masters {
+		m := &masters[i]
+		if m.State != "running" {
+			continue
+		}
+		if extractWorkerIndex(m.Name) == 0 {
+			return m
+		}
+		if m.InstanceID == preferredID {
+			preferred = m
+		}
+		if first == nil {
+			first = m
+		}
+	}
+	if preferred != nil {
+		return preferred
+	}
+	return first
+}
+
+// removeMaster gracefully removes a master from the K3s cluster and etcd,
+// then terminates its instance
+func (r *Reconciler) removeMaster(ctx context.Context, cluster *models.ClusterResource, keeper *models.InstanceStatus, victim models.InstanceStatus) error {
+	computeService := r.provider.GetComputeService()
+
+	if victim.PrivateIP != "" {
+		ipParts := strings.ReplaceAll(victim.PrivateIP, ".", "-")
+		nodeName := fmt.Sprintf("ip-%s.%s.compute.internal", ipParts, r.provider.Region())
+
+		// Drain workloads off the departing master
+		if _, err := r.drainNode(ctx, keeper.InstanceID, nodeName, "60s", false); err != nil {
+			logger.Warnf(ctx, "[DOWNSCALE] Warning: Failed to drain master %s: %v", nodeName, err)
+		}
+
+		// Ask K3s to remove the etcd member, then delete the node object.
+		// K3s's etcd controller removes the member once the annotation is seen.
+		removeCmd := fmt.Sprintf("kubectl annotate node %s etcd.k3s.cattle.io/remove=true --overwrite && sleep 15 && kubectl delete node %s", nodeName, nodeName)
+		result, err := r.runCommand(ctx, "remove-etcd-member", []string{keeper.InstanceID}, removeCmd)
+		if err != nil {
+			return fmt.Errorf("failed to remove etcd member %s: %w", nodeName, err)
+		}
+		if res := result.Instances[keeper.InstanceID]; res != nil && res.Status != "Success" {
+			return fmt.Errorf("etcd member removal for %s failed: %s", nodeName, res.Error)
+		}
+	}
+
+	// Stop K3s on the departing master so it cannot rejoin before termination
+	if victim.State == "running" {
+		if _, err := r.runCommand(ctx, "stop-k3s", []string{victim.InstanceID}, "systemctl stop k3s || true"); err != nil {
+			logger.Warnf(ctx, "[DOWNSCALE] Warning: Failed to stop k3s on %s: %v", victim.InstanceID, err)
+		}
+	}
+
+	if err := computeService.DeleteInstance(ctx, victim.InstanceID); err != nil {
+		return fmt.Errorf("failed to terminate instance %s: %w", victim.InstanceID, err)
+	}
+
+	return nil
+}
+
+// promoteScript makes a K3s server that joined another one standalone once
+// its peers are gone. The bootstrap script wrote the unit with the joined
+// server's address expanded, so any address is matched. It restarts K3s,
+// waits for the API and prints the admin kubeconfig like resumeScript.
+const promoteScript = `set -e
+UNIT=/etc/systemd/system/k3s.service
+if grep -q -- '--server=' $UNIT; then
+    sed -i 's| --server=https://[^ ]*:6443||' $UNIT
+    sed -i '/^MASTER_IP=/d' $UNIT.env
+    systemctl daemon-reload
+    systemctl restart k3s
+fi
+for i in $(seq 1 60); do
+    k3s kubectl get --raw=/readyz >/dev/null 2>&1 && break
+    sleep 2
+done
+k3s kubectl get --raw=/readyz >/dev/null 2>&1 || { echo "API server not ready after restart" >&2; exit 1; }
+PUBLIC_IP=$(curl -sf http://169.254.169.254/latest/meta-data/public-ipv4 || curl -s http://169.254.169.254/latest/meta-data/local-ipv4)
+sed "s/127.0.0.1/$PUBLIC_IP/g" /etc/rancher/k3s/k3s.yaml
+`
+
+// promoteSingleMaster rewrites the surviving master's K3s unit so it no longer
+// references removed servers, refreshes the stored kubeconfig and updates
+// connection information in status
+func (r *Reconciler) promoteSingleMaster(ctx context.Context, cluster *models.ClusterResource, keeper *models.InstanceStatus) error {
+	cluster.Status.PreferredMasterInstance = keeper.InstanceID
+	cluster.Status.MasterInstanceIDs = []string{keeper.InstanceID}
+	cluster.Status.InternalDNS = ""
+	cluster.Status.APIEndpoints = nil
+
+	result, err := r.runSecretCommand(ctx, "promote-single-master", []string{keeper.InstanceID}, promoteScript)
+	if err != nil {
+		return err
+	}
+	res := result.Instances[keeper.InstanceID]
+	if res == nil {
+		return fmt.Errorf("config rewrite returned no result")
+	}
+	if res.Status != "Success" {
+		return fmt.Errorf("config rewrite failed: %s", res.Error)
+	}
+
+	// The kubeconfig is stored from here so it goes to the configured secret backend
+	if err := r.provider.GetSecretService().PutSecret(ctx, cluster.Name, provider.SecretKubeconfig, []byte(res.Output)); err != nil {
+		return fmt.Errorf("failed to save kubeconfig: %w", err)
+	}
+	return nil
+}
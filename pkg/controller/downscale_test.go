@@ -0,0 +1,95 @@
+package controller
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/madhouselabs/goman/pkg/provider/aws/userdata"
+)
+
+// TestPromoteScript runs promoteScript against the unit the bootstrap script
+// writes on a joined master, with systemctl, k3s and curl stubbed
+func TestPromoteScript(t *testing.T) {
+	if _, err := exec.LookPath("bash"); err != nil {
+		t.Skip("bash not available")
+	}
+	bootstrap, err := userdata.Render(userdata.Params{Role: "master", NodeIndex: "1", MasterIP: "10.0.0.1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The joined master's unit and environment file, written as the
+	// bootstrap script does
+	var write string
+	for rest := bootstrap; ; {
+		i := strings.Index(rest, "cat > /etc/systemd/system/k3s.service <<EOF\n")
+		if i < 0 {
+			t.Fatal("bootstrap script writes no unit joining a server")
+		}
+		rest = rest[i:]
+		unitEnd := strings.Index(rest, "\nEOF\n")
+		envEnd := strings.Index(rest[unitEnd+5:], "\nEOF\n") + unitEnd + 5
+		if strings.Contains(rest[:unitEnd], "--server=") {
+			write = rest[:envEnd+5]
+			break
+		}
+		rest = rest[unitEnd:]
+	}
+
+	dir := t.TempDir()
+	bin := filepath.Join(dir, "bin")
+	if err := os.Mkdir(bin, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	stubs := map[string]string{
+		"systemctl": `echo "$@" >> ` + filepath.Join(dir, "systemctl.log"),
+		"k3s":       "exit 0",
+		"curl":      "echo 54.1.2.3",
+	}
+	for name, body := range stubs {
+		if err := os.WriteFile(filepath.Join(bin, name), []byte("#!/bin/sh\n"+body+"\n"), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(dir, "k3s.yaml"), []byte("server: https://127.0.0.1:6443\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	paths := strings.NewReplacer("/etc/systemd/system/", dir+"/", "/etc/rancher/k3s/", dir+"/")
+
+	run := func(script string) string {
+		cmd := exec.Command("bash", "-c", script)
+		cmd.Env = append(os.Environ(), "PATH="+bin+":"+os.Getenv("PATH"),
+			"MASTER_IP=10.0.0.1", "SERVER_TOKEN=secret", "PRIVATE_IP=10.0.0.2")
+		out, err := cmd.CombinedOutput()
+		if err != nil {
+			t.Fatalf("%v: %s", err, out)
+		}
+		return string(out)
+	}
+	run(paths.Replace(write))
+	if unit, _ := os.ReadFile(filepath.Join(dir, "k3s.service")); !strings.Contains(string(unit), "--server=https://10.0.0.1:6443") {
+		t.Fatalf("bootstrap unit does not join 10.0.0.1:\n%s", unit)
+	}
+
+	out := run(paths.Replace(promoteScript))
+
+	unit, _ := os.ReadFile(filepath.Join(dir, "k3s.service"))
+	if strings.Contains(string(unit), "--server=") {
+		t.Errorf("unit still joins a server:\n%s", unit)
+	}
+	if !strings.Contains(string(unit), "--token=secret --node-ip=10.0.0.2") {
+		t.Errorf("unit lost its other flags:\n%s", unit)
+	}
+	if env, _ := os.ReadFile(filepath.Join(dir, "k3s.service.env")); strings.Contains(string(env), "MASTER_IP") {
+		t.Errorf("environment still has the joined server:\n%s", env)
+	}
+	if calls, _ := os.ReadFile(filepath.Join(dir, "systemctl.log")); string(calls) != "daemon-reload\nrestart k3s\n" {
+		t.Errorf("systemctl calls = %q, want a reload and a restart", calls)
+	}
+	if out != "server: https://54.1.2.3:6443\n" {
+		t.Errorf("kubeconfig = %q", out)
+	}
+}
@@ -0,0 +1,393 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/madhouselabs/goman/pkg/logger"
+	"github.com/madhouselabs/goman/pkg/models"
+	"github.com/madhouselabs/goman/pkg/provider"
+)
+
+// driftInstanceGrace is how long an instance may be missing from the status,
+// or the status may list an instance the cloud no longer has, before it
+// counts as drift: launches and terminations take a while to show
+const driftInstanceGrace = 10 * time.Minute
+
+// driftFixRequested reports whether a fix of all drift was requested after
+// the last one
+func driftFixRequested(cluster *models.ClusterResource) bool {
+	requested := cluster.Spec.DriftFixRequestedAt
+	if requested == nil {
+		return false
+	}
+	done := cluster.Status.DriftFixedAt
+	return done == nil || done.Before(*requested)
+}
+
+// reconcileDrift compares the instances and security groups of a cluster
+// with its spec and status, records what changed outside goman in status
+// and the Drifted condition, and reverts drifted fields according to the
+// cluster's drift policy, or all of them once when a fix was requested.
+// Returns true while a revert is in progress so node pool reconciliation
+// does not replace a stopped worker.
+func (r *Reconciler) reconcileDrift(ctx context.Context, cluster *models.ClusterResource) (bool, error) {
+	instances, err := r.driftInstances(ctx, cluster)
+	if err != nil {
+		return false, err
+	}
+
+	drift := r.detectDrift(ctx, cluster, instances)
+	detected := keepDriftHistory(cluster, drift)
+	cluster.Status.Drift = drift
+	setDriftCondition(cluster)
+	for _, d := range detected {
+		logger.Infof(ctx, "[DRIFT] %s in cluster %s", d, cluster.Name)
+		if change, ok := driftChange(d); ok {
+			r.reportOutOfBandChange(ctx, cluster, change)
+		}
+	}
+
+	fix := driftFixRequested(cluster)
+	reverting, err := r.revertDrift(ctx, cluster, instances, fix)
+	if err != nil || reverting {
+		return reverting, err
+	}
+	if fix {
+		// Drift that could not be reverted, e.g. under stop protection,
+		// stays reported
+		logger.Infof(ctx, "[DRIFT] Fixed drift of cluster %s, %d field(s) left", cluster.Name, len(cluster.Status.Drift))
+		now := time.Now()
+		cluster.Status.DriftFixedAt = &now
+	}
+	return false, nil
+}
+
+// DetectDrift compares a cluster's spec and status with its instances and
+// security groups as they are now, without changing anything. Drift that
+// was already recorded keeps its detection time and author.
+func (r *Reconciler) DetectDrift(ctx context.Context, clusterName string) ([]models.DriftStatus, error) {
+	cluster, err := r.loadCluster(ctx, clusterName)
+	if err != nil {
+		return nil, err
+	}
+	instances, err := r.driftInstances(ctx, cluster)
+	if err != nil {
+		return nil, err
+	}
+	drift := r.detectDrift(ctx, cluster, instances)
+	keepDriftHistory(cluster, drift)
+	return drift, nil
+}
+
+// driftInstances returns the instances of a cluster by ID
+func (r *Reconciler) driftInstances(ctx context.Context, cluster *models.ClusterResource) (map[string]*provider.Instance, error) {
+	filters := map[string]string{
+		"tag:goman-cluster":   cluster.Name,
+		"instance-state-name": "running,pending,stopping,stopped",
+	}
+	instances, err := r.provider.GetComputeService().ListInstances(ctx, filters)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list instances: %w", err)
+	}
+	actual := make(map[string]*provider.Instance)
+	for _, inst := range instances {
+		actual[inst.ID] = inst
+	}
+	return actual, nil
+}
+
+// detectDrift refreshes the instances in status from the actual ones and
+// returns every field that differs from the spec: instance types, the
+// user tags once they were synced, the security group rules, and instances
+// missing from either side
+func (r *Reconciler) detectDrift(ctx context.Context, cluster *models.ClusterResource, actual map[string]*provider.Instance) []models.DriftStatus {
+	var drift []models.DriftStatus
+	now := time.Now()
+	newDrift := func(field, node, id, expected, actual string) models.DriftStatus {
+		policy := models.DriftPolicyFor(cluster.Spec.DriftPolicy, field)
+		if field == models.DriftFieldInstances {
+			policy = models.DriftAdopt
+		}
+		return models.DriftStatus{Field: field, Node: node, InstanceID: id, Expected: expected, Actual: actual, Policy: policy, DetectedAt: now}
+	}
+
+	// Tags are only compared once synced; until then the sync applies them
+	checkTags := len(cluster.Spec.Tags) > 0 && cluster.Status.Tags != nil &&
+		!tagsSyncNeeded(cluster.Status.Tags, models.ResourceTagsHash(cluster.Spec.Tags), cluster.Spec.RetagRequestedAt)
+
+	known := make(map[string]bool)
+	for i := range cluster.Status.Instances {
+		st := &cluster.Status.Instances[i]
+		known[st.InstanceID] = true
+		inst, ok := actual[st.InstanceID]
+		if !ok {
+			if st.InstanceID != "" && now.Sub(st.LaunchTime) > driftInstanceGrace &&
+				st.State != "terminated" && st.State != "shutting-down" {
+				drift = append(drift, newDrift(models.DriftFieldInstances, st.Name, st.InstanceID, st.State, "gone"))
+			}
+			continue
+		}
+		st.InstanceType = inst.InstanceType
+		st.AvailabilityZone = inst.AvailabilityZone
+		if inst.Region != "" {
+			st.Region = inst.Region
+		}
+		st.K3sVersion = nodeK3sVersion(inst)
+
+		if checkTags {
+			if expected, got, ok := tagDrift(cluster.Spec.Tags, inst.Tags); ok {
+				drift = append(drift, newDrift(models.DriftFieldTags, st.Name, st.InstanceID, expected, got))
+			}
+		}
+
+		expected := cluster.Spec.InstanceType
+		if st.Role == "worker" {
+			pool, ok := driftPool(cluster, inst, st.Name)
+			if !ok {
+				// Leftover worker of a removed pool; node pool reconciliation removes it
+				continue
+			}
+			// Compare with the type the worker was launched with; a pool
+			// type change in the spec is a rollout, not drift
+			expected = pool.InstanceType
+			if launched := inst.Tags[instanceTypeTag]; launched != "" {
+				expected = launched
+			}
+		}
+		if expected == "" || inst.InstanceType == "" || inst.InstanceType == expected {
+			continue
+		}
+		drift = append(drift, newDrift(models.DriftFieldInstanceType, st.Name, st.InstanceID, expected, inst.InstanceType))
+	}
+
+	for _, inst := range actual {
+		if known[inst.ID] || inst.Tags[parkedAtTag] != "" || now.Sub(inst.LaunchTime) <= driftInstanceGrace {
+			continue
+		}
+		drift = append(drift, newDrift(models.DriftFieldInstances, inst.Name, inst.ID, "not in cluster", inst.State))
+	}
+
+	if checker, ok := r.provider.GetComputeService().(provider.ClusterFirewallChecker); ok {
+		groups, err := checker.CheckClusterFirewall(ctx, cluster.Spec.Region, cluster.Name)
+		if err != nil {
+			logger.Warnf(ctx, "[DRIFT] Failed to check security groups of cluster %s: %v", cluster.Name, err)
+		}
+		for _, g := range groups {
+			var changes []string
+			for _, rule := range g.Missing {
+				changes = append(changes, "missing "+rule)
+			}
+			for _, rule := range g.Open {
+				changes = append(changes, "open "+rule)
+			}
+			drift = append(drift, newDrift(models.DriftFieldSecurityGroupRules, g.GroupName, g.GroupID, "cluster rules", strings.Join(changes, "; ")))
+		}
+	}
+
+	models.SortDrift(drift)
+	return drift
+}
+
+// keepDriftHistory carries the detection time and author of drift already
+// in status over to drift found again, and returns the drift that is new
+func keepDriftHistory(cluster *models.ClusterResource, drift []models.DriftStatus) []models.DriftStatus {
+	previous := make(map[string]models.DriftStatus)
+	for _, d := range cluster.Status.Drift {
+		previous[d.InstanceID+"/"+d.Field] = d
+	}
+	var detected []models.DriftStatus
+	for i := range drift {
+		d := &drift[i]
+		if prev, ok := previous[d.InstanceID+"/"+d.Field]; ok {
+			d.DetectedAt = prev.DetectedAt
+			d.ChangedBy = prev.ChangedBy
+			continue
+		}
+		detected = append(detected, *d)
+	}
+	return detected
+}
+
+// setDriftCondition sets the Drifted condition while drift is recorded
+func setDriftCondition(cluster *models.ClusterResource) {
+	drift := cluster.Status.Drift
+	if len(drift) == 0 {
+		cluster.Status.RemoveCondition(models.ConditionDrifted, models.ReasonChangedOutsideGoman)
+		return
+	}
+	var shown []string
+	for i, d := range drift {
+		if i == 3 {
+			shown = append(shown, fmt.Sprintf("and %d more", len(drift)-i))
+			break
+		}
+		shown = append(shown, d.Node+" "+d.Field)
+	}
+	cluster.Status.SetCondition(models.Condition{
+		Type:   models.ConditionDrifted,
+		Status: "True",
+		Reason: models.ReasonChangedOutsideGoman,
+		Message: fmt.Sprintf("%d field(s) changed outside goman: %s. Run 'goman cluster drift %s' for details",
+			len(drift), strings.Join(shown, ", "), cluster.Name),
+	})
+}
+
+// driftChange describes new drift as a change to look up in the audit log.
+// Vanished instances are not, as the worker and etcd checks report them.
+func driftChange(d models.DriftStatus) (models.UnattributedChange, bool) {
+	change := models.UnattributedChange{ResourceID: d.InstanceID, Node: d.Node, Field: d.Field}
+	switch d.Field {
+	case models.DriftFieldInstanceType:
+		change.Description = fmt.Sprintf("Instance type of %s (%s) changed from %s to %s", d.Node, d.InstanceID, d.Expected, d.Actual)
+		change.EventNames = []string{"ModifyInstanceAttribute"}
+	case models.DriftFieldTags:
+		change.Description = fmt.Sprintf("Tags of %s (%s) changed from %s to %s", d.Node, d.InstanceID, d.Expected, d.Actual)
+		change.EventNames = []string{"CreateTags", "DeleteTags"}
+	case models.DriftFieldSecurityGroupRules:
+		change.Description = fmt.Sprintf("Rules of security group %s (%s) changed: %s", d.Node, d.InstanceID, d.Actual)
+		change.EventNames = []string{"AuthorizeSecurityGroupIngress", "RevokeSecurityGroupIngress", "ModifySecurityGroupRules"}
+	case models.DriftFieldInstances:
+		if d.Actual == "gone" {
+			return change, false
+		}
+		change.Description = fmt.Sprintf("Instance %s (%s) launched", d.InstanceID, d.Node)
+		change.EventNames = []string{"RunInstances"}
+	default:
+		return change, false
+	}
+	return change, true
+}
+
+// revertDrift changes drifted fields back to the spec: those whose policy
+// is revert, or all of them when fix is set. Tags and security group rules
+// are restored at once. Reverting an instance type needs the instance
+// stopped, so one instance is reverted at a time: stop, then modify and
+// start once it is stopped. Returns true while an instance is reverted.
+func (r *Reconciler) revertDrift(ctx context.Context, cluster *models.ClusterResource, instances map[string]*provider.Instance, fix bool) (bool, error) {
+	computeService := r.provider.GetComputeService()
+
+	var tags, firewall bool
+	var revert *provider.Instance
+	var target models.DriftStatus
+	for _, d := range cluster.Status.Drift {
+		if d.Policy != models.DriftRevert && !fix {
+			continue
+		}
+		switch d.Field {
+		case models.DriftFieldTags:
+			tags = true
+		case models.DriftFieldSecurityGroupRules:
+			firewall = true
+		case models.DriftFieldInstanceType:
+			inst, ok := instances[d.InstanceID]
+			if !ok || revert != nil {
+				continue
+			}
+			// Drift in a paused pool is only reported, though a revert that
+			// already stopped the instance is finished
+			if st := upgradeNodeStatus(cluster, inst.ID); inst.State == "running" && st != nil && st.Role == "worker" {
+				if pool, ok := driftPool(cluster, inst, d.Node); ok && pool.Paused {
+					logger.Infof(ctx, "[DRIFT] Not reverting %s: its node pool is paused", d.Node)
+					continue
+				}
+			}
+			revert, target = inst, d
+		}
+	}
+
+	if tags {
+		logger.Infof(ctx, "[DRIFT] Restoring tags of cluster %s", cluster.Name)
+		if _, err := computeService.SyncClusterTags(ctx, cluster.Name, cluster.Spec.Tags, nil); err != nil {
+			return false, fmt.Errorf("failed to restore tags: %w", err)
+		}
+	}
+	if firewall {
+		// Rule drift is only found by a ClusterFirewallChecker
+		logger.Infof(ctx, "[DRIFT] Restoring security group rules of cluster %s", cluster.Name)
+		checker := computeService.(provider.ClusterFirewallChecker)
+		if err := checker.RestoreClusterFirewall(ctx, cluster.Spec.Region, cluster.Name); err != nil {
+			return false, fmt.Errorf("failed to restore security group rules: %w", err)
+		}
+	}
+
+	if revert == nil {
+		return false, nil
+	}
+
+	// The image is built for one architecture, so a node can't change
+	// between x86_64 and arm64 in place; it has to be replaced
+	if a, b := models.InstanceArchitecture(target.Actual), models.InstanceArchitecture(target.Expected); a != b {
+		logger.Infof(ctx, "[DRIFT] Not reverting %s: %s is %s and %s is %s, replace the node instead", target.Node, target.Actual, a, target.Expected, b)
+		return false, nil
+	}
+
+	switch revert.State {
+	case "running":
+		if cluster.Spec.InstanceProtection.StopProtected() {
+			// Reverting needs a stop, which the protection refuses; the
+			// drift stays reported
+			logger.Infof(ctx, "[DRIFT] Not reverting %s: cluster %s has stop protection", target.Node, cluster.Name)
+			return false, nil
+		}
+		if st := upgradeNodeStatus(cluster, revert.ID); st != nil && st.Role == "master" {
+			if err := cluster.Status.Etcd.CheckDisruption(r.etcdMemberName(*st), false); err != nil {
+				logger.Infof(ctx, "[DRIFT] Not reverting %s: %v", target.Node, err)
+				return false, nil
+			}
+		}
+		logger.Infof(ctx, "[DRIFT] Stopping %s to revert instance type %s to %s", target.Node, target.Actual, target.Expected)
+		if err := computeService.StopInstance(ctx, revert.ID); err != nil {
+			return false, fmt.Errorf("failed to stop %s: %w", target.Node, err)
+		}
+		cluster.Status.Message = fmt.Sprintf("Reverting drift on %s: stopping instance", target.Node)
+	case "stopped":
+		logger.Infof(ctx, "[DRIFT] Changing instance type of %s from %s to %s", target.Node, target.Actual, target.Expected)
+		if err := computeService.ModifyInstanceType(ctx, revert.ID, target.Expected); err != nil {
+			return false, fmt.Errorf("failed to change instance type of %s: %w", target.Node, err)
+		}
+		if err := computeService.StartInstance(ctx, revert.ID); err != nil {
+			return false, fmt.Errorf("failed to start %s: %w", target.Node, err)
+		}
+		cluster.Status.Message = fmt.Sprintf("Reverting drift on %s: starting as %s", target.Node, target.Expected)
+	default:
+		cluster.Status.Message = fmt.Sprintf("Reverting drift on %s: waiting for instance to be %s", target.Node, revert.State)
+	}
+	return true, nil
+}
+
+// driftPool returns the node pool of a worker, by its pool tag or its name
+func driftPool(cluster *models.ClusterResource, inst *provider.Instance, name string) (models.NodePool, bool) {
+	if pool, ok := findNodePool(cluster, inst.Tags["goman-nodepool"]); ok {
+		return pool, true
+	}
+	return workerPool(cluster, name)
+}
+
+// tagDrift compares the user tags of the spec with the tags of an instance
+// and renders the differing ones as "key=value" lists
+func tagDrift(want, have map[string]string) (expected, actual string, drifted bool) {
+	keys := make([]string, 0, len(want))
+	for key, value := range want {
+		if got, ok := have[key]; !ok || got != value {
+			keys = append(keys, key)
+		}
+	}
+	if len(keys) == 0 {
+		return "", "", false
+	}
+	sort.Strings(keys)
+	var e, a []string
+	for _, key := range keys {
+		e = append(e, key+"="+want[key])
+		if got, ok := have[key]; ok {
+			a = append(a, key+"="+got)
+		} else {
+			a = append(a, key+" missing")
+		}
+	}
+	return strings.Join(e, ", "), strings.Join(a, ", "), true
+}
@@ -0,0 +1,130 @@
+package controller
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/madhouselabs/goman/pkg/models"
+	"github.com/madhouselabs/goman/pkg/provider"
+)
+
+// driftCompute has instances and security groups changed outside goman,
+// and records the reverts
+type driftCompute struct {
+	provider.ComputeService
+	instances []*provider.Instance
+	firewall  []provider.FirewallDrift
+	reverted  []string
+}
+
+func (c *driftCompute) ListInstances(ctx context.Context, filters map[string]string) ([]*provider.Instance, error) {
+	return c.instances, nil
+}
+
+func (c *driftCompute) StopInstance(ctx context.Context, id string) error {
+	c.reverted = append(c.reverted, "stop "+id)
+	return nil
+}
+
+func (c *driftCompute) SyncClusterTags(ctx context.Context, clusterName string, tags map[string]string, removed []string) (*provider.TagSyncResult, error) {
+	c.reverted = append(c.reverted, "tags")
+	return &provider.TagSyncResult{}, nil
+}
+
+func (c *driftCompute) CheckClusterFirewall(ctx context.Context, region, clusterName string) ([]provider.FirewallDrift, error) {
+	return c.firewall, nil
+}
+
+func (c *driftCompute) RestoreClusterFirewall(ctx context.Context, region, clusterName string) error {
+	c.reverted = append(c.reverted, "firewall")
+	return nil
+}
+
+func TestReconcileDrift(t *testing.T) {
+	launched := time.Now().Add(-time.Hour)
+	compute := &driftCompute{
+		instances: []*provider.Instance{
+			{ID: "i-1", Name: "demo-master-0", State: "running", InstanceType: "t3.large", LaunchTime: launched,
+				Tags: map[string]string{"team": "web"}},
+			{ID: "i-9", Name: "stray", State: "running", InstanceType: "t3.small", LaunchTime: launched},
+			{ID: "i-10", Name: "launching", State: "pending", LaunchTime: time.Now()},
+		},
+		firewall: []provider.FirewallDrift{{GroupID: "sg-1", GroupName: "demo-sg", Open: []string{"tcp 22 from 0.0.0.0/0"}}},
+	}
+	r := &Reconciler{provider: &fakeProvider{compute: compute}, settings: DefaultSettings()}
+
+	cluster := &models.ClusterResource{Name: "demo"}
+	cluster.Spec.InstanceType = "t3.medium"
+	cluster.Spec.Tags = map[string]string{"team": "platform"}
+	cluster.Status.Tags = &models.TagSyncStatus{Hash: models.ResourceTagsHash(cluster.Spec.Tags)}
+	cluster.Status.Instances = []models.InstanceStatus{
+		{InstanceID: "i-1", Name: "demo-master-0", Role: "master", State: "running", LaunchTime: launched},
+		{InstanceID: "i-2", Name: "demo-master-1", Role: "master", State: "running", LaunchTime: launched},
+	}
+	ctx := context.Background()
+
+	// Adopted by default: reported, not reverted
+	reverting, err := r.reconcileDrift(ctx, cluster)
+	if err != nil || reverting {
+		t.Fatalf("reconcileDrift = %v, %v", reverting, err)
+	}
+	fields := make(map[string]string)
+	for _, d := range cluster.Status.Drift {
+		fields[d.InstanceID+" "+d.Field] = d.Actual
+	}
+	want := map[string]string{
+		"i-1 " + models.DriftFieldInstanceType:        "t3.large",
+		"i-1 " + models.DriftFieldTags:                "team=web",
+		"i-2 " + models.DriftFieldInstances:           "gone",
+		"i-9 " + models.DriftFieldInstances:           "running",
+		"sg-1 " + models.DriftFieldSecurityGroupRules: "open tcp 22 from 0.0.0.0/0",
+	}
+	if len(fields) != len(want) {
+		t.Fatalf("drift %v, want %v", fields, want)
+	}
+	for key, actual := range want {
+		if fields[key] != actual {
+			t.Errorf("drift %s = %q, want %q", key, fields[key], actual)
+		}
+	}
+	if c := cluster.Status.GetCondition(models.ConditionDrifted); c == nil || c.Status != "True" {
+		t.Errorf("Drifted condition = %+v", c)
+	}
+	if len(compute.reverted) != 0 {
+		t.Errorf("adopted drift reverted: %v", compute.reverted)
+	}
+
+	// A fix reverts all of it once, whatever the policy
+	requested := time.Now()
+	cluster.Spec.DriftFixRequestedAt = &requested
+	if reverting, err = r.reconcileDrift(ctx, cluster); err != nil || !reverting {
+		t.Fatalf("reconcileDrift with a fix = %v, %v", reverting, err)
+	}
+	if got := compute.reverted; len(got) != 3 || got[0] != "tags" || got[1] != "firewall" || got[2] != "stop i-1" {
+		t.Errorf("reverted %v", got)
+	}
+	if cluster.Status.DriftFixedAt != nil {
+		t.Error("fix done while an instance is being reverted")
+	}
+
+	// Done once nothing is being reverted
+	compute.instances = compute.instances[1:2]
+	compute.firewall = nil
+	cluster.Status.Instances = nil
+	if _, err := r.reconcileDrift(ctx, cluster); err != nil {
+		t.Fatal(err)
+	}
+	if driftFixRequested(cluster) {
+		t.Error("fix still requested after it was done")
+	}
+
+	cluster.Status.Drift = nil
+	compute.instances = nil
+	if _, err := r.reconcileDrift(ctx, cluster); err != nil {
+		t.Fatal(err)
+	}
+	if c := cluster.Status.GetCondition(models.ConditionDrifted); c != nil {
+		t.Errorf("Drifted condition kept without drift: %+v", c)
+	}
+}
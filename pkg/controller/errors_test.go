@@ -0,0 +1,77 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/madhouselabs/goman/pkg/provider"
+)
+
+func TestCategorize(t *testing.T) {
+	opErr := func(class provider.ErrorClass) error {
+		return fmt.Errorf("failed to create instance: %w", &provider.OperationError{
+			Service: "ec2", Operation: "RunInstances", Class: class, Err: errors.New("boom"),
+		})
+	}
+
+	tests := []struct {
+		name string
+		err  error
+		want provider.ErrorCategory
+	}{
+		{"invalid spec", provider.UserConfigErrorf("invalid cluster spec: %w", errors.New("bad preset")), provider.CategoryUserConfig},
+		{"wrapped bootstrap", fmt.Errorf("failed to provision node pools: %w", provider.BootstrapErrorf("no master node IP found")), provider.CategoryBootstrap},
+		{"permission", opErr(provider.ErrorClassPermission), provider.CategoryUserConfig},
+		{"quota", opErr(provider.ErrorClassQuota), provider.CategoryCloudQuota},
+		{"throttling", opErr(provider.ErrorClassThrottling), provider.CategoryTransientCloud},
+		{"capacity", opErr(provider.ErrorClassCapacity), provider.CategoryTransientCloud},
+		{"timeout", fmt.Errorf("reconcile: %w", context.DeadlineExceeded), provider.CategoryTransientCloud},
+		{"unknown", errors.New("something odd"), provider.CategoryInternal},
+	}
+	for _, tt := range tests {
+		if got := provider.Categorize(tt.err); got != tt.want {
+			t.Errorf("%s: Categorize = %s, want %s", tt.name, got, tt.want)
+		}
+		if tt.want.Guidance() == "" {
+			t.Errorf("%s: no guidance for %s", tt.name, tt.want)
+		}
+	}
+
+	if _, ok := provider.CategoryOf(errors.New("cluster not found")); ok {
+		t.Error("plain error has a category")
+	}
+}
+
+func TestErrNotFound(t *testing.T) {
+	missing := fmt.Errorf("failed to get object: %w", &provider.OperationError{
+		Service: "s3", Operation: "GetObject", Code: "NoSuchKey", Class: provider.ErrorClassNotFound, Err: errors.New("404"),
+	})
+	if !errors.Is(missing, provider.ErrNotFound) {
+		t.Error("missing object does not match ErrNotFound")
+	}
+	denied := &provider.OperationError{Service: "s3", Operation: "GetObject", Class: provider.ErrorClassPermission, Err: errors.New("403")}
+	if errors.Is(denied, provider.ErrNotFound) {
+		t.Error("access denied matches ErrNotFound")
+	}
+	if !errors.Is(fmt.Errorf("%w: c/kubeconfig", provider.ErrSecretNotFound), provider.ErrNotFound) {
+		t.Error("ErrSecretNotFound does not match ErrNotFound")
+	}
+}
+
+func TestFailureRequeue(t *testing.T) {
+	s := DefaultSettings()
+	if got := s.failureRequeue(provider.CategoryUserConfig); got != MaxRequeueInterval {
+		t.Errorf("user config requeue = %s, want %s", got, MaxRequeueInterval)
+	}
+	if got := s.failureRequeue(provider.CategoryCloudQuota); got != 5*s.FailureRequeue {
+		t.Errorf("quota requeue = %s, want %s", got, 5*s.FailureRequeue)
+	}
+	if got := s.failureRequeue(provider.CategoryTransientCloud); got >= s.FailureRequeue {
+		t.Errorf("transient requeue = %s, want less than %s", got, s.FailureRequeue)
+	}
+	if got := s.failureRequeue(provider.CategoryInternal); got != s.FailureRequeue {
+		t.Errorf("internal requeue = %s, want %s", got, s.FailureRequeue)
+	}
+}
@@ -0,0 +1,101 @@
+package controller
+
+import (
+	"context"
+	"time"
+
+	"github.com/madhouselabs/goman/pkg/logger"
+	"github.com/madhouselabs/goman/pkg/models"
+	"github.com/madhouselabs/goman/pkg/storage"
+	"gopkg.in/yaml.v3"
+)
+
+// trackProgress times the lifecycle phases of a cluster, records how long a
+// new cluster took to become Running, and refreshes its estimated
+// completion. The duration of a phase is recorded once the cluster leaves it
+// for the next one; failures are not recorded since they would skew the
+// estimates.
+func (r *Reconciler) trackProgress(ctx context.Context, cluster *models.ClusterResource, previousPhase string) {
+	now := time.Now()
+	status := &cluster.Status
+	mode, region, instanceType := cluster.Spec.Mode, cluster.Spec.Region, cluster.Spec.InstanceType
+
+	if status.Phase != previousPhase {
+		if status.PhaseStartedAt != nil && models.IsLifecyclePhase(previousPhase) && status.Phase != models.ClusterPhaseFailed {
+			r.recordStepDuration(ctx, models.LifecycleStep(previousPhase, mode), region, instanceType, now.Sub(*status.PhaseStartedAt))
+		}
+		status.PhaseStartedAt = &now
+		if status.Phase == models.ClusterPhaseRunning && status.FirstRunningAt == nil && models.IsLifecyclePhase(previousPhase) {
+			r.recordCreation(ctx, cluster, now)
+		}
+	}
+	if status.Phase == models.ClusterPhaseRunning && status.FirstRunningAt == nil {
+		status.FirstRunningAt = &now
+	}
+
+	var elapsed time.Duration
+	if status.PhaseStartedAt != nil {
+		elapsed = now.Sub(*status.PhaseStartedAt)
+	}
+	remaining, ok := time.Duration(0), false
+	if models.IsLifecyclePhase(status.Phase) || status.Phase == models.ClusterPhasePending {
+		remaining, ok = r.loadStepStats(ctx).LifecycleRemaining(status.Phase, mode, region, instanceType, elapsed)
+	} else if status.Phase == models.ClusterPhaseRunning && status.Rollout.Active() {
+		remaining, ok = r.loadStepStats(ctx).RolloutRemaining(status.Rollout, region, now)
+	}
+	if !ok {
+		status.EstimatedCompletion = nil
+		return
+	}
+	eta := now.Add(remaining).Truncate(time.Second)
+	status.EstimatedCompletion = &eta
+}
+
+// recordRolloutBatch records how long a finished rollout batch took. Batches
+// with a failed replacement are skipped.
+func (r *Reconciler) recordRolloutBatch(ctx context.Context, cluster *models.ClusterResource, rollout *models.RolloutStatus) {
+	started := models.BatchStartedAt(rollout.Batch)
+	if started == nil {
+		return
+	}
+	for _, st := range rollout.Batch {
+		if st.Phase == models.NodeReplacementFailed {
+			return
+		}
+	}
+	r.recordStepDuration(ctx, models.StepRolloutBatch, cluster.Spec.Region, rollout.ToInstanceType, time.Since(*started))
+}
+
+// recordStepDuration adds a step duration to the stored statistics.
+// Concurrent reconciles of other clusters may drop a sample, which only
+// makes the estimate slightly less precise.
+func (r *Reconciler) recordStepDuration(ctx context.Context, step, region, instanceType string, d time.Duration) {
+	stats := r.loadStepStats(ctx)
+	stats.Record(step, region, instanceType, d, time.Now())
+
+	data, err := yaml.Marshal(stats)
+	if err != nil {
+		logger.Warnf(ctx, "[ETA] Failed to marshal step durations: %v", err)
+		return
+	}
+	if err := r.provider.GetStorageService().PutObject(ctx, storage.StepDurationsKey, data); err != nil {
+		logger.Warnf(ctx, "[ETA] Failed to save step durations: %v", err)
+		return
+	}
+	logger.Infof(ctx, "[ETA] Step %s took %s in %s on %s", step, d.Round(time.Second), region, instanceType)
+}
+
+// loadStepStats reads the stored step durations. Missing or unreadable
+// statistics start empty, so estimates fall back to the defaults.
+func (r *Reconciler) loadStepStats(ctx context.Context) *models.StepDurationStats {
+	stats := &models.StepDurationStats{}
+	data, err := r.provider.GetStorageService().GetObject(ctx, storage.StepDurationsKey)
+	if err != nil {
+		return stats
+	}
+	if err := yaml.Unmarshal(data, stats); err != nil {
+		logger.Infof(ctx, "[ETA] Ignoring unreadable step durations: %v", err)
+		return &models.StepDurationStats{}
+	}
+	return stats
+}
@@ -0,0 +1,83 @@
+package controller
+
+import (
+	"testing"
+	"time"
+
+	"github.com/madhouselabs/goman/pkg/models"
+)
+
+func TestStepDurationEstimates(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	stats := &models.StepDurationStats{}
+	step := models.LifecycleStep(models.ClusterPhaseProvisioning, "ha")
+
+	// Defaults before any run
+	if got := stats.Estimate(step, "ap-south-1", "t3.medium"); got != 5*time.Minute {
+		t.Errorf("default estimate = %s, want 5m", got)
+	}
+
+	stats.Record(step, "ap-south-1", "t3.medium", 4*time.Minute, now)
+	stats.Record(step, "ap-south-1", "t3.medium", 6*time.Minute, now)
+	stats.Record(step, "us-east-1", "t3.large", 2*time.Minute, now)
+	if got := stats.Estimate(step, "ap-south-1", "t3.medium"); got != 5*time.Minute {
+		t.Errorf("estimate = %s, want mean 5m", got)
+	}
+	// Unknown instance type: mean over all runs of the step
+	if got := stats.Estimate(step, "eu-west-1", "m5.large"); got != 4*time.Minute {
+		t.Errorf("fallback estimate = %s, want 4m", got)
+	}
+
+	// Rest of provisioning plus installing and configuring defaults
+	remaining, ok := stats.LifecycleRemaining(models.ClusterPhaseProvisioning, "ha", "ap-south-1", "t3.medium", 2*time.Minute)
+	if !ok || remaining != 3*time.Minute+30*time.Second+time.Minute {
+		t.Errorf("remaining = %s, %v; want 4m30s", remaining, ok)
+	}
+	// An overrunning phase adds nothing
+	remaining, _ = stats.LifecycleRemaining(models.ClusterPhaseProvisioning, "ha", "ap-south-1", "t3.medium", time.Hour)
+	if remaining != 90*time.Second {
+		t.Errorf("overrun remaining = %s, want 1m30s", remaining)
+	}
+	if _, ok := stats.LifecycleRemaining(models.ClusterPhaseRunning, "ha", "ap-south-1", "t3.medium", 0); ok {
+		t.Error("running cluster has an estimate")
+	}
+
+	started := now.Add(-2 * time.Minute)
+	rollout := &models.RolloutStatus{
+		ToInstanceType: "t3.large",
+		Phase:          models.RolloutInProgress,
+		BatchIndex:     2,
+		BatchCount:     4,
+		Batch:          []models.NodeReplacementStatus{{Node: "w-1", StartedAt: &started}},
+	}
+	remaining, ok = stats.RolloutRemaining(rollout, "ap-south-1", now)
+	if !ok || remaining != 16*time.Minute {
+		t.Errorf("rollout remaining = %s, %v; want 16m", remaining, ok)
+	}
+	rollout.Phase = models.RolloutPaused
+	if _, ok := stats.RolloutRemaining(rollout, "ap-south-1", now); ok {
+		t.Error("paused rollout has an estimate")
+	}
+}
+
+func TestFormatRemaining(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		in   time.Duration
+		want string
+	}{
+		{-time.Minute, "<1m"},
+		{30 * time.Second, "<1m"},
+		{3*time.Minute + time.Second, "~4m"},
+		{75 * time.Minute, "~1h15m"},
+	}
+	for _, tt := range tests {
+		eta := now.Add(tt.in)
+		if got := models.FormatRemaining(&eta, now); got != tt.want {
+			t.Errorf("FormatRemaining(%s) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+	if got := models.FormatRemaining(nil, now); got != "" {
+		t.Errorf("FormatRemaining(nil) = %q, want empty", got)
+	}
+}
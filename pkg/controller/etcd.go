@@ -0,0 +1,181 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/madhouselabs/goman/pkg/logger"
+	"github.com/madhouselabs/goman/pkg/models"
+)
+
+// etcdMembersCmd lists the K3s server nodes with their Ready status, one
+// "<name> <True|False|Unknown>" per line
+const etcdMembersCmd = `kubectl get nodes -l node-role.kubernetes.io/etcd=true -o jsonpath='{range .items[*]}{.metadata.name}{" "}{.status.conditions[?(@.type=="Ready")].status}{"\n"}{end}'`
+
+// etcdConditionReasons are the reasons of the Degraded condition that
+// checkEtcdQuorum owns
+var etcdConditionReasons = []string{
+	models.ReasonEtcdQuorumLost,
+	models.ReasonEtcdEvenMembers,
+	models.ReasonEtcdQuorumAtRisk,
+}
+
+// checkEtcdQuorum records the health of the etcd members of a control plane
+// with more than one master and reports a lost, fragile or even membership
+// as a Degraded condition. Every master is an etcd member, healthy when its
+// node is Ready. The recorded status guards operations that take masters
+// down or remove them. Returns false when quorum is lost. Quorum is lost
+// only when a master was reached and no API server answered; when no master
+// could be reached at all, e.g. the command timed out or was throttled, the
+// health is unknown and the recorded status is kept: known is false.
+func (r *Reconciler) checkEtcdQuorum(ctx context.Context, cluster *models.ClusterResource) (quorate, known bool) {
+	var masters []models.InstanceStatus
+	for _, inst := range cluster.Status.Instances {
+		if inst.Role == "master" && inst.State != "terminated" && inst.State != "shutting-down" {
+			masters = append(masters, inst)
+		}
+	}
+	if len(masters) <= 1 {
+		cluster.Status.Etcd = nil
+		cluster.Status.RemoveCondition(models.ConditionDegraded, etcdConditionReasons...)
+		return true, true
+	}
+
+	// Ask each running master in turn; one whose API server answers is
+	// enough to see them all. A command that ran and failed means the
+	// master's API server did not answer; any other outcome means the
+	// master was not reached.
+	ready := map[string]bool{}
+	answered, reached, running := false, false, 0
+	for _, m := range masters {
+		if m.State != "running" {
+			continue
+		}
+		running++
+		result, err := r.runCommand(ctx, "etcd-members", []string{m.InstanceID}, etcdMembersCmd)
+		if err != nil {
+			logger.Warnf(ctx, "[ETCD] Failed to list etcd members from %s: %v", m.Name, err)
+			continue
+		}
+		res := result.Instances[m.InstanceID]
+		if res == nil {
+			continue
+		}
+		if res.Status == "Failed" {
+			reached = true
+			continue
+		}
+		if res.Status != "Success" {
+			logger.Warnf(ctx, "[ETCD] Listing etcd members on %s ended %s", m.Name, res.Status)
+			continue
+		}
+		ready = parseEtcdMembers(res.Output)
+		answered = true
+		break
+	}
+	if running > 0 && !answered && !reached {
+		logger.Warnf(ctx, "[ETCD] Cluster %s: no master could be reached to check etcd health; keeping the last check from %s",
+			cluster.Name, lastEtcdCheck(cluster.Status.Etcd))
+		return cluster.Status.Etcd == nil || cluster.Status.Etcd.Quorate(), false
+	}
+
+	etcd := &models.EtcdStatus{
+		Members:   len(masters),
+		Quorum:    models.EtcdQuorum(len(masters)),
+		CheckedAt: time.Now(),
+	}
+	var down []string
+	for _, m := range masters {
+		node := r.etcdMemberName(m)
+		if ready[node] {
+			etcd.Healthy++
+			continue
+		}
+		etcd.Unhealthy = append(etcd.Unhealthy, node)
+		down = append(down, m.Name)
+	}
+	cluster.Status.Etcd = etcd
+
+	switch {
+	case !etcd.Quorate():
+		detail := ""
+		if !answered {
+			detail = "no master's API server answered; "
+		}
+		message := fmt.Sprintf("etcd has lost quorum: %s%d of %d member(s) healthy, %d needed. "+
+			"Start or repair the unhealthy masters (%s) so that %d are healthy again; "+
+			"if they cannot be recovered, run 'goman cluster recover-quorum %s' to rebuild the control plane "+
+			"from a surviving master",
+			detail, etcd.Healthy, etcd.Members, etcd.Quorum, strings.Join(down, ", "), etcd.Quorum, cluster.Name)
+		logger.Infof(ctx, "[ETCD] Cluster %s: %s", cluster.Name, message)
+		cluster.Status.SetCondition(models.Condition{
+			Type:    models.ConditionDegraded,
+			Status:  "True",
+			Reason:  models.ReasonEtcdQuorumLost,
+			Message: message,
+		})
+		cluster.Status.Message = message
+		return false, true
+	case etcd.Members%2 == 0 && cluster.Spec.Mode == string(models.ModeHA):
+		cluster.Status.SetCondition(models.Condition{
+			Type:   models.ConditionDegraded,
+			Status: "True",
+			Reason: models.ReasonEtcdEvenMembers,
+			Message: fmt.Sprintf("etcd has %d members, which tolerates no more failures than %d; "+
+				"restore the control plane to %d masters", etcd.Members, etcd.Members-1, cluster.Spec.MasterCount),
+		})
+	case etcd.Healthy < etcd.Members && etcd.Healthy-1 < etcd.Quorum:
+		cluster.Status.SetCondition(models.Condition{
+			Type:   models.ConditionDegraded,
+			Status: "True",
+			Reason: models.ReasonEtcdQuorumAtRisk,
+			Message: fmt.Sprintf("%s; losing one more master loses quorum. Start or repair %s",
+				etcd, strings.Join(down, ", ")),
+		})
+	default:
+		cluster.Status.RemoveCondition(models.ConditionDegraded, etcdConditionReasons...)
+	}
+	return true, true
+}
+
+// lastEtcdCheck describes when etcd health was last checked
+func lastEtcdCheck(etcd *models.EtcdStatus) string {
+	if etcd == nil || etcd.CheckedAt.IsZero() {
+		return "never"
+	}
+	return etcd.CheckedAt.Format(time.RFC3339)
+}
+
+// etcdMemberName returns the node name a master is known by in EtcdStatus
+func (r *Reconciler) etcdMemberName(m models.InstanceStatus) string {
+	if m.PrivateIP == "" {
+		return m.Name
+	}
+	return r.k3sNodeName(m.PrivateIP)
+}
+
+// parseEtcdMembers returns the nodes of etcdMembersCmd output that are Ready
+func parseEtcdMembers(output string) map[string]bool {
+	ready := map[string]bool{}
+	for _, line := range strings.Split(strings.TrimSpace(output), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		ready[fields[0]] = len(fields) > 1 && fields[1] == "True"
+	}
+	return ready
+}
+
+// isEtcdMember reports whether a node IP belongs to a master the cluster
+// still has, stopped or not. Only a downscale removes those from etcd.
+func isEtcdMember(cluster *models.ClusterResource, nodeIP string) bool {
+	for _, inst := range cluster.Status.Instances {
+		if inst.Role == "master" && inst.PrivateIP == nodeIP && inst.State != "terminated" {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,128 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/madhouselabs/goman/pkg/models"
+	"github.com/madhouselabs/goman/pkg/provider"
+)
+
+func TestParseEtcdMembers(t *testing.T) {
+	output := "ip-10-0-1-1.ap-south-1.compute.internal True\nip-10-0-1-2.ap-south-1.compute.internal Unknown\n\nip-10-0-1-3.ap-south-1.compute.internal\n"
+	want := map[string]bool{
+		"ip-10-0-1-1.ap-south-1.compute.internal": true,
+		"ip-10-0-1-2.ap-south-1.compute.internal": false,
+		"ip-10-0-1-3.ap-south-1.compute.internal": false,
+	}
+	if got := parseEtcdMembers(output); !reflect.DeepEqual(got, want) {
+		t.Errorf("parseEtcdMembers() = %v, want %v", got, want)
+	}
+}
+
+// etcdCompute answers etcdMembersCmd with the outcome set for each master:
+// an SSM status, or "error" for a command that could not be sent
+type etcdCompute struct {
+	provider.ComputeService
+	outcomes map[string]string
+	members  string
+}
+
+func (c *etcdCompute) RunCommand(ctx context.Context, ids []string, command string) (*provider.CommandResult, error) {
+	id := ids[0]
+	if c.outcomes[id] == "error" {
+		return nil, errors.New("ThrottlingException: rate exceeded")
+	}
+	res := &provider.InstanceCommandResult{InstanceID: id, Status: c.outcomes[id]}
+	if res.Status == "Success" {
+		res.Output = c.members
+	}
+	return &provider.CommandResult{Status: res.Status, Instances: map[string]*provider.InstanceCommandResult{id: res}}, nil
+}
+
+func TestCheckEtcdQuorum(t *testing.T) {
+	const members = "ip-10-0-0-1.eu-west-1.compute.internal True\nip-10-0-0-2.eu-west-1.compute.internal True\nip-10-0-0-3.eu-west-1.compute.internal False\n"
+	tests := []struct {
+		name        string
+		outcomes    map[string]string
+		wantQuorate bool
+		wantKnown   bool
+		wantHealthy int // Of the recorded status, 3 if the last check is kept
+	}{
+		{"answered", map[string]string{"i-1": "Success"}, true, true, 2},
+		{"answered by a later master", map[string]string{"i-1": "Undeliverable", "i-2": "Success"}, true, true, 2},
+		{"no API server answered", map[string]string{"i-1": "Failed", "i-2": "Failed", "i-3": "Failed"}, false, true, 0},
+		{"one reached, others unreachable", map[string]string{"i-1": "Failed", "i-2": "TimedOut", "i-3": "error"}, false, true, 0},
+		{"none reached", map[string]string{"i-1": "DeliveryTimedOut", "i-2": "error", "i-3": "TimedOut"}, true, false, 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			compute := &etcdCompute{outcomes: tt.outcomes, members: members}
+			r := &Reconciler{provider: &fakeProvider{compute: compute, region: "eu-west-1"}, settings: DefaultSettings()}
+			cluster := &models.ClusterResource{Name: "prod"}
+			cluster.Spec.Mode = string(models.ModeHA)
+			for _, n := range []string{"1", "2", "3"} {
+				cluster.Status.Instances = append(cluster.Status.Instances, models.InstanceStatus{
+					Name: "prod-master-" + n, InstanceID: "i-" + n, Role: "master", State: "running", PrivateIP: "10.0.0." + n,
+				})
+			}
+			last := &models.EtcdStatus{Members: 3, Healthy: 3, Quorum: 2, CheckedAt: time.Now().Add(-time.Minute)}
+			cluster.Status.Etcd = last
+
+			quorate, known := r.checkEtcdQuorum(context.Background(), cluster)
+			if quorate != tt.wantQuorate || known != tt.wantKnown {
+				t.Errorf("checkEtcdQuorum() = %v, %v, want %v, %v", quorate, known, tt.wantQuorate, tt.wantKnown)
+			}
+			if got := cluster.Status.Etcd.Healthy; got != tt.wantHealthy {
+				t.Errorf("recorded %d healthy members, want %d", got, tt.wantHealthy)
+			}
+			lost := cluster.Status.GetCondition(models.ConditionDegraded)
+			if wantLost := tt.wantKnown && !tt.wantQuorate; (lost != nil && lost.Reason == models.ReasonEtcdQuorumLost) != wantLost {
+				t.Errorf("Degraded condition %+v, want quorum lost %v", lost, wantLost)
+			}
+		})
+	}
+}
+
+func TestEtcdCheckDisruption(t *testing.T) {
+	healthy3 := &models.EtcdStatus{Members: 3, Healthy: 3, Quorum: 2}
+	degraded3 := &models.EtcdStatus{Members: 3, Healthy: 2, Quorum: 2, Unhealthy: []string{"m2"}}
+	healthy2 := &models.EtcdStatus{Members: 2, Healthy: 2, Quorum: 2}
+
+	tests := []struct {
+		name    string
+		etcd    *models.EtcdStatus
+		node    string
+		remove  bool
+		wantErr bool
+	}{
+		{"single master", nil, "m0", false, false},
+		{"stop one of three healthy", healthy3, "m0", false, false},
+		{"remove one of three healthy", healthy3, "m2", true, false},
+		{"stop a healthy member with one down", degraded3, "m0", false, true},
+		{"remove a healthy member with one down", degraded3, "m0", true, true},
+		{"remove the unhealthy member", degraded3, "m2", true, false},
+		{"stop the unhealthy member", degraded3, "m2", false, false},
+		{"remove down to one member", healthy2, "m1", true, false},
+		{"stop one of two", healthy2, "m1", false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.etcd.CheckDisruption(tt.node, tt.remove)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("CheckDisruption(%s, %v) = %v, wantErr %v", tt.node, tt.remove, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateMasterCount(t *testing.T) {
+	for n, ok := range map[int]bool{0: true, 1: true, 2: false, 3: true, 4: false, 5: true, -1: false} {
+		if err := models.ValidateMasterCount(n); (err == nil) != ok {
+			t.Errorf("ValidateMasterCount(%d) = %v, want ok %v", n, err, ok)
+		}
+	}
+}
@@ -0,0 +1,163 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/madhouselabs/goman/pkg/logger"
+	"github.com/madhouselabs/goman/pkg/models"
+	"github.com/madhouselabs/goman/pkg/provider"
+	"github.com/madhouselabs/goman/pkg/storage"
+	"gopkg.in/yaml.v3"
+)
+
+// Reasons of the events the controller records
+const (
+	EventPhaseChanged        = "PhaseChanged"
+	EventInstanceProvisioned = "InstanceProvisioned"
+	EventReconcileFailed     = "ReconcileFailed"
+	EventCloudDegraded       = "CloudProviderDegraded"
+	EventDeleting            = "Deleting"
+	EventDeleted             = "Deleted"
+	EventNotificationFailed  = "NotificationFailed"
+	EventStopRefused         = "StopRefused"
+	EventScheduled           = "Scheduled"
+	EventResynced            = "Resynced"
+	EventOutOfBandChange     = "OutOfBandChange"
+	EventChangeAttributed    = "ChangeAttributed"
+	EventOperationExpired    = "OperationExpired"
+	EventVerified            = "Verified"
+	EventVerifyFailed        = "SmokeTestsFailed"
+)
+
+// EventRecorder persists cluster events to storage, one object per event
+// under events/<cluster>/, for 'goman cluster events'. Events are
+// informational: failures to write them are logged, never returned.
+type EventRecorder struct {
+	storage provider.StorageService
+	source  string
+
+	mu   sync.Mutex
+	last time.Time // Keeps keys of events recorded at once in order
+}
+
+// NewEventRecorder creates a recorder writing to storage; source names the
+// controller in each event
+func NewEventRecorder(storage provider.StorageService, source string) *EventRecorder {
+	return &EventRecorder{storage: storage, source: source}
+}
+
+// Normal records an event about normal operation
+func (e *EventRecorder) Normal(ctx context.Context, clusterName, reason, object, format string, args ...interface{}) {
+	e.record(ctx, clusterName, models.EventTypeNormal, reason, object, fmt.Sprintf(format, args...))
+}
+
+// Warning records an event about something that went wrong
+func (e *EventRecorder) Warning(ctx context.Context, clusterName, reason, object, format string, args ...interface{}) {
+	e.record(ctx, clusterName, models.EventTypeWarning, reason, object, fmt.Sprintf(format, args...))
+}
+
+func (e *EventRecorder) record(ctx context.Context, clusterName string, eventType models.EventType, reason, object, message string) {
+	if e == nil || e.storage == nil || clusterName == "" {
+		return
+	}
+	event := models.Event{
+		Type:      eventType,
+		Reason:    reason,
+		Object:    object,
+		Message:   message,
+		Timestamp: e.now(),
+		Source:    e.source,
+	}
+	data, err := yaml.Marshal(event)
+	if err != nil {
+		logger.Warnf(ctx, "[EVENTS] Warning: Failed to marshal event: %v", err)
+		return
+	}
+	// The event outlives the reconcile's deadline if it just ran out
+	writeCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 10*time.Second)
+	defer cancel()
+	if err := e.storage.PutObject(writeCtx, storage.EventKey(clusterName, event), data); err != nil {
+		logger.Warnf(writeCtx, "[EVENTS] Warning: Failed to record %s event for %s: %v", reason, clusterName, err)
+	}
+}
+
+// now returns the current time, later than any event recorded before
+func (e *EventRecorder) now() time.Time {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	t := time.Now().UTC()
+	if !t.After(e.last) {
+		t = e.last.Add(time.Nanosecond)
+	}
+	e.last = t
+	return t
+}
+
+// Prune removes the oldest events of a cluster beyond
+// storage.MaxClusterEvents
+func (e *EventRecorder) Prune(ctx context.Context, clusterName string) {
+	if e == nil || e.storage == nil {
+		return
+	}
+	keys, err := e.storage.ListObjects(ctx, storage.ClusterEventsPrefix(clusterName))
+	if err != nil {
+		logger.Warnf(ctx, "[EVENTS] Warning: Failed to list events of %s: %v", clusterName, err)
+		return
+	}
+	if len(keys) <= storage.MaxClusterEvents {
+		return
+	}
+	sort.Strings(keys)
+	for _, key := range keys[:len(keys)-storage.MaxClusterEvents] {
+		if err := e.storage.DeleteObject(ctx, key); err != nil {
+			logger.Warnf(ctx, "[EVENTS] Warning: Failed to prune event %s: %v", key, err)
+		}
+	}
+}
+
+// recordPhaseChange records a cluster moving to another phase. Events are
+// pruned then, a few times per cluster lifecycle.
+func (r *Reconciler) recordPhaseChange(ctx context.Context, cluster *models.ClusterResource, previousPhase string) {
+	phase := cluster.Status.Phase
+	if phase == previousPhase {
+		return
+	}
+	if previousPhase == "" {
+		r.events.Normal(ctx, cluster.Name, EventPhaseChanged, "", "Phase is %s: %s", phase, cluster.Status.Message)
+	} else {
+		r.events.Normal(ctx, cluster.Name, EventPhaseChanged, "", "Phase changed from %s to %s: %s", previousPhase, phase, cluster.Status.Message)
+	}
+	r.events.Prune(ctx, cluster.Name)
+}
+
+// recordFailure records a failed reconcile, unless it failed the same way
+// last time: failures are retried and would repeat the same event. Call it
+// before the status is updated with the failure.
+func (r *Reconciler) recordFailure(ctx context.Context, cluster *models.ClusterResource, category provider.ErrorCategory, err error) {
+	if cluster.Status.Message == err.Error() {
+		return
+	}
+	// Throttling and service errors differ by request ID; one event per run
+	// of them is enough
+	if opErr, ok := provider.AsOperationError(err); ok && cluster.Status.CloudFailures > 0 &&
+		(opErr.Class == provider.ErrorClassThrottling || opErr.Class == provider.ErrorClassTransient) {
+		return
+	}
+	r.events.Warning(ctx, cluster.Name, EventReconcileFailed, "", "%s error: %v", category, err)
+}
+
+// recordInstanceProvisioned records an instance launched for a cluster
+func (r *Reconciler) recordInstanceProvisioned(ctx context.Context, clusterName string, config provider.InstanceConfig, instance *provider.Instance) {
+	zone := instance.AvailabilityZone
+	if zone == "" {
+		zone = config.AvailabilityZone
+	}
+	if zone != "" {
+		zone = " in " + zone
+	}
+	r.events.Normal(ctx, clusterName, EventInstanceProvisioned, config.Name, "Launched %s instance %s%s", config.InstanceType, instance.ID, zone)
+}
@@ -0,0 +1,59 @@
+package controller
+
+import (
+	"context"
+
+	"github.com/madhouselabs/goman/pkg/provider"
+)
+
+// fakeProvider serves the services a test sets; anything else panics on the
+// nil embedded Provider
+type fakeProvider struct {
+	provider.Provider
+	compute provider.ComputeService
+	storage provider.StorageService
+	secrets provider.SecretService
+	locks   provider.LockService
+	region  string
+}
+
+func (p *fakeProvider) GetComputeService() provider.ComputeService { return p.compute }
+func (p *fakeProvider) GetStorageService() provider.StorageService { return p.storage }
+func (p *fakeProvider) GetSecretService() provider.SecretService   { return p.secrets }
+func (p *fakeProvider) GetLockService() provider.LockService       { return p.locks }
+func (p *fakeProvider) Region() string                             { return p.region }
+
+// regionalCompute lists only the instances of the region asked for, or of
+// its default region, and remembers the instance regions it is told, like
+// the EC2 compute service
+type regionalCompute struct {
+	provider.ComputeService
+	instances  map[string]map[string]string // region -> ID -> state
+	remembered map[string]string            // ID -> region
+}
+
+func (c *regionalCompute) ListInstances(ctx context.Context, filters map[string]string) ([]*provider.Instance, error) {
+	region := filters["region"]
+	if region == "" {
+		region = "us-east-1"
+	}
+	var result []*provider.Instance
+	for id, state := range c.instances[region] {
+		result = append(result, &provider.Instance{ID: id, State: state})
+	}
+	return result, nil
+}
+
+func (c *regionalCompute) DeleteInstance(ctx context.Context, instanceID string) error {
+	for _, instances := range c.instances {
+		delete(instances, instanceID)
+	}
+	return nil
+}
+
+func (c *regionalCompute) RememberInstanceRegion(instanceID, region string) {
+	if c.remembered == nil {
+		c.remembered = map[string]string{}
+	}
+	c.remembered[instanceID] = region
+}
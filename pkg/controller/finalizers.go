@@ -0,0 +1,224 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/madhouselabs/goman/pkg/logger"
+	"github.com/madhouselabs/goman/pkg/models"
+	"github.com/madhouselabs/goman/pkg/provider"
+	"github.com/madhouselabs/goman/pkg/storage"
+)
+
+// securityGroupDeleteTimeout bounds how long a deletion waits for the
+// cluster's security groups to be deleted before leaving them behind
+const securityGroupDeleteTimeout = 30 * time.Minute
+
+// liveInstanceStates are the instance states a deletion waits out
+const liveInstanceStates = "pending,running,shutting-down,stopping,stopped"
+
+// runFinalizers works off the finalizers of a cluster being deleted, in
+// order. It returns true once none is left; false while a cleanup is still
+// waiting on the cloud, so the deletion is requeued.
+func (r *Reconciler) runFinalizers(ctx context.Context, cluster *models.ClusterResource) bool {
+	if cluster.HasFinalizer(models.FinalizerInstances) {
+		if !r.deleteInstances(ctx, cluster) {
+			return false
+		}
+		cluster.RemoveFinalizer(models.FinalizerInstances)
+	}
+
+	if cluster.HasFinalizer(models.FinalizerNetwork) {
+		// Remove firewall rules and peerings shared with linked clusters
+		r.unlinkAllClusters(ctx, cluster)
+
+		// The API load balancer, the security groups and a dedicated VPC go
+		// once they are free; the cluster stays in Deleting until then
+		if !r.deleteLoadBalancer(ctx, cluster) || !r.deleteSecurityGroups(ctx, cluster) || !r.deleteDedicatedNetwork(ctx, cluster) {
+			return false
+		}
+		cluster.RemoveFinalizer(models.FinalizerNetwork)
+	}
+	return len(cluster.Status.Finalizers) == 0
+}
+
+// deleteInstances deletes all instances of a cluster being deleted, found in
+// the cloud rather than the status so instances the status lost go too. It
+// returns true only once the cloud lists none left, terminating ones
+// included; failed deletions are retried on the next pass.
+func (r *Reconciler) deleteInstances(ctx context.Context, cluster *models.ClusterResource) bool {
+	computeService := r.provider.GetComputeService()
+
+	instances, err := r.listLiveInstances(ctx, cluster)
+	if err != nil {
+		logger.Warnf(ctx, "[DELETE] Failed to list instances of cluster %s: %v", cluster.Name, err)
+		cluster.Status.Message = "Failed to list the instances to delete, retrying: " + err.Error()
+		return false
+	}
+
+	var pending []*provider.Instance
+	for _, instance := range instances {
+		if !instanceTerminating(instance.State) {
+			pending = append(pending, instance)
+		}
+	}
+	logger.Infof(ctx, "[DELETE] Found %d instances of cluster %s, %d to delete", len(instances), cluster.Name, len(pending))
+
+	var mu sync.Mutex
+	var failures []error
+	forEachNode(ctx, len(pending), r.settings.nodeParallelism(len(pending)), func(ctx context.Context, i int) error {
+		instance := pending[i]
+		logger.Infof(ctx, "[DELETE] Deleting instance %s (%s) - %s", instance.Name, instance.ID, instance.State)
+		if err := computeService.DeleteInstance(ctx, instance.ID); err != nil {
+			logger.Warnf(ctx, "[DELETE] Failed to delete instance %s: %v", instance.ID, err)
+			mu.Lock()
+			failures = append(failures, fmt.Errorf("%s: %w", instance.ID, err))
+			mu.Unlock()
+			return err
+		}
+		return nil
+	})
+
+	// Verify against the cloud, not the calls that succeeded
+	if len(instances) > 0 {
+		instances, err = r.listLiveInstances(ctx, cluster)
+		if err != nil {
+			logger.Warnf(ctx, "[DELETE] Failed to list instances of cluster %s: %v", cluster.Name, err)
+			cluster.Status.Message = "Failed to verify the instances were deleted, retrying: " + err.Error()
+			return false
+		}
+	}
+	if len(instances) == 0 {
+		logger.Infof(ctx, "[DELETE] No instances of cluster %s left", cluster.Name)
+		return true
+	}
+
+	if len(failures) > 0 {
+		cluster.Status.Message = fmt.Sprintf("Failed to delete %d of %d instances, retrying: %v", len(failures), len(instances), errors.Join(failures...))
+		r.events.Warning(ctx, cluster.Name, EventDeleting, "", "%s", cluster.Status.Message)
+	} else {
+		cluster.Status.Message = fmt.Sprintf("Waiting for %d instances to terminate", len(instances))
+	}
+	logger.Infof(ctx, "[DELETE] %d instances of cluster %s left", len(instances), cluster.Name)
+	return false
+}
+
+// listLiveInstances lists the instances of a cluster not yet terminated in
+// each of its regions: the cluster's region and any other region the status
+// recorded an instance in. The provider searches only its default region
+// when given none. Providers without regions list the same instances for
+// each, so they are counted once.
+func (r *Reconciler) listLiveInstances(ctx context.Context, cluster *models.ClusterResource) ([]*provider.Instance, error) {
+	var instances []*provider.Instance
+	seen := make(map[string]bool)
+	for _, region := range clusterRegions(cluster) {
+		// The filters are built per call, the provider consumes the region
+		filters := map[string]string{
+			"tag:goman-cluster":   cluster.Name,
+			"instance-state-name": liveInstanceStates,
+		}
+		if region != "" {
+			filters["region"] = region
+		}
+		found, err := r.provider.GetComputeService().ListInstances(ctx, filters)
+		if err != nil && region != "" {
+			return nil, fmt.Errorf("in %s: %w", region, err)
+		} else if err != nil {
+			return nil, err
+		}
+		for _, instance := range found {
+			if !seen[instance.ID] {
+				seen[instance.ID] = true
+				instances = append(instances, instance)
+			}
+		}
+	}
+	return instances, nil
+}
+
+// clusterRegions returns the cluster's region followed by the other regions
+// its instances were recorded in
+func clusterRegions(cluster *models.ClusterResource) []string {
+	var regions []string
+	seen := make(map[string]bool)
+	add := func(region string) {
+		if region != "" && !seen[region] {
+			seen[region] = true
+			regions = append(regions, region)
+		}
+	}
+	add(cluster.Spec.Region)
+	for _, inst := range cluster.Status.Instances {
+		add(inst.Region)
+	}
+	if len(regions) == 0 {
+		// The provider's default region
+		regions = append(regions, "")
+	}
+	return regions
+}
+
+// instanceTerminating reports whether an instance is already on its way out
+func instanceTerminating(state string) bool {
+	return state == "shutting-down" || state == provider.InstanceStateTerminating || state == provider.InstanceStateTerminated
+}
+
+// deleteSecurityGroups deletes the security groups of a cluster being
+// deleted. It returns false while network interfaces of its instances are
+// still being released, so the deletion is requeued; after
+// securityGroupDeleteTimeout the groups are left for deletion by hand.
+func (r *Reconciler) deleteSecurityGroups(ctx context.Context, cluster *models.ClusterResource) bool {
+	cleaner, ok := r.provider.GetComputeService().(provider.ClusterNetworkCleaner)
+	if !ok {
+		return true
+	}
+
+	err := cleaner.DeleteClusterSecurityGroups(ctx, cluster.Spec.Region, cluster.Name)
+	if err == nil {
+		logger.Infof(ctx, "[DELETE] Deleted security groups of cluster %s", cluster.Name)
+		return true
+	}
+	if cluster.DeletionTimestamp != nil && time.Since(*cluster.DeletionTimestamp) > securityGroupDeleteTimeout {
+		logger.Infof(ctx, "[DELETE] Giving up deleting the security groups of cluster %s: %v", cluster.Name, err)
+		r.events.Warning(ctx, cluster.Name, EventDeleting, "", "Security groups not deleted after %s, delete them by hand: %v", securityGroupDeleteTimeout, err)
+		return true
+	}
+	if errors.Is(err, provider.ErrNetworkInUse) {
+		logger.Infof(ctx, "[DELETE] Security groups of cluster %s not deleted yet: %v", cluster.Name, err)
+		cluster.Status.Message = "Waiting for network interfaces to be released to delete the security groups"
+	} else {
+		logger.Warnf(ctx, "[DELETE] Failed to delete security groups of cluster %s: %v", cluster.Name, err)
+		cluster.Status.Message = "Failed to delete the security groups, retrying: " + err.Error()
+	}
+	return false
+}
+
+// deleteClusterRecords removes the secrets, intent queue, status, config and
+// tombstone of a deleted cluster, stopping at the first failure so a retry
+// finds what is left. The status goes before the config: a cluster whose
+// config is still there is deleted again from scratch, with its cleanups
+// verified anew.
+func (r *Reconciler) deleteClusterRecords(ctx context.Context, clusterName string) error {
+	secretService := r.provider.GetSecretService()
+	for _, name := range provider.ClusterSecrets {
+		if err := secretService.DeleteSecret(ctx, clusterName, name); err != nil && !errors.Is(err, provider.ErrNotFound) {
+			return fmt.Errorf("failed to delete secret %s: %w", name, err)
+		}
+	}
+
+	storageService := r.provider.GetStorageService()
+	for _, key := range []string{
+		storage.IntentQueueKey(clusterName),
+		storage.ClusterStatusKey(clusterName),
+		storage.ClusterConfigKey(clusterName),
+		storage.TombstoneKey(clusterName),
+	} {
+		if err := storageService.DeleteObject(ctx, key); err != nil && !errors.Is(err, provider.ErrNotFound) {
+			return fmt.Errorf("failed to delete %s: %w", key, err)
+		}
+	}
+	return nil
+}
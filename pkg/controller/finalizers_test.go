@@ -0,0 +1,137 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/madhouselabs/goman/pkg/models"
+	"github.com/madhouselabs/goman/pkg/provider"
+)
+
+// finalizerCompute lists deleted instances shutting down once, like EC2, and
+// holds the security groups while any instance is left
+type finalizerCompute struct {
+	provider.ComputeService
+	instances map[string]string // ID -> state
+	failOnce  map[string]bool
+	groupsIn  int
+	groups    bool
+}
+
+func (c *finalizerCompute) ListInstances(ctx context.Context, filters map[string]string) ([]*provider.Instance, error) {
+	var result []*provider.Instance
+	for id, state := range c.instances {
+		result = append(result, &provider.Instance{ID: id, State: state})
+	}
+	// Instances listed shutting down are gone by the next listing
+	for id, state := range c.instances {
+		if state == "shutting-down" {
+			delete(c.instances, id)
+		}
+	}
+	return result, nil
+}
+
+func (c *finalizerCompute) DeleteInstance(ctx context.Context, instanceID string) error {
+	if c.failOnce[instanceID] {
+		delete(c.failOnce, instanceID)
+		return errors.New("throttled")
+	}
+	c.instances[instanceID] = "shutting-down"
+	return nil
+}
+
+func (c *finalizerCompute) DeleteClusterSecurityGroups(ctx context.Context, region, clusterName string) error {
+	if len(c.instances) > 0 {
+		return errors.New("instances left")
+	}
+	if c.groupsIn > 0 {
+		c.groupsIn--
+		return provider.ErrNetworkInUse
+	}
+	c.groups = false
+	return nil
+}
+
+func TestRunFinalizers(t *testing.T) {
+	compute := &finalizerCompute{
+		instances: map[string]string{"i-1": "running", "i-2": "stopped"},
+		failOnce:  map[string]bool{"i-2": true},
+		groupsIn:  1,
+		groups:    true,
+	}
+	r := &Reconciler{provider: &fakeProvider{compute: compute}, settings: DefaultSettings()}
+	deleted := time.Now()
+	cluster := &models.ClusterResource{Name: "demo", DeletionTimestamp: &deleted}
+	if !cluster.AddFinalizers() || cluster.AddFinalizers() {
+		t.Fatalf("finalizers %v", cluster.Status.Finalizers)
+	}
+	ctx := context.Background()
+
+	// The records stay while any instance is listed, a failed deletion
+	// is retried, and the security groups wait for their interfaces
+	passes := 0
+	for !r.runFinalizers(ctx, cluster) {
+		passes++
+		if passes > 10 {
+			t.Fatalf("finalizers %v left: %s", cluster.Status.Finalizers, cluster.Status.Message)
+		}
+		if len(compute.instances) > 0 && !cluster.HasFinalizer(models.FinalizerInstances) {
+			t.Fatalf("instances finalizer removed with instances %v left", compute.instances)
+		}
+	}
+	if len(compute.instances) != 0 || compute.groups || len(cluster.Status.Finalizers) != 0 {
+		t.Errorf("instances %v, groups kept %t, finalizers %v", compute.instances, compute.groups, cluster.Status.Finalizers)
+	}
+	if passes < 3 {
+		t.Errorf("deletion verified in %d passes", passes)
+	}
+
+	// Nothing left to verify
+	if !r.runFinalizers(ctx, cluster) || len(cluster.Status.Finalizers) != 0 {
+		t.Errorf("finalizers %v", cluster.Status.Finalizers)
+	}
+}
+
+func TestDeleteInstancesOutsideDefaultRegion(t *testing.T) {
+	tests := []struct {
+		name    string
+		region  string
+		regions []string // recorded in the status
+		kept    []string // regions whose instances are not the cluster's
+	}{
+		{"cluster region", "eu-west-1", nil, []string{"us-east-1", "ap-south-1"}},
+		{"recorded regions", "eu-west-1", []string{"eu-west-1", "ap-south-1"}, []string{"us-east-1"}},
+		{"default region", "", nil, []string{"eu-west-1", "ap-south-1"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			compute := &regionalCompute{instances: map[string]map[string]string{
+				"us-east-1":  {"i-1": "running"},
+				"eu-west-1":  {"i-2": "running", "i-3": "stopped"},
+				"ap-south-1": {"i-4": "running"},
+			}}
+			r := &Reconciler{provider: &fakeProvider{compute: compute}, settings: DefaultSettings()}
+			cluster := &models.ClusterResource{Name: "demo"}
+			cluster.Spec.Region = tt.region
+			for _, region := range tt.regions {
+				cluster.Status.Instances = append(cluster.Status.Instances, models.InstanceStatus{Region: region})
+			}
+
+			if !r.deleteInstances(context.Background(), cluster) {
+				t.Fatalf("deletion not verified: %s", cluster.Status.Message)
+			}
+			for region, instances := range compute.instances {
+				kept := false
+				for _, k := range tt.kept {
+					kept = kept || k == region
+				}
+				if kept == (len(instances) == 0) {
+					t.Errorf("%s: instances %v left", region, instances)
+				}
+			}
+		})
+	}
+}
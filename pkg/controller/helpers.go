@@ -3,20 +3,22 @@ package controller
 import (
 	"context"
 	"fmt"
-	"log"
+	"strings"
 	"time"
 
+	"github.com/madhouselabs/goman/pkg/logger"
 	"github.com/madhouselabs/goman/pkg/models"
+	"github.com/madhouselabs/goman/pkg/provider"
 	"github.com/madhouselabs/goman/pkg/storage"
 	"gopkg.in/yaml.v3"
 )
 
 // acquireLock acquires a distributed lock for a resource
 func (r *Reconciler) acquireLock(ctx context.Context, resourceID string) (string, error) {
-	lockCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	lockCtx, cancel := context.WithTimeout(ctx, r.settings.LockAcquireTimeout)
 	defer cancel()
 
-	return r.provider.GetLockService().AcquireLock(lockCtx, resourceID, r.owner, 15*time.Minute)
+	return r.provider.GetLockService().AcquireLock(lockCtx, resourceID, r.owner, r.settings.LockTTL)
 }
 
 // releaseLock releases a distributed lock
@@ -26,14 +28,14 @@ func (r *Reconciler) releaseLock(ctx context.Context, resourceID, token string)
 
 	err := r.provider.GetLockService().ReleaseLock(lockCtx, resourceID, token)
 	if err != nil {
-		log.Printf("[LOCK] Failed to release lock %s: %v", resourceID, err)
+		logger.Warnf(lockCtx, "[LOCK] Failed to release lock %s: %v", resourceID, err)
 	}
 }
 
 // loadCluster loads cluster configuration and status from S3
 func (r *Reconciler) loadCluster(ctx context.Context, clusterName string) (*models.ClusterResource, error) {
-	configKey := fmt.Sprintf("clusters/%s/config.yaml", clusterName)
-	statusKey := fmt.Sprintf("clusters/%s/status.yaml", clusterName)
+	configKey := storage.ClusterConfigKey(clusterName)
+	statusKey := storage.ClusterStatusKey(clusterName)
 
 	// Load config
 	configData, err := r.provider.GetStorageService().GetObject(ctx, configKey)
@@ -45,80 +47,113 @@ func (r *Reconciler) loadCluster(ctx context.Context, clusterName string) (*mode
 	var config storage.ClusterConfig
 	err = yaml.Unmarshal(configData, &config)
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse cluster config: %w", err)
+		return nil, provider.UserConfigErrorf("failed to parse cluster config: %w", err)
 	}
 	
 	// Debug: Log the NodePools
-	log.Printf("[DEBUG] Loaded config for %s: NodePools count = %d", clusterName, len(config.Spec.NodePools))
+	logger.Debugf(ctx, "[DEBUG] Loaded config for %s: NodePools count = %d", clusterName, len(config.Spec.NodePools))
 	for i, np := range config.Spec.NodePools {
-		log.Printf("[DEBUG]   NodePool %d: name=%s, count=%d, type=%s", i, np.Name, np.Count, np.InstanceType)
+		logger.Debugf(ctx, "[DEBUG]   NodePool %d: name=%s, count=%d, type=%s", i, np.Name, np.Count, np.InstanceType)
 	}
 
 	// Convert to models.ClusterResource
-	cluster := &models.ClusterResource{
-		Name:              clusterName,
-		Namespace:         "", // Will be set from provider
-		DeletionTimestamp: config.Metadata.DeletionTimestamp,
-		Spec: models.ClusterSpec{
-			Provider:     "aws",
-			Region:       config.Spec.Region,
-			InstanceType: config.Spec.InstanceType,
-			Mode:         string(config.Spec.Mode),
-			K3sVersion:   config.Spec.K3sVersion,
-			DesiredState: config.Spec.DesiredState,
-		},
-		Labels:      config.Metadata.Labels,
-		Annotations: config.Metadata.Annotations,
+	cluster := storage.ClusterResourceFromConfig(&config)
+	cluster.Name = clusterName
+
+	// Expand the sizing preset into concrete spec fields
+	if err := cluster.Spec.ApplyPreset(); err != nil {
+		return nil, provider.UserConfigErrorf("invalid cluster spec: %w", err)
 	}
-	
-	// Convert NodePools if present
-	if len(config.Spec.NodePools) > 0 {
-		cluster.Spec.NodePools = make([]models.NodePool, len(config.Spec.NodePools))
-		for i, np := range config.Spec.NodePools {
-			cluster.Spec.NodePools[i] = models.NodePool{
-				Name:         np.Name,
-				Count:        np.Count,
-				InstanceType: np.InstanceType,
-				Labels:       np.Labels,
-			}
-			// Convert taints if present
-			if len(np.Taints) > 0 {
-				cluster.Spec.NodePools[i].Taints = make([]models.Taint, len(np.Taints))
-				for j, t := range np.Taints {
-					cluster.Spec.NodePools[i].Taints[j] = models.Taint{
-						Key:    t.Key,
-						Value:  t.Value,
-						Effect: t.Effect,
-					}
-				}
-			}
+	if err := models.ValidateRootVolume("cluster "+cluster.Name, cluster.Spec.RootVolume); err != nil {
+		return nil, provider.UserConfigErrorf("invalid cluster spec: %w", err)
+	}
+	if err := models.ValidateK3sVersion(cluster.Spec.K3sVersion); err != nil {
+		return nil, provider.UserConfigErrorf("invalid cluster spec: %w", err)
+	}
+	if err := models.ValidateMasterCount(cluster.Spec.MasterCount); err != nil {
+		return nil, provider.UserConfigErrorf("invalid cluster spec: %w", err)
+	}
+	if err := models.ValidateArchitecture("cluster "+cluster.Name, cluster.Spec.Architecture, cluster.Spec.InstanceType); err != nil {
+		return nil, provider.UserConfigErrorf("invalid cluster spec: %w", err)
+	}
+	if err := models.ValidateImage("cluster "+cluster.Name, cluster.Spec.ImageID, cluster.Spec.OSFamily); err != nil {
+		return nil, provider.UserConfigErrorf("invalid cluster spec: %w", err)
+	}
+	if err := models.ValidateProxy(cluster.Spec.Proxy); err != nil {
+		return nil, provider.UserConfigErrorf("invalid cluster spec: %w", err)
+	}
+	if err := models.ValidateBootstrap(cluster.Spec.Bootstrap); err != nil {
+		return nil, provider.UserConfigErrorf("invalid cluster spec: %w", err)
+	}
+	if err := models.ValidateRegistries(cluster.Name, cluster.Spec.Registries, cluster.Spec.Bootstrap); err != nil {
+		return nil, provider.UserConfigErrorf("invalid cluster spec: %w", err)
+	}
+	if err := models.ValidateNetwork(cluster.Spec.Network); err != nil {
+		return nil, provider.UserConfigErrorf("invalid cluster spec: %w", err)
+	}
+	if err := models.ValidateSchedule(cluster.Spec.Schedule); err != nil {
+		return nil, provider.UserConfigErrorf("invalid cluster spec: %w", err)
+	}
+	for _, pool := range cluster.Spec.NodePools {
+		if err := models.ValidateDataVolumes(pool.Name, pool.Volumes); err != nil {
+			return nil, provider.UserConfigErrorf("invalid cluster spec: %w", err)
+		}
+		if err := models.ValidateRootVolume("node pool "+pool.Name, pool.RootVolume); err != nil {
+			return nil, provider.UserConfigErrorf("invalid cluster spec: %w", err)
+		}
+		if err := models.ValidateUserData(pool.Name, pool.UserData); err != nil {
+			return nil, provider.UserConfigErrorf("invalid cluster spec: %w", err)
+		}
+		if err := models.ValidateScaleDownBehavior(pool.Name, pool.ScaleDownBehavior); err != nil {
+			return nil, provider.UserConfigErrorf("invalid cluster spec: %w", err)
+		}
+		if err := models.ValidateArchitecture("node pool "+pool.Name, pool.Architecture, pool.InstanceType); err != nil {
+			return nil, provider.UserConfigErrorf("invalid cluster spec: %w", err)
+		}
+		if err := models.ValidateImage("node pool "+pool.Name, pool.ImageID, pool.OSFamily); err != nil {
+			return nil, provider.UserConfigErrorf("invalid cluster spec: %w", err)
+		}
+		if err := models.ValidateAvailabilityZones(pool.Name, cluster.Spec.Region, pool.AvailabilityZones); err != nil {
+			return nil, provider.UserConfigErrorf("invalid cluster spec: %w", err)
 		}
 	}
-	
-	// Set master count based on mode
-	if config.Spec.Mode == "ha" {
-		cluster.Spec.MasterCount = 3
-	} else {
-		cluster.Spec.MasterCount = 1
+	if err := models.ValidateClusterLinks(cluster.Name, cluster.Spec.ClusterLinks); err != nil {
+		return nil, provider.UserConfigErrorf("invalid cluster spec: %w", err)
+	}
+	if err := models.ValidateAddons(cluster.Spec.Addons, cluster.Spec.LowResource); err != nil {
+		return nil, provider.UserConfigErrorf("invalid cluster spec: %w", err)
 	}
 
 	// Load status if exists
+	staleStatus := false
 	statusData, err := r.provider.GetStorageService().GetObject(ctx, statusKey)
 	if err == nil {
-		log.Printf("[DEBUG] Status YAML for %s:\n%s", clusterName, string(statusData))
+		logger.Debugf(ctx, "[DEBUG] Status YAML for %s:\n%s", clusterName, string(statusData))
 		var status models.ClusterResourceStatus
 		err = yaml.Unmarshal(statusData, &status)
-		if err == nil {
+		if err == nil && !storage.StatusBelongsTo(status.ClusterID, cluster.ClusterID) {
+			// Left by an earlier cluster of the same name. Clients never
+			// remove it, so the first save replaces it entirely.
+			logger.Infof(ctx, "[RECONCILE] Replacing the status of an earlier cluster %s (ID %s)", clusterName, status.ClusterID)
+			staleStatus = true
+		} else if err == nil {
 			cluster.Status = status
-			log.Printf("[DEBUG] Loaded status: phase=%s, instances=%d", status.Phase, len(status.Instances))
+			logger.Debugf(ctx, "[DEBUG] Loaded status: phase=%s, instances=%d", status.Phase, len(status.Instances))
 			for i, inst := range status.Instances {
-				log.Printf("[DEBUG]   Instance %d: ID=%s, IP=%s", i, inst.InstanceID, inst.PrivateIP)
+				logger.Debugf(ctx, "[DEBUG]   Instance %d: ID=%s, IP=%s", i, inst.InstanceID, inst.PrivateIP)
 			}
 		} else {
-			log.Printf("[DEBUG] Failed to unmarshal status: %v", err)
+			logger.Debugf(ctx, "[DEBUG] Failed to unmarshal status: %v", err)
 		}
 	} else {
-		log.Printf("[DEBUG] No status found: %v", err)
+		logger.Debugf(ctx, "[DEBUG] No status found: %v", err)
+	}
+
+	// Remember what was stored so saveCluster only writes fields we change
+	if staleStatus {
+		r.forgetLoadedStatus(clusterName)
+	} else {
+		r.rememberLoadedStatus(clusterName, cluster.Status)
 	}
 
 	// Initialize status if empty
@@ -132,24 +167,311 @@ func (r *Reconciler) loadCluster(ctx context.Context, clusterName string) (*mode
 	return cluster, nil
 }
 
-// saveCluster saves cluster status to S3
+// saveCluster saves cluster status to S3.
+// Only fields changed since the cluster was loaded are written; everything
+// else keeps the currently stored value so concurrent updates are not lost.
 func (r *Reconciler) saveCluster(ctx context.Context, cluster *models.ClusterResource) error {
-	statusKey := fmt.Sprintf("clusters/%s/status.yaml", cluster.Name)
-	
-	now := time.Now()
-	cluster.Status.LastReconcileTime = &now
+	base := r.loadedStatus(cluster.Name)
+	cluster.Status.ClusterID = cluster.ClusterID
 
-	statusData, err := yaml.Marshal(cluster.Status)
+	merged, err := r.patchClusterStatus(ctx, cluster.Name, func(stored *models.ClusterResourceStatus) error {
+		mergeStatus(base, &cluster.Status, stored)
+		return nil
+	})
 	if err != nil {
-		return fmt.Errorf("failed to marshal cluster status: %w", err)
+		return err
 	}
 
-	err = r.provider.GetStorageService().PutObject(ctx, statusKey, statusData)
-	if err != nil {
-		return fmt.Errorf("failed to save cluster status: %w", err)
+	cluster.Status = *merged
+	return nil
+}
+
+// drainNode drains a K3s node from a master and optionally deletes the node
+// object, using the managed drain-node operation
+func (r *Reconciler) drainNode(ctx context.Context, masterInstanceID, nodeName, timeout string, deleteNode bool) (*provider.CommandResult, error) {
+	return r.runOperation(ctx, []string{masterInstanceID}, provider.OperationDrainNode, map[string]string{
+		"NodeName": nodeName,
+		"Timeout":  timeout,
+		"Delete":   fmt.Sprintf("%t", deleteNode),
+	})
+}
+
+// recordOperationError stores cloud API failure details (operation, error
+// code, request ID) in the cluster status when err carries them
+func recordOperationError(ctx context.Context, cluster *models.ClusterResource, err error) {
+	opErr, ok := provider.AsOperationError(err)
+	if !ok {
+		return
 	}
 
-	return nil
+	cluster.RecordOperationError(models.OperationErrorRecord{
+		Time:      time.Now(),
+		Phase:     cluster.Status.Phase,
+		Service:   opErr.Service,
+		Operation: opErr.Operation,
+		Code:      opErr.Code,
+		RequestID: opErr.RequestID,
+		Class:     string(opErr.Class),
+		Message:   opErr.Err.Error(),
+	})
+	logger.Warnf(ctx, "[RECONCILE] %s %s failed for cluster %s: code=%s class=%s request=%s",
+		opErr.Service, opErr.Operation, cluster.Name, opErr.Code, opErr.Class, opErr.RequestID)
+}
+
+// reportFailure records the category and message of a failure in the status
+// of a cluster that could not be loaded, keeping its phase
+func (r *Reconciler) reportFailure(ctx context.Context, clusterName string, err error) {
+	_, patchErr := r.patchClusterStatus(ctx, clusterName, func(status *models.ClusterResourceStatus) error {
+		status.Reason = string(provider.Categorize(err))
+		status.Message = err.Error()
+		return nil
+	})
+	if patchErr != nil {
+		logger.Warnf(ctx, "[RECONCILE] Failed to report failure of cluster %s: %v", clusterName, patchErr)
+	}
+}
+
+// masterInstanceConfig builds the instance configuration for a master node,
+// carrying preset sizing and K3s component toggles
+func masterInstanceConfig(cluster *models.ClusterResource, name string, tags map[string]string) provider.InstanceConfig {
+	tags["goman-cluster"] = cluster.Name
+	tags["goman-role"] = "master"
+	tags["ManagedBy"] = "goman"
+	tags["goman-k3s-disable"] = "none"
+	tags[k3sVersionTag] = launchK3sVersion(cluster)
+	if len(cluster.Spec.DisabledComponents) > 0 {
+		tags["goman-k3s-disable"] = strings.Join(cluster.Spec.DisabledComponents, ",")
+	}
+	if cluster.Spec.LowResource {
+		tags[lowResourceTag] = "true"
+	}
+
+	instanceConfig := provider.InstanceConfig{
+		Name:         name,
+		Region:       cluster.Spec.Region,
+		InstanceType: cluster.Spec.InstanceType,
+		Architecture: models.InstanceArchitecture(cluster.Spec.InstanceType),
+		Tags:         tags,
+		ResourceTags: cluster.Spec.Tags,
+
+		ShutdownBehavior:   cluster.Spec.InstanceProtection.Behavior(),
+		StopProtection:     cluster.Spec.InstanceProtection.StopProtected(),
+		DeletionProtection: cluster.Spec.InstanceProtection.DeletionProtected(cluster.Spec.Tags),
+	}
+	setRootVolume(&instanceConfig, cluster.Spec.RootVolume)
+	instanceConfig.ImageID, instanceConfig.OSFamily = cluster.Spec.ImageID, cluster.Spec.OSFamily
+	setProxy(&instanceConfig, cluster)
+	setBootstrap(&instanceConfig, cluster.Spec.Bootstrap)
+	setRegistries(&instanceConfig, cluster)
+	setNetwork(&instanceConfig, cluster.Spec.Network)
+	return instanceConfig
+}
+
+// setRootVolume sizes the root volume of an instance; nil keeps the image default
+func setRootVolume(instanceConfig *provider.InstanceConfig, v *models.RootVolume) {
+	if v == nil {
+		return
+	}
+	instanceConfig.RootVolumeSize = v.Size
+	instanceConfig.RootVolumeType = v.VolumeType()
+	instanceConfig.RootVolumeIOPS = v.IOPS
+}
+
+// setProxy passes the cluster's outbound proxy to the bootstrap script; nil
+// leaves nodes with direct internet access
+func setProxy(instanceConfig *provider.InstanceConfig, cluster *models.ClusterResource) {
+	proxy := cluster.Spec.Proxy
+	if !proxy.Enabled() {
+		return
+	}
+	instanceConfig.Proxy = &provider.ProxyConfig{
+		HTTPProxy:  proxy.HTTPProxy,
+		HTTPSProxy: proxy.HTTPS(),
+		NoProxy:    proxy.NoProxyList(cluster.Spec.Network.PodCIDR, cluster.Spec.Network.ServiceCIDR),
+	}
+}
+
+// setBootstrap passes the cluster's additions to the bootstrap script; nil
+// boots nodes with the script alone
+func setBootstrap(instanceConfig *provider.InstanceConfig, bootstrap *models.BootstrapSpec) {
+	if !bootstrap.Enabled() {
+		return
+	}
+	instanceConfig.Bootstrap = &provider.BootstrapConfig{
+		PreInstall:      bootstrap.PreInstall,
+		ExtraPackages:   bootstrap.ExtraPackages,
+		RegistryMirrors: bootstrap.RegistryMirrors,
+	}
+}
+
+// setRegistries passes the cluster's registry configuration, with the
+// bootstrap mirrors, to the bootstrap script; nil leaves the bootstrap
+// mirrors alone
+func setRegistries(instanceConfig *provider.InstanceConfig, cluster *models.ClusterResource) {
+	if !cluster.Spec.Registries.Enabled() {
+		return
+	}
+	config := registryConfig(cluster)
+	instanceConfig.Registries = &config
+}
+
+// registryConfig converts the cluster's registries for the nodes. The
+// credentials are read in the region of the provider, like the cluster's
+// own secrets.
+func registryConfig(cluster *models.ClusterResource) provider.RegistryConfig {
+	spec := cluster.Spec.Registries
+	config := provider.RegistryConfig{Mirrors: spec.MirrorsWith(cluster.Spec.Bootstrap)}
+	if spec == nil {
+		return config
+	}
+	config.Auth = make(map[string]provider.RegistryAuth, len(spec.Auth))
+	for host, auth := range spec.Auth {
+		switch {
+		case auth.ECR:
+			region, _ := models.ECRRegion(host)
+			config.Auth[host] = provider.RegistryAuth{ECRRegion: region}
+		case strings.HasPrefix(auth.Credentials, models.CredentialsSSM):
+			config.Auth[host] = provider.RegistryAuth{Parameter: strings.TrimPrefix(auth.Credentials, models.CredentialsSSM)}
+		default:
+			config.Auth[host] = provider.RegistryAuth{Secret: strings.TrimPrefix(auth.Credentials, models.CredentialsSecretsManager)}
+		}
+	}
+	return config
+}
+
+// setNetwork places an instance in the cluster's VPC and subnets, its
+// dedicated VPC, or the default VPC if it has neither
+func setNetwork(instanceConfig *provider.InstanceConfig, network models.NetworkConfig) {
+	instanceConfig.PrivateNetwork = network.Private
+	instanceConfig.VpcID = network.VpcID
+	instanceConfig.SubnetIDs = network.SubnetIDs
+	instanceConfig.ExtraSecurityGroups = network.SecurityGroupIDs
+	if network.DedicatedVPC {
+		instanceConfig.DedicatedVPC = true
+		instanceConfig.VpcCIDR = network.DedicatedCIDR()
+	}
+}
+
+// instanceTypeTag records the pool instance type a worker was launched with.
+// Workers whose tag differs from the pool spec are rolled out; workers whose
+// actual type differs from the tag have drifted.
+const instanceTypeTag = "goman-instance-type"
+
+// lowResourceTag selects the low-resource K3s profile in the bootstrap script
+const lowResourceTag = "goman-low-resource"
+
+// instanceStoreTag makes the bootstrap script put containerd and kubelet
+// storage on the instance store
+const instanceStoreTag = "goman-instance-store"
+
+// gpuTag makes the bootstrap script install the NVIDIA driver and container
+// runtime
+const gpuTag = "goman-gpu"
+
+// workerInstanceConfig builds the instance configuration for a worker in a
+// node pool. Pool labels and taints are carried as tags and applied to the
+// node by reconcileNodeMetadata once it joins.
+func workerInstanceConfig(cluster *models.ClusterResource, name string, pool models.NodePool, masterIP, nodeToken string) provider.InstanceConfig {
+	instanceConfig := provider.InstanceConfig{
+		Name:         name,
+		Region:       cluster.Spec.Region,
+		InstanceType: pool.InstanceType,
+		Architecture: models.InstanceArchitecture(pool.InstanceType),
+		Tags: map[string]string{
+			"goman-cluster":    cluster.Name,
+			"goman-role":       "worker",
+			"goman-nodepool":   pool.Name,
+			"goman-master-ip":  masterIP,
+			"goman-node-token": nodeToken,
+			"ManagedBy":        "goman",
+			instanceTypeTag:    pool.InstanceType,
+			k3sVersionTag:      launchK3sVersion(cluster),
+		},
+		ResourceTags:    cluster.Spec.Tags,
+		UserDataSnippet: pool.UserData,
+
+		ShutdownBehavior:   cluster.Spec.InstanceProtection.Behavior(),
+		StopProtection:     cluster.Spec.InstanceProtection.StopProtected(),
+		DeletionProtection: cluster.Spec.InstanceProtection.DeletionProtected(cluster.Spec.Tags),
+	}
+
+	if cluster.Spec.LowResource {
+		instanceConfig.Tags[lowResourceTag] = "true"
+	}
+	setRootVolume(&instanceConfig, cluster.Spec.PoolRootVolume(pool))
+	instanceConfig.ImageID, instanceConfig.OSFamily = cluster.Spec.PoolImage(pool)
+	setProxy(&instanceConfig, cluster)
+	setBootstrap(&instanceConfig, cluster.Spec.Bootstrap)
+	setRegistries(&instanceConfig, cluster)
+	setNetwork(&instanceConfig, cluster.Spec.Network)
+
+	// Only types with local NVMe storage get it; others boot unchanged
+	if pool.InstanceStore && models.HasInstanceStore(pool.InstanceType) {
+		instanceConfig.Tags[instanceStoreTag] = "true"
+	}
+
+	// GPU nodes get the NVIDIA runtime and are labeled for workloads to
+	// select them
+	if models.IsGPUInstanceType(pool.InstanceType) {
+		instanceConfig.Tags[gpuTag] = "true"
+		instanceConfig.Tags["k8s-label-"+models.GPUNodeLabel] = "true"
+	}
+
+	// Add Kubernetes labels as tags (prefixed with k8s-label-)
+	for k, v := range pool.Labels {
+		instanceConfig.Tags[fmt.Sprintf("k8s-label-%s", k)] = v
+	}
+
+	// Add taints as tags if present; labels and taints are applied via
+	// kubectl once the node registers
+	if len(pool.Taints) > 0 {
+		taintStrings := []string{}
+		for _, taint := range pool.Taints {
+			taintStrings = append(taintStrings, fmt.Sprintf("%s=%s:%s", taint.Key, taint.Value, taint.Effect))
+		}
+		instanceConfig.Tags["k8s-taints"] = strings.Join(taintStrings, ",")
+	}
+
+	// Data volumes are attached at launch; changes apply to new nodes
+	for _, v := range pool.Volumes {
+		instanceConfig.DataVolumes = append(instanceConfig.DataVolumes, provider.DataVolume{
+			SizeGiB:    v.Size,
+			Type:       v.VolumeType(),
+			MountPoint: v.MountPoint,
+			Filesystem: v.FilesystemType(),
+		})
+	}
+
+	return instanceConfig
+}
+
+// workerJoinInfo returns the master IP and token new workers use to join
+func (r *Reconciler) workerJoinInfo(ctx context.Context, cluster *models.ClusterResource) (string, string, error) {
+	var masterIP string
+	for _, inst := range cluster.Status.Instances {
+		if inst.Role == "master" && inst.PrivateIP != "" {
+			masterIP = inst.PrivateIP
+			break
+		}
+	}
+	if masterIP == "" {
+		return "", "", fmt.Errorf("no master node IP found for worker nodes to join")
+	}
+
+	secretService := r.provider.GetSecretService()
+	nodeTokenData, err := secretService.GetSecret(ctx, cluster.Name, provider.SecretNodeToken)
+	if err != nil {
+		// Fallback to agent token for backward compatibility
+		nodeTokenData, err = secretService.GetSecret(ctx, cluster.Name, provider.SecretAgentToken)
+		if err != nil {
+			return "", "", fmt.Errorf("failed to get join token for workers: %w", err)
+		}
+	}
+	return masterIP, strings.TrimSpace(string(nodeTokenData)), nil
 }
 
-// TODO: Add step-by-step functions here as we build them
\ No newline at end of file
+// k3sNodeName returns the K3s node name for an instance private IP.
+// K3s uses the EC2 hostname: ip-<ip-with-dashes>.<region>.compute.internal
+func (r *Reconciler) k3sNodeName(privateIP string) string {
+	return fmt.Sprintf("ip-%s.%s.compute.internal", strings.ReplaceAll(privateIP, ".", "-"), r.provider.Region())
+}
@@ -0,0 +1,221 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/madhouselabs/goman/pkg/logger"
+	"github.com/madhouselabs/goman/pkg/models"
+	"github.com/madhouselabs/goman/pkg/provider"
+)
+
+// Desired states of a cluster
+const (
+	DesiredStateRunning = "running"
+	DesiredStateStopped = "stopped"
+)
+
+// resumeScript points the K3s server of a started master at its new public
+// IP, which EC2 changes on every start, waits for the API and prints the
+// admin kubeconfig like kubeconfigScript
+const resumeScript = `PUBLIC_IP=$(curl -sf http://169.254.169.254/latest/meta-data/public-ipv4 || curl -s http://169.254.169.254/latest/meta-data/local-ipv4)
+UNIT=/etc/systemd/system/k3s.service
+sed -e "s/--node-external-ip=[^ ]*/--node-external-ip=$PUBLIC_IP/" -e "s/--tls-san=[^ ]*/--tls-san=$PUBLIC_IP/" $UNIT > $UNIT.new
+if [ -n "$PUBLIC_IP" ] && ! cmp -s $UNIT $UNIT.new; then
+    mv $UNIT.new $UNIT
+    systemctl daemon-reload
+    systemctl restart k3s
+else
+    rm -f $UNIT.new
+fi
+for i in $(seq 1 60); do
+    k3s kubectl get --raw=/readyz >/dev/null 2>&1 && break
+    sleep 2
+done
+k3s kubectl get --raw=/readyz >/dev/null 2>&1 || { echo "API server not ready after start" >&2; exit 1; }
+sed "s/127.0.0.1/$PUBLIC_IP/g" /etc/rancher/k3s/k3s.yaml
+`
+
+// reconcileDesiredState hibernates a cluster whose desired state is
+// "stopped" by stopping all its instances, and resumes it once the desired
+// state is back to "running". Returns whether the cluster is stopping,
+// stopped or starting, in which case nothing else is reconciled.
+func (r *Reconciler) reconcileDesiredState(ctx context.Context, cluster *models.ClusterResource) (bool, error) {
+	phase := cluster.Status.Phase
+	if cluster.Spec.DesiredState == DesiredStateStopped {
+		switch phase {
+		case models.ClusterPhaseRunning:
+			if cluster.Spec.InstanceProtection.StopProtected() {
+				logger.Infof(ctx, "[HIBERNATE] Not stopping cluster %s: it has stop protection", cluster.Name)
+				r.events.Warning(ctx, cluster.Name, EventStopRefused, "", "Not stopping the cluster: turn off instanceProtection.stopProtection first")
+				return false, nil
+			}
+			return true, r.stopCluster(ctx, cluster)
+		case models.ClusterPhaseStopping, models.ClusterPhaseStarting:
+			return true, r.stopCluster(ctx, cluster)
+		case models.ClusterPhaseStopped:
+			return true, nil
+		}
+		// Clusters being created are stopped once they run
+		return false, nil
+	}
+
+	switch phase {
+	case models.ClusterPhaseStopping, models.ClusterPhaseStopped, models.ClusterPhaseStarting:
+		return true, r.startCluster(ctx, cluster)
+	}
+	return false, nil
+}
+
+// stoppingOrStarting reports whether a cluster is being stopped or started
+func stoppingOrStarting(cluster *models.ClusterResource) bool {
+	return cluster.Status.Phase == models.ClusterPhaseStopping || cluster.Status.Phase == models.ClusterPhaseStarting
+}
+
+// stopCluster stops the running instances of a cluster and moves it to
+// Stopped once none is left running. Volumes and private IPs are kept.
+func (r *Reconciler) stopCluster(ctx context.Context, cluster *models.ClusterResource) error {
+	if cluster.Status.Phase != models.ClusterPhaseStopping {
+		logger.Infof(ctx, "[HIBERNATE] Stopping cluster %s", cluster.Name)
+		cluster.Status.Phase = models.ClusterPhaseStopping
+	}
+
+	computeService := r.provider.GetComputeService()
+	waiting := 0
+	for i := range cluster.Status.Instances {
+		inst := &cluster.Status.Instances[i]
+		instance, err := computeService.GetInstance(ctx, inst.InstanceID)
+		if err != nil {
+			return fmt.Errorf("failed to get instance %s: %w", inst.Name, err)
+		}
+		inst.State = instance.State
+		switch instance.State {
+		case "stopped", "terminated":
+			continue
+		case "running":
+			if err := computeService.StopInstance(ctx, inst.InstanceID); err != nil {
+				return fmt.Errorf("failed to stop %s: %w", inst.Name, err)
+			}
+			logger.Infof(ctx, "[HIBERNATE] Stopping instance %s (%s)", inst.Name, inst.InstanceID)
+			inst.State = "stopping"
+		}
+		// Instances still starting are stopped on the next pass
+		waiting++
+	}
+
+	if waiting > 0 {
+		cluster.Status.Message = fmt.Sprintf("Stopping cluster, waiting for %d instance(s)", waiting)
+		return nil
+	}
+	cluster.Status.Phase = models.ClusterPhaseStopped
+	cluster.Status.Message = "Cluster is stopped; set its desired state to running to start it"
+	logger.Infof(ctx, "[HIBERNATE] Cluster %s is stopped", cluster.Name)
+	return nil
+}
+
+// startCluster starts the stopped instances of a cluster. Once all of them
+// run, their new addresses are recorded, the masters serve the API on their
+// new public IPs and the stored kubeconfig points at it, and the cluster is
+// Running again.
+func (r *Reconciler) startCluster(ctx context.Context, cluster *models.ClusterResource) error {
+	if cluster.Status.Phase != models.ClusterPhaseStarting {
+		logger.Infof(ctx, "[HIBERNATE] Starting cluster %s", cluster.Name)
+		cluster.Status.Phase = models.ClusterPhaseStarting
+	}
+
+	computeService := r.provider.GetComputeService()
+	waiting := 0
+	for i := range cluster.Status.Instances {
+		inst := &cluster.Status.Instances[i]
+		instance, err := computeService.GetInstance(ctx, inst.InstanceID)
+		if err != nil {
+			return fmt.Errorf("failed to get instance %s: %w", inst.Name, err)
+		}
+		inst.State = instance.State
+		inst.PrivateIP = instance.PrivateIP
+		inst.PublicIP = instance.PublicIP
+		switch instance.State {
+		case "running", "terminated":
+			// Terminated workers are replaced by the node pool reconciliation
+			continue
+		case "stopped":
+			if err := computeService.StartInstance(ctx, inst.InstanceID); err != nil {
+				return fmt.Errorf("failed to start %s: %w", inst.Name, err)
+			}
+			logger.Infof(ctx, "[HIBERNATE] Starting instance %s (%s)", inst.Name, inst.InstanceID)
+			inst.State = "pending"
+		}
+		// Instances still stopping are started on the next pass
+		waiting++
+	}
+	if waiting > 0 {
+		cluster.Status.Message = fmt.Sprintf("Starting cluster, waiting for %d instance(s)", waiting)
+		return nil
+	}
+
+	if err := r.refreshEndpoint(ctx, cluster); err != nil {
+		// The SSM agent or K3s may still be coming up
+		logger.Infof(ctx, "[HIBERNATE] Cluster %s is not serving yet: %v", cluster.Name, err)
+		cluster.Status.Message = fmt.Sprintf("Starting cluster, waiting for the API server: %v", err)
+		return nil
+	}
+	cluster.Status.Phase = models.ClusterPhaseRunning
+	cluster.Status.Message = "K3s cluster is running and ready"
+	logger.Infof(ctx, "[HIBERNATE] Cluster %s is running again", cluster.Name)
+	return nil
+}
+
+// refreshEndpoint moves the K3s servers to their new public IPs after a
+// start and stores the kubeconfig of the first master. A virtual IP
+// endpoint does not change; any other endpoint follows the first master.
+func (r *Reconciler) refreshEndpoint(ctx context.Context, cluster *models.ClusterResource) error {
+	var masters []string
+	var first *models.InstanceStatus
+	for i, inst := range cluster.Status.Instances {
+		if inst.Role != "master" || inst.State != "running" {
+			continue
+		}
+		if first == nil {
+			first = &cluster.Status.Instances[i]
+		}
+		masters = append(masters, inst.InstanceID)
+	}
+	if first == nil {
+		return fmt.Errorf("no running master")
+	}
+
+	result, err := r.runSecretCommand(ctx, "resume-k3s", masters, resumeScript)
+	if err != nil {
+		return err
+	}
+	for _, id := range masters {
+		if res := result.Instances[id]; res == nil || res.Status != "Success" {
+			return fmt.Errorf("failed to resume K3s on %s: %s", id, operationOutput(res))
+		}
+	}
+
+	endpoint := cluster.Status.APIEndpoint
+	if endpoint != "" && endpoint != sharedAPIEndpoint(cluster) {
+		host := first.PublicIP
+		if host == "" {
+			host = first.PrivateIP
+		}
+		endpoint = models.APIEndpointURL(host)
+	}
+	kubeconfig := []byte(result.Instances[first.InstanceID].Output)
+	if endpoint != "" {
+		kubeconfig = kubeconfigServerPattern.ReplaceAll(kubeconfig, []byte("${1}"+endpoint))
+	}
+	if err := r.provider.GetSecretService().PutSecret(ctx, cluster.Name, provider.SecretKubeconfig, kubeconfig); err != nil {
+		return fmt.Errorf("failed to save kubeconfig: %w", err)
+	}
+	if endpoint != cluster.Status.APIEndpoint {
+		logger.Infof(ctx, "[HIBERNATE] API endpoint of cluster %s is now %s", cluster.Name, endpoint)
+		cluster.Status.APIEndpoint = endpoint
+	}
+	// The read-only kubeconfig is reissued for the new endpoint, and the
+	// other masters are listed again once running
+	cluster.Status.ReadOnlyKubeconfigExpiresAt = nil
+	cluster.Status.APIEndpoints = nil
+	return nil
+}
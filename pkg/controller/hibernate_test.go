@@ -0,0 +1,151 @@
+package controller
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/madhouselabs/goman/pkg/models"
+	"github.com/madhouselabs/goman/pkg/provider"
+)
+
+// powerCompute keeps instance states in memory; stops and starts complete
+// when settle is called
+type powerCompute struct {
+	provider.ComputeService
+	instances map[string]*provider.Instance
+	commands  []string
+}
+
+func (c *powerCompute) GetInstance(ctx context.Context, id string) (*provider.Instance, error) {
+	inst := *c.instances[id]
+	return &inst, nil
+}
+
+func (c *powerCompute) StopInstance(ctx context.Context, id string) error {
+	c.instances[id].State = "stopping"
+	return nil
+}
+
+func (c *powerCompute) StartInstance(ctx context.Context, id string) error {
+	c.instances[id].State = "pending"
+	return nil
+}
+
+func (c *powerCompute) RunCommand(ctx context.Context, ids []string, command string) (*provider.CommandResult, error) {
+	c.commands = append(c.commands, command)
+	result := &provider.CommandResult{Status: "Success", Instances: map[string]*provider.InstanceCommandResult{}}
+	for _, id := range ids {
+		output := "apiVersion: v1\nclusters:\n- cluster:\n    server: https://" + c.instances[id].PublicIP + ":6443\n"
+		result.Instances[id] = &provider.InstanceCommandResult{InstanceID: id, Status: "Success", Output: output}
+	}
+	return result, nil
+}
+
+// settle finishes pending stops and starts; started instances get a new
+// public IP like on EC2
+func (c *powerCompute) settle() {
+	for _, inst := range c.instances {
+		switch inst.State {
+		case "stopping":
+			inst.State = "stopped"
+			inst.PublicIP = ""
+		case "pending":
+			inst.State = "running"
+			inst.PublicIP = "13.200.0." + strings.TrimPrefix(inst.ID, "i-")
+		}
+	}
+}
+
+type secretMap struct {
+	provider.SecretService
+	secrets map[string][]byte
+}
+
+func (s *secretMap) PutSecret(ctx context.Context, clusterName, name string, value []byte) error {
+	s.secrets[clusterName+"/"+name] = value
+	return nil
+}
+
+func (s *secretMap) GetSecret(ctx context.Context, clusterName, name string) ([]byte, error) {
+	value, ok := s.secrets[clusterName+"/"+name]
+	if !ok {
+		return nil, provider.ErrNotFound
+	}
+	return value, nil
+}
+
+func TestHibernateAndResume(t *testing.T) {
+	compute := &powerCompute{instances: map[string]*provider.Instance{
+		"i-1": {ID: "i-1", State: "running", PrivateIP: "10.0.0.1", PublicIP: "3.110.0.1"},
+		"i-2": {ID: "i-2", State: "running", PrivateIP: "10.0.0.2", PublicIP: "3.110.0.2"},
+	}}
+	secrets := &secretMap{secrets: map[string][]byte{}}
+	r := &Reconciler{provider: &fakeProvider{compute: compute, secrets: secrets}, settings: DefaultSettings()}
+	cluster := &models.ClusterResource{Name: "demo"}
+	cluster.Spec.DesiredState = DesiredStateStopped
+	cluster.Status.Phase = models.ClusterPhaseRunning
+	cluster.Status.APIEndpoint = "https://3.110.0.1:6443"
+	cluster.Status.Instances = []models.InstanceStatus{
+		{InstanceID: "i-1", Name: "demo-master-0", Role: "master", State: "running"},
+		{InstanceID: "i-2", Name: "demo-default-0", Role: "worker", State: "running"},
+	}
+	ctx := context.Background()
+
+	if _, err := r.reconcileCluster(ctx, cluster); err != nil {
+		t.Fatal(err)
+	}
+	if cluster.Status.Phase != models.ClusterPhaseStopping || compute.instances["i-1"].State != "stopping" || compute.instances["i-2"].State != "stopping" {
+		t.Fatalf("phase %s, instances %+v %+v after the first pass", cluster.Status.Phase, compute.instances["i-1"], compute.instances["i-2"])
+	}
+	compute.settle()
+	r.reconcileCluster(ctx, cluster)
+	if cluster.Status.Phase != models.ClusterPhaseStopped {
+		t.Fatalf("phase = %s, want Stopped", cluster.Status.Phase)
+	}
+	r.reconcileCluster(ctx, cluster)
+	if cluster.Status.Phase != models.ClusterPhaseStopped {
+		t.Fatalf("phase = %s after another pass, want Stopped", cluster.Status.Phase)
+	}
+
+	cluster.Spec.DesiredState = DesiredStateRunning
+	r.reconcileCluster(ctx, cluster)
+	if cluster.Status.Phase != models.ClusterPhaseStarting || compute.instances["i-1"].State != "pending" {
+		t.Fatalf("phase %s, master %+v after asking to run", cluster.Status.Phase, compute.instances["i-1"])
+	}
+	compute.settle()
+	if _, err := r.reconcileCluster(ctx, cluster); err != nil {
+		t.Fatal(err)
+	}
+	if cluster.Status.Phase != models.ClusterPhaseRunning {
+		t.Fatalf("phase = %s, want Running: %s", cluster.Status.Phase, cluster.Status.Message)
+	}
+	if cluster.Status.APIEndpoint != "https://13.200.0.1:6443" || cluster.Status.Instances[0].PublicIP != "13.200.0.1" {
+		t.Errorf("endpoint %s, master IP %s after the start", cluster.Status.APIEndpoint, cluster.Status.Instances[0].PublicIP)
+	}
+	if len(compute.commands) != 1 || !strings.Contains(compute.commands[0], "--tls-san=$PUBLIC_IP") {
+		t.Errorf("masters were not moved to their new IP: %v", compute.commands)
+	}
+	if kubeconfig := string(secrets.secrets["demo/"+provider.SecretKubeconfig]); !strings.Contains(kubeconfig, "server: https://13.200.0.1:6443") {
+		t.Errorf("stored kubeconfig = %q", kubeconfig)
+	}
+}
+
+func TestHibernateRespectsStopProtection(t *testing.T) {
+	compute := &powerCompute{instances: map[string]*provider.Instance{
+		"i-1": {ID: "i-1", State: "running"},
+	}}
+	r := &Reconciler{provider: &fakeProvider{compute: compute}}
+	cluster := &models.ClusterResource{Name: "demo"}
+	cluster.Spec.DesiredState = DesiredStateStopped
+	cluster.Spec.InstanceProtection = &models.InstanceProtectionSpec{StopProtection: true}
+	cluster.Status.Phase = models.ClusterPhaseRunning
+	cluster.Status.Instances = []models.InstanceStatus{{InstanceID: "i-1", Role: "master", State: "running"}}
+
+	if handled, err := r.reconcileDesiredState(context.Background(), cluster); handled || err != nil {
+		t.Fatalf("reconcileDesiredState() = %v, %v with stop protection", handled, err)
+	}
+	if compute.instances["i-1"].State != "running" {
+		t.Errorf("protected instance is %s", compute.instances["i-1"].State)
+	}
+}
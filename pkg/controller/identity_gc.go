@@ -0,0 +1,42 @@
+package controller
+
+import (
+	"context"
+	"errors"
+
+	"github.com/madhouselabs/goman/pkg/logger"
+	"github.com/madhouselabs/goman/pkg/provider"
+	"github.com/madhouselabs/goman/pkg/storage"
+)
+
+// collectClusterIdentities deletes the node identities (IAM roles and
+// instance profiles) of clusters that no longer exist. Identities still used
+// by instances, e.g. the original role of a renamed cluster's nodes, are
+// kept until those instances are gone.
+func (r *Reconciler) collectClusterIdentities(ctx context.Context) {
+	computeService := r.provider.GetComputeService()
+	storageService := r.provider.GetStorageService()
+
+	clusters, err := computeService.ListClusterIdentities(ctx)
+	if err != nil {
+		logger.Warnf(ctx, "[IDENTITY] Failed to list cluster identities: %v", err)
+		return
+	}
+
+	for _, name := range clusters {
+		configKey := storage.ClusterConfigKey(name)
+		if _, err := storageService.GetObject(ctx, configKey); !errors.Is(err, provider.ErrNotFound) {
+			continue
+		}
+
+		err := computeService.DeleteClusterIdentity(ctx, name)
+		switch {
+		case errors.Is(err, provider.ErrIdentityInUse):
+			logger.Infof(ctx, "[IDENTITY] Keeping identity of former cluster %s: %v", name, err)
+		case err != nil:
+			logger.Warnf(ctx, "[IDENTITY] Failed to delete identity of cluster %s: %v", name, err)
+		default:
+			logger.Infof(ctx, "[IDENTITY] Deleted identity of cluster %s", name)
+		}
+	}
+}
@@ -0,0 +1,29 @@
+package controller
+
+import (
+	"github.com/madhouselabs/goman/pkg/models"
+	"github.com/madhouselabs/goman/pkg/provider"
+)
+
+// recordInstanceRegions records the region of each instance in the status
+// and passes it to the compute service, so instance calls go straight to
+// the instance's region instead of searching for it. Instances are created
+// in the cluster's region; the region the provider lists an instance in
+// replaces that when the status is refreshed.
+func (r *Reconciler) recordInstanceRegions(cluster *models.ClusterResource) {
+	for i := range cluster.Status.Instances {
+		if cluster.Status.Instances[i].Region == "" {
+			cluster.Status.Instances[i].Region = cluster.Spec.Region
+		}
+	}
+
+	hinter, ok := r.provider.GetComputeService().(provider.InstanceRegionHinter)
+	if !ok {
+		return
+	}
+	for _, inst := range cluster.Status.Instances {
+		if inst.InstanceID != "" && inst.Region != "" {
+			hinter.RememberInstanceRegion(inst.InstanceID, inst.Region)
+		}
+	}
+}
@@ -0,0 +1,29 @@
+package controller
+
+import (
+	"testing"
+
+	"github.com/madhouselabs/goman/pkg/models"
+)
+
+func TestRecordInstanceRegions(t *testing.T) {
+	compute := &regionalCompute{}
+	r := &Reconciler{provider: &fakeProvider{compute: compute}, settings: DefaultSettings()}
+	cluster := &models.ClusterResource{Name: "demo"}
+	cluster.Spec.Region = "eu-west-1"
+	cluster.Status.Instances = []models.InstanceStatus{
+		{InstanceID: "i-1"},
+		{InstanceID: "i-2", Region: "us-east-2"},
+	}
+
+	r.recordInstanceRegions(cluster)
+	if got := cluster.Status.Instances[0].Region; got != "eu-west-1" {
+		t.Errorf("instance without a region recorded in %q", got)
+	}
+	if got := cluster.Status.Instances[1].Region; got != "us-east-2" {
+		t.Errorf("recorded region replaced with %q", got)
+	}
+	if compute.remembered["i-1"] != "eu-west-1" || compute.remembered["i-2"] != "us-east-2" {
+		t.Errorf("compute service told %v", compute.remembered)
+	}
+}
@@ -0,0 +1,94 @@
+package controller
+
+import (
+	"context"
+	"time"
+
+	"github.com/madhouselabs/goman/pkg/logger"
+	"github.com/madhouselabs/goman/pkg/models"
+	"github.com/madhouselabs/goman/pkg/storage"
+	"gopkg.in/yaml.v3"
+)
+
+// takeIntents takes the queued spec edits the loaded spec covers and records
+// the generation this reconcile acts on. It returns false when an edit newer
+// than the loaded spec is queued: the read raced a write, and the reconcile
+// is retried so the latest generation wins.
+func (r *Reconciler) takeIntents(ctx context.Context, cluster *models.ClusterResource, requestID string) bool {
+	storageService := r.provider.GetStorageService()
+	queue, err := storage.LoadIntentQueue(ctx, storageService, cluster.Name)
+	if err != nil {
+		// The spec itself is loaded; act on it without coalescing
+		logger.Infof(ctx, "[INTENT] %v", err)
+		queue = &models.IntentQueue{}
+	}
+	if latest := queue.Latest(); latest > cluster.Generation {
+		logger.Infof(ctx, "[INTENT] Cluster %s spec is at generation %d but %d is queued, waiting for the latest spec",
+			cluster.Name, cluster.Generation, latest)
+		return false
+	}
+
+	var taken []models.SpecIntent
+	if len(queue.Pending) > 0 {
+		_, err := storage.UpdateIntentQueue(ctx, storageService, cluster.Name, func(q *models.IntentQueue) {
+			taken = q.Take(cluster.Generation)
+		})
+		if err != nil {
+			// Left in the queue, the edits are simply covered again next time
+			logger.Infof(ctx, "[INTENT] %v", err)
+			taken = nil
+		}
+	}
+
+	record := &models.IntentRecord{
+		Generation: cluster.Generation,
+		Coalesced:  len(taken),
+		RequestID:  requestID,
+		ActedAt:    time.Now(),
+	}
+	if len(taken) > 0 {
+		record.RequestedAt = &taken[0].RequestedAt
+		logger.Infof(ctx, "[INTENT] Cluster %s: acting on generation %d, covering %d queued edit(s)", cluster.Name, cluster.Generation, len(taken))
+	}
+	cluster.Status.LastIntent = record
+	return true
+}
+
+// SpecChangePending reports whether a cluster has spec edits no reconcile
+// has acted on yet. Spec write events for which it is false were covered by
+// a reconcile that coalesced them, and need no reconcile of their own.
+// Anything that can't be checked counts as pending.
+func (r *Reconciler) SpecChangePending(ctx context.Context, clusterName string) bool {
+	storageService := r.provider.GetStorageService()
+	queue, err := storage.LoadIntentQueue(ctx, storageService, clusterName)
+	if err != nil || len(queue.Pending) > 0 {
+		return true
+	}
+
+	configData, err := storageService.GetObject(ctx, storage.ClusterConfigKey(clusterName))
+	if err != nil {
+		return true
+	}
+	var config storage.ClusterConfig
+	if err := yaml.Unmarshal(configData, &config); err != nil {
+		return true
+	}
+	// Writers that don't track generations, and deletions, always reconcile
+	if config.Metadata.Generation == 0 || config.Metadata.DeletionTimestamp != nil {
+		return true
+	}
+
+	statusData, err := storageService.GetObject(ctx, storage.ClusterStatusKey(clusterName))
+	if err != nil {
+		return true
+	}
+	var status models.ClusterResourceStatus
+	if err := yaml.Unmarshal(statusData, &status); err != nil {
+		return true
+	}
+	// The status of an earlier cluster of the same name says nothing about this spec
+	if !storage.StatusBelongsTo(status.ClusterID, config.Metadata.ID) {
+		return true
+	}
+	return status.LastIntent == nil || status.LastIntent.Generation < config.Metadata.Generation
+}
@@ -0,0 +1,61 @@
+package controller
+
+import (
+	"testing"
+	"time"
+
+	"github.com/madhouselabs/goman/pkg/models"
+)
+
+func TestIntentQueueCoalesces(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	queue := &models.IntentQueue{}
+	if queue.Latest() != 0 {
+		t.Errorf("empty queue latest = %d, want 0", queue.Latest())
+	}
+
+	queue.Enqueue(models.SpecIntent{Generation: 3, RequestedAt: now.Add(2 * time.Second)})
+	queue.Enqueue(models.SpecIntent{Generation: 2, RequestedAt: now.Add(time.Second)})
+	queue.Enqueue(models.SpecIntent{Generation: 4, RequestedAt: now.Add(3 * time.Second)})
+	// A rewrite of a generation replaces it
+	queue.Enqueue(models.SpecIntent{Generation: 3, RequestedAt: now.Add(2 * time.Second), Source: "cli"})
+	if len(queue.Pending) != 3 || queue.Latest() != 4 {
+		t.Fatalf("queue = %+v, want generations 2-4", queue.Pending)
+	}
+
+	// A reconcile of generation 3 covers 2 and 3
+	taken := queue.Take(3)
+	if len(taken) != 2 || taken[0].Generation != 2 || taken[1].Source != "cli" {
+		t.Errorf("taken = %+v, want generations 2 and 3", taken)
+	}
+	if len(queue.Pending) != 1 || queue.Latest() != 4 {
+		t.Errorf("pending = %+v, want generation 4", queue.Pending)
+	}
+	if taken := queue.Take(3); len(taken) != 0 {
+		t.Errorf("second take = %+v, want none", taken)
+	}
+}
+
+func TestObservedGenerationNeverMovesBack(t *testing.T) {
+	base := &models.ClusterResourceStatus{ObservedGeneration: 3}
+	local := &models.ClusterResourceStatus{ObservedGeneration: 4}
+	remote := &models.ClusterResourceStatus{ObservedGeneration: 5}
+	mergeStatus(base, local, remote)
+	if remote.ObservedGeneration != 5 {
+		t.Errorf("observedGeneration = %d, want 5 kept", remote.ObservedGeneration)
+	}
+
+	remote.ObservedGeneration = 3
+	mergeStatus(base, local, remote)
+	if remote.ObservedGeneration != 4 {
+		t.Errorf("observedGeneration = %d, want 4", remote.ObservedGeneration)
+	}
+
+	cluster := &models.ClusterResource{Generation: 5, Status: *remote}
+	if !cluster.SpecPending() {
+		t.Error("generation 5 observed at 4 is not pending")
+	}
+	if models.SpecPending(0, 0) {
+		t.Error("untracked generation is pending")
+	}
+}
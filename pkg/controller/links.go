@@ -0,0 +1,184 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/madhouselabs/goman/pkg/logger"
+	"github.com/madhouselabs/goman/pkg/models"
+	"github.com/madhouselabs/goman/pkg/provider"
+	"github.com/madhouselabs/goman/pkg/storage"
+	"gopkg.in/yaml.v3"
+)
+
+// reconcileClusterLinks sets up the connectivity of new or changed cluster
+// links and removes that of links taken out of the spec. A link whose peer
+// is missing or not provisioned yet is reported as not ready and retried on
+// the next reconcile.
+func (r *Reconciler) reconcileClusterLinks(ctx context.Context, cluster *models.ClusterResource) error {
+	if err := models.ValidateClusterLinks(cluster.Name, cluster.Spec.ClusterLinks); err != nil {
+		return err
+	}
+
+	wanted := make(map[string]models.ClusterLink)
+	for _, link := range cluster.Spec.ClusterLinks {
+		wanted[link.Cluster] = link
+	}
+
+	// Remove links that are gone or changed; changed ones are set up again below
+	var kept []models.ClusterLinkStatus
+	for _, st := range cluster.Status.ClusterLinks {
+		link, ok := wanted[st.Cluster]
+		if ok && link.Key() == st.Key {
+			kept = append(kept, st)
+			continue
+		}
+		if err := r.unlinkCluster(ctx, cluster, st); err != nil {
+			return err
+		}
+	}
+	cluster.Status.ClusterLinks = kept
+
+	var errs []error
+	for _, link := range cluster.Spec.ClusterLinks {
+		st := linkStatus(cluster, link.Cluster)
+		if st != nil && st.Ready {
+			continue
+		}
+		if st == nil {
+			cluster.Status.ClusterLinks = append(cluster.Status.ClusterLinks, models.ClusterLinkStatus{Cluster: link.Cluster})
+			st = &cluster.Status.ClusterLinks[len(cluster.Status.ClusterLinks)-1]
+		}
+		if err := r.linkCluster(ctx, cluster, link, st); err != nil {
+			st.Ready = false
+			st.Message = err.Error()
+			errs = append(errs, fmt.Errorf("link to %s: %w", link.Cluster, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// linkCluster sets up one link and records it in st
+func (r *Reconciler) linkCluster(ctx context.Context, cluster *models.ClusterResource, link models.ClusterLink, st *models.ClusterLinkStatus) error {
+	peerRegion, err := r.peerRegion(ctx, link.Cluster)
+	if err != nil {
+		return err
+	}
+	ports, err := link.PortRanges()
+	if err != nil {
+		return err
+	}
+
+	config := provider.ClusterLinkConfig{
+		Cluster:      cluster.Name,
+		Region:       cluster.Spec.Region,
+		PeerCluster:  link.Cluster,
+		PeerRegion:   peerRegion,
+		Ports:        providerPorts(ports),
+		AllowPeering: link.Peering,
+	}
+	result, err := r.provider.GetComputeService().LinkClusters(ctx, config)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	*st = models.ClusterLinkStatus{
+		Cluster:   link.Cluster,
+		Key:       link.Key(),
+		Ports:     portNames(ports),
+		Region:    peerRegion,
+		Mode:      result.Mode,
+		PeeringID: result.PeeringID,
+		Ready:     true,
+		LinkedAt:  &now,
+	}
+	logger.Infof(ctx, "[LINKS] Cluster %s linked to %s (%s)", cluster.Name, link.Cluster, result.Mode)
+	return nil
+}
+
+// unlinkCluster removes the connectivity recorded in st. Links that were
+// never set up have nothing to remove.
+func (r *Reconciler) unlinkCluster(ctx context.Context, cluster *models.ClusterResource, st models.ClusterLinkStatus) error {
+	if st.Key == "" {
+		return nil
+	}
+	var ports []models.LinkPort
+	for _, name := range st.Ports {
+		port, err := models.ParseLinkPort(name)
+		if err != nil {
+			return err
+		}
+		ports = append(ports, port)
+	}
+
+	config := provider.ClusterLinkConfig{
+		Cluster:     cluster.Name,
+		Region:      cluster.Spec.Region,
+		PeerCluster: st.Cluster,
+		PeerRegion:  st.Region,
+		Ports:       providerPorts(ports),
+	}
+	if err := r.provider.GetComputeService().UnlinkClusters(ctx, config, st.PeeringID); err != nil {
+		return fmt.Errorf("failed to remove link to %s: %w", st.Cluster, err)
+	}
+	logger.Infof(ctx, "[LINKS] Cluster %s unlinked from %s", cluster.Name, st.Cluster)
+	return nil
+}
+
+// unlinkAllClusters removes all links of a cluster being deleted, so no
+// firewall rule of another cluster keeps referring to it
+func (r *Reconciler) unlinkAllClusters(ctx context.Context, cluster *models.ClusterResource) {
+	for _, st := range cluster.Status.ClusterLinks {
+		if err := r.unlinkCluster(ctx, cluster, st); err != nil {
+			logger.Warnf(ctx, "[DELETE] Warning: %v", err)
+		}
+	}
+}
+
+// peerRegion returns the region of a linked cluster from its spec
+func (r *Reconciler) peerRegion(ctx context.Context, clusterName string) (string, error) {
+	data, err := r.provider.GetStorageService().GetObject(ctx, storage.ClusterConfigKey(clusterName))
+	if errors.Is(err, provider.ErrNotFound) {
+		return "", provider.UserConfigErrorf("linked cluster %s does not exist", clusterName)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to load linked cluster %s: %w", clusterName, err)
+	}
+	var config storage.ClusterConfig
+	if err := yaml.Unmarshal(data, &config); err != nil {
+		return "", fmt.Errorf("failed to parse linked cluster %s: %w", clusterName, err)
+	}
+	if config.Metadata.DeletionTimestamp != nil {
+		return "", fmt.Errorf("linked cluster %s is being deleted", clusterName)
+	}
+	return config.Spec.Region, nil
+}
+
+// linkStatus returns the status entry of a link, nil if there is none
+func linkStatus(cluster *models.ClusterResource, peer string) *models.ClusterLinkStatus {
+	for i := range cluster.Status.ClusterLinks {
+		if cluster.Status.ClusterLinks[i].Cluster == peer {
+			return &cluster.Status.ClusterLinks[i]
+		}
+	}
+	return nil
+}
+
+func providerPorts(ports []models.LinkPort) []provider.PortRange {
+	out := make([]provider.PortRange, len(ports))
+	for i, p := range ports {
+		out[i] = provider.PortRange{Protocol: p.Protocol, FromPort: p.From, ToPort: p.To}
+	}
+	return out
+}
+
+func portNames(ports []models.LinkPort) []string {
+	out := make([]string, len(ports))
+	for i, p := range ports {
+		out[i] = p.String()
+	}
+	return out
+}
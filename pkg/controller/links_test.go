@@ -0,0 +1,61 @@
+package controller
+
+import (
+	"testing"
+
+	"github.com/madhouselabs/goman/pkg/models"
+)
+
+func TestParseLinkPort(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    models.LinkPort
+		wantErr bool
+	}{
+		{in: "443", want: models.LinkPort{Protocol: "tcp", From: 443, To: 443}},
+		{in: "30000-32767/tcp", want: models.LinkPort{Protocol: "tcp", From: 30000, To: 32767}},
+		{in: "53/UDP", want: models.LinkPort{Protocol: "udp", From: 53, To: 53}},
+		{in: "53/icmp", wantErr: true},
+		{in: "80-70", wantErr: true},
+		{in: "0", wantErr: true},
+		{in: "http", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := models.ParseLinkPort(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseLinkPort(%q) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && got != tt.want {
+			t.Errorf("ParseLinkPort(%q) = %+v, want %+v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestClusterLinks(t *testing.T) {
+	link := models.ClusterLink{Cluster: "shared"}
+	if got := link.Key(); got != "ports=30000-32767/tcp,peering=false" {
+		t.Errorf("default key = %q", got)
+	}
+	// Port order does not change the key
+	a := models.ClusterLink{Cluster: "shared", Ports: []string{"443", "53/udp"}}
+	b := models.ClusterLink{Cluster: "shared", Ports: []string{"53/udp", "443/tcp"}}
+	if a.Key() != b.Key() {
+		t.Errorf("keys differ: %q, %q", a.Key(), b.Key())
+	}
+
+	if err := models.ValidateClusterLinks("app", []models.ClusterLink{a, {Cluster: "db"}}); err != nil {
+		t.Errorf("valid links rejected: %v", err)
+	}
+	invalid := map[string][]models.ClusterLink{
+		"self":      {{Cluster: "app"}},
+		"duplicate": {{Cluster: "db"}, {Cluster: "db"}},
+		"no name":   {{Ports: []string{"443"}}},
+		"bad port":  {{Cluster: "db", Ports: []string{"99999"}}},
+	}
+	for name, links := range invalid {
+		if err := models.ValidateClusterLinks("app", links); err == nil {
+			t.Errorf("%s: links accepted", name)
+		}
+	}
+}
@@ -0,0 +1,232 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/madhouselabs/goman/pkg/logger"
+	"github.com/madhouselabs/goman/pkg/models"
+	"github.com/madhouselabs/goman/pkg/provider"
+)
+
+// loadBalancerDeleteTimeout bounds how long a deletion waits for the API
+// load balancer to be deleted before leaving it behind
+const loadBalancerDeleteTimeout = 30 * time.Minute
+
+// reconcileLoadBalancer serves the API endpoint of an HA cluster from a
+// network load balancer in front of all running masters, so losing any one
+// master leaves the API reachable. The load balancer is set up again when
+// the masters or the spec change. Each master not configured yet gets the
+// load balancer's name in its certificate, one at a time since that restarts
+// K3s. Once all masters have it, the API endpoint and the stored kubeconfig
+// point at the load balancer.
+func (r *Reconciler) reconcileLoadBalancer(ctx context.Context, cluster *models.ClusterResource) error {
+	spec := cluster.Spec.LoadBalancer
+	if err := models.ValidateLoadBalancer(spec, cluster.Spec.Mode, cluster.Spec.VirtualIP); err != nil {
+		return err
+	}
+	status := cluster.Status.LoadBalancer
+	if spec == nil {
+		if status == nil {
+			return nil
+		}
+		return r.removeLoadBalancer(ctx, cluster)
+	}
+	balancers, ok := r.provider.GetComputeService().(provider.APILoadBalancerService)
+	if !ok {
+		return fmt.Errorf("provider has no API load balancers")
+	}
+
+	var masters []string
+	known := make(map[string]bool)
+	for _, inst := range cluster.Status.Instances {
+		known[inst.InstanceID] = true
+		if inst.Role == "master" && inst.State == "running" {
+			masters = append(masters, inst.InstanceID)
+		}
+	}
+	sort.Strings(masters)
+	if status != nil {
+		// Forget removed masters
+		var nodes []string
+		for _, id := range status.Nodes {
+			if known[id] {
+				nodes = append(nodes, id)
+			}
+		}
+		status.Nodes = nodes
+	}
+	if len(masters) == 0 {
+		return nil
+	}
+
+	// The load balancer of a private cluster has no public subnet to go in
+	internal := spec.Internal || cluster.Spec.Network.Private
+	owner := spec.Owner(cluster.Name)
+	if status == nil || status.Owner(cluster.Name) != owner || status.Internal != internal || !slices.Equal(status.Targets, masters) || !slices.Equal(status.AllowedCIDRs, spec.AllowedCIDRs) {
+		// The load balancer of another cluster is taken over as it is; the
+		// other cluster has taken over this one's
+		dnsName, err := balancers.EnsureAPILoadBalancer(ctx, cluster.Spec.Region, owner, masters, internal, spec.AllowedCIDRs)
+		if err != nil {
+			return fmt.Errorf("failed to set up load balancer: %w", err)
+		}
+		if status == nil || status.DNSName != dnsName {
+			// A new name goes into every master's certificate
+			logger.Infof(ctx, "[LB] Serving API of cluster %s from load balancer %s", cluster.Name, dnsName)
+			status = &models.LoadBalancerStatus{DNSName: dnsName}
+		}
+		status.Cluster = owner
+		status.Internal = internal
+		status.Targets = masters
+		status.AllowedCIDRs = append([]string(nil), spec.AllowedCIDRs...)
+		cluster.Status.LoadBalancer = status
+	}
+
+	var pending []string
+	for _, id := range masters {
+		if !status.HasNode(id) {
+			pending = append(pending, id)
+		}
+	}
+	if len(pending) > 0 {
+		id := pending[0]
+		result, err := r.runOperation(ctx, []string{id}, provider.OperationAddTLSSAN, map[string]string{
+			"Host": status.DNSName,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to add load balancer to the certificate of %s: %w", id, err)
+		}
+		if res := result.Instances[id]; res == nil || res.Status != "Success" {
+			status.Message = fmt.Sprintf("pending on %s", id)
+			return fmt.Errorf("failed to add load balancer to the certificate of %s: %s", id, operationOutput(res))
+		}
+		status.Nodes = append(status.Nodes, id)
+		status.Message = ""
+		if len(pending) > 1 {
+			status.Message = fmt.Sprintf("%d masters left to configure", len(pending)-1)
+			return nil
+		}
+	}
+
+	endpoint := models.APIEndpointURL(status.DNSName)
+	if cluster.Status.APIEndpoint != endpoint {
+		if err := r.setKubeconfigServer(ctx, cluster.Name, endpoint); err != nil {
+			return err
+		}
+		now := time.Now()
+		status.AppliedAt = &now
+		cluster.Status.APIEndpoint = endpoint
+		logger.Infof(ctx, "[LB] API endpoint of cluster %s is now %s", cluster.Name, endpoint)
+	}
+	return nil
+}
+
+// removeLoadBalancer points the API endpoint back at the first master and
+// deletes the load balancer. The status stays until the load balancer is
+// gone, so the deletion is retried.
+func (r *Reconciler) removeLoadBalancer(ctx context.Context, cluster *models.ClusterResource) error {
+	status := cluster.Status.LoadBalancer
+	var nodes []string
+	var host string
+	for _, inst := range cluster.Status.Instances {
+		if inst.Role != "master" || inst.State != "running" {
+			continue
+		}
+		if host == "" {
+			// Same address the first master publishes at bootstrap
+			host = inst.PublicIP
+			if host == "" {
+				host = inst.PrivateIP
+			}
+		}
+		if status.HasNode(inst.InstanceID) {
+			nodes = append(nodes, inst.InstanceID)
+		}
+	}
+
+	if host != "" && cluster.Status.APIEndpoint == models.APIEndpointURL(status.DNSName) {
+		endpoint := models.APIEndpointURL(host)
+		if err := r.setKubeconfigServer(ctx, cluster.Name, endpoint); err != nil {
+			return err
+		}
+		cluster.Status.APIEndpoint = endpoint
+	}
+
+	if len(nodes) > 0 {
+		result, err := r.runOperation(ctx, nodes, provider.OperationAddTLSSAN, map[string]string{"Host": ""})
+		if err != nil {
+			return fmt.Errorf("failed to remove load balancer from the certificates: %w", err)
+		}
+		for _, id := range nodes {
+			if res := result.Instances[id]; res == nil || res.Status != "Success" {
+				return fmt.Errorf("failed to remove load balancer from the certificate of %s: %s", id, operationOutput(res))
+			}
+		}
+		status.Nodes = nil
+	}
+
+	if balancers, ok := r.provider.GetComputeService().(provider.APILoadBalancerService); ok {
+		if err := balancers.DeleteAPILoadBalancer(ctx, cluster.Spec.Region, status.Owner(cluster.Name)); err != nil {
+			if errors.Is(err, provider.ErrNetworkInUse) {
+				status.Message = "deleting"
+				logger.Infof(ctx, "[LB] Load balancer of cluster %s not deleted yet: %v", cluster.Name, err)
+				return nil
+			}
+			return fmt.Errorf("failed to delete load balancer: %w", err)
+		}
+	}
+	logger.Infof(ctx, "[LB] Removed load balancer %s from cluster %s", status.DNSName, cluster.Name)
+	cluster.Status.LoadBalancer = nil
+	return nil
+}
+
+// deleteLoadBalancer deletes the API load balancer of a cluster being
+// deleted. It returns false while the load balancer cannot be deleted yet,
+// so the deletion is requeued; after loadBalancerDeleteTimeout it is left
+// for deletion by hand.
+func (r *Reconciler) deleteLoadBalancer(ctx context.Context, cluster *models.ClusterResource) bool {
+	if cluster.Spec.LoadBalancer == nil && cluster.Status.LoadBalancer == nil {
+		return true
+	}
+	balancers, ok := r.provider.GetComputeService().(provider.APILoadBalancerService)
+	if !ok {
+		return true
+	}
+
+	// The load balancer in use, which a cutover may have taken over from
+	// another cluster
+	owner := cluster.Spec.LoadBalancer.Owner(cluster.Name)
+	if cluster.Status.LoadBalancer != nil {
+		owner = cluster.Status.LoadBalancer.Owner(cluster.Name)
+	}
+	err := balancers.DeleteAPILoadBalancer(ctx, cluster.Spec.Region, owner)
+	if err == nil {
+		logger.Infof(ctx, "[DELETE] Deleted API load balancer of cluster %s", cluster.Name)
+		cluster.Status.LoadBalancer = nil
+		return true
+	}
+	if cluster.DeletionTimestamp != nil && time.Since(*cluster.DeletionTimestamp) > loadBalancerDeleteTimeout {
+		logger.Infof(ctx, "[DELETE] Giving up deleting the API load balancer of cluster %s: %v", cluster.Name, err)
+		r.events.Warning(ctx, cluster.Name, EventDeleting, "", "API load balancer not deleted after %s, delete it by hand: %v", loadBalancerDeleteTimeout, err)
+		return true
+	}
+	logger.Infof(ctx, "[DELETE] API load balancer of cluster %s not deleted yet: %v", cluster.Name, err)
+	cluster.Status.Message = "Deleting the API load balancer"
+	return false
+}
+
+// sharedAPIEndpoint returns the endpoint all masters serve together, from a
+// virtual IP or a load balancer, empty if clients use a master's address
+func sharedAPIEndpoint(cluster *models.ClusterResource) string {
+	if vip := cluster.Status.VirtualIP; vip != nil {
+		return models.APIEndpointURL(vip.Address)
+	}
+	if lb := cluster.Status.LoadBalancer; lb != nil {
+		return models.APIEndpointURL(lb.DNSName)
+	}
+	return ""
+}
@@ -0,0 +1,175 @@
+package controller
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/madhouselabs/goman/pkg/models"
+	"github.com/madhouselabs/goman/pkg/provider"
+)
+
+func TestValidateLoadBalancer(t *testing.T) {
+	tests := []struct {
+		spec    *models.LoadBalancerSpec
+		mode    string
+		vip     *models.VirtualIPSpec
+		wantErr bool
+	}{
+		{nil, "dev", nil, false},
+		{&models.LoadBalancerSpec{}, "ha", nil, false},
+		{&models.LoadBalancerSpec{Internal: true, AllowedCIDRs: []string{"10.0.0.0/8", "203.0.113.0/24"}}, "ha", nil, false},
+		{&models.LoadBalancerSpec{}, "dev", nil, true},
+		{&models.LoadBalancerSpec{}, "ha", &models.VirtualIPSpec{}, true},
+		{&models.LoadBalancerSpec{AllowedCIDRs: []string{"10.0.0.1"}}, "ha", nil, true},
+		{&models.LoadBalancerSpec{AllowedCIDRs: []string{"fd00::/8"}}, "ha", nil, true},
+	}
+	for _, tt := range tests {
+		if err := models.ValidateLoadBalancer(tt.spec, tt.mode, tt.vip); (err != nil) != tt.wantErr {
+			t.Errorf("ValidateLoadBalancer(%+v, %s, %+v) = %v, want error %v", tt.spec, tt.mode, tt.vip, err, tt.wantErr)
+		}
+	}
+}
+
+// lbCompute keeps a load balancer in front of the masters it is given
+type lbCompute struct {
+	provider.ComputeService
+	targets  []string
+	internal bool
+	ensures  int
+	sans     []string
+	deletes  int
+	owner    string // Cluster of the last load balancer set up or deleted
+}
+
+func (c *lbCompute) EnsureAPILoadBalancer(ctx context.Context, region, clusterName string, instanceIDs []string, internal bool, allowedCIDRs []string) (string, error) {
+	c.ensures++
+	c.owner = clusterName
+	c.targets = instanceIDs
+	c.internal = internal
+	return clusterName + "-api.elb.eu-west-1.amazonaws.com", nil
+}
+
+func (c *lbCompute) DeleteAPILoadBalancer(ctx context.Context, region, clusterName string) error {
+	c.deletes++
+	c.owner = clusterName
+	return nil
+}
+
+func (c *lbCompute) RunOperation(ctx context.Context, instanceIDs []string, operation string, params map[string]string) (*provider.CommandResult, error) {
+	result := &provider.CommandResult{Instances: map[string]*provider.InstanceCommandResult{}}
+	for _, id := range instanceIDs {
+		c.sans = append(c.sans, id+"="+params["Host"])
+		result.Instances[id] = &provider.InstanceCommandResult{InstanceID: id, Status: "Success"}
+	}
+	return result, nil
+}
+
+func TestReconcileLoadBalancer(t *testing.T) {
+	compute := &lbCompute{}
+	secrets := &secretMap{secrets: map[string][]byte{"demo/" + provider.SecretKubeconfig: []byte(k3sKubeconfig)}}
+	r := &Reconciler{provider: &fakeProvider{compute: compute, secrets: secrets}, settings: DefaultSettings()}
+	cluster := &models.ClusterResource{Name: "demo"}
+	cluster.Spec.Mode = "ha"
+	cluster.Spec.Network.Private = true
+	cluster.Spec.LoadBalancer = &models.LoadBalancerSpec{}
+	cluster.Status.APIEndpoint = "https://3.110.0.1:6443"
+	cluster.Status.Instances = []models.InstanceStatus{
+		{InstanceID: "i-1", Name: "demo-master-1", Role: "master", State: "running", PrivateIP: "10.0.0.2"},
+		{InstanceID: "i-0", Name: "demo-master-0", Role: "master", State: "running", PrivateIP: "10.0.0.1"},
+		{InstanceID: "i-w", Name: "demo-default-0", Role: "worker", State: "running", PrivateIP: "10.0.0.9"},
+	}
+	ctx := context.Background()
+	dnsName := "demo-api.elb.eu-west-1.amazonaws.com"
+
+	// Masters get the name in their certificate one per pass; the endpoint
+	// moves once all have it
+	if err := r.reconcileLoadBalancer(ctx, cluster); err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(compute.targets, []string{"i-0", "i-1"}) || !compute.internal {
+		t.Fatalf("load balancer targets %v, internal %v", compute.targets, compute.internal)
+	}
+	if cluster.Status.APIEndpoint != "https://3.110.0.1:6443" {
+		t.Fatalf("endpoint moved with a master left: %s", cluster.Status.APIEndpoint)
+	}
+	if err := r.reconcileLoadBalancer(ctx, cluster); err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(compute.sans, []string{"i-0=" + dnsName, "i-1=" + dnsName}) || compute.ensures != 1 {
+		t.Fatalf("certificates %v after %d setups", compute.sans, compute.ensures)
+	}
+	if cluster.Status.APIEndpoint != models.APIEndpointURL(dnsName) {
+		t.Fatalf("API endpoint %s", cluster.Status.APIEndpoint)
+	}
+	servers, _ := kubeconfigServers(t, secrets.secrets["demo/"+provider.SecretKubeconfig])
+	if servers["default"] != models.APIEndpointURL(dnsName) {
+		t.Errorf("kubeconfig servers %v", servers)
+	}
+
+	// A replaced master is registered and configured
+	cluster.Status.Instances[1] = models.InstanceStatus{InstanceID: "i-2", Name: "demo-master-0", Role: "master", State: "running", PrivateIP: "10.0.0.3"}
+	if err := r.reconcileLoadBalancer(ctx, cluster); err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(compute.targets, []string{"i-1", "i-2"}) || !slices.Equal(cluster.Status.LoadBalancer.Nodes, []string{"i-1", "i-2"}) {
+		t.Fatalf("targets %v, configured masters %v", compute.targets, cluster.Status.LoadBalancer.Nodes)
+	}
+
+	// Removing the spec points clients back at a master and deletes it
+	cluster.Spec.LoadBalancer = nil
+	if err := r.reconcileLoadBalancer(ctx, cluster); err != nil {
+		t.Fatal(err)
+	}
+	if cluster.Status.LoadBalancer != nil || compute.deletes != 1 || cluster.Status.APIEndpoint != "https://10.0.0.2:6443" {
+		t.Errorf("status %+v, %d deletes, endpoint %s", cluster.Status.LoadBalancer, compute.deletes, cluster.Status.APIEndpoint)
+	}
+}
+
+// TestReconcileLoadBalancerTakeover swaps the load balancer of a cluster for
+// another's, as a blue/green cutover does
+func TestReconcileLoadBalancerTakeover(t *testing.T) {
+	compute := &lbCompute{}
+	secrets := &secretMap{secrets: map[string][]byte{"green/" + provider.SecretKubeconfig: []byte(k3sKubeconfig)}}
+	r := &Reconciler{provider: &fakeProvider{compute: compute, secrets: secrets}, settings: DefaultSettings()}
+	cluster := &models.ClusterResource{Name: "green"}
+	cluster.Spec.Mode = "ha"
+	cluster.Spec.LoadBalancer = &models.LoadBalancerSpec{}
+	cluster.Status.Instances = []models.InstanceStatus{
+		{InstanceID: "i-0", Name: "green-master-0", Role: "master", State: "running", PrivateIP: "10.0.0.1"},
+	}
+	ctx := context.Background()
+	for i := 0; i < 2; i++ {
+		if err := r.reconcileLoadBalancer(ctx, cluster); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if cluster.Status.APIEndpoint != models.APIEndpointURL("green-api.elb.eu-west-1.amazonaws.com") {
+		t.Fatalf("API endpoint %s", cluster.Status.APIEndpoint)
+	}
+
+	// The masters are registered with blue's load balancer and get its name
+	// in their certificate
+	cluster.Spec.LoadBalancer.Cluster = "blue"
+	for i := 0; i < 2; i++ {
+		if err := r.reconcileLoadBalancer(ctx, cluster); err != nil {
+			t.Fatal(err)
+		}
+	}
+	blueName := "blue-api.elb.eu-west-1.amazonaws.com"
+	if compute.owner != "blue" || !slices.Equal(compute.targets, []string{"i-0"}) || compute.deletes != 0 {
+		t.Fatalf("load balancer of %s targets %v after %d deletes", compute.owner, compute.targets, compute.deletes)
+	}
+	if got := compute.sans[len(compute.sans)-1]; got != "i-0="+blueName {
+		t.Errorf("certificate %s", got)
+	}
+	if cluster.Status.APIEndpoint != models.APIEndpointURL(blueName) || cluster.Status.LoadBalancer.Cluster != "blue" {
+		t.Fatalf("API endpoint %s, status %+v", cluster.Status.APIEndpoint, cluster.Status.LoadBalancer)
+	}
+
+	// Deleting the cluster deletes the load balancer it took over
+	cluster.Spec.LoadBalancer.Cluster = ""
+	if !r.deleteLoadBalancer(ctx, cluster) || compute.owner != "blue" {
+		t.Errorf("deleted the load balancer of %s", compute.owner)
+	}
+}
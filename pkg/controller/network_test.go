@@ -0,0 +1,128 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/madhouselabs/goman/pkg/models"
+	"github.com/madhouselabs/goman/pkg/provider"
+)
+
+func TestValidateNetwork(t *testing.T) {
+	valid := []models.NetworkConfig{
+		{},
+		{Private: true},
+		{SecurityGroupIDs: []string{"sg-0123456789abcdef0"}},
+		{VpcID: "vpc-0a1b2c3d", SubnetIDs: []string{"subnet-0123456789abcdef0", "subnet-1a2b3c4d"}},
+		{DedicatedVPC: true},
+		{DedicatedVPC: true, Private: true, VpcCIDR: "10.20.0.0/16"},
+	}
+	for _, n := range valid {
+		if err := models.ValidateNetwork(n); err != nil {
+			t.Errorf("ValidateNetwork(%+v) = %v", n, err)
+		}
+	}
+	invalid := []models.NetworkConfig{
+		{VpcID: "vpc-0a1b2c3d"},
+		{SubnetIDs: []string{"subnet-1a2b3c4d"}},
+		{VpcID: "default", SubnetIDs: []string{"subnet-1a2b3c4d"}},
+		{VpcID: "vpc-0a1b2c3d", SubnetIDs: []string{"subnet-1a2b3c4d", "subnet-1a2b3c4d"}},
+		{VpcID: "vpc-0a1b2c3d", SubnetIDs: []string{"sg-1a2b3c4d"}},
+		{SecurityGroupIDs: []string{"goman-demo-sg"}},
+		{SecurityGroupIDs: []string{"sg-00000001", "sg-00000002", "sg-00000003", "sg-00000004", "sg-00000005"}},
+		{DedicatedVPC: true, VpcID: "vpc-0a1b2c3d", SubnetIDs: []string{"subnet-1a2b3c4d"}},
+		{VpcCIDR: "10.20.0.0/16"},
+		{DedicatedVPC: true, VpcCIDR: "10.20.0.1/16"},
+		{DedicatedVPC: true, VpcCIDR: "10.0.0.0/12"},
+		{DedicatedVPC: true, VpcCIDR: "10.0.0.0/26"},
+		{DedicatedVPC: true, VpcCIDR: "fd00::/56"},
+	}
+	for _, n := range invalid {
+		if err := models.ValidateNetwork(n); err == nil {
+			t.Errorf("ValidateNetwork(%+v) = nil, want an error", n)
+		}
+	}
+}
+
+func TestInstanceConfigsCarryNetwork(t *testing.T) {
+	cluster := &models.ClusterResource{Name: "demo", Spec: models.ClusterSpec{Network: models.NetworkConfig{
+		VpcID:            "vpc-0a1b2c3d",
+		SubnetIDs:        []string{"subnet-1a2b3c4d"},
+		SecurityGroupIDs: []string{"sg-1a2b3c4d"},
+	}}}
+
+	master := masterInstanceConfig(cluster, "m", map[string]string{})
+	worker := workerInstanceConfig(cluster, "w", models.NodePool{Name: "default", InstanceType: "t3.medium"}, "", "")
+	for _, config := range []struct {
+		name         string
+		vpc          string
+		subnets, sgs []string
+	}{
+		{"master", master.VpcID, master.SubnetIDs, master.ExtraSecurityGroups},
+		{"worker", worker.VpcID, worker.SubnetIDs, worker.ExtraSecurityGroups},
+	} {
+		if config.vpc != "vpc-0a1b2c3d" || !slices.Equal(config.subnets, []string{"subnet-1a2b3c4d"}) || !slices.Equal(config.sgs, []string{"sg-1a2b3c4d"}) {
+			t.Errorf("%s network: %s %v %v", config.name, config.vpc, config.subnets, config.sgs)
+		}
+	}
+}
+
+func TestInstanceConfigDedicatedVPC(t *testing.T) {
+	cluster := &models.ClusterResource{Name: "demo", Spec: models.ClusterSpec{Network: models.NetworkConfig{DedicatedVPC: true}}}
+	master := masterInstanceConfig(cluster, "m", map[string]string{})
+	if !master.DedicatedVPC || master.VpcCIDR != models.DefaultDedicatedVPCCIDR || master.VpcID != "" {
+		t.Errorf("master network: dedicated %t, range %q, VPC %q", master.DedicatedVPC, master.VpcCIDR, master.VpcID)
+	}
+}
+
+// vpcCompute deletes a dedicated VPC once its instances are gone
+type vpcCompute struct {
+	provider.ComputeService
+	inUse   int
+	err     error
+	deletes []string
+}
+
+func (c *vpcCompute) DeleteDedicatedNetwork(ctx context.Context, region, clusterName string) error {
+	c.deletes = append(c.deletes, region+"/"+clusterName)
+	if c.inUse > 0 {
+		c.inUse--
+		return provider.ErrNetworkInUse
+	}
+	return c.err
+}
+
+func TestDeleteDedicatedNetwork(t *testing.T) {
+	compute := &vpcCompute{inUse: 1}
+	r := &Reconciler{provider: &fakeProvider{compute: compute}, settings: DefaultSettings()}
+	deleted := time.Now()
+	cluster := &models.ClusterResource{Name: "demo", DeletionTimestamp: &deleted}
+	cluster.Spec.Region = "eu-west-1"
+	ctx := context.Background()
+
+	// Clusters in the default VPC have nothing to delete
+	if !r.deleteDedicatedNetwork(ctx, cluster) || len(compute.deletes) != 0 {
+		t.Fatalf("VPC deleted for a cluster without one: %v", compute.deletes)
+	}
+
+	cluster.Spec.Network.DedicatedVPC = true
+	if r.deleteDedicatedNetwork(ctx, cluster) {
+		t.Fatal("deletion went on while the VPC is in use")
+	}
+	if !r.deleteDedicatedNetwork(ctx, cluster) || len(compute.deletes) != 2 || compute.deletes[1] != "eu-west-1/demo" {
+		t.Fatalf("VPC not deleted once free: %v", compute.deletes)
+	}
+
+	// Failures are retried for a while, then the VPC is left behind
+	compute.err = errors.New("throttled")
+	if r.deleteDedicatedNetwork(ctx, cluster) {
+		t.Error("deletion went on after a failure")
+	}
+	deleted = time.Now().Add(-2 * dedicatedNetworkDeleteTimeout)
+	if !r.deleteDedicatedNetwork(ctx, cluster) {
+		t.Error("deletion still waiting for the VPC after the timeout")
+	}
+}
@@ -0,0 +1,252 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/madhouselabs/goman/pkg/logger"
+	"github.com/madhouselabs/goman/pkg/models"
+)
+
+// Node annotations recording the pool labels and taints goman applied, so
+// the ones later removed from the pool are removed from the node too while
+// those set by others are left alone
+const (
+	poolLabelsAnnotation = "goman.io/pool-labels"
+	poolTaintsAnnotation = "goman.io/pool-taints"
+)
+
+// nodeMetadataCmd lists the K3s nodes, one tab-separated "<internal IP>
+// <name> <labels JSON> <taints JSON> <applied labels> <applied taints>" per
+// line
+const nodeMetadataCmd = `kubectl get nodes -o jsonpath='{range .items[*]}{.status.addresses[?(@.type=="InternalIP")].address}{"\t"}{.metadata.name}{"\t"}{.metadata.labels}{"\t"}{.spec.taints}{"\t"}{.metadata.annotations.goman\.io/pool-labels}{"\t"}{.metadata.annotations.goman\.io/pool-taints}{"\n"}{end}'`
+
+// nodeMetadata is the labels and taints of a K3s node
+type nodeMetadata struct {
+	name          string
+	labels        map[string]string
+	taints        []models.Taint
+	appliedLabels []string // label keys goman applied
+	appliedTaints []string // "key:Effect" of taints goman applied
+	managed       bool     // goman applied pool metadata before
+}
+
+// reconcileNodeMetadata applies the labels and taints of each worker's node
+// pool to its K3s node once it registered, through kubectl on a master. The
+// ones changed on the node since, or changed in the pool, are applied again
+// on every reconcile.
+func (r *Reconciler) reconcileNodeMetadata(ctx context.Context, cluster *models.ClusterResource) error {
+	masterID := runningMasterID(cluster)
+	if masterID == "" {
+		return nil
+	}
+	var master models.InstanceStatus
+	for _, inst := range cluster.Status.Instances {
+		if inst.InstanceID == masterID {
+			master = inst
+		}
+	}
+
+	output, err := r.runOnMaster(ctx, master, "node-metadata", nodeMetadataCmd, false)
+	if err != nil {
+		return fmt.Errorf("failed to list K3s nodes: %w", err)
+	}
+	nodes, err := parseNodeMetadata(output)
+	if err != nil {
+		return err
+	}
+
+	var script []string
+	for _, inst := range cluster.Status.Instances {
+		if inst.Role != "worker" || inst.State != "running" {
+			continue
+		}
+		node, ok := nodes[inst.PrivateIP]
+		if !ok {
+			// Not registered yet; applied once it is
+			continue
+		}
+		pool, ok := workerPool(cluster, inst.Name)
+		if !ok {
+			continue
+		}
+		instanceType := inst.InstanceType
+		if instanceType == "" {
+			instanceType = pool.InstanceType
+		}
+		commands := nodeMetadataCommands(node, poolNodeLabels(pool, instanceType), pool.Taints)
+		if len(commands) == 0 {
+			continue
+		}
+		if node.managed {
+			logger.Infof(ctx, "[NODE_METADATA] Re-applying labels and taints of pool %s to node %s (%s), changed since last applied", pool.Name, node.name, inst.Name)
+		} else {
+			logger.Infof(ctx, "[NODE_METADATA] Applying labels and taints of pool %s to node %s (%s)", pool.Name, node.name, inst.Name)
+		}
+		script = append(script, commands...)
+	}
+	if len(script) == 0 {
+		return nil
+	}
+
+	if _, err := r.runOnMaster(ctx, master, "node-metadata-apply", "set -e\n"+strings.Join(script, "\n"), false); err != nil {
+		return fmt.Errorf("failed to apply node labels and taints: %w", err)
+	}
+	return nil
+}
+
+// poolNodeLabels returns the labels nodes of a pool get: the pool's, and
+// the GPU label on GPU instance types
+func poolNodeLabels(pool models.NodePool, instanceType string) map[string]string {
+	labels := make(map[string]string, len(pool.Labels)+1)
+	if models.IsGPUInstanceType(instanceType) {
+		labels[models.GPUNodeLabel] = "true"
+	}
+	for k, v := range pool.Labels {
+		labels[k] = v
+	}
+	return labels
+}
+
+// parseNodeMetadata parses the output of nodeMetadataCmd into the nodes by
+// internal IP
+func parseNodeMetadata(output string) (map[string]nodeMetadata, error) {
+	nodes := make(map[string]nodeMetadata)
+	for _, line := range strings.Split(output, "\n") {
+		// Trailing fields are empty on nodes without taints or annotations
+		fields := strings.Split(strings.TrimRight(line, "\r"), "\t")
+		if len(fields) < 2 || fields[0] == "" {
+			continue
+		}
+		for len(fields) < 6 {
+			fields = append(fields, "")
+		}
+		node := nodeMetadata{name: fields[1]}
+		if fields[2] != "" {
+			if err := json.Unmarshal([]byte(fields[2]), &node.labels); err != nil {
+				return nil, fmt.Errorf("unexpected labels of node %s: %w", node.name, err)
+			}
+		}
+		if fields[3] != "" {
+			if err := json.Unmarshal([]byte(fields[3]), &node.taints); err != nil {
+				return nil, fmt.Errorf("unexpected taints of node %s: %w", node.name, err)
+			}
+		}
+		node.appliedLabels = splitList(fields[4])
+		node.appliedTaints = splitList(fields[5])
+		node.managed = len(node.appliedLabels) > 0 || len(node.appliedTaints) > 0
+		nodes[fields[0]] = node
+	}
+	return nodes, nil
+}
+
+// nodeMetadataCommands returns the kubectl commands bringing a node's
+// labels and taints to the desired ones, none if it has them. Labels and
+// taints goman applied before and no longer desired are removed.
+func nodeMetadataCommands(node nodeMetadata, labels map[string]string, taints []models.Taint) []string {
+	keys := make([]string, 0, len(labels))
+	for k := range labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var labelArgs []string
+	for _, k := range keys {
+		if current, ok := node.labels[k]; !ok || current != labels[k] {
+			labelArgs = append(labelArgs, fmt.Sprintf("%s=%s", k, labels[k]))
+		}
+	}
+	for _, k := range node.appliedLabels {
+		if _, desired := labels[k]; desired {
+			continue
+		}
+		if _, ok := node.labels[k]; ok {
+			labelArgs = append(labelArgs, k+"-")
+		}
+	}
+
+	var taintArgs []string
+	desiredTaints := make(map[string]bool, len(taints))
+	for _, t := range taints {
+		desiredTaints[taintID(t)] = true
+		if !hasTaint(node.taints, t) {
+			taintArgs = append(taintArgs, taintArg(t))
+		}
+	}
+	for _, id := range node.appliedTaints {
+		if desiredTaints[id] {
+			continue
+		}
+		for _, t := range node.taints {
+			if taintID(t) == id {
+				taintArgs = append(taintArgs, id+"-")
+				break
+			}
+		}
+	}
+
+	applied := strings.Join(keys, ",")
+	var taintIDs []string
+	for id := range desiredTaints {
+		taintIDs = append(taintIDs, id)
+	}
+	sort.Strings(taintIDs)
+	appliedTaints := strings.Join(taintIDs, ",")
+
+	name := quoteArgs([]string{node.name})
+	var commands []string
+	if len(labelArgs) > 0 {
+		commands = append(commands, fmt.Sprintf("kubectl label node %s --overwrite %s", name, quoteArgs(labelArgs)))
+	}
+	if len(taintArgs) > 0 {
+		commands = append(commands, fmt.Sprintf("kubectl taint node %s --overwrite %s", name, quoteArgs(taintArgs)))
+	}
+	if applied != strings.Join(node.appliedLabels, ",") || appliedTaints != strings.Join(node.appliedTaints, ",") {
+		commands = append(commands, fmt.Sprintf("kubectl annotate node %s --overwrite %s",
+			name, quoteArgs([]string{poolLabelsAnnotation + "=" + applied, poolTaintsAnnotation + "=" + appliedTaints})))
+	}
+	return commands
+}
+
+// taintID identifies a taint the way kubectl does: by key and effect
+func taintID(t models.Taint) string {
+	return t.Key + ":" + t.Effect
+}
+
+// taintArg returns the kubectl taint argument setting a taint
+func taintArg(t models.Taint) string {
+	if t.Value == "" {
+		return taintID(t)
+	}
+	return fmt.Sprintf("%s=%s:%s", t.Key, t.Value, t.Effect)
+}
+
+// hasTaint reports whether taints contain t with the same value
+func hasTaint(taints []models.Taint, t models.Taint) bool {
+	for _, current := range taints {
+		if current.Key == t.Key && current.Effect == t.Effect && current.Value == t.Value {
+			return true
+		}
+	}
+	return false
+}
+
+// splitList splits a comma-separated list, empty for an empty string
+func splitList(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(s, ",")
+}
+
+// quoteArgs shell-quotes and joins command arguments
+func quoteArgs(args []string) string {
+	quoted := make([]string, len(args))
+	for i, arg := range args {
+		quoted[i] = "'" + strings.ReplaceAll(arg, "'", `'\''`) + "'"
+	}
+	return strings.Join(quoted, " ")
+}
@@ -0,0 +1,99 @@
+package controller
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/madhouselabs/goman/pkg/models"
+)
+
+func TestParseNodeMetadata(t *testing.T) {
+	output := "10.0.1.5\tip-10-0-1-5.eu-west-1.compute.internal\t{\"kubernetes.io/os\":\"linux\",\"team\":\"data\"}\t[{\"effect\":\"NoSchedule\",\"key\":\"dedicated\",\"value\":\"data\"}]\tteam\tdedicated:NoSchedule\n" +
+		"10.0.1.6\tip-10-0-1-6.eu-west-1.compute.internal\t{\"kubernetes.io/os\":\"linux\"}\t\t\t\n"
+	nodes, err := parseNodeMetadata(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(nodes) != 2 {
+		t.Fatalf("got %d nodes, want 2", len(nodes))
+	}
+
+	managed := nodes["10.0.1.5"]
+	if managed.labels["team"] != "data" || !managed.managed {
+		t.Errorf("unexpected managed node: %+v", managed)
+	}
+	if want := []models.Taint{{Key: "dedicated", Value: "data", Effect: "NoSchedule"}}; !reflect.DeepEqual(managed.taints, want) {
+		t.Errorf("taints = %+v, want %+v", managed.taints, want)
+	}
+	if fresh := nodes["10.0.1.6"]; fresh.managed || len(fresh.taints) != 0 {
+		t.Errorf("unexpected new node: %+v", fresh)
+	}
+
+	if _, err := parseNodeMetadata("10.0.1.7\tnode\tnot-json\t\n"); err == nil {
+		t.Error("expected an error for malformed labels")
+	}
+}
+
+func TestNodeMetadataCommands(t *testing.T) {
+	labels := map[string]string{"team": "data"}
+	taints := []models.Taint{{Key: "dedicated", Value: "data", Effect: "NoSchedule"}}
+
+	// A new node gets everything, and the annotations recording it
+	fresh := nodeMetadata{name: "node-a", labels: map[string]string{"kubernetes.io/os": "linux"}}
+	want := []string{
+		"kubectl label node 'node-a' --overwrite 'team=data'",
+		"kubectl taint node 'node-a' --overwrite 'dedicated=data:NoSchedule'",
+		"kubectl annotate node 'node-a' --overwrite 'goman.io/pool-labels=team' 'goman.io/pool-taints=dedicated:NoSchedule'",
+	}
+	if got := nodeMetadataCommands(fresh, labels, taints); !reflect.DeepEqual(got, want) {
+		t.Errorf("new node:\n got %q\nwant %q", got, want)
+	}
+
+	// A node in sync needs nothing
+	synced := nodeMetadata{
+		name:          "node-a",
+		labels:        map[string]string{"team": "data"},
+		taints:        taints,
+		appliedLabels: []string{"team"},
+		appliedTaints: []string{"dedicated:NoSchedule"},
+		managed:       true,
+	}
+	if got := nodeMetadataCommands(synced, labels, taints); len(got) != 0 {
+		t.Errorf("synced node: got %q, want no commands", got)
+	}
+
+	// Drift on the node is reverted
+	drifted := synced
+	drifted.labels = map[string]string{"team": "web"}
+	drifted.taints = nil
+	want = []string{
+		"kubectl label node 'node-a' --overwrite 'team=data'",
+		"kubectl taint node 'node-a' --overwrite 'dedicated=data:NoSchedule'",
+	}
+	if got := nodeMetadataCommands(drifted, labels, taints); !reflect.DeepEqual(got, want) {
+		t.Errorf("drifted node:\n got %q\nwant %q", got, want)
+	}
+
+	// Labels and taints removed from the pool are removed from the node,
+	// those set by others are kept
+	synced.labels["owner"] = "ops"
+	want = []string{
+		"kubectl label node 'node-a' --overwrite 'team-'",
+		"kubectl taint node 'node-a' --overwrite 'dedicated:NoSchedule-'",
+		"kubectl annotate node 'node-a' --overwrite 'goman.io/pool-labels=' 'goman.io/pool-taints='",
+	}
+	if got := nodeMetadataCommands(synced, nil, nil); !reflect.DeepEqual(got, want) {
+		t.Errorf("removed from pool:\n got %q\nwant %q", got, want)
+	}
+}
+
+func TestPoolNodeLabels(t *testing.T) {
+	pool := models.NodePool{Name: "gpu", Labels: map[string]string{"team": "ml"}}
+	labels := poolNodeLabels(pool, "g5.xlarge")
+	if labels["team"] != "ml" || labels[models.GPUNodeLabel] != "true" {
+		t.Errorf("GPU pool labels = %v", labels)
+	}
+	if labels := poolNodeLabels(pool, "t3.large"); len(labels) != 1 {
+		t.Errorf("CPU pool labels = %v, want only the pool's", labels)
+	}
+}
@@ -0,0 +1,376 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/madhouselabs/goman/pkg/logger"
+	"github.com/madhouselabs/goman/pkg/models"
+)
+
+// nodeReplacementTimeout bounds how long a replacement may take from drain
+// until the new node reports Ready before it is rolled back
+const nodeReplacementTimeout = 20 * time.Minute
+
+// reconcileNodeReplacements advances the oldest unfinished node replacement
+// request by as many steps as it can without waiting:
+//
+//	Draining -> Provisioning -> WaitingReady -> Terminating -> Completed
+//
+// Only one node is replaced at a time. Returns true while a replacement is in
+// progress so node pool reconciliation does not treat the extra worker as surplus.
+func (r *Reconciler) reconcileNodeReplacements(ctx context.Context, cluster *models.ClusterResource) (bool, error) {
+	r.pruneNodeReplacementStatus(cluster)
+
+	var req *models.NodeReplacement
+	for i := range cluster.Spec.NodeReplacements {
+		st := models.FindNodeReplacement(cluster.Status.NodeReplacements, cluster.Spec.NodeReplacements[i])
+		if st != nil && st.Done() {
+			continue
+		}
+		// Nodes of paused pools are left alone, also halfway through a
+		// replacement; it continues once the pool is resumed
+		if pool, paused := pausedPoolOf(cluster, cluster.Spec.NodeReplacements[i].Node); paused {
+			logger.Infof(ctx, "[REPLACE] Not replacing %s: node pool %s is paused", cluster.Spec.NodeReplacements[i].Node, pool)
+			continue
+		}
+		req = &cluster.Spec.NodeReplacements[i]
+		break
+	}
+	if req == nil {
+		return false, nil
+	}
+
+	st := models.FindNodeReplacement(cluster.Status.NodeReplacements, *req)
+	if st == nil {
+		now := time.Now()
+		cluster.Status.NodeReplacements = append(cluster.Status.NodeReplacements, models.NodeReplacementStatus{
+			Node:        req.Node,
+			RequestedAt: req.RequestedAt,
+			Phase:       models.NodeReplacementDraining,
+			StartedAt:   &now,
+		})
+		st = &cluster.Status.NodeReplacements[len(cluster.Status.NodeReplacements)-1]
+	}
+
+	masterInstanceID := runningMasterID(cluster)
+	if masterInstanceID == "" {
+		return false, fmt.Errorf("no running master found to replace node %s", st.Node)
+	}
+
+	if err := r.advanceNodeReplacement(ctx, cluster, masterInstanceID, st); err != nil {
+		return false, err
+	}
+
+	logger.Infof(ctx, "[REPLACE] Node %s in cluster %s: %s", st.Node, cluster.Name, st.Phase)
+	cluster.Status.Message = fmt.Sprintf("Replacing node %s: %s", st.Node, st.Phase)
+	if st.Done() {
+		// Requeue to pick up the next request or reconcile node pools
+		cluster.Status.Message = fmt.Sprintf("Replacement of node %s %s", st.Node, strings.ToLower(string(st.Phase)))
+	}
+	return true, nil
+}
+
+// advanceNodeReplacement moves a replacement through as many phases as it
+// can without waiting
+func (r *Reconciler) advanceNodeReplacement(ctx context.Context, cluster *models.ClusterResource, masterInstanceID string, st *models.NodeReplacementStatus) error {
+	var err error
+	if st.Phase == models.NodeReplacementDraining {
+		err = r.drainReplacedNode(ctx, cluster, masterInstanceID, st)
+	}
+	if err == nil && st.Phase == models.NodeReplacementProvisioning {
+		err = r.provisionReplacementNode(ctx, cluster, st)
+	}
+	if err == nil && st.Phase == models.NodeReplacementWaitingReady {
+		err = r.waitForReplacementNode(ctx, cluster, masterInstanceID, st)
+	}
+	if err == nil && st.Phase == models.NodeReplacementTerminating {
+		err = r.terminateReplacedNode(ctx, cluster, masterInstanceID, st)
+	}
+	return err
+}
+
+// runningMasterID returns the instance ID of a running master, or ""
+func runningMasterID(cluster *models.ClusterResource) string {
+	for _, inst := range cluster.Status.Instances {
+		if inst.Role == "master" && inst.State == "running" {
+			return inst.InstanceID
+		}
+	}
+	return ""
+}
+
+// pruneNodeReplacementStatus drops status entries whose request was removed from the spec
+func (r *Reconciler) pruneNodeReplacementStatus(cluster *models.ClusterResource) {
+	var kept []models.NodeReplacementStatus
+	for _, st := range cluster.Status.NodeReplacements {
+		for _, req := range cluster.Spec.NodeReplacements {
+			if st.Matches(req) {
+				kept = append(kept, st)
+				break
+			}
+		}
+	}
+	cluster.Status.NodeReplacements = kept
+}
+
+// drainReplacedNode resolves the node to replace and drains it, leaving it
+// cordoned so nothing is scheduled back onto it
+func (r *Reconciler) drainReplacedNode(ctx context.Context, cluster *models.ClusterResource, masterInstanceID string, st *models.NodeReplacementStatus) error {
+	var old *models.InstanceStatus
+	for i := range cluster.Status.Instances {
+		inst := &cluster.Status.Instances[i]
+		if inst.Name == st.Node || inst.InstanceID == st.Node {
+			old = inst
+			break
+		}
+	}
+	if old == nil {
+		failNodeReplacement(st, "node not found in cluster")
+		return nil
+	}
+	if old.Role != "worker" {
+		failNodeReplacement(st, "only worker nodes can be replaced")
+		return nil
+	}
+
+	if old.LaunchTime.After(st.RequestedAt) {
+		// Status was lost after the replacement finished; don't replace twice
+		now := time.Now()
+		st.Phase = models.NodeReplacementCompleted
+		st.CompletedAt = &now
+		st.NewInstanceID = old.InstanceID
+		st.Message = "node was launched after the request"
+		return nil
+	}
+
+	pool, ok := workerPool(cluster, old.Name)
+	if !ok {
+		failNodeReplacement(st, "node does not belong to a configured node pool")
+		return nil
+	}
+	st.Pool = pool.Name
+	st.OldInstanceID = old.InstanceID
+
+	if old.PrivateIP != "" {
+		nodeName := r.k3sNodeName(old.PrivateIP)
+		logger.Infof(ctx, "[REPLACE] Draining node %s (%s)", nodeName, old.InstanceID)
+		result, err := r.drainNode(ctx, masterInstanceID, nodeName, "120s", false)
+		if err == nil {
+			if res := result.Instances[masterInstanceID]; res != nil && res.Status != "Success" {
+				err = fmt.Errorf("%s", res.Error)
+			}
+		}
+		if err != nil {
+			r.uncordonNode(ctx, masterInstanceID, nodeName)
+			failNodeReplacement(st, fmt.Sprintf("drain failed: %v", err))
+			return nil
+		}
+	}
+
+	st.Phase = models.NodeReplacementProvisioning
+	return nil
+}
+
+// provisionReplacementNode creates the new worker with the old node's pool configuration
+func (r *Reconciler) provisionReplacementNode(ctx context.Context, cluster *models.ClusterResource, st *models.NodeReplacementStatus) error {
+	pool, ok := findNodePool(cluster, st.Pool)
+	if !ok {
+		failNodeReplacement(st, fmt.Sprintf("node pool %s no longer exists", st.Pool))
+		return nil
+	}
+
+	var old models.InstanceStatus
+	for _, inst := range cluster.Status.Instances {
+		if inst.InstanceID == st.OldInstanceID {
+			old = inst
+			break
+		}
+	}
+	oldName := old.Name
+	if oldName == "" {
+		failNodeReplacement(st, "node disappeared before a replacement was created")
+		return nil
+	}
+
+	masterIP, nodeToken, err := r.workerJoinInfo(ctx, cluster)
+	if err != nil {
+		return err
+	}
+
+	// The replacement keeps the old name so pool indices stay stable
+	instanceConfig := workerInstanceConfig(cluster, oldName, pool, masterIP, nodeToken)
+	instanceConfig.AvailabilityZone = replacementZone(pool, poolWorkersOf(cluster, pool.Name), old)
+	instance, err := r.provider.GetComputeService().CreateInstance(ctx, instanceConfig)
+	if err != nil {
+		recordOperationError(ctx, cluster, err)
+		return fmt.Errorf("failed to create replacement for %s: %w", oldName, err)
+	}
+	logger.Infof(ctx, "[REPLACE] Created replacement %s (%s) for %s", oldName, instance.ID, st.OldInstanceID)
+	r.recordInstanceProvisioned(ctx, cluster.Name, instanceConfig, instance)
+
+	cluster.Status.Instances = append(cluster.Status.Instances, models.InstanceStatus{
+		InstanceID: instance.ID,
+		Name:       oldName,
+		Role:       "worker",
+		State:      instance.State,
+		LaunchTime: time.Now(),
+
+		AvailabilityZone: instance.AvailabilityZone,
+	})
+	st.NewInstanceID = instance.ID
+	st.Phase = models.NodeReplacementWaitingReady
+	return nil
+}
+
+// waitForReplacementNode checks whether the new node has joined and is Ready.
+// If it does not become Ready in time, the replacement is rolled back.
+func (r *Reconciler) waitForReplacementNode(ctx context.Context, cluster *models.ClusterResource, masterInstanceID string, st *models.NodeReplacementStatus) error {
+	computeService := r.provider.GetComputeService()
+
+	instance, err := computeService.GetInstance(ctx, st.NewInstanceID)
+	if err != nil {
+		return fmt.Errorf("failed to get replacement instance %s: %w", st.NewInstanceID, err)
+	}
+	if instance.State != "running" && instance.State != "pending" {
+		r.rollbackNodeReplacement(ctx, cluster, masterInstanceID, st, fmt.Sprintf("replacement instance is %s", instance.State))
+		return nil
+	}
+
+	for i := range cluster.Status.Instances {
+		if cluster.Status.Instances[i].InstanceID == instance.ID {
+			cluster.Status.Instances[i].State = instance.State
+			cluster.Status.Instances[i].PrivateIP = instance.PrivateIP
+			cluster.Status.Instances[i].PublicIP = instance.PublicIP
+		}
+	}
+
+	if instance.State == "running" && instance.PrivateIP != "" {
+		nodeName := r.k3sNodeName(instance.PrivateIP)
+		readyCmd := fmt.Sprintf(`kubectl get node %s -o jsonpath='{.status.conditions[?(@.type=="Ready")].status}' 2>/dev/null || true`, nodeName)
+		result, err := r.runCommand(ctx, "check-node-ready", []string{masterInstanceID}, readyCmd)
+		if err != nil {
+			return fmt.Errorf("failed to check readiness of %s: %w", nodeName, err)
+		}
+		if res := result.Instances[masterInstanceID]; res != nil && strings.TrimSpace(res.Output) == "True" {
+			logger.Infof(ctx, "[REPLACE] Replacement node %s is Ready", nodeName)
+			st.Phase = models.NodeReplacementTerminating
+			return nil
+		}
+	}
+
+	if st.StartedAt != nil && time.Since(*st.StartedAt) > nodeReplacementTimeout {
+		r.rollbackNodeReplacement(ctx, cluster, masterInstanceID, st, fmt.Sprintf("replacement did not become Ready within %s", nodeReplacementTimeout))
+	}
+	return nil
+}
+
+// terminateReplacedNode removes the old node from K3s and terminates its instance
+func (r *Reconciler) terminateReplacedNode(ctx context.Context, cluster *models.ClusterResource, masterInstanceID string, st *models.NodeReplacementStatus) error {
+	for _, inst := range cluster.Status.Instances {
+		if inst.InstanceID == st.OldInstanceID && inst.PrivateIP != "" {
+			nodeName := r.k3sNodeName(inst.PrivateIP)
+			if _, err := r.drainNode(ctx, masterInstanceID, nodeName, "60s", true); err != nil {
+				logger.Warnf(ctx, "[REPLACE] Warning: Failed to delete node %s from K3s: %v", nodeName, err)
+			}
+			break
+		}
+	}
+
+	if err := r.provider.GetComputeService().DeleteInstance(ctx, st.OldInstanceID); err != nil {
+		recordOperationError(ctx, cluster, err)
+		return fmt.Errorf("failed to terminate replaced instance %s: %w", st.OldInstanceID, err)
+	}
+	removeInstanceStatus(cluster, st.OldInstanceID)
+
+	now := time.Now()
+	st.Phase = models.NodeReplacementCompleted
+	st.CompletedAt = &now
+	st.Message = fmt.Sprintf("replaced %s with %s", st.OldInstanceID, st.NewInstanceID)
+	return nil
+}
+
+// rollbackNodeReplacement terminates the replacement and returns the old node to service
+func (r *Reconciler) rollbackNodeReplacement(ctx context.Context, cluster *models.ClusterResource, masterInstanceID string, st *models.NodeReplacementStatus, reason string) {
+	logger.Infof(ctx, "[REPLACE] Rolling back replacement of %s: %s", st.Node, reason)
+
+	if err := r.provider.GetComputeService().DeleteInstance(ctx, st.NewInstanceID); err != nil {
+		logger.Warnf(ctx, "[REPLACE] Warning: Failed to terminate replacement %s: %v", st.NewInstanceID, err)
+	}
+	removeInstanceStatus(cluster, st.NewInstanceID)
+
+	for _, inst := range cluster.Status.Instances {
+		if inst.InstanceID == st.OldInstanceID && inst.PrivateIP != "" {
+			r.uncordonNode(ctx, masterInstanceID, r.k3sNodeName(inst.PrivateIP))
+			break
+		}
+	}
+
+	failNodeReplacement(st, reason)
+}
+
+// uncordonNode makes a node schedulable again
+func (r *Reconciler) uncordonNode(ctx context.Context, masterInstanceID, nodeName string) {
+	if _, err := r.runCommand(ctx, "uncordon-node", []string{masterInstanceID}, fmt.Sprintf("kubectl uncordon %s", nodeName)); err != nil {
+		logger.Warnf(ctx, "[REPLACE] Warning: Failed to uncordon %s: %v", nodeName, err)
+	}
+}
+
+// failNodeReplacement marks a replacement as failed
+func failNodeReplacement(st *models.NodeReplacementStatus, reason string) {
+	now := time.Now()
+	st.Phase = models.NodeReplacementFailed
+	st.CompletedAt = &now
+	st.Message = reason
+}
+
+// removeInstanceStatus drops an instance from the cluster status
+func removeInstanceStatus(cluster *models.ClusterResource, instanceID string) {
+	remaining := []models.InstanceStatus{}
+	for _, inst := range cluster.Status.Instances {
+		if inst.InstanceID != instanceID {
+			remaining = append(remaining, inst)
+		}
+	}
+	cluster.Status.Instances = remaining
+}
+
+// workerPool returns the node pool a worker belongs to based on its name
+// (<cluster>-worker-<pool>-<index>)
+func workerPool(cluster *models.ClusterResource, workerName string) (models.NodePool, bool) {
+	rest := strings.TrimPrefix(workerName, cluster.Name+"-worker-")
+	if rest == workerName {
+		return models.NodePool{}, false
+	}
+	if idx := strings.LastIndex(rest, "-"); idx > 0 {
+		rest = rest[:idx]
+	}
+	return findNodePool(cluster, rest)
+}
+
+// findNodePool looks up a node pool by name
+func findNodePool(cluster *models.ClusterResource, name string) (models.NodePool, bool) {
+	for _, pool := range cluster.Spec.NodePools {
+		if pool.Name == name {
+			return pool, true
+		}
+	}
+	return models.NodePool{}, false
+}
+
+// pausedPoolOf returns the paused node pool a node belongs to, given by
+// instance name or ID. Nodes of other pools and masters return false.
+func pausedPoolOf(cluster *models.ClusterResource, node string) (string, bool) {
+	for _, inst := range cluster.Status.Instances {
+		if inst.Role != "worker" || (inst.InstanceID != node && inst.Name != node) {
+			continue
+		}
+		if pool, ok := workerPool(cluster, inst.Name); ok && pool.Paused {
+			return pool.Name, true
+		}
+		return "", false
+	}
+	return "", false
+}
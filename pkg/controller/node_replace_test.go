@@ -0,0 +1,268 @@
+package controller
+
+import (
+	"testing"
+	"time"
+
+	"github.com/madhouselabs/goman/pkg/models"
+)
+
+func TestWorkerPool(t *testing.T) {
+	cluster := &models.ClusterResource{
+		Name: "demo",
+		Spec: models.ClusterSpec{
+			NodePools: []models.NodePool{{Name: "gpu-large"}, {Name: "default"}},
+		},
+	}
+
+	tests := []struct {
+		name   string
+		want   string
+		wantOK bool
+	}{
+		{"demo-worker-default-0", "default", true},
+		{"demo-worker-gpu-large-3", "gpu-large", true},
+		{"demo-worker-missing-1", "", false},
+		{"demo-master-0", "", false},
+		{"other-worker-default-0", "", false},
+	}
+	for _, tt := range tests {
+		pool, ok := workerPool(cluster, tt.name)
+		if ok != tt.wantOK || pool.Name != tt.want {
+			t.Errorf("workerPool(%q) = %q, %v; want %q, %v", tt.name, pool.Name, ok, tt.want, tt.wantOK)
+		}
+	}
+}
+
+func TestPruneNodeReplacementStatus(t *testing.T) {
+	requested := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	cluster := &models.ClusterResource{
+		Spec: models.ClusterSpec{
+			NodeReplacements: []models.NodeReplacement{{Node: "a", RequestedAt: requested}},
+		},
+		Status: models.ClusterResourceStatus{
+			NodeReplacements: []models.NodeReplacementStatus{
+				{Node: "a", RequestedAt: requested, Phase: models.NodeReplacementWaitingReady},
+				{Node: "a", RequestedAt: requested.Add(-time.Hour), Phase: models.NodeReplacementCompleted},
+				{Node: "b", RequestedAt: requested, Phase: models.NodeReplacementFailed},
+			},
+		},
+	}
+
+	(&Reconciler{}).pruneNodeReplacementStatus(cluster)
+
+	if len(cluster.Status.NodeReplacements) != 1 {
+		t.Fatalf("expected 1 status entry, got %d", len(cluster.Status.NodeReplacements))
+	}
+	if st := cluster.Status.NodeReplacements[0]; st.Phase != models.NodeReplacementWaitingReady {
+		t.Errorf("kept wrong entry: %+v", st)
+	}
+}
+
+func TestWorkerInstanceConfigDataVolumes(t *testing.T) {
+	cluster := &models.ClusterResource{Name: "demo", Spec: models.ClusterSpec{Region: "ap-south-1"}}
+	pool := models.NodePool{
+		Name:         "storage",
+		InstanceType: "t3.large",
+		Volumes: []models.DataVolume{
+			{Size: 100, MountPoint: "/mnt/data"},
+			{Size: 500, Type: "st1", MountPoint: "/mnt/logs", Filesystem: "xfs"},
+		},
+	}
+
+	config := workerInstanceConfig(cluster, "demo-worker-storage-0", pool, "10.0.0.1", "token")
+
+	if len(config.DataVolumes) != 2 {
+		t.Fatalf("expected 2 data volumes, got %d", len(config.DataVolumes))
+	}
+	if v := config.DataVolumes[0]; v.SizeGiB != 100 || v.Type != "gp3" || v.Filesystem != "ext4" || v.MountPoint != "/mnt/data" {
+		t.Errorf("defaults not applied: %+v", v)
+	}
+	if v := config.DataVolumes[1]; v.Type != "st1" || v.Filesystem != "xfs" {
+		t.Errorf("unexpected volume: %+v", v)
+	}
+}
+
+func TestValidateDataVolumes(t *testing.T) {
+	tests := []struct {
+		name    string
+		volumes []models.DataVolume
+		wantErr bool
+	}{
+		{"valid", []models.DataVolume{{Size: 10, MountPoint: "/mnt/data"}}, false},
+		{"zero size", []models.DataVolume{{MountPoint: "/mnt/data"}}, true},
+		{"relative mount", []models.DataVolume{{Size: 10, MountPoint: "mnt/data"}}, true},
+		{"root mount", []models.DataVolume{{Size: 10, MountPoint: "/"}}, true},
+		{"duplicate mount", []models.DataVolume{{Size: 10, MountPoint: "/mnt/a"}, {Size: 10, MountPoint: "/mnt/a/"}}, true},
+		{"unknown type", []models.DataVolume{{Size: 10, Type: "standard", MountPoint: "/mnt/a"}}, true},
+		{"unknown filesystem", []models.DataVolume{{Size: 10, Filesystem: "btrfs", MountPoint: "/mnt/a"}}, true},
+	}
+	for _, tt := range tests {
+		if err := models.ValidateDataVolumes("pool", tt.volumes); (err != nil) != tt.wantErr {
+			t.Errorf("%s: err = %v, wantErr %v", tt.name, err, tt.wantErr)
+		}
+	}
+}
+
+func TestWorkerInstanceConfigInstanceStore(t *testing.T) {
+	cluster := &models.ClusterResource{Name: "demo"}
+
+	config := workerInstanceConfig(cluster, "w", models.NodePool{Name: "ci", InstanceType: "m5d.xlarge", InstanceStore: true}, "", "")
+	if config.Tags[instanceStoreTag] != "true" {
+		t.Errorf("expected instance store tag for m5d.xlarge")
+	}
+
+	config = workerInstanceConfig(cluster, "w", models.NodePool{Name: "ci", InstanceType: "m5.xlarge", InstanceStore: true}, "", "")
+	if _, ok := config.Tags[instanceStoreTag]; ok {
+		t.Errorf("unexpected instance store tag for m5.xlarge")
+	}
+
+	for instanceType, want := range map[string]bool{
+		"i3.large": true, "i4i.xlarge": true, "m5d.large": true, "c6gd.medium": true, "g4dn.xlarge": true,
+		"m5.large": false, "t3.micro": false, "inf1.xlarge": false, "c7i-flex.large": false, "": false,
+	} {
+		if got := models.HasInstanceStore(instanceType); got != want {
+			t.Errorf("HasInstanceStore(%q) = %v, want %v", instanceType, got, want)
+		}
+	}
+}
+
+func TestWorkerInstanceConfigGPU(t *testing.T) {
+	cluster := &models.ClusterResource{Name: "demo"}
+
+	config := workerInstanceConfig(cluster, "w", models.NodePool{Name: "gpu", InstanceType: "g5.xlarge"}, "", "")
+	if config.Tags[gpuTag] != "true" || config.Tags["k8s-label-nvidia.com/gpu"] != "true" {
+		t.Errorf("expected GPU tags for g5.xlarge, got %v", config.Tags)
+	}
+
+	config = workerInstanceConfig(cluster, "w", models.NodePool{Name: "ci", InstanceType: "m5.xlarge"}, "", "")
+	if _, ok := config.Tags[gpuTag]; ok {
+		t.Errorf("unexpected GPU tag for m5.xlarge")
+	}
+
+	for instanceType, want := range map[string]bool{
+		"g4dn.xlarge": true, "g5.2xlarge": true, "g6e.xlarge": true, "p3.8xlarge": true, "p4d.24xlarge": true,
+		"g4ad.xlarge": false, "m5.large": false, "inf1.xlarge": false, "": false,
+	} {
+		if got := models.IsGPUInstanceType(instanceType); got != want {
+			t.Errorf("IsGPUInstanceType(%q) = %v, want %v", instanceType, got, want)
+		}
+	}
+}
+
+func TestInstanceConfigPrivateNetwork(t *testing.T) {
+	cluster := &models.ClusterResource{Name: "demo"}
+	if masterInstanceConfig(cluster, "m", map[string]string{}).PrivateNetwork {
+		t.Errorf("master of a public cluster launched without a public IP")
+	}
+
+	cluster.Spec.Network.Private = true
+	master := masterInstanceConfig(cluster, "m", map[string]string{})
+	worker := workerInstanceConfig(cluster, "w", models.NodePool{Name: "default", InstanceType: "t3.medium"}, "", "")
+	if !master.PrivateNetwork || !worker.PrivateNetwork {
+		t.Errorf("private cluster: master %v, worker %v", master.PrivateNetwork, worker.PrivateNetwork)
+	}
+}
+
+func TestInstanceConfigRootVolume(t *testing.T) {
+	cluster := &models.ClusterResource{Name: "demo", Spec: models.ClusterSpec{
+		RootVolume: &models.RootVolume{Size: 50},
+	}}
+
+	master := masterInstanceConfig(cluster, "m", map[string]string{})
+	if master.RootVolumeSize != 50 || master.RootVolumeType != "gp3" || master.RootVolumeIOPS != 0 {
+		t.Errorf("master root volume: %d %s %d", master.RootVolumeSize, master.RootVolumeType, master.RootVolumeIOPS)
+	}
+
+	// Pools without their own root volume use the cluster's
+	worker := workerInstanceConfig(cluster, "w", models.NodePool{Name: "general", InstanceType: "t3.large"}, "", "")
+	if worker.RootVolumeSize != 50 {
+		t.Errorf("worker root volume size = %d, want the cluster's 50", worker.RootVolumeSize)
+	}
+
+	pool := models.NodePool{Name: "db", InstanceType: "r5.large", RootVolume: &models.RootVolume{Size: 200, Type: "io2", IOPS: 8000}}
+	worker = workerInstanceConfig(cluster, "w", pool, "", "")
+	if worker.RootVolumeSize != 200 || worker.RootVolumeType != "io2" || worker.RootVolumeIOPS != 8000 {
+		t.Errorf("pool root volume: %d %s %d", worker.RootVolumeSize, worker.RootVolumeType, worker.RootVolumeIOPS)
+	}
+
+	// Without any root volume the image default is kept
+	worker = workerInstanceConfig(&models.ClusterResource{Name: "demo"}, "w", models.NodePool{Name: "general"}, "", "")
+	if worker.RootVolumeSize != 0 || worker.RootVolumeType != "" {
+		t.Errorf("unexpected root volume: %d %s", worker.RootVolumeSize, worker.RootVolumeType)
+	}
+}
+
+func TestValidateRootVolume(t *testing.T) {
+	tests := []struct {
+		name    string
+		volume  *models.RootVolume
+		wantErr bool
+	}{
+		{"nil", nil, false},
+		{"size only", &models.RootVolume{Size: 100}, false},
+		{"too small", &models.RootVolume{Size: 4}, true},
+		{"gp3 iops", &models.RootVolume{Size: 100, IOPS: 6000}, false},
+		{"gp3 iops too low", &models.RootVolume{Size: 100, IOPS: 1000}, true},
+		{"gp2 iops", &models.RootVolume{Type: "gp2", IOPS: 3000}, true},
+		{"io2 without iops", &models.RootVolume{Size: 100, Type: "io2"}, true},
+		{"io2", &models.RootVolume{Size: 100, Type: "io2", IOPS: 10000}, false},
+		{"st1 cannot boot", &models.RootVolume{Size: 500, Type: "st1"}, true},
+	}
+	for _, tt := range tests {
+		if err := models.ValidateRootVolume("pool", tt.volume); (err != nil) != tt.wantErr {
+			t.Errorf("%s: err = %v, wantErr %v", tt.name, err, tt.wantErr)
+		}
+	}
+}
+
+func TestInstanceConfigArchitecture(t *testing.T) {
+	cluster := &models.ClusterResource{Name: "demo"}
+	cluster.Spec.InstanceType = "t4g.medium"
+	if got := masterInstanceConfig(cluster, "m", map[string]string{}).Architecture; got != models.ArchitectureARM64 {
+		t.Errorf("master of t4g.medium is %s", got)
+	}
+	if got := workerInstanceConfig(cluster, "w", models.NodePool{Name: "x86", InstanceType: "m5.large"}, "", "").Architecture; got != models.ArchitectureX86_64 {
+		t.Errorf("worker of m5.large is %s", got)
+	}
+
+	for instanceType, want := range map[string]string{
+		"t4g.small": "arm64", "m7g.large": "arm64", "c6gd.xlarge": "arm64", "im4gn.large": "arm64", "g5g.xlarge": "arm64", "a1.medium": "arm64",
+		"t3.medium": "x86_64", "g5.xlarge": "x86_64", "c7i-flex.large": "x86_64", "m5dn.large": "x86_64", "": "x86_64",
+	} {
+		if got := models.InstanceArchitecture(instanceType); got != want {
+			t.Errorf("InstanceArchitecture(%q) = %s, want %s", instanceType, got, want)
+		}
+	}
+
+	if err := models.ValidateArchitecture("node pool arm", models.ArchitectureARM64, "m5.large"); err == nil {
+		t.Error("arm64 pool of m5.large accepted")
+	}
+	if err := models.ValidateArchitecture("node pool arm", "aarch64", ""); err == nil {
+		t.Error("unknown architecture accepted")
+	}
+}
+
+func TestInstanceConfigImage(t *testing.T) {
+	cluster := &models.ClusterResource{Name: "demo"}
+	cluster.Spec.ImageID = "ami-0123456789abcdef0"
+	if got := masterInstanceConfig(cluster, "m", map[string]string{}); got.ImageID != "ami-0123456789abcdef0" || got.OSFamily != "" {
+		t.Errorf("master image = %q/%q", got.ImageID, got.OSFamily)
+	}
+
+	// A pool setting either field uses its own image, not the cluster's
+	pool := models.NodePool{Name: "ubuntu", InstanceType: "m5.large", OSFamily: models.OSUbuntu2204}
+	if got := workerInstanceConfig(cluster, "w", pool, "", ""); got.ImageID != "" || got.OSFamily != models.OSUbuntu2204 {
+		t.Errorf("ubuntu pool image = %q/%q", got.ImageID, got.OSFamily)
+	}
+	pool = models.NodePool{Name: "default", InstanceType: "m5.large"}
+	if got := workerInstanceConfig(cluster, "w", pool, "", ""); got.ImageID != "ami-0123456789abcdef0" {
+		t.Errorf("default pool image = %q", got.ImageID)
+	}
+
+	for _, bad := range [][2]string{{"ami-123", ""}, {"golden", ""}, {"", "debian12"}} {
+		if err := models.ValidateImage("cluster demo", bad[0], bad[1]); err == nil {
+			t.Errorf("image %q/%q accepted", bad[0], bad[1])
+		}
+	}
+}
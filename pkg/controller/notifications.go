@@ -0,0 +1,125 @@
+package controller
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/madhouselabs/goman/pkg/logger"
+	"github.com/madhouselabs/goman/pkg/models"
+	"github.com/madhouselabs/goman/pkg/provider"
+)
+
+// notificationTimeout bounds the delivery to one target
+const notificationTimeout = 10 * time.Second
+
+// Notifier delivers cluster notifications to webhooks and SNS topics
+type Notifier struct {
+	client *http.Client
+	topics provider.NotificationService
+}
+
+// NewNotifier creates a notifier publishing SNS notifications to topics
+func NewNotifier(topics provider.NotificationService) *Notifier {
+	return &Notifier{
+		client: &http.Client{Timeout: notificationTimeout},
+		topics: topics,
+	}
+}
+
+// Send delivers a notification to one target
+func (n *Notifier) Send(ctx context.Context, target models.NotificationTarget, msg models.NotificationMessage) error {
+	switch target.Type {
+	case models.NotificationSlack:
+		text := fmt.Sprintf("Cluster *%s* is %s", msg.Cluster, msg.Phase)
+		if msg.PreviousPhase != "" {
+			text = fmt.Sprintf("Cluster *%s* moved from %s to %s", msg.Cluster, msg.PreviousPhase, msg.Phase)
+		}
+		if msg.Message != "" {
+			text += ": " + msg.Message
+		}
+		return n.post(ctx, target.URL, map[string]string{"text": text})
+	case models.NotificationWebhook:
+		return n.post(ctx, target.URL, msg)
+	case models.NotificationSNS:
+		if n.topics == nil {
+			return fmt.Errorf("no notification service to publish to %s", target.Topic)
+		}
+		data, err := json.Marshal(msg)
+		if err != nil {
+			return err
+		}
+		return n.topics.Publish(ctx, target.Topic, string(data))
+	default:
+		return fmt.Errorf("unknown notification target type %q", target.Type)
+	}
+}
+
+// post sends body as JSON to a webhook
+func (n *Notifier) post(ctx context.Context, endpoint string, body interface{}) error {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "goman")
+	resp, err := n.client.Do(req)
+	if err != nil {
+		// Leave out the URL: Slack webhook URLs are credentials
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook returned %s", resp.Status)
+	}
+	return nil
+}
+
+// notifyPhaseChange tells the cluster's notification targets about a move
+// to a phase they subscribe to. Notifications are informational: failures
+// are recorded as events, never returned.
+func (r *Reconciler) notifyPhaseChange(ctx context.Context, cluster *models.ClusterResource, previousPhase string) {
+	phase := cluster.Status.Phase
+	policy := cluster.Spec.Notifications
+	if phase == previousPhase || !policy.Notifies(phase) || r.notifier == nil {
+		return
+	}
+	if err := models.ValidateNotifications(policy); err != nil {
+		r.events.Warning(ctx, cluster.Name, EventNotificationFailed, "", "Invalid notification policy: %v", err)
+		return
+	}
+
+	msg := models.NotificationMessage{
+		Cluster:       cluster.Name,
+		Region:        cluster.Spec.Region,
+		Phase:         phase,
+		PreviousPhase: previousPhase,
+		Message:       cluster.Status.Message,
+		Timestamp:     time.Now().UTC(),
+	}
+	for _, target := range policy.Targets {
+		// The notification outlives the reconcile's deadline if it just ran out
+		sendCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), notificationTimeout)
+		err := r.notifier.Send(sendCtx, target, msg)
+		cancel()
+		if err != nil {
+			logger.Warnf(sendCtx, "[NOTIFY] Warning: Failed to notify %s target of %s: %v", target.Type, cluster.Name, err)
+			r.events.Warning(ctx, cluster.Name, EventNotificationFailed, "", "Failed to send %s notification for phase %s: %v", target.Type, phase, err)
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/madhouselabs/goman/pkg/models"
+	"github.com/madhouselabs/goman/pkg/provider"
+)
+
+// topicRecorder keeps published messages; other methods are not used
+type topicRecorder struct {
+	provider.NotificationService
+	published map[string][]string
+}
+
+func (s *topicRecorder) Publish(ctx context.Context, topic string, message string) error {
+	if s.published == nil {
+		s.published = make(map[string][]string)
+	}
+	s.published[topic] = append(s.published[topic], message)
+	return nil
+}
+
+func TestNotifyPhaseChange(t *testing.T) {
+	var bodies []map[string]interface{}
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		var body map[string]interface{}
+		if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+			t.Errorf("webhook body: %v", err)
+		}
+		body["path"] = req.URL.Path
+		bodies = append(bodies, body)
+	}))
+	defer server.Close()
+
+	topic := "arn:aws:sns:ap-south-1:123456789012:goman-alerts"
+	topics := &topicRecorder{}
+	r := &Reconciler{notifier: NewNotifier(topics)}
+	r.notifier.client = server.Client()
+	cluster := &models.ClusterResource{Name: "demo"}
+	cluster.Spec.Region = "ap-south-1"
+	cluster.Spec.Notifications = &models.NotificationPolicy{Targets: []models.NotificationTarget{
+		{Type: models.NotificationSlack, URL: server.URL + "/slack"},
+		{Type: models.NotificationWebhook, URL: server.URL + "/hook"},
+		{Type: models.NotificationSNS, Topic: topic},
+	}}
+
+	// Not a default phase, and not a change
+	cluster.Status.Phase = models.ClusterPhaseConfiguring
+	r.notifyPhaseChange(context.Background(), cluster, models.ClusterPhaseProvisioning)
+	cluster.Status.Phase = models.ClusterPhaseRunning
+	r.notifyPhaseChange(context.Background(), cluster, models.ClusterPhaseRunning)
+	if len(bodies) != 0 || len(topics.published) != 0 {
+		t.Fatalf("notified without a subscribed transition: %v %v", bodies, topics.published)
+	}
+
+	cluster.Status.Message = "Cluster is ready"
+	r.notifyPhaseChange(context.Background(), cluster, models.ClusterPhaseConfiguring)
+	if len(bodies) != 2 {
+		t.Fatalf("got %d webhook calls, want 2", len(bodies))
+	}
+	if bodies[0]["path"] != "/slack" || !strings.Contains(bodies[0]["text"].(string), "*demo* moved from Configuring to Running") {
+		t.Errorf("slack body = %v", bodies[0])
+	}
+	if bodies[1]["path"] != "/hook" || bodies[1]["cluster"] != "demo" || bodies[1]["phase"] != "Running" ||
+		bodies[1]["previousPhase"] != "Configuring" || bodies[1]["message"] != "Cluster is ready" {
+		t.Errorf("webhook body = %v", bodies[1])
+	}
+	if got := topics.published[topic]; len(got) != 1 || !strings.Contains(got[0], `"phase":"Running"`) {
+		t.Errorf("sns messages = %v", got)
+	}
+}
+
+func TestNotifierWebhookError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer server.Close()
+
+	n := NewNotifier(nil)
+	err := n.Send(context.Background(), models.NotificationTarget{Type: models.NotificationWebhook, URL: server.URL},
+		models.NotificationMessage{Cluster: "demo", Phase: models.ClusterPhaseFailed})
+	if err == nil || !strings.Contains(err.Error(), "403") {
+		t.Errorf("Send() = %v, want the status", err)
+	}
+
+	// Unreachable: the error must not carry the URL
+	secret := "http://127.0.0.1:1/services/T000/B000/secret"
+	err = n.Send(context.Background(), models.NotificationTarget{Type: models.NotificationSlack, URL: secret},
+		models.NotificationMessage{Cluster: "demo", Phase: models.ClusterPhaseFailed})
+	if err == nil || strings.Contains(err.Error(), "secret") {
+		t.Errorf("Send() = %v, want an error without the URL", err)
+	}
+}
+
+func TestValidateNotifications(t *testing.T) {
+	valid := &models.NotificationPolicy{
+		Targets: []models.NotificationTarget{
+			{Type: models.NotificationSlack, URL: "https://hooks.slack.com/services/T/B/x"},
+			{Type: models.NotificationSNS, Topic: "arn:aws:sns:us-east-1:123456789012:goman-ops"},
+		},
+		Phases: []string{"running", "Failed"},
+	}
+	if err := models.ValidateNotifications(valid); err != nil {
+		t.Errorf("ValidateNotifications(valid) = %v", err)
+	}
+	for _, policy := range []*models.NotificationPolicy{
+		{Targets: []models.NotificationTarget{{Type: models.NotificationSlack, URL: "http://hooks.slack.com/x"}}},
+		{Targets: []models.NotificationTarget{{Type: models.NotificationWebhook, URL: "hooks"}}},
+		{Targets: []models.NotificationTarget{{Type: models.NotificationSNS, Topic: "goman-ops"}}},
+		{Targets: []models.NotificationTarget{{Type: "email", URL: "https://example.com"}}},
+		{Targets: valid.Targets, Phases: []string{"Pending"}},
+	} {
+		if err := models.ValidateNotifications(policy); err == nil {
+			t.Errorf("ValidateNotifications(%+v) = nil, want an error", policy)
+		}
+	}
+	if key := valid.Key(); strings.Contains(key, "hooks.slack.com") {
+		t.Errorf("Key() = %q leaks the Slack URL", key)
+	}
+}
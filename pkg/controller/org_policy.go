@@ -0,0 +1,51 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/madhouselabs/goman/pkg/logger"
+	"github.com/madhouselabs/goman/pkg/models"
+	"github.com/madhouselabs/goman/pkg/provider"
+	"gopkg.in/yaml.v3"
+)
+
+// loadOrgPolicy reads the organization policy from storage; nil when none
+// is set
+func loadOrgPolicy(ctx context.Context, storage provider.StorageService) (*models.OrgPolicy, error) {
+	data, err := storage.GetObject(ctx, models.OrgPolicyKey)
+	if err != nil {
+		if errors.Is(err, provider.ErrNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to load organization policy: %w", err)
+	}
+	var policy models.OrgPolicy
+	if err := yaml.Unmarshal(data, &policy); err != nil {
+		return nil, provider.UserConfigErrorf("failed to parse organization policy %s: %w", models.OrgPolicyKey, err)
+	}
+	if err := policy.Validate(); err != nil {
+		return nil, provider.UserConfigErrorf("invalid organization policy %s: %w", models.OrgPolicyKey, err)
+	}
+	return &policy, nil
+}
+
+// enforceOrgPolicy checks a created or edited cluster against the
+// organization policy, so clusters written to the bucket without the CLI
+// are held to it too. A spec already processed is not checked again:
+// tightening the policy must not stop existing clusters from reconciling.
+func (r *Reconciler) enforceOrgPolicy(ctx context.Context, cluster *models.ClusterResource) error {
+	if cluster.Generation != 0 && cluster.Generation == cluster.Status.ObservedGeneration {
+		return nil
+	}
+	policy, err := loadOrgPolicy(ctx, r.provider.GetStorageService())
+	if err != nil {
+		return err
+	}
+	if err := policy.Check(cluster.Subject()); err != nil {
+		logger.Infof(ctx, "[POLICY] Rejecting spec of cluster %s: %v", cluster.Name, err)
+		return provider.UserConfigErrorf("%w", err)
+	}
+	return nil
+}
@@ -0,0 +1,93 @@
+package controller
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/madhouselabs/goman/pkg/models"
+)
+
+func TestOrgPolicyCheck(t *testing.T) {
+	policy := &models.OrgPolicy{
+		NamePattern:             "(dev|prod)-[a-z0-9-]+",
+		RequiredTags:            map[string]string{"CostCenter": "[0-9]{4}", "Owner": ""},
+		AllowedRegions:          []string{"eu-west-1", "eu-central-1"},
+		AllowedInstanceFamilies: []string{"t3", "m6i"},
+	}
+	if err := policy.Validate(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	compliant := &models.ClusterResource{
+		Name: "prod-payments",
+		Spec: models.ClusterSpec{
+			Region:       "eu-west-1",
+			InstanceType: "t3.medium",
+			NodePools:    []models.NodePool{{Name: "workers", InstanceType: "m6i.large"}},
+			Tags:         map[string]string{"CostCenter": "1234", "Owner": "payments"},
+		},
+	}
+	if err := policy.Check(compliant.Subject()); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		edit func(c *models.ClusterResource)
+		want string
+	}{
+		{"name", func(c *models.ClusterResource) { c.Name = "payments" }, `name "payments" does not match`},
+		{"name prefix only", func(c *models.ClusterResource) { c.Name = "prod-Payments" }, "does not match"},
+		{"missing tag", func(c *models.ClusterResource) { delete(c.Spec.Tags, "Owner") }, "tag Owner is required"},
+		{"empty tag", func(c *models.ClusterResource) { c.Spec.Tags["Owner"] = "" }, "tag Owner is required"},
+		{"tag value", func(c *models.ClusterResource) { c.Spec.Tags["CostCenter"] = "12345" }, "tag CostCenter=12345 does not match"},
+		{"region", func(c *models.ClusterResource) { c.Spec.Region = "us-east-1" }, `region "us-east-1" is not allowed`},
+		{"master type", func(c *models.ClusterResource) { c.Spec.InstanceType = "p4d.24xlarge" }, "instance type p4d.24xlarge"},
+		{"pool type", func(c *models.ClusterResource) { c.Spec.NodePools[0].InstanceType = "m6a.large" }, "instance type m6a.large"},
+	}
+	for _, tt := range tests {
+		c := *compliant
+		c.Spec.Tags = map[string]string{"CostCenter": "1234", "Owner": "payments"}
+		c.Spec.NodePools = append([]models.NodePool(nil), compliant.Spec.NodePools...)
+		tt.edit(&c)
+		err := policy.Check(c.Subject())
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: error = %v, want it to contain %q", tt.name, err, tt.want)
+		}
+	}
+
+	// Every violation is reported at once
+	c := models.K3sCluster{Name: "test", Region: "us-east-1", InstanceType: "c5.large"}
+	err := policy.Check(c.Subject())
+	if err == nil || strings.Count(err.Error(), ";") != 4 {
+		t.Errorf("expected five violations, got %v", err)
+	}
+
+	var none *models.OrgPolicy
+	if err := none.Check(c.Subject()); err != nil {
+		t.Errorf("no policy: unexpected error: %v", err)
+	}
+}
+
+func TestOrgPolicyValidate(t *testing.T) {
+	for _, policy := range []models.OrgPolicy{
+		{NamePattern: "team-("},
+		{RequiredTags: map[string]string{"Owner": "[a-"}},
+		{RequiredTags: map[string]string{"": ""}},
+	} {
+		if err := policy.Validate(); err == nil {
+			t.Errorf("expected %+v to be rejected", policy)
+		}
+	}
+}
+
+func TestEnforceOrgPolicySkipsProcessedSpecs(t *testing.T) {
+	// Processed specs are not checked again, so no storage is needed
+	r := &Reconciler{}
+	cluster := &models.ClusterResource{Name: "legacy", Generation: 3}
+	cluster.Status.ObservedGeneration = 3
+	if err := r.enforceOrgPolicy(context.Background(), cluster); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
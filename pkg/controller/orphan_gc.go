@@ -0,0 +1,322 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/madhouselabs/goman/pkg/logger"
+	"github.com/madhouselabs/goman/pkg/provider"
+	"github.com/madhouselabs/goman/pkg/storage"
+	"gopkg.in/yaml.v3"
+)
+
+// OrphanReportKey is the storage key of the report of the last periodic
+// orphan collection. It lives outside clusters/ so writing it doesn't
+// trigger reconciles.
+const OrphanReportKey = "controller/orphan-gc.json"
+
+// orphanGCLock serializes orphan collections
+const orphanGCLock = "orphan-gc"
+
+// What an orphan collection did with an orphaned resource
+const (
+	OrphanDeleted     = "deleted"
+	OrphanWouldDelete = "would delete"
+	OrphanInUse       = "in use"
+	OrphanFailed      = "failed"
+	OrphanUntagged    = "untagged" // Created before installs were tagged, kept
+)
+
+// OrphanResource is a resource of a cluster that no longer exists
+type OrphanResource struct {
+	provider.TaggedResource
+	Action string `json:"action"`
+	Error  string `json:"error,omitempty"`
+}
+
+// OrphanReport is the result of an orphan collection
+type OrphanReport struct {
+	StartedAt time.Time        `json:"startedAt"`
+	DryRun    bool             `json:"dryRun"`
+	Regions   []string         `json:"regions"`
+	Resources []OrphanResource `json:"resources"`
+	Errors    []string         `json:"errors,omitempty"` // Listings that failed
+}
+
+// Count returns how many resources the collection left with an action
+func (r *OrphanReport) Count(action string) int {
+	n := 0
+	for _, res := range r.Resources {
+		if res.Action == action {
+			n++
+		}
+	}
+	return n
+}
+
+// orphanKindOrder deletes network interfaces before the security groups
+// they are in
+var orphanKindOrder = map[string]int{
+	provider.ResourceNetworkInterface: 0,
+	provider.ResourceVolume:           1,
+	provider.ResourceSecurityGroup:    2,
+	provider.ResourceIdentity:         3,
+}
+
+// CollectOrphans finds the security groups, network interfaces, volumes and
+// node identities goman created for clusters whose config no longer exists,
+// and deletes them unless dryRun is set. The provider's region, the regions
+// of existing clusters and the given regions are searched.
+//
+// Only resources tagged with this install's InstallID are deleted; other
+// installs sharing the account keep theirs, and untagged resources are
+// reported but kept. Former names of renamed clusters count as existing.
+// Resources in use are kept, such as groups of instances left running; a
+// cluster created during the collection keeps its resources, as each
+// cluster is looked up again before deleting.
+func (r *Reconciler) CollectOrphans(ctx context.Context, regions []string, dryRun bool) (*OrphanReport, error) {
+	report := &OrphanReport{StartedAt: time.Now().UTC(), DryRun: dryRun, Resources: []OrphanResource{}}
+
+	existing, clusterRegions, err := r.existingClusters(ctx)
+	if err != nil {
+		return nil, err
+	}
+	report.Regions = orphanRegions(r.provider.Region(), regions, clusterRegions)
+
+	var resources []provider.TaggedResource
+	install := ""
+	computeService := r.provider.GetComputeService()
+	if collector, ok := computeService.(provider.OrphanCollector); ok {
+		install = collector.InstallID()
+		for _, region := range report.Regions {
+			found, err := collector.ListTaggedResources(ctx, region)
+			if err != nil {
+				logger.Warnf(ctx, "[GC] Failed to list resources in %s: %v", region, err)
+				report.Errors = append(report.Errors, fmt.Sprintf("%s: %v", region, err))
+				continue
+			}
+			resources = append(resources, found...)
+		}
+
+		identities, err := collector.ListTaggedIdentities(ctx)
+		if err != nil {
+			logger.Warnf(ctx, "[GC] Failed to list cluster identities: %v", err)
+			report.Errors = append(report.Errors, fmt.Sprintf("identities: %v", err))
+		}
+		resources = append(resources, identities...)
+	} else {
+		identities, err := computeService.ListClusterIdentities(ctx)
+		if err != nil {
+			logger.Warnf(ctx, "[GC] Failed to list cluster identities: %v", err)
+			report.Errors = append(report.Errors, fmt.Sprintf("identities: %v", err))
+		}
+		for _, name := range identities {
+			resources = append(resources, provider.TaggedResource{Kind: provider.ResourceIdentity, ID: name, Cluster: name})
+		}
+	}
+
+	var orphans []provider.TaggedResource
+	for _, res := range resources {
+		if res.Cluster == "" || existing[res.Cluster] {
+			continue
+		}
+		// Another install's resource is none of this one's business
+		if res.Install != "" && res.Install != install {
+			continue
+		}
+		orphans = append(orphans, res)
+	}
+
+	sort.SliceStable(orphans, func(i, j int) bool {
+		a, b := orphans[i], orphans[j]
+		if orphanKindOrder[a.Kind] != orphanKindOrder[b.Kind] {
+			return orphanKindOrder[a.Kind] < orphanKindOrder[b.Kind]
+		}
+		if a.Region != b.Region {
+			return a.Region < b.Region
+		}
+		return a.ID < b.ID
+	})
+
+	exists := make(map[string]bool)
+	for _, res := range orphans {
+		if _, checked := exists[res.Cluster]; !checked {
+			exists[res.Cluster] = r.clusterExists(ctx, res.Cluster)
+		}
+		if exists[res.Cluster] {
+			continue
+		}
+
+		orphan := OrphanResource{TaggedResource: res}
+		switch {
+		case res.InUse:
+			orphan.Action = OrphanInUse
+		case res.Install == "" || install == "":
+			orphan.Action = OrphanUntagged
+		case dryRun:
+			orphan.Action = OrphanWouldDelete
+		default:
+			err := r.deleteOrphan(ctx, res)
+			switch {
+			case err == nil:
+				orphan.Action = OrphanDeleted
+			case errors.Is(err, provider.ErrResourceInUse), errors.Is(err, provider.ErrIdentityInUse):
+				orphan.Action = OrphanInUse
+			default:
+				orphan.Action = OrphanFailed
+				orphan.Error = err.Error()
+			}
+			logger.Infof(ctx, "[GC] %s %s of former cluster %s: %s", res.Kind, res.ID, res.Cluster, orphan.Action)
+		}
+		report.Resources = append(report.Resources, orphan)
+	}
+
+	return report, nil
+}
+
+// CollectOrphansIfDue runs a periodic orphan collection when the last one
+// is older than the orphanGCInterval setting, and stores its report under
+// OrphanReportKey. It is a dry run unless the orphanGCDelete setting is on.
+// The state bucket is pruned along with it.
+func (r *Reconciler) CollectOrphansIfDue(ctx context.Context, now time.Time) {
+	interval := r.settings.OrphanGCInterval
+	if interval <= 0 {
+		return
+	}
+	storageService := r.provider.GetStorageService()
+	if data, err := storageService.GetObject(ctx, OrphanReportKey); err == nil {
+		var last OrphanReport
+		if json.Unmarshal(data, &last) == nil && now.Sub(last.StartedAt) < interval {
+			return
+		}
+	}
+
+	lockToken, err := r.acquireLock(ctx, orphanGCLock)
+	if err != nil {
+		logger.Infof(ctx, "[GC] Skipping orphan collection, lock not acquired: %v", err)
+		return
+	}
+	defer r.releaseLock(ctx, orphanGCLock, lockToken)
+
+	r.pruneArtifacts(ctx)
+
+	report, err := r.CollectOrphans(ctx, nil, !r.settings.OrphanGCDelete)
+	if err != nil {
+		logger.Warnf(ctx, "[GC] Orphan collection failed: %v", err)
+		return
+	}
+	logger.Warnf(ctx, "[GC] Orphan collection in %s: %d deleted, %d to delete, %d in use, %d untagged, %d failed",
+		strings.Join(report.Regions, ", "), report.Count(OrphanDeleted), report.Count(OrphanWouldDelete),
+		report.Count(OrphanInUse), report.Count(OrphanUntagged), report.Count(OrphanFailed))
+
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		logger.Warnf(ctx, "[GC] Failed to encode orphan report: %v", err)
+		return
+	}
+	if err := storageService.PutObject(ctx, OrphanReportKey, data); err != nil {
+		logger.Warnf(ctx, "[GC] Failed to save orphan report: %v", err)
+	}
+}
+
+// deleteOrphan deletes one orphaned resource
+func (r *Reconciler) deleteOrphan(ctx context.Context, res provider.TaggedResource) error {
+	computeService := r.provider.GetComputeService()
+	if res.Kind == provider.ResourceIdentity {
+		return computeService.DeleteClusterIdentity(ctx, res.ID)
+	}
+	collector, ok := computeService.(provider.OrphanCollector)
+	if !ok {
+		return fmt.Errorf("cannot delete %s %s", res.Kind, res.ID)
+	}
+	return collector.DeleteTaggedResource(ctx, res)
+}
+
+// existingClusters returns the clusters with a config, under their current
+// and former names, and the regions they run in. The former names come from
+// the configs and the rename aliases; resources that cannot be renamed keep
+// them. Any failure is returned: a cluster missed would lose its resources.
+func (r *Reconciler) existingClusters(ctx context.Context) (map[string]bool, []string, error) {
+	storageService := r.provider.GetStorageService()
+	keys, err := storageService.ListObjects(ctx, "clusters/")
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to list clusters: %w", err)
+	}
+
+	existing := make(map[string]bool)
+	var regions []string
+	for _, key := range keys {
+		name, ok := storage.ClusterNameFromConfigKey(key)
+		if !ok {
+			continue
+		}
+		existing[name] = true
+
+		data, err := storageService.GetObject(ctx, key)
+		if err != nil {
+			if errors.Is(err, provider.ErrNotFound) {
+				continue
+			}
+			return nil, nil, fmt.Errorf("failed to read config of cluster %s: %w", name, err)
+		}
+		var config storage.ClusterConfig
+		if err := yaml.Unmarshal(data, &config); err != nil {
+			logger.Warnf(ctx, "[GC] Failed to parse config of cluster %s: %v", name, err)
+			continue
+		}
+		for _, legacy := range storage.LegacyNames(config.Metadata.Annotations) {
+			existing[legacy] = true
+		}
+		if config.Spec.Region != "" {
+			regions = append(regions, config.Spec.Region)
+		}
+	}
+
+	aliases, err := storageService.ListObjects(ctx, storage.AliasPrefix)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to list cluster aliases: %w", err)
+	}
+	for _, key := range aliases {
+		if name, ok := strings.CutSuffix(strings.TrimPrefix(key, storage.AliasPrefix), ".yaml"); ok && name != "" {
+			existing[name] = true
+		}
+	}
+	return existing, regions, nil
+}
+
+// clusterExists reports whether a cluster has a config, or is the former
+// name of one; failing to tell counts as existing
+func (r *Reconciler) clusterExists(ctx context.Context, name string) bool {
+	storageService := r.provider.GetStorageService()
+	for _, key := range []string{storage.ClusterConfigKey(name), storage.AliasKey(name)} {
+		if _, err := storageService.GetObject(ctx, key); !errors.Is(err, provider.ErrNotFound) {
+			return true
+		}
+	}
+	return false
+}
+
+// orphanRegions returns the default region and the given ones, sorted and
+// without duplicates
+func orphanRegions(defaultRegion string, lists ...[]string) []string {
+	seen := map[string]bool{defaultRegion: defaultRegion != ""}
+	regions := []string{}
+	if defaultRegion != "" {
+		regions = append(regions, defaultRegion)
+	}
+	for _, list := range lists {
+		for _, region := range list {
+			if region != "" && !seen[region] {
+				seen[region] = true
+				regions = append(regions, region)
+			}
+		}
+	}
+	sort.Strings(regions)
+	return regions
+}
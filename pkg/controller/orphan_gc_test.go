@@ -0,0 +1,212 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/madhouselabs/goman/pkg/provider"
+)
+
+// orphanStorage holds the configs of existing clusters
+type orphanStorage struct {
+	provider.StorageService
+	objects map[string][]byte
+}
+
+func (s *orphanStorage) GetObject(ctx context.Context, key string) ([]byte, error) {
+	if data, ok := s.objects[key]; ok {
+		return data, nil
+	}
+	return nil, provider.ErrNotFound
+}
+
+func (s *orphanStorage) PutObject(ctx context.Context, key string, data []byte) error {
+	s.objects[key] = data
+	return nil
+}
+
+func (s *orphanStorage) ListObjects(ctx context.Context, prefix string) ([]string, error) {
+	var keys []string
+	for key := range s.objects {
+		if strings.HasPrefix(key, prefix) {
+			keys = append(keys, key)
+		}
+	}
+	return keys, nil
+}
+
+// orphanCompute lists tagged resources and records the deletions
+type orphanCompute struct {
+	provider.ComputeService
+	resources  []provider.TaggedResource
+	identities []provider.TaggedResource
+	deleted    []string
+}
+
+func (c *orphanCompute) ListTaggedResources(ctx context.Context, region string) ([]provider.TaggedResource, error) {
+	var result []provider.TaggedResource
+	for _, res := range c.resources {
+		if res.Region == region {
+			result = append(result, res)
+		}
+	}
+	return result, nil
+}
+
+func (c *orphanCompute) DeleteTaggedResource(ctx context.Context, res provider.TaggedResource) error {
+	c.deleted = append(c.deleted, res.ID)
+	return nil
+}
+
+func (c *orphanCompute) ListTaggedIdentities(ctx context.Context) ([]provider.TaggedResource, error) {
+	return c.identities, nil
+}
+
+func (c *orphanCompute) InstallID() string { return "goman-bucket" }
+
+func (c *orphanCompute) DeleteClusterIdentity(ctx context.Context, clusterName string) error {
+	c.deleted = append(c.deleted, clusterName)
+	return nil
+}
+
+// orphanLocks always grants the lock
+type orphanLocks struct {
+	provider.LockService
+}
+
+func (l orphanLocks) AcquireLock(ctx context.Context, resourceID, owner string, ttl time.Duration) (string, error) {
+	return "token", nil
+}
+
+func (l orphanLocks) ReleaseLock(ctx context.Context, resourceID, token string) error { return nil }
+
+func TestCollectOrphans(t *testing.T) {
+	const install = "goman-bucket"
+	compute := &orphanCompute{
+		resources: []provider.TaggedResource{
+			{Kind: provider.ResourceSecurityGroup, ID: "sg-gone", Region: "us-east-1", Cluster: "gone", Install: install},
+			{Kind: provider.ResourceNetworkInterface, ID: "eni-gone", Region: "us-east-1", Cluster: "gone", Install: install},
+			{Kind: provider.ResourceNetworkInterface, ID: "eni-attached", Region: "us-east-1", Cluster: "gone", Install: install, InUse: true},
+			{Kind: provider.ResourceSecurityGroup, ID: "sg-live", Region: "eu-west-1", Cluster: "live", Install: install},
+			{Kind: provider.ResourceVolume, ID: "vol-far", Region: "ap-south-1", Cluster: "far", Install: install},
+		},
+		identities: []provider.TaggedResource{
+			{Kind: provider.ResourceIdentity, ID: "gone", Cluster: "gone", Install: install},
+			{Kind: provider.ResourceIdentity, ID: "live", Cluster: "live", Install: install},
+		},
+	}
+	storage := &orphanStorage{objects: map[string][]byte{
+		"clusters/live/config.yaml": []byte("spec:\n  region: eu-west-1\n"),
+	}}
+	r := &Reconciler{provider: &fakeProvider{compute: compute, storage: storage, locks: orphanLocks{}, region: "us-east-1"}, settings: DefaultSettings()}
+	ctx := context.Background()
+
+	// A dry run deletes nothing; ap-south-1 is only searched when asked
+	report, err := r.CollectOrphans(ctx, nil, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(compute.deleted) != 0 {
+		t.Errorf("dry run deleted %v", compute.deleted)
+	}
+	if !slices.Equal(report.Regions, []string{"eu-west-1", "us-east-1"}) {
+		t.Errorf("regions %v", report.Regions)
+	}
+	if report.Count(OrphanWouldDelete) != 3 || report.Count(OrphanInUse) != 1 {
+		t.Errorf("report %+v", report.Resources)
+	}
+
+	report, err = r.CollectOrphans(ctx, []string{"ap-south-1"}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Interfaces go before the groups they are in, identities last
+	if !slices.Equal(compute.deleted, []string{"eni-gone", "vol-far", "sg-gone", "gone"}) {
+		t.Errorf("deleted %v", compute.deleted)
+	}
+	if report.Count(OrphanDeleted) != 4 || report.Count(OrphanInUse) != 1 {
+		t.Errorf("report %+v", report.Resources)
+	}
+}
+
+func TestCollectOrphansOwnership(t *testing.T) {
+	const install = "goman-bucket"
+	config := "metadata:\n  annotations:\n    goman.io/legacy-names: first,second\nspec:\n  region: us-east-1\n"
+	tests := []struct {
+		name   string
+		res    provider.TaggedResource
+		action string // empty when left out of the report
+	}{
+		{"orphan of this install", provider.TaggedResource{Cluster: "gone", Install: install}, OrphanDeleted},
+		{"orphan of another install", provider.TaggedResource{Cluster: "gone", Install: "other-bucket"}, ""},
+		{"created before installs were tagged", provider.TaggedResource{Cluster: "gone"}, OrphanUntagged},
+		{"current name", provider.TaggedResource{Cluster: "renamed", Install: install}, ""},
+		{"legacy name", provider.TaggedResource{Cluster: "first", Install: install}, ""},
+		{"older legacy name", provider.TaggedResource{Cluster: "second", Install: install}, ""},
+		{"aliased name", provider.TaggedResource{Cluster: "aliased", Install: install}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res := tt.res
+			res.Kind, res.ID, res.Region = provider.ResourceSecurityGroup, "sg-1", "us-east-1"
+			compute := &orphanCompute{resources: []provider.TaggedResource{res}}
+			storage := &orphanStorage{objects: map[string][]byte{
+				"clusters/renamed/config.yaml": []byte(config),
+				"aliases/aliased.yaml":         []byte("from: aliased\nto: renamed\n"),
+			}}
+			r := &Reconciler{provider: &fakeProvider{compute: compute, storage: storage, locks: orphanLocks{}, region: "us-east-1"}, settings: DefaultSettings()}
+
+			report, err := r.CollectOrphans(context.Background(), nil, false)
+			if err != nil {
+				t.Fatal(err)
+			}
+			action := ""
+			if len(report.Resources) > 0 {
+				action = report.Resources[0].Action
+			}
+			if action != tt.action || (len(compute.deleted) > 0) != (tt.action == OrphanDeleted) {
+				t.Errorf("action %q, deleted %v", action, compute.deleted)
+			}
+		})
+	}
+}
+
+func TestCollectOrphansIfDue(t *testing.T) {
+	tests := []struct {
+		name    string
+		delete  bool
+		deleted int
+	}{
+		{"reports by default", false, 0},
+		{"deletes when enabled", true, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			compute := &orphanCompute{resources: []provider.TaggedResource{
+				{Kind: provider.ResourceVolume, ID: "vol-1", Region: "us-east-1", Cluster: "gone", Install: "goman-bucket"},
+			}}
+			storage := &orphanStorage{objects: map[string][]byte{
+				provider.RetentionKey: []byte("diagnosticsDays: 0\nlogDays: 0\nbinaryDays: 0\n"),
+			}}
+			settings := DefaultSettings()
+			settings.OrphanGCDelete = tt.delete
+			r := &Reconciler{provider: &fakeProvider{compute: compute, storage: storage, locks: orphanLocks{}, region: "us-east-1"}, settings: settings}
+
+			r.CollectOrphansIfDue(context.Background(), time.Now())
+			if len(compute.deleted) != tt.deleted {
+				t.Errorf("deleted %v", compute.deleted)
+			}
+			var report OrphanReport
+			if err := json.Unmarshal(storage.objects[OrphanReportKey], &report); err != nil {
+				t.Fatalf("report not stored: %v", err)
+			}
+			if report.DryRun == tt.delete || len(report.Resources) != 1 {
+				t.Errorf("report %+v", report)
+			}
+		})
+	}
+}
@@ -0,0 +1,132 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/madhouselabs/goman/pkg/logger"
+	"github.com/madhouselabs/goman/pkg/models"
+	"github.com/madhouselabs/goman/pkg/provider"
+)
+
+// parkedAtTag marks a worker stopped by a scale-down of a pool with
+// scaleDownBehavior "stop", with the time it was stopped (RFC 3339). Workers
+// stopped by a cluster hibernation don't carry it.
+const parkedAtTag = "goman-parked-at"
+
+// listParkedWorkers returns the stopped workers kept for reuse, by pool
+func (r *Reconciler) listParkedWorkers(ctx context.Context, cluster *models.ClusterResource) (map[string][]*provider.Instance, error) {
+	instances, err := r.provider.GetComputeService().ListInstances(ctx, map[string]string{
+		"tag:goman-cluster":   cluster.Name,
+		"instance-state-name": "stopping,stopped",
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list stopped workers: %w", err)
+	}
+	parked := make(map[string][]*provider.Instance)
+	for _, inst := range instances {
+		if inst.Tags["goman-role"] == "worker" && inst.Tags[parkedAtTag] != "" {
+			pool := inst.Tags["goman-nodepool"]
+			parked[pool] = append(parked[pool], inst)
+		}
+	}
+	return parked, nil
+}
+
+// reapParkedWorkers terminates parked workers that were stopped longer than
+// the stoppedWorkerMaxAge setting, or whose pool was removed or no longer
+// stops on scale-down. Returns the workers left for reuse.
+func (r *Reconciler) reapParkedWorkers(ctx context.Context, cluster *models.ClusterResource, parked map[string][]*provider.Instance) map[string][]*provider.Instance {
+	computeService := r.provider.GetComputeService()
+	kept := make(map[string][]*provider.Instance)
+	for poolName, workers := range parked {
+		pool, ok := findNodePool(cluster, poolName)
+		for _, inst := range workers {
+			reason := ""
+			switch {
+			case !ok:
+				reason = "its node pool was removed"
+			case !pool.StopsOnScaleDown():
+				reason = "its node pool terminates scaled-down workers"
+			default:
+				parkedAt, err := time.Parse(time.RFC3339, inst.Tags[parkedAtTag])
+				if err != nil {
+					reason = fmt.Sprintf("invalid %s tag %q", parkedAtTag, inst.Tags[parkedAtTag])
+				} else if maxAge := r.settings.StoppedWorkerMaxAge; maxAge > 0 && time.Since(parkedAt) > maxAge {
+					reason = fmt.Sprintf("stopped for more than %s", maxAge)
+				}
+			}
+			if reason == "" {
+				kept[poolName] = append(kept[poolName], inst)
+				continue
+			}
+			logger.Infof(ctx, "[NODEPOOLS] Terminating stopped worker %s (%s): %s", inst.Name, inst.ID, reason)
+			if err := computeService.DeleteInstance(ctx, inst.ID); err != nil {
+				logger.Warnf(ctx, "[NODEPOOLS] Failed to terminate stopped worker %s: %v", inst.ID, err)
+			}
+		}
+	}
+	return kept
+}
+
+// parkWorker drains a scaled-down worker, removes its K3s node and stops it
+// for reuse by the next scale-up of its pool. The node registers again when
+// the instance is started.
+func (r *Reconciler) parkWorker(ctx context.Context, masterInstanceID string, worker models.InstanceStatus) error {
+	if masterInstanceID != "" && worker.PrivateIP != "" {
+		nodeName := r.k3sNodeName(worker.PrivateIP)
+		if _, err := r.drainNode(ctx, masterInstanceID, nodeName, "120s", true); err != nil {
+			logger.Warnf(ctx, "[NODEPOOLS] Warning: Failed to drain %s before stopping it: %v", nodeName, err)
+		}
+	}
+	computeService := r.provider.GetComputeService()
+	if err := computeService.TagInstance(ctx, worker.InstanceID, map[string]string{parkedAtTag: time.Now().UTC().Format(time.RFC3339)}); err != nil {
+		return fmt.Errorf("failed to tag %s: %w", worker.Name, err)
+	}
+	if err := computeService.StopInstance(ctx, worker.InstanceID); err != nil {
+		return fmt.Errorf("failed to stop %s: %w", worker.Name, err)
+	}
+	return nil
+}
+
+// unparkWorkers starts up to n stopped workers of a pool, lowest index
+// first, and returns the ones started. Workers still stopping are left for a
+// later scale-up.
+func (r *Reconciler) unparkWorkers(ctx context.Context, parked []*provider.Instance, n int) []*provider.Instance {
+	computeService := r.provider.GetComputeService()
+	candidates := make([]*provider.Instance, 0, len(parked))
+	for _, inst := range parked {
+		if inst.State == "stopped" {
+			candidates = append(candidates, inst)
+		}
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		return extractWorkerIndex(candidates[i].Name) < extractWorkerIndex(candidates[j].Name)
+	})
+
+	var started []*provider.Instance
+	for _, inst := range candidates {
+		if len(started) == n {
+			break
+		}
+		// Clear the mark first so a started worker is never reaped
+		if err := computeService.TagInstance(ctx, inst.ID, map[string]string{parkedAtTag: ""}); err != nil {
+			logger.Warnf(ctx, "[NODEPOOLS] Failed to reuse stopped worker %s: %v", inst.Name, err)
+			continue
+		}
+		if err := computeService.StartInstance(ctx, inst.ID); err != nil {
+			logger.Warnf(ctx, "[NODEPOOLS] Failed to start stopped worker %s: %v", inst.Name, err)
+			if err := computeService.TagInstance(ctx, inst.ID, map[string]string{parkedAtTag: inst.Tags[parkedAtTag]}); err != nil {
+				logger.Warnf(ctx, "[NODEPOOLS] Warning: Failed to mark %s as stopped again: %v", inst.Name, err)
+			}
+			continue
+		}
+		logger.Infof(ctx, "[NODEPOOLS] Starting stopped worker %s (%s)", inst.Name, inst.ID)
+		inst.State = "pending"
+		delete(inst.Tags, parkedAtTag)
+		started = append(started, inst)
+	}
+	return started
+}
@@ -0,0 +1,203 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/madhouselabs/goman/pkg/models"
+	"github.com/madhouselabs/goman/pkg/provider"
+)
+
+// poolCompute keeps the instances of one cluster in memory
+type poolCompute struct {
+	provider.ComputeService
+
+	mu        sync.Mutex
+	instances map[string]*provider.Instance
+	created   []string
+	deleted   []string
+	drained   []string
+}
+
+func (c *poolCompute) ListInstances(ctx context.Context, filters map[string]string) ([]*provider.Instance, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	states := strings.Split(filters["instance-state-name"], ",")
+	var result []*provider.Instance
+	for _, inst := range c.instances {
+		for _, state := range states {
+			if inst.State == state {
+				copied := *inst
+				copied.Tags = make(map[string]string)
+				for k, v := range inst.Tags {
+					copied.Tags[k] = v
+				}
+				result = append(result, &copied)
+			}
+		}
+	}
+	return result, nil
+}
+
+func (c *poolCompute) CreateInstance(ctx context.Context, config provider.InstanceConfig) (*provider.Instance, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	inst := &provider.Instance{
+		ID:    fmt.Sprintf("i-new%d", len(c.created)),
+		Name:  config.Name,
+		State: "pending",
+		Tags:  config.Tags,
+	}
+	c.instances[inst.ID] = inst
+	c.created = append(c.created, config.Name)
+	return inst, nil
+}
+
+func (c *poolCompute) DeleteInstance(ctx context.Context, id string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.instances[id].State = "terminated"
+	c.deleted = append(c.deleted, id)
+	return nil
+}
+
+func (c *poolCompute) StopInstance(ctx context.Context, id string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.instances[id].State = "stopped"
+	return nil
+}
+
+func (c *poolCompute) StartInstance(ctx context.Context, id string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.instances[id].State = "pending"
+	return nil
+}
+
+func (c *poolCompute) TagInstance(ctx context.Context, id string, tags map[string]string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for k, v := range tags {
+		c.instances[id].Tags[k] = v
+	}
+	return nil
+}
+
+func (c *poolCompute) RunOperation(ctx context.Context, ids []string, operation string, params map[string]string) (*provider.CommandResult, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.drained = append(c.drained, params["NodeName"])
+	return &provider.CommandResult{Status: "Success"}, nil
+}
+
+type tokenSecrets struct {
+	provider.SecretService
+}
+
+func (tokenSecrets) GetSecret(ctx context.Context, clusterName, name string) ([]byte, error) {
+	return []byte("token"), nil
+}
+
+func poolWorker(id, name, ip string) *provider.Instance {
+	return &provider.Instance{
+		ID:        id,
+		Name:      name,
+		State:     "running",
+		PrivateIP: ip,
+		Tags:      map[string]string{"goman-cluster": "demo", "goman-role": "worker", "goman-nodepool": "dev"},
+	}
+}
+
+func TestScaleDownStopsAndReusesWorkers(t *testing.T) {
+	compute := &poolCompute{instances: map[string]*provider.Instance{
+		"i-w0": poolWorker("i-w0", "demo-worker-dev-0", "10.0.1.10"),
+		"i-w1": poolWorker("i-w1", "demo-worker-dev-1", "10.0.1.11"),
+	}}
+	r := &Reconciler{provider: &fakeProvider{compute: compute, secrets: tokenSecrets{}, region: "ap-south-1"}, settings: DefaultSettings()}
+	cluster := &models.ClusterResource{Name: "demo"}
+	cluster.Spec.NodePools = []models.NodePool{{Name: "dev", Count: 1, InstanceType: "t3.medium", ScaleDownBehavior: models.ScaleDownStop}}
+	cluster.Status.Instances = []models.InstanceStatus{{InstanceID: "i-m", Name: "demo-master-0", Role: "master", State: "running", PrivateIP: "10.0.1.5"}}
+	ctx := context.Background()
+
+	if err := r.reconcileNodePools(ctx, cluster); err != nil {
+		t.Fatal(err)
+	}
+	w1 := compute.instances["i-w1"]
+	if w1.State != "stopped" || w1.Tags[parkedAtTag] == "" || len(compute.deleted) != 0 {
+		t.Fatalf("scaled-down worker %+v, deleted %v", w1, compute.deleted)
+	}
+	if len(compute.drained) != 1 || compute.drained[0] != "ip-10-0-1-11.ap-south-1.compute.internal" {
+		t.Errorf("drained %v", compute.drained)
+	}
+	if len(cluster.Status.Instances) != 2 {
+		t.Errorf("status lists %d instances, want the master and one worker", len(cluster.Status.Instances))
+	}
+
+	cluster.Spec.NodePools[0].Count = 3
+	if err := r.reconcileNodePools(ctx, cluster); err != nil {
+		t.Fatal(err)
+	}
+	if w1.State != "pending" || w1.Tags[parkedAtTag] != "" {
+		t.Errorf("stopped worker was not reused: %+v", w1)
+	}
+	if len(compute.created) != 1 || compute.created[0] != "demo-worker-dev-2" {
+		t.Errorf("created %v, want only demo-worker-dev-2", compute.created)
+	}
+	if len(cluster.Status.Instances) != 4 {
+		t.Errorf("status lists %d instances after the scale-up, want 4", len(cluster.Status.Instances))
+	}
+}
+
+func TestStoppedWorkersAreReaped(t *testing.T) {
+	old := poolWorker("i-old", "demo-worker-dev-1", "10.0.1.11")
+	old.State = "stopped"
+	old.Tags[parkedAtTag] = time.Now().Add(-8 * 24 * time.Hour).UTC().Format(time.RFC3339)
+	recent := poolWorker("i-recent", "demo-worker-dev-2", "10.0.1.12")
+	recent.State = "stopped"
+	recent.Tags[parkedAtTag] = time.Now().Add(-time.Hour).UTC().Format(time.RFC3339)
+	hibernated := poolWorker("i-hibernated", "demo-worker-dev-3", "10.0.1.13")
+	hibernated.State = "stopped"
+	compute := &poolCompute{instances: map[string]*provider.Instance{"i-old": old, "i-recent": recent, "i-hibernated": hibernated}}
+	r := &Reconciler{provider: &fakeProvider{compute: compute, secrets: tokenSecrets{}, region: "ap-south-1"}, settings: DefaultSettings()}
+	cluster := &models.ClusterResource{Name: "demo"}
+	cluster.Spec.NodePools = []models.NodePool{{Name: "dev", ScaleDownBehavior: models.ScaleDownStop}}
+	ctx := context.Background()
+
+	parked, err := r.listParkedWorkers(ctx, cluster)
+	if err != nil {
+		t.Fatal(err)
+	}
+	kept := r.reapParkedWorkers(ctx, cluster, parked)
+	if len(kept["dev"]) != 1 || kept["dev"][0].ID != "i-recent" {
+		t.Errorf("kept %v, want i-recent", kept["dev"])
+	}
+	if len(compute.deleted) != 1 || compute.deleted[0] != "i-old" {
+		t.Errorf("deleted %v, want i-old", compute.deleted)
+	}
+
+	// Switching the pool back to terminate removes the rest
+	cluster.Spec.NodePools[0].ScaleDownBehavior = models.ScaleDownTerminate
+	parked, _ = r.listParkedWorkers(ctx, cluster)
+	if kept := r.reapParkedWorkers(ctx, cluster, parked); len(kept) != 0 {
+		t.Errorf("kept %v after switching to terminate", kept)
+	}
+	if hibernated.State != "stopped" {
+		t.Error("a worker stopped by hibernation was reaped")
+	}
+}
+
+func TestValidateScaleDownBehavior(t *testing.T) {
+	for _, behavior := range []string{"", models.ScaleDownStop, models.ScaleDownTerminate} {
+		if err := models.ValidateScaleDownBehavior("dev", behavior); err != nil {
+			t.Errorf("ValidateScaleDownBehavior(%q) = %v", behavior, err)
+		}
+	}
+	if err := models.ValidateScaleDownBehavior("dev", "hibernate"); err == nil {
+		t.Error("ValidateScaleDownBehavior(hibernate) = nil, want an error")
+	}
+}
@@ -0,0 +1,228 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/madhouselabs/goman/pkg/logger"
+	"github.com/madhouselabs/goman/pkg/models"
+	"github.com/madhouselabs/goman/pkg/provider"
+)
+
+const (
+	// pendingDefaultTimeout applies to pending commands and processes
+	// recorded without a timeout
+	pendingDefaultTimeout = time.Hour
+
+	// finishedProcessRetention is how long a finished background process is
+	// kept for its result
+	finishedProcessRetention = 24 * time.Hour
+
+	// Caps on the pending operations kept in a cluster's status; the oldest
+	// entries are expired first
+	maxPendingCommands     = 50
+	maxBackgroundProcesses = 50
+	maxPendingStateChanges = 100
+
+	// expiredCheckRetryDelay is when a check whose operation expired may be
+	// retried
+	expiredCheckRetryDelay = 30 * time.Second
+)
+
+// collectPendingOperations prunes the pending operations of a cluster.
+// Invocations that crash leave their entries behind, and nothing else
+// removes them: commands and running background processes past their
+// timeout are expired, their SSM commands cancelled and the failure recorded
+// on their progress check. Finished processes are dropped after
+// finishedProcessRetention, state changes of instances no longer in the
+// cluster are dropped, and each map is capped.
+func (r *Reconciler) collectPendingOperations(ctx context.Context, cluster *models.ClusterResource, now time.Time) {
+	ops := cluster.Status.PendingOperations
+	if ops == nil {
+		return
+	}
+
+	for _, key := range sortedCommandKeys(ops.Commands) {
+		cmd := ops.Commands[key]
+		if cmd == nil {
+			delete(ops.Commands, key)
+			continue
+		}
+		timeout := pendingTimeout(cmd.Timeout)
+		if now.Sub(cmd.StartedAt) <= timeout {
+			continue
+		}
+		r.expireOperation(ctx, cluster, cmd.CommandID, cmd.StepName, cmd.CheckName, "",
+			fmt.Sprintf("%s timed out after %s", pendingPurpose(cmd.Purpose, "command "+cmd.CommandID), timeout))
+		delete(ops.Commands, key)
+	}
+	for _, key := range sortedCommandKeys(ops.Commands) {
+		if len(ops.Commands) <= maxPendingCommands {
+			break
+		}
+		cmd := ops.Commands[key]
+		r.expireOperation(ctx, cluster, cmd.CommandID, cmd.StepName, cmd.CheckName, "",
+			fmt.Sprintf("%s dropped, more than %d commands pending", pendingPurpose(cmd.Purpose, "command "+cmd.CommandID), maxPendingCommands))
+		delete(ops.Commands, key)
+	}
+
+	for _, key := range sortedProcessKeys(ops.BackgroundProcesses) {
+		proc := ops.BackgroundProcesses[key]
+		if proc == nil {
+			delete(ops.BackgroundProcesses, key)
+			continue
+		}
+		if proc.Status != "running" {
+			finished := proc.LastChecked
+			if finished.IsZero() {
+				finished = proc.StartedAt
+			}
+			if now.Sub(finished) > finishedProcessRetention {
+				delete(ops.BackgroundProcesses, key)
+			}
+			continue
+		}
+		timeout := pendingTimeout(proc.Timeout)
+		if now.Sub(proc.StartedAt) <= timeout {
+			continue
+		}
+		r.expireProcess(ctx, cluster, proc, now,
+			fmt.Sprintf("%s timed out after %s", pendingPurpose(proc.Purpose, "process "+key), timeout))
+	}
+	for _, key := range sortedProcessKeys(ops.BackgroundProcesses) {
+		if len(ops.BackgroundProcesses) <= maxBackgroundProcesses {
+			break
+		}
+		if proc := ops.BackgroundProcesses[key]; proc.Status == "running" {
+			r.expireProcess(ctx, cluster, proc, now,
+				fmt.Sprintf("%s dropped, more than %d background processes pending", pendingPurpose(proc.Purpose, "process "+key), maxBackgroundProcesses))
+		}
+		delete(ops.BackgroundProcesses, key)
+	}
+
+	// State changes of instances no longer in the cluster never complete
+	known := make(map[string]bool)
+	for _, inst := range cluster.Status.Instances {
+		known[inst.InstanceID] = true
+	}
+	instanceIDs := make([]string, 0, len(ops.InstanceStateChanges))
+	for id := range ops.InstanceStateChanges {
+		if !known[id] {
+			delete(ops.InstanceStateChanges, id)
+			continue
+		}
+		instanceIDs = append(instanceIDs, id)
+	}
+	sort.Strings(instanceIDs)
+	for _, id := range instanceIDs[min(len(instanceIDs), maxPendingStateChanges):] {
+		delete(ops.InstanceStateChanges, id)
+	}
+
+	if ops.IsEmpty() {
+		cluster.Status.PendingOperations = nil
+	}
+}
+
+// expireOperation cancels the command of an expired pending operation and
+// records the failure on its progress check, or its step if it has no check
+func (r *Reconciler) expireOperation(ctx context.Context, cluster *models.ClusterResource, commandID, stepName, checkName, object, message string) {
+	logger.Infof(ctx, "[GC] Cluster %s: %s", cluster.Name, message)
+	if commandID != "" {
+		if canceller, ok := r.provider.GetComputeService().(provider.CommandCanceller); ok {
+			if err := canceller.CancelCommand(ctx, commandID); err != nil {
+				logger.Warnf(ctx, "[GC] Failed to cancel command %s: %v", commandID, err)
+			}
+		}
+	}
+	switch {
+	case stepName != "" && checkName != "":
+		cluster.FailCheckWithRetry(stepName, checkName, message, expiredCheckRetryDelay)
+	case stepName != "":
+		cluster.FailStep(stepName, message)
+	}
+	r.events.Warning(ctx, cluster.Name, EventOperationExpired, object, "%s", message)
+}
+
+// expireProcess marks a running background process timed out, cancels the
+// command that started it and stops the process if its instance is running.
+// The entry is kept like other finished processes.
+func (r *Reconciler) expireProcess(ctx context.Context, cluster *models.ClusterResource, proc *models.BackgroundProcess, now time.Time, message string) {
+	r.expireOperation(ctx, cluster, proc.StartCommandID, proc.StepName, proc.CheckName, proc.InstanceID, message)
+	proc.Status = "timeout"
+	proc.ErrorMessage = message
+	proc.LastChecked = now
+
+	if proc.PIDFile == "" || !instanceRunning(cluster, proc.InstanceID) {
+		return
+	}
+	pidFile := "'" + strings.ReplaceAll(proc.PIDFile, "'", `'\''`) + "'"
+	script := fmt.Sprintf("if [ -f %[1]s ]; then kill $(cat %[1]s) 2>/dev/null; rm -f %[1]s; fi", pidFile)
+	if _, err := r.provider.GetComputeService().StartCommand(ctx, []string{proc.InstanceID}, script); err != nil {
+		logger.Warnf(ctx, "[GC] Failed to stop process %s on %s: %v", proc.ProcessKey, proc.InstanceID, err)
+	}
+}
+
+// instanceRunning reports whether an instance of the cluster is running
+func instanceRunning(cluster *models.ClusterResource, instanceID string) bool {
+	for _, inst := range cluster.Status.Instances {
+		if inst.InstanceID == instanceID {
+			return inst.State == "running"
+		}
+	}
+	return false
+}
+
+func pendingTimeout(timeout time.Duration) time.Duration {
+	if timeout <= 0 {
+		return pendingDefaultTimeout
+	}
+	return timeout
+}
+
+func pendingPurpose(purpose, fallback string) string {
+	if purpose == "" {
+		return fallback
+	}
+	return purpose
+}
+
+// sortedCommandKeys returns the keys of pending commands, oldest first
+func sortedCommandKeys(commands map[string]*models.PendingCommand) []string {
+	keys := make([]string, 0, len(commands))
+	for key := range commands {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		a, b := commands[keys[i]], commands[keys[j]]
+		if a == nil || b == nil {
+			return a == nil && b != nil
+		}
+		if !a.StartedAt.Equal(b.StartedAt) {
+			return a.StartedAt.Before(b.StartedAt)
+		}
+		return keys[i] < keys[j]
+	})
+	return keys
+}
+
+// sortedProcessKeys returns the keys of background processes, oldest first
+func sortedProcessKeys(processes map[string]*models.BackgroundProcess) []string {
+	keys := make([]string, 0, len(processes))
+	for key := range processes {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		a, b := processes[keys[i]], processes[keys[j]]
+		if a == nil || b == nil {
+			return a == nil && b != nil
+		}
+		if !a.StartedAt.Equal(b.StartedAt) {
+			return a.StartedAt.Before(b.StartedAt)
+		}
+		return keys[i] < keys[j]
+	})
+	return keys
+}
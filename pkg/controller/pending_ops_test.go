@@ -0,0 +1,97 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/madhouselabs/goman/pkg/models"
+	"github.com/madhouselabs/goman/pkg/provider"
+)
+
+// cancelCompute records the commands it cancels and starts
+type cancelCompute struct {
+	provider.ComputeService
+	cancelled []string
+	started   []string
+}
+
+func (c *cancelCompute) CancelCommand(ctx context.Context, commandID string) error {
+	c.cancelled = append(c.cancelled, commandID)
+	return nil
+}
+
+func (c *cancelCompute) StartCommand(ctx context.Context, instanceIDs []string, command string) (string, error) {
+	c.started = append(c.started, instanceIDs...)
+	return "cmd-kill", nil
+}
+
+func TestCollectPendingOperations(t *testing.T) {
+	compute := &cancelCompute{}
+	r := &Reconciler{provider: &fakeProvider{compute: compute}, settings: DefaultSettings()}
+	now := time.Now()
+	cluster := &models.ClusterResource{Name: "demo"}
+	cluster.InitializeClusterLifecycleProgress("create")
+	step := cluster.Status.ProgressMetrics.Steps[0]
+	if len(step.Checks) == 0 {
+		t.Fatalf("step %s has no checks", step.Name)
+	}
+	cluster.Status.Instances = []models.InstanceStatus{{InstanceID: "i-1", State: "running"}}
+	cluster.Status.PendingOperations = &models.PendingOperations{
+		Commands: map[string]*models.PendingCommand{
+			"stale": {CommandID: "cmd-stale", StartedAt: now.Add(-2 * time.Hour), Timeout: time.Hour, StepName: step.Name, CheckName: step.Checks[0].Name},
+			"fresh": {CommandID: "cmd-fresh", StartedAt: now.Add(-time.Minute), Timeout: time.Hour},
+			"bare":  {CommandID: "cmd-bare", StartedAt: now.Add(-2 * time.Hour)},
+		},
+		BackgroundProcesses: map[string]*models.BackgroundProcess{
+			"hung": {ProcessKey: "hung", InstanceID: "i-1", StartedAt: now.Add(-time.Hour), Timeout: time.Minute, Status: "running", PIDFile: "/tmp/goman_hung.pid", StartCommandID: "cmd-hung"},
+			"done": {ProcessKey: "done", InstanceID: "i-1", StartedAt: now.Add(-48 * time.Hour), Status: "completed", LastChecked: now.Add(-25 * time.Hour)},
+		},
+		InstanceStateChanges: map[string]string{"i-1": "stopped", "i-gone": "running"},
+	}
+
+	r.collectPendingOperations(context.Background(), cluster, now)
+	ops := cluster.Status.PendingOperations
+	slices.Sort(compute.cancelled)
+	if !slices.Equal(compute.cancelled, []string{"cmd-bare", "cmd-hung", "cmd-stale"}) {
+		t.Errorf("cancelled %v", compute.cancelled)
+	}
+	if len(ops.Commands) != 1 || ops.Commands["fresh"] == nil {
+		t.Errorf("commands left %v", ops.Commands)
+	}
+	if hung := ops.BackgroundProcesses["hung"]; hung == nil || hung.Status != "timeout" || !slices.Equal(compute.started, []string{"i-1"}) {
+		t.Errorf("hung process %+v, stopped on %v", hung, compute.started)
+	}
+	if ops.BackgroundProcesses["done"] != nil {
+		t.Error("finished process kept past its retention")
+	}
+	if len(ops.InstanceStateChanges) != 1 || ops.InstanceStateChanges["i-1"] != "stopped" {
+		t.Errorf("state changes %v", ops.InstanceStateChanges)
+	}
+	if check := cluster.Status.ProgressMetrics.Steps[0].Checks[0]; check.Status != "Failed" || check.FailureCount != 1 {
+		t.Errorf("check %+v", check)
+	}
+
+	// Maps are capped, the oldest entries going first
+	ops.Commands = map[string]*models.PendingCommand{}
+	for i := 0; i < maxPendingCommands+5; i++ {
+		ops.Commands[fmt.Sprint(i)] = &models.PendingCommand{CommandID: fmt.Sprintf("cmd-%d", i), StartedAt: now.Add(time.Duration(i) * time.Second)}
+	}
+	compute.cancelled = nil
+	r.collectPendingOperations(context.Background(), cluster, now.Add(time.Minute))
+	if len(ops.Commands) != maxPendingCommands || ops.Commands["0"] != nil || ops.Commands["5"] == nil {
+		t.Errorf("%d commands left after the cap", len(ops.Commands))
+	}
+	if !slices.Equal(compute.cancelled, []string{"cmd-0", "cmd-1", "cmd-2", "cmd-3", "cmd-4"}) {
+		t.Errorf("cancelled %v", compute.cancelled)
+	}
+
+	// Nothing left pending clears the operations
+	cluster.Status.PendingOperations = &models.PendingOperations{InstanceStateChanges: map[string]string{"i-gone": "running"}}
+	r.collectPendingOperations(context.Background(), cluster, now)
+	if cluster.Status.PendingOperations != nil {
+		t.Errorf("pending operations %+v", cluster.Status.PendingOperations)
+	}
+}
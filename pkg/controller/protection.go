@@ -0,0 +1,63 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/madhouselabs/goman/pkg/logger"
+	"github.com/madhouselabs/goman/pkg/models"
+)
+
+// reconcileInstanceProtection applies the shutdown behavior, stop protection
+// and deletion protection of the spec to existing nodes that don't have them
+// yet, all nodes again after a change. New nodes get the settings at launch
+// too.
+func (r *Reconciler) reconcileInstanceProtection(ctx context.Context, cluster *models.ClusterResource) error {
+	spec := cluster.Spec.InstanceProtection
+	if err := models.ValidateInstanceProtection(spec); err != nil {
+		return err
+	}
+	status := cluster.Status.InstanceProtection
+	if spec == nil && status == nil && !models.IsProduction(cluster.Spec.Tags) {
+		// Launch defaults, nothing was ever changed
+		return nil
+	}
+
+	// Tagging a cluster production turns on deletion protection by default
+	key := spec.AppliedKey(cluster.Spec.Tags)
+	if status == nil || status.Key != key {
+		logger.Infof(ctx, "[PROTECTION] Applying %s to nodes of cluster %s", key, cluster.Name)
+		status = &models.InstanceProtectionStatus{Key: key}
+		cluster.Status.InstanceProtection = status
+	}
+
+	known := make(map[string]bool)
+	var pending []string
+	for _, inst := range cluster.Status.Instances {
+		known[inst.InstanceID] = true
+		if (inst.State == "running" || inst.State == "stopped") && !status.HasNode(inst.InstanceID) {
+			pending = append(pending, inst.InstanceID)
+		}
+	}
+	var nodes []string
+	for _, id := range status.Nodes {
+		if known[id] {
+			nodes = append(nodes, id)
+		}
+	}
+	status.Nodes = nodes
+
+	computeService := r.provider.GetComputeService()
+	for _, id := range pending {
+		if err := computeService.SetInstanceProtection(ctx, id, spec.Behavior(), spec.StopProtected(), spec.DeletionProtected(cluster.Spec.Tags)); err != nil {
+			return fmt.Errorf("failed to set instance protection on %s: %w", id, err)
+		}
+		status.Nodes = append(status.Nodes, id)
+	}
+	if len(pending) > 0 {
+		now := time.Now()
+		status.AppliedAt = &now
+	}
+	return nil
+}
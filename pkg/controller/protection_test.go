@@ -0,0 +1,56 @@
+package controller
+
+import (
+	"testing"
+
+	"github.com/madhouselabs/goman/pkg/models"
+)
+
+func TestInstanceProtectionSpec(t *testing.T) {
+	var unset *models.InstanceProtectionSpec
+	if unset.Behavior() != models.ShutdownBehaviorStop || unset.StopProtected() {
+		t.Errorf("unset spec = %s, want stop without protection", unset.Key())
+	}
+	// An explicit default is the same setting as none
+	if explicit := (&models.InstanceProtectionSpec{ShutdownBehavior: "stop"}); explicit.Key() != unset.Key() {
+		t.Errorf("key %q differs from default %q", explicit.Key(), unset.Key())
+	}
+	prod := &models.InstanceProtectionSpec{StopProtection: true}
+	if prod.Key() == unset.Key() {
+		t.Error("stop protection does not change the key")
+	}
+
+	if err := models.ValidateInstanceProtection(&models.InstanceProtectionSpec{ShutdownBehavior: "terminate"}); err != nil {
+		t.Errorf("terminate rejected: %v", err)
+	}
+	if err := models.ValidateInstanceProtection(&models.InstanceProtectionSpec{ShutdownBehavior: "hibernate"}); err == nil {
+		t.Error("unknown shutdown behavior accepted")
+	}
+}
+
+func TestDeletionProtection(t *testing.T) {
+	var unset *models.InstanceProtectionSpec
+	if unset.DeletionProtected(nil) {
+		t.Error("untagged cluster protected against deletion")
+	}
+	for _, tags := range []map[string]string{{"Environment": "prod"}, {"env": "Production"}, {"Stage": "PROD"}} {
+		if !unset.DeletionProtected(tags) {
+			t.Errorf("cluster tagged %v not protected against deletion", tags)
+		}
+	}
+	if unset.DeletionProtected(map[string]string{"Environment": "staging", "Owner": "prod"}) {
+		t.Error("non-production tags protect against deletion")
+	}
+
+	off, on := false, true
+	if (&models.InstanceProtectionSpec{DeletionProtection: &off}).DeletionProtected(map[string]string{"Environment": "prod"}) {
+		t.Error("explicit setting overridden by the tags")
+	}
+	if !(&models.InstanceProtectionSpec{DeletionProtection: &on}).DeletionProtected(nil) {
+		t.Error("explicit setting ignored")
+	}
+	// Tagging a cluster production changes the settings applied to its nodes
+	if unset.AppliedKey(nil) == unset.AppliedKey(map[string]string{"Environment": "prod"}) {
+		t.Error("production tag does not change the applied key")
+	}
+}
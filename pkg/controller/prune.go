@@ -0,0 +1,260 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/madhouselabs/goman/pkg/logger"
+	"github.com/madhouselabs/goman/pkg/models"
+	"github.com/madhouselabs/goman/pkg/provider"
+	"github.com/madhouselabs/goman/pkg/storage"
+	"gopkg.in/yaml.v3"
+)
+
+// Kinds of artifacts pruned from the state bucket
+const (
+	PruneDiagnostics = "diagnostics"
+	PruneLogs        = "log"
+	PruneEvents      = "events"
+	PruneBinary      = "binary"
+)
+
+// PrunedObject is an object of the state bucket past its retention
+type PrunedObject struct {
+	Key      string    `json:"key"`
+	Kind     string    `json:"kind"`
+	Modified time.Time `json:"modified"`
+	Action   string    `json:"action"` // OrphanDeleted, OrphanWouldDelete or OrphanFailed
+	Error    string    `json:"error,omitempty"`
+}
+
+// PruneReport is the result of pruning the state bucket
+type PruneReport struct {
+	StartedAt time.Time                `json:"startedAt"`
+	DryRun    bool                     `json:"dryRun"`
+	Retention provider.RetentionConfig `json:"retention"`
+	Objects   []PrunedObject           `json:"objects"`
+	Errors    []string                 `json:"errors,omitempty"` // Listings that failed
+}
+
+// Count returns how many objects the pruning left with an action
+func (r *PruneReport) Count(action string) int {
+	n := 0
+	for _, obj := range r.Objects {
+		if obj.Action == action {
+			n++
+		}
+	}
+	return n
+}
+
+// PruneArtifacts deletes the objects of the state bucket past their
+// retention, unless dryRun is set: full command output, the command
+// history and session logs, the events of deleted clusters and the K3s
+// binaries of versions no cluster uses. Objects whose age is unknown are
+// kept. Lifecycle rules expire most of these too; this also cleans up
+// buckets without them and removes unused binaries, which rules can't
+// tell apart.
+func (r *Reconciler) PruneArtifacts(ctx context.Context, retention provider.RetentionConfig, dryRun bool) (*PruneReport, error) {
+	now := time.Now().UTC()
+	report := &PruneReport{StartedAt: now, DryRun: dryRun, Retention: retention, Objects: []PrunedObject{}}
+
+	existing, _, err := r.existingClusters(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var candidates []PrunedObject
+	expired := func(kind, prefix string, days int, keep func(key string) bool) {
+		if days <= 0 {
+			return
+		}
+		times, err := r.objectTimes(ctx, prefix)
+		if err != nil {
+			logger.Warnf(ctx, "[GC] Failed to list %s: %v", prefix, err)
+			report.Errors = append(report.Errors, fmt.Sprintf("%s: %v", prefix, err))
+			return
+		}
+		cutoff := now.AddDate(0, 0, -days)
+		for key, modified := range times {
+			if modified.IsZero() || !modified.Before(cutoff) || (keep != nil && keep(key)) {
+				continue
+			}
+			candidates = append(candidates, PrunedObject{Key: key, Kind: kind, Modified: modified})
+		}
+	}
+
+	expired(PruneDiagnostics, provider.DiagnosticsPrefix, retention.DiagnosticsDays, nil)
+	expired(PruneLogs, storage.CommandHistoryPrefix, retention.LogDays, nil)
+	expired(PruneLogs, r.sessionLogPrefix(ctx), retention.LogDays, nil)
+	expired(PruneEvents, storage.EventsPrefix, retention.LogDays, func(key string) bool {
+		name, _, _ := strings.Cut(strings.TrimPrefix(key, storage.EventsPrefix), "/")
+		return existing[name]
+	})
+	candidates = append(candidates, r.unusedBinaries(ctx, report, existing, now, retention.BinaryDays)...)
+
+	sort.Slice(candidates, func(i, j int) bool { return candidates[i].Key < candidates[j].Key })
+
+	storageService := r.provider.GetStorageService()
+	exists := make(map[string]bool)
+	for _, obj := range candidates {
+		// A cluster created during the pruning keeps its events
+		if obj.Kind == PruneEvents {
+			name, _, _ := strings.Cut(strings.TrimPrefix(obj.Key, storage.EventsPrefix), "/")
+			if _, checked := exists[name]; !checked {
+				exists[name] = r.clusterExists(ctx, name)
+			}
+			if exists[name] {
+				continue
+			}
+		}
+
+		switch {
+		case dryRun:
+			obj.Action = OrphanWouldDelete
+		default:
+			err := storageService.DeleteObject(ctx, obj.Key)
+			switch {
+			case err == nil, errors.Is(err, provider.ErrNotFound):
+				obj.Action = OrphanDeleted
+			default:
+				obj.Action = OrphanFailed
+				obj.Error = err.Error()
+				logger.Warnf(ctx, "[GC] Failed to prune %s: %v", obj.Key, err)
+			}
+		}
+		report.Objects = append(report.Objects, obj)
+	}
+
+	return report, nil
+}
+
+// unusedBinaries returns the K3s binaries of versions no cluster runs or is
+// upgrading to, when every binary of the version is older than days
+func (r *Reconciler) unusedBinaries(ctx context.Context, report *PruneReport, existing map[string]bool, now time.Time, days int) []PrunedObject {
+	if days <= 0 {
+		return nil
+	}
+	cutoff := now.AddDate(0, 0, -days)
+	times, err := r.objectTimes(ctx, provider.BinariesPrefix)
+	if err != nil {
+		logger.Warnf(ctx, "[GC] Failed to list %s: %v", provider.BinariesPrefix, err)
+		report.Errors = append(report.Errors, fmt.Sprintf("%s: %v", provider.BinariesPrefix, err))
+		return nil
+	}
+	inUse, err := r.k3sVersionsInUse(ctx, existing)
+	if err != nil {
+		report.Errors = append(report.Errors, err.Error())
+		return nil
+	}
+
+	byVersion := make(map[string][]PrunedObject)
+	recent := make(map[string]bool)
+	for key, modified := range times {
+		version, _, ok := strings.Cut(strings.TrimPrefix(key, provider.BinariesPrefix), "/")
+		if !ok || inUse[version] {
+			continue
+		}
+		if modified.IsZero() || !modified.Before(cutoff) {
+			recent[version] = true
+		}
+		byVersion[version] = append(byVersion[version], PrunedObject{Key: key, Kind: PruneBinary, Modified: modified})
+	}
+
+	var unused []PrunedObject
+	for version, objects := range byVersion {
+		if !recent[version] {
+			unused = append(unused, objects...)
+		}
+	}
+	return unused
+}
+
+// k3sVersionsInUse returns the K3s versions of the given clusters: the one
+// their spec asks for and the ones their nodes run, plus the default
+func (r *Reconciler) k3sVersionsInUse(ctx context.Context, clusters map[string]bool) (map[string]bool, error) {
+	storageService := r.provider.GetStorageService()
+	versions := map[string]bool{models.DefaultK3sVersion: true}
+	for name := range clusters {
+		data, err := storageService.GetObject(ctx, storage.ClusterConfigKey(name))
+		if errors.Is(err, provider.ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read config of cluster %s: %w", name, err)
+		}
+		var config storage.ClusterConfig
+		if err := yaml.Unmarshal(data, &config); err != nil {
+			// Its version is unknown, so no binary counts as unused
+			return nil, fmt.Errorf("failed to parse config of cluster %s: %w", name, err)
+		}
+		versions[config.Spec.K3sVersion] = true
+
+		data, err = storageService.GetObject(ctx, storage.ClusterStatusKey(name))
+		if errors.Is(err, provider.ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read status of cluster %s: %w", name, err)
+		}
+		var status models.ClusterResourceStatus
+		if err := yaml.Unmarshal(data, &status); err != nil {
+			return nil, fmt.Errorf("failed to parse status of cluster %s: %w", name, err)
+		}
+		for _, instance := range status.Instances {
+			versions[instance.K3sVersion] = true
+		}
+	}
+	return versions, nil
+}
+
+// objectTimes returns the last write time of the objects under prefix. On
+// storage that can't tell, it is read from keys starting with a timestamp,
+// such as events and command records; other objects get the zero time.
+func (r *Reconciler) objectTimes(ctx context.Context, prefix string) (map[string]time.Time, error) {
+	storageService := r.provider.GetStorageService()
+	if lister, ok := storageService.(provider.ObjectTimeLister); ok {
+		return lister.ListObjectTimes(ctx, prefix)
+	}
+	keys, err := storageService.ListObjects(ctx, prefix)
+	if err != nil {
+		return nil, err
+	}
+	times := make(map[string]time.Time, len(keys))
+	for _, key := range keys {
+		t, _ := storage.EventTime(key)
+		times[key] = t
+	}
+	return times, nil
+}
+
+// sessionLogPrefix returns the key prefix of the session logs
+func (r *Reconciler) sessionLogPrefix(ctx context.Context) string {
+	var cfg provider.SessionLoggingConfig
+	if data, err := r.provider.GetStorageService().GetObject(ctx, provider.SessionLoggingKey); err == nil {
+		if err := yaml.Unmarshal(data, &cfg); err != nil {
+			logger.Warnf(ctx, "[GC] Ignoring invalid session logging settings: %v", err)
+		}
+	}
+	return cfg.ClusterS3Prefix("") + "/"
+}
+
+// pruneArtifacts prunes the state bucket with the stored retention settings
+func (r *Reconciler) pruneArtifacts(ctx context.Context) {
+	retention, err := provider.LoadRetention(ctx, r.provider.GetStorageService())
+	if err != nil {
+		logger.Infof(ctx, "[GC] Not pruning the state bucket: %v", err)
+		return
+	}
+	report, err := r.PruneArtifacts(ctx, retention, false)
+	if err != nil {
+		logger.Warnf(ctx, "[GC] Pruning the state bucket failed: %v", err)
+		return
+	}
+	logger.Warnf(ctx, "[GC] Pruned the state bucket (%s): %d deleted, %d failed",
+		retention, report.Count(OrphanDeleted), report.Count(OrphanFailed))
+}
@@ -0,0 +1,91 @@
+package controller
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/madhouselabs/goman/pkg/provider"
+)
+
+// prunableStorage tells when objects were written and records deletions
+type prunableStorage struct {
+	orphanStorage
+	times   map[string]time.Time
+	deleted []string
+}
+
+func (s *prunableStorage) ListObjectTimes(ctx context.Context, prefix string) (map[string]time.Time, error) {
+	times := make(map[string]time.Time)
+	for key := range s.objects {
+		if strings.HasPrefix(key, prefix) {
+			times[key] = s.times[key]
+		}
+	}
+	return times, nil
+}
+
+func (s *prunableStorage) DeleteObject(ctx context.Context, key string) error {
+	delete(s.objects, key)
+	s.deleted = append(s.deleted, key)
+	return nil
+}
+
+func TestPruneArtifacts(t *testing.T) {
+	now := time.Now()
+	old, recent := now.AddDate(0, 0, -100), now.AddDate(0, 0, -1)
+	storage := &prunableStorage{orphanStorage: orphanStorage{objects: map[string][]byte{
+		"clusters/live/config.yaml": []byte("spec:\n  k3sVersion: v1.32.1+k3s1\n"),
+		"clusters/live/status.yaml": []byte("instances:\n- k3sVersion: v1.31.9+k3s1\n"),
+	}}, times: map[string]time.Time{}}
+	for key, modified := range map[string]time.Time{
+		"ssm-output/cmd-1/i-1/stdout":                         old,
+		"ssm-output/cmd-2/i-1/stdout":                         recent,
+		"commands/gone/20240101T000000.000000000Z-drain.yaml": old,
+		"events/gone/20240101T000000.000000000Z-Created.yaml": old,
+		"events/live/20240101T000000.000000000Z-Created.yaml": old,
+		"binaries/k3s/v1.30.1+k3s1/k3s-amd64":                 old,
+		"binaries/k3s/v1.30.2+k3s1/k3s-amd64":                 old,
+		"binaries/k3s/v1.30.2+k3s1/k3s-arm64":                 recent,
+		"binaries/k3s/v1.31.9+k3s1/k3s-amd64":                 old,
+		"binaries/k3s/v1.32.1+k3s1/k3s-amd64":                 old,
+	} {
+		storage.objects[key] = []byte("x")
+		storage.times[key] = modified
+	}
+	r := &Reconciler{provider: &fakeProvider{storage: storage, locks: orphanLocks{}, region: "us-east-1"}, settings: DefaultSettings()}
+	ctx := context.Background()
+
+	report, err := r.PruneArtifacts(ctx, provider.DefaultRetention(), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(storage.deleted) != 0 {
+		t.Errorf("dry run deleted %v", storage.deleted)
+	}
+
+	// Versions the live cluster asks for or runs are kept, and so is a
+	// version with a recent upload
+	want := []string{
+		"binaries/k3s/v1.30.1+k3s1/k3s-amd64",
+		"commands/gone/20240101T000000.000000000Z-drain.yaml",
+		"events/gone/20240101T000000.000000000Z-Created.yaml",
+		"ssm-output/cmd-1/i-1/stdout",
+	}
+	var keys []string
+	for _, obj := range report.Objects {
+		keys = append(keys, obj.Key)
+	}
+	if !slices.Equal(keys, want) || report.Count(OrphanWouldDelete) != len(want) {
+		t.Errorf("report %+v", report.Objects)
+	}
+
+	if _, err := r.PruneArtifacts(ctx, provider.RetentionConfig{LogDays: 30}, false); err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(storage.deleted, want[1:3]) {
+		t.Errorf("deleted %v", storage.deleted)
+	}
+}
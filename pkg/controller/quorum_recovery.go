@@ -0,0 +1,306 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/madhouselabs/goman/pkg/logger"
+	"github.com/madhouselabs/goman/pkg/models"
+	"github.com/madhouselabs/goman/pkg/provider"
+)
+
+// quorumRecoveryTimeout bounds how long the replacement masters may take to
+// join the reset survivor before the recovery is reported as failed
+const quorumRecoveryTimeout = 30 * time.Minute
+
+// clusterResetScript resets the etcd of the surviving master to a single
+// member, from the newest local snapshot when the placeholder is "latest".
+// The survivor stops referring to the lost masters as its server. K3s exits
+// after the reset whatever its status, so success is judged by the API
+// server coming back. The last line of output names the restored snapshot.
+const clusterResetScript = `set -e
+SNAPSHOTS=/var/lib/rancher/k3s/server/db/snapshots
+SNAPSHOT=""
+if [ "%s" = "latest" ]; then
+    SNAPSHOT=$(ls -1t "$SNAPSHOTS" 2>/dev/null | head -n 1)
+fi
+systemctl stop k3s
+sed -i -E 's| --server=https://[^ ]+:6443||' /etc/systemd/system/k3s.service
+sed -i '/^MASTER_IP=/d' /etc/systemd/system/k3s.service.env 2>/dev/null || true
+systemctl daemon-reload
+TOKEN=$(cat /var/lib/rancher/k3s/server/token)
+if [ -n "$SNAPSHOT" ]; then
+    k3s server --cluster-reset --token="$TOKEN" --cluster-reset-restore-path="$SNAPSHOTS/$SNAPSHOT" || true
+else
+    k3s server --cluster-reset --token="$TOKEN" || true
+fi
+systemctl start k3s
+for i in $(seq 1 90); do
+    if k3s kubectl get --raw=/readyz >/dev/null 2>&1; then
+        echo "snapshot=$SNAPSHOT"
+        exit 0
+    fi
+    sleep 2
+done
+echo "API server not ready after the cluster reset" >&2
+exit 1
+`
+
+// reconcileQuorumRecovery rebuilds an HA control plane that lost etcd quorum
+// from one surviving master once recovery was requested:
+//
+//	Resetting -> Replacing -> Rejoining -> Completed
+//
+// The survivor's etcd is reset to a single member, restored from its newest
+// snapshot unless the request keeps its own data. Every other master is
+// terminated and launched again under its name, joining the survivor. A
+// request on a quorate control plane is refused: a reset would throw away
+// the healthy members. Returns true while a recovery is in progress.
+func (r *Reconciler) reconcileQuorumRecovery(ctx context.Context, cluster *models.ClusterResource, quorate bool) (bool, error) {
+	req := cluster.Spec.QuorumRecovery
+	if req == nil {
+		return false, nil
+	}
+	st := cluster.Status.QuorumRecovery
+	if st == nil || (st.Done() && st.RequestedAt.Before(req.RequestedAt)) {
+		st = r.startQuorumRecovery(ctx, cluster, req, quorate)
+		cluster.Status.QuorumRecovery = st
+	}
+	if st.Done() {
+		return false, nil
+	}
+
+	err := r.advanceQuorumRecovery(ctx, cluster, req, st)
+	logger.Infof(ctx, "[QUORUM] Cluster %s: %s", cluster.Name, st)
+	cluster.Status.Message = fmt.Sprintf("Recovering etcd quorum: %s", st)
+	if err != nil {
+		return false, err
+	}
+	// Requeue to follow the replacement masters, or to check etcd health
+	// once the recovery has finished
+	return true, nil
+}
+
+// startQuorumRecovery returns the status of a new recovery request, failed
+// right away when there is nothing to recover or nothing to recover from
+func (r *Reconciler) startQuorumRecovery(ctx context.Context, cluster *models.ClusterResource, req *models.QuorumRecoveryRequest, quorate bool) *models.QuorumRecoveryStatus {
+	st := &models.QuorumRecoveryStatus{
+		RequestedAt: req.RequestedAt,
+		Phase:       models.QuorumRecoveryResetting,
+		StartedAt:   time.Now(),
+	}
+
+	var masters []models.InstanceStatus
+	for _, inst := range cluster.Status.Instances {
+		if inst.Role == "master" {
+			masters = append(masters, inst)
+		}
+	}
+	switch {
+	case len(masters) <= 1:
+		failQuorumRecovery(st, "the control plane has a single master; there is no etcd quorum to recover")
+		return st
+	case quorate:
+		failQuorumRecovery(st, "etcd has quorum; recovery would discard the data of the healthy members")
+		return st
+	}
+
+	survivor := selectSurvivingMaster(masters, cluster.Status.PreferredMasterInstance)
+	if survivor == nil {
+		failQuorumRecovery(st, "no master is running to recover from; start one of the masters first")
+		return st
+	}
+	st.Survivor = survivor.InstanceID
+	st.SurvivorName = survivor.Name
+	for _, m := range masters {
+		if m.InstanceID != survivor.InstanceID {
+			st.Replaced = append(st.Replaced, m.Name)
+		}
+	}
+	logger.Infof(ctx, "[QUORUM] Recovering etcd of cluster %s from %s, replacing %s", cluster.Name, survivor.Name, strings.Join(st.Replaced, ", "))
+	return st
+}
+
+// advanceQuorumRecovery moves a recovery through as many phases as it can
+// without waiting
+func (r *Reconciler) advanceQuorumRecovery(ctx context.Context, cluster *models.ClusterResource, req *models.QuorumRecoveryRequest, st *models.QuorumRecoveryStatus) error {
+	inst := upgradeNodeStatus(cluster, st.Survivor)
+	if inst == nil {
+		failQuorumRecovery(st, fmt.Sprintf("surviving master %s is gone", st.SurvivorName))
+		return nil
+	}
+	survivor := *inst
+
+	if st.Phase == models.QuorumRecoveryResetting {
+		restore := req.Restore
+		if restore == "" {
+			restore = models.QuorumRestoreLatest
+		}
+		logger.Infof(ctx, "[QUORUM] Resetting etcd on %s (restore: %s)", survivor.Name, restore)
+		output, err := r.runOnMaster(ctx, survivor, "cluster-reset", fmt.Sprintf(clusterResetScript, restore), false)
+		if err != nil {
+			failQuorumRecovery(st, fmt.Sprintf("cluster reset on %s failed: %v", survivor.Name, err))
+			return nil
+		}
+		st.Snapshot = parseResetSnapshot(output)
+		cluster.Status.PreferredMasterInstance = survivor.InstanceID
+		st.Phase = models.QuorumRecoveryReplacing
+	}
+	if st.Phase == models.QuorumRecoveryReplacing {
+		if err := r.replaceLostMasters(ctx, cluster, survivor, st); err != nil {
+			return err
+		}
+		st.Phase = models.QuorumRecoveryRejoining
+	}
+	if st.Phase == models.QuorumRecoveryRejoining {
+		return r.waitForRejoinedMasters(ctx, cluster, survivor, st)
+	}
+	return nil
+}
+
+// replaceLostMasters terminates every master but the survivor and launches
+// it again under the same name, joining the survivor. Masters already
+// replaced are skipped, so a failed pass can be retried.
+func (r *Reconciler) replaceLostMasters(ctx context.Context, cluster *models.ClusterResource, survivor models.InstanceStatus, st *models.QuorumRecoveryStatus) error {
+	computeService := r.provider.GetComputeService()
+
+	for _, name := range st.Replaced {
+		replaced := false
+		for _, inst := range cluster.Status.Instances {
+			if inst.Name != name {
+				continue
+			}
+			if slices.Contains(st.Replacements, inst.InstanceID) {
+				replaced = true
+				continue
+			}
+
+			// The old member must not come back with its stale etcd data
+			if inst.State == "running" {
+				if _, err := r.runCommand(ctx, "stop-k3s", []string{inst.InstanceID}, "systemctl disable --now k3s || true"); err != nil {
+					logger.Warnf(ctx, "[QUORUM] Warning: Failed to stop k3s on %s: %v", inst.Name, err)
+				}
+			}
+			if inst.PrivateIP != "" {
+				deleteCmd := fmt.Sprintf("kubectl delete node %s --ignore-not-found", r.k3sNodeName(inst.PrivateIP))
+				if _, err := r.runCommand(ctx, "delete-lost-master", []string{survivor.InstanceID}, deleteCmd); err != nil {
+					logger.Warnf(ctx, "[QUORUM] Warning: Failed to delete node of %s: %v", inst.Name, err)
+				}
+			}
+			if err := computeService.DeleteInstance(ctx, inst.InstanceID); err != nil && !errors.Is(err, provider.ErrNotFound) {
+				recordOperationError(ctx, cluster, err)
+				return fmt.Errorf("failed to terminate lost master %s: %w", inst.InstanceID, err)
+			}
+			logger.Infof(ctx, "[QUORUM] Terminated lost master %s (%s)", inst.Name, inst.InstanceID)
+			removeInstanceStatus(cluster, inst.InstanceID)
+		}
+		if replaced {
+			continue
+		}
+
+		instanceConfig := masterInstanceConfig(cluster, name, map[string]string{
+			"goman-index":     fmt.Sprintf("%d", extractWorkerIndex(name)),
+			"goman-master-ip": survivor.PrivateIP,
+		})
+		instance, err := computeService.CreateInstance(ctx, instanceConfig)
+		if err != nil {
+			recordOperationError(ctx, cluster, err)
+			return fmt.Errorf("failed to create replacement master %s: %w", name, err)
+		}
+		logger.Infof(ctx, "[QUORUM] Created replacement master %s (%s) joining %s", name, instance.ID, survivor.Name)
+		r.recordInstanceProvisioned(ctx, cluster.Name, instanceConfig, instance)
+
+		cluster.Status.Instances = append(cluster.Status.Instances, models.InstanceStatus{
+			InstanceID: instance.ID,
+			Name:       name,
+			Role:       "master",
+			State:      instance.State,
+			LaunchTime: time.Now(),
+
+			AvailabilityZone: instance.AvailabilityZone,
+		})
+		st.Replacements = append(st.Replacements, instance.ID)
+	}
+
+	cluster.Status.MasterInstanceIDs = append([]string{survivor.InstanceID}, st.Replacements...)
+	return nil
+}
+
+// waitForRejoinedMasters completes the recovery once every replacement
+// master is running and its node is a Ready etcd member
+func (r *Reconciler) waitForRejoinedMasters(ctx context.Context, cluster *models.ClusterResource, survivor models.InstanceStatus, st *models.QuorumRecoveryStatus) error {
+	computeService := r.provider.GetComputeService()
+
+	joining := false
+	var nodes []string
+	for _, id := range st.Replacements {
+		instance, err := computeService.GetInstance(ctx, id)
+		if err != nil {
+			return fmt.Errorf("failed to get replacement master %s: %w", id, err)
+		}
+		if instance.State != "running" && instance.State != "pending" {
+			failQuorumRecovery(st, fmt.Sprintf("replacement master %s is %s", instance.Name, instance.State))
+			return nil
+		}
+		if inst := upgradeNodeStatus(cluster, id); inst != nil {
+			inst.State = instance.State
+			inst.PrivateIP = instance.PrivateIP
+			inst.PublicIP = instance.PublicIP
+		}
+		if instance.State != "running" || instance.PrivateIP == "" {
+			joining = true
+			continue
+		}
+		nodes = append(nodes, r.k3sNodeName(instance.PrivateIP))
+	}
+
+	if !joining {
+		result, err := r.runCommand(ctx, "etcd-members", []string{survivor.InstanceID}, etcdMembersCmd)
+		if err != nil {
+			return fmt.Errorf("failed to list etcd members from %s: %w", survivor.Name, err)
+		}
+		var ready map[string]bool
+		if res := result.Instances[survivor.InstanceID]; res != nil && res.Status == "Success" {
+			ready = parseEtcdMembers(res.Output)
+		}
+		for _, node := range nodes {
+			if !ready[node] {
+				joining = true
+			}
+		}
+	}
+
+	if !joining {
+		now := time.Now()
+		st.Phase = models.QuorumRecoveryCompleted
+		st.CompletedAt = &now
+		st.Message = ""
+		return nil
+	}
+	if time.Since(st.StartedAt) > quorumRecoveryTimeout {
+		failQuorumRecovery(st, fmt.Sprintf("replacement masters did not join within %s", quorumRecoveryTimeout))
+	}
+	return nil
+}
+
+// parseResetSnapshot returns the snapshot clusterResetScript restored, or ""
+func parseResetSnapshot(output string) string {
+	for _, line := range strings.Split(output, "\n") {
+		if name, ok := strings.CutPrefix(strings.TrimSpace(line), "snapshot="); ok {
+			return name
+		}
+	}
+	return ""
+}
+
+// failQuorumRecovery marks a recovery as failed
+func failQuorumRecovery(st *models.QuorumRecoveryStatus, reason string) {
+	now := time.Now()
+	st.Phase = models.QuorumRecoveryFailed
+	st.CompletedAt = &now
+	st.Message = reason
+}
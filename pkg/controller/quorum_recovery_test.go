@@ -0,0 +1,78 @@
+package controller
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/madhouselabs/goman/pkg/models"
+)
+
+func TestStartQuorumRecovery(t *testing.T) {
+	masters := func(states ...string) []models.InstanceStatus {
+		var instances []models.InstanceStatus
+		for i, state := range states {
+			instances = append(instances, models.InstanceStatus{
+				InstanceID: "i-" + string(rune('a'+i)),
+				Name:       "c-master-" + string(rune('0'+i)),
+				Role:       "master",
+				State:      state,
+			})
+		}
+		return append(instances, models.InstanceStatus{InstanceID: "i-w", Name: "c-worker-default-0", Role: "worker", State: "running"})
+	}
+	req := &models.QuorumRecoveryRequest{RequestedAt: time.Now()}
+
+	tests := []struct {
+		name         string
+		instances    []models.InstanceStatus
+		quorate      bool
+		wantPhase    models.QuorumRecoveryPhase
+		wantSurvivor string
+		wantReplaced []string
+	}{
+		{"two of three lost", masters("terminated", "running", "stopped"), false, models.QuorumRecoveryResetting, "c-master-1", []string{"c-master-0", "c-master-2"}},
+		{"first master survives", masters("running", "running", "terminated"), false, models.QuorumRecoveryResetting, "c-master-0", []string{"c-master-1", "c-master-2"}},
+		{"quorum intact", masters("running", "running", "stopped"), true, models.QuorumRecoveryFailed, "", nil},
+		{"no survivor", masters("stopped", "terminated", "stopped"), false, models.QuorumRecoveryFailed, "", nil},
+		{"single master", masters("running"), true, models.QuorumRecoveryFailed, "", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cluster := &models.ClusterResource{}
+			cluster.Status.Instances = tt.instances
+			st := (&Reconciler{}).startQuorumRecovery(context.Background(), cluster, req, tt.quorate)
+			if st.Phase != tt.wantPhase {
+				t.Fatalf("phase = %s, want %s (%s)", st.Phase, tt.wantPhase, st.Message)
+			}
+			if st.SurvivorName != tt.wantSurvivor {
+				t.Errorf("survivor = %q, want %q", st.SurvivorName, tt.wantSurvivor)
+			}
+			if !reflect.DeepEqual(st.Replaced, tt.wantReplaced) {
+				t.Errorf("replaced = %v, want %v", st.Replaced, tt.wantReplaced)
+			}
+		})
+	}
+}
+
+func TestParseResetSnapshot(t *testing.T) {
+	tests := map[string]string{
+		"snapshot=etcd-snapshot-ip-10-0-1-5-1760572800\n": "etcd-snapshot-ip-10-0-1-5-1760572800",
+		"some k3s output\nsnapshot=\n":                    "",
+		"":                                                "",
+	}
+	for output, want := range tests {
+		if got := parseResetSnapshot(output); got != want {
+			t.Errorf("parseResetSnapshot(%q) = %q, want %q", output, got, want)
+		}
+	}
+}
+
+func TestValidateQuorumRestore(t *testing.T) {
+	for restore, ok := range map[string]bool{"": true, "latest": true, "none": true, "oldest": false} {
+		if err := models.ValidateQuorumRestore(restore); (err == nil) != ok {
+			t.Errorf("ValidateQuorumRestore(%q) = %v, want ok %v", restore, err, ok)
+		}
+	}
+}
@@ -0,0 +1,165 @@
+package controller
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/madhouselabs/goman/pkg/logger"
+	"github.com/madhouselabs/goman/pkg/models"
+	"github.com/madhouselabs/goman/pkg/provider"
+)
+
+// readOnlyAccount is the ServiceAccount behind the read-only kubeconfig. It
+// is bound to the built-in view ClusterRole, which excludes secrets.
+const readOnlyAccount = "goman-viewer"
+
+// readOnlyTokenScript ensures the viewer ServiceAccount exists and prints
+// the cluster CA and a fresh token for it, one "<key> <value>" line each
+const readOnlyTokenScript = `set -e
+cat <<'EOF' | k3s kubectl apply -f - >/dev/null
+apiVersion: v1
+kind: ServiceAccount
+metadata:
+  name: goman-viewer
+  namespace: kube-system
+---
+apiVersion: rbac.authorization.k8s.io/v1
+kind: ClusterRoleBinding
+metadata:
+  name: goman-viewer
+roleRef:
+  apiGroup: rbac.authorization.k8s.io
+  kind: ClusterRole
+  name: view
+subjects:
+  - kind: ServiceAccount
+    name: goman-viewer
+    namespace: kube-system
+EOF
+echo "ca $(grep certificate-authority-data /etc/rancher/k3s/k3s.yaml | awk '{print $2}')"
+echo "token $(k3s kubectl -n kube-system create token goman-viewer --duration=%ds)"
+`
+
+var kubeconfigServerValuePattern = regexp.MustCompile(`(?m)^\s*server:\s*(\S+)$`)
+
+// reconcileReadOnlyKubeconfig keeps a view-only kubeconfig next to the admin
+// one, so access can be handed out without cluster admin rights. Its token
+// is reissued once less than a third of the configured TTL is left.
+func (r *Reconciler) reconcileReadOnlyKubeconfig(ctx context.Context, cluster *models.ClusterResource) error {
+	ttl := r.settings.ReadOnlyTokenTTL
+	if ttl == 0 {
+		return nil
+	}
+	if expiresAt := cluster.Status.ReadOnlyKubeconfigExpiresAt; expiresAt != nil && time.Until(*expiresAt) > ttl/3 {
+		return nil
+	}
+
+	var master *models.InstanceStatus
+	for i, inst := range cluster.Status.Instances {
+		if inst.Role == "master" && inst.State == "running" {
+			master = &cluster.Status.Instances[i]
+			break
+		}
+	}
+	if master == nil {
+		return fmt.Errorf("no running master to issue a read-only token on")
+	}
+
+	// The read-only kubeconfig reaches the API the same way as the admin one
+	secretService := r.provider.GetSecretService()
+	admin, err := secretService.GetSecret(ctx, cluster.Name, provider.SecretKubeconfig)
+	if err != nil {
+		return fmt.Errorf("failed to read kubeconfig: %w", err)
+	}
+	m := kubeconfigServerValuePattern.FindSubmatch(admin)
+	if m == nil {
+		return fmt.Errorf("stored kubeconfig has no server")
+	}
+	server := string(m[1])
+
+	output, err := r.runOnMaster(ctx, *master, "issue-read-only-token", fmt.Sprintf(readOnlyTokenScript, int(ttl.Seconds())), true)
+	if err != nil {
+		return fmt.Errorf("failed to issue read-only token on %s: %w", master.Name, err)
+	}
+	ca, token, err := parseReadOnlyToken(output)
+	if err != nil {
+		return err
+	}
+	expiresAt, err := tokenExpiry(token)
+	if err != nil {
+		return err
+	}
+
+	kubeconfig := readOnlyKubeconfig(cluster.Name, server, ca, token)
+	if err := secretService.PutSecret(ctx, cluster.Name, provider.SecretKubeconfigReadOnly, []byte(kubeconfig)); err != nil {
+		return fmt.Errorf("failed to save read-only kubeconfig: %w", err)
+	}
+	cluster.Status.ReadOnlyKubeconfigExpiresAt = expiresAt
+	logger.Infof(ctx, "[KUBECONFIG] Issued read-only kubeconfig for cluster %s, valid until %s", cluster.Name, expiresAt.Format(time.RFC3339))
+	return nil
+}
+
+// parseReadOnlyToken parses the output of readOnlyTokenScript
+func parseReadOnlyToken(output string) (ca, token string, err error) {
+	for _, line := range strings.Split(strings.TrimSpace(output), "\n") {
+		key, value, _ := strings.Cut(strings.TrimSpace(line), " ")
+		switch key {
+		case "ca":
+			ca = strings.TrimSpace(value)
+		case "token":
+			token = strings.TrimSpace(value)
+		}
+	}
+	if ca == "" || token == "" {
+		return "", "", fmt.Errorf("unexpected output issuing read-only token: %q", output)
+	}
+	return ca, token, nil
+}
+
+// tokenExpiry returns the exp claim of a ServiceAccount token
+func tokenExpiry(token string) (*time.Time, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("read-only token is not a JWT")
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, fmt.Errorf("invalid read-only token: %w", err)
+	}
+	var claims struct {
+		Exp int64 `json:"exp"`
+	}
+	if err := json.Unmarshal(payload, &claims); err != nil || claims.Exp == 0 {
+		return nil, fmt.Errorf("read-only token has no expiry")
+	}
+	expiresAt := time.Unix(claims.Exp, 0).UTC()
+	return &expiresAt, nil
+}
+
+// readOnlyKubeconfig renders a kubeconfig authenticating with a token
+func readOnlyKubeconfig(clusterName, server, ca, token string) string {
+	user := clusterName + "-" + readOnlyAccount
+	return fmt.Sprintf(`apiVersion: v1
+kind: Config
+clusters:
+- cluster:
+    certificate-authority-data: %s
+    server: %s
+  name: %s
+contexts:
+- context:
+    cluster: %s
+    user: %s
+  name: %s
+current-context: %s
+users:
+- name: %s
+  user:
+    token: %s
+`, ca, server, clusterName, clusterName, user, clusterName, clusterName, user, token)
+}
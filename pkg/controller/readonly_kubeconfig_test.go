@@ -0,0 +1,35 @@
+package controller
+
+import (
+	"encoding/base64"
+	"testing"
+	"time"
+)
+
+func TestParseReadOnlyToken(t *testing.T) {
+	payload := base64.RawURLEncoding.EncodeToString([]byte(`{"exp":1767225600,"sub":"system:serviceaccount:kube-system:goman-viewer"}`))
+	token := "eyJhbGciOiJSUzI1NiJ9." + payload + ".c2ln"
+
+	ca, got, err := parseReadOnlyToken("ca Q0FEQVRB\ntoken " + token + "\n")
+	if err != nil {
+		t.Fatalf("parseReadOnlyToken: %v", err)
+	}
+	if ca != "Q0FEQVRB" || got != token {
+		t.Fatalf("got ca %q token %q", ca, got)
+	}
+
+	expiresAt, err := tokenExpiry(got)
+	if err != nil {
+		t.Fatalf("tokenExpiry: %v", err)
+	}
+	if want := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC); !expiresAt.Equal(want) {
+		t.Errorf("expiry = %s, want %s", expiresAt, want)
+	}
+
+	if _, _, err := parseReadOnlyToken("ca \ntoken \n"); err == nil {
+		t.Error("expected an error for empty output")
+	}
+	if _, err := tokenExpiry("not-a-token"); err == nil {
+		t.Error("expected an error for a malformed token")
+	}
+}
@@ -4,13 +4,15 @@ import (
 	"context"
 	"crypto/rand"
 	"encoding/base64"
+	"errors"
 	"fmt"
-	"log"
 	"sort"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
+	"github.com/madhouselabs/goman/pkg/logger"
 	"github.com/madhouselabs/goman/pkg/models"
 	"github.com/madhouselabs/goman/pkg/provider"
 )
@@ -19,6 +21,22 @@ import (
 type Reconciler struct {
 	provider provider.Provider
 	owner    string
+	settings Settings
+
+	// Status snapshots taken at load time, used to merge concurrent updates
+	snapshotsMu sync.Mutex
+	snapshots   map[string]*models.ClusterResourceStatus
+
+	// Cloud service health by service, so failing reconciles don't each
+	// query it
+	healthMu sync.Mutex
+	health   map[string]serviceHealth
+
+	events   *EventRecorder
+	notifier *Notifier
+
+	// Set by goman debug reconcile
+	debug DebugOptions
 }
 
 // NewReconciler creates a new simple reconciler
@@ -34,9 +52,26 @@ func NewReconciler(prov provider.Provider, owner string) (*Reconciler, error) {
 	return &Reconciler{
 		provider: prov,
 		owner:    owner,
+		settings: DefaultSettings(),
+		events:   NewEventRecorder(prov.GetStorageService(), owner),
+		notifier: NewNotifier(prov.GetNotificationService()),
 	}, nil
 }
 
+// SetSettings replaces the reconciler timings after validating them
+func (r *Reconciler) SetSettings(settings Settings) error {
+	if err := settings.Validate(); err != nil {
+		return err
+	}
+	r.settings = settings
+	return nil
+}
+
+// Settings returns the reconciler timings in effect
+func (r *Reconciler) Settings() Settings {
+	return r.settings
+}
+
 // ReconcileCluster reconciles a cluster with simple linear flow
 func (r *Reconciler) ReconcileCluster(ctx context.Context, clusterName string) (*models.ReconcileResult, error) {
 	return r.ReconcileClusterWithRequestID(ctx, clusterName, "unknown")
@@ -44,72 +79,160 @@ func (r *Reconciler) ReconcileCluster(ctx context.Context, clusterName string) (
 
 // ReconcileClusterWithRequestID reconciles a cluster with request tracking
 func (r *Reconciler) ReconcileClusterWithRequestID(ctx context.Context, clusterName string, requestID string) (*models.ReconcileResult, error) {
-	log.Printf("[RECONCILE] Starting reconciliation for cluster %s (request: %s)", clusterName, requestID)
+	ctx = logger.With(ctx, "cluster", clusterName, "requestID", requestID)
+	logger.Infof(ctx, "[RECONCILE] Starting reconciliation for cluster %s (request: %s)", clusterName, requestID)
 
-	// Create timeout context (14 minutes to be safe within Lambda limit)
-	reconcileCtx, cancel := context.WithTimeout(ctx, 14*time.Minute)
+	// Create timeout context (kept within the Lambda limit by settings validation)
+	reconcileCtx, cancel := context.WithTimeout(withCommandCluster(ctx, clusterName), r.settings.ReconcileTimeout)
 	defer cancel()
 
 	// Acquire distributed lock
 	resourceID := fmt.Sprintf("cluster-%s", clusterName)
 	lockToken, err := r.acquireLock(reconcileCtx, resourceID)
 	if err != nil {
-		log.Printf("[RECONCILE] Failed to acquire lock: %v", err)
-		return &models.ReconcileResult{Requeue: true, RequeueAfter: 30 * time.Second}, nil
+		logger.Warnf(reconcileCtx, "[RECONCILE] Failed to acquire lock: %v", err)
+		return &models.ReconcileResult{Requeue: true, RequeueAfter: r.settings.LockBusyRequeue}, nil
 	}
 	defer r.releaseLock(reconcileCtx, resourceID, lockToken)
 
 	// Load cluster configuration and status
 	cluster, err := r.loadCluster(reconcileCtx, clusterName)
 	if err != nil {
-		if strings.Contains(err.Error(), "not found") {
-			log.Printf("[RECONCILE] Cluster %s not found, skipping", clusterName)
+		if errors.Is(err, provider.ErrNotFound) {
+			logger.Infof(reconcileCtx, "[RECONCILE] Cluster %s not found, skipping", clusterName)
 			return &models.ReconcileResult{Requeue: false}, nil
 		}
-		log.Printf("[RECONCILE] Failed to load cluster: %v", err)
-		return &models.ReconcileResult{Requeue: true, RequeueAfter: 1 * time.Minute}, nil
+		logger.Warnf(reconcileCtx, "[RECONCILE] Failed to load cluster: %v", err)
+		if provider.Categorize(err) == provider.CategoryUserConfig {
+			// Nothing to reconcile until the spec is fixed; tell the user why
+			r.reportFailure(reconcileCtx, clusterName, err)
+			return &models.ReconcileResult{Requeue: true, RequeueAfter: r.settings.failureRequeue(provider.CategoryUserConfig)}, nil
+		}
+		return &models.ReconcileResult{Requeue: true, RequeueAfter: r.settings.LoadErrorRequeue}, nil
 	}
 
+	// Records tell the phase the reconcile started in
+	reconcileCtx = logger.With(reconcileCtx, "phase", cluster.Status.Phase)
+
+	// Instance calls go to the regions the instances were recorded in
+	r.recordInstanceRegions(cluster)
+
 	// Handle deletion if requested
 	if cluster.DeletionTimestamp != nil {
 		return r.handleDeletion(reconcileCtx, cluster)
 	}
 
+	// Hold new and edited specs to the organization policy
+	if err := r.enforceOrgPolicy(reconcileCtx, cluster); err != nil {
+		if provider.Categorize(err) == provider.CategoryUserConfig {
+			r.reportFailure(reconcileCtx, clusterName, err)
+			return &models.ReconcileResult{Requeue: true, RequeueAfter: r.settings.failureRequeue(provider.CategoryUserConfig)}, nil
+		}
+		logger.Warnf(reconcileCtx, "[RECONCILE] Failed to check organization policy: %v", err)
+		return &models.ReconcileResult{Requeue: true, RequeueAfter: r.settings.LoadErrorRequeue}, nil
+	}
+
+	// Act on the latest spec only, covering all edits queued up to it
+	if !r.takeIntents(reconcileCtx, cluster, requestID) {
+		return &models.ReconcileResult{Requeue: true, RequeueAfter: r.settings.StaleSpecRequeue}, nil
+	}
+
+	// Status rebuilt from the live state on request, before acting on it
+	if resyncRequested(cluster) {
+		if err := r.resyncStatus(reconcileCtx, cluster); err != nil {
+			logger.Warnf(reconcileCtx, "[RECONCILE] Failed to resync status of cluster %s: %v", clusterName, err)
+			r.reportFailure(reconcileCtx, clusterName, fmt.Errorf("status resync failed: %w", err))
+			return &models.ReconcileResult{Requeue: true, RequeueAfter: r.settings.failureRequeue(provider.Categorize(err))}, nil
+		}
+	}
+
+	// Scheduled stops and starts override the desired state
+	r.applySchedule(reconcileCtx, cluster, time.Now())
+	r.forceDebugPhase(reconcileCtx, cluster)
+
+	// Deletion verifies these cleanups before removing the cluster's records
+	cluster.AddFinalizers()
+
+	// Entries left behind by crashed invocations are expired before acting
+	r.collectPendingOperations(reconcileCtx, cluster, time.Now())
+
 	// Execute reconciliation based on current phase
+	previousPhase := cluster.Status.Phase
 	needsRequeue, err := r.reconcileCluster(reconcileCtx, cluster)
 	if err != nil {
-		log.Printf("[RECONCILE] Reconciliation failed: %v", err)
-		cluster.Status.Phase = string(models.ClusterPhaseFailed)
+		category := provider.Categorize(err)
+		logger.Errorf(reconcileCtx, "[RECONCILE] Reconciliation failed (%s): %v", category, err)
+		r.recordFailure(reconcileCtx, cluster, category, err)
+		recordOperationError(reconcileCtx, cluster, err)
+		cluster.Status.Reason = string(category)
 		cluster.Status.Message = err.Error()
+		if degraded := r.noteCloudFailure(reconcileCtx, cluster, err); degraded != nil {
+			// The phase is kept: the cluster waits for the cloud provider
+			// to recover, not for a fix
+			cluster.Status.Message = degraded.Message
+			if cluster.Status.CloudFailures == r.settings.CloudDegradedAfter {
+				r.events.Warning(reconcileCtx, cluster.Name, EventCloudDegraded, "", "%s", degraded.Message)
+			}
+		} else if !stoppingOrStarting(cluster) {
+			// Stops and starts are retried in their phase instead, since
+			// failed clusters are provisioned again
+			cluster.Status.Phase = string(models.ClusterPhaseFailed)
+		}
+		cluster.Status.EstimatedCompletion = nil
+		r.recordPhaseChange(reconcileCtx, cluster, previousPhase)
+		r.notifyPhaseChange(reconcileCtx, cluster, previousPhase)
 		r.saveCluster(reconcileCtx, cluster)
-		return &models.ReconcileResult{Requeue: true, RequeueAfter: 2 * time.Minute}, nil
+		return &models.ReconcileResult{Requeue: true, RequeueAfter: r.settings.failureRequeue(category)}, nil
+	}
+
+	cluster.Status.Reason = ""
+	clearCloudFailures(cluster)
+	r.trackProgress(reconcileCtx, cluster, previousPhase)
+	r.recordPhaseChange(reconcileCtx, cluster, previousPhase)
+	r.notifyPhaseChange(reconcileCtx, cluster, previousPhase)
+
+	// The spec is fully processed once the cluster runs, or is stopped as
+	// asked, and needs no further pass
+	settled := cluster.Status.Phase == string(models.ClusterPhaseRunning) ||
+		(cluster.Status.Phase == string(models.ClusterPhaseStopped) && cluster.Spec.DesiredState == DesiredStateStopped)
+	if settled && !needsRequeue {
+		cluster.Status.ObservedGeneration = cluster.Generation
 	}
 
 	// Save final state
 	err = r.saveCluster(reconcileCtx, cluster)
 	if err != nil {
-		log.Printf("[RECONCILE] Failed to save cluster state: %v", err)
-		return &models.ReconcileResult{Requeue: true, RequeueAfter: 30 * time.Second}, nil
+		logger.Errorf(reconcileCtx, "[RECONCILE] Failed to save cluster state: %v", err)
+		return &models.ReconcileResult{Requeue: true, RequeueAfter: r.settings.SaveErrorRequeue}, nil
 	}
 
 	// Check if we need to requeue for further processing
 	if cluster.Status.Phase == string(models.ClusterPhaseRunning) {
 		if needsRequeue {
-			log.Printf("[RECONCILE] Cluster %s is running but needs requeue (cleanup happened)", clusterName)
-			return &models.ReconcileResult{Requeue: true, RequeueAfter: 15 * time.Second}, nil
+			logger.Infof(reconcileCtx, "[RECONCILE] Cluster %s is running but needs requeue (cleanup happened)", clusterName)
+			return &models.ReconcileResult{Requeue: true, RequeueAfter: r.settings.CleanupRequeue}, nil
 		}
-		log.Printf("[RECONCILE] Cluster %s is ready", clusterName)
+		logger.Infof(reconcileCtx, "[RECONCILE] Cluster %s is ready", clusterName)
+		return &models.ReconcileResult{Requeue: false}, nil
+	}
+	if settled {
+		logger.Infof(reconcileCtx, "[RECONCILE] Cluster %s is stopped", clusterName)
 		return &models.ReconcileResult{Requeue: false}, nil
 	}
 
-	log.Printf("[RECONCILE] Cluster %s phase: %s, requeuing", clusterName, cluster.Status.Phase)
-	return &models.ReconcileResult{Requeue: true, RequeueAfter: 30 * time.Second}, nil
+	logger.Infof(reconcileCtx, "[RECONCILE] Cluster %s phase: %s, requeuing", clusterName, cluster.Status.Phase)
+	return &models.ReconcileResult{Requeue: true, RequeueAfter: r.settings.ProgressRequeue}, nil
 }
 
 // reconcileCluster performs the main reconciliation logic
 // Returns (needsRequeue, error) where needsRequeue indicates if reconciliation should run again
 func (r *Reconciler) reconcileCluster(ctx context.Context, cluster *models.ClusterResource) (bool, error) {
-	log.Printf("[RECONCILE] Processing cluster %s in phase %s", cluster.Name, cluster.Status.Phase)
+	logger.Infof(ctx, "[RECONCILE] Processing cluster %s in phase %s", cluster.Name, cluster.Status.Phase)
+
+	// Stop or start the instances when the desired state asks for it
+	if handled, err := r.reconcileDesiredState(ctx, cluster); handled || err != nil {
+		return false, err
+	}
 
 	switch cluster.Status.Phase {
 	case string(models.ClusterPhasePending), "":
@@ -121,10 +244,10 @@ func (r *Reconciler) reconcileCluster(ctx context.Context, cluster *models.Clust
 	case string(models.ClusterPhaseConfiguring):
 		return false, r.configureK3s(ctx, cluster)
 	case string(models.ClusterPhaseRunning):
-		log.Printf("[RECONCILE] Cluster %s is running, checking for updates", cluster.Name)
+		logger.Infof(ctx, "[RECONCILE] Cluster %s is running, checking for updates", cluster.Name)
 		return r.reconcileRunningCluster(ctx, cluster)
 	default:
-		log.Printf("[RECONCILE] Unknown phase %s for cluster %s", cluster.Status.Phase, cluster.Name)
+		logger.Infof(ctx, "[RECONCILE] Unknown phase %s for cluster %s", cluster.Status.Phase, cluster.Name)
 		cluster.Status.Phase = string(models.ClusterPhasePending)
 		return false, nil
 	}
@@ -132,98 +255,50 @@ func (r *Reconciler) reconcileCluster(ctx context.Context, cluster *models.Clust
 
 // handleDeletion handles cluster deletion
 func (r *Reconciler) handleDeletion(ctx context.Context, cluster *models.ClusterResource) (*models.ReconcileResult, error) {
-	log.Printf("[DELETE] Processing deletion for cluster %s", cluster.Name)
+	logger.Infof(ctx, "[DELETE] Processing deletion for cluster %s", cluster.Name)
+	if cluster.Status.Phase != "Deleting" {
+		r.events.Normal(ctx, cluster.Name, EventDeleting, "", "Deleting cluster resources")
+	}
 	
 	// Update status to deleting
+	previousPhase := cluster.Status.Phase
 	cluster.Status.Phase = "Deleting"
 	cluster.Status.Message = "Deleting cluster resources"
+	r.notifyPhaseChange(ctx, cluster, previousPhase)
 	
-	// Get compute service
-	computeService := r.provider.GetComputeService()
-	
-	// Get ALL instances for this cluster from EC2 (not just from status)
-	filters := map[string]string{
-		"tag:goman-cluster": cluster.Name,
-		"instance-state-name": "running,pending,stopping,stopped",
-	}
-	
-	instances, err := computeService.ListInstances(ctx, filters)
-	if err != nil {
-		log.Printf("[DELETE] Warning: Failed to list instances from EC2: %v", err)
-		// Fall back to status file if we can't query EC2
-		for _, instance := range cluster.Status.Instances {
-			if instance.InstanceID != "" {
-				log.Printf("[DELETE] Deleting instance from status: %s (%s)", instance.Name, instance.InstanceID)
-				if err := computeService.DeleteInstance(ctx, instance.InstanceID); err != nil {
-					log.Printf("[DELETE] Failed to delete instance %s: %v", instance.InstanceID, err)
-				}
-			}
-		}
-	} else {
-		// Delete all instances found in EC2
-		log.Printf("[DELETE] Found %d instances to delete for cluster %s", len(instances), cluster.Name)
-		deletedCount := 0
-		for _, instance := range instances {
-			log.Printf("[DELETE] Deleting instance %s (%s) - %s", instance.Name, instance.ID, instance.State)
-			if err := computeService.DeleteInstance(ctx, instance.ID); err != nil {
-				log.Printf("[DELETE] Failed to delete instance %s: %v", instance.ID, err)
-				// Continue with other instances even if one fails
-			} else {
-				deletedCount++
-			}
-		}
-		log.Printf("[DELETE] Successfully deleted %d/%d instances for cluster %s", deletedCount, len(instances), cluster.Name)
+	// Clusters deleted before their first reconcile verify all cleanups too
+	if previousPhase != "Deleting" {
+		cluster.AddFinalizers()
 	}
 	
-	// Note: We intentionally keep the security group as it can be reused
-	// if the cluster is recreated with the same name. AWS will clean up
-	// unused security groups during account maintenance.
-	// If you want to delete it, uncomment the following:
-	// sgName := fmt.Sprintf("goman-%s-sg", cluster.Name)
-	// err := computeService.DeleteSecurityGroup(ctx, sgName)
-	// if err != nil {
-	//     log.Printf("[DELETE] Failed to delete security group: %v", err)
-	// }
-	
-	// Delete cluster files from S3
-	storageService := r.provider.GetStorageService()
-	
-	// Delete config file
-	configKey := fmt.Sprintf("clusters/%s/config.yaml", cluster.Name)
-	if err := storageService.DeleteObject(ctx, configKey); err != nil {
-		log.Printf("[DELETE] Failed to delete config file: %v", err)
+	// The cluster's records stay until its cloud resources are verified gone
+	if !r.runFinalizers(ctx, cluster) {
+		r.saveCluster(ctx, cluster)
+		return &models.ReconcileResult{Requeue: true, RequeueAfter: r.settings.ProgressRequeue}, nil
 	}
 	
-	// Delete status file
-	statusKey := fmt.Sprintf("clusters/%s/status.yaml", cluster.Name)
-	err = storageService.DeleteObject(ctx, statusKey)
-	if err != nil {
-		log.Printf("[DELETE] Failed to delete status file: %v", err)
+	// Records go last; the tombstone releases the name for reuse
+	if err := r.deleteClusterRecords(ctx, cluster.Name); err != nil {
+		logger.Warnf(ctx, "[DELETE] Failed to delete records of cluster %s: %v", cluster.Name, err)
+		return &models.ReconcileResult{Requeue: true, RequeueAfter: r.settings.ProgressRequeue}, nil
 	}
 	
-	// Delete token files (using correct paths)
-	tokenKeys := []string{
-		fmt.Sprintf("clusters/%s/k3s-server-token", cluster.Name),
-		fmt.Sprintf("clusters/%s/k3s-agent-token", cluster.Name),
-	}
-	for _, key := range tokenKeys {
-		err = storageService.DeleteObject(ctx, key)
-		if err != nil {
-			log.Printf("[DELETE] Failed to delete token file %s: %v", key, err)
-		}
-	}
+	// Remove this cluster's IAM role and instance profile, and any left
+	// behind by earlier deletions or renames
+	r.collectClusterIdentities(ctx)
 	
-	log.Printf("[DELETE] Cluster %s deletion completed", cluster.Name)
+	logger.Infof(ctx, "[DELETE] Cluster %s deletion completed", cluster.Name)
+	r.events.Normal(ctx, cluster.Name, EventDeleted, "", "Cluster deleted")
 	return &models.ReconcileResult{Requeue: false}, nil
 }
 
 // provisionInfrastructure provisions VMs and generates tokens
 func (r *Reconciler) provisionInfrastructure(ctx context.Context, cluster *models.ClusterResource) error {
-	log.Printf("[PROVISION] Starting infrastructure provisioning for cluster %s", cluster.Name)
+	logger.Infof(ctx, "[PROVISION] Starting infrastructure provisioning for cluster %s", cluster.Name)
 	
 	// Generate K3s token - same token for both server and agents
 	// K3s agents can join with the server token directly
-	k3sToken, err := r.generateToken()
+	k3sToken, err := r.clusterToken(ctx, cluster)
 	if err != nil {
 		return fmt.Errorf("failed to generate K3s token: %w", err)
 	}
@@ -245,20 +320,12 @@ func (r *Reconciler) provisionInfrastructure(ctx context.Context, cluster *model
 		// Check if we already have instances created
 		if len(cluster.Status.Instances) == 0 {
 			// Create only the first master node
-			log.Printf("[PROVISION] Creating first master node for HA cluster %s", cluster.Name)
+			logger.Infof(ctx, "[PROVISION] Creating first master node for HA cluster %s", cluster.Name)
 			
 			instanceName := fmt.Sprintf("%s-master-0", cluster.Name)
-			instanceConfig := provider.InstanceConfig{
-				Name:         instanceName,
-				Region:       cluster.Spec.Region,
-				InstanceType: cluster.Spec.InstanceType,
-				Tags: map[string]string{
-					"goman-cluster": cluster.Name,
-					"goman-role":    "master",
-					"goman-index":   "0",
-					"ManagedBy":     "goman",
-				},
-			}
+			instanceConfig := masterInstanceConfig(cluster, instanceName, map[string]string{
+				"goman-index": "0",
+			})
 			
 			instance, err := computeService.CreateInstance(ctx, instanceConfig)
 			if err != nil {
@@ -276,7 +343,8 @@ func (r *Reconciler) provisionInfrastructure(ctx context.Context, cluster *model
 			cluster.Status.Instances = []models.InstanceStatus{instanceStatus}
 			cluster.Status.Phase = string(models.ClusterPhaseProvisioning)
 			cluster.Status.Message = "Created first master node, waiting for it to start"
-			log.Printf("[PROVISION] Created first master %s (%s)", instanceName, instance.ID)
+			logger.Infof(ctx, "[PROVISION] Created first master %s (%s)", instanceName, instance.ID)
+			r.recordInstanceProvisioned(ctx, cluster.Name, instanceConfig, instance)
 			
 		} else {
 			// Additional masters will be created in checkProvisioningProgress
@@ -284,19 +352,10 @@ func (r *Reconciler) provisionInfrastructure(ctx context.Context, cluster *model
 		}
 	} else {
 		// Dev mode - create single master
-		log.Printf("[PROVISION] Creating single master for dev cluster %s", cluster.Name)
+		logger.Infof(ctx, "[PROVISION] Creating single master for dev cluster %s", cluster.Name)
 		
 		instanceName := fmt.Sprintf("%s-master-0", cluster.Name)
-		instanceConfig := provider.InstanceConfig{
-			Name:         instanceName,
-			Region:       cluster.Spec.Region,
-			InstanceType: cluster.Spec.InstanceType,
-			Tags: map[string]string{
-				"goman-cluster": cluster.Name,
-				"goman-role":    "master",
-				"ManagedBy":     "goman",
-			},
-		}
+		instanceConfig := masterInstanceConfig(cluster, instanceName, map[string]string{})
 		
 		instance, err := computeService.CreateInstance(ctx, instanceConfig)
 		if err != nil {
@@ -314,16 +373,17 @@ func (r *Reconciler) provisionInfrastructure(ctx context.Context, cluster *model
 		cluster.Status.Instances = []models.InstanceStatus{instanceStatus}
 		cluster.Status.Phase = string(models.ClusterPhaseProvisioning)
 		cluster.Status.Message = "Provisioned 1 instance, waiting for it to start"
-		log.Printf("[PROVISION] Created instance %s (%s) in state %s", instanceName, instance.ID, instance.State)
+		logger.Infof(ctx, "[PROVISION] Created instance %s (%s) in state %s", instanceName, instance.ID, instance.State)
+		r.recordInstanceProvisioned(ctx, cluster.Name, instanceConfig, instance)
 	}
 	
-	log.Printf("[PROVISION] Infrastructure provisioning initiated for cluster %s", cluster.Name)
+	logger.Infof(ctx, "[PROVISION] Infrastructure provisioning initiated for cluster %s", cluster.Name)
 	return nil
 }
 
 // checkProvisioningProgress checks if instances are ready
 func (r *Reconciler) checkProvisioningProgress(ctx context.Context, cluster *models.ClusterResource) error {
-	log.Printf("[PROVISION] Checking provisioning progress for cluster %s", cluster.Name)
+	logger.Infof(ctx, "[PROVISION] Checking provisioning progress for cluster %s", cluster.Name)
 	
 	computeService := r.provider.GetComputeService()
 	
@@ -333,7 +393,7 @@ func (r *Reconciler) checkProvisioningProgress(ctx context.Context, cluster *mod
 		for i, instanceStatus := range cluster.Status.Instances {
 			instance, err := computeService.GetInstance(ctx, instanceStatus.InstanceID)
 			if err != nil {
-				log.Printf("[PROVISION] Failed to get instance %s status: %v", instanceStatus.InstanceID, err)
+				logger.Warnf(ctx, "[PROVISION] Failed to get instance %s status: %v", instanceStatus.InstanceID, err)
 				continue
 			}
 			
@@ -349,25 +409,17 @@ func (r *Reconciler) checkProvisioningProgress(ctx context.Context, cluster *mod
 			firstMaster := cluster.Status.Instances[0]
 			if firstMaster.State == "running" && firstMaster.PrivateIP != "" {
 				// First master is ready, create the remaining two masters
-				log.Printf("[PROVISION] First master ready with IP %s, creating remaining masters", firstMaster.PrivateIP)
+				logger.Infof(ctx, "[PROVISION] First master ready with IP %s, creating remaining masters", firstMaster.PrivateIP)
 				
 				// Store the first master IP for other nodes to join
 				cluster.Status.PreferredMasterInstance = firstMaster.InstanceID
 				
 				for i := 1; i < 3; i++ {
 					instanceName := fmt.Sprintf("%s-master-%d", cluster.Name, i)
-					instanceConfig := provider.InstanceConfig{
-						Name:         instanceName,
-						Region:       cluster.Spec.Region,
-						InstanceType: cluster.Spec.InstanceType,
-						Tags: map[string]string{
-							"goman-cluster":     cluster.Name,
-							"goman-role":        "master",
-							"goman-index":       fmt.Sprintf("%d", i),
-							"goman-master-ip":   firstMaster.PrivateIP,
-							"ManagedBy":         "goman",
-						},
-					}
+					instanceConfig := masterInstanceConfig(cluster, instanceName, map[string]string{
+						"goman-index":     fmt.Sprintf("%d", i),
+						"goman-master-ip": firstMaster.PrivateIP,
+					})
 					
 					instance, err := computeService.CreateInstance(ctx, instanceConfig)
 					if err != nil {
@@ -383,7 +435,8 @@ func (r *Reconciler) checkProvisioningProgress(ctx context.Context, cluster *mod
 					}
 					
 					cluster.Status.Instances = append(cluster.Status.Instances, instanceStatus)
-					log.Printf("[PROVISION] Created additional master %s (%s)", instanceName, instance.ID)
+					logger.Infof(ctx, "[PROVISION] Created additional master %s (%s)", instanceName, instance.ID)
+					r.recordInstanceProvisioned(ctx, cluster.Name, instanceConfig, instance)
 				}
 				
 				cluster.Status.Message = "Created all 3 master nodes, waiting for them to start"
@@ -391,7 +444,7 @@ func (r *Reconciler) checkProvisioningProgress(ctx context.Context, cluster *mod
 			} else {
 				// First master not ready yet
 				cluster.Status.Message = fmt.Sprintf("Waiting for first master to be ready (state: %s)", firstMaster.State)
-				log.Printf("[PROVISION] Waiting for first master to be ready")
+				logger.Infof(ctx, "[PROVISION] Waiting for first master to be ready")
 				return nil
 			}
 		}
@@ -409,10 +462,10 @@ func (r *Reconciler) checkProvisioningProgress(ctx context.Context, cluster *mod
 			if allRunning {
 				cluster.Status.Phase = string(models.ClusterPhaseInstalling)
 				cluster.Status.Message = "All 3 master nodes are running, ready for K3s installation"
-				log.Printf("[PROVISION] All HA masters running for cluster %s", cluster.Name)
+				logger.Infof(ctx, "[PROVISION] All HA masters running for cluster %s", cluster.Name)
 			} else {
 				cluster.Status.Message = "Waiting for all master nodes to reach running state"
-				log.Printf("[PROVISION] Still waiting for HA masters to start for cluster %s", cluster.Name)
+				logger.Infof(ctx, "[PROVISION] Still waiting for HA masters to start for cluster %s", cluster.Name)
 			}
 		}
 	} else {
@@ -421,7 +474,7 @@ func (r *Reconciler) checkProvisioningProgress(ctx context.Context, cluster *mod
 		for i, instanceStatus := range cluster.Status.Instances {
 			instance, err := computeService.GetInstance(ctx, instanceStatus.InstanceID)
 			if err != nil {
-				log.Printf("[PROVISION] Failed to get instance %s status: %v", instanceStatus.InstanceID, err)
+				logger.Warnf(ctx, "[PROVISION] Failed to get instance %s status: %v", instanceStatus.InstanceID, err)
 				continue
 			}
 			
@@ -432,17 +485,17 @@ func (r *Reconciler) checkProvisioningProgress(ctx context.Context, cluster *mod
 			
 			if instance.State != "running" {
 				allRunning = false
-				log.Printf("[PROVISION] Instance %s is in state %s", instanceStatus.InstanceID, instance.State)
+				logger.Infof(ctx, "[PROVISION] Instance %s is in state %s", instanceStatus.InstanceID, instance.State)
 			}
 		}
 		
 		if allRunning {
 			cluster.Status.Phase = string(models.ClusterPhaseInstalling)
 			cluster.Status.Message = "All instances are running, ready for K3s installation"
-			log.Printf("[PROVISION] All instances running for cluster %s", cluster.Name)
+			logger.Infof(ctx, "[PROVISION] All instances running for cluster %s", cluster.Name)
 		} else {
 			cluster.Status.Message = "Waiting for instances to reach running state"
-			log.Printf("[PROVISION] Still waiting for instances to start for cluster %s", cluster.Name)
+			logger.Infof(ctx, "[PROVISION] Still waiting for instances to start for cluster %s", cluster.Name)
 		}
 	}
 	
@@ -451,65 +504,216 @@ func (r *Reconciler) checkProvisioningProgress(ctx context.Context, cluster *mod
 
 // installK3s installs K3s on instances
 func (r *Reconciler) installK3s(ctx context.Context, cluster *models.ClusterResource) error {
-	log.Printf("[INSTALL] Starting K3s installation for cluster %s", cluster.Name)
+	logger.Infof(ctx, "[INSTALL] Starting K3s installation for cluster %s", cluster.Name)
 	
 	// For now, just move to configuring phase
 	cluster.Status.Phase = string(models.ClusterPhaseConfiguring)
 	cluster.Status.Message = "K3s installation completed, configuring cluster"
 	
-	log.Printf("[INSTALL] K3s installation completed for cluster %s", cluster.Name)
+	logger.Infof(ctx, "[INSTALL] K3s installation completed for cluster %s", cluster.Name)
 	return nil
 }
 
 // configureK3s configures K3s cluster and provisions node pools
 func (r *Reconciler) configureK3s(ctx context.Context, cluster *models.ClusterResource) error {
-	log.Printf("[CONFIGURE] Starting K3s configuration for cluster %s", cluster.Name)
+	logger.Infof(ctx, "[CONFIGURE] Starting K3s configuration for cluster %s", cluster.Name)
 	
+	// Restore from a snapshot before any worker joins
+	if restored, err := r.reconcileSnapshotRestore(ctx, cluster); err != nil || !restored {
+		return err
+	}
+
 	// Check if we need to provision node pools
 	if len(cluster.Spec.NodePools) > 0 {
-		log.Printf("[CONFIGURE] Provisioning %d node pools for cluster %s", len(cluster.Spec.NodePools), cluster.Name)
+		logger.Infof(ctx, "[CONFIGURE] Provisioning %d node pools for cluster %s", len(cluster.Spec.NodePools), cluster.Name)
 		if err := r.provisionNodePools(ctx, cluster); err != nil {
 			return fmt.Errorf("failed to provision node pools: %w", err)
 		}
 	}
 	
+	// Smoke test the cluster before it counts as running
+	if passed, err := r.verifyBeforeRunning(ctx, cluster); err != nil || !passed {
+		return err
+	}
+
 	// Mark as running
 	cluster.Status.Phase = string(models.ClusterPhaseRunning)
 	cluster.Status.Message = "K3s cluster is running and ready"
 	
-	log.Printf("[CONFIGURE] K3s configuration completed for cluster %s", cluster.Name)
+	logger.Infof(ctx, "[CONFIGURE] K3s configuration completed for cluster %s", cluster.Name)
 	return nil
 }
 
 // reconcileRunningCluster handles reconciliation for a running cluster (scaling, updates, etc.)
 func (r *Reconciler) reconcileRunningCluster(ctx context.Context, cluster *models.ClusterResource) (bool, error) {
-	log.Printf("[RUNNING] Reconciling running cluster %s", cluster.Name)
+	logger.Infof(ctx, "[RUNNING] Reconciling running cluster %s", cluster.Name)
 	
 	needsRequeue := false
+
+	// Record etcd health before anything takes a master down. Nothing else
+	// is changed while quorum is lost or being recovered; the Degraded
+	// condition says how to recover it. While the health is unknown, nothing
+	// is changed either and no recovery starts: the check is retried.
+	quorate, known := r.checkEtcdQuorum(ctx, cluster)
+	if st := cluster.Status.QuorumRecovery; !known && (st == nil || st.Done()) {
+		return true, nil
+	}
+	recovering, err := r.reconcileQuorumRecovery(ctx, cluster, quorate)
+	if err != nil {
+		return false, fmt.Errorf("failed to recover etcd quorum: %w", err)
+	}
+	if recovering || !quorate {
+		return true, nil
+	}
 	
 	// First, clean up any stale nodes from K3s cluster
 	cleanupHappened, err := r.cleanupStaleK3sNodes(ctx, cluster)
 	if err != nil {
-		log.Printf("[RUNNING] Warning: Failed to cleanup stale nodes: %v", err)
+		logger.Warnf(ctx, "[RUNNING] Warning: Failed to cleanup stale nodes: %v", err)
 		// Continue with reconciliation even if cleanup fails
 	} else if cleanupHappened {
 		needsRequeue = true  // Requeue to verify cluster is healthy after cleanup
 	}
-	
+
+	// Shrink the control plane if the mode was changed from HA to dev
+	downscaling, err := r.reconcileMasterCount(ctx, cluster)
+	if err != nil {
+		return false, fmt.Errorf("failed to downscale masters: %w", err)
+	}
+	if downscaling {
+		// Requeue to remove the next master or verify the single master
+		return true, nil
+	}
+
+	// Who made changes reported earlier, once the audit log has the calls
+	r.attributeChanges(ctx, cluster)
+
+	// Report instances changed outside goman and revert them if asked to
+	reverting, err := r.reconcileDrift(ctx, cluster)
+	if err != nil {
+		logger.Warnf(ctx, "[RUNNING] Warning: Failed to reconcile drift: %v", err)
+	} else if reverting {
+		// Requeue to follow the stop/modify/start of the reverted instance
+		return true, nil
+	}
+
+	// Replace requested worker nodes one at a time
+	replacing, err := r.reconcileNodeReplacements(ctx, cluster)
+	if err != nil {
+		return false, fmt.Errorf("failed to replace node: %w", err)
+	}
+	if replacing {
+		// Skip node pool reconciliation while old and new node coexist
+		return true, nil
+	}
+
+	// Roll out node pool instance type changes batch by batch
+	rollingOut, err := r.reconcileRollout(ctx, cluster)
+	if err != nil {
+		return false, fmt.Errorf("failed to roll out node pool: %w", err)
+	}
+	if rollingOut {
+		// Skip node pool reconciliation while a batch has extra workers
+		return true, nil
+	}
+
+	// Upgrade K3s node by node after a version change
+	upgrading, err := r.reconcileUpgrade(ctx, cluster)
+	if err != nil {
+		return false, fmt.Errorf("failed to upgrade K3s: %w", err)
+	}
+	if upgrading {
+		// Skip node pool reconciliation while a node is drained
+		return true, nil
+	}
+
+	// Rotate the token and client credentials node by node once requested
+	rotating, err := r.reconcileCredentialRotation(ctx, cluster)
+	if err != nil {
+		return false, err
+	}
+	if rotating {
+		// Skip node pool reconciliation so new nodes join with the new token
+		return true, nil
+	}
+
 	// Always reconcile node pools - this handles scaling, adding, and removing pools
 	if err := r.reconcileNodePools(ctx, cluster); err != nil {
 		return false, fmt.Errorf("failed to reconcile node pools: %w", err)
 	}
+
+	// DNS customization; failures are retried without blocking the cluster
+	if err := r.reconcileDNS(ctx, cluster); err != nil {
+		logger.Warnf(ctx, "[RUNNING] Warning: Failed to reconcile DNS: %v", err)
+	}
+
+	// Virtual IP API endpoint of HA clusters, also retried
+	if err := r.reconcileVIP(ctx, cluster); err != nil {
+		logger.Warnf(ctx, "[RUNNING] Warning: Failed to reconcile virtual IP: %v", err)
+	}
+
+	// Load balancer API endpoint of HA clusters, also retried
+	if err := r.reconcileLoadBalancer(ctx, cluster); err != nil {
+		logger.Warnf(ctx, "[RUNNING] Warning: Failed to reconcile API load balancer: %v", err)
+	}
+
+	// Every master of HA clusters in the kubeconfig, for clients to fail over
+	if err := r.reconcileAPIEndpoints(ctx, cluster); err != nil {
+		logger.Warnf(ctx, "[RUNNING] Warning: Failed to reconcile API endpoints: %v", err)
+	}
+
+	// User tags on existing resources, after a change or a retag request
+	if err := r.reconcileTags(ctx, cluster); err != nil {
+		logger.Warnf(ctx, "[RUNNING] Warning: Failed to reconcile tags: %v", err)
+	}
+
+	// Shutdown behavior and stop protection of existing nodes
+	if err := r.reconcileInstanceProtection(ctx, cluster); err != nil {
+		logger.Warnf(ctx, "[RUNNING] Warning: Failed to reconcile instance protection: %v", err)
+	}
+
+	// Registry mirrors and credentials of the spec, on every running node
+	if err := r.reconcileRegistries(ctx, cluster); err != nil {
+		logger.Warnf(ctx, "[RUNNING] Warning: Failed to reconcile registries: %v", err)
+	}
+
+	// Pool labels and taints on registered worker nodes, again on drift
+	if err := r.reconcileNodeMetadata(ctx, cluster); err != nil {
+		logger.Warnf(ctx, "[RUNNING] Warning: Failed to reconcile node labels and taints: %v", err)
+	}
+
+	// Helm charts and manifests of the spec, on every running master
+	if err := r.reconcileAddons(ctx, cluster); err != nil {
+		logger.Warnf(ctx, "[RUNNING] Warning: Failed to reconcile addons: %v", err)
+	}
+
+	// Private connectivity to linked clusters
+	if err := r.reconcileClusterLinks(ctx, cluster); err != nil {
+		logger.Warnf(ctx, "[RUNNING] Warning: Failed to reconcile cluster links: %v", err)
+	}
+
+	// Certificate expiry, rotation and the stored kubeconfig
+	if err := r.reconcileCertificates(ctx, cluster); err != nil {
+		logger.Warnf(ctx, "[RUNNING] Warning: Failed to reconcile certificates: %v", err)
+	}
+
+	// View-only kubeconfig for handing out access
+	if err := r.reconcileReadOnlyKubeconfig(ctx, cluster); err != nil {
+		logger.Warnf(ctx, "[RUNNING] Warning: Failed to reconcile read-only kubeconfig: %v", err)
+	}
 	
 	// Cluster remains in running state
 	cluster.Status.Message = "K3s cluster is running and ready"
+	if len(cluster.Status.Drift) > 0 {
+		cluster.Status.Message = fmt.Sprintf("K3s cluster is running and ready, %d field(s) drifted from spec", len(cluster.Status.Drift))
+	}
 	return needsRequeue, nil
 }
 
 // cleanupStaleK3sNodes removes terminated nodes from the K3s cluster
 // removeAllWorkers removes all worker nodes when no NodePools are defined
 func (r *Reconciler) removeAllWorkers(ctx context.Context, cluster *models.ClusterResource) error {
-	log.Printf("[REMOVE_WORKERS] Removing all worker nodes from cluster %s", cluster.Name)
+	logger.Infof(ctx, "[REMOVE_WORKERS] Removing all worker nodes from cluster %s", cluster.Name)
 	
 	computeService := r.provider.GetComputeService()
 	var workersToRemove []*provider.Instance
@@ -523,11 +727,11 @@ func (r *Reconciler) removeAllWorkers(ctx context.Context, cluster *models.Clust
 	}
 	runningWorkers, err := computeService.ListInstances(ctx, filters)
 	if err != nil {
-		log.Printf("[REMOVE_WORKERS] Warning: Failed to list worker instances: %v", err)
+		logger.Warnf(ctx, "[REMOVE_WORKERS] Warning: Failed to list worker instances: %v", err)
 		// Fall back to using status if we can't list instances
 		for _, instance := range cluster.Status.Instances {
 			if instance.Role == "worker" {
-				log.Printf("[REMOVE_WORKERS] Removing worker from status: %s (%s)", instance.Name, instance.InstanceID)
+				logger.Infof(ctx, "[REMOVE_WORKERS] Removing worker from status: %s (%s)", instance.Name, instance.InstanceID)
 				workersToRemove = append(workersToRemove, &provider.Instance{
 					ID: instance.InstanceID,
 					Name: instance.Name,
@@ -540,7 +744,7 @@ func (r *Reconciler) removeAllWorkers(ctx context.Context, cluster *models.Clust
 	} else {
 		// Use actual running instances
 		workersToRemove = runningWorkers
-		log.Printf("[REMOVE_WORKERS] Found %d running workers to remove", len(workersToRemove))
+		logger.Infof(ctx, "[REMOVE_WORKERS] Found %d running workers to remove", len(workersToRemove))
 		
 		// Keep only non-worker instances in status
 		for _, instance := range cluster.Status.Instances {
@@ -551,7 +755,7 @@ func (r *Reconciler) removeAllWorkers(ctx context.Context, cluster *models.Clust
 	}
 	
 	if len(workersToRemove) == 0 {
-		log.Printf("[REMOVE_WORKERS] No workers to remove")
+		logger.Infof(ctx, "[REMOVE_WORKERS] No workers to remove")
 		return nil
 	}
 	
@@ -564,10 +768,13 @@ func (r *Reconciler) removeAllWorkers(ctx context.Context, cluster *models.Clust
 		}
 	}
 	
+	parallelism := r.settings.nodeParallelism(len(workersToRemove))
+	logger.Infof(ctx, "[REMOVE_WORKERS] Removing %d workers, %d at a time", len(workersToRemove), parallelism)
+
 	if masterInstance != "" {
-		for _, worker := range workersToRemove {
+		forEachNode(ctx, len(workersToRemove), parallelism, func(ctx context.Context, i int) error {
 			// Use worker's private IP to determine K3s node name
-			workerIP := worker.PrivateIP
+			workerIP := workersToRemove[i].PrivateIP
 			
 			if workerIP != "" {
 				// K3s uses the hostname as node name, which is based on private IP
@@ -575,47 +782,39 @@ func (r *Reconciler) removeAllWorkers(ctx context.Context, cluster *models.Clust
 				ipParts := strings.ReplaceAll(workerIP, ".", "-")
 				nodeName := fmt.Sprintf("ip-%s.%s.compute.internal", ipParts, r.provider.Region())
 				
-				// Drain the node first
-				drainCmd := fmt.Sprintf("kubectl drain %s --ignore-daemonsets --delete-emptydir-data --force || true", nodeName)
-				log.Printf("[REMOVE_WORKERS] Draining node %s from K3s", nodeName)
-				result, err := computeService.RunCommand(ctx, []string{masterInstance}, drainCmd)
-				if err != nil {
-					log.Printf("[REMOVE_WORKERS] Warning: Failed to drain node %s: %v", nodeName, err)
-				} else if result.Instances[masterInstance] != nil {
-					log.Printf("[REMOVE_WORKERS] Drain output: %s", result.Instances[masterInstance].Output)
-				}
-				
-				// Delete the node from K3s
-				deleteCmd := fmt.Sprintf("kubectl delete node %s || true", nodeName)
-				log.Printf("[REMOVE_WORKERS] Deleting node %s from K3s", nodeName)
-				result, err = computeService.RunCommand(ctx, []string{masterInstance}, deleteCmd)
+				// Drain the node and delete it from K3s
+				logger.Infof(ctx, "[REMOVE_WORKERS] Draining and deleting node %s from K3s", nodeName)
+				result, err := r.drainNode(ctx, masterInstance, nodeName, "120s", true)
 				if err != nil {
-					log.Printf("[REMOVE_WORKERS] Warning: Failed to delete node %s from K3s: %v", nodeName, err)
+					logger.Warnf(ctx, "[REMOVE_WORKERS] Warning: Failed to remove node %s from K3s: %v", nodeName, err)
 				} else if result.Instances[masterInstance] != nil {
-					log.Printf("[REMOVE_WORKERS] Delete output: %s", result.Instances[masterInstance].Output)
+					logger.Infof(ctx, "[REMOVE_WORKERS] Drain output: %s", result.Instances[masterInstance].Output)
 				}
 			}
-		}
+			return nil
+		})
 	}
 	
 	// Terminate EC2 instances
-	for _, worker := range workersToRemove {
-		log.Printf("[REMOVE_WORKERS] Terminating EC2 instance %s (%s)", worker.Name, worker.ID)
+	forEachNode(ctx, len(workersToRemove), parallelism, func(ctx context.Context, i int) error {
+		worker := workersToRemove[i]
+		logger.Infof(ctx, "[REMOVE_WORKERS] Terminating EC2 instance %s (%s)", worker.Name, worker.ID)
 		if err := computeService.DeleteInstance(ctx, worker.ID); err != nil {
-			log.Printf("[REMOVE_WORKERS] Error terminating instance %s: %v", worker.ID, err)
+			logger.Warnf(ctx, "[REMOVE_WORKERS] Error terminating instance %s: %v", worker.ID, err)
 			// Continue with other instances
 		}
-	}
+		return nil
+	})
 	
 	// Update cluster status to remove workers
 	cluster.Status.Instances = updatedInstances
-	log.Printf("[REMOVE_WORKERS] Removed %d workers from cluster %s", len(workersToRemove), cluster.Name)
+	logger.Infof(ctx, "[REMOVE_WORKERS] Removed %d workers from cluster %s", len(workersToRemove), cluster.Name)
 	
 	return nil
 }
 
 func (r *Reconciler) cleanupStaleK3sNodes(ctx context.Context, cluster *models.ClusterResource) (bool, error) {
-	log.Printf("[CLEANUP] Checking for stale nodes in K3s cluster %s", cluster.Name)
+	logger.Infof(ctx, "[CLEANUP] Checking for stale nodes in K3s cluster %s", cluster.Name)
 	
 	// Get the master instance to run kubectl commands
 	var masterInstanceID string
@@ -634,7 +833,7 @@ func (r *Reconciler) cleanupStaleK3sNodes(ctx context.Context, cluster *models.C
 	computeService := r.provider.GetComputeService()
 	getNodesCmd := "kubectl get nodes -o json | jq -r '.items[] | \"\\(.metadata.name),\\(.status.addresses[] | select(.type==\"InternalIP\") | .address)\"'"
 	
-	result, err := computeService.RunCommand(ctx, []string{masterInstanceID}, getNodesCmd)
+	result, err := r.runCommand(ctx, "list-nodes", []string{masterInstanceID}, getNodesCmd)
 	if err != nil {
 		return false, fmt.Errorf("failed to get K3s nodes: %w", err)
 	}
@@ -677,39 +876,37 @@ func (r *Reconciler) cleanupStaleK3sNodes(ctx context.Context, cluster *models.C
 			nodeName := parts[0]
 			nodeIP := parts[1]
 			
-			// Check if this node's IP is still running
-			if !runningIPs[nodeIP] {
+			// Check if this node's IP is still running. A stopped master
+			// stays an etcd member: deleting its node would remove it.
+			if !runningIPs[nodeIP] && isEtcdMember(cluster, nodeIP) {
+				logger.Infof(ctx, "[CLEANUP] Keeping node %s of stopped master (IP: %s) in etcd", nodeName, nodeIP)
+			} else if !runningIPs[nodeIP] {
 				staleNodes = append(staleNodes, nodeName)
-				log.Printf("[CLEANUP] Found stale node: %s (IP: %s)", nodeName, nodeIP)
+				logger.Infof(ctx, "[CLEANUP] Found stale node: %s (IP: %s)", nodeName, nodeIP)
 			}
 		}
 	}
 	
 	// Remove stale nodes from K3s cluster
-	for _, nodeName := range staleNodes {
-		log.Printf("[CLEANUP] Removing stale node %s from K3s cluster", nodeName)
-		
-		// First, drain the node (in case it still has pods)
-		drainCmd := fmt.Sprintf("kubectl drain %s --ignore-daemonsets --delete-emptydir-data --force --timeout=30s || true", nodeName)
-		if _, err := computeService.RunCommand(ctx, []string{masterInstanceID}, drainCmd); err != nil {
-			log.Printf("[CLEANUP] Warning: Failed to drain node %s: %v", nodeName, err)
-		}
+	forEachNode(ctx, len(staleNodes), r.settings.nodeParallelism(len(staleNodes)), func(ctx context.Context, i int) error {
+		nodeName := staleNodes[i]
+		logger.Infof(ctx, "[CLEANUP] Removing stale node %s from K3s cluster", nodeName)
 		
-		// Then delete the node
-		deleteCmd := fmt.Sprintf("kubectl delete node %s", nodeName)
-		if _, err := computeService.RunCommand(ctx, []string{masterInstanceID}, deleteCmd); err != nil {
-			log.Printf("[CLEANUP] Warning: Failed to delete node %s: %v", nodeName, err)
+		// Drain the node (in case it still has pods) and delete it
+		if _, err := r.drainNode(ctx, masterInstanceID, nodeName, "30s", true); err != nil {
+			logger.Warnf(ctx, "[CLEANUP] Warning: Failed to delete node %s: %v", nodeName, err)
 			// Continue with other nodes
 		} else {
-			log.Printf("[CLEANUP] Successfully removed node %s from K3s cluster", nodeName)
+			logger.Infof(ctx, "[CLEANUP] Successfully removed node %s from K3s cluster", nodeName)
 		}
-	}
+		return nil
+	})
 	
 	if len(staleNodes) > 0 {
-		log.Printf("[CLEANUP] Removed %d stale nodes from K3s cluster", len(staleNodes))
+		logger.Infof(ctx, "[CLEANUP] Removed %d stale nodes from K3s cluster", len(staleNodes))
 		return true, nil  // Cleanup happened, should requeue
 	} else {
-		log.Printf("[CLEANUP] No stale nodes found in K3s cluster")
+		logger.Infof(ctx, "[CLEANUP] No stale nodes found in K3s cluster")
 		return false, nil  // No cleanup needed
 	}
 }
@@ -717,7 +914,6 @@ func (r *Reconciler) cleanupStaleK3sNodes(ctx context.Context, cluster *models.C
 // reconcileNodePools ensures the actual worker nodes match the desired configuration
 func (r *Reconciler) reconcileNodePools(ctx context.Context, cluster *models.ClusterResource) error {
 	computeService := r.provider.GetComputeService()
-	storageService := r.provider.GetStorageService()
 	
 	// Get master IP for new workers to join
 	var masterIP string
@@ -729,20 +925,19 @@ func (r *Reconciler) reconcileNodePools(ctx context.Context, cluster *models.Clu
 	}
 	
 	if masterIP == "" {
-		return fmt.Errorf("no master node IP found for worker nodes to join")
+		return provider.BootstrapErrorf("no master node IP found for worker nodes to join")
 	}
 	
-	// Get the node token from S3 for workers to join
-	nodeTokenKey := fmt.Sprintf("clusters/%s/k3s-node-token", cluster.Name)
-	nodeTokenData, err := storageService.GetObject(ctx, nodeTokenKey)
+	// Get the node token from the secret backend for workers to join
+	nodeTokenData, err := r.provider.GetSecretService().GetSecret(ctx, cluster.Name, provider.SecretNodeToken)
 	if err != nil {
-		log.Printf("[NODEPOOLS] Failed to get agent token, using server token: %v", err)
+		logger.Warnf(ctx, "[NODEPOOLS] Failed to get agent token, using server token: %v", err)
 		// For K3s, agents can join with just the server token
 		// No need to construct a special format - K3s handles this
 		if cluster.Status.K3sServerToken != "" {
 			nodeTokenData = []byte(cluster.Status.K3sServerToken)
 		} else {
-			return fmt.Errorf("no server token available for workers to join")
+			return provider.BootstrapErrorf("no server token available for workers to join")
 		}
 	}
 	nodeToken := strings.TrimSpace(string(nodeTokenData))
@@ -754,7 +949,7 @@ func (r *Reconciler) reconcileNodePools(ctx context.Context, cluster *models.Clu
 	}
 	computeInstances, err := computeService.ListInstances(ctx, filters)
 	if err != nil {
-		log.Printf("[NODEPOOLS] Warning: Failed to list instances from AWS: %v", err)
+		logger.Warnf(ctx, "[NODEPOOLS] Warning: Failed to list instances from AWS: %v", err)
 		// Fall back to status, but it might be stale
 	}
 	
@@ -766,6 +961,11 @@ func (r *Reconciler) reconcileNodePools(ctx context.Context, cluster *models.Clu
 		}
 	}
 	
+	// Workers stopped or terminated behind goman's back are reported
+	if err == nil {
+		r.reportVanishedWorkers(ctx, cluster, actualInstances)
+	}
+	
 	// Count existing workers per pool based on actual instances
 	existingWorkers := make(map[string][]models.InstanceStatus)
 	allWorkers := []models.InstanceStatus{}
@@ -802,7 +1002,18 @@ func (r *Reconciler) reconcileNodePools(ctx context.Context, cluster *models.Clu
 		}
 	}
 	
-	log.Printf("[NODEPOOLS] Found %d total workers across all pools", len(allWorkers))
+	logger.Infof(ctx, "[NODEPOOLS] Found %d total workers across all pools", len(allWorkers))
+	
+	// Stopped workers of pools with scaleDownBehavior "stop" are started
+	// before new ones are created
+	parked, err := r.listParkedWorkers(ctx, cluster)
+	if err != nil {
+		logger.Warnf(ctx, "[NODEPOOLS] Warning: %v", err)
+	}
+	parked = r.reapParkedWorkers(ctx, cluster, parked)
+	
+	// Master for kubectl commands (drains)
+	masterInstanceID := runningMasterID(cluster)
 	
 	// Process each node pool
 	for _, pool := range cluster.Spec.NodePools {
@@ -810,30 +1021,33 @@ func (r *Reconciler) reconcileNodePools(ctx context.Context, cluster *models.Clu
 		currentCount := len(poolWorkers)
 		desiredCount := pool.Count
 		
-		log.Printf("[NODEPOOLS] Pool '%s': current=%d, desired=%d", pool.Name, currentCount, desiredCount)
+		logger.Infof(ctx, "[NODEPOOLS] Pool '%s': current=%d, desired=%d", pool.Name, currentCount, desiredCount)
+		
+		// A paused pool keeps its nodes as they are, e.g. to preserve
+		// evidence while debugging workloads
+		if pool.Paused {
+			if currentCount != desiredCount {
+				logger.Infof(ctx, "[NODEPOOLS] Pool '%s' is paused, not scaling", pool.Name)
+			}
+			continue
+		}
 		
 		// First, handle any duplicates - keep the newest ones
 		if currentCount > desiredCount {
 			// Scale down - terminate extra workers
 			toTerminate := currentCount - desiredCount
-			log.Printf("[NODEPOOLS] Scaling down pool '%s': terminating %d excess workers", pool.Name, toTerminate)
+			logger.Infof(ctx, "[NODEPOOLS] Scaling down pool '%s': terminating %d excess workers", pool.Name, toTerminate)
 			
-			// Sort workers by name to identify duplicates and by launch time
-			// Group by base name (without index) to find duplicates
+			// Group by name to find duplicates: instances launched twice for
+			// the same index. Workers of a pool only differ by their index, so
+			// it must be kept in the key.
 			workerGroups := make(map[string][]models.InstanceStatus)
 			for _, worker := range poolWorkers {
-				// Extract base name without index
-				baseName := worker.Name
-				if idx := strings.LastIndex(baseName, "-"); idx > 0 {
-					if _, err := strconv.Atoi(baseName[idx+1:]); err == nil {
-						baseName = baseName[:idx]
-					}
-				}
-				workerGroups[baseName] = append(workerGroups[baseName], worker)
+				workerGroups[worker.Name] = append(workerGroups[worker.Name], worker)
 			}
 			
-			// Build list of workers to terminate
-			var toDelete []models.InstanceStatus
+			// Build list of workers to terminate, or to stop for reuse
+			var toDelete, toPark []models.InstanceStatus
 			
 			// First, remove duplicates (keep newest)
 			for _, workers := range workerGroups {
@@ -845,7 +1059,7 @@ func (r *Reconciler) reconcileNodePools(ctx context.Context, cluster *models.Clu
 					// Mark older duplicates for deletion
 					for i := 1; i < len(workers); i++ {
 						toDelete = append(toDelete, workers[i])
-						log.Printf("[NODEPOOLS] Marking duplicate %s (%s) for termination", workers[i].Name, workers[i].InstanceID)
+						logger.Infof(ctx, "[NODEPOOLS] Marking duplicate %s (%s) for termination", workers[i].Name, workers[i].InstanceID)
 					}
 				}
 			}
@@ -866,83 +1080,117 @@ func (r *Reconciler) reconcileNodePools(ctx context.Context, cluster *models.Clu
 			}
 			
 			if len(remainingWorkers) > desiredCount {
-				// Sort by index (descending) to remove highest indexed first
-				sort.Slice(remainingWorkers, func(i, j int) bool {
-					iIndex := extractWorkerIndex(remainingWorkers[i].Name)
-					jIndex := extractWorkerIndex(remainingWorkers[j].Name)
-					return iIndex > jIndex
-				})
-				
-				// Add excess workers to delete list
-				for i := 0; i < len(remainingWorkers)-desiredCount; i++ {
-					toDelete = append(toDelete, remainingWorkers[i])
-					log.Printf("[NODEPOOLS] Marking excess %s (%s) for termination", remainingWorkers[i].Name, remainingWorkers[i].InstanceID)
+				// Remove the highest indexed first, from the most used zones
+				for _, worker := range zoneSurplus(pool, remainingWorkers, len(remainingWorkers)-desiredCount) {
+					if pool.StopsOnScaleDown() {
+						toPark = append(toPark, worker)
+						logger.Infof(ctx, "[NODEPOOLS] Marking excess %s (%s) to be stopped", worker.Name, worker.InstanceID)
+						continue
+					}
+					toDelete = append(toDelete, worker)
+					logger.Infof(ctx, "[NODEPOOLS] Marking excess %s (%s) for termination", worker.Name, worker.InstanceID)
 				}
 			}
 			
-			// Terminate all workers marked for deletion
-			for _, worker := range toDelete {
-				log.Printf("[NODEPOOLS] Terminating worker %s (%s)", worker.Name, worker.InstanceID)
+			// Terminate all workers marked for deletion; terminated ones
+			// leave the status
+			var terminatedMu sync.Mutex
+			forEachNode(ctx, len(toDelete), r.settings.nodeParallelism(len(toDelete)), func(ctx context.Context, i int) error {
+				worker := toDelete[i]
+				logger.Infof(ctx, "[NODEPOOLS] Terminating worker %s (%s)", worker.Name, worker.InstanceID)
 				
 				if err := computeService.DeleteInstance(ctx, worker.InstanceID); err != nil {
-					log.Printf("[NODEPOOLS] Failed to terminate %s: %v", worker.InstanceID, err)
+					logger.Warnf(ctx, "[NODEPOOLS] Failed to terminate %s: %v", worker.InstanceID, err)
 					// Continue with other terminations
+					return nil
 				}
+				terminatedMu.Lock()
+				delete(actualInstances, worker.InstanceID)
+				terminatedMu.Unlock()
+				return nil
+			})
+			
+			// Stop the others; they leave the status until they are reused
+			forEachNode(ctx, len(toPark), r.settings.nodeParallelism(len(toPark)), func(ctx context.Context, i int) error {
+				worker := toPark[i]
+				logger.Infof(ctx, "[NODEPOOLS] Stopping worker %s (%s) for reuse", worker.Name, worker.InstanceID)
+				if err := r.parkWorker(ctx, masterInstanceID, worker); err != nil {
+					logger.Warnf(ctx, "[NODEPOOLS] Failed to stop %s: %v", worker.InstanceID, err)
+				}
+				return nil
+			})
+			for _, worker := range toPark {
+				delete(actualInstances, worker.InstanceID)
 			}
 			
 		} else if currentCount < desiredCount {
 			// Scale up - provision new workers
 			toCreate := desiredCount - currentCount
-			log.Printf("[NODEPOOLS] Scaling up pool '%s': creating %d new workers", pool.Name, toCreate)
 			
-			// Find which indices are missing
+			// Reuse stopped workers first
+			for _, inst := range r.unparkWorkers(ctx, parked[pool.Name], toCreate) {
+				actualInstances[inst.ID] = inst
+				poolWorkers = append(poolWorkers, models.InstanceStatus{
+					InstanceID:       inst.ID,
+					Name:             inst.Name,
+					AvailabilityZone: inst.AvailabilityZone,
+				})
+				toCreate--
+			}
+			if toCreate == 0 {
+				continue
+			}
+			logger.Infof(ctx, "[NODEPOOLS] Scaling up pool '%s': creating %d new workers", pool.Name, toCreate)
+			
+			// Find which indices are missing; stopped workers keep theirs
 			existingIndices := make(map[int]bool)
 			for _, worker := range poolWorkers {
 				if idx := extractWorkerIndex(worker.Name); idx >= 0 {
 					existingIndices[idx] = true
 				}
 			}
+			for _, inst := range parked[pool.Name] {
+				if idx := extractWorkerIndex(inst.Name); idx >= 0 {
+					existingIndices[idx] = true
+				}
+			}
 			
-			// Create workers with missing indices
-			createdCount := 0
-			for i := 0; createdCount < toCreate && i < desiredCount*2; i++ {
+			// Pick the lowest missing indices
+			var missing []int
+			for i := 0; len(missing) < toCreate && i < desiredCount*2+len(parked[pool.Name]); i++ {
 				if !existingIndices[i] {
-					workerName := fmt.Sprintf("%s-worker-%s-%d", cluster.Name, pool.Name, i)
-					
-					instanceConfig := provider.InstanceConfig{
-						Name:         workerName,
-						InstanceType: pool.InstanceType,
-						Region:       cluster.Spec.Region,
-						Tags: map[string]string{
-							"goman-cluster":    cluster.Name,
-							"goman-role":       "worker",
-							"goman-nodepool":   pool.Name,
-							"goman-master-ip":  masterIP,
-							"goman-node-token": nodeToken,
-							"ManagedBy":        "goman",
-						},
-					}
-					
-					// Apply labels as tags if present
-					for k, v := range pool.Labels {
-						instanceConfig.Tags[fmt.Sprintf("k8s-label-%s", k)] = v
-					}
-					
-					instance, err := computeService.CreateInstance(ctx, instanceConfig)
-					if err != nil {
-						log.Printf("[NODEPOOLS] Failed to create worker %s: %v", workerName, err)
-						continue
-					}
-					
-					log.Printf("[NODEPOOLS] Created worker node %s (%s) in pool '%s'", workerName, instance.ID, pool.Name)
-					createdCount++
-					
-					// Add the newly created instance to actualInstances so it gets included in status
-					actualInstances[instance.ID] = instance
+					missing = append(missing, i)
 				}
 			}
+			
+			// Spread the new workers across the pool's zones
+			zones := nextZones(pool, poolWorkers, len(missing))
+			
+			// Create workers with bounded parallelism
+			var createdMu sync.Mutex
+			forEachNode(ctx, len(missing), r.settings.nodeParallelism(len(missing)), func(ctx context.Context, n int) error {
+				workerName := fmt.Sprintf("%s-worker-%s-%d", cluster.Name, pool.Name, missing[n])
+				
+				instanceConfig := workerInstanceConfig(cluster, workerName, pool, masterIP, nodeToken)
+				instanceConfig.AvailabilityZone = zones[n]
+
+				instance, err := computeService.CreateInstance(ctx, instanceConfig)
+				if err != nil {
+					logger.Warnf(ctx, "[NODEPOOLS] Failed to create worker %s: %v", workerName, err)
+					return err
+				}
+				
+				logger.Infof(ctx, "[NODEPOOLS] Created worker node %s (%s) in pool '%s'", workerName, instance.ID, pool.Name)
+				r.recordInstanceProvisioned(ctx, cluster.Name, instanceConfig, instance)
+				
+				// Add the newly created instance to actualInstances so it gets included in status
+				createdMu.Lock()
+				actualInstances[instance.ID] = instance
+				createdMu.Unlock()
+				return nil
+			})
 		} else {
-			log.Printf("[NODEPOOLS] Pool '%s' has correct number of workers", pool.Name)
+			logger.Infof(ctx, "[NODEPOOLS] Pool '%s' has correct number of workers", pool.Name)
 		}
 	}
 	
@@ -967,6 +1215,8 @@ func (r *Reconciler) reconcileNodePools(ctx context.Context, cluster *models.Clu
 				PrivateIP:  inst.PrivateIP,
 				PublicIP:   inst.PublicIP,
 				LaunchTime: inst.LaunchTime,
+
+				AvailabilityZone: inst.AvailabilityZone,
 			})
 		}
 	}
@@ -984,7 +1234,7 @@ func (r *Reconciler) reconcileNodePools(ctx context.Context, cluster *models.Clu
 	orphanedWorkers := []models.InstanceStatus{}
 	for poolName, workers := range existingWorkers {
 		if !configuredPools[poolName] && poolName != "" {
-			log.Printf("[NODEPOOLS] Pool '%s' no longer exists in config, removing %d workers", poolName, len(workers))
+			logger.Infof(ctx, "[NODEPOOLS] Pool '%s' no longer exists in config, removing %d workers", poolName, len(workers))
 			orphanedWorkers = append(orphanedWorkers, workers...)
 		}
 	}
@@ -1004,64 +1254,54 @@ func (r *Reconciler) reconcileNodePools(ctx context.Context, cluster *models.Clu
 			}
 		}
 		if !hasPool {
-			log.Printf("[NODEPOOLS] Worker %s has no pool assignment, marking for removal", worker.Name)
+			logger.Infof(ctx, "[NODEPOOLS] Worker %s has no pool assignment, marking for removal", worker.Name)
 			orphanedWorkers = append(orphanedWorkers, worker)
 		}
 	}
 	
 	// Remove orphaned workers
 	if len(orphanedWorkers) > 0 {
-		log.Printf("[NODEPOOLS] Removing %d workers from deleted pools", len(orphanedWorkers))
+		logger.Infof(ctx, "[NODEPOOLS] Removing %d workers from deleted pools", len(orphanedWorkers))
 		
-		// Get master instance for kubectl commands
-		var masterInstanceID string
-		for _, inst := range cluster.Status.Instances {
-			if inst.Role == "master" && inst.State == "running" {
-				masterInstanceID = inst.InstanceID
-				break
-			}
-		}
-		
-		for _, worker := range orphanedWorkers {
-			log.Printf("[NODEPOOLS] Removing orphaned worker %s (%s)", worker.Name, worker.InstanceID)
+		forEachNode(ctx, len(orphanedWorkers), r.settings.nodeParallelism(len(orphanedWorkers)), func(ctx context.Context, i int) error {
+			worker := orphanedWorkers[i]
+			logger.Infof(ctx, "[NODEPOOLS] Removing orphaned worker %s (%s)", worker.Name, worker.InstanceID)
 			
 			// First drain and delete from K3s if master is available
 			if masterInstanceID != "" && worker.PrivateIP != "" {
 				ipParts := strings.ReplaceAll(worker.PrivateIP, ".", "-")
 				nodeName := fmt.Sprintf("ip-%s.%s.compute.internal", ipParts, r.provider.Region())
 				
-				// Drain the node
-				drainCmd := fmt.Sprintf("kubectl drain %s --ignore-daemonsets --delete-emptydir-data --force --timeout=30s || true", nodeName)
-				if _, err := computeService.RunCommand(ctx, []string{masterInstanceID}, drainCmd); err != nil {
-					log.Printf("[NODEPOOLS] Warning: Failed to drain orphaned node %s: %v", nodeName, err)
-				}
-				
-				// Delete from K3s
-				deleteCmd := fmt.Sprintf("kubectl delete node %s || true", nodeName)
-				if _, err := computeService.RunCommand(ctx, []string{masterInstanceID}, deleteCmd); err != nil {
-					log.Printf("[NODEPOOLS] Warning: Failed to delete orphaned node %s from K3s: %v", nodeName, err)
+				// Drain and delete from K3s
+				if _, err := r.drainNode(ctx, masterInstanceID, nodeName, "30s", true); err != nil {
+					logger.Warnf(ctx, "[NODEPOOLS] Warning: Failed to remove orphaned node %s from K3s: %v", nodeName, err)
 				}
 			}
 			
 			// Terminate the EC2 instance
 			if err := computeService.DeleteInstance(ctx, worker.InstanceID); err != nil {
-				log.Printf("[NODEPOOLS] Error terminating orphaned instance %s: %v", worker.InstanceID, err)
+				logger.Warnf(ctx, "[NODEPOOLS] Error terminating orphaned instance %s: %v", worker.InstanceID, err)
 			} else {
-				log.Printf("[NODEPOOLS] Terminated orphaned instance %s", worker.InstanceID)
+				logger.Infof(ctx, "[NODEPOOLS] Terminated orphaned instance %s", worker.InstanceID)
 			}
-			
-			// Remove from status
-			newStatusInstances := []models.InstanceStatus{}
-			for _, inst := range cluster.Status.Instances {
-				if inst.InstanceID != worker.InstanceID {
-					newStatusInstances = append(newStatusInstances, inst)
-				}
+			return nil
+		})
+		
+		// Remove from status
+		orphaned := make(map[string]bool)
+		for _, worker := range orphanedWorkers {
+			orphaned[worker.InstanceID] = true
+		}
+		newStatusInstances := []models.InstanceStatus{}
+		for _, inst := range cluster.Status.Instances {
+			if !orphaned[inst.InstanceID] {
+				newStatusInstances = append(newStatusInstances, inst)
 			}
-			cluster.Status.Instances = newStatusInstances
 		}
+		cluster.Status.Instances = newStatusInstances
 	}
 	
-	log.Printf("[NODEPOOLS] Node pool reconciliation completed for cluster %s", cluster.Name)
+	logger.Infof(ctx, "[NODEPOOLS] Node pool reconciliation completed for cluster %s", cluster.Name)
 	return nil
 }
 
@@ -1079,13 +1319,12 @@ func extractWorkerIndex(name string) int {
 // provisionNodePools provisions worker node pools
 func (r *Reconciler) provisionNodePools(ctx context.Context, cluster *models.ClusterResource) error {
 	computeService := r.provider.GetComputeService()
-	storageService := r.provider.GetStorageService()
 	
 	// Get first master's IP for workers to join
 	var masterIP string
-	log.Printf("[NODEPOOLS] Looking for master IP, total instances: %d", len(cluster.Status.Instances))
+	logger.Infof(ctx, "[NODEPOOLS] Looking for master IP, total instances: %d", len(cluster.Status.Instances))
 	for _, inst := range cluster.Status.Instances {
-		log.Printf("[NODEPOOLS] Instance %s: role=%s, privateIP=%s", inst.InstanceID, inst.Role, inst.PrivateIP)
+		logger.Infof(ctx, "[NODEPOOLS] Instance %s: role=%s, privateIP=%s", inst.InstanceID, inst.Role, inst.PrivateIP)
 		if inst.Role == "master" && inst.PrivateIP != "" {
 			masterIP = inst.PrivateIP
 			break
@@ -1093,19 +1332,18 @@ func (r *Reconciler) provisionNodePools(ctx context.Context, cluster *models.Clu
 	}
 	
 	if masterIP == "" {
-		return fmt.Errorf("no master node IP found for worker nodes to join")
+		return provider.BootstrapErrorf("no master node IP found for worker nodes to join")
 	}
 	
-	// Get the node token from S3 for workers to join
-	nodeTokenKey := fmt.Sprintf("clusters/%s/k3s-node-token", cluster.Name)
-	nodeTokenData, err := storageService.GetObject(ctx, nodeTokenKey)
+	// Get the node token from the secret backend for workers to join
+	secretService := r.provider.GetSecretService()
+	nodeTokenData, err := secretService.GetSecret(ctx, cluster.Name, provider.SecretNodeToken)
 	if err != nil {
 		// Fallback to agent token for backward compatibility
-		log.Printf("[NODEPOOLS] Failed to get node token, trying agent token: %v", err)
-		agentTokenKey := fmt.Sprintf("clusters/%s/k3s-agent-token", cluster.Name)
-		nodeTokenData, err = storageService.GetObject(ctx, agentTokenKey)
+		logger.Warnf(ctx, "[NODEPOOLS] Failed to get node token, trying agent token: %v", err)
+		nodeTokenData, err = secretService.GetSecret(ctx, cluster.Name, provider.SecretAgentToken)
 		if err != nil {
-			return fmt.Errorf("failed to get join token for workers: %w", err)
+			return provider.BootstrapErrorf("failed to get join token for workers: %w", err)
 		}
 	}
 	nodeToken := strings.TrimSpace(string(nodeTokenData))
@@ -1118,70 +1356,72 @@ func (r *Reconciler) provisionNodePools(ctx context.Context, cluster *models.Clu
 		}
 	}
 	
-	// Provision each node pool
+	// Collect the workers to create across all pools
+	type pendingWorker struct {
+		name string
+		pool models.NodePool
+		zone string
+	}
+	var pending []pendingWorker
 	for _, pool := range cluster.Spec.NodePools {
-		log.Printf("[NODEPOOLS] Provisioning node pool '%s' with %d nodes", pool.Name, pool.Count)
+		logger.Infof(ctx, "[NODEPOOLS] Provisioning node pool '%s' with %d nodes", pool.Name, pool.Count)
 		
+		var names []string
 		for i := 0; i < pool.Count; i++ {
 			workerName := fmt.Sprintf("%s-worker-%s-%d", cluster.Name, pool.Name, i)
 			
 			// Skip if already exists
 			if existingWorkers[workerName] {
-				log.Printf("[NODEPOOLS] Worker %s already exists, skipping", workerName)
+				logger.Infof(ctx, "[NODEPOOLS] Worker %s already exists, skipping", workerName)
 				continue
 			}
-			
-			// Prepare instance configuration
-			instanceConfig := provider.InstanceConfig{
-				Name:         workerName,
-				Region:       cluster.Spec.Region,
-				InstanceType: pool.InstanceType,
-				Tags: map[string]string{
-					"goman-cluster":     cluster.Name,
-					"goman-role":        "worker",
-					"goman-nodepool":    pool.Name,
-					"goman-master-ip":   masterIP,
-					"goman-node-token":  nodeToken,
-					"ManagedBy":         "goman",
-				},
-			}
-			
-			// Add Kubernetes labels as tags (prefixed with k8s-label-)
-			for k, v := range pool.Labels {
-				instanceConfig.Tags[fmt.Sprintf("k8s-label-%s", k)] = v
-			}
-			
-			// Add taints as tags if present (for later application via kubectl)
-			if len(pool.Taints) > 0 {
-				taintStrings := []string{}
-				for _, taint := range pool.Taints {
-					taintStrings = append(taintStrings, fmt.Sprintf("%s=%s:%s", taint.Key, taint.Value, taint.Effect))
-				}
-				instanceConfig.Tags["k8s-taints"] = strings.Join(taintStrings, ",")
-			}
-			
-			// Create the instance
-			instance, err := computeService.CreateInstance(ctx, instanceConfig)
-			if err != nil {
-				log.Printf("[NODEPOOLS] Failed to create worker %s: %v", workerName, err)
-				continue // Continue with other workers
-			}
-			
-			// Add to cluster status
-			instanceStatus := models.InstanceStatus{
-				InstanceID: instance.ID,
-				Name:       workerName,
-				Role:       "worker",
-				State:      instance.State,
-				LaunchTime: time.Now(),
-			}
-			cluster.Status.Instances = append(cluster.Status.Instances, instanceStatus)
-			
-			log.Printf("[NODEPOOLS] Created worker node %s (%s) in pool '%s'", workerName, instance.ID, pool.Name)
+			names = append(names, workerName)
+		}
+		
+		// Spread the new workers across the pool's zones
+		zones := nextZones(pool, poolWorkersOf(cluster, pool.Name), len(names))
+		for i, workerName := range names {
+			pending = append(pending, pendingWorker{name: workerName, pool: pool, zone: zones[i]})
 		}
 	}
 	
-	log.Printf("[NODEPOOLS] Node pool provisioning completed for cluster %s", cluster.Name)
+	// Create workers with bounded parallelism
+	var statusMu sync.Mutex
+	forEachNode(ctx, len(pending), r.settings.nodeParallelism(len(pending)), func(ctx context.Context, n int) error {
+		workerName := pending[n].name
+		pool := pending[n].pool
+		
+		// Prepare instance configuration
+		instanceConfig := workerInstanceConfig(cluster, workerName, pool, masterIP, nodeToken)
+		instanceConfig.AvailabilityZone = pending[n].zone
+		
+		// Create the instance
+		instance, err := computeService.CreateInstance(ctx, instanceConfig)
+		if err != nil {
+			logger.Warnf(ctx, "[NODEPOOLS] Failed to create worker %s: %v", workerName, err)
+			return err // Continue with other workers
+		}
+		
+		// Add to cluster status
+		instanceStatus := models.InstanceStatus{
+			InstanceID: instance.ID,
+			Name:       workerName,
+			Role:       "worker",
+			State:      instance.State,
+			LaunchTime: time.Now(),
+
+			AvailabilityZone: instance.AvailabilityZone,
+		}
+		statusMu.Lock()
+		cluster.Status.Instances = append(cluster.Status.Instances, instanceStatus)
+		statusMu.Unlock()
+		
+		logger.Infof(ctx, "[NODEPOOLS] Created worker node %s (%s) in pool '%s'", workerName, instance.ID, pool.Name)
+		r.recordInstanceProvisioned(ctx, cluster.Name, instanceConfig, instance)
+		return nil
+	})
+	
+	logger.Infof(ctx, "[NODEPOOLS] Node pool provisioning completed for cluster %s", cluster.Name)
 	return nil
 }
 
@@ -1194,22 +1434,20 @@ func (r *Reconciler) generateToken() (string, error) {
 	return base64.URLEncoding.EncodeToString(bytes), nil
 }
 
-// saveTokens saves K3s tokens to S3
+// saveTokens saves K3s tokens to the secret backend
 func (r *Reconciler) saveTokens(ctx context.Context, clusterName, masterToken, workerToken string) error {
-	storageService := r.provider.GetStorageService()
+	secretService := r.provider.GetSecretService()
 	
 	// Save master token
-	masterTokenKey := fmt.Sprintf("clusters/%s/k3s-server-token", clusterName)
-	if err := storageService.PutObject(ctx, masterTokenKey, []byte(masterToken)); err != nil {
+	if err := secretService.PutSecret(ctx, clusterName, provider.SecretServerToken, []byte(masterToken)); err != nil {
 		return fmt.Errorf("failed to save master token: %w", err)
 	}
 	
 	// Save worker token  
-	workerTokenKey := fmt.Sprintf("clusters/%s/k3s-agent-token", clusterName)
-	if err := storageService.PutObject(ctx, workerTokenKey, []byte(workerToken)); err != nil {
+	if err := secretService.PutSecret(ctx, clusterName, provider.SecretAgentToken, []byte(workerToken)); err != nil {
 		return fmt.Errorf("failed to save worker token: %w", err)
 	}
 	
-	log.Printf("[TOKENS] Saved K3s tokens for cluster %s", clusterName)
+	logger.Infof(ctx, "[TOKENS] Saved K3s tokens for cluster %s in %s", clusterName, secretService.Backend())
 	return nil
 }
\ No newline at end of file
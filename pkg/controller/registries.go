@@ -0,0 +1,83 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/madhouselabs/goman/pkg/logger"
+	"github.com/madhouselabs/goman/pkg/models"
+	"github.com/madhouselabs/goman/pkg/provider"
+)
+
+// reconcileRegistries applies the registry mirrors and credentials of the
+// spec to running nodes that don't have them yet, all nodes again after a
+// change. Nodes rewrite K3s's registries.yaml and restart K3s if it
+// changed. Taking the registries out of the spec puts the nodes back on the
+// bootstrap mirrors.
+func (r *Reconciler) reconcileRegistries(ctx context.Context, cluster *models.ClusterResource) error {
+	spec := cluster.Spec.Registries
+	if err := models.ValidateRegistries(cluster.Name, spec, cluster.Spec.Bootstrap); err != nil {
+		return err
+	}
+	status := cluster.Status.Registries
+	if !spec.Enabled() && status == nil {
+		return nil
+	}
+
+	key := spec.Key()
+	if status == nil || status.Key != key {
+		logger.Infof(ctx, "[REGISTRIES] Applying registry configuration %s to nodes of cluster %s", key, cluster.Name)
+		status = &models.RegistryStatus{Key: key}
+		cluster.Status.Registries = status
+	}
+
+	known := make(map[string]bool)
+	var pending []string
+	for _, inst := range cluster.Status.Instances {
+		known[inst.InstanceID] = true
+		if inst.State == "running" && !status.HasNode(inst.InstanceID) {
+			pending = append(pending, inst.InstanceID)
+		}
+	}
+	var nodes []string
+	for _, id := range status.Nodes {
+		if known[id] {
+			nodes = append(nodes, id)
+		}
+	}
+	status.Nodes = nodes
+
+	if len(pending) > 0 {
+		config := registryConfig(cluster)
+		config.Region = r.provider.Region()
+		result, err := r.runCommand(ctx, "registries", pending, provider.ApplyRegistriesScript(config))
+		if err != nil {
+			return fmt.Errorf("failed to apply registry configuration: %w", err)
+		}
+		var errs []error
+		for _, id := range pending {
+			res := result.Instances[id]
+			switch {
+			case res == nil:
+				errs = append(errs, fmt.Errorf("%s: command returned no result", id))
+			case res.Status != "Success":
+				errs = append(errs, fmt.Errorf("%s: %s", id, res.Error))
+			default:
+				status.Nodes = append(status.Nodes, id)
+			}
+		}
+		now := time.Now()
+		status.AppliedAt = &now
+		if err := errors.Join(errs...); err != nil {
+			return fmt.Errorf("failed to apply registry configuration: %w", err)
+		}
+	}
+
+	// Every node is back to the bootstrap mirrors
+	if !spec.Enabled() && len(pending) == 0 {
+		cluster.Status.Registries = nil
+	}
+	return nil
+}
@@ -0,0 +1,136 @@
+package controller
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/madhouselabs/goman/pkg/models"
+	"github.com/madhouselabs/goman/pkg/provider"
+)
+
+// registryCompute records the instances each script ran on, failing those
+// in fail
+type registryCompute struct {
+	provider.ComputeService
+	runs    [][]string
+	scripts []string
+	fail    map[string]bool
+}
+
+func (c *registryCompute) RunCommand(ctx context.Context, ids []string, command string) (*provider.CommandResult, error) {
+	c.runs = append(c.runs, ids)
+	c.scripts = append(c.scripts, command)
+	result := &provider.CommandResult{Status: "Success", Instances: make(map[string]*provider.InstanceCommandResult)}
+	for _, id := range ids {
+		if c.fail[id] {
+			result.Instances[id] = &provider.InstanceCommandResult{Status: "Failed", Error: "access denied"}
+			continue
+		}
+		result.Instances[id] = &provider.InstanceCommandResult{Status: "Success"}
+	}
+	return result, nil
+}
+
+func TestValidateRegistries(t *testing.T) {
+	valid := &models.RegistrySpec{
+		Mirrors: map[string][]string{"docker.io": {"https://mirror.corp"}},
+		Auth: map[string]models.RegistryAuth{
+			"mirror.corp": {Credentials: "ssm:/goman/demo/mirror"},
+			"ghcr.io":     {Credentials: "secretsmanager:goman/demo/ghcr"},
+			"123456789012.dkr.ecr.eu-west-1.amazonaws.com": {ECR: true},
+		},
+	}
+	if err := models.ValidateRegistries("demo", valid, nil); err != nil {
+		t.Fatalf("valid registries rejected: %v", err)
+	}
+	bootstrap := &models.BootstrapSpec{RegistryMirrors: map[string][]string{"docker.io": {"https://other.corp"}}}
+	if err := models.ValidateRegistries("demo", valid, bootstrap); err == nil {
+		t.Error("registry mirrored in bootstrap too accepted")
+	}
+	for _, auth := range []map[string]models.RegistryAuth{
+		{"*": {ECR: true}},
+		{"ghcr.io": {ECR: true}},
+		{"ghcr.io": {Credentials: "ssm:/goman/other/ghcr"}},
+		{"ghcr.io": {Credentials: "secretsmanager:goman/other/ghcr"}},
+		{"ghcr.io": {Credentials: "user:password"}},
+		{"123456789012.dkr.ecr.eu-west-1.amazonaws.com": {ECR: true, Credentials: "ssm:/goman/demo/ecr"}},
+	} {
+		if err := models.ValidateRegistries("demo", &models.RegistrySpec{Auth: auth}, nil); err == nil {
+			t.Errorf("invalid credentials %v accepted", auth)
+		}
+	}
+	if err := models.ValidateRegistries("demo", &models.RegistrySpec{Mirrors: map[string][]string{"docker.io": {"mirror.corp"}}}, nil); err == nil {
+		t.Error("mirror without a scheme accepted")
+	}
+}
+
+func TestReconcileRegistries(t *testing.T) {
+	compute := &registryCompute{fail: map[string]bool{"i-3": true}}
+	r := &Reconciler{provider: &fakeProvider{compute: compute, region: "ap-south-1"}, settings: DefaultSettings()}
+	cluster := &models.ClusterResource{Name: "demo"}
+	cluster.Spec.Bootstrap = &models.BootstrapSpec{RegistryMirrors: map[string][]string{"*": {"https://all.corp"}}}
+	cluster.Spec.Registries = &models.RegistrySpec{
+		Mirrors: map[string][]string{"docker.io": {"https://mirror.corp"}},
+		Auth:    map[string]models.RegistryAuth{"mirror.corp": {Credentials: "ssm:/goman/demo/mirror"}},
+	}
+	cluster.Status.Instances = []models.InstanceStatus{
+		{InstanceID: "i-1", Role: "master", State: "running"},
+		{InstanceID: "i-2", Role: "worker", State: "running"},
+		{InstanceID: "i-3", Role: "worker", State: "running"},
+		{InstanceID: "i-4", Role: "worker", State: "pending"},
+	}
+	ctx := context.Background()
+
+	// A node failing to apply is tried again on the next reconcile
+	if err := r.reconcileRegistries(ctx, cluster); err == nil || !strings.Contains(err.Error(), "access denied") {
+		t.Fatalf("reconcile error = %v", err)
+	}
+	if strings.Join(compute.runs[0], ",") != "i-1,i-2,i-3" {
+		t.Errorf("applied to %v", compute.runs[0])
+	}
+	for _, want := range []string{
+		`"*":`, `"https://all.corp"`, `"docker.io":`,
+		"aws ssm get-parameter --region 'ap-south-1' --with-decryption --name '/goman/demo/mirror'",
+		"systemctl enable --now goman-registries.timer",
+	} {
+		if !strings.Contains(compute.scripts[0], want) {
+			t.Errorf("script lacks %q", want)
+		}
+	}
+	st := cluster.Status.Registries
+	if st == nil || st.Key != cluster.Spec.Registries.Key() || strings.Join(st.Nodes, ",") != "i-1,i-2" || st.AppliedAt == nil {
+		t.Fatalf("status = %+v", st)
+	}
+
+	delete(compute.fail, "i-3")
+	if err := r.reconcileRegistries(ctx, cluster); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(compute.runs[1], ",") != "i-3" {
+		t.Errorf("retried on %v", compute.runs[1])
+	}
+
+	// Nothing to do once applied
+	if err := r.reconcileRegistries(ctx, cluster); err != nil || len(compute.runs) != 2 {
+		t.Fatalf("third reconcile ran %v, %v", compute.runs, err)
+	}
+
+	// Removing the registries puts every node back on the bootstrap mirrors
+	// and units, then clears the status
+	cluster.Spec.Registries = nil
+	if err := r.reconcileRegistries(ctx, cluster); err != nil {
+		t.Fatal(err)
+	}
+	script := compute.scripts[2]
+	if strings.Join(compute.runs[2], ",") != "i-1,i-2,i-3" || !strings.Contains(script, `"https://all.corp"`) ||
+		strings.Contains(script, "mirror.corp") || !strings.Contains(script, "systemctl disable --now goman-registries.timer") {
+		t.Errorf("removal on %v:\n%s", compute.runs[2], script)
+	}
+	if cluster.Status.Registries == nil {
+		t.Fatal("status cleared before the nodes were updated")
+	}
+	if err := r.reconcileRegistries(ctx, cluster); err != nil || cluster.Status.Registries != nil {
+		t.Errorf("status after removal = %+v, %v", cluster.Status.Registries, err)
+	}
+}
@@ -0,0 +1,228 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/madhouselabs/goman/pkg/logger"
+	"github.com/madhouselabs/goman/pkg/models"
+	"github.com/madhouselabs/goman/pkg/provider"
+)
+
+// resyncNodesCmd lists the K3s nodes, one "<internal IP> <Ready status>
+// <kubelet version>" per line
+const resyncNodesCmd = `kubectl get nodes -o jsonpath='{range .items[*]}{.status.addresses[?(@.type=="InternalIP")].address}{" "}{.status.conditions[?(@.type=="Ready")].status}{" "}{.status.nodeInfo.kubeletVersion}{"\n"}{end}'`
+
+// resyncNode is a K3s node as seen by the API server
+type resyncNode struct {
+	ready   bool
+	version string
+}
+
+// resyncRequested reports whether a status resync was requested after the
+// last one
+func resyncRequested(cluster *models.ClusterResource) bool {
+	requested := cluster.Spec.ResyncRequestedAt
+	if requested == nil {
+		return false
+	}
+	done := cluster.Status.ResyncedAt
+	return done == nil || done.Before(*requested)
+}
+
+// resyncStatus replaces the status of a cluster with one rebuilt from the
+// live state: its instances from the cloud, the K3s nodes from a running
+// master, the join tokens from the secret backend and the API endpoint from
+// the stored kubeconfig, fetched again from a master if it is missing. Progress of rollouts, upgrades and other
+// operations is dropped, so they start over. The phase follows what was
+// found: Running with a running master, Stopped when all instances are
+// stopped, and Pending, provisioning the cluster again, without instances.
+func (r *Reconciler) resyncStatus(ctx context.Context, cluster *models.ClusterResource) error {
+	logger.Infof(ctx, "[RESYNC] Rebuilding status of cluster %s from the live state", cluster.Name)
+
+	instances, err := r.provider.GetComputeService().ListInstances(ctx, map[string]string{
+		"tag:goman-cluster":   cluster.Name,
+		"instance-state-name": "pending,running,stopping,stopped",
+	})
+	if err != nil {
+		return fmt.Errorf("failed to list instances: %w", err)
+	}
+
+	status := models.ClusterResourceStatus{
+		// Spec processing is not part of the live state
+		ObservedGeneration: cluster.Status.ObservedGeneration,
+		LastIntent:         cluster.Status.LastIntent,
+	}
+	for _, inst := range instances {
+		role := inst.Tags["goman-role"]
+		if role == "worker" && inst.Tags[parkedAtTag] != "" {
+			// Stopped for reuse, never listed
+			continue
+		}
+		if role == "" {
+			role = "worker"
+			if strings.Contains(inst.Name, "-master-") {
+				role = "master"
+			}
+		}
+		status.Instances = append(status.Instances, models.InstanceStatus{
+			InstanceID:       inst.ID,
+			Name:             inst.Name,
+			Role:             role,
+			State:            inst.State,
+			PrivateIP:        inst.PrivateIP,
+			PublicIP:         inst.PublicIP,
+			LaunchTime:       inst.LaunchTime,
+			InstanceType:     inst.InstanceType,
+			AvailabilityZone: inst.AvailabilityZone,
+			Region:           inst.Region,
+		})
+	}
+	sort.SliceStable(status.Instances, func(i, j int) bool {
+		a, b := status.Instances[i], status.Instances[j]
+		if a.Role != b.Role {
+			return a.Role == "master"
+		}
+		return a.Name < b.Name
+	})
+
+	var running, stopped int
+	var masters []models.InstanceStatus
+	for _, inst := range status.Instances {
+		switch inst.State {
+		case "running":
+			running++
+		case "stopping", "stopped":
+			stopped++
+		}
+		if inst.Role != "master" {
+			continue
+		}
+		status.MasterInstanceIDs = append(status.MasterInstanceIDs, inst.InstanceID)
+		if inst.State == "running" {
+			masters = append(masters, inst)
+		}
+	}
+	if len(masters) > 0 {
+		status.PreferredMasterInstance = masters[0].InstanceID
+	}
+
+	nodes := r.resyncNodes(ctx, masters)
+	for i, inst := range status.Instances {
+		if node, ok := nodes[inst.PrivateIP]; ok {
+			status.Instances[i].K3sInstalled = true
+			status.Instances[i].K3sRunning = node.ready
+			status.Instances[i].K3sVersion = node.version
+		}
+	}
+
+	// Join tokens, kept in status for nodes launched later
+	secretService := r.provider.GetSecretService()
+	if token, err := secretService.GetSecret(ctx, cluster.Name, provider.SecretServerToken); err == nil {
+		status.K3sServerToken = strings.TrimSpace(string(token))
+	} else {
+		logger.Warnf(ctx, "[RESYNC] Warning: No server token for cluster %s: %v", cluster.Name, err)
+	}
+	if token, err := secretService.GetSecret(ctx, cluster.Name, provider.SecretAgentToken); err == nil {
+		status.K3sAgentToken = strings.TrimSpace(string(token))
+	}
+
+	endpoint, err := r.resyncAPIEndpoint(ctx, cluster.Name, masters)
+	if err != nil {
+		logger.Warnf(ctx, "[RESYNC] Warning: No API endpoint for cluster %s: %v", cluster.Name, err)
+	}
+	status.APIEndpoint = endpoint
+
+	switch {
+	case len(status.Instances) == 0:
+		status.Phase = string(models.ClusterPhasePending)
+		status.Message = "No instances found; the cluster is provisioned again"
+	case len(masters) > 0 && nodes != nil:
+		status.Phase = string(models.ClusterPhaseRunning)
+		status.Message = "K3s cluster is running and ready"
+	case len(masters) > 0:
+		status.Phase = string(models.ClusterPhaseRunning)
+		status.Message = "K3s API server is not answering on the masters"
+	case running == 0 && stopped > 0:
+		status.Phase = string(models.ClusterPhaseStopped)
+		status.Message = "All instances are stopped"
+	default:
+		status.Phase = string(models.ClusterPhaseProvisioning)
+		status.Message = "Waiting for a master to run"
+	}
+
+	now := time.Now()
+	status.ResyncedAt = &now
+	saved, err := r.patchClusterStatus(ctx, cluster.Name, func(stored *models.ClusterResourceStatus) error {
+		*stored = status
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	cluster.Status = *saved
+
+	logger.Infof(ctx, "[RESYNC] Status of cluster %s rebuilt: %d instances, phase %s", cluster.Name, len(status.Instances), status.Phase)
+	r.events.Normal(ctx, cluster.Name, EventResynced, "", "Status rebuilt from the live state: %d instances, %d K3s nodes, phase %s",
+		len(status.Instances), len(nodes), status.Phase)
+	return nil
+}
+
+// resyncNodes lists the K3s nodes by internal IP from the first master
+// whose API server answers; nil if none does
+func (r *Reconciler) resyncNodes(ctx context.Context, masters []models.InstanceStatus) map[string]resyncNode {
+	for _, m := range masters {
+		output, err := r.runOnMaster(ctx, m, "resync-nodes", resyncNodesCmd, false)
+		if err != nil {
+			logger.Warnf(ctx, "[RESYNC] Failed to list K3s nodes from %s: %v", m.Name, err)
+			continue
+		}
+		nodes := make(map[string]resyncNode)
+		for _, line := range strings.Split(strings.TrimSpace(output), "\n") {
+			fields := strings.Fields(line)
+			if len(fields) < 2 {
+				continue
+			}
+			node := resyncNode{ready: fields[1] == "True"}
+			if len(fields) > 2 {
+				node.version = fields[2]
+			}
+			nodes[fields[0]] = node
+		}
+		return nodes
+	}
+	return nil
+}
+
+// resyncAPIEndpoint returns the API server of the stored kubeconfig. A
+// missing kubeconfig is stored again from the first running master.
+func (r *Reconciler) resyncAPIEndpoint(ctx context.Context, clusterName string, masters []models.InstanceStatus) (string, error) {
+	secretService := r.provider.GetSecretService()
+	kubeconfig, err := secretService.GetSecret(ctx, clusterName, provider.SecretKubeconfig)
+	if err != nil && !errors.Is(err, provider.ErrNotFound) {
+		return "", fmt.Errorf("failed to read kubeconfig: %w", err)
+	}
+	if err != nil {
+		if len(masters) == 0 {
+			return "", fmt.Errorf("no kubeconfig stored and no running master to read it from")
+		}
+		output, err := r.runOnMaster(ctx, masters[0], "read-kubeconfig", kubeconfigScript, true)
+		if err != nil {
+			return "", fmt.Errorf("failed to read kubeconfig of %s: %w", masters[0].Name, err)
+		}
+		kubeconfig = []byte(output)
+		if err := secretService.PutSecret(ctx, clusterName, provider.SecretKubeconfig, kubeconfig); err != nil {
+			return "", fmt.Errorf("failed to save kubeconfig: %w", err)
+		}
+		logger.Infof(ctx, "[RESYNC] Stored kubeconfig of cluster %s again from %s", clusterName, masters[0].Name)
+	}
+	m := kubeconfigServerValuePattern.FindSubmatch(kubeconfig)
+	if m == nil {
+		return "", fmt.Errorf("stored kubeconfig has no server")
+	}
+	return string(m[1]), nil
+}
@@ -0,0 +1,253 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/madhouselabs/goman/pkg/logger"
+	"github.com/madhouselabs/goman/pkg/models"
+	"github.com/madhouselabs/goman/pkg/provider"
+)
+
+// reconcileRollout replaces workers launched with an outdated pool instance
+// type, one batch at a time, using the node replacement steps.
+//
+// Batches are checkpoints: a pause requested in the spec takes effect once
+// the current batch has finished, and a batch with a failed replacement
+// halts the rollout until it is resumed. Returns true while a batch is in
+// progress so node pool reconciliation does not remove the extra workers.
+func (r *Reconciler) reconcileRollout(ctx context.Context, cluster *models.ClusterResource) (bool, error) {
+	rollout := cluster.Status.Rollout
+
+	// A paused pool keeps its nodes, also in the middle of a batch
+	if rollout.Active() {
+		if pool, ok := findNodePool(cluster, rollout.Pool); ok && pool.Paused {
+			rollout.Message = fmt.Sprintf("node pool paused, batch %d/%d", rollout.BatchIndex, rollout.BatchCount)
+			return false, nil
+		}
+	}
+
+	if rollout.Active() && len(rollout.Batch) > 0 {
+		masterInstanceID := runningMasterID(cluster)
+		if masterInstanceID == "" {
+			return false, fmt.Errorf("no running master found to roll out pool %s", rollout.Pool)
+		}
+		for i := range rollout.Batch {
+			st := &rollout.Batch[i]
+			if st.Done() {
+				continue
+			}
+			if err := r.advanceNodeReplacement(ctx, cluster, masterInstanceID, st); err != nil {
+				return false, err
+			}
+		}
+		rollout.UpdatedAt = time.Now()
+		if rollout.BatchInProgress() {
+			rollout.Message = fmt.Sprintf("batch %d/%d: %s", rollout.BatchIndex, rollout.BatchCount, batchSummary(rollout.Batch))
+			cluster.Status.Message = fmt.Sprintf("Rolling out pool %s to %s, %s", rollout.Pool, rollout.ToInstanceType, rollout.Message)
+			return true, nil
+		}
+		r.recordRolloutBatch(ctx, cluster, rollout)
+		r.finishRolloutBatch(ctx, rollout)
+	}
+
+	// Between batches: pick up spec changes and pause requests here
+	outdated, err := r.outdatedWorkers(ctx, cluster)
+	if err != nil {
+		return false, err
+	}
+
+	if !rollout.Active() || !samePoolTarget(cluster, rollout) {
+		if rollout.Active() {
+			logger.Infof(ctx, "[ROLLOUT] Pool %s changed during rollout, restarting", rollout.Pool)
+		}
+		rollout = newRollout(cluster, outdated)
+		if rollout == nil {
+			if cluster.Status.Rollout.Active() {
+				completeRollout(cluster.Status.Rollout, "pool no longer needs a rollout")
+			}
+			return false, nil
+		}
+		cluster.Status.Rollout = rollout
+		logger.Infof(ctx, "[ROLLOUT] Starting rollout of pool %s to %s (%d nodes)", rollout.Pool, rollout.ToInstanceType, len(outdated[rollout.Pool]))
+	}
+
+	nodes := outdated[rollout.Pool]
+	if len(nodes) == 0 {
+		completeRollout(rollout, fmt.Sprintf("replaced %d node(s)", rollout.Replaced))
+		logger.Infof(ctx, "[ROLLOUT] Rollout of pool %s completed", rollout.Pool)
+		return false, nil
+	}
+
+	spec := cluster.Spec.Rollout
+	if spec.IsPaused() {
+		if rollout.Phase != models.RolloutPaused {
+			logger.Infof(ctx, "[ROLLOUT] Rollout of pool %s paused after batch %d", rollout.Pool, rollout.BatchIndex)
+		}
+		rollout.Phase = models.RolloutPaused
+		rollout.Message = fmt.Sprintf("paused after batch %d/%d, %d node(s) left", rollout.BatchIndex, rollout.BatchCount, len(nodes))
+		return false, nil
+	}
+	if rollout.HaltedAt != nil {
+		if spec == nil || spec.ResumedAt == nil || !spec.ResumedAt.After(*rollout.HaltedAt) {
+			// Stays halted until resumed
+			return false, nil
+		}
+		rollout.HaltedAt = nil
+	}
+
+	// Start the next batch
+	batchSize := spec.RolloutBatchSize()
+	if len(nodes) > batchSize {
+		nodes = nodes[:batchSize]
+	}
+	now := time.Now()
+	for _, node := range nodes {
+		rollout.Batch = append(rollout.Batch, models.NodeReplacementStatus{
+			Node:        node,
+			RequestedAt: now,
+			Phase:       models.NodeReplacementDraining,
+			StartedAt:   &now,
+		})
+	}
+	rollout.Phase = models.RolloutInProgress
+	rollout.BatchSize = batchSize
+	rollout.BatchIndex++
+	rollout.BatchCount = rollout.BatchIndex + (len(outdated[rollout.Pool])-len(nodes)+batchSize-1)/batchSize
+	rollout.UpdatedAt = now
+	rollout.Message = fmt.Sprintf("batch %d/%d started", rollout.BatchIndex, rollout.BatchCount)
+	logger.Infof(ctx, "[ROLLOUT] Pool %s: starting batch %d/%d with %d node(s)", rollout.Pool, rollout.BatchIndex, rollout.BatchCount, len(nodes))
+	cluster.Status.Message = fmt.Sprintf("Rolling out pool %s to %s, %s", rollout.Pool, rollout.ToInstanceType, rollout.Message)
+	return true, nil
+}
+
+// finishRolloutBatch counts the results of a finished batch. A failed
+// replacement halts the rollout; the failed node is retried after a resume.
+func (r *Reconciler) finishRolloutBatch(ctx context.Context, rollout *models.RolloutStatus) {
+	var failures []string
+	for _, st := range rollout.Batch {
+		if st.Phase == models.NodeReplacementFailed {
+			rollout.Failed++
+			failures = append(failures, fmt.Sprintf("%s: %s", st.Node, st.Message))
+		} else {
+			rollout.Replaced++
+		}
+	}
+	logger.Infof(ctx, "[ROLLOUT] Pool %s: batch %d/%d finished", rollout.Pool, rollout.BatchIndex, rollout.BatchCount)
+
+	if len(failures) > 0 {
+		now := time.Now()
+		rollout.HaltedAt = &now
+		rollout.Phase = models.RolloutPaused
+		rollout.Message = fmt.Sprintf("halted after batch %d: %v; resume to retry", rollout.BatchIndex, failures)
+		logger.Infof(ctx, "[ROLLOUT] Pool %s halted: %v", rollout.Pool, failures)
+	}
+	rollout.Batch = nil
+}
+
+// outdatedWorkers returns, per pool, the running workers launched with an
+// instance type other than the pool's, sorted by name. Workers launched
+// before the type was tagged are left to the drift policy.
+func (r *Reconciler) outdatedWorkers(ctx context.Context, cluster *models.ClusterResource) (map[string][]string, error) {
+	filters := map[string]string{
+		"tag:goman-cluster":   cluster.Name,
+		"instance-state-name": "running",
+	}
+	instances, err := r.provider.GetComputeService().ListInstances(ctx, filters)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list instances: %w", err)
+	}
+
+	outdated := make(map[string][]*provider.Instance)
+	for _, inst := range instances {
+		if inst.Tags["goman-role"] != "worker" {
+			continue
+		}
+		pool, ok := findNodePool(cluster, inst.Tags["goman-nodepool"])
+		if !ok {
+			continue
+		}
+		launched := inst.Tags[instanceTypeTag]
+		if launched == "" || launched == pool.InstanceType {
+			continue
+		}
+		outdated[pool.Name] = append(outdated[pool.Name], inst)
+	}
+
+	result := make(map[string][]string)
+	for pool, insts := range outdated {
+		sort.Slice(insts, func(i, j int) bool { return insts[i].Name < insts[j].Name })
+		for _, inst := range insts {
+			result[pool] = append(result[pool], inst.ID)
+		}
+	}
+	return result, nil
+}
+
+// newRollout starts a rollout for the first pool in spec order with outdated
+// workers, or returns nil if no pool needs one. Paused pools are skipped.
+func newRollout(cluster *models.ClusterResource, outdated map[string][]string) *models.RolloutStatus {
+	for _, pool := range cluster.Spec.NodePools {
+		if len(outdated[pool.Name]) == 0 || pool.Paused {
+			continue
+		}
+		from := ""
+		for _, inst := range cluster.Status.Instances {
+			if inst.InstanceID == outdated[pool.Name][0] {
+				from = inst.InstanceType
+				break
+			}
+		}
+		now := time.Now()
+		return &models.RolloutStatus{
+			Pool:             pool.Name,
+			FromInstanceType: from,
+			ToInstanceType:   pool.InstanceType,
+			Phase:            models.RolloutInProgress,
+			StartedAt:        now,
+			UpdatedAt:        now,
+		}
+	}
+	return nil
+}
+
+// samePoolTarget reports whether the rollout still matches its pool in the spec
+func samePoolTarget(cluster *models.ClusterResource, rollout *models.RolloutStatus) bool {
+	pool, ok := findNodePool(cluster, rollout.Pool)
+	return ok && pool.InstanceType == rollout.ToInstanceType
+}
+
+// completeRollout marks a rollout as finished
+func completeRollout(rollout *models.RolloutStatus, message string) {
+	now := time.Now()
+	rollout.Phase = models.RolloutCompleted
+	rollout.Batch = nil
+	rollout.HaltedAt = nil
+	rollout.UpdatedAt = now
+	rollout.CompletedAt = &now
+	rollout.Message = message
+}
+
+// batchSummary describes the phases of a batch's replacements
+func batchSummary(batch []models.NodeReplacementStatus) string {
+	counts := make(map[models.NodeReplacementPhase]int)
+	for _, st := range batch {
+		counts[st.Phase]++
+	}
+	summary := ""
+	for _, phase := range []models.NodeReplacementPhase{
+		models.NodeReplacementDraining, models.NodeReplacementProvisioning, models.NodeReplacementWaitingReady,
+		models.NodeReplacementTerminating, models.NodeReplacementCompleted, models.NodeReplacementFailed,
+	} {
+		if counts[phase] == 0 {
+			continue
+		}
+		if summary != "" {
+			summary += ", "
+		}
+		summary += fmt.Sprintf("%d %s", counts[phase], phase)
+	}
+	return summary
+}
@@ -0,0 +1,102 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	"github.com/madhouselabs/goman/pkg/models"
+)
+
+func TestNewRolloutPicksFirstPoolInSpecOrder(t *testing.T) {
+	cluster := &models.ClusterResource{
+		Spec: models.ClusterSpec{
+			NodePools: []models.NodePool{
+				{Name: "default", InstanceType: "t3.large"},
+				{Name: "gpu", InstanceType: "g4dn.xlarge"},
+			},
+		},
+		Status: models.ClusterResourceStatus{
+			Instances: []models.InstanceStatus{{InstanceID: "i-1", InstanceType: "t3.medium"}},
+		},
+	}
+
+	if rollout := newRollout(cluster, map[string][]string{}); rollout != nil {
+		t.Fatalf("expected no rollout without outdated workers, got %+v", rollout)
+	}
+
+	rollout := newRollout(cluster, map[string][]string{"gpu": {"i-2"}, "default": {"i-1"}})
+	if rollout == nil {
+		t.Fatal("expected a rollout")
+	}
+	if rollout.Pool != "default" || rollout.FromInstanceType != "t3.medium" || rollout.ToInstanceType != "t3.large" {
+		t.Errorf("unexpected rollout: %+v", rollout)
+	}
+}
+
+func TestPausedPoolIsLeftAlone(t *testing.T) {
+	cluster := &models.ClusterResource{
+		Name: "demo",
+		Spec: models.ClusterSpec{
+			NodePools: []models.NodePool{
+				{Name: "default", InstanceType: "t3.large", Paused: true},
+				{Name: "gpu", InstanceType: "g4dn.xlarge"},
+			},
+		},
+		Status: models.ClusterResourceStatus{
+			Instances: []models.InstanceStatus{
+				{InstanceID: "i-1", Name: "demo-worker-default-0", Role: "worker"},
+				{InstanceID: "i-2", Name: "demo-worker-gpu-0", Role: "worker"},
+			},
+		},
+	}
+
+	rollout := newRollout(cluster, map[string][]string{"default": {"i-1"}, "gpu": {"i-2"}})
+	if rollout == nil || rollout.Pool != "gpu" {
+		t.Fatalf("expected a rollout of the unpaused pool, got %+v", rollout)
+	}
+
+	for _, node := range []string{"i-1", "demo-worker-default-0"} {
+		if pool, paused := pausedPoolOf(cluster, node); !paused || pool != "default" {
+			t.Errorf("pausedPoolOf(%s) = %q, %t, want default, true", node, pool, paused)
+		}
+	}
+	if _, paused := pausedPoolOf(cluster, "i-2"); paused {
+		t.Error("node of an unpaused pool reported as paused")
+	}
+}
+
+func TestFinishRolloutBatchHaltsOnFailure(t *testing.T) {
+	rollout := &models.RolloutStatus{
+		Pool:       "default",
+		Phase:      models.RolloutInProgress,
+		BatchIndex: 1,
+		BatchCount: 2,
+		Batch: []models.NodeReplacementStatus{
+			{Node: "i-1", Phase: models.NodeReplacementCompleted},
+			{Node: "i-2", Phase: models.NodeReplacementFailed, Message: "drain failed"},
+		},
+	}
+
+	(&Reconciler{}).finishRolloutBatch(context.Background(), rollout)
+
+	if rollout.Replaced != 1 || rollout.Failed != 1 {
+		t.Errorf("replaced/failed = %d/%d, want 1/1", rollout.Replaced, rollout.Failed)
+	}
+	if rollout.Phase != models.RolloutPaused || rollout.HaltedAt == nil {
+		t.Errorf("expected halted rollout, got phase %s", rollout.Phase)
+	}
+	if len(rollout.Batch) != 0 {
+		t.Errorf("expected batch to be cleared, got %d entries", len(rollout.Batch))
+	}
+}
+
+func TestRolloutSpecDefaults(t *testing.T) {
+	var spec *models.RolloutSpec
+	if spec.IsPaused() || spec.RolloutBatchSize() != 1 {
+		t.Errorf("nil spec: paused=%v batch=%d", spec.IsPaused(), spec.RolloutBatchSize())
+	}
+	spec = &models.RolloutSpec{BatchSize: 3, Paused: true}
+	if !spec.IsPaused() || spec.RolloutBatchSize() != 3 {
+		t.Errorf("spec: paused=%v batch=%d", spec.IsPaused(), spec.RolloutBatchSize())
+	}
+}
@@ -0,0 +1,106 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/madhouselabs/goman/pkg/logger"
+	"github.com/madhouselabs/goman/pkg/models"
+	"github.com/madhouselabs/goman/pkg/storage"
+)
+
+// applySchedule applies the stops and starts the cluster's schedule asked
+// for since the last reconcile. The desired state of the latest scheduled
+// action replaces the spec's for this reconcile, unless the cluster was
+// stopped or started by hand after it.
+func (r *Reconciler) applySchedule(ctx context.Context, cluster *models.ClusterResource, now time.Time) {
+	schedule := cluster.Spec.Schedule
+	if !schedule.Enabled() {
+		cluster.Status.Schedule = nil
+		return
+	}
+
+	st := cluster.Status.Schedule
+	if st == nil {
+		// Actions are taken from now on, not for times already past
+		st = &models.ScheduleStatus{CheckedAt: now}
+		cluster.Status.Schedule = st
+	}
+	if action, at := schedule.LastAction(st.CheckedAt, now); action != "" {
+		logger.Infof(ctx, "[SCHEDULE] Cluster %s is scheduled to be %s as of %s", cluster.Name, action, at.Format(time.RFC3339))
+		r.events.Normal(ctx, cluster.Name, EventScheduled, "", "Scheduled %s at %s", scheduleVerb(action), at.Format("2006-01-02 15:04 MST"))
+		st.LastAction = action
+		st.LastActionAt = &at
+	}
+	st.CheckedAt = now
+
+	st.NextAction, st.NextActionAt = "", nil
+	if action, at := schedule.NextAction(now); action != "" {
+		st.NextAction = action
+		st.NextActionAt = &at
+	}
+
+	if st.LastActionAt == nil {
+		return
+	}
+	if setAt := cluster.Spec.DesiredStateSetAt; setAt != nil && setAt.After(*st.LastActionAt) {
+		return
+	}
+	if cluster.Spec.DesiredState != st.LastAction {
+		logger.Infof(ctx, "[SCHEDULE] Cluster %s: desired state %s from the schedule", cluster.Name, st.LastAction)
+	}
+	cluster.Spec.DesiredState = st.LastAction
+}
+
+// ScheduleDue reports whether a scheduled stop or start of a cluster has
+// come since its last reconcile. The controller is invoked periodically to
+// reconcile the clusters for which it has.
+func (r *Reconciler) ScheduleDue(ctx context.Context, clusterName string, now time.Time) (bool, error) {
+	cluster, err := r.loadCluster(ctx, clusterName)
+	if err != nil {
+		return false, err
+	}
+	if cluster.DeletionTimestamp != nil || !cluster.Spec.Schedule.Enabled() {
+		return false, nil
+	}
+	st := cluster.Status.Schedule
+	if st == nil {
+		return true, nil
+	}
+	action, _ := cluster.Spec.Schedule.LastAction(st.CheckedAt, now)
+	return action != "", nil
+}
+
+// ScheduledClusters returns the names of the clusters due for a scheduled
+// stop or start
+func (r *Reconciler) ScheduledClusters(ctx context.Context, now time.Time) ([]string, error) {
+	keys, err := r.provider.GetStorageService().ListObjects(ctx, "clusters/")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list clusters: %w", err)
+	}
+	var due []string
+	for _, key := range keys {
+		name, ok := storage.ClusterNameFromConfigKey(key)
+		if !ok {
+			continue
+		}
+		isDue, err := r.ScheduleDue(ctx, name, now)
+		if err != nil {
+			logger.Infof(ctx, "[SCHEDULE] Skipping cluster %s: %v", name, err)
+			continue
+		}
+		if isDue {
+			due = append(due, name)
+		}
+	}
+	return due, nil
+}
+
+// scheduleVerb names a scheduled action for events
+func scheduleVerb(state string) string {
+	if state == DesiredStateStopped {
+		return "stop"
+	}
+	return "start"
+}
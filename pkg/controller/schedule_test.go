@@ -0,0 +1,117 @@
+package controller
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/madhouselabs/goman/pkg/models"
+)
+
+func TestCronNext(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skip("no time zone data")
+	}
+	tests := []struct {
+		expr  string
+		after time.Time
+		want  time.Time
+	}{
+		{"0 20 * * *", time.Date(2026, 3, 2, 19, 0, 0, 0, time.UTC), time.Date(2026, 3, 2, 20, 0, 0, 0, time.UTC)},
+		{"0 20 * * *", time.Date(2026, 3, 2, 20, 0, 0, 0, time.UTC), time.Date(2026, 3, 3, 20, 0, 0, 0, time.UTC)},
+		// Friday evening to Monday morning
+		{"0 8 * * 1-5", time.Date(2026, 3, 6, 21, 0, 0, 0, time.UTC), time.Date(2026, 3, 9, 8, 0, 0, 0, time.UTC)},
+		{"30 7 * * MON,fri", time.Date(2026, 3, 3, 0, 0, 0, 0, time.UTC), time.Date(2026, 3, 6, 7, 30, 0, 0, time.UTC)},
+		{"*/15 * * * *", time.Date(2026, 3, 2, 10, 7, 0, 0, time.UTC), time.Date(2026, 3, 2, 10, 15, 0, 0, time.UTC)},
+		{"0 0 1 jan *", time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC), time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 9 * * 7", time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC), time.Date(2026, 3, 8, 9, 0, 0, 0, time.UTC)},
+		// Local time across the change to summer time
+		{"0 20 * * *", time.Date(2026, 3, 28, 20, 0, 0, 0, berlin), time.Date(2026, 3, 29, 20, 0, 0, 0, berlin)},
+	}
+	for _, tt := range tests {
+		cron, err := models.ParseCron(tt.expr)
+		if err != nil {
+			t.Fatalf("ParseCron(%q) = %v", tt.expr, err)
+		}
+		if got := cron.Next(tt.after); !got.Equal(tt.want) {
+			t.Errorf("Next(%q, %s) = %s, want %s", tt.expr, tt.after, got, tt.want)
+		}
+	}
+
+	for _, expr := range []string{"0 20 * *", "60 * * * *", "0 8 * * 1-9", "0 8 * * fri-mon", "*/0 * * * *", "0 8 * * x"} {
+		if _, err := models.ParseCron(expr); err == nil {
+			t.Errorf("ParseCron(%q) = nil error", expr)
+		}
+	}
+}
+
+func TestValidateSchedule(t *testing.T) {
+	valid := &models.ScheduleSpec{Stop: "0 20 * * *", Start: "0 8 * * 1-5", Timezone: "UTC"}
+	if err := models.ValidateSchedule(valid); err != nil {
+		t.Errorf("ValidateSchedule() = %v", err)
+	}
+	for _, s := range []*models.ScheduleSpec{
+		{Stop: "0 20 * * *", Timezone: "Mars/Olympus"},
+		{Stop: "0 25 * * *"},
+		{Stop: "0 8 * * *", Start: "0 8 * * *"},
+	} {
+		if err := models.ValidateSchedule(s); err == nil {
+			t.Errorf("ValidateSchedule(%+v) = nil, want an error", s)
+		}
+	}
+}
+
+func TestApplySchedule(t *testing.T) {
+	r := &Reconciler{}
+	cluster := &models.ClusterResource{Name: "demo"}
+	cluster.Spec.DesiredState = DesiredStateRunning
+	cluster.Spec.Schedule = &models.ScheduleSpec{Stop: "0 20 * * *", Start: "0 8 * * 1-5"}
+	ctx := context.Background()
+	monday := func(hour, minute int) time.Time { return time.Date(2026, 3, 2, hour, minute, 0, 0, time.UTC) }
+
+	// Past times are not acted on when the schedule is first seen
+	r.applySchedule(ctx, cluster, monday(21, 0))
+	if cluster.Spec.DesiredState != DesiredStateRunning {
+		t.Fatalf("desired state %s right after adding the schedule", cluster.Spec.DesiredState)
+	}
+	if st := cluster.Status.Schedule; st == nil || st.NextAction != DesiredStateRunning || !st.NextActionAt.Equal(time.Date(2026, 3, 3, 8, 0, 0, 0, time.UTC)) {
+		t.Fatalf("schedule status %+v", cluster.Status.Schedule)
+	}
+
+	// The evening stop
+	cluster.Status.Schedule.CheckedAt = monday(19, 58)
+	cluster.Spec.DesiredState = DesiredStateRunning
+	r.applySchedule(ctx, cluster, monday(20, 3))
+	if cluster.Spec.DesiredState != DesiredStateStopped {
+		t.Fatalf("desired state %s after the scheduled stop", cluster.Spec.DesiredState)
+	}
+
+	// Still stopped on later passes, from the spec as stored
+	cluster.Spec.DesiredState = DesiredStateRunning
+	r.applySchedule(ctx, cluster, monday(22, 0))
+	if cluster.Spec.DesiredState != DesiredStateStopped {
+		t.Fatalf("desired state %s later that night", cluster.Spec.DesiredState)
+	}
+
+	// A start by hand after the stop wins until the next scheduled action
+	startedAt := monday(22, 30)
+	cluster.Spec.DesiredState = DesiredStateRunning
+	cluster.Spec.DesiredStateSetAt = &startedAt
+	r.applySchedule(ctx, cluster, monday(23, 0))
+	if cluster.Spec.DesiredState != DesiredStateRunning {
+		t.Fatalf("desired state %s after a start by hand", cluster.Spec.DesiredState)
+	}
+	r.applySchedule(ctx, cluster, time.Date(2026, 3, 3, 20, 1, 0, 0, time.UTC))
+	if cluster.Spec.DesiredState != DesiredStateStopped {
+		t.Fatalf("desired state %s after the next scheduled stop", cluster.Spec.DesiredState)
+	}
+
+	// Removing the schedule hands the desired state back to the spec
+	cluster.Spec.Schedule = nil
+	cluster.Spec.DesiredState = DesiredStateRunning
+	r.applySchedule(ctx, cluster, time.Date(2026, 3, 3, 21, 0, 0, 0, time.UTC))
+	if cluster.Spec.DesiredState != DesiredStateRunning || cluster.Status.Schedule != nil {
+		t.Errorf("desired state %s, schedule status %+v without a schedule", cluster.Spec.DesiredState, cluster.Status.Schedule)
+	}
+}
@@ -0,0 +1,255 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/madhouselabs/goman/pkg/logger"
+	"github.com/madhouselabs/goman/pkg/provider"
+	"gopkg.in/yaml.v3"
+)
+
+// SettingsKey is the storage key of the controller settings object. It lives
+// outside clusters/ so editing it doesn't trigger reconciles.
+const SettingsKey = "controller/settings.yaml"
+
+// Bounds used to validate settings
+const (
+	// MinRequeueInterval avoids hot loops on the reconcile queue
+	MinRequeueInterval = 1 * time.Second
+
+	// MaxRequeueInterval is the longest delay SQS supports
+	MaxRequeueInterval = 15 * time.Minute
+
+	// MaxReconcileTimeout keeps a reconcile within the Lambda execution limit
+	MaxReconcileTimeout = 15 * time.Minute
+
+	// MaxNodeParallelism bounds concurrent node operations (SSM and EC2 rate limits)
+	MaxNodeParallelism = 50
+)
+
+// Settings holds the tunable reconciler timings. Durations use Go syntax
+// ("30s", "2m") in the settings object; unset fields keep their defaults.
+type Settings struct {
+	// Requeue intervals
+	LockBusyRequeue  time.Duration `yaml:"lockBusyRequeue"`  // Another reconciler holds the cluster lock
+	LoadErrorRequeue time.Duration `yaml:"loadErrorRequeue"` // Config or status could not be loaded
+	FailureRequeue   time.Duration `yaml:"failureRequeue"`   // Reconciliation failed
+	SaveErrorRequeue time.Duration `yaml:"saveErrorRequeue"` // Status could not be saved
+	CleanupRequeue   time.Duration `yaml:"cleanupRequeue"`   // Running cluster needs another pass after cleanup
+	ProgressRequeue  time.Duration `yaml:"progressRequeue"`  // Cluster is still converging
+	StaleSpecRequeue time.Duration `yaml:"staleSpecRequeue"` // Loaded spec is older than a queued edit
+
+	// Lock behavior
+	LockTTL            time.Duration `yaml:"lockTTL"`            // Lock lifetime; must outlast a reconcile
+	LockAcquireTimeout time.Duration `yaml:"lockAcquireTimeout"` // How long to wait for the lock
+
+	// ReconcileTimeout bounds a single reconciliation
+	ReconcileTimeout time.Duration `yaml:"reconcileTimeout"`
+
+	// Node operation concurrency (drains, terminations, worker creation)
+	MaxNodeParallelism     int `yaml:"maxNodeParallelism"`     // Nodes touched at once
+	NodeParallelismPercent int `yaml:"nodeParallelismPercent"` // Optional percentage of the affected nodes, 0 to disable
+
+	// Certificate expiry tracking of running clusters
+	CertCheckInterval time.Duration `yaml:"certCheckInterval"` // How often expiry dates are read from the masters
+	CertRenewBefore   time.Duration `yaml:"certRenewBefore"`   // Rotate certificates expiring within this, 0 to only warn
+
+	// Lifetime of the token in the read-only kubeconfig, 0 to not issue one
+	ReadOnlyTokenTTL time.Duration `yaml:"readOnlyTokenTTL"`
+
+	// Reconciles in a row failing on cloud throttling or service errors
+	// before a cluster is marked CloudProviderDegraded instead of Failed
+	CloudDegradedAfter int `yaml:"cloudDegradedAfter"`
+
+	// Objective for the time from creating a cluster to Running; slower
+	// creations are counted as breaches, 0 to not count them
+	CreationSLO time.Duration `yaml:"creationSLO"`
+
+	// How long workers stopped by a pool scale-down are kept for reuse
+	// before they are terminated, 0 to keep them
+	StoppedWorkerMaxAge time.Duration `yaml:"stoppedWorkerMaxAge"`
+
+	// Look up who made changes detected outside goman, such as terminated
+	// instances or drift, in the provider's audit log (AWS CloudTrail)
+	ChangeAttribution bool `yaml:"changeAttribution"`
+
+	// How often resources of deleted clusters are looked for, and the state
+	// bucket is pruned by its retention settings, 0 to not collect them
+	OrphanGCInterval time.Duration `yaml:"orphanGCInterval"`
+
+	// Let the periodic orphan collection delete what it finds; by default
+	// it only reports, for 'goman admin gc' to delete
+	OrphanGCDelete bool `yaml:"orphanGCDelete"`
+
+	// How long a cluster that is not settled may go without a reconcile
+	// before the periodic stale check reconciles it again, 0 to not check
+	StaleReconcileAfter time.Duration `yaml:"staleReconcileAfter"`
+
+	// Run the smoke tests of 'goman cluster verify' at the end of
+	// provisioning; clusters become Running once they pass
+	VerifyOnProvision bool `yaml:"verifyOnProvision"`
+}
+
+// DefaultSettings returns the settings used when no settings object exists
+func DefaultSettings() Settings {
+	return Settings{
+		LockBusyRequeue:    30 * time.Second,
+		LoadErrorRequeue:   1 * time.Minute,
+		FailureRequeue:     2 * time.Minute,
+		SaveErrorRequeue:   30 * time.Second,
+		CleanupRequeue:     15 * time.Second,
+		ProgressRequeue:    30 * time.Second,
+		StaleSpecRequeue:   5 * time.Second,
+		LockTTL:            15 * time.Minute,
+		LockAcquireTimeout: 30 * time.Second,
+		ReconcileTimeout:   14 * time.Minute,
+
+		MaxNodeParallelism:     5,
+		NodeParallelismPercent: 0,
+
+		CertCheckInterval: 24 * time.Hour,
+		CertRenewBefore:   30 * 24 * time.Hour,
+
+		ReadOnlyTokenTTL: 30 * 24 * time.Hour,
+
+		CloudDegradedAfter: 3,
+
+		CreationSLO: 15 * time.Minute,
+
+		StoppedWorkerMaxAge: 7 * 24 * time.Hour,
+
+		OrphanGCInterval: 24 * time.Hour,
+
+		StaleReconcileAfter: 30 * time.Minute,
+	}
+}
+
+// failureRequeue returns how long to wait before retrying a failed
+// reconcile. Spec errors wait for a fix, which triggers a reconcile of its
+// own; quotas rarely clear within minutes.
+func (s Settings) failureRequeue(category provider.ErrorCategory) time.Duration {
+	switch category {
+	case provider.CategoryUserConfig:
+		return MaxRequeueInterval
+	case provider.CategoryCloudQuota:
+		if d := 5 * s.FailureRequeue; d < MaxRequeueInterval {
+			return d
+		}
+		return MaxRequeueInterval
+	case provider.CategoryTransientCloud:
+		if d := s.FailureRequeue / 2; d > MinRequeueInterval {
+			return d
+		}
+		return MinRequeueInterval
+	default:
+		return s.FailureRequeue
+	}
+}
+
+// Validate checks that the settings are safe to run with
+func (s Settings) Validate() error {
+	var problems []string
+
+	requeues := []struct {
+		name  string
+		value time.Duration
+	}{
+		{"lockBusyRequeue", s.LockBusyRequeue},
+		{"loadErrorRequeue", s.LoadErrorRequeue},
+		{"failureRequeue", s.FailureRequeue},
+		{"saveErrorRequeue", s.SaveErrorRequeue},
+		{"cleanupRequeue", s.CleanupRequeue},
+		{"progressRequeue", s.ProgressRequeue},
+		{"staleSpecRequeue", s.StaleSpecRequeue},
+	}
+	for _, rq := range requeues {
+		if rq.value < MinRequeueInterval || rq.value > MaxRequeueInterval {
+			problems = append(problems, fmt.Sprintf("%s must be between %s and %s, got %s", rq.name, MinRequeueInterval, MaxRequeueInterval, rq.value))
+		}
+	}
+
+	if s.ReconcileTimeout <= 0 || s.ReconcileTimeout > MaxReconcileTimeout {
+		problems = append(problems, fmt.Sprintf("reconcileTimeout must be positive and at most %s, got %s", MaxReconcileTimeout, s.ReconcileTimeout))
+	}
+	// A lock that expires mid-reconcile lets a second reconciler in
+	if s.LockTTL <= s.ReconcileTimeout {
+		problems = append(problems, fmt.Sprintf("lockTTL (%s) must be longer than reconcileTimeout (%s)", s.LockTTL, s.ReconcileTimeout))
+	}
+	if s.LockAcquireTimeout <= 0 || s.LockAcquireTimeout >= s.ReconcileTimeout {
+		problems = append(problems, fmt.Sprintf("lockAcquireTimeout must be positive and shorter than reconcileTimeout, got %s", s.LockAcquireTimeout))
+	}
+
+	if s.MaxNodeParallelism < 1 || s.MaxNodeParallelism > MaxNodeParallelism {
+		problems = append(problems, fmt.Sprintf("maxNodeParallelism must be between 1 and %d, got %d", MaxNodeParallelism, s.MaxNodeParallelism))
+	}
+	if s.NodeParallelismPercent < 0 || s.NodeParallelismPercent > 100 {
+		problems = append(problems, fmt.Sprintf("nodeParallelismPercent must be between 0 and 100, got %d", s.NodeParallelismPercent))
+	}
+
+	if s.CertCheckInterval < time.Minute {
+		problems = append(problems, fmt.Sprintf("certCheckInterval must be at least 1m, got %s", s.CertCheckInterval))
+	}
+	if s.CertRenewBefore < 0 {
+		problems = append(problems, fmt.Sprintf("certRenewBefore must not be negative, got %s", s.CertRenewBefore))
+	}
+	// Kubernetes refuses tokens shorter than 10 minutes
+	if s.ReadOnlyTokenTTL != 0 && s.ReadOnlyTokenTTL < 10*time.Minute {
+		problems = append(problems, fmt.Sprintf("readOnlyTokenTTL must be 0 or at least 10m, got %s", s.ReadOnlyTokenTTL))
+	}
+
+	if s.CloudDegradedAfter < 1 {
+		problems = append(problems, fmt.Sprintf("cloudDegradedAfter must be at least 1, got %d", s.CloudDegradedAfter))
+	}
+	if s.CreationSLO < 0 {
+		problems = append(problems, fmt.Sprintf("creationSLO must not be negative, got %s", s.CreationSLO))
+	}
+	if s.StoppedWorkerMaxAge < 0 {
+		problems = append(problems, fmt.Sprintf("stoppedWorkerMaxAge must not be negative, got %s", s.StoppedWorkerMaxAge))
+	}
+	if s.OrphanGCInterval != 0 && s.OrphanGCInterval < time.Hour {
+		problems = append(problems, fmt.Sprintf("orphanGCInterval must be 0 or at least 1h, got %s", s.OrphanGCInterval))
+	}
+	// Shorter would reconcile clusters that are only waiting for a requeue
+	if s.StaleReconcileAfter != 0 && s.StaleReconcileAfter <= MaxRequeueInterval {
+		problems = append(problems, fmt.Sprintf("staleReconcileAfter must be 0 or longer than %s, got %s", MaxRequeueInterval, s.StaleReconcileAfter))
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("invalid controller settings: %s", strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// ParseSettings parses a settings object on top of the defaults
+func ParseSettings(data []byte) (Settings, error) {
+	settings := DefaultSettings()
+	if err := yaml.Unmarshal(data, &settings); err != nil {
+		return DefaultSettings(), fmt.Errorf("failed to parse controller settings: %w", err)
+	}
+	if err := settings.Validate(); err != nil {
+		return DefaultSettings(), err
+	}
+	return settings, nil
+}
+
+// LoadSettings reads the settings object from storage. A missing or invalid
+// object falls back to the defaults so a bad edit can't stop reconciliation.
+func LoadSettings(ctx context.Context, storage provider.StorageService) Settings {
+	data, err := storage.GetObject(ctx, SettingsKey)
+	if err != nil {
+		logger.Infof(ctx, "[SETTINGS] No controller settings at %s, using defaults: %v", SettingsKey, err)
+		return DefaultSettings()
+	}
+
+	settings, err := ParseSettings(data)
+	if err != nil {
+		logger.Infof(ctx, "[SETTINGS] Ignoring %s, using defaults: %v", SettingsKey, err)
+		return settings
+	}
+
+	logger.Infof(ctx, "[SETTINGS] Loaded controller settings from %s: %+v", SettingsKey, settings)
+	return settings
+}
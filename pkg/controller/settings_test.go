@@ -0,0 +1,41 @@
+package controller
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseSettingsOverridesDefaults(t *testing.T) {
+	settings, err := ParseSettings([]byte("progressRequeue: 5s\nfailureRequeue: 10m\n"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if settings.ProgressRequeue != 5*time.Second {
+		t.Errorf("progressRequeue = %s, want 5s", settings.ProgressRequeue)
+	}
+	if settings.FailureRequeue != 10*time.Minute {
+		t.Errorf("failureRequeue = %s, want 10m", settings.FailureRequeue)
+	}
+	if settings.LockTTL != DefaultSettings().LockTTL {
+		t.Errorf("unset lockTTL changed to %s", settings.LockTTL)
+	}
+}
+
+func TestParseSettingsRejectsUnsafeValues(t *testing.T) {
+	cases := map[string]string{
+		"requeue too short":     "progressRequeue: 100ms\n",
+		"requeue beyond SQS":    "lockBusyRequeue: 20m\n",
+		"lock shorter than run": "lockTTL: 5m\nreconcileTimeout: 10m\n",
+		"timeout beyond lambda": "reconcileTimeout: 20m\nlockTTL: 30m\n",
+		"negative creation SLO": "creationSLO: -1m\n",
+	}
+	for name, data := range cases {
+		settings, err := ParseSettings([]byte(data))
+		if err == nil {
+			t.Errorf("%s: expected validation error", name)
+		}
+		if settings != DefaultSettings() {
+			t.Errorf("%s: expected defaults on error, got %+v", name, settings)
+		}
+	}
+}
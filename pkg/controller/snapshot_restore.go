@@ -0,0 +1,282 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/madhouselabs/goman/pkg/logger"
+	"github.com/madhouselabs/goman/pkg/models"
+	"github.com/madhouselabs/goman/pkg/provider"
+	"github.com/madhouselabs/goman/pkg/storage"
+	"gopkg.in/yaml.v3"
+)
+
+// snapshotRestoreTimeout bounds how long a snapshot restore keeps retrying
+// before it is reported as failed
+const snapshotRestoreTimeout = 30 * time.Minute
+
+// snapshotTransferTTL is how long the URLs moving a snapshot stay valid;
+// each is used right away by the script it is handed to
+const snapshotTransferTTL = 15 * time.Minute
+
+// snapshotSaveScript takes an etcd snapshot on a master of the source
+// cluster and uploads it to a presigned URL, since nodes may only write
+// their own cluster's objects. K3s appends the node name and a timestamp to
+// the snapshot name, so the newest file with the prefix is the one taken.
+// The last line of output names the snapshot.
+const snapshotSaveScript = `set -e
+SNAPSHOTS=/var/lib/rancher/k3s/server/db/snapshots
+k3s etcd-snapshot save --name %[1]s >&2
+SNAPSHOT=$(ls -1t "$SNAPSHOTS" | grep '^%[1]s' | head -n 1)
+curl -sSf -X PUT -T "$SNAPSHOTS/$SNAPSHOT" '%[2]s' >&2
+rm -f "$SNAPSHOTS/$SNAPSHOT"
+echo "snapshot=$SNAPSHOT"
+`
+
+// snapshotStopScript stops K3s on a joined master before the first master
+// is reset, once the bootstrap script has started it
+const snapshotStopScript = `for i in $(seq 1 90); do
+    k3s kubectl get --raw=/readyz >/dev/null 2>&1 && break
+    sleep 2
+done
+systemctl stop k3s
+`
+
+// snapshotResetScript resets the etcd of the first master from the
+// snapshot at a presigned URL, like clusterResetScript. The snapshot can
+// only be decrypted with the source cluster's token, which the cluster was
+// bootstrapped with. It prints the admin kubeconfig like kubeconfigScript,
+// since the restored datastore may bring the source's certificates along.
+const snapshotResetScript = `set -e
+SNAPSHOTS=/var/lib/rancher/k3s/server/db/snapshots
+for i in $(seq 1 90); do
+    k3s kubectl get --raw=/readyz >/dev/null 2>&1 && break
+    sleep 2
+done
+mkdir -p "$SNAPSHOTS"
+curl -sSf -o "$SNAPSHOTS/%[1]s" '%[2]s'
+. /etc/systemd/system/k3s.service.env
+systemctl stop k3s
+k3s server --cluster-reset --token="$SERVER_TOKEN" --cluster-reset-restore-path="$SNAPSHOTS/%[1]s" >&2 || true
+rm -f "$SNAPSHOTS/%[1]s"
+systemctl start k3s
+for i in $(seq 1 90); do
+    k3s kubectl get --raw=/readyz >/dev/null 2>&1 && break
+    sleep 2
+done
+k3s kubectl get --raw=/readyz >/dev/null 2>&1 || { echo "API server not ready after the restore" >&2; exit 1; }
+PUBLIC_IP=$(curl -sf http://169.254.169.254/latest/meta-data/public-ipv4 || curl -s http://169.254.169.254/latest/meta-data/local-ipv4)
+sed "s/127.0.0.1/$PUBLIC_IP/g" /etc/rancher/k3s/k3s.yaml
+`
+
+// snapshotRejoinScript drops the etcd data of a joined master and starts
+// K3s again, so it joins the restored first master afresh
+const snapshotRejoinScript = `set -e
+rm -rf /var/lib/rancher/k3s/server/db
+systemctl start k3s
+`
+
+// snapshotRestoreKey is where the snapshot of source taken for cluster is
+// staged, under the source's objects
+func snapshotRestoreKey(source, cluster string) string {
+	return fmt.Sprintf("clusters/%s/snapshots/%s.db", source, cluster)
+}
+
+// clusterToken returns the K3s token a new cluster is bootstrapped with: the
+// source's when it is restored from a snapshot, a new one otherwise
+func (r *Reconciler) clusterToken(ctx context.Context, cluster *models.ClusterResource) (string, error) {
+	req := cluster.Spec.Restore
+	if req == nil {
+		return r.generateToken()
+	}
+	token, err := r.provider.GetSecretService().GetSecret(ctx, req.Source, provider.SecretServerToken)
+	if err != nil {
+		return "", fmt.Errorf("failed to read the token of %s to restore its snapshot with: %w", req.Source, err)
+	}
+	return string(token), nil
+}
+
+// reconcileSnapshotRestore restores a new cluster from an etcd snapshot of
+// its source before the node pools join:
+//
+//	Saving -> Restoring -> Completed
+//
+// A snapshot taken on a running master of the source is staged in storage
+// and the first master is reset from it; the other masters drop their etcd
+// data and join it again. Failed steps are retried until
+// snapshotRestoreTimeout. Returns true once there is nothing left to
+// restore, false while the restore is in progress or has failed.
+func (r *Reconciler) reconcileSnapshotRestore(ctx context.Context, cluster *models.ClusterResource) (bool, error) {
+	req := cluster.Spec.Restore
+	if req == nil {
+		return true, nil
+	}
+	st := cluster.Status.Restore
+	if st == nil || st.Source != req.Source {
+		st = &models.SnapshotRestoreStatus{
+			Source:    req.Source,
+			Phase:     models.SnapshotRestoreSaving,
+			StartedAt: time.Now(),
+		}
+		cluster.Status.Restore = st
+		logger.Infof(ctx, "[RESTORE] Restoring cluster %s from a snapshot of %s", cluster.Name, req.Source)
+	}
+	if st.Phase == models.SnapshotRestoreCompleted {
+		return true, nil
+	}
+
+	if st.Phase != models.SnapshotRestoreFailed {
+		if err := r.advanceSnapshotRestore(ctx, cluster, st); err != nil {
+			st.Message = err.Error()
+			if time.Since(st.StartedAt) > snapshotRestoreTimeout {
+				failSnapshotRestore(st, fmt.Sprintf("%v (gave up after %s)", err, snapshotRestoreTimeout))
+			}
+		}
+	}
+	logger.Infof(ctx, "[RESTORE] Cluster %s: %s", cluster.Name, st)
+	switch st.Phase {
+	case models.SnapshotRestoreCompleted:
+		return true, nil
+	case models.SnapshotRestoreFailed:
+		cluster.Status.Message = fmt.Sprintf("Restore from %s failed: %s; delete the cluster and create it again", st.Source, st.Message)
+	default:
+		cluster.Status.Message = fmt.Sprintf("Restoring etcd: %s", st)
+	}
+	return false, nil
+}
+
+// advanceSnapshotRestore moves a restore through as many phases as it can
+func (r *Reconciler) advanceSnapshotRestore(ctx context.Context, cluster *models.ClusterResource, st *models.SnapshotRestoreStatus) error {
+	presigner, ok := r.provider.GetStorageService().(provider.PresignedStorage)
+	if !ok {
+		failSnapshotRestore(st, "the storage of this provider cannot hand snapshots to nodes")
+		return nil
+	}
+	key := snapshotRestoreKey(st.Source, cluster.Name)
+
+	if st.Phase == models.SnapshotRestoreSaving {
+		source, err := r.sourceMaster(ctx, cluster, st.Source)
+		if err != nil {
+			return err
+		}
+		url, _, err := presigner.PresignPutObject(ctx, key, snapshotTransferTTL)
+		if err != nil {
+			return fmt.Errorf("failed to presign the snapshot upload: %w", err)
+		}
+		output, err := r.runOnMaster(ctx, *source, "etcd-snapshot-save", fmt.Sprintf(snapshotSaveScript, "restore-"+cluster.Name, url), false)
+		if err != nil {
+			return fmt.Errorf("snapshot on %s failed: %w", source.Name, err)
+		}
+		st.Snapshot = parseResetSnapshot(output)
+		st.Phase = models.SnapshotRestoreRestoring
+		st.Message = ""
+		logger.Infof(ctx, "[RESTORE] Took snapshot %s on %s", st.Snapshot, source.Name)
+	}
+
+	var first *models.InstanceStatus
+	var others []string
+	for i, inst := range cluster.Status.Instances {
+		if inst.Role != "master" {
+			continue
+		}
+		if extractWorkerIndex(inst.Name) == 0 {
+			first = &cluster.Status.Instances[i]
+		} else {
+			others = append(others, inst.InstanceID)
+		}
+	}
+	if first == nil {
+		return fmt.Errorf("cluster %s has no first master to restore", cluster.Name)
+	}
+
+	url, _, err := presigner.PresignGetObject(ctx, key, snapshotTransferTTL)
+	if err != nil {
+		return fmt.Errorf("failed to presign the snapshot download: %w", err)
+	}
+	if err := r.runOnMasters(ctx, "stop-k3s", others, snapshotStopScript); err != nil {
+		return err
+	}
+	kubeconfig, err := r.runOnMaster(ctx, *first, "etcd-snapshot-restore", fmt.Sprintf(snapshotResetScript, st.Snapshot, url), true)
+	if err != nil {
+		return fmt.Errorf("restore on %s failed: %w", first.Name, err)
+	}
+	if err := r.runOnMasters(ctx, "rejoin-restored-master", others, snapshotRejoinScript); err != nil {
+		return err
+	}
+
+	updated := []byte(kubeconfig)
+	if cluster.Status.APIEndpoint != "" {
+		updated = kubeconfigServerPattern.ReplaceAll(updated, []byte("${1}"+cluster.Status.APIEndpoint))
+	}
+	if err := r.provider.GetSecretService().PutSecret(ctx, cluster.Name, provider.SecretKubeconfig, updated); err != nil {
+		return fmt.Errorf("failed to save kubeconfig: %w", err)
+	}
+	// The read-only kubeconfig is reissued against the restored datastore
+	cluster.Status.ReadOnlyKubeconfigExpiresAt = nil
+	cluster.Status.APIEndpoints = nil
+
+	// The snapshot holds the source's secrets; it is not kept around
+	if err := r.provider.GetStorageService().DeleteObject(ctx, key); err != nil && !errors.Is(err, provider.ErrNotFound) {
+		logger.Warnf(ctx, "[RESTORE] Warning: Failed to delete staged snapshot %s: %v", key, err)
+	}
+
+	now := time.Now()
+	st.Phase = models.SnapshotRestoreCompleted
+	st.CompletedAt = &now
+	st.Message = ""
+	return nil
+}
+
+// sourceMaster returns a running master of the cluster a snapshot is taken
+// of, read from its stored status
+func (r *Reconciler) sourceMaster(ctx context.Context, cluster *models.ClusterResource, source string) (*models.InstanceStatus, error) {
+	data, err := r.provider.GetStorageService().GetObject(ctx, storage.ClusterStatusKey(source))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load the status of %s: %w", source, err)
+	}
+	var status models.ClusterResourceStatus
+	if err := yaml.Unmarshal(data, &status); err != nil {
+		return nil, fmt.Errorf("failed to parse the status of %s: %w", source, err)
+	}
+	// The source runs in the cluster's region, which it was copied from
+	r.recordInstanceRegions(&models.ClusterResource{Name: source, Spec: models.ClusterSpec{Region: cluster.Spec.Region}, Status: status})
+
+	var masters []models.InstanceStatus
+	for _, inst := range status.Instances {
+		if inst.Role == "master" {
+			masters = append(masters, inst)
+		}
+	}
+	master := selectSurvivingMaster(masters, status.PreferredMasterInstance)
+	if master == nil {
+		return nil, fmt.Errorf("cluster %s has no running master to take a snapshot on", source)
+	}
+	return master, nil
+}
+
+// runOnMasters runs a script on masters, failing unless it succeeded on all
+func (r *Reconciler) runOnMasters(ctx context.Context, purpose string, instanceIDs []string, script string) error {
+	if len(instanceIDs) == 0 {
+		return nil
+	}
+	result, err := r.runCommand(ctx, purpose, instanceIDs, script)
+	if err != nil {
+		return fmt.Errorf("%s failed: %w", purpose, err)
+	}
+	for _, id := range instanceIDs {
+		if res := result.Instances[id]; res == nil || res.Status != "Success" {
+			return fmt.Errorf("%s failed on %s: %s", purpose, id, operationOutput(res))
+		}
+	}
+	return nil
+}
+
+// failSnapshotRestore marks a restore as failed
+func failSnapshotRestore(st *models.SnapshotRestoreStatus, reason string) {
+	now := time.Now()
+	st.Phase = models.SnapshotRestoreFailed
+	st.CompletedAt = &now
+	st.Message = reason
+}
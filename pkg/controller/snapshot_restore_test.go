@@ -0,0 +1,226 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/madhouselabs/goman/pkg/models"
+	"github.com/madhouselabs/goman/pkg/provider"
+	"github.com/madhouselabs/goman/pkg/storage"
+)
+
+// restoreStorage keeps objects in memory and presigns URLs naming the key
+type restoreStorage struct {
+	provider.StorageService
+	objects map[string][]byte
+}
+
+func (s *restoreStorage) GetObject(ctx context.Context, key string) ([]byte, error) {
+	data, ok := s.objects[key]
+	if !ok {
+		return nil, provider.ErrNotFound
+	}
+	return data, nil
+}
+
+func (s *restoreStorage) DeleteObject(ctx context.Context, key string) error {
+	delete(s.objects, key)
+	return nil
+}
+
+func (s *restoreStorage) PresignGetObject(ctx context.Context, key string, ttl time.Duration) (string, time.Time, error) {
+	if _, ok := s.objects[key]; !ok {
+		return "", time.Time{}, provider.ErrNotFound
+	}
+	return "https://get/" + key, time.Now().Add(ttl), nil
+}
+
+func (s *restoreStorage) PresignPutObject(ctx context.Context, key string, ttl time.Duration) (string, time.Time, error) {
+	return "https://put/" + key, time.Now().Add(ttl), nil
+}
+
+// restoreCompute runs the restore scripts: an upload stores the snapshot,
+// instances in failing fail every command
+type restoreCompute struct {
+	provider.ComputeService
+	storage *restoreStorage
+	failing map[string]bool
+	calls   []string // "<instance> <script>" in order
+}
+
+func (c *restoreCompute) RunCommand(ctx context.Context, ids []string, command string) (*provider.CommandResult, error) {
+	result := &provider.CommandResult{Status: "Success", Instances: map[string]*provider.InstanceCommandResult{}}
+	for _, id := range ids {
+		script := "other"
+		res := &provider.InstanceCommandResult{InstanceID: id, Status: "Success"}
+		switch command {
+		case snapshotStopScript:
+			script = "stop"
+		case snapshotRejoinScript:
+			script = "rejoin"
+		default:
+			if strings.Contains(command, "etcd-snapshot save") {
+				script = "save"
+				c.storage.objects[snapshotRestoreKey("blue", "green")] = []byte("snapshot")
+				res.Output = "snapshot=restore-green-ip-10-0-0-1-1760572800\n"
+			} else if strings.Contains(command, "--cluster-reset-restore-path") {
+				script = "reset"
+				res.Output = "server: https://13.200.0.1:6443\n"
+			}
+		}
+		if c.failing[id] {
+			res.Status = "Failed"
+			res.Error = "exit status 1"
+		}
+		c.calls = append(c.calls, id+" "+script)
+		result.Instances[id] = res
+	}
+	return result, nil
+}
+
+func restoreCluster(phase models.SnapshotRestorePhase) *models.ClusterResource {
+	cluster := &models.ClusterResource{Name: "green"}
+	cluster.Spec.Restore = &models.SnapshotRestoreRequest{Source: "blue", RequestedAt: time.Now()}
+	cluster.Status.APIEndpoint = "https://10.0.1.50:6443"
+	for i := 0; i < 3; i++ {
+		cluster.Status.Instances = append(cluster.Status.Instances, models.InstanceStatus{
+			InstanceID: fmt.Sprintf("i-g%d", i), Name: fmt.Sprintf("green-master-%d", i), Role: "master", State: "running",
+		})
+	}
+	if phase != "" {
+		cluster.Status.Restore = &models.SnapshotRestoreStatus{Source: "blue", Phase: phase, Snapshot: "restore-green-ip-10-0-0-1-1760572800", StartedAt: time.Now()}
+	}
+	return cluster
+}
+
+func TestSnapshotRestore(t *testing.T) {
+	blueStatus := "instances:\n  - instanceId: i-b0\n    name: blue-master-0\n    role: master\n    state: running\n"
+	tests := []struct {
+		name      string
+		phase     models.SnapshotRestorePhase
+		startedAt time.Duration // Before now
+		failing   string
+		wantPhase models.SnapshotRestorePhase
+		wantCalls []string
+	}{
+		{
+			name:      "restored",
+			wantPhase: models.SnapshotRestoreCompleted,
+			wantCalls: []string{"i-b0 save", "i-g1 stop", "i-g2 stop", "i-g0 reset", "i-g1 rejoin", "i-g2 rejoin"},
+		},
+		{
+			name:      "source unreachable",
+			failing:   "i-b0",
+			wantPhase: models.SnapshotRestoreSaving,
+			wantCalls: []string{"i-b0 save"},
+		},
+		{
+			name:      "reset retried",
+			phase:     models.SnapshotRestoreRestoring,
+			failing:   "i-g0",
+			wantPhase: models.SnapshotRestoreRestoring,
+			wantCalls: []string{"i-g1 stop", "i-g2 stop", "i-g0 reset"},
+		},
+		{
+			name:      "given up",
+			phase:     models.SnapshotRestoreRestoring,
+			startedAt: time.Hour,
+			failing:   "i-g0",
+			wantPhase: models.SnapshotRestoreFailed,
+			wantCalls: []string{"i-g1 stop", "i-g2 stop", "i-g0 reset"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := &restoreStorage{objects: map[string][]byte{storage.ClusterStatusKey("blue"): []byte(blueStatus)}}
+			if tt.phase == models.SnapshotRestoreRestoring {
+				store.objects[snapshotRestoreKey("blue", "green")] = []byte("snapshot")
+			}
+			compute := &restoreCompute{storage: store, failing: map[string]bool{tt.failing: true}}
+			secrets := &secretMap{secrets: map[string][]byte{}}
+			r := &Reconciler{provider: &fakeProvider{compute: compute, storage: store, secrets: secrets}}
+			cluster := restoreCluster(tt.phase)
+			if cluster.Status.Restore != nil {
+				cluster.Status.Restore.StartedAt = time.Now().Add(-tt.startedAt)
+			}
+
+			restored, err := r.reconcileSnapshotRestore(context.Background(), cluster)
+			if err != nil {
+				t.Fatal(err)
+			}
+			st := cluster.Status.Restore
+			if st.Phase != tt.wantPhase {
+				t.Fatalf("phase = %s, want %s (%s)", st.Phase, tt.wantPhase, st.Message)
+			}
+			if restored != (tt.wantPhase == models.SnapshotRestoreCompleted) {
+				t.Errorf("restored = %v in phase %s", restored, st.Phase)
+			}
+			if got := strings.Join(compute.calls, ", "); got != strings.Join(tt.wantCalls, ", ") {
+				t.Errorf("calls = %s, want %s", got, strings.Join(tt.wantCalls, ", "))
+			}
+
+			_, staged := store.objects[snapshotRestoreKey("blue", "green")]
+			kubeconfig := string(secrets.secrets["green/"+provider.SecretKubeconfig])
+			if tt.wantPhase == models.SnapshotRestoreCompleted {
+				if st.Snapshot != "restore-green-ip-10-0-0-1-1760572800" {
+					t.Errorf("snapshot = %q", st.Snapshot)
+				}
+				if staged {
+					t.Error("staged snapshot not deleted")
+				}
+				if kubeconfig != "server: https://10.0.1.50:6443\n" {
+					t.Errorf("kubeconfig = %q, want the restored one at the API endpoint", kubeconfig)
+				}
+				return
+			}
+			if !staged && tt.phase == models.SnapshotRestoreRestoring {
+				t.Error("staged snapshot deleted before the restore succeeded")
+			}
+			if kubeconfig != "" {
+				t.Errorf("kubeconfig replaced by a failed restore: %q", kubeconfig)
+			}
+			if st.Message == "" || !strings.Contains(cluster.Status.Message, st.Message) {
+				t.Errorf("failure not reported: status %q, cluster %q", st.Message, cluster.Status.Message)
+			}
+		})
+	}
+}
+
+func TestSnapshotRestoreCommands(t *testing.T) {
+	save := fmt.Sprintf(snapshotSaveScript, "restore-green", "https://put/x?a=1&b=2")
+	if !strings.Contains(save, "'https://put/x?a=1&b=2'") {
+		t.Errorf("upload URL not quoted:\n%s", save)
+	}
+	reset := fmt.Sprintf(snapshotResetScript, "restore-green-1", "https://get/x")
+	for _, want := range []string{
+		`--token="$SERVER_TOKEN"`,
+		`--cluster-reset-restore-path="$SNAPSHOTS/restore-green-1"`,
+		`curl -sSf -o "$SNAPSHOTS/restore-green-1" 'https://get/x'`,
+	} {
+		if !strings.Contains(reset, want) {
+			t.Errorf("reset script lacks %s:\n%s", want, reset)
+		}
+	}
+}
+
+func TestClusterToken(t *testing.T) {
+	secrets := &secretMap{secrets: map[string][]byte{"blue/" + provider.SecretServerToken: []byte("blue-token")}}
+	r := &Reconciler{provider: &fakeProvider{secrets: secrets}}
+	ctx := context.Background()
+
+	cluster := restoreCluster("")
+	if token, err := r.clusterToken(ctx, cluster); err != nil || token != "blue-token" {
+		t.Errorf("token of a restored cluster = %q, %v; want the source's", token, err)
+	}
+	cluster.Spec.Restore.Source = "gone"
+	if _, err := r.clusterToken(ctx, cluster); err == nil {
+		t.Error("restored cluster got a token without the source's")
+	}
+	cluster.Spec.Restore = nil
+	if token, err := r.clusterToken(ctx, cluster); err != nil || token == "" || token == "blue-token" {
+		t.Errorf("token of a new cluster = %q, %v; want a new one", token, err)
+	}
+}
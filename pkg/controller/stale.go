@@ -0,0 +1,96 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/madhouselabs/goman/pkg/logger"
+	"github.com/madhouselabs/goman/pkg/models"
+	"github.com/madhouselabs/goman/pkg/provider"
+	"github.com/madhouselabs/goman/pkg/storage"
+	"gopkg.in/yaml.v3"
+)
+
+// StaleClusters returns the names of the clusters that are not settled and
+// whose status was last written over the staleReconcileAfter setting ago.
+// Their requeue chain broke, e.g. a requeue message or an S3 event was
+// lost, and nothing would reconcile them until a user acts. Clusters that
+// run, or are stopped as asked, with their spec observed are settled and
+// never stale.
+func (r *Reconciler) StaleClusters(ctx context.Context, now time.Time) ([]string, error) {
+	after := r.settings.StaleReconcileAfter
+	if after <= 0 {
+		return nil, nil
+	}
+	storageService := r.provider.GetStorageService()
+	keys, err := storageService.ListObjects(ctx, "clusters/")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list clusters: %w", err)
+	}
+
+	var stale []string
+	for _, key := range keys {
+		name, ok := storage.ClusterNameFromConfigKey(key)
+		if !ok {
+			continue
+		}
+		data, err := storageService.GetObject(ctx, key)
+		if err != nil {
+			logger.Infof(ctx, "[STALE] Skipping cluster %s: %v", name, err)
+			continue
+		}
+		var config storage.ClusterConfig
+		if err := yaml.Unmarshal(data, &config); err != nil {
+			logger.Warnf(ctx, "[STALE] Skipping cluster %s: invalid config: %v", name, err)
+			continue
+		}
+
+		var status *models.ClusterResourceStatus
+		data, err = storageService.GetObject(ctx, storage.ClusterStatusKey(name))
+		switch {
+		case err == nil:
+			status = &models.ClusterResourceStatus{}
+			if err := yaml.Unmarshal(data, status); err != nil {
+				logger.Warnf(ctx, "[STALE] Skipping cluster %s: invalid status: %v", name, err)
+				continue
+			}
+			if !storage.StatusBelongsTo(status.ClusterID, config.Metadata.ID) {
+				status = nil
+			}
+		case errors.Is(err, provider.ErrNotFound):
+		default:
+			logger.Infof(ctx, "[STALE] Skipping cluster %s: %v", name, err)
+			continue
+		}
+
+		if since, isStale := statusStale(&config, status, now, after); isStale {
+			logger.Infof(ctx, "[STALE] Cluster %s was last reconciled %s ago without settling", name, since.Round(time.Second))
+			stale = append(stale, name)
+		}
+	}
+	return stale, nil
+}
+
+// statusStale reports whether a cluster is not settled and was last
+// reconciled more than after ago, and how long ago. A cluster without a
+// status counts from its last spec write.
+func statusStale(config *storage.ClusterConfig, status *models.ClusterResourceStatus, now time.Time, after time.Duration) (time.Duration, bool) {
+	last := config.Metadata.UpdatedAt
+	if status != nil {
+		if status.LastReconcileTime != nil {
+			last = *status.LastReconcileTime
+		}
+		settled := status.Phase == string(models.ClusterPhaseRunning) ||
+			(status.Phase == string(models.ClusterPhaseStopped) && config.Spec.DesiredState == DesiredStateStopped)
+		if settled && config.Metadata.DeletionTimestamp == nil && status.ObservedGeneration >= config.Metadata.Generation {
+			return 0, false
+		}
+	}
+	if last.IsZero() {
+		return 0, false
+	}
+	since := now.Sub(last)
+	return since, since > after
+}
@@ -0,0 +1,54 @@
+package controller
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/madhouselabs/goman/pkg/models"
+	"github.com/madhouselabs/goman/pkg/storage"
+	"gopkg.in/yaml.v3"
+)
+
+func TestStaleClusters(t *testing.T) {
+	now := time.Now()
+	hourAgo, minuteAgo := now.Add(-time.Hour), now.Add(-time.Minute)
+	objects := map[string][]byte{}
+	put := func(name string, updated time.Time, phase string, reconciled *time.Time) {
+		config := storage.ClusterConfig{Metadata: storage.ClusterMetadata{Name: name, ID: name + "-id", UpdatedAt: updated, Generation: 1}}
+		data, err := yaml.Marshal(config)
+		if err != nil {
+			t.Fatal(err)
+		}
+		objects[storage.ClusterConfigKey(name)] = data
+		if phase == "" {
+			return
+		}
+		status := models.ClusterResourceStatus{Phase: phase, ObservedGeneration: 1, LastReconcileTime: reconciled, ClusterID: name + "-id"}
+		if data, err = yaml.Marshal(status); err != nil {
+			t.Fatal(err)
+		}
+		objects[storage.ClusterStatusKey(name)] = data
+	}
+	put("running", hourAgo, string(models.ClusterPhaseRunning), &hourAgo)
+	put("stuck", hourAgo, string(models.ClusterPhaseProvisioning), &hourAgo)
+	put("busy", hourAgo, string(models.ClusterPhaseProvisioning), &minuteAgo)
+	put("new", hourAgo, "", nil)
+	put("fresh", minuteAgo, "", nil)
+
+	r := &Reconciler{provider: &fakeProvider{storage: &orphanStorage{objects: objects}}, settings: DefaultSettings()}
+	stale, err := r.StaleClusters(context.Background(), now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	slices.Sort(stale)
+	if want := []string{"new", "stuck"}; !slices.Equal(stale, want) {
+		t.Errorf("stale clusters %v, want %v", stale, want)
+	}
+
+	r.settings.StaleReconcileAfter = 0
+	if stale, _ := r.StaleClusters(context.Background(), now); len(stale) != 0 {
+		t.Errorf("disabled check returned %v", stale)
+	}
+}
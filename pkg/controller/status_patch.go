@@ -0,0 +1,276 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/madhouselabs/goman/pkg/models"
+	"github.com/madhouselabs/goman/pkg/provider"
+	"github.com/madhouselabs/goman/pkg/storage"
+	"github.com/madhouselabs/goman/pkg/version"
+	"gopkg.in/yaml.v3"
+)
+
+// statusMergeFunc merges one status field using a three-way merge.
+// base is the status as it was loaded, local is our in-memory copy and
+// remote is what is currently stored. The result is written into remote.
+type statusMergeFunc func(base, local, remote *models.ClusterResourceStatus)
+
+// statusMergeFuncs holds field-specific merge functions. Fields without an
+// entry use mergeFieldIfChanged.
+var statusMergeFuncs = map[string]statusMergeFunc{
+	"Instances":          mergeInstances,
+	"PendingOperations":  mergePendingOperations,
+	"ObservedGeneration": mergeObservedGeneration,
+}
+
+// statusLocks serializes status read-modify-write cycles within one process
+var statusLocks sync.Map // cluster name -> *sync.Mutex
+
+func statusLock(clusterName string) *sync.Mutex {
+	mu, _ := statusLocks.LoadOrStore(clusterName, &sync.Mutex{})
+	return mu.(*sync.Mutex)
+}
+
+// rememberLoadedStatus keeps a snapshot of the status as loaded from storage
+// so saveCluster can tell which fields this reconcile actually changed
+func (r *Reconciler) rememberLoadedStatus(clusterName string, status models.ClusterResourceStatus) {
+	snapshot, err := copyStatus(&status)
+	if err != nil {
+		return
+	}
+	r.snapshotsMu.Lock()
+	defer r.snapshotsMu.Unlock()
+	if r.snapshots == nil {
+		r.snapshots = make(map[string]*models.ClusterResourceStatus)
+	}
+	r.snapshots[clusterName] = snapshot
+}
+
+// forgetLoadedStatus drops the snapshot of a cluster, so its next save
+// replaces the stored status instead of merging into it
+func (r *Reconciler) forgetLoadedStatus(clusterName string) {
+	r.snapshotsMu.Lock()
+	defer r.snapshotsMu.Unlock()
+	delete(r.snapshots, clusterName)
+}
+
+// loadedStatus returns the snapshot taken when the cluster was loaded
+func (r *Reconciler) loadedStatus(clusterName string) *models.ClusterResourceStatus {
+	r.snapshotsMu.Lock()
+	defer r.snapshotsMu.Unlock()
+	return r.snapshots[clusterName]
+}
+
+// patchClusterStatus performs a read-modify-write of the stored status.
+// The mutate function receives the latest stored status, so concurrent
+// writers updating different fields do not clobber each other. The write is
+// conditional on the stored status being unchanged since it was read; if
+// another process (the CLI, or another controller) wrote it meanwhile,
+// mutate runs again on the newer status.
+func (r *Reconciler) patchClusterStatus(ctx context.Context, clusterName string, mutate func(status *models.ClusterResourceStatus) error) (*models.ClusterResourceStatus, error) {
+	mu := statusLock(clusterName)
+	mu.Lock()
+	defer mu.Unlock()
+
+	statusKey := storage.ClusterStatusKey(clusterName)
+	storageService := r.provider.GetStorageService()
+
+	// Never recreate status for a cluster whose config is gone; a late write
+	// after deletion would otherwise resurrect the cluster in listings
+	if _, err := storageService.GetObject(ctx, storage.ClusterConfigKey(clusterName)); errors.Is(err, provider.ErrNotFound) {
+		return nil, fmt.Errorf("cluster %s has been deleted, not saving status", clusterName)
+	}
+
+	var status *models.ClusterResourceStatus
+	err := storage.UpdateObject(ctx, storageService, statusKey, func(data []byte) ([]byte, error) {
+		status = &models.ClusterResourceStatus{}
+		if data != nil {
+			if err := yaml.Unmarshal(data, status); err != nil {
+				return nil, fmt.Errorf("failed to parse stored cluster status: %w", err)
+			}
+		}
+
+		if err := mutate(status); err != nil {
+			return nil, err
+		}
+
+		now := time.Now()
+		status.LastReconcileTime = &now
+		status.WriterVersion = version.String()
+
+		statusData, err := yaml.Marshal(status)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal cluster status: %w", err)
+		}
+		return statusData, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// The stored status is now the baseline for further saves
+	r.rememberLoadedStatus(clusterName, *status)
+	return status, nil
+}
+
+// mergeStatus applies the fields changed between base and local on top of remote
+func mergeStatus(base, local, remote *models.ClusterResourceStatus) {
+	if base == nil {
+		// Nothing to diff against - local wins entirely
+		*remote = *local
+		return
+	}
+
+	t := reflect.TypeOf(*local)
+	for i := 0; i < t.NumField(); i++ {
+		name := t.Field(i).Name
+		if merge, ok := statusMergeFuncs[name]; ok {
+			merge(base, local, remote)
+			continue
+		}
+		mergeFieldIfChanged(name)(base, local, remote)
+	}
+}
+
+// mergeFieldIfChanged takes the local value of a field only if this
+// process changed it since loading
+func mergeFieldIfChanged(name string) statusMergeFunc {
+	return func(base, local, remote *models.ClusterResourceStatus) {
+		b := reflect.ValueOf(base).Elem().FieldByName(name)
+		l := reflect.ValueOf(local).Elem().FieldByName(name)
+		if !reflect.DeepEqual(b.Interface(), l.Interface()) {
+			reflect.ValueOf(remote).Elem().FieldByName(name).Set(l)
+		}
+	}
+}
+
+// mergeObservedGeneration never moves the observed generation back, so a
+// save carrying an older spec can't mark newer edits as pending again
+func mergeObservedGeneration(base, local, remote *models.ClusterResourceStatus) {
+	if local.ObservedGeneration != base.ObservedGeneration && local.ObservedGeneration > remote.ObservedGeneration {
+		remote.ObservedGeneration = local.ObservedGeneration
+	}
+}
+
+// mergeInstances merges instance entries keyed by instance ID
+func mergeInstances(base, local, remote *models.ClusterResourceStatus) {
+	if reflect.DeepEqual(base.Instances, local.Instances) {
+		return
+	}
+
+	baseByID := make(map[string]models.InstanceStatus)
+	for _, inst := range base.Instances {
+		baseByID[inst.InstanceID] = inst
+	}
+	localByID := make(map[string]models.InstanceStatus)
+	for _, inst := range local.Instances {
+		localByID[inst.InstanceID] = inst
+	}
+
+	merged := []models.InstanceStatus{}
+	seen := make(map[string]bool)
+
+	// Local order wins; keep remote versions of entries we did not touch
+	remoteByID := make(map[string]models.InstanceStatus)
+	for _, inst := range remote.Instances {
+		remoteByID[inst.InstanceID] = inst
+	}
+	for _, inst := range local.Instances {
+		if b, ok := baseByID[inst.InstanceID]; ok && reflect.DeepEqual(b, inst) {
+			if r, ok := remoteByID[inst.InstanceID]; ok {
+				inst = r
+			} else {
+				// Removed by another writer
+				continue
+			}
+		}
+		merged = append(merged, inst)
+		seen[inst.InstanceID] = true
+	}
+
+	// Keep instances added remotely, drop ones we removed locally
+	for _, inst := range remote.Instances {
+		if seen[inst.InstanceID] {
+			continue
+		}
+		_, inBase := baseByID[inst.InstanceID]
+		_, inLocal := localByID[inst.InstanceID]
+		if inBase && !inLocal {
+			continue
+		}
+		merged = append(merged, inst)
+	}
+
+	remote.Instances = merged
+}
+
+// mergePendingOperations merges pending commands, state changes and
+// background processes per key
+func mergePendingOperations(base, local, remote *models.ClusterResourceStatus) {
+	if reflect.DeepEqual(base.PendingOperations, local.PendingOperations) {
+		return
+	}
+
+	b := base.PendingOperations
+	if b == nil {
+		b = &models.PendingOperations{}
+	}
+	l := local.PendingOperations
+	if l == nil {
+		l = &models.PendingOperations{}
+	}
+	r := remote.PendingOperations
+	if r == nil {
+		r = &models.PendingOperations{}
+	}
+
+	r.Commands = mergeKeyed(b.Commands, l.Commands, r.Commands)
+	r.InstanceStateChanges = mergeKeyed(b.InstanceStateChanges, l.InstanceStateChanges, r.InstanceStateChanges)
+	r.BackgroundProcesses = mergeKeyed(b.BackgroundProcesses, l.BackgroundProcesses, r.BackgroundProcesses)
+
+	if len(r.Commands) == 0 && len(r.InstanceStateChanges) == 0 && len(r.BackgroundProcesses) == 0 {
+		remote.PendingOperations = nil
+		return
+	}
+	remote.PendingOperations = r
+}
+
+// mergeKeyed applies local additions, changes and removals to remote
+func mergeKeyed[V any](base, local, remote map[string]V) map[string]V {
+	result := make(map[string]V, len(remote))
+	for k, v := range remote {
+		result[k] = v
+	}
+	for k, v := range local {
+		if bv, ok := base[k]; !ok || !reflect.DeepEqual(bv, v) {
+			result[k] = v
+		}
+	}
+	for k := range base {
+		if _, ok := local[k]; !ok {
+			delete(result, k)
+		}
+	}
+	if len(result) == 0 {
+		return nil
+	}
+	return result
+}
+
+// copyStatus deep copies a status through its YAML representation
+func copyStatus(status *models.ClusterResourceStatus) (*models.ClusterResourceStatus, error) {
+	data, err := yaml.Marshal(status)
+	if err != nil {
+		return nil, err
+	}
+	var out models.ClusterResourceStatus
+	if err := yaml.Unmarshal(data, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
@@ -0,0 +1,196 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/madhouselabs/goman/pkg/models"
+	"github.com/madhouselabs/goman/pkg/provider"
+	"gopkg.in/yaml.v3"
+)
+
+func TestMergeStatusKeepsConcurrentUpdates(t *testing.T) {
+	base := &models.ClusterResourceStatus{
+		Phase: models.ClusterPhaseRunning,
+		Instances: []models.InstanceStatus{
+			{InstanceID: "i-1", Name: "c-master-0", Role: "master", State: "running"},
+		},
+	}
+
+	// This process only touched pending commands
+	local, _ := copyStatus(base)
+	local.PendingOperations = &models.PendingOperations{
+		Commands: map[string]*models.PendingCommand{
+			"cmd-1": {CommandID: "cmd-1", Purpose: "install", Timeout: time.Minute},
+		},
+	}
+
+	// Another writer updated instance states meanwhile
+	remote, _ := copyStatus(base)
+	remote.Instances[0].State = "stopped"
+	remote.Message = "updated elsewhere"
+
+	mergeStatus(base, local, remote)
+
+	if remote.Instances[0].State != "stopped" {
+		t.Errorf("instance state from concurrent writer was lost: %s", remote.Instances[0].State)
+	}
+	if remote.Message != "updated elsewhere" {
+		t.Errorf("message from concurrent writer was lost: %q", remote.Message)
+	}
+	if remote.PendingOperations.GetCommand("cmd-1") == nil {
+		t.Errorf("locally added pending command was not merged")
+	}
+}
+
+func TestMergeStatusAppliesLocalRemovals(t *testing.T) {
+	base := &models.ClusterResourceStatus{
+		Instances: []models.InstanceStatus{
+			{InstanceID: "i-1", Role: "master"},
+			{InstanceID: "i-2", Role: "worker"},
+		},
+		PendingOperations: &models.PendingOperations{
+			InstanceStateChanges: map[string]string{"i-2": "stopped"},
+		},
+	}
+
+	local, _ := copyStatus(base)
+	local.Instances = local.Instances[:1]
+	local.PendingOperations = nil
+
+	remote, _ := copyStatus(base)
+	remote.Instances = append(remote.Instances, models.InstanceStatus{InstanceID: "i-3", Role: "worker"})
+
+	mergeStatus(base, local, remote)
+
+	ids := []string{}
+	for _, inst := range remote.Instances {
+		ids = append(ids, inst.InstanceID)
+	}
+	if len(ids) != 2 || ids[0] != "i-1" || ids[1] != "i-3" {
+		t.Errorf("unexpected merged instances: %v", ids)
+	}
+	if remote.PendingOperations != nil {
+		t.Errorf("expected pending operations to be cleared, got %+v", remote.PendingOperations)
+	}
+}
+
+// racingStorage is versioned storage where another writer changes an object
+// right after its first versioned read
+type racingStorage struct {
+	provider.StorageService
+	objects  map[string][]byte
+	versions map[string]int
+	race     func(key string)
+}
+
+func (s *racingStorage) GetObject(ctx context.Context, key string) ([]byte, error) {
+	data, ok := s.objects[key]
+	if !ok {
+		return nil, provider.ErrNotFound
+	}
+	return data, nil
+}
+
+func (s *racingStorage) GetObjectVersion(ctx context.Context, key string) ([]byte, string, error) {
+	data, ok := s.objects[key]
+	if !ok {
+		return nil, "", provider.ErrNotFound
+	}
+	version := fmt.Sprint(s.versions[key])
+	if race := s.race; race != nil {
+		s.race = nil
+		race(key)
+	}
+	return data, version, nil
+}
+
+func (s *racingStorage) PutObjectIfMatch(ctx context.Context, key string, data []byte, version string) (string, error) {
+	if _, ok := s.objects[key]; ok && version != fmt.Sprint(s.versions[key]) || !ok && version != "" {
+		return "", provider.ErrPreconditionFailed
+	}
+	s.objects[key] = data
+	s.versions[key]++
+	return fmt.Sprint(s.versions[key]), nil
+}
+
+func TestPatchClusterStatusRetriesConcurrentWrites(t *testing.T) {
+	stored := models.ClusterResourceStatus{Phase: string(models.ClusterPhaseRunning), Message: "loaded"}
+	data, _ := yaml.Marshal(stored)
+	statusKey := "clusters/demo/status.yaml"
+	s := &racingStorage{
+		objects:  map[string][]byte{"clusters/demo/config.yaml": []byte("name: demo"), statusKey: data},
+		versions: map[string]int{},
+	}
+	r := &Reconciler{provider: &fakeProvider{storage: s}, settings: DefaultSettings()}
+	ctx := context.Background()
+
+	// Another writer sets the message between our read and write
+	s.race = func(key string) {
+		other := stored
+		other.Message = "updated elsewhere"
+		s.objects[key], _ = yaml.Marshal(other)
+		s.versions[key]++
+	}
+
+	r.rememberLoadedStatus("demo", stored)
+	cluster := &models.ClusterResource{Name: "demo", Status: stored}
+	cluster.Status.Phase = string(models.ClusterPhaseStopping)
+	if err := r.saveCluster(ctx, cluster); err != nil {
+		t.Fatal(err)
+	}
+
+	var saved models.ClusterResourceStatus
+	if err := yaml.Unmarshal(s.objects[statusKey], &saved); err != nil {
+		t.Fatal(err)
+	}
+	if saved.Phase != string(models.ClusterPhaseStopping) {
+		t.Errorf("phase = %s, want our change", saved.Phase)
+	}
+	if saved.Message != "updated elsewhere" {
+		t.Errorf("message = %q, the concurrent write was clobbered", saved.Message)
+	}
+	if s.versions[statusKey] != 2 {
+		t.Errorf("status written %d times, want once after the concurrent write", s.versions[statusKey])
+	}
+}
+
+func TestStatusOfEarlierClusterIsReplaced(t *testing.T) {
+	stale := models.ClusterResourceStatus{
+		Phase:     string(models.ClusterPhaseRunning),
+		ClusterID: "old-id",
+		Instances: []models.InstanceStatus{{InstanceID: "i-old"}},
+	}
+	data, _ := yaml.Marshal(stale)
+	statusKey := "clusters/demo/status.yaml"
+	s := &racingStorage{
+		objects: map[string][]byte{
+			"clusters/demo/config.yaml": []byte("metadata:\n  name: demo\n  id: new-id\nspec:\n  mode: dev\n  region: us-east-1\n"),
+			statusKey:                   data,
+		},
+		versions: map[string]int{},
+	}
+	r := &Reconciler{provider: &fakeProvider{storage: s}, settings: DefaultSettings()}
+	ctx := context.Background()
+
+	cluster, err := r.loadCluster(ctx, "demo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cluster.Status.Instances) != 0 || cluster.Status.Phase != string(models.ClusterPhasePending) {
+		t.Fatalf("loaded the earlier cluster's status: %+v", cluster.Status)
+	}
+	if err := r.saveCluster(ctx, cluster); err != nil {
+		t.Fatal(err)
+	}
+
+	var saved models.ClusterResourceStatus
+	if err := yaml.Unmarshal(s.objects[statusKey], &saved); err != nil {
+		t.Fatal(err)
+	}
+	if saved.ClusterID != "new-id" || len(saved.Instances) != 0 {
+		t.Errorf("saved %+v, want the earlier status replaced", saved)
+	}
+}
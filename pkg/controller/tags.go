@@ -0,0 +1,62 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/madhouselabs/goman/pkg/logger"
+	"github.com/madhouselabs/goman/pkg/models"
+)
+
+// reconcileTags applies the user tags of the spec to all existing resources
+// of a cluster when they changed since the last sync, or when a retag was
+// requested after it. New instances get the tags at launch.
+func (r *Reconciler) reconcileTags(ctx context.Context, cluster *models.ClusterResource) error {
+	tags := cluster.Spec.Tags
+	if err := models.ValidateResourceTags(tags); err != nil {
+		return fmt.Errorf("invalid tags: %w", err)
+	}
+	hash := models.ResourceTagsHash(tags)
+	status := cluster.Status.Tags
+	if !tagsSyncNeeded(status, hash, cluster.Spec.RetagRequestedAt) {
+		return nil
+	}
+
+	var removed []string
+	if status != nil {
+		for _, key := range status.Keys {
+			if _, ok := tags[key]; !ok {
+				removed = append(removed, key)
+			}
+		}
+	}
+
+	logger.Infof(ctx, "[TAGS] Syncing %d tags to resources of cluster %s", len(tags), cluster.Name)
+	result, err := r.provider.GetComputeService().SyncClusterTags(ctx, cluster.Name, tags, removed)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	cluster.Status.Tags = &models.TagSyncStatus{
+		Hash:      hash,
+		Keys:      models.SortedTagKeys(tags),
+		SyncedAt:  &now,
+		Resources: result.Resources,
+		Updated:   result.Updated,
+	}
+	return nil
+}
+
+// tagsSyncNeeded reports whether resources need their tags synced: the tags
+// changed since the last sync, or a retag was requested after it
+func tagsSyncNeeded(status *models.TagSyncStatus, hash string, requestedAt *time.Time) bool {
+	if status == nil {
+		return hash != "" || requestedAt != nil
+	}
+	if status.Hash != hash {
+		return true
+	}
+	return requestedAt != nil && (status.SyncedAt == nil || status.SyncedAt.Before(*requestedAt))
+}
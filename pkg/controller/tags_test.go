@@ -0,0 +1,57 @@
+package controller
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/madhouselabs/goman/pkg/models"
+)
+
+func TestTagsSyncNeeded(t *testing.T) {
+	synced := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	before, after := synced.Add(-time.Hour), synced.Add(time.Hour)
+	status := &models.TagSyncStatus{Hash: "abc", SyncedAt: &synced}
+
+	tests := []struct {
+		name      string
+		status    *models.TagSyncStatus
+		hash      string
+		requested *time.Time
+		want      bool
+	}{
+		{"never tagged", nil, "", nil, false},
+		{"first tags", nil, "abc", nil, true},
+		{"retag without tags", nil, "", &after, true},
+		{"unchanged", status, "abc", nil, false},
+		{"changed", status, "def", nil, true},
+		{"tags removed", status, "", nil, true},
+		{"old retag request", status, "abc", &before, false},
+		{"new retag request", status, "abc", &after, true},
+	}
+	for _, tt := range tests {
+		if got := tagsSyncNeeded(tt.status, tt.hash, tt.requested); got != tt.want {
+			t.Errorf("%s: tagsSyncNeeded = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestResourceTags(t *testing.T) {
+	tags := models.ParseResourceTags([]string{"CostCenter=1234", "team = platform", "Temporary"})
+	want := map[string]string{"CostCenter": "1234", "team": "platform", "Temporary": ""}
+	if !reflect.DeepEqual(tags, want) {
+		t.Errorf("ParseResourceTags = %v, want %v", tags, want)
+	}
+	formatted := models.FormatResourceTags(tags)
+	if !reflect.DeepEqual(formatted, []string{"CostCenter=1234", "Temporary=", "team=platform"}) {
+		t.Errorf("FormatResourceTags = %v", formatted)
+	}
+	if err := models.ValidateResourceTags(tags); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	for _, key := range []string{"Name", "ManagedBy", "goman-cluster", "aws:createdBy", "k8s-taints"} {
+		if err := models.ValidateResourceTags(map[string]string{key: "x"}); err == nil {
+			t.Errorf("expected reserved tag %s to be rejected", key)
+		}
+	}
+}
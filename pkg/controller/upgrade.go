@@ -0,0 +1,350 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/madhouselabs/goman/pkg/logger"
+	"github.com/madhouselabs/goman/pkg/models"
+	"github.com/madhouselabs/goman/pkg/provider"
+)
+
+// k3sVersionTag records the K3s version a node was launched with or last
+// upgraded to. Nodes without it run models.DefaultK3sVersion.
+const k3sVersionTag = "goman-k3s-version"
+
+// upgradeNodeTimeout bounds how long a node may take from the start of its
+// drain to reporting the new version and being Ready
+const upgradeNodeTimeout = 15 * time.Minute
+
+// reconcileUpgrade moves the cluster's nodes to the K3s version of the spec.
+// Masters are upgraded one at a time, then workers pool by pool in spec
+// order. Each node is drained, upgraded in place and must report the new
+// version and be Ready before it is uncordoned and the next one starts.
+//
+// The rollout spec pauses and resumes upgrades as it does pool rollouts. A
+// node that fails to upgrade halts the upgrade until it is resumed.
+// Downgrades and upgrades skipping a minor version are refused. Returns true
+// while a node is being upgraded so node pool reconciliation waits.
+func (r *Reconciler) reconcileUpgrade(ctx context.Context, cluster *models.ClusterResource) (bool, error) {
+	target := cluster.Spec.TargetK3sVersion()
+	upgrade := cluster.Status.Upgrade
+
+	if upgrade.Active() && upgrade.Node != "" {
+		if upgrade.ToVersion == target {
+			if err := r.advanceNodeUpgrade(ctx, cluster, upgrade); err != nil {
+				return false, err
+			}
+			upgrade.UpdatedAt = time.Now()
+			if upgrade.Node != "" {
+				cluster.Status.Message = fmt.Sprintf("Upgrading K3s to %s, %s", upgrade.ToVersion, upgrade.Message)
+				return true, nil
+			}
+		} else {
+			// The target changed mid-node: finish by returning the node to service
+			r.releaseUpgradeNode(ctx, cluster, upgrade)
+		}
+	}
+
+	// Between nodes: pick up spec changes and pause requests here
+	outdated, err := r.outdatedK3sNodes(ctx, cluster, target)
+	if err != nil {
+		return false, err
+	}
+
+	if upgrade == nil || upgrade.ToVersion != target || upgrade.Phase == models.UpgradeCompleted {
+		if len(outdated) == 0 {
+			if upgrade.Active() {
+				completeUpgrade(upgrade, "cluster no longer needs an upgrade")
+			}
+			return false, nil
+		}
+		if upgrade.Active() {
+			logger.Infof(ctx, "[UPGRADE] Target of cluster %s changed from %s to %s, restarting", cluster.Name, upgrade.ToVersion, target)
+		}
+		now := time.Now()
+		upgrade = &models.UpgradeStatus{
+			FromVersion: nodeK3sVersion(outdated[0]),
+			ToVersion:   target,
+			Phase:       models.UpgradeInProgress,
+			Total:       len(outdated),
+			StartedAt:   now,
+			UpdatedAt:   now,
+		}
+		cluster.Status.Upgrade = upgrade
+		if err := checkUpgradeable(outdated, target); err != nil {
+			upgrade.Phase = models.UpgradeRefused
+			upgrade.Message = err.Error()
+			logger.Infof(ctx, "[UPGRADE] Refusing upgrade of cluster %s: %v", cluster.Name, err)
+			return false, nil
+		}
+		logger.Infof(ctx, "[UPGRADE] Starting upgrade of cluster %s to K3s %s (%d nodes)", cluster.Name, target, len(outdated))
+	}
+	if upgrade.Phase == models.UpgradeRefused {
+		// Retried as nodes change, e.g. after older nodes were replaced
+		if err := checkUpgradeable(outdated, target); err != nil {
+			upgrade.Message = err.Error()
+			return false, nil
+		}
+		upgrade.Phase = models.UpgradeInProgress
+		upgrade.Total = len(outdated)
+	}
+
+	if len(outdated) == 0 {
+		completeUpgrade(upgrade, fmt.Sprintf("upgraded %d node(s)", upgrade.Upgraded))
+		logger.Infof(ctx, "[UPGRADE] Upgrade of cluster %s to K3s %s completed", cluster.Name, target)
+		return false, nil
+	}
+
+	spec := cluster.Spec.Rollout
+	if spec.IsPaused() {
+		if upgrade.Phase != models.UpgradePaused {
+			logger.Infof(ctx, "[UPGRADE] Upgrade of cluster %s paused", cluster.Name)
+		}
+		upgrade.Phase = models.UpgradePaused
+		upgrade.Message = fmt.Sprintf("paused, %d node(s) left", len(outdated))
+		return false, nil
+	}
+	if upgrade.HaltedAt != nil {
+		if spec == nil || spec.ResumedAt == nil || !spec.ResumedAt.After(*upgrade.HaltedAt) {
+			// Stays halted until resumed
+			return false, nil
+		}
+		upgrade.HaltedAt = nil
+	}
+
+	// Start the next node
+	inst := outdated[0]
+	if pool, ok := findNodePool(cluster, inst.Tags["goman-nodepool"]); ok && inst.Tags["goman-role"] == "worker" && pool.Paused {
+		upgrade.Message = fmt.Sprintf("node pool %s paused, %d node(s) left", pool.Name, len(outdated))
+		return false, nil
+	}
+	if inst.Tags["goman-role"] == "master" && inst.PrivateIP != "" {
+		// Restarting K3s takes the master's etcd member down
+		if err := cluster.Status.Etcd.CheckDisruption(r.k3sNodeName(inst.PrivateIP), false); err != nil {
+			upgrade.Message = fmt.Sprintf("waiting for etcd: %v, %d node(s) left", err, len(outdated))
+			return false, nil
+		}
+	}
+	now := time.Now()
+	upgrade.Phase = models.UpgradeInProgress
+	upgrade.Node = inst.ID
+	upgrade.NodeName = inst.Name
+	upgrade.NodePhase = models.UpgradeNodeDraining
+	upgrade.NodeStartedAt = &now
+	upgrade.UpdatedAt = now
+	upgrade.Message = fmt.Sprintf("%s: %s (%d node(s) left)", inst.Name, upgrade.NodePhase, len(outdated))
+	logger.Infof(ctx, "[UPGRADE] Upgrading %s (%s) from K3s %s to %s", inst.Name, inst.ID, nodeK3sVersion(inst), target)
+	cluster.Status.Message = fmt.Sprintf("Upgrading K3s to %s, %s", target, upgrade.Message)
+	return true, nil
+}
+
+// advanceNodeUpgrade runs the next step of the node being upgraded
+func (r *Reconciler) advanceNodeUpgrade(ctx context.Context, cluster *models.ClusterResource, upgrade *models.UpgradeStatus) error {
+	node := upgradeNodeStatus(cluster, upgrade.Node)
+	if node == nil || node.PrivateIP == "" {
+		r.haltUpgrade(ctx, cluster, upgrade, "node is no longer part of the cluster")
+		return nil
+	}
+	nodeName := r.k3sNodeName(node.PrivateIP)
+
+	// Masters can report on themselves; workers are checked from a master
+	masterInstanceID := runningMasterID(cluster)
+	if node.Role == "master" {
+		masterInstanceID = node.InstanceID
+	}
+	if masterInstanceID == "" {
+		return fmt.Errorf("no running master found to upgrade %s", upgrade.NodeName)
+	}
+
+	if upgrade.NodePhase == models.UpgradeNodeDraining {
+		if _, err := r.drainNode(ctx, masterInstanceID, nodeName, "300s", false); err != nil {
+			return fmt.Errorf("failed to drain %s: %w", nodeName, err)
+		}
+		upgrade.NodePhase = models.UpgradeNodeUpgrading
+	}
+
+	if upgrade.NodePhase == models.UpgradeNodeUpgrading {
+		result, err := r.runOperation(ctx, []string{node.InstanceID}, provider.OperationUpgradeK3s, map[string]string{
+			"K3sVersion": upgrade.ToVersion,
+		})
+		if err != nil {
+			r.haltUpgrade(ctx, cluster, upgrade, fmt.Sprintf("upgrade failed: %v", err))
+			return nil
+		}
+		if res := result.Instances[node.InstanceID]; res == nil || res.Status != "Success" {
+			reason := "no result"
+			if res != nil {
+				reason = strings.TrimSpace(res.Error + " " + res.Output)
+			}
+			r.haltUpgrade(ctx, cluster, upgrade, fmt.Sprintf("upgrade failed: %s", reason))
+			return nil
+		}
+		upgrade.NodePhase = models.UpgradeNodeVerifying
+	}
+
+	if upgrade.NodePhase == models.UpgradeNodeVerifying {
+		checkCmd := fmt.Sprintf(`kubectl get node %s -o jsonpath='{.status.nodeInfo.kubeletVersion} {.status.conditions[?(@.type=="Ready")].status}' 2>/dev/null || true`, nodeName)
+		result, err := r.runCommand(ctx, "check-node-version", []string{masterInstanceID}, checkCmd)
+		if err == nil {
+			if res := result.Instances[masterInstanceID]; res != nil && strings.TrimSpace(res.Output) == upgrade.ToVersion+" True" {
+				r.finishNodeUpgrade(ctx, cluster, upgrade, masterInstanceID, nodeName)
+				return nil
+			}
+		}
+		// The API server of a single master is briefly down after its upgrade
+		if upgrade.NodeStartedAt != nil && time.Since(*upgrade.NodeStartedAt) > upgradeNodeTimeout {
+			r.haltUpgrade(ctx, cluster, upgrade, fmt.Sprintf("node did not report %s and Ready within %s", upgrade.ToVersion, upgradeNodeTimeout))
+			return nil
+		}
+	}
+
+	upgrade.Message = fmt.Sprintf("%s: %s", upgrade.NodeName, upgrade.NodePhase)
+	return nil
+}
+
+// finishNodeUpgrade returns an upgraded node to service and records its version
+func (r *Reconciler) finishNodeUpgrade(ctx context.Context, cluster *models.ClusterResource, upgrade *models.UpgradeStatus, masterInstanceID, nodeName string) {
+	r.uncordonNode(ctx, masterInstanceID, nodeName)
+	if err := r.provider.GetComputeService().TagInstance(ctx, upgrade.Node, map[string]string{k3sVersionTag: upgrade.ToVersion}); err != nil {
+		// The node is upgraded again, which is a no-op for the binary
+		logger.Warnf(ctx, "[UPGRADE] Warning: Failed to tag %s with its K3s version: %v", upgrade.Node, err)
+	}
+	if st := upgradeNodeStatus(cluster, upgrade.Node); st != nil {
+		st.K3sVersion = upgrade.ToVersion
+	}
+
+	logger.Infof(ctx, "[UPGRADE] %s is running K3s %s", upgrade.NodeName, upgrade.ToVersion)
+	upgrade.Upgraded++
+	upgrade.Message = fmt.Sprintf("upgraded %s", upgrade.NodeName)
+	clearUpgradeNode(upgrade)
+}
+
+// haltUpgrade stops an upgrade after a failed node, returning the node to
+// service. The node is retried once the upgrade is resumed.
+func (r *Reconciler) haltUpgrade(ctx context.Context, cluster *models.ClusterResource, upgrade *models.UpgradeStatus, reason string) {
+	logger.Infof(ctx, "[UPGRADE] Upgrade of %s to K3s %s halted: %s", upgrade.NodeName, upgrade.ToVersion, reason)
+	now := time.Now()
+	upgrade.Message = fmt.Sprintf("halted at %s: %s; resume to retry", upgrade.NodeName, reason)
+	r.releaseUpgradeNode(ctx, cluster, upgrade)
+	upgrade.Phase = models.UpgradePaused
+	upgrade.HaltedAt = &now
+}
+
+// releaseUpgradeNode uncordons the node being upgraded and forgets it
+func (r *Reconciler) releaseUpgradeNode(ctx context.Context, cluster *models.ClusterResource, upgrade *models.UpgradeStatus) {
+	if node := upgradeNodeStatus(cluster, upgrade.Node); node != nil && node.PrivateIP != "" {
+		if masterInstanceID := runningMasterID(cluster); masterInstanceID != "" {
+			r.uncordonNode(ctx, masterInstanceID, r.k3sNodeName(node.PrivateIP))
+		}
+	}
+	clearUpgradeNode(upgrade)
+}
+
+// outdatedK3sNodes returns the running nodes not on the target version, in
+// upgrade order
+func (r *Reconciler) outdatedK3sNodes(ctx context.Context, cluster *models.ClusterResource, target string) ([]*provider.Instance, error) {
+	filters := map[string]string{
+		"tag:goman-cluster":   cluster.Name,
+		"instance-state-name": "running",
+	}
+	instances, err := r.provider.GetComputeService().ListInstances(ctx, filters)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list instances: %w", err)
+	}
+
+	var outdated []*provider.Instance
+	for _, inst := range instances {
+		if nodeK3sVersion(inst) != target {
+			outdated = append(outdated, inst)
+		}
+	}
+	return upgradeOrder(cluster, outdated), nil
+}
+
+// upgradeOrder sorts nodes for an upgrade: masters first, then the workers
+// of each pool in spec order, then workers of unknown pools, by name within
+// each group
+func upgradeOrder(cluster *models.ClusterResource, nodes []*provider.Instance) []*provider.Instance {
+	rank := func(inst *provider.Instance) int {
+		if inst.Tags["goman-role"] == "master" {
+			return 0
+		}
+		for i, pool := range cluster.Spec.NodePools {
+			if pool.Name == inst.Tags["goman-nodepool"] {
+				return i + 1
+			}
+		}
+		return len(cluster.Spec.NodePools) + 1
+	}
+
+	sorted := append([]*provider.Instance(nil), nodes...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if ri, rj := rank(sorted[i]), rank(sorted[j]); ri != rj {
+			return ri < rj
+		}
+		return sorted[i].Name < sorted[j].Name
+	})
+	return sorted
+}
+
+// checkUpgradeable refuses upgrades that would downgrade a node or skip a
+// minor version
+func checkUpgradeable(nodes []*provider.Instance, target string) error {
+	for _, inst := range nodes {
+		if err := models.CheckK3sUpgrade(nodeK3sVersion(inst), target); err != nil {
+			return fmt.Errorf("%s: %w", inst.Name, err)
+		}
+	}
+	return nil
+}
+
+// launchK3sVersion returns the K3s version new nodes are launched with.
+// During an upgrade they keep the version the cluster is upgraded from, so
+// no node runs ahead of the masters; the upgrade moves them later.
+func launchK3sVersion(cluster *models.ClusterResource) string {
+	if upgrade := cluster.Status.Upgrade; upgrade != nil && upgrade.Phase != models.UpgradeCompleted && upgrade.FromVersion != "" {
+		return upgrade.FromVersion
+	}
+	return cluster.Spec.TargetK3sVersion()
+}
+
+// nodeK3sVersion returns the K3s version a node runs according to its tag
+func nodeK3sVersion(inst *provider.Instance) string {
+	if v := inst.Tags[k3sVersionTag]; v != "" {
+		return v
+	}
+	return models.DefaultK3sVersion
+}
+
+// upgradeNodeStatus finds the status of the node being upgraded
+func upgradeNodeStatus(cluster *models.ClusterResource, instanceID string) *models.InstanceStatus {
+	for i := range cluster.Status.Instances {
+		if cluster.Status.Instances[i].InstanceID == instanceID {
+			return &cluster.Status.Instances[i]
+		}
+	}
+	return nil
+}
+
+// clearUpgradeNode forgets the node being upgraded
+func clearUpgradeNode(upgrade *models.UpgradeStatus) {
+	upgrade.Node = ""
+	upgrade.NodeName = ""
+	upgrade.NodePhase = ""
+	upgrade.NodeStartedAt = nil
+}
+
+// completeUpgrade marks an upgrade as finished
+func completeUpgrade(upgrade *models.UpgradeStatus, message string) {
+	now := time.Now()
+	upgrade.Phase = models.UpgradeCompleted
+	upgrade.HaltedAt = nil
+	upgrade.UpdatedAt = now
+	upgrade.CompletedAt = &now
+	upgrade.Message = message
+	clearUpgradeNode(upgrade)
+}
@@ -0,0 +1,59 @@
+package controller
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/madhouselabs/goman/pkg/models"
+	"github.com/madhouselabs/goman/pkg/provider"
+)
+
+func TestUpgradeOrder(t *testing.T) {
+	cluster := &models.ClusterResource{}
+	cluster.Spec.NodePools = []models.NodePool{{Name: "web"}, {Name: "batch"}}
+	node := func(name, role, pool string) *provider.Instance {
+		return &provider.Instance{ID: "i-" + name, Name: name, Tags: map[string]string{"goman-role": role, "goman-nodepool": pool}}
+	}
+	nodes := []*provider.Instance{
+		node("c-worker-batch-0", "worker", "batch"),
+		node("c-worker-old-0", "worker", "old"),
+		node("c-worker-web-1", "worker", "web"),
+		node("c-master-1", "master", ""),
+		node("c-worker-web-0", "worker", "web"),
+		node("c-master-0", "master", ""),
+	}
+
+	var got []string
+	for _, inst := range upgradeOrder(cluster, nodes) {
+		got = append(got, inst.Name)
+	}
+	want := []string{"c-master-0", "c-master-1", "c-worker-web-0", "c-worker-web-1", "c-worker-batch-0", "c-worker-old-0"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("upgradeOrder = %v, want %v", got, want)
+	}
+}
+
+func TestCheckK3sUpgrade(t *testing.T) {
+	tests := []struct {
+		from, to string
+		ok       bool
+	}{
+		{"v1.31.4+k3s1", "v1.31.5+k3s1", true},
+		{"v1.31.4+k3s1", "v1.31.4+k3s2", true},
+		{"v1.31.4+k3s1", "v1.32.0+k3s1", true},
+		{"v1.31.4+k3s1", "v1.33.0+k3s1", false},
+		{"v1.31.4+k3s1", "v1.30.9+k3s1", false},
+		{"v1.31.4+k3s2", "v1.31.4+k3s1", false},
+		{"v1.31.4+k3s1", "1.32.0", false},
+	}
+	for _, tt := range tests {
+		if err := models.CheckK3sUpgrade(tt.from, tt.to); (err == nil) != tt.ok {
+			t.Errorf("CheckK3sUpgrade(%s, %s) = %v, want ok %t", tt.from, tt.to, err, tt.ok)
+		}
+	}
+
+	legacy := &provider.Instance{Name: "c-master-0", Tags: map[string]string{}}
+	if got := nodeK3sVersion(legacy); got != models.DefaultK3sVersion {
+		t.Errorf("untagged node runs %s, want %s", got, models.DefaultK3sVersion)
+	}
+}
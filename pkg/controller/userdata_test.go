@@ -0,0 +1,101 @@
+package controller
+
+import (
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/mail"
+	"strings"
+	"testing"
+
+	"github.com/madhouselabs/goman/pkg/models"
+	"github.com/madhouselabs/goman/pkg/provider"
+)
+
+func TestValidateUserData(t *testing.T) {
+	for _, snippet := range []string{
+		"",
+		"#!/bin/bash\necho hello\n",
+		"#cloud-config\npackages:\n  - htop\n",
+	} {
+		if err := models.ValidateUserData("default", snippet); err != nil {
+			t.Errorf("ValidateUserData(%q) = %v", snippet, err)
+		}
+	}
+	for _, snippet := range []string{
+		"echo hello\n",
+		"#cloud-config\npackages: [htop\n",
+		"#!/bin/bash\n" + strings.Repeat("x", models.MaxUserDataSnippet),
+		"#!/bin/bash\necho \x00\n",
+	} {
+		if err := models.ValidateUserData("default", snippet); err == nil {
+			t.Errorf("ValidateUserData(%.40q) = nil, want an error", snippet)
+		}
+	}
+}
+
+func TestMultipartUserData(t *testing.T) {
+	bootstrap := "#!/bin/bash\necho bootstrap\n"
+	if got, err := provider.MultipartUserData(bootstrap, ""); err != nil || got != bootstrap {
+		t.Errorf("MultipartUserData() without a snippet = %q, %v", got, err)
+	}
+
+	tests := []struct {
+		snippet     string
+		contentType string
+	}{
+		{"#!/bin/sh\necho agent", "text/x-shellscript"},
+		{"#cloud-config\npackages:\n  - htop\n", "text/cloud-config"},
+	}
+	for _, tt := range tests {
+		data, err := provider.MultipartUserData(bootstrap, tt.snippet)
+		if err != nil {
+			t.Fatal(err)
+		}
+		msg, err := mail.ReadMessage(strings.NewReader(data))
+		if err != nil {
+			t.Fatalf("not a MIME message: %v", err)
+		}
+		_, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		reader := multipart.NewReader(msg.Body, params["boundary"])
+		var parts []string
+		for {
+			part, err := reader.NextPart()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			body, _ := io.ReadAll(part)
+			mediaType, _, _ := mime.ParseMediaType(part.Header.Get("Content-Type"))
+			parts = append(parts, mediaType+" "+strings.TrimSpace(string(body)))
+		}
+		want := []string{"text/x-shellscript " + strings.TrimSpace(bootstrap), tt.contentType + " " + strings.TrimSpace(tt.snippet)}
+		if len(parts) != 2 || parts[0] != want[0] || parts[1] != want[1] {
+			t.Errorf("parts = %q, want %q", parts, want)
+		}
+	}
+
+	if _, err := provider.MultipartUserData(bootstrap, "#!/bin/sh\necho ==GOMAN-USER-DATA==\n"); err == nil {
+		t.Error("MultipartUserData() accepted a snippet containing the boundary")
+	}
+}
+
+func TestWorkerInstanceConfigCarriesUserData(t *testing.T) {
+	cluster := &models.ClusterResource{Name: "demo"}
+	pool := models.NodePool{Name: "default", Count: 1, InstanceType: "t3.small", UserData: "#!/bin/sh\necho agent\n"}
+	cfg := workerInstanceConfig(cluster, "demo-default-0", pool, "10.0.0.1", "token")
+	if cfg.UserDataSnippet != pool.UserData {
+		t.Errorf("UserDataSnippet = %q", cfg.UserDataSnippet)
+	}
+	if script := provider.UserDataSnippetScript(cfg.UserDataSnippet); !strings.Contains(script, "pool-user-data.sh") {
+		t.Errorf("UserDataSnippetScript() = %q", script)
+	}
+	if script := provider.UserDataSnippetScript("#cloud-config\n"); script != "" {
+		t.Errorf("UserDataSnippetScript() of cloud-config = %q, want none", script)
+	}
+}
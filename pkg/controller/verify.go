@@ -0,0 +1,257 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/madhouselabs/goman/pkg/logger"
+	"github.com/madhouselabs/goman/pkg/models"
+)
+
+// verifyRetryInterval spaces out smoke test runs gating the Running phase
+// after one failed
+const verifyRetryInterval = 5 * time.Minute
+
+// verifyScript runs the smoke tests in the goman-verify namespace and prints
+// one "RESULT <test> <pass|fail|skip> <detail>" line each. It expects the
+// number of nodes in $expected and waits up to a minute for them to join.
+// All objects are created first so images are pulled while the first tests
+// wait, keeping the run within the command timeout; the namespace is
+// deleted afterwards.
+const verifyScript = `set -u
+k() { k3s kubectl "$@"; }
+result() { echo "RESULT $1 $2 ${3:-}"; }
+ns=goman-verify
+
+k delete namespace $ns --ignore-not-found --timeout=20s >/dev/null 2>&1
+trap 'k delete namespace goman-verify --ignore-not-found --wait=false >/dev/null 2>&1' EXIT
+cat <<'EOF' | k apply -f - >/dev/null || { echo "failed to create the test objects" >&2; exit 1; }
+apiVersion: v1
+kind: Namespace
+metadata:
+  name: goman-verify
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+  namespace: goman-verify
+spec:
+  replicas: 2
+  selector:
+    matchLabels: {app: web}
+  template:
+    metadata:
+      labels: {app: web}
+    spec:
+      affinity:
+        podAntiAffinity:
+          preferredDuringSchedulingIgnoredDuringExecution:
+            - weight: 100
+              podAffinityTerm:
+                topologyKey: kubernetes.io/hostname
+                labelSelector:
+                  matchLabels: {app: web}
+      containers:
+        - name: nginx
+          image: nginx:alpine
+          ports: [{containerPort: 80}]
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: web
+  namespace: goman-verify
+spec:
+  selector: {app: web}
+  ports: [{port: 80}]
+---
+apiVersion: v1
+kind: Pod
+metadata:
+  name: client
+  namespace: goman-verify
+spec:
+  containers:
+    - name: client
+      image: busybox:1.36
+      command: [sleep, "600"]
+---
+apiVersion: v1
+kind: PersistentVolumeClaim
+metadata:
+  name: data
+  namespace: goman-verify
+spec:
+  accessModes: [ReadWriteOnce]
+  resources:
+    requests:
+      storage: 64Mi
+---
+apiVersion: v1
+kind: Pod
+metadata:
+  name: writer
+  namespace: goman-verify
+spec:
+  containers:
+    - name: writer
+      image: busybox:1.36
+      command: [sh, -c, "echo ok > /data/ok && sleep 600"]
+      volumeMounts: [{name: data, mountPath: /data}]
+  volumes:
+    - name: data
+      persistentVolumeClaim: {claimName: data}
+EOF
+
+# Nodes launched with the cluster may still be joining
+for i in $(seq 12); do
+  nodes=$(k get nodes --no-headers 2>/dev/null | wc -l)
+  notready=$(k get nodes --no-headers 2>/dev/null | awk '$2 != "Ready" {print $1}' | tr '\n' ' ')
+  [ "$nodes" -ge "$expected" ] && [ -z "$notready" ] && break
+  sleep 5
+done
+if [ "$nodes" -ge "$expected" ] && [ -z "$notready" ]; then
+  result nodes pass "$nodes node(s) Ready"
+else
+  result nodes fail "$nodes of $expected node(s) registered, not Ready: ${notready:-none}"
+fi
+
+if k -n $ns rollout status deployment/web --timeout=60s >/dev/null 2>&1; then
+  result deployment pass "nginx rolled out with 2 replicas"
+else
+  result deployment fail "nginx did not roll out within 60s"
+fi
+
+if ! k -n $ns wait --for=condition=Ready pod/client --timeout=30s >/dev/null 2>&1; then
+  result service fail "client pod not Ready within 30s"
+  result dns fail "client pod not Ready within 30s"
+  result pod-network fail "client pod not Ready within 30s"
+else
+  ip=$(k -n $ns get service web -o jsonpath='{.spec.clusterIP}')
+  if k -n $ns exec client -- wget -q -T 5 -O /dev/null "http://$ip" >/dev/null 2>&1; then
+    result service pass "nginx answered on $ip"
+  else
+    result service fail "no answer from nginx on $ip"
+  fi
+
+  if k -n $ns exec client -- nslookup web.goman-verify.svc.cluster.local >/dev/null 2>&1; then
+    result dns pass "web.goman-verify.svc.cluster.local resolved"
+  else
+    result dns fail "web.goman-verify.svc.cluster.local did not resolve"
+  fi
+
+  node=$(k -n $ns get pod client -o jsonpath='{.spec.nodeName}')
+  target=$(k -n $ns get pods -l app=web -o jsonpath='{range .items[*]}{.spec.nodeName} {.status.podIP}{"\n"}{end}' | awk -v n="$node" '$1 != n && $2 != "" {print $1 " " $2; exit}')
+  if [ "$nodes" -lt 2 ]; then
+    result pod-network skip "single node"
+  elif [ -z "$target" ]; then
+    result pod-network skip "no nginx pod on another node than $node"
+  elif k -n $ns exec client -- wget -q -T 5 -O /dev/null "http://${target#* }" >/dev/null 2>&1; then
+    result pod-network pass "$node reached ${target% *}"
+  else
+    result pod-network fail "$node could not reach ${target% *} (${target#* })"
+  fi
+fi
+
+default=$(k get storageclass -o jsonpath='{range .items[?(@.metadata.annotations.storageclass\.kubernetes\.io/is-default-class=="true")]}{.metadata.name}{end}')
+if [ -z "$default" ]; then
+  result volume fail "no default storage class"
+elif k -n $ns wait --for=jsonpath='{.status.phase}'=Bound pvc/data --timeout=45s >/dev/null 2>&1; then
+  result volume pass "claim bound on storage class $default"
+else
+  result volume fail "claim on storage class $default not bound within 45s"
+fi
+`
+
+// VerifyCluster runs the smoke tests on a cluster and returns their results
+func (r *Reconciler) VerifyCluster(ctx context.Context, clusterName string) (*models.VerificationStatus, error) {
+	cluster, err := r.loadCluster(ctx, clusterName)
+	if err != nil {
+		return nil, err
+	}
+	return r.verifyCluster(ctx, cluster)
+}
+
+// verifyCluster runs the smoke tests on a running master of a cluster. An
+// error means the tests could not be run; failed tests are in the result.
+func (r *Reconciler) verifyCluster(ctx context.Context, cluster *models.ClusterResource) (*models.VerificationStatus, error) {
+	masterID := runningMasterID(cluster)
+	if masterID == "" {
+		return nil, fmt.Errorf("no running master to run the smoke tests on")
+	}
+	start := time.Now()
+	script := fmt.Sprintf("expected=%d\n", len(cluster.Status.Instances)) + verifyScript
+	output, err := r.runOnMaster(ctx, models.InstanceStatus{InstanceID: masterID}, "verify", script, false)
+	if err != nil {
+		return nil, fmt.Errorf("failed to run the smoke tests: %w", err)
+	}
+	return &models.VerificationStatus{
+		StartedAt: start.UTC(),
+		Duration:  time.Since(start).Round(time.Second),
+		Tests:     parseVerifyOutput(output),
+	}, nil
+}
+
+// parseVerifyOutput reads the results printed by verifyScript. Tests that
+// printed none count as failed.
+func parseVerifyOutput(output string) []models.VerifyTestResult {
+	found := make(map[string]models.VerifyTestResult)
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.SplitN(strings.TrimSpace(line), " ", 4)
+		if len(fields) < 3 || fields[0] != "RESULT" {
+			continue
+		}
+		res := models.VerifyTestResult{Name: fields[1], Result: fields[2]}
+		if len(fields) == 4 {
+			res.Detail = strings.TrimSpace(fields[3])
+		}
+		found[res.Name] = res
+	}
+
+	results := make([]models.VerifyTestResult, 0, len(models.VerifyTests))
+	for _, name := range models.VerifyTests {
+		res, ok := found[name]
+		if !ok {
+			res = models.VerifyTestResult{Name: name, Result: models.VerifyFail, Detail: "did not run"}
+		}
+		results = append(results, res)
+	}
+	return results
+}
+
+// verifyBeforeRunning runs the smoke tests when a cluster finished
+// configuring, if the verifyOnProvision setting asks for it, and reports
+// whether the cluster may become Running. Failed runs are retried every
+// verifyRetryInterval; the cluster stays Configuring until one passes.
+func (r *Reconciler) verifyBeforeRunning(ctx context.Context, cluster *models.ClusterResource) (bool, error) {
+	if !r.settings.VerifyOnProvision {
+		return true, nil
+	}
+	last := cluster.Status.Verification
+	if last != nil && !last.Passed() && time.Since(last.StartedAt) < verifyRetryInterval {
+		return false, nil
+	}
+
+	logger.Infof(ctx, "[CONFIGURE] Running smoke tests on cluster %s", cluster.Name)
+	result, err := r.verifyCluster(ctx, cluster)
+	if err != nil {
+		return false, err
+	}
+	if !result.Passed() {
+		if last != nil {
+			result.Failures = last.Failures
+		}
+		result.Failures++
+		cluster.Status.Verification = result
+		cluster.Status.Message = fmt.Sprintf("Waiting for the cluster to pass its smoke tests, %s (run %d); "+
+			"'goman cluster verify %s' shows the details", result.Summary(), result.Failures, cluster.Name)
+		r.events.Warning(ctx, cluster.Name, EventVerifyFailed, "", "%s", result.Summary())
+		return false, nil
+	}
+	cluster.Status.Verification = result
+	r.events.Normal(ctx, cluster.Name, EventVerified, "", "All smoke tests passed in %s", result.Duration)
+	return true, nil
+}
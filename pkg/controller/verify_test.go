@@ -0,0 +1,71 @@
+package controller
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/madhouselabs/goman/pkg/models"
+	"github.com/madhouselabs/goman/pkg/provider"
+)
+
+// verifyCompute answers the smoke test script with canned results
+type verifyCompute struct {
+	provider.ComputeService
+	output string
+	runs   int
+}
+
+func (c *verifyCompute) RunCommand(ctx context.Context, ids []string, command string) (*provider.CommandResult, error) {
+	c.runs++
+	if !strings.HasPrefix(command, "expected=2\n") {
+		return nil, nil
+	}
+	return &provider.CommandResult{Status: "Success", Instances: map[string]*provider.InstanceCommandResult{
+		ids[0]: {Status: "Success", Output: c.output},
+	}}, nil
+}
+
+func TestVerifyBeforeRunning(t *testing.T) {
+	compute := &verifyCompute{output: "RESULT nodes pass 2 node(s) Ready\n" +
+		"RESULT deployment pass nginx rolled out with 2 replicas\n" +
+		"RESULT service pass nginx answered on 10.43.0.10\n" +
+		"RESULT dns fail web.goman-verify.svc.cluster.local did not resolve\n" +
+		"RESULT pod-network skip no nginx pod on another node than demo-master-0\n"}
+	r := &Reconciler{provider: &fakeProvider{compute: compute}, settings: DefaultSettings()}
+	cluster := &models.ClusterResource{Name: "demo"}
+	cluster.Status.Instances = []models.InstanceStatus{
+		{InstanceID: "i-1", Role: "master", State: "running"},
+		{InstanceID: "i-2", Role: "worker", State: "pending"},
+	}
+	ctx := context.Background()
+
+	// Off by default
+	if passed, err := r.verifyBeforeRunning(ctx, cluster); err != nil || !passed || compute.runs != 0 {
+		t.Fatalf("verifyBeforeRunning without the setting = %v, %v after %d runs", passed, err, compute.runs)
+	}
+
+	r.settings.VerifyOnProvision = true
+	if passed, err := r.verifyBeforeRunning(ctx, cluster); err != nil || passed {
+		t.Fatalf("verifyBeforeRunning with failed tests = %v, %v", passed, err)
+	}
+	v := cluster.Status.Verification
+	if got := strings.Join(v.Failed(), ","); got != "dns,volume" || v.Failures != 1 {
+		t.Fatalf("failed %q after %d runs, want dns and the volume test that printed nothing", got, v.Failures)
+	}
+
+	// Retried only after the interval
+	if passed, _ := r.verifyBeforeRunning(ctx, cluster); passed || compute.runs != 1 {
+		t.Fatalf("retried right away: %d runs", compute.runs)
+	}
+
+	compute.output = strings.Replace(compute.output, "RESULT dns fail", "RESULT dns pass", 1) + "RESULT volume pass claim bound on storage class local-path\n"
+	cluster.Status.Verification.StartedAt = time.Now().Add(-verifyRetryInterval)
+	if passed, err := r.verifyBeforeRunning(ctx, cluster); err != nil || !passed {
+		t.Fatalf("verifyBeforeRunning with passing tests = %v, %v", passed, err)
+	}
+	if !cluster.Status.Verification.Passed() || compute.runs != 2 {
+		t.Errorf("verification %+v after %d runs", cluster.Status.Verification, compute.runs)
+	}
+}
@@ -0,0 +1,180 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"time"
+
+	"github.com/madhouselabs/goman/pkg/logger"
+	"github.com/madhouselabs/goman/pkg/models"
+	"github.com/madhouselabs/goman/pkg/provider"
+)
+
+// reconcileVIP serves the virtual IP of an HA cluster from its masters.
+// The address is assigned to a master first (allocated if the spec leaves it
+// empty), then each master not configured yet gets kube-vip, one at a time
+// since adding the address to the certificate restarts K3s. Once all masters
+// serve it, the API endpoint and the stored kubeconfig point at the address.
+func (r *Reconciler) reconcileVIP(ctx context.Context, cluster *models.ClusterResource) error {
+	spec := cluster.Spec.VirtualIP
+	if err := models.ValidateVirtualIP(spec, cluster.Spec.Mode); err != nil {
+		return err
+	}
+	status := cluster.Status.VirtualIP
+	if spec == nil {
+		if status == nil {
+			return nil
+		}
+		return r.removeVIP(ctx, cluster)
+	}
+
+	if status != nil && spec.Address != "" && status.Address != spec.Address {
+		// Address changed; masters are configured again for the new one
+		logger.Infof(ctx, "[VIP] Virtual IP of cluster %s changes from %s to %s", cluster.Name, status.Address, spec.Address)
+		status = nil
+	}
+
+	var masters, pending []string
+	known := make(map[string]bool)
+	for _, inst := range cluster.Status.Instances {
+		known[inst.InstanceID] = true
+		if inst.Role != "master" || inst.State != "running" {
+			continue
+		}
+		masters = append(masters, inst.InstanceID)
+		if !status.HasNode(inst.InstanceID) {
+			pending = append(pending, inst.InstanceID)
+		}
+	}
+	if status != nil {
+		// Forget removed masters
+		var nodes []string
+		for _, id := range status.Nodes {
+			if known[id] {
+				nodes = append(nodes, id)
+			}
+		}
+		status.Nodes = nodes
+	}
+	if len(masters) == 0 {
+		return nil
+	}
+
+	if len(pending) > 0 {
+		// New masters need their network interface tagged to take over the IP
+		address := spec.Address
+		if status != nil {
+			address = status.Address
+		}
+		address, err := r.provider.GetComputeService().PrepareVirtualIP(ctx, cluster.Name, masters, address)
+		if err != nil {
+			return fmt.Errorf("failed to prepare virtual IP: %w", err)
+		}
+		if status == nil {
+			logger.Infof(ctx, "[VIP] Serving virtual IP %s for cluster %s", address, cluster.Name)
+			status = &models.VirtualIPStatus{Address: address}
+		}
+		cluster.Status.VirtualIP = status
+
+		id := pending[0]
+		result, err := r.runOperation(ctx, []string{id}, provider.OperationConfigureVIP, map[string]string{
+			"Address": status.Address,
+			"Enabled": "true",
+		})
+		if err != nil {
+			return fmt.Errorf("failed to configure virtual IP on %s: %w", id, err)
+		}
+		if res := result.Instances[id]; res == nil || res.Status != "Success" {
+			status.Message = fmt.Sprintf("pending on %s", id)
+			return fmt.Errorf("failed to configure virtual IP on %s: %s", id, operationOutput(res))
+		}
+		status.Nodes = append(status.Nodes, id)
+		status.Message = ""
+		if len(pending) > 1 {
+			status.Message = fmt.Sprintf("%d masters left to configure", len(pending)-1)
+			return nil
+		}
+	}
+
+	endpoint := models.APIEndpointURL(status.Address)
+	if cluster.Status.APIEndpoint != endpoint {
+		if err := r.setKubeconfigServer(ctx, cluster.Name, endpoint); err != nil {
+			return err
+		}
+		now := time.Now()
+		status.AppliedAt = &now
+		cluster.Status.APIEndpoint = endpoint
+		logger.Infof(ctx, "[VIP] API endpoint of cluster %s is now %s", cluster.Name, endpoint)
+	}
+	return nil
+}
+
+// removeVIP stops kube-vip on the masters and points the API endpoint back
+// at the first master
+func (r *Reconciler) removeVIP(ctx context.Context, cluster *models.ClusterResource) error {
+	status := cluster.Status.VirtualIP
+	var nodes []string
+	var host string
+	for _, inst := range cluster.Status.Instances {
+		if inst.Role != "master" || inst.State != "running" {
+			continue
+		}
+		if host == "" {
+			// Same address the first master publishes at bootstrap
+			host = inst.PublicIP
+			if host == "" {
+				host = inst.PrivateIP
+			}
+		}
+		if status.HasNode(inst.InstanceID) {
+			nodes = append(nodes, inst.InstanceID)
+		}
+	}
+
+	if len(nodes) > 0 {
+		result, err := r.runOperation(ctx, nodes, provider.OperationConfigureVIP, map[string]string{
+			"Address": status.Address,
+			"Enabled": "false",
+		})
+		if err != nil {
+			return fmt.Errorf("failed to remove virtual IP: %w", err)
+		}
+		for _, id := range nodes {
+			if res := result.Instances[id]; res == nil || res.Status != "Success" {
+				return fmt.Errorf("failed to remove virtual IP from %s: %s", id, operationOutput(res))
+			}
+		}
+	}
+
+	if host != "" && cluster.Status.APIEndpoint == models.APIEndpointURL(status.Address) {
+		endpoint := models.APIEndpointURL(host)
+		if err := r.setKubeconfigServer(ctx, cluster.Name, endpoint); err != nil {
+			return err
+		}
+		cluster.Status.APIEndpoint = endpoint
+	}
+	logger.Infof(ctx, "[VIP] Removed virtual IP %s from cluster %s", status.Address, cluster.Name)
+	cluster.Status.VirtualIP = nil
+	return nil
+}
+
+var kubeconfigServerPattern = regexp.MustCompile(`(?m)^(\s*server:\s*)\S+$`)
+
+// setKubeconfigServer points the stored kubeconfig of a cluster at a new
+// API server URL
+func (r *Reconciler) setKubeconfigServer(ctx context.Context, clusterName, endpoint string) error {
+	secretService := r.provider.GetSecretService()
+	kubeconfig, err := secretService.GetSecret(ctx, clusterName, provider.SecretKubeconfig)
+	if err != nil {
+		return fmt.Errorf("failed to read kubeconfig: %w", err)
+	}
+	updated := kubeconfigServerPattern.ReplaceAll(kubeconfig, []byte("${1}"+endpoint))
+	if string(updated) == string(kubeconfig) {
+		return nil
+	}
+	if err := secretService.PutSecret(ctx, clusterName, provider.SecretKubeconfig, updated); err != nil {
+		return fmt.Errorf("failed to update kubeconfig: %w", err)
+	}
+	return nil
+}
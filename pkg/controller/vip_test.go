@@ -0,0 +1,37 @@
+package controller
+
+import (
+	"testing"
+
+	"github.com/madhouselabs/goman/pkg/models"
+)
+
+func TestValidateVirtualIP(t *testing.T) {
+	tests := []struct {
+		spec    *models.VirtualIPSpec
+		mode    string
+		wantErr bool
+	}{
+		{nil, "dev", false},
+		{&models.VirtualIPSpec{}, "ha", false},
+		{&models.VirtualIPSpec{Address: "172.31.16.250"}, "ha", false},
+		{&models.VirtualIPSpec{}, "dev", true},
+		{&models.VirtualIPSpec{Address: "8.8.8.8"}, "ha", true},
+		{&models.VirtualIPSpec{Address: "fd00::10"}, "ha", true},
+		{&models.VirtualIPSpec{Address: "api.internal"}, "ha", true},
+	}
+	for _, tt := range tests {
+		if err := models.ValidateVirtualIP(tt.spec, tt.mode); (err != nil) != tt.wantErr {
+			t.Errorf("ValidateVirtualIP(%+v, %s) = %v, want error %v", tt.spec, tt.mode, err, tt.wantErr)
+		}
+	}
+}
+
+func TestKubeconfigServerPattern(t *testing.T) {
+	kubeconfig := "apiVersion: v1\nclusters:\n- cluster:\n    certificate-authority-data: abc\n    server: https://3.110.1.2:6443\n  name: default\n"
+	got := string(kubeconfigServerPattern.ReplaceAll([]byte(kubeconfig), []byte("${1}"+models.APIEndpointURL("172.31.16.250"))))
+	want := "apiVersion: v1\nclusters:\n- cluster:\n    certificate-authority-data: abc\n    server: https://172.31.16.250:6443\n  name: default\n"
+	if got != want {
+		t.Errorf("unexpected kubeconfig:\n%s", got)
+	}
+}
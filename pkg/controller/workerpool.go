@@ -0,0 +1,69 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// nodeParallelism returns how many of total nodes an operation may touch at
+// once: NodeParallelismPercent of total (rounded up) when set, capped by
+// MaxNodeParallelism, and never less than one
+func (s Settings) nodeParallelism(total int) int {
+	limit := s.MaxNodeParallelism
+	if s.NodeParallelismPercent > 0 {
+		byPercent := (total*s.NodeParallelismPercent + 99) / 100
+		if byPercent < limit {
+			limit = byPercent
+		}
+	}
+	if limit < 1 {
+		limit = 1
+	}
+	return limit
+}
+
+// forEachNode runs fn for items 0..total-1 with at most limit running at
+// once. All items are attempted; failures are joined into the returned error.
+// Items not yet started when ctx is cancelled are skipped with ctx.Err().
+func forEachNode(ctx context.Context, total, limit int, fn func(ctx context.Context, i int) error) error {
+	if total == 0 {
+		return nil
+	}
+	if limit < 1 {
+		limit = 1
+	}
+
+	var (
+		wg   sync.WaitGroup
+		mu   sync.Mutex
+		errs []error
+	)
+	sem := make(chan struct{}, limit)
+
+	for i := 0; i < total; i++ {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			mu.Lock()
+			errs = append(errs, ctx.Err())
+			mu.Unlock()
+			wg.Wait()
+			return errors.Join(errs...)
+		}
+
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			if err := fn(ctx, i); err != nil {
+				mu.Lock()
+				errs = append(errs, err)
+				mu.Unlock()
+			}
+		}(i)
+	}
+
+	wg.Wait()
+	return errors.Join(errs...)
+}
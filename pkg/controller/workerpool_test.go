@@ -0,0 +1,60 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestNodeParallelism(t *testing.T) {
+	s := DefaultSettings()
+	s.MaxNodeParallelism = 5
+
+	if got := s.nodeParallelism(100); got != 5 {
+		t.Errorf("max cap: got %d, want 5", got)
+	}
+
+	s.NodeParallelismPercent = 20
+	if got := s.nodeParallelism(10); got != 2 {
+		t.Errorf("20%% of 10: got %d, want 2", got)
+	}
+	if got := s.nodeParallelism(3); got != 1 {
+		t.Errorf("20%% of 3 rounds up: got %d, want 1", got)
+	}
+	if got := s.nodeParallelism(100); got != 5 {
+		t.Errorf("percent capped by max: got %d, want 5", got)
+	}
+}
+
+func TestForEachNodeBoundsConcurrency(t *testing.T) {
+	var running, peak, calls int32
+
+	err := forEachNode(context.Background(), 12, 3, func(ctx context.Context, i int) error {
+		n := atomic.AddInt32(&running, 1)
+		for {
+			p := atomic.LoadInt32(&peak)
+			if n <= p || atomic.CompareAndSwapInt32(&peak, p, n) {
+				break
+			}
+		}
+		time.Sleep(5 * time.Millisecond)
+		atomic.AddInt32(&running, -1)
+		atomic.AddInt32(&calls, 1)
+		if i == 4 {
+			return fmt.Errorf("node %d failed", i)
+		}
+		return nil
+	})
+
+	if calls != 12 {
+		t.Errorf("expected all 12 nodes to run, got %d", calls)
+	}
+	if peak > 3 {
+		t.Errorf("concurrency exceeded limit: peak %d", peak)
+	}
+	if err == nil {
+		t.Errorf("expected failure from node 4 to be reported")
+	}
+}
@@ -0,0 +1,108 @@
+package controller
+
+import (
+	"sort"
+
+	"github.com/madhouselabs/goman/pkg/models"
+)
+
+// zoneCounts counts the workers in each of a pool's zones. Workers in zones
+// the pool no longer lists, or whose zone is not known yet, are not counted.
+func zoneCounts(pool models.NodePool, workers []models.InstanceStatus) map[string]int {
+	counts := make(map[string]int, len(pool.AvailabilityZones))
+	for _, zone := range pool.AvailabilityZones {
+		counts[zone] = 0
+	}
+	for _, w := range workers {
+		if _, ok := counts[w.AvailabilityZone]; ok {
+			counts[w.AvailabilityZone]++
+		}
+	}
+	return counts
+}
+
+// nextZones picks the zones for n new workers of a pool, each going to the
+// zone with the fewest workers, ties broken by the pool's zone order. It
+// returns empty zones when the pool does not list any, leaving the choice to
+// the provider.
+func nextZones(pool models.NodePool, workers []models.InstanceStatus, n int) []string {
+	zones := make([]string, n)
+	if len(pool.AvailabilityZones) == 0 {
+		return zones
+	}
+	counts := zoneCounts(pool, workers)
+	for i := range zones {
+		best := pool.AvailabilityZones[0]
+		for _, zone := range pool.AvailabilityZones[1:] {
+			if counts[zone] < counts[best] {
+				best = zone
+			}
+		}
+		zones[i] = best
+		counts[best]++
+	}
+	return zones
+}
+
+// replacementZone keeps a replaced worker in its zone while the pool still
+// lists it, and otherwise moves it to the pool's least used zone
+func replacementZone(pool models.NodePool, workers []models.InstanceStatus, old models.InstanceStatus) string {
+	for _, zone := range pool.AvailabilityZones {
+		if zone == old.AvailabilityZone {
+			return zone
+		}
+	}
+	var others []models.InstanceStatus
+	for _, w := range workers {
+		if w.InstanceID != old.InstanceID {
+			others = append(others, w)
+		}
+	}
+	return nextZones(pool, others, 1)[0]
+}
+
+// zoneSurplus picks n workers to remove when scaling a pool down so that the
+// remaining ones stay spread across its zones. Workers outside the pool's
+// zones go first, then the highest index of the most used zone.
+func zoneSurplus(pool models.NodePool, workers []models.InstanceStatus, n int) []models.InstanceStatus {
+	counts := zoneCounts(pool, workers)
+	remaining := append([]models.InstanceStatus(nil), workers...)
+	sort.Slice(remaining, func(i, j int) bool {
+		return extractWorkerIndex(remaining[i].Name) > extractWorkerIndex(remaining[j].Name)
+	})
+
+	var surplus []models.InstanceStatus
+	for len(surplus) < n && len(remaining) > 0 {
+		victim := -1
+		for i, w := range remaining {
+			if _, ok := counts[w.AvailabilityZone]; !ok {
+				victim = i
+				break
+			}
+			if victim < 0 || counts[w.AvailabilityZone] > counts[remaining[victim].AvailabilityZone] {
+				victim = i
+			}
+		}
+		w := remaining[victim]
+		if _, ok := counts[w.AvailabilityZone]; ok {
+			counts[w.AvailabilityZone]--
+		}
+		surplus = append(surplus, w)
+		remaining = append(remaining[:victim], remaining[victim+1:]...)
+	}
+	return surplus
+}
+
+// poolWorkersOf returns the workers of a pool in the cluster status
+func poolWorkersOf(cluster *models.ClusterResource, pool string) []models.InstanceStatus {
+	var workers []models.InstanceStatus
+	for _, inst := range cluster.Status.Instances {
+		if inst.Role != "worker" {
+			continue
+		}
+		if p, ok := workerPool(cluster, inst.Name); ok && p.Name == pool {
+			workers = append(workers, inst)
+		}
+	}
+	return workers
+}
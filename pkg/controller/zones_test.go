@@ -0,0 +1,72 @@
+package controller
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/madhouselabs/goman/pkg/models"
+)
+
+func TestNextZones(t *testing.T) {
+	pool := models.NodePool{Name: "web", AvailabilityZones: []string{"us-east-1a", "us-east-1b", "us-east-1c"}}
+	workers := []models.InstanceStatus{
+		{Name: "c-worker-web-0", AvailabilityZone: "us-east-1a"},
+		{Name: "c-worker-web-1", AvailabilityZone: "us-east-1a"},
+		{Name: "c-worker-web-2", AvailabilityZone: "us-east-1c"},
+		{Name: "c-worker-web-3", AvailabilityZone: "us-west-2a"},
+	}
+	got := nextZones(pool, workers, 3)
+	want := []string{"us-east-1b", "us-east-1b", "us-east-1c"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("nextZones = %v, want %v", got, want)
+	}
+
+	if got := nextZones(models.NodePool{Name: "web"}, workers, 2); !reflect.DeepEqual(got, []string{"", ""}) {
+		t.Errorf("nextZones without zones = %v, want provider defaults", got)
+	}
+}
+
+func TestZoneSurplus(t *testing.T) {
+	pool := models.NodePool{Name: "web", AvailabilityZones: []string{"us-east-1a", "us-east-1b"}}
+	workers := []models.InstanceStatus{
+		{InstanceID: "i-0", Name: "c-worker-web-0", AvailabilityZone: "us-east-1a"},
+		{InstanceID: "i-1", Name: "c-worker-web-1", AvailabilityZone: "us-east-1b"},
+		{InstanceID: "i-2", Name: "c-worker-web-2", AvailabilityZone: "us-east-1a"},
+		{InstanceID: "i-3", Name: "c-worker-web-3", AvailabilityZone: "us-east-1a"},
+		{InstanceID: "i-4", Name: "c-worker-web-4", AvailabilityZone: "us-east-1b"},
+		{InstanceID: "i-5", Name: "c-worker-web-5", AvailabilityZone: "us-east-1c"},
+	}
+
+	var got []string
+	for _, w := range zoneSurplus(pool, workers, 3) {
+		got = append(got, w.InstanceID)
+	}
+	// The worker outside the pool's zones, then the highest index of the
+	// most used zone until both have two
+	want := []string{"i-5", "i-3", "i-4"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("zoneSurplus = %v, want %v", got, want)
+	}
+
+	got = nil
+	for _, w := range zoneSurplus(models.NodePool{Name: "web"}, workers, 2) {
+		got = append(got, w.InstanceID)
+	}
+	if !reflect.DeepEqual(got, []string{"i-5", "i-4"}) {
+		t.Errorf("zoneSurplus without zones = %v, want the highest indices", got)
+	}
+}
+
+func TestReplacementZone(t *testing.T) {
+	pool := models.NodePool{Name: "web", AvailabilityZones: []string{"us-east-1a", "us-east-1b"}}
+	workers := []models.InstanceStatus{
+		{InstanceID: "i-0", AvailabilityZone: "us-east-1a"},
+		{InstanceID: "i-1", AvailabilityZone: "us-east-1c"},
+	}
+	if got := replacementZone(pool, workers, workers[0]); got != "us-east-1a" {
+		t.Errorf("replacementZone = %s, want the old zone us-east-1a", got)
+	}
+	if got := replacementZone(pool, workers, workers[1]); got != "us-east-1b" {
+		t.Errorf("replacementZone = %s, want the least used zone us-east-1b", got)
+	}
+}
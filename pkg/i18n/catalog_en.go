@@ -0,0 +1,579 @@
+package i18n
+
+// english is the built-in catalog and the fallback for every other locale.
+// Messages take fmt verbs where the call site passes arguments; TUI color
+// tags are added by the caller and never appear here.
+var english = map[string]string{
+	// Status bar
+	"status.title":                 "K3s Cluster Manager",
+	"status.provider":              "Provider: %s",
+	"status.refreshing":            "↻ Refreshing...",
+	"status.connected":             "● AWS connected",
+	"status.connected_short":       "● Connected",
+	"status.session_expired":       "● AWS session expired",
+	"status.offline":               "● Offline - cached data",
+	"status.connection_error":      "● AWS connection error",
+	"status.slow_connection":       "● Slow AWS connection",
+	"status.credentials_refreshed": "● AWS credentials refreshed",
+	"status.opening_editor":        "Opening editor...",
+	"status.edit_deleting":         "Cluster %s is being deleted and can no longer be edited",
+	"status.edit_kept_draft":       "Changes to %s not saved; reopen the editor to resume them",
+	"status.drift":                 "%s ⚠ drift",
+	"status.certs":                 "%s ⚠ %s",
+	"status.drift_count":           "%s ⚠ %d node(s) drifted",
+	"status.eta":                   "%s — %s remaining",
+	"status.spec_pending":          "%s • changes pending",
+	"status.sorted":                "Sorted by %s",
+
+	// Shortcut hints
+	"shortcut.navigate":  "Navigate",
+	"shortcut.details":   "Details",
+	"shortcut.select":    "Select",
+	"shortcut.create":    "Create",
+	"shortcut.reconcile": "Reconcile",
+	"shortcut.stop":      "Stop",
+	"shortcut.start":     "Start",
+	"shortcut.refresh":   "Refresh",
+	"shortcut.quit":      "Quit",
+	"shortcut.back":      "Back",
+	"shortcut.edit":      "Edit",
+	"shortcut.commands":  "Commands",
+	"shortcut.events":    "Events",
+	"shortcut.notes":     "Notes",
+	"shortcut.init":      "Initialize",
+	"shortcut.pane":      "Switch pane",
+	"shortcut.help":      "Help",
+	"shortcut.sort":      "Sort",
+
+	// Help overlay, action descriptions are keyed by keymap action name
+	"help.title":         "Keyboard Shortcuts",
+	"help.view.clusters": "Cluster list",
+	"help.view.empty":    "Cluster list (no clusters)",
+	"help.view.details":  "Cluster details",
+	"help.view.commands": "Command history",
+	"help.view.events":   "Cluster events",
+	"help.config":        "Remap keys in the keymap section of %s",
+	"action.up":          "Move up",
+	"action.down":        "Move down",
+	"action.top":         "Go to the first row",
+	"action.bottom":      "Go to the last row",
+	"action.open":        "Show cluster details",
+	"action.back":        "Go back",
+	"action.select":      "Make the cluster current for kubectl",
+	"action.create":      "Create a cluster",
+	"action.edit":        "Edit the cluster",
+	"action.delete":      "Delete the cluster",
+	"action.reconcile":   "Trigger reconciliation",
+	"action.stop":        "Stop the cluster",
+	"action.start":       "Start the cluster",
+	"action.refresh":     "Refresh",
+	"action.sort":        "Sort by name, created, status or region",
+	"action.commands":    "Show node command history",
+	"action.events":      "Show cluster events",
+	"action.notes":       "Edit the cluster's notes",
+	"action.init":        "Initialize infrastructure",
+	"action.switch-pane": "Switch between list and output",
+	"action.help":        "Show or close this help",
+	"action.quit":        "Quit",
+
+	// Buttons
+	"button.ok":      "OK",
+	"button.cancel":  "Cancel",
+	"button.delete":  "Delete",
+	"button.save":    "Save",
+	"button.stop":    "Stop",
+	"button.start":   "Start",
+	"button.trigger": "Trigger",
+	"button.apply":   "Apply",
+	"button.discard": "Discard",
+	"button.login":   "Log in",
+	"button.later":   "Later",
+	"button.resume":  "Resume",
+
+	// Dialogs
+	"dialog.error":                  "Error",
+	"dialog.success":                "Success",
+	"dialog.progress":               "Progress",
+	"dialog.confirm_delete":         "Confirm Delete",
+	"dialog.confirm_delete.body":    "Are you sure you want to delete cluster '%s'?",
+	"dialog.confirm_stop":           "Confirm Stop",
+	"dialog.confirm_stop.body":      "Stop cluster '%s'?\nThis will stop all EC2 instances.",
+	"dialog.confirm_start":          "Confirm Start",
+	"dialog.confirm_start.body":     "Start cluster '%s'?\nThis will start all EC2 instances.",
+	"dialog.creating":               "Creating Cluster",
+	"dialog.creating.body":          "Creating cluster '%s'...",
+	"dialog.reconcile":              "Trigger Reconciliation",
+	"dialog.reconcile.body":         "Trigger reconciliation for cluster '%s'?\n\nThis will force the Lambda controller to re-process the cluster.",
+	"dialog.reconciling":            "Triggering Reconciliation",
+	"dialog.reconciling.body":       "Triggering reconciliation for cluster '%s'...",
+	"dialog.reconciled.body":        "Reconciliation triggered for cluster '%s'.\n\nCheck the cluster status for progress.",
+	"dialog.offline":                "Offline",
+	"dialog.offline.body":           "AWS is unreachable. The %s of cluster '%s' has been queued.\nYou will be asked to confirm it when the connection returns.",
+	"dialog.queued":                 "Queued Action",
+	"dialog.queued.body":            "While offline at %s you requested the %s of cluster '%s'.\n\nApply it now?",
+	"dialog.session_expired":        "AWS Session Expired",
+	"dialog.session_expired.body":   "Your AWS credentials (SSO or assumed role) have expired.\nLog in again to continue?",
+	"dialog.refreshing_credentials": "Refreshing AWS credentials...",
+	"dialog.draft":                  "Unsaved Draft",
+	"dialog.draft.body":             "An unsaved %s form for cluster '%s' from %s was kept.\n\nResume editing it?",
+	"dialog.confirm_edit":           "Confirm Changes",
+	"dialog.confirm_edit.body":      "Save the changes to cluster '%s'?",
+
+	// Impact previews of destructive actions
+	"impact.terminated":        "%d instance(s) will be terminated:",
+	"impact.more":              "... and %d more",
+	"impact.cost":              "Approximate cost change: %s per month",
+	"impact.cost_unknown":      "No price known for %s",
+	"commands.loading":         "Loading...",
+	"commands.none":            "No commands recorded",
+	"events.none":              "No events recorded",
+	"details.notes":            "Notes",
+	"details.notes_none":       "No notes; press %s to add the owner, purpose or a link",
+	"impact.workloads_loading": "Looking up workloads on these nodes...",
+	"impact.workloads":         "%d workload(s) on these nodes:",
+	"impact.workloads_none":    "No workloads besides DaemonSets on these nodes",
+	"impact.workloads_error":   "Workloads unknown: %v",
+
+	// Draft form kinds
+	"draft.create": "create",
+	"draft.edit":   "edit",
+	"draft.notes":  "notes",
+
+	// Queued intent nouns
+	"intent.delete": "deletion",
+	"intent.stop":   "stop",
+	"intent.start":  "start",
+
+	// Errors, formatted with Error(key, err)
+	"error.delete_cluster":      "Error deleting cluster",
+	"error.stop_cluster":        "Error stopping cluster",
+	"error.start_cluster":       "Error starting cluster",
+	"error.create_cluster":      "Error creating cluster",
+	"error.trigger_reconcile":   "Failed to trigger reconciliation",
+	"error.refresh_credentials": "Failed to refresh AWS credentials",
+	"error.load_config":         "Error loading config",
+	"error.load_keymap":         "Error loading keymap",
+
+	// Errors with arguments, formatted with T
+	"error.cannot_stop":        "Cannot stop cluster '%s' - it is not running (status: %s)",
+	"error.cannot_start":       "Cannot start cluster '%s' - it is not stopped (status: %s)",
+	"error.apply_intent":       "Failed to apply queued %s of '%s': %v",
+	"error.draft_cluster_gone": "Cluster '%s' no longer exists; its unsaved draft was discarded",
+
+	// Command help, by command path: cmd.<command>.<subcommand>.short and .long
+	"cmd.root.short":     "Goman - Kubernetes Cluster Manager",
+	"cmd.root.long":      "Goman is a CLI tool for managing Kubernetes clusters on AWS.",
+	"cmd.admin.short":    "Platform administration",
+	"cmd.admin.long":     "Commands for the team running goman: statistics across all clusters of the account, the organization policy, status repair and cleanup after deleted clusters.",
+	"cmd.admin.gc.short": "Delete cloud resources left behind by deleted clusters",
+	"cmd.admin.gc.long": `Finds the security groups, network interfaces, volumes and node IAM roles
+goman created for clusters whose config no longer exists in the state
+bucket, and deletes them. Only resources tagged with this state bucket are
+deleted; those of other installs sharing the account are left alone, and
+those created before installs were tagged are listed as untagged and kept.
+Resources still in use, such as security groups of instances left running,
+are listed and kept. Resources of renamed clusters still tagged with a
+former name belong to the cluster.
+
+The default region, the regions of existing clusters and the regions given
+with --region are searched; add the regions of deleted clusters that ran
+elsewhere.
+
+The controller runs the same collection every orphanGCInterval (default
+24h) from its schedule events, as a dry run unless the orphanGCDelete
+setting is on; --last shows the report of its last run.
+
+  --dry-run  list what would be deleted without deleting anything
+  --region   also search this region (repeatable)
+  --json     print the report as JSON
+  --last     show the report of the controller's last run`,
+	"cmd.admin.policy.short": "Manage the organization policy for clusters",
+	"cmd.admin.policy.long": `The organization policy sets guardrails on self-service clusters: a naming
+pattern, required tags, allowed regions and allowed instance families.
+It is stored in the goman bucket, so both the CLI and the controller reject
+clusters that break it when they are created or edited. Existing clusters
+keep running when the policy is tightened; their next edit must comply.
+
+Example policy file:
+
+  namePattern: "(dev|staging|prod)-[a-z0-9-]+"
+  requiredTags:
+    CostCenter: "[0-9]{4}"   # Regular expression the value must match
+    Owner: ""                # Any non-empty value
+  allowedRegions: [eu-west-1, eu-central-1]
+  allowedInstanceFamilies: [t3, m6i, c6i]`,
+	"cmd.admin.policy.clear.short": "Remove the organization policy",
+	"cmd.admin.policy.set.short":   "Set the organization policy from a YAML file",
+	"cmd.admin.policy.show.short":  "Show the organization policy",
+	"cmd.admin.prune.short":        "Delete old diagnostics, logs and unused binaries from the state bucket",
+	"cmd.admin.prune.long": `Deletes the objects of the state bucket past their retention:
+
+  diagnostics  full output of remote commands (ssm-output/)
+  logs         command history, session logs and the events of deleted
+               clusters
+  binaries     K3s binaries under binaries/k3s/ of versions no cluster runs
+               or is upgrading to
+
+The retention is set with 'goman init --retention', by default
+diagnostics=30,logs=90,binaries=30 days. On AWS, init also sets lifecycle
+rules so S3 expires diagnostics and logs by itself, and the controller
+prunes along with its orphan collection (orphanGCInterval). This command
+prunes now.
+
+  --dry-run    list what would be deleted without deleting anything
+  --retention  use other days for this run, e.g. binaries=0 to keep binaries
+  --json       print the report as JSON`,
+	"cmd.admin.resync.short": "Rebuild a cluster's status from the live AWS and K3s state",
+	"cmd.admin.resync.long": `A recovery tool for when a cluster's status.yaml got corrupted or out of
+sync with reality. resync asks the controller to throw the status away and
+rebuild it from scratch on its next reconcile:
+
+  - instances, IPs, zones and states from EC2 (workers stopped for reuse
+    by a scale-down are left out, as always)
+  - K3s nodes, their Ready state and version from a running master via SSM
+  - the API endpoint from the stored kubeconfig, which is read again from
+    a master if it is missing
+
+The phase follows what was found: Running with a running master, Stopped
+when every instance is stopped, and Pending, which provisions the cluster
+again, when no instance exists. Progress of rollouts, upgrades, quorum
+recoveries and similar operations is dropped, so they start over. The
+rebuild is recorded as a Resynced cluster event.`,
+	"cmd.admin.stats.short":           "Show statistics across all clusters",
+	"cmd.admin.stats.creations.short": "Show how long cluster creations take",
+	"cmd.admin.stats.creations.long": `Shows how long new clusters took from creation to Running, per mode and
+region: mean, median, 90th and 95th percentile, the slowest creation, and
+how many met the creationSLO controller setting.
+
+The controller also publishes every creation time to CloudWatch as the
+ClusterCreationTime metric in the Goman namespace (with and without the
+Mode and Region dimensions), and ClusterCreationBreach as 1 for creations
+over the objective, so alarms can be set on regressions.`,
+	"cmd.audit.short":     "Inspect the operation audit trail",
+	"cmd.audit.long":      "Inspect the append-only audit trail of cluster spec changes recorded in S3.",
+	"cmd.audit.log.short": "Show who changed what",
+	"cmd.audit.log.long": `Shows recorded cluster mutations (create, update, scale, delete, start, stop)
+with the initiating AWS principal, local user, and a summary of the changes.`,
+	"cmd.cleanup.short":          "Force cleanup a cluster (removes all resources)",
+	"cmd.cluster.short":          "Manage K3s clusters",
+	"cmd.cluster.long":           "Manage K3s cluster operations including create, list, delete, and connect.",
+	"cmd.cluster.annotate.short": "Show or edit the notes of a cluster",
+	"cmd.cluster.annotate.long": `Shows the notes of a cluster, or sets and removes them. Notes are freeform
+key/value pairs kept in the cluster's metadata annotations, so everyone
+sharing the state bucket sees them:
+
+  owner    person or team responsible for the cluster
+  purpose  what the cluster is for
+  link     ticket or document, e.g. a Jira issue
+  note     anything else
+
+Other keys work too. Notes are not part of the spec: changing them leaves
+the cluster as it is. They are shown by 'goman cluster list' (owner),
+'goman cluster status' and the cluster details in the TUI, where n edits
+them.
+
+  goman cluster annotate dev owner=alice purpose="load tests"
+  goman cluster annotate dev link=https://jira.example.com/browse/OPS-42
+  goman cluster annotate dev purpose-
+
+  --json  print the notes as JSON`,
+	"cmd.cluster.apply.short": "Create or update a cluster from a YAML or JSON manifest",
+	"cmd.cluster.apply.long": `Applies a manifest written by 'goman cluster export' (or by hand, as YAML
+or JSON). The cluster is created if it does not exist, otherwise its spec is
+replaced with the manifest's; fields left out are cleared. Mode and preset
+cannot change after creation.
+
+The manifest is validated and the changes are shown against the cluster as it
+is now (node pools created, resized or deleted, nodes terminated, cost) before
+you confirm. --dry-run only shows them, --yes applies without asking. Use
+'-f -' to read the manifest from stdin, together with --yes or --dry-run.`,
+	"cmd.cluster.bluegreen.short": "Create a twin of a cluster to test a change before cutting over",
+	"cmd.cluster.bluegreen.long": `Creates a new cluster (green) as a twin of a running HA cluster (blue): same
+mode, region, instance types, node pools, features and settings, optionally
+with another K3s version. Try the risky change on green, compare the two with
+'bluegreen status', then move the API endpoint over with 'bluegreen cutover'.
+
+Green is restored from an etcd snapshot of blue, taken when its masters are
+up and before its workers join, so it starts with blue's workloads and
+Kubernetes objects. It shares blue's K3s token, which the snapshot is
+encrypted with. Writes to blue after the snapshot are not copied. Blue must
+be HA: dev clusters keep their data outside etcd. Cluster links and the
+virtual IP are not copied; a load balancer is, green getting its own.
+
+The cutover moves the shared API endpoint of blue to green. A virtual IP is
+released by blue and taken over by green, whose masters must be in the same
+zone, as EC2 only moves an address within its subnet. Load balancers are
+swapped, so green serves the name of blue's; both clusters must be in one
+VPC. If green does not serve the endpoint within --timeout, both clusters
+get their old spec back. Green has its own CA, so clients also need its
+kubeconfig.`,
+	"cmd.cluster.bluegreen.abandon.short": "Forget the green twin of a cluster without cutting over",
+	"cmd.cluster.bluegreen.abandon.long": `Forgets the pairing of a cluster with its green twin. The twin itself is
+kept: delete it with 'goman cluster delete' if it is no longer needed.`,
+	"cmd.cluster.bluegreen.cutover.short": "Move the API endpoint of the cluster to its green twin",
+	"cmd.cluster.bluegreen.status.short":  "Show how the green twin differs from the cluster",
+	"cmd.cluster.commands.short":          "Show the commands the controller ran on the cluster's nodes",
+	"cmd.cluster.commands.long": `Lists the commands the controller ran on the nodes of a cluster (drains,
+DNS and virtual IP configuration, certificate checks, ...) with their target
+instances, status and duration, newest last. --output prints what each
+command wrote, cut off at 16 KiB; output containing credentials is not kept.`,
+	"cmd.cluster.connect.short": "Connect to a K3s cluster via SSM tunnel",
+	"cmd.cluster.connect.long": `Establishes an SSM tunnel to the specified K3s cluster.
+This creates a secure connection without requiring public IPs.`,
+	"cmd.cluster.create.short": "Create a cluster",
+	"cmd.cluster.create.long": `Creates a K3s cluster. The controller provisions it in the background; follow
+progress with 'goman cluster status <cluster-name>'.`,
+	"cmd.cluster.delete.short": "Delete a cluster and its resources",
+	"cmd.cluster.delete.long": `Deletes a cluster. You are asked to type the cluster name to confirm unless
+--yes is given.`,
+	"cmd.cluster.disconnect.short": "Disconnect from a K3s cluster",
+	"cmd.cluster.disconnect.long":  "Closes the SSM tunnel to the specified K3s cluster.",
+	"cmd.cluster.downscale.short":  "Downscale an HA cluster to dev mode",
+	"cmd.cluster.downscale.long": `Converts an HA cluster to dev mode. The controller removes the two extra
+masters from etcd one at a time, terminates them, and reconfigures the first
+master to keep serving on its own. Useful for demoting staging clusters to save cost.`,
+	"cmd.cluster.drift.short": "Show what was changed outside goman on a cluster's resources",
+	"cmd.cluster.drift.long": `Compares the cluster's spec and status with its EC2 instances and security
+groups as they are now, and lists what was changed outside goman:
+
+  instanceType        instances resized in the console or by other tools
+  tags                user tags of the spec changed or removed on instances
+  securityGroupRules  cluster rules removed from the security groups, and
+                      rules opening them to any address
+  instances           instances of the cluster missing from its status, or
+                      in its status but gone (only reported)
+
+The controller runs the same check on every reconcile of a running cluster,
+records the result in status and sets the Drifted condition. Each field is
+adopted or reverted according to the cluster's driftPolicy.
+
+  --fix   ask the controller to revert all drift once, whatever the policy;
+          an instance type is reverted by stopping and starting the instance
+  --json  print the drift as JSON`,
+	"cmd.cluster.edit.short": "Edit a cluster in $EDITOR",
+	"cmd.cluster.edit.long": `Opens the editable fields of a cluster (description, region, instance type,
+node pools) in $EDITOR and applies them when the file is saved. Validation
+errors are shown at the top of the file and the editor is reopened.`,
+	"cmd.cluster.events.short": "Show the events recorded for a cluster",
+	"cmd.cluster.events.long": `Lists what the controller recorded about a cluster, oldest first, like
+'kubectl get events': instances it launched, phase changes, failed
+reconciles and deletion. Events are kept after the cluster is deleted, up
+to 500 per cluster.`,
+	"cmd.cluster.export.short": "Export a cluster's spec as a YAML manifest",
+	"cmd.cluster.export.long": `Prints the user-owned spec of a cluster (mode, region, node pools, tags,
+taints, ...) as a YAML manifest that can be kept in git and re-applied with
+'goman cluster apply -f'. Nodes, addresses and other observed state are not
+included.`,
+	"cmd.cluster.list.short": "List clusters",
+	"cmd.cluster.list.long": `Lists clusters as stored in the state bucket, with the phase reported by
+the controller and the owner noted with 'goman cluster annotate'. --json prints the full cluster resources, including their
+resourceVersion, in the format used by the Go client package.`,
+	"cmd.cluster.logs.short": "Show controller logs for a cluster",
+	"cmd.cluster.logs.long": `Shows what the goman controller logged about a cluster, read from the
+CloudWatch Logs of the controller Lambda, so reconcile failures can be
+debugged without the AWS console.
+
+With --request-id, every line of that controller invocation is shown,
+including lines that don't name the cluster. 'goman cluster status' shows
+the request ID of the last reconcile.`,
+	"cmd.cluster.reconcile.short":      "Trigger reconciliation for a cluster",
+	"cmd.cluster.reconcile.long":       "Forces the Lambda controller to re-process the cluster.",
+	"cmd.cluster.recover-quorum.short": "Rebuild an HA control plane that lost etcd quorum",
+	"cmd.cluster.recover-quorum.long": `When two of three masters are lost, etcd has no quorum and the API server
+stops serving. recover-quorum asks the controller to rebuild the control plane
+from a running master:
+
+  1. etcd on the surviving master is reset to a single member with
+     'k3s server --cluster-reset', restored from its newest etcd snapshot
+  2. the other masters are terminated and launched again under their names
+  3. the new masters join the survivor; recovery completes once they are Ready
+
+With --restore none the survivor keeps its own etcd data instead of the
+snapshot, which also keeps the writes made since the last snapshot. Without
+a snapshot on the survivor, its own data is used either way.
+
+The controller refuses the request while etcd still has quorum. Workers keep
+running and reconnect to the survivor. Use --status to follow progress.`,
+	"cmd.cluster.rename.short": "Rename a cluster",
+	"cmd.cluster.rename.long": `Renames a cluster. Instances are re-tagged, the cluster's S3 objects are moved
+to the new name and security groups are re-tagged (EC2 group names cannot change,
+so the legacy name is recorded on the group). The old name keeps resolving to
+the new one for 7 days. The cluster must be running or stopped.`,
+	"cmd.cluster.retag.short": "Re-apply the cluster's tags to all of its AWS resources",
+	"cmd.cluster.retag.long": `Tag changes in the edit form are applied to existing instances, volumes and
+security groups automatically. retag forces the controller to compare and
+re-apply them anyway, e.g. after tags were changed or removed by hand or a
+cost-allocation tag scheme changed outside goman.`,
+	"cmd.cluster.rollout.short": "Pause, resume and inspect node pool rollouts",
+	"cmd.cluster.rollout.long": `When a node pool's instance type changes, the controller replaces its workers
+in batches (one node per batch unless configured otherwise). Between batches it
+checks for a pause, so a pause takes effect once the current batch has finished.
+A batch with a failed replacement halts the rollout until it is resumed.
+Pause and resume apply to K3s upgrades as well, between nodes.`,
+	"cmd.cluster.rollout.pause.short":  "Pause rollouts after the current batch",
+	"cmd.cluster.rollout.resume.short": "Resume a paused or halted rollout",
+	"cmd.cluster.rollout.status.short": "Show the current or last rollout",
+	"cmd.cluster.rotate-certs.short":   "Rotate the cluster's K3s certificates and refresh the kubeconfig",
+	"cmd.cluster.rotate-certs.long": `K3s renews its certificates only when it restarts within 90 days of their
+expiry, so a cluster that runs without restarts, or was stopped for a long
+time, can end up with expired certificates. rotate-certs asks the controller
+to run 'k3s certificate rotate' on each master in turn and to store a fresh
+kubeconfig afterwards.`,
+	"cmd.cluster.rotate-credentials.short": "Rotate the K3s token, client certificates and kubeconfigs",
+	"cmd.cluster.rotate-credentials.long": `Revokes cluster access handed out so far, e.g. when someone with a kubeconfig
+leaves. The controller rotates the K3s server and agent token, replaces the
+CA signing client certificates, restarts the masters and then the workers one
+at a time with the new token and certificates, and stores a new admin
+kubeconfig. The read-only kubeconfig's ServiceAccount is recreated, which
+revokes its tokens. Kubeconfigs and join tokens copied before stop working;
+fetch the new one with 'goman kubeconfig get --admin' once the rotation
+completed. Workers stopped during the rotation keep the old token and must
+be replaced. Tokens of other ServiceAccounts are not affected.`,
+	"cmd.cluster.schedule.short": "Show or set when a cluster is stopped and started",
+	"cmd.cluster.schedule.long": `Without flags, shows the cluster's schedule. --stop and --start take cron
+expressions (minute hour day-of-month month day-of-week) in --timezone, UTC by
+default; the controller checks them every 5 minutes. Stopping or starting the
+cluster by hand holds until its next scheduled action.
+
+  goman cluster schedule dev --stop "0 20 * * *" --start "0 8 * * 1-5" --timezone Europe/Berlin
+  goman cluster schedule dev --clear`,
+	"cmd.cluster.start.short":   "Start a stopped cluster",
+	"cmd.cluster.status.short":  "Show cluster progress and status",
+	"cmd.cluster.status.long":   "Shows detailed progress and status for K3s clusters including reconciliation progress, instance states, and recent activity.",
+	"cmd.cluster.stop.short":    "Stop a running cluster",
+	"cmd.cluster.stop.long":     "Stops all EC2 instances of a running cluster.",
+	"cmd.cluster.upgrade.short": "Upgrade K3s and inspect upgrades",
+	"cmd.cluster.upgrade.long": `When a cluster's K3s version changes, the controller upgrades its nodes in
+place one at a time: masters first, then workers pool by pool. Each node is
+drained, upgraded and must report the new version and be Ready before the next
+one starts. Downgrades and upgrades that skip a minor version are refused.
+
+The amd64 and arm64 K3s binaries of the new version are uploaded to the goman
+bucket under binaries/k3s/<version>/ from the K3s release if missing.
+
+Upgrades are paused and resumed with 'goman cluster rollout pause|resume'. A
+node that fails to upgrade halts the upgrade until it is resumed.`,
+	"cmd.cluster.upgrade.start.short":  "Upgrade a cluster to a K3s release",
+	"cmd.cluster.upgrade.status.short": "Show the current or last K3s upgrade",
+	"cmd.cluster.verify.short":         "Run smoke tests against a cluster",
+	"cmd.cluster.verify.long": `Runs a battery of smoke tests on a master of the cluster, in a temporary
+goman-verify namespace, and reports each as passed, failed or skipped:
+
+  nodes        every node is registered and Ready
+  deployment   an nginx deployment with 2 replicas rolls out
+  service      nginx answers on its ClusterIP service
+  dns          cluster DNS resolves the service name
+  pod-network  a pod reaches an nginx pod on another node (skipped on a
+               single node)
+  volume       a claim on the default storage class binds
+
+The run takes up to a few minutes; the nginx and busybox images are pulled
+from Docker Hub. The command fails when a test failed.
+
+With verifyOnProvision: true in the controller settings, the controller
+runs the same tests at the end of provisioning: new clusters become Running
+once they pass, and failed runs are retried every few minutes.
+
+  --json  print the results as JSON`,
+	"cmd.debug.short":           "Debug the controller against real state",
+	"cmd.debug.reconcile.short": "Run one reconcile of a cluster locally",
+	"cmd.debug.reconcile.long": `Runs one reconcile of a cluster on this machine with the code being worked
+on, against the real state of the configured account, without deploying a
+new controller function. Run it under a debugger to step through the
+controller:
+
+  dlv debug ./cmd/goman -- debug reconcile my-cluster --dry-run
+
+The reconcile takes the cluster lock like the controller does, and uses the
+controller settings stored in the account.
+
+  --phase         run the step of this phase (Pending, Provisioning,
+                  Installing, Configuring or Running) whatever the phase of
+                  the cluster is
+  --dry-run       make no changes: creating, changing or deleting cloud
+                  resources, running commands on nodes, sending
+                  notifications and invoking functions are skipped and
+                  listed; state writes are kept in memory and read back
+  --trace         log every cloud call with its arguments, duration and error
+  --snapshot-dir  save the cluster's stored objects before and after the
+                  reconcile, as <dir>/before and <dir>/after, and list the
+                  ones that changed; with --dry-run, after shows what would
+                  have been written`,
+	"cmd.init.short": "Initialize infrastructure",
+	"cmd.kube.short": "Run commands with KUBECONFIG configured",
+	"cmd.kube.long": `Run any command with the KUBECONFIG environment variable set to the connected cluster.
+
+Examples:
+  goman kube kubectl get nodes       # Run kubectl commands
+  goman kube k9s                     # Launch k9s
+  goman kube bash                    # Open shell with KUBECONFIG set
+  goman kube helm install myapp .    # Run helm commands
+  goman kube --force-clean kubectl get nodes  # Force clean all tunnels before connecting`,
+	"cmd.kubeconfig.short":     "Manage cluster kubeconfigs",
+	"cmd.kubeconfig.get.short": "Print the read-only or admin kubeconfig of a cluster",
+	"cmd.kubeconfig.get.long": `Prints the read-only kubeconfig of a cluster, or with --admin the admin
+kubeconfig. The read-only kubeconfig authenticates as a ServiceAccount bound
+to the view ClusterRole (no access to secrets) with a token the controller
+reissues before it expires, so fetch it again when access stops working.`,
+	"cmd.kubeconfig.share.short": "Create a time-limited kubeconfig download link",
+	"cmd.kubeconfig.share.long": `Prints a presigned URL that downloads the cluster kubeconfig without AWS
+credentials, for sharing cluster access with a teammate. The link expires
+after --ttl (at most 7 days), cut short to the expiry of the AWS credentials
+signing it when they are temporary; the printed validity is the link's. The shared kubeconfig is read-only (the view
+ClusterRole) unless --admin is given, in which case anyone holding the link
+gets admin access to the cluster; every share is recorded in the audit log.
+
+Requires the s3 secret backend.`,
+	"cmd.kubectl.short": "Manage kubectl access to K3s clusters",
+	"cmd.kubectl.long": `Connect to K3s clusters using secure SSM port forwarding.
+No public IPs or open security groups required.
+
+When run without subcommands, shows an interactive cluster selector.`,
+	"cmd.kubectl.connect.short": "Connect to a K3s cluster via SSM tunnel",
+	"cmd.kubectl.connect.long": `Establishes a secure SSM port forwarding session to the K3s API server.
+Downloads the kubeconfig and sets up kubectl context.
+If no cluster name is provided, shows an interactive selector.`,
+	"cmd.kubectl.disconnect.short": "Disconnect from a K3s cluster",
+	"cmd.kubectl.disconnect.long": `Stops the SSM tunnel for a connected cluster.
+If no cluster name is provided, shows an interactive selector.`,
+	"cmd.kubectl.exec.short": "Execute kubectl commands on a cluster",
+	"cmd.kubectl.exec.long": `Execute kubectl commands on a cluster with automatic SSM tunnel setup.
+If no cluster name is provided, shows an interactive selector.`,
+	"cmd.kubectl.status.short": "Show connection status for a cluster",
+	"cmd.local.short":          "Run clusters on local VMs",
+	"cmd.local.long": `Commands of the local provider, which runs clusters on VMs of this machine
+(GOMAN_PROVIDER=local). State lives in GOMAN_LOCAL_DIR (default ~/.goman/local);
+GOMAN_LOCAL_DRIVER selects multipass (default) or fake, which runs no VMs.`,
+	"cmd.local.controller.short": "Reconcile local clusters until interrupted",
+	"cmd.local.controller.long": `Runs the controller on this machine: clusters are reconciled when their spec
+changes and requeued as the cloud controller functions do. Keep it running
+while working with local clusters from the TUI or the CLI.`,
+	"cmd.node.short":         "Manage cluster nodes",
+	"cmd.node.long":          "Manage individual nodes of a K3s cluster.",
+	"cmd.node.replace.short": "Replace a worker node with a fresh instance",
+	"cmd.node.replace.long": `Replaces a worker node, identified by instance name or ID. The controller
+cordons and drains the node, provisions a replacement with the same pool
+configuration, waits for it to become Ready and then terminates the old instance.
+If the replacement does not become Ready, it is terminated and the old node is
+uncordoned. Useful for degraded or misbehaving nodes.`,
+	"cmd.tunnel.short": "Manage SSM tunnels",
+	"cmd.tunnel.long": `Manage the SSM tunnels to cluster API servers.
+
+Every cluster gets its own tunnel on a local port derived from its name
+(16443-17442), so several clusters can be tunneled at once. Tunnels are
+tracked in ~/.goman/tunnels.json and restarted when they are used after
+dying, while the TUI runs, or by 'goman tunnel watch'.`,
+	"cmd.tunnel.cleanup.short":  "Stop all SSM tunnels and orphaned processes",
+	"cmd.tunnel.cleanup.long":   "Stops all tracked SSM tunnels and kills SSM port-forward processes goman doesn't track.",
+	"cmd.tunnel.health.short":   "Check health of a specific tunnel",
+	"cmd.tunnel.health.long":    "Performs a health check on a specific SSM tunnel.",
+	"cmd.tunnel.list.short":     "List tunnels and their health",
+	"cmd.tunnel.status.short":   "Show tunnel status and diagnostics",
+	"cmd.tunnel.status.long":    "Shows the tracked SSM tunnels and SSM processes goman doesn't track.",
+	"cmd.tunnel.stop.short":     "Stop the tunnel to a cluster",
+	"cmd.tunnel.stop-all.short": "Stop the tunnels to all clusters",
+	"cmd.tunnel.watch.short":    "Restart tunnels that die until interrupted",
+	"cmd.version.short":         "Show the goman version",
+	"cmd.version.long": `Shows the version, commit and build date of goman. With --check, queries
+GitHub for a newer release. Set GOMAN_NO_UPDATE_CHECK=1 to never contact GitHub.`,
+}
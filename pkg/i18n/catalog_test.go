@@ -0,0 +1,142 @@
+package i18n
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// keyUse is a catalog key passed as a literal to T, Error or Has
+type keyUse struct {
+	key string
+	pos token.Position
+}
+
+// sourceFiles parses the non-test Go files of the goman command and packages
+func sourceFiles(t *testing.T) (*token.FileSet, []*ast.File) {
+	t.Helper()
+	fset := token.NewFileSet()
+	var files []*ast.File
+	for _, dir := range []string{"../../cmd", "../../pkg"} {
+		err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+			if err != nil || d.IsDir() || !strings.HasSuffix(path, ".go") || strings.HasSuffix(path, "_test.go") {
+				return err
+			}
+			f, err := parser.ParseFile(fset, path, nil, 0)
+			if err != nil {
+				return err
+			}
+			files = append(files, f)
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	return fset, files
+}
+
+// keysUsed returns the literal keys looked up with i18n.T, i18n.Error and
+// i18n.Has
+func keysUsed(fset *token.FileSet, files []*ast.File) []keyUse {
+	var uses []keyUse
+	for _, f := range files {
+		ast.Inspect(f, func(n ast.Node) bool {
+			call, ok := n.(*ast.CallExpr)
+			if !ok || len(call.Args) == 0 {
+				return true
+			}
+			if !isSelector(call.Fun, "i18n", "T") && !isSelector(call.Fun, "i18n", "Error") && !isSelector(call.Fun, "i18n", "Has") {
+				return true
+			}
+			lit, ok := call.Args[0].(*ast.BasicLit)
+			if !ok || lit.Kind != token.STRING {
+				return true
+			}
+			key, err := strconv.Unquote(lit.Value)
+			if err == nil {
+				uses = append(uses, keyUse{key: key, pos: fset.Position(lit.Pos())})
+			}
+			return true
+		})
+	}
+	return uses
+}
+
+// isSelector reports whether expr is the qualified identifier pkg.name
+func isSelector(expr ast.Expr, pkg, name string) bool {
+	sel, ok := expr.(*ast.SelectorExpr)
+	if !ok || sel.Sel.Name != name {
+		return false
+	}
+	id, ok := sel.X.(*ast.Ident)
+	return ok && id.Name == pkg
+}
+
+func TestCatalogHasEveryKey(t *testing.T) {
+	fset, files := sourceFiles(t)
+	uses := keysUsed(fset, files)
+	if len(uses) == 0 {
+		t.Fatal("no catalog lookups found")
+	}
+
+	used := make(map[string]bool)
+	for _, use := range uses {
+		used[use.key] = true
+		if _, ok := english[use.key]; !ok {
+			t.Errorf("%s: key %q is not in the English catalog", use.pos, use.key)
+		}
+	}
+
+	// Command help is only reached through its key
+	for key := range english {
+		if strings.HasPrefix(key, "cmd.") && !used[key] {
+			t.Errorf("command help %q is not used by any command", key)
+		}
+	}
+}
+
+func TestCommandHelpFromCatalog(t *testing.T) {
+	fset, files := sourceFiles(t)
+	commands := 0
+	for _, f := range files {
+		ast.Inspect(f, func(n ast.Node) bool {
+			lit, ok := n.(*ast.CompositeLit)
+			if !ok {
+				return true
+			}
+			if !isSelector(lit.Type, "cobra", "Command") {
+				return true
+			}
+			commands++
+			for _, elt := range lit.Elts {
+				kv, ok := elt.(*ast.KeyValueExpr)
+				if !ok {
+					continue
+				}
+				field, ok := kv.Key.(*ast.Ident)
+				if !ok || (field.Name != "Short" && field.Name != "Long") {
+					continue
+				}
+				call, ok := kv.Value.(*ast.CallExpr)
+				if !ok || !isSelector(call.Fun, "i18n", "T") || len(call.Args) != 1 {
+					t.Errorf("%s: %s is not looked up with i18n.T", fset.Position(kv.Value.Pos()), field.Name)
+					continue
+				}
+				if arg, ok := call.Args[0].(*ast.BasicLit); !ok || !strings.HasPrefix(arg.Value, `"cmd.`) ||
+					!strings.HasSuffix(arg.Value, "."+strings.ToLower(field.Name)+`"`) {
+					t.Errorf("%s: %s is not a cmd.<path>.%s key", fset.Position(kv.Value.Pos()), field.Name, strings.ToLower(field.Name))
+				}
+			}
+			return true
+		})
+	}
+	if commands == 0 {
+		t.Fatal("no commands found")
+	}
+}
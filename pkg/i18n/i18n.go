@@ -0,0 +1,171 @@
+// Package i18n holds the message catalogs for user-facing CLI and TUI text.
+//
+// English is built in and always complete. Localized builds register extra
+// catalogs from an init function in this package, typically in a file
+// guarded by a build tag:
+//
+//	//go:build locale_de
+//
+//	func init() {
+//		Register("de", map[string]string{
+//			"dialog.error":      "Fehler",
+//			"cmd.cluster.short": "Cluster verwalten",
+//		})
+//	}
+//
+// Command help is looked up when the goman command's variables are
+// initialized, before its own init functions run, so catalogs must be
+// registered in this package.
+// Missing keys fall back to English, so partial catalogs are fine.
+package i18n
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+)
+
+// DefaultLocale is used when no locale is selected or the selected locale
+// has no catalog
+const DefaultLocale = "en"
+
+var (
+	mu       sync.RWMutex
+	locale   = DefaultLocale
+	catalogs = map[string]map[string]string{
+		DefaultLocale: english,
+	}
+)
+
+func init() {
+	SetLocale(DetectLocale())
+}
+
+// Register adds messages for a locale. Calling it again for the same locale
+// merges the messages, later registrations win.
+func Register(loc string, messages map[string]string) {
+	loc = normalize(loc)
+	mu.Lock()
+	defer mu.Unlock()
+
+	catalog, ok := catalogs[loc]
+	if !ok {
+		catalog = make(map[string]string, len(messages))
+		catalogs[loc] = catalog
+	}
+	for key, msg := range messages {
+		catalog[key] = msg
+	}
+}
+
+// SetLocale selects the active locale. Region variants fall back to the
+// base language ("pt_BR" uses "pt" when only that is registered).
+func SetLocale(loc string) {
+	loc = normalize(loc)
+	mu.Lock()
+	defer mu.Unlock()
+
+	switch {
+	case catalogs[loc] != nil:
+		locale = loc
+	case catalogs[baseLanguage(loc)] != nil:
+		locale = baseLanguage(loc)
+	default:
+		locale = DefaultLocale
+	}
+}
+
+// Locale returns the active locale
+func Locale() string {
+	mu.RLock()
+	defer mu.RUnlock()
+	return locale
+}
+
+// Locales returns the locales that have a catalog
+func Locales() []string {
+	mu.RLock()
+	defer mu.RUnlock()
+	locales := make([]string, 0, len(catalogs))
+	for loc := range catalogs {
+		locales = append(locales, loc)
+	}
+	return locales
+}
+
+// DetectLocale returns the locale requested by the environment. GOMAN_LANG
+// takes precedence over the POSIX LC_ALL, LC_MESSAGES and LANG variables.
+func DetectLocale() string {
+	for _, env := range []string{"GOMAN_LANG", "LC_ALL", "LC_MESSAGES", "LANG"} {
+		if v := os.Getenv(env); v != "" && v != "C" && v != "POSIX" {
+			return v
+		}
+	}
+	return DefaultLocale
+}
+
+// T returns the message for key in the active locale, formatted with args.
+// Unknown keys return the key itself so missing entries are easy to spot.
+func T(key string, args ...interface{}) string {
+	msg := lookup(key)
+	if len(args) == 0 {
+		return msg
+	}
+	return fmt.Sprintf(msg, args...)
+}
+
+// Has reports whether the active locale, or English, defines key
+func Has(key string) bool {
+	mu.RLock()
+	defer mu.RUnlock()
+	if _, ok := catalogs[locale][key]; ok {
+		return true
+	}
+	_, ok := catalogs[DefaultLocale][key]
+	return ok
+}
+
+// Error formats err for display, prefixed by the message for key.
+// The message for key describes what failed, e.g. "Error deleting cluster".
+func Error(key string, err error) string {
+	if err == nil {
+		return lookup(key)
+	}
+	return fmt.Sprintf("%s: %v", lookup(key), err)
+}
+
+func lookup(key string) string {
+	mu.RLock()
+	defer mu.RUnlock()
+	if msg, ok := catalogs[locale][key]; ok {
+		return msg
+	}
+	if msg, ok := catalogs[DefaultLocale][key]; ok {
+		return msg
+	}
+	return key
+}
+
+// normalize turns values like "de_DE.UTF-8" or "de-DE" into "de_DE"
+func normalize(loc string) string {
+	if i := strings.IndexAny(loc, ".@"); i >= 0 {
+		loc = loc[:i]
+	}
+	loc = strings.ReplaceAll(loc, "-", "_")
+	if loc == "" {
+		return DefaultLocale
+	}
+	parts := strings.SplitN(loc, "_", 2)
+	if len(parts) == 2 {
+		return strings.ToLower(parts[0]) + "_" + strings.ToUpper(parts[1])
+	}
+	return strings.ToLower(loc)
+}
+
+func baseLanguage(loc string) string {
+	if i := strings.Index(loc, "_"); i >= 0 {
+		return loc[:i]
+	}
+	return loc
+}
@@ -1,15 +1,35 @@
 package logger
 
 import (
-	"log"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
 	"os"
+	"slices"
+	"strings"
 )
 
-var silent bool
+// Environment variables configuring the log records
+const (
+	LevelEnv  = "GOMAN_LOG_LEVEL"  // debug, info (default), warn or error
+	FormatEnv = "GOMAN_LOG_FORMAT" // json (default in Lambda) or text
+)
+
+var (
+	silent bool
+	level  = new(slog.LevelVar)
+	base   *slog.Logger
+	output io.Writer = os.Stderr
+)
 
 func init() {
 	// Silent in TUI mode (default), verbose in Lambda or debug mode
 	silent = os.Getenv("AWS_LAMBDA_RUNTIME_API") == "" && os.Getenv("GOMAN_DEBUG") != "true"
+	if err := Configure(os.Getenv(LevelEnv), os.Getenv(FormatEnv)); err != nil {
+		fmt.Fprintf(os.Stderr, "Ignoring log settings: %v\n", err)
+		Configure("", "")
+	}
 }
 
 // SetSilent allows runtime control of logging
@@ -17,16 +37,130 @@ func SetSilent(s bool) {
 	silent = s
 }
 
+// Configure sets the lowest level logged and the format of the records, as
+// read from GOMAN_LOG_LEVEL and GOMAN_LOG_FORMAT at start. An empty level
+// is info, or debug with GOMAN_DEBUG; an empty format is JSON in Lambda,
+// where CloudWatch Logs Insights queries its fields, and text elsewhere.
+func Configure(levelName, format string) error {
+	lvl := slog.LevelInfo
+	if os.Getenv("GOMAN_DEBUG") == "true" {
+		lvl = slog.LevelDebug
+	}
+	if levelName != "" {
+		if err := lvl.UnmarshalText([]byte(levelName)); err != nil {
+			return fmt.Errorf("invalid log level %q, want debug, info, warn or error", levelName)
+		}
+	}
+	if format == "" {
+		format = "text"
+		if os.Getenv("AWS_LAMBDA_RUNTIME_API") != "" {
+			format = "json"
+		}
+	}
+
+	opts := &slog.HandlerOptions{Level: level}
+	var handler slog.Handler
+	switch strings.ToLower(format) {
+	case "json":
+		handler = slog.NewJSONHandler(output, opts)
+	case "text":
+		handler = slog.NewTextHandler(output, opts)
+	default:
+		return fmt.Errorf("invalid log format %q, want json or text", format)
+	}
+	level.Set(lvl)
+	base = slog.New(handler)
+	return nil
+}
+
+// CaptureStandardLog sends the output of the standard log package through
+// the configured handler, so packages still using it log records too
+func CaptureStandardLog() {
+	slog.SetDefault(base)
+}
+
+type attrsKey struct{}
+
+// With returns a context whose log records carry the given attributes,
+// given as slog key-value pairs or slog.Attr. They replace attributes of
+// the same key the context already carries.
+func With(ctx context.Context, args ...any) context.Context {
+	attrs := slices.Clone(attrsFrom(ctx))
+	var added slog.Record
+	added.Add(args...)
+	added.Attrs(func(a slog.Attr) bool {
+		attrs = slices.DeleteFunc(attrs, func(b slog.Attr) bool { return b.Key == a.Key })
+		attrs = append(attrs, a)
+		return true
+	})
+	return context.WithValue(ctx, attrsKey{}, attrs)
+}
+
+// attrsFrom returns the attributes set on a context by With
+func attrsFrom(ctx context.Context) []slog.Attr {
+	if ctx == nil {
+		return nil
+	}
+	attrs, _ := ctx.Value(attrsKey{}).([]slog.Attr)
+	return attrs
+}
+
+// Debugf logs a debug record with the attributes of ctx
+func Debugf(ctx context.Context, format string, v ...interface{}) {
+	logf(ctx, slog.LevelDebug, format, v...)
+}
+
+// Infof logs an info record with the attributes of ctx
+func Infof(ctx context.Context, format string, v ...interface{}) {
+	logf(ctx, slog.LevelInfo, format, v...)
+}
+
+// Warnf logs a warning record with the attributes of ctx
+func Warnf(ctx context.Context, format string, v ...interface{}) {
+	logf(ctx, slog.LevelWarn, format, v...)
+}
+
+// Errorf logs an error record with the attributes of ctx
+func Errorf(ctx context.Context, format string, v ...interface{}) {
+	logf(ctx, slog.LevelError, format, v...)
+}
+
 // Printf logs formatted output if not in silent mode
 func Printf(format string, v ...interface{}) {
-	if !silent {
-		log.Printf(format, v...)
-	}
+	logf(context.Background(), slog.LevelInfo, format, v...)
 }
 
 // Println logs output if not in silent mode
 func Println(v ...interface{}) {
-	if !silent {
-		log.Println(v...)
+	logf(context.Background(), slog.LevelInfo, "%s", strings.TrimSuffix(fmt.Sprintln(v...), "\n"))
+}
+
+// logf logs a record unless in silent mode. A message starting with a tag
+// such as "[DNS] " has it moved to the step attribute.
+func logf(ctx context.Context, lvl slog.Level, format string, v ...interface{}) {
+	if silent || !base.Enabled(ctx, lvl) {
+		return
+	}
+	msg := fmt.Sprintf(format, v...)
+	attrs := attrsFrom(ctx)
+	if step, rest, ok := cutStep(msg); ok {
+		attrs = append(slices.Clip(attrs), slog.String("step", step))
+		msg = rest
 	}
-}
\ No newline at end of file
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	base.LogAttrs(ctx, lvl, msg, attrs...)
+}
+
+// cutStep splits a leading "[STEP] " tag from a message
+func cutStep(msg string) (step, rest string, ok bool) {
+	if !strings.HasPrefix(msg, "[") {
+		return "", msg, false
+	}
+	tag, rest, ok := strings.Cut(msg[1:], "] ")
+	if !ok || tag == "" || strings.ContainsAny(tag, " []") {
+		return "", msg, false
+	}
+	return strings.ToLower(tag), rest, true
+}
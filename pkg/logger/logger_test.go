@@ -0,0 +1,43 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+)
+
+func TestRecordsCarryContextAttributes(t *testing.T) {
+	var buf bytes.Buffer
+	output = &buf
+	silent = false
+	if err := Configure("info", "json"); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := With(context.Background(), "cluster", "demo", "requestID", "r-1")
+	ctx = With(ctx, "requestID", "r-2")
+	Debugf(ctx, "hidden")
+	Warnf(ctx, "[DNS] Failed to update record of %s", "demo")
+
+	var record map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &record); err != nil {
+		t.Fatalf("want one JSON record, got %q: %v", buf.String(), err)
+	}
+	want := map[string]any{
+		"level":     "WARN",
+		"msg":       "Failed to update record of demo",
+		"cluster":   "demo",
+		"requestID": "r-2",
+		"step":      "dns",
+	}
+	for key, value := range want {
+		if record[key] != value {
+			t.Errorf("%s = %v, want %v", key, record[key], value)
+		}
+	}
+
+	if err := Configure("loud", ""); err == nil {
+		t.Error("invalid level accepted")
+	}
+}
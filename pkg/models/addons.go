@@ -0,0 +1,169 @@
+package models
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Addon is a component the controller installs into the cluster: a Helm
+// chart, from AddonCatalog by name or from a chart repository, or the
+// manifests at a URL. Both are written to the K3s manifests directory of
+// the masters, charts as HelmChart resources of the K3s Helm controller.
+type Addon struct {
+	Name        string `json:"name" yaml:"name"`
+	Chart       string `json:"chart,omitempty" yaml:"chart,omitempty"`             // Chart name in the repository, or an oci:// or https:// chart reference
+	Repo        string `json:"repo,omitempty" yaml:"repo,omitempty"`               // Chart repository URL
+	Version     string `json:"version,omitempty" yaml:"version,omitempty"`         // Chart version, the latest if empty
+	Namespace   string `json:"namespace,omitempty" yaml:"namespace,omitempty"`     // Namespace the chart is installed in, created if missing
+	Values      string `json:"values,omitempty" yaml:"values,omitempty"`           // Helm values, as YAML
+	ManifestURL string `json:"manifestURL,omitempty" yaml:"manifestURL,omitempty"` // URL of Kubernetes manifests to apply instead of a chart
+}
+
+// AddonCatalog are the addons installed by name alone. Listing one with a
+// version, namespace or values overrides those of the catalog.
+var AddonCatalog = map[string]Addon{
+	"ingress-nginx": {
+		Name:      "ingress-nginx",
+		Chart:     "ingress-nginx",
+		Repo:      "https://kubernetes.github.io/ingress-nginx",
+		Version:   "4.11.3",
+		Namespace: "ingress-nginx",
+	},
+	"cert-manager": {
+		Name:      "cert-manager",
+		Chart:     "cert-manager",
+		Repo:      "https://charts.jetstack.io",
+		Version:   "v1.16.1",
+		Namespace: "cert-manager",
+		Values:    "crds:\n  enabled: true\n",
+	},
+	"metrics-server": {
+		Name:      "metrics-server",
+		Chart:     "metrics-server",
+		Repo:      "https://kubernetes-sigs.github.io/metrics-server/",
+		Version:   "3.12.2",
+		Namespace: "kube-system",
+	},
+}
+
+// AddonNames returns the names of the catalog addons, sorted
+func AddonNames() []string {
+	names := make([]string, 0, len(AddonCatalog))
+	for name := range AddonCatalog {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// IsChart reports whether the addon is a Helm chart rather than manifests
+func (a Addon) IsChart() bool {
+	return a.ManifestURL == ""
+}
+
+// Resolve returns the addon with the catalog entry of its name filled in
+// when it names neither a chart nor manifests. Others are returned as is.
+func (a Addon) Resolve() Addon {
+	if a.Chart != "" || a.ManifestURL != "" {
+		return a
+	}
+	entry, ok := AddonCatalog[a.Name]
+	if !ok {
+		return a
+	}
+	if a.Version != "" {
+		entry.Version = a.Version
+	}
+	if a.Namespace != "" {
+		entry.Namespace = a.Namespace
+	}
+	if a.Values != "" {
+		entry.Values = a.Values
+	}
+	return entry
+}
+
+// Source describes where a resolved addon comes from: the chart and its
+// repository, or the manifest URL
+func (a Addon) Source() string {
+	if !a.IsChart() {
+		return a.ManifestURL
+	}
+	if a.Repo == "" {
+		return a.Chart
+	}
+	return strings.TrimSuffix(a.Repo, "/") + "/" + a.Chart
+}
+
+// Key identifies the settings of a resolved addon, to detect changes that
+// must be installed again
+func (a Addon) Key() string {
+	sum := sha256.Sum256([]byte(strings.Join([]string{a.Chart, a.Repo, a.Version, a.Namespace, a.Values, a.ManifestURL}, "\x00")))
+	return hex.EncodeToString(sum[:8])
+}
+
+// addonName is a Kubernetes resource name short enough for a Helm release
+var addonName = regexp.MustCompile(`^[a-z0-9]([-a-z0-9]{0,51}[a-z0-9])?$`)
+
+// ValidateAddons checks the addons of a cluster: each is listed once, and
+// is a catalog addon, a chart or a manifest URL. Charts need the K3s Helm
+// controller, which the low-resource profile turns off.
+func ValidateAddons(addons []Addon, lowResource bool) error {
+	seen := make(map[string]bool)
+	for _, addon := range addons {
+		if !addonName.MatchString(addon.Name) {
+			return fmt.Errorf("addon %q: name must be lowercase letters, digits and dashes, at most 53 characters", addon.Name)
+		}
+		if seen[addon.Name] {
+			return fmt.Errorf("addon %s is listed twice", addon.Name)
+		}
+		seen[addon.Name] = true
+
+		resolved := addon.Resolve()
+		switch {
+		case resolved.Chart != "" && resolved.ManifestURL != "":
+			return fmt.Errorf("addon %s: set either chart or manifestURL, not both", addon.Name)
+		case resolved.Chart == "" && resolved.ManifestURL == "":
+			return fmt.Errorf("addon %s: not in the catalog (%s), set chart or manifestURL", addon.Name, strings.Join(AddonNames(), ", "))
+		case resolved.ManifestURL != "":
+			if !strings.HasPrefix(resolved.ManifestURL, "https://") && !strings.HasPrefix(resolved.ManifestURL, "http://") {
+				return fmt.Errorf("addon %s: manifestURL must be an http(s) URL", addon.Name)
+			}
+			if resolved.Repo != "" || resolved.Version != "" || resolved.Namespace != "" || resolved.Values != "" {
+				return fmt.Errorf("addon %s: repo, version, namespace and values only apply to charts", addon.Name)
+			}
+		case lowResource:
+			return fmt.Errorf("addon %s: charts need the K3s Helm controller, which lowResource turns off; use manifestURL", addon.Name)
+		}
+	}
+	return nil
+}
+
+// AddonStatus reports the installation of an addon. The source is kept so
+// the addon can be removed after it was taken out of the spec.
+type AddonStatus struct {
+	Name        string     `json:"name" yaml:"name"`
+	Key         string     `json:"key" yaml:"key"`
+	Chart       bool       `json:"chart,omitempty" yaml:"chart,omitempty"` // Installed as a HelmChart resource
+	Source      string     `json:"source,omitempty" yaml:"source,omitempty"`
+	Version     string     `json:"version,omitempty" yaml:"version,omitempty"`     // Chart version installed
+	Namespace   string     `json:"namespace,omitempty" yaml:"namespace,omitempty"` // Namespace the chart is installed in
+	Masters     []string   `json:"masters,omitempty" yaml:"masters,omitempty"`     // Masters whose manifests directory has the addon
+	Message     string     `json:"message,omitempty" yaml:"message,omitempty"`     // Why the last installation failed
+	InstalledAt *time.Time `json:"installedAt,omitempty" yaml:"installedAt,omitempty"`
+}
+
+// HasMaster reports whether the addon was written to a master
+func (s *AddonStatus) HasMaster(id string) bool {
+	for _, m := range s.Masters {
+		if m == id {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,56 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+)
+
+// CPU architectures of nodes, as named by EC2
+const (
+	ArchitectureX86_64 = "x86_64"
+	ArchitectureARM64  = "arm64"
+)
+
+// InstanceArchitecture returns the CPU architecture of an instance type:
+// arm64 for AWS Graviton types, whose family has a "g" attribute (t4g,
+// m7g, c6gd, im4gn) or is a1, x86_64 for all others
+func InstanceArchitecture(instanceType string) string {
+	family, _, _ := strings.Cut(instanceType, ".")
+	family, _, _ = strings.Cut(family, "-")
+	if family == "a1" {
+		return ArchitectureARM64
+	}
+	i := strings.IndexAny(family, "0123456789")
+	if i <= 0 {
+		return ArchitectureX86_64
+	}
+	if attributes := strings.TrimLeft(family[i:], "0123456789"); strings.Contains(attributes, "g") {
+		return ArchitectureARM64
+	}
+	return ArchitectureX86_64
+}
+
+// K3sArchitecture returns the name K3s release binaries use for an
+// architecture: amd64 or arm64
+func K3sArchitecture(architecture string) string {
+	if architecture == ArchitectureARM64 {
+		return "arm64"
+	}
+	return "amd64"
+}
+
+// ValidateArchitecture checks an architecture asked for by what, and that
+// the instance type, if set, has it
+func ValidateArchitecture(what, architecture, instanceType string) error {
+	switch architecture {
+	case "":
+		return nil
+	case ArchitectureX86_64, ArchitectureARM64:
+	default:
+		return fmt.Errorf("%s: invalid architecture %q, want %s or %s", what, architecture, ArchitectureX86_64, ArchitectureARM64)
+	}
+	if instanceType != "" && InstanceArchitecture(instanceType) != architecture {
+		return fmt.Errorf("%s: instance type %s is not %s", what, instanceType, architecture)
+	}
+	return nil
+}
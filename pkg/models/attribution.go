@@ -0,0 +1,17 @@
+package models
+
+import "time"
+
+// UnattributedChange is a change made outside goman, such as a terminated
+// instance, whose author the controller is still looking up in the cloud
+// provider's audit log. Audit logs deliver calls minutes after they are
+// made, so the lookup is retried for a while.
+type UnattributedChange struct {
+	ResourceID  string    `json:"resourceId" yaml:"resourceId"`
+	Node        string    `json:"node,omitempty" yaml:"node,omitempty"`
+	Field       string    `json:"field,omitempty" yaml:"field,omitempty"` // Drift field the change caused, if any
+	Description string    `json:"description" yaml:"description"`         // e.g. "Instance i-abc (demo-worker-default-0) terminated"
+	EventNames  []string  `json:"eventNames" yaml:"eventNames"`           // API calls that make the change
+	DetectedAt  time.Time `json:"detectedAt" yaml:"detectedAt"`
+	CheckedAt   time.Time `json:"checkedAt,omitempty" yaml:"checkedAt,omitempty"`
+}
@@ -0,0 +1,101 @@
+package models
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// MaxPreInstallScript bounds the pre-install hook, which shares the user
+// data limit with the bootstrap script and the pools' snippets
+const MaxPreInstallScript = 4 * 1024
+
+// BootstrapSpec adds to the bootstrap script of new nodes: a hook run before
+// packages are installed, extra packages and registry mirrors for K3s's
+// containerd. Running nodes keep the script they booted with.
+type BootstrapSpec struct {
+	PreInstall      string              `json:"preInstall,omitempty" yaml:"preInstall,omitempty"`           // Shell script, e.g. to trust a corporate CA
+	ExtraPackages   []string            `json:"extraPackages,omitempty" yaml:"extraPackages,omitempty"`     // Installed with the node's package manager
+	RegistryMirrors map[string][]string `json:"registryMirrors,omitempty" yaml:"registryMirrors,omitempty"` // Registry, e.g. docker.io, to mirror endpoints
+}
+
+var (
+	packageName  = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._+:-]*$`)
+	registryHost = regexp.MustCompile(`^(\*|[A-Za-z0-9]([A-Za-z0-9.-]*[A-Za-z0-9])?(:[0-9]+)?)$`)
+)
+
+// Enabled reports whether the bootstrap script is extended
+func (b *BootstrapSpec) Enabled() bool {
+	return b != nil && (b.PreInstall != "" || len(b.ExtraPackages) > 0 || len(b.RegistryMirrors) > 0)
+}
+
+// Key describes the additions without credentials, so changes can be
+// audited; the hook is identified by its hash
+func (b *BootstrapSpec) Key() string {
+	if !b.Enabled() {
+		return "none"
+	}
+	hook := "none"
+	if b.PreInstall != "" {
+		hook = fmt.Sprintf("sha256:%x", sha256.Sum256([]byte(b.PreInstall)))[:19]
+	}
+	packages := append([]string(nil), b.ExtraPackages...)
+	sort.Strings(packages)
+	var mirrors []string
+	for _, registry := range b.Registries() {
+		var endpoints []string
+		for _, endpoint := range b.RegistryMirrors[registry] {
+			endpoints = append(endpoints, redactProxy(endpoint))
+		}
+		mirrors = append(mirrors, registry+"="+strings.Join(endpoints, "|"))
+	}
+	return fmt.Sprintf("preInstall=%s,packages=%s,mirrors=%s", hook, strings.Join(packages, ";"), strings.Join(mirrors, ";"))
+}
+
+// Registries returns the mirrored registries in sorted order
+func (b *BootstrapSpec) Registries() []string {
+	registries := make([]string, 0, len(b.RegistryMirrors))
+	for registry := range b.RegistryMirrors {
+		registries = append(registries, registry)
+	}
+	sort.Strings(registries)
+	return registries
+}
+
+// ValidateBootstrap checks the hook size, package names and mirrors
+func ValidateBootstrap(spec *BootstrapSpec) error {
+	if spec == nil {
+		return nil
+	}
+	if len(spec.PreInstall) > MaxPreInstallScript {
+		return fmt.Errorf("bootstrap: preInstall is %d bytes, the limit is %d", len(spec.PreInstall), MaxPreInstallScript)
+	}
+	for _, pkg := range spec.ExtraPackages {
+		if !packageName.MatchString(pkg) {
+			return fmt.Errorf("bootstrap: invalid package name %q", pkg)
+		}
+	}
+	return validateMirrors("bootstrap", spec.RegistryMirrors)
+}
+
+// validateMirrors checks registry hosts and their mirror endpoints
+func validateMirrors(what string, mirrors map[string][]string) error {
+	for registry, endpoints := range mirrors {
+		if !registryHost.MatchString(registry) {
+			return fmt.Errorf("%s: invalid registry %q, want a host such as docker.io or *", what, registry)
+		}
+		if len(endpoints) == 0 {
+			return fmt.Errorf("%s: registry %s has no mirror endpoints", what, registry)
+		}
+		for _, endpoint := range endpoints {
+			u, err := url.Parse(endpoint)
+			if err != nil || u.Host == "" || (u.Scheme != "http" && u.Scheme != "https") || !proxyValue.MatchString(endpoint) {
+				return fmt.Errorf("%s: mirror of %s must be an http:// or https:// URL, got %q", what, registry, redactProxy(endpoint))
+			}
+		}
+	}
+	return nil
+}
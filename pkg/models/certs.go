@@ -0,0 +1,59 @@
+package models
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// CertificateWarningWindow is how long before expiry certificates are
+// warned about in the UI
+const CertificateWarningWindow = 30 * 24 * time.Hour
+
+// CertificateExpiry is the expiry date of one K3s certificate on a master
+type CertificateExpiry struct {
+	Node      string    `json:"node" yaml:"node"`
+	Name      string    `json:"name" yaml:"name"` // e.g. client-admin, serving-kube-apiserver
+	ExpiresAt time.Time `json:"expiresAt" yaml:"expiresAt"`
+}
+
+// CertificateStatus tracks the expiry of the cluster's certificates. K3s
+// renews them only when it restarts close to their expiry, so clusters that
+// were stopped for a long time can come back with expired certificates.
+type CertificateStatus struct {
+	ExpiresAt           *time.Time          `json:"expiresAt,omitempty" yaml:"expiresAt,omitempty"`                     // Earliest server certificate expiry
+	KubeconfigExpiresAt *time.Time          `json:"kubeconfigExpiresAt,omitempty" yaml:"kubeconfigExpiresAt,omitempty"` // Client certificate of the stored kubeconfig
+	Certificates        []CertificateExpiry `json:"certificates,omitempty" yaml:"certificates,omitempty"`
+	CheckedAt           time.Time           `json:"checkedAt" yaml:"checkedAt"`
+	RotatedAt           *time.Time          `json:"rotatedAt,omitempty" yaml:"rotatedAt,omitempty"`
+	Message             string              `json:"message,omitempty" yaml:"message,omitempty"` // Why the last check or rotation failed
+}
+
+// EarliestExpiry returns the first expiry of the server certificates and
+// the stored kubeconfig, nil if none is known
+func (s *CertificateStatus) EarliestExpiry() *time.Time {
+	if s == nil {
+		return nil
+	}
+	earliest := s.ExpiresAt
+	if s.KubeconfigExpiresAt != nil && (earliest == nil || s.KubeconfigExpiresAt.Before(*earliest)) {
+		earliest = s.KubeconfigExpiresAt
+	}
+	return earliest
+}
+
+// CertificateWarning describes certificates that expired or expire within
+// the warning window, "" otherwise
+func CertificateWarning(expiresAt *time.Time, now time.Time) string {
+	if expiresAt == nil {
+		return ""
+	}
+	left := expiresAt.Sub(now)
+	switch {
+	case left <= 0:
+		return fmt.Sprintf("certificates expired on %s", expiresAt.Format("2006-01-02"))
+	case left <= CertificateWarningWindow:
+		return fmt.Sprintf("certificates expire in %d day(s)", int(math.Ceil(left.Hours()/24)))
+	}
+	return ""
+}
@@ -80,6 +80,74 @@ type K3sCluster struct {
 	Features       K3sFeatures   `json:"features"`
 	DesiredState   string        `json:"desired_state"` // "running" or "stopped"
 	NodePools      []NodePool    `json:"node_pools,omitempty"` // Worker node pools
+	Preset         string        `json:"preset,omitempty"`     // Sizing preset (nano, dev, small, standard)
+	LowResource    bool          `json:"low_resource,omitempty"` // K3s tuning for very small instances
+	Architecture   string        `json:"architecture,omitempty"` // CPU architecture of the masters, x86_64 or arm64
+	ImageID        string        `json:"image_id,omitempty"`     // Custom AMI of the nodes
+	OSFamily       string        `json:"os_family,omitempty"`    // OS family of the nodes' bootstrap variant
+	PrivateNetwork bool          `json:"private_network,omitempty"` // Nodes without public IPs, fixed at creation
+
+	VpcID            string   `json:"vpc_id,omitempty"`             // VPC to launch in instead of the default one
+	SubnetIDs        []string `json:"subnet_ids,omitempty"`         // Subnets of VpcID, one per zone used
+	SecurityGroupIDs []string `json:"security_group_ids,omitempty"` // Security groups added to the cluster's own
+	DedicatedVPC     bool     `json:"dedicated_vpc,omitempty"`      // A VPC created for the cluster alone
+	VpcCIDR          string   `json:"vpc_cidr,omitempty"`           // Address range of the dedicated VPC
+	RootVolume     *RootVolume   `json:"root_volume,omitempty"`  // Root volume of the masters and of pools without their own
+
+	NodeReplacements []NodeReplacement `json:"node_replacements,omitempty"` // Pending worker replacements
+
+	DriftPolicy map[string]DriftPolicy `json:"drift_policy,omitempty"` // Per-field drift handling, adopt by default
+	Drift       []DriftStatus          `json:"drift,omitempty"`        // Drift reported by the controller
+
+	Rollout       *RolloutSpec   `json:"rollout,omitempty"`        // Rollout batching and pause
+	RolloutStatus *RolloutStatus `json:"rollout_status,omitempty"` // Rollout reported by the controller
+
+	DNS *DNSSpec `json:"dns,omitempty"` // CoreDNS customization
+
+	VirtualIP *VirtualIPSpec `json:"virtual_ip,omitempty"` // Floating API endpoint on the masters (HA only)
+
+	LoadBalancer *LoadBalancerSpec `json:"load_balancer,omitempty"` // Load balancer in front of the masters (HA only)
+
+	InstanceProtection *InstanceProtectionSpec `json:"instance_protection,omitempty"` // Shutdown behavior and stop protection
+
+	ClusterLinks      []ClusterLink       `json:"cluster_links,omitempty"`       // Private connectivity to other clusters
+	ClusterLinkStatus []ClusterLinkStatus `json:"cluster_link_status,omitempty"` // Link state reported by the controller
+
+	Addons      []Addon       `json:"addons,omitempty"`       // Helm charts and manifests installed into the cluster
+	AddonStatus []AddonStatus `json:"addon_status,omitempty"` // Addon installations reported by the controller
+
+	Notifications *NotificationPolicy `json:"notifications,omitempty"` // Webhooks and SNS topics told about phase changes
+
+	Proxy *ProxySpec `json:"proxy,omitempty"` // Outbound HTTP(S) proxy of the nodes
+
+	Bootstrap *BootstrapSpec `json:"bootstrap,omitempty"` // Pre-install hook, extra packages and registry mirrors of new nodes
+
+	Registries *RegistrySpec `json:"registries,omitempty"` // Registry mirrors and credentials of all nodes
+
+	Schedule          *ScheduleSpec `json:"schedule,omitempty"`             // Times at which the cluster is stopped and started
+	DesiredStateSetAt *time.Time    `json:"desired_state_set_at,omitempty"` // Last stop or start by hand
+
+	RetagRequestedAt *time.Time `json:"retag_requested_at,omitempty"` // Re-apply Tags to all resources
+
+	CertRotationRequestedAt *time.Time `json:"cert_rotation_requested_at,omitempty"` // Rotate the K3s certificates on all masters
+	CertificatesExpireAt    *time.Time `json:"certificates_expire_at,omitempty"`     // Earliest certificate expiry, from the controller
+
+	CredentialRotationRequestedAt *time.Time `json:"credential_rotation_requested_at,omitempty"` // Rotate the K3s token, client certificates and kubeconfigs
+
+	QuorumRecovery *QuorumRecoveryRequest `json:"quorum_recovery,omitempty"` // Rebuild the control plane after etcd lost quorum
+
+	Restore *SnapshotRestoreRequest `json:"restore,omitempty"` // Restore from an etcd snapshot of another cluster at creation
+
+	ResyncRequestedAt *time.Time `json:"resync_requested_at,omitempty"` // Rebuild the status from the live state
+
+	DriftFixRequestedAt *time.Time `json:"drift_fix_requested_at,omitempty"` // Revert all drift once
+
+	EstimatedCompletion *time.Time `json:"estimated_completion,omitempty"` // Expected end of setup or rollout, from the controller
+
+	Generation         int `json:"generation,omitempty"`          // Spec generation, bumped on every write
+	ObservedGeneration int `json:"observed_generation,omitempty"` // Last generation the controller fully processed
+
+	Annotations map[string]string `json:"annotations,omitempty"` // Notes and goman's own metadata, outside the spec
 }
 
 // GetMasterCount returns the number of master nodes based on the cluster mode
@@ -119,10 +187,27 @@ type ClusterConfig struct {
 
 // NetworkConfig represents network configuration
 type NetworkConfig struct {
-	VpcCIDR     string `yaml:"vpc_cidr"`
+	VpcCIDR     string `json:"vpcCIDR,omitempty" yaml:"vpc_cidr"` // Address range of a dedicated VPC
 	SubnetCIDR  string `yaml:"subnet_cidr"`
 	ServiceCIDR string `yaml:"service_cidr"`
 	PodCIDR     string `yaml:"pod_cidr"`
+
+	// Private launches the nodes without public IPs in private subnets,
+	// reaching the internet through a NAT gateway; the API server is only
+	// reachable through the SSM tunnel. Fixed at creation.
+	Private bool `json:"private,omitempty" yaml:"private,omitempty"`
+
+	// An existing VPC and its subnets to launch in instead of the default
+	// VPC, and security groups added to the cluster's own. Fixed at creation.
+	VpcID            string   `json:"vpcID,omitempty" yaml:"vpcID,omitempty"`
+	SubnetIDs        []string `json:"subnetIDs,omitempty" yaml:"subnetIDs,omitempty"`
+	SecurityGroupIDs []string `json:"securityGroupIDs,omitempty" yaml:"securityGroupIDs,omitempty"`
+
+	// DedicatedVPC has goman create a VPC for the cluster alone, with a
+	// public and a private subnet in each of up to three availability zones,
+	// an internet gateway and route tables, deleted with the cluster. Its
+	// address range is VpcCIDR. Fixed at creation.
+	DedicatedVPC bool `json:"dedicatedVPC,omitempty" yaml:"dedicatedVPC,omitempty"`
 }
 
 // ClusterState represents the actual infrastructure state
@@ -0,0 +1,50 @@
+package models
+
+import "time"
+
+// SetCondition adds or updates a condition of the cluster. The transition
+// time only moves when the condition's status changes.
+func (s *ClusterResourceStatus) SetCondition(c Condition) {
+	for i := range s.Conditions {
+		if s.Conditions[i].Type != c.Type {
+			continue
+		}
+		if s.Conditions[i].Status == c.Status {
+			c.LastTransitionTime = s.Conditions[i].LastTransitionTime
+		} else if c.LastTransitionTime.IsZero() {
+			c.LastTransitionTime = time.Now()
+		}
+		s.Conditions[i] = c
+		return
+	}
+	if c.LastTransitionTime.IsZero() {
+		c.LastTransitionTime = time.Now()
+	}
+	s.Conditions = append(s.Conditions, c)
+}
+
+// GetCondition returns the condition of the given type, or nil
+func (s *ClusterResourceStatus) GetCondition(conditionType string) *Condition {
+	for i := range s.Conditions {
+		if s.Conditions[i].Type == conditionType {
+			return &s.Conditions[i]
+		}
+	}
+	return nil
+}
+
+// RemoveCondition drops the condition of the given type if it was set for
+// one of the reasons given
+func (s *ClusterResourceStatus) RemoveCondition(conditionType string, reasons ...string) {
+	kept := s.Conditions[:0]
+	for _, c := range s.Conditions {
+		if c.Type == conditionType && contains(reasons, c.Reason) {
+			continue
+		}
+		kept = append(kept, c)
+	}
+	s.Conditions = kept
+	if len(s.Conditions) == 0 {
+		s.Conditions = nil
+	}
+}
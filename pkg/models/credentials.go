@@ -0,0 +1,58 @@
+package models
+
+import (
+	"fmt"
+	"time"
+)
+
+// MinTokenRotationK3sVersion is the first K3s release of the supported
+// minor versions with 'k3s token rotate'
+const MinTokenRotationK3sVersion = "v1.28.6+k3s1"
+
+// CredentialRotationPhase is the step of a credential rotation
+type CredentialRotationPhase string
+
+const (
+	CredentialRotationToken      CredentialRotationPhase = "RotatingToken"     // Moving the cluster to a new join token
+	CredentialRotationClientCA   CredentialRotationPhase = "RotatingClientCA"  // Replacing the CA signing client certificates
+	CredentialRotationServers    CredentialRotationPhase = "RestartingServers" // Restarting masters one at a time
+	CredentialRotationAgents     CredentialRotationPhase = "RestartingAgents"  // Restarting workers one at a time
+	CredentialRotationKubeconfig CredentialRotationPhase = "StoringKubeconfig" // Storing the new admin kubeconfig
+	CredentialRotationCompleted  CredentialRotationPhase = "Completed"
+	CredentialRotationRefused    CredentialRotationPhase = "Refused" // The K3s version can't rotate tokens
+)
+
+// CredentialRotationStatus tracks a rotation of the cluster's credentials:
+// the K3s token, the client CA with every certificate it signed, the admin
+// kubeconfig and the read-only one. Nodes are restarted one per reconcile.
+type CredentialRotationStatus struct {
+	RequestedAt time.Time               `json:"requestedAt" yaml:"requestedAt"` // Request being handled
+	Phase       CredentialRotationPhase `json:"phase" yaml:"phase"`
+	Nodes       []string                `json:"nodes,omitempty" yaml:"nodes,omitempty"` // Instance IDs restarted in the current phase
+	Message     string                  `json:"message,omitempty" yaml:"message,omitempty"`
+	CompletedAt *time.Time              `json:"completedAt,omitempty" yaml:"completedAt,omitempty"`
+}
+
+// Active reports whether the rotation has steps left
+func (s *CredentialRotationStatus) Active() bool {
+	return s != nil && s.Phase != CredentialRotationCompleted && s.Phase != CredentialRotationRefused
+}
+
+// HasNode reports whether an instance was restarted in the current phase
+func (s *CredentialRotationStatus) HasNode(instanceID string) bool {
+	return s != nil && contains(s.Nodes, instanceID)
+}
+
+// CheckCredentialRotation reports whether nodes on a K3s version can rotate
+// their token
+func CheckCredentialRotation(version string) error {
+	v, err := ParseK3sVersion(version)
+	if err != nil {
+		return err
+	}
+	min, _ := ParseK3sVersion(MinTokenRotationK3sVersion)
+	if v.Compare(min) < 0 {
+		return fmt.Errorf("K3s %s can't rotate its token, upgrade to %s or later first", version, MinTokenRotationK3sVersion)
+	}
+	return nil
+}
@@ -0,0 +1,126 @@
+package models
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// NodeLocalDNSAddress is the link-local address NodeLocal DNSCache listens on
+const NodeLocalDNSAddress = "169.254.20.10"
+
+// DNSSpec customizes cluster DNS. K3s restores its CoreDNS ConfigMap on
+// every server start, so changes go through the coredns-custom ConfigMap
+// and node configuration, which the controller keeps in sync with the spec.
+type DNSSpec struct {
+	// Resolvers for specific domains, e.g. corp.example.com -> 10.0.0.2
+	StubDomains map[string][]string `json:"stubDomains,omitempty" yaml:"stubDomains,omitempty"`
+	// Resolvers for all other external names, instead of the VPC resolver
+	Upstreams []string `json:"upstreams,omitempty" yaml:"upstreams,omitempty"`
+	// Run NodeLocal DNSCache on every node
+	NodeLocalCache bool `json:"nodeLocalCache,omitempty" yaml:"nodeLocalCache,omitempty"`
+}
+
+// DNSStatus records which DNS configuration the controller applied
+type DNSStatus struct {
+	ConfigHash string     `json:"configHash" yaml:"configHash"`
+	Nodes      []string   `json:"nodes,omitempty" yaml:"nodes,omitempty"` // Instances configured with ConfigHash
+	AppliedAt  *time.Time `json:"appliedAt,omitempty" yaml:"appliedAt,omitempty"`
+	Message    string     `json:"message,omitempty" yaml:"message,omitempty"`
+}
+
+// HasNode reports whether an instance has the applied configuration
+func (s *DNSStatus) HasNode(instanceID string) bool {
+	if s == nil {
+		return false
+	}
+	for _, id := range s.Nodes {
+		if id == instanceID {
+			return true
+		}
+	}
+	return false
+}
+
+var domainPattern = regexp.MustCompile(`^([a-z0-9]([a-z0-9-]*[a-z0-9])?\.)*[a-z0-9]([a-z0-9-]*[a-z0-9])?$`)
+
+// ValidateDNS checks stub domains and resolver addresses. Upstreams end up
+// in a resolv.conf and so cannot carry a port; stub domain resolvers can.
+func ValidateDNS(spec *DNSSpec) error {
+	if spec == nil {
+		return nil
+	}
+	for _, upstream := range spec.Upstreams {
+		if net.ParseIP(upstream) == nil {
+			return fmt.Errorf("dns upstream %q must be an IP address", upstream)
+		}
+	}
+	if len(spec.Upstreams) > 3 {
+		return fmt.Errorf("at most 3 dns upstreams are supported")
+	}
+	for domain, servers := range spec.StubDomains {
+		if !domainPattern.MatchString(domain) {
+			return fmt.Errorf("invalid stub domain %q", domain)
+		}
+		if domain == "cluster.local" || strings.HasSuffix(domain, ".cluster.local") {
+			return fmt.Errorf("stub domain %s overlaps the cluster domain", domain)
+		}
+		if len(servers) == 0 {
+			return fmt.Errorf("stub domain %s needs at least one resolver", domain)
+		}
+		for _, server := range servers {
+			host := server
+			if h, _, err := net.SplitHostPort(server); err == nil {
+				host = h
+			}
+			if net.ParseIP(host) == nil {
+				return fmt.Errorf("resolver %q of stub domain %s must be an IP address, optionally with a port", server, domain)
+			}
+		}
+	}
+	return nil
+}
+
+// DNSConfigHash identifies a DNS configuration, so the controller can tell
+// which nodes still need it. Empty for no custom DNS.
+func DNSConfigHash(spec *DNSSpec) string {
+	if spec == nil || (len(spec.StubDomains) == 0 && len(spec.Upstreams) == 0 && !spec.NodeLocalCache) {
+		return ""
+	}
+	// Map keys are marshaled in sorted order
+	data, _ := json.Marshal(spec)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:8])
+}
+
+// CoreDNSCustomManifest renders the coredns-custom ConfigMap that K3s's
+// CoreDNS imports, with one server block per stub domain. Empty if there
+// are no stub domains.
+func CoreDNSCustomManifest(spec *DNSSpec) string {
+	if spec == nil || len(spec.StubDomains) == 0 {
+		return ""
+	}
+	domains := make([]string, 0, len(spec.StubDomains))
+	for domain := range spec.StubDomains {
+		domains = append(domains, domain)
+	}
+	sort.Strings(domains)
+
+	var b strings.Builder
+	b.WriteString("apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: coredns-custom\n  namespace: kube-system\n")
+	b.WriteString("  labels:\n    app.kubernetes.io/managed-by: goman\ndata:\n")
+	for _, domain := range domains {
+		fmt.Fprintf(&b, "  %s.server: |\n", domain)
+		fmt.Fprintf(&b, "    %s:53 {\n", domain)
+		b.WriteString("        errors\n        cache 30\n")
+		fmt.Fprintf(&b, "        forward . %s\n", strings.Join(spec.StubDomains[domain], " "))
+		b.WriteString("    }\n")
+	}
+	return b.String()
+}
@@ -0,0 +1,98 @@
+package models
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Fields the controller checks for drift
+const (
+	DriftFieldInstanceType       = "instanceType"
+	DriftFieldTags               = "tags"               // User tags of the spec on the instances
+	DriftFieldSecurityGroupRules = "securityGroupRules" // Rules goman sets up, and rules open to any address
+
+	// Instances of the cluster missing from its status, or in its status but
+	// gone. Only reported: node reconciliation replaces missing nodes, and
+	// instances goman did not launch are never touched.
+	DriftFieldInstances = "instances"
+)
+
+// DriftFields lists the fields a drift policy can be set for
+var DriftFields = []string{DriftFieldInstanceType, DriftFieldTags, DriftFieldSecurityGroupRules}
+
+// DriftPolicy decides what the controller does when an instance no longer
+// matches the spec because it was changed outside goman, e.g. resized in
+// the AWS console
+type DriftPolicy string
+
+const (
+	// DriftAdopt leaves the instance as it is and reports the drift in
+	// status. This is the default so goman never undoes a manual change
+	// unless asked to.
+	DriftAdopt DriftPolicy = "adopt"
+	// DriftRevert changes the instance back to the spec, stopping and
+	// starting it if the change requires it
+	DriftRevert DriftPolicy = "revert"
+)
+
+// DriftStatus records a field of one instance that differs from the spec.
+// Security group rule drift is recorded per group, with the group's name as
+// Node and its ID as InstanceID.
+type DriftStatus struct {
+	Field      string      `json:"field" yaml:"field"`
+	Node       string      `json:"node" yaml:"node"`
+	InstanceID string      `json:"instanceId" yaml:"instanceId"`
+	Expected   string      `json:"expected" yaml:"expected"`
+	Actual     string      `json:"actual" yaml:"actual"`
+	Policy     DriftPolicy `json:"policy" yaml:"policy"`
+	DetectedAt time.Time   `json:"detectedAt" yaml:"detectedAt"`
+	ChangedBy  string      `json:"changedBy,omitempty" yaml:"changedBy,omitempty"` // Who made the change, from the cloud audit log
+}
+
+// String describes the drift for status messages and the UI
+func (d DriftStatus) String() string {
+	if d.ChangedBy != "" {
+		return fmt.Sprintf("%s %s: %s (spec %s, %s, changed by %s)", d.Node, d.Field, d.Actual, d.Expected, d.Policy, d.ChangedBy)
+	}
+	return fmt.Sprintf("%s %s: %s (spec %s, %s)", d.Node, d.Field, d.Actual, d.Expected, d.Policy)
+}
+
+// DriftPolicyFor returns the policy configured for a field, DriftAdopt if none is
+func DriftPolicyFor(policies map[string]DriftPolicy, field string) DriftPolicy {
+	if policy, ok := policies[field]; ok && policy != "" {
+		return policy
+	}
+	return DriftAdopt
+}
+
+// ValidateDriftPolicies checks that policies only name known fields and policies
+func ValidateDriftPolicies(policies map[string]DriftPolicy) error {
+	for field, policy := range policies {
+		known := false
+		for _, f := range DriftFields {
+			if f == field {
+				known = true
+				break
+			}
+		}
+		if !known {
+			return fmt.Errorf("unknown drift policy field %q (use %s)", field, strings.Join(DriftFields, ", "))
+		}
+		if policy != DriftAdopt && policy != DriftRevert {
+			return fmt.Errorf("drift policy for %s must be %q or %q", field, DriftAdopt, DriftRevert)
+		}
+	}
+	return nil
+}
+
+// SortDrift orders drift entries by node and field for stable status output
+func SortDrift(drift []DriftStatus) {
+	sort.Slice(drift, func(i, j int) bool {
+		if drift[i].Node != drift[j].Node {
+			return drift[i].Node < drift[j].Node
+		}
+		return drift[i].Field < drift[j].Field
+	})
+}
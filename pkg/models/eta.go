@@ -0,0 +1,183 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// StepRolloutBatch is the step timed for one batch of a node pool rollout
+const StepRolloutBatch = "rollout-batch"
+
+// maxStepSamples caps the weight of history in a step's mean, so estimates
+// follow changes such as a slower AMI within a few runs
+const maxStepSamples = 20
+
+// defaultStepDurations are used for steps without any recorded run
+var defaultStepDurations = map[string]time.Duration{
+	"provisioning-dev": 2 * time.Minute,
+	"provisioning-ha":  5 * time.Minute,
+	"installing-dev":   30 * time.Second,
+	"installing-ha":    30 * time.Second,
+	"configuring-dev":  time.Minute,
+	"configuring-ha":   time.Minute,
+	StepRolloutBatch:   6 * time.Minute,
+}
+
+// lifecyclePhases are the phases a new cluster goes through, in order
+var lifecyclePhases = []string{ClusterPhaseProvisioning, ClusterPhaseInstalling, ClusterPhaseConfiguring}
+
+// IsLifecyclePhase reports whether phase is one of the timed setup phases
+// of a new cluster
+func IsLifecyclePhase(phase string) bool {
+	return contains(lifecyclePhases, phase)
+}
+
+// StepStats is the observed duration of one step for a region and
+// instance type
+type StepStats struct {
+	Samples     int       `json:"samples" yaml:"samples"`
+	MeanSeconds float64   `json:"meanSeconds" yaml:"meanSeconds"`
+	UpdatedAt   time.Time `json:"updatedAt" yaml:"updatedAt"`
+}
+
+// Mean returns the mean duration
+func (s StepStats) Mean() time.Duration {
+	return time.Duration(s.MeanSeconds * float64(time.Second))
+}
+
+// StepDurationStats holds step durations of past operations, keyed by
+// StepStatsKey
+type StepDurationStats struct {
+	Steps map[string]StepStats `json:"steps" yaml:"steps"`
+}
+
+// LifecycleStep names the step timed for a lifecycle phase. HA clusters
+// take longer to provision, so they are timed separately.
+func LifecycleStep(phase, mode string) string {
+	if mode != "ha" {
+		mode = "dev"
+	}
+	return strings.ToLower(phase) + "-" + mode
+}
+
+// StepStatsKey identifies the durations of a step in a region for an
+// instance type
+func StepStatsKey(step, region, instanceType string) string {
+	return step + "/" + region + "/" + instanceType
+}
+
+// Record adds a duration of a step to its running mean
+func (s *StepDurationStats) Record(step, region, instanceType string, d time.Duration, now time.Time) {
+	if s.Steps == nil {
+		s.Steps = make(map[string]StepStats)
+	}
+	key := StepStatsKey(step, region, instanceType)
+	stats := s.Steps[key]
+	if stats.Samples < maxStepSamples {
+		stats.Samples++
+	}
+	stats.MeanSeconds += (d.Seconds() - stats.MeanSeconds) / float64(stats.Samples)
+	stats.UpdatedAt = now
+	s.Steps[key] = stats
+}
+
+// Estimate returns the expected duration of a step. Without runs for the
+// region and instance type, the mean over all runs of the step is used,
+// then a built-in default.
+func (s *StepDurationStats) Estimate(step, region, instanceType string) time.Duration {
+	if s != nil {
+		if stats, ok := s.Steps[StepStatsKey(step, region, instanceType)]; ok && stats.Samples > 0 {
+			return stats.Mean()
+		}
+		var total float64
+		var samples int
+		for key, stats := range s.Steps {
+			if strings.HasPrefix(key, step+"/") {
+				total += stats.MeanSeconds * float64(stats.Samples)
+				samples += stats.Samples
+			}
+		}
+		if samples > 0 {
+			return time.Duration(total / float64(samples) * float64(time.Second))
+		}
+	}
+	return defaultStepDurations[step]
+}
+
+// LifecycleRemaining estimates the time until a cluster in phase becomes
+// running: the rest of the current phase plus all later phases. ok is false
+// outside the lifecycle phases.
+func (s *StepDurationStats) LifecycleRemaining(phase, mode, region, instanceType string, elapsed time.Duration) (remaining time.Duration, ok bool) {
+	current := -1
+	if phase == "" || phase == ClusterPhasePending {
+		current = 0
+		elapsed = 0
+	}
+	for i, p := range lifecyclePhases {
+		if p == phase {
+			current = i
+		}
+	}
+	if current < 0 {
+		return 0, false
+	}
+
+	for i := current; i < len(lifecyclePhases); i++ {
+		d := s.Estimate(LifecycleStep(lifecyclePhases[i], mode), region, instanceType)
+		if i == current {
+			// An overrunning phase counts as about to finish
+			d -= elapsed
+			if d < 0 {
+				d = 0
+			}
+		}
+		remaining += d
+	}
+	return remaining, true
+}
+
+// RolloutRemaining estimates the time until a rollout has replaced all of
+// its nodes. ok is false for rollouts that are paused or done.
+func (s *StepDurationStats) RolloutRemaining(rollout *RolloutStatus, region string, now time.Time) (time.Duration, bool) {
+	if rollout == nil || rollout.Phase != RolloutInProgress {
+		return 0, false
+	}
+	batch := s.Estimate(StepRolloutBatch, region, rollout.ToInstanceType)
+	remaining := time.Duration(rollout.BatchCount-rollout.BatchIndex) * batch
+	if started := BatchStartedAt(rollout.Batch); started != nil {
+		current := batch - now.Sub(*started)
+		if current > 0 {
+			remaining += current
+		}
+	}
+	return remaining, true
+}
+
+// BatchStartedAt returns when the first replacement of a batch started
+func BatchStartedAt(batch []NodeReplacementStatus) *time.Time {
+	var started *time.Time
+	for _, st := range batch {
+		if st.StartedAt != nil && (started == nil || st.StartedAt.Before(*started)) {
+			started = st.StartedAt
+		}
+	}
+	return started
+}
+
+// FormatRemaining renders the time left until eta, e.g. "~4m", rounding up
+// to whole minutes. Empty when there is no estimate.
+func FormatRemaining(eta *time.Time, now time.Time) string {
+	if eta == nil {
+		return ""
+	}
+	d := eta.Sub(now)
+	if d < time.Minute {
+		return "<1m"
+	}
+	minutes := int((d + time.Minute - 1) / time.Minute)
+	if minutes < 60 {
+		return fmt.Sprintf("~%dm", minutes)
+	}
+	return fmt.Sprintf("~%dh%dm", minutes/60, minutes%60)
+}
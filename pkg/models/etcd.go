@@ -0,0 +1,83 @@
+package models
+
+import (
+	"fmt"
+	"time"
+)
+
+// Reasons of the Degraded condition set from etcd health
+const (
+	ReasonEtcdQuorumLost   = "EtcdQuorumLost"
+	ReasonEtcdEvenMembers  = "EtcdEvenMembers"
+	ReasonEtcdQuorumAtRisk = "EtcdQuorumAtRisk"
+)
+
+// EtcdQuorum returns the number of healthy members an etcd cluster of the
+// given size needs to accept writes
+func EtcdQuorum(members int) int {
+	return members/2 + 1
+}
+
+// ValidateMasterCount checks the number of masters of a cluster. Embedded
+// etcd needs an odd number of members: an even one tolerates no more
+// failures than the odd number below it and can split evenly.
+func ValidateMasterCount(n int) error {
+	if n < 0 {
+		return fmt.Errorf("master count %d cannot be negative", n)
+	}
+	if n > 0 && n%2 == 0 {
+		return fmt.Errorf("master count %d is even; etcd needs an odd number of masters such as %d or %d", n, n-1, n+1)
+	}
+	return nil
+}
+
+// EtcdStatus is the last observed health of the etcd members of an HA
+// control plane. Every master is an etcd member; a member is healthy when
+// its node is Ready.
+type EtcdStatus struct {
+	Members   int       `json:"members" yaml:"members"`
+	Healthy   int       `json:"healthy" yaml:"healthy"`
+	Quorum    int       `json:"quorum" yaml:"quorum"`
+	Unhealthy []string  `json:"unhealthy,omitempty" yaml:"unhealthy,omitempty"` // K3s node names
+	CheckedAt time.Time `json:"checkedAt" yaml:"checkedAt"`
+}
+
+// Quorate reports whether enough members are healthy to accept writes
+func (s *EtcdStatus) Quorate() bool {
+	return s.Healthy >= EtcdQuorum(s.Members)
+}
+
+// IsHealthy reports whether the member on the given node is healthy
+func (s *EtcdStatus) IsHealthy(node string) bool {
+	return !contains(s.Unhealthy, node)
+}
+
+// CheckDisruption reports whether the member on the given node can be taken
+// down, or removed from etcd if remove is set, while the rest keep quorum.
+// A nil status means the control plane has a single member, which nothing
+// can keep available.
+func (s *EtcdStatus) CheckDisruption(node string, remove bool) error {
+	if s == nil {
+		return nil
+	}
+	members, healthy := s.Members, s.Healthy
+	if remove {
+		members--
+	}
+	if s.IsHealthy(node) {
+		healthy--
+	}
+	if members > 0 && healthy < EtcdQuorum(members) {
+		verb := "stopping"
+		if remove {
+			verb = "removing"
+		}
+		return fmt.Errorf("%s etcd member %s would leave %d of %d member(s) healthy, below the quorum of %d",
+			verb, node, healthy, members, EtcdQuorum(members))
+	}
+	return nil
+}
+
+func (s *EtcdStatus) String() string {
+	return fmt.Sprintf("%d/%d etcd member(s) healthy, quorum %d", s.Healthy, s.Members, s.Quorum)
+}
@@ -0,0 +1,41 @@
+package models
+
+// Finalizers of a cluster: each names cloud resources whose deletion must be
+// verified before the cluster's config, status and secrets are removed
+const (
+	FinalizerInstances = "goman.io/instances" // No instance of the cluster left
+	FinalizerNetwork   = "goman.io/network"   // Load balancer, security groups and dedicated VPC deleted
+)
+
+// ClusterFinalizers are set on every cluster, in the order they are worked
+// off on deletion
+var ClusterFinalizers = []string{FinalizerInstances, FinalizerNetwork}
+
+// HasFinalizer reports whether a cleanup is still pending
+func (r *ClusterResource) HasFinalizer(name string) bool {
+	return contains(r.Status.Finalizers, name)
+}
+
+// AddFinalizers sets the finalizers the cluster lacks, returning whether any
+// was added
+func (r *ClusterResource) AddFinalizers() bool {
+	added := false
+	for _, name := range ClusterFinalizers {
+		if !r.HasFinalizer(name) {
+			r.Status.Finalizers = append(r.Status.Finalizers, name)
+			added = true
+		}
+	}
+	return added
+}
+
+// RemoveFinalizer marks a cleanup done
+func (r *ClusterResource) RemoveFinalizer(name string) {
+	var kept []string
+	for _, f := range r.Status.Finalizers {
+		if f != name {
+			kept = append(kept, f)
+		}
+	}
+	r.Status.Finalizers = kept
+}
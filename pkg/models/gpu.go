@@ -0,0 +1,20 @@
+package models
+
+import "strings"
+
+// GPUNodeLabel is set on nodes with an NVIDIA GPU, for workloads to select
+// them
+const GPUNodeLabel = "nvidia.com/gpu"
+
+// Instance families with NVIDIA GPUs. g4ad has AMD GPUs and is left out.
+var nvidiaGPUFamilies = map[string]bool{
+	"g3": true, "g3s": true, "g4dn": true, "g5": true, "g5g": true, "g6": true, "g6e": true, "gr6": true,
+	"p2": true, "p3": true, "p3dn": true, "p4d": true, "p4de": true, "p5": true, "p5e": true, "p5en": true,
+}
+
+// IsGPUInstanceType reports whether an instance type comes with NVIDIA GPUs,
+// e.g. g4dn.xlarge, g5.2xlarge or p3.8xlarge
+func IsGPUInstanceType(instanceType string) bool {
+	family, _, _ := strings.Cut(instanceType, ".")
+	return nvidiaGPUFamilies[family]
+}
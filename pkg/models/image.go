@@ -0,0 +1,42 @@
+package models
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// OS families of node images. The bootstrap script has a variant for each,
+// differing in package manager, SSM agent and AWS CLI installation.
+const (
+	OSAmazonLinux2    = "amazonlinux2"
+	OSAmazonLinux2023 = "amazonlinux2023"
+	OSUbuntu2204      = "ubuntu2204"
+)
+
+// OSFamilies are the supported OS families, the default first
+var OSFamilies = []string{OSAmazonLinux2, OSAmazonLinux2023, OSUbuntu2204}
+
+var amiID = regexp.MustCompile(`^ami-([0-9a-f]{8}|[0-9a-f]{17})$`)
+
+// PoolImage returns the image ID and OS family of a node pool's nodes: the
+// pool's if it sets either, the cluster's otherwise. An empty image ID is
+// the latest image of the OS family, Amazon Linux 2 if that is empty too.
+func (s ClusterSpec) PoolImage(pool NodePool) (imageID, osFamily string) {
+	if pool.ImageID != "" || pool.OSFamily != "" {
+		return pool.ImageID, pool.OSFamily
+	}
+	return s.ImageID, s.OSFamily
+}
+
+// ValidateImage checks an image override of what: a custom AMI ID, and the
+// OS family picking its bootstrap variant
+func ValidateImage(what, imageID, osFamily string) error {
+	if imageID != "" && !amiID.MatchString(imageID) {
+		return fmt.Errorf("%s: invalid image ID %q, want an AMI ID such as ami-0123456789abcdef0", what, imageID)
+	}
+	if osFamily != "" && !contains(OSFamilies, osFamily) {
+		return fmt.Errorf("%s: unknown OS family %q (valid: %s)", what, osFamily, strings.Join(OSFamilies, ", "))
+	}
+	return nil
+}
@@ -0,0 +1,93 @@
+package models
+
+import (
+	"sort"
+	"time"
+)
+
+// SpecIntent is one spec change a user made, waiting to be acted upon by
+// the controller. Generation is the metadata generation the write produced.
+type SpecIntent struct {
+	Generation  int       `json:"generation" yaml:"generation"`
+	RequestedAt time.Time `json:"requestedAt" yaml:"requestedAt"`
+	Source      string    `json:"source,omitempty" yaml:"source,omitempty"`   // Writer, e.g. cli or client
+	Changes     []string  `json:"changes,omitempty" yaml:"changes,omitempty"` // Changed spec fields, when known
+}
+
+// IntentQueue holds the spec changes of a cluster not yet reconciled, oldest
+// first. Rapid edits pile up here and are acted upon together by the next
+// reconcile, which always works on the latest generation.
+type IntentQueue struct {
+	Cluster string       `json:"cluster" yaml:"cluster"`
+	Pending []SpecIntent `json:"pending,omitempty" yaml:"pending,omitempty"`
+}
+
+// Enqueue adds an intent. An intent for a generation already queued replaces
+// it, since only the latest write of a generation is stored.
+func (q *IntentQueue) Enqueue(intent SpecIntent) {
+	for i := range q.Pending {
+		if q.Pending[i].Generation == intent.Generation {
+			q.Pending[i] = intent
+			return
+		}
+	}
+	q.Pending = append(q.Pending, intent)
+	sort.SliceStable(q.Pending, func(i, j int) bool {
+		return q.Pending[i].Generation < q.Pending[j].Generation
+	})
+}
+
+// Latest returns the newest queued generation, 0 when the queue is empty
+func (q *IntentQueue) Latest() int {
+	if q == nil || len(q.Pending) == 0 {
+		return 0
+	}
+	return q.Pending[len(q.Pending)-1].Generation
+}
+
+// Take removes and returns the intents up to and including generation. A
+// reconcile of that generation covers all of them.
+func (q *IntentQueue) Take(generation int) []SpecIntent {
+	if q == nil {
+		return nil
+	}
+	var taken, rest []SpecIntent
+	for _, intent := range q.Pending {
+		if intent.Generation <= generation {
+			taken = append(taken, intent)
+		} else {
+			rest = append(rest, intent)
+		}
+	}
+	q.Pending = rest
+	return taken
+}
+
+// IntentRecord tells which spec generation a reconcile acted on and how
+// many queued edits it coalesced
+type IntentRecord struct {
+	Generation  int        `json:"generation" yaml:"generation"`
+	Coalesced   int        `json:"coalesced,omitempty" yaml:"coalesced,omitempty"`     // Queued intents covered
+	RequestedAt *time.Time `json:"requestedAt,omitempty" yaml:"requestedAt,omitempty"` // Oldest covered intent
+	RequestID   string     `json:"requestId,omitempty" yaml:"requestId,omitempty"`
+	ActedAt     time.Time  `json:"actedAt" yaml:"actedAt"`
+}
+
+// SpecPending reports whether a spec generation has not been fully processed
+// by the controller yet. Specs written before generations were tracked have
+// generation 0 and count as processed.
+func SpecPending(generation, observedGeneration int) bool {
+	return generation > observedGeneration
+}
+
+// SpecPending reports whether the cluster has spec changes the controller
+// has not fully processed yet
+func (r *ClusterResource) SpecPending() bool {
+	return SpecPending(r.Generation, r.Status.ObservedGeneration)
+}
+
+// SpecPending reports whether the cluster has spec changes the controller
+// has not fully processed yet
+func (c *K3sCluster) SpecPending() bool {
+	return SpecPending(c.Generation, c.ObservedGeneration)
+}
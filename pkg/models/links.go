@@ -0,0 +1,137 @@
+package models
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultLinkPorts are opened between linked clusters when a link lists no
+// ports: the NodePort range, where their services are exposed
+var DefaultLinkPorts = []string{"30000-32767/tcp"}
+
+// ClusterLink lets a cluster reach the services of another goman cluster
+// privately, and the other cluster reach it. Clusters in the same network
+// only need firewall rules; clusters in different networks are peered when
+// the link allows it.
+type ClusterLink struct {
+	Cluster string   `json:"cluster" yaml:"cluster"`
+	Ports   []string `json:"ports,omitempty" yaml:"ports,omitempty"`     // e.g. "443", "8000-8080/tcp", "53/udp"; NodePorts by default
+	Peering bool     `json:"peering,omitempty" yaml:"peering,omitempty"` // Allow peering the networks of the two clusters
+}
+
+// LinkPort is one port range of a link
+type LinkPort struct {
+	Protocol string
+	From     int
+	To       int
+}
+
+// String renders the port range as written in the spec
+func (p LinkPort) String() string {
+	if p.From == p.To {
+		return fmt.Sprintf("%d/%s", p.From, p.Protocol)
+	}
+	return fmt.Sprintf("%d-%d/%s", p.From, p.To, p.Protocol)
+}
+
+// ParseLinkPort parses "port[-port][/protocol]", tcp unless given
+func ParseLinkPort(s string) (LinkPort, error) {
+	port := LinkPort{Protocol: "tcp"}
+	spec := strings.TrimSpace(s)
+	if i := strings.Index(spec, "/"); i >= 0 {
+		port.Protocol = strings.ToLower(spec[i+1:])
+		spec = spec[:i]
+	}
+	if port.Protocol != "tcp" && port.Protocol != "udp" {
+		return LinkPort{}, fmt.Errorf("port %q: protocol must be tcp or udp", s)
+	}
+
+	from, to := spec, spec
+	if i := strings.Index(spec, "-"); i >= 0 {
+		from, to = spec[:i], spec[i+1:]
+	}
+	var err error
+	if port.From, err = strconv.Atoi(from); err != nil {
+		return LinkPort{}, fmt.Errorf("port %q: invalid port %q", s, from)
+	}
+	if port.To, err = strconv.Atoi(to); err != nil {
+		return LinkPort{}, fmt.Errorf("port %q: invalid port %q", s, to)
+	}
+	if port.From < 1 || port.To > 65535 || port.From > port.To {
+		return LinkPort{}, fmt.Errorf("port %q: range must be within 1-65535", s)
+	}
+	return port, nil
+}
+
+// PortRanges returns the parsed ports of the link, the defaults if none are
+// listed
+func (l ClusterLink) PortRanges() ([]LinkPort, error) {
+	specs := l.Ports
+	if len(specs) == 0 {
+		specs = DefaultLinkPorts
+	}
+	ports := make([]LinkPort, 0, len(specs))
+	for _, s := range specs {
+		port, err := ParseLinkPort(s)
+		if err != nil {
+			return nil, err
+		}
+		ports = append(ports, port)
+	}
+	return ports, nil
+}
+
+// Key identifies the settings of a link, to detect changes that must be
+// applied again
+func (l ClusterLink) Key() string {
+	ports, err := l.PortRanges()
+	if err != nil {
+		return ""
+	}
+	names := make([]string, len(ports))
+	for i, p := range ports {
+		names[i] = p.String()
+	}
+	sort.Strings(names)
+	return fmt.Sprintf("ports=%s,peering=%t", strings.Join(names, ","), l.Peering)
+}
+
+// ValidateClusterLinks checks the links of a cluster: each names another
+// cluster once and has valid ports
+func ValidateClusterLinks(clusterName string, links []ClusterLink) error {
+	seen := make(map[string]bool)
+	for _, link := range links {
+		if link.Cluster == "" {
+			return fmt.Errorf("cluster link without cluster name")
+		}
+		if link.Cluster == clusterName {
+			return fmt.Errorf("cluster link to %s: a cluster can't link to itself", link.Cluster)
+		}
+		if seen[link.Cluster] {
+			return fmt.Errorf("cluster link to %s is listed twice", link.Cluster)
+		}
+		seen[link.Cluster] = true
+		if _, err := link.PortRanges(); err != nil {
+			return fmt.Errorf("cluster link to %s: %w", link.Cluster, err)
+		}
+	}
+	return nil
+}
+
+// ClusterLinkStatus reports the connectivity set up for a link. The ports
+// and regions applied are kept so the link can be removed after the spec
+// changed.
+type ClusterLinkStatus struct {
+	Cluster   string     `json:"cluster" yaml:"cluster"`
+	Key       string     `json:"key" yaml:"key"`
+	Ports     []string   `json:"ports,omitempty" yaml:"ports,omitempty"`
+	Region    string     `json:"region,omitempty" yaml:"region,omitempty"`       // Region of the linked cluster
+	Mode      string     `json:"mode,omitempty" yaml:"mode,omitempty"`           // security-group or peering
+	PeeringID string     `json:"peeringId,omitempty" yaml:"peeringId,omitempty"` // Network peering created for the link
+	Ready     bool       `json:"ready" yaml:"ready"`
+	Message   string     `json:"message,omitempty" yaml:"message,omitempty"` // Why the link is not ready
+	LinkedAt  *time.Time `json:"linkedAt,omitempty" yaml:"linkedAt,omitempty"`
+}
@@ -0,0 +1,85 @@
+package models
+
+import (
+	"fmt"
+	"net"
+	"time"
+)
+
+// LoadBalancerSpec puts a network load balancer in front of the masters of
+// an HA cluster. The API endpoint and the kubeconfig use its DNS name, so
+// clients keep working when any one master is down.
+type LoadBalancerSpec struct {
+	// Internal makes the load balancer reachable from inside the VPC only;
+	// the load balancer of a private cluster is always internal
+	Internal bool `json:"internal,omitempty" yaml:"internal,omitempty"`
+
+	// Address ranges allowed to reach the API through the load balancer,
+	// the VPC's range if empty
+	AllowedCIDRs []string `json:"allowedCIDRs,omitempty" yaml:"allowedCIDRs,omitempty"`
+
+	// Cluster whose load balancer serves the API, the cluster's own if
+	// empty. A blue/green cutover swaps the load balancers of the two
+	// clusters, so clients of blue reach green at the same name.
+	Cluster string `json:"cluster,omitempty" yaml:"cluster,omitempty"`
+}
+
+// Owner returns the cluster whose load balancer serves the API of cluster
+func (s *LoadBalancerSpec) Owner(cluster string) string {
+	if s != nil && s.Cluster != "" {
+		return s.Cluster
+	}
+	return cluster
+}
+
+// LoadBalancerStatus records the load balancer and the masters behind it
+type LoadBalancerStatus struct {
+	DNSName      string     `json:"dnsName" yaml:"dnsName"`
+	Cluster      string     `json:"cluster,omitempty" yaml:"cluster,omitempty"` // Cluster the load balancer was created for
+	Internal     bool       `json:"internal,omitempty" yaml:"internal,omitempty"`
+	AllowedCIDRs []string   `json:"allowedCIDRs,omitempty" yaml:"allowedCIDRs,omitempty"`
+	Targets      []string   `json:"targets,omitempty" yaml:"targets,omitempty"` // Masters registered with the load balancer
+	Nodes        []string   `json:"nodes,omitempty" yaml:"nodes,omitempty"`     // Masters with DNSName in their certificate
+	AppliedAt    *time.Time `json:"appliedAt,omitempty" yaml:"appliedAt,omitempty"`
+	Message      string     `json:"message,omitempty" yaml:"message,omitempty"`
+}
+
+// Owner returns the cluster the load balancer was created for
+func (s *LoadBalancerStatus) Owner(cluster string) string {
+	if s != nil && s.Cluster != "" {
+		return s.Cluster
+	}
+	return cluster
+}
+
+// HasNode reports whether a master has the load balancer in its certificate
+func (s *LoadBalancerStatus) HasNode(instanceID string) bool {
+	if s == nil {
+		return false
+	}
+	for _, id := range s.Nodes {
+		if id == instanceID {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidateLoadBalancer checks the load balancer spec of a cluster
+func ValidateLoadBalancer(spec *LoadBalancerSpec, mode string, virtualIP *VirtualIPSpec) error {
+	if spec == nil {
+		return nil
+	}
+	if mode != string(ModeHA) {
+		return fmt.Errorf("an API load balancer needs an HA cluster")
+	}
+	if virtualIP != nil {
+		return fmt.Errorf("an API load balancer and a virtual IP cannot both serve the API endpoint")
+	}
+	for _, cidr := range spec.AllowedCIDRs {
+		if _, network, err := net.ParseCIDR(cidr); err != nil || network.IP.To4() == nil {
+			return fmt.Errorf("allowed range %q of the API load balancer must be an IPv4 CIDR", cidr)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,95 @@
+package models
+
+import (
+	"fmt"
+	"net"
+	"regexp"
+)
+
+// MaxExtraSecurityGroups is how many security groups can be added to a
+// cluster's own; EC2 allows five per network interface
+const MaxExtraSecurityGroups = 4
+
+// DefaultDedicatedVPCCIDR is the address range of a dedicated VPC without
+// one of its own. Clusters linked by peering need ranges that don't overlap.
+const DefaultDedicatedVPCCIDR = "10.0.0.0/16"
+
+// Prefix lengths a dedicated VPC's range can have: it is split into eight
+// subnets, which EC2 allows down to /28
+const (
+	minDedicatedVPCPrefix = 16
+	maxDedicatedVPCPrefix = 24
+)
+
+var (
+	vpcIDPattern           = regexp.MustCompile(`^vpc-([0-9a-f]{8}|[0-9a-f]{17})$`)
+	subnetIDPattern        = regexp.MustCompile(`^subnet-([0-9a-f]{8}|[0-9a-f]{17})$`)
+	securityGroupIDPattern = regexp.MustCompile(`^sg-([0-9a-f]{8}|[0-9a-f]{17})$`)
+)
+
+// CustomVPC reports whether the cluster is placed in a VPC of its own
+// choosing instead of the default VPC
+func (n NetworkConfig) CustomVPC() bool {
+	return n.VpcID != ""
+}
+
+// DedicatedCIDR returns the address range of the cluster's dedicated VPC
+func (n NetworkConfig) DedicatedCIDR() string {
+	if n.VpcCIDR == "" {
+		return DefaultDedicatedVPCCIDR
+	}
+	return n.VpcCIDR
+}
+
+// ValidateNetwork checks the VPC, subnets and security groups a cluster is
+// placed in. A VPC needs the subnets to launch in, one per availability
+// zone used; without either the default VPC is used.
+func ValidateNetwork(n NetworkConfig) error {
+	if n.VpcID != "" && !vpcIDPattern.MatchString(n.VpcID) {
+		return fmt.Errorf("network: invalid vpcID %q", n.VpcID)
+	}
+	if n.VpcID != "" && len(n.SubnetIDs) == 0 {
+		return fmt.Errorf("network: vpcID %s needs subnetIDs to launch in", n.VpcID)
+	}
+	if n.VpcID == "" && len(n.SubnetIDs) > 0 {
+		return fmt.Errorf("network: subnetIDs need the vpcID they belong to")
+	}
+	if n.DedicatedVPC && n.VpcID != "" {
+		return fmt.Errorf("network: dedicatedVPC creates a VPC, it cannot be combined with vpcID")
+	}
+	if n.VpcCIDR != "" {
+		if !n.DedicatedVPC {
+			return fmt.Errorf("network: vpcCIDR is only used with dedicatedVPC")
+		}
+		ip, ipNet, err := net.ParseCIDR(n.VpcCIDR)
+		if err != nil || ip.To4() == nil || !ip.Equal(ipNet.IP) {
+			return fmt.Errorf("network: invalid vpcCIDR %q, must be an IPv4 network like %s", n.VpcCIDR, DefaultDedicatedVPCCIDR)
+		}
+		if ones, _ := ipNet.Mask.Size(); ones < minDedicatedVPCPrefix || ones > maxDedicatedVPCPrefix {
+			return fmt.Errorf("network: vpcCIDR %s must be between /%d and /%d", n.VpcCIDR, minDedicatedVPCPrefix, maxDedicatedVPCPrefix)
+		}
+	}
+	seen := make(map[string]bool)
+	for _, id := range n.SubnetIDs {
+		if !subnetIDPattern.MatchString(id) {
+			return fmt.Errorf("network: invalid subnet ID %q", id)
+		}
+		if seen[id] {
+			return fmt.Errorf("network: subnet %s listed twice", id)
+		}
+		seen[id] = true
+	}
+	if len(n.SecurityGroupIDs) > MaxExtraSecurityGroups {
+		return fmt.Errorf("network: at most %d securityGroupIDs can be added to the cluster's own", MaxExtraSecurityGroups)
+	}
+	for _, id := range n.SecurityGroupIDs {
+		if !securityGroupIDPattern.MatchString(id) {
+			return fmt.Errorf("network: invalid security group ID %q", id)
+		}
+		if seen[id] {
+			return fmt.Errorf("network: security group %s listed twice", id)
+		}
+		seen[id] = true
+	}
+	return nil
+}
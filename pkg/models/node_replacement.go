@@ -0,0 +1,56 @@
+package models
+
+import "time"
+
+// NodeReplacementPhase is the step a node replacement is in
+type NodeReplacementPhase string
+
+const (
+	NodeReplacementDraining     NodeReplacementPhase = "Draining"
+	NodeReplacementProvisioning NodeReplacementPhase = "Provisioning"
+	NodeReplacementWaitingReady NodeReplacementPhase = "WaitingReady"
+	NodeReplacementTerminating  NodeReplacementPhase = "Terminating"
+	NodeReplacementCompleted    NodeReplacementPhase = "Completed"
+	NodeReplacementFailed       NodeReplacementPhase = "Failed"
+)
+
+// NodeReplacement asks the controller to replace a worker node with a fresh
+// instance from the same pool. Requests are kept in the spec so they survive
+// controller restarts; progress is tracked in NodeReplacementStatus.
+type NodeReplacement struct {
+	Node        string    `json:"node" yaml:"node"` // Instance name or ID
+	RequestedAt time.Time `json:"requestedAt" yaml:"requestedAt"`
+}
+
+// NodeReplacementStatus tracks the progress of one replacement request
+type NodeReplacementStatus struct {
+	Node          string               `json:"node" yaml:"node"`
+	RequestedAt   time.Time            `json:"requestedAt" yaml:"requestedAt"`
+	Phase         NodeReplacementPhase `json:"phase" yaml:"phase"`
+	Pool          string               `json:"pool,omitempty" yaml:"pool,omitempty"`
+	OldInstanceID string               `json:"oldInstanceId,omitempty" yaml:"oldInstanceId,omitempty"`
+	NewInstanceID string               `json:"newInstanceId,omitempty" yaml:"newInstanceId,omitempty"`
+	Message       string               `json:"message,omitempty" yaml:"message,omitempty"`
+	StartedAt     *time.Time           `json:"startedAt,omitempty" yaml:"startedAt,omitempty"`
+	CompletedAt   *time.Time           `json:"completedAt,omitempty" yaml:"completedAt,omitempty"`
+}
+
+// Done reports whether the replacement has finished, successfully or not
+func (s NodeReplacementStatus) Done() bool {
+	return s.Phase == NodeReplacementCompleted || s.Phase == NodeReplacementFailed
+}
+
+// Matches reports whether this status belongs to the given request
+func (s NodeReplacementStatus) Matches(req NodeReplacement) bool {
+	return s.Node == req.Node && s.RequestedAt.Equal(req.RequestedAt)
+}
+
+// FindNodeReplacement returns the status entry for a request, or nil
+func FindNodeReplacement(statuses []NodeReplacementStatus, req NodeReplacement) *NodeReplacementStatus {
+	for i := range statuses {
+		if statuses[i].Matches(req) {
+			return &statuses[i]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,72 @@
+package models
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Well-known notes, kept in the cluster's metadata annotations. Any other
+// annotation key works as a note too.
+const (
+	AnnotationOwner   = "owner"   // Person or team responsible for the cluster
+	AnnotationPurpose = "purpose" // What the cluster is for
+	AnnotationLink    = "link"    // Ticket or document, e.g. a Jira issue
+	AnnotationNote    = "note"    // Freeform text
+)
+
+// NoteKeys lists the well-known notes in display order
+var NoteKeys = []string{AnnotationOwner, AnnotationPurpose, AnnotationLink, AnnotationNote}
+
+// ReservedAnnotationPrefix marks annotations goman maintains itself, which
+// are not notes and can't be edited
+const ReservedAnnotationPrefix = "goman.io/"
+
+// Limits of notes, to keep config.yaml small
+const (
+	maxNoteKeyLength   = 63
+	maxNoteValueLength = 1024
+)
+
+// ValidateNote checks the key and value of a note
+func ValidateNote(key, value string) error {
+	if key == "" {
+		return fmt.Errorf("note key is empty")
+	}
+	if strings.HasPrefix(key, ReservedAnnotationPrefix) {
+		return fmt.Errorf("note key %q: keys starting with %s are reserved for goman", key, ReservedAnnotationPrefix)
+	}
+	if len(key) > maxNoteKeyLength {
+		return fmt.Errorf("note key %q is longer than %d characters", key, maxNoteKeyLength)
+	}
+	for _, r := range key {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) && !strings.ContainsRune("-_./", r) {
+			return fmt.Errorf("note key %q may only contain letters, digits, '-', '_', '.' and '/'", key)
+		}
+	}
+	if len(value) > maxNoteValueLength {
+		return fmt.Errorf("note %s is longer than %d characters", key, maxNoteValueLength)
+	}
+	return nil
+}
+
+// Notes returns the keys of the notes among annotations: the well-known
+// ones first, then the others sorted
+func Notes(annotations map[string]string) []string {
+	var keys, others []string
+	for _, key := range NoteKeys {
+		if _, ok := annotations[key]; ok {
+			keys = append(keys, key)
+		}
+	}
+	for key := range annotations {
+		if strings.HasPrefix(key, ReservedAnnotationPrefix) || slices.Contains(NoteKeys, key) {
+			continue
+		}
+		others = append(others, key)
+	}
+	sort.Strings(others)
+	return append(keys, others...)
+}
@@ -0,0 +1,138 @@
+package models
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Notification target types
+const (
+	NotificationSlack   = "slack"   // Slack incoming webhook
+	NotificationWebhook = "webhook" // Generic HTTP endpoint receiving a JSON NotificationMessage
+	NotificationSNS     = "sns"     // SNS topic receiving a JSON NotificationMessage
+)
+
+// NotificationPolicy publishes a message to each target when the cluster
+// moves to one of the phases
+type NotificationPolicy struct {
+	Targets []NotificationTarget `json:"targets" yaml:"targets"`
+	// Phases to notify on; Running, Failed and Deleting if empty
+	Phases []string `json:"phases,omitempty" yaml:"phases,omitempty"`
+}
+
+// NotificationTarget is where a notification is published
+type NotificationTarget struct {
+	Type string `json:"type" yaml:"type"`                   // slack, webhook or sns
+	URL  string `json:"url,omitempty" yaml:"url,omitempty"` // Webhook URL (slack, webhook)
+	// SNS topic ARN (sns). The controller may publish to topics of its own
+	// account named goman-*.
+	Topic string `json:"topic,omitempty" yaml:"topic,omitempty"`
+}
+
+// NotificationMessage is the body sent to webhook and SNS targets
+type NotificationMessage struct {
+	Cluster       string    `json:"cluster"`
+	Region        string    `json:"region,omitempty"`
+	Phase         string    `json:"phase"`
+	PreviousPhase string    `json:"previousPhase,omitempty"`
+	Message       string    `json:"message,omitempty"`
+	Timestamp     time.Time `json:"timestamp"`
+}
+
+// DefaultNotificationPhases are notified on when a policy lists none
+var DefaultNotificationPhases = []string{ClusterPhaseRunning, ClusterPhaseFailed, ClusterPhaseDeleting}
+
+// notifiablePhases are the phases a policy may list
+var notifiablePhases = []string{ClusterPhaseProvisioning, ClusterPhaseRunning, ClusterPhaseUpdating,
+	ClusterPhaseStopped, ClusterPhaseFailed, ClusterPhaseDeleting}
+
+// Notifies reports whether moving to phase is notified
+func (p *NotificationPolicy) Notifies(phase string) bool {
+	if p == nil || len(p.Targets) == 0 {
+		return false
+	}
+	phases := p.Phases
+	if len(phases) == 0 {
+		phases = DefaultNotificationPhases
+	}
+	return containsFold(phases, phase)
+}
+
+func containsFold(list []string, s string) bool {
+	for _, item := range list {
+		if strings.EqualFold(item, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// Key describes the targets without their URLs, which hold credentials for
+// Slack, so changes can be audited
+func (p *NotificationPolicy) Key() string {
+	if p == nil || len(p.Targets) == 0 {
+		return "none"
+	}
+	var targets []string
+	for _, t := range p.Targets {
+		switch t.Type {
+		case NotificationSNS:
+			targets = append(targets, "sns:"+t.Topic)
+		case NotificationWebhook:
+			if u, err := url.Parse(t.URL); err == nil {
+				targets = append(targets, "webhook:"+u.Host)
+			} else {
+				targets = append(targets, "webhook")
+			}
+		default:
+			targets = append(targets, t.Type)
+		}
+	}
+	sort.Strings(targets)
+	phases := p.Phases
+	if len(phases) == 0 {
+		phases = DefaultNotificationPhases
+	}
+	return fmt.Sprintf("%s on %s", strings.Join(targets, ","), strings.Join(phases, ","))
+}
+
+// ValidateNotifications checks the notification targets and phases
+func ValidateNotifications(policy *NotificationPolicy) error {
+	if policy == nil {
+		return nil
+	}
+	for i, t := range policy.Targets {
+		switch t.Type {
+		case NotificationSlack, NotificationWebhook:
+			u, err := url.Parse(t.URL)
+			if err != nil || u.Host == "" || (u.Scheme != "https" && u.Scheme != "http") {
+				return fmt.Errorf("notification target %d: url must be an http(s) URL", i+1)
+			}
+			if t.Type == NotificationSlack && u.Scheme != "https" {
+				return fmt.Errorf("notification target %d: Slack webhooks are https URLs", i+1)
+			}
+		case NotificationSNS:
+			if !IsSNSTopicARN(t.Topic) {
+				return fmt.Errorf("notification target %d: topic must be an SNS topic ARN, got %q", i+1, t.Topic)
+			}
+		default:
+			return fmt.Errorf("notification target %d: type must be %s, %s or %s, got %q",
+				i+1, NotificationSlack, NotificationWebhook, NotificationSNS, t.Type)
+		}
+	}
+	for _, phase := range policy.Phases {
+		if !containsFold(notifiablePhases, phase) {
+			return fmt.Errorf("notification phase must be one of %s, got %q", strings.Join(notifiablePhases, ", "), phase)
+		}
+	}
+	return nil
+}
+
+// IsSNSTopicARN reports whether s looks like an SNS topic ARN
+func IsSNSTopicARN(s string) bool {
+	parts := strings.Split(s, ":")
+	return len(parts) == 6 && parts[0] == "arn" && parts[2] == "sns" && parts[3] != "" && parts[4] != "" && parts[5] != ""
+}
@@ -0,0 +1,150 @@
+package models
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// OrgPolicyKey is the storage key of the organization policy. It lives in
+// the goman bucket so the CLI and the controller enforce the same rules.
+const OrgPolicyKey = "settings/org-policy.yaml"
+
+// OrgPolicy holds the guardrails a platform team sets on self-service
+// clusters. Every cluster created or updated must satisfy it; empty fields
+// allow anything.
+type OrgPolicy struct {
+	// NamePattern is a regular expression cluster names must match in full
+	NamePattern string `json:"namePattern,omitempty" yaml:"namePattern,omitempty"`
+	// RequiredTags maps the tag keys every cluster must set to a regular
+	// expression their value must match in full; empty for any non-empty value
+	RequiredTags map[string]string `json:"requiredTags,omitempty" yaml:"requiredTags,omitempty"`
+	// AllowedRegions lists the regions clusters may run in
+	AllowedRegions []string `json:"allowedRegions,omitempty" yaml:"allowedRegions,omitempty"`
+	// AllowedInstanceFamilies lists the instance families, such as t3 or m6i,
+	// masters and node pools may use
+	AllowedInstanceFamilies []string `json:"allowedInstanceFamilies,omitempty" yaml:"allowedInstanceFamilies,omitempty"`
+}
+
+// PolicySubject is what the organization policy checks of a cluster
+type PolicySubject struct {
+	Name          string
+	Region        string
+	InstanceTypes []string // Masters and node pools; empty ones are skipped
+	Tags          map[string]string
+}
+
+// Subject returns what the organization policy checks of a stored cluster
+func (c *ClusterResource) Subject() PolicySubject {
+	return newPolicySubject(c.Name, c.Spec.Region, c.Spec.InstanceType, c.Spec.NodePools, c.Spec.Tags)
+}
+
+// Subject returns what the organization policy checks of a cluster being
+// created or edited
+func (c *K3sCluster) Subject() PolicySubject {
+	return newPolicySubject(c.Name, c.Region, c.InstanceType, c.NodePools, ParseResourceTags(c.Tags))
+}
+
+func newPolicySubject(name, region, instanceType string, pools []NodePool, tags map[string]string) PolicySubject {
+	types := []string{instanceType}
+	for _, pool := range pools {
+		types = append(types, pool.InstanceType)
+	}
+	return PolicySubject{Name: name, Region: region, InstanceTypes: types, Tags: tags}
+}
+
+// Empty reports whether the policy allows any cluster
+func (p *OrgPolicy) Empty() bool {
+	return p == nil || (p.NamePattern == "" && len(p.RequiredTags) == 0 &&
+		len(p.AllowedRegions) == 0 && len(p.AllowedInstanceFamilies) == 0)
+}
+
+// Validate checks that the policy's patterns compile
+func (p *OrgPolicy) Validate() error {
+	if p == nil {
+		return nil
+	}
+	if _, err := compileFullMatch(p.NamePattern); err != nil {
+		return fmt.Errorf("invalid namePattern: %w", err)
+	}
+	for _, key := range SortedTagKeys(p.RequiredTags) {
+		if key == "" {
+			return fmt.Errorf("required tag keys must not be empty")
+		}
+		if _, err := compileFullMatch(p.RequiredTags[key]); err != nil {
+			return fmt.Errorf("invalid pattern for required tag %s: %w", key, err)
+		}
+	}
+	return nil
+}
+
+// Check returns an error listing every way the cluster breaks the policy,
+// or nil when it complies
+func (p *OrgPolicy) Check(s PolicySubject) error {
+	if p.Empty() {
+		return nil
+	}
+	var violations []string
+
+	if re, err := compileFullMatch(p.NamePattern); err != nil {
+		violations = append(violations, fmt.Sprintf("invalid namePattern: %v", err))
+	} else if re != nil && !re.MatchString(s.Name) {
+		violations = append(violations, fmt.Sprintf("name %q does not match %s", s.Name, p.NamePattern))
+	}
+
+	for _, key := range SortedTagKeys(p.RequiredTags) {
+		pattern := p.RequiredTags[key]
+		value, ok := s.Tags[key]
+		if !ok || value == "" {
+			violations = append(violations, fmt.Sprintf("tag %s is required", key))
+			continue
+		}
+		if re, err := compileFullMatch(pattern); err != nil {
+			violations = append(violations, fmt.Sprintf("invalid pattern for required tag %s: %v", key, err))
+		} else if re != nil && !re.MatchString(value) {
+			violations = append(violations, fmt.Sprintf("tag %s=%s does not match %s", key, value, pattern))
+		}
+	}
+
+	if len(p.AllowedRegions) > 0 && !contains(p.AllowedRegions, s.Region) {
+		violations = append(violations, fmt.Sprintf("region %q is not allowed (allowed: %s)",
+			s.Region, strings.Join(p.AllowedRegions, ", ")))
+	}
+
+	if len(p.AllowedInstanceFamilies) > 0 {
+		seen := make(map[string]bool)
+		for _, instanceType := range s.InstanceTypes {
+			if instanceType == "" || seen[instanceType] {
+				continue
+			}
+			seen[instanceType] = true
+			if !contains(p.AllowedInstanceFamilies, InstanceFamily(instanceType)) {
+				violations = append(violations, fmt.Sprintf("instance type %s is not in an allowed family (allowed: %s)",
+					instanceType, strings.Join(p.AllowedInstanceFamilies, ", ")))
+			}
+		}
+	}
+
+	if len(violations) == 0 {
+		return nil
+	}
+	sort.Strings(violations)
+	return fmt.Errorf("cluster %s violates the organization policy: %s", s.Name, strings.Join(violations, "; "))
+}
+
+// InstanceFamily returns the family of an instance type, "m6i" for
+// "m6i.large"
+func InstanceFamily(instanceType string) string {
+	family, _, _ := strings.Cut(instanceType, ".")
+	return family
+}
+
+// compileFullMatch compiles a pattern anchored to match whole strings; nil
+// for an empty pattern
+func compileFullMatch(pattern string) (*regexp.Regexp, error) {
+	if pattern == "" {
+		return nil, nil
+	}
+	return regexp.Compile("^(?:" + pattern + ")$")
+}
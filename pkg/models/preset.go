@@ -0,0 +1,153 @@
+package models
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// SizingPreset bundles instance sizing and K3s component toggles for clusters
+// that run workloads directly on the master (all-in-one dev clusters)
+type SizingPreset struct {
+	Name            string
+	Description     string
+	InstanceType    string
+	ARMInstanceType string // Graviton type of the same size, for arm64 clusters
+	RootVolumeGB    int
+	// K3s packaged components to disable (--disable=<name>)
+	DisabledComponents []string
+}
+
+// DefaultDisabledComponents are disabled when no preset is selected
+var DefaultDisabledComponents = []string{"traefik", "servicelb", "metrics-server"}
+
+// LowResourceDisabledComponents are always disabled with the low-resource
+// profile; together they need more memory than a 1 GiB instance can spare
+var LowResourceDisabledComponents = []string{"traefik", "servicelb", "metrics-server"}
+
+// SizingPresets are the supported presets keyed by name
+var SizingPresets = map[string]SizingPreset{
+	"nano": {
+		Name:               "nano",
+		Description:        "Smallest footprint for experiments (2 vCPU, 2 GiB)",
+		InstanceType:       "t3.small",
+		ARMInstanceType:    "t4g.small",
+		RootVolumeGB:       20,
+		DisabledComponents: []string{"traefik", "servicelb", "metrics-server", "local-storage"},
+	},
+	"dev": {
+		Name:               "dev",
+		Description:        "Single-node development cluster (2 vCPU, 4 GiB)",
+		InstanceType:       "t3.medium",
+		ARMInstanceType:    "t4g.medium",
+		RootVolumeGB:       30,
+		DisabledComponents: []string{"traefik", "servicelb"},
+	},
+	"small": {
+		Name:               "small",
+		Description:        "Small workloads on the master (2 vCPU, 8 GiB)",
+		InstanceType:       "t3.large",
+		ARMInstanceType:    "t4g.large",
+		RootVolumeGB:       50,
+		DisabledComponents: []string{"traefik"},
+	},
+	"standard": {
+		Name:               "standard",
+		Description:        "All-in-one with all packaged components (4 vCPU, 16 GiB)",
+		InstanceType:       "t3.xlarge",
+		ARMInstanceType:    "t4g.xlarge",
+		RootVolumeGB:       80,
+		DisabledComponents: nil,
+	},
+}
+
+// PresetNames returns the preset names sorted by instance size
+func PresetNames() []string {
+	names := make([]string, 0, len(SizingPresets))
+	for name := range SizingPresets {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		return SizingPresets[names[i]].RootVolumeGB < SizingPresets[names[j]].RootVolumeGB
+	})
+	return names
+}
+
+// LookupPreset returns the preset with the given name
+func LookupPreset(name string) (SizingPreset, error) {
+	preset, ok := SizingPresets[strings.ToLower(name)]
+	if !ok {
+		return SizingPreset{}, fmt.Errorf("unknown preset %q (valid: %s)", name, strings.Join(PresetNames(), ", "))
+	}
+	return preset, nil
+}
+
+// ApplyPreset expands Spec.Preset into concrete spec fields. An explicitly
+// set instance type is kept so users can override the preset's sizing.
+func (s *ClusterSpec) ApplyPreset() error {
+	if s.Preset == "" {
+		if s.DisabledComponents == nil {
+			s.DisabledComponents = DefaultDisabledComponents
+		}
+		s.applyLowResource()
+		return nil
+	}
+
+	preset, err := LookupPreset(s.Preset)
+	if err != nil {
+		return err
+	}
+
+	if s.InstanceType == "" {
+		s.InstanceType = preset.InstanceType
+		if s.Architecture == ArchitectureARM64 {
+			s.InstanceType = preset.ARMInstanceType
+		}
+	}
+	root := RootVolume{}
+	if s.RootVolume != nil {
+		root = *s.RootVolume
+	}
+	if root.Size == 0 {
+		root.Size = preset.RootVolumeGB
+		s.RootVolume = &root
+	}
+	s.DisabledComponents = preset.DisabledComponents
+	s.applyLowResource()
+	return nil
+}
+
+// applyLowResource adds the components the low-resource profile disables
+func (s *ClusterSpec) applyLowResource() {
+	if !s.LowResource {
+		return
+	}
+	disabled := append([]string{}, s.DisabledComponents...)
+	for _, component := range LowResourceDisabledComponents {
+		found := false
+		for _, d := range disabled {
+			if d == component {
+				found = true
+				break
+			}
+		}
+		if !found {
+			disabled = append(disabled, component)
+		}
+	}
+	s.DisabledComponents = disabled
+}
+
+// smallInstanceTypes have 2 GiB of memory or less
+var smallInstanceTypes = map[string]bool{
+	"t2.nano": true, "t2.micro": true, "t2.small": true,
+	"t3.nano": true, "t3.micro": true, "t3.small": true,
+	"t3a.nano": true, "t3a.micro": true, "t3a.small": true,
+	"t4g.nano": true, "t4g.micro": true, "t4g.small": true,
+}
+
+// IsSmallInstanceType reports whether an instance type is small enough that
+// K3s needs the low-resource profile to run reliably
+func IsSmallInstanceType(instanceType string) bool {
+	return smallInstanceTypes[instanceType]
+}
@@ -0,0 +1,65 @@
+package models
+
+// HoursPerMonth is the average number of hours in a month, as used by AWS
+// pricing
+const HoursPerMonth = 730
+
+// hourlyPrices are approximate on-demand Linux prices in USD (us-east-1).
+// They are only used for rough impact estimates, never for billing.
+var hourlyPrices = map[string]float64{
+	"t3.micro":    0.0104,
+	"t3.small":    0.0208,
+	"t3.medium":   0.0416,
+	"t3.large":    0.0832,
+	"t3.xlarge":   0.1664,
+	"t3.2xlarge":  0.3328,
+	"t3a.medium":  0.0376,
+	"t3a.large":   0.0752,
+	"t3a.xlarge":  0.1504,
+	"t4g.small":   0.0168,
+	"t4g.medium":  0.0336,
+	"t4g.large":   0.0672,
+	"t4g.xlarge":  0.1344,
+	"m5.large":    0.096,
+	"m5.xlarge":   0.192,
+	"m5.2xlarge":  0.384,
+	"m5.4xlarge":  0.768,
+	"m5d.large":   0.113,
+	"m5d.xlarge":  0.226,
+	"m6i.large":   0.096,
+	"m6i.xlarge":  0.192,
+	"m6g.large":   0.077,
+	"m6g.xlarge":  0.154,
+	"c5.large":    0.085,
+	"c5.xlarge":   0.17,
+	"c5.2xlarge":  0.34,
+	"c6i.large":   0.085,
+	"c6i.xlarge":  0.17,
+	"c6gd.large":  0.0768,
+	"c6gd.xlarge": 0.1536,
+	"r5.large":    0.126,
+	"r5.xlarge":   0.252,
+	"r6i.large":   0.126,
+	"r6i.xlarge":  0.252,
+	"i3.large":    0.156,
+	"i3.xlarge":   0.312,
+	"g4dn.xlarge": 0.526,
+	"g5.xlarge":   1.006,
+}
+
+// HourlyPrice returns the approximate on-demand price of an instance type
+// per hour, false if it is not known
+func HourlyPrice(instanceType string) (float64, bool) {
+	price, ok := hourlyPrices[instanceType]
+	return price, ok
+}
+
+// MonthlyCost returns the approximate monthly cost of count instances of a
+// type, false if the price of the type is not known
+func MonthlyCost(instanceType string, count int) (float64, bool) {
+	price, ok := HourlyPrice(instanceType)
+	if !ok {
+		return 0, false
+	}
+	return price * HoursPerMonth * float64(count), true
+}
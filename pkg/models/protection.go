@@ -0,0 +1,112 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Instance-initiated shutdown behaviors: what happens when a node is shut
+// down from inside the OS (e.g. `shutdown -h now`)
+const (
+	ShutdownBehaviorStop      = "stop"
+	ShutdownBehaviorTerminate = "terminate"
+)
+
+// InstanceProtectionSpec sets EC2 protection options on all cluster nodes.
+// Dev clusters meant for hibernation keep the stop behavior so they can be
+// shut down from inside the OS safely; production clusters turn on stop
+// protection so nodes can't be stopped through the API by accident.
+// Deletion protection is on for production clusters unless set; ephemeral
+// clusters go without it, sparing an API call on every node deletion.
+type InstanceProtectionSpec struct {
+	ShutdownBehavior   string `json:"shutdownBehavior,omitempty" yaml:"shutdownBehavior,omitempty"`     // stop (default) or terminate
+	StopProtection     bool   `json:"stopProtection,omitempty" yaml:"stopProtection,omitempty"`         // Refuse StopInstances
+	DeletionProtection *bool  `json:"deletionProtection,omitempty" yaml:"deletionProtection,omitempty"` // Refuse TerminateInstances; production clusters only if unset
+}
+
+// Tag keys and values marking a production cluster
+var (
+	environmentTagKeys  = []string{"environment", "env", "stage"}
+	productionTagValues = []string{"prod", "production"}
+)
+
+// IsProduction reports whether cluster tags mark a production cluster: an
+// Environment, Env or Stage tag of prod or production, in any case
+func IsProduction(tags map[string]string) bool {
+	for key, value := range tags {
+		for _, k := range environmentTagKeys {
+			if !strings.EqualFold(key, k) {
+				continue
+			}
+			for _, v := range productionTagValues {
+				if strings.EqualFold(strings.TrimSpace(value), v) {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// Behavior returns the shutdown behavior, stop unless set
+func (s *InstanceProtectionSpec) Behavior() string {
+	if s == nil || s.ShutdownBehavior == "" {
+		return ShutdownBehaviorStop
+	}
+	return s.ShutdownBehavior
+}
+
+// StopProtected reports whether nodes are protected against stopping
+func (s *InstanceProtectionSpec) StopProtected() bool {
+	return s != nil && s.StopProtection
+}
+
+// DeletionProtected reports whether nodes are protected against deletion
+// through the API: as set, or if the cluster's tags mark it production
+func (s *InstanceProtectionSpec) DeletionProtected(tags map[string]string) bool {
+	if s != nil && s.DeletionProtection != nil {
+		return *s.DeletionProtection
+	}
+	return IsProduction(tags)
+}
+
+// Key identifies the settings as written in the spec
+func (s *InstanceProtectionSpec) Key() string {
+	key := fmt.Sprintf("shutdown=%s,stopProtection=%t", s.Behavior(), s.StopProtected())
+	if s != nil && s.DeletionProtection != nil {
+		key += fmt.Sprintf(",deletionProtection=%t", *s.DeletionProtection)
+	}
+	return key
+}
+
+// AppliedKey identifies the settings nodes of a cluster with the given tags
+// get, to detect changes that must be applied to existing nodes
+func (s *InstanceProtectionSpec) AppliedKey(tags map[string]string) string {
+	return fmt.Sprintf("shutdown=%s,stopProtection=%t,deletionProtection=%t", s.Behavior(), s.StopProtected(), s.DeletionProtected(tags))
+}
+
+// ValidateInstanceProtection checks the protection settings
+func ValidateInstanceProtection(spec *InstanceProtectionSpec) error {
+	if spec == nil {
+		return nil
+	}
+	switch spec.ShutdownBehavior {
+	case "", ShutdownBehaviorStop, ShutdownBehaviorTerminate:
+		return nil
+	default:
+		return fmt.Errorf("shutdownBehavior must be %s or %s, got %q", ShutdownBehaviorStop, ShutdownBehaviorTerminate, spec.ShutdownBehavior)
+	}
+}
+
+// InstanceProtectionStatus records the settings applied to existing nodes
+type InstanceProtectionStatus struct {
+	Key       string     `json:"key" yaml:"key"`
+	Nodes     []string   `json:"nodes,omitempty" yaml:"nodes,omitempty"` // Instance IDs with Key applied
+	AppliedAt *time.Time `json:"appliedAt,omitempty" yaml:"appliedAt,omitempty"`
+}
+
+// HasNode reports whether the settings were applied to an instance
+func (s *InstanceProtectionStatus) HasNode(instanceID string) bool {
+	return s != nil && contains(s.Nodes, instanceID)
+}
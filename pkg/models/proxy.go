@@ -0,0 +1,108 @@
+package models
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// ProxySpec sends the outbound HTTP(S) traffic of the nodes through a proxy,
+// for networks where instances only reach the internet that way: package
+// installs, the K3s download, image pulls and the SSM agent
+type ProxySpec struct {
+	HTTPProxy  string   `json:"httpProxy,omitempty" yaml:"httpProxy,omitempty"`   // e.g. http://proxy.corp:3128
+	HTTPSProxy string   `json:"httpsProxy,omitempty" yaml:"httpsProxy,omitempty"` // The HTTP proxy if empty
+	NoProxy    []string `json:"noProxy,omitempty" yaml:"noProxy,omitempty"`       // Added to DefaultNoProxy
+}
+
+// DefaultNoProxy is always reached directly: loopback, instance metadata,
+// private networks and cluster-internal names
+var DefaultNoProxy = []string{
+	"localhost", "127.0.0.0/8", "169.254.169.254", "169.254.170.2", "metadata.google.internal",
+	"10.0.0.0/8", "172.16.0.0/12", "192.168.0.0/16",
+	".svc", ".cluster.local", ".internal",
+}
+
+// proxyValue matches what can be written to environment files and shell
+// scripts unquoted
+var proxyValue = regexp.MustCompile(`^[A-Za-z0-9._~:/?#\[\]@!%+,=*-]+$`)
+
+// Enabled reports whether a proxy is configured
+func (p *ProxySpec) Enabled() bool {
+	return p != nil && (p.HTTPProxy != "" || p.HTTPSProxy != "")
+}
+
+// HTTPS returns the proxy for HTTPS traffic
+func (p *ProxySpec) HTTPS() string {
+	if p.HTTPSProxy != "" {
+		return p.HTTPSProxy
+	}
+	return p.HTTPProxy
+}
+
+// NoProxyList returns the comma-separated hosts and networks reached
+// directly: the defaults, the cluster's pod and service networks and the
+// spec's own
+func (p *ProxySpec) NoProxyList(clusterCIDR, serviceCIDR string) string {
+	list := append([]string(nil), DefaultNoProxy...)
+	for _, cidr := range []string{clusterCIDR, serviceCIDR} {
+		if cidr != "" {
+			list = append(list, cidr)
+		}
+	}
+	seen := make(map[string]bool)
+	var out []string
+	for _, entry := range append(list, p.NoProxy...) {
+		if entry = strings.TrimSpace(entry); entry != "" && !seen[entry] {
+			seen[entry] = true
+			out = append(out, entry)
+		}
+	}
+	return strings.Join(out, ",")
+}
+
+// Key describes the proxy without credentials, so changes can be audited
+func (p *ProxySpec) Key() string {
+	if !p.Enabled() {
+		return "none"
+	}
+	noProxy := append([]string(nil), p.NoProxy...)
+	sort.Strings(noProxy)
+	return fmt.Sprintf("http=%s,https=%s,noProxy=%s", redactProxy(p.HTTPProxy), redactProxy(p.HTTPS()), strings.Join(noProxy, ";"))
+}
+
+// redactProxy hides the password of a proxy URL
+func redactProxy(s string) string {
+	u, err := url.Parse(s)
+	if err != nil || u.User == nil {
+		return s
+	}
+	return u.Redacted()
+}
+
+// ValidateProxy checks the proxy URLs and exceptions
+func ValidateProxy(spec *ProxySpec) error {
+	if spec == nil {
+		return nil
+	}
+	if spec.HTTPProxy == "" && spec.HTTPSProxy == "" && len(spec.NoProxy) > 0 {
+		return fmt.Errorf("proxy: noProxy needs httpProxy or httpsProxy")
+	}
+	for field, value := range map[string]string{"httpProxy": spec.HTTPProxy, "httpsProxy": spec.HTTPSProxy} {
+		if value == "" {
+			continue
+		}
+		u, err := url.Parse(value)
+		if err != nil || u.Host == "" || (u.Scheme != "http" && u.Scheme != "https") || !proxyValue.MatchString(value) {
+			return fmt.Errorf("proxy: %s must be an http:// or https:// URL, got %q", field, redactProxy(value))
+		}
+	}
+	for _, entry := range spec.NoProxy {
+		if entry == "" || strings.Contains(entry, ",") || !proxyValue.MatchString(entry) {
+			return fmt.Errorf("proxy: invalid noProxy entry %q", entry)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,81 @@
+package models
+
+import (
+	"fmt"
+	"time"
+)
+
+// What the surviving master's etcd is reset from in a quorum recovery
+const (
+	QuorumRestoreLatest = "latest" // Newest etcd snapshot on the survivor, its own data if it has none
+	QuorumRestoreNone   = "none"   // The survivor's own data, keeping writes since the last snapshot
+)
+
+// ValidateQuorumRestore checks the restore source of a quorum recovery
+// request; empty means latest
+func ValidateQuorumRestore(s string) error {
+	switch s {
+	case "", QuorumRestoreLatest, QuorumRestoreNone:
+		return nil
+	}
+	return fmt.Errorf("invalid restore source %q (expected %s or %s)", s, QuorumRestoreLatest, QuorumRestoreNone)
+}
+
+// QuorumRecoveryPhase is the step a quorum recovery is in
+type QuorumRecoveryPhase string
+
+const (
+	QuorumRecoveryResetting QuorumRecoveryPhase = "Resetting"
+	QuorumRecoveryReplacing QuorumRecoveryPhase = "Replacing"
+	QuorumRecoveryRejoining QuorumRecoveryPhase = "Rejoining"
+	QuorumRecoveryCompleted QuorumRecoveryPhase = "Completed"
+	QuorumRecoveryFailed    QuorumRecoveryPhase = "Failed"
+)
+
+// QuorumRecoveryRequest asks the controller to rebuild an HA control plane
+// that lost etcd quorum from one surviving master. It is kept in the spec so
+// it survives controller restarts; progress is tracked in QuorumRecoveryStatus.
+type QuorumRecoveryRequest struct {
+	RequestedAt time.Time `json:"requestedAt" yaml:"requestedAt"`
+	Restore     string    `json:"restore,omitempty" yaml:"restore,omitempty"` // QuorumRestore*, latest if empty
+}
+
+// QuorumRecoveryStatus tracks the progress of a quorum recovery request
+type QuorumRecoveryStatus struct {
+	RequestedAt  time.Time           `json:"requestedAt" yaml:"requestedAt"`
+	Phase        QuorumRecoveryPhase `json:"phase" yaml:"phase"`
+	Survivor     string              `json:"survivor,omitempty" yaml:"survivor,omitempty"` // Instance ID
+	SurvivorName string              `json:"survivorName,omitempty" yaml:"survivorName,omitempty"`
+	Snapshot     string              `json:"snapshot,omitempty" yaml:"snapshot,omitempty"` // Empty when reset from the survivor's own data
+
+	// Names of the masters launched again, and the instance IDs of those
+	// launched so far
+	Replaced     []string `json:"replaced,omitempty" yaml:"replaced,omitempty"`
+	Replacements []string `json:"replacements,omitempty" yaml:"replacements,omitempty"`
+
+	Message     string     `json:"message,omitempty" yaml:"message,omitempty"`
+	StartedAt   time.Time  `json:"startedAt" yaml:"startedAt"`
+	CompletedAt *time.Time `json:"completedAt,omitempty" yaml:"completedAt,omitempty"`
+}
+
+// Done reports whether the recovery has finished, successfully or not
+func (s *QuorumRecoveryStatus) Done() bool {
+	return s.Phase == QuorumRecoveryCompleted || s.Phase == QuorumRecoveryFailed
+}
+
+func (s *QuorumRecoveryStatus) String() string {
+	source := "the survivor's own data"
+	if s.Snapshot != "" {
+		source = "snapshot " + s.Snapshot
+	}
+	switch s.Phase {
+	case QuorumRecoveryCompleted:
+		return fmt.Sprintf("recovered from %s on %s, rejoined %d master(s)", source, s.SurvivorName, len(s.Replaced))
+	case QuorumRecoveryFailed:
+		return "failed: " + s.Message
+	case QuorumRecoveryResetting:
+		return fmt.Sprintf("resetting etcd on %s", s.SurvivorName)
+	default:
+		return fmt.Sprintf("%s: etcd of %s reset from %s, %d master(s) to rejoin", s.Phase, s.SurvivorName, source, len(s.Replaced))
+	}
+}
@@ -0,0 +1,153 @@
+package models
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Prefixes of registry credential references: an SSM parameter or a
+// Secrets Manager secret
+const (
+	CredentialsSSM            = "ssm:"
+	CredentialsSecretsManager = "secretsmanager:"
+)
+
+// RegistrySpec configures the registries K3s's containerd pulls images
+// from: mirror endpoints per registry, and credentials per registry host,
+// read by the nodes themselves so they never pass through goman. Unlike
+// bootstrap mirrors, changes are applied to running nodes.
+type RegistrySpec struct {
+	Mirrors map[string][]string     `json:"mirrors,omitempty" yaml:"mirrors,omitempty"` // Registry, e.g. docker.io or *, to mirror endpoints
+	Auth    map[string]RegistryAuth `json:"auth,omitempty" yaml:"auth,omitempty"`       // Registry or mirror host to its credentials
+}
+
+// RegistryAuth is where the nodes get the credentials of a registry
+type RegistryAuth struct {
+	ECR         bool   `json:"ecr,omitempty" yaml:"ecr,omitempty"`                 // An ECR registry, logged in to with the nodes' role
+	Credentials string `json:"credentials,omitempty" yaml:"credentials,omitempty"` // ssm:<parameter> or secretsmanager:<secret> holding user:password or {"username":..,"password":..}
+}
+
+var ecrHost = regexp.MustCompile(`^[0-9]{12}\.dkr\.ecr\.([a-z0-9-]+)\.amazonaws\.com(\.cn)?$`)
+
+// ECRRegion returns the region of an ECR registry host, false for other
+// hosts
+func ECRRegion(host string) (string, bool) {
+	m := ecrHost.FindStringSubmatch(host)
+	if m == nil {
+		return "", false
+	}
+	return m[1], true
+}
+
+// Enabled reports whether registries are configured
+func (r *RegistrySpec) Enabled() bool {
+	return r != nil && (len(r.Mirrors) > 0 || len(r.Auth) > 0)
+}
+
+// Key describes the configuration, so changes can be audited and applied
+// to the nodes; credentials are references and appear as they are
+func (r *RegistrySpec) Key() string {
+	if !r.Enabled() {
+		return "none"
+	}
+	var mirrors []string
+	for registry, endpoints := range r.Mirrors {
+		var redacted []string
+		for _, endpoint := range endpoints {
+			redacted = append(redacted, redactProxy(endpoint))
+		}
+		mirrors = append(mirrors, registry+"="+strings.Join(redacted, "|"))
+	}
+	sort.Strings(mirrors)
+	var auth []string
+	for host, a := range r.Auth {
+		source := a.Credentials
+		if a.ECR {
+			source = "ecr"
+		}
+		auth = append(auth, host+"="+source)
+	}
+	sort.Strings(auth)
+	return fmt.Sprintf("mirrors=%s,auth=%s", strings.Join(mirrors, ";"), strings.Join(auth, ";"))
+}
+
+// MirrorsWith returns the mirrors together with those of the bootstrap spec
+func (r *RegistrySpec) MirrorsWith(bootstrap *BootstrapSpec) map[string][]string {
+	mirrors := make(map[string][]string)
+	if bootstrap != nil {
+		for registry, endpoints := range bootstrap.RegistryMirrors {
+			mirrors[registry] = endpoints
+		}
+	}
+	if r != nil {
+		for registry, endpoints := range r.Mirrors {
+			mirrors[registry] = endpoints
+		}
+	}
+	return mirrors
+}
+
+// ValidateRegistries checks the mirrors and credentials of a cluster's
+// registries. Nodes may only read credentials under the cluster's own
+// prefix, /goman/<cluster>/ in Parameter Store and goman/<cluster>/ in
+// Secrets Manager.
+func ValidateRegistries(clusterName string, spec *RegistrySpec, bootstrap *BootstrapSpec) error {
+	if spec == nil {
+		return nil
+	}
+	if err := validateMirrors("registries", spec.Mirrors); err != nil {
+		return err
+	}
+	if bootstrap != nil {
+		for registry := range spec.Mirrors {
+			if _, ok := bootstrap.RegistryMirrors[registry]; ok {
+				return fmt.Errorf("registries: %s is mirrored in bootstrap too, keep it in one place", registry)
+			}
+		}
+	}
+	for host, auth := range spec.Auth {
+		if host == "*" || !registryHost.MatchString(host) {
+			return fmt.Errorf("registries: invalid registry host %q for credentials", host)
+		}
+		switch {
+		case auth.ECR && auth.Credentials != "":
+			return fmt.Errorf("registries: %s sets both ecr and credentials", host)
+		case auth.ECR:
+			if _, ok := ECRRegion(host); !ok {
+				return fmt.Errorf("registries: %s is not an ECR registry, want <account>.dkr.ecr.<region>.amazonaws.com", host)
+			}
+		case strings.HasPrefix(auth.Credentials, CredentialsSSM):
+			prefix := fmt.Sprintf("/goman/%s/", clusterName)
+			if name := strings.TrimPrefix(auth.Credentials, CredentialsSSM); !strings.HasPrefix(name, prefix) || !credentialName.MatchString(name) {
+				return fmt.Errorf("registries: credentials of %s must be an SSM parameter under %s", host, prefix)
+			}
+		case strings.HasPrefix(auth.Credentials, CredentialsSecretsManager):
+			prefix := fmt.Sprintf("goman/%s/", clusterName)
+			if name := strings.TrimPrefix(auth.Credentials, CredentialsSecretsManager); !strings.HasPrefix(name, prefix) || !credentialName.MatchString(name) {
+				return fmt.Errorf("registries: credentials of %s must be a Secrets Manager secret under %s", host, prefix)
+			}
+		default:
+			return fmt.Errorf("registries: credentials of %s must be ecr: true, ssm:<parameter> or secretsmanager:<secret>", host)
+		}
+	}
+	return nil
+}
+
+// credentialName matches the names of parameters and secrets
+var credentialName = regexp.MustCompile(`^[A-Za-z0-9/_+=.@-]+$`)
+
+// RegistryStatus reports the nodes the registry configuration was applied
+// to
+type RegistryStatus struct {
+	Key       string     `json:"key" yaml:"key"`
+	Nodes     []string   `json:"nodes,omitempty" yaml:"nodes,omitempty"` // Instance IDs with Key applied
+	AppliedAt *time.Time `json:"appliedAt,omitempty" yaml:"appliedAt,omitempty"`
+}
+
+// HasNode reports whether the configuration was applied to an instance
+func (s *RegistryStatus) HasNode(instanceID string) bool {
+	return s != nil && contains(s.Nodes, instanceID)
+}
@@ -39,15 +39,113 @@ type ClusterSpec struct {
 	Tags         map[string]string `json:"tags,omitempty"`
 	DesiredState string            `json:"desiredState,omitempty"` // "running" or "stopped"
 	NodePools    []NodePool        `json:"nodePools,omitempty"`    // Worker node pools
+
+	// Sizing preset and the concrete fields it expands to
+	Preset             string      `json:"preset,omitempty"`             // nano, dev, small or standard
+	RootVolume         *RootVolume `json:"rootVolume,omitempty"`         // Root volume of the masters, and of pools without their own
+	DisabledComponents []string    `json:"disabledComponents,omitempty"` // K3s packaged components to disable
+	LowResource        bool        `json:"lowResource,omitempty"`        // Tune K3s for instances with 1-2 GiB of memory
+
+	// CPU architecture of the masters, x86_64 or arm64 (Graviton); the
+	// instance type's if empty
+	Architecture string `json:"architecture,omitempty"`
+
+	// Image of the nodes: a custom AMI, and the OS family of its bootstrap
+	// variant; the latest Amazon Linux 2 image if both are empty
+	ImageID  string `json:"imageID,omitempty"`
+	OSFamily string `json:"osFamily,omitempty"` // amazonlinux2, amazonlinux2023 or ubuntu2204
+
+	// Worker nodes to replace, processed one at a time
+	NodeReplacements []NodeReplacement `json:"nodeReplacements,omitempty"`
+
+	// What to do when instances are changed outside goman, per DriftField*
+	DriftPolicy map[string]DriftPolicy `json:"driftPolicy,omitempty"`
+
+	// Batching and pausing of node pool rollouts
+	Rollout *RolloutSpec `json:"rollout,omitempty"`
+
+	// Cluster DNS customization, applied by the controller
+	DNS *DNSSpec `json:"dns,omitempty"`
+
+	// Floating private IP served by the masters as the API endpoint (HA only)
+	VirtualIP *VirtualIPSpec `json:"virtualIP,omitempty"`
+
+	// Network load balancer serving the API endpoint from all masters (HA only)
+	LoadBalancer *LoadBalancerSpec `json:"loadBalancer,omitempty"`
+
+	// Shutdown behavior and stop protection of the nodes
+	InstanceProtection *InstanceProtectionSpec `json:"instanceProtection,omitempty"`
+
+	// Other goman clusters this cluster connects to privately
+	ClusterLinks []ClusterLink `json:"clusterLinks,omitempty"`
+
+	// Helm charts and manifests installed into the cluster
+	Addons []Addon `json:"addons,omitempty"`
+
+	// Webhooks and SNS topics told about phase changes
+	Notifications *NotificationPolicy `json:"notifications,omitempty"`
+
+	// Outbound HTTP(S) proxy of the nodes, applied at bootstrap
+	Proxy *ProxySpec `json:"proxy,omitempty"`
+
+	// Pre-install hook, extra packages and registry mirrors of new nodes
+	Bootstrap *BootstrapSpec `json:"bootstrap,omitempty"`
+
+	// Registry mirrors and credentials, applied to running nodes too
+	Registries *RegistrySpec `json:"registries,omitempty"`
+
+	// Times at which the cluster is stopped and started
+	Schedule *ScheduleSpec `json:"schedule,omitempty"`
+
+	// When the cluster was last stopped or started by hand; a scheduled
+	// action before then does not override DesiredState
+	DesiredStateSetAt *time.Time `json:"desiredStateSetAt,omitempty"`
+
+	// Set to request re-applying Tags to all existing resources
+	RetagRequestedAt *time.Time `json:"retagRequestedAt,omitempty"`
+
+	// Set to request rotating the K3s certificates on all masters
+	CertRotationRequestedAt *time.Time `json:"certRotationRequestedAt,omitempty"`
+
+	// Set to request rotating the K3s token, client certificates and
+	// kubeconfigs
+	CredentialRotationRequestedAt *time.Time `json:"credentialRotationRequestedAt,omitempty"`
+
+	// Set to request rebuilding a control plane that lost etcd quorum
+	QuorumRecovery *QuorumRecoveryRequest `json:"quorumRecovery,omitempty"`
+
+	// Set at creation to restore from an etcd snapshot of another cluster
+	Restore *SnapshotRestoreRequest `json:"restore,omitempty"`
+
+	// Set to request rebuilding the status from the live cloud and K3s state
+	ResyncRequestedAt *time.Time `json:"resyncRequestedAt,omitempty"`
+
+	// Set to request reverting all drift once, whatever the drift policy
+	DriftFixRequestedAt *time.Time `json:"driftFixRequestedAt,omitempty"`
 }
 
 // NodePool defines a group of worker nodes with similar configuration
 type NodePool struct {
-	Name         string            `json:"name"`
-	Count        int               `json:"count"`
-	InstanceType string            `json:"instanceType"`
-	Labels       map[string]string `json:"labels,omitempty"`
-	Taints       []Taint           `json:"taints,omitempty"`
+	Name          string            `json:"name"`
+	Count         int               `json:"count"`
+	InstanceType  string            `json:"instanceType"`
+	Labels        map[string]string `json:"labels,omitempty"`
+	Taints        []Taint           `json:"taints,omitempty"`
+	Volumes       []DataVolume      `json:"volumes,omitempty"`       // Data volumes attached to each node
+	InstanceStore bool              `json:"instanceStore,omitempty"` // Use local NVMe storage for containerd and emptyDir
+	Paused        bool              `json:"paused,omitempty"`        // Keep the existing nodes: no scaling, replacement or drift revert
+	RootVolume    *RootVolume       `json:"rootVolume,omitempty"`    // Root volume of the pool's nodes, the cluster's if nil
+	UserData      string            `json:"userData,omitempty"`      // Script or cloud-config run at each node's first boot
+	Architecture  string            `json:"architecture,omitempty"`  // x86_64 or arm64 (Graviton), the instance type's if empty
+	ImageID       string            `json:"imageID,omitempty"`       // Custom AMI of the pool's nodes, the cluster's if neither is set
+	OSFamily      string            `json:"osFamily,omitempty"`      // OS family of the pool's nodes
+
+	// ScaleDownBehavior is "stop" to keep scaled-down workers stopped for
+	// the next scale-up, "terminate" (default) to remove them
+	ScaleDownBehavior string `json:"scaleDownBehavior,omitempty"`
+
+	// Zones to spread the pool's nodes across, the provider's default if empty
+	AvailabilityZones []string `json:"availabilityZones,omitempty"`
 }
 
 // Taint represents a Kubernetes taint on nodes
@@ -63,6 +161,7 @@ type ClusterResourceStatus struct {
 	ObservedGeneration int         `json:"observedGeneration" yaml:"observedGeneration"`
 	Conditions         []Condition `json:"conditions,omitempty" yaml:"conditions,omitempty"`
 	LastReconcileTime  *time.Time  `json:"lastReconcileTime,omitempty" yaml:"lastReconcileTime,omitempty"`
+	WriterVersion      string      `json:"writerVersion,omitempty" yaml:"writerVersion,omitempty"` // goman version that last wrote the status
 
 	// Actual infrastructure state
 	ClusterID      string           `json:"clusterId,omitempty" yaml:"clusterId,omitempty"`
@@ -71,6 +170,7 @@ type ClusterResourceStatus struct {
 	SecurityGroups []string         `json:"securityGroups,omitempty" yaml:"securityGroups,omitempty"`
 	Instances      []InstanceStatus `json:"instances,omitempty" yaml:"instances,omitempty"`
 	APIEndpoint    string           `json:"apiEndpoint,omitempty" yaml:"apiEndpoint,omitempty"`
+	APIEndpoints   []string         `json:"apiEndpoints,omitempty" yaml:"apiEndpoints,omitempty"` // Every master's API server of an HA cluster, in failover order
 	
 	// K3s cluster status (will be populated after installation)
 	K3sServerURL       string `json:"k3sServerUrl,omitempty" yaml:"k3sServerUrl,omitempty"`       // K3s API server URL
@@ -90,6 +190,97 @@ type ClusterResourceStatus struct {
 	
 	// Pending operations tracking (for non-blocking execution)
 	PendingOperations *PendingOperations `json:"pendingOperations,omitempty" yaml:"pendingOperations,omitempty"`
+
+	// Recent failed cloud API calls, newest last
+	RecentErrors []OperationErrorRecord `json:"recentErrors,omitempty" yaml:"recentErrors,omitempty"`
+
+	// Reconciles in a row that failed on cloud throttling or service errors
+	CloudFailures int `json:"cloudFailures,omitempty" yaml:"cloudFailures,omitempty"`
+
+	// Progress of node replacement requests from the spec
+	NodeReplacements []NodeReplacementStatus `json:"nodeReplacements,omitempty" yaml:"nodeReplacements,omitempty"`
+
+	// Instances that differ from the spec, whether adopted or being reverted
+	Drift []DriftStatus `json:"drift,omitempty" yaml:"drift,omitempty"`
+
+	// Changes made outside goman whose author is still looked up
+	UnattributedChanges []UnattributedChange `json:"unattributedChanges,omitempty" yaml:"unattributedChanges,omitempty"`
+
+	// Current or last node pool rollout
+	Rollout *RolloutStatus `json:"rollout,omitempty" yaml:"rollout,omitempty"`
+
+	// Current or last K3s version upgrade
+	Upgrade *UpgradeStatus `json:"upgrade,omitempty" yaml:"upgrade,omitempty"`
+
+	// Health of the etcd members of an HA control plane
+	Etcd *EtcdStatus `json:"etcd,omitempty" yaml:"etcd,omitempty"`
+
+	// Current or last rebuild of the control plane after etcd lost quorum
+	QuorumRecovery *QuorumRecoveryStatus `json:"quorumRecovery,omitempty" yaml:"quorumRecovery,omitempty"`
+
+	// Restore from an etcd snapshot of another cluster at creation
+	Restore *SnapshotRestoreStatus `json:"restore,omitempty" yaml:"restore,omitempty"`
+
+	// When the status was last rebuilt from the live state on request
+	ResyncedAt *time.Time `json:"resyncedAt,omitempty" yaml:"resyncedAt,omitempty"`
+
+	// When drift was last reverted on request
+	DriftFixedAt *time.Time `json:"driftFixedAt,omitempty" yaml:"driftFixedAt,omitempty"`
+
+	// Last run of the smoke tests gating the Running phase
+	Verification *VerificationStatus `json:"verification,omitempty" yaml:"verification,omitempty"`
+
+	// DNS configuration applied to the cluster and its nodes
+	DNS *DNSStatus `json:"dns,omitempty" yaml:"dns,omitempty"`
+
+	// Virtual IP serving the API endpoint and the masters configured for it
+	VirtualIP *VirtualIPStatus `json:"virtualIP,omitempty" yaml:"virtualIP,omitempty"`
+
+	// Load balancer serving the API endpoint and the masters behind it
+	LoadBalancer *LoadBalancerStatus `json:"loadBalancer,omitempty" yaml:"loadBalancer,omitempty"`
+
+	// User tags last applied to the cluster's resources
+	Tags *TagSyncStatus `json:"tags,omitempty" yaml:"tags,omitempty"`
+
+	// Instance protection settings applied to the nodes
+	InstanceProtection *InstanceProtectionStatus `json:"instanceProtection,omitempty" yaml:"instanceProtection,omitempty"`
+
+	// Expiry of the K3s certificates and the stored kubeconfig
+	Certificates *CertificateStatus `json:"certificates,omitempty" yaml:"certificates,omitempty"`
+
+	// Current or last rotation of the token, client certificates and
+	// kubeconfigs
+	CredentialRotation *CredentialRotationStatus `json:"credentialRotation,omitempty" yaml:"credentialRotation,omitempty"`
+
+	// Expiry of the token in the stored read-only kubeconfig
+	ReadOnlyKubeconfigExpiresAt *time.Time `json:"readOnlyKubeconfigExpiresAt,omitempty" yaml:"readOnlyKubeconfigExpiresAt,omitempty"`
+
+	// Connectivity set up for cluster links
+	ClusterLinks []ClusterLinkStatus `json:"clusterLinks,omitempty" yaml:"clusterLinks,omitempty"`
+
+	// Nodes the registry configuration was applied to
+	Registries *RegistryStatus `json:"registries,omitempty" yaml:"registries,omitempty"`
+
+	// Installed addons and their versions
+	Addons []AddonStatus `json:"addons,omitempty" yaml:"addons,omitempty"`
+
+	// When the current phase began, and when the cluster setup or rollout in
+	// progress is expected to finish based on past durations
+	PhaseStartedAt      *time.Time `json:"phaseStartedAt,omitempty" yaml:"phaseStartedAt,omitempty"`
+	EstimatedCompletion *time.Time `json:"estimatedCompletion,omitempty" yaml:"estimatedCompletion,omitempty"`
+
+	// When the cluster first became Running after being created
+	FirstRunningAt *time.Time `json:"firstRunningAt,omitempty" yaml:"firstRunningAt,omitempty"`
+
+	// Spec generation the last reconcile acted on, with the queued edits it
+	// coalesced
+	LastIntent *IntentRecord `json:"lastIntent,omitempty" yaml:"lastIntent,omitempty"`
+
+	// Scheduled stops and starts applied, and the next one
+	Schedule *ScheduleStatus `json:"schedule,omitempty" yaml:"schedule,omitempty"`
+
+	// Cleanups a deletion must verify before the cluster's records go
+	Finalizers []string `json:"finalizers,omitempty" yaml:"finalizers,omitempty"`
 }
 
 // InstanceStatus represents the status of an EC2 instance
@@ -101,6 +292,15 @@ type InstanceStatus struct {
 	PrivateIP  string    `json:"privateIp,omitempty" yaml:"privateIp,omitempty"`
 	PublicIP   string    `json:"publicIp,omitempty" yaml:"publicIp,omitempty"`
 	LaunchTime time.Time `json:"launchTime" yaml:"launchTime"`
+
+	// Actual instance type, refreshed from the cloud on every reconcile
+	InstanceType string `json:"instanceType,omitempty" yaml:"instanceType,omitempty"`
+
+	// Availability zone the instance runs in
+	AvailabilityZone string `json:"availabilityZone,omitempty" yaml:"availabilityZone,omitempty"`
+
+	// Region the instance runs in, so instance calls need not look it up
+	Region string `json:"region,omitempty" yaml:"region,omitempty"`
 	
 	// K3s installation status
 	K3sInstalled       bool      `json:"k3sInstalled" yaml:"k3sInstalled"`
@@ -141,24 +341,41 @@ type StepProgress struct {
 
 // CheckProgress tracks individual checks within a step
 type CheckProgress struct {
-	Name         string     `json:"name"`         // e.g., "Create security group", "Wait for instances", "Extract K3s token"
-	Description  string     `json:"description,omitempty"` // Human-readable description
-	Status       string     `json:"status"`       // "Pending", "InProgress", "Done", "Failed", "Skipped"
-	StartTime    *time.Time `json:"startTime,omitempty"`
-	EndTime      *time.Time `json:"endTime,omitempty"`
-	ErrorMessage string     `json:"errorMessage,omitempty"`
-	Details      string     `json:"details,omitempty"`     // Additional context (e.g., instance IDs, token status)
-	FailureCount int        `json:"failureCount,omitempty"` // Number of times this check has failed
-	RetryAfter   *time.Time `json:"retryAfter,omitempty"`   // When to retry this check after failure
+	Name         string                `json:"name"`                  // e.g., "Create security group", "Wait for instances", "Extract K3s token"
+	Description  string                `json:"description,omitempty"` // Human-readable description
+	Status       string                `json:"status"`                // "Pending", "InProgress", "Done", "Failed", "Skipped"
+	StartTime    *time.Time            `json:"startTime,omitempty"`
+	EndTime      *time.Time            `json:"endTime,omitempty"`
+	ErrorMessage string                `json:"errorMessage,omitempty"`
+	Details      string                `json:"details,omitempty"`      // Additional context (e.g., instance IDs, token status)
+	FailureCount int                   `json:"failureCount,omitempty"` // Number of times this check has failed
+	RetryAfter   *time.Time            `json:"retryAfter,omitempty"`   // When to retry this check after failure
+	LastError    *OperationErrorRecord `json:"lastError,omitempty"`    // Cloud API failure behind the last error, if any
 }
 
+// OperationErrorRecord records a failed cloud API call so it can be
+// correlated with the provider's audit trail (e.g., CloudTrail)
+type OperationErrorRecord struct {
+	Time      time.Time `json:"time" yaml:"time"`
+	Phase     string    `json:"phase,omitempty" yaml:"phase,omitempty"`         // Cluster phase when the error occurred
+	Service   string    `json:"service" yaml:"service"`                         // e.g., "ec2", "ssm"
+	Operation string    `json:"operation" yaml:"operation"`                     // e.g., "RunInstances"
+	Code      string    `json:"code,omitempty" yaml:"code,omitempty"`           // Provider error code
+	RequestID string    `json:"requestId,omitempty" yaml:"requestId,omitempty"` // Provider request ID
+	Class     string    `json:"class,omitempty" yaml:"class,omitempty"`         // Throttling, NotFound, Permission, ...
+	Message   string    `json:"message,omitempty" yaml:"message,omitempty"`
+}
+
+// MaxRecentErrors bounds the number of operation errors kept in status
+const MaxRecentErrors = 10
+
 // Condition represents a condition of a resource
 type Condition struct {
-	Type               string    `json:"type"`
-	Status             string    `json:"status"` // True, False, Unknown
-	LastTransitionTime time.Time `json:"lastTransitionTime"`
-	Reason             string    `json:"reason,omitempty"`
-	Message            string    `json:"message,omitempty"`
+	Type               string    `json:"type" yaml:"type"`
+	Status             string    `json:"status" yaml:"status"` // True, False, Unknown
+	LastTransitionTime time.Time `json:"lastTransitionTime" yaml:"lastTransitionTime"`
+	Reason             string    `json:"reason,omitempty" yaml:"reason,omitempty"`
+	Message            string    `json:"message,omitempty" yaml:"message,omitempty"`
 }
 
 // PendingOperations tracks long-running operations that don't block reconciliation
@@ -258,6 +475,11 @@ func (p *PendingOperations) RemoveBackgroundProcess(key string) {
 	delete(p.BackgroundProcesses, key)
 }
 
+// IsEmpty reports whether nothing is pending
+func (p *PendingOperations) IsEmpty() bool {
+	return p == nil || (len(p.Commands) == 0 && len(p.InstanceStateChanges) == 0 && len(p.BackgroundProcesses) == 0)
+}
+
 // IsTimedOut checks if a background process has timed out
 func (bp *BackgroundProcess) IsTimedOut() bool {
 	if bp == nil {
@@ -700,6 +922,33 @@ func (r *ClusterResource) FailCheckWithRetry(stepName, checkName, errorMsg strin
 	}
 }
 
+// RecordOperationError appends an operation error to the cluster's recent
+// errors and attaches it to the check currently in progress, if any
+func (r *ClusterResource) RecordOperationError(record OperationErrorRecord) {
+	r.Status.RecentErrors = append(r.Status.RecentErrors, record)
+	if len(r.Status.RecentErrors) > MaxRecentErrors {
+		r.Status.RecentErrors = r.Status.RecentErrors[len(r.Status.RecentErrors)-MaxRecentErrors:]
+	}
+
+	if r.Status.ProgressMetrics == nil {
+		return
+	}
+
+	for i := range r.Status.ProgressMetrics.Steps {
+		step := &r.Status.ProgressMetrics.Steps[i]
+		if step.Status != "InProgress" {
+			continue
+		}
+		for j := range step.Checks {
+			if step.Checks[j].Status == "InProgress" || step.Checks[j].Status == "Failed" {
+				rec := record
+				step.Checks[j].LastError = &rec
+				return
+			}
+		}
+	}
+}
+
 // HasPermanentFailures checks if any checks in a step have permanently failed (3+ failures)
 func (r *ClusterResource) HasPermanentFailures(stepName string) bool {
 	if r.Status.ProgressMetrics == nil {
@@ -791,8 +1040,23 @@ const (
 	ConditionProgressing = "Progressing"
 	ConditionDegraded    = "Degraded"
 	ConditionAvailable   = "Available"
+
+	// Reconciles keep failing on cloud throttling or service errors
+	ConditionCloudProviderDegraded = "CloudProviderDegraded"
+
+	// Instances or security groups were changed outside goman
+	ConditionDrifted = "Drifted"
 )
 
+// Reasons of the CloudProviderDegraded condition
+const (
+	ReasonCloudServiceErrors = "ServiceErrors" // Only the errors point at the provider
+	ReasonCloudHealthEvent   = "HealthEvent"   // The provider reports an open issue too
+)
+
+// ReasonChangedOutsideGoman is the reason of the Drifted condition
+const ReasonChangedOutsideGoman = "ChangedOutsideGoman"
+
 // ReconcileResult represents the result of a reconciliation
 type ReconcileResult struct {
 	Requeue      bool          // Should reconcile again
@@ -809,11 +1073,12 @@ const (
 
 // Event represents a cluster event
 type Event struct {
-	Type      EventType `json:"type"`
-	Reason    string    `json:"reason"`
-	Message   string    `json:"message"`
-	Timestamp time.Time `json:"timestamp"`
-	Source    string    `json:"source"`
+	Type      EventType `json:"type" yaml:"type"`
+	Reason    string    `json:"reason" yaml:"reason"`
+	Object    string    `json:"object,omitempty" yaml:"object,omitempty"` // Instance or node the event is about; empty for the cluster
+	Message   string    `json:"message" yaml:"message"`
+	Timestamp time.Time `json:"timestamp" yaml:"timestamp"`
+	Source    string    `json:"source" yaml:"source"`
 }
 
 // AddPendingCommand adds a pending command to track
@@ -841,8 +1106,7 @@ func (r *ClusterResource) RemovePendingCommand(commandID string) {
 		delete(r.Status.PendingOperations.Commands, commandID)
 		
 		// Clean up empty maps
-		if len(r.Status.PendingOperations.Commands) == 0 && 
-		   len(r.Status.PendingOperations.InstanceStateChanges) == 0 {
+		if r.Status.PendingOperations.IsEmpty() {
 			r.Status.PendingOperations = nil
 		}
 	}
@@ -874,8 +1138,7 @@ func (r *ClusterResource) RemovePendingInstanceStateChange(instanceID string) {
 		delete(r.Status.PendingOperations.InstanceStateChanges, instanceID)
 		
 		// Clean up empty maps
-		if len(r.Status.PendingOperations.Commands) == 0 && 
-		   len(r.Status.PendingOperations.InstanceStateChanges) == 0 {
+		if r.Status.PendingOperations.IsEmpty() {
 			r.Status.PendingOperations = nil
 		}
 	}
@@ -0,0 +1,72 @@
+package models
+
+import "time"
+
+// RolloutPhase is the state of a node pool rollout
+type RolloutPhase string
+
+const (
+	RolloutInProgress RolloutPhase = "InProgress"
+	RolloutPaused     RolloutPhase = "Paused"
+	RolloutCompleted  RolloutPhase = "Completed"
+)
+
+// RolloutSpec controls how the controller rolls out node pool changes that
+// need new instances, such as an instance type change. Kept in the spec so
+// a pause survives controller restarts.
+type RolloutSpec struct {
+	BatchSize int        `json:"batchSize,omitempty" yaml:"batchSize,omitempty"` // Nodes replaced at once, 1 if unset
+	Paused    bool       `json:"paused,omitempty" yaml:"paused,omitempty"`       // Stop before the next batch
+	ResumedAt *time.Time `json:"resumedAt,omitempty" yaml:"resumedAt,omitempty"` // Last resume, continues a halted rollout
+}
+
+// RolloutBatchSize returns the configured batch size, at least 1
+func (s *RolloutSpec) RolloutBatchSize() int {
+	if s == nil || s.BatchSize < 1 {
+		return 1
+	}
+	return s.BatchSize
+}
+
+// IsPaused reports whether a pause was requested
+func (s *RolloutSpec) IsPaused() bool {
+	return s != nil && s.Paused
+}
+
+// RolloutStatus tracks a rollout of one node pool. Nodes are replaced in
+// batches; pausing takes effect once the current batch has finished.
+type RolloutStatus struct {
+	Pool             string       `json:"pool" yaml:"pool"`
+	FromInstanceType string       `json:"fromInstanceType,omitempty" yaml:"fromInstanceType,omitempty"`
+	ToInstanceType   string       `json:"toInstanceType" yaml:"toInstanceType"`
+	Phase            RolloutPhase `json:"phase" yaml:"phase"`
+	BatchSize        int          `json:"batchSize" yaml:"batchSize"`
+	BatchIndex       int          `json:"batchIndex" yaml:"batchIndex"` // Batches started so far
+	BatchCount       int          `json:"batchCount" yaml:"batchCount"` // Expected number of batches
+	Replaced         int          `json:"replaced" yaml:"replaced"`
+	Failed           int          `json:"failed,omitempty" yaml:"failed,omitempty"`
+
+	// Replacements of the current batch, empty between batches
+	Batch []NodeReplacementStatus `json:"batch,omitempty" yaml:"batch,omitempty"`
+
+	Message     string     `json:"message,omitempty" yaml:"message,omitempty"`
+	StartedAt   time.Time  `json:"startedAt" yaml:"startedAt"`
+	UpdatedAt   time.Time  `json:"updatedAt" yaml:"updatedAt"`
+	HaltedAt    *time.Time `json:"haltedAt,omitempty" yaml:"haltedAt,omitempty"` // Set when a failed batch stopped the rollout
+	CompletedAt *time.Time `json:"completedAt,omitempty" yaml:"completedAt,omitempty"`
+}
+
+// Active reports whether the rollout has nodes left to replace
+func (s *RolloutStatus) Active() bool {
+	return s != nil && s.Phase != RolloutCompleted
+}
+
+// BatchInProgress reports whether replacements of the current batch are still running
+func (s *RolloutStatus) BatchInProgress() bool {
+	for _, st := range s.Batch {
+		if !st.Done() {
+			return true
+		}
+	}
+	return false
+}
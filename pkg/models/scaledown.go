@@ -0,0 +1,24 @@
+package models
+
+import "fmt"
+
+// What happens to the workers removed when a node pool scales down
+const (
+	ScaleDownTerminate = "terminate" // Drain and terminate them (default)
+	ScaleDownStop      = "stop"      // Drain and stop them, to be started again on the next scale-up
+)
+
+// StopsOnScaleDown reports whether a pool keeps its scaled-down workers
+// stopped for reuse
+func (p NodePool) StopsOnScaleDown() bool {
+	return p.ScaleDownBehavior == ScaleDownStop
+}
+
+// ValidateScaleDownBehavior checks the scale-down behavior of a node pool
+func ValidateScaleDownBehavior(pool, behavior string) error {
+	switch behavior {
+	case "", ScaleDownTerminate, ScaleDownStop:
+		return nil
+	}
+	return fmt.Errorf("node pool %s: scaleDownBehavior must be %s or %s, got %q", pool, ScaleDownStop, ScaleDownTerminate, behavior)
+}
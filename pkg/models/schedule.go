@@ -0,0 +1,277 @@
+package models
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ScheduleSpec stops and starts a cluster at fixed times, e.g. nightly
+// for dev clusters. Times are five-field cron expressions (minute hour
+// day-of-month month day-of-week) in the schedule's time zone.
+type ScheduleSpec struct {
+	Stop     string `json:"stop,omitempty" yaml:"stop,omitempty"`         // e.g. "0 20 * * *" stops at 8pm
+	Start    string `json:"start,omitempty" yaml:"start,omitempty"`       // e.g. "0 8 * * 1-5" starts at 8am on weekdays
+	Timezone string `json:"timezone,omitempty" yaml:"timezone,omitempty"` // IANA name, UTC if empty
+}
+
+// ScheduleStatus records the scheduled actions the controller applied. The
+// desired state of the last one holds until the next, unless the cluster
+// was stopped or started by hand after it.
+type ScheduleStatus struct {
+	// Scheduled actions up to this time were applied
+	CheckedAt time.Time `json:"checkedAt" yaml:"checkedAt"`
+
+	LastAction   string     `json:"lastAction,omitempty" yaml:"lastAction,omitempty"` // "running" or "stopped"
+	LastActionAt *time.Time `json:"lastActionAt,omitempty" yaml:"lastActionAt,omitempty"`
+	NextAction   string     `json:"nextAction,omitempty" yaml:"nextAction,omitempty"`
+	NextActionAt *time.Time `json:"nextActionAt,omitempty" yaml:"nextActionAt,omitempty"`
+}
+
+// maxScheduleCatchUp bounds how far back missed scheduled actions are
+// looked for; only the latest one is applied anyway
+const maxScheduleCatchUp = 7 * 24 * time.Hour
+
+// Enabled reports whether the schedule stops or starts the cluster
+func (s *ScheduleSpec) Enabled() bool {
+	return s != nil && (s.Stop != "" || s.Start != "")
+}
+
+// Key summarizes the schedule for change tracking
+func (s *ScheduleSpec) Key() string {
+	if !s.Enabled() {
+		return ""
+	}
+	tz := s.Timezone
+	if tz == "" {
+		tz = "UTC"
+	}
+	return fmt.Sprintf("stop=%q start=%q tz=%s", s.Stop, s.Start, tz)
+}
+
+// ValidateSchedule checks the cron expressions and time zone of a schedule
+func ValidateSchedule(s *ScheduleSpec) error {
+	if s == nil {
+		return nil
+	}
+	if _, err := time.LoadLocation(s.Timezone); err != nil {
+		return fmt.Errorf("schedule: unknown timezone %q", s.Timezone)
+	}
+	for _, expr := range []struct{ name, value string }{{"stop", s.Stop}, {"start", s.Start}} {
+		if expr.value == "" {
+			continue
+		}
+		if _, err := ParseCron(expr.value); err != nil {
+			return fmt.Errorf("schedule %s: %w", expr.name, err)
+		}
+	}
+	if s.Stop != "" && s.Stop == s.Start {
+		return fmt.Errorf("schedule: stop and start are the same time")
+	}
+	return nil
+}
+
+// LastAction returns the desired state set by the latest scheduled action
+// in (from, to], and its time; "" if none falls in it. When a stop and a
+// start fall at once, the start wins.
+func (s *ScheduleSpec) LastAction(from, to time.Time) (string, time.Time) {
+	if !s.Enabled() || !to.After(from) {
+		return "", time.Time{}
+	}
+	if to.Sub(from) > maxScheduleCatchUp {
+		from = to.Add(-maxScheduleCatchUp)
+	}
+	var action string
+	var at time.Time
+	for _, e := range s.entries() {
+		for t := e.cron.Next(from.In(e.loc)); !t.IsZero() && !t.After(to); t = e.cron.Next(t) {
+			if t.After(at) || (t.Equal(at) && e.state == "running") {
+				action, at = e.state, t
+			}
+		}
+	}
+	return action, at
+}
+
+// NextAction returns the desired state set by the first scheduled action
+// after a time, and its time; "" if there is none
+func (s *ScheduleSpec) NextAction(after time.Time) (string, time.Time) {
+	if !s.Enabled() {
+		return "", time.Time{}
+	}
+	var action string
+	var at time.Time
+	for _, e := range s.entries() {
+		t := e.cron.Next(after.In(e.loc))
+		if t.IsZero() {
+			continue
+		}
+		if at.IsZero() || t.Before(at) || (t.Equal(at) && e.state == "running") {
+			action, at = e.state, t
+		}
+	}
+	return action, at
+}
+
+type scheduleEntry struct {
+	state string
+	cron  *CronExpr
+	loc   *time.Location
+}
+
+// entries parses the schedule, skipping invalid expressions; specs are
+// validated before they reach the controller
+func (s *ScheduleSpec) entries() []scheduleEntry {
+	loc, err := time.LoadLocation(s.Timezone)
+	if err != nil {
+		loc = time.UTC
+	}
+	var entries []scheduleEntry
+	for _, e := range []struct{ state, expr string }{{"stopped", s.Stop}, {"running", s.Start}} {
+		if e.expr == "" {
+			continue
+		}
+		if cron, err := ParseCron(e.expr); err == nil {
+			entries = append(entries, scheduleEntry{state: e.state, cron: cron, loc: loc})
+		}
+	}
+	return entries
+}
+
+// CronExpr is a parsed five-field cron expression
+type CronExpr struct {
+	minute, hour, dom, month, dow uint64 // Bit sets of the allowed values
+	domAny, dowAny                bool   // Day fields given as *
+}
+
+var (
+	monthNames = []string{"", "jan", "feb", "mar", "apr", "may", "jun", "jul", "aug", "sep", "oct", "nov", "dec"}
+	dayNames   = []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}
+)
+
+// ParseCron parses a cron expression of five fields: minute (0-59), hour
+// (0-23), day of month (1-31), month (1-12 or JAN-DEC) and day of week
+// (0-7 or SUN-SAT, 0 and 7 being Sunday). Fields accept *, lists, ranges
+// and steps such as */15 or 1-5.
+func ParseCron(expr string) (*CronExpr, error) {
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("cron expression %q must have 5 fields (minute hour day-of-month month day-of-week)", expr)
+	}
+	c := &CronExpr{}
+	var err error
+	if c.minute, err = parseCronField(fields[0], "minute", 0, 59, nil); err != nil {
+		return nil, err
+	}
+	if c.hour, err = parseCronField(fields[1], "hour", 0, 23, nil); err != nil {
+		return nil, err
+	}
+	if c.dom, err = parseCronField(fields[2], "day of month", 1, 31, nil); err != nil {
+		return nil, err
+	}
+	if c.month, err = parseCronField(fields[3], "month", 1, 12, monthNames); err != nil {
+		return nil, err
+	}
+	if c.dow, err = parseCronField(fields[4], "day of week", 0, 7, dayNames); err != nil {
+		return nil, err
+	}
+	if c.dow&(1<<7) != 0 {
+		c.dow |= 1
+	}
+	c.domAny = fields[2] == "*"
+	c.dowAny = fields[4] == "*"
+	return c, nil
+}
+
+// parseCronField returns the bit set of the values a field allows
+func parseCronField(field, name string, min, max int, names []string) (uint64, error) {
+	value := func(s string) (int, error) {
+		for i, n := range names {
+			if n != "" && strings.EqualFold(s, n) {
+				return i, nil
+			}
+		}
+		v, err := strconv.Atoi(s)
+		if err != nil || v < min || v > max {
+			return 0, fmt.Errorf("invalid %s %q: must be %d-%d", name, s, min, max)
+		}
+		return v, nil
+	}
+
+	var bits uint64
+	for _, part := range strings.Split(field, ",") {
+		rangePart, stepPart, hasStep := strings.Cut(part, "/")
+		step := 1
+		if hasStep {
+			s, err := strconv.Atoi(stepPart)
+			if err != nil || s < 1 {
+				return 0, fmt.Errorf("invalid %s step %q", name, stepPart)
+			}
+			step = s
+		}
+		lo, hi := min, max
+		switch {
+		case rangePart == "*":
+		case strings.Contains(rangePart, "-"):
+			a, b, _ := strings.Cut(rangePart, "-")
+			var err error
+			if lo, err = value(a); err != nil {
+				return 0, err
+			}
+			if hi, err = value(b); err != nil {
+				return 0, err
+			}
+			if lo > hi {
+				return 0, fmt.Errorf("invalid %s range %q", name, rangePart)
+			}
+		default:
+			v, err := value(rangePart)
+			if err != nil {
+				return 0, err
+			}
+			lo = v
+			if !hasStep {
+				hi = v
+			}
+		}
+		for v := lo; v <= hi; v += step {
+			bits |= 1 << uint(v)
+		}
+	}
+	return bits, nil
+}
+
+// Next returns the first time after t matching the expression, in t's
+// location, or the zero time if none comes within five years
+func (c *CronExpr) Next(t time.Time) time.Time {
+	loc := t.Location()
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	limit := t.AddDate(5, 0, 0)
+	for t.Before(limit) {
+		switch {
+		case c.month&(1<<uint(t.Month())) == 0:
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, loc)
+		case !c.dayMatches(t):
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, loc)
+		case c.hour&(1<<uint(t.Hour())) == 0:
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, loc)
+		case c.minute&(1<<uint(t.Minute())) == 0:
+			t = t.Add(time.Minute)
+		default:
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// dayMatches follows cron: when both day fields are restricted, a day
+// matching either of them matches
+func (c *CronExpr) dayMatches(t time.Time) bool {
+	dom := c.dom&(1<<uint(t.Day())) != 0
+	dow := c.dow&(1<<uint(t.Weekday())) != 0
+	if c.domAny || c.dowAny {
+		return dom && dow
+	}
+	return dom || dow
+}
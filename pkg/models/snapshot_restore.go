@@ -0,0 +1,61 @@
+package models
+
+import (
+	"fmt"
+	"time"
+)
+
+// SnapshotRestorePhase is the step a snapshot restore is in
+type SnapshotRestorePhase string
+
+const (
+	SnapshotRestoreSaving    SnapshotRestorePhase = "Saving"
+	SnapshotRestoreRestoring SnapshotRestorePhase = "Restoring"
+	SnapshotRestoreCompleted SnapshotRestorePhase = "Completed"
+	SnapshotRestoreFailed    SnapshotRestorePhase = "Failed"
+)
+
+// SnapshotRestoreRequest asks the controller to restore a new cluster from
+// an etcd snapshot of another one, the source, before its workers join. The
+// cluster is bootstrapped with the source's K3s token, which the snapshot
+// can only be restored with. Set at creation, e.g. for a blue/green twin.
+type SnapshotRestoreRequest struct {
+	Source      string    `json:"source" yaml:"source"` // Name of the cluster the snapshot is taken of
+	RequestedAt time.Time `json:"requestedAt" yaml:"requestedAt"`
+}
+
+// SnapshotRestoreStatus tracks the progress of a snapshot restore
+type SnapshotRestoreStatus struct {
+	Source      string               `json:"source" yaml:"source"`
+	Phase       SnapshotRestorePhase `json:"phase" yaml:"phase"`
+	Snapshot    string               `json:"snapshot,omitempty" yaml:"snapshot,omitempty"` // Name of the snapshot taken on the source
+	Message     string               `json:"message,omitempty" yaml:"message,omitempty"`
+	StartedAt   time.Time            `json:"startedAt" yaml:"startedAt"`
+	CompletedAt *time.Time           `json:"completedAt,omitempty" yaml:"completedAt,omitempty"`
+}
+
+// Done reports whether the restore has finished, successfully or not
+func (s *SnapshotRestoreStatus) Done() bool {
+	return s.Phase == SnapshotRestoreCompleted || s.Phase == SnapshotRestoreFailed
+}
+
+func (s *SnapshotRestoreStatus) String() string {
+	switch s.Phase {
+	case SnapshotRestoreCompleted:
+		return fmt.Sprintf("restored from snapshot %s of %s", s.Snapshot, s.Source)
+	case SnapshotRestoreFailed:
+		return "failed: " + s.Message
+	case SnapshotRestoreSaving:
+		msg := fmt.Sprintf("taking a snapshot of %s", s.Source)
+		if s.Message != "" {
+			msg += " (" + s.Message + ")"
+		}
+		return msg
+	default:
+		msg := fmt.Sprintf("restoring snapshot %s of %s", s.Snapshot, s.Source)
+		if s.Message != "" {
+			msg += " (" + s.Message + ")"
+		}
+		return msg
+	}
+}
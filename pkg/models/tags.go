@@ -0,0 +1,103 @@
+package models
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// MaxResourceTags is the number of user tags a cluster can put on its cloud
+// resources; AWS allows 50 per resource and goman uses up to about 15
+const MaxResourceTags = 30
+
+// reservedTagKeys and reservedTagPrefixes are tags goman manages itself
+var (
+	reservedTagKeys     = []string{"Name", "ManagedBy", "Cluster"}
+	reservedTagPrefixes = []string{"goman-", "k8s-", "aws:"}
+)
+
+// TagSyncStatus records the user tags last applied to a cluster's resources
+type TagSyncStatus struct {
+	Hash      string     `json:"hash" yaml:"hash"`
+	Keys      []string   `json:"keys,omitempty" yaml:"keys,omitempty"` // Applied keys, to remove ones dropped from the spec
+	SyncedAt  *time.Time `json:"syncedAt,omitempty" yaml:"syncedAt,omitempty"`
+	Resources int        `json:"resources" yaml:"resources"` // Resources checked
+	Updated   int        `json:"updated" yaml:"updated"`     // Resources whose tags were changed
+}
+
+// ParseResourceTags converts the stored key=value list into a map. Entries
+// without "=" are tags with an empty value.
+func ParseResourceTags(tags []string) map[string]string {
+	if len(tags) == 0 {
+		return nil
+	}
+	parsed := make(map[string]string, len(tags))
+	for _, tag := range tags {
+		key, value, _ := strings.Cut(tag, "=")
+		parsed[strings.TrimSpace(key)] = strings.TrimSpace(value)
+	}
+	return parsed
+}
+
+// FormatResourceTags converts a tag map into the stored key=value list,
+// sorted by key
+func FormatResourceTags(tags map[string]string) []string {
+	if len(tags) == 0 {
+		return nil
+	}
+	formatted := make([]string, 0, len(tags))
+	for _, key := range SortedTagKeys(tags) {
+		formatted = append(formatted, key+"="+tags[key])
+	}
+	return formatted
+}
+
+// SortedTagKeys returns the keys of a tag map in order
+func SortedTagKeys(tags map[string]string) []string {
+	keys := make([]string, 0, len(tags))
+	for key := range tags {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// ValidateResourceTags checks user tags against the AWS limits and the
+// tags goman manages itself
+func ValidateResourceTags(tags map[string]string) error {
+	if len(tags) > MaxResourceTags {
+		return fmt.Errorf("at most %d tags are supported", MaxResourceTags)
+	}
+	for key, value := range tags {
+		if key == "" || len(key) > 128 {
+			return fmt.Errorf("tag key %q must have 1 to 128 characters", key)
+		}
+		if len(value) > 256 {
+			return fmt.Errorf("value of tag %s must have at most 256 characters", key)
+		}
+		if contains(reservedTagKeys, key) {
+			return fmt.Errorf("tag %s is managed by goman", key)
+		}
+		for _, prefix := range reservedTagPrefixes {
+			if strings.HasPrefix(key, prefix) {
+				return fmt.Errorf("tag %s is managed by goman (prefix %s)", key, prefix)
+			}
+		}
+	}
+	return nil
+}
+
+// ResourceTagsHash identifies a set of user tags. Empty for no tags.
+func ResourceTagsHash(tags map[string]string) string {
+	if len(tags) == 0 {
+		return ""
+	}
+	// Map keys are marshaled in sorted order
+	data, _ := json.Marshal(tags)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:8])
+}
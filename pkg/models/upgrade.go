@@ -0,0 +1,135 @@
+package models
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"time"
+)
+
+// DefaultK3sVersion is installed on clusters that do not set a K3s version,
+// and is assumed for nodes launched before versions were tagged
+const DefaultK3sVersion = "v1.31.4+k3s1"
+
+var k3sVersionPattern = regexp.MustCompile(`^v(\d+)\.(\d+)\.(\d+)\+k3s(\d+)$`)
+
+// K3sVersion is a parsed K3s release such as v1.31.4+k3s1
+type K3sVersion struct {
+	Major, Minor, Patch, Build int
+}
+
+// ParseK3sVersion parses a K3s release name
+func ParseK3sVersion(s string) (K3sVersion, error) {
+	m := k3sVersionPattern.FindStringSubmatch(s)
+	if m == nil {
+		return K3sVersion{}, fmt.Errorf("invalid K3s version %q (expected a release such as %s)", s, DefaultK3sVersion)
+	}
+	var v K3sVersion
+	for i, p := range []*int{&v.Major, &v.Minor, &v.Patch, &v.Build} {
+		n, err := strconv.Atoi(m[i+1])
+		if err != nil {
+			return K3sVersion{}, fmt.Errorf("invalid K3s version %q: %w", s, err)
+		}
+		*p = n
+	}
+	return v, nil
+}
+
+// Compare returns -1, 0 or 1 as v is older than, equal to or newer than o
+func (v K3sVersion) Compare(o K3sVersion) int {
+	for _, d := range []int{v.Major - o.Major, v.Minor - o.Minor, v.Patch - o.Patch, v.Build - o.Build} {
+		if d < 0 {
+			return -1
+		}
+		if d > 0 {
+			return 1
+		}
+	}
+	return 0
+}
+
+// ValidateK3sVersion checks the K3s version of a cluster spec; empty keeps
+// the default
+func ValidateK3sVersion(s string) error {
+	if s == "" {
+		return nil
+	}
+	_, err := ParseK3sVersion(s)
+	return err
+}
+
+// CheckK3sUpgrade reports whether nodes on one K3s version can be moved to
+// another. Kubernetes supports upgrading one minor version at a time and
+// does not support downgrades.
+func CheckK3sUpgrade(from, to string) error {
+	f, err := ParseK3sVersion(from)
+	if err != nil {
+		return err
+	}
+	t, err := ParseK3sVersion(to)
+	if err != nil {
+		return err
+	}
+	if t.Compare(f) < 0 {
+		return fmt.Errorf("downgrading K3s from %s to %s is not supported", from, to)
+	}
+	if t.Major != f.Major || t.Minor > f.Minor+1 {
+		return fmt.Errorf("upgrading K3s from %s to %s skips a minor version; upgrade to v%d.%d first", from, to, f.Major, f.Minor+1)
+	}
+	return nil
+}
+
+// TargetK3sVersion returns the K3s version the cluster's nodes should run
+func (s ClusterSpec) TargetK3sVersion() string {
+	if s.K3sVersion != "" {
+		return s.K3sVersion
+	}
+	return DefaultK3sVersion
+}
+
+// UpgradePhase is the phase of a K3s version upgrade
+type UpgradePhase string
+
+const (
+	UpgradeInProgress UpgradePhase = "InProgress"
+	UpgradePaused     UpgradePhase = "Paused"
+	UpgradeRefused    UpgradePhase = "Refused" // The version change is not supported
+	UpgradeCompleted  UpgradePhase = "Completed"
+)
+
+// UpgradeNodePhase is the step of the node being upgraded
+type UpgradeNodePhase string
+
+const (
+	UpgradeNodeDraining  UpgradeNodePhase = "Draining"
+	UpgradeNodeUpgrading UpgradeNodePhase = "Upgrading"
+	UpgradeNodeVerifying UpgradeNodePhase = "Verifying"
+)
+
+// UpgradeStatus tracks a K3s version upgrade. Masters are upgraded one at a
+// time, then workers pool by pool; each node is drained, upgraded in place
+// and must report the new version and be Ready before the next one starts.
+type UpgradeStatus struct {
+	FromVersion string       `json:"fromVersion,omitempty" yaml:"fromVersion,omitempty"`
+	ToVersion   string       `json:"toVersion" yaml:"toVersion"`
+	Phase       UpgradePhase `json:"phase" yaml:"phase"`
+	Upgraded    int          `json:"upgraded" yaml:"upgraded"`
+	Total       int          `json:"total" yaml:"total"` // Nodes to upgrade when the upgrade started
+
+	// Node being upgraded, empty between nodes
+	Node          string           `json:"node,omitempty" yaml:"node,omitempty"` // Instance ID
+	NodeName      string           `json:"nodeName,omitempty" yaml:"nodeName,omitempty"`
+	NodePhase     UpgradeNodePhase `json:"nodePhase,omitempty" yaml:"nodePhase,omitempty"`
+	NodeStartedAt *time.Time       `json:"nodeStartedAt,omitempty" yaml:"nodeStartedAt,omitempty"`
+
+	Message     string     `json:"message,omitempty" yaml:"message,omitempty"`
+	StartedAt   time.Time  `json:"startedAt" yaml:"startedAt"`
+	UpdatedAt   time.Time  `json:"updatedAt" yaml:"updatedAt"`
+	HaltedAt    *time.Time `json:"haltedAt,omitempty" yaml:"haltedAt,omitempty"` // Set when a failed node stopped the upgrade
+	CompletedAt *time.Time `json:"completedAt,omitempty" yaml:"completedAt,omitempty"`
+}
+
+// Active reports whether the upgrade has nodes left to upgrade
+func (s *UpgradeStatus) Active() bool {
+	return s != nil && s.Phase != UpgradeCompleted && s.Phase != UpgradeRefused
+}
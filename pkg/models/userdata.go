@@ -0,0 +1,56 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"gopkg.in/yaml.v3"
+)
+
+// MaxUserDataSnippet bounds the user data of a node pool, leaving room for
+// goman's bootstrap within the 16 KiB EC2 limit
+const MaxUserDataSnippet = 8 * 1024
+
+// Kinds of user data snippets, told apart by their first line
+const (
+	UserDataShell       = "shell"        // Script starting with #!, run after the bootstrap
+	UserDataCloudConfig = "cloud-config" // #cloud-config document, merged into cloud-init's
+)
+
+// UserDataKind returns the kind of a user data snippet, or "" if it is
+// neither a script nor a cloud-config document
+func UserDataKind(snippet string) string {
+	firstLine, _, _ := strings.Cut(snippet, "\n")
+	switch {
+	case strings.HasPrefix(firstLine, "#!"):
+		return UserDataShell
+	case strings.TrimSpace(firstLine) == "#cloud-config":
+		return UserDataCloudConfig
+	}
+	return ""
+}
+
+// ValidateUserData checks the user data snippet of a node pool
+func ValidateUserData(pool, snippet string) error {
+	if snippet == "" {
+		return nil
+	}
+	if len(snippet) > MaxUserDataSnippet {
+		return fmt.Errorf("node pool %s: userData is %d bytes, the limit is %d", pool, len(snippet), MaxUserDataSnippet)
+	}
+	if !utf8.ValidString(snippet) || strings.ContainsRune(snippet, 0) {
+		return fmt.Errorf("node pool %s: userData must be text", pool)
+	}
+	switch UserDataKind(snippet) {
+	case UserDataShell:
+		return nil
+	case UserDataCloudConfig:
+		var doc map[string]interface{}
+		if err := yaml.Unmarshal([]byte(snippet), &doc); err != nil {
+			return fmt.Errorf("node pool %s: userData is not a valid cloud-config document: %v", pool, err)
+		}
+		return nil
+	}
+	return fmt.Errorf("node pool %s: userData must start with #! (a script) or #cloud-config", pool)
+}
@@ -0,0 +1,68 @@
+package models
+
+import (
+	"strings"
+	"time"
+)
+
+// Smoke tests run by 'goman cluster verify', in the order they run
+const (
+	VerifyTestNodes      = "nodes"       // Every node registered and Ready
+	VerifyTestDeployment = "deployment"  // An nginx deployment rolls out
+	VerifyTestService    = "service"     // The deployment answers on its ClusterIP service
+	VerifyTestDNS        = "dns"         // Cluster DNS resolves the service name
+	VerifyTestPodNetwork = "pod-network" // A pod reaches a pod on another node
+	VerifyTestVolume     = "volume"      // A claim on the default storage class binds
+)
+
+// VerifyTests lists the smoke tests
+var VerifyTests = []string{VerifyTestNodes, VerifyTestDeployment, VerifyTestService, VerifyTestDNS, VerifyTestPodNetwork, VerifyTestVolume}
+
+// Results of a smoke test
+const (
+	VerifyPass = "pass"
+	VerifyFail = "fail"
+	VerifySkip = "skip" // Does not apply, e.g. pod-network on a single node
+)
+
+// VerifyTestResult is the outcome of one smoke test
+type VerifyTestResult struct {
+	Name   string `json:"name" yaml:"name"`
+	Result string `json:"result" yaml:"result"`
+	Detail string `json:"detail,omitempty" yaml:"detail,omitempty"`
+}
+
+// VerificationStatus records the last run of the smoke tests
+type VerificationStatus struct {
+	StartedAt time.Time          `json:"startedAt" yaml:"startedAt"`
+	Duration  time.Duration      `json:"duration" yaml:"duration"`
+	Tests     []VerifyTestResult `json:"tests" yaml:"tests"`
+	Failures  int                `json:"failures,omitempty" yaml:"failures,omitempty"` // Failed runs in a row while gating Running
+}
+
+// Passed reports whether no smoke test failed
+func (s *VerificationStatus) Passed() bool {
+	return s != nil && len(s.Failed()) == 0
+}
+
+// Failed returns the names of the smoke tests that failed
+func (s *VerificationStatus) Failed() []string {
+	if s == nil {
+		return nil
+	}
+	var failed []string
+	for _, t := range s.Tests {
+		if t.Result == VerifyFail {
+			failed = append(failed, t.Name)
+		}
+	}
+	return failed
+}
+
+// Summary describes the failed tests, or that all passed
+func (s *VerificationStatus) Summary() string {
+	if failed := s.Failed(); len(failed) > 0 {
+		return "smoke tests failed: " + strings.Join(failed, ", ")
+	}
+	return "smoke tests passed"
+}
@@ -0,0 +1,60 @@
+package models
+
+import (
+	"fmt"
+	"net"
+	"time"
+)
+
+// VirtualIPSpec gives an HA cluster a floating private IP as its API
+// endpoint, an alternative to a load balancer for clients inside the VPC.
+// kube-vip on the masters elects the holder; the holder moves the IP to its
+// network interface through the EC2 API.
+type VirtualIPSpec struct {
+	// Private IPv4 address in the masters' subnet; one is allocated if empty
+	Address string `json:"address,omitempty" yaml:"address,omitempty"`
+}
+
+// VirtualIPStatus records the virtual IP and the masters serving it
+type VirtualIPStatus struct {
+	Address   string     `json:"address" yaml:"address"`
+	Nodes     []string   `json:"nodes,omitempty" yaml:"nodes,omitempty"` // Masters running kube-vip with Address
+	AppliedAt *time.Time `json:"appliedAt,omitempty" yaml:"appliedAt,omitempty"`
+	Message   string     `json:"message,omitempty" yaml:"message,omitempty"`
+}
+
+// HasNode reports whether a master is configured for the virtual IP
+func (s *VirtualIPStatus) HasNode(instanceID string) bool {
+	if s == nil {
+		return false
+	}
+	for _, id := range s.Nodes {
+		if id == instanceID {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidateVirtualIP checks the virtual IP spec of a cluster
+func ValidateVirtualIP(spec *VirtualIPSpec, mode string) error {
+	if spec == nil {
+		return nil
+	}
+	if mode != string(ModeHA) {
+		return fmt.Errorf("a virtual IP needs an HA cluster")
+	}
+	if spec.Address == "" {
+		return nil
+	}
+	ip := net.ParseIP(spec.Address)
+	if ip == nil || ip.To4() == nil || !ip.IsPrivate() {
+		return fmt.Errorf("virtual IP %q must be a private IPv4 address", spec.Address)
+	}
+	return nil
+}
+
+// APIEndpointURL returns the API server URL for a host
+func APIEndpointURL(host string) string {
+	return fmt.Sprintf("https://%s:6443", host)
+}
@@ -0,0 +1,180 @@
+package models
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+// MaxDataVolumes is the number of data volumes a node pool can attach
+const MaxDataVolumes = 8
+
+// Data volume types and filesystems
+const (
+	DefaultVolumeType       = "gp3"
+	DefaultVolumeFilesystem = "ext4"
+)
+
+// VolumeTypes lists the EBS volume types a data volume can use
+var VolumeTypes = []string{"gp3", "gp2", "io1", "io2", "st1", "sc1"}
+
+// VolumeFilesystems lists the filesystems a data volume can be formatted with
+var VolumeFilesystems = []string{"ext4", "xfs"}
+
+// RootVolumeTypes lists the EBS volume types a node can boot from
+var RootVolumeTypes = []string{"gp3", "gp2", "io1", "io2"}
+
+// Root volume limits in GiB
+const (
+	MinRootVolumeSize = 8
+	MaxRootVolumeSize = 16384
+)
+
+// RootVolume sizes the boot volume of nodes. Changes apply to nodes created
+// afterwards; existing nodes keep their volume.
+type RootVolume struct {
+	Size int    `json:"size,omitempty" yaml:"size,omitempty"` // Size in GiB, the image default (8) if 0
+	Type string `json:"type,omitempty" yaml:"type,omitempty"` // Volume type, gp3 if empty
+	IOPS int    `json:"iops,omitempty" yaml:"iops,omitempty"` // Provisioned IOPS (gp3, io1, io2)
+}
+
+// VolumeType returns the volume type, defaulting to gp3
+func (v RootVolume) VolumeType() string {
+	if v.Type == "" {
+		return DefaultVolumeType
+	}
+	return v.Type
+}
+
+// String summarizes a root volume, e.g. "100GiB io2 5000 IOPS"
+func (v *RootVolume) String() string {
+	if v == nil {
+		return "default"
+	}
+	s := v.VolumeType()
+	if v.Size > 0 {
+		s = fmt.Sprintf("%dGiB %s", v.Size, s)
+	}
+	if v.IOPS > 0 {
+		s += fmt.Sprintf(" %d IOPS", v.IOPS)
+	}
+	return s
+}
+
+// PoolRootVolume returns the root volume of a node pool's nodes: the pool's
+// own, or the cluster's
+func (s ClusterSpec) PoolRootVolume(pool NodePool) *RootVolume {
+	if pool.RootVolume != nil {
+		return pool.RootVolume
+	}
+	return s.RootVolume
+}
+
+// ValidateRootVolume checks a root volume; owner names the cluster or node
+// pool it belongs to in errors
+func ValidateRootVolume(owner string, v *RootVolume) error {
+	if v == nil {
+		return nil
+	}
+	if v.Size != 0 && (v.Size < MinRootVolumeSize || v.Size > MaxRootVolumeSize) {
+		return fmt.Errorf("%s: root volume size must be between %d and %d GiB", owner, MinRootVolumeSize, MaxRootVolumeSize)
+	}
+	if !contains(RootVolumeTypes, v.VolumeType()) {
+		return fmt.Errorf("%s: unknown root volume type %q (use %s)", owner, v.Type, strings.Join(RootVolumeTypes, ", "))
+	}
+	switch v.VolumeType() {
+	case "gp2":
+		if v.IOPS != 0 {
+			return fmt.Errorf("%s: gp2 root volumes have no provisioned IOPS", owner)
+		}
+	case "gp3":
+		if v.IOPS != 0 && (v.IOPS < 3000 || v.IOPS > 16000) {
+			return fmt.Errorf("%s: gp3 root volume IOPS must be between 3000 and 16000", owner)
+		}
+	case "io1", "io2":
+		if v.IOPS < 100 {
+			return fmt.Errorf("%s: %s root volumes need at least 100 provisioned IOPS", owner, v.Type)
+		}
+	}
+	return nil
+}
+
+// DataVolume is an additional block volume attached to every node of a
+// pool, formatted and mounted by the node bootstrap. It lives and dies with
+// its node, for workloads using local persistent storage paths.
+type DataVolume struct {
+	Size       int    `json:"size" yaml:"size"`                                 // Size in GiB
+	Type       string `json:"type,omitempty" yaml:"type,omitempty"`             // Volume type, gp3 if empty
+	MountPoint string `json:"mountPoint" yaml:"mountPoint"`                     // Absolute path to mount at
+	Filesystem string `json:"filesystem,omitempty" yaml:"filesystem,omitempty"` // ext4 if empty
+}
+
+// VolumeType returns the volume type, defaulting to gp3
+func (v DataVolume) VolumeType() string {
+	if v.Type == "" {
+		return DefaultVolumeType
+	}
+	return v.Type
+}
+
+// FilesystemType returns the filesystem, defaulting to ext4
+func (v DataVolume) FilesystemType() string {
+	if v.Filesystem == "" {
+		return DefaultVolumeFilesystem
+	}
+	return v.Filesystem
+}
+
+// ValidateDataVolumes checks the data volumes of a node pool
+func ValidateDataVolumes(pool string, volumes []DataVolume) error {
+	if len(volumes) > MaxDataVolumes {
+		return fmt.Errorf("node pool %s: at most %d volumes are supported", pool, MaxDataVolumes)
+	}
+	mounts := make(map[string]bool)
+	for _, v := range volumes {
+		if v.Size <= 0 {
+			return fmt.Errorf("node pool %s: volume size must be positive", pool)
+		}
+		if !contains(VolumeTypes, v.VolumeType()) {
+			return fmt.Errorf("node pool %s: unknown volume type %q (use %s)", pool, v.Type, strings.Join(VolumeTypes, ", "))
+		}
+		if !contains(VolumeFilesystems, v.FilesystemType()) {
+			return fmt.Errorf("node pool %s: unknown filesystem %q (use %s)", pool, v.Filesystem, strings.Join(VolumeFilesystems, ", "))
+		}
+		mount := path.Clean(v.MountPoint)
+		if !path.IsAbs(v.MountPoint) || mount == "/" || strings.ContainsAny(v.MountPoint, " \t\"'$`\\") {
+			return fmt.Errorf("node pool %s: mount point %q must be an absolute path other than /", pool, v.MountPoint)
+		}
+		if mounts[mount] {
+			return fmt.Errorf("node pool %s: mount point %s is used twice", pool, mount)
+		}
+		mounts[mount] = true
+	}
+	return nil
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+// Families with local NVMe instance storage even without a "d" attribute
+var instanceStoreFamilies = map[string]bool{"i": true, "im": true, "is": true, "d": true, "h": true}
+
+// HasInstanceStore reports whether an instance type comes with local
+// instance storage, e.g. i3, m5d or c6gd. Types are recognized by family
+// name: storage-optimized families, and others with a "d" attribute.
+func HasInstanceStore(instanceType string) bool {
+	family, _, _ := strings.Cut(instanceType, ".")
+	family, _, _ = strings.Cut(family, "-")
+	i := strings.IndexAny(family, "0123456789")
+	if i <= 0 {
+		return false
+	}
+	attributes := strings.TrimLeft(family[i:], "0123456789")
+	return instanceStoreFamilies[family[:i]] || strings.Contains(attributes, "d")
+}
@@ -0,0 +1,25 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ValidateAvailabilityZones checks the zones of a node pool: each must be
+// listed once and belong to the cluster's region
+func ValidateAvailabilityZones(pool, region string, zones []string) error {
+	seen := make(map[string]bool, len(zones))
+	for _, zone := range zones {
+		if zone == "" {
+			return fmt.Errorf("node pool %s: empty availability zone", pool)
+		}
+		if seen[zone] {
+			return fmt.Errorf("node pool %s: availability zone %s listed twice", pool, zone)
+		}
+		seen[zone] = true
+		if region != "" && !strings.HasPrefix(zone, region) {
+			return fmt.Errorf("node pool %s: availability zone %s is not in region %s", pool, zone, region)
+		}
+	}
+	return nil
+}
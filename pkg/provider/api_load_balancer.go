@@ -0,0 +1,17 @@
+package provider
+
+import "context"
+
+// APILoadBalancerService is implemented by compute services that can put a
+// network load balancer in front of the API servers of a cluster
+type APILoadBalancerService interface {
+	// EnsureAPILoadBalancer creates the load balancer of a cluster in region
+	// if it has none, makes its targets port 6443 of exactly instanceIDs and
+	// returns its DNS name. Clients from allowedCIDRs, the VPC's range if
+	// empty, may reach it.
+	EnsureAPILoadBalancer(ctx context.Context, region, clusterName string, instanceIDs []string, internal bool, allowedCIDRs []string) (string, error)
+
+	// DeleteAPILoadBalancer removes a cluster's load balancer. A cluster
+	// without one is no error.
+	DeleteAPILoadBalancer(ctx context.Context, region, clusterName string) error
+}
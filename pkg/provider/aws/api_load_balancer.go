@@ -0,0 +1,558 @@
+package aws
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	elbv2 "github.com/aws/aws-sdk-go-v2/service/elasticloadbalancingv2"
+	elbtypes "github.com/aws/aws-sdk-go-v2/service/elasticloadbalancingv2/types"
+	"github.com/madhouselabs/goman/pkg/logger"
+	"github.com/madhouselabs/goman/pkg/provider"
+)
+
+// The API load balancer of an HA cluster is a network load balancer with a
+// TCP listener on 6443 forwarding to the masters. It has a security group of
+// its own admitting the allowed ranges; the cluster's security group admits
+// the load balancer. Client IPs are not preserved, so a master reaching the
+// API through the load balancer is not dropped as a hairpin connection.
+
+// apiLoadBalancerTag marks a cluster's load balancer, target group and
+// security group, with the cluster name as value. The load balancer is
+// found by this tag rather than its name, which keeps the name the cluster
+// had when it was created.
+const apiLoadBalancerTag = "goman-api-lb"
+
+// apiServerPort is the port of the listener and of the targets
+const apiServerPort = 6443
+
+// apiLoadBalancerTimeout bounds the wait for a new load balancer to become
+// active and for a deleted one to be gone
+const apiLoadBalancerTimeout = 10 * time.Minute
+
+// getELBClient returns an Elastic Load Balancing client for a region
+func (s *ComputeService) getELBClient(region string) *elbv2.Client {
+	return elbv2.NewFromConfig(s.config.Copy(), func(o *elbv2.Options) {
+		if region != "" {
+			o.Region = region
+		}
+	})
+}
+
+// apiLoadBalancerName returns the name of a cluster's load balancer and
+// target group: at most 32 characters, unique through a hash of the name
+func apiLoadBalancerName(clusterName string) string {
+	sum := sha256.Sum256([]byte(clusterName))
+	base := "goman-" + clusterName
+	if len(base) > 23 {
+		base = strings.TrimRight(base[:23], "-")
+	}
+	return base + "-" + hex.EncodeToString(sum[:])[:8]
+}
+
+// apiLoadBalancerTags tags the load balancer and target group of a cluster
+func apiLoadBalancerTags(clusterName string) []elbtypes.Tag {
+	return []elbtypes.Tag{
+		{Key: aws.String("Name"), Value: aws.String("goman-" + clusterName + "-api")},
+		{Key: aws.String("ManagedBy"), Value: aws.String("goman")},
+		{Key: aws.String(apiLoadBalancerTag), Value: aws.String(clusterName)},
+	}
+}
+
+// EnsureAPILoadBalancer creates the network load balancer of a cluster in
+// the VPC and zones of its masters, waits for it to become active and keeps
+// its targets and allowed ranges up to date. The scheme of a load balancer
+// cannot change, so one with the other scheme is replaced.
+func (s *ComputeService) EnsureAPILoadBalancer(ctx context.Context, region, clusterName string, instanceIDs []string, internal bool, allowedCIDRs []string) (string, error) {
+	if len(instanceIDs) == 0 {
+		return "", fmt.Errorf("an API load balancer needs at least one master")
+	}
+	ec2Client := s.getEC2Client(region)
+	elbClient := s.getELBClient(region)
+
+	instances, err := ec2Client.DescribeInstances(ctx, &ec2.DescribeInstancesInput{InstanceIds: instanceIDs})
+	if err != nil {
+		return "", fmt.Errorf("failed to describe masters: %w", wrapAWSError("ec2", "DescribeInstances", err))
+	}
+	var vpcID string
+	zoneSubnets := make(map[string]string)
+	masterGroups := make(map[string]bool)
+	for _, reservation := range instances.Reservations {
+		for _, inst := range reservation.Instances {
+			vpcID = aws.ToString(inst.VpcId)
+			if inst.Placement != nil {
+				zoneSubnets[aws.ToString(inst.Placement.AvailabilityZone)] = aws.ToString(inst.SubnetId)
+			}
+			for _, group := range inst.SecurityGroups {
+				masterGroups[aws.ToString(group.GroupId)] = true
+			}
+		}
+	}
+	if vpcID == "" {
+		return "", fmt.Errorf("masters %v of cluster %s not found", instanceIDs, clusterName)
+	}
+
+	groupID, err := s.ensureAPILoadBalancerGroup(ctx, ec2Client, vpcID, clusterName, allowedCIDRs)
+	if err != nil {
+		return "", err
+	}
+	for masterGroup := range masterGroups {
+		_, err := ec2Client.AuthorizeSecurityGroupIngress(ctx, &ec2.AuthorizeSecurityGroupIngressInput{
+			GroupId: aws.String(masterGroup),
+			IpPermissions: []types.IpPermission{{
+				IpProtocol: aws.String("tcp"),
+				FromPort:   aws.Int32(apiServerPort),
+				ToPort:     aws.Int32(apiServerPort),
+				UserIdGroupPairs: []types.UserIdGroupPair{{
+					GroupId:     aws.String(groupID),
+					Description: aws.String("K3s API server - load balancer"),
+				}},
+			}},
+		})
+		if err != nil && !hasErrorCode(err, "InvalidPermission.Duplicate") {
+			return "", fmt.Errorf("failed to admit the load balancer to security group %s: %w", masterGroup, wrapAWSError("ec2", "AuthorizeSecurityGroupIngress", err))
+		}
+	}
+
+	scheme := elbtypes.LoadBalancerSchemeEnumInternetFacing
+	if internal {
+		scheme = elbtypes.LoadBalancerSchemeEnumInternal
+	}
+	lb, err := findAPILoadBalancer(ctx, elbClient, clusterName)
+	if err != nil {
+		return "", err
+	}
+	if lb != nil && lb.Scheme != scheme {
+		logger.Infof(ctx, "Replacing %s load balancer of cluster %s with an %s one", lb.Scheme, clusterName, scheme)
+		if err := deleteLoadBalancer(ctx, elbClient, lb); err != nil {
+			return "", err
+		}
+		lb = nil
+	}
+
+	name := apiLoadBalancerName(clusterName)
+	if lb == nil {
+		zones := make([]string, 0, len(zoneSubnets))
+		for zone := range zoneSubnets {
+			zones = append(zones, zone)
+		}
+		sort.Strings(zones)
+		var subnets []string
+		for _, zone := range zones {
+			subnets = append(subnets, zoneSubnets[zone])
+		}
+
+		logger.Infof(ctx, "Creating %s load balancer %s for cluster %s in subnets %v", scheme, name, clusterName, subnets)
+		created, err := elbClient.CreateLoadBalancer(ctx, &elbv2.CreateLoadBalancerInput{
+			Name:           aws.String(name),
+			Type:           elbtypes.LoadBalancerTypeEnumNetwork,
+			Scheme:         scheme,
+			Subnets:        subnets,
+			SecurityGroups: []string{groupID},
+			Tags:           apiLoadBalancerTags(clusterName),
+		})
+		if err != nil {
+			if hasErrorCode(err, "TooManyLoadBalancers") {
+				return "", provider.UserConfigErrorf("no load balancer left in the account's quota for cluster %s: %v", clusterName, err)
+			}
+			return "", fmt.Errorf("failed to create load balancer: %w", wrapAWSError("elasticloadbalancing", "CreateLoadBalancer", err))
+		}
+		if len(created.LoadBalancers) == 0 {
+			return "", fmt.Errorf("load balancer %s was not created", name)
+		}
+		lb = &created.LoadBalancers[0]
+	}
+
+	targetGroupARN, err := apiTargetGroup(ctx, elbClient, lb)
+	if err != nil {
+		return "", err
+	}
+	if targetGroupARN == nil {
+		// Creating the target group again returns the existing one
+		targetGroup, err := elbClient.CreateTargetGroup(ctx, &elbv2.CreateTargetGroupInput{
+			Name:                aws.String(name),
+			Protocol:            elbtypes.ProtocolEnumTcp,
+			Port:                aws.Int32(apiServerPort),
+			VpcId:               aws.String(vpcID),
+			TargetType:          elbtypes.TargetTypeEnumInstance,
+			HealthCheckProtocol: elbtypes.ProtocolEnumTcp,
+			Tags:                apiLoadBalancerTags(clusterName),
+		})
+		if err != nil {
+			return "", fmt.Errorf("failed to create target group: %w", wrapAWSError("elasticloadbalancing", "CreateTargetGroup", err))
+		}
+		if len(targetGroup.TargetGroups) == 0 {
+			return "", fmt.Errorf("target group %s was not created", name)
+		}
+		targetGroupARN = targetGroup.TargetGroups[0].TargetGroupArn
+		if _, err := elbClient.ModifyTargetGroupAttributes(ctx, &elbv2.ModifyTargetGroupAttributesInput{
+			TargetGroupArn: targetGroupARN,
+			Attributes: []elbtypes.TargetGroupAttribute{
+				{Key: aws.String("preserve_client_ip.enabled"), Value: aws.String("false")},
+				{Key: aws.String("deregistration_delay.timeout_seconds"), Value: aws.String("30")},
+			},
+		}); err != nil {
+			return "", fmt.Errorf("failed to configure target group %s: %w", name, wrapAWSError("elasticloadbalancing", "ModifyTargetGroupAttributes", err))
+		}
+		if _, err := elbClient.CreateListener(ctx, &elbv2.CreateListenerInput{
+			LoadBalancerArn: lb.LoadBalancerArn,
+			Protocol:        elbtypes.ProtocolEnumTcp,
+			Port:            aws.Int32(apiServerPort),
+			DefaultActions: []elbtypes.Action{{
+				Type:           elbtypes.ActionTypeEnumForward,
+				TargetGroupArn: targetGroupARN,
+			}},
+		}); err != nil {
+			return "", fmt.Errorf("failed to create listener: %w", wrapAWSError("elasticloadbalancing", "CreateListener", err))
+		}
+	}
+
+	if err := syncAPITargets(ctx, elbClient, targetGroupARN, instanceIDs); err != nil {
+		return "", err
+	}
+
+	if lb.State == nil || lb.State.Code != elbtypes.LoadBalancerStateEnumActive {
+		waiter := elbv2.NewLoadBalancerAvailableWaiter(elbClient)
+		if err := waiter.Wait(ctx, &elbv2.DescribeLoadBalancersInput{LoadBalancerArns: []string{aws.ToString(lb.LoadBalancerArn)}}, apiLoadBalancerTimeout); err != nil {
+			return "", fmt.Errorf("load balancer %s did not become active: %w", name, err)
+		}
+	}
+	return aws.ToString(lb.DNSName), nil
+}
+
+// syncAPITargets registers the masters with the target group and
+// deregisters instances that are no longer masters
+func syncAPITargets(ctx context.Context, elbClient *elbv2.Client, targetGroupARN *string, instanceIDs []string) error {
+	health, err := elbClient.DescribeTargetHealth(ctx, &elbv2.DescribeTargetHealthInput{TargetGroupArn: targetGroupARN})
+	if err != nil {
+		return fmt.Errorf("failed to describe targets: %w", wrapAWSError("elasticloadbalancing", "DescribeTargetHealth", err))
+	}
+	registered := make(map[string]bool)
+	var stale []elbtypes.TargetDescription
+	for _, description := range health.TargetHealthDescriptions {
+		if description.Target == nil {
+			continue
+		}
+		id := aws.ToString(description.Target.Id)
+		registered[id] = true
+		if !slices.Contains(instanceIDs, id) {
+			stale = append(stale, *description.Target)
+		}
+	}
+
+	var missing []elbtypes.TargetDescription
+	for _, id := range instanceIDs {
+		if !registered[id] {
+			missing = append(missing, elbtypes.TargetDescription{Id: aws.String(id), Port: aws.Int32(apiServerPort)})
+		}
+	}
+	if len(missing) > 0 {
+		if _, err := elbClient.RegisterTargets(ctx, &elbv2.RegisterTargetsInput{TargetGroupArn: targetGroupARN, Targets: missing}); err != nil {
+			return fmt.Errorf("failed to register masters: %w", wrapAWSError("elasticloadbalancing", "RegisterTargets", err))
+		}
+	}
+	if len(stale) > 0 {
+		if _, err := elbClient.DeregisterTargets(ctx, &elbv2.DeregisterTargetsInput{TargetGroupArn: targetGroupARN, Targets: stale}); err != nil {
+			return fmt.Errorf("failed to deregister removed masters: %w", wrapAWSError("elasticloadbalancing", "DeregisterTargets", err))
+		}
+	}
+	return nil
+}
+
+// ensureAPILoadBalancerGroup returns the security group of a cluster's load
+// balancer, admitting port 6443 from exactly allowedCIDRs, or from the VPC's
+// range if there are none
+func (s *ComputeService) ensureAPILoadBalancerGroup(ctx context.Context, ec2Client *ec2.Client, vpcID, clusterName string, allowedCIDRs []string) (string, error) {
+	if len(allowedCIDRs) == 0 {
+		vpcs, err := ec2Client.DescribeVpcs(ctx, &ec2.DescribeVpcsInput{VpcIds: []string{vpcID}})
+		if err != nil {
+			return "", fmt.Errorf("failed to describe VPC %s: %w", vpcID, wrapAWSError("ec2", "DescribeVpcs", err))
+		}
+		if len(vpcs.Vpcs) == 0 {
+			return "", fmt.Errorf("VPC %s not found", vpcID)
+		}
+		allowedCIDRs = []string{aws.ToString(vpcs.Vpcs[0].CidrBlock)}
+	}
+
+	group, err := findAPILoadBalancerGroup(ctx, ec2Client, clusterName)
+	if err != nil {
+		return "", err
+	}
+	var groupID string
+	allowed := make(map[string]bool)
+	if group != nil {
+		groupID = aws.ToString(group.GroupId)
+		for _, permission := range group.IpPermissions {
+			if aws.ToInt32(permission.FromPort) != apiServerPort {
+				continue
+			}
+			for _, ipRange := range permission.IpRanges {
+				allowed[aws.ToString(ipRange.CidrIp)] = true
+			}
+		}
+	} else {
+		created, err := ec2Client.CreateSecurityGroup(ctx, &ec2.CreateSecurityGroupInput{
+			GroupName:   aws.String(apiLoadBalancerName(clusterName)),
+			Description: aws.String(fmt.Sprintf("API load balancer of goman cluster %s", clusterName)),
+			VpcId:       aws.String(vpcID),
+			TagSpecifications: []types.TagSpecification{{
+				ResourceType: types.ResourceTypeSecurityGroup,
+				Tags: []types.Tag{
+					{Key: aws.String("Name"), Value: aws.String("goman-" + clusterName + "-api")},
+					{Key: aws.String("ManagedBy"), Value: aws.String("goman")},
+					{Key: aws.String(apiLoadBalancerTag), Value: aws.String(clusterName)},
+					s.installTag(),
+				},
+			}},
+		})
+		if err != nil {
+			return "", fmt.Errorf("failed to create load balancer security group: %w", wrapAWSError("ec2", "CreateSecurityGroup", err))
+		}
+		groupID = aws.ToString(created.GroupId)
+	}
+
+	var add, remove []types.IpRange
+	for _, cidr := range allowedCIDRs {
+		if !allowed[cidr] {
+			add = append(add, types.IpRange{CidrIp: aws.String(cidr), Description: aws.String("K3s API server clients")})
+		}
+	}
+	for cidr := range allowed {
+		if !slices.Contains(allowedCIDRs, cidr) {
+			remove = append(remove, types.IpRange{CidrIp: aws.String(cidr)})
+		}
+	}
+	if len(add) > 0 {
+		_, err := ec2Client.AuthorizeSecurityGroupIngress(ctx, &ec2.AuthorizeSecurityGroupIngressInput{
+			GroupId:       aws.String(groupID),
+			IpPermissions: []types.IpPermission{apiPermission(add)},
+		})
+		if err != nil && !hasErrorCode(err, "InvalidPermission.Duplicate") {
+			return "", fmt.Errorf("failed to allow clients to the load balancer: %w", wrapAWSError("ec2", "AuthorizeSecurityGroupIngress", err))
+		}
+	}
+	if len(remove) > 0 {
+		_, err := ec2Client.RevokeSecurityGroupIngress(ctx, &ec2.RevokeSecurityGroupIngressInput{
+			GroupId:       aws.String(groupID),
+			IpPermissions: []types.IpPermission{apiPermission(remove)},
+		})
+		if err != nil && !hasErrorCode(err, "InvalidPermission.NotFound") {
+			return "", fmt.Errorf("failed to remove clients from the load balancer: %w", wrapAWSError("ec2", "RevokeSecurityGroupIngress", err))
+		}
+	}
+	return groupID, nil
+}
+
+// apiPermission admits TCP 6443 from address ranges
+func apiPermission(ranges []types.IpRange) types.IpPermission {
+	return types.IpPermission{
+		IpProtocol: aws.String("tcp"),
+		FromPort:   aws.Int32(apiServerPort),
+		ToPort:     aws.Int32(apiServerPort),
+		IpRanges:   ranges,
+	}
+}
+
+// findAPILoadBalancerGroup returns the security group of a cluster's load
+// balancer, nil if it has none
+func findAPILoadBalancerGroup(ctx context.Context, ec2Client *ec2.Client, clusterName string) (*types.SecurityGroup, error) {
+	groups, err := ec2Client.DescribeSecurityGroups(ctx, &ec2.DescribeSecurityGroupsInput{
+		Filters: []types.Filter{{Name: aws.String("tag:" + apiLoadBalancerTag), Values: []string{clusterName}}},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe security groups: %w", wrapAWSError("ec2", "DescribeSecurityGroups", err))
+	}
+	if len(groups.SecurityGroups) == 0 {
+		return nil, nil
+	}
+	return &groups.SecurityGroups[0], nil
+}
+
+// findAPILoadBalancer returns the load balancer tagged for a cluster, nil if
+// it has none. Load balancers cannot be filtered by tag, so goman's network
+// load balancers are listed and their tags read in batches of 20.
+func findAPILoadBalancer(ctx context.Context, elbClient *elbv2.Client, clusterName string) (*elbtypes.LoadBalancer, error) {
+	candidates := make(map[string]elbtypes.LoadBalancer)
+	paginator := elbv2.NewDescribeLoadBalancersPaginator(elbClient, &elbv2.DescribeLoadBalancersInput{})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to describe load balancers: %w", wrapAWSError("elasticloadbalancing", "DescribeLoadBalancers", err))
+		}
+		for _, lb := range page.LoadBalancers {
+			if lb.Type == elbtypes.LoadBalancerTypeEnumNetwork && strings.HasPrefix(aws.ToString(lb.LoadBalancerName), "goman-") {
+				candidates[aws.ToString(lb.LoadBalancerArn)] = lb
+			}
+		}
+	}
+
+	arns := make([]string, 0, len(candidates))
+	for arn := range candidates {
+		arns = append(arns, arn)
+	}
+	sort.Strings(arns)
+	for len(arns) > 0 {
+		batch := arns[:min(20, len(arns))]
+		arns = arns[len(batch):]
+		tags, err := elbClient.DescribeTags(ctx, &elbv2.DescribeTagsInput{ResourceArns: batch})
+		if err != nil {
+			return nil, fmt.Errorf("failed to describe load balancer tags: %w", wrapAWSError("elasticloadbalancing", "DescribeTags", err))
+		}
+		for _, description := range tags.TagDescriptions {
+			for _, tag := range description.Tags {
+				if aws.ToString(tag.Key) == apiLoadBalancerTag && aws.ToString(tag.Value) == clusterName {
+					lb := candidates[aws.ToString(description.ResourceArn)]
+					return &lb, nil
+				}
+			}
+		}
+	}
+	return nil, nil
+}
+
+// deleteLoadBalancer deletes a load balancer and the target group its
+// listener forwards to, waiting for the load balancer to be gone
+func deleteLoadBalancer(ctx context.Context, elbClient *elbv2.Client, lb *elbtypes.LoadBalancer) error {
+	targetGroup, err := apiTargetGroup(ctx, elbClient, lb)
+	if err != nil {
+		return err
+	}
+
+	logger.Infof(ctx, "Deleting load balancer %s", aws.ToString(lb.LoadBalancerName))
+	if _, err := elbClient.DeleteLoadBalancer(ctx, &elbv2.DeleteLoadBalancerInput{LoadBalancerArn: lb.LoadBalancerArn}); err != nil {
+		return fmt.Errorf("failed to delete load balancer: %w", wrapAWSError("elasticloadbalancing", "DeleteLoadBalancer", err))
+	}
+	waiter := elbv2.NewLoadBalancersDeletedWaiter(elbClient)
+	if err := waiter.Wait(ctx, &elbv2.DescribeLoadBalancersInput{LoadBalancerArns: []string{aws.ToString(lb.LoadBalancerArn)}}, apiLoadBalancerTimeout); err != nil {
+		return fmt.Errorf("load balancer %s was not deleted: %w", aws.ToString(lb.LoadBalancerName), err)
+	}
+
+	if targetGroup != nil {
+		_, err := elbClient.DeleteTargetGroup(ctx, &elbv2.DeleteTargetGroupInput{TargetGroupArn: targetGroup})
+		if err != nil && !hasErrorCode(err, "TargetGroupNotFound") {
+			return fmt.Errorf("failed to delete target group: %w", wrapAWSError("elasticloadbalancing", "DeleteTargetGroup", err))
+		}
+	}
+	return nil
+}
+
+// apiTargetGroup returns the ARN of the target group the API listener of a
+// load balancer forwards to, nil if it has no listener yet
+func apiTargetGroup(ctx context.Context, elbClient *elbv2.Client, lb *elbtypes.LoadBalancer) (*string, error) {
+	listeners, err := elbClient.DescribeListeners(ctx, &elbv2.DescribeListenersInput{LoadBalancerArn: lb.LoadBalancerArn})
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe listeners: %w", wrapAWSError("elasticloadbalancing", "DescribeListeners", err))
+	}
+	for _, listener := range listeners.Listeners {
+		if aws.ToInt32(listener.Port) != apiServerPort {
+			continue
+		}
+		for _, action := range listener.DefaultActions {
+			if action.TargetGroupArn != nil {
+				return action.TargetGroupArn, nil
+			}
+		}
+	}
+	return nil, nil
+}
+
+// DeleteAPILoadBalancer removes a cluster's load balancer, its target group
+// and its security group, after revoking the rules admitting the group to
+// the masters. The network interfaces of a deleted load balancer take a
+// while to go, so the security group usually goes on a later call; until
+// then ErrNetworkInUse is returned.
+func (s *ComputeService) DeleteAPILoadBalancer(ctx context.Context, region, clusterName string) error {
+	ec2Client := s.getEC2Client(region)
+	elbClient := s.getELBClient(region)
+
+	lb, err := findAPILoadBalancer(ctx, elbClient, clusterName)
+	if err != nil {
+		return err
+	}
+	if lb != nil {
+		if err := deleteLoadBalancer(ctx, elbClient, lb); err != nil {
+			return err
+		}
+	}
+
+	group, err := findAPILoadBalancerGroup(ctx, ec2Client, clusterName)
+	if err != nil || group == nil {
+		return err
+	}
+	groupID := aws.ToString(group.GroupId)
+	referencing, err := ec2Client.DescribeSecurityGroups(ctx, &ec2.DescribeSecurityGroupsInput{
+		Filters: []types.Filter{{Name: aws.String("ip-permission.group-id"), Values: []string{groupID}}},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to describe security groups: %w", wrapAWSError("ec2", "DescribeSecurityGroups", err))
+	}
+	for _, other := range referencing.SecurityGroups {
+		_, err := ec2Client.RevokeSecurityGroupIngress(ctx, &ec2.RevokeSecurityGroupIngressInput{
+			GroupId: other.GroupId,
+			IpPermissions: []types.IpPermission{{
+				IpProtocol:       aws.String("tcp"),
+				FromPort:         aws.Int32(apiServerPort),
+				ToPort:           aws.Int32(apiServerPort),
+				UserIdGroupPairs: []types.UserIdGroupPair{{GroupId: aws.String(groupID)}},
+			}},
+		})
+		if err != nil && !hasErrorCode(err, "InvalidPermission.NotFound") {
+			return fmt.Errorf("failed to revoke load balancer access to %s: %w", aws.ToString(other.GroupId), wrapAWSError("ec2", "RevokeSecurityGroupIngress", err))
+		}
+	}
+	if _, err := ec2Client.DeleteSecurityGroup(ctx, &ec2.DeleteSecurityGroupInput{GroupId: aws.String(groupID)}); err != nil {
+		if hasErrorCode(err, "DependencyViolation") {
+			return fmt.Errorf("%w: load balancer security group %s", provider.ErrNetworkInUse, groupID)
+		}
+		return fmt.Errorf("failed to delete load balancer security group %s: %w", groupID, wrapAWSError("ec2", "DeleteSecurityGroup", err))
+	}
+	logger.Infof(ctx, "Deleted API load balancer of cluster %s", clusterName)
+	return nil
+}
+
+// renameAPILoadBalancer moves the load balancer, target group and security
+// group of a cluster to a new cluster name
+func (s *ComputeService) renameAPILoadBalancer(ctx context.Context, ec2Client *ec2.Client, region, oldName, newName string) error {
+	group, err := findAPILoadBalancerGroup(ctx, ec2Client, oldName)
+	if err != nil {
+		return err
+	}
+	if group != nil {
+		if _, err := ec2Client.CreateTags(ctx, &ec2.CreateTagsInput{
+			Resources: []string{aws.ToString(group.GroupId)},
+			Tags:      []types.Tag{{Key: aws.String(apiLoadBalancerTag), Value: aws.String(newName)}},
+		}); err != nil {
+			return fmt.Errorf("failed to tag load balancer security group: %w", wrapAWSError("ec2", "CreateTags", err))
+		}
+	}
+
+	elbClient := s.getELBClient(region)
+	lb, err := findAPILoadBalancer(ctx, elbClient, oldName)
+	if err != nil || lb == nil {
+		return err
+	}
+	resources := []string{aws.ToString(lb.LoadBalancerArn)}
+	targetGroup, err := apiTargetGroup(ctx, elbClient, lb)
+	if err != nil {
+		return err
+	}
+	if targetGroup != nil {
+		resources = append(resources, aws.ToString(targetGroup))
+	}
+	if _, err := elbClient.AddTags(ctx, &elbv2.AddTagsInput{
+		ResourceArns: resources,
+		Tags:         []elbtypes.Tag{{Key: aws.String(apiLoadBalancerTag), Value: aws.String(newName)}},
+	}); err != nil {
+		return fmt.Errorf("failed to tag load balancer: %w", wrapAWSError("elasticloadbalancing", "AddTags", err))
+	}
+	logger.Infof(ctx, "Re-tagged load balancer %s from cluster %s to %s", aws.ToString(lb.LoadBalancerName), oldName, newName)
+	return nil
+}
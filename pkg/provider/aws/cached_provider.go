@@ -11,7 +11,6 @@ import (
 	"github.com/aws/aws-sdk-go-v2/service/ec2"
 	"github.com/aws/aws-sdk-go-v2/service/iam"
 	"github.com/aws/aws-sdk-go-v2/service/lambda"
-	"github.com/aws/aws-sdk-go-v2/service/s3"
 	"github.com/aws/aws-sdk-go-v2/service/sns"
 	"github.com/aws/aws-sdk-go-v2/service/sqs"
 	"github.com/aws/aws-sdk-go-v2/service/sts"
@@ -109,7 +108,7 @@ func NewProviderWithCache(profile, region string) (*AWSProvider, error) {
 		accountID:    accountID,
 		cfg:          cfg,
 		dynamoClient: dynamodb.NewFromConfig(cfg),
-		s3Client:     s3.NewFromConfig(cfg),
+		s3Client:     newS3Client(cfg),
 		snsClient:    sns.NewFromConfig(cfg),
 		sqsClient:    sqs.NewFromConfig(cfg),
 		lambdaClient: lambda.NewFromConfig(cfg),
@@ -125,6 +124,12 @@ func NewProviderWithCache(profile, region string) (*AWSProvider, error) {
 	p.functionService = NewFunctionService(p.lambdaClient, p.s3Client, p.iamClient, p.accountID, p.region)
 	p.computeService = NewComputeService(p.ec2Client, p.iamClient, p.cfg, p.accountID)
 
+	secretService, err := NewSecretService(p.cfg, p.storageService)
+	if err != nil {
+		return nil, err
+	}
+	p.secretService = secretService
+
 	return p, nil
 }
 
@@ -0,0 +1,104 @@
+package aws
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"time"
+
+	gomanconfig "github.com/madhouselabs/goman/pkg/config"
+	"github.com/madhouselabs/goman/pkg/provider"
+)
+
+// cloudTrailMaxPages bounds the LookupEvents pages read per resource. The
+// API allows 2 requests per second per account.
+const cloudTrailMaxPages = 3
+
+// cloudTrailEvent is an event of the LookupEvents response
+type cloudTrailEvent struct {
+	EventName       string  `json:"EventName"`
+	EventTime       float64 `json:"EventTime"` // Epoch seconds
+	Username        string  `json:"Username"`
+	CloudTrailEvent string  `json:"CloudTrailEvent"` // The full record as JSON
+}
+
+// LookupChange returns the latest of the named API calls on a resource,
+// such as an instance ID, recorded by CloudTrail in the provider's region
+// between start and end. CloudTrail delivers events within about 15 minutes
+// of the call, so a change seen right away may not be found yet. Like the
+// Health API, the JSON API is called directly.
+func (p *AWSProvider) LookupChange(ctx context.Context, resourceID string, eventNames []string, start, end time.Time) (*provider.ChangeRecord, error) {
+	if gomanconfig.GetAWSEndpointURL() != "" {
+		return nil, fmt.Errorf("CloudTrail is not available with a custom AWS endpoint")
+	}
+	endpoint := fmt.Sprintf("https://cloudtrail.%s.amazonaws.com", p.region)
+	if partition(p.region) == "aws-cn" {
+		endpoint += ".cn"
+	}
+
+	request := map[string]interface{}{
+		"LookupAttributes": []map[string]string{{"AttributeKey": "ResourceName", "AttributeValue": resourceID}},
+		"StartTime":        start.Unix(),
+		"EndTime":          end.Unix(),
+		"MaxResults":       50,
+	}
+	for page := 0; page < cloudTrailMaxPages; page++ {
+		data, err := p.callJSONAPI(ctx, jsonAPICall{
+			Endpoint:      endpoint,
+			SigningName:   "cloudtrail",
+			SigningRegion: p.region,
+			Target:        "com.amazonaws.cloudtrail.v20131101.CloudTrail_20131101.LookupEvents",
+			Operation:     "LookupEvents",
+		}, request)
+		if err != nil {
+			return nil, err
+		}
+
+		var out struct {
+			Events    []cloudTrailEvent `json:"Events"`
+			NextToken string            `json:"NextToken"`
+		}
+		if err := json.Unmarshal(data, &out); err != nil {
+			return nil, fmt.Errorf("failed to parse CloudTrail events: %w", err)
+		}
+		// Events come newest first
+		for _, e := range out.Events {
+			if slices.Contains(eventNames, e.EventName) {
+				return &provider.ChangeRecord{
+					EventName: e.EventName,
+					Principal: cloudTrailPrincipal(e),
+					Time:      epochTime(e.EventTime),
+				}, nil
+			}
+		}
+		if out.NextToken == "" {
+			break
+		}
+		request["NextToken"] = out.NextToken
+	}
+	return nil, nil
+}
+
+// cloudTrailPrincipal names who made a call: the ARN of the IAM identity,
+// the AWS service acting on its behalf, or the user name
+func cloudTrailPrincipal(e cloudTrailEvent) string {
+	var record struct {
+		UserIdentity struct {
+			ARN       string `json:"arn"`
+			InvokedBy string `json:"invokedBy"`
+		} `json:"userIdentity"`
+	}
+	if json.Unmarshal([]byte(e.CloudTrailEvent), &record) == nil {
+		if record.UserIdentity.ARN != "" {
+			return record.UserIdentity.ARN
+		}
+		if record.UserIdentity.InvokedBy != "" {
+			return record.UserIdentity.InvokedBy
+		}
+	}
+	if e.Username != "" {
+		return e.Username
+	}
+	return "an unknown principal"
+}
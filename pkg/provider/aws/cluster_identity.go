@@ -0,0 +1,447 @@
+package aws
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/aws/aws-sdk-go-v2/service/iam"
+	iamTypes "github.com/aws/aws-sdk-go-v2/service/iam/types"
+	"github.com/madhouselabs/goman/pkg/logger"
+	"github.com/madhouselabs/goman/pkg/provider"
+)
+
+// Each cluster's nodes run with their own IAM role and instance profile,
+// scoped to clusters/<name>/* and binaries/* in the goman bucket, so a node
+// cannot read another cluster's tokens or kubeconfig. The shared
+// goman-ssm-instance-profile is only used for instances without a cluster.
+const (
+	// clusterIdentityPath groups the cluster roles and instance profiles
+	clusterIdentityPath = "/goman/"
+	// clusterIdentityPrefix is followed by the cluster name in role and
+	// instance profile names
+	clusterIdentityPrefix = "goman-node-"
+	// clusterAccessPolicyName is the inline policy holding the S3 scope
+	clusterAccessPolicyName = "goman-cluster-access"
+	// instanceProfilePropagation is how long EC2 takes to accept a new
+	// instance profile in RunInstances
+	instanceProfilePropagation = 10 * time.Second
+)
+
+// clusterIdentityName returns the role and instance profile name of a cluster
+func clusterIdentityName(clusterName string) string {
+	return clusterIdentityPrefix + clusterName
+}
+
+// EnsureClusterIdentity creates the IAM role and instance profile for a
+// cluster's nodes if needed and refreshes the role's access policy.
+// Returns the instance profile name to launch nodes with.
+func (s *ComputeService) EnsureClusterIdentity(ctx context.Context, clusterName string) (string, error) {
+	if clusterName == "" {
+		return "", fmt.Errorf("cluster name is required")
+	}
+	name := clusterIdentityName(clusterName)
+
+	// Workers of one cluster are created in parallel; set up the identity once
+	s.identityMu.Lock()
+	defer s.identityMu.Unlock()
+	if s.identitiesReady[clusterName] {
+		return name, nil
+	}
+
+	_, err := s.iamClient.GetRole(ctx, &iam.GetRoleInput{
+		RoleName: aws.String(name),
+	})
+	if err != nil {
+		if !strings.Contains(err.Error(), "NoSuchEntity") {
+			return "", wrapAWSError("iam", "GetRole", err)
+		}
+		if err := s.createClusterRole(ctx, clusterName); err != nil {
+			return "", err
+		}
+	}
+
+	// Re-applied every time so roles pick up policy changes
+	if err := s.putClusterAccessPolicy(ctx, name, s.clusterIdentityScope(ctx, clusterName)); err != nil {
+		return "", err
+	}
+
+	created, err := s.ensureClusterInstanceProfile(ctx, name)
+	if err != nil {
+		return "", err
+	}
+	if created {
+		logger.Infof(ctx, "Created instance profile %s for cluster %s, waiting for it to propagate", name, clusterName)
+		select {
+		case <-ctx.Done():
+			return "", ctx.Err()
+		case <-time.After(instanceProfilePropagation):
+		}
+	}
+
+	s.identitiesReady[clusterName] = true
+	return name, nil
+}
+
+// createClusterRole creates the IAM role for a cluster's nodes
+func (s *ComputeService) createClusterRole(ctx context.Context, clusterName string) error {
+	name := clusterIdentityName(clusterName)
+
+	trustPolicy := map[string]interface{}{
+		"Version": "2012-10-17",
+		"Statement": []map[string]interface{}{
+			{
+				"Effect": "Allow",
+				"Principal": map[string]string{
+					"Service": "ec2.amazonaws.com",
+				},
+				"Action": "sts:AssumeRole",
+			},
+		},
+	}
+	trustPolicyJSON, err := json.Marshal(trustPolicy)
+	if err != nil {
+		return fmt.Errorf("failed to marshal trust policy: %w", err)
+	}
+
+	_, err = s.iamClient.CreateRole(ctx, &iam.CreateRoleInput{
+		RoleName:                 aws.String(name),
+		Path:                     aws.String(clusterIdentityPath),
+		AssumeRolePolicyDocument: aws.String(string(trustPolicyJSON)),
+		Description:              aws.String(fmt.Sprintf("Role for nodes of goman cluster %s", clusterName)),
+		Tags: []iamTypes.Tag{
+			{Key: aws.String("ManagedBy"), Value: aws.String("goman")},
+			{Key: aws.String("goman-cluster"), Value: aws.String(clusterName)},
+			{Key: aws.String(provider.InstallTag), Value: aws.String(s.InstallID())},
+		},
+	})
+	if err != nil && !strings.Contains(err.Error(), "EntityAlreadyExists") {
+		return fmt.Errorf("failed to create role for cluster %s: %w", clusterName, wrapAWSError("iam", "CreateRole", err))
+	}
+
+	_, err = s.iamClient.AttachRolePolicy(ctx, &iam.AttachRolePolicyInput{
+		RoleName:  aws.String(name),
+		PolicyArn: aws.String(fmt.Sprintf("arn:%s:iam::aws:policy/AmazonSSMManagedInstanceCore", partition(s.config.Region))),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to attach SSM policy to %s: %w", name, wrapAWSError("iam", "AttachRolePolicy", err))
+	}
+
+	logger.Infof(ctx, "Created IAM role %s for cluster %s", name, clusterName)
+	return nil
+}
+
+// ensureClusterInstanceProfile creates the instance profile for a cluster
+// role. Returns true if the profile was created.
+func (s *ComputeService) ensureClusterInstanceProfile(ctx context.Context, name string) (bool, error) {
+	profileResp, err := s.iamClient.GetInstanceProfile(ctx, &iam.GetInstanceProfileInput{
+		InstanceProfileName: aws.String(name),
+	})
+	if err == nil {
+		for _, role := range profileResp.InstanceProfile.Roles {
+			if aws.ToString(role.RoleName) == name {
+				return false, nil
+			}
+		}
+	} else {
+		if !strings.Contains(err.Error(), "NoSuchEntity") {
+			return false, wrapAWSError("iam", "GetInstanceProfile", err)
+		}
+		_, err = s.iamClient.CreateInstanceProfile(ctx, &iam.CreateInstanceProfileInput{
+			InstanceProfileName: aws.String(name),
+			Path:                aws.String(clusterIdentityPath),
+			Tags: []iamTypes.Tag{
+				{Key: aws.String("ManagedBy"), Value: aws.String("goman")},
+			},
+		})
+		if err != nil && !strings.Contains(err.Error(), "EntityAlreadyExists") {
+			return false, fmt.Errorf("failed to create instance profile %s: %w", name, wrapAWSError("iam", "CreateInstanceProfile", err))
+		}
+	}
+
+	_, err = s.iamClient.AddRoleToInstanceProfile(ctx, &iam.AddRoleToInstanceProfileInput{
+		InstanceProfileName: aws.String(name),
+		RoleName:            aws.String(name),
+	})
+	if err != nil && !strings.Contains(err.Error(), "LimitExceeded") {
+		return false, fmt.Errorf("failed to add role to instance profile %s: %w", name, wrapAWSError("iam", "AddRoleToInstanceProfile", err))
+	}
+	return true, nil
+}
+
+// putClusterAccessPolicy scopes a cluster role to the K3s binaries and the
+// objects of the given clusters. A renamed cluster's nodes keep their
+// original role, so it can cover more than one name.
+func (s *ComputeService) putClusterAccessPolicy(ctx context.Context, roleName string, clusterNames []string) error {
+	bucket := stateBucketName(s.accountID)
+
+	readResources := []string{fmt.Sprintf("arn:%s:s3:::%s/binaries/*", partition(s.config.Region), bucket)}
+	var writeResources []string
+	listPrefixes := []string{"binaries/*"}
+	for _, name := range clusterNames {
+		resource := fmt.Sprintf("arn:%s:s3:::%s/clusters/%s/*", partition(s.config.Region), bucket, name)
+		readResources = append(readResources, resource)
+		writeResources = append(writeResources, resource)
+		listPrefixes = append(listPrefixes, fmt.Sprintf("clusters/%s/*", name))
+	}
+
+	statements := []map[string]interface{}{
+		{
+			"Effect":   "Allow",
+			"Action":   []string{"s3:GetObject"},
+			"Resource": readResources,
+		},
+		{
+			// Masters publish the kubeconfig under their cluster prefix
+			"Effect":   "Allow",
+			"Action":   []string{"s3:PutObject"},
+			"Resource": writeResources,
+		},
+		{
+			"Effect":   "Allow",
+			"Action":   []string{"s3:ListBucket"},
+			"Resource": fmt.Sprintf("arn:%s:s3:::%s", partition(s.config.Region), bucket),
+			"Condition": map[string]interface{}{
+				"StringLike": map[string]interface{}{
+					"s3:prefix": listPrefixes,
+				},
+			},
+		},
+	}
+	secretResources := map[string][]string{}
+	for _, name := range clusterNames {
+		secretResources["secretsmanager"] = append(secretResources["secretsmanager"],
+			fmt.Sprintf("arn:%s:secretsmanager:%s:%s:secret:%s/%s/*", partition(s.config.Region), s.config.Region, s.accountID, secretNamePrefix, name))
+		secretResources["ssm"] = append(secretResources["ssm"],
+			fmt.Sprintf("arn:%s:ssm:%s:%s:parameter/%s/%s/*", partition(s.config.Region), s.config.Region, s.accountID, secretNamePrefix, name))
+	}
+	statements = append(statements,
+		map[string]interface{}{
+			// Tokens and kubeconfigs when a secret backend other than S3 is used
+			"Effect":   "Allow",
+			"Action":   []string{"secretsmanager:GetSecretValue", "secretsmanager:PutSecretValue", "secretsmanager:CreateSecret"},
+			"Resource": secretResources["secretsmanager"],
+		},
+		map[string]interface{}{
+			"Effect":   "Allow",
+			"Action":   []string{"ssm:GetParameter", "ssm:PutParameter"},
+			"Resource": secretResources["ssm"],
+		},
+	)
+	statements = append(statements, map[string]interface{}{
+		// The master elected by kube-vip moves the cluster's virtual IP to itself
+		"Effect":   "Allow",
+		"Action":   []string{"ec2:AssignPrivateIpAddresses"},
+		"Resource": fmt.Sprintf("arn:%s:ec2:*:%s:network-interface/*", partition(s.config.Region), s.accountID),
+		"Condition": map[string]interface{}{
+			"StringEquals": map[string]interface{}{
+				"aws:ResourceTag/goman-cluster": clusterNames,
+			},
+		},
+	})
+	statements = append(statements,
+		map[string]interface{}{
+			// Image pulls from ECR registries of the spec's registries
+			"Effect":   "Allow",
+			"Action":   []string{"ecr:GetAuthorizationToken"},
+			"Resource": "*",
+		},
+		map[string]interface{}{
+			"Effect":   "Allow",
+			"Action":   []string{"ecr:BatchGetImage", "ecr:GetDownloadUrlForLayer", "ecr:BatchCheckLayerAvailability"},
+			"Resource": fmt.Sprintf("arn:%s:ecr:*:%s:repository/*", partition(s.config.Region), s.accountID),
+		},
+	)
+	statements = append(statements, s.commandOutputStatements(ctx, clusterNames[0])...)
+
+	policyJSON, err := json.Marshal(map[string]interface{}{
+		"Version":   "2012-10-17",
+		"Statement": statements,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal cluster access policy: %w", err)
+	}
+
+	_, err = s.iamClient.PutRolePolicy(ctx, &iam.PutRolePolicyInput{
+		RoleName:       aws.String(roleName),
+		PolicyName:     aws.String(clusterAccessPolicyName),
+		PolicyDocument: aws.String(string(policyJSON)),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to put cluster access policy on %s: %w", roleName, wrapAWSError("iam", "PutRolePolicy", err))
+	}
+	return nil
+}
+
+// clusterIdentityScope returns the cluster names a cluster role may access:
+// its own and, after a rename, the name in its goman-cluster tag
+func (s *ComputeService) clusterIdentityScope(ctx context.Context, clusterName string) []string {
+	names := []string{clusterName}
+	tags, err := s.iamClient.ListRoleTags(ctx, &iam.ListRoleTagsInput{
+		RoleName: aws.String(clusterIdentityName(clusterName)),
+	})
+	if err != nil {
+		return names
+	}
+	for _, tag := range tags.Tags {
+		if aws.ToString(tag.Key) == "goman-cluster" && aws.ToString(tag.Value) != clusterName {
+			// The current name goes first; command output is logged under it
+			names = append([]string{aws.ToString(tag.Value)}, names...)
+		}
+	}
+	return names
+}
+
+// refreshClusterAccessPolicies re-applies the access policy of every cluster
+// role, e.g. after the session logging settings changed
+func (s *ComputeService) refreshClusterAccessPolicies(ctx context.Context) error {
+	clusters, err := s.ListClusterIdentities(ctx)
+	if err != nil {
+		return err
+	}
+	for _, clusterName := range clusters {
+		if err := s.putClusterAccessPolicy(ctx, clusterIdentityName(clusterName), s.clusterIdentityScope(ctx, clusterName)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ListClusterIdentities returns the names of the clusters that have a node
+// identity
+func (s *ComputeService) ListClusterIdentities(ctx context.Context) ([]string, error) {
+	var clusters []string
+	paginator := iam.NewListRolesPaginator(s.iamClient, &iam.ListRolesInput{
+		PathPrefix: aws.String(clusterIdentityPath),
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, wrapAWSError("iam", "ListRoles", err)
+		}
+		for _, role := range page.Roles {
+			name := aws.ToString(role.RoleName)
+			if strings.HasPrefix(name, clusterIdentityPrefix) {
+				clusters = append(clusters, strings.TrimPrefix(name, clusterIdentityPrefix))
+			}
+		}
+	}
+	return clusters, nil
+}
+
+// DeleteClusterIdentity removes a cluster's role and instance profile.
+// Identities still attached to live instances (e.g., nodes of a renamed
+// cluster) are kept and provider.ErrIdentityInUse is returned.
+func (s *ComputeService) DeleteClusterIdentity(ctx context.Context, clusterName string) error {
+	name := clusterIdentityName(clusterName)
+
+	profileResp, err := s.iamClient.GetInstanceProfile(ctx, &iam.GetInstanceProfileInput{
+		InstanceProfileName: aws.String(name),
+	})
+	if err == nil {
+		inUse, err := s.instanceProfileInUse(ctx, aws.ToString(profileResp.InstanceProfile.Arn))
+		if err != nil {
+			return err
+		}
+		if inUse {
+			return fmt.Errorf("%w: %s", provider.ErrIdentityInUse, name)
+		}
+
+		for _, role := range profileResp.InstanceProfile.Roles {
+			s.iamClient.RemoveRoleFromInstanceProfile(ctx, &iam.RemoveRoleFromInstanceProfileInput{
+				InstanceProfileName: aws.String(name),
+				RoleName:            role.RoleName,
+			})
+		}
+		_, err = s.iamClient.DeleteInstanceProfile(ctx, &iam.DeleteInstanceProfileInput{
+			InstanceProfileName: aws.String(name),
+		})
+		if err != nil && !strings.Contains(err.Error(), "NoSuchEntity") {
+			return fmt.Errorf("failed to delete instance profile %s: %w", name, wrapAWSError("iam", "DeleteInstanceProfile", err))
+		}
+	} else if !strings.Contains(err.Error(), "NoSuchEntity") {
+		return wrapAWSError("iam", "GetInstanceProfile", err)
+	}
+
+	s.iamClient.DetachRolePolicy(ctx, &iam.DetachRolePolicyInput{
+		RoleName:  aws.String(name),
+		PolicyArn: aws.String(fmt.Sprintf("arn:%s:iam::aws:policy/AmazonSSMManagedInstanceCore", partition(s.config.Region))),
+	})
+	s.iamClient.DeleteRolePolicy(ctx, &iam.DeleteRolePolicyInput{
+		RoleName:   aws.String(name),
+		PolicyName: aws.String(clusterAccessPolicyName),
+	})
+	_, err = s.iamClient.DeleteRole(ctx, &iam.DeleteRoleInput{
+		RoleName: aws.String(name),
+	})
+	if err != nil && !strings.Contains(err.Error(), "NoSuchEntity") {
+		return fmt.Errorf("failed to delete role %s: %w", name, wrapAWSError("iam", "DeleteRole", err))
+	}
+
+	s.identityMu.Lock()
+	delete(s.identitiesReady, clusterName)
+	s.identityMu.Unlock()
+
+	logger.Infof(ctx, "Deleted IAM role and instance profile %s", name)
+	return nil
+}
+
+// instanceProfileInUse reports whether any non-terminated instance, in any
+// region goman has used, runs with the instance profile
+func (s *ComputeService) instanceProfileInUse(ctx context.Context, profileArn string) (bool, error) {
+	clients := []*ec2.Client{s.client}
+	for _, client := range s.cachedRegionClients() {
+		clients = append(clients, client)
+	}
+
+	for _, client := range clients {
+		result, err := client.DescribeInstances(ctx, &ec2.DescribeInstancesInput{
+			Filters: []types.Filter{
+				{Name: aws.String("iam-instance-profile.arn"), Values: []string{profileArn}},
+				{Name: aws.String("instance-state-name"), Values: []string{"pending", "running", "stopping", "stopped"}},
+			},
+		})
+		if err != nil {
+			return false, wrapAWSError("ec2", "DescribeInstances", err)
+		}
+		for _, reservation := range result.Reservations {
+			if len(reservation.Instances) > 0 {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
+
+// RenameClusterIdentity lets the nodes of a renamed cluster, which keep the
+// role of the old name, access the objects under the new name. The role is
+// re-tagged like the instances; the new name's own identity is created when
+// its first node is launched.
+func (p *AWSProvider) RenameClusterIdentity(ctx context.Context, oldName, newName string) error {
+	cs, ok := p.computeService.(*ComputeService)
+	if !ok {
+		return nil
+	}
+
+	name := clusterIdentityName(oldName)
+	_, err := cs.iamClient.TagRole(ctx, &iam.TagRoleInput{
+		RoleName: aws.String(name),
+		Tags: []iamTypes.Tag{
+			{Key: aws.String("goman-cluster"), Value: aws.String(newName)},
+			{Key: aws.String("goman-legacy-cluster"), Value: aws.String(oldName)},
+		},
+	})
+	if err != nil {
+		if strings.Contains(err.Error(), "NoSuchEntity") {
+			// Nodes created before per-cluster roles use the shared role
+			return nil
+		}
+		return fmt.Errorf("failed to tag role %s: %w", name, wrapAWSError("iam", "TagRole", err))
+	}
+	return cs.putClusterAccessPolicy(ctx, name, []string{newName, oldName})
+}
@@ -0,0 +1,362 @@
+package aws
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"sort"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/madhouselabs/goman/pkg/logger"
+	"github.com/madhouselabs/goman/pkg/provider"
+)
+
+// Linked clusters reach each other through their security groups. Clusters
+// in the same VPC (the default VPC of a region) allow each other's group;
+// clusters in different VPCs are connected with a VPC peering connection,
+// routes in both VPCs and rules allowing the other VPC's CIDR, since security
+// groups can't refer to groups across regions.
+
+// linkEnd is one side of a cluster link
+type linkEnd struct {
+	client  *ec2.Client
+	cluster string
+	groupID string
+	vpcID   string
+	cidr    string
+}
+
+// LinkClusters opens the link's ports between two clusters in both directions
+func (s *ComputeService) LinkClusters(ctx context.Context, link provider.ClusterLinkConfig) (*provider.ClusterLinkResult, error) {
+	local, err := s.findLinkEnd(ctx, link.Region, link.Cluster)
+	if err != nil {
+		return nil, err
+	}
+	peer, err := s.findLinkEnd(ctx, link.PeerRegion, link.PeerCluster)
+	if err != nil {
+		return nil, err
+	}
+	description := linkDescription(link.Cluster, link.PeerCluster)
+
+	if local.vpcID == peer.vpcID {
+		if err := authorizeLink(ctx, local.client, local.groupID, link.Ports, description, ipPermissionFromGroup(peer.groupID)); err != nil {
+			return nil, err
+		}
+		if err := authorizeLink(ctx, peer.client, peer.groupID, link.Ports, description, ipPermissionFromGroup(local.groupID)); err != nil {
+			return nil, err
+		}
+		logger.Infof(ctx, "Linked clusters %s and %s through their security groups", link.Cluster, link.PeerCluster)
+		return &provider.ClusterLinkResult{Mode: provider.LinkModeSecurityGroup}, nil
+	}
+
+	if !link.AllowPeering {
+		return nil, provider.UserConfigErrorf("clusters %s and %s are in different VPCs (%s, %s); set peering: true on the link to peer them",
+			link.Cluster, link.PeerCluster, local.vpcID, peer.vpcID)
+	}
+	if cidrsOverlap(local.cidr, peer.cidr) {
+		return nil, provider.UserConfigErrorf("VPCs of clusters %s (%s) and %s (%s) have overlapping CIDRs and can't be peered",
+			link.Cluster, local.cidr, link.PeerCluster, peer.cidr)
+	}
+
+	peeringID, err := s.ensureVpcPeering(ctx, link, local, peer)
+	if err != nil {
+		return nil, err
+	}
+	if err := addPeeringRoutes(ctx, local, peer.cidr, peeringID); err != nil {
+		return nil, err
+	}
+	if err := addPeeringRoutes(ctx, peer, local.cidr, peeringID); err != nil {
+		return nil, err
+	}
+	if err := authorizeLink(ctx, local.client, local.groupID, link.Ports, description, ipPermissionFromCIDR(peer.cidr)); err != nil {
+		return nil, err
+	}
+	if err := authorizeLink(ctx, peer.client, peer.groupID, link.Ports, description, ipPermissionFromCIDR(local.cidr)); err != nil {
+		return nil, err
+	}
+	logger.Infof(ctx, "Linked clusters %s and %s through VPC peering %s", link.Cluster, link.PeerCluster, peeringID)
+	return &provider.ClusterLinkResult{Mode: provider.LinkModePeering, PeeringID: peeringID}, nil
+}
+
+// UnlinkClusters revokes the rules LinkClusters added and deletes the VPC
+// peering, if any. A cluster that no longer exists has nothing left to
+// clean up on its side.
+func (s *ComputeService) UnlinkClusters(ctx context.Context, link provider.ClusterLinkConfig, peeringID string) error {
+	local, localErr := s.findLinkEnd(ctx, link.Region, link.Cluster)
+	peer, peerErr := s.findLinkEnd(ctx, link.PeerRegion, link.PeerCluster)
+	description := linkDescription(link.Cluster, link.PeerCluster)
+
+	if localErr == nil {
+		var source func(types.UserIdGroupPair, types.IpRange) types.IpPermission
+		switch {
+		case peeringID != "" && peerErr == nil:
+			source = ipPermissionFromCIDR(peer.cidr)
+		case peerErr == nil:
+			source = ipPermissionFromGroup(peer.groupID)
+		}
+		if source != nil {
+			if err := revokeLink(ctx, local.client, local.groupID, link.Ports, description, source); err != nil {
+				return err
+			}
+		}
+	}
+	if peerErr == nil && localErr == nil {
+		source := ipPermissionFromGroup(local.groupID)
+		if peeringID != "" {
+			source = ipPermissionFromCIDR(local.cidr)
+		}
+		if err := revokeLink(ctx, peer.client, peer.groupID, link.Ports, description, source); err != nil {
+			return err
+		}
+	}
+
+	if peeringID == "" {
+		return nil
+	}
+	if localErr == nil && peerErr == nil {
+		deletePeeringRoutes(ctx, local, peer.cidr, peeringID)
+		deletePeeringRoutes(ctx, peer, local.cidr, peeringID)
+	}
+	client := s.getEC2Client(link.Region)
+	_, err := client.DeleteVpcPeeringConnection(ctx, &ec2.DeleteVpcPeeringConnectionInput{
+		VpcPeeringConnectionId: aws.String(peeringID),
+	})
+	if err != nil && !hasErrorCode(err, "InvalidVpcPeeringConnectionID.NotFound") {
+		return fmt.Errorf("failed to delete VPC peering %s: %w", peeringID, wrapAWSError("ec2", "DeleteVpcPeeringConnection", err))
+	}
+	logger.Infof(ctx, "Deleted VPC peering %s between clusters %s and %s", peeringID, link.Cluster, link.PeerCluster)
+	return nil
+}
+
+// findLinkEnd finds the security group and VPC of a cluster
+func (s *ComputeService) findLinkEnd(ctx context.Context, region, clusterName string) (*linkEnd, error) {
+	client := s.getEC2Client(region)
+	groups, err := client.DescribeSecurityGroups(ctx, &ec2.DescribeSecurityGroupsInput{
+		Filters: []types.Filter{
+			{Name: aws.String("tag:Cluster"), Values: []string{clusterName}},
+			{Name: aws.String("tag:ManagedBy"), Values: []string{"goman"}},
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to find security group of %s: %w", clusterName, wrapAWSError("ec2", "DescribeSecurityGroups", err))
+	}
+	if len(groups.SecurityGroups) == 0 {
+		return nil, fmt.Errorf("cluster %s has no security group in %s yet", clusterName, region)
+	}
+	group := groups.SecurityGroups[0]
+
+	vpcs, err := client.DescribeVpcs(ctx, &ec2.DescribeVpcsInput{VpcIds: []string{aws.ToString(group.VpcId)}})
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe VPC of %s: %w", clusterName, wrapAWSError("ec2", "DescribeVpcs", err))
+	}
+	if len(vpcs.Vpcs) == 0 {
+		return nil, fmt.Errorf("VPC %s of cluster %s not found", aws.ToString(group.VpcId), clusterName)
+	}
+
+	return &linkEnd{
+		client:  client,
+		cluster: clusterName,
+		groupID: aws.ToString(group.GroupId),
+		vpcID:   aws.ToString(group.VpcId),
+		cidr:    aws.ToString(vpcs.Vpcs[0].CidrBlock),
+	}, nil
+}
+
+// ensureVpcPeering returns the active peering between the two VPCs,
+// requesting and accepting one if needed
+func (s *ComputeService) ensureVpcPeering(ctx context.Context, link provider.ClusterLinkConfig, local, peer *linkEnd) (string, error) {
+	existing, err := local.client.DescribeVpcPeeringConnections(ctx, &ec2.DescribeVpcPeeringConnectionsInput{
+		Filters: []types.Filter{
+			{Name: aws.String("requester-vpc-info.vpc-id"), Values: []string{local.vpcID}},
+			{Name: aws.String("accepter-vpc-info.vpc-id"), Values: []string{peer.vpcID}},
+			{Name: aws.String("status-code"), Values: []string{"active", "pending-acceptance", "initiating-request", "provisioning"}},
+		},
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to describe VPC peerings: %w", wrapAWSError("ec2", "DescribeVpcPeeringConnections", err))
+	}
+
+	var peeringID string
+	var state types.VpcPeeringConnectionStateReasonCode
+	if len(existing.VpcPeeringConnections) > 0 {
+		pc := existing.VpcPeeringConnections[0]
+		peeringID = aws.ToString(pc.VpcPeeringConnectionId)
+		if pc.Status != nil {
+			state = pc.Status.Code
+		}
+	} else {
+		input := &ec2.CreateVpcPeeringConnectionInput{
+			VpcId:     aws.String(local.vpcID),
+			PeerVpcId: aws.String(peer.vpcID),
+			TagSpecifications: []types.TagSpecification{{
+				ResourceType: types.ResourceTypeVpcPeeringConnection,
+				Tags: []types.Tag{
+					{Key: aws.String("Name"), Value: aws.String(linkDescription(link.Cluster, link.PeerCluster))},
+					{Key: aws.String("ManagedBy"), Value: aws.String("goman")},
+					{Key: aws.String("Cluster"), Value: aws.String(link.Cluster)},
+				},
+			}},
+		}
+		if link.PeerRegion != link.Region {
+			input.PeerRegion = aws.String(link.PeerRegion)
+		}
+		created, err := local.client.CreateVpcPeeringConnection(ctx, input)
+		if err != nil {
+			return "", fmt.Errorf("failed to request VPC peering: %w", wrapAWSError("ec2", "CreateVpcPeeringConnection", err))
+		}
+		peeringID = aws.ToString(created.VpcPeeringConnection.VpcPeeringConnectionId)
+		state = types.VpcPeeringConnectionStateReasonCodeInitiatingRequest
+		logger.Infof(ctx, "Requested VPC peering %s between %s and %s", peeringID, local.vpcID, peer.vpcID)
+	}
+
+	if state == types.VpcPeeringConnectionStateReasonCodeActive {
+		return peeringID, nil
+	}
+	// Cross-region requests take a moment to show up in the accepter region;
+	// the error is retried with the next reconcile
+	_, err = peer.client.AcceptVpcPeeringConnection(ctx, &ec2.AcceptVpcPeeringConnectionInput{
+		VpcPeeringConnectionId: aws.String(peeringID),
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to accept VPC peering %s: %w", peeringID, wrapAWSError("ec2", "AcceptVpcPeeringConnection", err))
+	}
+	return peeringID, nil
+}
+
+// addPeeringRoutes routes the other VPC's CIDR through the peering in all
+// route tables of a VPC
+func addPeeringRoutes(ctx context.Context, end *linkEnd, destination, peeringID string) error {
+	tables, err := routeTables(ctx, end)
+	if err != nil {
+		return err
+	}
+	for _, table := range tables {
+		_, err := end.client.CreateRoute(ctx, &ec2.CreateRouteInput{
+			RouteTableId:           table.RouteTableId,
+			DestinationCidrBlock:   aws.String(destination),
+			VpcPeeringConnectionId: aws.String(peeringID),
+		})
+		if err != nil && !hasErrorCode(err, "RouteAlreadyExists") {
+			return fmt.Errorf("failed to add route to %s: %w", destination, wrapAWSError("ec2", "CreateRoute", err))
+		}
+	}
+	return nil
+}
+
+// deletePeeringRoutes removes the routes through a peering. Failures only
+// leave a blackhole route behind, so they are logged.
+func deletePeeringRoutes(ctx context.Context, end *linkEnd, destination, peeringID string) {
+	tables, err := routeTables(ctx, end)
+	if err != nil {
+		logger.Warnf(ctx, "%v", err)
+		return
+	}
+	for _, table := range tables {
+		for _, route := range table.Routes {
+			if aws.ToString(route.VpcPeeringConnectionId) != peeringID || aws.ToString(route.DestinationCidrBlock) != destination {
+				continue
+			}
+			_, err := end.client.DeleteRoute(ctx, &ec2.DeleteRouteInput{
+				RouteTableId:         table.RouteTableId,
+				DestinationCidrBlock: aws.String(destination),
+			})
+			if err != nil {
+				logger.Warnf(ctx, "Failed to delete route to %s from %s: %v", destination, aws.ToString(table.RouteTableId), err)
+			}
+		}
+	}
+}
+
+func routeTables(ctx context.Context, end *linkEnd) ([]types.RouteTable, error) {
+	resp, err := end.client.DescribeRouteTables(ctx, &ec2.DescribeRouteTablesInput{
+		Filters: []types.Filter{{Name: aws.String("vpc-id"), Values: []string{end.vpcID}}},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe route tables of %s: %w", end.vpcID, wrapAWSError("ec2", "DescribeRouteTables", err))
+	}
+	return resp.RouteTables, nil
+}
+
+// ipPermissionFromGroup builds rules allowing a security group
+func ipPermissionFromGroup(groupID string) func(types.UserIdGroupPair, types.IpRange) types.IpPermission {
+	return func(pair types.UserIdGroupPair, _ types.IpRange) types.IpPermission {
+		pair.GroupId = aws.String(groupID)
+		return types.IpPermission{UserIdGroupPairs: []types.UserIdGroupPair{pair}}
+	}
+}
+
+// ipPermissionFromCIDR builds rules allowing a CIDR
+func ipPermissionFromCIDR(cidr string) func(types.UserIdGroupPair, types.IpRange) types.IpPermission {
+	return func(_ types.UserIdGroupPair, ipRange types.IpRange) types.IpPermission {
+		ipRange.CidrIp = aws.String(cidr)
+		return types.IpPermission{IpRanges: []types.IpRange{ipRange}}
+	}
+}
+
+// linkPermissions builds one rule per port range from a source
+func linkPermissions(ports []provider.PortRange, description string, source func(types.UserIdGroupPair, types.IpRange) types.IpPermission) []types.IpPermission {
+	perms := make([]types.IpPermission, 0, len(ports))
+	for _, p := range ports {
+		perm := source(types.UserIdGroupPair{Description: aws.String(description)}, types.IpRange{Description: aws.String(description)})
+		perm.IpProtocol = aws.String(p.Protocol)
+		perm.FromPort = aws.Int32(int32(p.FromPort))
+		perm.ToPort = aws.Int32(int32(p.ToPort))
+		perms = append(perms, perm)
+	}
+	return perms
+}
+
+// authorizeLink adds the link's rules to a security group, one call per port
+// range so rules that already exist don't keep the others from being added
+func authorizeLink(ctx context.Context, client *ec2.Client, groupID string, ports []provider.PortRange, description string, source func(types.UserIdGroupPair, types.IpRange) types.IpPermission) error {
+	for _, perm := range linkPermissions(ports, description, source) {
+		_, err := client.AuthorizeSecurityGroupIngress(ctx, &ec2.AuthorizeSecurityGroupIngressInput{
+			GroupId:       aws.String(groupID),
+			IpPermissions: []types.IpPermission{perm},
+		})
+		if err != nil && !hasErrorCode(err, "InvalidPermission.Duplicate") {
+			return fmt.Errorf("failed to open ports on %s: %w", groupID, wrapAWSError("ec2", "AuthorizeSecurityGroupIngress", err))
+		}
+	}
+	return nil
+}
+
+// revokeLink removes the link's rules from a security group
+func revokeLink(ctx context.Context, client *ec2.Client, groupID string, ports []provider.PortRange, description string, source func(types.UserIdGroupPair, types.IpRange) types.IpPermission) error {
+	for _, perm := range linkPermissions(ports, description, source) {
+		_, err := client.RevokeSecurityGroupIngress(ctx, &ec2.RevokeSecurityGroupIngressInput{
+			GroupId:       aws.String(groupID),
+			IpPermissions: []types.IpPermission{perm},
+		})
+		if err != nil && !hasErrorCode(err, "InvalidPermission.NotFound") {
+			return fmt.Errorf("failed to close ports on %s: %w", groupID, wrapAWSError("ec2", "RevokeSecurityGroupIngress", err))
+		}
+	}
+	return nil
+}
+
+// linkDescription names a link the same way from both of its clusters
+func linkDescription(a, b string) string {
+	names := []string{a, b}
+	sort.Strings(names)
+	return fmt.Sprintf("goman link %s-%s", names[0], names[1])
+}
+
+// cidrsOverlap reports whether two CIDR blocks share addresses
+func cidrsOverlap(a, b string) bool {
+	_, netA, errA := net.ParseCIDR(a)
+	_, netB, errB := net.ParseCIDR(b)
+	if errA != nil || errB != nil {
+		return a == b
+	}
+	return netA.Contains(netB.IP) || netB.Contains(netA.IP)
+}
+
+// hasErrorCode reports whether err is an AWS API error with the given code
+func hasErrorCode(err error, code string) bool {
+	var coded interface{ ErrorCode() string }
+	return errors.As(err, &coded) && coded.ErrorCode() == code
+}
@@ -0,0 +1,75 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/madhouselabs/goman/pkg/logger"
+	"github.com/madhouselabs/goman/pkg/provider"
+)
+
+// DeleteClusterSecurityGroups deletes the security groups goman created for
+// a cluster, found by their Cluster tag so renamed clusters' groups are
+// found too. Network interfaces of terminated instances can linger in a
+// group for minutes: detached ones are deleted, attached ones make the group
+// wait for a later call.
+func (s *ComputeService) DeleteClusterSecurityGroups(ctx context.Context, region, clusterName string) error {
+	ec2Client := s.getEC2Client(region)
+
+	groups, err := ec2Client.DescribeSecurityGroups(ctx, &ec2.DescribeSecurityGroupsInput{
+		Filters: []types.Filter{
+			{Name: aws.String("tag:Cluster"), Values: []string{clusterName}},
+			{Name: aws.String("tag:ManagedBy"), Values: []string{"goman"}},
+		},
+	})
+	if err != nil {
+		return wrapAWSError("ec2", "DescribeSecurityGroups", err)
+	}
+
+	var inUse []string
+	for _, group := range groups.SecurityGroups {
+		groupID := aws.ToString(group.GroupId)
+		interfaces, err := ec2Client.DescribeNetworkInterfaces(ctx, &ec2.DescribeNetworkInterfacesInput{
+			Filters: []types.Filter{{Name: aws.String("group-id"), Values: []string{groupID}}},
+		})
+		if err != nil {
+			return wrapAWSError("ec2", "DescribeNetworkInterfaces", err)
+		}
+		attached := false
+		for _, eni := range interfaces.NetworkInterfaces {
+			if eni.Status != types.NetworkInterfaceStatusAvailable {
+				attached = true
+				continue
+			}
+			logger.Infof(ctx, "Deleting detached network interface %s of security group %s", aws.ToString(eni.NetworkInterfaceId), groupID)
+			_, err := ec2Client.DeleteNetworkInterface(ctx, &ec2.DeleteNetworkInterfaceInput{NetworkInterfaceId: eni.NetworkInterfaceId})
+			if err != nil && !hasErrorCode(err, "InvalidNetworkInterfaceID.NotFound") {
+				return fmt.Errorf("failed to delete network interface %s: %w", aws.ToString(eni.NetworkInterfaceId), wrapAWSError("ec2", "DeleteNetworkInterface", err))
+			}
+		}
+		if attached {
+			inUse = append(inUse, groupID)
+			continue
+		}
+
+		_, err = ec2Client.DeleteSecurityGroup(ctx, &ec2.DeleteSecurityGroupInput{GroupId: group.GroupId})
+		switch {
+		case err == nil:
+			logger.Infof(ctx, "Deleted security group %s (%s) of cluster %s", aws.ToString(group.GroupName), groupID, clusterName)
+		case hasErrorCode(err, "InvalidGroup.NotFound"):
+		case hasErrorCode(err, "DependencyViolation"):
+			inUse = append(inUse, groupID)
+		default:
+			return fmt.Errorf("failed to delete security group %s: %w", groupID, wrapAWSError("ec2", "DeleteSecurityGroup", err))
+		}
+	}
+
+	if len(inUse) > 0 {
+		return fmt.Errorf("%w: security groups %s", provider.ErrNetworkInUse, strings.Join(inUse, ", "))
+	}
+	return nil
+}
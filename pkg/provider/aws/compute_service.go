@@ -5,7 +5,9 @@ import (
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
+	"sort"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
@@ -13,9 +15,13 @@ import (
 	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
 	"github.com/aws/aws-sdk-go-v2/service/iam"
 	iamTypes "github.com/aws/aws-sdk-go-v2/service/iam/types"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
 	"github.com/aws/aws-sdk-go-v2/service/ssm"
+	gomanconfig "github.com/madhouselabs/goman/pkg/config"
 	"github.com/madhouselabs/goman/pkg/logger"
+	"github.com/madhouselabs/goman/pkg/models"
 	"github.com/madhouselabs/goman/pkg/provider"
+	"github.com/madhouselabs/goman/pkg/provider/aws/userdata"
 	"github.com/madhouselabs/goman/pkg/utils"
 )
 
@@ -24,11 +30,30 @@ type ComputeService struct {
 	client          *ec2.Client
 	ssmClient       *ssm.Client
 	iamClient       *iam.Client
+	s3Client        *s3.Client
 	config          aws.Config
 	instanceProfile string
 	accountID       string
+	regionMu         sync.Mutex             // Guards regionClients and instanceRegions
 	regionClients    map[string]*ec2.Client // Cache of region-specific EC2 clients
+	instanceRegions  map[string]string      // Instance ID -> region
 	regionSSMClients map[string]*ssm.Client // Cache of region-specific SSM clients
+	documentsMu      sync.Mutex
+	documentsReady   map[string]bool // Regions where managed SSM documents are current
+
+	sessionLoggingMu     sync.Mutex
+	sessionLoggingCfg    provider.SessionLoggingConfig
+	sessionLoggingLoaded time.Time
+	instanceClusters     map[string]string // Instance ID -> goman-cluster tag
+
+	identityMu      sync.Mutex
+	identitiesReady map[string]bool // Clusters whose node identity is set up
+
+	binariesMu    sync.Mutex
+	binariesReady map[string]bool // K3s versions whose binaries are staged
+
+	privateNetworkMu sync.Mutex // Serializes setting up the private subnets
+	dedicatedVPCMu   sync.Mutex // Serializes setting up and deleting dedicated VPCs
 }
 
 // NewComputeService creates a new EC2-based compute service
@@ -37,11 +62,17 @@ func NewComputeService(client *ec2.Client, iamClient *iam.Client, cfg aws.Config
 		client:          client,
 		ssmClient:       ssm.NewFromConfig(cfg),
 		iamClient:       iamClient,
+		s3Client:        newS3Client(cfg),
 		config:          cfg,
 		instanceProfile: "goman-ssm-instance-profile",
 		accountID:       accountID,
 		regionClients:    make(map[string]*ec2.Client),
+		instanceRegions:  make(map[string]string),
 		regionSSMClients: make(map[string]*ssm.Client),
+		documentsReady:   make(map[string]bool),
+		instanceClusters: make(map[string]string),
+		identitiesReady:  make(map[string]bool),
+		binariesReady:    make(map[string]bool),
 	}
 }
 
@@ -51,6 +82,10 @@ func (s *ComputeService) Initialize(ctx context.Context) error {
 	if err := s.ensureSSMInstanceProfile(ctx); err != nil {
 		return fmt.Errorf("failed to ensure SSM instance profile: %w", err)
 	}
+	// Allow instances to write full command output to S3
+	if err := s.ensureCommandOutputPolicy(ctx); err != nil {
+		return fmt.Errorf("failed to ensure SSM command output policy: %w", err)
+	}
 	return nil
 }
 
@@ -58,18 +93,21 @@ func (s *ComputeService) Initialize(ctx context.Context) error {
 func (s *ComputeService) getEC2Client(region string) *ec2.Client {
 	// If no region specified, use default client
 	if region == "" {
-		logger.Printf("Warning: No region specified, using default client for region: %s", s.config.Region)
+		logger.Warnf(context.Background(), "No region specified, using default client for region: %s", s.config.Region)
 		return s.client
 	}
 
+	s.regionMu.Lock()
+	defer s.regionMu.Unlock()
+
 	// Check cache first
 	if client, exists := s.regionClients[region]; exists {
-		logger.Printf("Using cached EC2 client for region: %s", region)
+		logger.Debugf(context.Background(), "Using cached EC2 client for region: %s", region)
 		return client
 	}
 
 	// Create new client for the region
-	logger.Printf("Creating new EC2 client for region: %s", region)
+	logger.Debugf(context.Background(), "Creating new EC2 client for region: %s", region)
 	cfg := s.config.Copy()
 	cfg.Region = region
 	client := ec2.NewFromConfig(cfg)
@@ -78,48 +116,100 @@ func (s *ComputeService) getEC2Client(region string) *ec2.Client {
 	return client
 }
 
-// detectInstanceRegion detects which region an instance is in
+// cachedRegionClients returns the region-specific EC2 clients created so far
+func (s *ComputeService) cachedRegionClients() map[string]*ec2.Client {
+	s.regionMu.Lock()
+	defer s.regionMu.Unlock()
+	clients := make(map[string]*ec2.Client, len(s.regionClients))
+	for region, client := range s.regionClients {
+		clients[region] = client
+	}
+	return clients
+}
+
+// RememberInstanceRegion records the region of an instance, so instance
+// calls go to that region without looking it up
+func (s *ComputeService) RememberInstanceRegion(instanceID, region string) {
+	if instanceID == "" || region == "" {
+		return
+	}
+	s.regionMu.Lock()
+	s.instanceRegions[instanceID] = region
+	s.regionMu.Unlock()
+}
+
+// detectInstanceRegion detects which region an instance is in. A region
+// remembered for the instance is used as is; otherwise the default region
+// and the regions with cached clients are searched, and the result is
+// remembered.
 func (s *ComputeService) detectInstanceRegion(ctx context.Context, instanceID string) string {
+	s.regionMu.Lock()
+	region, known := s.instanceRegions[instanceID]
+	s.regionMu.Unlock()
+	if known {
+		return region
+	}
+
 	// First try the default region (most likely case)
 	result, err := s.client.DescribeInstances(ctx, &ec2.DescribeInstancesInput{
 		InstanceIds: []string{instanceID},
 	})
 	if err == nil && len(result.Reservations) > 0 && len(result.Reservations[0].Instances) > 0 {
-		logger.Printf("Found instance %s in default region %s", instanceID, s.config.Region)
+		logger.Infof(ctx, "Found instance %s in default region %s", instanceID, s.config.Region)
+		s.RememberInstanceRegion(instanceID, s.config.Region)
 		return s.config.Region
 	}
 	
 	// Then check cached region clients
-	for region, client := range s.regionClients {
+	for region, client := range s.cachedRegionClients() {
 		result, err := client.DescribeInstances(ctx, &ec2.DescribeInstancesInput{
 			InstanceIds: []string{instanceID},
 		})
 		if err == nil && len(result.Reservations) > 0 && len(result.Reservations[0].Instances) > 0 {
-			logger.Printf("Found instance %s in cached region %s", instanceID, region)
+			logger.Infof(ctx, "Found instance %s in cached region %s", instanceID, region)
+			s.RememberInstanceRegion(instanceID, region)
 			return region
 		}
 	}
 	
-	logger.Printf("Could not detect region for instance %s", instanceID)
+	logger.Warnf(ctx, "Could not detect region for instance %s", instanceID)
 	return ""
 }
 
+// instanceClient returns the EC2 client for the region an instance is in,
+// the default client if the region is unknown
+func (s *ComputeService) instanceClient(ctx context.Context, instanceID string) *ec2.Client {
+	region := s.detectInstanceRegion(ctx, instanceID)
+	if region == "" || region == s.config.Region {
+		return s.client
+	}
+	return s.getEC2Client(region)
+}
+
+// regionOrDefault is the region a call without one goes to
+func (s *ComputeService) regionOrDefault(region string) string {
+	if region == "" {
+		return s.config.Region
+	}
+	return region
+}
+
 // getSSMClient returns an SSM client for the specified region
 func (s *ComputeService) getSSMClient(region string) *ssm.Client {
 	// If no region specified, use default client
 	if region == "" {
-		logger.Printf("Warning: No region specified, using default SSM client for region: %s", s.config.Region)
+		logger.Warnf(context.Background(), "No region specified, using default SSM client for region: %s", s.config.Region)
 		return s.ssmClient
 	}
 
 	// Check cache first
 	if client, exists := s.regionSSMClients[region]; exists {
-		logger.Printf("Using cached SSM client for region: %s", region)
+		logger.Debugf(context.Background(), "Using cached SSM client for region: %s", region)
 		return client
 	}
 
 	// Create new client for the region
-	logger.Printf("Creating new SSM client for region: %s", region)
+	logger.Debugf(context.Background(), "Creating new SSM client for region: %s", region)
 	cfg := s.config.Copy()
 	cfg.Region = region
 	client := ssm.NewFromConfig(cfg)
@@ -174,61 +264,19 @@ func (s *ComputeService) ensureSSMInstanceProfile(ctx context.Context) error {
 		// Attach SSM managed policy
 		_, err = s.iamClient.AttachRolePolicy(ctx, &iam.AttachRolePolicyInput{
 			RoleName:  aws.String(roleName),
-			PolicyArn: aws.String("arn:aws:iam::aws:policy/AmazonSSMManagedInstanceCore"),
+			PolicyArn: aws.String(fmt.Sprintf("arn:%s:iam::aws:policy/AmazonSSMManagedInstanceCore", partition(s.config.Region))),
 		})
 		if err != nil {
 			return fmt.Errorf("failed to attach SSM policy: %w", err)
 		}
+	}
 
-		// Create and attach custom policy for S3 access to K3s binaries
-		policyName := fmt.Sprintf("goman-instance-s3-policy-%s", s.accountID)
-		bucketName := fmt.Sprintf("goman-%s", s.accountID)
-		
-		// Create the policy document for S3 read access
-		policyDoc := map[string]interface{}{
-			"Version": "2012-10-17",
-			"Statement": []map[string]interface{}{
-				{
-					"Effect": "Allow",
-					"Action": []string{
-						"s3:GetObject",
-						"s3:ListBucket",
-					},
-					"Resource": []string{
-						fmt.Sprintf("arn:aws:s3:::%s/*", bucketName),
-						fmt.Sprintf("arn:aws:s3:::%s", bucketName),
-					},
-				},
-			},
-		}
-
-		policyJSON, err := json.Marshal(policyDoc)
-		if err != nil {
-			return fmt.Errorf("failed to marshal S3 policy: %w", err)
-		}
-
-		// Try to create the policy
-		_, err = s.iamClient.CreatePolicy(ctx, &iam.CreatePolicyInput{
-			PolicyName:     aws.String(policyName),
-			PolicyDocument: aws.String(string(policyJSON)),
-			Description:    aws.String("Policy for goman instances to access K3s binaries in S3"),
-		})
-		if err != nil {
-			// Policy might already exist, that's okay
-			if !strings.Contains(err.Error(), "EntityAlreadyExists") {
-				logger.Printf("Warning: Failed to create S3 policy: %v", err)
-			}
-		}
-
-		// Attach the custom S3 policy
-		_, err = s.iamClient.AttachRolePolicy(ctx, &iam.AttachRolePolicyInput{
-			RoleName:  aws.String(roleName),
-			PolicyArn: aws.String(fmt.Sprintf("arn:aws:iam::%s:policy/%s", s.accountID, policyName)),
-		})
-		if err != nil {
-			// Don't fail if policy attachment fails, instances can still work without S3 access
-			logger.Printf("Warning: Failed to attach S3 policy: %v (instances will fallback to GitHub downloads)", err)
-		}
+	// Scope the role to the state bucket's binaries and cluster prefixes.
+	// Applied on every init so roles created with the former bucket-wide
+	// policy are narrowed.
+	if err := s.putSharedAccessPolicy(ctx, roleName); err != nil {
+		// Don't fail, instances can still work without S3 access
+		logger.Warnf(ctx, "Failed to apply S3 policy: %v (instances will fallback to GitHub downloads)", err)
 	}
 
 	// Check if instance profile exists
@@ -281,42 +329,127 @@ func (s *ComputeService) ensureSSMInstanceProfile(ctx context.Context) error {
 	return nil
 }
 
-// getLatestAmazonLinux2AMI gets the latest Amazon Linux 2 AMI for the specified region
-func (s *ComputeService) getLatestAmazonLinux2AMI(ctx context.Context, region string) (string, error) {
-	// Use SSM Parameter Store to get the latest Amazon Linux 2 AMI
+// putSharedAccessPolicy limits the shared instance role to the K3s binaries
+// and cluster objects in the state bucket and detaches the bucket-wide
+// policy earlier versions created. Only instances launched before per-cluster
+// roles still use this role.
+func (s *ComputeService) putSharedAccessPolicy(ctx context.Context, roleName string) error {
+	bucketName := stateBucketName(s.accountID)
+	policyDoc := map[string]interface{}{
+		"Version": "2012-10-17",
+		"Statement": []map[string]interface{}{
+			{
+				"Effect": "Allow",
+				"Action": []string{"s3:GetObject"},
+				"Resource": []string{
+					fmt.Sprintf("arn:%s:s3:::%s/binaries/*", partition(s.config.Region), bucketName),
+					fmt.Sprintf("arn:%s:s3:::%s/clusters/*", partition(s.config.Region), bucketName),
+				},
+			},
+			{
+				"Effect":   "Allow",
+				"Action":   []string{"s3:ListBucket"},
+				"Resource": fmt.Sprintf("arn:%s:s3:::%s", partition(s.config.Region), bucketName),
+				"Condition": map[string]interface{}{
+					"StringLike": map[string]interface{}{
+						"s3:prefix": []string{"binaries/*", "clusters/*"},
+					},
+				},
+			},
+		},
+	}
+
+	policyJSON, err := json.Marshal(policyDoc)
+	if err != nil {
+		return fmt.Errorf("failed to marshal S3 policy: %w", err)
+	}
+
+	_, err = s.iamClient.PutRolePolicy(ctx, &iam.PutRolePolicyInput{
+		RoleName:       aws.String(roleName),
+		PolicyName:     aws.String(clusterAccessPolicyName),
+		PolicyDocument: aws.String(string(policyJSON)),
+	})
+	if err != nil {
+		return wrapAWSError("iam", "PutRolePolicy", err)
+	}
+
+	// Remove the bucket-wide managed policy of earlier versions
+	legacyPolicyArn := fmt.Sprintf("arn:%s:iam::%s:policy/goman-instance-s3-policy-%s", partition(s.config.Region), s.accountID, s.accountID)
+	if _, err := s.iamClient.DetachRolePolicy(ctx, &iam.DetachRolePolicyInput{
+		RoleName:  aws.String(roleName),
+		PolicyArn: aws.String(legacyPolicyArn),
+	}); err == nil {
+		s.iamClient.DeletePolicy(ctx, &iam.DeletePolicyInput{PolicyArn: aws.String(legacyPolicyArn)})
+		logger.Infof(ctx, "Replaced bucket-wide instance policy with scoped policy on %s", roleName)
+	}
+	return nil
+}
+
+// amiParameters are the SSM parameters of the latest image of each OS
+// family, by architecture
+var amiParameters = map[string]map[string]string{
+	models.OSAmazonLinux2: {
+		models.ArchitectureX86_64: "/aws/service/ami-amazon-linux-latest/amzn2-ami-hvm-x86_64-gp2",
+		models.ArchitectureARM64:  "/aws/service/ami-amazon-linux-latest/amzn2-ami-hvm-arm64-gp2",
+	},
+	models.OSAmazonLinux2023: {
+		models.ArchitectureX86_64: "/aws/service/ami-amazon-linux-latest/al2023-ami-kernel-default-x86_64",
+		models.ArchitectureARM64:  "/aws/service/ami-amazon-linux-latest/al2023-ami-kernel-default-arm64",
+	},
+	models.OSUbuntu2204: {
+		models.ArchitectureX86_64: "/aws/service/canonical/ubuntu/server/22.04/stable/current/amd64/hvm/ebs-gp2/ami-id",
+		models.ArchitectureARM64:  "/aws/service/canonical/ubuntu/server/22.04/stable/current/arm64/hvm/ebs-gp2/ami-id",
+	},
+}
+
+// getLatestAMI gets the latest AMI of an OS family and architecture,
+// Amazon Linux 2 and x86_64 if empty, for the specified region. Amazon
+// Linux 2 falls back to Ubuntu where its parameter doesn't exist; the OS
+// family of the image found is returned with it.
+func (s *ComputeService) getLatestAMI(ctx context.Context, region, osFamily, architecture string) (string, string, error) {
+	// Use SSM Parameter Store to get the latest image
 	// AWS publishes these parameters in all regions
 	ssmClient := ssm.NewFromConfig(s.config.Copy(), func(o *ssm.Options) {
 		o.Region = region
 	})
 
-	// Parameter path for Amazon Linux 2 (has SSM agent pre-installed and configured)
-	parameterName := "/aws/service/ami-amazon-linux-latest/amzn2-ami-hvm-x86_64-gp2"
+	if osFamily == "" {
+		osFamily = models.OSAmazonLinux2
+	}
+	if architecture == "" {
+		architecture = models.ArchitectureX86_64
+	}
+	parameterName := amiParameters[osFamily][architecture]
+	if parameterName == "" {
+		return "", "", fmt.Errorf("no %s image of OS family %s", architecture, osFamily)
+	}
 
 	result, err := ssmClient.GetParameter(ctx, &ssm.GetParameterInput{
 		Name: aws.String(parameterName),
 	})
 
-	if err != nil {
-		logger.Printf("Failed to get Amazon Linux 2 AMI from SSM for region %s: %v", region, err)
+	if err != nil && osFamily == models.OSAmazonLinux2 {
+		logger.Warnf(ctx, "Failed to get Amazon Linux 2 AMI from SSM for region %s: %v", region, err)
 		// Fallback to Ubuntu if Amazon Linux 2 parameter doesn't exist
-		parameterName = "/aws/service/canonical/ubuntu/server/22.04/stable/current/amd64/hvm/ebs-gp2/ami-id"
+		osFamily = models.OSUbuntu2204
+		parameterName = amiParameters[osFamily][architecture]
 		result, err = ssmClient.GetParameter(ctx, &ssm.GetParameterInput{
 			Name: aws.String(parameterName),
 		})
-		if err != nil {
-			return "", fmt.Errorf("failed to get AMI from SSM Parameter Store: %w", err)
-		}
+	}
+	if err != nil {
+		return "", "", fmt.Errorf("failed to get AMI from SSM Parameter Store: %w", err)
 	}
 
 	amiID := aws.ToString(result.Parameter.Value)
-	logger.Printf("Using AMI %s for region %s", amiID, region)
-	return amiID, nil
+	logger.Infof(ctx, "Using AMI %s (%s) for region %s", amiID, parameterName, region)
+	return amiID, osFamily, nil
 }
 
 // CreateInstance creates a new EC2 instance with retry logic
 func (s *ComputeService) CreateInstance(ctx context.Context, config provider.InstanceConfig) (*provider.Instance, error) {
 	// Log the target region for debugging
-	logger.Printf("Creating instance %s in region: %s", config.Name, config.Region)
+	logger.Infof(ctx, "Creating instance %s in region: %s", config.Name, config.Region)
 
 	// Get region-specific EC2 client
 	ec2Client := s.getEC2Client(config.Region)
@@ -336,28 +469,79 @@ func (s *ComputeService) CreateInstance(ctx context.Context, config provider.Ins
 		Key:   aws.String("Name"),
 		Value: aws.String(config.Name),
 	})
+	ec2Tags = append(ec2Tags, s.installTag())
+
+	// User tags never override goman's own
+	var userTags []types.Tag
+	for k, v := range config.ResourceTags {
+		if _, ok := config.Tags[k]; ok || k == "Name" {
+			continue
+		}
+		userTags = append(userTags, types.Tag{Key: aws.String(k), Value: aws.String(v)})
+	}
+	ec2Tags = append(ec2Tags, userTags...)
+
+	// Clusters with a dedicated VPC launch in its public subnets, or its
+	// private ones for private clusters
+	vpcID, subnetIDs := config.VpcID, config.SubnetIDs
+	if config.DedicatedVPC {
+		dedicated, err := s.ensureDedicatedVPC(ctx, ec2Client, config.Region, config.Tags["goman-cluster"], config.VpcCIDR, config.AvailabilityZone, config.PrivateNetwork)
+		if err != nil {
+			return nil, fmt.Errorf("failed to ensure dedicated VPC in region %s: %w", config.Region, err)
+		}
+		vpcID, subnetIDs = dedicated.VPCID, dedicated.PublicSubnets
+		if config.PrivateNetwork {
+			subnetIDs = dedicated.PrivateSubnets
+		}
+	}
 
 	// HARD RULE: Always ensure network infrastructure in the target region
-	// This ensures we use the default VPC in the specified region
-	networkInfo, err := s.ensureNetworkInfrastructure(ctx, config.Name, config.Region)
+	// This ensures we use the default VPC in the specified region, or the
+	// cluster's own VPC
+	networkInfo, err := s.ensureNetworkInfrastructure(ctx, config.Name, config.Region, config.AvailabilityZone, vpcID, subnetIDs)
 	if err != nil {
 		return nil, fmt.Errorf("failed to ensure network infrastructure in region %s: %w", config.Region, err)
 	}
 
 	// Always use the network info from the target region
 	config.SubnetID = networkInfo.SubnetID
-	config.SecurityGroups = []string{networkInfo.SecurityGroupID}
+	config.SecurityGroups = append([]string{networkInfo.SecurityGroupID}, config.ExtraSecurityGroups...)
 
-	// Always use Amazon Linux 2 AMI for AWS (provider-specific decision)
-	amiID, err := s.getLatestAmazonLinux2AMI(ctx, config.Region)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get AMI for region %s: %w", config.Region, err)
+	// Nodes of private clusters get no public IP: they launch in a private
+	// subnet of the same zone, which reaches out through a NAT gateway.
+	// Subnets chosen for the cluster are used as they are.
+	if config.PrivateNetwork && len(subnetIDs) == 0 {
+		subnetID, err := s.ensurePrivateSubnet(ctx, ec2Client, config.Region, networkInfo)
+		if err != nil {
+			return nil, fmt.Errorf("failed to ensure private subnet in %s: %w", networkInfo.AvailabilityZone, err)
+		}
+		config.SubnetID = subnetID
+	}
+
+	// Nodes run a custom image as it is, or the latest image of their OS
+	// family, Amazon Linux 2 by default (provider-specific decision)
+	if config.Architecture == "" {
+		config.Architecture = models.InstanceArchitecture(config.InstanceType)
+	}
+	if config.ImageID == "" {
+		config.ImageID, config.OSFamily, err = s.getLatestAMI(ctx, config.Region, config.OSFamily, config.Architecture)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get AMI for region %s: %w", config.Region, err)
+		}
 	}
-	config.ImageID = amiID
 	
-	// AWS-specific: Always use SSM instance profile
+	// AWS-specific: Nodes use their cluster's instance profile, anything else
+	// the shared SSM instance profile
 	if config.InstanceProfile == "" {
-		config.InstanceProfile = s.instanceProfile
+		if clusterName := config.Tags["goman-cluster"]; clusterName != "" {
+			profile, err := s.EnsureClusterIdentity(ctx, clusterName)
+			if err != nil {
+				return nil, fmt.Errorf("failed to ensure instance profile for cluster %s: %w", clusterName, err)
+			}
+			config.InstanceProfile = profile
+		} else {
+			config.InstanceProfile = s.instanceProfile
+		}
 	}
 	
 	// AWS-specific: Add UserData to install K3s and ensure SSM agent is running
@@ -370,265 +554,64 @@ func (s *ComputeService) CreateInstance(ctx context.Context, config provider.Ins
 		nodeToken := config.Tags["goman-node-token"] // For workers to join cluster
 		
 		// Build the user data script based on role
-		userDataScript := fmt.Sprintf(`#!/bin/bash
-set -e
-
-# Log startup
-echo "[$(date)] Starting instance initialization" >> /var/log/goman-startup.log
-
-# Amazon Linux 2 has SSM agent pre-installed
-# Just ensure it's enabled and running
-systemctl enable amazon-ssm-agent
-systemctl start amazon-ssm-agent
-
-# Wait for SSM agent to be ready
-sleep 10
-
-# Set up environment
-export CLUSTER_NAME="%s"
-export NODE_ROLE="%s"
-export AWS_REGION="%s"
-export S3_BUCKET="goman-%s"
-export NODE_INDEX="%s"
-export MASTER_IP="%s"
-export NODE_TOKEN="%s"
-
-echo "[$(date)] Cluster: $CLUSTER_NAME, Role: $NODE_ROLE, Index: $NODE_INDEX" >> /var/log/goman-startup.log
-
-# Install required packages
-yum update -y
-yum install -y jq
-
-# Download K3s binary from S3
-echo "[$(date)] Downloading K3s binary from S3..." >> /var/log/goman-startup.log
-# Use a specific version for now (can be made configurable later)
-K3S_VERSION="v1.31.4+k3s1"
-ARCH=$(uname -m)
-if [ "$ARCH" = "x86_64" ]; then
-    ARCH="amd64"
-elif [ "$ARCH" = "aarch64" ]; then
-    ARCH="arm64"
-fi
-
-aws s3 cp s3://$S3_BUCKET/binaries/k3s/$K3S_VERSION/k3s-$ARCH /usr/local/bin/k3s
-if [ $? -ne 0 ]; then
-    echo "[$(date)] ERROR: Failed to download K3s binary from S3" >> /var/log/goman-startup.log
-    exit 1
-fi
-chmod +x /usr/local/bin/k3s
-
-# Create symlinks for kubectl and other tools
-ln -sf /usr/local/bin/k3s /usr/local/bin/kubectl
-ln -sf /usr/local/bin/k3s /usr/local/bin/crictl
-ln -sf /usr/local/bin/k3s /usr/local/bin/ctr
-
-# Get tokens from S3
-if [ "$NODE_ROLE" = "master" ]; then
-    # Get server token from S3
-    SERVER_TOKEN=$(aws s3 cp s3://$S3_BUCKET/clusters/$CLUSTER_NAME/k3s-server-token - 2>/dev/null || echo "")
-    
-    if [ -z "$SERVER_TOKEN" ]; then
-        echo "[$(date)] ERROR: Failed to get server token from S3" >> /var/log/goman-startup.log
-        exit 1
-    fi
-    
-    # Get instance private IP
-    PRIVATE_IP=$(curl -s http://169.254.169.254/latest/meta-data/local-ipv4)
-    
-    # Determine if this is the first master or additional HA master
-    if [ "$NODE_INDEX" = "0" ] || [ -z "$MASTER_IP" ]; then
-        # First master - initialize new cluster
-        echo "[$(date)] Installing K3s server as first master..." >> /var/log/goman-startup.log
-        
-        # Check if this is HA mode by looking for a specific tag or checking node count
-        # For HA mode, we need --cluster-init to enable embedded etcd
-        CLUSTER_INIT_FLAG=""
-        if [ -n "$NODE_INDEX" ] && [ "$NODE_INDEX" = "0" ]; then
-            # This is explicitly the first master in HA mode
-            CLUSTER_INIT_FLAG="--cluster-init"
-            echo "[$(date)] Enabling embedded etcd for HA mode" >> /var/log/goman-startup.log
-        fi
-        
-        # Create K3s systemd service for first master
-        cat > /etc/systemd/system/k3s.service <<EOF
-[Unit]
-Description=Lightweight Kubernetes
-Documentation=https://k3s.io
-After=network-online.target
-Wants=network-online.target
-
-[Service]
-Type=notify
-EnvironmentFile=-/etc/systemd/system/k3s.service.env
-KillMode=process
-Delegate=yes
-LimitNOFILE=1048576
-LimitNPROC=infinity
-LimitCORE=infinity
-TasksMax=infinity
-TimeoutStartSec=0
-Restart=always
-RestartSec=5s
-ExecStartPre=/bin/sh -xc '! /usr/bin/systemctl is-enabled --quiet nm-cloud-setup.service'
-ExecStartPre=-/sbin/modprobe br_netfilter
-ExecStartPre=-/sbin/modprobe overlay
-ExecStart=/usr/local/bin/k3s server ${CLUSTER_INIT_FLAG} --token=${SERVER_TOKEN} --node-ip=${PRIVATE_IP} --flannel-iface=eth0 --disable=traefik --disable=servicelb --disable=metrics-server --write-kubeconfig-mode=644
-
-[Install]
-WantedBy=multi-user.target
-EOF
-
-    # Create environment file with actual values
-    cat > /etc/systemd/system/k3s.service.env <<EOF
-SERVER_TOKEN=${SERVER_TOKEN}
-PRIVATE_IP=${PRIVATE_IP}
-CLUSTER_INIT_FLAG=${CLUSTER_INIT_FLAG}
-EOF
-
-        # Start K3s server
-        systemctl daemon-reload
-        systemctl enable k3s.service
-        systemctl start k3s.service
-        
-        # Wait for K3s to be ready
-        echo "[$(date)] Waiting for K3s to be ready..." >> /var/log/goman-startup.log
-        for i in {1..60}; do
-            if kubectl get nodes >/dev/null 2>&1; then
-                echo "[$(date)] K3s is ready!" >> /var/log/goman-startup.log
-                break
-            fi
-            sleep 5
-        done
-        
-        # Save kubeconfig to S3
-        if [ -f /etc/rancher/k3s/k3s.yaml ]; then
-            # Replace localhost with instance public IP
-            PUBLIC_IP=$(curl -s http://169.254.169.254/latest/meta-data/public-ipv4)
-            sed "s/127.0.0.1/$PUBLIC_IP/g" /etc/rancher/k3s/k3s.yaml > /tmp/kubeconfig.yaml
-            aws s3 cp /tmp/kubeconfig.yaml s3://$S3_BUCKET/clusters/$CLUSTER_NAME/kubeconfig.yaml
-            echo "[$(date)] Kubeconfig saved to S3" >> /var/log/goman-startup.log
-        fi
-        
-    else
-        # Additional HA master - join existing cluster
-        echo "[$(date)] Installing K3s server as additional HA master, joining $MASTER_IP..." >> /var/log/goman-startup.log
-        
-        # Wait a bit for the first master to be fully ready
-        echo "[$(date)] Waiting 30 seconds for first master to initialize etcd..." >> /var/log/goman-startup.log
-        sleep 30
-        
-        # Create K3s systemd service for additional master
-        cat > /etc/systemd/system/k3s.service <<EOF
-[Unit]
-Description=Lightweight Kubernetes
-Documentation=https://k3s.io
-After=network-online.target
-Wants=network-online.target
-
-[Service]
-Type=notify
-EnvironmentFile=-/etc/systemd/system/k3s.service.env
-KillMode=process
-Delegate=yes
-LimitNOFILE=1048576
-LimitNPROC=infinity
-LimitCORE=infinity
-TasksMax=infinity
-TimeoutStartSec=0
-Restart=always
-RestartSec=5s
-ExecStartPre=/bin/sh -xc '! /usr/bin/systemctl is-enabled --quiet nm-cloud-setup.service'
-ExecStartPre=-/sbin/modprobe br_netfilter
-ExecStartPre=-/sbin/modprobe overlay
-ExecStart=/usr/local/bin/k3s server --server=https://${MASTER_IP}:6443 --token=${SERVER_TOKEN} --node-ip=${PRIVATE_IP} --flannel-iface=eth0 --disable=traefik --disable=servicelb --disable=metrics-server --write-kubeconfig-mode=644
-
-[Install]
-WantedBy=multi-user.target
-EOF
-
-        # Create environment file with actual values
-        cat > /etc/systemd/system/k3s.service.env <<EOF
-SERVER_TOKEN=${SERVER_TOKEN}
-PRIVATE_IP=${PRIVATE_IP}
-MASTER_IP=${MASTER_IP}
-EOF
-
-        # Start K3s server
-        systemctl daemon-reload
-        systemctl enable k3s.service
-        systemctl start k3s.service
-        
-        echo "[$(date)] K3s HA master installation initiated, joining cluster at $MASTER_IP" >> /var/log/goman-startup.log
-    fi
-    
-elif [ "$NODE_ROLE" = "worker" ]; then
-    # NODE_TOKEN and MASTER_IP are already set from environment variables
-    # They come from the EC2 tags passed to the instance
-    
-    if [ -z "$NODE_TOKEN" ]; then
-        echo "[$(date)] ERROR: Node token not provided" >> /var/log/goman-startup.log
-        exit 1
-    fi
-    
-    if [ -z "$MASTER_IP" ]; then
-        echo "[$(date)] ERROR: Master IP not configured" >> /var/log/goman-startup.log
-        exit 1
-    fi
-    
-    echo "[$(date)] Installing K3s agent to join cluster at $MASTER_IP" >> /var/log/goman-startup.log
-    
-    # Create K3s agent systemd service
-    cat > /etc/systemd/system/k3s-agent.service <<EOF
-[Unit]
-Description=Lightweight Kubernetes Agent
-Documentation=https://k3s.io
-After=network-online.target
-Wants=network-online.target
-
-[Service]
-Type=notify
-EnvironmentFile=-/etc/systemd/system/k3s-agent.service.env
-KillMode=process
-Delegate=yes
-LimitNOFILE=1048576
-LimitNPROC=infinity
-LimitCORE=infinity
-TasksMax=infinity
-TimeoutStartSec=0
-Restart=always
-RestartSec=5s
-ExecStartPre=/bin/sh -xc '! /usr/bin/systemctl is-enabled --quiet nm-cloud-setup.service'
-ExecStartPre=-/sbin/modprobe br_netfilter
-ExecStartPre=-/sbin/modprobe overlay
-ExecStart=/usr/local/bin/k3s agent --server=https://${MASTER_IP}:6443 --token=${NODE_TOKEN}
-
-[Install]
-WantedBy=multi-user.target
-EOF
-
-    # Create environment file with actual values
-    cat > /etc/systemd/system/k3s-agent.service.env <<EOF
-MASTER_IP=${MASTER_IP}
-NODE_TOKEN=${NODE_TOKEN}
-EOF
-    
-    # Start K3s agent
-    systemctl daemon-reload
-    systemctl enable k3s-agent.service
-    systemctl start k3s-agent.service
-    
-    echo "[$(date)] K3s agent installation initiated" >> /var/log/goman-startup.log
-fi
-
-echo "[$(date)] K3s installation completed" >> /var/log/goman-startup.log
-
-# Log for debugging
-echo "Instance started at $(date)" >> /var/log/instance-startup.log
-`, clusterName, role, config.Region, s.accountID, nodeIndex, masterIP, nodeToken)
+		lowResource := config.Tags["goman-low-resource"]
+		params := userdata.Params{
+			ClusterName:     clusterName,
+			Role:            role,
+			Region:          config.Region,
+			Bucket:          stateBucketName(s.accountID),
+			NodeIndex:       nodeIndex,
+			MasterIP:        masterIP,
+			NodeToken:       nodeToken,
+			K3sDisableFlags: provider.K3sDisableFlags(config.Tags["goman-k3s-disable"]),
+			LowResource:     lowResource,
+			K3sTuningFlags:  provider.K3sTuningFlags(role, lowResource == "true"),
+			K3sVersion:      provider.K3sVersion(config.Tags["goman-k3s-version"]),
+			K3sArch:         models.K3sArchitecture(config.Architecture),
+			OSFamily:        config.OSFamily,
+			SecretBackend:   gomanconfig.GetSecretBackend(),
+			SecretRegion:    s.config.Region,
+
+			SecretFunctions:     secretShellFunctions,
+			ProxyScript:         provider.ProxyScript(config.Proxy),
+			DataVolumeScript:    provider.DataVolumeScript(config.DataVolumes, dataVolumeDevice),
+			InstanceStoreScript: instanceStoreScript(config.Tags["goman-instance-store"] == "true"),
+			GPUScript:           gpuScript(config.Tags["goman-gpu"] == "true"),
+		}
+		if config.Bootstrap != nil {
+			params.Bootstrap = *config.Bootstrap
+		}
+		if config.Registries != nil {
+			registries := *config.Registries
+			if registries.Region == "" {
+				registries.Region = s.config.Region
+			}
+			params.RegistriesScript = provider.RegistriesScript(&registries)
+		}
+		if err := s.ensureK3sBinaries(ctx, params.K3sVersion); err != nil {
+			return nil, err
+		}
+		userDataScript, err := userdata.Render(params)
+		if err != nil {
+			return nil, fmt.Errorf("failed to render user data for %s: %w", config.Name, err)
+		}
 		
 		config.UserData = base64.StdEncoding.EncodeToString([]byte(userDataScript))
 	}
 
+	// EC2 rejects user data over 16KB - stage oversized scripts in S3 instead.
+	// The pool's snippet is added as a second cloud-init part.
+	config.UserData, err = s.prepareUserData(ctx, config.Name, config.Tags["goman-cluster"], config.OSFamily, config.UserData, config.UserDataSnippet)
+	if err != nil {
+		return nil, err
+	}
+
+	// The root volume is addressed by the image's root device name
+	rootDevice := ""
+	if config.RootVolumeSize > 0 || config.RootVolumeType != "" || config.RootVolumeIOPS > 0 {
+		rootDevice = s.rootDeviceName(ctx, ec2Client, config.ImageID)
+	}
+
 	// Run instance with retry logic
 	var result *ec2.RunInstancesOutput
 	retryConfig := utils.DefaultRetryConfig()
@@ -643,7 +626,10 @@ echo "Instance started at $(date)" >> /var/log/instance-startup.log
 			SecurityGroupIds:      config.SecurityGroups,
 			SubnetId:              aws.String(config.SubnetID),
 			UserData:              aws.String(config.UserData),
-			DisableApiTermination: aws.Bool(true), // Enable deletion protection
+			DisableApiTermination: aws.Bool(config.DeletionProtection),
+			DisableApiStop:        aws.Bool(config.StopProtection),
+
+			InstanceInitiatedShutdownBehavior: types.ShutdownBehavior(config.ShutdownBehavior),
 			TagSpecifications: []types.TagSpecification{
 				{
 					ResourceType: types.ResourceTypeInstance,
@@ -652,6 +638,31 @@ echo "Instance started at $(date)" >> /var/log/instance-startup.log
 			},
 		}
 
+		// Size the root volume when requested (e.g., by a sizing preset)
+		if rootDevice != "" {
+			runInstancesInput.BlockDeviceMappings = []types.BlockDeviceMapping{rootVolumeMapping(rootDevice, config)}
+		}
+
+		// Attach data volumes; they are deleted with the instance and tagged
+		// so leftovers can be traced back to their node
+		if len(config.DataVolumes) > 0 {
+			runInstancesInput.BlockDeviceMappings = append(runInstancesInput.BlockDeviceMappings, dataVolumeMappings(config.DataVolumes)...)
+		}
+		if len(config.DataVolumes) > 0 || len(userTags) > 0 {
+			// Root volumes get the same tags, so cost allocation covers them
+			runInstancesInput.TagSpecifications = append(runInstancesInput.TagSpecifications, types.TagSpecification{
+				ResourceType: types.ResourceTypeVolume,
+				Tags: append([]types.Tag{
+					{Key: aws.String("Name"), Value: aws.String(config.Name)},
+					{Key: aws.String("goman-cluster"), Value: aws.String(config.Tags["goman-cluster"])},
+					{Key: aws.String("goman-nodepool"), Value: aws.String(config.Tags["goman-nodepool"])},
+					{Key: aws.String("goman-node"), Value: aws.String(config.Name)},
+					{Key: aws.String("ManagedBy"), Value: aws.String("goman")},
+					s.installTag(),
+				}, userTags...),
+			})
+		}
+
 		// Add IAM instance profile for SSM access (always set by now)
 		runInstancesInput.IamInstanceProfile = &types.IamInstanceProfileSpecification{
 			Name: aws.String(config.InstanceProfile),
@@ -666,7 +677,7 @@ echo "Instance started at $(date)" >> /var/log/instance-startup.log
 	})
 
 	if err != nil {
-		return nil, fmt.Errorf("failed to create instance after retries: %w", err)
+		return nil, fmt.Errorf("failed to create instance after retries: %w", wrapAWSError("ec2", "RunInstances", err))
 	}
 
 	if len(result.Instances) == 0 {
@@ -679,20 +690,13 @@ echo "Instance started at $(date)" >> /var/log/instance-startup.log
 	// The reconciler will check the status in subsequent reconciliation loops
 	// This allows parallel instance creation without blocking
 
-	return s.convertToProviderInstance(&inst), nil
+	return s.convertToProviderInstance(&inst, s.regionOrDefault(config.Region)), nil
 }
 
 // DeleteInstance terminates an EC2 instance with retry logic
 func (s *ComputeService) DeleteInstance(ctx context.Context, instanceID string) error {
-	// Try to find which region the instance is in by checking our cached clients
-	ec2Client := s.client // Default to main client
-
-	// Detect instance region
-	instanceRegion := s.detectInstanceRegion(ctx, instanceID)
-	if instanceRegion != "" && instanceRegion != s.config.Region {
-		ec2Client = s.getEC2Client(instanceRegion)
-		logger.Printf("Using region-specific client for instance %s in %s", instanceID, instanceRegion)
-	}
+	// Use the client of the region the instance is in
+	ec2Client := s.instanceClient(ctx, instanceID)
 
 	// If not found in cached regions, try with default client
 	if ec2Client == s.client {
@@ -701,28 +705,34 @@ func (s *ComputeService) DeleteInstance(ctx context.Context, instanceID string)
 			InstanceIds: []string{instanceID},
 		})
 		if err != nil || len(result.Reservations) == 0 {
-			logger.Printf("Warning: Instance %s not found in any known region, attempting deletion with default client", instanceID)
+			logger.Warnf(ctx, "Instance %s not found in any known region, attempting deletion with default client", instanceID)
 		}
 	}
 
-	// First, disable deletion protection
-	_, err := ec2Client.ModifyInstanceAttribute(ctx, &ec2.ModifyInstanceAttributeInput{
-		InstanceId: aws.String(instanceID),
-		DisableApiTermination: &types.AttributeBooleanValue{
-			Value: aws.Bool(false),
-		},
-	})
-	if err != nil {
-		logger.Printf("Warning: Failed to disable deletion protection for %s: %v", instanceID, err)
-		// Continue anyway - the instance might not have protection enabled
-	}
-
-	// Now terminate the instance
+	// Terminate the instance; deletion protection is lifted only for
+	// instances that have it, sparing the call for the others
 	retryConfig := utils.DefaultRetryConfig()
-	err = utils.RetryWithBackoff(ctx, retryConfig, func(ctx context.Context) error {
+	protectionLifted := false
+	err := utils.RetryWithBackoff(ctx, retryConfig, func(ctx context.Context) error {
 		_, err := ec2Client.TerminateInstances(ctx, &ec2.TerminateInstancesInput{
 			InstanceIds: []string{instanceID},
 		})
+		if err != nil && !protectionLifted && hasErrorCode(err, "OperationNotPermitted") {
+			protectionLifted = true
+			_, modErr := ec2Client.ModifyInstanceAttribute(ctx, &ec2.ModifyInstanceAttributeInput{
+				InstanceId: aws.String(instanceID),
+				DisableApiTermination: &types.AttributeBooleanValue{
+					Value: aws.Bool(false),
+				},
+			})
+			if modErr != nil {
+				return fmt.Errorf("failed to disable deletion protection: %w", wrapAWSError("ec2", "ModifyInstanceAttribute", modErr))
+			}
+			logger.Infof(ctx, "Disabled deletion protection of instance %s", instanceID)
+			_, err = ec2Client.TerminateInstances(ctx, &ec2.TerminateInstancesInput{
+				InstanceIds: []string{instanceID},
+			})
+		}
 
 		if err != nil && utils.IsRetryableError(err) {
 			return err
@@ -731,7 +741,7 @@ func (s *ComputeService) DeleteInstance(ctx context.Context, instanceID string)
 	})
 
 	if err != nil {
-		return fmt.Errorf("failed to terminate instance after retries: %w", err)
+		return fmt.Errorf("failed to terminate instance after retries: %w", wrapAWSError("ec2", "TerminateInstances", err))
 	}
 
 	// Note: Cleanup of associated resources (security groups, key pairs) should be done
@@ -742,11 +752,17 @@ func (s *ComputeService) DeleteInstance(ctx context.Context, instanceID string)
 
 // GetInstance gets instance details with retry logic
 func (s *ComputeService) GetInstance(ctx context.Context, instanceID string) (*provider.Instance, error) {
+	region := s.regionOrDefault(s.detectInstanceRegion(ctx, instanceID))
+	ec2Client := s.client
+	if region != s.config.Region {
+		ec2Client = s.getEC2Client(region)
+	}
+
 	var result *ec2.DescribeInstancesOutput
 	retryConfig := utils.DefaultRetryConfig()
 	err := utils.RetryWithBackoff(ctx, retryConfig, func(ctx context.Context) error {
 		var descErr error
-		result, descErr = s.client.DescribeInstances(ctx, &ec2.DescribeInstancesInput{
+		result, descErr = ec2Client.DescribeInstances(ctx, &ec2.DescribeInstancesInput{
 			InstanceIds: []string{instanceID},
 		})
 
@@ -757,14 +773,14 @@ func (s *ComputeService) GetInstance(ctx context.Context, instanceID string) (*p
 	})
 
 	if err != nil {
-		return nil, fmt.Errorf("failed to describe instance after retries: %w", err)
+		return nil, fmt.Errorf("failed to describe instance after retries: %w", wrapAWSError("ec2", "DescribeInstances", err))
 	}
 
 	if len(result.Reservations) == 0 || len(result.Reservations[0].Instances) == 0 {
 		return nil, fmt.Errorf("instance not found")
 	}
 
-	return s.convertToProviderInstance(&result.Reservations[0].Instances[0]), nil
+	return s.convertToProviderInstance(&result.Reservations[0].Instances[0], region), nil
 }
 
 // ListInstances lists instances with filters
@@ -780,12 +796,12 @@ func (s *ComputeService) ListInstances(ctx context.Context, filters map[string]s
 	var ec2Client *ec2.Client
 	if region != "" {
 		ec2Client = s.getEC2Client(region)
-		logger.Printf("Listing instances in specific region: %s", region)
+		logger.Infof(ctx, "Listing instances in specific region: %s", region)
 	} else {
 		// If no region specified, we should check all regions where we have instances
 		// For now, use default client but log a warning
 		ec2Client = s.client
-		logger.Printf("Warning: ListInstances using default region. May miss instances in other regions.")
+		logger.Warnf(ctx, "ListInstances using default region. May miss instances in other regions.")
 	}
 
 	// Convert filters to EC2 format
@@ -811,13 +827,13 @@ func (s *ComputeService) ListInstances(ctx context.Context, filters map[string]s
 	})
 
 	if err != nil {
-		return nil, fmt.Errorf("failed to list instances: %w", err)
+		return nil, fmt.Errorf("failed to list instances: %w", wrapAWSError("ec2", "DescribeInstances", err))
 	}
 
 	var instances []*provider.Instance
 	for _, reservation := range result.Reservations {
 		for _, inst := range reservation.Instances {
-			instances = append(instances, s.convertToProviderInstance(&inst))
+			instances = append(instances, s.convertToProviderInstance(&inst, s.regionOrDefault(region)))
 		}
 	}
 
@@ -826,12 +842,12 @@ func (s *ComputeService) ListInstances(ctx context.Context, filters map[string]s
 
 // StartInstance starts a stopped instance
 func (s *ComputeService) StartInstance(ctx context.Context, instanceID string) error {
-	_, err := s.client.StartInstances(ctx, &ec2.StartInstancesInput{
+	_, err := s.instanceClient(ctx, instanceID).StartInstances(ctx, &ec2.StartInstancesInput{
 		InstanceIds: []string{instanceID},
 	})
 
 	if err != nil {
-		return fmt.Errorf("failed to start instance: %w", err)
+		return fmt.Errorf("failed to start instance: %w", wrapAWSError("ec2", "StartInstances", err))
 	}
 
 	return nil
@@ -839,12 +855,12 @@ func (s *ComputeService) StartInstance(ctx context.Context, instanceID string) e
 
 // StopInstance stops a running instance
 func (s *ComputeService) StopInstance(ctx context.Context, instanceID string) error {
-	_, err := s.client.StopInstances(ctx, &ec2.StopInstancesInput{
+	_, err := s.instanceClient(ctx, instanceID).StopInstances(ctx, &ec2.StopInstancesInput{
 		InstanceIds: []string{instanceID},
 	})
 
 	if err != nil {
-		return fmt.Errorf("failed to stop instance: %w", err)
+		return fmt.Errorf("failed to stop instance: %w", wrapAWSError("ec2", "StopInstances", err))
 	}
 
 	return nil
@@ -852,15 +868,7 @@ func (s *ComputeService) StopInstance(ctx context.Context, instanceID string) er
 
 // ModifyInstanceType changes the instance type of a stopped instance
 func (s *ComputeService) ModifyInstanceType(ctx context.Context, instanceID string, instanceType string) error {
-	// Try to find which region the instance is in
-	ec2Client := s.client
-
-	// Detect instance region
-	instanceRegion := s.detectInstanceRegion(ctx, instanceID)
-	if instanceRegion != "" && instanceRegion != s.config.Region {
-		ec2Client = s.getEC2Client(instanceRegion)
-		logger.Printf("Using region-specific client for instance %s modification in %s", instanceID, instanceRegion)
-	}
+	ec2Client := s.instanceClient(ctx, instanceID)
 
 	// Modify the instance attribute
 	_, err := ec2Client.ModifyInstanceAttribute(ctx, &ec2.ModifyInstanceAttributeInput{
@@ -871,21 +879,43 @@ func (s *ComputeService) ModifyInstanceType(ctx context.Context, instanceID stri
 	})
 
 	if err != nil {
-		return fmt.Errorf("failed to modify instance type: %w", err)
+		return fmt.Errorf("failed to modify instance type: %w", wrapAWSError("ec2", "ModifyInstanceAttribute", err))
 	}
 
-	logger.Printf("Successfully modified instance %s to type %s", instanceID, instanceType)
+	logger.Infof(ctx, "Successfully modified instance %s to type %s", instanceID, instanceType)
 	return nil
 }
 
-// convertToProviderInstance converts EC2 instance to provider instance
-func (s *ComputeService) convertToProviderInstance(inst *types.Instance) *provider.Instance {
+// TagInstance adds or overwrites tags on an instance
+func (s *ComputeService) TagInstance(ctx context.Context, instanceID string, tags map[string]string) error {
+	ec2Client := s.instanceClient(ctx, instanceID)
+
+	ec2Tags := make([]types.Tag, 0, len(tags))
+	for k, v := range tags {
+		ec2Tags = append(ec2Tags, types.Tag{Key: aws.String(k), Value: aws.String(v)})
+	}
+
+	_, err := ec2Client.CreateTags(ctx, &ec2.CreateTagsInput{
+		Resources: []string{instanceID},
+		Tags:      ec2Tags,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to tag instance %s: %w", instanceID, wrapAWSError("ec2", "CreateTags", err))
+	}
+	return nil
+}
+
+// convertToProviderInstance converts EC2 instance to provider instance and
+// remembers the region it was found in
+func (s *ComputeService) convertToProviderInstance(inst *types.Instance, region string) *provider.Instance {
 	p := &provider.Instance{
 		ID:           aws.ToString(inst.InstanceId),
 		State:        string(inst.State.Name),
 		InstanceType: string(inst.InstanceType),
+		Region:       region,
 		Tags:         make(map[string]string),
 	}
+	s.RememberInstanceRegion(p.ID, region)
 
 	if inst.PrivateIpAddress != nil {
 		p.PrivateIP = *inst.PrivateIpAddress
@@ -899,6 +929,10 @@ func (s *ComputeService) convertToProviderInstance(inst *types.Instance) *provid
 		p.LaunchTime = *inst.LaunchTime
 	}
 
+	if inst.Placement != nil {
+		p.AvailabilityZone = aws.ToString(inst.Placement.AvailabilityZone)
+	}
+
 	// Extract tags
 	for _, tag := range inst.Tags {
 		if tag.Key != nil && tag.Value != nil {
@@ -916,62 +950,34 @@ func (s *ComputeService) convertToProviderInstance(inst *types.Instance) *provid
 
 // NetworkInfo holds network infrastructure details
 type NetworkInfo struct {
-	VPCID           string
-	SubnetID        string
-	SecurityGroupID string
+	VPCID            string
+	SubnetID         string
+	SecurityGroupID  string
+	AvailabilityZone string
 }
 
-// ensureNetworkInfrastructure ensures VPC, subnet, and security group exist in the specified region
-func (s *ComputeService) ensureNetworkInfrastructure(ctx context.Context, resourceName string, region string) (*NetworkInfo, error) {
+// ensureNetworkInfrastructure ensures VPC, subnet, and security group exist in the specified region.
+// The subnet is the default subnet of zone, or the first default subnet if zone is empty. Clusters
+// in a VPC of their own pick from their subnets the same way.
+func (s *ComputeService) ensureNetworkInfrastructure(ctx context.Context, resourceName string, region string, zone string, customVPC string, customSubnets []string) (*NetworkInfo, error) {
 	// Use the default VPC and subnets for simplicity
 	// These resources are reused across all clusters
 
-	logger.Printf("Ensuring network infrastructure for %s in region: %s", resourceName, region)
+	logger.Infof(ctx, "Ensuring network infrastructure for %s in region: %s", resourceName, region)
 
 	// Get region-specific EC2 client
 	ec2Client := s.getEC2Client(region)
 
-	// Get default VPC
-	describeVpcsOutput, err := ec2Client.DescribeVpcs(ctx, &ec2.DescribeVpcsInput{
-		Filters: []types.Filter{
-			{
-				Name:   aws.String("is-default"),
-				Values: []string{"true"},
-			},
-		},
-	})
+	vpcID, subnets, err := clusterSubnets(ctx, ec2Client, customVPC, customSubnets)
 	if err != nil {
-		return nil, fmt.Errorf("failed to describe VPCs: %w", err)
-	}
-
-	if len(describeVpcsOutput.Vpcs) == 0 {
-		return nil, fmt.Errorf("no default VPC found")
+		return nil, err
 	}
 
-	vpcID := aws.ToString(describeVpcsOutput.Vpcs[0].VpcId)
-
-	// Get default subnet in the first AZ
-	describeSubnetsOutput, err := ec2Client.DescribeSubnets(ctx, &ec2.DescribeSubnetsInput{
-		Filters: []types.Filter{
-			{
-				Name:   aws.String("vpc-id"),
-				Values: []string{vpcID},
-			},
-			{
-				Name:   aws.String("default-for-az"),
-				Values: []string{"true"},
-			},
-		},
-	})
+	subnet, err := defaultSubnet(subnets, zone)
 	if err != nil {
-		return nil, fmt.Errorf("failed to describe subnets: %w", err)
-	}
-
-	if len(describeSubnetsOutput.Subnets) == 0 {
-		return nil, fmt.Errorf("no default subnets found")
+		return nil, err
 	}
-
-	subnetID := aws.ToString(describeSubnetsOutput.Subnets[0].SubnetId)
+	subnetID := aws.ToString(subnet.SubnetId)
 
 	// Extract cluster name from resource name 
 	// Formats: {cluster}-master-{index} or {cluster}-worker-{index}
@@ -994,6 +1000,11 @@ func (s *ComputeService) ensureNetworkInfrastructure(ctx context.Context, resour
 				Name:   aws.String("group-name"),
 				Values: []string{sgName},
 			},
+			{
+				// A renamed cluster may still own a group with this name
+				Name:   aws.String("tag:Cluster"),
+				Values: []string{clusterName},
+			},
 			{
 				Name:   aws.String("vpc-id"),
 				Values: []string{vpcID},
@@ -1001,10 +1012,41 @@ func (s *ComputeService) ensureNetworkInfrastructure(ctx context.Context, resour
 		},
 	})
 
+	if err == nil && len(describeSGOutput.SecurityGroups) == 0 {
+		// Renamed clusters keep their original group, found by its Cluster tag
+		describeSGOutput, err = ec2Client.DescribeSecurityGroups(ctx, &ec2.DescribeSecurityGroupsInput{
+			Filters: []types.Filter{
+				{
+					Name:   aws.String("tag:Cluster"),
+					Values: []string{clusterName},
+				},
+				{
+					Name:   aws.String("tag:ManagedBy"),
+					Values: []string{"goman"},
+				},
+				{
+					Name:   aws.String("vpc-id"),
+					Values: []string{vpcID},
+				},
+			},
+		})
+	}
+
 	var securityGroupID string
 	if err != nil || len(describeSGOutput.SecurityGroups) == 0 {
+		// The default name may still belong to a cluster that was renamed away from it
+		taken, takenErr := ec2Client.DescribeSecurityGroups(ctx, &ec2.DescribeSecurityGroupsInput{
+			Filters: []types.Filter{
+				{Name: aws.String("group-name"), Values: []string{sgName}},
+				{Name: aws.String("vpc-id"), Values: []string{vpcID}},
+			},
+		})
+		if takenErr == nil && len(taken.SecurityGroups) > 0 {
+			sgName = fmt.Sprintf("goman-%s-%d-sg", clusterName, time.Now().Unix())
+		}
+
 		// Create security group (will be reused if cluster is recreated)
-		logger.Printf("Creating new security group %s for cluster %s", sgName, clusterName)
+		logger.Infof(ctx, "Creating new security group %s for cluster %s", sgName, clusterName)
 		createSGOutput, err := ec2Client.CreateSecurityGroup(ctx, &ec2.CreateSecurityGroupInput{
 			GroupName:   aws.String(sgName),
 			Description: aws.String(fmt.Sprintf("Security group for goman cluster %s (reusable)", clusterName)),
@@ -1025,123 +1067,129 @@ func (s *ComputeService) ensureNetworkInfrastructure(ctx context.Context, resour
 							Key:   aws.String("Cluster"),
 							Value: aws.String(clusterName),
 						},
+						s.installTag(),
 					},
 				},
 			},
 		})
 		if err != nil {
-			return nil, fmt.Errorf("failed to create security group: %w", err)
+			return nil, fmt.Errorf("failed to create security group: %w", wrapAWSError("ec2", "CreateSecurityGroup", err))
 		}
 
 		securityGroupID = aws.ToString(createSGOutput.GroupId)
 
 		// Add ingress rules for K3s
 		_, err = ec2Client.AuthorizeSecurityGroupIngress(ctx, &ec2.AuthorizeSecurityGroupIngressInput{
-			GroupId: aws.String(securityGroupID),
-			IpPermissions: []types.IpPermission{
-				// No SSH access needed - using Systems Manager Session Manager
-				// K3s API server - allow from all nodes
-				{
-					IpProtocol: aws.String("tcp"),
-					FromPort:   aws.Int32(6443),
-					ToPort:     aws.Int32(6443),
-					UserIdGroupPairs: []types.UserIdGroupPair{
-						{
-							GroupId:     aws.String(securityGroupID),
-							Description: aws.String("K3s API server - all nodes"),
-						},
-					},
-				},
-				// etcd client/server communication - CRITICAL for HA clusters
-				{
-					IpProtocol: aws.String("tcp"),
-					FromPort:   aws.Int32(2379),
-					ToPort:     aws.Int32(2380),
-					UserIdGroupPairs: []types.UserIdGroupPair{
-						{
-							GroupId:     aws.String(securityGroupID),
-							Description: aws.String("etcd client and peer - required for HA"),
-						},
-					},
-				},
-				// Kubelet metrics
-				{
-					IpProtocol: aws.String("tcp"),
-					FromPort:   aws.Int32(10250),
-					ToPort:     aws.Int32(10250),
-					UserIdGroupPairs: []types.UserIdGroupPair{
-						{
-							GroupId:     aws.String(securityGroupID),
-							Description: aws.String("Kubelet metrics"),
-						},
-					},
-				},
-				// Flannel VXLAN
-				{
-					IpProtocol: aws.String("udp"),
-					FromPort:   aws.Int32(8472),
-					ToPort:     aws.Int32(8472),
-					UserIdGroupPairs: []types.UserIdGroupPair{
-						{
-							GroupId:     aws.String(securityGroupID),
-							Description: aws.String("Flannel VXLAN"),
-						},
-					},
-				},
-				// Flannel Wireguard with IPv4 (optional)
-				{
-					IpProtocol: aws.String("udp"),
-					FromPort:   aws.Int32(51820),
-					ToPort:     aws.Int32(51820),
-					UserIdGroupPairs: []types.UserIdGroupPair{
-						{
-							GroupId:     aws.String(securityGroupID),
-							Description: aws.String("Flannel Wireguard IPv4"),
-						},
-					},
-				},
-				// Flannel Wireguard with IPv6 (optional)
-				{
-					IpProtocol: aws.String("udp"),
-					FromPort:   aws.Int32(51821),
-					ToPort:     aws.Int32(51821),
-					UserIdGroupPairs: []types.UserIdGroupPair{
-						{
-							GroupId:     aws.String(securityGroupID),
-							Description: aws.String("Flannel Wireguard IPv6"),
-						},
-					},
-				},
-				// Embedded distributed registry - Spegel (optional)
-				{
-					IpProtocol: aws.String("tcp"),
-					FromPort:   aws.Int32(5001),
-					ToPort:     aws.Int32(5001),
-					UserIdGroupPairs: []types.UserIdGroupPair{
-						{
-							GroupId:     aws.String(securityGroupID),
-							Description: aws.String("Spegel distributed registry"),
-						},
-					},
-				},
-			},
+			GroupId:       aws.String(securityGroupID),
+			IpPermissions: clusterIngressRules(securityGroupID),
 		})
 		if err != nil {
-			logger.Printf("Warning: failed to add ingress rules: %v", err)
+			logger.Warnf(ctx, "Failed to add ingress rules: %v", err)
 		}
 	} else {
 		// Reuse existing security group
 		securityGroupID = aws.ToString(describeSGOutput.SecurityGroups[0].GroupId)
-		logger.Printf("Reusing existing security group %s (ID: %s)", sgName, securityGroupID)
+		logger.Infof(ctx, "Reusing existing security group %s (ID: %s)", sgName, securityGroupID)
 	}
 
 	return &NetworkInfo{
-		VPCID:           vpcID,
-		SubnetID:        subnetID,
-		SecurityGroupID: securityGroupID,
+		VPCID:            vpcID,
+		SubnetID:         subnetID,
+		SecurityGroupID:  securityGroupID,
+		AvailabilityZone: aws.ToString(subnet.AvailabilityZone),
 	}, nil
 }
 
+// clusterSubnets returns the VPC and the subnets a cluster launches in: the
+// given ones, checked to exist and belong to the VPC, or the default VPC
+// and its default subnets, one per zone
+func clusterSubnets(ctx context.Context, ec2Client *ec2.Client, customVPC string, customSubnets []string) (string, []types.Subnet, error) {
+	if customVPC != "" {
+		output, err := ec2Client.DescribeSubnets(ctx, &ec2.DescribeSubnetsInput{SubnetIds: customSubnets})
+		if err != nil {
+			if hasErrorCode(err, "InvalidSubnetID.NotFound") {
+				return "", nil, provider.UserConfigErrorf("subnets %s not found: %v", strings.Join(customSubnets, ", "), err)
+			}
+			return "", nil, fmt.Errorf("failed to describe subnets: %w", wrapAWSError("ec2", "DescribeSubnets", err))
+		}
+		for _, subnet := range output.Subnets {
+			if aws.ToString(subnet.VpcId) != customVPC {
+				return "", nil, provider.UserConfigErrorf("subnet %s belongs to VPC %s, not %s", aws.ToString(subnet.SubnetId), aws.ToString(subnet.VpcId), customVPC)
+			}
+		}
+		if len(output.Subnets) == 0 {
+			return "", nil, provider.UserConfigErrorf("no subnets found in VPC %s", customVPC)
+		}
+		// Keep the order of the spec, so the first subnet is the default one
+		order := make(map[string]int, len(customSubnets))
+		for i, id := range customSubnets {
+			order[id] = i
+		}
+		sort.SliceStable(output.Subnets, func(i, j int) bool {
+			return order[aws.ToString(output.Subnets[i].SubnetId)] < order[aws.ToString(output.Subnets[j].SubnetId)]
+		})
+		return customVPC, output.Subnets, nil
+	}
+
+	// Get default VPC
+	describeVpcsOutput, err := ec2Client.DescribeVpcs(ctx, &ec2.DescribeVpcsInput{
+		Filters: []types.Filter{
+			{
+				Name:   aws.String("is-default"),
+				Values: []string{"true"},
+			},
+		},
+	})
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to describe VPCs: %w", wrapAWSError("ec2", "DescribeVpcs", err))
+	}
+
+	if len(describeVpcsOutput.Vpcs) == 0 {
+		return "", nil, fmt.Errorf("no default VPC found")
+	}
+
+	vpcID := aws.ToString(describeVpcsOutput.Vpcs[0].VpcId)
+
+	// Get the default subnets, one per AZ
+	describeSubnetsOutput, err := ec2Client.DescribeSubnets(ctx, &ec2.DescribeSubnetsInput{
+		Filters: []types.Filter{
+			{
+				Name:   aws.String("vpc-id"),
+				Values: []string{vpcID},
+			},
+			{
+				Name:   aws.String("default-for-az"),
+				Values: []string{"true"},
+			},
+		},
+	})
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to describe subnets: %w", wrapAWSError("ec2", "DescribeSubnets", err))
+	}
+
+	if len(describeSubnetsOutput.Subnets) == 0 {
+		return "", nil, fmt.Errorf("no default subnets found")
+	}
+	return vpcID, describeSubnetsOutput.Subnets, nil
+}
+
+// defaultSubnet returns the subnet of zone, or the first one if zone is
+// empty
+func defaultSubnet(subnets []types.Subnet, zone string) (types.Subnet, error) {
+	if zone == "" {
+		return subnets[0], nil
+	}
+	var zones []string
+	for _, subnet := range subnets {
+		if aws.ToString(subnet.AvailabilityZone) == zone {
+			return subnet, nil
+		}
+		zones = append(zones, aws.ToString(subnet.AvailabilityZone))
+	}
+	sort.Strings(zones)
+	return types.Subnet{}, provider.UserConfigErrorf("no cluster subnet in availability zone %s (available: %s)", zone, strings.Join(zones, ", "))
+}
+
 // RunCommand executes a command on instances using AWS Systems Manager
 func (s *ComputeService) RunCommand(ctx context.Context, instanceIDs []string, command string) (*provider.CommandResult, error) {
 	if len(instanceIDs) == 0 {
@@ -1158,7 +1206,7 @@ func (s *ComputeService) RunCommand(ctx context.Context, instanceIDs []string, c
 		ssmClient = s.getSSMClient(instanceRegion)
 	} else {
 		// Fallback to default SSM client
-		logger.Printf("Could not detect region for instance %s, using default SSM client", instanceIDs[0])
+		logger.Warnf(ctx, "Could not detect region for instance %s, using default SSM client", instanceIDs[0])
 		ssmClient = s.ssmClient
 	}
 
@@ -1167,18 +1215,26 @@ func (s *ComputeService) RunCommand(ctx context.Context, instanceIDs []string, c
 		return nil, fmt.Errorf("SSM client not initialized - Systems Manager support not available")
 	}
 
+	return s.runDocument(ctx, ssmClient, instanceIDs, "AWS-RunShellScript", map[string][]string{
+		"commands": {command},
+	})
+}
+
+// runDocument sends an SSM document to instances and polls until every invocation completes
+func (s *ComputeService) runDocument(ctx context.Context, ssmClient *ssm.Client, instanceIDs []string, documentName string, parameters map[string][]string) (*provider.CommandResult, error) {
 	// Send command to instances
-	result, err := ssmClient.SendCommand(ctx, &ssm.SendCommandInput{
-		InstanceIds:  instanceIDs,
-		DocumentName: aws.String("AWS-RunShellScript"),
-		Parameters: map[string][]string{
-			"commands": {command},
-		},
+	input := &ssm.SendCommandInput{
+		InstanceIds:    instanceIDs,
+		DocumentName:   aws.String(documentName),
+		Parameters:     parameters,
 		TimeoutSeconds: aws.Int32(300), // 5 minutes timeout
-	})
+	}
+	s.applyCommandOutput(ctx, input)
+
+	result, err := ssmClient.SendCommand(ctx, input)
 
 	if err != nil {
-		return nil, fmt.Errorf("failed to send command: %w", err)
+		return nil, fmt.Errorf("failed to send command: %w", wrapAWSError("ssm", "SendCommand", err))
 	}
 
 	commandID := aws.ToString(result.Command.CommandId)
@@ -1202,7 +1258,7 @@ func (s *ComputeService) RunCommand(ctx context.Context, instanceIDs []string, c
 			})
 
 			if err != nil {
-				return nil, fmt.Errorf("failed to get command status: %w", err)
+				return nil, fmt.Errorf("failed to get command status: %w", wrapAWSError("ssm", "ListCommandInvocations", err))
 			}
 
 			// Check if all invocations are complete
@@ -1237,6 +1293,7 @@ func (s *ComputeService) RunCommand(ctx context.Context, instanceIDs []string, c
 					instanceResult.Output = aws.ToString(output.StandardOutputContent)
 					instanceResult.Error = aws.ToString(output.StandardErrorContent)
 					instanceResult.ExitCode = int(output.ResponseCode)
+					s.fillFullOutput(waitCtx, commandID, instanceResult)
 				}
 
 				cmdResult.Instances[instanceID] = instanceResult
@@ -1267,7 +1324,7 @@ func (s *ComputeService) StartCommand(ctx context.Context, instanceIDs []string,
 	if instanceRegion != "" {
 		ssmClient = s.getSSMClient(instanceRegion)
 	} else {
-		logger.Printf("Could not detect region for instance %s, using default SSM client", instanceIDs[0])
+		logger.Warnf(ctx, "Could not detect region for instance %s, using default SSM client", instanceIDs[0])
 		ssmClient = s.ssmClient
 	}
 
@@ -1277,21 +1334,24 @@ func (s *ComputeService) StartCommand(ctx context.Context, instanceIDs []string,
 	}
 
 	// Send command to instances
-	result, err := ssmClient.SendCommand(ctx, &ssm.SendCommandInput{
+	input := &ssm.SendCommandInput{
 		InstanceIds:  instanceIDs,
 		DocumentName: aws.String("AWS-RunShellScript"),
 		Parameters: map[string][]string{
 			"commands": {command},
 		},
 		TimeoutSeconds: aws.Int32(600), // 10 minutes timeout (increased)
-	})
+	}
+	s.applyCommandOutput(ctx, input)
+
+	result, err := ssmClient.SendCommand(ctx, input)
 
 	if err != nil {
-		return "", fmt.Errorf("failed to send command: %w", err)
+		return "", fmt.Errorf("failed to send command: %w", wrapAWSError("ssm", "SendCommand", err))
 	}
 
 	commandID := aws.ToString(result.Command.CommandId)
-	logger.Printf("Started command %s on instances %v", commandID, instanceIDs)
+	logger.Infof(ctx, "Started command %s on instances %v", commandID, instanceIDs)
 	
 	return commandID, nil
 }
@@ -1372,6 +1432,7 @@ func (s *ComputeService) GetCommandResult(ctx context.Context, commandID string)
 			instanceResult.Output = aws.ToString(output.StandardOutputContent)
 			instanceResult.Error = aws.ToString(output.StandardErrorContent)
 			instanceResult.ExitCode = int(output.ResponseCode)
+			s.fillFullOutput(ctx, commandID, instanceResult)
 		}
 
 		cmdResult.Instances[instanceID] = instanceResult
@@ -1388,3 +1449,183 @@ func (s *ComputeService) GetCommandResult(ctx context.Context, commandID string)
 
 	return cmdResult, nil
 }
+
+// CancelCommand stops a command on all its instances. The command may have
+// been sent in any region, so every SSM client is tried; a command no client
+// knows has already completed or expired.
+func (s *ComputeService) CancelCommand(ctx context.Context, commandID string) error {
+	if commandID == "" {
+		return fmt.Errorf("command ID cannot be empty")
+	}
+	clients := make([]*ssm.Client, 0, len(s.regionSSMClients)+1)
+	if s.ssmClient != nil {
+		clients = append(clients, s.ssmClient)
+	}
+	for _, client := range s.regionSSMClients {
+		clients = append(clients, client)
+	}
+	for _, client := range clients {
+		_, err := client.CancelCommand(ctx, &ssm.CancelCommandInput{
+			CommandId: aws.String(commandID),
+		})
+		if err == nil {
+			return nil
+		}
+		if !hasErrorCode(err, "InvalidCommandId") {
+			return fmt.Errorf("failed to cancel command %s: %w", commandID, err)
+		}
+	}
+	return nil
+}
+
+// dataVolumeDevice returns the device name the i-th data volume is attached
+// as. On Nitro instances the volumes show up as NVMe devices; Amazon Linux
+// udev rules link them to these names.
+func dataVolumeDevice(i int) string {
+	return fmt.Sprintf("/dev/sd%c", 'f'+i)
+}
+
+// dataVolumeMappings returns the block device mappings of data volumes
+func dataVolumeMappings(volumes []provider.DataVolume) []types.BlockDeviceMapping {
+	var mappings []types.BlockDeviceMapping
+	for i, v := range volumes {
+		mappings = append(mappings, types.BlockDeviceMapping{
+			DeviceName: aws.String(dataVolumeDevice(i)),
+			Ebs: &types.EbsBlockDevice{
+				VolumeSize:          aws.Int32(int32(v.SizeGiB)),
+				VolumeType:          types.VolumeType(v.Type),
+				DeleteOnTermination: aws.Bool(true),
+				Encrypted:           aws.Bool(true),
+			},
+		})
+	}
+	return mappings
+}
+
+// rootVolumeMapping overrides the root volume of an image. A zero size keeps
+// the size of the image's snapshot.
+func rootVolumeMapping(device string, config provider.InstanceConfig) types.BlockDeviceMapping {
+	volumeType := config.RootVolumeType
+	if volumeType == "" {
+		volumeType = string(types.VolumeTypeGp3)
+	}
+	ebs := &types.EbsBlockDevice{
+		VolumeType:          types.VolumeType(volumeType),
+		DeleteOnTermination: aws.Bool(true),
+	}
+	if config.RootVolumeSize > 0 {
+		ebs.VolumeSize = aws.Int32(int32(config.RootVolumeSize))
+	}
+	if config.RootVolumeIOPS > 0 {
+		ebs.Iops = aws.Int32(int32(config.RootVolumeIOPS))
+	}
+	return types.BlockDeviceMapping{DeviceName: aws.String(device), Ebs: ebs}
+}
+
+// rootDeviceName returns the root device of an image: /dev/xvda for Amazon
+// Linux, /dev/sda1 for Ubuntu. A mapping for any other device would attach
+// an extra volume instead of resizing the root one.
+func (s *ComputeService) rootDeviceName(ctx context.Context, ec2Client *ec2.Client, imageID string) string {
+	out, err := ec2Client.DescribeImages(ctx, &ec2.DescribeImagesInput{ImageIds: []string{imageID}})
+	if err == nil && len(out.Images) > 0 && out.Images[0].RootDeviceName != nil {
+		return aws.ToString(out.Images[0].RootDeviceName)
+	}
+	logger.Warnf(ctx, "Failed to look up root device of %s, assuming /dev/xvda: %v", imageID, err)
+	return "/dev/xvda"
+}
+
+// instanceStoreScript returns the bootstrap step that puts containerd images
+// and kubelet pod storage (emptyDir volumes) on the local NVMe instance
+// store. Instance store contents are lost when an instance stops, so a
+// systemd unit sets it up again on every boot before K3s starts. Devices are
+// striped if there are several. Empty unless enabled.
+func instanceStoreScript(enabled bool) string {
+	if !enabled {
+		return ""
+	}
+	return `
+# Instance store: local NVMe storage for containerd and emptyDir volumes
+cat > /usr/local/bin/goman-instance-store.sh <<'SCRIPT'
+#!/bin/bash
+set -e
+MOUNT=/mnt/instance-store
+DEVICES=$(ls /dev/disk/by-id/nvme-Amazon_EC2_NVMe_Instance_Storage_* 2>/dev/null | grep -v -- '-ns-\|-part' | xargs -r -n1 readlink -f | sort -u)
+if [ -z "$DEVICES" ]; then
+    echo "[$(date)] No instance store devices found, using the root volume" >> /var/log/goman-startup.log
+    exit 0
+fi
+if ! mountpoint -q "$MOUNT"; then
+    COUNT=$(echo "$DEVICES" | wc -l)
+    DEVICE=$(echo "$DEVICES" | head -n1)
+    if [ "$COUNT" -gt 1 ]; then
+        command -v mdadm > /dev/null 2>&1 || yum install -y mdadm
+        DEVICE=/dev/md/goman-instance-store
+        # Reassemble after a reboot, create after a stop/start wiped the devices
+        [ -e "$DEVICE" ] || mdadm --assemble "$DEVICE" $DEVICES 2> /dev/null ||
+            mdadm --create "$DEVICE" --run --level=0 --raid-devices="$COUNT" $DEVICES
+    fi
+    blkid "$DEVICE" > /dev/null 2>&1 || mkfs.xfs -f "$DEVICE"
+    mkdir -p "$MOUNT"
+    mount -o defaults,noatime "$DEVICE" "$MOUNT"
+fi
+for dir in /var/lib/rancher/k3s/agent/containerd /var/lib/kubelet; do
+    mkdir -p "$MOUNT$dir" "$dir"
+    mountpoint -q "$dir" || mount --bind "$MOUNT$dir" "$dir"
+done
+echo "[$(date)] Instance store mounted at $MOUNT ($DEVICES)" >> /var/log/goman-startup.log
+SCRIPT
+chmod +x /usr/local/bin/goman-instance-store.sh
+
+cat > /etc/systemd/system/goman-instance-store.service <<'UNIT'
+[Unit]
+Description=Set up instance store for K3s
+After=local-fs.target
+Before=k3s.service k3s-agent.service
+
+[Service]
+Type=oneshot
+RemainAfterExit=yes
+ExecStart=/usr/local/bin/goman-instance-store.sh
+
+[Install]
+WantedBy=multi-user.target
+UNIT
+systemctl daemon-reload
+systemctl enable goman-instance-store.service
+systemctl start goman-instance-store.service || echo "[$(date)] Instance store setup failed, using the root volume" >> /var/log/goman-startup.log
+`
+}
+
+// gpuScript returns the bootstrap step for NVIDIA GPU instances: it installs
+// the driver from NVIDIA's CUDA repository and the NVIDIA container toolkit,
+// which K3s picks up as a containerd runtime when it starts. The node uses it
+// as its default runtime and joins labeled nvidia.com/gpu=true. Empty unless
+// enabled.
+func gpuScript(enabled bool) string {
+	if !enabled {
+		return ""
+	}
+	return `
+# GPU: NVIDIA driver and container runtime, set up before K3s starts
+echo "[$(date)] Installing NVIDIA driver and container toolkit..." >> /var/log/goman-startup.log
+CUDA_ARCH=$(uname -m)
+[ "$CUDA_ARCH" = "aarch64" ] && CUDA_ARCH=sbsa
+dnf install -y dkms kernel-devel-$(uname -r) kernel-modules-extra-$(uname -r)
+dnf config-manager --add-repo https://developer.download.nvidia.com/compute/cuda/repos/amzn2023/$CUDA_ARCH/cuda-amzn2023.repo
+curl -fsSL -o /etc/yum.repos.d/nvidia-container-toolkit.repo https://nvidia.github.io/libnvidia-container/stable/rpm/nvidia-container-toolkit.repo
+dnf module install -y nvidia-driver:latest-dkms
+dnf install -y nvidia-container-toolkit
+modprobe nvidia || true
+if nvidia-smi >> /var/log/goman-startup.log 2>&1; then
+    echo "[$(date)] NVIDIA driver loaded" >> /var/log/goman-startup.log
+else
+    echo "[$(date)] WARNING: NVIDIA driver did not load, GPUs are unusable until it does" >> /var/log/goman-startup.log
+fi
+mkdir -p /etc/rancher/k3s/config.yaml.d
+cat > /etc/rancher/k3s/config.yaml.d/goman-gpu.yaml <<'CONFIG'
+default-runtime: nvidia
+node-label:
+  - nvidia.com/gpu=true
+CONFIG
+`
+}
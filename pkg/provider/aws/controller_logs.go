@@ -0,0 +1,80 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
+	"github.com/madhouselabs/goman/pkg/provider"
+)
+
+// maxControllerLogEvents caps the lines returned by one query
+const maxControllerLogEvents = 10000
+
+// controllerLogGroup is the log group of the controller Lambda
+func (p *AWSProvider) controllerLogGroup() string {
+	return fmt.Sprintf("/aws/lambda/goman-controller-%s", p.accountID)
+}
+
+// ControllerLogs reads the controller Lambda's lines from CloudWatch Logs.
+// For a request ID, the Lambda runtime's START and END lines of that
+// invocation bound the lines returned; otherwise lines mentioning the
+// cluster are returned.
+func (p *AWSProvider) ControllerLogs(ctx context.Context, query provider.ControllerLogQuery) ([]provider.LogEvent, error) {
+	client := cloudwatchlogs.NewFromConfig(p.cfg)
+	input := &cloudwatchlogs.FilterLogEventsInput{
+		LogGroupName: aws.String(p.controllerLogGroup()),
+		StartTime:    aws.Int64(query.Since.UnixMilli()),
+	}
+	if !query.Until.IsZero() {
+		input.EndTime = aws.Int64(query.Until.UnixMilli())
+	}
+
+	if query.RequestID != "" {
+		input.FilterPattern = aws.String(fmt.Sprintf("%q", "RequestId: "+query.RequestID))
+		bounds, err := filterLogEvents(ctx, client, input)
+		if err != nil || len(bounds) == 0 {
+			return nil, err
+		}
+		input.FilterPattern = nil
+		input.LogStreamNames = []string{bounds[0].Stream}
+		input.StartTime = aws.Int64(bounds[0].Time.UnixMilli())
+		// Without an END line yet, the invocation is still running
+		if last := bounds[len(bounds)-1]; len(bounds) > 1 || strings.HasPrefix(last.Message, "END") {
+			input.EndTime = aws.Int64(last.Time.UnixMilli() + 1)
+		}
+		return filterLogEvents(ctx, client, input)
+	}
+
+	if query.Cluster != "" {
+		input.FilterPattern = aws.String(fmt.Sprintf("%q", query.Cluster))
+	}
+	return filterLogEvents(ctx, client, input)
+}
+
+// filterLogEvents runs a FilterLogEvents query across all its pages and
+// returns the lines oldest first
+func filterLogEvents(ctx context.Context, client *cloudwatchlogs.Client, input *cloudwatchlogs.FilterLogEventsInput) ([]provider.LogEvent, error) {
+	var events []provider.LogEvent
+	paginator := cloudwatchlogs.NewFilterLogEventsPaginator(client, input)
+	for paginator.HasMorePages() && len(events) < maxControllerLogEvents {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, wrapAWSError("logs", "FilterLogEvents", err)
+		}
+		for _, e := range page.Events {
+			events = append(events, provider.LogEvent{
+				ID:      aws.ToString(e.EventId),
+				Time:    time.UnixMilli(aws.ToInt64(e.Timestamp)),
+				Stream:  aws.ToString(e.LogStreamName),
+				Message: strings.TrimRight(aws.ToString(e.Message), "\n"),
+			})
+		}
+	}
+	sort.SliceStable(events, func(i, j int) bool { return events[i].Time.Before(events[j].Time) })
+	return events, nil
+}
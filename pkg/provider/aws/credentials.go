@@ -0,0 +1,147 @@
+package aws
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/madhouselabs/goman/pkg/logger"
+)
+
+// expiredCredentialCodes are AWS error codes returned when the caller's
+// session credentials are no longer valid
+var expiredCredentialCodes = map[string]bool{
+	"ExpiredToken":                true,
+	"ExpiredTokenException":       true,
+	"RequestExpired":              true,
+	"InvalidClientTokenId":        true,
+	"UnrecognizedClientException": true,
+	"InvalidGrantException":       true,
+	"UnauthorizedException":       true, // SSO GetRoleCredentials with a stale access token
+}
+
+// expiredCredentialMessages match credential provider failures that don't
+// carry an API error code (e.g., the SSO token cache has expired)
+var expiredCredentialMessages = []string{
+	"sso session has expired",
+	"the sso session associated with this profile has expired",
+	"refresh cached sso token failed",
+	"failed to refresh cached credentials",
+	"token has expired",
+	"security token included in the request is expired",
+}
+
+// IsCredentialExpiredError reports whether err was caused by expired or
+// revoked session credentials (SSO or assumed role) that a fresh login fixes
+func IsCredentialExpiredError(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	var coded interface{ ErrorCode() string }
+	if errors.As(err, &coded) && expiredCredentialCodes[coded.ErrorCode()] {
+		return true
+	}
+
+	msg := strings.ToLower(err.Error())
+	for _, pattern := range expiredCredentialMessages {
+		if strings.Contains(msg, pattern) {
+			return true
+		}
+	}
+	return false
+}
+
+// CredentialStatus describes the credentials a provider is using
+type CredentialStatus struct {
+	Source    string    // Credential provider name (e.g., SSOProvider, AssumeRoleProvider)
+	CanExpire bool      // Session credentials expire and are refreshed by the SDK
+	Expires   time.Time // Expiry of the current credentials when CanExpire is set
+}
+
+// CredentialStatus retrieves the current credentials, refreshing them through
+// the SDK credential cache if they are about to expire
+func (p *AWSProvider) CredentialStatus(ctx context.Context) (*CredentialStatus, error) {
+	creds, err := p.cfg.Credentials.Retrieve(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve AWS credentials: %w", err)
+	}
+	return &CredentialStatus{
+		Source:    creds.Source,
+		CanExpire: creds.CanExpire,
+		Expires:   creds.Expires,
+	}, nil
+}
+
+// UsesSSO reports whether a shared config profile obtains credentials through
+// IAM Identity Center, either directly or via its source profile chain
+func UsesSSO(ctx context.Context, profile string) bool {
+	if profile == "" {
+		profile = os.Getenv("AWS_PROFILE")
+	}
+	if profile == "" {
+		profile = "default"
+	}
+
+	shared, err := config.LoadSharedConfigProfile(ctx, profile)
+	if err != nil {
+		return false
+	}
+
+	for cfg := &shared; cfg != nil; cfg = cfg.Source {
+		if cfg.SSOSessionName != "" || cfg.SSOStartURL != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// SSOLogin runs "aws sso login" for a profile, attached to the terminal so
+// the user can complete the device authorization flow
+func SSOLogin(ctx context.Context, profile string) error {
+	args := []string{"sso", "login"}
+	if profile != "" {
+		args = append(args, "--profile", profile)
+	}
+
+	cmd := exec.CommandContext(ctx, "aws", args...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("aws sso login failed: %w", err)
+	}
+	return nil
+}
+
+// RefreshCredentials renews expired session credentials and rebuilds the
+// cached provider. SSO profiles go through "aws sso login"; other profiles
+// (assumed roles, env credentials) are simply reloaded so rotated keys or a
+// refreshed credential_process are picked up.
+func RefreshCredentials(ctx context.Context, profile, region string) (*AWSProvider, error) {
+	if UsesSSO(ctx, profile) {
+		logger.Infof(ctx, "AWS SSO session expired for profile %q, starting login", profile)
+		if err := SSOLogin(ctx, profile); err != nil {
+			return nil, err
+		}
+	}
+
+	// Drop the provider and clients bound to the expired credentials
+	ClearProviderCache()
+
+	prov, err := GetCachedProvider(profile, region)
+	if err != nil {
+		return nil, fmt.Errorf("failed to rebuild AWS provider: %w", err)
+	}
+
+	// Verify the new credentials before handing the provider out
+	if _, err := prov.CredentialStatus(ctx); err != nil {
+		return nil, err
+	}
+	return prov, nil
+}
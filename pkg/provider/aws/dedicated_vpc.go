@@ -0,0 +1,544 @@
+package aws
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"net"
+	"slices"
+	"sort"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/madhouselabs/goman/pkg/logger"
+	"github.com/madhouselabs/goman/pkg/provider"
+)
+
+// A dedicated VPC belongs to one cluster and is deleted with it. Its range is
+// split in eight: the first four blocks hold a public subnet per zone, the
+// last four the private subnet of the same zone. Public subnets route to an
+// internet gateway and give instances public IPs; private subnets route
+// through a NAT gateway, created for private clusters only, and reach S3
+// through a gateway endpoint.
+
+// dedicatedVPCTag marks a dedicated VPC and everything goman creates in it,
+// with the cluster name as value
+const dedicatedVPCTag = "goman-dedicated-vpc"
+
+// dedicatedVPCTierTag tells the public and private subnets and route tables
+// of a dedicated VPC apart
+const dedicatedVPCTierTag = "goman-tier"
+
+// dedicatedVPCZones is how many availability zones a new dedicated VPC spans
+const dedicatedVPCZones = 3
+
+// dedicatedVPCMaxZones is how many zones fit in the range of a dedicated VPC
+const dedicatedVPCMaxZones = 4
+
+// dedicatedNetwork is a cluster's dedicated VPC and its subnets, in zone order
+type dedicatedNetwork struct {
+	VPCID          string
+	PublicSubnets  []string
+	PrivateSubnets []string
+}
+
+// ensureDedicatedVPC returns the dedicated VPC of a cluster, creating it
+// and its subnets, gateways and route tables on first use. A zone the VPC
+// does not span yet gets its subnets added; private clusters get a NAT
+// gateway.
+func (s *ComputeService) ensureDedicatedVPC(ctx context.Context, ec2Client *ec2.Client, region, clusterName, cidr, zone string, private bool) (*dedicatedNetwork, error) {
+	if clusterName == "" {
+		return nil, fmt.Errorf("a dedicated VPC needs the instance's cluster")
+	}
+	s.dedicatedVPCMu.Lock()
+	defer s.dedicatedVPCMu.Unlock()
+
+	vpcID, err := s.findDedicatedVPC(ctx, ec2Client, clusterName)
+	if err != nil {
+		return nil, err
+	}
+	if vpcID == "" {
+		if vpcID, err = s.createDedicatedVPC(ctx, ec2Client, clusterName, cidr); err != nil {
+			return nil, err
+		}
+	}
+
+	publicTable, err := s.ensureDedicatedRouteTable(ctx, ec2Client, region, vpcID, clusterName, "public")
+	if err != nil {
+		return nil, err
+	}
+	privateTable, err := s.ensureDedicatedRouteTable(ctx, ec2Client, region, vpcID, clusterName, "private")
+	if err != nil {
+		return nil, err
+	}
+
+	network, err := s.ensureDedicatedSubnets(ctx, ec2Client, vpcID, clusterName, zone, publicTable, privateTable)
+	if err != nil {
+		return nil, err
+	}
+
+	if private {
+		natID, err := s.ensureNATGateway(ctx, ec2Client, &NetworkInfo{VPCID: vpcID, SubnetID: network.PublicSubnets[0]})
+		if err != nil {
+			return nil, err
+		}
+		if err := ensureDefaultRoute(ctx, ec2Client, privateTable, &ec2.CreateRouteInput{NatGatewayId: aws.String(natID)}); err != nil {
+			return nil, err
+		}
+	}
+	return network, nil
+}
+
+// findDedicatedVPC returns the ID of a cluster's dedicated VPC, empty if it
+// has none
+func (s *ComputeService) findDedicatedVPC(ctx context.Context, ec2Client *ec2.Client, clusterName string) (string, error) {
+	vpcs, err := ec2Client.DescribeVpcs(ctx, &ec2.DescribeVpcsInput{
+		Filters: []types.Filter{{Name: aws.String("tag:" + dedicatedVPCTag), Values: []string{clusterName}}},
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to describe VPCs: %w", wrapAWSError("ec2", "DescribeVpcs", err))
+	}
+	if len(vpcs.Vpcs) == 0 {
+		return "", nil
+	}
+	return aws.ToString(vpcs.Vpcs[0].VpcId), nil
+}
+
+// createDedicatedVPC creates a cluster's VPC with its internet gateway
+func (s *ComputeService) createDedicatedVPC(ctx context.Context, ec2Client *ec2.Client, clusterName, cidr string) (string, error) {
+	logger.Infof(ctx, "Creating dedicated VPC %s for cluster %s", cidr, clusterName)
+	created, err := ec2Client.CreateVpc(ctx, &ec2.CreateVpcInput{
+		CidrBlock:         aws.String(cidr),
+		TagSpecifications: []types.TagSpecification{dedicatedVPCTags(types.ResourceTypeVpc, clusterName, "goman-"+clusterName)},
+	})
+	if err != nil {
+		if hasErrorCode(err, "VpcLimitExceeded") {
+			return "", provider.UserConfigErrorf("no VPC left in the account's quota for the dedicated VPC of cluster %s: %v", clusterName, err)
+		}
+		return "", fmt.Errorf("failed to create VPC: %w", wrapAWSError("ec2", "CreateVpc", err))
+	}
+	vpcID := aws.ToString(created.Vpc.VpcId)
+
+	waiter := ec2.NewVpcAvailableWaiter(ec2Client)
+	if err := waiter.Wait(ctx, &ec2.DescribeVpcsInput{VpcIds: []string{vpcID}}, natGatewayTimeout); err != nil {
+		return "", fmt.Errorf("VPC %s did not become available: %w", vpcID, err)
+	}
+
+	// Instances get DNS names, which SSM and K3s node names rely on
+	if _, err := ec2Client.ModifyVpcAttribute(ctx, &ec2.ModifyVpcAttributeInput{
+		VpcId:              aws.String(vpcID),
+		EnableDnsHostnames: &types.AttributeBooleanValue{Value: aws.Bool(true)},
+	}); err != nil {
+		return "", fmt.Errorf("failed to enable DNS hostnames in VPC %s: %w", vpcID, wrapAWSError("ec2", "ModifyVpcAttribute", err))
+	}
+
+	gateway, err := ec2Client.CreateInternetGateway(ctx, &ec2.CreateInternetGatewayInput{
+		TagSpecifications: []types.TagSpecification{dedicatedVPCTags(types.ResourceTypeInternetGateway, clusterName, "goman-"+clusterName)},
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to create internet gateway: %w", wrapAWSError("ec2", "CreateInternetGateway", err))
+	}
+	if _, err := ec2Client.AttachInternetGateway(ctx, &ec2.AttachInternetGatewayInput{
+		InternetGatewayId: gateway.InternetGateway.InternetGatewayId,
+		VpcId:             aws.String(vpcID),
+	}); err != nil {
+		return "", fmt.Errorf("failed to attach internet gateway to VPC %s: %w", vpcID, wrapAWSError("ec2", "AttachInternetGateway", err))
+	}
+	return vpcID, nil
+}
+
+// ensureDedicatedRouteTable returns the route table of one tier of a
+// dedicated VPC. The public one routes to the internet gateway; the private
+// one reaches S3 through a gateway endpoint.
+func (s *ComputeService) ensureDedicatedRouteTable(ctx context.Context, ec2Client *ec2.Client, region, vpcID, clusterName, tier string) (string, error) {
+	tables, err := ec2Client.DescribeRouteTables(ctx, &ec2.DescribeRouteTablesInput{
+		Filters: []types.Filter{
+			{Name: aws.String("vpc-id"), Values: []string{vpcID}},
+			{Name: aws.String("tag:" + dedicatedVPCTierTag), Values: []string{tier}},
+		},
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to describe route tables: %w", wrapAWSError("ec2", "DescribeRouteTables", err))
+	}
+	if len(tables.RouteTables) > 0 {
+		return aws.ToString(tables.RouteTables[0].RouteTableId), nil
+	}
+
+	tags := dedicatedVPCTags(types.ResourceTypeRouteTable, clusterName, fmt.Sprintf("goman-%s-%s", clusterName, tier))
+	tags.Tags = append(tags.Tags, types.Tag{Key: aws.String(dedicatedVPCTierTag), Value: aws.String(tier)})
+	created, err := ec2Client.CreateRouteTable(ctx, &ec2.CreateRouteTableInput{
+		VpcId:             aws.String(vpcID),
+		TagSpecifications: []types.TagSpecification{tags},
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to create %s route table: %w", tier, wrapAWSError("ec2", "CreateRouteTable", err))
+	}
+	routeTableID := aws.ToString(created.RouteTable.RouteTableId)
+
+	if tier == "private" {
+		if err := s.ensureS3Endpoint(ctx, ec2Client, region, vpcID, routeTableID); err != nil {
+			// S3 is still reached through the NAT gateway of private clusters
+			logger.Warnf(ctx, "S3 gateway endpoint not set up for VPC %s: %v", vpcID, err)
+		}
+		return routeTableID, nil
+	}
+
+	gateways, err := ec2Client.DescribeInternetGateways(ctx, &ec2.DescribeInternetGatewaysInput{
+		Filters: []types.Filter{{Name: aws.String("attachment.vpc-id"), Values: []string{vpcID}}},
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to describe internet gateways: %w", wrapAWSError("ec2", "DescribeInternetGateways", err))
+	}
+	if len(gateways.InternetGateways) == 0 {
+		return "", fmt.Errorf("VPC %s has no internet gateway", vpcID)
+	}
+	if err := ensureDefaultRoute(ctx, ec2Client, routeTableID, &ec2.CreateRouteInput{GatewayId: gateways.InternetGateways[0].InternetGatewayId}); err != nil {
+		return "", err
+	}
+	return routeTableID, nil
+}
+
+// ensureDefaultRoute adds the default route of a route table to the target
+// set in route, unless the table has one
+func ensureDefaultRoute(ctx context.Context, ec2Client *ec2.Client, routeTableID string, route *ec2.CreateRouteInput) error {
+	tables, err := ec2Client.DescribeRouteTables(ctx, &ec2.DescribeRouteTablesInput{RouteTableIds: []string{routeTableID}})
+	if err != nil {
+		return fmt.Errorf("failed to describe route table %s: %w", routeTableID, wrapAWSError("ec2", "DescribeRouteTables", err))
+	}
+	for _, table := range tables.RouteTables {
+		for _, r := range table.Routes {
+			if aws.ToString(r.DestinationCidrBlock) == "0.0.0.0/0" && r.State != types.RouteStateBlackhole {
+				return nil
+			}
+		}
+	}
+	route.RouteTableId = aws.String(routeTableID)
+	route.DestinationCidrBlock = aws.String("0.0.0.0/0")
+	if _, err := ec2Client.CreateRoute(ctx, route); err != nil && !hasErrorCode(err, "RouteAlreadyExists") {
+		return fmt.Errorf("failed to add default route to %s: %w", routeTableID, wrapAWSError("ec2", "CreateRoute", err))
+	}
+	return nil
+}
+
+// ensureDedicatedSubnets returns the subnets of a dedicated VPC, creating
+// the public and private subnet of the first zones of the region, and of
+// zone if the VPC does not span it yet
+func (s *ComputeService) ensureDedicatedSubnets(ctx context.Context, ec2Client *ec2.Client, vpcID, clusterName, zone, publicTable, privateTable string) (*dedicatedNetwork, error) {
+	vpcs, err := ec2Client.DescribeVpcs(ctx, &ec2.DescribeVpcsInput{VpcIds: []string{vpcID}})
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe VPC %s: %w", vpcID, wrapAWSError("ec2", "DescribeVpcs", err))
+	}
+	if len(vpcs.Vpcs) == 0 {
+		return nil, fmt.Errorf("VPC %s not found", vpcID)
+	}
+	cidr := aws.ToString(vpcs.Vpcs[0].CidrBlock)
+
+	subnets, err := ec2Client.DescribeSubnets(ctx, &ec2.DescribeSubnetsInput{
+		Filters: []types.Filter{{Name: aws.String("vpc-id"), Values: []string{vpcID}}},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe subnets: %w", wrapAWSError("ec2", "DescribeSubnets", err))
+	}
+	public := make(map[string]string)
+	private := make(map[string]string)
+	used := make(map[string]bool)
+	for _, subnet := range subnets.Subnets {
+		used[aws.ToString(subnet.CidrBlock)] = true
+		az := aws.ToString(subnet.AvailabilityZone)
+		switch tagMap(subnet.Tags)[dedicatedVPCTierTag] {
+		case "public":
+			public[az] = aws.ToString(subnet.SubnetId)
+		case "private":
+			private[az] = aws.ToString(subnet.SubnetId)
+		}
+	}
+
+	var zones []string
+	if len(public) == 0 {
+		if zones, err = availableZones(ctx, ec2Client); err != nil {
+			return nil, err
+		}
+		if len(zones) > dedicatedVPCZones {
+			zones = zones[:dedicatedVPCZones]
+		}
+	} else {
+		for az := range public {
+			zones = append(zones, az)
+		}
+	}
+	if zone != "" && !slices.Contains(zones, zone) {
+		zones = append(zones, zone)
+	}
+	sort.Strings(zones)
+
+	network := &dedicatedNetwork{VPCID: vpcID}
+	for _, az := range zones {
+		if public[az] == "" || private[az] == "" {
+			block, err := freeDedicatedBlock(cidr, used)
+			if err != nil {
+				return nil, provider.UserConfigErrorf("dedicated VPC %s of cluster %s cannot span zone %s: %v", vpcID, clusterName, az, err)
+			}
+			if public[az] == "" {
+				if public[az], err = s.createDedicatedSubnet(ctx, ec2Client, vpcID, clusterName, az, "public", subnetBlock(cidr, block), publicTable); err != nil {
+					return nil, err
+				}
+			}
+			if private[az] == "" {
+				if private[az], err = s.createDedicatedSubnet(ctx, ec2Client, vpcID, clusterName, az, "private", subnetBlock(cidr, block+dedicatedVPCMaxZones), privateTable); err != nil {
+					return nil, err
+				}
+			}
+			used[subnetBlock(cidr, block)] = true
+			used[subnetBlock(cidr, block+dedicatedVPCMaxZones)] = true
+		}
+		network.PublicSubnets = append(network.PublicSubnets, public[az])
+		network.PrivateSubnets = append(network.PrivateSubnets, private[az])
+	}
+	return network, nil
+}
+
+// createDedicatedSubnet creates one subnet of a dedicated VPC in its tier's
+// route table; public subnets give instances public IPs
+func (s *ComputeService) createDedicatedSubnet(ctx context.Context, ec2Client *ec2.Client, vpcID, clusterName, zone, tier, cidr, routeTableID string) (string, error) {
+	logger.Infof(ctx, "Creating %s subnet %s in %s of VPC %s", tier, cidr, zone, vpcID)
+	tags := dedicatedVPCTags(types.ResourceTypeSubnet, clusterName, fmt.Sprintf("goman-%s-%s-%s", clusterName, tier, zone))
+	tags.Tags = append(tags.Tags, types.Tag{Key: aws.String(dedicatedVPCTierTag), Value: aws.String(tier)})
+	created, err := ec2Client.CreateSubnet(ctx, &ec2.CreateSubnetInput{
+		VpcId:             aws.String(vpcID),
+		AvailabilityZone:  aws.String(zone),
+		CidrBlock:         aws.String(cidr),
+		TagSpecifications: []types.TagSpecification{tags},
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to create %s subnet in %s: %w", tier, zone, wrapAWSError("ec2", "CreateSubnet", err))
+	}
+	subnetID := aws.ToString(created.Subnet.SubnetId)
+
+	if tier == "public" {
+		if _, err := ec2Client.ModifySubnetAttribute(ctx, &ec2.ModifySubnetAttributeInput{
+			SubnetId:            aws.String(subnetID),
+			MapPublicIpOnLaunch: &types.AttributeBooleanValue{Value: aws.Bool(true)},
+		}); err != nil {
+			return "", fmt.Errorf("failed to enable public IPs in subnet %s: %w", subnetID, wrapAWSError("ec2", "ModifySubnetAttribute", err))
+		}
+	}
+	if _, err := ec2Client.AssociateRouteTable(ctx, &ec2.AssociateRouteTableInput{
+		RouteTableId: aws.String(routeTableID),
+		SubnetId:     aws.String(subnetID),
+	}); err != nil {
+		return "", fmt.Errorf("failed to associate subnet %s with its route table: %w", subnetID, wrapAWSError("ec2", "AssociateRouteTable", err))
+	}
+	return subnetID, nil
+}
+
+// availableZones returns the region's availability zones, without local
+// and wavelength zones, in name order
+func availableZones(ctx context.Context, ec2Client *ec2.Client) ([]string, error) {
+	output, err := ec2Client.DescribeAvailabilityZones(ctx, &ec2.DescribeAvailabilityZonesInput{
+		Filters: []types.Filter{
+			{Name: aws.String("state"), Values: []string{"available"}},
+			{Name: aws.String("zone-type"), Values: []string{"availability-zone"}},
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe availability zones: %w", wrapAWSError("ec2", "DescribeAvailabilityZones", err))
+	}
+	var zones []string
+	for _, az := range output.AvailabilityZones {
+		zones = append(zones, aws.ToString(az.ZoneName))
+	}
+	if len(zones) == 0 {
+		return nil, fmt.Errorf("no availability zone available")
+	}
+	sort.Strings(zones)
+	return zones, nil
+}
+
+// subnetBlock returns the i-th of the eight blocks a VPC range is split in
+func subnetBlock(vpcCIDR string, i int) string {
+	_, vpcNet, err := net.ParseCIDR(vpcCIDR)
+	if err != nil {
+		return ""
+	}
+	ones, _ := vpcNet.Mask.Size()
+	size := uint32(1) << (32 - ones - 3)
+	ip := make(net.IP, 4)
+	binary.BigEndian.PutUint32(ip, binary.BigEndian.Uint32(vpcNet.IP.To4())+uint32(i)*size)
+	return (&net.IPNet{IP: ip, Mask: net.CIDRMask(ones+3, 32)}).String()
+}
+
+// freeDedicatedBlock returns the first zone block whose public and private
+// subnets are both free
+func freeDedicatedBlock(vpcCIDR string, used map[string]bool) (int, error) {
+	for i := 0; i < dedicatedVPCMaxZones; i++ {
+		if !used[subnetBlock(vpcCIDR, i)] && !used[subnetBlock(vpcCIDR, i+dedicatedVPCMaxZones)] {
+			return i, nil
+		}
+	}
+	return 0, fmt.Errorf("all %d zones of its range %s are taken", dedicatedVPCMaxZones, vpcCIDR)
+}
+
+// DeleteDedicatedNetwork removes a cluster's dedicated VPC with its NAT
+// gateway and Elastic IP, endpoints, subnets, route tables, security groups
+// and internet gateway. NAT gateways take a minute to delete, so the VPC
+// usually goes on a later call.
+func (s *ComputeService) DeleteDedicatedNetwork(ctx context.Context, region, clusterName string) error {
+	ec2Client := s.getEC2Client(region)
+	s.dedicatedVPCMu.Lock()
+	defer s.dedicatedVPCMu.Unlock()
+
+	vpcID, err := s.findDedicatedVPC(ctx, ec2Client, clusterName)
+	if err != nil || vpcID == "" {
+		return err
+	}
+	inVPC := []types.Filter{{Name: aws.String("vpc-id"), Values: []string{vpcID}}}
+
+	instances, err := ec2Client.DescribeInstances(ctx, &ec2.DescribeInstancesInput{
+		Filters: append(inVPC, types.Filter{
+			Name:   aws.String("instance-state-name"),
+			Values: []string{"pending", "running", "shutting-down", "stopping", "stopped"},
+		}),
+	})
+	if err != nil {
+		return wrapAWSError("ec2", "DescribeInstances", err)
+	}
+	for _, reservation := range instances.Reservations {
+		if len(reservation.Instances) > 0 {
+			return fmt.Errorf("%w: instances still in VPC %s", provider.ErrNetworkInUse, vpcID)
+		}
+	}
+
+	// NAT gateways first, their Elastic IPs once they are gone
+	gateways, err := ec2Client.DescribeNatGateways(ctx, &ec2.DescribeNatGatewaysInput{Filter: inVPC})
+	if err != nil {
+		return wrapAWSError("ec2", "DescribeNatGateways", err)
+	}
+	deleting := false
+	for _, gateway := range gateways.NatGateways {
+		switch gateway.State {
+		case types.NatGatewayStatePending, types.NatGatewayStateAvailable:
+			logger.Infof(ctx, "Deleting NAT gateway %s of VPC %s", aws.ToString(gateway.NatGatewayId), vpcID)
+			if _, err := ec2Client.DeleteNatGateway(ctx, &ec2.DeleteNatGatewayInput{NatGatewayId: gateway.NatGatewayId}); err != nil {
+				return wrapAWSError("ec2", "DeleteNatGateway", err)
+			}
+			deleting = true
+		case types.NatGatewayStateDeleting:
+			deleting = true
+		case types.NatGatewayStateDeleted:
+			for _, address := range gateway.NatGatewayAddresses {
+				if address.AllocationId == nil {
+					continue
+				}
+				_, err := ec2Client.ReleaseAddress(ctx, &ec2.ReleaseAddressInput{AllocationId: address.AllocationId})
+				if err != nil && !hasErrorCode(err, "InvalidAllocationID.NotFound") {
+					return fmt.Errorf("failed to release Elastic IP %s: %w", aws.ToString(address.PublicIp), wrapAWSError("ec2", "ReleaseAddress", err))
+				}
+			}
+		}
+	}
+	if deleting {
+		return fmt.Errorf("%w: NAT gateway of VPC %s is being deleted", provider.ErrNetworkInUse, vpcID)
+	}
+
+	endpoints, err := ec2Client.DescribeVpcEndpoints(ctx, &ec2.DescribeVpcEndpointsInput{Filters: inVPC})
+	if err != nil {
+		return wrapAWSError("ec2", "DescribeVpcEndpoints", err)
+	}
+	var endpointIDs []string
+	for _, endpoint := range endpoints.VpcEndpoints {
+		if endpoint.State != types.StateDeleted && endpoint.State != types.StateDeleting {
+			endpointIDs = append(endpointIDs, aws.ToString(endpoint.VpcEndpointId))
+		}
+	}
+	if len(endpointIDs) > 0 {
+		if _, err := ec2Client.DeleteVpcEndpoints(ctx, &ec2.DeleteVpcEndpointsInput{VpcEndpointIds: endpointIDs}); err != nil {
+			return wrapAWSError("ec2", "DeleteVpcEndpoints", err)
+		}
+	}
+
+	groups, err := ec2Client.DescribeSecurityGroups(ctx, &ec2.DescribeSecurityGroupsInput{Filters: inVPC})
+	if err != nil {
+		return wrapAWSError("ec2", "DescribeSecurityGroups", err)
+	}
+	for _, group := range groups.SecurityGroups {
+		if aws.ToString(group.GroupName) == "default" {
+			continue
+		}
+		if _, err := ec2Client.DeleteSecurityGroup(ctx, &ec2.DeleteSecurityGroupInput{GroupId: group.GroupId}); err != nil {
+			return dependencyError("security group "+aws.ToString(group.GroupId), vpcID, wrapAWSError("ec2", "DeleteSecurityGroup", err))
+		}
+	}
+
+	subnets, err := ec2Client.DescribeSubnets(ctx, &ec2.DescribeSubnetsInput{Filters: inVPC})
+	if err != nil {
+		return wrapAWSError("ec2", "DescribeSubnets", err)
+	}
+	for _, subnet := range subnets.Subnets {
+		if _, err := ec2Client.DeleteSubnet(ctx, &ec2.DeleteSubnetInput{SubnetId: subnet.SubnetId}); err != nil {
+			return dependencyError("subnet "+aws.ToString(subnet.SubnetId), vpcID, wrapAWSError("ec2", "DeleteSubnet", err))
+		}
+	}
+
+	tables, err := ec2Client.DescribeRouteTables(ctx, &ec2.DescribeRouteTablesInput{Filters: inVPC})
+	if err != nil {
+		return wrapAWSError("ec2", "DescribeRouteTables", err)
+	}
+	for _, table := range tables.RouteTables {
+		main := false
+		for _, association := range table.Associations {
+			main = main || aws.ToBool(association.Main)
+		}
+		if main {
+			continue
+		}
+		if _, err := ec2Client.DeleteRouteTable(ctx, &ec2.DeleteRouteTableInput{RouteTableId: table.RouteTableId}); err != nil {
+			return dependencyError("route table "+aws.ToString(table.RouteTableId), vpcID, wrapAWSError("ec2", "DeleteRouteTable", err))
+		}
+	}
+
+	igws, err := ec2Client.DescribeInternetGateways(ctx, &ec2.DescribeInternetGatewaysInput{
+		Filters: []types.Filter{{Name: aws.String("attachment.vpc-id"), Values: []string{vpcID}}},
+	})
+	if err != nil {
+		return wrapAWSError("ec2", "DescribeInternetGateways", err)
+	}
+	for _, igw := range igws.InternetGateways {
+		if _, err := ec2Client.DetachInternetGateway(ctx, &ec2.DetachInternetGatewayInput{
+			InternetGatewayId: igw.InternetGatewayId,
+			VpcId:             aws.String(vpcID),
+		}); err != nil {
+			return dependencyError("internet gateway "+aws.ToString(igw.InternetGatewayId), vpcID, wrapAWSError("ec2", "DetachInternetGateway", err))
+		}
+		if _, err := ec2Client.DeleteInternetGateway(ctx, &ec2.DeleteInternetGatewayInput{InternetGatewayId: igw.InternetGatewayId}); err != nil {
+			return wrapAWSError("ec2", "DeleteInternetGateway", err)
+		}
+	}
+
+	if _, err := ec2Client.DeleteVpc(ctx, &ec2.DeleteVpcInput{VpcId: aws.String(vpcID)}); err != nil {
+		return dependencyError("VPC", vpcID, wrapAWSError("ec2", "DeleteVpc", err))
+	}
+	logger.Infof(ctx, "Deleted dedicated VPC %s of cluster %s", vpcID, clusterName)
+	return nil
+}
+
+// dependencyError reports a resource of a VPC that could not be deleted;
+// resources still used by something being deleted are in use, not failed
+func dependencyError(resource, vpcID string, err error) error {
+	if hasErrorCode(err, "DependencyViolation") {
+		return fmt.Errorf("%w: %s of VPC %s: %v", provider.ErrNetworkInUse, resource, vpcID, err)
+	}
+	return fmt.Errorf("failed to delete %s of VPC %s: %w", resource, vpcID, err)
+}
+
+// dedicatedVPCTags tags a resource of a cluster's dedicated VPC
+func dedicatedVPCTags(resourceType types.ResourceType, clusterName, name string) types.TagSpecification {
+	return types.TagSpecification{
+		ResourceType: resourceType,
+		Tags: []types.Tag{
+			{Key: aws.String("Name"), Value: aws.String(name)},
+			{Key: aws.String(dedicatedVPCTag), Value: aws.String(clusterName)},
+			{Key: aws.String("ManagedBy"), Value: aws.String("goman")},
+		},
+	}
+}
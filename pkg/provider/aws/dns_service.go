@@ -3,13 +3,14 @@ package aws
 import (
 	"context"
 	"fmt"
-	"log"
 	"strings"
 	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/route53"
 	"github.com/aws/aws-sdk-go-v2/service/route53/types"
+
+	"github.com/madhouselabs/goman/pkg/logger"
 )
 
 // DNSService implements provider.DNSService for AWS Route53
@@ -41,7 +42,7 @@ func (d *DNSService) Initialize(ctx context.Context, config map[string]string) e
 	if config != nil {
 		if vpcID, ok := config["vpc_id"]; ok && vpcID != "" {
 			d.vpcID = vpcID
-			log.Printf("[DNS] Using VPC ID from config: %s", vpcID)
+			logger.Infof(ctx, "[DNS] Using VPC ID from config: %s", vpcID)
 		}
 	}
 	// Check if hosted zone already exists
@@ -52,12 +53,12 @@ func (d *DNSService) Initialize(ctx context.Context, config map[string]string) e
 
 	if existingZone != nil {
 		d.hostedZoneID = *existingZone.Id
-		log.Printf("[DNS] Using existing hosted zone %s for domain %s", d.hostedZoneID, d.zoneName)
+		logger.Infof(ctx, "[DNS] Using existing hosted zone %s for domain %s", d.hostedZoneID, d.zoneName)
 		return nil
 	}
 
 	// Create new private hosted zone
-	log.Printf("[DNS] Creating new private hosted zone for domain %s", d.zoneName)
+	logger.Infof(ctx, "[DNS] Creating new private hosted zone for domain %s", d.zoneName)
 	
 	input := &route53.CreateHostedZoneInput{
 		Name:            aws.String(d.zoneName),
@@ -82,7 +83,7 @@ func (d *DNSService) Initialize(ctx context.Context, config map[string]string) e
 	}
 
 	d.hostedZoneID = *result.HostedZone.Id
-	log.Printf("[DNS] Created hosted zone %s for domain %s", d.hostedZoneID, d.zoneName)
+	logger.Infof(ctx, "[DNS] Created hosted zone %s for domain %s", d.hostedZoneID, d.zoneName)
 	
 	// Wait for zone to be created
 	time.Sleep(2 * time.Second)
@@ -139,18 +140,18 @@ func (d *DNSService) CreateRecordSet(ctx context.Context, domain string, recordT
 	if err != nil {
 		// If record already exists, try to update it instead
 		if strings.Contains(err.Error(), "already exists") {
-			log.Printf("[DNS] Record %s already exists, updating instead", domain)
+			logger.Infof(ctx, "[DNS] Record %s already exists, updating instead", domain)
 			return d.UpdateRecordSet(ctx, domain, recordType, records, ttl)
 		}
 		return fmt.Errorf("failed to create DNS record: %w", err)
 	}
 
-	log.Printf("[DNS] Created DNS record %s (%s) with values %v, change ID: %s", 
+	logger.Infof(ctx, "[DNS] Created DNS record %s (%s) with values %v, change ID: %s", 
 		domain, recordType, records, *result.ChangeInfo.Id)
 	
 	// Wait for change to propagate
 	if err := d.waitForChange(ctx, *result.ChangeInfo.Id); err != nil {
-		log.Printf("[DNS] Warning: failed to wait for change propagation: %v", err)
+		logger.Warnf(ctx, "[DNS] Warning: failed to wait for change propagation: %v", err)
 	}
 	
 	return nil
@@ -200,11 +201,11 @@ func (d *DNSService) UpdateRecordSet(ctx context.Context, domain string, recordT
 		return fmt.Errorf("failed to update DNS record: %w", err)
 	}
 
-	log.Printf("[DNS] Updated DNS record %s (%s) with values %v", domain, recordType, records)
+	logger.Infof(ctx, "[DNS] Updated DNS record %s (%s) with values %v", domain, recordType, records)
 	
 	// Wait for change to propagate
 	if err := d.waitForChange(ctx, *result.ChangeInfo.Id); err != nil {
-		log.Printf("[DNS] Warning: failed to wait for change propagation: %v", err)
+		logger.Warnf(ctx, "[DNS] Warning: failed to wait for change propagation: %v", err)
 	}
 	
 	return nil
@@ -225,7 +226,7 @@ func (d *DNSService) DeleteRecordSet(ctx context.Context, domain string, recordT
 	}
 
 	if len(records) == 0 {
-		log.Printf("[DNS] Record %s (%s) not found, nothing to delete", domain, recordType)
+		logger.Infof(ctx, "[DNS] Record %s (%s) not found, nothing to delete", domain, recordType)
 		return nil
 	}
 
@@ -281,11 +282,11 @@ func (d *DNSService) DeleteRecordSet(ctx context.Context, domain string, recordT
 		return fmt.Errorf("failed to delete DNS record: %w", err)
 	}
 
-	log.Printf("[DNS] Deleted DNS record %s (%s)", domain, recordType)
+	logger.Infof(ctx, "[DNS] Deleted DNS record %s (%s)", domain, recordType)
 	
 	// Wait for change to propagate
 	if err := d.waitForChange(ctx, *result.ChangeInfo.Id); err != nil {
-		log.Printf("[DNS] Warning: failed to wait for change propagation: %v", err)
+		logger.Warnf(ctx, "[DNS] Warning: failed to wait for change propagation: %v", err)
 	}
 	
 	return nil
@@ -426,12 +427,12 @@ func (d *DNSService) AssociateVPC(ctx context.Context, vpcID string, vpcRegion s
 	if err != nil {
 		// Ignore if already associated
 		if strings.Contains(err.Error(), "already associated") {
-			log.Printf("[DNS] VPC %s already associated with hosted zone", vpcID)
+			logger.Infof(ctx, "[DNS] VPC %s already associated with hosted zone", vpcID)
 			return nil
 		}
 		return fmt.Errorf("failed to associate VPC: %w", err)
 	}
 
-	log.Printf("[DNS] Associated VPC %s with hosted zone %s", vpcID, d.hostedZoneID)
+	logger.Infof(ctx, "[DNS] Associated VPC %s with hosted zone %s", vpcID, d.hostedZoneID)
 	return nil
 }
\ No newline at end of file
@@ -0,0 +1,77 @@
+package aws
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/madhouselabs/goman/pkg/provider"
+)
+
+// wrapAWSError annotates an AWS SDK error with its service, operation, error
+// code, request ID and classification. Nil errors are returned unchanged.
+func wrapAWSError(service, operation string, err error) error {
+	if err == nil {
+		return nil
+	}
+	if _, ok := provider.AsOperationError(err); ok {
+		return err
+	}
+
+	opErr := &provider.OperationError{
+		Service:   service,
+		Operation: operation,
+		Code:      "Unknown",
+		Class:     provider.ErrorClassUnknown,
+		Err:       err,
+	}
+
+	// smithy.APIError carries the error code
+	var apiErr interface{ ErrorCode() string }
+	if errors.As(err, &apiErr) {
+		opErr.Code = apiErr.ErrorCode()
+	}
+
+	// awshttp.ResponseError carries the request ID
+	var respErr interface{ ServiceRequestID() string }
+	if errors.As(err, &respErr) {
+		opErr.RequestID = respErr.ServiceRequestID()
+	}
+
+	opErr.Class = classifyAWSErrorCode(opErr.Code, err)
+	return opErr
+}
+
+// classifyAWSErrorCode maps AWS error codes to provider error classes
+func classifyAWSErrorCode(code string, err error) provider.ErrorClass {
+	switch {
+	case strings.Contains(code, "Throttl"), code == "RequestLimitExceeded", code == "TooManyRequestsException":
+		return provider.ErrorClassThrottling
+	case strings.HasSuffix(code, "NotFound"), strings.Contains(code, "NotFoundException"),
+		strings.HasPrefix(code, "NoSuch"), code == "InvalidInstanceId", code == "InvalidDocument":
+		return provider.ErrorClassNotFound
+	case code == "UnauthorizedOperation", code == "AccessDenied", code == "AccessDeniedException",
+		strings.HasPrefix(code, "Unauthorized"), code == "AuthFailure":
+		return provider.ErrorClassPermission
+	case strings.Contains(code, "LimitExceeded"), strings.Contains(code, "Quota"):
+		return provider.ErrorClassQuota
+	case code == "InsufficientInstanceCapacity", code == "InsufficientCapacity", code == "Unsupported":
+		return provider.ErrorClassCapacity
+	case strings.HasPrefix(code, "Invalid"), strings.Contains(code, "Validation"), strings.HasPrefix(code, "Missing"):
+		return provider.ErrorClassValidation
+	case code == "InternalError", code == "InternalFailure", code == "ServiceUnavailable", code == "RequestTimeout":
+		return provider.ErrorClassTransient
+	}
+
+	// Server errors without a known code are the service's problem
+	var statusErr interface{ HTTPStatusCode() int }
+	if errors.As(err, &statusErr) && statusErr.HTTPStatusCode() >= 500 {
+		return provider.ErrorClassTransient
+	}
+
+	// Fall back to the message for errors without an API code (network errors, timeouts)
+	msg := strings.ToLower(err.Error())
+	if strings.Contains(msg, "timeout") || strings.Contains(msg, "connection reset") || strings.Contains(msg, "eof") {
+		return provider.ErrorClassTransient
+	}
+	return provider.ErrorClassUnknown
+}
@@ -0,0 +1,262 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/madhouselabs/goman/pkg/logger"
+	"github.com/madhouselabs/goman/pkg/provider"
+)
+
+// clusterIngressRules are the rules goman sets up in a cluster's security
+// group: the K3s ports, open to the nodes of the group
+func clusterIngressRules(groupID string) []types.IpPermission {
+	return []types.IpPermission{
+		// No SSH access needed - using Systems Manager Session Manager
+		// K3s API server - allow from all nodes
+		{
+			IpProtocol: aws.String("tcp"),
+			FromPort:   aws.Int32(6443),
+			ToPort:     aws.Int32(6443),
+			UserIdGroupPairs: []types.UserIdGroupPair{
+				{
+					GroupId:     aws.String(groupID),
+					Description: aws.String("K3s API server - all nodes"),
+				},
+			},
+		},
+		// etcd client/server communication - CRITICAL for HA clusters
+		{
+			IpProtocol: aws.String("tcp"),
+			FromPort:   aws.Int32(2379),
+			ToPort:     aws.Int32(2380),
+			UserIdGroupPairs: []types.UserIdGroupPair{
+				{
+					GroupId:     aws.String(groupID),
+					Description: aws.String("etcd client and peer - required for HA"),
+				},
+			},
+		},
+		// Kubelet metrics
+		{
+			IpProtocol: aws.String("tcp"),
+			FromPort:   aws.Int32(10250),
+			ToPort:     aws.Int32(10250),
+			UserIdGroupPairs: []types.UserIdGroupPair{
+				{
+					GroupId:     aws.String(groupID),
+					Description: aws.String("Kubelet metrics"),
+				},
+			},
+		},
+		// Flannel VXLAN
+		{
+			IpProtocol: aws.String("udp"),
+			FromPort:   aws.Int32(8472),
+			ToPort:     aws.Int32(8472),
+			UserIdGroupPairs: []types.UserIdGroupPair{
+				{
+					GroupId:     aws.String(groupID),
+					Description: aws.String("Flannel VXLAN"),
+				},
+			},
+		},
+		// Flannel Wireguard with IPv4 (optional)
+		{
+			IpProtocol: aws.String("udp"),
+			FromPort:   aws.Int32(51820),
+			ToPort:     aws.Int32(51820),
+			UserIdGroupPairs: []types.UserIdGroupPair{
+				{
+					GroupId:     aws.String(groupID),
+					Description: aws.String("Flannel Wireguard IPv4"),
+				},
+			},
+		},
+		// Flannel Wireguard with IPv6 (optional)
+		{
+			IpProtocol: aws.String("udp"),
+			FromPort:   aws.Int32(51821),
+			ToPort:     aws.Int32(51821),
+			UserIdGroupPairs: []types.UserIdGroupPair{
+				{
+					GroupId:     aws.String(groupID),
+					Description: aws.String("Flannel Wireguard IPv6"),
+				},
+			},
+		},
+		// Embedded distributed registry - Spegel (optional)
+		{
+			IpProtocol: aws.String("tcp"),
+			FromPort:   aws.Int32(5001),
+			ToPort:     aws.Int32(5001),
+			UserIdGroupPairs: []types.UserIdGroupPair{
+				{
+					GroupId:     aws.String(groupID),
+					Description: aws.String("Spegel distributed registry"),
+				},
+			},
+		},
+	}
+}
+
+// CheckClusterFirewall compares the security groups of a cluster with the
+// rules goman sets up: rules of clusterIngressRules that are gone are
+// missing, and any rule admitting all addresses is open. Rules of cluster
+// links and other rules added to the groups are left alone.
+func (s *ComputeService) CheckClusterFirewall(ctx context.Context, region, clusterName string) ([]provider.FirewallDrift, error) {
+	groups, err := s.clusterSecurityGroups(ctx, region, clusterName)
+	if err != nil {
+		return nil, err
+	}
+
+	var drift []provider.FirewallDrift
+	for _, group := range groups {
+		missing, open := firewallDrift(group)
+		if len(missing) == 0 && len(open) == 0 {
+			continue
+		}
+		d := provider.FirewallDrift{GroupID: aws.ToString(group.GroupId), GroupName: aws.ToString(group.GroupName)}
+		for _, perm := range missing {
+			d.Missing = append(d.Missing, describeRule(perm, "the cluster"))
+		}
+		for _, perm := range open {
+			d.Open = append(d.Open, describeRule(perm, openSources(perm)))
+		}
+		drift = append(drift, d)
+	}
+	return drift, nil
+}
+
+// RestoreClusterFirewall adds the missing rules of clusterIngressRules back
+// to the security groups of a cluster and revokes the rules admitting all
+// addresses
+func (s *ComputeService) RestoreClusterFirewall(ctx context.Context, region, clusterName string) error {
+	ec2Client := s.getEC2Client(region)
+	groups, err := s.clusterSecurityGroups(ctx, region, clusterName)
+	if err != nil {
+		return err
+	}
+
+	for _, group := range groups {
+		missing, open := firewallDrift(group)
+		if len(missing) > 0 {
+			_, err := ec2Client.AuthorizeSecurityGroupIngress(ctx, &ec2.AuthorizeSecurityGroupIngressInput{
+				GroupId:       group.GroupId,
+				IpPermissions: missing,
+			})
+			if err != nil && !hasErrorCode(err, "InvalidPermission.Duplicate") {
+				return fmt.Errorf("failed to restore rules of %s: %w", aws.ToString(group.GroupId), wrapAWSError("ec2", "AuthorizeSecurityGroupIngress", err))
+			}
+			logger.Infof(ctx, "Restored %d rules of security group %s", len(missing), aws.ToString(group.GroupId))
+		}
+		if len(open) > 0 {
+			_, err := ec2Client.RevokeSecurityGroupIngress(ctx, &ec2.RevokeSecurityGroupIngressInput{
+				GroupId:       group.GroupId,
+				IpPermissions: open,
+			})
+			if err != nil && !hasErrorCode(err, "InvalidPermission.NotFound") {
+				return fmt.Errorf("failed to revoke open rules of %s: %w", aws.ToString(group.GroupId), wrapAWSError("ec2", "RevokeSecurityGroupIngress", err))
+			}
+			logger.Infof(ctx, "Revoked %d open rules of security group %s", len(open), aws.ToString(group.GroupId))
+		}
+	}
+	return nil
+}
+
+// clusterSecurityGroups returns the security groups goman created for a
+// cluster's nodes, found by their Cluster tag
+func (s *ComputeService) clusterSecurityGroups(ctx context.Context, region, clusterName string) ([]types.SecurityGroup, error) {
+	output, err := s.getEC2Client(region).DescribeSecurityGroups(ctx, &ec2.DescribeSecurityGroupsInput{
+		Filters: []types.Filter{
+			{Name: aws.String("tag:Cluster"), Values: []string{clusterName}},
+			{Name: aws.String("tag:ManagedBy"), Values: []string{"goman"}},
+		},
+	})
+	if err != nil {
+		return nil, wrapAWSError("ec2", "DescribeSecurityGroups", err)
+	}
+	return output.SecurityGroups, nil
+}
+
+// firewallDrift returns the rules of clusterIngressRules a group lacks, and
+// its rules admitting all addresses, each narrowed to those addresses
+func firewallDrift(group types.SecurityGroup) (missing, open []types.IpPermission) {
+	groupID := aws.ToString(group.GroupId)
+	for _, want := range clusterIngressRules(groupID) {
+		found := false
+		for _, have := range group.IpPermissions {
+			if samePorts(want, have) && hasGroupSource(have, groupID) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			missing = append(missing, want)
+		}
+	}
+
+	for _, have := range group.IpPermissions {
+		perm := types.IpPermission{IpProtocol: have.IpProtocol, FromPort: have.FromPort, ToPort: have.ToPort}
+		for _, r := range have.IpRanges {
+			if aws.ToString(r.CidrIp) == "0.0.0.0/0" {
+				perm.IpRanges = append(perm.IpRanges, types.IpRange{CidrIp: r.CidrIp})
+			}
+		}
+		for _, r := range have.Ipv6Ranges {
+			if aws.ToString(r.CidrIpv6) == "::/0" {
+				perm.Ipv6Ranges = append(perm.Ipv6Ranges, types.Ipv6Range{CidrIpv6: r.CidrIpv6})
+			}
+		}
+		if len(perm.IpRanges) > 0 || len(perm.Ipv6Ranges) > 0 {
+			open = append(open, perm)
+		}
+	}
+	return missing, open
+}
+
+// samePorts reports whether two rules cover the same protocol and ports
+func samePorts(a, b types.IpPermission) bool {
+	return aws.ToString(a.IpProtocol) == aws.ToString(b.IpProtocol) &&
+		aws.ToInt32(a.FromPort) == aws.ToInt32(b.FromPort) &&
+		aws.ToInt32(a.ToPort) == aws.ToInt32(b.ToPort)
+}
+
+// hasGroupSource reports whether a rule admits the members of a group
+func hasGroupSource(perm types.IpPermission, groupID string) bool {
+	for _, pair := range perm.UserIdGroupPairs {
+		if aws.ToString(pair.GroupId) == groupID {
+			return true
+		}
+	}
+	return false
+}
+
+// openSources names the all-address sources of a rule
+func openSources(perm types.IpPermission) string {
+	var sources []string
+	for _, r := range perm.IpRanges {
+		sources = append(sources, aws.ToString(r.CidrIp))
+	}
+	for _, r := range perm.Ipv6Ranges {
+		sources = append(sources, aws.ToString(r.CidrIpv6))
+	}
+	return strings.Join(sources, ", ")
+}
+
+// describeRule renders a rule as "tcp 2379-2380 from <source>"
+func describeRule(perm types.IpPermission, source string) string {
+	protocol := aws.ToString(perm.IpProtocol)
+	if protocol == "-1" {
+		return "all traffic from " + source
+	}
+	from, to := aws.ToInt32(perm.FromPort), aws.ToInt32(perm.ToPort)
+	if from == to {
+		return fmt.Sprintf("%s %d from %s", protocol, from, source)
+	}
+	return fmt.Sprintf("%s %d-%d from %s", protocol, from, to, source)
+}
@@ -16,6 +16,7 @@ import (
 	"github.com/aws/aws-sdk-go-v2/service/lambda/types"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
 	s3types "github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/madhouselabs/goman/pkg/config"
 	"github.com/madhouselabs/goman/pkg/logger"
 )
 
@@ -76,7 +77,7 @@ func (s *FunctionService) DeployFunction(ctx context.Context, name string, packa
 	var codeLocation types.FunctionCode
 	if len(packageData) > 50*1024*1024 { // 50MB limit for direct upload
 		// Upload to S3
-		bucketName := fmt.Sprintf("goman-%s", s.accountID)
+		bucketName := stateBucketName(s.accountID)
 		keyName := fmt.Sprintf("lambda/%s.zip", name)
 
 		_, err = s.s3Client.PutObject(ctx, &s3.PutObjectInput{
@@ -124,7 +125,7 @@ func (s *FunctionService) DeployFunction(ctx context.Context, name string, packa
 		// Update existing function
 		if len(packageData) > 50*1024*1024 {
 			// Update from S3
-			bucketName := fmt.Sprintf("goman-%s", s.accountID)
+			bucketName := stateBucketName(s.accountID)
 			keyName := fmt.Sprintf("lambda/%s.zip", name)
 			_, err = s.lambdaClient.UpdateFunctionCode(ctx, &lambda.UpdateFunctionCodeInput{
 				FunctionName: aws.String(name),
@@ -147,10 +148,7 @@ func (s *FunctionService) DeployFunction(ctx context.Context, name string, packa
 			Timeout:      aws.Int32(900), // 15 minutes
 			MemorySize:   aws.Int32(512),
 			Environment: &types.Environment{
-				Variables: map[string]string{
-					"GOMAN_REGION":     s.region,
-					"GOMAN_ACCOUNT_ID": s.accountID,
-				},
+				Variables: s.functionEnvironment(),
 			},
 		})
 		if err != nil {
@@ -161,7 +159,7 @@ func (s *FunctionService) DeployFunction(ctx context.Context, name string, packa
 		// This handles cases where notifications were lost
 		err = s.setupS3Trigger(ctx, name)
 		if err != nil {
-			logger.Printf("Warning: Failed to ensure S3 trigger: %v", err)
+			logger.Warnf(ctx, "Failed to ensure S3 trigger: %v", err)
 			// Don't fail deployment if trigger setup fails - it can be retried
 		}
 	} else {
@@ -185,10 +183,7 @@ func (s *FunctionService) DeployFunction(ctx context.Context, name string, packa
 			MemorySize:   aws.Int32(512),
 			Description:  aws.String(fmt.Sprintf("Goman function: %s", name)),
 			Environment: &types.Environment{
-				Variables: map[string]string{
-					"GOMAN_REGION":     s.region,
-					"GOMAN_ACCOUNT_ID": s.accountID,
-				},
+				Variables: s.functionEnvironment(),
 			},
 			Tags: map[string]string{
 				"Application": "goman",
@@ -218,6 +213,24 @@ func (s *FunctionService) DeployFunction(ctx context.Context, name string, packa
 	return nil
 }
 
+// functionEnvironment returns the environment of the controller function.
+// Endpoint and partition overrides of the deploying CLI are passed on, so
+// the controller talks to the same endpoints, e.g. localstack, and so are
+// its log level and format.
+func (s *FunctionService) functionEnvironment() map[string]string {
+	env := config.AWSEndpointEnvironment()
+	for _, key := range []string{logger.LevelEnv, logger.FormatEnv} {
+		if value := os.Getenv(key); value != "" {
+			env[key] = value
+		}
+	}
+	env["GOMAN_REGION"] = s.region
+	env["GOMAN_ACCOUNT_ID"] = s.accountID
+	env["GOMAN_STATE_BUCKET"] = stateBucketName(s.accountID)
+	env["GOMAN_SECRET_BACKEND"] = config.GetSecretBackend()
+	return env
+}
+
 // InvokeFunction invokes a function with payload
 func (s *FunctionService) InvokeFunction(ctx context.Context, name string, payload []byte) ([]byte, error) {
 	result, err := s.lambdaClient.Invoke(ctx, &lambda.InvokeInput{
@@ -361,13 +374,13 @@ func (s *FunctionService) ensureIAMRole(ctx context.Context) (string, error) {
 	}
 
 	// Attach basic Lambda execution policy
-	basicPolicyArn := "arn:aws:iam::aws:policy/service-role/AWSLambdaBasicExecutionRole"
+	basicPolicyArn := fmt.Sprintf("arn:%s:iam::aws:policy/service-role/AWSLambdaBasicExecutionRole", partition(s.region))
 	_, err = s.iamClient.AttachRolePolicy(ctx, &iam.AttachRolePolicyInput{
 		RoleName:  aws.String(roleName),
 		PolicyArn: aws.String(basicPolicyArn),
 	})
 	if err != nil && !strings.Contains(err.Error(), "AttachedPolicies") {
-		logger.Printf("Warning: Failed to attach basic Lambda execution policy: %v", err)
+		logger.Warnf(ctx, "Failed to attach basic Lambda execution policy: %v", err)
 	}
 
 	// Create and attach custom policy with least privilege
@@ -385,8 +398,8 @@ func (s *FunctionService) ensureIAMRole(ctx context.Context) (string, error) {
 					"s3:ListBucket",
 				},
 				"Resource": []string{
-					fmt.Sprintf("arn:aws:s3:::goman-%s/*", s.accountID),
-					fmt.Sprintf("arn:aws:s3:::goman-%s", s.accountID),
+					fmt.Sprintf("arn:%s:s3:::%s/*", partition(s.region), stateBucketName(s.accountID)),
+					fmt.Sprintf("arn:%s:s3:::%s", partition(s.region), stateBucketName(s.accountID)),
 				},
 			},
 			// DynamoDB permissions for distributed locking
@@ -399,7 +412,7 @@ func (s *FunctionService) ensureIAMRole(ctx context.Context) (string, error) {
 					"dynamodb:DeleteItem",
 					"dynamodb:UpdateItem",
 				},
-				"Resource": fmt.Sprintf("arn:aws:dynamodb:%s:%s:table/goman-resource-locks", s.region, s.accountID),
+				"Resource": fmt.Sprintf("arn:%s:dynamodb:%s:%s:table/goman-resource-locks", partition(s.region), s.region, s.accountID),
 			},
 			// EC2 permissions for instance management - split for least privilege
 			{
@@ -409,9 +422,33 @@ func (s *FunctionService) ensureIAMRole(ctx context.Context) (string, error) {
 					"ec2:DescribeSecurityGroups",
 					"ec2:DescribeVpcs",
 					"ec2:DescribeSubnets",
+					"ec2:DescribeVolumes",
+					"ec2:DescribeRouteTables",
+					"ec2:DescribeVpcPeeringConnections",
+					"ec2:DescribeImages", // Root device of the AMI, for sizing root volumes
+					"ec2:DescribeNetworkInterfaces",
 				},
 				"Resource": "*", // Read operations require wildcard
 			},
+			// Cluster links: rules between security groups, and VPC peering
+			// with routes for clusters in different VPCs
+			{
+				"Effect": "Allow",
+				"Action": []string{
+					"ec2:RevokeSecurityGroupIngress",
+					"ec2:CreateVpcPeeringConnection",
+					"ec2:AcceptVpcPeeringConnection",
+					"ec2:DeleteVpcPeeringConnection",
+					"ec2:CreateRoute",
+					"ec2:DeleteRoute",
+				},
+				"Resource": []string{
+					fmt.Sprintf("arn:%s:ec2:*:%s:security-group/*", partition(s.region), s.accountID),
+					fmt.Sprintf("arn:%s:ec2:*:%s:vpc/*", partition(s.region), s.accountID),
+					fmt.Sprintf("arn:%s:ec2:*:%s:vpc-peering-connection/*", partition(s.region), s.accountID),
+					fmt.Sprintf("arn:%s:ec2:*:%s:route-table/*", partition(s.region), s.accountID),
+				},
+			},
 			{
 				"Effect": "Allow",
 				"Action": []string{
@@ -422,17 +459,21 @@ func (s *FunctionService) ensureIAMRole(ctx context.Context) (string, error) {
 					"ec2:CreateSecurityGroup",
 					"ec2:AuthorizeSecurityGroupIngress",
 					"ec2:DeleteSecurityGroup",
+					"ec2:DeleteNetworkInterface", // Interfaces left behind by deleted clusters
+					"ec2:DeleteVolume",           // Volumes left behind by deleted clusters
 					"ec2:CreateTags",
+					"ec2:DeleteTags",
 					"ec2:ModifyInstanceAttribute",
+					"ec2:AssignPrivateIpAddresses", // Virtual IPs of HA clusters
 				},
 				"Resource": []string{
-					fmt.Sprintf("arn:aws:ec2:*:%s:instance/*", s.accountID),
-					fmt.Sprintf("arn:aws:ec2:*:%s:security-group/*", s.accountID),
-					fmt.Sprintf("arn:aws:ec2:*:%s:vpc/*", s.accountID), // Required for CreateSecurityGroup
-					fmt.Sprintf("arn:aws:ec2:*:%s:subnet/*", s.accountID),
-					fmt.Sprintf("arn:aws:ec2:*:%s:volume/*", s.accountID),
-					fmt.Sprintf("arn:aws:ec2:*:%s:network-interface/*", s.accountID),
-					"arn:aws:ec2:*::image/*", // AMIs can be public (no account ID needed)
+					fmt.Sprintf("arn:%s:ec2:*:%s:instance/*", partition(s.region), s.accountID),
+					fmt.Sprintf("arn:%s:ec2:*:%s:security-group/*", partition(s.region), s.accountID),
+					fmt.Sprintf("arn:%s:ec2:*:%s:vpc/*", partition(s.region), s.accountID), // Required for CreateSecurityGroup
+					fmt.Sprintf("arn:%s:ec2:*:%s:subnet/*", partition(s.region), s.accountID),
+					fmt.Sprintf("arn:%s:ec2:*:%s:volume/*", partition(s.region), s.accountID),
+					fmt.Sprintf("arn:%s:ec2:*:%s:network-interface/*", partition(s.region), s.accountID),
+					fmt.Sprintf("arn:%s:ec2:*::image/*", partition(s.region)), // AMIs can be public (no account ID needed)
 				},
 			},
 			// IAM permissions for using instance profiles (not creating them)
@@ -444,8 +485,56 @@ func (s *FunctionService) ensureIAMRole(ctx context.Context) (string, error) {
 					"iam:PassRole",
 				},
 				"Resource": []string{
-					fmt.Sprintf("arn:aws:iam::%s:role/goman-ssm-instance-role", s.accountID),
-					fmt.Sprintf("arn:aws:iam::%s:instance-profile/goman-ssm-instance-profile", s.accountID),
+					fmt.Sprintf("arn:%s:iam::%s:role/goman-ssm-instance-role", partition(s.region), s.accountID),
+					fmt.Sprintf("arn:%s:iam::%s:instance-profile/goman-ssm-instance-profile", partition(s.region), s.accountID),
+				},
+			},
+			// Cluster tokens and kubeconfigs in the Secrets Manager and Parameter Store backends
+			{
+				"Effect": "Allow",
+				"Action": []string{
+					"secretsmanager:CreateSecret",
+					"secretsmanager:GetSecretValue",
+					"secretsmanager:PutSecretValue",
+					"secretsmanager:DeleteSecret",
+					"secretsmanager:TagResource",
+				},
+				"Resource": fmt.Sprintf("arn:%s:secretsmanager:%s:%s:secret:%s/*", partition(s.region), s.region, s.accountID, secretNamePrefix),
+			},
+			{
+				"Effect": "Allow",
+				"Action": []string{
+					"ssm:GetParameter",
+					"ssm:PutParameter",
+					"ssm:DeleteParameter",
+				},
+				"Resource": fmt.Sprintf("arn:%s:ssm:%s:%s:parameter/%s/*", partition(s.region), s.region, s.accountID, secretNamePrefix),
+			},
+			// IAM permissions for managing the per-cluster node roles and instance profiles
+			{
+				"Effect": "Allow",
+				"Action": []string{
+					"iam:GetRole",
+					"iam:CreateRole",
+					"iam:DeleteRole",
+					"iam:TagRole",
+					"iam:ListRoles",
+					"iam:ListRoleTags",
+					"iam:PutRolePolicy",
+					"iam:DeleteRolePolicy",
+					"iam:AttachRolePolicy",
+					"iam:DetachRolePolicy",
+					"iam:PassRole",
+					"iam:GetInstanceProfile",
+					"iam:CreateInstanceProfile",
+					"iam:DeleteInstanceProfile",
+					"iam:TagInstanceProfile",
+					"iam:AddRoleToInstanceProfile",
+					"iam:RemoveRoleFromInstanceProfile",
+				},
+				"Resource": []string{
+					fmt.Sprintf("arn:%s:iam::%s:role/goman/*", partition(s.region), s.accountID),
+					fmt.Sprintf("arn:%s:iam::%s:instance-profile/goman/*", partition(s.region), s.accountID),
 				},
 			},
 			// SSM permissions for discovering managed instances
@@ -463,12 +552,25 @@ func (s *FunctionService) ensureIAMRole(ctx context.Context) (string, error) {
 					"ssm:SendCommand",
 					"ssm:GetCommandInvocation",
 					"ssm:ListCommandInvocations",
+					"ssm:CancelCommand",
 				},
 				"Resource": []string{
-					fmt.Sprintf("arn:aws:ssm:*:%s:*", s.accountID),
-					fmt.Sprintf("arn:aws:ec2:*:%s:instance/*", s.accountID),
-					"arn:aws:ssm:*::document/AWS-RunShellScript",
+					fmt.Sprintf("arn:%s:ssm:*:%s:*", partition(s.region), s.accountID),
+					fmt.Sprintf("arn:%s:ec2:*:%s:instance/*", partition(s.region), s.accountID),
+					fmt.Sprintf("arn:%s:ssm:*::document/AWS-RunShellScript", partition(s.region)),
+				},
+			},
+			// SSM permissions for managing goman command documents in other regions
+			{
+				"Effect": "Allow",
+				"Action": []string{
+					"ssm:DescribeDocument",
+					"ssm:CreateDocument",
+					"ssm:UpdateDocument",
+					"ssm:UpdateDocumentDefaultVersion",
+					"ssm:AddTagsToResource",
 				},
+				"Resource": fmt.Sprintf("arn:%s:ssm:*:%s:document/goman-*", partition(s.region), s.accountID),
 			},
 			{
 				"Effect": "Allow",
@@ -476,9 +578,105 @@ func (s *FunctionService) ensureIAMRole(ctx context.Context) (string, error) {
 					"ssm:GetParameter",
 				},
 				"Resource": []string{
-					"arn:aws:ssm:*::parameter/aws/service/canonical/ubuntu/*",
-					"arn:aws:ssm:*::parameter/aws/service/ami-amazon-linux-latest/*",
+					fmt.Sprintf("arn:%s:ssm:*::parameter/aws/service/canonical/ubuntu/*", partition(s.region)),
+					fmt.Sprintf("arn:%s:ssm:*::parameter/aws/service/ami-amazon-linux-latest/*", partition(s.region)),
+				},
+			},
+			// AWS Health events, to tell service outages from goman failures.
+			// The API needs a Business or Enterprise support plan.
+			{
+				"Effect": "Allow",
+				"Action": []string{
+					"health:DescribeEvents",
+				},
+				"Resource": "*", // DescribeEvents requires wildcard
+			},
+			// Private subnets, their NAT gateway and S3 endpoint, shared by
+			// clusters without public IPs
+			{
+				"Effect": "Allow",
+				"Action": []string{
+					"ec2:DescribeNatGateways",
+					"ec2:DescribeVpcEndpoints",
+					"ec2:CreateSubnet",
+					"ec2:CreateRouteTable",
+					"ec2:AssociateRouteTable",
+					"ec2:AllocateAddress",
+					"ec2:CreateNatGateway",
+					"ec2:CreateVpcEndpoint",
+					"ec2:ModifyVpcEndpoint",
+				},
+				"Resource": "*", // New resources have no ARN until created
+			},
+			// Dedicated VPCs of clusters, created with their first node
+			{
+				"Effect": "Allow",
+				"Action": []string{
+					"ec2:DescribeInternetGateways",
+					"ec2:DescribeAvailabilityZones",
+					"ec2:DescribeTags",
+					"ec2:CreateVpc",
+					"ec2:ModifyVpcAttribute",
+					"ec2:CreateInternetGateway",
+					"ec2:AttachInternetGateway",
+					"ec2:ModifySubnetAttribute",
+				},
+				"Resource": "*", // New resources have no ARN until created
+			},
+			// and deleted with the cluster; only resources goman created
+			{
+				"Effect": "Allow",
+				"Action": []string{
+					"ec2:DeleteNatGateway",
+					"ec2:ReleaseAddress",
+					"ec2:DeleteVpcEndpoints",
+					"ec2:DeleteSubnet",
+					"ec2:DeleteRouteTable",
+					"ec2:DetachInternetGateway",
+					"ec2:DeleteInternetGateway",
+					"ec2:DeleteVpc",
+				},
+				"Resource": "*",
+				"Condition": map[string]interface{}{
+					"StringEquals": map[string]string{"aws:ResourceTag/ManagedBy": "goman"},
+				},
+			},
+			// Network load balancers in front of the masters of HA clusters
+			{
+				"Effect": "Allow",
+				"Action": []string{
+					"elasticloadbalancing:DescribeLoadBalancers",
+					"elasticloadbalancing:DescribeListeners",
+					"elasticloadbalancing:DescribeTags",
+					"elasticloadbalancing:DescribeTargetHealth",
+					"elasticloadbalancing:CreateLoadBalancer",
+					"elasticloadbalancing:CreateTargetGroup",
+					"elasticloadbalancing:CreateListener",
+					"elasticloadbalancing:AddTags",
+				},
+				"Resource": "*", // New resources have no ARN until created
+			},
+			{
+				"Effect": "Allow",
+				"Action": []string{
+					"elasticloadbalancing:ModifyTargetGroupAttributes",
+					"elasticloadbalancing:RegisterTargets",
+					"elasticloadbalancing:DeregisterTargets",
+					"elasticloadbalancing:DeleteLoadBalancer",
+					"elasticloadbalancing:DeleteTargetGroup",
+				},
+				"Resource": "*",
+				"Condition": map[string]interface{}{
+					"StringEquals": map[string]string{"aws:ResourceTag/ManagedBy": "goman"},
+				},
+			},
+			// CloudTrail, to attribute changes made outside goman
+			{
+				"Effect": "Allow",
+				"Action": []string{
+					"cloudtrail:LookupEvents",
 				},
+				"Resource": "*", // LookupEvents requires wildcard
 			},
 			// SNS permissions for notification service
 			{
@@ -492,7 +690,7 @@ func (s *FunctionService) ensureIAMRole(ctx context.Context) (string, error) {
 					"sns:GetTopicAttributes",
 				},
 				"Resource": []string{
-					fmt.Sprintf("arn:aws:sns:*:%s:goman-*", s.accountID),
+					fmt.Sprintf("arn:%s:sns:*:%s:goman-*", partition(s.region), s.accountID),
 				},
 			},
 			// SQS permissions for notification service subscriptions
@@ -507,7 +705,7 @@ func (s *FunctionService) ensureIAMRole(ctx context.Context) (string, error) {
 					"sqs:SendMessage",
 				},
 				"Resource": []string{
-					fmt.Sprintf("arn:aws:sqs:*:%s:goman-*", s.accountID),
+					fmt.Sprintf("arn:%s:sqs:*:%s:goman-*", partition(s.region), s.accountID),
 				},
 			},
 		},
@@ -520,7 +718,7 @@ func (s *FunctionService) ensureIAMRole(ctx context.Context) (string, error) {
 
 	// Since we're pre-v1.0, always recreate the policy for simplicity
 	// This ensures we always have the latest permissions without complex version management
-	policyArn := fmt.Sprintf("arn:aws:iam::%s:policy/%s", s.accountID, policyName)
+	policyArn := fmt.Sprintf("arn:%s:iam::%s:policy/%s", partition(s.region), s.accountID, policyName)
 	
 	// First, detach the policy from the role if it exists
 	_, _ = s.iamClient.DetachRolePolicy(ctx, &iam.DetachRolePolicyInput{
@@ -559,7 +757,7 @@ func (s *FunctionService) ensureIAMRole(ctx context.Context) (string, error) {
 		return "", fmt.Errorf("failed to create policy %s: %w", policyName, err)
 	}
 	
-	logger.Printf("Created fresh IAM policy %s with latest permissions", policyName)
+	logger.Infof(ctx, "Created fresh IAM policy %s with latest permissions", policyName)
 	
 	// Attach the fresh policy
 	_, err = s.iamClient.AttachRolePolicy(ctx, &iam.AttachRolePolicyInput{
@@ -578,7 +776,7 @@ func (s *FunctionService) ensureIAMRole(ctx context.Context) (string, error) {
 
 // setupS3Trigger sets up S3 event notification to trigger Lambda
 func (s *FunctionService) setupS3Trigger(ctx context.Context, functionName string) error {
-	bucketName := fmt.Sprintf("goman-%s", s.accountID)
+	bucketName := stateBucketName(s.accountID)
 
 	// First check if notifications are already configured
 	existingConfig, err := s.s3Client.GetBucketNotificationConfiguration(ctx, &s3.GetBucketNotificationConfigurationInput{
@@ -588,7 +786,7 @@ func (s *FunctionService) setupS3Trigger(ctx context.Context, functionName strin
 		// Check if our notification already exists (could be either ID)
 		for _, config := range existingConfig.LambdaFunctionConfigurations {
 			if config.Id != nil && (*config.Id == "goman-state-changes" || *config.Id == "goman-cluster-changes") {
-				logger.Printf("S3 notifications already configured for function %s", functionName)
+				logger.Infof(ctx, "S3 notifications already configured for function %s", functionName)
 				return nil
 			}
 		}
@@ -600,7 +798,7 @@ func (s *FunctionService) setupS3Trigger(ctx context.Context, functionName strin
 		StatementId:  aws.String("s3-invoke-permission"),
 		Action:       aws.String("lambda:InvokeFunction"),
 		Principal:    aws.String("s3.amazonaws.com"),
-		SourceArn:    aws.String(fmt.Sprintf("arn:aws:s3:::%s", bucketName)),
+		SourceArn:    aws.String(fmt.Sprintf("arn:%s:s3:::%s", partition(s.region), bucketName)),
 	})
 
 	if err != nil {
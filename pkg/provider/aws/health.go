@@ -0,0 +1,79 @@
+package aws
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	gomanconfig "github.com/madhouselabs/goman/pkg/config"
+	"github.com/madhouselabs/goman/pkg/provider"
+)
+
+// healthEndpoints are the global AWS Health API endpoints per partition
+// and the region requests to them are signed for
+var healthEndpoints = map[string][2]string{
+	"aws":        {"https://health.us-east-1.amazonaws.com", "us-east-1"},
+	"aws-cn":     {"https://health.cn-northwest-1.amazonaws.com.cn", "cn-northwest-1"},
+	"aws-us-gov": {"https://health.us-gov-west-1.amazonaws.com", "us-gov-west-1"},
+}
+
+// healthEvent is an event of the DescribeEvents response
+type healthEvent struct {
+	Service       string  `json:"service"`
+	EventTypeCode string  `json:"eventTypeCode"`
+	Region        string  `json:"region"`
+	StartTime     float64 `json:"startTime"` // Epoch seconds
+}
+
+// ServiceHealth returns the open issues the AWS Health API reports for the
+// given services in the provider's region. The SDK module of the Health API
+// is not a goman dependency, so the JSON API is called directly. Accounts
+// without a Business or Enterprise support plan get a
+// SubscriptionRequiredException.
+func (p *AWSProvider) ServiceHealth(ctx context.Context, services []string) ([]provider.ServiceHealthEvent, error) {
+	endpoint, ok := healthEndpoints[partition(p.region)]
+	if !ok || gomanconfig.GetAWSEndpointURL() != "" {
+		return nil, fmt.Errorf("the AWS Health API is not available in %s", p.region)
+	}
+
+	var names []string
+	for _, s := range services {
+		names = append(names, strings.ToUpper(s))
+	}
+	data, err := p.callJSONAPI(ctx, jsonAPICall{
+		Endpoint:      endpoint[0],
+		SigningName:   "health",
+		SigningRegion: endpoint[1],
+		Target:        "AWSHealth_20160804.DescribeEvents",
+		Operation:     "DescribeEvents",
+	}, map[string]interface{}{
+		"filter": map[string]interface{}{
+			"services":            names,
+			"regions":             []string{p.region},
+			"eventStatusCodes":    []string{"open"},
+			"eventTypeCategories": []string{"issue"},
+		},
+		"maxResults": 10,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var out struct {
+		Events []healthEvent `json:"events"`
+	}
+	if err := json.Unmarshal(data, &out); err != nil {
+		return nil, fmt.Errorf("failed to parse AWS Health events: %w", err)
+	}
+	events := make([]provider.ServiceHealthEvent, 0, len(out.Events))
+	for _, e := range out.Events {
+		events = append(events, provider.ServiceHealthEvent{
+			Service:   e.Service,
+			Region:    e.Region,
+			EventType: e.EventTypeCode,
+			StartTime: epochTime(e.StartTime),
+		})
+	}
+	return events, nil
+}
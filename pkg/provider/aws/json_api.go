@@ -0,0 +1,100 @@
+package aws
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"strings"
+	"time"
+
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
+	"github.com/madhouselabs/goman/pkg/provider"
+)
+
+// jsonAPICall is a request to an AWS JSON 1.1 API, for services whose SDK
+// module is not a goman dependency
+type jsonAPICall struct {
+	Endpoint      string // e.g. https://health.us-east-1.amazonaws.com
+	SigningName   string // e.g. "health"
+	SigningRegion string
+	Target        string // X-Amz-Target, e.g. "AWSHealth_20160804.DescribeEvents"
+	Operation     string // For errors, e.g. "DescribeEvents"
+}
+
+// callJSONAPI signs and sends a JSON API request with the provider's
+// credentials and returns the response body. Error responses are returned
+// as classified OperationErrors.
+func (p *AWSProvider) callJSONAPI(ctx context.Context, call jsonAPICall, payload interface{}) ([]byte, error) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, call.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-amz-json-1.1")
+	req.Header.Set("X-Amz-Target", call.Target)
+
+	creds, err := p.cfg.Credentials.Retrieve(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get AWS credentials: %w", err)
+	}
+	hash := sha256.Sum256(body)
+	if err := v4.NewSigner().SignHTTP(ctx, creds, req, hex.EncodeToString(hash[:]), call.SigningName, call.SigningRegion, time.Now()); err != nil {
+		return nil, fmt.Errorf("failed to sign %s request: %w", call.Operation, err)
+	}
+
+	var client interface {
+		Do(*http.Request) (*http.Response, error)
+	} = http.DefaultClient
+	if p.cfg.HTTPClient != nil {
+		client = p.cfg.HTTPClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, &provider.OperationError{Service: call.SigningName, Operation: call.Operation, Code: "RequestError", Class: provider.ErrorClassTransient, Err: err}
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		var apiErr struct {
+			Type    string `json:"__type"`
+			Message string `json:"message"`
+		}
+		_ = json.Unmarshal(data, &apiErr)
+		code := apiErr.Type
+		if i := strings.LastIndex(code, "#"); i >= 0 {
+			code = code[i+1:]
+		}
+		if code == "" {
+			code = http.StatusText(resp.StatusCode)
+		}
+		return nil, &provider.OperationError{
+			Service:   call.SigningName,
+			Operation: call.Operation,
+			Code:      code,
+			RequestID: resp.Header.Get("X-Amzn-Requestid"),
+			Class:     classifyAWSErrorCode(code, fmt.Errorf("%s", apiErr.Message)),
+			Err:       fmt.Errorf("%s", apiErr.Message),
+		}
+	}
+	return data, nil
+}
+
+// epochTime converts the fractional epoch seconds of JSON API timestamps
+func epochTime(seconds float64) time.Time {
+	sec, frac := math.Modf(seconds)
+	return time.Unix(int64(sec), int64(frac*1e9)).UTC()
+}
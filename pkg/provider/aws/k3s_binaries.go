@@ -0,0 +1,28 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/madhouselabs/goman/pkg/logger"
+	"github.com/madhouselabs/goman/pkg/provider"
+)
+
+// ensureK3sBinaries stages the amd64 and arm64 K3s binaries of a version in
+// the state bucket, where the bootstrap and upgrade scripts of both x86_64
+// and Graviton nodes fetch them. Versions found staged are remembered, so
+// later launches skip the check.
+func (s *ComputeService) ensureK3sBinaries(ctx context.Context, version string) error {
+	s.binariesMu.Lock()
+	defer s.binariesMu.Unlock()
+	if s.binariesReady[version] {
+		return nil
+	}
+
+	logger.Debugf(ctx, "[K3S] Checking K3s %s binaries in the state bucket", version)
+	if err := provider.StageK3sBinaries(ctx, NewStorageService(s.s3Client, s.accountID), version); err != nil {
+		return fmt.Errorf("failed to stage K3s %s binaries: %w", version, err)
+	}
+	s.binariesReady[version] = true
+	return nil
+}
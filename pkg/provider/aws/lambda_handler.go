@@ -3,8 +3,8 @@ package aws
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"log"
 	"os"
 	"strings"
 	"time"
@@ -17,7 +17,9 @@ import (
 	"github.com/aws/aws-sdk-go-v2/service/sqs"
 	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
 	"github.com/madhouselabs/goman/pkg/controller"
+	"github.com/madhouselabs/goman/pkg/logger"
 	"github.com/madhouselabs/goman/pkg/models"
+	"github.com/madhouselabs/goman/pkg/provider"
 	"github.com/madhouselabs/goman/pkg/storage"
 )
 
@@ -27,6 +29,14 @@ type LambdaEvent struct {
 	Action      string `json:"action"`
 }
 
+// ScheduleAction is the action of the periodic event sent by the schedule
+// rule to apply cluster schedules
+const ScheduleAction = "schedule"
+
+// ReconcileStaleAction is the action of the periodic event sent by the stale
+// reconcile rule to reconcile clusters stuck without a reconcile
+const ReconcileStaleAction = "reconcile-stale"
+
 // LambdaHandler wraps the reconciler for AWS Lambda
 type LambdaHandler struct {
 	reconciler *controller.Reconciler
@@ -38,22 +48,22 @@ type LambdaHandler struct {
 
 // NewLambdaHandler creates a new Lambda handler
 func NewLambdaHandler() (*LambdaHandler, error) {
-	log.Println("Creating Lambda handler...")
+	logger.Infof(context.Background(), "Creating Lambda handler...")
 
 	// Create AWS provider directly (we're in AWS Lambda environment)
-	log.Println("Creating AWS provider...")
+	logger.Infof(context.Background(), "Creating AWS provider...")
 	prov, err := NewProvider("", "") // Will use defaults from environment
 	if err != nil {
-		log.Printf("Failed to create provider: %v", err)
+		logger.Errorf(context.Background(), "Failed to create provider: %v", err)
 		return nil, fmt.Errorf("failed to create provider: %w", err)
 	}
-	log.Println("Provider created successfully")
+	logger.Infof(context.Background(), "Provider created successfully")
 
 	// Initialize provider services
 	ctx := context.Background()
 
 	if err := prov.GetLockService().Initialize(ctx); err != nil {
-		log.Printf("Warning: Lock service initialization error: %v", err)
+		logger.Warnf(ctx, "Lock service initialization error: %v", err)
 	}
 
 	// Initialize storage service
@@ -62,7 +72,7 @@ func NewLambdaHandler() (*LambdaHandler, error) {
 	}
 
 	if err := prov.GetNotificationService().Initialize(ctx); err != nil {
-		log.Printf("Warning: Notification service initialization error: %v", err)
+		logger.Warnf(ctx, "Notification service initialization error: %v", err)
 	}
 
 	owner := fmt.Sprintf("lambda-%s-%d", prov.Region(), time.Now().UnixNano())
@@ -73,6 +83,11 @@ func NewLambdaHandler() (*LambdaHandler, error) {
 		return nil, fmt.Errorf("failed to create reconciler: %w", err)
 	}
 
+	// Read tunable timings once per cold start
+	if err := reconciler.SetSettings(controller.LoadSettings(ctx, prov.GetStorageService())); err != nil {
+		logger.Warnf(ctx, "%v", err)
+	}
+
 	stor, err := storage.NewStorageWithProvider(prov)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create storage: %w", err)
@@ -89,7 +104,7 @@ func NewLambdaHandler() (*LambdaHandler, error) {
 	// Get queue URL from environment variable
 	queueURL := os.Getenv("RECONCILE_QUEUE_URL")
 	if queueURL == "" {
-		log.Println("Warning: RECONCILE_QUEUE_URL not set, requeue functionality will be disabled")
+		logger.Warnf(ctx, "RECONCILE_QUEUE_URL not set, requeue functionality will be disabled")
 	}
 
 	return &LambdaHandler{
@@ -103,13 +118,13 @@ func NewLambdaHandler() (*LambdaHandler, error) {
 
 // HandleRequest processes Lambda events
 func (h *LambdaHandler) HandleRequest(ctx context.Context, event json.RawMessage) (*models.ReconcileResult, error) {
-	log.Printf("Received event: %s", string(event))
-
 	// Get Lambda request ID from context
 	requestID := "unknown"
 	if lc, ok := lambdacontext.FromContext(ctx); ok {
 		requestID = lc.AwsRequestID
 	}
+	ctx = logger.With(ctx, "requestID", requestID)
+	logger.Debugf(ctx, "Received event: %s", string(event))
 
 	// Store the cluster name for requeue if needed
 	var clusterName string
@@ -120,6 +135,12 @@ func (h *LambdaHandler) HandleRequest(ctx context.Context, event json.RawMessage
 	{
 		// Check for direct Lambda event
 		var lambdaEvent LambdaEvent
+		if err := json.Unmarshal(event, &lambdaEvent); err == nil && lambdaEvent.Action == ScheduleAction && lambdaEvent.ClusterName == "" {
+			return h.handleSchedules(ctx, requestID)
+		}
+		if err := json.Unmarshal(event, &lambdaEvent); err == nil && lambdaEvent.Action == ReconcileStaleAction && lambdaEvent.ClusterName == "" {
+			return h.handleStaleClusters(ctx, requestID)
+		}
 		if err := json.Unmarshal(event, &lambdaEvent); err == nil && lambdaEvent.ClusterName != "" {
 			clusterName = lambdaEvent.ClusterName
 			result, err = h.reconciler.ReconcileClusterWithRequestID(ctx, clusterName, requestID)
@@ -133,7 +154,7 @@ func (h *LambdaHandler) HandleRequest(ctx context.Context, event json.RawMessage
 				// Parse the SQS message body
 				var requeueMsg RequeueMessage
 				if err := json.Unmarshal([]byte(record.Body), &requeueMsg); err == nil && requeueMsg.ClusterName != "" {
-					log.Printf("Processing SQS requeue event for cluster: %s", requeueMsg.ClusterName)
+					logger.Infof(ctx, "Processing SQS requeue event for cluster: %s", requeueMsg.ClusterName)
 					clusterName = requeueMsg.ClusterName
 					result, err = h.reconciler.ReconcileClusterWithRequestID(ctx, clusterName, requestID)
 					goto handleRequeue
@@ -148,7 +169,13 @@ func (h *LambdaHandler) HandleRequest(ctx context.Context, event json.RawMessage
 				if record.S3.Object.Key != "" {
 					clusterName = extractClusterName(record.S3.Object.Key)
 					if clusterName != "" {
-						log.Printf("Processing S3 event for cluster: %s", clusterName)
+						// Rapid edits fire one event each; the first reconcile
+						// covers them all
+						if strings.HasSuffix(record.S3.Object.Key, "/config.yaml") && !h.reconciler.SpecChangePending(ctx, clusterName) {
+							logger.Infof(ctx, "Spec of cluster %s already reconciled, skipping S3 event", clusterName)
+							return &models.ReconcileResult{}, nil
+						}
+						logger.Infof(ctx, "Processing S3 event for cluster: %s", clusterName)
 						result, err = h.reconciler.ReconcileClusterWithRequestID(ctx, clusterName, requestID)
 						goto handleRequeue
 					}
@@ -163,20 +190,20 @@ func (h *LambdaHandler) HandleRequest(ctx context.Context, event json.RawMessage
 			state := ec2Event.Detail.State
 			region := ec2Event.Region
 
-			log.Printf("Processing EC2 state change event: instance %s in region %s changed to %s", instanceID, region, state)
+			logger.Infof(ctx, "Processing EC2 state change event: instance %s in region %s changed to %s", instanceID, region, state)
 
 			clusterName, err = h.getClusterFromInstanceTags(ctx, instanceID, region)
 			if err != nil {
-				log.Printf("Failed to get cluster from instance tags: %v", err)
+				logger.Warnf(ctx, "Failed to get cluster from instance tags: %v", err)
 				return nil, err
 			}
 
 			if clusterName == "" {
-				log.Printf("Instance %s has no Cluster tag, ignoring state change to %s", instanceID, state)
+				logger.Infof(ctx, "Instance %s has no Cluster tag, ignoring state change to %s", instanceID, state)
 				return &models.ReconcileResult{}, nil
 			}
 
-			log.Printf("Instance %s belongs to cluster %s (state: %s), triggering reconciliation",
+			logger.Infof(ctx, "Instance %s belongs to cluster %s (state: %s), triggering reconciliation",
 				instanceID, clusterName, state)
 			result, err = h.reconciler.ReconcileClusterWithRequestID(ctx, clusterName, requestID)
 			goto handleRequeue
@@ -189,13 +216,65 @@ handleRequeue:
 	// If reconciliation succeeded and requeue is requested, schedule next reconciliation
 	if err == nil && result != nil && result.Requeue && clusterName != "" {
 		if requeueErr := h.scheduleRequeue(ctx, clusterName, result.RequeueAfter); requeueErr != nil {
-			log.Printf("Failed to schedule requeue for cluster %s: %v", clusterName, requeueErr)
+			logger.Warnf(ctx, "Failed to schedule requeue for cluster %s: %v", clusterName, requeueErr)
 		}
 	}
 
 	return result, err
 }
 
+// handleSchedules reconciles the clusters due for a scheduled stop or
+// start, through the requeue queue when there is one so each gets a Lambda
+// invocation of its own, and runs the periodic orphan collection when due
+func (h *LambdaHandler) handleSchedules(ctx context.Context, requestID string) (*models.ReconcileResult, error) {
+	due, err := h.reconciler.ScheduledClusters(ctx, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	for _, clusterName := range due {
+		logger.Infof(ctx, "Cluster %s has a scheduled stop or start, reconciling", clusterName)
+		h.reconcileQueued(ctx, clusterName, requestID)
+	}
+
+	// Resources of deleted clusters are collected on the same schedule
+	h.reconciler.CollectOrphansIfDue(ctx, time.Now())
+	return &models.ReconcileResult{}, nil
+}
+
+// handleStaleClusters reconciles the clusters that are not settled and went
+// without a reconcile for the staleReconcileAfter setting, so clusters whose
+// requeue was lost recover without a user action
+func (h *LambdaHandler) handleStaleClusters(ctx context.Context, requestID string) (*models.ReconcileResult, error) {
+	stale, err := h.reconciler.StaleClusters(ctx, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	for _, clusterName := range stale {
+		logger.Infof(ctx, "Cluster %s is stale, reconciling", clusterName)
+		h.reconcileQueued(ctx, clusterName, requestID)
+	}
+	return &models.ReconcileResult{}, nil
+}
+
+// reconcileQueued reconciles a cluster through the requeue queue when there
+// is one, so each cluster gets a Lambda invocation of its own, and otherwise
+// right away
+func (h *LambdaHandler) reconcileQueued(ctx context.Context, clusterName, requestID string) {
+	if h.queueURL != "" {
+		err := h.scheduleRequeue(ctx, clusterName, 0)
+		if err == nil {
+			return
+		}
+		logger.Warnf(ctx, "Failed to queue cluster %s, reconciling it now: %v", clusterName, err)
+	}
+	result, err := h.reconciler.ReconcileClusterWithRequestID(ctx, clusterName, requestID)
+	if err == nil && result != nil && result.Requeue {
+		if requeueErr := h.scheduleRequeue(ctx, clusterName, result.RequeueAfter); requeueErr != nil {
+			logger.Warnf(ctx, "Failed to schedule requeue for cluster %s: %v", clusterName, requeueErr)
+		}
+	}
+}
+
 // SQSEvent represents an SQS event notification
 type SQSEvent struct {
 	Records []SQSRecord `json:"Records"`
@@ -288,20 +367,20 @@ func (h *LambdaHandler) getClusterFromInstanceTags(ctx context.Context, instance
 // scheduleRequeue schedules a requeue message to SQS with delay
 func (h *LambdaHandler) scheduleRequeue(ctx context.Context, clusterName string, requeueAfter time.Duration) error {
 	if h.queueURL == "" {
-		log.Printf("Cannot schedule requeue: RECONCILE_QUEUE_URL not configured")
+		logger.Warnf(ctx, "Cannot schedule requeue: RECONCILE_QUEUE_URL not configured")
 		return nil // Not an error, just skip requeue
 	}
 
 	// First check if the cluster still exists before scheduling requeue
-	configKey := fmt.Sprintf("clusters/%s/config.yaml", clusterName)
+	configKey := storage.ClusterConfigKey(clusterName)
 	_, err := h.provider.GetStorageService().GetObject(ctx, configKey)
 	if err != nil {
-		if strings.Contains(err.Error(), "not found") || strings.Contains(err.Error(), "NoSuchKey") {
-			log.Printf("Cluster %s no longer exists, not scheduling requeue", clusterName)
+		if errors.Is(err, provider.ErrNotFound) {
+			logger.Infof(ctx, "Cluster %s no longer exists, not scheduling requeue", clusterName)
 			return nil // Cluster deleted, don't requeue
 		}
 		// Some other error checking existence, log but continue with requeue
-		log.Printf("Warning: could not check cluster %s existence: %v", clusterName, err)
+		logger.Warnf(ctx, "Could not check cluster %s existence: %v", clusterName, err)
 	}
 
 	// Calculate delay in seconds (SQS supports 0-900 seconds)
@@ -341,24 +420,26 @@ func (h *LambdaHandler) scheduleRequeue(ctx context.Context, clusterName string,
 		return fmt.Errorf("failed to send requeue message to SQS: %w", err)
 	}
 
-	log.Printf("Scheduled requeue for cluster %s in %d seconds", clusterName, delaySeconds)
+	logger.Infof(ctx, "Scheduled requeue for cluster %s in %d seconds", clusterName, delaySeconds)
 	return nil
 }
 
 // StartLambdaHandler starts the Lambda handler
 func StartLambdaHandler() {
-	log.Println("Starting Lambda handler...")
+	// Lines of the standard log package become records as well
+	logger.CaptureStandardLog()
+	logger.Infof(context.Background(), "Starting Lambda handler...")
 
 
 	handler, err := NewLambdaHandler()
 	if err != nil {
-		log.Printf("ERROR: Failed to create handler: %v", err)
+		logger.Errorf(context.Background(), "Failed to create handler: %v", err)
 		// Don't use log.Fatal as it calls os.Exit(1) immediately
 		// Instead, let the error propagate properly
 		panic(fmt.Sprintf("Failed to create handler: %v", err))
 	}
 
-	log.Println("Handler created, starting Lambda runtime...")
+	logger.Infof(context.Background(), "Handler created, starting Lambda runtime...")
 	lambda.Start(handler.HandleRequest)
-	log.Println("Lambda.Start returned (should not happen)")
+	logger.Errorf(context.Background(), "Lambda.Start returned (should not happen)")
 }
@@ -3,7 +3,6 @@ package aws
 import (
 	"context"
 	"fmt"
-	"log"
 	"os"
 	"time"
 
@@ -12,6 +11,7 @@ import (
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
 	"github.com/google/uuid"
+	"github.com/madhouselabs/goman/pkg/logger"
 	"github.com/madhouselabs/goman/pkg/provider"
 )
 
@@ -59,7 +59,7 @@ func (s *LockService) Initialize(ctx context.Context) error {
 	}
 
 	if os.Getenv("LAMBDA_TASK_ROOT") != "" {
-		log.Printf("Warning: Could not describe DynamoDB table %s in Lambda environment: %v", s.tableName, err)
+		logger.Warnf(ctx, "Could not describe DynamoDB table %s in Lambda environment: %v", s.tableName, err)
 		return nil
 	}
 
@@ -193,7 +193,7 @@ func (s *LockService) AcquireLockWithMetadata(ctx context.Context, resourceID st
 		return "", fmt.Errorf("failed to acquire lock: %w", err)
 	}
 
-	log.Printf("[LOCK] Acquired lock for %s (phase: %s, step: %s, requestId: %s)", 
+	logger.Infof(ctx, "[LOCK] Acquired lock for %s (phase: %s, step: %s, requestId: %s)", 
 		resourceID, 
 		func() string { if metadata != nil { return metadata.Phase } else { return "unknown" } }(),
 		func() string { if metadata != nil { return metadata.Step } else { return "unknown" } }(),
@@ -220,14 +220,14 @@ func (s *LockService) ReleaseLock(ctx context.Context, resourceID string, token
 
 	if err != nil {
 		if _, ok := err.(*types.ConditionalCheckFailedException); ok {
-			log.Printf("[LOCK] Failed to release lock for %s: invalid token or lock already released", resourceID)
+			logger.Warnf(ctx, "[LOCK] Failed to release lock for %s: invalid token or lock already released", resourceID)
 			return fmt.Errorf("invalid token or lock already released")
 		}
-		log.Printf("[LOCK] Failed to release lock for %s: %v", resourceID, err)
+		logger.Warnf(ctx, "[LOCK] Failed to release lock for %s: %v", resourceID, err)
 		return fmt.Errorf("failed to release lock: %w", err)
 	}
 
-	log.Printf("[LOCK] Released lock for %s", resourceID)
+	logger.Infof(ctx, "[LOCK] Released lock for %s", resourceID)
 	return nil
 }
 
@@ -0,0 +1,54 @@
+package aws
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/madhouselabs/goman/pkg/provider"
+)
+
+// MetricNamespace is the CloudWatch namespace of goman's metrics
+const MetricNamespace = "Goman"
+
+// PublishMetric reports a metric to CloudWatch in the embedded metric
+// format: a structured line in the controller Lambda's log, which CloudWatch
+// Logs turns into the metric. This needs neither the CloudWatch SDK nor
+// extra IAM permissions. The metric is published with its dimensions and
+// without any, so alarms can be set per dimension or overall.
+func (p *AWSProvider) PublishMetric(ctx context.Context, metric provider.Metric) error {
+	if os.Getenv("AWS_LAMBDA_FUNCTION_NAME") == "" {
+		return fmt.Errorf("metrics are only published from the controller Lambda")
+	}
+
+	dimensions := make([]string, 0, len(metric.Dimensions))
+	for name := range metric.Dimensions {
+		dimensions = append(dimensions, name)
+	}
+	sort.Strings(dimensions)
+
+	record := map[string]interface{}{
+		"_aws": map[string]interface{}{
+			"Timestamp": time.Now().UnixMilli(),
+			"CloudWatchMetrics": []map[string]interface{}{{
+				"Namespace":  MetricNamespace,
+				"Dimensions": [][]string{{}, dimensions},
+				"Metrics":    []map[string]string{{"Name": metric.Name, "Unit": metric.Unit}},
+			}},
+		},
+		metric.Name: metric.Value,
+	}
+	for name, value := range metric.Dimensions {
+		record[name] = value
+	}
+
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(os.Stdout, string(data))
+	return err
+}
@@ -3,7 +3,6 @@ package aws
 import (
 	"context"
 	"fmt"
-	"log"
 	"os"
 	"strings"
 	"time"
@@ -13,6 +12,8 @@ import (
 	snstypes "github.com/aws/aws-sdk-go-v2/service/sns/types"
 	"github.com/aws/aws-sdk-go-v2/service/sqs"
 	sqstypes "github.com/aws/aws-sdk-go-v2/service/sqs/types"
+
+	"github.com/madhouselabs/goman/pkg/logger"
 )
 
 // NotificationService implements pub/sub using SNS and SQS
@@ -42,7 +43,7 @@ func (s *NotificationService) Initialize(ctx context.Context) error {
 	// In Lambda environment, skip initialization as SNS topics should already exist
 	// and we might not have permissions to create them
 	if os.Getenv("LAMBDA_TASK_ROOT") != "" {
-		log.Println("Running in Lambda environment, skipping SNS topic initialization")
+		logger.Infof(ctx, "Running in Lambda environment, skipping SNS topic initialization")
 		// Just populate the ARNs for known topics without checking/creating
 		topics := []string{
 			"goman-cluster-events",
@@ -51,7 +52,7 @@ func (s *NotificationService) Initialize(ctx context.Context) error {
 		}
 		for _, topic := range topics {
 			// Construct the ARN directly without checking if topic exists
-			arn := fmt.Sprintf("arn:aws:sns:%s:%s:%s", s.region, s.accountID, topic)
+			arn := fmt.Sprintf("arn:%s:sns:%s:%s:%s", partition(s.region), s.region, s.accountID, topic)
 			s.topicArns[topic] = arn
 		}
 		return nil
@@ -75,10 +76,13 @@ func (s *NotificationService) Initialize(ctx context.Context) error {
 	return nil
 }
 
-// Publish sends a message to a topic
+// Publish sends a message to a topic, given by name or ARN
 func (s *NotificationService) Publish(ctx context.Context, topic string, message string) error {
-	// Get or create topic ARN
+	// Get or create topic ARN; topics given by ARN must exist
 	arn, ok := s.topicArns[topic]
+	if strings.HasPrefix(topic, "arn:") {
+		arn, ok = topic, true
+	}
 	if !ok {
 		var err error
 		arn, err = s.ensureTopic(ctx, topic)
@@ -0,0 +1,194 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/aws/aws-sdk-go-v2/service/iam"
+	"github.com/madhouselabs/goman/pkg/logger"
+	"github.com/madhouselabs/goman/pkg/provider"
+)
+
+// managedFilter selects the resources goman tagged as its own
+var managedFilter = types.Filter{Name: aws.String("tag:ManagedBy"), Values: []string{"goman"}}
+
+// InstallID returns the state bucket, which tells the resources of goman
+// installs sharing the account apart
+func (s *ComputeService) InstallID() string {
+	return stateBucketName(s.accountID)
+}
+
+// installTag is written on the resources the orphan collection looks for
+func (s *ComputeService) installTag() types.Tag {
+	return types.Tag{Key: aws.String(provider.InstallTag), Value: aws.String(s.InstallID())}
+}
+
+// ListTaggedResources lists the security groups, network interfaces and
+// volumes goman created for clusters in region. Cluster security groups
+// carry the cluster in their Cluster tag, API load balancer groups in
+// their load balancer tag; network interfaces and volumes in goman-cluster.
+func (s *ComputeService) ListTaggedResources(ctx context.Context, region string) ([]provider.TaggedResource, error) {
+	ec2Client := s.getEC2Client(region)
+	region = s.regionOrDefault(region)
+	var resources []provider.TaggedResource
+
+	// Groups referenced by instances or other groups only show up as in use
+	// when deleting them
+	groups := ec2.NewDescribeSecurityGroupsPaginator(ec2Client, &ec2.DescribeSecurityGroupsInput{
+		Filters: []types.Filter{managedFilter},
+	})
+	for groups.HasMorePages() {
+		page, err := groups.NextPage(ctx)
+		if err != nil {
+			return nil, wrapAWSError("ec2", "DescribeSecurityGroups", err)
+		}
+		for _, group := range page.SecurityGroups {
+			tags := tagMap(group.Tags)
+			cluster := tags["Cluster"]
+			if cluster == "" {
+				cluster = tags[apiLoadBalancerTag]
+			}
+			if cluster == "" {
+				continue
+			}
+			resources = append(resources, provider.TaggedResource{
+				Kind:    provider.ResourceSecurityGroup,
+				ID:      aws.ToString(group.GroupId),
+				Name:    aws.ToString(group.GroupName),
+				Region:  region,
+				Cluster: cluster,
+				Install: tags[provider.InstallTag],
+			})
+		}
+	}
+
+	interfaces := ec2.NewDescribeNetworkInterfacesPaginator(ec2Client, &ec2.DescribeNetworkInterfacesInput{
+		Filters: []types.Filter{managedFilter, {Name: aws.String("tag-key"), Values: []string{"goman-cluster"}}},
+	})
+	for interfaces.HasMorePages() {
+		page, err := interfaces.NextPage(ctx)
+		if err != nil {
+			return nil, wrapAWSError("ec2", "DescribeNetworkInterfaces", err)
+		}
+		for _, eni := range page.NetworkInterfaces {
+			tags := tagMap(eni.TagSet)
+			resources = append(resources, provider.TaggedResource{
+				Kind:    provider.ResourceNetworkInterface,
+				ID:      aws.ToString(eni.NetworkInterfaceId),
+				Region:  region,
+				Cluster: tags["goman-cluster"],
+				Install: tags[provider.InstallTag],
+				InUse:   eni.Status != types.NetworkInterfaceStatusAvailable,
+			})
+		}
+	}
+
+	volumes := ec2.NewDescribeVolumesPaginator(ec2Client, &ec2.DescribeVolumesInput{
+		Filters: []types.Filter{managedFilter, {Name: aws.String("tag-key"), Values: []string{"goman-cluster"}}},
+	})
+	for volumes.HasMorePages() {
+		page, err := volumes.NextPage(ctx)
+		if err != nil {
+			return nil, wrapAWSError("ec2", "DescribeVolumes", err)
+		}
+		for _, volume := range page.Volumes {
+			tags := tagMap(volume.Tags)
+			resources = append(resources, provider.TaggedResource{
+				Kind:    provider.ResourceVolume,
+				ID:      aws.ToString(volume.VolumeId),
+				Name:    tags["Name"],
+				Region:  region,
+				Cluster: tags["goman-cluster"],
+				Install: tags[provider.InstallTag],
+				InUse:   volume.State != types.VolumeStateAvailable,
+				Created: aws.ToTime(volume.CreateTime),
+			})
+		}
+	}
+
+	return resources, nil
+}
+
+// ListTaggedIdentities lists the cluster roles with the cluster in their
+// goman-cluster tag, which a rename moves to the new name
+func (s *ComputeService) ListTaggedIdentities(ctx context.Context) ([]provider.TaggedResource, error) {
+	names, err := s.ListClusterIdentities(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var identities []provider.TaggedResource
+	for _, name := range names {
+		roleName := clusterIdentityName(name)
+		out, err := s.iamClient.ListRoleTags(ctx, &iam.ListRoleTagsInput{RoleName: aws.String(roleName)})
+		if err != nil {
+			if strings.Contains(err.Error(), "NoSuchEntity") {
+				continue
+			}
+			return nil, wrapAWSError("iam", "ListRoleTags", err)
+		}
+		identity := provider.TaggedResource{Kind: provider.ResourceIdentity, ID: name, Name: roleName, Cluster: name}
+		for _, tag := range out.Tags {
+			switch aws.ToString(tag.Key) {
+			case "goman-cluster":
+				identity.Cluster = aws.ToString(tag.Value)
+			case provider.InstallTag:
+				identity.Install = aws.ToString(tag.Value)
+			}
+		}
+		identities = append(identities, identity)
+	}
+	return identities, nil
+}
+
+// DeleteTaggedResource deletes a security group, network interface or
+// volume listed by ListTaggedResources
+func (s *ComputeService) DeleteTaggedResource(ctx context.Context, resource provider.TaggedResource) error {
+	ec2Client := s.getEC2Client(resource.Region)
+
+	var err error
+	switch resource.Kind {
+	case provider.ResourceSecurityGroup:
+		_, err = ec2Client.DeleteSecurityGroup(ctx, &ec2.DeleteSecurityGroupInput{GroupId: aws.String(resource.ID)})
+		switch {
+		case hasErrorCode(err, "InvalidGroup.NotFound"):
+			err = nil
+		case hasErrorCode(err, "DependencyViolation"):
+			return fmt.Errorf("%w: security group %s", provider.ErrResourceInUse, resource.ID)
+		case err != nil:
+			err = wrapAWSError("ec2", "DeleteSecurityGroup", err)
+		}
+	case provider.ResourceNetworkInterface:
+		_, err = ec2Client.DeleteNetworkInterface(ctx, &ec2.DeleteNetworkInterfaceInput{NetworkInterfaceId: aws.String(resource.ID)})
+		switch {
+		case hasErrorCode(err, "InvalidNetworkInterfaceID.NotFound"):
+			err = nil
+		case hasErrorCode(err, "InvalidNetworkInterface.InUse"):
+			return fmt.Errorf("%w: network interface %s", provider.ErrResourceInUse, resource.ID)
+		case err != nil:
+			err = wrapAWSError("ec2", "DeleteNetworkInterface", err)
+		}
+	case provider.ResourceVolume:
+		_, err = ec2Client.DeleteVolume(ctx, &ec2.DeleteVolumeInput{VolumeId: aws.String(resource.ID)})
+		switch {
+		case hasErrorCode(err, "InvalidVolume.NotFound"):
+			err = nil
+		case hasErrorCode(err, "VolumeInUse"):
+			return fmt.Errorf("%w: volume %s", provider.ErrResourceInUse, resource.ID)
+		case err != nil:
+			err = wrapAWSError("ec2", "DeleteVolume", err)
+		}
+	default:
+		return fmt.Errorf("unknown resource kind %q", resource.Kind)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to delete %s %s: %w", resource.Kind, resource.ID, err)
+	}
+
+	logger.Infof(ctx, "Deleted %s %s of former cluster %s in %s", resource.Kind, resource.ID, resource.Cluster, resource.Region)
+	return nil
+}
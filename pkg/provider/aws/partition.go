@@ -0,0 +1,21 @@
+package aws
+
+import (
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	gomanconfig "github.com/madhouselabs/goman/pkg/config"
+)
+
+// partition returns the ARN partition of a region, so ARNs built by goman
+// are valid in GovCloud and China as well as the standard regions
+func partition(region string) string {
+	return gomanconfig.GetAWSPartition(region)
+}
+
+// newS3Client creates an S3 client that addresses buckets by path when a
+// custom endpoint, such as localstack, needs it
+func newS3Client(cfg aws.Config) *s3.Client {
+	return s3.NewFromConfig(cfg, func(o *s3.Options) {
+		o.UsePathStyle = gomanconfig.S3UsePathStyle()
+	})
+}
@@ -0,0 +1,270 @@
+package aws
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/madhouselabs/goman/pkg/logger"
+)
+
+// privateNetworkTag marks the subnets, route table, NAT gateway and Elastic
+// IP that private clusters share in a VPC
+const privateNetworkTag = "goman-private"
+
+// privateSubnetPrefix is the size of the private subnet created per zone,
+// the size of the default subnets
+const privateSubnetPrefix = 20
+
+// natGatewayTimeout bounds the wait for a new NAT gateway, which takes a
+// few minutes to become available
+const natGatewayTimeout = 8 * time.Minute
+
+// ensurePrivateSubnet returns the private subnet in the zone of network,
+// creating it on first use. Private subnets have no route to the internet
+// gateway: outbound traffic goes through a NAT gateway in the default
+// subnet, and S3 traffic (the K3s binary, packages, state) through a gateway
+// endpoint. The subnets, their route table and the NAT gateway are shared
+// by the private clusters in the VPC and kept when a cluster is deleted.
+func (s *ComputeService) ensurePrivateSubnet(ctx context.Context, ec2Client *ec2.Client, region string, network *NetworkInfo) (string, error) {
+	s.privateNetworkMu.Lock()
+	defer s.privateNetworkMu.Unlock()
+
+	subnets, err := ec2Client.DescribeSubnets(ctx, &ec2.DescribeSubnetsInput{
+		Filters: []types.Filter{
+			{Name: aws.String("vpc-id"), Values: []string{network.VPCID}},
+			{Name: aws.String("availability-zone"), Values: []string{network.AvailabilityZone}},
+			{Name: aws.String("tag:" + privateNetworkTag), Values: []string{"true"}},
+		},
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to describe private subnets: %w", wrapAWSError("ec2", "DescribeSubnets", err))
+	}
+	if len(subnets.Subnets) > 0 {
+		return aws.ToString(subnets.Subnets[0].SubnetId), nil
+	}
+
+	routeTableID, err := s.ensurePrivateRouteTable(ctx, ec2Client, region, network)
+	if err != nil {
+		return "", err
+	}
+
+	cidr, err := s.freeSubnetCIDR(ctx, ec2Client, network.VPCID)
+	if err != nil {
+		return "", err
+	}
+	logger.Infof(ctx, "Creating private subnet %s in %s of VPC %s", cidr, network.AvailabilityZone, network.VPCID)
+	created, err := ec2Client.CreateSubnet(ctx, &ec2.CreateSubnetInput{
+		VpcId:            aws.String(network.VPCID),
+		AvailabilityZone: aws.String(network.AvailabilityZone),
+		CidrBlock:        aws.String(cidr),
+		TagSpecifications: []types.TagSpecification{
+			privateNetworkTags(types.ResourceTypeSubnet, "goman-private-"+network.AvailabilityZone),
+		},
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to create private subnet: %w", wrapAWSError("ec2", "CreateSubnet", err))
+	}
+	subnetID := aws.ToString(created.Subnet.SubnetId)
+
+	if _, err := ec2Client.AssociateRouteTable(ctx, &ec2.AssociateRouteTableInput{
+		RouteTableId: aws.String(routeTableID),
+		SubnetId:     aws.String(subnetID),
+	}); err != nil {
+		return "", fmt.Errorf("failed to associate private subnet %s with its route table: %w", subnetID, wrapAWSError("ec2", "AssociateRouteTable", err))
+	}
+	return subnetID, nil
+}
+
+// ensurePrivateRouteTable returns the route table of the private subnets in
+// a VPC, created with a default route to a new NAT gateway and the S3
+// gateway endpoint
+func (s *ComputeService) ensurePrivateRouteTable(ctx context.Context, ec2Client *ec2.Client, region string, network *NetworkInfo) (string, error) {
+	tables, err := ec2Client.DescribeRouteTables(ctx, &ec2.DescribeRouteTablesInput{
+		Filters: []types.Filter{
+			{Name: aws.String("vpc-id"), Values: []string{network.VPCID}},
+			{Name: aws.String("tag:" + privateNetworkTag), Values: []string{"true"}},
+		},
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to describe route tables: %w", wrapAWSError("ec2", "DescribeRouteTables", err))
+	}
+	if len(tables.RouteTables) > 0 {
+		return aws.ToString(tables.RouteTables[0].RouteTableId), nil
+	}
+
+	natID, err := s.ensureNATGateway(ctx, ec2Client, network)
+	if err != nil {
+		return "", err
+	}
+
+	created, err := ec2Client.CreateRouteTable(ctx, &ec2.CreateRouteTableInput{
+		VpcId:             aws.String(network.VPCID),
+		TagSpecifications: []types.TagSpecification{privateNetworkTags(types.ResourceTypeRouteTable, "goman-private")},
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to create private route table: %w", wrapAWSError("ec2", "CreateRouteTable", err))
+	}
+	routeTableID := aws.ToString(created.RouteTable.RouteTableId)
+
+	if _, err := ec2Client.CreateRoute(ctx, &ec2.CreateRouteInput{
+		RouteTableId:         aws.String(routeTableID),
+		DestinationCidrBlock: aws.String("0.0.0.0/0"),
+		NatGatewayId:         aws.String(natID),
+	}); err != nil {
+		return "", fmt.Errorf("failed to route private subnets through NAT gateway %s: %w", natID, wrapAWSError("ec2", "CreateRoute", err))
+	}
+
+	if err := s.ensureS3Endpoint(ctx, ec2Client, region, network.VPCID, routeTableID); err != nil {
+		// S3 is still reached through the NAT gateway, at data processing cost
+		logger.Warnf(ctx, "S3 gateway endpoint not set up for VPC %s: %v", network.VPCID, err)
+	}
+	logger.Infof(ctx, "Created private route table %s through NAT gateway %s in VPC %s", routeTableID, natID, network.VPCID)
+	return routeTableID, nil
+}
+
+// ensureNATGateway returns the NAT gateway of the private subnets in a VPC,
+// created in the default subnet of network with a new Elastic IP, once it
+// is available
+func (s *ComputeService) ensureNATGateway(ctx context.Context, ec2Client *ec2.Client, network *NetworkInfo) (string, error) {
+	gateways, err := ec2Client.DescribeNatGateways(ctx, &ec2.DescribeNatGatewaysInput{
+		Filter: []types.Filter{
+			{Name: aws.String("vpc-id"), Values: []string{network.VPCID}},
+			{Name: aws.String("tag:" + privateNetworkTag), Values: []string{"true"}},
+			{Name: aws.String("state"), Values: []string{"pending", "available"}},
+		},
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to describe NAT gateways: %w", wrapAWSError("ec2", "DescribeNatGateways", err))
+	}
+
+	var natID string
+	if len(gateways.NatGateways) > 0 {
+		natID = aws.ToString(gateways.NatGateways[0].NatGatewayId)
+	} else {
+		address, err := ec2Client.AllocateAddress(ctx, &ec2.AllocateAddressInput{
+			Domain:            types.DomainTypeVpc,
+			TagSpecifications: []types.TagSpecification{privateNetworkTags(types.ResourceTypeElasticIp, "goman-private-nat")},
+		})
+		if err != nil {
+			return "", fmt.Errorf("failed to allocate an Elastic IP for the NAT gateway: %w", wrapAWSError("ec2", "AllocateAddress", err))
+		}
+		logger.Infof(ctx, "Creating NAT gateway for private clusters in VPC %s", network.VPCID)
+		created, err := ec2Client.CreateNatGateway(ctx, &ec2.CreateNatGatewayInput{
+			SubnetId:          aws.String(network.SubnetID),
+			AllocationId:      address.AllocationId,
+			TagSpecifications: []types.TagSpecification{privateNetworkTags(types.ResourceTypeNatgateway, "goman-private-nat")},
+		})
+		if err != nil {
+			return "", fmt.Errorf("failed to create NAT gateway: %w", wrapAWSError("ec2", "CreateNatGateway", err))
+		}
+		natID = aws.ToString(created.NatGateway.NatGatewayId)
+	}
+
+	waiter := ec2.NewNatGatewayAvailableWaiter(ec2Client)
+	if err := waiter.Wait(ctx, &ec2.DescribeNatGatewaysInput{NatGatewayIds: []string{natID}}, natGatewayTimeout); err != nil {
+		return "", fmt.Errorf("NAT gateway %s did not become available: %w", natID, err)
+	}
+	return natID, nil
+}
+
+// ensureS3Endpoint routes S3 traffic of a route table through the VPC's S3
+// gateway endpoint, creating the endpoint if the VPC has none
+func (s *ComputeService) ensureS3Endpoint(ctx context.Context, ec2Client *ec2.Client, region, vpcID, routeTableID string) error {
+	serviceName := fmt.Sprintf("com.amazonaws.%s.s3", region)
+	endpoints, err := ec2Client.DescribeVpcEndpoints(ctx, &ec2.DescribeVpcEndpointsInput{
+		Filters: []types.Filter{
+			{Name: aws.String("vpc-id"), Values: []string{vpcID}},
+			{Name: aws.String("service-name"), Values: []string{serviceName}},
+			{Name: aws.String("vpc-endpoint-type"), Values: []string{string(types.VpcEndpointTypeGateway)}},
+		},
+	})
+	if err != nil {
+		return wrapAWSError("ec2", "DescribeVpcEndpoints", err)
+	}
+	if len(endpoints.VpcEndpoints) > 0 {
+		_, err := ec2Client.ModifyVpcEndpoint(ctx, &ec2.ModifyVpcEndpointInput{
+			VpcEndpointId:    endpoints.VpcEndpoints[0].VpcEndpointId,
+			AddRouteTableIds: []string{routeTableID},
+		})
+		return wrapAWSError("ec2", "ModifyVpcEndpoint", err)
+	}
+	_, err = ec2Client.CreateVpcEndpoint(ctx, &ec2.CreateVpcEndpointInput{
+		VpcId:             aws.String(vpcID),
+		ServiceName:       aws.String(serviceName),
+		VpcEndpointType:   types.VpcEndpointTypeGateway,
+		RouteTableIds:     []string{routeTableID},
+		TagSpecifications: []types.TagSpecification{privateNetworkTags(types.ResourceTypeVpcEndpoint, "goman-private-s3")},
+	})
+	return wrapAWSError("ec2", "CreateVpcEndpoint", err)
+}
+
+// freeSubnetCIDR returns the first block of privateSubnetPrefix bits in the
+// VPC's primary CIDR that overlaps none of its subnets
+func (s *ComputeService) freeSubnetCIDR(ctx context.Context, ec2Client *ec2.Client, vpcID string) (string, error) {
+	vpcs, err := ec2Client.DescribeVpcs(ctx, &ec2.DescribeVpcsInput{VpcIds: []string{vpcID}})
+	if err != nil {
+		return "", fmt.Errorf("failed to describe VPC %s: %w", vpcID, wrapAWSError("ec2", "DescribeVpcs", err))
+	}
+	if len(vpcs.Vpcs) == 0 {
+		return "", fmt.Errorf("VPC %s not found", vpcID)
+	}
+	subnets, err := ec2Client.DescribeSubnets(ctx, &ec2.DescribeSubnetsInput{
+		Filters: []types.Filter{{Name: aws.String("vpc-id"), Values: []string{vpcID}}},
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to describe subnets: %w", wrapAWSError("ec2", "DescribeSubnets", err))
+	}
+
+	_, vpcNet, err := net.ParseCIDR(aws.ToString(vpcs.Vpcs[0].CidrBlock))
+	if err != nil {
+		return "", fmt.Errorf("VPC %s has no IPv4 CIDR: %w", vpcID, err)
+	}
+	var used []*net.IPNet
+	for _, subnet := range subnets.Subnets {
+		if _, n, err := net.ParseCIDR(aws.ToString(subnet.CidrBlock)); err == nil {
+			used = append(used, n)
+		}
+	}
+
+	ones, bits := vpcNet.Mask.Size()
+	if bits != 32 || ones > privateSubnetPrefix {
+		return "", fmt.Errorf("VPC %s (%s) is too small for a /%d private subnet", vpcID, vpcNet, privateSubnetPrefix)
+	}
+	base := binary.BigEndian.Uint32(vpcNet.IP.To4())
+	size := uint32(1) << (32 - privateSubnetPrefix)
+	mask := net.CIDRMask(privateSubnetPrefix, 32)
+	for i := uint32(0); i < uint32(1)<<(privateSubnetPrefix-ones); i++ {
+		ip := make(net.IP, 4)
+		binary.BigEndian.PutUint32(ip, base+i*size)
+		candidate := &net.IPNet{IP: ip, Mask: mask}
+		free := true
+		for _, n := range used {
+			if n.Contains(candidate.IP) || candidate.Contains(n.IP) {
+				free = false
+				break
+			}
+		}
+		if free {
+			return candidate.String(), nil
+		}
+	}
+	return "", fmt.Errorf("no free /%d block left in VPC %s (%s) for a private subnet", privateSubnetPrefix, vpcID, vpcNet)
+}
+
+// privateNetworkTags tags a shared resource of the private subnets
+func privateNetworkTags(resourceType types.ResourceType, name string) types.TagSpecification {
+	return types.TagSpecification{
+		ResourceType: resourceType,
+		Tags: []types.Tag{
+			{Key: aws.String("Name"), Value: aws.String(name)},
+			{Key: aws.String(privateNetworkTag), Value: aws.String("true")},
+			{Key: aws.String("ManagedBy"), Value: aws.String("goman")},
+		},
+	}
+}
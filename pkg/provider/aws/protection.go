@@ -0,0 +1,46 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/madhouselabs/goman/pkg/logger"
+)
+
+// SetInstanceProtection changes the shutdown behavior, stop protection and
+// deletion protection of an instance. EC2 changes one attribute per call.
+func (s *ComputeService) SetInstanceProtection(ctx context.Context, instanceID string, shutdownBehavior string, stopProtection, deletionProtection bool) error {
+	ec2Client := s.instanceClient(ctx, instanceID)
+
+	if shutdownBehavior != "" {
+		_, err := ec2Client.ModifyInstanceAttribute(ctx, &ec2.ModifyInstanceAttributeInput{
+			InstanceId:                        aws.String(instanceID),
+			InstanceInitiatedShutdownBehavior: &types.AttributeValue{Value: aws.String(shutdownBehavior)},
+		})
+		if err != nil {
+			return fmt.Errorf("failed to set shutdown behavior: %w", wrapAWSError("ec2", "ModifyInstanceAttribute", err))
+		}
+	}
+
+	_, err := ec2Client.ModifyInstanceAttribute(ctx, &ec2.ModifyInstanceAttributeInput{
+		InstanceId:     aws.String(instanceID),
+		DisableApiStop: &types.AttributeBooleanValue{Value: aws.Bool(stopProtection)},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to set stop protection: %w", wrapAWSError("ec2", "ModifyInstanceAttribute", err))
+	}
+
+	_, err = ec2Client.ModifyInstanceAttribute(ctx, &ec2.ModifyInstanceAttributeInput{
+		InstanceId:            aws.String(instanceID),
+		DisableApiTermination: &types.AttributeBooleanValue{Value: aws.Bool(deletionProtection)},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to set deletion protection: %w", wrapAWSError("ec2", "ModifyInstanceAttribute", err))
+	}
+
+	logger.Infof(ctx, "Instance %s: shutdown behavior %s, stop protection %t, deletion protection %t", instanceID, shutdownBehavior, stopProtection, deletionProtection)
+	return nil
+}
@@ -11,7 +11,6 @@ import (
 	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
 	"github.com/aws/aws-sdk-go-v2/service/ec2"
-	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
 	"github.com/aws/aws-sdk-go-v2/service/eventbridge"
 	eventbridgetypes "github.com/aws/aws-sdk-go-v2/service/eventbridge/types"
 	"github.com/aws/aws-sdk-go-v2/service/iam"
@@ -40,6 +39,7 @@ type AWSProvider struct {
 	notificationService provider.NotificationService
 	functionService     provider.FunctionService
 	computeService      provider.ComputeService
+	secretService       provider.SecretService
 
 	// AWS clients
 	dynamoClient *dynamodb.Client
@@ -58,11 +58,11 @@ func NewProvider(profile, region string) (*AWSProvider, error) {
 	// Create a context for initialization
 	ctx := context.Background()
 
-	logger.Printf("NewProvider called with profile='%s', region='%s'", profile, region)
+	logger.Infof(ctx, "NewProvider called with profile='%s', region='%s'", profile, region)
 
 	if region == "" {
 		region = "ap-south-1" // Default to Mumbai
-		logger.Printf("Using default region: %s", region)
+		logger.Infof(ctx, "Using default region: %s", region)
 	}
 
 	// Load AWS config
@@ -71,29 +71,29 @@ func NewProvider(profile, region string) (*AWSProvider, error) {
 
 	// Only add profile if it's not empty (for Lambda environment)
 	if profile != "" {
-		logger.Printf("Adding profile to config: %s", profile)
+		logger.Infof(ctx, "Adding profile to config: %s", profile)
 		cfgOptions = append(cfgOptions, config.WithSharedConfigProfile(profile))
 	} else {
-		logger.Println("No profile specified, using default credentials chain")
+		logger.Infof(ctx, "No profile specified, using default credentials chain")
 	}
 
-	logger.Println("Loading AWS config...")
+	logger.Infof(ctx, "Loading AWS config...")
 	cfg, err := config.LoadDefaultConfig(ctx, cfgOptions...)
 	if err != nil {
-		logger.Printf("Failed to load AWS config: %v", err)
+		logger.Warnf(ctx, "Failed to load AWS config: %v", err)
 		return nil, fmt.Errorf("failed to load AWS config: %w", err)
 	}
-	logger.Println("AWS config loaded successfully")
+	logger.Infof(ctx, "AWS config loaded successfully")
 
 	// Get account ID
-	logger.Println("Getting AWS account ID...")
+	logger.Infof(ctx, "Getting AWS account ID...")
 	stsClient := sts.NewFromConfig(cfg)
 	identity, err := stsClient.GetCallerIdentity(ctx, &sts.GetCallerIdentityInput{})
 	if err != nil {
-		logger.Printf("Failed to get AWS account ID: %v", err)
+		logger.Warnf(ctx, "Failed to get AWS account ID: %v", err)
 		return nil, fmt.Errorf("failed to get AWS account ID: %w", err)
 	}
-	logger.Printf("AWS account ID: %s", *identity.Account)
+	logger.Infof(ctx, "AWS account ID: %s", *identity.Account)
 
 	p := &AWSProvider{
 		profile:      profile,
@@ -101,7 +101,7 @@ func NewProvider(profile, region string) (*AWSProvider, error) {
 		accountID:    *identity.Account,
 		cfg:          cfg,
 		dynamoClient: dynamodb.NewFromConfig(cfg),
-		s3Client:     s3.NewFromConfig(cfg),
+		s3Client:     newS3Client(cfg),
 		snsClient:    sns.NewFromConfig(cfg),
 		sqsClient:    sqs.NewFromConfig(cfg),
 		lambdaClient: lambda.NewFromConfig(cfg),
@@ -117,6 +117,12 @@ func NewProvider(profile, region string) (*AWSProvider, error) {
 	p.functionService = NewFunctionService(p.lambdaClient, p.s3Client, p.iamClient, p.accountID, p.region)
 	p.computeService = NewComputeService(p.ec2Client, p.iamClient, p.cfg, p.accountID)
 
+	secretService, err := NewSecretService(p.cfg, p.storageService)
+	if err != nil {
+		return nil, err
+	}
+	p.secretService = secretService
+
 	return p, nil
 }
 
@@ -145,6 +151,11 @@ func (p *AWSProvider) GetComputeService() provider.ComputeService {
 	return p.computeService
 }
 
+// GetSecretService returns the secret service
+func (p *AWSProvider) GetSecretService() provider.SecretService {
+	return p.secretService
+}
+
 
 // Name returns the provider name
 func (p *AWSProvider) Name() string {
@@ -166,6 +177,15 @@ func (p *AWSProvider) GetAccountID() string {
 	return p.accountID
 }
 
+// CallerARN returns the ARN of the identity the provider's credentials resolve to
+func (p *AWSProvider) CallerARN(ctx context.Context) (string, error) {
+	identity, err := p.stsClient.GetCallerIdentity(ctx, &sts.GetCallerIdentityInput{})
+	if err != nil {
+		return "", fmt.Errorf("failed to get caller identity: %w", err)
+	}
+	return aws.ToString(identity.Arn), nil
+}
+
 // GetServiceName returns AWS-specific service name for generic service type
 func (p *AWSProvider) GetServiceName(serviceType provider.ServiceType) string {
 	switch serviceType {
@@ -233,8 +253,8 @@ func (p *AWSProvider) GetServiceConfiguration(serviceType provider.ServiceType)
 	case provider.ServiceTypeCommand:
 		config.ProviderSpecific = map[string]interface{}{
 			"documentName": "AWS-RunShellScript",
-			"outputS3BucketName": "",
-			"outputS3KeyPrefix": "ssm-output",
+			"outputS3BucketName": stateBucketName(p.accountID),
+			"outputS3KeyPrefix": defaultSSMOutputKeyPrefix,
 		}
 	case provider.ServiceTypeLock:
 		config.ProviderSpecific = map[string]interface{}{
@@ -280,62 +300,6 @@ func (p *AWSProvider) GetConfig() aws.Config {
 	return p.cfg
 }
 
-// CleanupClusterResources implements the ClusterCleaner interface
-func (p *AWSProvider) CleanupClusterResources(ctx context.Context, clusterName string) error {
-	logger.Printf("Cleaning up AWS resources for cluster %s", clusterName)
-
-	// Check if there are any instances still running
-	instances, err := p.computeService.ListInstances(ctx, map[string]string{
-		"tag:Cluster":         clusterName,
-		"instance-state-name": "running,pending,stopping,stopped",
-	})
-
-	if err == nil && len(instances) > 0 {
-		logger.Printf("Cannot cleanup: %d instances still exist for cluster %s", len(instances), clusterName)
-		return fmt.Errorf("cannot cleanup: instances still exist")
-	}
-
-	// Clean up cluster-specific security groups
-	// Look for all possible patterns: goman-{cluster}-sg, goman-{cluster}-*-sg
-	patterns := []string{
-		fmt.Sprintf("goman-%s-sg", clusterName),
-		fmt.Sprintf("goman-%s-*", clusterName), // Catch any variations
-	}
-	
-	for _, pattern := range patterns {
-		describeSGOutput, err := p.ec2Client.DescribeSecurityGroups(ctx, &ec2.DescribeSecurityGroupsInput{
-			Filters: []types.Filter{
-				{
-					Name:   aws.String("group-name"),
-					Values: []string{pattern},
-				},
-			},
-		})
-
-		if err == nil && len(describeSGOutput.SecurityGroups) > 0 {
-			for _, sg := range describeSGOutput.SecurityGroups {
-				sgName := aws.ToString(sg.GroupName)
-				sgID := sg.GroupId
-
-				// Try to delete the security group
-				_, err = p.ec2Client.DeleteSecurityGroup(ctx, &ec2.DeleteSecurityGroupInput{
-					GroupId: sgID,
-				})
-				if err != nil {
-					// Security group might still be in use, which is fine
-					logger.Printf("Note: Security group %s not deleted (may be in use): %v", sgName, err)
-				} else {
-					logger.Printf("Deleted security group %s", sgName)
-				}
-			}
-		}
-	}
-
-
-	logger.Printf("Cluster %s cleanup complete (preserving reusable resources)", clusterName)
-	return nil
-}
-
 // Initialize sets up AWS infrastructure
 func (p *AWSProvider) Initialize(ctx context.Context) (*provider.InitializeResult, error) {
 	result := &provider.InitializeResult{
@@ -349,7 +313,7 @@ func (p *AWSProvider) Initialize(ctx context.Context) (*provider.InitializeResul
 		result.Errors = append(result.Errors, fmt.Sprintf("Storage: %v", err))
 	} else {
 		result.StorageReady = true
-		result.Resources["s3_bucket"] = fmt.Sprintf("goman-%s", p.accountID)
+		result.Resources["s3_bucket"] = stateBucketName(p.accountID)
 	}
 
 	// Initialize lock service (DynamoDB)
@@ -366,7 +330,7 @@ func (p *AWSProvider) Initialize(ctx context.Context) (*provider.InitializeResul
 	} else {
 		result.NotificationsReady = true
 		result.Resources["sns_topics"] = "goman-cluster-events, goman-reconcile-events, goman-error-events"
-		logger.Printf("SNS topics initialized successfully")
+		logger.Infof(ctx, "SNS topics initialized successfully")
 	}
 
 	// Initialize compute service (SSM instance profile)
@@ -376,6 +340,13 @@ func (p *AWSProvider) Initialize(ctx context.Context) (*provider.InitializeResul
 		} else {
 			result.Resources["iam_role_ssm"] = "goman-ssm-instance-role"
 		}
+
+		// Deploy managed SSM documents used for node operations
+		if err := computeService.EnsureDocuments(ctx, p.region); err != nil {
+			result.Errors = append(result.Errors, fmt.Sprintf("SSM documents: %v", err))
+		} else {
+			result.Resources["ssm_documents"] = fmt.Sprintf("%s* (%s)", ssmDocumentPrefix, SSMDocumentsVersion)
+		}
 	}
 
 	// Deploy function (Lambda)
@@ -390,7 +361,7 @@ func (p *AWSProvider) Initialize(ctx context.Context) (*provider.InitializeResul
 		// Wait for Lambda to be fully ready before setting up notifications
 		// This avoids conflicts when Lambda is updating
 		if err := p.waitForLambdaReady(ctx, functionName); err != nil {
-			logger.Printf("Warning: Lambda may not be fully ready: %v", err)
+			logger.Warnf(ctx, "Lambda may not be fully ready: %v", err)
 		}
 		
 		// Set up S3 notifications for Lambda with retry
@@ -399,7 +370,7 @@ func (p *AWSProvider) Initialize(ctx context.Context) (*provider.InitializeResul
 		for i := 0; i < retryCount; i++ {
 			if err := p.setupS3Notifications(ctx, functionName); err != nil {
 				lastErr = err
-				logger.Printf("Attempt %d: Failed to setup S3 notifications: %v", i+1, err)
+				logger.Warnf(ctx, "Attempt %d: Failed to setup S3 notifications: %v", i+1, err)
 				if i < retryCount-1 {
 					time.Sleep(time.Duration(5*(i+1)) * time.Second) // Exponential backoff
 				}
@@ -418,13 +389,21 @@ func (p *AWSProvider) Initialize(ctx context.Context) (*provider.InitializeResul
 			result.Errors = append(result.Errors, fmt.Sprintf("SQS queue: %v", err))
 		} else {
 			result.Resources["sqs_queue"] = queueURL
-			logger.Printf("Created SQS queue for reconciliation: %s", queueURL)
+			logger.Infof(ctx, "Created SQS queue for reconciliation: %s", queueURL)
 		}
 		
 		// Set up EventBridge rule for EC2 state changes
 		if err := p.setupEventBridgeRule(ctx, functionName); err != nil {
 			result.Errors = append(result.Errors, fmt.Sprintf("EventBridge rule: %v", err))
 		}
+		
+		// Set up EventBridge rules applying cluster schedules and
+		// reconciling stale clusters
+		for _, rule := range periodicRules {
+			if err := p.setupPeriodicRule(ctx, functionName, rule); err != nil {
+				result.Errors = append(result.Errors, fmt.Sprintf("EventBridge rule %s: %v", rule.name, err))
+			}
+		}
 	}
 
 	// Auth is handled by IAM roles created during service initialization
@@ -443,7 +422,7 @@ func (p *AWSProvider) Initialize(ctx context.Context) (*provider.InitializeResul
 func (p *AWSProvider) Cleanup(ctx context.Context) error {
 	var errors []string
 	
-	bucketName := fmt.Sprintf("goman-%s", p.accountID)
+	bucketName := stateBucketName(p.accountID)
 	functionName := fmt.Sprintf("goman-controller-%s", p.accountID)
 	tableName := "goman-resource-locks"
 	lambdaRoleName := fmt.Sprintf("goman-lambda-role-%s", p.accountID)
@@ -463,19 +442,45 @@ func (p *AWSProvider) Cleanup(ctx context.Context) error {
 		Name: aws.String(ruleName),
 	})
 	
+	for _, rule := range periodicRules {
+		eventClient.RemoveTargets(ctx, &eventbridge.RemoveTargetsInput{
+			Rule: aws.String(rule.name),
+			Ids:  []string{"1"},
+		})
+		eventClient.DeleteRule(ctx, &eventbridge.DeleteRuleInput{
+			Name: aws.String(rule.name),
+		})
+	}
+	
 	if err := p.functionService.DeleteFunction(ctx, functionName); err != nil {
 		if !strings.Contains(err.Error(), "ResourceNotFoundException") {
 			errors = append(errors, fmt.Sprintf("Lambda: %v", err))
 		}
 	}
 	
+	if computeService, ok := p.computeService.(*ComputeService); ok {
+		if err := computeService.DeleteDocuments(ctx, p.region); err != nil {
+			errors = append(errors, fmt.Sprintf("SSM documents: %v", err))
+		}
+		
+		clusters, err := computeService.ListClusterIdentities(ctx)
+		if err != nil {
+			errors = append(errors, fmt.Sprintf("cluster IAM roles: %v", err))
+		}
+		for _, clusterName := range clusters {
+			if err := computeService.DeleteClusterIdentity(ctx, clusterName); err != nil {
+				errors = append(errors, fmt.Sprintf("IAM role for cluster %s: %v", clusterName, err))
+			}
+		}
+	}
+	
 	snsTopics := []string{
 		"goman-cluster-events",
 		"goman-reconcile-events",
 		"goman-error-events",
 	}
 	for _, topicName := range snsTopics {
-		topicArn := fmt.Sprintf("arn:aws:sns:%s:%s:%s", p.region, p.accountID, topicName)
+		topicArn := fmt.Sprintf("arn:%s:sns:%s:%s:%s", partition(p.region), p.region, p.accountID, topicName)
 		_, err := p.snsClient.DeleteTopic(ctx, &sns.DeleteTopicInput{
 			TopicArn: aws.String(topicArn),
 		})
@@ -495,11 +500,14 @@ func (p *AWSProvider) Cleanup(ctx context.Context) error {
 			})
 		}
 	}
-	_, err = p.s3Client.DeleteBucket(ctx, &s3.DeleteBucketInput{
-		Bucket: aws.String(bucketName),
-	})
-	if err != nil && !strings.Contains(err.Error(), "NoSuchBucket") {
-		errors = append(errors, fmt.Sprintf("S3: %v", err))
+	// A customer-provided bucket is emptied but kept, it was not created by goman
+	if bucketName == fmt.Sprintf("goman-%s", p.accountID) {
+		_, err = p.s3Client.DeleteBucket(ctx, &s3.DeleteBucketInput{
+			Bucket: aws.String(bucketName),
+		})
+		if err != nil && !strings.Contains(err.Error(), "NoSuchBucket") {
+			errors = append(errors, fmt.Sprintf("S3: %v", err))
+		}
 	}
 	
 	_, err = p.dynamoClient.DeleteTable(ctx, &dynamodb.DeleteTableInput{
@@ -520,7 +528,15 @@ func (p *AWSProvider) Cleanup(ctx context.Context) error {
 	
 	p.iamClient.DetachRolePolicy(ctx, &iam.DetachRolePolicyInput{
 		RoleName:  aws.String(ssmRoleName),
-		PolicyArn: aws.String("arn:aws:iam::aws:policy/AmazonSSMManagedInstanceCore"),
+		PolicyArn: aws.String(fmt.Sprintf("arn:%s:iam::aws:policy/AmazonSSMManagedInstanceCore", partition(p.region))),
+	})
+	p.iamClient.DeleteRolePolicy(ctx, &iam.DeleteRolePolicyInput{
+		RoleName:   aws.String(ssmRoleName),
+		PolicyName: aws.String(commandOutputPolicyName),
+	})
+	p.iamClient.DeleteRolePolicy(ctx, &iam.DeleteRolePolicyInput{
+		RoleName:   aws.String(ssmRoleName),
+		PolicyName: aws.String(clusterAccessPolicyName),
 	})
 	p.iamClient.DeleteRole(ctx, &iam.DeleteRoleInput{
 		RoleName: aws.String(ssmRoleName),
@@ -538,7 +554,7 @@ func (p *AWSProvider) Cleanup(ctx context.Context) error {
 		}
 	}
 	
-	policyArn := fmt.Sprintf("arn:aws:iam::%s:policy/%s", p.accountID, lambdaPolicyName)
+	policyArn := fmt.Sprintf("arn:%s:iam::%s:policy/%s", partition(p.region), p.accountID, lambdaPolicyName)
 	listVersionsOutput, err := p.iamClient.ListPolicyVersions(ctx, &iam.ListPolicyVersionsInput{
 		PolicyArn: aws.String(policyArn),
 	})
@@ -573,7 +589,7 @@ func (p *AWSProvider) GetStatus(ctx context.Context) (*provider.InfrastructureSt
 		Resources: make(map[string]string),
 	}
 
-	bucketName := fmt.Sprintf("goman-%s", p.accountID)
+	bucketName := stateBucketName(p.accountID)
 	status.Resources["s3_bucket"] = bucketName
 	status.StorageStatus = "ready"
 
@@ -627,7 +643,7 @@ func (p *AWSProvider) waitForLambdaReady(ctx context.Context, functionName strin
 
 // setupS3Notifications configures S3 bucket notifications to trigger Lambda
 func (p *AWSProvider) setupS3Notifications(ctx context.Context, functionName string) error {
-	bucketName := fmt.Sprintf("goman-%s", p.accountID)
+	bucketName := stateBucketName(p.accountID)
 	
 	// First check if notifications are already configured
 	existingConfig, err := p.s3Client.GetBucketNotificationConfiguration(ctx, &s3.GetBucketNotificationConfigurationInput{
@@ -637,7 +653,7 @@ func (p *AWSProvider) setupS3Notifications(ctx context.Context, functionName str
 		// Check if our notification already exists
 		for _, config := range existingConfig.LambdaFunctionConfigurations {
 			if config.Id != nil && *config.Id == "goman-cluster-changes" {
-				logger.Printf("S3 notifications already configured for bucket %s", bucketName)
+				logger.Infof(ctx, "S3 notifications already configured for bucket %s", bucketName)
 				return nil
 			}
 		}
@@ -649,7 +665,7 @@ func (p *AWSProvider) setupS3Notifications(ctx context.Context, functionName str
 		StatementId:  aws.String("s3-invoke-permission"),
 		Action:       aws.String("lambda:InvokeFunction"),
 		Principal:    aws.String("s3.amazonaws.com"),
-		SourceArn:    aws.String(fmt.Sprintf("arn:aws:s3:::%s", bucketName)),
+		SourceArn:    aws.String(fmt.Sprintf("arn:%s:s3:::%s", partition(p.region), bucketName)),
 	})
 	
 	if err != nil {
@@ -769,7 +785,7 @@ func (p *AWSProvider) setupSQSQueue(ctx context.Context, functionName string) (s
 			if err != nil {
 				return queueURL, fmt.Errorf("failed to create event source mapping: %w", err)
 			}
-			logger.Printf("Created SQS event source mapping for Lambda function %s", functionName)
+			logger.Infof(ctx, "Created SQS event source mapping for Lambda function %s", functionName)
 		} else {
 			// Check if existing mapping is enabled
 			for _, mapping := range listResult.EventSourceMappings {
@@ -780,9 +796,9 @@ func (p *AWSProvider) setupSQSQueue(ctx context.Context, functionName string) (s
 						Enabled: aws.Bool(true),
 					})
 					if err != nil {
-						logger.Printf("Warning: Failed to enable existing event source mapping: %v", err)
+						logger.Warnf(ctx, "Failed to enable existing event source mapping: %v", err)
 					} else {
-						logger.Printf("Enabled existing SQS event source mapping for Lambda function %s", functionName)
+						logger.Infof(ctx, "Enabled existing SQS event source mapping for Lambda function %s", functionName)
 					}
 				}
 			}
@@ -794,7 +810,7 @@ func (p *AWSProvider) setupSQSQueue(ctx context.Context, functionName string) (s
 		FunctionName: aws.String(functionName),
 	})
 	if err != nil {
-		logger.Printf("Warning: Failed to get existing Lambda configuration: %v", err)
+		logger.Warnf(ctx, "Failed to get existing Lambda configuration: %v", err)
 		// Try to update anyway with just the new variable
 		existingConfig = &lambda.GetFunctionConfigurationOutput{
 			Environment: &lambdatypes.EnvironmentResponse{
@@ -820,7 +836,7 @@ func (p *AWSProvider) setupSQSQueue(ctx context.Context, functionName string) (s
 		},
 	})
 	if err != nil {
-		logger.Printf("Warning: Failed to update Lambda environment with queue URL: %v", err)
+		logger.Warnf(ctx, "Failed to update Lambda environment with queue URL: %v", err)
 	}
 	
 	return queueURL, nil
@@ -871,7 +887,7 @@ func (p *AWSProvider) setupEventBridgeRule(ctx context.Context, functionName str
 		StatementId:  aws.String("eventbridge-ec2-invoke"),
 		Action:       aws.String("lambda:InvokeFunction"),
 		Principal:    aws.String("events.amazonaws.com"),
-		SourceArn:    aws.String(fmt.Sprintf("arn:aws:events:%s:%s:rule/%s", p.region, p.accountID, ruleName)),
+		SourceArn:    aws.String(fmt.Sprintf("arn:%s:events:%s:%s:rule/%s", partition(p.region), p.region, p.accountID, ruleName)),
 	})
 	if err != nil && !strings.Contains(err.Error(), "ResourceConflictException") {
 		return fmt.Errorf("failed to add Lambda permission for EventBridge: %w", err)
@@ -893,3 +909,99 @@ func (p *AWSProvider) setupEventBridgeRule(ctx context.Context, functionName str
 	
 	return nil
 }
+
+// scheduleRuleName is the EventBridge rule invoking the controller
+// periodically to apply cluster schedules
+const scheduleRuleName = "goman-cluster-schedule-rule"
+
+// scheduleRate is how often cluster schedules are checked, and so how late
+// a scheduled stop or start can be
+const scheduleRate = "rate(5 minutes)"
+
+// staleRuleName is the EventBridge rule invoking the controller
+// periodically to reconcile clusters stuck without a reconcile
+const staleRuleName = "goman-stale-reconcile-rule"
+
+// staleRate is how often clusters are checked for a stale status
+const staleRate = "rate(10 minutes)"
+
+// periodicRule is an EventBridge rule invoking the controller on a rate with
+// an action event
+type periodicRule struct {
+	name        string
+	rate        string
+	description string
+	action      string
+	statementID string // Of the Lambda permission letting the rule invoke
+}
+
+// periodicRules are the rules set up by Initialize and removed by Cleanup
+var periodicRules = []periodicRule{
+	{
+		name:        scheduleRuleName,
+		rate:        scheduleRate,
+		description: "Invoke the Goman controller to apply scheduled cluster stops and starts",
+		action:      ScheduleAction,
+		statementID: "eventbridge-schedule-invoke",
+	},
+	{
+		name:        staleRuleName,
+		rate:        staleRate,
+		description: "Invoke the Goman controller to reconcile clusters stuck without a reconcile",
+		action:      ReconcileStaleAction,
+		statementID: "eventbridge-stale-reconcile-invoke",
+	},
+}
+
+// setupPeriodicRule creates an EventBridge rule invoking the controller
+// Lambda at the rule's rate with an event of its action
+func (p *AWSProvider) setupPeriodicRule(ctx context.Context, functionName string, rule periodicRule) error {
+	eventClient := eventbridge.NewFromConfig(p.cfg)
+
+	_, err := eventClient.PutRule(ctx, &eventbridge.PutRuleInput{
+		Name:               aws.String(rule.name),
+		Description:        aws.String(rule.description),
+		ScheduleExpression: aws.String(rule.rate),
+		State:              eventbridgetypes.RuleStateEnabled,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create EventBridge rule: %w", err)
+	}
+
+	functionConfig, err := p.lambdaClient.GetFunction(ctx, &lambda.GetFunctionInput{
+		FunctionName: aws.String(functionName),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to get Lambda function: %w", err)
+	}
+
+	_, err = p.lambdaClient.AddPermission(ctx, &lambda.AddPermissionInput{
+		FunctionName: aws.String(functionName),
+		StatementId:  aws.String(rule.statementID),
+		Action:       aws.String("lambda:InvokeFunction"),
+		Principal:    aws.String("events.amazonaws.com"),
+		SourceArn:    aws.String(fmt.Sprintf("arn:%s:events:%s:%s:rule/%s", partition(p.region), p.region, p.accountID, rule.name)),
+	})
+	if err != nil && !strings.Contains(err.Error(), "ResourceConflictException") {
+		return fmt.Errorf("failed to add Lambda permission for the rule: %w", err)
+	}
+
+	input, err := json.Marshal(map[string]string{"action": rule.action})
+	if err != nil {
+		return err
+	}
+	_, err = eventClient.PutTargets(ctx, &eventbridge.PutTargetsInput{
+		Rule: aws.String(rule.name),
+		Targets: []eventbridgetypes.Target{
+			{
+				Id:    aws.String("1"),
+				Arn:   functionConfig.Configuration.FunctionArn,
+				Input: aws.String(string(input)),
+			},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to add Lambda target to the rule: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,97 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/madhouselabs/goman/pkg/logger"
+)
+
+// RenameClusterSecurityGroups moves a cluster's security groups to a new
+// cluster name. Group names cannot be changed in EC2, so the groups keep their
+// name and are re-tagged; the former name is recorded in goman-legacy-cluster.
+// The resources of its dedicated VPC and API load balancer, if it has them,
+// are re-tagged too.
+// Returns the IDs of the groups that were re-tagged.
+func (p *AWSProvider) RenameClusterSecurityGroups(ctx context.Context, region, oldName, newName string) ([]string, error) {
+	ec2Client := p.ec2Client
+	cs, ok := p.computeService.(*ComputeService)
+	if ok && region != "" {
+		ec2Client = cs.getEC2Client(region)
+	}
+
+	output, err := ec2Client.DescribeSecurityGroups(ctx, &ec2.DescribeSecurityGroupsInput{
+		Filters: []types.Filter{
+			{Name: aws.String("tag:Cluster"), Values: []string{oldName}},
+			{Name: aws.String("tag:ManagedBy"), Values: []string{"goman"}},
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe security groups: %w", wrapAWSError("ec2", "DescribeSecurityGroups", err))
+	}
+
+	if err := renameDedicatedVPC(ctx, ec2Client, oldName, newName); err != nil {
+		return nil, err
+	}
+	if ok {
+		if err := cs.renameAPILoadBalancer(ctx, ec2Client, region, oldName, newName); err != nil {
+			return nil, err
+		}
+	}
+
+	var groupIDs []string
+	for _, sg := range output.SecurityGroups {
+		groupIDs = append(groupIDs, aws.ToString(sg.GroupId))
+	}
+	if len(groupIDs) == 0 {
+		return nil, nil
+	}
+
+	_, err = ec2Client.CreateTags(ctx, &ec2.CreateTagsInput{
+		Resources: groupIDs,
+		Tags: []types.Tag{
+			{Key: aws.String("Cluster"), Value: aws.String(newName)},
+			{Key: aws.String("goman-legacy-cluster"), Value: aws.String(oldName)},
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to tag security groups: %w", wrapAWSError("ec2", "CreateTags", err))
+	}
+
+	logger.Infof(ctx, "Re-tagged security groups %v from cluster %s to %s", groupIDs, oldName, newName)
+	return groupIDs, nil
+}
+
+// renameDedicatedVPC moves the VPC, subnets, route tables and internet
+// gateway of a cluster's dedicated VPC to a new cluster name
+func renameDedicatedVPC(ctx context.Context, ec2Client *ec2.Client, oldName, newName string) error {
+	output, err := ec2Client.DescribeTags(ctx, &ec2.DescribeTagsInput{
+		Filters: []types.Filter{
+			{Name: aws.String("key"), Values: []string{dedicatedVPCTag}},
+			{Name: aws.String("value"), Values: []string{oldName}},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to describe dedicated VPC tags: %w", wrapAWSError("ec2", "DescribeTags", err))
+	}
+	var resourceIDs []string
+	for _, tag := range output.Tags {
+		resourceIDs = append(resourceIDs, aws.ToString(tag.ResourceId))
+	}
+	if len(resourceIDs) == 0 {
+		return nil
+	}
+
+	_, err = ec2Client.CreateTags(ctx, &ec2.CreateTagsInput{
+		Resources: resourceIDs,
+		Tags:      []types.Tag{{Key: aws.String(dedicatedVPCTag), Value: aws.String(newName)}},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to tag dedicated VPC resources: %w", wrapAWSError("ec2", "CreateTags", err))
+	}
+	logger.Infof(ctx, "Re-tagged dedicated VPC resources %v from cluster %s to %s", resourceIDs, oldName, newName)
+	return nil
+}
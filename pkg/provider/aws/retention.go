@@ -0,0 +1,86 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	s3types "github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/madhouselabs/goman/pkg/logger"
+	"github.com/madhouselabs/goman/pkg/provider"
+	"github.com/madhouselabs/goman/pkg/storage"
+	"gopkg.in/yaml.v3"
+)
+
+// lifecycleRulePrefix marks the lifecycle rules goman maintains on the state
+// bucket; rules added by others are kept as they are
+const lifecycleRulePrefix = "goman-"
+
+// ConfigureRetention stores the retention settings in the goman bucket and
+// sets lifecycle rules on it, so S3 expires old diagnostics and logs by
+// itself. Unused K3s binaries can't be told apart by a rule; the controller
+// prunes them.
+func (p *AWSProvider) ConfigureRetention(ctx context.Context, cfg provider.RetentionConfig) error {
+	data, err := yaml.Marshal(&cfg)
+	if err != nil {
+		return fmt.Errorf("failed to marshal retention settings: %w", err)
+	}
+	if err := p.storageService.PutObject(ctx, provider.RetentionKey, data); err != nil {
+		return fmt.Errorf("failed to save retention settings: %w", err)
+	}
+
+	bucket := stateBucketName(p.accountID)
+	var rules []s3types.LifecycleRule
+	existing, err := p.s3Client.GetBucketLifecycleConfiguration(ctx, &s3.GetBucketLifecycleConfigurationInput{
+		Bucket: aws.String(bucket),
+	})
+	switch {
+	case err == nil:
+		for _, rule := range existing.Rules {
+			if !strings.HasPrefix(aws.ToString(rule.ID), lifecycleRulePrefix) {
+				rules = append(rules, rule)
+			}
+		}
+	case hasErrorCode(err, "NoSuchLifecycleConfiguration"):
+	default:
+		return fmt.Errorf("failed to read lifecycle rules of %s: %w", bucket, err)
+	}
+
+	logPrefixes := []string{storage.CommandHistoryPrefix, p.SessionLogging(ctx).ClusterS3Prefix("") + "/"}
+	rules = append(rules, expirationRule("goman-diagnostics", provider.DiagnosticsPrefix, cfg.DiagnosticsDays)...)
+	for _, prefix := range logPrefixes {
+		rules = append(rules, expirationRule(lifecycleRulePrefix+strings.TrimSuffix(prefix, "/"), prefix, cfg.LogDays)...)
+	}
+
+	if len(rules) == 0 {
+		if _, err := p.s3Client.DeleteBucketLifecycle(ctx, &s3.DeleteBucketLifecycleInput{Bucket: aws.String(bucket)}); err != nil {
+			return fmt.Errorf("failed to remove lifecycle rules of %s: %w", bucket, err)
+		}
+	} else {
+		_, err := p.s3Client.PutBucketLifecycleConfiguration(ctx, &s3.PutBucketLifecycleConfigurationInput{
+			Bucket:                 aws.String(bucket),
+			LifecycleConfiguration: &s3types.BucketLifecycleConfiguration{Rules: rules},
+		})
+		if err != nil {
+			return fmt.Errorf("failed to set lifecycle rules of %s: %w", bucket, err)
+		}
+	}
+	logger.Infof(ctx, "Retention set to %s", cfg)
+	return nil
+}
+
+// expirationRule returns a lifecycle rule expiring the objects under prefix
+// after days, or none when they are kept forever
+func expirationRule(id, prefix string, days int) []s3types.LifecycleRule {
+	if days <= 0 {
+		return nil
+	}
+	return []s3types.LifecycleRule{{
+		ID:         aws.String(id),
+		Status:     s3types.ExpirationStatusEnabled,
+		Filter:     &s3types.LifecycleRuleFilter{Prefix: aws.String(prefix)},
+		Expiration: &s3types.LifecycleExpiration{Days: aws.Int32(int32(days))},
+	}}
+}
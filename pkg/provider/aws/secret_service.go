@@ -0,0 +1,262 @@
+package aws
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
+	smtypes "github.com/aws/aws-sdk-go-v2/service/secretsmanager/types"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+	ssmtypes "github.com/aws/aws-sdk-go-v2/service/ssm/types"
+	"github.com/madhouselabs/goman/pkg/config"
+	"github.com/madhouselabs/goman/pkg/provider"
+)
+
+// secretNamePrefix prefixes secret and parameter names of all backends
+// other than S3, e.g. goman/<cluster>/k3s-server-token
+const secretNamePrefix = "goman"
+
+// NewSecretService returns the secret service for the backend selected with
+// GOMAN_SECRET_BACKEND, see the provider.SecretBackend* constants
+func NewSecretService(cfg aws.Config, storage provider.StorageService) (provider.SecretService, error) {
+	switch backend := config.GetSecretBackend(); backend {
+	case "", provider.SecretBackendStorage:
+		return &StorageSecretService{storage: storage}, nil
+	case provider.SecretBackendSecretsManager:
+		return &SecretsManagerSecretService{client: secretsmanager.NewFromConfig(cfg)}, nil
+	case provider.SecretBackendParameterStore:
+		return &ParameterStoreSecretService{client: ssm.NewFromConfig(cfg)}, nil
+	default:
+		return nil, fmt.Errorf("unknown secret backend %q (use %s, %s or %s)", backend,
+			provider.SecretBackendStorage, provider.SecretBackendSecretsManager, provider.SecretBackendParameterStore)
+	}
+}
+
+// StorageSecretService keeps secrets in the state bucket under
+// clusters/<cluster>/, where they have always been
+type StorageSecretService struct {
+	storage provider.StorageService
+}
+
+func storageSecretKey(clusterName, name string) string {
+	return fmt.Sprintf("clusters/%s/%s", clusterName, name)
+}
+
+// PutSecret stores a secret
+func (s *StorageSecretService) PutSecret(ctx context.Context, clusterName, name string, value []byte) error {
+	return s.storage.PutObject(ctx, storageSecretKey(clusterName, name), value)
+}
+
+// GetSecret retrieves a secret
+func (s *StorageSecretService) GetSecret(ctx context.Context, clusterName, name string) ([]byte, error) {
+	data, err := s.storage.GetObject(ctx, storageSecretKey(clusterName, name))
+	if err != nil {
+		if errors.Is(err, provider.ErrNotFound) {
+			return nil, fmt.Errorf("%w: %s/%s", provider.ErrSecretNotFound, clusterName, name)
+		}
+		return nil, err
+	}
+	return data, nil
+}
+
+// DeleteSecret removes a secret
+func (s *StorageSecretService) DeleteSecret(ctx context.Context, clusterName, name string) error {
+	return s.storage.DeleteObject(ctx, storageSecretKey(clusterName, name))
+}
+
+// Backend returns the backend name
+func (s *StorageSecretService) Backend() string {
+	return provider.SecretBackendStorage
+}
+
+// ShareSecret returns a presigned URL for a secret and when it expires.
+// Only the S3 backend can share secrets; the others have no credential-free
+// download.
+func (s *StorageSecretService) ShareSecret(ctx context.Context, clusterName, name string, ttl time.Duration) (string, time.Time, error) {
+	presigner, ok := s.storage.(interface {
+		PresignGetObject(ctx context.Context, key string, ttl time.Duration) (string, time.Time, error)
+	})
+	if !ok {
+		return "", time.Time{}, fmt.Errorf("storage backend does not support presigned URLs")
+	}
+	url, expiresAt, err := presigner.PresignGetObject(ctx, storageSecretKey(clusterName, name), ttl)
+	if err != nil {
+		if errors.Is(err, provider.ErrNotFound) {
+			return "", time.Time{}, fmt.Errorf("%w: %s/%s", provider.ErrSecretNotFound, clusterName, name)
+		}
+		return "", time.Time{}, err
+	}
+	return url, expiresAt, nil
+}
+
+// SecretsManagerSecretService keeps secrets in AWS Secrets Manager as
+// goman/<cluster>/<name>
+type SecretsManagerSecretService struct {
+	client *secretsmanager.Client
+}
+
+func managedSecretName(clusterName, name string) string {
+	return fmt.Sprintf("%s/%s/%s", secretNamePrefix, clusterName, name)
+}
+
+// PutSecret stores a secret, creating it on first use
+func (s *SecretsManagerSecretService) PutSecret(ctx context.Context, clusterName, name string, value []byte) error {
+	secretID := managedSecretName(clusterName, name)
+	_, err := s.client.PutSecretValue(ctx, &secretsmanager.PutSecretValueInput{
+		SecretId:     aws.String(secretID),
+		SecretString: aws.String(string(value)),
+	})
+	var notFound *smtypes.ResourceNotFoundException
+	if errors.As(err, &notFound) {
+		_, err = s.client.CreateSecret(ctx, &secretsmanager.CreateSecretInput{
+			Name:         aws.String(secretID),
+			SecretString: aws.String(string(value)),
+			Description:  aws.String(fmt.Sprintf("goman cluster %s: %s", clusterName, name)),
+			Tags: []smtypes.Tag{
+				{Key: aws.String("ManagedBy"), Value: aws.String("goman")},
+				{Key: aws.String("goman-cluster"), Value: aws.String(clusterName)},
+			},
+		})
+		if err != nil {
+			return fmt.Errorf("failed to create secret %s: %w", secretID, wrapAWSError("secretsmanager", "CreateSecret", err))
+		}
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to put secret %s: %w", secretID, wrapAWSError("secretsmanager", "PutSecretValue", err))
+	}
+	return nil
+}
+
+// GetSecret retrieves a secret
+func (s *SecretsManagerSecretService) GetSecret(ctx context.Context, clusterName, name string) ([]byte, error) {
+	secretID := managedSecretName(clusterName, name)
+	result, err := s.client.GetSecretValue(ctx, &secretsmanager.GetSecretValueInput{
+		SecretId: aws.String(secretID),
+	})
+	if err != nil {
+		var notFound *smtypes.ResourceNotFoundException
+		if errors.As(err, &notFound) {
+			return nil, fmt.Errorf("%w: %s", provider.ErrSecretNotFound, secretID)
+		}
+		return nil, fmt.Errorf("failed to get secret %s: %w", secretID, wrapAWSError("secretsmanager", "GetSecretValue", err))
+	}
+	if result.SecretString != nil {
+		return []byte(aws.ToString(result.SecretString)), nil
+	}
+	return result.SecretBinary, nil
+}
+
+// DeleteSecret removes a secret immediately, without the recovery window,
+// so a new cluster with the same name can create it again
+func (s *SecretsManagerSecretService) DeleteSecret(ctx context.Context, clusterName, name string) error {
+	secretID := managedSecretName(clusterName, name)
+	_, err := s.client.DeleteSecret(ctx, &secretsmanager.DeleteSecretInput{
+		SecretId:                   aws.String(secretID),
+		ForceDeleteWithoutRecovery: aws.Bool(true),
+	})
+	var notFound *smtypes.ResourceNotFoundException
+	if err != nil && !errors.As(err, &notFound) {
+		return fmt.Errorf("failed to delete secret %s: %w", secretID, wrapAWSError("secretsmanager", "DeleteSecret", err))
+	}
+	return nil
+}
+
+// Backend returns the backend name
+func (s *SecretsManagerSecretService) Backend() string {
+	return provider.SecretBackendSecretsManager
+}
+
+// ParameterStoreSecretService keeps secrets in SSM Parameter Store as
+// SecureString parameters named /goman/<cluster>/<name>
+type ParameterStoreSecretService struct {
+	client *ssm.Client
+}
+
+func secretParameterName(clusterName, name string) string {
+	return fmt.Sprintf("/%s/%s/%s", secretNamePrefix, clusterName, name)
+}
+
+// PutSecret stores a secret. Intelligent-Tiering moves kubeconfigs over the
+// 4 KB standard parameter limit to the advanced tier.
+func (s *ParameterStoreSecretService) PutSecret(ctx context.Context, clusterName, name string, value []byte) error {
+	paramName := secretParameterName(clusterName, name)
+	_, err := s.client.PutParameter(ctx, &ssm.PutParameterInput{
+		Name:      aws.String(paramName),
+		Value:     aws.String(string(value)),
+		Type:      ssmtypes.ParameterTypeSecureString,
+		Tier:      ssmtypes.ParameterTierIntelligentTiering,
+		Overwrite: aws.Bool(true),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to put parameter %s: %w", paramName, wrapAWSError("ssm", "PutParameter", err))
+	}
+	return nil
+}
+
+// GetSecret retrieves and decrypts a secret
+func (s *ParameterStoreSecretService) GetSecret(ctx context.Context, clusterName, name string) ([]byte, error) {
+	paramName := secretParameterName(clusterName, name)
+	result, err := s.client.GetParameter(ctx, &ssm.GetParameterInput{
+		Name:           aws.String(paramName),
+		WithDecryption: aws.Bool(true),
+	})
+	if err != nil {
+		var notFound *ssmtypes.ParameterNotFound
+		if errors.As(err, &notFound) {
+			return nil, fmt.Errorf("%w: %s", provider.ErrSecretNotFound, paramName)
+		}
+		return nil, fmt.Errorf("failed to get parameter %s: %w", paramName, wrapAWSError("ssm", "GetParameter", err))
+	}
+	return []byte(aws.ToString(result.Parameter.Value)), nil
+}
+
+// DeleteSecret removes a secret
+func (s *ParameterStoreSecretService) DeleteSecret(ctx context.Context, clusterName, name string) error {
+	paramName := secretParameterName(clusterName, name)
+	_, err := s.client.DeleteParameter(ctx, &ssm.DeleteParameterInput{
+		Name: aws.String(paramName),
+	})
+	var notFound *ssmtypes.ParameterNotFound
+	if err != nil && !errors.As(err, &notFound) {
+		return fmt.Errorf("failed to delete parameter %s: %w", paramName, wrapAWSError("ssm", "DeleteParameter", err))
+	}
+	return nil
+}
+
+// Backend returns the backend name
+func (s *ParameterStoreSecretService) Backend() string {
+	return provider.SecretBackendParameterStore
+}
+
+// secretShellFunctions defines get_secret and put_secret for node scripts.
+// They read SECRET_BACKEND, SECRET_REGION, S3_BUCKET and CLUSTER_NAME:
+//
+//	TOKEN=$(get_secret k3s-server-token)
+//	put_secret kubeconfig.yaml /tmp/kubeconfig.yaml
+const secretShellFunctions = `
+get_secret() {
+    case "$SECRET_BACKEND" in
+        secretsmanager)
+            aws secretsmanager get-secret-value --region "$SECRET_REGION" --secret-id "goman/$CLUSTER_NAME/$1" --query SecretString --output text ;;
+        ssm)
+            aws ssm get-parameter --region "$SECRET_REGION" --name "/goman/$CLUSTER_NAME/$1" --with-decryption --query Parameter.Value --output text ;;
+        *)
+            aws s3 cp "s3://$S3_BUCKET/clusters/$CLUSTER_NAME/$1" - ;;
+    esac
+}
+put_secret() {
+    case "$SECRET_BACKEND" in
+        secretsmanager)
+            aws secretsmanager put-secret-value --region "$SECRET_REGION" --secret-id "goman/$CLUSTER_NAME/$1" --secret-string "file://$2" >/dev/null ||
+                aws secretsmanager create-secret --region "$SECRET_REGION" --name "goman/$CLUSTER_NAME/$1" --secret-string "file://$2" >/dev/null ;;
+        ssm)
+            aws ssm put-parameter --region "$SECRET_REGION" --name "/goman/$CLUSTER_NAME/$1" --type SecureString --tier Intelligent-Tiering --overwrite --value "file://$2" >/dev/null ;;
+        *)
+            aws s3 cp "$2" "s3://$S3_BUCKET/clusters/$CLUSTER_NAME/$1" ;;
+    esac
+}
+`
@@ -0,0 +1,131 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/madhouselabs/goman/pkg/logger"
+	"github.com/madhouselabs/goman/pkg/provider"
+	"gopkg.in/yaml.v3"
+)
+
+// sessionLoggingRefresh is how long loaded session logging settings are
+// reused; warm Lambda containers pick up changes after this
+const sessionLoggingRefresh = 5 * time.Minute
+
+// ConfigureSessionLogging stores the session logging settings in the goman
+// bucket and grants instances the permissions to write to the destinations
+func (p *AWSProvider) ConfigureSessionLogging(ctx context.Context, cfg provider.SessionLoggingConfig) error {
+	data, err := yaml.Marshal(&cfg)
+	if err != nil {
+		return fmt.Errorf("failed to marshal session logging settings: %w", err)
+	}
+	if err := p.storageService.PutObject(ctx, provider.SessionLoggingKey, data); err != nil {
+		return fmt.Errorf("failed to save session logging settings: %w", err)
+	}
+
+	cs, ok := p.computeService.(*ComputeService)
+	if !ok {
+		return nil
+	}
+	cs.setSessionLogging(cfg)
+	if err := cs.ensureCommandOutputPolicy(ctx); err != nil {
+		return fmt.Errorf("failed to update instance role for session logging: %w", err)
+	}
+	logger.Infof(ctx, "Session logging set to %s", cfg)
+	return nil
+}
+
+// SessionLogging returns the stored session logging settings
+func (p *AWSProvider) SessionLogging(ctx context.Context) provider.SessionLoggingConfig {
+	if cs, ok := p.computeService.(*ComputeService); ok {
+		return cs.sessionLogging(ctx)
+	}
+	return provider.SessionLoggingConfig{}
+}
+
+// sessionLogging returns the session logging settings, loading them from the
+// goman bucket at most every sessionLoggingRefresh. Missing or unreadable
+// settings mean logging is off.
+func (s *ComputeService) sessionLogging(ctx context.Context) provider.SessionLoggingConfig {
+	s.sessionLoggingMu.Lock()
+	defer s.sessionLoggingMu.Unlock()
+
+	if time.Since(s.sessionLoggingLoaded) < sessionLoggingRefresh {
+		return s.sessionLoggingCfg
+	}
+
+	var cfg provider.SessionLoggingConfig
+	obj, err := s.s3Client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(stateBucketName(s.accountID)),
+		Key:    aws.String(provider.SessionLoggingKey),
+	})
+	if err == nil {
+		data, readErr := io.ReadAll(obj.Body)
+		obj.Body.Close()
+		if readErr == nil {
+			if err := yaml.Unmarshal(data, &cfg); err != nil {
+				logger.Warnf(ctx, "Ignoring invalid session logging settings: %v", err)
+				cfg = provider.SessionLoggingConfig{}
+			}
+		}
+	}
+
+	s.sessionLoggingCfg = cfg
+	s.sessionLoggingLoaded = time.Now()
+	return cfg
+}
+
+// setSessionLogging replaces the cached settings after they were written
+func (s *ComputeService) setSessionLogging(cfg provider.SessionLoggingConfig) {
+	s.sessionLoggingMu.Lock()
+	defer s.sessionLoggingMu.Unlock()
+	s.sessionLoggingCfg = cfg
+	s.sessionLoggingLoaded = time.Now()
+}
+
+// instanceCluster returns the goman cluster an instance belongs to, or ""
+// if it cannot be determined. Results are cached; tags don't change for
+// the lifetime of a command.
+func (s *ComputeService) instanceCluster(ctx context.Context, instanceID string) string {
+	if instanceID == "" {
+		return ""
+	}
+
+	s.sessionLoggingMu.Lock()
+	if name, ok := s.instanceClusters[instanceID]; ok {
+		s.sessionLoggingMu.Unlock()
+		return name
+	}
+	s.sessionLoggingMu.Unlock()
+
+	clients := []*ec2.Client{s.client}
+	for _, client := range s.cachedRegionClients() {
+		clients = append(clients, client)
+	}
+
+	for _, client := range clients {
+		result, err := client.DescribeInstances(ctx, &ec2.DescribeInstancesInput{
+			InstanceIds: []string{instanceID},
+		})
+		if err != nil || len(result.Reservations) == 0 || len(result.Reservations[0].Instances) == 0 {
+			continue
+		}
+		for _, tag := range result.Reservations[0].Instances[0].Tags {
+			if aws.ToString(tag.Key) == "goman-cluster" {
+				name := aws.ToString(tag.Value)
+				s.sessionLoggingMu.Lock()
+				s.instanceClusters[instanceID] = name
+				s.sessionLoggingMu.Unlock()
+				return name
+			}
+		}
+		return ""
+	}
+	return ""
+}
@@ -0,0 +1,488 @@
+package aws
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+	ssmtypes "github.com/aws/aws-sdk-go-v2/service/ssm/types"
+	"github.com/madhouselabs/goman/pkg/config"
+	"github.com/madhouselabs/goman/pkg/logger"
+	"github.com/madhouselabs/goman/pkg/provider"
+)
+
+// SSMDocumentsVersion is bumped whenever the managed documents change shape.
+// The content hash is appended so edits without a bump still roll out.
+const SSMDocumentsVersion = "5"
+
+// ssmDocumentPrefix prefixes all goman-managed SSM documents
+const ssmDocumentPrefix = "goman-"
+
+// ssmDocumentParameter describes a single document parameter
+type ssmDocumentParameter struct {
+	Type        string `json:"type"`
+	Description string `json:"description"`
+	Default     string `json:"default,omitempty"`
+}
+
+// ssmDocumentDefinition describes a managed command document
+type ssmDocumentDefinition struct {
+	Operation   string
+	Description string
+	Parameters  map[string]ssmDocumentParameter
+	Script      string
+}
+
+// managedSSMDocuments are the documents deployed by `goman init`
+var managedSSMDocuments = []ssmDocumentDefinition{
+	{
+		Operation:   provider.OperationDrainNode,
+		Description: "Drain a node and remove it from the K3s cluster",
+		Parameters: map[string]ssmDocumentParameter{
+			"NodeName": {Type: "String", Description: "Kubernetes node name"},
+			"Timeout":  {Type: "String", Description: "Drain timeout", Default: "60s"},
+			"Delete":   {Type: "String", Description: "Delete the node object after draining (true/false)", Default: "true"},
+		},
+		Script: `
+kubectl drain {{ NodeName }} --ignore-daemonsets --delete-emptydir-data --force --timeout={{ Timeout }} || true
+if [ "{{ Delete }}" = "true" ]; then
+    kubectl delete node {{ NodeName }} --ignore-not-found
+fi
+`,
+	},
+	{
+		Operation:   provider.OperationConfigureDNS,
+		Description: "Apply goman's cluster DNS configuration to a node and, on one server, to CoreDNS",
+		Parameters: map[string]ssmDocumentParameter{
+			"Upstreams":      {Type: "String", Description: "Space-separated upstream resolver IPs (empty for the VPC resolver)", Default: ""},
+			"ClusterConfig":  {Type: "String", Description: "Also apply the kube-system DNS resources (true/false)", Default: "false"},
+			"CorednsCustom":  {Type: "String", Description: "Base64 coredns-custom ConfigMap (empty to remove it)", Default: ""},
+			"NodeLocalCache": {Type: "String", Description: "Run NodeLocal DNSCache (true/false)", Default: "false"},
+		},
+		Script: provider.ConfigureDNSScript,
+	},
+	{
+		Operation:   provider.OperationConfigureVIP,
+		Description: "Serve the cluster's virtual IP from a K3s server with kube-vip",
+		Parameters: map[string]ssmDocumentParameter{
+			"Address": {Type: "String", Description: "Virtual IP of the API endpoint", Default: ""},
+			"Enabled": {Type: "String", Description: "Run kube-vip for Address (true/false)", Default: "true"},
+		},
+		Script: ssmScriptConfigureVIP,
+	},
+	{
+		Operation:   provider.OperationAddTLSSAN,
+		Description: "Add the API load balancer's DNS name to the API server certificate of a K3s server",
+		Parameters: map[string]ssmDocumentParameter{
+			"Host": {Type: "String", Description: "DNS name clients use for the API server (empty to stop adding one)", Default: ""},
+		},
+		Script: ssmScriptAddTLSSAN,
+	},
+	{
+		Operation:   provider.OperationUpgradeK3s,
+		Description: "Replace the K3s binary of a drained node and restart K3s",
+		Parameters: map[string]ssmDocumentParameter{
+			"S3Bucket":      {Type: "String", Description: "goman state bucket"},
+			"SecretBackend": {Type: "String", Description: "Where tokens and kubeconfigs are stored (s3, secretsmanager, ssm)", Default: "s3"},
+			"SecretRegion":  {Type: "String", Description: "Region of the secret backend", Default: ""},
+			"K3sVersion":    {Type: "String", Description: "K3s version to upgrade to"},
+		},
+		Script: ssmScriptUpgradeK3s,
+	},
+	{
+		Operation:   provider.OperationRotateToken,
+		Description: "Rotate the K3s token of a goman cluster to the one stored for the rotation",
+		Parameters: map[string]ssmDocumentParameter{
+			"ClusterName":   {Type: "String", Description: "goman cluster name"},
+			"S3Bucket":      {Type: "String", Description: "goman state bucket"},
+			"SecretBackend": {Type: "String", Description: "Where tokens and kubeconfigs are stored (s3, secretsmanager, ssm)", Default: "s3"},
+			"SecretRegion":  {Type: "String", Description: "Region of the secret backend", Default: ""},
+		},
+		Script: ssmScriptSecrets + provider.RotateTokenScript,
+	},
+	{
+		Operation:   provider.OperationUpdateToken,
+		Description: "Restart the K3s server or agent of a node with the cluster's current token",
+		Parameters: map[string]ssmDocumentParameter{
+			"ClusterName":   {Type: "String", Description: "goman cluster name"},
+			"S3Bucket":      {Type: "String", Description: "goman state bucket"},
+			"SecretBackend": {Type: "String", Description: "Where tokens and kubeconfigs are stored (s3, secretsmanager, ssm)", Default: "s3"},
+			"SecretRegion":  {Type: "String", Description: "Region of the secret backend", Default: ""},
+		},
+		Script: ssmScriptSecrets + provider.UpdateTokenScript,
+	},
+}
+
+// ssmScriptUpgradeK3s swaps in the K3s binary of the requested version and
+// restarts the server or agent unit. The download goes next to the binary
+// first so a failed copy leaves the running version in place.
+const ssmScriptUpgradeK3s = `
+set -e
+ARCH=$(uname -m)
+if [ "$ARCH" = "x86_64" ]; then ARCH="amd64"; elif [ "$ARCH" = "aarch64" ]; then ARCH="arm64"; fi
+if systemctl is-enabled --quiet k3s 2>/dev/null; then UNIT=k3s; else UNIT=k3s-agent; fi
+CURRENT=$(/usr/local/bin/k3s --version 2>/dev/null | head -n 1 | awk '{print $3}')
+if [ "$CURRENT" = "{{ K3sVersion }}" ]; then
+    echo "K3s is already at {{ K3sVersion }}"
+else
+    aws s3 cp s3://{{ S3Bucket }}/binaries/k3s/{{ K3sVersion }}/k3s-$ARCH /usr/local/bin/k3s.new
+    chmod +x /usr/local/bin/k3s.new
+    mv /usr/local/bin/k3s.new /usr/local/bin/k3s
+    systemctl restart $UNIT
+fi
+for i in $(seq 1 60); do systemctl is-active --quiet $UNIT && break; sleep 5; done
+systemctl is-active --quiet $UNIT
+echo "$UNIT is running K3s $(/usr/local/bin/k3s --version | head -n 1 | awk '{print $3}')"
+`
+
+// ssmScriptConfigureVIP adds the virtual IP to the API server certificate,
+// with a K3s restart when it changes, and runs kube-vip as a static pod to
+// elect the master holding it. kube-vip only configures the IP on the host;
+// goman-vip.service moves it to the elected master's network interface with
+// the EC2 API, since the VPC ignores gratuitous ARP.
+const ssmScriptConfigureVIP = `
+set -e
+if ! systemctl is-enabled --quiet k3s 2>/dev/null; then
+    echo "K3s server is not installed yet"
+    exit 1
+fi
+VIP="{{ Address }}"
+MANIFEST=/var/lib/rancher/k3s/agent/pod-manifests/goman-kube-vip.yaml
+
+if [ "{{ Enabled }}" != "true" ]; then
+    systemctl disable --now goman-vip.service 2>/dev/null || true
+    rm -f $MANIFEST /etc/systemd/system/goman-vip.service /usr/local/bin/goman-vip.sh
+    systemctl daemon-reload
+    # The certificate keeps the address; dropping it would need another restart
+    exit 0
+fi
+
+SAN=/etc/rancher/k3s/config.yaml.d/goman-vip.yaml
+mkdir -p $(dirname $SAN)
+printf 'tls-san:\n  - %s\n' "$VIP" > $SAN.new
+if ! cmp -s $SAN.new $SAN; then
+    mv $SAN.new $SAN
+    echo "Restarting k3s to add $VIP to the API server certificate"
+    systemctl restart k3s
+fi
+rm -f $SAN.new
+for i in $(seq 1 60); do kubectl get nodes >/dev/null 2>&1 && break; sleep 5; done
+
+IFACE=$(ip route show default | awk '{print $5; exit}')
+mkdir -p $(dirname $MANIFEST)
+cat <<'MANIFEST' | sed -e "s/__VIP__/$VIP/g" -e "s/__IFACE__/$IFACE/g" > $MANIFEST
+` + kubeVIPManifest + `MANIFEST
+
+REGION=$(curl -s http://169.254.169.254/latest/meta-data/placement/region)
+cat > /usr/local/bin/goman-vip.sh <<SCRIPT
+#!/bin/bash
+# Moves $VIP to this node's network interface while kube-vip elects it
+export KUBECONFIG=/etc/rancher/k3s/k3s.yaml
+NODE=\$(hostname)
+MAC=\$(curl -s http://169.254.169.254/latest/meta-data/mac)
+ENI=\$(curl -s http://169.254.169.254/latest/meta-data/network/interfaces/macs/\$MAC/interface-id)
+while true; do
+    HOLDER=\$(kubectl -n kube-system get lease plndr-cp-lock -o jsonpath='{.spec.holderIdentity}' 2>/dev/null)
+    if [ "\$HOLDER" = "\$NODE" ] && ! curl -s http://169.254.169.254/latest/meta-data/network/interfaces/macs/\$MAC/local-ipv4s | grep -qx "$VIP"; then
+        aws ec2 assign-private-ip-addresses --region $REGION --network-interface-id \$ENI --private-ip-addresses $VIP --allow-reassignment && echo "Took over $VIP"
+    fi
+    sleep 2
+done
+SCRIPT
+chmod +x /usr/local/bin/goman-vip.sh
+cat > /etc/systemd/system/goman-vip.service <<'UNIT'
+[Unit]
+Description=goman virtual IP failover
+After=k3s.service
+
+[Service]
+ExecStart=/usr/local/bin/goman-vip.sh
+Restart=always
+RestartSec=5s
+
+[Install]
+WantedBy=multi-user.target
+UNIT
+systemctl daemon-reload
+systemctl enable goman-vip.service
+systemctl restart goman-vip.service
+echo "Serving virtual IP $VIP on $IFACE"
+`
+
+// ssmScriptAddTLSSAN adds a name to the API server certificate, restarting
+// K3s when it changes. The list is appended to the SANs of the unit and of
+// the virtual IP.
+const ssmScriptAddTLSSAN = `
+set -e
+if ! systemctl is-enabled --quiet k3s 2>/dev/null; then
+    echo "K3s server is not installed yet"
+    exit 1
+fi
+HOST="{{ Host }}"
+SAN=/etc/rancher/k3s/config.yaml.d/goman-lb.yaml
+if [ -z "$HOST" ]; then
+    # The certificate keeps the name; dropping it would need another restart
+    rm -f $SAN
+    exit 0
+fi
+mkdir -p $(dirname $SAN)
+printf 'tls-san+:\n  - %s\n' "$HOST" > $SAN.new
+if ! cmp -s $SAN.new $SAN; then
+    mv $SAN.new $SAN
+    echo "Restarting k3s to add $HOST to the API server certificate"
+    systemctl restart k3s
+fi
+rm -f $SAN.new
+for i in $(seq 1 60); do kubectl get nodes >/dev/null 2>&1 && break; sleep 5; done
+kubectl get nodes >/dev/null
+echo "API server certificate includes $HOST"
+`
+
+// kubeVIPManifest runs kube-vip in control plane mode on a K3s server; the
+// elected master adds the virtual IP to its interface
+const kubeVIPManifest = `apiVersion: v1
+kind: Pod
+metadata:
+  name: kube-vip
+  namespace: kube-system
+  labels:
+    app.kubernetes.io/name: goman-kube-vip
+spec:
+  hostNetwork: true
+  containers:
+  - name: kube-vip
+    image: ghcr.io/kube-vip/kube-vip:v0.8.9
+    args: ["manager"]
+    env:
+    - name: address
+      value: "__VIP__"
+    - name: port
+      value: "6443"
+    - name: vip_interface
+      value: "__IFACE__"
+    - name: vip_cidr
+      value: "32"
+    - name: vip_arp
+      value: "true"
+    - name: cp_enable
+      value: "true"
+    - name: cp_namespace
+      value: kube-system
+    - name: vip_leaderelection
+      value: "true"
+    - name: vip_leaseduration
+      value: "5"
+    - name: vip_renewdeadline
+      value: "3"
+    - name: vip_retryperiod
+      value: "1"
+    - name: vip_nodename
+      valueFrom:
+        fieldRef:
+          fieldPath: spec.nodeName
+    securityContext:
+      capabilities:
+        add: ["NET_ADMIN", "NET_RAW"]
+    volumeMounts:
+    - name: kubeconfig
+      mountPath: /etc/kubernetes/admin.conf
+      readOnly: true
+  volumes:
+  - name: kubeconfig
+    hostPath:
+      path: /etc/rancher/k3s/k3s.yaml
+`
+
+// ssmScriptSecrets sets up get_secret and put_secret from document parameters
+const ssmScriptSecrets = `
+SECRET_BACKEND="{{ SecretBackend }}"
+SECRET_REGION="{{ SecretRegion }}"
+S3_BUCKET="{{ S3Bucket }}"
+CLUSTER_NAME="{{ ClusterName }}"
+` + secretShellFunctions
+
+// ssmDocumentName returns the managed document name for an operation
+func ssmDocumentName(operation string) string {
+	return ssmDocumentPrefix + operation
+}
+
+// content renders the document as SSM schema 2.2 JSON
+func (d ssmDocumentDefinition) content() (string, error) {
+	doc := map[string]interface{}{
+		"schemaVersion": "2.2",
+		"description":   d.Description,
+		"parameters":    d.Parameters,
+		"mainSteps": []map[string]interface{}{
+			{
+				"action": "aws:runShellScript",
+				"name":   strings.ReplaceAll(d.Operation, "-", ""),
+				"inputs": map[string]interface{}{
+					"runCommand": strings.Split(strings.TrimSpace(d.Script), "\n"),
+				},
+			},
+		},
+	}
+	data, err := json.Marshal(doc)
+	if err != nil {
+		return "", fmt.Errorf("failed to render SSM document %s: %w", d.Operation, err)
+	}
+	return string(data), nil
+}
+
+// versionName returns a version label that changes with the document content
+func (d ssmDocumentDefinition) versionName(content string) string {
+	sum := sha256.Sum256([]byte(content))
+	return fmt.Sprintf("v%s-%s", SSMDocumentsVersion, hex.EncodeToString(sum[:])[:12])
+}
+
+// EnsureDocuments creates or updates all managed SSM documents in a region
+func (s *ComputeService) EnsureDocuments(ctx context.Context, region string) error {
+	ssmClient := s.getSSMClient(region)
+
+	for _, def := range managedSSMDocuments {
+		if err := s.ensureDocument(ctx, ssmClient, def); err != nil {
+			return err
+		}
+	}
+
+	s.documentsMu.Lock()
+	s.documentsReady[region] = true
+	s.documentsMu.Unlock()
+	return nil
+}
+
+// ensureDocument creates a single document or rolls it forward to the current version
+func (s *ComputeService) ensureDocument(ctx context.Context, ssmClient *ssm.Client, def ssmDocumentDefinition) error {
+	name := ssmDocumentName(def.Operation)
+	content, err := def.content()
+	if err != nil {
+		return err
+	}
+	version := def.versionName(content)
+
+	existing, err := ssmClient.DescribeDocument(ctx, &ssm.DescribeDocumentInput{
+		Name: aws.String(name),
+	})
+	if err != nil {
+		if !strings.Contains(err.Error(), "InvalidDocument") {
+			return fmt.Errorf("failed to describe SSM document %s: %w", name, err)
+		}
+
+		_, err = ssmClient.CreateDocument(ctx, &ssm.CreateDocumentInput{
+			Name:           aws.String(name),
+			Content:        aws.String(content),
+			DocumentType:   ssmtypes.DocumentTypeCommand,
+			DocumentFormat: ssmtypes.DocumentFormatJson,
+			VersionName:    aws.String(version),
+			Tags: []ssmtypes.Tag{
+				{Key: aws.String("ManagedBy"), Value: aws.String("goman")},
+			},
+		})
+		if err != nil {
+			return fmt.Errorf("failed to create SSM document %s: %w", name, err)
+		}
+		logger.Infof(ctx, "Created SSM document %s (%s)", name, version)
+		return nil
+	}
+
+	if existing.Document != nil && aws.ToString(existing.Document.VersionName) == version {
+		return nil
+	}
+
+	updated, err := ssmClient.UpdateDocument(ctx, &ssm.UpdateDocumentInput{
+		Name:            aws.String(name),
+		Content:         aws.String(content),
+		DocumentFormat:  ssmtypes.DocumentFormatJson,
+		DocumentVersion: aws.String("$LATEST"),
+		VersionName:     aws.String(version),
+	})
+	if err != nil {
+		if strings.Contains(err.Error(), "DuplicateDocumentContent") || strings.Contains(err.Error(), "DuplicateDocumentVersionName") {
+			return nil
+		}
+		return fmt.Errorf("failed to update SSM document %s: %w", name, err)
+	}
+
+	_, err = ssmClient.UpdateDocumentDefaultVersion(ctx, &ssm.UpdateDocumentDefaultVersionInput{
+		Name:            aws.String(name),
+		DocumentVersion: updated.DocumentDescription.DocumentVersion,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to set default version of SSM document %s: %w", name, err)
+	}
+
+	logger.Infof(ctx, "Updated SSM document %s to %s", name, version)
+	return nil
+}
+
+// DeleteDocuments removes all managed SSM documents from a region
+func (s *ComputeService) DeleteDocuments(ctx context.Context, region string) error {
+	ssmClient := s.getSSMClient(region)
+
+	var errs []string
+	for _, def := range managedSSMDocuments {
+		name := ssmDocumentName(def.Operation)
+		_, err := ssmClient.DeleteDocument(ctx, &ssm.DeleteDocumentInput{
+			Name: aws.String(name),
+		})
+		if err != nil && !strings.Contains(err.Error(), "InvalidDocument") {
+			errs = append(errs, fmt.Sprintf("%s: %v", name, err))
+		}
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("failed to delete SSM documents: %s", strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// RunOperation runs a managed SSM document on instances and waits for the result
+func (s *ComputeService) RunOperation(ctx context.Context, instanceIDs []string, operation string, params map[string]string) (*provider.CommandResult, error) {
+	if len(instanceIDs) == 0 {
+		return nil, fmt.Errorf("no instance IDs provided")
+	}
+
+	region := s.detectInstanceRegion(ctx, instanceIDs[0])
+	if region == "" {
+		region = s.config.Region
+	}
+
+	// Documents are regional - make sure the current version exists where the instance runs
+	s.documentsMu.Lock()
+	ready := s.documentsReady[region]
+	s.documentsMu.Unlock()
+	if !ready {
+		if err := s.EnsureDocuments(ctx, region); err != nil {
+			return nil, fmt.Errorf("failed to ensure SSM documents in %s: %w", region, err)
+		}
+	}
+
+	// Upgrades fetch the binary of the version they upgrade to
+	if version := params["K3sVersion"]; version != "" {
+		if err := s.ensureK3sBinaries(ctx, version); err != nil {
+			return nil, err
+		}
+	}
+
+	parameters := make(map[string][]string, len(params))
+	for k, v := range params {
+		parameters[k] = []string{v}
+	}
+	// Tokens and kubeconfigs live in the configured secret backend
+	if _, ok := parameters["S3Bucket"]; !ok {
+		parameters["S3Bucket"] = []string{stateBucketName(s.accountID)}
+	}
+	if _, ok := parameters["SecretBackend"]; !ok {
+		parameters["SecretBackend"] = []string{config.GetSecretBackend()}
+	}
+	if _, ok := parameters["SecretRegion"]; !ok {
+		parameters["SecretRegion"] = []string{s.config.Region}
+	}
+
+	return s.runDocument(ctx, s.getSSMClient(region), instanceIDs, ssmDocumentName(operation), parameters)
+}
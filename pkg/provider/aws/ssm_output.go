@@ -0,0 +1,232 @@
+package aws
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/iam"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+	ssmtypes "github.com/aws/aws-sdk-go-v2/service/ssm/types"
+	"github.com/madhouselabs/goman/pkg/logger"
+	"github.com/madhouselabs/goman/pkg/provider"
+)
+
+// ssmInlineOutputLimit is the maximum stdout/stderr size GetCommandInvocation
+// returns inline. Anything longer is truncated and must be read from S3.
+const ssmInlineOutputLimit = 24000
+
+// defaultSSMOutputKeyPrefix is where SSM writes full command output in the goman bucket
+const defaultSSMOutputKeyPrefix = "ssm-output"
+
+// commandOutputPolicyName is the inline role policy allowing instances to upload command output
+const commandOutputPolicyName = "goman-ssm-command-output"
+
+// commandOutputLocation returns the bucket and key prefix for SSM command output
+// on an instance. GOMAN_SSM_OUTPUT_BUCKET and GOMAN_SSM_OUTPUT_PREFIX override
+// the defaults; setting GOMAN_SSM_OUTPUT_BUCKET=none disables S3 output unless
+// S3 session logging is enabled, which also moves output under a per-cluster prefix.
+func (s *ComputeService) commandOutputLocation(ctx context.Context, instanceID string) (string, string) {
+	bucket := stateBucketName(s.accountID)
+	if b := os.Getenv("GOMAN_SSM_OUTPUT_BUCKET"); b != "" && b != "none" {
+		bucket = b
+	}
+
+	if logging := s.sessionLogging(ctx); logging.S3 {
+		return bucket, logging.ClusterS3Prefix(s.instanceCluster(ctx, instanceID))
+	}
+	if os.Getenv("GOMAN_SSM_OUTPUT_BUCKET") == "none" {
+		return "", ""
+	}
+
+	prefix := defaultSSMOutputKeyPrefix
+	if p := os.Getenv("GOMAN_SSM_OUTPUT_PREFIX"); p != "" {
+		prefix = strings.Trim(p, "/")
+	}
+	return bucket, prefix
+}
+
+// applyCommandOutput configures SendCommand to also write full output to S3,
+// and to CloudWatch when CloudWatch session logging is enabled
+func (s *ComputeService) applyCommandOutput(ctx context.Context, input *ssm.SendCommandInput) {
+	var instanceID string
+	if len(input.InstanceIds) > 0 {
+		instanceID = input.InstanceIds[0]
+	}
+
+	if logging := s.sessionLogging(ctx); logging.CloudWatch {
+		input.CloudWatchOutputConfig = &ssmtypes.CloudWatchOutputConfig{
+			CloudWatchOutputEnabled: true,
+			CloudWatchLogGroupName:  aws.String(logging.ClusterLogGroup(s.instanceCluster(ctx, instanceID))),
+		}
+	}
+
+	bucket, prefix := s.commandOutputLocation(ctx, instanceID)
+	if bucket == "" {
+		return
+	}
+	input.OutputS3BucketName = aws.String(bucket)
+	input.OutputS3KeyPrefix = aws.String(prefix)
+	// The bucket lives in the provider region even when the instance does not
+	input.OutputS3Region = aws.String(s.config.Region)
+}
+
+// commandOutputStatements returns the policy statements that let instances
+// upload SSM command output to the output prefix and, with session logging,
+// to the log destinations. A cluster name narrows the log destinations to
+// that cluster's.
+func (s *ComputeService) commandOutputStatements(ctx context.Context, clusterName string) []map[string]interface{} {
+	logging := s.sessionLogging(ctx)
+	bucket, prefix := s.commandOutputLocation(ctx, "")
+	if logging.S3 && clusterName != "" {
+		prefix = logging.ClusterS3Prefix(clusterName)
+	}
+	logGroups := []string{fmt.Sprintf("arn:%s:logs:*:%s:log-group:%s/*", partition(s.config.Region), s.accountID, logging.LogGroupBase())}
+	if clusterName != "" {
+		group := fmt.Sprintf("arn:%s:logs:*:%s:log-group:%s", partition(s.config.Region), s.accountID, logging.ClusterLogGroup(clusterName))
+		logGroups = []string{group, group + ":*"}
+	}
+
+	var statements []map[string]interface{}
+	if bucket != "" {
+		statements = append(statements, map[string]interface{}{
+			"Effect":   "Allow",
+			"Action":   []string{"s3:PutObject"},
+			"Resource": fmt.Sprintf("arn:%s:s3:::%s/%s/*", partition(s.config.Region), bucket, prefix),
+		})
+	}
+	if logging.CloudWatch {
+		statements = append(statements, map[string]interface{}{
+			"Effect":   "Allow",
+			"Action":   []string{"logs:CreateLogGroup", "logs:CreateLogStream", "logs:PutLogEvents", "logs:DescribeLogStreams"},
+			"Resource": logGroups,
+		})
+	}
+	return statements
+}
+
+// ensureCommandOutputPolicy applies the command output statements to the
+// shared instance role and refreshes the cluster roles' access policies.
+// It is applied on every init so existing roles pick it up.
+func (s *ComputeService) ensureCommandOutputPolicy(ctx context.Context) error {
+	if err := s.refreshClusterAccessPolicies(ctx); err != nil {
+		logger.Warnf(ctx, "Failed to refresh cluster role policies: %v", err)
+	}
+
+	statements := s.commandOutputStatements(ctx, "")
+	if len(statements) == 0 {
+		return nil
+	}
+
+	policyDoc := map[string]interface{}{
+		"Version":   "2012-10-17",
+		"Statement": statements,
+	}
+
+	policyJSON, err := json.Marshal(policyDoc)
+	if err != nil {
+		return fmt.Errorf("failed to marshal command output policy: %w", err)
+	}
+
+	_, err = s.iamClient.PutRolePolicy(ctx, &iam.PutRolePolicyInput{
+		RoleName:       aws.String("goman-ssm-instance-role"),
+		PolicyName:     aws.String(commandOutputPolicyName),
+		PolicyDocument: aws.String(string(policyJSON)),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to put command output policy: %w", err)
+	}
+	return nil
+}
+
+// fillFullOutput replaces truncated inline output with the full output stored in S3
+func (s *ComputeService) fillFullOutput(ctx context.Context, commandID string, result *provider.InstanceCommandResult) {
+	if len(result.Output) < ssmInlineOutputLimit && len(result.Error) < ssmInlineOutputLimit {
+		return
+	}
+
+	stdout, stderr, err := s.fetchCommandOutput(ctx, commandID, result.InstanceID)
+	if err != nil {
+		logger.Infof(ctx, "Output of command %s on %s is truncated and S3 output is unavailable: %v", commandID, result.InstanceID, err)
+		return
+	}
+
+	if len(result.Output) >= ssmInlineOutputLimit && stdout != "" {
+		result.Output = stdout
+	}
+	if len(result.Error) >= ssmInlineOutputLimit && stderr != "" {
+		result.Error = stderr
+	}
+}
+
+// fetchCommandOutput reads stdout and stderr written by SSM for one instance.
+// SSM writes <prefix>/<commandID>/<instanceID>/<plugin>/[<step>/]stdout|stderr.
+func (s *ComputeService) fetchCommandOutput(ctx context.Context, commandID, instanceID string) (string, string, error) {
+	bucket, prefix := s.commandOutputLocation(ctx, instanceID)
+	if bucket == "" {
+		return "", "", fmt.Errorf("S3 command output is disabled")
+	}
+
+	listOutput, err := s.s3Client.ListObjectsV2(ctx, &s3.ListObjectsV2Input{
+		Bucket: aws.String(bucket),
+		Prefix: aws.String(fmt.Sprintf("%s/%s/%s/", prefix, commandID, instanceID)),
+	})
+	if err != nil {
+		return "", "", wrapAWSError("s3", "ListObjectsV2", err)
+	}
+
+	var stdoutKeys, stderrKeys []string
+	for _, obj := range listOutput.Contents {
+		key := aws.ToString(obj.Key)
+		switch {
+		case strings.HasSuffix(key, "/stdout"):
+			stdoutKeys = append(stdoutKeys, key)
+		case strings.HasSuffix(key, "/stderr"):
+			stderrKeys = append(stderrKeys, key)
+		}
+	}
+	if len(stdoutKeys) == 0 && len(stderrKeys) == 0 {
+		return "", "", fmt.Errorf("no output objects found for command %s", commandID)
+	}
+
+	// Multi-step documents write one object per step; keep step order
+	sort.Strings(stdoutKeys)
+	sort.Strings(stderrKeys)
+
+	stdout, err := s.readObjects(ctx, bucket, stdoutKeys)
+	if err != nil {
+		return "", "", err
+	}
+	stderr, err := s.readObjects(ctx, bucket, stderrKeys)
+	if err != nil {
+		return "", "", err
+	}
+	return stdout, stderr, nil
+}
+
+// readObjects concatenates the content of S3 objects
+func (s *ComputeService) readObjects(ctx context.Context, bucket string, keys []string) (string, error) {
+	var sb strings.Builder
+	for _, key := range keys {
+		obj, err := s.s3Client.GetObject(ctx, &s3.GetObjectInput{
+			Bucket: aws.String(bucket),
+			Key:    aws.String(key),
+		})
+		if err != nil {
+			return "", wrapAWSError("s3", "GetObject", err)
+		}
+		data, err := io.ReadAll(obj.Body)
+		obj.Body.Close()
+		if err != nil {
+			return "", fmt.Errorf("failed to read %s: %w", key, err)
+		}
+		sb.Write(data)
+	}
+	return sb.String(), nil
+}
@@ -3,11 +3,15 @@ package aws
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"io"
+	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/madhouselabs/goman/pkg/config"
+	"github.com/madhouselabs/goman/pkg/provider"
 )
 
 // StorageService implements object storage using S3
@@ -20,10 +24,15 @@ type StorageService struct {
 func NewStorageService(client *s3.Client, accountID string) *StorageService {
 	return &StorageService{
 		client:     client,
-		bucketName: fmt.Sprintf("goman-%s", accountID),
+		bucketName: stateBucketName(accountID),
 	}
 }
 
+// stateBucketName returns the goman state bucket of an account
+func stateBucketName(accountID string) string {
+	return config.GetStateBucket(accountID)
+}
+
 // Initialize ensures the S3 bucket exists
 func (s *StorageService) Initialize(ctx context.Context) error {
 	// Try to list objects first - this is a lighter check that usually works
@@ -76,7 +85,7 @@ func (s *StorageService) GetObject(ctx context.Context, key string) ([]byte, err
 	})
 
 	if err != nil {
-		return nil, fmt.Errorf("failed to get object: %w", err)
+		return nil, fmt.Errorf("failed to get object: %w", wrapAWSError("s3", "GetObject", err))
 	}
 	defer result.Body.Close()
 
@@ -88,6 +97,55 @@ func (s *StorageService) GetObject(ctx context.Context, key string) ([]byte, err
 	return data, nil
 }
 
+// GetObjectVersion retrieves an object with its ETag as version
+func (s *StorageService) GetObjectVersion(ctx context.Context, key string) ([]byte, string, error) {
+	result, err := s.client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(s.bucketName),
+		Key:    aws.String(key),
+	})
+
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to get object: %w", wrapAWSError("s3", "GetObject", err))
+	}
+	defer result.Body.Close()
+
+	data, err := io.ReadAll(result.Body)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read object: %w", err)
+	}
+
+	return data, aws.ToString(result.ETag), nil
+}
+
+// PutObjectIfMatch stores an object only if its ETag still matches, using
+// S3 conditional writes. An empty version only creates new objects.
+func (s *StorageService) PutObjectIfMatch(ctx context.Context, key string, data []byte, version string) (string, error) {
+	input := &s3.PutObjectInput{
+		Bucket: aws.String(s.bucketName),
+		Key:    aws.String(key),
+		Body:   bytes.NewReader(data),
+	}
+	if version == "" {
+		input.IfNoneMatch = aws.String("*")
+	} else {
+		input.IfMatch = aws.String(version)
+	}
+
+	result, err := s.client.PutObject(ctx, input)
+	if err != nil {
+		var coded interface{ ErrorCode() string }
+		if errors.As(err, &coded) {
+			switch coded.ErrorCode() {
+			case "PreconditionFailed", "ConditionalRequestConflict":
+				return "", fmt.Errorf("failed to put object %s: %w", key, provider.ErrPreconditionFailed)
+			}
+		}
+		return "", fmt.Errorf("failed to put object: %w", err)
+	}
+
+	return aws.ToString(result.ETag), nil
+}
+
 // DeleteObject deletes an object
 func (s *StorageService) DeleteObject(ctx context.Context, key string) error {
 	_, err := s.client.DeleteObject(ctx, &s3.DeleteObjectInput{
@@ -125,6 +183,29 @@ func (s *StorageService) ListObjects(ctx context.Context, prefix string) ([]stri
 	return keys, nil
 }
 
+// ListObjectTimes returns the last write time of every object under prefix
+func (s *StorageService) ListObjectTimes(ctx context.Context, prefix string) (map[string]time.Time, error) {
+	times := make(map[string]time.Time)
+
+	paginator := s3.NewListObjectsV2Paginator(s.client, &s3.ListObjectsV2Input{
+		Bucket: aws.String(s.bucketName),
+		Prefix: aws.String(prefix),
+	})
+
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list objects: %w", err)
+		}
+
+		for _, obj := range page.Contents {
+			times[aws.ToString(obj.Key)] = aws.ToTime(obj.LastModified)
+		}
+	}
+
+	return times, nil
+}
+
 // DeleteFolder deletes all objects with a given prefix (folder)
 func (s *StorageService) DeleteFolder(ctx context.Context, prefix string) error {
 	// List all objects with the prefix
@@ -143,3 +224,66 @@ func (s *StorageService) DeleteFolder(ctx context.Context, prefix string) error
 
 	return nil
 }
+
+// PresignGetObject returns a URL that downloads an object without AWS
+// credentials until ttl has passed, and when it expires. URLs signed with
+// temporary credentials stop working when those credentials expire, so ttl
+// is cut short to their expiry.
+func (s *StorageService) PresignGetObject(ctx context.Context, key string, ttl time.Duration) (string, time.Time, error) {
+	// Fail early for missing objects rather than handing out a URL that 404s
+	if _, err := s.client.HeadObject(ctx, &s3.HeadObjectInput{
+		Bucket: aws.String(s.bucketName),
+		Key:    aws.String(key),
+	}); err != nil {
+		return "", time.Time{}, fmt.Errorf("failed to find object: %w", wrapAWSError("s3", "HeadObject", err))
+	}
+
+	now := time.Now()
+	ttl, err := s.presignLifetime(ctx, now, ttl)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	req, err := s3.NewPresignClient(s.client).PresignGetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(s.bucketName),
+		Key:    aws.String(key),
+	}, s3.WithPresignExpires(ttl))
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("failed to presign object: %w", err)
+	}
+	return req.URL, now.Add(ttl), nil
+}
+
+// PresignPutObject returns a URL that uploads an object without AWS
+// credentials until ttl has passed, and when it expires. The ttl is cut
+// short like for PresignGetObject.
+func (s *StorageService) PresignPutObject(ctx context.Context, key string, ttl time.Duration) (string, time.Time, error) {
+	now := time.Now()
+	ttl, err := s.presignLifetime(ctx, now, ttl)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	req, err := s3.NewPresignClient(s.client).PresignPutObject(ctx, &s3.PutObjectInput{
+		Bucket: aws.String(s.bucketName),
+		Key:    aws.String(key),
+	}, s3.WithPresignExpires(ttl))
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("failed to presign object upload: %w", err)
+	}
+	return req.URL, now.Add(ttl), nil
+}
+
+// presignLifetime returns how long a URL presigned now for ttl can be valid,
+// given the expiry of the signing credentials
+func (s *StorageService) presignLifetime(ctx context.Context, now time.Time, ttl time.Duration) (time.Duration, error) {
+	var credentialsExpiry time.Time
+	if credentials := s.client.Options().Credentials; credentials != nil {
+		creds, err := credentials.Retrieve(ctx)
+		if err != nil {
+			return 0, fmt.Errorf("failed to retrieve signing credentials: %w", err)
+		}
+		if creds.CanExpire {
+			credentialsExpiry = creds.Expires
+		}
+	}
+	return provider.ShareLifetime(now, ttl, credentialsExpiry)
+}
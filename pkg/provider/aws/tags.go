@@ -0,0 +1,157 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/madhouselabs/goman/pkg/logger"
+	"github.com/madhouselabs/goman/pkg/provider"
+)
+
+// maxTagResources is how many resources one CreateTags or DeleteTags call
+// accepts
+const maxTagResources = 1000
+
+// SyncClusterTags brings the user tags of a cluster's instances, volumes and
+// security groups in line with tags. Resources are grouped per tag, so a
+// changed tag costs one call however many resources carry it.
+func (s *ComputeService) SyncClusterTags(ctx context.Context, clusterName string, tags map[string]string, removed []string) (*provider.TagSyncResult, error) {
+	actual, err := s.clusterResourceTags(ctx, clusterName)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &provider.TagSyncResult{Resources: len(actual)}
+	updated := make(map[string]bool)
+
+	for key, value := range tags {
+		var ids []string
+		for id, current := range actual {
+			if v, ok := current[key]; !ok || v != value {
+				ids = append(ids, id)
+			}
+		}
+		for _, batch := range chunkIDs(ids, maxTagResources) {
+			_, err := s.client.CreateTags(ctx, &ec2.CreateTagsInput{
+				Resources: batch,
+				Tags:      []types.Tag{{Key: aws.String(key), Value: aws.String(value)}},
+			})
+			if err != nil {
+				return result, fmt.Errorf("failed to set tag %s: %w", key, wrapAWSError("ec2", "CreateTags", err))
+			}
+			for _, id := range batch {
+				updated[id] = true
+			}
+		}
+	}
+
+	for _, key := range removed {
+		if _, ok := tags[key]; ok {
+			continue
+		}
+		var ids []string
+		for id, current := range actual {
+			if _, ok := current[key]; ok {
+				ids = append(ids, id)
+			}
+		}
+		for _, batch := range chunkIDs(ids, maxTagResources) {
+			_, err := s.client.DeleteTags(ctx, &ec2.DeleteTagsInput{
+				Resources: batch,
+				Tags:      []types.Tag{{Key: aws.String(key)}},
+			})
+			if err != nil {
+				return result, fmt.Errorf("failed to remove tag %s: %w", key, wrapAWSError("ec2", "DeleteTags", err))
+			}
+			for _, id := range batch {
+				updated[id] = true
+			}
+		}
+	}
+
+	result.Updated = len(updated)
+	logger.Infof(ctx, "Synced tags of cluster %s: %d of %d resources updated", clusterName, result.Updated, result.Resources)
+	return result, nil
+}
+
+// clusterResourceTags returns the current tags of a cluster's instances,
+// their volumes and its security groups, keyed by resource ID
+func (s *ComputeService) clusterResourceTags(ctx context.Context, clusterName string) (map[string]map[string]string, error) {
+	resources := make(map[string]map[string]string)
+	var volumeIDs []string
+
+	instances := ec2.NewDescribeInstancesPaginator(s.client, &ec2.DescribeInstancesInput{
+		Filters: []types.Filter{
+			{Name: aws.String("tag:goman-cluster"), Values: []string{clusterName}},
+			{Name: aws.String("instance-state-name"), Values: []string{"pending", "running", "stopping", "stopped"}},
+		},
+	})
+	for instances.HasMorePages() {
+		page, err := instances.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list instances: %w", wrapAWSError("ec2", "DescribeInstances", err))
+		}
+		for _, reservation := range page.Reservations {
+			for _, inst := range reservation.Instances {
+				resources[aws.ToString(inst.InstanceId)] = tagMap(inst.Tags)
+				for _, mapping := range inst.BlockDeviceMappings {
+					if mapping.Ebs != nil {
+						volumeIDs = append(volumeIDs, aws.ToString(mapping.Ebs.VolumeId))
+					}
+				}
+			}
+		}
+	}
+
+	for _, batch := range chunkIDs(volumeIDs, 200) {
+		volumes, err := s.client.DescribeVolumes(ctx, &ec2.DescribeVolumesInput{
+			VolumeIds: batch,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to describe volumes: %w", wrapAWSError("ec2", "DescribeVolumes", err))
+		}
+		for _, volume := range volumes.Volumes {
+			resources[aws.ToString(volume.VolumeId)] = tagMap(volume.Tags)
+		}
+	}
+
+	groups, err := s.client.DescribeSecurityGroups(ctx, &ec2.DescribeSecurityGroupsInput{
+		Filters: []types.Filter{
+			{Name: aws.String("tag:Cluster"), Values: []string{clusterName}},
+			{Name: aws.String("tag:ManagedBy"), Values: []string{"goman"}},
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe security groups: %w", wrapAWSError("ec2", "DescribeSecurityGroups", err))
+	}
+	for _, group := range groups.SecurityGroups {
+		resources[aws.ToString(group.GroupId)] = tagMap(group.Tags)
+	}
+
+	return resources, nil
+}
+
+// tagMap converts EC2 tags into a map
+func tagMap(tags []types.Tag) map[string]string {
+	m := make(map[string]string, len(tags))
+	for _, tag := range tags {
+		m[aws.ToString(tag.Key)] = aws.ToString(tag.Value)
+	}
+	return m
+}
+
+// chunkIDs splits IDs into batches of at most size
+func chunkIDs(ids []string, size int) [][]string {
+	var chunks [][]string
+	for len(ids) > size {
+		chunks = append(chunks, ids[:size])
+		ids = ids[size:]
+	}
+	if len(ids) > 0 {
+		chunks = append(chunks, ids)
+	}
+	return chunks
+}
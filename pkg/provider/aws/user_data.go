@@ -0,0 +1,87 @@
+package aws
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/madhouselabs/goman/pkg/logger"
+	"github.com/madhouselabs/goman/pkg/provider"
+	"github.com/madhouselabs/goman/pkg/provider/aws/userdata"
+)
+
+// MaxUserDataSize is the EC2 limit for raw (pre-base64) user data
+const MaxUserDataSize = 16 * 1024
+
+// UserDataTooLargeError is returned when user data exceeds the EC2 limit
+// and cannot be staged for a thin bootstrap
+type UserDataTooLargeError struct {
+	InstanceName string
+	Size         int
+	Err          error
+}
+
+func (e *UserDataTooLargeError) Error() string {
+	return fmt.Sprintf("user data for %s is %d bytes, exceeding the EC2 limit of %d bytes, and could not be staged to S3: %v",
+		e.InstanceName, e.Size, MaxUserDataSize, e.Err)
+}
+
+func (e *UserDataTooLargeError) Unwrap() error {
+	return e.Err
+}
+
+// prepareUserData validates the size of base64-encoded user data, combined
+// with the node pool's snippet if any. Scripts over the EC2 limit are staged
+// in the state bucket and replaced by a thin bootstrap that fetches them
+// from the bucket's region, whatever region the instance launches in.
+func (s *ComputeService) prepareUserData(ctx context.Context, instanceName, clusterName, osFamily, encoded, snippet string) (string, error) {
+	raw, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		// Not base64 - treat as raw script
+		raw = []byte(encoded)
+	}
+
+	full, err := provider.MultipartUserData(string(raw), snippet)
+	if err != nil {
+		return "", provider.UserConfigErrorf("user data for %s: %w", instanceName, err)
+	}
+	if len(full) <= MaxUserDataSize {
+		return base64.StdEncoding.EncodeToString([]byte(full)), nil
+	}
+
+	logger.Infof(ctx, "User data for %s is %d bytes (limit %d), falling back to thin bootstrap", instanceName, len(full), MaxUserDataSize)
+
+	bucketName := stateBucketName(s.accountID)
+	if clusterName == "" {
+		clusterName = "_unassigned"
+	}
+	key := fmt.Sprintf("clusters/%s/bootstrap/%s.sh", clusterName, instanceName)
+
+	_, err = s.s3Client.PutObject(ctx, &s3.PutObjectInput{
+		Bucket: aws.String(bucketName),
+		Key:    aws.String(key),
+		Body:   bytes.NewReader(raw),
+	})
+	if err != nil {
+		return "", &UserDataTooLargeError{InstanceName: instanceName, Size: len(full), Err: err}
+	}
+
+	// The snippet stays in the user data, after the thin bootstrap
+	script, err := userdata.RenderThin(bucketName, key, s.config.Region, osFamily)
+	if err != nil {
+		return "", fmt.Errorf("failed to render thin bootstrap for %s: %w", instanceName, err)
+	}
+	thin, err := provider.MultipartUserData(script, snippet)
+	if err != nil {
+		return "", provider.UserConfigErrorf("user data for %s: %w", instanceName, err)
+	}
+	if len(thin) > MaxUserDataSize {
+		// Snippets are bounded by models.MaxUserDataSnippet, but keep the invariant explicit
+		return "", &UserDataTooLargeError{InstanceName: instanceName, Size: len(thin), Err: fmt.Errorf("thin bootstrap too large")}
+	}
+
+	return base64.StdEncoding.EncodeToString([]byte(thin)), nil
+}
@@ -0,0 +1,84 @@
+// Package userdata renders the bootstrap scripts EC2 instances run at first
+// boot from the templates under templates/. The K3s units, the pre-install
+// hook, the package list and the registry mirrors are templates of their
+// own in snippets.tmpl, filled from the cluster's bootstrap spec.
+package userdata
+
+import (
+	"embed"
+	"encoding/base64"
+	"strings"
+	"text/template"
+
+	"github.com/madhouselabs/goman/pkg/provider"
+)
+
+//go:embed templates/*.tmpl
+var templateFS embed.FS
+
+// templates holds the scripts and the snippets they include
+var templates = template.Must(template.New("userdata").Funcs(template.FuncMap{
+	"base64": func(s string) string { return base64.StdEncoding.EncodeToString([]byte(s)) },
+	"unit": func(description, environmentFile, command string) k3sUnit {
+		return k3sUnit{Description: description, EnvironmentFile: environmentFile, Command: command}
+	},
+}).ParseFS(templateFS, "templates/*.tmpl"))
+
+// k3sUnit is a systemd unit running K3s
+type k3sUnit struct {
+	Description     string
+	EnvironmentFile string // Under /etc/systemd/system
+	Command         string // k3s arguments, expanded by the shell writing the unit
+}
+
+// Params are the values of a node's bootstrap script. The scripts are
+// written into the script as they are; the other values are exported to it
+// as variables and must not contain double quotes.
+type Params struct {
+	ClusterName     string
+	Role            string // master or worker
+	Region          string
+	Bucket          string // State bucket holding the K3s binaries
+	NodeIndex       string
+	MasterIP        string // Server joined by additional masters and workers
+	NodeToken       string // Token of joining workers
+	K3sDisableFlags string
+	LowResource     string // "true" for the low-resource profile
+	K3sTuningFlags  string
+	K3sVersion      string
+	K3sArch         string // amd64 or arm64, the machine's if empty
+	OSFamily        string // Picks the package manager and SSM agent setup, Amazon Linux 2 if empty
+	SecretBackend   string
+	SecretRegion    string
+
+	SecretFunctions     string // Shell functions reading and writing secrets
+	ProxyScript         string
+	DataVolumeScript    string
+	InstanceStoreScript string
+	GPUScript           string
+	RegistriesScript    string // Registry mirrors and credentials, replacing the bootstrap mirrors
+
+	Bootstrap provider.BootstrapConfig // Additions of the cluster spec
+}
+
+// Render returns the bootstrap script of a node
+func Render(params Params) (string, error) {
+	return execute("bootstrap.sh.tmpl", params)
+}
+
+// RenderThin returns the script fetching a bootstrap script staged in S3
+// and running it, for scripts over the EC2 user data limit. It keeps the
+// SSM agent enabled first so the node stays reachable for SSM operations
+// even if the staged script fails.
+func RenderThin(bucket, key, region, osFamily string) (string, error) {
+	return execute("thin.sh.tmpl", struct{ Bucket, Key, Region, OSFamily string }{bucket, key, region, osFamily})
+}
+
+// execute renders one of the templates
+func execute(name string, data any) (string, error) {
+	var b strings.Builder
+	if err := templates.ExecuteTemplate(&b, name, data); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
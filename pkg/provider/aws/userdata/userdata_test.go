@@ -0,0 +1,176 @@
+package userdata
+
+import (
+	"encoding/base64"
+	"strings"
+	"testing"
+
+	"github.com/madhouselabs/goman/pkg/provider"
+)
+
+func TestRenderRoles(t *testing.T) {
+	params := Params{ClusterName: "demo", Region: "eu-west-1", Bucket: "goman-123", K3sVersion: "v1.31.1+k3s1", K3sArch: "arm64", MasterIP: "10.0.0.1"}
+	for role, want := range map[string][]string{
+		"master": {
+			`export NODE_ROLE="master"`,
+			"ExecStart=/usr/local/bin/k3s server ${CLUSTER_INIT_FLAG} --token=${SERVER_TOKEN}",
+			"ExecStart=/usr/local/bin/k3s server --server=https://${MASTER_IP}:6443",
+		},
+		"worker": {
+			`export NODE_ROLE="worker"`,
+			"Description=Lightweight Kubernetes Agent",
+			"EnvironmentFile=-/etc/systemd/system/k3s-agent.service.env",
+			"ExecStart=/usr/local/bin/k3s agent --server=https://${MASTER_IP}:6443 --token=${NODE_TOKEN} ${K3S_TUNING_FLAGS}",
+		},
+	} {
+		params.Role = role
+		script, err := Render(params)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.HasPrefix(script, "#!/bin/bash\nset -e\n") {
+			t.Errorf("%s script starts with %q", role, script[:20])
+		}
+		for _, line := range append(want, `export CLUSTER_NAME="demo"`, `export K3S_ARCH="arm64"`, "yum install -y jq\n") {
+			if !strings.Contains(script, line) {
+				t.Errorf("%s script lacks %q", role, line)
+			}
+		}
+		for _, absent := range []string{"pre-install.sh", "registries.yaml", "{{", "<no value>"} {
+			if strings.Contains(script, absent) {
+				t.Errorf("%s script contains %q", role, absent)
+			}
+		}
+	}
+}
+
+func TestRenderBootstrapAdditions(t *testing.T) {
+	hook := "#!/bin/bash\nupdate-ca-trust extract\n"
+	script, err := Render(Params{
+		Role:        "worker",
+		ProxyScript: "\n# Reach the internet through the proxy\n",
+		Bootstrap: provider.BootstrapConfig{
+			PreInstall:    hook,
+			ExtraPackages: []string{"nfs-utils", "iscsi-initiator-utils"},
+			RegistryMirrors: map[string][]string{
+				"docker.io": {"https://mirror.corp", "https://mirror2.corp"},
+				"*":         {"https://all.corp"},
+			},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The hook runs behind the proxy and before any package is installed,
+	// and the mirrors are in place before K3s starts
+	order := []string{
+		"# Reach the internet through the proxy",
+		"echo " + base64.StdEncoding.EncodeToString([]byte(hook)) + " | base64 -d > /var/lib/goman/pre-install.sh",
+		"yum install -y jq nfs-utils iscsi-initiator-utils\n",
+		`mirrors:
+  "*":
+    endpoint:
+      - "https://all.corp"
+  "docker.io":
+    endpoint:
+      - "https://mirror.corp"
+      - "https://mirror2.corp"
+REGISTRIES
+`,
+		"systemctl start k3s-agent.service",
+	}
+	last := -1
+	for _, part := range order {
+		i := strings.Index(script, part)
+		if i < 0 {
+			t.Fatalf("script lacks %q", part)
+		}
+		if i < last {
+			t.Errorf("%q comes too early", part)
+		}
+		last = i
+	}
+}
+
+func TestRenderThin(t *testing.T) {
+	script, err := RenderThin("goman-123", "clusters/demo/bootstrap/demo-worker-1.sh", "eu-west-1", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "aws s3 cp s3://goman-123/clusters/demo/bootstrap/demo-worker-1.sh /var/lib/goman-bootstrap.sh --region eu-west-1"; !strings.Contains(script, want) {
+		t.Errorf("thin script lacks %q:\n%s", want, script)
+	}
+}
+
+func TestRenderOSFamilies(t *testing.T) {
+	for osFamily, want := range map[string][]string{
+		"":                {"systemctl enable amazon-ssm-agent\n", "yum update -y\n", "yum install -y jq nfs-utils\n"},
+		"amazonlinux2023": {"systemctl enable amazon-ssm-agent\n", "dnf upgrade -y\n", "dnf install -y jq nfs-utils\n"},
+		"ubuntu2204": {
+			"snap install amazon-ssm-agent --classic\n",
+			"apt-get update -y\n",
+			"apt-get install -y jq nfs-utils\n",
+			"command -v aws > /dev/null 2>&1 || snap install aws-cli --classic\n",
+		},
+	} {
+		script, err := Render(Params{Role: "worker", OSFamily: osFamily, Bootstrap: provider.BootstrapConfig{ExtraPackages: []string{"nfs-utils"}}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		thin, err := RenderThin("goman-123", "key", "eu-west-1", osFamily)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, line := range want {
+			if !strings.Contains(script, line) {
+				t.Errorf("%q script lacks %q", osFamily, line)
+			}
+		}
+		if !strings.Contains(thin, want[0]) {
+			t.Errorf("%q thin script lacks %q", osFamily, want[0])
+		}
+		if osFamily != "ubuntu2204" && strings.Contains(script+thin, "snap ") {
+			t.Errorf("%q scripts use snap", osFamily)
+		}
+	}
+}
+
+func TestRenderRegistries(t *testing.T) {
+	script, err := Render(Params{
+		Role: "master",
+		RegistriesScript: provider.RegistriesScript(&provider.RegistryConfig{
+			Mirrors: map[string][]string{"docker.io": {"https://mirror.corp"}},
+			Auth: map[string]provider.RegistryAuth{
+				"mirror.corp": {Secret: "goman/demo/mirror"},
+				"123456789012.dkr.ecr.eu-west-1.amazonaws.com": {ECRRegion: "eu-west-1"},
+			},
+			Region: "ap-south-1",
+		}),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Credentials are read with the AWS CLI and jq, and written before K3s
+	// starts
+	order := []string{
+		"yum install -y jq\n",
+		"value=$(aws ecr get-login-password --region 'eu-west-1')",
+		"aws secretsmanager get-secret-value --region 'ap-south-1' --secret-id 'goman/demo/mirror'",
+		"systemctl enable --now goman-registries.timer",
+		"if /usr/local/bin/goman-registries; then",
+		"systemctl start k3s.service",
+	}
+	last := -1
+	for _, part := range order {
+		i := strings.Index(script, part)
+		if i < 0 {
+			t.Fatalf("script lacks %q", part)
+		}
+		if i < last {
+			t.Errorf("%q comes too early", part)
+		}
+		last = i
+	}
+}
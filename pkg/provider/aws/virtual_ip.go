@@ -0,0 +1,99 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/madhouselabs/goman/pkg/logger"
+)
+
+// A cluster's virtual IP is a secondary private IP on the primary network
+// interface of one master. The masters' network interfaces are tagged with
+// the cluster, and the node role may only reassign IPs on interfaces with
+// its cluster's tag, so the master elected by kube-vip can move the IP to
+// itself without the controller.
+
+// PrepareVirtualIP tags the primary network interfaces of the instances with
+// the cluster and makes sure one of them holds the address
+func (s *ComputeService) PrepareVirtualIP(ctx context.Context, clusterName string, instanceIDs []string, address string) (string, error) {
+	if len(instanceIDs) == 0 {
+		return "", fmt.Errorf("no instances to hold the virtual IP")
+	}
+
+	ec2Client := s.instanceClient(ctx, instanceIDs[0])
+
+	resp, err := ec2Client.DescribeInstances(ctx, &ec2.DescribeInstancesInput{
+		InstanceIds: instanceIDs,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to describe instances: %w", wrapAWSError("ec2", "DescribeInstances", err))
+	}
+
+	interfaces := make(map[string]types.InstanceNetworkInterface)
+	for _, reservation := range resp.Reservations {
+		for _, inst := range reservation.Instances {
+			for _, ni := range inst.NetworkInterfaces {
+				if ni.Attachment != nil && aws.ToInt32(ni.Attachment.DeviceIndex) == 0 {
+					interfaces[aws.ToString(inst.InstanceId)] = ni
+				}
+			}
+		}
+	}
+
+	var interfaceIDs []string
+	holder := ""
+	for _, id := range instanceIDs {
+		ni, ok := interfaces[id]
+		if !ok {
+			return "", fmt.Errorf("instance %s has no primary network interface", id)
+		}
+		interfaceIDs = append(interfaceIDs, aws.ToString(ni.NetworkInterfaceId))
+		for _, ip := range ni.PrivateIpAddresses {
+			if address != "" && !aws.ToBool(ip.Primary) && aws.ToString(ip.PrivateIpAddress) == address {
+				holder = id
+			}
+		}
+	}
+
+	_, err = ec2Client.CreateTags(ctx, &ec2.CreateTagsInput{
+		Resources: interfaceIDs,
+		Tags: []types.Tag{
+			{Key: aws.String("goman-cluster"), Value: aws.String(clusterName)},
+			{Key: aws.String("ManagedBy"), Value: aws.String("goman")},
+			s.installTag(),
+		},
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to tag network interfaces: %w", wrapAWSError("ec2", "CreateTags", err))
+	}
+
+	if holder != "" {
+		return address, nil
+	}
+
+	input := &ec2.AssignPrivateIpAddressesInput{
+		NetworkInterfaceId: aws.String(interfaceIDs[0]),
+		AllowReassignment:  aws.Bool(true),
+	}
+	if address == "" {
+		input.SecondaryPrivateIpAddressCount = aws.Int32(1)
+	} else {
+		input.PrivateIpAddresses = []string{address}
+	}
+	out, err := ec2Client.AssignPrivateIpAddresses(ctx, input)
+	if err != nil {
+		return "", fmt.Errorf("failed to assign virtual IP: %w", wrapAWSError("ec2", "AssignPrivateIpAddresses", err))
+	}
+	if address == "" {
+		if len(out.AssignedPrivateIpAddresses) == 0 {
+			return "", fmt.Errorf("no virtual IP was allocated on %s", interfaceIDs[0])
+		}
+		address = aws.ToString(out.AssignedPrivateIpAddresses[0].PrivateIpAddress)
+	}
+
+	logger.Infof(ctx, "Assigned virtual IP %s of cluster %s to %s", address, clusterName, instanceIDs[0])
+	return address, nil
+}
@@ -0,0 +1,461 @@
+package provider
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	"github.com/madhouselabs/goman/pkg/models"
+)
+
+// K3sVersion returns the K3s version of the goman-k3s-version tag, or the
+// default for callers that do not set it
+func K3sVersion(tag string) string {
+	if tag == "" {
+		return models.DefaultK3sVersion
+	}
+	return tag
+}
+
+// K3sDisableFlags converts the comma-separated goman-k3s-disable tag into K3s
+// server flags. Instances created without the tag keep the historical defaults;
+// "none" enables every packaged component.
+func K3sDisableFlags(components string) string {
+	switch components {
+	case "":
+		components = "traefik,servicelb,metrics-server"
+	case "none":
+		return ""
+	}
+	var flags []string
+	for _, component := range strings.Split(components, ",") {
+		if component = strings.TrimSpace(component); component != "" {
+			flags = append(flags, "--disable="+component)
+		}
+	}
+	return strings.Join(flags, " ")
+}
+
+// K3sTuningFlags returns the K3s flags of the low-resource profile: smaller
+// kubelet reservations and eviction thresholds, fewer pods per node, no
+// swap check, and on servers no cloud, helm or network policy controllers.
+// Empty unless the profile is enabled.
+func K3sTuningFlags(role string, lowResource bool) string {
+	if !lowResource {
+		return ""
+	}
+	flags := []string{
+		"--kubelet-arg=system-reserved=cpu=50m,memory=96Mi",
+		"--kubelet-arg=kube-reserved=cpu=50m,memory=96Mi",
+		"--kubelet-arg=eviction-hard=memory.available<64Mi,nodefs.available<1Gi",
+		"--kubelet-arg=max-pods=32",
+		"--kubelet-arg=fail-swap-on=false",
+		"--kubelet-arg=image-gc-high-threshold=70",
+		"--kubelet-arg=image-gc-low-threshold=50",
+		"--kube-proxy-arg=conntrack-max-per-core=8192",
+	}
+	if role == "master" {
+		flags = append(flags,
+			"--disable-cloud-controller",
+			"--disable-helm-controller",
+			"--disable-network-policy",
+			"--kube-apiserver-arg=max-requests-inflight=100",
+			"--kube-apiserver-arg=max-mutating-requests-inflight=50",
+		)
+	}
+	return strings.Join(flags, " ")
+}
+
+// DataVolumeScript returns the bootstrap step that formats data volumes on
+// first boot and mounts them (also on reboots, via fstab). device returns
+// the device path the i-th volume is attached as. Empty without data volumes.
+func DataVolumeScript(volumes []DataVolume, device func(int) string) string {
+	if len(volumes) == 0 {
+		return ""
+	}
+	script := `
+# Format and mount the node pool's data volumes
+mount_data_volume() {
+    local device="$1" fstype="$2" target="$3"
+    for i in $(seq 1 60); do
+        [ -e "$device" ] && break
+        sleep 2
+    done
+    if [ ! -e "$device" ]; then
+        echo "[$(date)] Data volume $device did not appear, not mounting $target" >> /var/log/goman-startup.log
+        return 1
+    fi
+    device=$(readlink -f "$device")
+    if ! blkid "$device" > /dev/null 2>&1; then
+        echo "[$(date)] Formatting $device as $fstype" >> /var/log/goman-startup.log
+        mkfs -t "$fstype" "$device"
+    fi
+    mkdir -p "$target"
+    local uuid
+    uuid=$(blkid -s UUID -o value "$device")
+    grep -q "UUID=$uuid " /etc/fstab || echo "UUID=$uuid $target $fstype defaults,nofail 0 2" >> /etc/fstab
+    mountpoint -q "$target" || mount "$target"
+    echo "[$(date)] Mounted $device at $target" >> /var/log/goman-startup.log
+}
+`
+	for i, v := range volumes {
+		script += fmt.Sprintf("mount_data_volume %s %s %s || true\n", device(i), v.Filesystem, v.MountPoint)
+	}
+	return script
+}
+
+// ProxyScript returns the bootstrap step that sends the node's outbound
+// HTTP(S) traffic through a proxy: for the rest of the script (cloud CLIs,
+// curl), login shells, yum/dnf or apt, and K3s, its containerd and the SSM
+// agent through systemd drop-ins, which outlive the units the script writes.
+// Values must be validated by models.ValidateProxy. Empty without a proxy.
+func ProxyScript(proxy *ProxyConfig) string {
+	if proxy == nil || (proxy.HTTPProxy == "" && proxy.HTTPSProxy == "") {
+		return ""
+	}
+	httpProxy, httpsProxy := proxy.HTTPProxy, proxy.HTTPSProxy
+	if httpsProxy == "" {
+		httpsProxy = httpProxy
+	}
+	if httpProxy == "" {
+		httpProxy = httpsProxy
+	}
+	return fmt.Sprintf(`
+# Reach the internet through the proxy
+cat > /etc/goman-proxy.env <<'PROXY'
+HTTP_PROXY=%[1]s
+HTTPS_PROXY=%[2]s
+NO_PROXY=%[3]s
+http_proxy=%[1]s
+https_proxy=%[2]s
+no_proxy=%[3]s
+PROXY
+chmod 600 /etc/goman-proxy.env
+set -a
+. /etc/goman-proxy.env
+set +a
+sed 's/^/export /' /etc/goman-proxy.env > /etc/profile.d/goman-proxy.sh
+chmod 600 /etc/profile.d/goman-proxy.sh
+# yum and dnf read the proxy from their configuration only
+for conf in /etc/yum.conf /etc/dnf/dnf.conf; do
+    if [ -f "$conf" ]; then
+        sed -i '/^proxy=/d' "$conf"
+        sed -i '/^\[main\]/a proxy=%[2]s' "$conf"
+    fi
+done
+if [ -d /etc/apt/apt.conf.d ]; then
+    cat > /etc/apt/apt.conf.d/90goman-proxy <<'PROXY'
+Acquire::http::Proxy "%[1]s";
+Acquire::https::Proxy "%[2]s";
+PROXY
+fi
+for unit in k3s k3s-agent amazon-ssm-agent; do
+    mkdir -p /etc/systemd/system/$unit.service.d
+    printf '[Service]\nEnvironmentFile=/etc/goman-proxy.env\n' > /etc/systemd/system/$unit.service.d/goman-proxy.conf
+done
+systemctl daemon-reload
+systemctl try-restart amazon-ssm-agent 2>/dev/null || true
+echo "[$(date)] Using proxy $(echo '%[2]s' | sed 's|//[^@/]*@|//|')" >> /var/log/goman-startup.log
+`, httpProxy, httpsProxy, proxy.NoProxy)
+}
+
+// userDataBoundary separates the parts of multipart user data
+const userDataBoundary = "==GOMAN-USER-DATA=="
+
+// MultipartUserData combines the bootstrap script with a node pool's user
+// data snippet as cloud-init multipart user data: scripts run after the
+// bootstrap, cloud-config is merged into cloud-init's configuration. The
+// script is returned unchanged without a snippet.
+func MultipartUserData(script, snippet string) (string, error) {
+	if snippet == "" {
+		return script, nil
+	}
+	if strings.Contains(script, userDataBoundary) || strings.Contains(snippet, userDataBoundary) {
+		return "", fmt.Errorf("user data must not contain %s", userDataBoundary)
+	}
+	contentType, filename := "text/x-shellscript", "50-pool-user-data.sh"
+	if models.UserDataKind(snippet) == models.UserDataCloudConfig {
+		contentType, filename = "text/cloud-config", "pool-user-data.cfg"
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Content-Type: multipart/mixed; boundary=\"%s\"\nMIME-Version: 1.0\n", userDataBoundary)
+	for _, part := range []struct{ contentType, filename, body string }{
+		{"text/x-shellscript", "00-goman-bootstrap.sh", script},
+		{contentType, filename, snippet},
+	} {
+		fmt.Fprintf(&b, "\n--%s\nContent-Type: %s; charset=\"utf-8\"\nMIME-Version: 1.0\nContent-Disposition: attachment; filename=\"%s\"\n\n%s",
+			userDataBoundary, part.contentType, part.filename, part.body)
+		if !strings.HasSuffix(part.body, "\n") {
+			b.WriteString("\n")
+		}
+	}
+	fmt.Fprintf(&b, "--%s--\n", userDataBoundary)
+	return b.String(), nil
+}
+
+// UserDataSnippetScript runs a node pool's user data script after the
+// bootstrap, for images without cloud-init. Cloud-config snippets need
+// cloud-init and give an empty script.
+func UserDataSnippetScript(snippet string) string {
+	if models.UserDataKind(snippet) != models.UserDataShell {
+		return ""
+	}
+	return fmt.Sprintf(`
+mkdir -p /var/lib/goman
+echo %s | base64 -d > /var/lib/goman/pool-user-data.sh
+chmod 700 /var/lib/goman/pool-user-data.sh
+echo "[$(date)] Running pool user data" >> /var/log/goman-startup.log
+/var/lib/goman/pool-user-data.sh >> /var/log/goman-user-data.log 2>&1 || echo "[$(date)] Pool user data exited with $?" >> /var/log/goman-startup.log
+`, base64.StdEncoding.EncodeToString([]byte(snippet)))
+}
+
+// K3sServerUnitScript writes and starts the K3s server unit with extra
+// flags, for providers that install K3s from shell scripts. The script
+// expects SERVER_TOKEN, PRIVATE_IP, PUBLIC_IP, K3S_DISABLE_FLAGS and
+// K3S_TUNING_FLAGS to be set.
+func K3sServerUnitScript(extraFlags string) string {
+	return fmt.Sprintf(`
+cat > /etc/systemd/system/k3s.service <<EOF
+[Unit]
+Description=Lightweight Kubernetes
+After=network-online.target
+Wants=network-online.target
+
+[Service]
+Type=notify
+EnvironmentFile=-/etc/systemd/system/k3s.service.env
+KillMode=process
+Delegate=yes
+LimitNOFILE=1048576
+Restart=always
+RestartSec=5s
+ExecStartPre=-/sbin/modprobe br_netfilter
+ExecStartPre=-/sbin/modprobe overlay
+ExecStart=/usr/local/bin/k3s server %s --token=${SERVER_TOKEN} --node-ip=${PRIVATE_IP} --node-external-ip=${PUBLIC_IP} --tls-san=${PUBLIC_IP} ${K3S_DISABLE_FLAGS} ${K3S_TUNING_FLAGS} --write-kubeconfig-mode=644
+
+[Install]
+WantedBy=multi-user.target
+EOF
+touch /etc/systemd/system/k3s.service.env
+systemctl daemon-reload
+systemctl enable k3s.service
+systemctl start k3s.service
+`, extraFlags)
+}
+
+// K3sAgentUnitScript writes and starts the K3s agent unit joining server.
+// The script expects NODE_TOKEN, PRIVATE_IP and K3S_TUNING_FLAGS to be set.
+func K3sAgentUnitScript(server string) string {
+	return fmt.Sprintf(`
+cat > /etc/systemd/system/k3s-agent.service <<EOF
+[Unit]
+Description=Lightweight Kubernetes Agent
+After=network-online.target
+Wants=network-online.target
+
+[Service]
+Type=notify
+EnvironmentFile=-/etc/systemd/system/k3s-agent.service.env
+KillMode=process
+Delegate=yes
+LimitNOFILE=1048576
+Restart=always
+RestartSec=5s
+ExecStartPre=-/sbin/modprobe br_netfilter
+ExecStartPre=-/sbin/modprobe overlay
+ExecStart=/usr/local/bin/k3s agent --server=https://%s:6443 --token=${NODE_TOKEN} --node-ip=${PRIVATE_IP} ${K3S_TUNING_FLAGS}
+
+[Install]
+WantedBy=multi-user.target
+EOF
+touch /etc/systemd/system/k3s-agent.service.env
+systemctl daemon-reload
+systemctl enable k3s-agent.service
+systemctl start k3s-agent.service
+`, server)
+}
+
+// ConfigureDNSScript points the kubelet at a goman-managed resolv.conf,
+// which CoreDNS and other dnsPolicy Default pods forward to, and applies the
+// coredns-custom ConfigMap and NodeLocal DNSCache. Existing nodes are moved
+// to the managed resolv.conf with a one-time K3s restart; running pods keep
+// running across it. Parameters are {{ Name }} placeholders, the syntax of
+// SSM documents.
+const ConfigureDNSScript = `
+set -e
+mkdir -p /etc/goman
+UPSTREAMS="{{ Upstreams }}"
+if [ -n "$UPSTREAMS" ]; then
+    : > /etc/goman/resolv.conf.new
+    for ip in $UPSTREAMS; do echo "nameserver $ip" >> /etc/goman/resolv.conf.new; done
+else
+    # Same fallback as K3s: skip resolvers on loopback (systemd-resolved stub)
+    SOURCE=/etc/resolv.conf
+    if grep -qE '^nameserver 127\.' /etc/resolv.conf && [ -f /run/systemd/resolve/resolv.conf ]; then
+        SOURCE=/run/systemd/resolve/resolv.conf
+    fi
+    grep -vE '^nameserver 127\.' "$SOURCE" > /etc/goman/resolv.conf.new
+fi
+mv /etc/goman/resolv.conf.new /etc/goman/resolv.conf
+
+if systemctl is-enabled --quiet k3s 2>/dev/null; then UNIT=k3s; else UNIT=k3s-agent; fi
+ENV_FILE=/etc/systemd/system/$UNIT.service.env
+if [ ! -f "$ENV_FILE" ]; then
+    echo "K3s is not installed yet"
+    exit 1
+fi
+if ! grep -q '^K3S_RESOLV_CONF=' "$ENV_FILE" 2>/dev/null; then
+    echo "K3S_RESOLV_CONF=/etc/goman/resolv.conf" >> "$ENV_FILE"
+    echo "Restarting $UNIT to use the managed resolv.conf"
+    systemctl restart $UNIT
+    for i in $(seq 1 60); do systemctl is-active --quiet $UNIT && break; sleep 5; done
+fi
+
+if [ "{{ ClusterConfig }}" != "true" ]; then
+    exit 0
+fi
+for i in $(seq 1 60); do kubectl get nodes >/dev/null 2>&1 && break; sleep 5; done
+
+if [ -n "{{ CorednsCustom }}" ]; then
+    echo "{{ CorednsCustom }}" | base64 -d | kubectl apply -f -
+else
+    kubectl -n kube-system delete configmap coredns-custom --ignore-not-found
+fi
+
+if [ "{{ NodeLocalCache }}" = "true" ]; then
+    KUBE_DNS=$(kubectl -n kube-system get service kube-dns -o jsonpath='{.spec.clusterIP}')
+    cat <<'MANIFEST' | sed -e "s/__KUBE_DNS__/$KUBE_DNS/g" | kubectl apply -f -
+` + NodeLocalDNSManifest + `MANIFEST
+else
+    kubectl -n kube-system delete daemonset,configmap,service,serviceaccount -l app.kubernetes.io/name=goman-node-local-dns --ignore-not-found
+fi
+
+# Pick up the new resolv.conf and server blocks right away
+kubectl -n kube-system rollout restart deployment coredns
+`
+
+// NodeLocalDNSManifest runs NodeLocal DNSCache on every node. It listens on
+// the link-local address and the kube-dns service IP, so pods use it without
+// kubelet changes, and forwards everything it cannot answer from its cache
+// to CoreDNS, which applies stub domains and upstreams.
+const NodeLocalDNSManifest = `apiVersion: v1
+kind: ServiceAccount
+metadata:
+  name: node-local-dns
+  namespace: kube-system
+  labels:
+    app.kubernetes.io/name: goman-node-local-dns
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: kube-dns-upstream
+  namespace: kube-system
+  labels:
+    app.kubernetes.io/name: goman-node-local-dns
+spec:
+  ports:
+  - name: dns
+    port: 53
+    protocol: UDP
+    targetPort: 53
+  - name: dns-tcp
+    port: 53
+    protocol: TCP
+    targetPort: 53
+  selector:
+    k8s-app: kube-dns
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: node-local-dns
+  namespace: kube-system
+  labels:
+    app.kubernetes.io/name: goman-node-local-dns
+data:
+  Corefile: |
+    .:53 {
+        errors
+        cache {
+            success 9984 30
+            denial 9984 5
+        }
+        reload
+        loop
+        bind ` + models.NodeLocalDNSAddress + ` __KUBE_DNS__
+        forward . __PILLAR__CLUSTER__DNS__ {
+            force_tcp
+        }
+        prometheus :9253
+        health ` + models.NodeLocalDNSAddress + `:8080
+    }
+---
+apiVersion: apps/v1
+kind: DaemonSet
+metadata:
+  name: node-local-dns
+  namespace: kube-system
+  labels:
+    app.kubernetes.io/name: goman-node-local-dns
+spec:
+  selector:
+    matchLabels:
+      k8s-app: node-local-dns
+  updateStrategy:
+    rollingUpdate:
+      maxUnavailable: 10%
+  template:
+    metadata:
+      labels:
+        k8s-app: node-local-dns
+    spec:
+      priorityClassName: system-node-critical
+      serviceAccountName: node-local-dns
+      hostNetwork: true
+      dnsPolicy: Default
+      tolerations:
+      - operator: Exists
+      containers:
+      - name: node-cache
+        image: registry.k8s.io/dns/k8s-dns-node-cache:1.23.1
+        resources:
+          requests:
+            cpu: 25m
+            memory: 5Mi
+        args: ["-localip", "` + models.NodeLocalDNSAddress + `,__KUBE_DNS__", "-conf", "/etc/Corefile", "-upstreamsvc", "kube-dns-upstream"]
+        securityContext:
+          capabilities:
+            add: ["NET_ADMIN"]
+        ports:
+        - containerPort: 53
+          name: dns
+          protocol: UDP
+        - containerPort: 53
+          name: dns-tcp
+          protocol: TCP
+        livenessProbe:
+          httpGet:
+            host: ` + models.NodeLocalDNSAddress + `
+            path: /health
+            port: 8080
+          initialDelaySeconds: 60
+          timeoutSeconds: 5
+        volumeMounts:
+        - mountPath: /run/xtables.lock
+          name: xtables-lock
+        - name: config-volume
+          mountPath: /etc/coredns
+      volumes:
+      - name: xtables-lock
+        hostPath:
+          path: /run/xtables.lock
+          type: FileOrCreate
+      - name: config-volume
+        configMap:
+          name: node-local-dns
+          items:
+          - key: Corefile
+            path: Corefile.base
+`
@@ -0,0 +1,23 @@
+package provider
+
+import (
+	"context"
+	"time"
+)
+
+// ChangeRecord is an API call on a cloud resource found in the provider's
+// audit log
+type ChangeRecord struct {
+	EventName string // e.g. "TerminateInstances"
+	Principal string // Who made the call, e.g. an IAM user ARN
+	Time      time.Time
+}
+
+// ChangeAttributor is implemented by providers that keep an audit log of API
+// calls, such as AWS CloudTrail. The controller uses it to say who made
+// changes it did not make itself.
+type ChangeAttributor interface {
+	// LookupChange returns the latest of the named calls on a resource
+	// between start and end, or nil if the log has none (yet)
+	LookupChange(ctx context.Context, resourceID string, eventNames []string, start, end time.Time) (*ChangeRecord, error)
+}
@@ -0,0 +1,15 @@
+package provider
+
+import "context"
+
+// ClusterNetworkCleaner is implemented by compute services that give each
+// cluster network resources of its own outside a dedicated network, such as
+// security groups in a shared VPC
+type ClusterNetworkCleaner interface {
+	// DeleteClusterSecurityGroups removes the security groups of a cluster in
+	// region, with the detached network interfaces left in them. It returns
+	// ErrNetworkInUse while interfaces of terminating instances or other
+	// groups still hold them; deleting them again later continues where it
+	// stopped. A cluster without groups is no error.
+	DeleteClusterSecurityGroups(ctx context.Context, region, clusterName string) error
+}
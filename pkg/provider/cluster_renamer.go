@@ -0,0 +1,17 @@
+package provider
+
+import "context"
+
+// ClusterRenamer is implemented by providers whose cluster resources other
+// than instances carry the cluster name, so a rename has to move them too
+type ClusterRenamer interface {
+	// RenameClusterSecurityGroups moves the security groups of a cluster in
+	// region, and the network resources tagged like them, to a new name. The
+	// former name is recorded on them where it cannot be changed. It
+	// returns the IDs of the groups moved.
+	RenameClusterSecurityGroups(ctx context.Context, region, oldName, newName string) ([]string, error)
+
+	// RenameClusterIdentity lets the nodes of a renamed cluster, which keep
+	// the identity of the old name, reach the objects of the new one
+	RenameClusterIdentity(ctx context.Context, oldName, newName string) error
+}
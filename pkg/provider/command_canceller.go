@@ -0,0 +1,11 @@
+package provider
+
+import "context"
+
+// CommandCanceller is implemented by compute services that can stop a
+// command started with StartCommand before it completes
+type CommandCanceller interface {
+	// CancelCommand stops a command on all its instances. A command that
+	// already completed or is unknown is no error.
+	CancelCommand(ctx context.Context, commandID string) error
+}
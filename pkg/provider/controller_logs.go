@@ -0,0 +1,29 @@
+package provider
+
+import (
+	"context"
+	"time"
+)
+
+// LogEvent is a line logged by the controller
+type LogEvent struct {
+	ID      string // Unique per line, to skip lines already seen when following
+	Time    time.Time
+	Stream  string // Log stream, one per controller instance
+	Message string
+}
+
+// ControllerLogQuery selects controller log lines
+type ControllerLogQuery struct {
+	Cluster   string // Only lines mentioning the cluster
+	RequestID string // Every line of one controller invocation
+	Since     time.Time
+	Until     time.Time // Zero for now
+}
+
+// ControllerLogReader is implemented by providers whose controller logs can
+// be read back, such as the CloudWatch Logs of the controller Lambda on AWS
+type ControllerLogReader interface {
+	// ControllerLogs returns the matching lines, oldest first
+	ControllerLogs(ctx context.Context, query ControllerLogQuery) ([]LogEvent, error)
+}
@@ -0,0 +1,52 @@
+package provider
+
+// RotateTokenScript moves the cluster from the token in SecretServerToken to
+// the one in SecretServerTokenNext with 'k3s token rotate' on a server. Run
+// again after it succeeded, the new token is already the current one and
+// rotating it to itself succeeds. The script expects get_secret to be
+// defined.
+const RotateTokenScript = `
+set -e
+OLD=$(get_secret ` + SecretServerToken + `)
+NEW=$(get_secret ` + SecretServerTokenNext + `)
+if [ -z "$NEW" ]; then
+    echo "No new token to rotate to" >&2
+    exit 1
+fi
+if ! k3s token rotate --token "$OLD" --new-token "$NEW"; then
+    k3s token rotate --token "$NEW" --new-token "$NEW"
+fi
+echo "Token rotated"
+`
+
+// UpdateTokenScript writes the token in SecretServerToken to the K3s server
+// or agent unit of a node and restarts it, waiting for the API of a server
+// and for an agent to be active again. Restarting also reloads the
+// certificates of a server and fetches new client certificates for an
+// agent. The script expects get_secret to be defined.
+const UpdateTokenScript = `
+set -e
+TOKEN=$(get_secret ` + SecretServerToken + `)
+if [ -z "$TOKEN" ]; then
+    echo "No token stored" >&2
+    exit 1
+fi
+if systemctl is-enabled --quiet k3s 2>/dev/null; then UNIT=k3s; else UNIT=k3s-agent; fi
+for f in /etc/systemd/system/$UNIT.service /etc/systemd/system/$UNIT.service.env; do
+    if [ -f "$f" ]; then
+        sed -i -E "s#--token=[^ ]*#--token=$TOKEN#; s#^(SERVER|NODE)_TOKEN=.*#\1_TOKEN=$TOKEN#" "$f"
+    fi
+done
+systemctl daemon-reload
+systemctl restart $UNIT
+for i in $(seq 1 60); do
+    if [ "$UNIT" = "k3s" ]; then
+        k3s kubectl get --raw=/readyz >/dev/null 2>&1 && exit 0
+    else
+        systemctl is-active --quiet $UNIT && exit 0
+    fi
+    sleep 5
+done
+echo "$UNIT not ready after the restart" >&2
+exit 1
+`
@@ -0,0 +1,180 @@
+// Package debug wraps a provider for running the controller from a
+// developer's machine: every cloud call can be traced, and in dry-run mode
+// calls that change anything are recorded instead of made.
+package debug
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/madhouselabs/goman/pkg/provider"
+)
+
+// Options select what the wrapped provider does
+type Options struct {
+	// Trace logs every call with its arguments, duration and error
+	Trace bool
+
+	// DryRun records calls that create, change or delete resources instead
+	// of making them. Storage and secret writes are kept in memory, so the
+	// rest of the run reads them back; commands on nodes are not run and
+	// succeed without output. Locks are still taken, so a dry run does not
+	// race the controller.
+	DryRun bool
+
+	// Logf writes trace and dry-run lines, log.Printf if nil
+	Logf func(format string, args ...interface{})
+}
+
+// Provider is a provider with traced and, in dry-run mode, skipped calls
+type Provider struct {
+	provider.Provider
+	opts Options
+
+	mu      sync.Mutex
+	skipped []string
+	fakeIDs int
+
+	compute       *computeService
+	storage       *storageService
+	secrets       *secretService
+	locks         *lockService
+	notifications *notificationService
+	functions     *functionService
+}
+
+// Wrap returns p with the given options applied to all its services
+func Wrap(p provider.Provider, opts Options) *Provider {
+	if opts.Logf == nil {
+		opts.Logf = log.Printf
+	}
+	d := &Provider{Provider: p, opts: opts}
+	d.compute = &computeService{d: d, next: p.GetComputeService()}
+	d.storage = &storageService{d: d, next: p.GetStorageService(), written: map[string][]byte{}, deleted: map[string]bool{}}
+	d.secrets = &secretService{d: d, next: p.GetSecretService(), written: map[string][]byte{}, deleted: map[string]bool{}}
+	d.locks = &lockService{d: d, next: p.GetLockService()}
+	d.notifications = &notificationService{d: d, next: p.GetNotificationService()}
+	d.functions = &functionService{d: d, next: p.GetFunctionService()}
+	return d
+}
+
+func (d *Provider) GetComputeService() provider.ComputeService           { return d.compute }
+func (d *Provider) GetStorageService() provider.StorageService           { return d.storage }
+func (d *Provider) GetSecretService() provider.SecretService             { return d.secrets }
+func (d *Provider) GetLockService() provider.LockService                 { return d.locks }
+func (d *Provider) GetNotificationService() provider.NotificationService { return d.notifications }
+func (d *Provider) GetFunctionService() provider.FunctionService         { return d.functions }
+
+// Skipped returns the calls a dry run did not make, in call order
+func (d *Provider) Skipped() []string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return append([]string(nil), d.skipped...)
+}
+
+// ChangeAttributor, ServiceHealthChecker and MetricPublisher are passed on
+// when the wrapped provider has them, so the controller behaves the same
+
+func (d *Provider) LookupChange(ctx context.Context, resourceID string, eventNames []string, start, end time.Time) (*provider.ChangeRecord, error) {
+	attributor, ok := d.Provider.(provider.ChangeAttributor)
+	if !ok {
+		return nil, nil
+	}
+	var record *provider.ChangeRecord
+	err := d.call("audit.LookupChange", resourceID, func() (err error) {
+		record, err = attributor.LookupChange(ctx, resourceID, eventNames, start, end)
+		return err
+	})
+	return record, err
+}
+
+func (d *Provider) ServiceHealth(ctx context.Context, services []string) ([]provider.ServiceHealthEvent, error) {
+	checker, ok := d.Provider.(provider.ServiceHealthChecker)
+	if !ok {
+		return nil, nil
+	}
+	var events []provider.ServiceHealthEvent
+	err := d.call("health.ServiceHealth", strings.Join(services, ","), func() (err error) {
+		events, err = checker.ServiceHealth(ctx, services)
+		return err
+	})
+	return events, err
+}
+
+func (d *Provider) PublishMetric(ctx context.Context, metric provider.Metric) error {
+	publisher, ok := d.Provider.(provider.MetricPublisher)
+	if !ok {
+		return nil
+	}
+	return d.change("metrics.PublishMetric", metric.Name, func() error {
+		return publisher.PublishMetric(ctx, metric)
+	})
+}
+
+// call makes a call that only reads, tracing it
+func (d *Provider) call(name, args string, fn func() error) error {
+	if !d.opts.Trace {
+		return fn()
+	}
+	start := time.Now()
+	err := fn()
+	if err != nil {
+		d.opts.Logf("[TRACE] %s(%s) %s: %v", name, args, time.Since(start).Round(time.Millisecond), err)
+	} else {
+		d.opts.Logf("[TRACE] %s(%s) %s", name, args, time.Since(start).Round(time.Millisecond))
+	}
+	return err
+}
+
+// change makes a call that changes resources, or records it in dry-run mode
+func (d *Provider) change(name, args string, fn func() error) error {
+	if !d.opts.DryRun {
+		return d.call(name, args, fn)
+	}
+	line := fmt.Sprintf("%s(%s)", name, args)
+	d.mu.Lock()
+	d.skipped = append(d.skipped, line)
+	d.mu.Unlock()
+	d.opts.Logf("[DRY-RUN] Skipped %s", line)
+	return nil
+}
+
+// fakeID returns an ID for a resource a dry run pretends to create
+func (d *Provider) fakeID(prefix string) string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.fakeIDs++
+	return fmt.Sprintf("%s-dryrun%d", prefix, d.fakeIDs)
+}
+
+// formatMap renders tags and filters in key order
+func formatMap(m map[string]string) string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	parts := make([]string, 0, len(keys))
+	for _, k := range keys {
+		parts = append(parts, k+"="+m[k])
+	}
+	return strings.Join(parts, " ")
+}
+
+// firstLine shortens a command to its first line
+func firstLine(command string) string {
+	line, rest, _ := strings.Cut(strings.TrimSpace(command), "\n")
+	if len(line) > 80 {
+		line = line[:80]
+		rest = "..."
+	}
+	if rest != "" {
+		line += " ..."
+	}
+	return line
+}
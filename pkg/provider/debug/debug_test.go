@@ -0,0 +1,172 @@
+package debug
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/madhouselabs/goman/pkg/provider"
+)
+
+type fakeStorage struct {
+	provider.StorageService
+	objects map[string][]byte
+}
+
+func (s *fakeStorage) PutObject(ctx context.Context, key string, data []byte) error {
+	s.objects[key] = data
+	return nil
+}
+
+func (s *fakeStorage) GetObject(ctx context.Context, key string) ([]byte, error) {
+	data, ok := s.objects[key]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", provider.ErrNotFound, key)
+	}
+	return data, nil
+}
+
+func (s *fakeStorage) DeleteObject(ctx context.Context, key string) error {
+	delete(s.objects, key)
+	return nil
+}
+
+func (s *fakeStorage) ListObjects(ctx context.Context, prefix string) ([]string, error) {
+	var keys []string
+	for key := range s.objects {
+		if strings.HasPrefix(key, prefix) {
+			keys = append(keys, key)
+		}
+	}
+	return keys, nil
+}
+
+type fakeCompute struct {
+	provider.ComputeService
+	created int
+}
+
+func (c *fakeCompute) CreateInstance(ctx context.Context, config provider.InstanceConfig) (*provider.Instance, error) {
+	c.created++
+	return &provider.Instance{ID: "i-real", Name: config.Name}, nil
+}
+
+type fakeProvider struct {
+	provider.Provider
+	storage *fakeStorage
+	compute *fakeCompute
+}
+
+func (p *fakeProvider) GetStorageService() provider.StorageService { return p.storage }
+func (p *fakeProvider) GetComputeService() provider.ComputeService { return p.compute }
+
+func (p *fakeProvider) GetSecretService() provider.SecretService             { return nil }
+func (p *fakeProvider) GetLockService() provider.LockService                 { return nil }
+func (p *fakeProvider) GetNotificationService() provider.NotificationService { return nil }
+func (p *fakeProvider) GetFunctionService() provider.FunctionService         { return nil }
+
+func newFakeProvider() *fakeProvider {
+	return &fakeProvider{
+		storage: &fakeStorage{objects: map[string][]byte{
+			"clusters/demo/config.yaml": []byte("spec"),
+			"clusters/demo/status.yaml": []byte("status"),
+		}},
+		compute: &fakeCompute{},
+	}
+}
+
+func TestDryRunStorageOverlay(t *testing.T) {
+	inner := newFakeProvider()
+	var lines []string
+	d := Wrap(inner, Options{DryRun: true, Logf: func(format string, args ...interface{}) {
+		lines = append(lines, fmt.Sprintf(format, args...))
+	}})
+	storage := d.GetStorageService()
+	ctx := context.Background()
+
+	if err := storage.PutObject(ctx, "clusters/demo/status.yaml", []byte("new status")); err != nil {
+		t.Fatal(err)
+	}
+	if err := storage.PutObject(ctx, "clusters/demo/events.yaml", []byte("events")); err != nil {
+		t.Fatal(err)
+	}
+	if err := storage.DeleteObject(ctx, "clusters/demo/config.yaml"); err != nil {
+		t.Fatal(err)
+	}
+
+	// The stored objects are untouched
+	if string(inner.storage.objects["clusters/demo/status.yaml"]) != "status" || len(inner.storage.objects) != 2 {
+		t.Fatalf("stored objects changed: %v", inner.storage.objects)
+	}
+
+	// but the run reads its own writes back
+	if data, err := storage.GetObject(ctx, "clusters/demo/status.yaml"); err != nil || string(data) != "new status" {
+		t.Errorf("status = %q, %v", data, err)
+	}
+	if _, err := storage.GetObject(ctx, "clusters/demo/config.yaml"); !errors.Is(err, provider.ErrNotFound) {
+		t.Errorf("deleted object read: %v", err)
+	}
+	keys, err := storage.ListObjects(ctx, "clusters/demo/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"clusters/demo/events.yaml", "clusters/demo/status.yaml"}
+	if !reflect.DeepEqual(keys, want) {
+		t.Errorf("keys = %v, want %v", keys, want)
+	}
+
+	if got := d.Skipped(); len(got) != 3 || got[2] != "storage.DeleteObject(clusters/demo/config.yaml)" {
+		t.Errorf("skipped = %v", got)
+	}
+	if len(lines) != 3 || !strings.HasPrefix(lines[0], "[DRY-RUN] Skipped storage.PutObject") {
+		t.Errorf("log = %v", lines)
+	}
+}
+
+func TestDryRunCompute(t *testing.T) {
+	inner := newFakeProvider()
+	d := Wrap(inner, Options{DryRun: true, Logf: func(string, ...interface{}) {}})
+	ctx := context.Background()
+
+	instance, err := d.GetComputeService().CreateInstance(ctx, provider.InstanceConfig{Name: "demo-master-0"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if inner.compute.created != 0 || instance.ID != "i-dryrun1" || instance.Name != "demo-master-0" {
+		t.Errorf("instance %+v, %d created", instance, inner.compute.created)
+	}
+
+	result, err := d.GetComputeService().RunCommand(ctx, []string{"i-1"}, "systemctl restart k3s\necho done")
+	if err != nil || result.Status != "Success" || result.Instances["i-1"] == nil {
+		t.Errorf("command result %+v, %v", result, err)
+	}
+	if got := d.Skipped(); len(got) != 2 || got[1] != "compute.RunCommand(i-1: systemctl restart k3s ...)" {
+		t.Errorf("skipped = %v", got)
+	}
+}
+
+func TestTrace(t *testing.T) {
+	inner := newFakeProvider()
+	var lines []string
+	d := Wrap(inner, Options{Trace: true, Logf: func(format string, args ...interface{}) {
+		lines = append(lines, fmt.Sprintf(format, args...))
+	}})
+	ctx := context.Background()
+
+	if _, err := d.GetComputeService().CreateInstance(ctx, provider.InstanceConfig{Name: "demo-master-0", InstanceType: "t3.medium"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := d.GetStorageService().GetObject(ctx, "clusters/other/config.yaml"); err == nil {
+		t.Fatal("missing object found")
+	}
+	if inner.compute.created != 1 || len(d.Skipped()) != 0 {
+		t.Errorf("%d created, skipped %v without dry run", inner.compute.created, d.Skipped())
+	}
+	if len(lines) != 2 || !strings.HasPrefix(lines[0], "[TRACE] compute.CreateInstance(demo-master-0 t3.medium)") ||
+		!strings.Contains(lines[1], "not found") {
+		t.Errorf("trace = %q", lines)
+	}
+}
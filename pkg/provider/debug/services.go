@@ -0,0 +1,615 @@
+package debug
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/madhouselabs/goman/pkg/provider"
+)
+
+// computeService traces compute calls; in dry-run mode new instances get
+// fake IDs and commands succeed without running
+type computeService struct {
+	d    *Provider
+	next provider.ComputeService
+}
+
+func (s *computeService) CreateInstance(ctx context.Context, config provider.InstanceConfig) (*provider.Instance, error) {
+	var instance *provider.Instance
+	err := s.d.change("compute.CreateInstance", fmt.Sprintf("%s %s", config.Name, config.InstanceType), func() (err error) {
+		instance, err = s.next.CreateInstance(ctx, config)
+		return err
+	})
+	if instance == nil && err == nil {
+		instance = &provider.Instance{
+			ID:               s.d.fakeID("i"),
+			Name:             config.Name,
+			State:            "pending",
+			InstanceType:     config.InstanceType,
+			LaunchTime:       time.Now(),
+			Tags:             config.Tags,
+			AvailabilityZone: config.AvailabilityZone,
+		}
+	}
+	return instance, err
+}
+
+func (s *computeService) DeleteInstance(ctx context.Context, instanceID string) error {
+	return s.d.change("compute.DeleteInstance", instanceID, func() error {
+		return s.next.DeleteInstance(ctx, instanceID)
+	})
+}
+
+func (s *computeService) GetInstance(ctx context.Context, instanceID string) (*provider.Instance, error) {
+	var instance *provider.Instance
+	err := s.d.call("compute.GetInstance", instanceID, func() (err error) {
+		instance, err = s.next.GetInstance(ctx, instanceID)
+		return err
+	})
+	return instance, err
+}
+
+func (s *computeService) ListInstances(ctx context.Context, filters map[string]string) ([]*provider.Instance, error) {
+	var instances []*provider.Instance
+	err := s.d.call("compute.ListInstances", formatMap(filters), func() (err error) {
+		instances, err = s.next.ListInstances(ctx, filters)
+		return err
+	})
+	return instances, err
+}
+
+func (s *computeService) StartInstance(ctx context.Context, instanceID string) error {
+	return s.d.change("compute.StartInstance", instanceID, func() error {
+		return s.next.StartInstance(ctx, instanceID)
+	})
+}
+
+func (s *computeService) StopInstance(ctx context.Context, instanceID string) error {
+	return s.d.change("compute.StopInstance", instanceID, func() error {
+		return s.next.StopInstance(ctx, instanceID)
+	})
+}
+
+func (s *computeService) ModifyInstanceType(ctx context.Context, instanceID string, instanceType string) error {
+	return s.d.change("compute.ModifyInstanceType", instanceID+" "+instanceType, func() error {
+		return s.next.ModifyInstanceType(ctx, instanceID, instanceType)
+	})
+}
+
+func (s *computeService) TagInstance(ctx context.Context, instanceID string, tags map[string]string) error {
+	return s.d.change("compute.TagInstance", instanceID+" "+formatMap(tags), func() error {
+		return s.next.TagInstance(ctx, instanceID, tags)
+	})
+}
+
+func (s *computeService) PrepareVirtualIP(ctx context.Context, clusterName string, instanceIDs []string, address string) (string, error) {
+	result := address
+	err := s.d.change("compute.PrepareVirtualIP", fmt.Sprintf("%s %s %s", clusterName, strings.Join(instanceIDs, ","), address), func() (err error) {
+		result, err = s.next.PrepareVirtualIP(ctx, clusterName, instanceIDs, address)
+		return err
+	})
+	return result, err
+}
+
+func (s *computeService) SetInstanceProtection(ctx context.Context, instanceID string, shutdownBehavior string, stopProtection, deletionProtection bool) error {
+	return s.d.change("compute.SetInstanceProtection", fmt.Sprintf("%s %s stop-protection=%t deletion-protection=%t", instanceID, shutdownBehavior, stopProtection, deletionProtection), func() error {
+		return s.next.SetInstanceProtection(ctx, instanceID, shutdownBehavior, stopProtection, deletionProtection)
+	})
+}
+
+func (s *computeService) SyncClusterTags(ctx context.Context, clusterName string, tags map[string]string, removed []string) (*provider.TagSyncResult, error) {
+	result := &provider.TagSyncResult{}
+	err := s.d.change("compute.SyncClusterTags", fmt.Sprintf("%s %s -%s", clusterName, formatMap(tags), strings.Join(removed, ",")), func() (err error) {
+		result, err = s.next.SyncClusterTags(ctx, clusterName, tags, removed)
+		return err
+	})
+	return result, err
+}
+
+func (s *computeService) LinkClusters(ctx context.Context, link provider.ClusterLinkConfig) (*provider.ClusterLinkResult, error) {
+	result := &provider.ClusterLinkResult{Mode: provider.LinkModeSecurityGroup}
+	err := s.d.change("compute.LinkClusters", link.Cluster+" "+link.PeerCluster, func() (err error) {
+		result, err = s.next.LinkClusters(ctx, link)
+		return err
+	})
+	return result, err
+}
+
+func (s *computeService) UnlinkClusters(ctx context.Context, link provider.ClusterLinkConfig, peeringID string) error {
+	return s.d.change("compute.UnlinkClusters", link.Cluster+" "+link.PeerCluster, func() error {
+		return s.next.UnlinkClusters(ctx, link, peeringID)
+	})
+}
+
+func (s *computeService) RunCommand(ctx context.Context, instanceIDs []string, command string) (*provider.CommandResult, error) {
+	result := fakeCommandResult("", instanceIDs)
+	err := s.d.change("compute.RunCommand", strings.Join(instanceIDs, ",")+": "+firstLine(command), func() (err error) {
+		result, err = s.next.RunCommand(ctx, instanceIDs, command)
+		return err
+	})
+	return result, err
+}
+
+func (s *computeService) StartCommand(ctx context.Context, instanceIDs []string, command string) (string, error) {
+	commandID := s.d.fakeID("cmd")
+	err := s.d.change("compute.StartCommand", strings.Join(instanceIDs, ",")+": "+firstLine(command), func() (err error) {
+		commandID, err = s.next.StartCommand(ctx, instanceIDs, command)
+		return err
+	})
+	return commandID, err
+}
+
+func (s *computeService) GetCommandResult(ctx context.Context, commandID string) (*provider.CommandResult, error) {
+	if strings.HasPrefix(commandID, "cmd-dryrun") {
+		return fakeCommandResult(commandID, nil), nil
+	}
+	var result *provider.CommandResult
+	err := s.d.call("compute.GetCommandResult", commandID, func() (err error) {
+		result, err = s.next.GetCommandResult(ctx, commandID)
+		return err
+	})
+	return result, err
+}
+
+func (s *computeService) RunOperation(ctx context.Context, instanceIDs []string, operation string, params map[string]string) (*provider.CommandResult, error) {
+	result := fakeCommandResult("", instanceIDs)
+	err := s.d.change("compute.RunOperation", fmt.Sprintf("%s %s", strings.Join(instanceIDs, ","), operation), func() (err error) {
+		result, err = s.next.RunOperation(ctx, instanceIDs, operation, params)
+		return err
+	})
+	return result, err
+}
+
+func (s *computeService) EnsureClusterIdentity(ctx context.Context, clusterName string) (string, error) {
+	var profile string
+	err := s.d.change("compute.EnsureClusterIdentity", clusterName, func() (err error) {
+		profile, err = s.next.EnsureClusterIdentity(ctx, clusterName)
+		return err
+	})
+	return profile, err
+}
+
+func (s *computeService) ListClusterIdentities(ctx context.Context) ([]string, error) {
+	var names []string
+	err := s.d.call("compute.ListClusterIdentities", "", func() (err error) {
+		names, err = s.next.ListClusterIdentities(ctx)
+		return err
+	})
+	return names, err
+}
+
+func (s *computeService) DeleteClusterIdentity(ctx context.Context, clusterName string) error {
+	return s.d.change("compute.DeleteClusterIdentity", clusterName, func() error {
+		return s.next.DeleteClusterIdentity(ctx, clusterName)
+	})
+}
+
+// DeleteDedicatedNetwork is passed on when the wrapped compute service has it
+func (s *computeService) DeleteDedicatedNetwork(ctx context.Context, region, clusterName string) error {
+	networks, ok := s.next.(provider.DedicatedNetworkService)
+	if !ok {
+		return nil
+	}
+	return s.d.change("compute.DeleteDedicatedNetwork", region+" "+clusterName, func() error {
+		return networks.DeleteDedicatedNetwork(ctx, region, clusterName)
+	})
+}
+
+// DeleteClusterSecurityGroups is passed on when the wrapped compute service
+// has it
+func (s *computeService) DeleteClusterSecurityGroups(ctx context.Context, region, clusterName string) error {
+	cleaner, ok := s.next.(provider.ClusterNetworkCleaner)
+	if !ok {
+		return nil
+	}
+	return s.d.change("compute.DeleteClusterSecurityGroups", region+" "+clusterName, func() error {
+		return cleaner.DeleteClusterSecurityGroups(ctx, region, clusterName)
+	})
+}
+
+// EnsureAPILoadBalancer is passed on when the wrapped compute service has
+// it; a dry run returns a made-up DNS name
+func (s *computeService) EnsureAPILoadBalancer(ctx context.Context, region, clusterName string, instanceIDs []string, internal bool, allowedCIDRs []string) (string, error) {
+	balancers, ok := s.next.(provider.APILoadBalancerService)
+	if !ok {
+		return "", fmt.Errorf("provider has no API load balancers")
+	}
+	dnsName := s.d.fakeID("lb") + ".invalid"
+	err := s.d.change("compute.EnsureAPILoadBalancer", fmt.Sprintf("%s %s %s internal=%t", region, clusterName, strings.Join(instanceIDs, ","), internal), func() (err error) {
+		dnsName, err = balancers.EnsureAPILoadBalancer(ctx, region, clusterName, instanceIDs, internal, allowedCIDRs)
+		return err
+	})
+	return dnsName, err
+}
+
+// DeleteAPILoadBalancer is passed on when the wrapped compute service has it
+func (s *computeService) DeleteAPILoadBalancer(ctx context.Context, region, clusterName string) error {
+	balancers, ok := s.next.(provider.APILoadBalancerService)
+	if !ok {
+		return nil
+	}
+	return s.d.change("compute.DeleteAPILoadBalancer", region+" "+clusterName, func() error {
+		return balancers.DeleteAPILoadBalancer(ctx, region, clusterName)
+	})
+}
+
+// RememberInstanceRegion is passed on when the wrapped compute service has
+// it; it changes nothing in the cloud
+func (s *computeService) RememberInstanceRegion(instanceID, region string) {
+	if hinter, ok := s.next.(provider.InstanceRegionHinter); ok {
+		hinter.RememberInstanceRegion(instanceID, region)
+	}
+}
+
+// ListTaggedResources is passed on when the wrapped compute service has it
+func (s *computeService) ListTaggedResources(ctx context.Context, region string) ([]provider.TaggedResource, error) {
+	collector, ok := s.next.(provider.OrphanCollector)
+	if !ok {
+		return nil, nil
+	}
+	var resources []provider.TaggedResource
+	err := s.d.call("compute.ListTaggedResources", region, func() (err error) {
+		resources, err = collector.ListTaggedResources(ctx, region)
+		return err
+	})
+	return resources, err
+}
+
+// DeleteTaggedResource is passed on when the wrapped compute service has it
+func (s *computeService) DeleteTaggedResource(ctx context.Context, resource provider.TaggedResource) error {
+	collector, ok := s.next.(provider.OrphanCollector)
+	if !ok {
+		return nil
+	}
+	return s.d.change("compute.DeleteTaggedResource", resource.Kind+" "+resource.ID, func() error {
+		return collector.DeleteTaggedResource(ctx, resource)
+	})
+}
+
+// ListTaggedIdentities is passed on when the wrapped compute service has it
+func (s *computeService) ListTaggedIdentities(ctx context.Context) ([]provider.TaggedResource, error) {
+	collector, ok := s.next.(provider.OrphanCollector)
+	if !ok {
+		return nil, nil
+	}
+	var identities []provider.TaggedResource
+	err := s.d.call("compute.ListTaggedIdentities", "", func() (err error) {
+		identities, err = collector.ListTaggedIdentities(ctx)
+		return err
+	})
+	return identities, err
+}
+
+// InstallID is passed on when the wrapped compute service has it
+func (s *computeService) InstallID() string {
+	if collector, ok := s.next.(provider.OrphanCollector); ok {
+		return collector.InstallID()
+	}
+	return ""
+}
+
+// CheckClusterFirewall is passed on when the wrapped compute service has it
+func (s *computeService) CheckClusterFirewall(ctx context.Context, region, clusterName string) ([]provider.FirewallDrift, error) {
+	checker, ok := s.next.(provider.ClusterFirewallChecker)
+	if !ok {
+		return nil, nil
+	}
+	var drift []provider.FirewallDrift
+	err := s.d.call("compute.CheckClusterFirewall", clusterName, func() (err error) {
+		drift, err = checker.CheckClusterFirewall(ctx, region, clusterName)
+		return err
+	})
+	return drift, err
+}
+
+// RestoreClusterFirewall is passed on when the wrapped compute service has it
+func (s *computeService) RestoreClusterFirewall(ctx context.Context, region, clusterName string) error {
+	checker, ok := s.next.(provider.ClusterFirewallChecker)
+	if !ok {
+		return nil
+	}
+	return s.d.change("compute.RestoreClusterFirewall", clusterName, func() error {
+		return checker.RestoreClusterFirewall(ctx, region, clusterName)
+	})
+}
+
+// CancelCommand is passed on when the wrapped compute service has it
+func (s *computeService) CancelCommand(ctx context.Context, commandID string) error {
+	cancelling, ok := s.next.(provider.CommandCanceller)
+	if !ok || strings.HasPrefix(commandID, "cmd-dryrun") {
+		return nil
+	}
+	return s.d.change("compute.CancelCommand", commandID, func() error {
+		return cancelling.CancelCommand(ctx, commandID)
+	})
+}
+
+// fakeCommandResult is the result of a command a dry run did not run
+func fakeCommandResult(commandID string, instanceIDs []string) *provider.CommandResult {
+	result := &provider.CommandResult{CommandID: commandID, Status: "Success", Instances: map[string]*provider.InstanceCommandResult{}}
+	for _, id := range instanceIDs {
+		result.Instances[id] = &provider.InstanceCommandResult{InstanceID: id, Status: "Success"}
+	}
+	return result
+}
+
+// storageService traces storage calls; in dry-run mode writes go to memory
+type storageService struct {
+	d       *Provider
+	next    provider.StorageService
+	mu      sync.Mutex
+	written map[string][]byte
+	deleted map[string]bool
+}
+
+func (s *storageService) Initialize(ctx context.Context) error {
+	return s.d.call("storage.Initialize", "", func() error { return s.next.Initialize(ctx) })
+}
+
+func (s *storageService) PutObject(ctx context.Context, key string, data []byte) error {
+	err := s.d.change("storage.PutObject", fmt.Sprintf("%s, %d bytes", key, len(data)), func() error {
+		return s.next.PutObject(ctx, key, data)
+	})
+	if err == nil && s.d.opts.DryRun {
+		s.mu.Lock()
+		s.written[key] = append([]byte(nil), data...)
+		delete(s.deleted, key)
+		s.mu.Unlock()
+	}
+	return err
+}
+
+func (s *storageService) GetObject(ctx context.Context, key string) ([]byte, error) {
+	s.mu.Lock()
+	data, written := s.written[key]
+	deleted := s.deleted[key]
+	s.mu.Unlock()
+	if written {
+		return append([]byte(nil), data...), nil
+	}
+	if deleted {
+		return nil, fmt.Errorf("%w: %s (deleted by the dry run)", provider.ErrNotFound, key)
+	}
+	err := s.d.call("storage.GetObject", key, func() (err error) {
+		data, err = s.next.GetObject(ctx, key)
+		return err
+	})
+	return data, err
+}
+
+func (s *storageService) DeleteObject(ctx context.Context, key string) error {
+	err := s.d.change("storage.DeleteObject", key, func() error {
+		return s.next.DeleteObject(ctx, key)
+	})
+	if err == nil && s.d.opts.DryRun {
+		s.mu.Lock()
+		delete(s.written, key)
+		s.deleted[key] = true
+		s.mu.Unlock()
+	}
+	return err
+}
+
+func (s *storageService) ListObjects(ctx context.Context, prefix string) ([]string, error) {
+	var keys []string
+	err := s.d.call("storage.ListObjects", prefix, func() (err error) {
+		keys, err = s.next.ListObjects(ctx, prefix)
+		return err
+	})
+	if err != nil || !s.d.opts.DryRun {
+		return keys, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	listed := make(map[string]bool, len(keys))
+	var merged []string
+	for _, key := range keys {
+		listed[key] = true
+		if !s.deleted[key] {
+			merged = append(merged, key)
+		}
+	}
+	for key := range s.written {
+		if strings.HasPrefix(key, prefix) && !listed[key] {
+			merged = append(merged, key)
+		}
+	}
+	sort.Strings(merged)
+	return merged, nil
+}
+
+// secretService traces secret calls; in dry-run mode writes go to memory
+// and values are never logged
+type secretService struct {
+	d       *Provider
+	next    provider.SecretService
+	mu      sync.Mutex
+	written map[string][]byte
+	deleted map[string]bool
+}
+
+func (s *secretService) PutSecret(ctx context.Context, clusterName, name string, value []byte) error {
+	err := s.d.change("secrets.PutSecret", clusterName+"/"+name, func() error {
+		return s.next.PutSecret(ctx, clusterName, name, value)
+	})
+	if err == nil && s.d.opts.DryRun {
+		s.mu.Lock()
+		s.written[clusterName+"/"+name] = append([]byte(nil), value...)
+		delete(s.deleted, clusterName+"/"+name)
+		s.mu.Unlock()
+	}
+	return err
+}
+
+func (s *secretService) GetSecret(ctx context.Context, clusterName, name string) ([]byte, error) {
+	key := clusterName + "/" + name
+	s.mu.Lock()
+	value, written := s.written[key]
+	deleted := s.deleted[key]
+	s.mu.Unlock()
+	if written {
+		return append([]byte(nil), value...), nil
+	}
+	if deleted {
+		return nil, fmt.Errorf("%w: secret %s (deleted by the dry run)", provider.ErrNotFound, key)
+	}
+	err := s.d.call("secrets.GetSecret", key, func() (err error) {
+		value, err = s.next.GetSecret(ctx, clusterName, name)
+		return err
+	})
+	return value, err
+}
+
+func (s *secretService) DeleteSecret(ctx context.Context, clusterName, name string) error {
+	err := s.d.change("secrets.DeleteSecret", clusterName+"/"+name, func() error {
+		return s.next.DeleteSecret(ctx, clusterName, name)
+	})
+	if err == nil && s.d.opts.DryRun {
+		s.mu.Lock()
+		delete(s.written, clusterName+"/"+name)
+		s.deleted[clusterName+"/"+name] = true
+		s.mu.Unlock()
+	}
+	return err
+}
+
+func (s *secretService) Backend() string {
+	return s.next.Backend()
+}
+
+// lockService traces lock calls; locks are taken in dry-run mode too
+type lockService struct {
+	d    *Provider
+	next provider.LockService
+}
+
+func (s *lockService) Initialize(ctx context.Context) error {
+	return s.d.call("locks.Initialize", "", func() error { return s.next.Initialize(ctx) })
+}
+
+func (s *lockService) AcquireLock(ctx context.Context, resourceID string, owner string, ttl time.Duration) (string, error) {
+	var token string
+	err := s.d.call("locks.AcquireLock", resourceID+" "+owner, func() (err error) {
+		token, err = s.next.AcquireLock(ctx, resourceID, owner, ttl)
+		return err
+	})
+	return token, err
+}
+
+func (s *lockService) AcquireLockWithMetadata(ctx context.Context, resourceID string, owner string, ttl time.Duration, metadata *provider.LockMetadata) (string, error) {
+	var token string
+	err := s.d.call("locks.AcquireLockWithMetadata", resourceID+" "+owner, func() (err error) {
+		token, err = s.next.AcquireLockWithMetadata(ctx, resourceID, owner, ttl, metadata)
+		return err
+	})
+	return token, err
+}
+
+func (s *lockService) ReleaseLock(ctx context.Context, resourceID string, token string) error {
+	return s.d.call("locks.ReleaseLock", resourceID, func() error {
+		return s.next.ReleaseLock(ctx, resourceID, token)
+	})
+}
+
+func (s *lockService) RenewLock(ctx context.Context, resourceID string, token string, ttl time.Duration) error {
+	return s.d.call("locks.RenewLock", resourceID, func() error {
+		return s.next.RenewLock(ctx, resourceID, token, ttl)
+	})
+}
+
+func (s *lockService) IsLocked(ctx context.Context, resourceID string) (bool, string, error) {
+	var locked bool
+	var owner string
+	err := s.d.call("locks.IsLocked", resourceID, func() (err error) {
+		locked, owner, err = s.next.IsLocked(ctx, resourceID)
+		return err
+	})
+	return locked, owner, err
+}
+
+// notificationService traces notification calls; nothing is published in
+// dry-run mode
+type notificationService struct {
+	d    *Provider
+	next provider.NotificationService
+}
+
+func (s *notificationService) Initialize(ctx context.Context) error {
+	return s.d.call("notifications.Initialize", "", func() error { return s.next.Initialize(ctx) })
+}
+
+func (s *notificationService) Publish(ctx context.Context, topic string, message string) error {
+	return s.d.change("notifications.Publish", topic, func() error {
+		return s.next.Publish(ctx, topic, message)
+	})
+}
+
+func (s *notificationService) Subscribe(ctx context.Context, topic string) (string, error) {
+	var id string
+	err := s.d.change("notifications.Subscribe", topic, func() (err error) {
+		id, err = s.next.Subscribe(ctx, topic)
+		return err
+	})
+	return id, err
+}
+
+func (s *notificationService) Unsubscribe(ctx context.Context, subscriptionID string) error {
+	return s.d.change("notifications.Unsubscribe", subscriptionID, func() error {
+		return s.next.Unsubscribe(ctx, subscriptionID)
+	})
+}
+
+// functionService traces function calls; in dry-run mode functions are
+// neither deployed nor invoked, so a run never requeues itself
+type functionService struct {
+	d    *Provider
+	next provider.FunctionService
+}
+
+func (s *functionService) Initialize(ctx context.Context) error {
+	return s.d.call("functions.Initialize", "", func() error { return s.next.Initialize(ctx) })
+}
+
+func (s *functionService) DeployFunction(ctx context.Context, name string, packagePath string) error {
+	return s.d.change("functions.DeployFunction", name, func() error {
+		return s.next.DeployFunction(ctx, name, packagePath)
+	})
+}
+
+func (s *functionService) InvokeFunction(ctx context.Context, name string, payload []byte) ([]byte, error) {
+	var output []byte
+	err := s.d.change("functions.InvokeFunction", name, func() (err error) {
+		output, err = s.next.InvokeFunction(ctx, name, payload)
+		return err
+	})
+	return output, err
+}
+
+func (s *functionService) DeleteFunction(ctx context.Context, name string) error {
+	return s.d.change("functions.DeleteFunction", name, func() error {
+		return s.next.DeleteFunction(ctx, name)
+	})
+}
+
+func (s *functionService) FunctionExists(ctx context.Context, name string) (bool, error) {
+	var exists bool
+	err := s.d.call("functions.FunctionExists", name, func() (err error) {
+		exists, err = s.next.FunctionExists(ctx, name)
+		return err
+	})
+	return exists, err
+}
+
+func (s *functionService) GetFunctionURL(ctx context.Context, name string) (string, error) {
+	var url string
+	err := s.d.call("functions.GetFunctionURL", name, func() (err error) {
+		url, err = s.next.GetFunctionURL(ctx, name)
+		return err
+	})
+	return url, err
+}
@@ -0,0 +1,20 @@
+package provider
+
+import (
+	"context"
+	"errors"
+)
+
+// DedicatedNetworkService is implemented by compute services that give
+// clusters a network of their own (a VPC on AWS), created by CreateInstance
+// for instances with InstanceConfig.DedicatedVPC set
+type DedicatedNetworkService interface {
+	// DeleteDedicatedNetwork removes a cluster's network in region and
+	// everything in it. It returns ErrNetworkInUse while instances or other
+	// resources being deleted are still in it; deleting it again later
+	// continues where it stopped. A cluster without a network is no error.
+	DeleteDedicatedNetwork(ctx context.Context, region, clusterName string) error
+}
+
+// ErrNetworkInUse is returned when a cluster's network cannot be deleted yet
+var ErrNetworkInUse = errors.New("network is still in use")
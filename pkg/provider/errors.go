@@ -0,0 +1,159 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// ErrorClass is a provider-agnostic classification of a failed cloud call
+type ErrorClass string
+
+const (
+	ErrorClassThrottling ErrorClass = "Throttling"
+	ErrorClassNotFound   ErrorClass = "NotFound"
+	ErrorClassPermission ErrorClass = "Permission"
+	ErrorClassValidation ErrorClass = "Validation"
+	ErrorClassQuota      ErrorClass = "Quota"
+	ErrorClassCapacity   ErrorClass = "Capacity"
+	ErrorClassTransient  ErrorClass = "Transient"
+	ErrorClassUnknown    ErrorClass = "Unknown"
+)
+
+// OperationError describes a failed cloud API call with enough context to
+// correlate it with the provider's audit log (e.g., CloudTrail for AWS)
+type OperationError struct {
+	Service   string     // e.g., "ec2", "ssm"
+	Operation string     // API operation, e.g., "RunInstances"
+	Code      string     // Provider error code, e.g., "UnauthorizedOperation"
+	RequestID string     // Provider request ID
+	Class     ErrorClass // Classification of the failure
+	Err       error
+}
+
+func (e *OperationError) Error() string {
+	if e.RequestID != "" {
+		return fmt.Sprintf("%s %s failed (%s, request %s): %v", e.Service, e.Operation, e.Code, e.RequestID, e.Err)
+	}
+	return fmt.Sprintf("%s %s failed (%s): %v", e.Service, e.Operation, e.Code, e.Err)
+}
+
+func (e *OperationError) Unwrap() error {
+	return e.Err
+}
+
+// Retryable reports whether the failure is likely to succeed on retry
+func (e *OperationError) Retryable() bool {
+	switch e.Class {
+	case ErrorClassThrottling, ErrorClassTransient, ErrorClassCapacity:
+		return true
+	}
+	return false
+}
+
+// AsOperationError extracts the first OperationError in an error chain
+func AsOperationError(err error) (*OperationError, bool) {
+	var opErr *OperationError
+	if errors.As(err, &opErr) {
+		return opErr, true
+	}
+	return nil, false
+}
+
+// ErrNotFound matches, with errors.Is, failures caused by a missing object,
+// secret or cloud resource
+var ErrNotFound = errors.New("not found")
+
+// Is makes errors.Is(err, ErrNotFound) true for cloud calls that failed
+// because the resource does not exist
+func (e *OperationError) Is(target error) bool {
+	return target == ErrNotFound && e.Class == ErrorClassNotFound
+}
+
+// ErrorCategory is the user-facing category of a failure. It selects the
+// guidance shown with the error and how soon the controller retries.
+type ErrorCategory string
+
+const (
+	CategoryUserConfig     ErrorCategory = "UserConfigError"     // The spec or the account setup needs a change
+	CategoryCloudQuota     ErrorCategory = "CloudQuotaError"     // An account limit was reached
+	CategoryTransientCloud ErrorCategory = "TransientCloudError" // Throttling, outages or missing capacity
+	CategoryBootstrap      ErrorCategory = "BootstrapError"      // Nodes failed to install or join K3s
+	CategoryInternal       ErrorCategory = "InternalError"       // Anything else, likely a goman bug
+)
+
+// CategorizedError assigns a category to a failure that the cloud error
+// classes can't tell, such as an invalid spec or a node that can't join
+type CategorizedError struct {
+	Category ErrorCategory
+	Err      error
+}
+
+func (e *CategorizedError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *CategorizedError) Unwrap() error {
+	return e.Err
+}
+
+// UserConfigErrorf returns an error the user fixes by changing the spec
+func UserConfigErrorf(format string, args ...interface{}) error {
+	return &CategorizedError{Category: CategoryUserConfig, Err: fmt.Errorf(format, args...)}
+}
+
+// BootstrapErrorf returns an error about nodes failing to form the cluster
+func BootstrapErrorf(format string, args ...interface{}) error {
+	return &CategorizedError{Category: CategoryBootstrap, Err: fmt.Errorf(format, args...)}
+}
+
+// CategoryOf returns the category of an error marked with CategorizedError
+// or carrying an OperationError. ok is false for errors without either.
+func CategoryOf(err error) (category ErrorCategory, ok bool) {
+	var catErr *CategorizedError
+	if errors.As(err, &catErr) {
+		return catErr.Category, true
+	}
+	if opErr, ok := AsOperationError(err); ok {
+		switch opErr.Class {
+		case ErrorClassValidation, ErrorClassPermission:
+			return CategoryUserConfig, true
+		case ErrorClassQuota:
+			return CategoryCloudQuota, true
+		case ErrorClassThrottling, ErrorClassTransient, ErrorClassCapacity:
+			return CategoryTransientCloud, true
+		default:
+			return CategoryInternal, true
+		}
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return CategoryTransientCloud, true
+	}
+	return "", false
+}
+
+// Categorize returns the category of an error, InternalError for errors
+// nothing is known about
+func Categorize(err error) ErrorCategory {
+	if category, ok := CategoryOf(err); ok {
+		return category
+	}
+	return CategoryInternal
+}
+
+// Guidance tells the user what to do about a failure of the category
+func (c ErrorCategory) Guidance() string {
+	switch c {
+	case CategoryUserConfig:
+		return "Fix the cluster spec with `goman cluster edit`, or the IAM permissions if access was denied; goman retries after the change"
+	case CategoryCloudQuota:
+		return "An AWS account limit was reached; free up resources or request a quota increase in the Service Quotas console. goman keeps retrying slowly"
+	case CategoryTransientCloud:
+		return "Temporary cloud problem (throttling, outage or no capacity); goman retries automatically"
+	case CategoryBootstrap:
+		return "Nodes could not form the cluster; check the node logs with `goman cluster status` and the instances' console output. goman keeps retrying"
+	case CategoryInternal:
+		return "Unexpected error; goman retries, and if it persists please report it with the controller logs"
+	}
+	return ""
+}
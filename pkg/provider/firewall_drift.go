@@ -0,0 +1,24 @@
+package provider
+
+import "context"
+
+// FirewallDrift is a security group of a cluster whose rules differ from
+// the rules goman sets up
+type FirewallDrift struct {
+	GroupID   string
+	GroupName string
+	Missing   []string // Rules goman sets up that are gone, e.g. "tcp 6443 from the cluster"
+	Open      []string // Rules admitting any address, e.g. "tcp 22 from 0.0.0.0/0"
+}
+
+// ClusterFirewallChecker is implemented by compute services that can
+// compare the firewall of a cluster with the rules goman sets up for it
+type ClusterFirewallChecker interface {
+	// CheckClusterFirewall returns the security groups of a cluster in
+	// region whose rules drifted; none when all match
+	CheckClusterFirewall(ctx context.Context, region, clusterName string) ([]FirewallDrift, error)
+
+	// RestoreClusterFirewall adds the missing rules back and removes the
+	// rules open to any address
+	RestoreClusterFirewall(ctx context.Context, region, clusterName string) error
+}
@@ -0,0 +1,329 @@
+package gcp
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	cloudPlatformScope = "https://www.googleapis.com/auth/cloud-platform"
+	oauthTokenURL      = "https://oauth2.googleapis.com/token"
+)
+
+// credentials is an application default credentials file: a service
+// account key or the user credentials written by
+// 'gcloud auth application-default login'
+type credentials struct {
+	Type         string `json:"type"`
+	ProjectID    string `json:"project_id"`
+	ClientEmail  string `json:"client_email"`
+	PrivateKey   string `json:"private_key"`
+	ClientID     string `json:"client_id"`
+	ClientSecret string `json:"client_secret"`
+	RefreshToken string `json:"refresh_token"`
+	QuotaProject string `json:"quota_project_id"`
+}
+
+// tokenSource hands out OAuth access tokens, refreshing them before they
+// expire. Credentials are looked up the way Google's client libraries do:
+// GOOGLE_OAUTH_ACCESS_TOKEN, then GOOGLE_APPLICATION_CREDENTIALS, then the
+// gcloud application default credentials, then the metadata server of the
+// VM or function goman runs on.
+type tokenSource struct {
+	httpClient *http.Client
+	creds      *credentials // nil on the metadata server
+
+	mu     sync.Mutex
+	token  string
+	expiry time.Time
+}
+
+// newTokenSource finds the credentials goman authenticates with
+func newTokenSource(httpClient *http.Client) (*tokenSource, error) {
+	ts := &tokenSource{httpClient: httpClient}
+	if os.Getenv("GOOGLE_OAUTH_ACCESS_TOKEN") != "" {
+		return ts, nil
+	}
+
+	path := os.Getenv("GOOGLE_APPLICATION_CREDENTIALS")
+	if path == "" {
+		if adc := gcloudCredentialsPath(); fileExists(adc) {
+			path = adc
+		}
+	}
+	if path == "" {
+		// Running on GCP; the metadata server holds the credentials
+		return ts, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read GCP credentials %s: %w", path, err)
+	}
+	var creds credentials
+	if err := json.Unmarshal(data, &creds); err != nil {
+		return nil, fmt.Errorf("failed to parse GCP credentials %s: %w", path, err)
+	}
+	switch creds.Type {
+	case "service_account", "authorized_user":
+	default:
+		return nil, fmt.Errorf("unsupported GCP credentials type %q in %s", creds.Type, path)
+	}
+	ts.creds = &creds
+	return ts, nil
+}
+
+// projectID returns the project of the credentials, if they name one
+func (t *tokenSource) projectID() string {
+	if t.creds == nil {
+		return ""
+	}
+	if t.creds.ProjectID != "" {
+		return t.creds.ProjectID
+	}
+	return t.creds.QuotaProject
+}
+
+// quotaProject returns the project user credentials bill API calls to.
+// Service accounts bill their own project.
+func (t *tokenSource) quotaProject() string {
+	if t.creds == nil || t.creds.Type != "authorized_user" {
+		return ""
+	}
+	return t.creds.QuotaProject
+}
+
+// Token returns a valid access token
+func (t *tokenSource) Token(ctx context.Context) (string, error) {
+	if token := os.Getenv("GOOGLE_OAUTH_ACCESS_TOKEN"); token != "" {
+		return token, nil
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.token != "" && time.Until(t.expiry) > time.Minute {
+		return t.token, nil
+	}
+
+	var resp tokenResponse
+	var err error
+	switch {
+	case t.creds == nil:
+		err = t.metadataJSON(ctx, "instance/service-accounts/default/token", &resp)
+	case t.creds.Type == "service_account":
+		resp, err = t.exchangeJWT(ctx, map[string]interface{}{"scope": cloudPlatformScope})
+	default:
+		resp, err = t.refresh(ctx)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to get GCP access token: %w", err)
+	}
+	t.token = resp.AccessToken
+	t.expiry = time.Now().Add(time.Duration(resp.ExpiresIn) * time.Second)
+	return t.token, nil
+}
+
+// IdentityToken returns an OpenID Connect token for audience, which Cloud
+// Functions and Cloud Run require of callers
+func (t *tokenSource) IdentityToken(ctx context.Context, audience string) (string, error) {
+	switch {
+	case t.creds == nil:
+		data, err := t.metadata(ctx, "instance/service-accounts/default/identity?audience="+url.QueryEscape(audience)+"&format=full")
+		return strings.TrimSpace(string(data)), err
+	case t.creds.Type == "service_account":
+		resp, err := t.exchangeJWT(ctx, map[string]interface{}{"target_audience": audience})
+		return resp.IDToken, err
+	default:
+		// User credentials cannot mint tokens for another audience
+		out, err := exec.CommandContext(ctx, "gcloud", "auth", "print-identity-token").Output()
+		if err != nil {
+			return "", fmt.Errorf("failed to get an identity token with gcloud: %w", err)
+		}
+		return strings.TrimSpace(string(out)), nil
+	}
+}
+
+// Email returns the account the credentials belong to, for audit records
+func (t *tokenSource) Email(ctx context.Context) (string, error) {
+	if t.creds != nil && t.creds.ClientEmail != "" {
+		return t.creds.ClientEmail, nil
+	}
+	if t.creds == nil && os.Getenv("GOOGLE_OAUTH_ACCESS_TOKEN") == "" {
+		data, err := t.metadata(ctx, "instance/service-accounts/default/email")
+		return strings.TrimSpace(string(data)), err
+	}
+
+	token, err := t.Token(ctx)
+	if err != nil {
+		return "", err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "https://oauth2.googleapis.com/tokeninfo?access_token="+url.QueryEscape(token), nil)
+	if err != nil {
+		return "", err
+	}
+	var info struct {
+		Email string `json:"email"`
+	}
+	if err := t.doJSON(req, &info); err != nil {
+		return "", err
+	}
+	return info.Email, nil
+}
+
+type tokenResponse struct {
+	AccessToken string `json:"access_token"`
+	IDToken     string `json:"id_token"`
+	ExpiresIn   int    `json:"expires_in"`
+}
+
+// exchangeJWT trades a JWT signed with the service account key for a token
+func (t *tokenSource) exchangeJWT(ctx context.Context, claims map[string]interface{}) (tokenResponse, error) {
+	block, _ := pem.Decode([]byte(t.creds.PrivateKey))
+	if block == nil {
+		return tokenResponse{}, fmt.Errorf("service account key of %s is not PEM encoded", t.creds.ClientEmail)
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return tokenResponse{}, fmt.Errorf("failed to parse service account key: %w", err)
+	}
+	key, ok := parsed.(*rsa.PrivateKey)
+	if !ok {
+		return tokenResponse{}, fmt.Errorf("service account key of %s is not an RSA key", t.creds.ClientEmail)
+	}
+
+	now := time.Now()
+	claims["iss"] = t.creds.ClientEmail
+	claims["aud"] = oauthTokenURL
+	claims["iat"] = now.Unix()
+	claims["exp"] = now.Add(time.Hour).Unix()
+	header, _ := json.Marshal(map[string]string{"alg": "RS256", "typ": "JWT"})
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return tokenResponse{}, err
+	}
+	signingInput := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	sum := sha256.Sum256([]byte(signingInput))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, sum[:])
+	if err != nil {
+		return tokenResponse{}, fmt.Errorf("failed to sign token request: %w", err)
+	}
+
+	form := url.Values{
+		"grant_type": {"urn:ietf:params:oauth:grant-type:jwt-bearer"},
+		"assertion":  {signingInput + "." + base64.RawURLEncoding.EncodeToString(signature)},
+	}
+	return t.postForm(ctx, form)
+}
+
+// refresh trades the user's refresh token for an access token
+func (t *tokenSource) refresh(ctx context.Context) (tokenResponse, error) {
+	form := url.Values{
+		"grant_type":    {"refresh_token"},
+		"client_id":     {t.creds.ClientID},
+		"client_secret": {t.creds.ClientSecret},
+		"refresh_token": {t.creds.RefreshToken},
+	}
+	return t.postForm(ctx, form)
+}
+
+func (t *tokenSource) postForm(ctx context.Context, form url.Values) (tokenResponse, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, oauthTokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return tokenResponse{}, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	var resp tokenResponse
+	err = t.doJSON(req, &resp)
+	return resp, err
+}
+
+// metadataHost is the metadata server, GCE_METADATA_HOST for emulators
+func metadataHost() string {
+	if host := os.Getenv("GCE_METADATA_HOST"); host != "" {
+		return host
+	}
+	return "metadata.google.internal"
+}
+
+// metadata reads a value from the metadata server
+func (t *tokenSource) metadata(ctx context.Context, path string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "http://"+metadataHost()+"/computeMetadata/v1/"+path, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Metadata-Flavor", "Google")
+	resp, err := t.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("metadata server not reachable (not running on GCP and no credentials found; run 'gcloud auth application-default login'): %w", err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("metadata server returned %s for %s", resp.Status, path)
+	}
+	return data, nil
+}
+
+func (t *tokenSource) metadataJSON(ctx context.Context, path string, out interface{}) error {
+	data, err := t.metadata(ctx, path)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, out)
+}
+
+func (t *tokenSource) doJSON(req *http.Request, out interface{}) error {
+	resp, err := t.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s returned %s: %s", req.URL.Host, resp.Status, bytes.TrimSpace(data))
+	}
+	return json.Unmarshal(data, out)
+}
+
+// gcloudCredentialsPath returns where 'gcloud auth application-default
+// login' writes the user's credentials
+func gcloudCredentialsPath() string {
+	dir := os.Getenv("CLOUDSDK_CONFIG")
+	if dir == "" {
+		if appData := os.Getenv("APPDATA"); appData != "" {
+			dir = filepath.Join(appData, "gcloud")
+		} else if home, err := os.UserHomeDir(); err == nil {
+			dir = filepath.Join(home, ".config", "gcloud")
+		}
+	}
+	return filepath.Join(dir, "application_default_credentials.json")
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
@@ -0,0 +1,254 @@
+package gcp
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+// roundTripFunc answers requests without a network
+type roundTripFunc func(req *http.Request) *http.Response
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req), nil
+}
+
+func jsonResponse(status int, body string) *http.Response {
+	return &http.Response{
+		StatusCode: status,
+		Status:     http.StatusText(status),
+		Header:     http.Header{"Content-Type": {"application/json"}},
+		Body:       io.NopCloser(strings.NewReader(body)),
+	}
+}
+
+func serviceAccountKey(t *testing.T) (*rsa.PrivateKey, string) {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key, string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}))
+}
+
+func TestExchangeJWT(t *testing.T) {
+	t.Setenv("GOOGLE_OAUTH_ACCESS_TOKEN", "")
+	key, keyPEM := serviceAccountKey(t)
+
+	tests := []struct {
+		name   string
+		token  func(ts *tokenSource) (string, error)
+		claim  string
+		value  string
+		answer string
+	}{
+		{
+			name:   "access token",
+			token:  func(ts *tokenSource) (string, error) { return ts.Token(context.Background()) },
+			claim:  "scope",
+			value:  cloudPlatformScope,
+			answer: `{"access_token":"ya29.token","expires_in":3600}`,
+		},
+		{
+			name: "identity token",
+			token: func(ts *tokenSource) (string, error) {
+				return ts.IdentityToken(context.Background(), "https://fn.example")
+			},
+			claim:  "target_audience",
+			value:  "https://fn.example",
+			answer: `{"id_token":"ya29.token"}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var form url.Values
+			client := &http.Client{Transport: roundTripFunc(func(req *http.Request) *http.Response {
+				if req.URL.String() != oauthTokenURL || req.Method != http.MethodPost {
+					t.Errorf("request to %s %s", req.Method, req.URL)
+				}
+				body, _ := io.ReadAll(req.Body)
+				form, _ = url.ParseQuery(string(body))
+				return jsonResponse(http.StatusOK, tt.answer)
+			})}
+			ts := &tokenSource{httpClient: client, creds: &credentials{
+				Type:        "service_account",
+				ClientEmail: "goman@project.iam.gserviceaccount.com",
+				PrivateKey:  keyPEM,
+			}}
+
+			token, err := tt.token(ts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if token != "ya29.token" {
+				t.Errorf("token %q", token)
+			}
+			if form.Get("grant_type") != "urn:ietf:params:oauth:grant-type:jwt-bearer" {
+				t.Errorf("grant type %q", form.Get("grant_type"))
+			}
+
+			parts := strings.Split(form.Get("assertion"), ".")
+			if len(parts) != 3 {
+				t.Fatalf("assertion has %d parts", len(parts))
+			}
+			signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+			if err != nil {
+				t.Fatal(err)
+			}
+			sum := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+			if err := rsa.VerifyPKCS1v15(&key.PublicKey, crypto.SHA256, sum[:], signature); err != nil {
+				t.Errorf("signature does not verify: %v", err)
+			}
+
+			var header map[string]string
+			decodeSegment(t, parts[0], &header)
+			if header["alg"] != "RS256" || header["typ"] != "JWT" {
+				t.Errorf("header %v", header)
+			}
+			var claims map[string]interface{}
+			decodeSegment(t, parts[1], &claims)
+			if claims["iss"] != "goman@project.iam.gserviceaccount.com" || claims["aud"] != oauthTokenURL || claims[tt.claim] != tt.value {
+				t.Errorf("claims %v", claims)
+			}
+			iat, exp := claims["iat"].(float64), claims["exp"].(float64)
+			if exp-iat != time.Hour.Seconds() || time.Since(time.Unix(int64(iat), 0)) > time.Minute {
+				t.Errorf("issued %v, expires %v", iat, exp)
+			}
+		})
+	}
+}
+
+func decodeSegment(t *testing.T, segment string, out interface{}) {
+	t.Helper()
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(data, out); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestExchangeJWTInvalidKey(t *testing.T) {
+	tests := []struct {
+		name string
+		key  string
+		want string
+	}{
+		{"not PEM", "not a key", "not PEM encoded"},
+		{"not PKCS8", string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: []byte("junk")})), "failed to parse"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ts := &tokenSource{httpClient: &http.Client{Transport: roundTripFunc(func(req *http.Request) *http.Response {
+				t.Errorf("unexpected request to %s", req.URL)
+				return jsonResponse(http.StatusOK, "{}")
+			})}, creds: &credentials{Type: "service_account", PrivateKey: tt.key}}
+
+			if _, err := ts.exchangeJWT(context.Background(), map[string]interface{}{}); err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("error %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestTokenRefreshAndCache(t *testing.T) {
+	t.Setenv("GOOGLE_OAUTH_ACCESS_TOKEN", "")
+
+	tests := []struct {
+		name      string
+		creds     *credentials
+		wantURL   string
+		wantForm  url.Values
+		wantQuota string
+	}{
+		{
+			name:     "user credentials",
+			creds:    &credentials{Type: "authorized_user", ClientID: "id", ClientSecret: "secret", RefreshToken: "refresh", QuotaProject: "billing"},
+			wantURL:  oauthTokenURL,
+			wantForm: url.Values{"grant_type": {"refresh_token"}, "client_id": {"id"}, "client_secret": {"secret"}, "refresh_token": {"refresh"}},
+			// User credentials bill their quota project
+			wantQuota: "billing",
+		},
+		{
+			name:    "metadata server",
+			wantURL: "http://metadata.google.internal/computeMetadata/v1/instance/service-accounts/default/token",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			requests := 0
+			client := &http.Client{Transport: roundTripFunc(func(req *http.Request) *http.Response {
+				requests++
+				if req.URL.String() != tt.wantURL {
+					t.Errorf("request to %s", req.URL)
+				}
+				if tt.wantForm != nil {
+					body, _ := io.ReadAll(req.Body)
+					if form, _ := url.ParseQuery(string(body)); form.Encode() != tt.wantForm.Encode() {
+						t.Errorf("form %v", form)
+					}
+				} else if req.Header.Get("Metadata-Flavor") != "Google" {
+					t.Error("metadata request without Metadata-Flavor")
+				}
+				return jsonResponse(http.StatusOK, `{"access_token":"ya29.token","expires_in":3600}`)
+			})}
+			ts := &tokenSource{httpClient: client, creds: tt.creds}
+
+			for i := 0; i < 2; i++ {
+				token, err := ts.Token(context.Background())
+				if err != nil {
+					t.Fatal(err)
+				}
+				if token != "ya29.token" {
+					t.Errorf("token %q", token)
+				}
+			}
+			if requests != 1 {
+				t.Errorf("%d token requests, want the token cached", requests)
+			}
+			if ts.quotaProject() != tt.wantQuota {
+				t.Errorf("quota project %q", ts.quotaProject())
+			}
+
+			// A token about to expire is refreshed
+			ts.expiry = time.Now().Add(30 * time.Second)
+			if _, err := ts.Token(context.Background()); err != nil {
+				t.Fatal(err)
+			}
+			if requests != 2 {
+				t.Errorf("%d token requests, want a refresh", requests)
+			}
+		})
+	}
+}
+
+func TestTokenFromEnvironment(t *testing.T) {
+	t.Setenv("GOOGLE_OAUTH_ACCESS_TOKEN", "from-env")
+	ts, err := newTokenSource(&http.Client{Transport: roundTripFunc(func(req *http.Request) *http.Response {
+		t.Errorf("unexpected request to %s", req.URL)
+		return jsonResponse(http.StatusOK, "{}")
+	})})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token, err := ts.Token(context.Background()); err != nil || token != "from-env" {
+		t.Errorf("token %q, %v", token, err)
+	}
+}
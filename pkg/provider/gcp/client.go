@@ -0,0 +1,222 @@
+package gcp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/madhouselabs/goman/pkg/provider"
+)
+
+// API endpoints; GOMAN_GCP_ENDPOINT_<SERVICE> points one at an emulator,
+// e.g. GOMAN_GCP_ENDPOINT_FIRESTORE=http://localhost:8080/v1
+var defaultEndpoints = map[string]string{
+	"compute":        "https://compute.googleapis.com/compute/v1",
+	"storage":        "https://storage.googleapis.com/storage/v1",
+	"storage-upload": "https://storage.googleapis.com/upload/storage/v1",
+	"firestore":      "https://firestore.googleapis.com/v1",
+	"pubsub":         "https://pubsub.googleapis.com/v1",
+	"cloudfunctions": "https://cloudfunctions.googleapis.com/v2",
+	"cloudtasks":     "https://cloudtasks.googleapis.com/v2",
+	"eventarc":       "https://eventarc.googleapis.com/v1",
+}
+
+func endpoint(service string) string {
+	key := "GOMAN_GCP_ENDPOINT_" + strings.ToUpper(strings.ReplaceAll(service, "-", "_"))
+	if url := os.Getenv(key); url != "" {
+		return strings.TrimSuffix(url, "/")
+	}
+	return defaultEndpoints[service]
+}
+
+// apiClient calls Google Cloud REST APIs with the caller's credentials
+type apiClient struct {
+	http   *http.Client
+	tokens *tokenSource
+}
+
+func newAPIClient() (*apiClient, error) {
+	httpClient := &http.Client{Timeout: 60 * time.Second}
+	tokens, err := newTokenSource(httpClient)
+	if err != nil {
+		return nil, err
+	}
+	return &apiClient{http: httpClient, tokens: tokens}, nil
+}
+
+// apiError is the error body of Google APIs
+type apiError struct {
+	Error struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+		Status  string `json:"status"`
+	} `json:"error"`
+}
+
+// call sends a JSON request and decodes the JSON response into out, if
+// given. Failures are returned as *provider.OperationError.
+func (c *apiClient) call(ctx context.Context, service, operation, method, url string, in, out interface{}) error {
+	var body io.Reader
+	if in != nil {
+		data, err := json.Marshal(in)
+		if err != nil {
+			return fmt.Errorf("failed to encode %s request: %w", operation, err)
+		}
+		body = bytes.NewReader(data)
+	}
+	data, err := c.send(ctx, service, operation, method, url, "application/json", body, nil)
+	if err != nil {
+		return err
+	}
+	if out == nil || len(data) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("failed to decode %s response: %w", operation, err)
+	}
+	return nil
+}
+
+// send sends a request with a raw body and returns the raw response body
+func (c *apiClient) send(ctx context.Context, service, operation, method, url, contentType string, body io.Reader, header http.Header) ([]byte, error) {
+	token, err := c.tokens.Token(ctx)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, method, url, body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	if body != nil {
+		req.Header.Set("Content-Type", contentType)
+	}
+	if project := c.tokens.quotaProject(); project != "" {
+		req.Header.Set("X-Goog-User-Project", project)
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, &provider.OperationError{Service: service, Operation: operation, Code: "RequestFailed", Class: provider.ErrorClassTransient, Err: err}
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, &provider.OperationError{Service: service, Operation: operation, Code: "RequestFailed", Class: provider.ErrorClassTransient, Err: err}
+	}
+	if resp.StatusCode >= 300 {
+		return nil, newOperationError(service, operation, resp, data)
+	}
+	return data, nil
+}
+
+// newOperationError classifies a failed API response
+func newOperationError(service, operation string, resp *http.Response, data []byte) error {
+	var body apiError
+	message := strings.TrimSpace(string(data))
+	code := resp.Status
+	if json.Unmarshal(data, &body) == nil && body.Error.Message != "" {
+		message = body.Error.Message
+		if body.Error.Status != "" {
+			code = body.Error.Status
+		}
+	}
+
+	err := errors.New(message)
+	class := provider.ErrorClassUnknown
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		class = provider.ErrorClassNotFound
+	case resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden:
+		class = provider.ErrorClassPermission
+	case resp.StatusCode == http.StatusTooManyRequests && code == "RESOURCE_EXHAUSTED" && strings.Contains(strings.ToLower(message), "quota"):
+		class = provider.ErrorClassQuota
+	case resp.StatusCode == http.StatusTooManyRequests:
+		class = provider.ErrorClassThrottling
+	case resp.StatusCode == http.StatusPreconditionFailed || code == "FAILED_PRECONDITION":
+		class = provider.ErrorClassValidation
+		err = fmt.Errorf("%w: %s", provider.ErrPreconditionFailed, message)
+	case resp.StatusCode == http.StatusBadRequest || resp.StatusCode == http.StatusConflict:
+		class = provider.ErrorClassValidation
+	case resp.StatusCode >= 500:
+		class = provider.ErrorClassTransient
+	}
+	if strings.Contains(message, "ZONE_RESOURCE_POOL_EXHAUSTED") || strings.Contains(message, "does not have enough resources") {
+		class = provider.ErrorClassCapacity
+	}
+
+	return &provider.OperationError{
+		Service:   service,
+		Operation: operation,
+		Code:      code,
+		RequestID: resp.Header.Get("X-Goog-Request-Id"),
+		Class:     class,
+		Err:       err,
+	}
+}
+
+// isConflict reports whether a create failed because the resource exists
+func isConflict(err error) bool {
+	opErr, ok := provider.AsOperationError(err)
+	return ok && (opErr.Code == "ALREADY_EXISTS" || opErr.Code == "409 Conflict")
+}
+
+// operation is a long-running operation of Compute Engine or the newer
+// APIs, which report completion differently
+type operation struct {
+	Name     string `json:"name"`
+	SelfLink string `json:"selfLink"`
+	Status   string `json:"status"` // Compute Engine: PENDING, RUNNING, DONE
+	Done     bool   `json:"done"`   // Other APIs
+	Error    *struct {
+		Message string `json:"message"`
+		Errors  []struct {
+			Code    string `json:"code"`
+			Message string `json:"message"`
+		} `json:"errors"`
+	} `json:"error"`
+	Response json.RawMessage `json:"response"`
+}
+
+func (o *operation) finished() bool {
+	return o.Done || o.Status == "DONE"
+}
+
+func (o *operation) err() error {
+	if o.Error == nil {
+		return nil
+	}
+	if o.Error.Message != "" {
+		return errors.New(o.Error.Message)
+	}
+	var msgs []string
+	for _, e := range o.Error.Errors {
+		msgs = append(msgs, fmt.Sprintf("%s: %s", e.Code, e.Message))
+	}
+	return errors.New(strings.Join(msgs, "; "))
+}
+
+// wait polls a long-running operation until it finishes or ctx ends
+func (c *apiClient) wait(ctx context.Context, service string, op *operation, pollURL string) error {
+	for !op.finished() {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(2 * time.Second):
+		}
+		if err := c.call(ctx, service, "GetOperation", http.MethodGet, pollURL, nil, op); err != nil {
+			return err
+		}
+	}
+	return op.err()
+}
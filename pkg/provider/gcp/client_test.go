@@ -0,0 +1,291 @@
+package gcp
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/madhouselabs/goman/pkg/provider"
+)
+
+// fakeAPI answers API calls from a handler and authenticates with a fixed
+// token
+func fakeAPI(t *testing.T, handler func(req *http.Request, body string) *http.Response) *apiClient {
+	t.Helper()
+	t.Setenv("GOOGLE_OAUTH_ACCESS_TOKEN", "test-token")
+	client := &http.Client{Transport: roundTripFunc(func(req *http.Request) *http.Response {
+		if got := req.Header.Get("Authorization"); got != "Bearer test-token" {
+			t.Errorf("%s sent with Authorization %q", req.URL, got)
+		}
+		var body string
+		if req.Body != nil {
+			data, _ := io.ReadAll(req.Body)
+			body = string(data)
+		}
+		return handler(req, body)
+	})}
+	return &apiClient{http: client, tokens: &tokenSource{httpClient: client}}
+}
+
+func TestOperationErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		status   int
+		body     string
+		class    provider.ErrorClass
+		code     string
+		notFound bool
+		precond  bool
+	}{
+		{"not found", http.StatusNotFound, `{"error":{"code":404,"message":"gone","status":"NOT_FOUND"}}`, provider.ErrorClassNotFound, "NOT_FOUND", true, false},
+		{"forbidden", http.StatusForbidden, `{"error":{"code":403,"message":"denied","status":"PERMISSION_DENIED"}}`, provider.ErrorClassPermission, "PERMISSION_DENIED", false, false},
+		{"quota", http.StatusTooManyRequests, `{"error":{"code":429,"message":"Quota exceeded for cpus","status":"RESOURCE_EXHAUSTED"}}`, provider.ErrorClassQuota, "RESOURCE_EXHAUSTED", false, false},
+		{"throttled", http.StatusTooManyRequests, `{"error":{"code":429,"message":"slow down","status":"RESOURCE_EXHAUSTED"}}`, provider.ErrorClassThrottling, "RESOURCE_EXHAUSTED", false, false},
+		{"generation mismatch", http.StatusPreconditionFailed, `{"error":{"code":412,"message":"conditionNotMet"}}`, provider.ErrorClassValidation, "Precondition Failed", false, true},
+		{"capacity", http.StatusServiceUnavailable, `{"error":{"code":503,"message":"ZONE_RESOURCE_POOL_EXHAUSTED in us-central1-a"}}`, provider.ErrorClassCapacity, "Service Unavailable", false, false},
+		{"server error without body", http.StatusInternalServerError, "oops", provider.ErrorClassTransient, "Internal Server Error", false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := fakeAPI(t, func(req *http.Request, body string) *http.Response {
+				resp := jsonResponse(tt.status, tt.body)
+				resp.Header.Set("X-Goog-Request-Id", "req-1")
+				return resp
+			})
+
+			err := c.call(context.Background(), "compute", "GetInstance", http.MethodGet, "https://compute.example/instance", nil, nil)
+			opErr, ok := provider.AsOperationError(err)
+			if !ok {
+				t.Fatalf("error %v is not an operation error", err)
+			}
+			if opErr.Class != tt.class || opErr.Code != tt.code || opErr.RequestID != "req-1" {
+				t.Errorf("class %s, code %q, request %q", opErr.Class, opErr.Code, opErr.RequestID)
+			}
+			if errors.Is(err, provider.ErrNotFound) != tt.notFound {
+				t.Errorf("errors.Is(ErrNotFound) = %v", !tt.notFound)
+			}
+			if errors.Is(err, provider.ErrPreconditionFailed) != tt.precond {
+				t.Errorf("errors.Is(ErrPreconditionFailed) = %v", !tt.precond)
+			}
+		})
+	}
+}
+
+func TestCallEncodesAndDecodes(t *testing.T) {
+	c := fakeAPI(t, func(req *http.Request, body string) *http.Response {
+		if req.Method != http.MethodPost || req.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("%s with Content-Type %q", req.Method, req.Header.Get("Content-Type"))
+		}
+		if body != `{"name":"goman"}` {
+			t.Errorf("body %s", body)
+		}
+		return jsonResponse(http.StatusOK, `{"name":"operation-1","status":"DONE"}`)
+	})
+
+	var op operation
+	if err := c.call(context.Background(), "compute", "Insert", http.MethodPost, "https://compute.example", map[string]string{"name": "goman"}, &op); err != nil {
+		t.Fatal(err)
+	}
+	if op.Name != "operation-1" || !op.finished() || op.err() != nil {
+		t.Errorf("operation %+v", op)
+	}
+}
+
+func TestOperationError(t *testing.T) {
+	tests := []struct {
+		name string
+		op   operation
+		want string
+	}{
+		{"running", operation{Status: "RUNNING"}, ""},
+		{"message", operation{Done: true, Error: &struct {
+			Message string `json:"message"`
+			Errors  []struct {
+				Code    string `json:"code"`
+				Message string `json:"message"`
+			} `json:"errors"`
+		}{Message: "function failed to deploy"}}, "function failed to deploy"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.op.err()
+			if (err == nil && tt.want != "") || (err != nil && err.Error() != tt.want) {
+				t.Errorf("err %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestListInstances(t *testing.T) {
+	var requests []*url.URL
+	c := fakeAPI(t, func(req *http.Request, body string) *http.Response {
+		requests = append(requests, req.URL)
+		if req.URL.Query().Get("pageToken") == "" {
+			return jsonResponse(http.StatusOK, `{
+				"items": {
+					"zones/europe-west1-b": {"instances": [{
+						"name": "prod-master-0",
+						"zone": "https://www.googleapis.com/compute/v1/projects/p/zones/europe-west1-b",
+						"status": "RUNNING",
+						"machineType": "https://www.googleapis.com/compute/v1/projects/p/zones/europe-west1-b/machineTypes/e2-medium",
+						"creationTimestamp": "2026-01-02T03:04:05.000-07:00",
+						"metadata": {"items": [{"key": "goman-tags", "value": "{\"goman-cluster\":\"prod\",\"goman-role\":\"master\",\"Name\":\"prod-master-0\"}"}]},
+						"networkInterfaces": [{"networkIP": "10.0.0.2", "accessConfigs": [{"natIP": "34.1.2.3"}]}]
+					}]},
+					"zones/us-central1-a": {"instances": [{
+						"name": "prod-master-1",
+						"zone": "zones/us-central1-a",
+						"status": "RUNNING",
+						"metadata": {"items": [{"key": "goman-tags", "value": "{\"goman-cluster\":\"prod\",\"goman-role\":\"master\"}"}]}
+					}]}
+				},
+				"nextPageToken": "page-2"
+			}`)
+		}
+		return jsonResponse(http.StatusOK, `{"items": {"zones/europe-west1-c": {"instances": [
+			{"name": "prod-worker-0", "zone": "zones/europe-west1-c", "status": "TERMINATED",
+			 "metadata": {"items": [{"key": "goman-tags", "value": "{\"goman-cluster\":\"prod\",\"goman-role\":\"worker\"}"}]}},
+			{"name": "prod-master-2", "zone": "zones/europe-west1-c", "status": "STOPPING",
+			 "metadata": {"items": [{"key": "goman-tags", "value": "{\"goman-cluster\":\"prod\",\"goman-role\":\"master\"}"}]}}
+		]}}}`)
+	})
+	s := &ComputeService{client: c, project: "p", region: "europe-west1"}
+
+	instances, err := s.ListInstances(context.Background(), map[string]string{
+		"tag:goman-cluster":   "prod",
+		"instance-state-name": "running,stopped",
+		"region":              "europe-west1",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(requests) != 2 || requests[1].Query().Get("pageToken") != "page-2" {
+		t.Fatalf("requests %v", requests)
+	}
+	filter := requests[0].Query().Get("filter")
+	if filter != "labels.managed-by=goman AND labels.goman-cluster=prod" {
+		t.Errorf("filter %q", filter)
+	}
+
+	// us-central1 is outside the region and STOPPING is not asked for
+	var ids []string
+	for _, inst := range instances {
+		ids = append(ids, inst.ID)
+	}
+	sort.Strings(ids)
+	if !slices.Equal(ids, []string{"europe-west1-b/prod-master-0", "europe-west1-c/prod-worker-0"}) {
+		t.Fatalf("instances %v", ids)
+	}
+
+	for _, inst := range instances {
+		if inst.ID != "europe-west1-b/prod-master-0" {
+			if inst.State != provider.InstanceStateStopped {
+				t.Errorf("%s state %s", inst.ID, inst.State)
+			}
+			continue
+		}
+		want := provider.Instance{
+			ID:               "europe-west1-b/prod-master-0",
+			Name:             "prod-master-0",
+			State:            provider.InstanceStateRunning,
+			PrivateIP:        "10.0.0.2",
+			PublicIP:         "34.1.2.3",
+			InstanceType:     "e2-medium",
+			AvailabilityZone: "europe-west1-b",
+		}
+		got := *inst
+		got.Tags, got.LaunchTime = nil, time.Time{}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("instance %+v, want %+v", got, want)
+		}
+		if inst.Tags["goman-role"] != "master" || inst.LaunchTime.IsZero() {
+			t.Errorf("tags %v, launched %v", inst.Tags, inst.LaunchTime)
+		}
+	}
+}
+
+func TestConditionalObjectWrites(t *testing.T) {
+	tests := []struct {
+		name       string
+		version    string
+		wantMatch  string
+		status     int
+		response   string
+		wantErr    error
+		wantResult string
+	}{
+		{"create", "", "0", http.StatusOK, `{"name":"k","generation":"7"}`, nil, "7"},
+		{"update", "7", "7", http.StatusOK, `{"name":"k","generation":"8"}`, nil, "8"},
+		{"stale", "7", "7", http.StatusPreconditionFailed, `{"error":{"code":412,"message":"conditionNotMet"}}`, provider.ErrPreconditionFailed, ""},
+		{"invalid version", "etag", "", 0, "", nil, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			requests := 0
+			c := fakeAPI(t, func(req *http.Request, body string) *http.Response {
+				requests++
+				q := req.URL.Query()
+				if q.Get("ifGenerationMatch") != tt.wantMatch || q.Get("name") != "clusters/a b/config.yaml" || body != "data" {
+					t.Errorf("upload %s with body %q", req.URL, body)
+				}
+				return jsonResponse(tt.status, tt.response)
+			})
+			s := &StorageService{client: c, bucketName: "goman-state"}
+
+			version, err := s.PutObjectIfMatch(context.Background(), "clusters/a b/config.yaml", []byte("data"), tt.version)
+			switch {
+			case tt.status == 0:
+				if err == nil || requests != 0 {
+					t.Errorf("invalid version accepted: %v", err)
+				}
+			case tt.wantErr != nil:
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("error %v, want %v", err, tt.wantErr)
+				}
+			case err != nil || version != tt.wantResult:
+				t.Errorf("version %q, %v", version, err)
+			}
+		})
+	}
+}
+
+func TestObjectReadsAndDeletes(t *testing.T) {
+	c := fakeAPI(t, func(req *http.Request, body string) *http.Response {
+		switch {
+		case req.Method == http.MethodDelete:
+			return jsonResponse(http.StatusNotFound, `{"error":{"code":404,"message":"No such object"}}`)
+		case req.URL.Query().Get("alt") == "media" && strings.Contains(req.URL.EscapedPath(), "/o/clusters%2Fprod%2Fconfig.yaml"):
+			if req.URL.Query().Get("generation") != "5" {
+				t.Errorf("read %s, want the looked up generation", req.URL)
+			}
+			return jsonResponse(http.StatusOK, "spec: {}")
+		case strings.Contains(req.URL.EscapedPath(), "/o/clusters%2Fprod%2Fconfig.yaml"):
+			return jsonResponse(http.StatusOK, `{"name":"clusters/prod/config.yaml","generation":"5"}`)
+		default:
+			return jsonResponse(http.StatusNotFound, `{"error":{"code":404,"message":"No such object"}}`)
+		}
+	})
+	s := &StorageService{client: c, bucketName: "goman-state"}
+	ctx := context.Background()
+
+	data, version, err := s.GetObjectVersion(ctx, "clusters/prod/config.yaml")
+	if err != nil || string(data) != "spec: {}" || version != "5" {
+		t.Errorf("read %q at %q: %v", data, version, err)
+	}
+	if _, err := s.GetObject(ctx, "clusters/gone/config.yaml"); !errors.Is(err, provider.ErrNotFound) {
+		t.Errorf("missing object: %v", err)
+	}
+	// Deleting a missing object succeeds, as on S3
+	if err := s.DeleteObject(ctx, "clusters/gone/config.yaml"); err != nil {
+		t.Errorf("delete: %v", err)
+	}
+}
@@ -0,0 +1,256 @@
+package gcp
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/madhouselabs/goman/pkg/config"
+	"github.com/madhouselabs/goman/pkg/logger"
+	"github.com/madhouselabs/goman/pkg/provider"
+)
+
+const (
+	// commandMetadataPrefix prefixes the metadata keys commands are posted
+	// under; goman-agent on the node runs each new one
+	commandMetadataPrefix = "goman-command-"
+
+	// guestAttributeNamespace holds the status and exit code goman-agent
+	// reports for each command
+	guestAttributeNamespace = "goman-commands"
+)
+
+func newCommandID() (string, error) {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// RunCommand runs a shell script on instances through goman-agent and
+// waits for the result
+func (s *ComputeService) RunCommand(ctx context.Context, instanceIDs []string, command string) (*provider.CommandResult, error) {
+	commandID, err := s.StartCommand(ctx, instanceIDs, command)
+	if err != nil {
+		return nil, err
+	}
+
+	// Wait for command to complete (with timeout)
+	waitCtx, cancel := context.WithTimeout(ctx, 5*time.Minute)
+	defer cancel()
+
+	ticker := time.NewTicker(2 * time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-waitCtx.Done():
+			return nil, fmt.Errorf("timeout waiting for command to complete")
+		case <-ticker.C:
+			result, err := s.GetCommandResult(waitCtx, commandID)
+			if err != nil {
+				return nil, err
+			}
+			if result.Status != "InProgress" {
+				return result, nil
+			}
+		}
+	}
+}
+
+// StartCommand posts a shell script to instances without waiting for
+// completion. Command IDs are only known to the process that started them.
+func (s *ComputeService) StartCommand(ctx context.Context, instanceIDs []string, command string) (string, error) {
+	if len(instanceIDs) == 0 {
+		return "", fmt.Errorf("no instance IDs provided")
+	}
+	commandID, err := newCommandID()
+	if err != nil {
+		return "", fmt.Errorf("failed to generate command ID: %w", err)
+	}
+
+	script := base64.StdEncoding.EncodeToString([]byte(command))
+	for _, id := range instanceIDs {
+		err := s.updateMetadata(ctx, id, func(m *gceMetadata) {
+			m.set(commandMetadataPrefix+commandID, script)
+		})
+		if err != nil {
+			return "", fmt.Errorf("failed to send command to %s: %w", id, err)
+		}
+	}
+
+	s.commandsMu.Lock()
+	s.commands[commandID] = instanceIDs
+	s.commandsMu.Unlock()
+	return commandID, nil
+}
+
+// GetCommandResult checks the status of a previously started command. Once
+// it finished everywhere, the command is removed from the instances.
+func (s *ComputeService) GetCommandResult(ctx context.Context, commandID string) (*provider.CommandResult, error) {
+	s.commandsMu.Lock()
+	instanceIDs, ok := s.commands[commandID]
+	s.commandsMu.Unlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown command %s", commandID)
+	}
+
+	cmdResult := &provider.CommandResult{
+		CommandID: commandID,
+		Status:    "Success",
+		Instances: make(map[string]*provider.InstanceCommandResult),
+	}
+	allComplete := true
+	for _, id := range instanceIDs {
+		res, err := s.commandResult(ctx, id, commandID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get command status: %w", err)
+		}
+		cmdResult.Instances[id] = res
+		switch res.Status {
+		case "Pending", "InProgress":
+			allComplete = false
+		case "Success":
+		default:
+			cmdResult.Status = "Failed"
+		}
+	}
+	if !allComplete {
+		cmdResult.Status = "InProgress"
+		return cmdResult, nil
+	}
+
+	for _, id := range instanceIDs {
+		err := s.updateMetadata(ctx, id, func(m *gceMetadata) {
+			m.remove(commandMetadataPrefix + commandID)
+		})
+		if err != nil {
+			logger.Printf("Warning: failed to remove command %s from %s: %v", commandID, id, err)
+		}
+	}
+	s.commandsMu.Lock()
+	delete(s.commands, commandID)
+	s.commandsMu.Unlock()
+	return cmdResult, nil
+}
+
+// commandResult reads what goman-agent reported for a command
+func (s *ComputeService) commandResult(ctx context.Context, instanceID, commandID string) (*provider.InstanceCommandResult, error) {
+	res := &provider.InstanceCommandResult{InstanceID: instanceID, Status: "Pending"}
+	u, err := s.instanceURL(instanceID)
+	if err != nil {
+		return nil, err
+	}
+
+	var attrs struct {
+		QueryValue struct {
+			Items []struct {
+				Key   string `json:"key"`
+				Value string `json:"value"`
+			} `json:"items"`
+		} `json:"queryValue"`
+	}
+	err = s.client.call(ctx, "compute", "GetGuestAttributes", http.MethodGet,
+		u+"/getGuestAttributes?queryPath="+url.QueryEscape(guestAttributeNamespace+"/"), nil, &attrs)
+	if isNotFound(err) {
+		// Nothing reported yet
+		return res, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	for _, item := range attrs.QueryValue.Items {
+		switch item.Key {
+		case commandID + "-status":
+			res.Status = item.Value
+		case commandID + "-exit":
+			res.ExitCode, _ = strconv.Atoi(item.Value)
+		}
+	}
+	if res.Status == "Pending" || res.Status == "InProgress" {
+		return res, nil
+	}
+
+	// Output is too large for guest attributes; the agent uploads it
+	_, name, _ := instanceRef(instanceID)
+	prefix := commandOutputPrefix(commandID, name)
+	if data, err := s.storage.GetObject(ctx, prefix+"stdout"); err == nil {
+		res.Output = string(data)
+	}
+	if data, err := s.storage.GetObject(ctx, prefix+"stderr"); err == nil {
+		res.Error = string(data)
+	}
+	if err := s.storage.DeleteFolder(ctx, prefix); err != nil {
+		logger.Printf("Warning: failed to remove output of command %s: %v", commandID, err)
+	}
+	return res, nil
+}
+
+// commandOutputPrefix is where goman-agent uploads the output of a command
+// on an instance
+func commandOutputPrefix(commandID, instanceName string) string {
+	return fmt.Sprintf("commands/%s/%s/", commandID, instanceName)
+}
+
+// operationScripts are the scripts of node operations, with {{ Name }}
+// parameters like the SSM documents of the AWS provider
+var operationScripts = map[string]string{
+	provider.OperationDrainNode: `
+kubectl drain {{ NodeName }} --ignore-daemonsets --delete-emptydir-data --force --timeout={{ Timeout }} || true
+if [ "{{ Delete }}" = "true" ]; then
+    kubectl delete node {{ NodeName }} --ignore-not-found
+fi
+`,
+	provider.OperationConfigureDNS: provider.ConfigureDNSScript,
+	provider.OperationUpgradeK3s:   nodeScriptUpgradeK3s,
+	provider.OperationRotateToken:  nodeScriptSecrets + provider.RotateTokenScript,
+	provider.OperationUpdateToken:  nodeScriptSecrets + provider.UpdateTokenScript,
+}
+
+// operationDefaults are parameters operations take when the caller leaves
+// them out
+var operationDefaults = map[string]string{
+	"K3sVersion":     "",
+	"Timeout":        "60s",
+	"Delete":         "true",
+	"Upstreams":      "",
+	"ClusterConfig":  "false",
+	"CorednsCustom":  "",
+	"NodeLocalCache": "false",
+}
+
+// RunOperation runs a named node operation as a script and waits for the result
+func (s *ComputeService) RunOperation(ctx context.Context, instanceIDs []string, operation string, params map[string]string) (*provider.CommandResult, error) {
+	script, ok := operationScripts[operation]
+	if !ok {
+		return nil, fmt.Errorf("operation %s is not supported on GCP", operation)
+	}
+
+	values := map[string]string{
+		"GCSBucket": config.GetStateBucket(s.project),
+	}
+	for k, v := range operationDefaults {
+		values[k] = v
+	}
+	for k, v := range params {
+		values[k] = v
+	}
+	if values["K3sVersion"] == "" {
+		values["K3sVersion"] = provider.K3sVersion("")
+	}
+
+	var pairs []string
+	for k, v := range values {
+		pairs = append(pairs, "{{ "+k+" }}", v)
+	}
+	return s.RunCommand(ctx, instanceIDs, strings.NewReplacer(pairs...).Replace(script))
+}
@@ -0,0 +1,876 @@
+package gcp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	gomanconfig "github.com/madhouselabs/goman/pkg/config"
+	"github.com/madhouselabs/goman/pkg/logger"
+	"github.com/madhouselabs/goman/pkg/models"
+	"github.com/madhouselabs/goman/pkg/provider"
+)
+
+const (
+	// defaultImage is the boot image of nodes; Debian ships the gcloud CLI
+	// and the guest agent
+	defaultImage = "projects/debian-cloud/global/images/family/debian-12"
+
+	// defaultMachineType replaces instance types that are not GCE machine
+	// types, such as the AWS defaults of older specs
+	defaultMachineType = "e2-medium"
+
+	// tagsMetadataKey holds goman's instance tags as JSON. Labels cannot hold
+	// tag values such as join tokens and IPs, so only the few tags instances
+	// are looked up by are also labels.
+	tagsMetadataKey = "goman-tags"
+)
+
+// labelTags are the tags mirrored to labels so ListInstances can filter on them
+var labelTags = []string{"goman-cluster", "goman-role", "goman-nodepool"}
+
+// ComputeService implements compute operations using Compute Engine.
+// Instance IDs are <zone>/<name>, which addresses an instance without a
+// lookup of its zone.
+type ComputeService struct {
+	client  *apiClient
+	project string
+	region  string
+	storage *StorageService // Command output
+
+	zonesMu sync.Mutex
+	zones   map[string][]string // Zones per region
+
+	commandsMu sync.Mutex
+	commands   map[string][]string // Instances of started commands
+}
+
+// NewComputeService creates a new Compute Engine-based compute service
+func NewComputeService(client *apiClient, project, region string, storage *StorageService) *ComputeService {
+	return &ComputeService{
+		client:   client,
+		project:  project,
+		region:   region,
+		storage:  storage,
+		zones:    make(map[string][]string),
+		commands: make(map[string][]string),
+	}
+}
+
+type metadataItem struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+type gceMetadata struct {
+	Fingerprint string         `json:"fingerprint,omitempty"`
+	Items       []metadataItem `json:"items"`
+}
+
+func (m *gceMetadata) get(key string) (string, bool) {
+	for _, item := range m.Items {
+		if item.Key == key {
+			return item.Value, true
+		}
+	}
+	return "", false
+}
+
+func (m *gceMetadata) set(key, value string) {
+	for i, item := range m.Items {
+		if item.Key == key {
+			m.Items[i].Value = value
+			return
+		}
+	}
+	m.Items = append(m.Items, metadataItem{Key: key, Value: value})
+}
+
+func (m *gceMetadata) remove(key string) {
+	items := m.Items[:0]
+	for _, item := range m.Items {
+		if item.Key != key {
+			items = append(items, item)
+		}
+	}
+	m.Items = items
+}
+
+type gceInstance struct {
+	Name              string            `json:"name"`
+	Zone              string            `json:"zone"`
+	Status            string            `json:"status"`
+	MachineType       string            `json:"machineType"`
+	CreationTimestamp string            `json:"creationTimestamp"`
+	Labels            map[string]string `json:"labels"`
+	LabelFingerprint  string            `json:"labelFingerprint"`
+	Metadata          gceMetadata       `json:"metadata"`
+	Disks             []struct {
+		Source string `json:"source"`
+	} `json:"disks"`
+	NetworkInterfaces []struct {
+		Network       string `json:"network"`
+		NetworkIP     string `json:"networkIP"`
+		AccessConfigs []struct {
+			NatIP string `json:"natIP"`
+		} `json:"accessConfigs"`
+	} `json:"networkInterfaces"`
+}
+
+// instanceRef splits an instance ID into zone and name
+func instanceRef(instanceID string) (zone, name string, err error) {
+	zone, name, ok := strings.Cut(instanceID, "/")
+	if !ok || zone == "" || name == "" {
+		return "", "", fmt.Errorf("invalid instance ID %q, expected <zone>/<name>", instanceID)
+	}
+	return zone, name, nil
+}
+
+func (s *ComputeService) projectURL() string {
+	return fmt.Sprintf("%s/projects/%s", endpoint("compute"), s.project)
+}
+
+func (s *ComputeService) instanceURL(instanceID string) (string, error) {
+	zone, name, err := instanceRef(instanceID)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s/zones/%s/instances/%s", s.projectURL(), zone, name), nil
+}
+
+// zonesOf returns the zones of a region, looked up once
+func (s *ComputeService) zonesOf(ctx context.Context, region string) ([]string, error) {
+	s.zonesMu.Lock()
+	defer s.zonesMu.Unlock()
+	if zones, ok := s.zones[region]; ok {
+		return zones, nil
+	}
+
+	var r struct {
+		Zones []string `json:"zones"`
+	}
+	if err := s.client.call(ctx, "compute", "GetRegion", http.MethodGet, s.projectURL()+"/regions/"+region, nil, &r); err != nil {
+		return nil, fmt.Errorf("failed to get zones of %s: %w", region, err)
+	}
+	zones := make([]string, 0, len(r.Zones))
+	for _, z := range r.Zones {
+		zones = append(zones, path.Base(z))
+	}
+	sort.Strings(zones)
+	if len(zones) == 0 {
+		return nil, fmt.Errorf("region %s has no zones", region)
+	}
+	s.zones[region] = zones
+	return zones, nil
+}
+
+// waitZoneOperation waits for a zonal operation to finish
+func (s *ComputeService) waitZoneOperation(ctx context.Context, op *operation) error {
+	waitCtx, cancel := context.WithTimeout(ctx, 5*time.Minute)
+	defer cancel()
+	return s.client.wait(waitCtx, "compute", op, op.SelfLink)
+}
+
+var invalidNameChars = regexp.MustCompile(`[^a-z0-9-]+`)
+
+// instanceName turns a node name into a valid GCE resource name
+func instanceName(name string) string {
+	name = strings.Trim(invalidNameChars.ReplaceAllString(strings.ToLower(name), "-"), "-")
+	if name == "" || name[0] < 'a' || name[0] > 'z' {
+		name = "goman-" + name
+	}
+	if len(name) > 63 {
+		name = strings.TrimRight(name[:63], "-")
+	}
+	return name
+}
+
+var invalidLabelChars = regexp.MustCompile(`[^a-z0-9_-]+`)
+
+// labelValue turns a tag value into a valid label value
+func labelValue(value string) string {
+	value = invalidLabelChars.ReplaceAllString(strings.ToLower(value), "-")
+	if len(value) > 63 {
+		value = value[:63]
+	}
+	return value
+}
+
+// labelKey turns a tag key into a valid label key, empty if none fits
+func labelKey(key string) string {
+	key = labelValue(key)
+	if key == "" || key[0] < 'a' || key[0] > 'z' {
+		return ""
+	}
+	return key
+}
+
+// networkTag is the network tag of a cluster's instances, which its
+// firewall rules target
+func networkTag(clusterName string) string {
+	return instanceName("goman-" + clusterName)
+}
+
+// machineType returns the GCE machine type to launch for an instance type
+func machineType(instanceType string) string {
+	if instanceType == "" || strings.Contains(instanceType, ".") {
+		if instanceType != "" {
+			logger.Printf("Instance type %s is not a GCE machine type, using %s", instanceType, defaultMachineType)
+		}
+		return defaultMachineType
+	}
+	return instanceType
+}
+
+// diskType maps a volume type to a GCE disk type. EBS types, as set by
+// sizing presets, become balanced persistent disks.
+func diskType(volumeType string) string {
+	if strings.HasPrefix(volumeType, "pd-") || strings.HasPrefix(volumeType, "hyperdisk-") {
+		return volumeType
+	}
+	return "pd-balanced"
+}
+
+// dataDiskDevice returns the device the i-th data disk shows up as
+func dataDiskDevice(i int) string {
+	return fmt.Sprintf("/dev/disk/by-id/google-goman-data-%d", i)
+}
+
+// CreateInstance creates a Compute Engine instance
+func (s *ComputeService) CreateInstance(ctx context.Context, config provider.InstanceConfig) (*provider.Instance, error) {
+	region := config.Region
+	if !gcpRegionPattern.MatchString(region) {
+		region = s.region
+	}
+	// Zones of other providers, e.g. from a spec written for AWS, are ignored
+	zone := config.AvailabilityZone
+	if !strings.HasPrefix(zone, region+"-") {
+		zones, err := s.zonesOf(ctx, region)
+		if err != nil {
+			return nil, err
+		}
+		zone = zones[0]
+	}
+	name := instanceName(config.Name)
+	logger.Printf("Creating instance %s in zone: %s", name, zone)
+
+	clusterName := config.Tags["goman-cluster"]
+	serviceAccount := ""
+	if clusterName != "" {
+		account, err := s.EnsureClusterIdentity(ctx, clusterName)
+		if err != nil {
+			return nil, fmt.Errorf("failed to ensure service account for cluster %s: %w", clusterName, err)
+		}
+		serviceAccount = account
+		if err := s.ensureClusterFirewall(ctx, clusterName); err != nil {
+			return nil, err
+		}
+	}
+
+	tags := make(map[string]string, len(config.Tags)+1)
+	for k, v := range config.Tags {
+		tags[k] = v
+	}
+	tags["Name"] = config.Name
+	tagData, err := json.Marshal(tags)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode tags: %w", err)
+	}
+
+	// User labels never override goman's own
+	labels := map[string]string{"managed-by": "goman"}
+	for k, v := range config.ResourceTags {
+		if key := labelKey(k); key != "" {
+			labels[key] = labelValue(v)
+		}
+	}
+	for _, k := range labelTags {
+		if v, ok := config.Tags[k]; ok {
+			labels[k] = labelValue(v)
+		}
+	}
+
+	startupScript := config.UserData
+	if startupScript == "" {
+		startupScript = s.startupScript(config)
+	}
+	// The image has no cloud-init: scripts run after the startup script
+	// and cloud-config is not applied
+	if models.UserDataKind(config.UserDataSnippet) == models.UserDataCloudConfig {
+		logger.Printf("Instance %s: cloud-config user data is not supported on GCP, ignoring it", config.Name)
+	}
+	startupScript += provider.UserDataSnippetScript(config.UserDataSnippet)
+
+	disks := []map[string]interface{}{{
+		"boot":       true,
+		"autoDelete": true,
+		"initializeParams": map[string]interface{}{
+			"sourceImage": defaultImage,
+			"diskType":    fmt.Sprintf("zones/%s/diskTypes/%s", zone, diskType(config.RootVolumeType)),
+			"labels":      labels,
+		},
+	}}
+	if config.RootVolumeSize > 0 {
+		disks[0]["initializeParams"].(map[string]interface{})["diskSizeGb"] = fmt.Sprintf("%d", config.RootVolumeSize)
+	}
+	if config.RootVolumeIOPS > 0 {
+		disks[0]["initializeParams"].(map[string]interface{})["provisionedIops"] = fmt.Sprintf("%d", config.RootVolumeIOPS)
+	}
+	for i, v := range config.DataVolumes {
+		disks = append(disks, map[string]interface{}{
+			"autoDelete": true,
+			"deviceName": fmt.Sprintf("goman-data-%d", i),
+			"initializeParams": map[string]interface{}{
+				"diskSizeGb": fmt.Sprintf("%d", v.SizeGiB),
+				"diskType":   fmt.Sprintf("zones/%s/diskTypes/%s", zone, diskType(v.Type)),
+				"labels":     labels,
+			},
+		})
+	}
+
+	instance := map[string]interface{}{
+		"name":        name,
+		"machineType": fmt.Sprintf("zones/%s/machineTypes/%s", zone, machineType(config.InstanceType)),
+		"labels":      labels,
+		"metadata": gceMetadata{Items: []metadataItem{
+			{Key: "startup-script", Value: startupScript},
+			{Key: "enable-guest-attributes", Value: "TRUE"},
+			{Key: "goman-bucket", Value: gomanconfig.GetStateBucket(s.project)},
+			{Key: tagsMetadataKey, Value: string(tagData)},
+		}},
+		"disks": disks,
+		"networkInterfaces": []map[string]interface{}{{
+			"network":       "global/networks/default",
+			"accessConfigs": []map[string]string{{"type": "ONE_TO_ONE_NAT", "name": "External NAT"}},
+		}},
+		"deletionProtection": config.DeletionProtection,
+	}
+	if clusterName != "" {
+		instance["tags"] = map[string][]string{"items": {networkTag(clusterName)}}
+	}
+	if serviceAccount != "" {
+		instance["serviceAccounts"] = []map[string]interface{}{{
+			"email":  serviceAccount,
+			"scopes": []string{cloudPlatformScope},
+		}}
+	}
+
+	var op operation
+	err = s.client.call(ctx, "compute", "InsertInstance", http.MethodPost,
+		fmt.Sprintf("%s/zones/%s/instances", s.projectURL(), zone), instance, &op)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create instance: %w", err)
+	}
+	if err := op.err(); err != nil {
+		return nil, fmt.Errorf("failed to create instance: %w", err)
+	}
+
+	// Don't wait for the instance to run; the reconciler checks on it
+	return &provider.Instance{
+		ID:               zone + "/" + name,
+		Name:             config.Name,
+		State:            provider.InstanceStatePending,
+		InstanceType:     machineType(config.InstanceType),
+		LaunchTime:       time.Now(),
+		Tags:             tags,
+		AvailabilityZone: zone,
+	}, nil
+}
+
+// DeleteInstance deletes an instance, lifting its deletion protection first
+func (s *ComputeService) DeleteInstance(ctx context.Context, instanceID string) error {
+	u, err := s.instanceURL(instanceID)
+	if err != nil {
+		return err
+	}
+
+	var op operation
+	err = s.client.call(ctx, "compute", "SetDeletionProtection", http.MethodPost, u+"/setDeletionProtection?deletionProtection=false", nil, &op)
+	if isNotFound(err) {
+		return nil
+	}
+	if err == nil {
+		err = s.waitZoneOperation(ctx, &op)
+	}
+	if err != nil {
+		logger.Printf("Warning: Failed to disable deletion protection for %s: %v", instanceID, err)
+	}
+
+	if err := s.client.call(ctx, "compute", "DeleteInstance", http.MethodDelete, u, nil, nil); err != nil && !isNotFound(err) {
+		return fmt.Errorf("failed to delete instance: %w", err)
+	}
+	return nil
+}
+
+// GetInstance gets instance details
+func (s *ComputeService) GetInstance(ctx context.Context, instanceID string) (*provider.Instance, error) {
+	inst, err := s.getInstance(ctx, instanceID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get instance: %w", err)
+	}
+	return convertInstance(inst), nil
+}
+
+func (s *ComputeService) getInstance(ctx context.Context, instanceID string) (*gceInstance, error) {
+	u, err := s.instanceURL(instanceID)
+	if err != nil {
+		return nil, err
+	}
+	var inst gceInstance
+	if err := s.client.call(ctx, "compute", "GetInstance", http.MethodGet, u, nil, &inst); err != nil {
+		return nil, err
+	}
+	return &inst, nil
+}
+
+// ListInstances lists goman's instances. Filters use the EC2 names the
+// controller passes: tag:<key> and instance-state-name (comma-separated);
+// region limits the listing to the zones of a region.
+func (s *ComputeService) ListInstances(ctx context.Context, filters map[string]string) ([]*provider.Instance, error) {
+	region := filters["region"]
+	if !gcpRegionPattern.MatchString(region) {
+		region = ""
+	}
+
+	expr := []string{"labels.managed-by=goman"}
+	for _, k := range labelTags {
+		if v, ok := filters["tag:"+k]; ok {
+			expr = append(expr, fmt.Sprintf("labels.%s=%s", k, labelValue(v)))
+		}
+	}
+
+	var instances []*provider.Instance
+	pageToken := ""
+	for {
+		u := fmt.Sprintf("%s/aggregated/instances?returnPartialSuccess=true&filter=%s", s.projectURL(), url.QueryEscape(strings.Join(expr, " AND ")))
+		if pageToken != "" {
+			u += "&pageToken=" + url.QueryEscape(pageToken)
+		}
+		var page struct {
+			Items map[string]struct {
+				Instances []gceInstance `json:"instances"`
+			} `json:"items"`
+			NextPageToken string `json:"nextPageToken"`
+		}
+		if err := s.client.call(ctx, "compute", "AggregatedListInstances", http.MethodGet, u, nil, &page); err != nil {
+			return nil, fmt.Errorf("failed to list instances: %w", err)
+		}
+		for scope, list := range page.Items {
+			if region != "" && !strings.HasPrefix(strings.TrimPrefix(scope, "zones/"), region+"-") {
+				continue
+			}
+			for i := range list.Instances {
+				inst := convertInstance(&list.Instances[i])
+				if matchesFilters(inst, filters) {
+					instances = append(instances, inst)
+				}
+			}
+		}
+		if page.NextPageToken == "" {
+			return instances, nil
+		}
+		pageToken = page.NextPageToken
+	}
+}
+
+// matchesFilters applies the filters labels cannot express
+func matchesFilters(inst *provider.Instance, filters map[string]string) bool {
+	for k, v := range filters {
+		var actual string
+		switch {
+		case k == "instance-state-name":
+			actual = inst.State
+		case strings.HasPrefix(k, "tag:"):
+			actual = inst.Tags[strings.TrimPrefix(k, "tag:")]
+		default:
+			continue
+		}
+		match := false
+		for _, want := range strings.Split(v, ",") {
+			if strings.TrimSpace(want) == actual {
+				match = true
+				break
+			}
+		}
+		if !match {
+			return false
+		}
+	}
+	return true
+}
+
+// instanceState maps GCE instance statuses to provider states. A
+// TERMINATED instance is stopped; deleted instances are gone.
+func instanceState(status string) string {
+	switch status {
+	case "PROVISIONING", "STAGING", "REPAIRING":
+		return provider.InstanceStatePending
+	case "RUNNING":
+		return provider.InstanceStateRunning
+	case "STOPPING", "SUSPENDING":
+		return provider.InstanceStateStopping
+	case "TERMINATED", "SUSPENDED":
+		return provider.InstanceStateStopped
+	default:
+		return strings.ToLower(status)
+	}
+}
+
+// convertInstance converts a GCE instance to a provider instance
+func convertInstance(inst *gceInstance) *provider.Instance {
+	zone := path.Base(inst.Zone)
+	p := &provider.Instance{
+		ID:               zone + "/" + inst.Name,
+		Name:             inst.Name,
+		State:            instanceState(inst.Status),
+		InstanceType:     path.Base(inst.MachineType),
+		Tags:             make(map[string]string),
+		AvailabilityZone: zone,
+	}
+	if t, err := time.Parse(time.RFC3339, inst.CreationTimestamp); err == nil {
+		p.LaunchTime = t
+	}
+	if len(inst.NetworkInterfaces) > 0 {
+		nic := inst.NetworkInterfaces[0]
+		p.PrivateIP = nic.NetworkIP
+		if len(nic.AccessConfigs) > 0 {
+			p.PublicIP = nic.AccessConfigs[0].NatIP
+		}
+	}
+	if data, ok := inst.Metadata.get(tagsMetadataKey); ok {
+		if err := json.Unmarshal([]byte(data), &p.Tags); err != nil {
+			logger.Printf("Warning: instance %s has invalid tags: %v", p.ID, err)
+		}
+	}
+	if name := p.Tags["Name"]; name != "" {
+		p.Name = name
+	}
+	return p
+}
+
+// StartInstance starts a stopped instance
+func (s *ComputeService) StartInstance(ctx context.Context, instanceID string) error {
+	u, err := s.instanceURL(instanceID)
+	if err != nil {
+		return err
+	}
+	if err := s.client.call(ctx, "compute", "StartInstance", http.MethodPost, u+"/start", nil, nil); err != nil {
+		return fmt.Errorf("failed to start instance: %w", err)
+	}
+	return nil
+}
+
+// StopInstance stops a running instance
+func (s *ComputeService) StopInstance(ctx context.Context, instanceID string) error {
+	u, err := s.instanceURL(instanceID)
+	if err != nil {
+		return err
+	}
+	if err := s.client.call(ctx, "compute", "StopInstance", http.MethodPost, u+"/stop", nil, nil); err != nil {
+		return fmt.Errorf("failed to stop instance: %w", err)
+	}
+	return nil
+}
+
+// ModifyInstanceType changes the machine type of a stopped instance
+func (s *ComputeService) ModifyInstanceType(ctx context.Context, instanceID string, instanceType string) error {
+	zone, _, err := instanceRef(instanceID)
+	if err != nil {
+		return err
+	}
+	u, _ := s.instanceURL(instanceID)
+
+	var op operation
+	body := map[string]string{"machineType": fmt.Sprintf("zones/%s/machineTypes/%s", zone, machineType(instanceType))}
+	if err := s.client.call(ctx, "compute", "SetMachineType", http.MethodPost, u+"/setMachineType", body, &op); err != nil {
+		return fmt.Errorf("failed to modify instance type: %w", err)
+	}
+	if err := s.waitZoneOperation(ctx, &op); err != nil {
+		return fmt.Errorf("failed to modify instance type: %w", err)
+	}
+	logger.Printf("Successfully modified instance %s to type %s", instanceID, instanceType)
+	return nil
+}
+
+// updateMetadata changes an instance's metadata with fn, retrying when a
+// concurrent change moved the fingerprint
+func (s *ComputeService) updateMetadata(ctx context.Context, instanceID string, fn func(*gceMetadata)) error {
+	u, err := s.instanceURL(instanceID)
+	if err != nil {
+		return err
+	}
+	for attempt := 0; ; attempt++ {
+		inst, err := s.getInstance(ctx, instanceID)
+		if err != nil {
+			return err
+		}
+		fn(&inst.Metadata)
+		var op operation
+		err = s.client.call(ctx, "compute", "SetMetadata", http.MethodPost, u+"/setMetadata", inst.Metadata, &op)
+		if err == nil {
+			return s.waitZoneOperation(ctx, &op)
+		}
+		if !errors.Is(err, provider.ErrPreconditionFailed) || attempt >= 4 {
+			return err
+		}
+	}
+}
+
+// TagInstance adds or overwrites tags on an instance
+func (s *ComputeService) TagInstance(ctx context.Context, instanceID string, tags map[string]string) error {
+	err := s.updateMetadata(ctx, instanceID, func(m *gceMetadata) {
+		current := make(map[string]string)
+		if data, ok := m.get(tagsMetadataKey); ok {
+			_ = json.Unmarshal([]byte(data), &current)
+		}
+		for k, v := range tags {
+			current[k] = v
+		}
+		data, _ := json.Marshal(current)
+		m.set(tagsMetadataKey, string(data))
+	})
+	if err != nil {
+		return fmt.Errorf("failed to tag instance %s: %w", instanceID, err)
+	}
+	return nil
+}
+
+// PrepareVirtualIP is not supported: GCE networks route addresses to
+// instances and ignore the gratuitous ARP kube-vip fails over with
+func (s *ComputeService) PrepareVirtualIP(ctx context.Context, clusterName string, instanceIDs []string, address string) (string, error) {
+	return "", provider.UserConfigErrorf("virtual IPs are not supported on GCP; remove virtualIP from the spec of cluster %s", clusterName)
+}
+
+// SetInstanceProtection sets the instance's deletion protection. GCE
+// instances always stop on shutdown and cannot be protected from stopping.
+func (s *ComputeService) SetInstanceProtection(ctx context.Context, instanceID string, shutdownBehavior string, stopProtection, deletionProtection bool) error {
+	if shutdownBehavior == "terminate" || stopProtection {
+		logger.Printf("Instance %s: GCE does not support terminate on shutdown or stop protection, ignoring", instanceID)
+	}
+	u, err := s.instanceURL(instanceID)
+	if err != nil {
+		return err
+	}
+	var op operation
+	err = s.client.call(ctx, "compute", "SetDeletionProtection", http.MethodPost, fmt.Sprintf("%s/setDeletionProtection?deletionProtection=%t", u, deletionProtection), nil, &op)
+	if err == nil {
+		err = s.waitZoneOperation(ctx, &op)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to set deletion protection: %w", err)
+	}
+	return nil
+}
+
+// SyncClusterTags sets user labels on a cluster's instances and disks and
+// removes the given keys. Tags are converted to valid labels.
+func (s *ComputeService) SyncClusterTags(ctx context.Context, clusterName string, tags map[string]string, removed []string) (*provider.TagSyncResult, error) {
+	var instances []gceInstance
+	pageToken := ""
+	for {
+		filter := fmt.Sprintf("labels.goman-cluster=%s", labelValue(clusterName))
+		u := fmt.Sprintf("%s/aggregated/instances?returnPartialSuccess=true&filter=%s", s.projectURL(), url.QueryEscape(filter))
+		if pageToken != "" {
+			u += "&pageToken=" + url.QueryEscape(pageToken)
+		}
+		var page struct {
+			Items map[string]struct {
+				Instances []gceInstance `json:"instances"`
+			} `json:"items"`
+			NextPageToken string `json:"nextPageToken"`
+		}
+		if err := s.client.call(ctx, "compute", "AggregatedListInstances", http.MethodGet, u, nil, &page); err != nil {
+			return nil, fmt.Errorf("failed to list instances: %w", err)
+		}
+		for _, list := range page.Items {
+			instances = append(instances, list.Instances...)
+		}
+		if page.NextPageToken == "" {
+			break
+		}
+		pageToken = page.NextPageToken
+	}
+
+	result := &provider.TagSyncResult{}
+	apply := func(labels map[string]string) (map[string]string, bool) {
+		updated := make(map[string]string, len(labels)+len(tags))
+		for k, v := range labels {
+			updated[k] = v
+		}
+		changed := false
+		for _, k := range removed {
+			if key := labelKey(k); key != "" && key != "managed-by" && !isLabelTag(key) {
+				if _, ok := updated[key]; ok {
+					delete(updated, key)
+					changed = true
+				}
+			}
+		}
+		for k, v := range tags {
+			key := labelKey(k)
+			if key == "" || key == "managed-by" || isLabelTag(key) {
+				continue
+			}
+			if updated[key] != labelValue(v) {
+				updated[key] = labelValue(v)
+				changed = true
+			}
+		}
+		return updated, changed
+	}
+
+	for _, inst := range instances {
+		result.Resources++
+		if labels, changed := apply(inst.Labels); changed {
+			u := fmt.Sprintf("%s/zones/%s/instances/%s/setLabels", s.projectURL(), path.Base(inst.Zone), inst.Name)
+			body := map[string]interface{}{"labels": labels, "labelFingerprint": inst.LabelFingerprint}
+			if err := s.client.call(ctx, "compute", "SetLabels", http.MethodPost, u, body, nil); err != nil {
+				return result, fmt.Errorf("failed to label instance %s: %w", inst.Name, err)
+			}
+			result.Updated++
+		}
+
+		for _, d := range inst.Disks {
+			var disk struct {
+				Labels           map[string]string `json:"labels"`
+				LabelFingerprint string            `json:"labelFingerprint"`
+			}
+			if err := s.client.call(ctx, "compute", "GetDisk", http.MethodGet, d.Source, nil, &disk); err != nil {
+				return result, fmt.Errorf("failed to get disk of %s: %w", inst.Name, err)
+			}
+			result.Resources++
+			if labels, changed := apply(disk.Labels); changed {
+				body := map[string]interface{}{"labels": labels, "labelFingerprint": disk.LabelFingerprint}
+				if err := s.client.call(ctx, "compute", "SetDiskLabels", http.MethodPost, d.Source+"/setLabels", body, nil); err != nil {
+					return result, fmt.Errorf("failed to label disk of %s: %w", inst.Name, err)
+				}
+				result.Updated++
+			}
+		}
+	}
+	return result, nil
+}
+
+func isLabelTag(key string) bool {
+	for _, k := range labelTags {
+		if k == key {
+			return true
+		}
+	}
+	return false
+}
+
+// clusterPorts are the ports a cluster's nodes reach each other on, those
+// of the security group on AWS
+var clusterPorts = []map[string]interface{}{
+	{"IPProtocol": "tcp", "ports": []string{"6443", "2379-2380", "10250", "5001"}},
+	{"IPProtocol": "udp", "ports": []string{"8472", "51820-51821"}},
+	{"IPProtocol": "icmp"},
+}
+
+// ensureClusterFirewall lets a cluster's nodes reach each other
+func (s *ComputeService) ensureClusterFirewall(ctx context.Context, clusterName string) error {
+	tag := networkTag(clusterName)
+	rule := map[string]interface{}{
+		"name":        tag,
+		"description": fmt.Sprintf("Node traffic of goman cluster %s", clusterName),
+		"network":     "global/networks/default",
+		"direction":   "INGRESS",
+		"sourceTags":  []string{tag},
+		"targetTags":  []string{tag},
+		"allowed":     clusterPorts,
+	}
+	err := s.client.call(ctx, "compute", "InsertFirewall", http.MethodPost, s.projectURL()+"/global/firewalls", rule, nil)
+	if err != nil && !isConflict(err) {
+		return fmt.Errorf("failed to create firewall rule %s: %w", tag, err)
+	}
+	return nil
+}
+
+// linkFirewallName names the rule admitting traffic from one cluster to another
+func linkFirewallName(from, to string) string {
+	return instanceName(fmt.Sprintf("goman-link-%s-%s", from, to))
+}
+
+// LinkClusters lets two clusters reach each other's nodes with firewall
+// rules. VPC networks are global, so clusters in any region share the
+// default network and never need peering.
+func (s *ComputeService) LinkClusters(ctx context.Context, link provider.ClusterLinkConfig) (*provider.ClusterLinkResult, error) {
+	var allowed []map[string]interface{}
+	for _, p := range link.Ports {
+		ports := fmt.Sprintf("%d", p.FromPort)
+		if p.ToPort != p.FromPort {
+			ports = fmt.Sprintf("%d-%d", p.FromPort, p.ToPort)
+		}
+		allowed = append(allowed, map[string]interface{}{"IPProtocol": p.Protocol, "ports": []string{ports}})
+	}
+
+	for _, pair := range [][2]string{{link.Cluster, link.PeerCluster}, {link.PeerCluster, link.Cluster}} {
+		name := linkFirewallName(pair[0], pair[1])
+		rule := map[string]interface{}{
+			"name":        name,
+			"description": fmt.Sprintf("goman cluster link from %s to %s", pair[0], pair[1]),
+			"network":     "global/networks/default",
+			"direction":   "INGRESS",
+			"sourceTags":  []string{networkTag(pair[0])},
+			"targetTags":  []string{networkTag(pair[1])},
+			"allowed":     allowed,
+		}
+		err := s.client.call(ctx, "compute", "InsertFirewall", http.MethodPost, s.projectURL()+"/global/firewalls", rule, nil)
+		if isConflict(err) {
+			err = s.client.call(ctx, "compute", "UpdateFirewall", http.MethodPut, s.projectURL()+"/global/firewalls/"+name, rule, nil)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to create firewall rule %s: %w", name, err)
+		}
+	}
+	return &provider.ClusterLinkResult{Mode: provider.LinkModeSecurityGroup}, nil
+}
+
+// UnlinkClusters removes the firewall rules LinkClusters created
+func (s *ComputeService) UnlinkClusters(ctx context.Context, link provider.ClusterLinkConfig, peeringID string) error {
+	for _, name := range []string{linkFirewallName(link.Cluster, link.PeerCluster), linkFirewallName(link.PeerCluster, link.Cluster)} {
+		err := s.client.call(ctx, "compute", "DeleteFirewall", http.MethodDelete, s.projectURL()+"/global/firewalls/"+name, nil, nil)
+		if err != nil && !isNotFound(err) {
+			return fmt.Errorf("failed to delete firewall rule %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// EnsureClusterIdentity returns the service account a cluster's nodes run
+// as. Clusters share it; GCS offers no per-prefix scoping without IAM
+// conditions on every secret.
+func (s *ComputeService) EnsureClusterIdentity(ctx context.Context, clusterName string) (string, error) {
+	return defaultServiceAccount(ctx, s.client, s.project)
+}
+
+// ListClusterIdentities returns no clusters, they share one service account
+func (s *ComputeService) ListClusterIdentities(ctx context.Context) ([]string, error) {
+	return nil, nil
+}
+
+// DeleteClusterIdentity removes the cluster's firewall rule; the shared
+// service account stays
+func (s *ComputeService) DeleteClusterIdentity(ctx context.Context, clusterName string) error {
+	err := s.client.call(ctx, "compute", "DeleteFirewall", http.MethodDelete, s.projectURL()+"/global/firewalls/"+networkTag(clusterName), nil, nil)
+	if err != nil && !isNotFound(err) {
+		var opErr *provider.OperationError
+		if errors.As(err, &opErr) && opErr.Code == "RESOURCE_IN_USE_BY_ANOTHER_RESOURCE" {
+			return provider.ErrIdentityInUse
+		}
+		return fmt.Errorf("failed to delete firewall rule %s: %w", networkTag(clusterName), err)
+	}
+	return nil
+}
+
+var _ provider.ComputeService = (*ComputeService)(nil)
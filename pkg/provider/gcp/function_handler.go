@@ -0,0 +1,223 @@
+package gcp
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/madhouselabs/goman/pkg/controller"
+	"github.com/madhouselabs/goman/pkg/models"
+	"github.com/madhouselabs/goman/pkg/provider"
+	"github.com/madhouselabs/goman/pkg/storage"
+)
+
+// FunctionEvent is a direct invocation or a requeue task
+type FunctionEvent struct {
+	ClusterName string `json:"cluster_name"`
+	Action      string `json:"action"`
+}
+
+// storageEvent is the object of a google.cloud.storage.object.v1.finalized
+// CloudEvent, which Eventarc delivers in binary mode
+type storageEvent struct {
+	Bucket string `json:"bucket"`
+	Name   string `json:"name"`
+}
+
+// FunctionHandler wraps the reconciler for Cloud Functions
+type FunctionHandler struct {
+	reconciler *controller.Reconciler
+	provider   *GCPProvider
+}
+
+// NewFunctionHandler creates a new function handler
+func NewFunctionHandler() (*FunctionHandler, error) {
+	prov, err := NewProvider("", "") // Project and region come from the environment
+	if err != nil {
+		return nil, fmt.Errorf("failed to create provider: %w", err)
+	}
+
+	ctx := context.Background()
+	if err := prov.GetLockService().Initialize(ctx); err != nil {
+		log.Printf("Warning: Lock service initialization error: %v", err)
+	}
+	if err := prov.GetStorageService().Initialize(ctx); err != nil {
+		return nil, fmt.Errorf("failed to initialize storage service: %w", err)
+	}
+	if err := prov.GetNotificationService().Initialize(ctx); err != nil {
+		log.Printf("Warning: Notification service initialization error: %v", err)
+	}
+
+	owner := fmt.Sprintf("function-%s-%d", prov.Region(), time.Now().UnixNano())
+	reconciler, err := controller.NewReconciler(prov, owner)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create reconciler: %w", err)
+	}
+
+	// Read tunable timings once per cold start
+	if err := reconciler.SetSettings(controller.LoadSettings(ctx, prov.GetStorageService())); err != nil {
+		log.Printf("Warning: %v", err)
+	}
+
+	return &FunctionHandler{reconciler: reconciler, provider: prov}, nil
+}
+
+// ServeHTTP reconciles the cluster an event is about
+func (h *FunctionHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	ctx := r.Context()
+	requestID := r.Header.Get("Function-Execution-Id")
+	if requestID == "" {
+		requestID = "unknown"
+	}
+
+	var clusterName string
+	switch eventType := r.Header.Get("Ce-Type"); eventType {
+	case "google.cloud.storage.object.v1.finalized":
+		var event storageEvent
+		if err := json.Unmarshal(body, &event); err != nil {
+			http.Error(w, fmt.Sprintf("invalid storage event: %v", err), http.StatusBadRequest)
+			return
+		}
+		clusterName = extractClusterName(event.Name)
+		if clusterName == "" {
+			// Status writes, secrets and command output; nothing to do
+			writeJSON(w, &models.ReconcileResult{})
+			return
+		}
+		// Rapid edits fire one event each; the first reconcile covers them all
+		if strings.HasSuffix(event.Name, "/config.yaml") && !h.reconciler.SpecChangePending(ctx, clusterName) {
+			log.Printf("Spec of cluster %s already reconciled, skipping storage event", clusterName)
+			writeJSON(w, &models.ReconcileResult{})
+			return
+		}
+		log.Printf("Processing storage event for cluster: %s", clusterName)
+	case "":
+		var event FunctionEvent
+		if err := json.Unmarshal(body, &event); err != nil || event.ClusterName == "" {
+			http.Error(w, "invalid event format or missing cluster name", http.StatusBadRequest)
+			return
+		}
+		clusterName = event.ClusterName
+	default:
+		log.Printf("Ignoring event of type %s", eventType)
+		writeJSON(w, &models.ReconcileResult{})
+		return
+	}
+
+	result, err := h.reconciler.ReconcileClusterWithRequestID(ctx, clusterName, requestID)
+	if err != nil {
+		log.Printf("Reconcile of cluster %s failed: %v", clusterName, err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if result != nil && result.Requeue {
+		if err := h.scheduleRequeue(ctx, clusterName, result.RequeueAfter); err != nil {
+			log.Printf("Failed to schedule requeue for cluster %s: %v", clusterName, err)
+		}
+	}
+	writeJSON(w, result)
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// extractClusterName extracts the cluster name from the key of a cluster's
+// config.yaml or status.yaml
+func extractClusterName(key string) string {
+	path, ok := strings.CutPrefix(key, "clusters/")
+	if !ok {
+		return ""
+	}
+	parts := strings.Split(path, "/")
+	if len(parts) == 2 && (parts[1] == "config.yaml" || parts[1] == "status.yaml") {
+		return parts[0]
+	}
+	return ""
+}
+
+// scheduleRequeue creates a Cloud Task that invokes the function again
+// after requeueAfter
+func (h *FunctionHandler) scheduleRequeue(ctx context.Context, clusterName string, requeueAfter time.Duration) error {
+	// Deleted clusters are not requeued
+	configKey := storage.ClusterConfigKey(clusterName)
+	if _, err := h.provider.GetStorageService().GetObject(ctx, configKey); err != nil {
+		if errors.Is(err, provider.ErrNotFound) {
+			log.Printf("Cluster %s no longer exists, not scheduling requeue", clusterName)
+			return nil
+		}
+		log.Printf("Warning: could not check cluster %s existence: %v", clusterName, err)
+	}
+
+	fs := h.provider.functionService
+	uri, err := fs.GetFunctionURL(ctx, controllerFunctionName(h.provider.project))
+	if err != nil {
+		return err
+	}
+	serviceAccount, err := fs.serviceAccount(ctx)
+	if err != nil {
+		return err
+	}
+	payload, err := json.Marshal(FunctionEvent{ClusterName: clusterName, Action: "requeue"})
+	if err != nil {
+		return fmt.Errorf("failed to marshal requeue message: %w", err)
+	}
+
+	if requeueAfter < 0 {
+		requeueAfter = 0
+	}
+	task := map[string]interface{}{
+		"task": map[string]interface{}{
+			"scheduleTime": time.Now().Add(requeueAfter).UTC().Format(time.RFC3339),
+			"httpRequest": map[string]interface{}{
+				"url":        uri,
+				"httpMethod": "POST",
+				"headers":    map[string]string{"Content-Type": "application/json"},
+				"body":       base64.StdEncoding.EncodeToString(payload),
+				"oidcToken":  map[string]string{"serviceAccountEmail": serviceAccount, "audience": uri},
+			},
+		},
+	}
+	err = fs.client.call(ctx, "cloudtasks", "CreateTask", http.MethodPost,
+		endpoint("cloudtasks")+"/"+fs.queueName()+"/tasks", task, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create requeue task: %w", err)
+	}
+
+	log.Printf("Scheduled requeue for cluster %s in %s", clusterName, requeueAfter)
+	return nil
+}
+
+var (
+	handler     *FunctionHandler
+	handlerErr  error
+	handlerOnce sync.Once
+)
+
+// Reconcile is the HTTP entry point of the controller function. The
+// handler is created on the first request of an instance.
+func Reconcile(w http.ResponseWriter, r *http.Request) {
+	handlerOnce.Do(func() {
+		handler, handlerErr = NewFunctionHandler()
+	})
+	if handlerErr != nil {
+		log.Printf("ERROR: Failed to create handler: %v", handlerErr)
+		http.Error(w, handlerErr.Error(), http.StatusInternalServerError)
+		return
+	}
+	handler.ServeHTTP(w, r)
+}
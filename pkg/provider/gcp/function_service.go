@@ -0,0 +1,338 @@
+package gcp
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/madhouselabs/goman/pkg/config"
+	"github.com/madhouselabs/goman/pkg/logger"
+)
+
+const (
+	functionRuntime    = "go123"
+	functionEntryPoint = "Reconcile"
+	requeueQueueName   = "goman-reconcile-queue"
+)
+
+// FunctionService implements serverless functions using Cloud Functions
+// (2nd gen). The controller is an HTTP function; Eventarc delivers state
+// bucket changes to it and Cloud Tasks requeues reconciles.
+type FunctionService struct {
+	client  *apiClient
+	project string
+	region  string
+}
+
+// NewFunctionService creates a new Cloud Functions-based function service
+func NewFunctionService(client *apiClient, project, region string) *FunctionService {
+	return &FunctionService{
+		client:  client,
+		project: project,
+		region:  region,
+	}
+}
+
+type cloudFunction struct {
+	Name          string `json:"name"`
+	State         string `json:"state"`
+	ServiceConfig struct {
+		URI            string `json:"uri"`
+		ServiceAccount string `json:"serviceAccountEmail"`
+	} `json:"serviceConfig"`
+}
+
+func (s *FunctionService) locationURL(service string) string {
+	return fmt.Sprintf("%s/projects/%s/locations/%s", endpoint(service), s.project, s.region)
+}
+
+func (s *FunctionService) functionURL(name string) string {
+	return s.locationURL("cloudfunctions") + "/functions/" + name
+}
+
+// Initialize ensures required resources exist
+func (s *FunctionService) Initialize(ctx context.Context) error {
+	return nil
+}
+
+// DeployFunction uploads the function source package and creates or
+// updates the function, waiting for its build
+func (s *FunctionService) DeployFunction(ctx context.Context, name string, packagePath string) error {
+	packageData, err := os.ReadFile(packagePath)
+	if err != nil {
+		return fmt.Errorf("failed to read package %s (build it with 'task build:function-gcp'): %w", packagePath, err)
+	}
+
+	var upload struct {
+		UploadURL     string                 `json:"uploadUrl"`
+		StorageSource map[string]interface{} `json:"storageSource"`
+	}
+	err = s.client.call(ctx, "cloudfunctions", "GenerateUploadUrl", http.MethodPost,
+		s.locationURL("cloudfunctions")+"/functions:generateUploadUrl", map[string]string{}, &upload)
+	if err != nil {
+		return fmt.Errorf("failed to get upload URL: %w", err)
+	}
+	if err := s.uploadSource(ctx, upload.UploadURL, packageData); err != nil {
+		return err
+	}
+
+	serviceAccount, err := s.serviceAccount(ctx)
+	if err != nil {
+		return err
+	}
+	function := map[string]interface{}{
+		"buildConfig": map[string]interface{}{
+			"runtime":    functionRuntime,
+			"entryPoint": functionEntryPoint,
+			"source":     map[string]interface{}{"storageSource": upload.StorageSource},
+		},
+		"serviceConfig": map[string]interface{}{
+			"timeoutSeconds":                540,
+			"availableMemory":               "512Mi",
+			"maxInstanceCount":              10,
+			"ingressSettings":               "ALLOW_ALL",
+			"serviceAccountEmail":           serviceAccount,
+			"environmentVariables":          s.functionEnvironment(serviceAccount),
+			"allTrafficOnLatestRevision":    true,
+			"maxInstanceRequestConcurrency": 1,
+		},
+		"labels": map[string]string{"application": "goman"},
+	}
+
+	exists, err := s.FunctionExists(ctx, name)
+	if err != nil {
+		return err
+	}
+	var op operation
+	if exists {
+		logger.Printf("Updating function %s", name)
+		err = s.client.call(ctx, "cloudfunctions", "UpdateFunction", http.MethodPatch,
+			s.functionURL(name)+"?updateMask=buildConfig,serviceConfig,labels", function, &op)
+	} else {
+		logger.Printf("Creating function %s", name)
+		err = s.client.call(ctx, "cloudfunctions", "CreateFunction", http.MethodPost,
+			s.locationURL("cloudfunctions")+"/functions?functionId="+name, function, &op)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to deploy function: %w", err)
+	}
+
+	// Builds take a few minutes
+	waitCtx, cancel := context.WithTimeout(ctx, 15*time.Minute)
+	defer cancel()
+	if err := s.client.wait(waitCtx, "cloudfunctions", &op, endpoint("cloudfunctions")+"/"+op.Name); err != nil {
+		return fmt.Errorf("failed waiting for function to be active: %w", err)
+	}
+	return nil
+}
+
+// uploadSource puts the package to the signed URL Cloud Functions handed
+// out, which takes no credentials
+func (s *FunctionService) uploadSource(ctx context.Context, uploadURL string, data []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, uploadURL, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/zip")
+	resp, err := s.client.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to upload function source: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("failed to upload function source: %s: %s", resp.Status, bytes.TrimSpace(body))
+	}
+	return nil
+}
+
+// serviceAccount returns the account the function runs and is triggered as
+func (s *FunctionService) serviceAccount(ctx context.Context) (string, error) {
+	return defaultServiceAccount(ctx, s.client, s.project)
+}
+
+// defaultServiceAccount returns the account goman's function and nodes run
+// as: GOMAN_GCP_SERVICE_ACCOUNT, or the project's Compute Engine default
+// service account
+func defaultServiceAccount(ctx context.Context, client *apiClient, project string) (string, error) {
+	if account := os.Getenv("GOMAN_GCP_SERVICE_ACCOUNT"); account != "" {
+		return account, nil
+	}
+	var p struct {
+		DefaultServiceAccount string `json:"defaultServiceAccount"`
+	}
+	err := client.call(ctx, "compute", "GetProject", http.MethodGet,
+		fmt.Sprintf("%s/projects/%s", endpoint("compute"), project), nil, &p)
+	if err != nil {
+		return "", fmt.Errorf("failed to get default service account: %w", err)
+	}
+	return p.DefaultServiceAccount, nil
+}
+
+// functionEnvironment returns the environment of the controller function
+func (s *FunctionService) functionEnvironment(serviceAccount string) map[string]string {
+	return map[string]string{
+		"GOMAN_PROVIDER":            "gcp",
+		"GOMAN_GCP_PROJECT":         s.project,
+		"GOMAN_GCP_REGION":          s.region,
+		"GOMAN_GCP_SERVICE_ACCOUNT": serviceAccount,
+		"GOMAN_STATE_BUCKET":        config.GetStateBucket(s.project),
+		"GOMAN_SECRET_BACKEND":      config.GetSecretBackend(),
+	}
+}
+
+// InvokeFunction calls an HTTP function with payload and an identity token
+func (s *FunctionService) InvokeFunction(ctx context.Context, name string, payload []byte) ([]byte, error) {
+	uri, err := s.GetFunctionURL(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	token, err := s.client.tokens.IdentityToken(ctx, uri)
+	if err != nil {
+		return nil, fmt.Errorf("failed to invoke function: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, uri, bytes.NewReader(payload))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := s.client.http.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to invoke function: %w", err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read function response: %w", err)
+	}
+	if resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("function error: %s: %s", resp.Status, bytes.TrimSpace(body))
+	}
+	return body, nil
+}
+
+// DeleteFunction deletes a function
+func (s *FunctionService) DeleteFunction(ctx context.Context, name string) error {
+	var op operation
+	if err := s.client.call(ctx, "cloudfunctions", "DeleteFunction", http.MethodDelete, s.functionURL(name), nil, &op); err != nil {
+		return fmt.Errorf("failed to delete function: %w", err)
+	}
+	waitCtx, cancel := context.WithTimeout(ctx, 5*time.Minute)
+	defer cancel()
+	if err := s.client.wait(waitCtx, "cloudfunctions", &op, endpoint("cloudfunctions")+"/"+op.Name); err != nil {
+		return fmt.Errorf("failed waiting for function deletion: %w", err)
+	}
+	return nil
+}
+
+// FunctionExists checks if a function exists
+func (s *FunctionService) FunctionExists(ctx context.Context, name string) (bool, error) {
+	_, err := s.getFunction(ctx, name)
+	if isNotFound(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to get function: %w", err)
+	}
+	return true, nil
+}
+
+// GetFunctionURL returns the HTTPS endpoint of a function. Callers need an
+// identity token of an account with the Cloud Run invoker role.
+func (s *FunctionService) GetFunctionURL(ctx context.Context, name string) (string, error) {
+	fn, err := s.getFunction(ctx, name)
+	if err != nil {
+		return "", fmt.Errorf("failed to get function URL: %w", err)
+	}
+	if fn.ServiceConfig.URI == "" {
+		return "", fmt.Errorf("function %s has no URL yet (state %s)", name, fn.State)
+	}
+	return fn.ServiceConfig.URI, nil
+}
+
+func (s *FunctionService) getFunction(ctx context.Context, name string) (*cloudFunction, error) {
+	var fn cloudFunction
+	if err := s.client.call(ctx, "cloudfunctions", "GetFunction", http.MethodGet, s.functionURL(name), nil, &fn); err != nil {
+		return nil, err
+	}
+	return &fn, nil
+}
+
+// EnsureStorageTrigger routes object changes in the state bucket to the
+// function through Eventarc, like the S3 notification of the Lambda
+func (s *FunctionService) EnsureStorageTrigger(ctx context.Context, functionName, bucket string) error {
+	serviceAccount, err := s.serviceAccount(ctx)
+	if err != nil {
+		return err
+	}
+	trigger := map[string]interface{}{
+		"eventFilters": []map[string]string{
+			{"attribute": "type", "value": "google.cloud.storage.object.v1.finalized"},
+			{"attribute": "bucket", "value": bucket},
+		},
+		"destination": map[string]interface{}{
+			"cloudRun": map[string]string{"service": functionName, "region": s.region},
+		},
+		"serviceAccount": serviceAccount,
+		"labels":         map[string]string{"application": "goman"},
+	}
+
+	var op operation
+	err = s.client.call(ctx, "eventarc", "CreateTrigger", http.MethodPost,
+		s.locationURL("eventarc")+"/triggers?triggerId="+functionName+"-storage", trigger, &op)
+	if isConflict(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to create storage trigger: %w", err)
+	}
+	waitCtx, cancel := context.WithTimeout(ctx, 5*time.Minute)
+	defer cancel()
+	return s.client.wait(waitCtx, "eventarc", &op, endpoint("eventarc")+"/"+op.Name)
+}
+
+// DeleteStorageTrigger removes the trigger EnsureStorageTrigger created
+func (s *FunctionService) DeleteStorageTrigger(ctx context.Context, functionName string) error {
+	err := s.client.call(ctx, "eventarc", "DeleteTrigger", http.MethodDelete,
+		s.locationURL("eventarc")+"/triggers/"+functionName+"-storage", nil, nil)
+	if err != nil && !isNotFound(err) {
+		return err
+	}
+	return nil
+}
+
+// queueName returns the full name of the requeue queue
+func (s *FunctionService) queueName() string {
+	return fmt.Sprintf("projects/%s/locations/%s/queues/%s", s.project, s.region, requeueQueueName)
+}
+
+// EnsureRequeueQueue creates the Cloud Tasks queue delayed reconciles are
+// scheduled on, the counterpart of the Lambda's SQS queue
+func (s *FunctionService) EnsureRequeueQueue(ctx context.Context) (string, error) {
+	queue := map[string]interface{}{
+		"name": s.queueName(),
+		"retryConfig": map[string]interface{}{
+			"maxAttempts": 3,
+		},
+	}
+	err := s.client.call(ctx, "cloudtasks", "CreateQueue", http.MethodPost, s.locationURL("cloudtasks")+"/queues", queue, nil)
+	if err != nil && !isConflict(err) {
+		return "", fmt.Errorf("failed to create queue: %w", err)
+	}
+	return s.queueName(), nil
+}
+
+// DeleteRequeueQueue removes the requeue queue
+func (s *FunctionService) DeleteRequeueQueue(ctx context.Context) error {
+	err := s.client.call(ctx, "cloudtasks", "DeleteQueue", http.MethodDelete, endpoint("cloudtasks")+"/"+s.queueName(), nil, nil)
+	if err != nil && !isNotFound(err) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,313 @@
+package gcp
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/madhouselabs/goman/pkg/provider"
+)
+
+const (
+	lockCollection = "goman-resource-locks"
+	lockTTLField   = "expires_at"
+)
+
+// LockService implements distributed locking using Firestore documents.
+// Writes are conditional on the update time of the document read, the way
+// the DynamoDB lock service uses condition expressions.
+type LockService struct {
+	client   *apiClient
+	project  string
+	location string
+}
+
+// NewLockService creates a new Firestore-based lock service
+func NewLockService(client *apiClient, project, region string) *LockService {
+	return &LockService{
+		client:   client,
+		project:  project,
+		location: region,
+	}
+}
+
+// firestoreValue is a typed Firestore field value
+type firestoreValue struct {
+	StringValue    *string `json:"stringValue,omitempty"`
+	TimestampValue *string `json:"timestampValue,omitempty"`
+}
+
+type firestoreDocument struct {
+	Name       string                    `json:"name,omitempty"`
+	Fields     map[string]firestoreValue `json:"fields"`
+	UpdateTime string                    `json:"updateTime,omitempty"`
+}
+
+func stringValue(s string) firestoreValue {
+	return firestoreValue{StringValue: &s}
+}
+
+func timestampValue(t time.Time) firestoreValue {
+	s := t.UTC().Format(time.RFC3339Nano)
+	return firestoreValue{TimestampValue: &s}
+}
+
+func (d *firestoreDocument) str(field string) string {
+	if v, ok := d.Fields[field]; ok && v.StringValue != nil {
+		return *v.StringValue
+	}
+	return ""
+}
+
+func (d *firestoreDocument) expiresAt() time.Time {
+	if v, ok := d.Fields[lockTTLField]; ok && v.TimestampValue != nil {
+		t, _ := time.Parse(time.RFC3339Nano, *v.TimestampValue)
+		return t
+	}
+	return time.Time{}
+}
+
+func (s *LockService) databaseURL() string {
+	return fmt.Sprintf("%s/projects/%s/databases/(default)", endpoint("firestore"), s.project)
+}
+
+// documentURL returns the lock document of a resource; document IDs cannot
+// contain slashes
+func (s *LockService) documentURL(resourceID string) string {
+	return fmt.Sprintf("%s/documents/%s/%s", s.databaseURL(), lockCollection,
+		url.PathEscape(strings.ReplaceAll(resourceID, "/", "__")))
+}
+
+// Initialize ensures the Firestore database exists, and lets Firestore
+// delete expired locks
+func (s *LockService) Initialize(ctx context.Context) error {
+	err := s.client.call(ctx, "firestore", "GetDatabase", http.MethodGet, s.databaseURL(), nil, nil)
+	if err == nil {
+		return nil
+	}
+	if isFunctionEnvironment() {
+		log.Printf("Warning: Could not get Firestore database of project %s in function environment: %v", s.project, err)
+		return nil
+	}
+	if !isNotFound(err) {
+		return fmt.Errorf("failed to get Firestore database: %w", err)
+	}
+
+	var op operation
+	err = s.client.call(ctx, "firestore", "CreateDatabase", http.MethodPost,
+		fmt.Sprintf("%s/projects/%s/databases?databaseId=(default)", endpoint("firestore"), s.project),
+		map[string]string{"locationId": s.location, "type": "FIRESTORE_NATIVE"}, &op)
+	if err != nil && !isConflict(err) {
+		return fmt.Errorf("failed to create Firestore database: %w", err)
+	}
+	if err == nil {
+		waitCtx, cancel := context.WithTimeout(ctx, 5*time.Minute)
+		defer cancel()
+		if err := s.client.wait(waitCtx, "firestore", &op, endpoint("firestore")+"/"+op.Name); err != nil {
+			return fmt.Errorf("failed waiting for Firestore database: %w", err)
+		}
+	}
+
+	// Expired locks are ignored anyway; the TTL policy only tidies them up
+	ttl := map[string]interface{}{"ttlConfig": map[string]interface{}{}}
+	err = s.client.call(ctx, "firestore", "UpdateField", http.MethodPatch,
+		fmt.Sprintf("%s/collectionGroups/%s/fields/%s?updateMask=ttlConfig", s.databaseURL(), lockCollection, lockTTLField), ttl, nil)
+	if err != nil {
+		log.Printf("Warning: Could not enable TTL on %s: %v", lockCollection, err)
+	}
+	return nil
+}
+
+// AcquireLock tries to acquire a lock for a resource
+func (s *LockService) AcquireLock(ctx context.Context, resourceID string, owner string, ttl time.Duration) (string, error) {
+	return s.acquire(ctx, resourceID, owner, ttl, nil)
+}
+
+// AcquireLockWithMetadata tries to acquire a lock with additional metadata
+func (s *LockService) AcquireLockWithMetadata(ctx context.Context, resourceID string, owner string, ttl time.Duration, metadata *provider.LockMetadata) (string, error) {
+	token, err := s.acquire(ctx, resourceID, owner, ttl, metadata)
+	if err != nil {
+		return "", err
+	}
+
+	phase, step, requestID := "unknown", "unknown", "unknown"
+	if metadata != nil {
+		phase, step, requestID = metadata.Phase, metadata.Step, metadata.RequestID
+	}
+	log.Printf("[LOCK] Acquired lock for %s (phase: %s, step: %s, requestId: %s)", resourceID, phase, step, requestID)
+	return token, nil
+}
+
+func (s *LockService) acquire(ctx context.Context, resourceID, owner string, ttl time.Duration, metadata *provider.LockMetadata) (string, error) {
+	token := newLockToken()
+	now := time.Now()
+	doc := firestoreDocument{Fields: map[string]firestoreValue{
+		"resource_id": stringValue(resourceID),
+		"owner":       stringValue(owner),
+		"token":       stringValue(token),
+		lockTTLField:  timestampValue(now.Add(ttl)),
+		"created_at":  stringValue(now.Format(time.RFC3339)),
+	}}
+	if metadata != nil {
+		doc.Fields["phase"] = stringValue(metadata.Phase)
+		doc.Fields["step"] = stringValue(metadata.Step)
+		doc.Fields["request_id"] = stringValue(metadata.RequestID)
+		doc.Fields["started_at"] = stringValue(metadata.StartedAt.Format(time.RFC3339))
+	}
+
+	collectionURL := fmt.Sprintf("%s/documents/%s?documentId=%s", s.databaseURL(), lockCollection,
+		url.QueryEscape(strings.ReplaceAll(resourceID, "/", "__")))
+	err := s.client.call(ctx, "firestore", "CreateDocument", http.MethodPost, collectionURL, doc, nil)
+	if err == nil {
+		return token, nil
+	}
+	if !isConflict(err) {
+		return "", fmt.Errorf("failed to acquire lock: %w", err)
+	}
+
+	// Held already; take it over only if it expired and nobody else did
+	current, err := s.get(ctx, resourceID)
+	if err != nil {
+		return "", fmt.Errorf("failed to acquire lock: %w", err)
+	}
+	if current == nil || current.expiresAt().After(now) {
+		if current != nil {
+			return "", fmt.Errorf("resource %s is locked by %s", resourceID, current.str("owner"))
+		}
+		return "", fmt.Errorf("lock condition check failed")
+	}
+	err = s.client.call(ctx, "firestore", "UpdateDocument", http.MethodPatch,
+		s.documentURL(resourceID)+"?currentDocument.updateTime="+url.QueryEscape(current.UpdateTime), doc, nil)
+	if err != nil {
+		if isPreconditionFailed(err) {
+			locked, lockOwner, _ := s.IsLocked(ctx, resourceID)
+			if locked {
+				return "", fmt.Errorf("resource %s is locked by %s", resourceID, lockOwner)
+			}
+			return "", fmt.Errorf("lock condition check failed")
+		}
+		return "", fmt.Errorf("failed to acquire lock: %w", err)
+	}
+	return token, nil
+}
+
+// get returns the lock document of a resource, nil if there is none
+func (s *LockService) get(ctx context.Context, resourceID string) (*firestoreDocument, error) {
+	var doc firestoreDocument
+	err := s.client.call(ctx, "firestore", "GetDocument", http.MethodGet, s.documentURL(resourceID), nil, &doc)
+	if isNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &doc, nil
+}
+
+// ReleaseLock releases a lock using the token
+func (s *LockService) ReleaseLock(ctx context.Context, resourceID string, token string) error {
+	doc, err := s.get(ctx, resourceID)
+	if err != nil {
+		log.Printf("[LOCK] Failed to release lock for %s: %v", resourceID, err)
+		return fmt.Errorf("failed to release lock: %w", err)
+	}
+	if doc == nil || doc.str("token") != token {
+		log.Printf("[LOCK] Failed to release lock for %s: invalid token or lock already released", resourceID)
+		return fmt.Errorf("invalid token or lock already released")
+	}
+
+	err = s.client.call(ctx, "firestore", "DeleteDocument", http.MethodDelete,
+		s.documentURL(resourceID)+"?currentDocument.updateTime="+url.QueryEscape(doc.UpdateTime), nil, nil)
+	if err != nil {
+		if isPreconditionFailed(err) || isNotFound(err) {
+			log.Printf("[LOCK] Failed to release lock for %s: invalid token or lock already released", resourceID)
+			return fmt.Errorf("invalid token or lock already released")
+		}
+		log.Printf("[LOCK] Failed to release lock for %s: %v", resourceID, err)
+		return fmt.Errorf("failed to release lock: %w", err)
+	}
+
+	log.Printf("[LOCK] Released lock for %s", resourceID)
+	return nil
+}
+
+// RenewLock extends the TTL of an existing lock
+func (s *LockService) RenewLock(ctx context.Context, resourceID string, token string, ttl time.Duration) error {
+	doc, err := s.get(ctx, resourceID)
+	if err != nil {
+		return fmt.Errorf("failed to renew lock: %w", err)
+	}
+	if doc == nil || doc.str("token") != token || !doc.expiresAt().After(time.Now()) {
+		return fmt.Errorf("invalid token or lock expired")
+	}
+
+	update := firestoreDocument{Fields: map[string]firestoreValue{
+		lockTTLField: timestampValue(time.Now().Add(ttl)),
+	}}
+	err = s.client.call(ctx, "firestore", "UpdateDocument", http.MethodPatch,
+		s.documentURL(resourceID)+"?updateMask.fieldPaths="+lockTTLField+
+			"&currentDocument.updateTime="+url.QueryEscape(doc.UpdateTime), update, nil)
+	if err != nil {
+		if isPreconditionFailed(err) {
+			return fmt.Errorf("invalid token or lock expired")
+		}
+		return fmt.Errorf("failed to renew lock: %w", err)
+	}
+	return nil
+}
+
+// IsLocked checks if a resource is currently locked
+func (s *LockService) IsLocked(ctx context.Context, resourceID string) (bool, string, error) {
+	doc, err := s.get(ctx, resourceID)
+	if err != nil {
+		return false, "", fmt.Errorf("failed to get lock document: %w", err)
+	}
+	if doc == nil || !doc.expiresAt().After(time.Now()) {
+		return false, "", nil
+	}
+	return true, doc.str("owner"), nil
+}
+
+// DeleteLocks removes all lock documents, for Cleanup
+func (s *LockService) DeleteLocks(ctx context.Context) error {
+	var page struct {
+		Documents []firestoreDocument `json:"documents"`
+	}
+	err := s.client.call(ctx, "firestore", "ListDocuments", http.MethodGet,
+		fmt.Sprintf("%s/documents/%s?pageSize=300&mask.fieldPaths=owner", s.databaseURL(), lockCollection), nil, &page)
+	if err != nil {
+		if isNotFound(err) {
+			return nil
+		}
+		return err
+	}
+	for _, doc := range page.Documents {
+		if err := s.client.call(ctx, "firestore", "DeleteDocument", http.MethodDelete,
+			endpoint("firestore")+"/"+doc.Name, nil, nil); err != nil && !isNotFound(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// newLockToken returns a random version 4 UUID
+func newLockToken() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		panic(fmt.Sprintf("failed to read random bytes: %v", err))
+	}
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+}
+
+// isPreconditionFailed reports whether a conditional write lost a race
+func isPreconditionFailed(err error) bool {
+	return errors.Is(err, provider.ErrPreconditionFailed)
+}
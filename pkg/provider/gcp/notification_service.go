@@ -0,0 +1,130 @@
+package gcp
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+)
+
+// notificationTopics are the topics goman publishes cluster events to
+var notificationTopics = []string{
+	"goman-cluster-events",
+	"goman-reconcile-events",
+	"goman-error-events",
+}
+
+// NotificationService implements pub/sub using Pub/Sub
+type NotificationService struct {
+	client  *apiClient
+	project string
+	topics  map[string]bool // topics known to exist
+}
+
+// NewNotificationService creates a new Pub/Sub-based notification service
+func NewNotificationService(client *apiClient, project string) *NotificationService {
+	return &NotificationService{
+		client:  client,
+		project: project,
+		topics:  make(map[string]bool),
+	}
+}
+
+func (s *NotificationService) topicURL(topic string) string {
+	return fmt.Sprintf("%s/projects/%s/topics/%s", endpoint("pubsub"), s.project, topic)
+}
+
+// Initialize ensures required topics exist
+func (s *NotificationService) Initialize(ctx context.Context) error {
+	// The function may not create topics; Initialize from the CLI did
+	if isFunctionEnvironment() {
+		log.Println("Running in function environment, skipping Pub/Sub topic initialization")
+		for _, topic := range notificationTopics {
+			s.topics[topic] = true
+		}
+		return nil
+	}
+
+	for _, topic := range notificationTopics {
+		if err := s.ensureTopic(ctx, topic); err != nil {
+			return fmt.Errorf("failed to ensure topic %s: %w", topic, err)
+		}
+	}
+	return nil
+}
+
+// Publish sends a message to a topic
+func (s *NotificationService) Publish(ctx context.Context, topic string, message string) error {
+	if err := s.ensureTopic(ctx, topic); err != nil {
+		return fmt.Errorf("failed to ensure topic %s: %w", topic, err)
+	}
+
+	body := map[string]interface{}{
+		"messages": []map[string]interface{}{{
+			"data":       base64.StdEncoding.EncodeToString([]byte(message)),
+			"attributes": map[string]string{"Source": "goman"},
+		}},
+	}
+	if err := s.client.call(ctx, "pubsub", "Publish", http.MethodPost, s.topicURL(topic)+":publish", body, nil); err != nil {
+		return fmt.Errorf("failed to publish message: %w", err)
+	}
+	return nil
+}
+
+// Subscribe creates a pull subscription to a topic and returns its name
+func (s *NotificationService) Subscribe(ctx context.Context, topic string) (string, error) {
+	if err := s.ensureTopic(ctx, topic); err != nil {
+		return "", fmt.Errorf("failed to ensure topic %s: %w", topic, err)
+	}
+
+	name := fmt.Sprintf("projects/%s/subscriptions/goman-sub-%s-%d", s.project, topic, time.Now().Unix())
+	body := map[string]interface{}{
+		"topic":                    fmt.Sprintf("projects/%s/topics/%s", s.project, topic),
+		"ackDeadlineSeconds":       300,
+		"messageRetentionDuration": "86400s", // 1 day
+		"labels":                   map[string]string{"application": "goman"},
+	}
+	if err := s.client.call(ctx, "pubsub", "CreateSubscription", http.MethodPut, endpoint("pubsub")+"/"+name, body, nil); err != nil {
+		return "", fmt.Errorf("failed to subscribe: %w", err)
+	}
+	return name, nil
+}
+
+// Unsubscribe removes a subscription
+func (s *NotificationService) Unsubscribe(ctx context.Context, subscriptionID string) error {
+	err := s.client.call(ctx, "pubsub", "DeleteSubscription", http.MethodDelete, endpoint("pubsub")+"/"+subscriptionID, nil, nil)
+	if err != nil && !isNotFound(err) {
+		return fmt.Errorf("failed to unsubscribe: %w", err)
+	}
+	return nil
+}
+
+// ensureTopic creates a topic if it doesn't exist
+func (s *NotificationService) ensureTopic(ctx context.Context, topic string) error {
+	if s.topics[topic] {
+		return nil
+	}
+	body := map[string]interface{}{
+		"labels": map[string]string{"application": "goman"},
+	}
+	err := s.client.call(ctx, "pubsub", "CreateTopic", http.MethodPut, s.topicURL(topic), body, nil)
+	if err != nil && !isConflict(err) {
+		return fmt.Errorf("failed to create topic: %w", err)
+	}
+	s.topics[topic] = true
+	return nil
+}
+
+// DeleteTopics removes goman's topics, for Cleanup
+func (s *NotificationService) DeleteTopics(ctx context.Context) error {
+	for _, topic := range notificationTopics {
+		err := s.client.call(ctx, "pubsub", "DeleteTopic", http.MethodDelete, s.topicURL(topic), nil, nil)
+		if err != nil && !isNotFound(err) {
+			return fmt.Errorf("failed to delete topic %s: %w", topic, err)
+		}
+		delete(s.topics, topic)
+	}
+	return nil
+}
@@ -0,0 +1,409 @@
+package gcp
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/madhouselabs/goman/pkg/config"
+	"github.com/madhouselabs/goman/pkg/logger"
+	"github.com/madhouselabs/goman/pkg/provider"
+)
+
+// gcpRegionPattern matches GCP region names such as asia-south1
+var gcpRegionPattern = regexp.MustCompile(`^[a-z]+-[a-z]+[0-9]+$`)
+
+// GCPProvider implements the Provider interface for Google Cloud
+type GCPProvider struct {
+	profile string
+	project string
+	region  string
+	client  *apiClient
+
+	// Services
+	lockService         provider.LockService
+	storageService      *StorageService
+	notificationService provider.NotificationService
+	functionService     *FunctionService
+	computeService      *ComputeService
+	secretService       provider.SecretService
+}
+
+// NewProvider creates a new GCP provider. profile names the project; empty
+// or "default" uses the configured project or that of the credentials.
+func NewProvider(profile, region string) (*GCPProvider, error) {
+	logger.Printf("NewProvider called with profile='%s', region='%s'", profile, region)
+
+	client, err := newAPIClient()
+	if err != nil {
+		return nil, err
+	}
+
+	project := profile
+	if project == "" || project == "default" {
+		project = config.GetGCPProject()
+	}
+	if project == "" {
+		project = client.tokens.projectID()
+	}
+	if project == "" && client.tokens.creds == nil && os.Getenv("GOOGLE_OAUTH_ACCESS_TOKEN") == "" {
+		data, err := client.tokens.metadata(context.Background(), "project/project-id")
+		if err != nil {
+			return nil, fmt.Errorf("failed to determine GCP project: %w", err)
+		}
+		project = strings.TrimSpace(string(data))
+	}
+	if project == "" {
+		return nil, fmt.Errorf("no GCP project configured; set GOMAN_GCP_PROJECT or GOOGLE_CLOUD_PROJECT")
+	}
+
+	// Callers default to AWS regions; those mean nothing here
+	if !gcpRegionPattern.MatchString(region) {
+		if region != "" {
+			logger.Printf("Region %s is not a GCP region, using %s", region, config.GetGCPRegion())
+		}
+		region = config.GetGCPRegion()
+	}
+	logger.Printf("GCP project: %s, region: %s", project, region)
+
+	p := &GCPProvider{
+		profile: profile,
+		project: project,
+		region:  region,
+		client:  client,
+	}
+
+	p.storageService = NewStorageService(client, project, region)
+	p.lockService = NewLockService(client, project, region)
+	p.notificationService = NewNotificationService(client, project)
+	p.functionService = NewFunctionService(client, project, region)
+	p.computeService = NewComputeService(client, project, region, p.storageService)
+
+	secretService, err := NewSecretService(p.storageService)
+	if err != nil {
+		return nil, err
+	}
+	p.secretService = secretService
+
+	return p, nil
+}
+
+var (
+	providerCache *GCPProvider
+	providerMu    sync.Mutex
+)
+
+// GetCachedProvider returns a cached GCP provider instance, so credentials
+// are only looked up once per process
+func GetCachedProvider(profile, region string) (*GCPProvider, error) {
+	providerMu.Lock()
+	defer providerMu.Unlock()
+
+	if providerCache != nil && providerCache.profile == profile &&
+		(providerCache.region == region || !gcpRegionPattern.MatchString(region)) {
+		return providerCache, nil
+	}
+
+	p, err := NewProvider(profile, region)
+	if err != nil {
+		return nil, err
+	}
+	providerCache = p
+	return p, nil
+}
+
+// GetLockService returns the lock service
+func (p *GCPProvider) GetLockService() provider.LockService {
+	return p.lockService
+}
+
+// GetStorageService returns the storage service
+func (p *GCPProvider) GetStorageService() provider.StorageService {
+	return p.storageService
+}
+
+// GetNotificationService returns the notification service
+func (p *GCPProvider) GetNotificationService() provider.NotificationService {
+	return p.notificationService
+}
+
+// GetFunctionService returns the function service
+func (p *GCPProvider) GetFunctionService() provider.FunctionService {
+	return p.functionService
+}
+
+// GetComputeService returns the compute service
+func (p *GCPProvider) GetComputeService() provider.ComputeService {
+	return p.computeService
+}
+
+// GetSecretService returns the secret service
+func (p *GCPProvider) GetSecretService() provider.SecretService {
+	return p.secretService
+}
+
+// Name returns the provider name
+func (p *GCPProvider) Name() string {
+	return "gcp"
+}
+
+// Region returns the provider region
+func (p *GCPProvider) Region() string {
+	return p.region
+}
+
+// GetAccountID returns the GCP project, which scopes goman's resources the
+// way an AWS account does
+func (p *GCPProvider) GetAccountID() string {
+	return p.project
+}
+
+// CallerARN returns the account the provider's credentials belong to
+func (p *GCPProvider) CallerARN(ctx context.Context) (string, error) {
+	email, err := p.client.tokens.Email(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to get caller identity: %w", err)
+	}
+	return email, nil
+}
+
+// GetServiceName returns the GCP service name for a generic service type
+func (p *GCPProvider) GetServiceName(serviceType provider.ServiceType) string {
+	switch serviceType {
+	case provider.ServiceTypeCompute:
+		return "Compute Engine"
+	case provider.ServiceTypeStorage:
+		return "Cloud Storage"
+	case provider.ServiceTypeCommand:
+		return "Guest attributes"
+	case provider.ServiceTypeLock:
+		return "Firestore"
+	case provider.ServiceTypeFunction:
+		return "Cloud Functions"
+	case provider.ServiceTypeNotification:
+		return "Pub/Sub"
+	default:
+		return string(serviceType)
+	}
+}
+
+// GetProviderConfig returns GCP-specific provider configuration
+func (p *GCPProvider) GetProviderConfig() provider.ProviderConfig {
+	return provider.ProviderConfig{
+		DefaultInstanceType: "e2-medium",
+		DefaultRegion:       config.DefaultGCPRegion,
+		DefaultTopics: map[string]string{
+			"reconciliation": requeueQueueName,
+			"notifications":  "goman-cluster-events",
+		},
+		ServiceNames: map[provider.ServiceType]string{
+			provider.ServiceTypeCompute:      "Compute Engine",
+			provider.ServiceTypeStorage:      "Cloud Storage",
+			provider.ServiceTypeCommand:      "Guest attributes",
+			provider.ServiceTypeLock:         "Firestore",
+			provider.ServiceTypeFunction:     "Cloud Functions",
+			provider.ServiceTypeNotification: "Pub/Sub",
+		},
+		CustomSettings: map[string]interface{}{
+			"image":   defaultImage,
+			"project": p.project,
+		},
+	}
+}
+
+// GetServiceConfiguration returns service-specific configuration for GCP
+func (p *GCPProvider) GetServiceConfiguration(serviceType provider.ServiceType) provider.ServiceConfiguration {
+	cfg := provider.DefaultServiceConfiguration(serviceType)
+
+	switch serviceType {
+	case provider.ServiceTypeCompute:
+		cfg.ProviderSpecific = map[string]interface{}{
+			"image":   defaultImage,
+			"network": "default",
+		}
+	case provider.ServiceTypeStorage:
+		cfg.ProviderSpecific = map[string]interface{}{
+			"bucket":     p.storageService.bucketName,
+			"versioning": true,
+		}
+	case provider.ServiceTypeCommand:
+		cfg.ProviderSpecific = map[string]interface{}{
+			"namespace": guestAttributeNamespace,
+		}
+	case provider.ServiceTypeLock:
+		cfg.ProviderSpecific = map[string]interface{}{
+			"collection": lockCollection,
+		}
+	case provider.ServiceTypeFunction:
+		cfg.ProviderSpecific = map[string]interface{}{
+			"runtime":    functionRuntime,
+			"entryPoint": functionEntryPoint,
+			"timeout":    540,
+			"memory":     "512Mi",
+		}
+	case provider.ServiceTypeNotification:
+		cfg.ProviderSpecific = map[string]interface{}{
+			"messageRetentionDuration": "604800s", // 7 days
+		}
+	}
+
+	return cfg
+}
+
+// controllerFunctionName is the name of the controller function of a project
+func controllerFunctionName(project string) string {
+	return fmt.Sprintf("goman-controller-%s", project)
+}
+
+// Initialize sets up the GCP infrastructure goman runs on
+func (p *GCPProvider) Initialize(ctx context.Context) (*provider.InitializeResult, error) {
+	result := &provider.InitializeResult{
+		ProviderType: "gcp",
+		Resources:    make(map[string]string),
+		Errors:       []string{},
+	}
+
+	// Initialize storage service (GCS)
+	if err := p.storageService.Initialize(ctx); err != nil {
+		result.Errors = append(result.Errors, fmt.Sprintf("Storage: %v", err))
+	} else {
+		result.StorageReady = true
+		result.Resources["gcs_bucket"] = p.storageService.bucketName
+	}
+
+	// Initialize lock service (Firestore)
+	if err := p.lockService.Initialize(ctx); err != nil {
+		result.Errors = append(result.Errors, fmt.Sprintf("LockService: %v", err))
+	} else {
+		result.LockServiceReady = true
+		result.Resources["firestore_collection"] = lockCollection
+	}
+
+	// Initialize notification service (Pub/Sub topics)
+	if err := p.notificationService.Initialize(ctx); err != nil {
+		result.Errors = append(result.Errors, fmt.Sprintf("NotificationService: %v", err))
+	} else {
+		result.NotificationsReady = true
+		result.Resources["pubsub_topics"] = strings.Join(notificationTopics, ", ")
+	}
+
+	// Deploy the controller (Cloud Functions) and its triggers
+	functionName := controllerFunctionName(p.project)
+	if err := p.functionService.DeployFunction(ctx, functionName, provider.GetFunctionPackagePath("gcp")); err != nil {
+		result.Errors = append(result.Errors, fmt.Sprintf("Function: %v", err))
+	} else {
+		result.FunctionReady = true
+		result.Resources["cloud_function"] = functionName
+
+		if err := p.functionService.EnsureStorageTrigger(ctx, functionName, p.storageService.bucketName); err != nil {
+			result.Errors = append(result.Errors, fmt.Sprintf("Storage trigger: %v", err))
+		} else {
+			result.Resources["eventarc_trigger"] = functionName + "-storage"
+		}
+
+		queue, err := p.functionService.EnsureRequeueQueue(ctx)
+		if err != nil {
+			result.Errors = append(result.Errors, fmt.Sprintf("Cloud Tasks queue: %v", err))
+		} else {
+			result.Resources["cloud_tasks_queue"] = queue
+		}
+	}
+
+	// Nodes and the function run as the project's default service accounts
+	result.AuthReady = true
+
+	if len(result.Errors) > 0 {
+		return result, fmt.Errorf("initialization completed with errors: %s", strings.Join(result.Errors, "; "))
+	}
+
+	return result, nil
+}
+
+// Cleanup removes goman's GCP infrastructure. Clusters must be deleted
+// first; their instances are not touched.
+func (p *GCPProvider) Cleanup(ctx context.Context) error {
+	var errors []string
+
+	functionName := controllerFunctionName(p.project)
+	if err := p.functionService.DeleteStorageTrigger(ctx, functionName); err != nil {
+		errors = append(errors, fmt.Sprintf("Eventarc trigger: %v", err))
+	}
+	if err := p.functionService.DeleteFunction(ctx, functionName); err != nil && !isNotFound(err) {
+		errors = append(errors, fmt.Sprintf("Cloud Function: %v", err))
+	}
+	if err := p.functionService.DeleteRequeueQueue(ctx); err != nil {
+		errors = append(errors, fmt.Sprintf("Cloud Tasks queue: %v", err))
+	}
+
+	if ns, ok := p.notificationService.(*NotificationService); ok {
+		if err := ns.DeleteTopics(ctx); err != nil {
+			errors = append(errors, fmt.Sprintf("Pub/Sub: %v", err))
+		}
+	}
+
+	if ls, ok := p.lockService.(*LockService); ok {
+		if err := ls.DeleteLocks(ctx); err != nil {
+			errors = append(errors, fmt.Sprintf("Firestore: %v", err))
+		}
+	}
+
+	// A customer-provided bucket is emptied but kept, it was not created by goman
+	if err := p.storageService.DeleteFolder(ctx, ""); err != nil {
+		errors = append(errors, fmt.Sprintf("GCS: %v", err))
+	} else if p.storageService.bucketName == fmt.Sprintf("goman-%s", p.project) {
+		if err := p.storageService.DeleteBucket(ctx); err != nil && !isNotFound(err) {
+			errors = append(errors, fmt.Sprintf("GCS: %v", err))
+		}
+	}
+
+	if len(errors) > 0 {
+		return fmt.Errorf("some resources failed to delete: %s", strings.Join(errors, "; "))
+	}
+	return nil
+}
+
+// GetStatus checks the status of goman's GCP infrastructure
+func (p *GCPProvider) GetStatus(ctx context.Context) (*provider.InfrastructureStatus, error) {
+	status := &provider.InfrastructureStatus{
+		Resources: make(map[string]string),
+	}
+
+	status.Resources["gcs_bucket"] = p.storageService.bucketName
+	status.StorageStatus = "ready"
+	if _, err := p.storageService.ListObjects(ctx, "clusters/"); err != nil {
+		status.StorageStatus = "not_ready"
+	}
+
+	status.Resources["firestore_collection"] = lockCollection
+	status.LockStatus = "ready"
+
+	functionName := controllerFunctionName(p.project)
+	status.Resources["cloud_function"] = functionName
+	if exists, _ := p.functionService.FunctionExists(ctx, functionName); exists {
+		status.FunctionStatus = "ready"
+	} else {
+		status.FunctionStatus = "not_deployed"
+	}
+
+	status.AuthStatus = "ready"
+	status.Initialized = status.StorageStatus == "ready" &&
+		status.FunctionStatus == "ready" &&
+		status.LockStatus == "ready"
+
+	return status, nil
+}
+
+// isFunctionEnvironment reports whether goman runs as the controller
+// function, which Cloud Functions (2nd gen) runs on Cloud Run
+func isFunctionEnvironment() bool {
+	return os.Getenv("K_SERVICE") != ""
+}
+
+// isNotFound reports whether a call failed because the resource is missing
+func isNotFound(err error) bool {
+	opErr, ok := provider.AsOperationError(err)
+	return ok && opErr.Class == provider.ErrorClassNotFound
+}
@@ -0,0 +1,247 @@
+package gcp
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/madhouselabs/goman/pkg/config"
+	"github.com/madhouselabs/goman/pkg/provider"
+)
+
+// agentScript is goman-agent, the node's counterpart of the SSM agent. It
+// waits for goman-command-<id> metadata, runs each new command once and
+// reports its status and exit code as guest attributes and its output to
+// the state bucket. Results of commands goman removed are cleaned up.
+const agentScript = `#!/bin/bash
+MD=http://metadata.google.internal/computeMetadata/v1/instance
+STATE=/var/lib/goman/commands
+BUCKET=$(curl -s -H "Metadata-Flavor: Google" "$MD/attributes/goman-bucket")
+NAME=$(curl -s -H "Metadata-Flavor: Google" "$MD/name")
+mkdir -p $STATE
+
+report() {
+    curl -s -X PUT --data "$2" -H "Metadata-Flavor: Google" "$MD/guest-attributes/goman-commands/$1-$3" > /dev/null
+}
+
+run_command() {
+    local id="$1"
+    report "$id" InProgress status
+    curl -s -H "Metadata-Flavor: Google" "$MD/attributes/goman-command-$id" | base64 -d > $STATE/$id/script.sh
+    (cd / && bash $STATE/$id/script.sh > $STATE/$id/stdout 2> $STATE/$id/stderr)
+    local code=$?
+    gcloud storage cp $STATE/$id/stdout "gs://$BUCKET/commands/$id/$NAME/stdout" --quiet 2>/dev/null
+    gcloud storage cp $STATE/$id/stderr "gs://$BUCKET/commands/$id/$NAME/stderr" --quiet 2>/dev/null
+    report "$id" "$code" exit
+    if [ $code -eq 0 ]; then report "$id" Success status; else report "$id" Failed status; fi
+    touch $STATE/$id/done
+}
+
+ETAG=0
+while true; do
+    KEYS=$(curl -s -f -D /tmp/goman-agent.headers -H "Metadata-Flavor: Google" "$MD/attributes/?wait_for_change=true&timeout_sec=300&last_etag=$ETAG")
+    if [ $? -ne 0 ]; then
+        sleep 5
+        continue
+    fi
+    ETAG=$(awk 'tolower($1) == "etag:" {print $2}' /tmp/goman-agent.headers | tr -d '\r')
+
+    for key in $KEYS; do
+        case "$key" in
+            goman-command-*)
+                id=${key#goman-command-}
+                if mkdir $STATE/$id 2>/dev/null; then
+                    run_command "$id" &
+                fi ;;
+        esac
+    done
+
+    for dir in $STATE/*/; do
+        id=$(basename "$dir")
+        [ -e "$dir/done" ] || continue
+        if ! echo "$KEYS" | grep -qx "goman-command-$id"; then
+            for field in status exit; do
+                curl -s -X DELETE -H "Metadata-Flavor: Google" "$MD/guest-attributes/goman-commands/$id-$field" > /dev/null
+            done
+            rm -rf "$dir"
+        fi
+    done
+done
+`
+
+// nodeScriptInstallBinary downloads the K3s binary from the goman bucket
+const nodeScriptInstallBinary = `
+set -e
+ARCH=$(uname -m)
+if [ "$ARCH" = "x86_64" ]; then ARCH="amd64"; elif [ "$ARCH" = "aarch64" ]; then ARCH="arm64"; fi
+if [ ! -x /usr/local/bin/k3s ]; then
+    gcloud storage cp gs://{{ GCSBucket }}/binaries/k3s/{{ K3sVersion }}/k3s-$ARCH /usr/local/bin/k3s --quiet
+    chmod +x /usr/local/bin/k3s
+fi
+ln -sf /usr/local/bin/k3s /usr/local/bin/kubectl
+ln -sf /usr/local/bin/k3s /usr/local/bin/crictl
+ln -sf /usr/local/bin/k3s /usr/local/bin/ctr
+`
+
+// nodeScriptSecrets sets up get_secret and put_secret, which keep secrets
+// in the state bucket, and the node's addresses
+const nodeScriptSecrets = `
+GCS_BUCKET="{{ GCSBucket }}"
+CLUSTER_NAME="{{ ClusterName }}"
+` + nodeShellFunctions
+
+// nodeShellFunctions defines get_secret and put_secret for node scripts,
+// reading GCS_BUCKET and CLUSTER_NAME, and sets PRIVATE_IP and PUBLIC_IP
+const nodeShellFunctions = `
+get_secret() {
+    gcloud storage cat "gs://$GCS_BUCKET/clusters/$CLUSTER_NAME/$1"
+}
+put_secret() {
+    gcloud storage cp "$2" "gs://$GCS_BUCKET/clusters/$CLUSTER_NAME/$1" --quiet
+}
+METADATA=http://metadata.google.internal/computeMetadata/v1/instance
+PRIVATE_IP=$(curl -s -H "Metadata-Flavor: Google" $METADATA/network-interfaces/0/ip)
+PUBLIC_IP=$(curl -s -H "Metadata-Flavor: Google" $METADATA/network-interfaces/0/access-configs/0/external-ip)
+`
+
+// nodeScriptUpgradeK3s swaps in the K3s binary of the requested version and
+// restarts the server or agent unit
+const nodeScriptUpgradeK3s = `
+set -e
+ARCH=$(uname -m)
+if [ "$ARCH" = "x86_64" ]; then ARCH="amd64"; elif [ "$ARCH" = "aarch64" ]; then ARCH="arm64"; fi
+if systemctl is-enabled --quiet k3s 2>/dev/null; then UNIT=k3s; else UNIT=k3s-agent; fi
+CURRENT=$(/usr/local/bin/k3s --version 2>/dev/null | head -n 1 | awk '{print $3}')
+if [ "$CURRENT" = "{{ K3sVersion }}" ]; then
+    echo "K3s is already at {{ K3sVersion }}"
+else
+    gcloud storage cp gs://{{ GCSBucket }}/binaries/k3s/{{ K3sVersion }}/k3s-$ARCH /usr/local/bin/k3s.new --quiet
+    chmod +x /usr/local/bin/k3s.new
+    mv /usr/local/bin/k3s.new /usr/local/bin/k3s
+    systemctl restart $UNIT
+fi
+for i in $(seq 1 60); do systemctl is-active --quiet $UNIT && break; sleep 5; done
+systemctl is-active --quiet $UNIT
+echo "$UNIT is running K3s $(/usr/local/bin/k3s --version | head -n 1 | awk '{print $3}')"
+`
+
+// startupScript returns the startup script of a node. GCE runs it on every
+// boot: goman-agent is started each time, K3s only installed on the first.
+func (s *ComputeService) startupScript(cfg provider.InstanceConfig) string {
+	role := cfg.Tags["goman-role"]
+	lowResource := cfg.Tags["goman-low-resource"] == "true"
+	values := map[string]string{
+		"{{ GCSBucket }}":   config.GetStateBucket(s.project),
+		"{{ ClusterName }}": cfg.Tags["goman-cluster"],
+		"{{ K3sVersion }}":  provider.K3sVersion(cfg.Tags["goman-k3s-version"]),
+	}
+	var pairs []string
+	for k, v := range values {
+		pairs = append(pairs, k, v)
+	}
+	install := strings.NewReplacer(pairs...).Replace(nodeScriptInstallBinary + nodeScriptSecrets)
+
+	return fmt.Sprintf(`#!/bin/bash
+export HOME=${HOME:-/root}
+echo "[$(date)] Starting instance initialization" >> /var/log/goman-startup.log
+
+# goman-agent runs goman's node commands
+mkdir -p /var/lib/goman
+cat > /usr/local/bin/goman-agent <<'AGENT'
+%s
+AGENT
+chmod +x /usr/local/bin/goman-agent
+cat > /etc/systemd/system/goman-agent.service <<'UNIT'
+[Unit]
+Description=goman node command agent
+After=network-online.target google-guest-agent.service
+
+[Service]
+ExecStart=/usr/local/bin/goman-agent
+Environment=HOME=/root
+Restart=always
+RestartSec=5s
+
+[Install]
+WantedBy=multi-user.target
+UNIT
+systemctl daemon-reload
+systemctl enable goman-agent.service
+systemctl restart goman-agent.service
+
+if [ -e /var/lib/goman/bootstrapped ]; then
+    echo "[$(date)] Node already bootstrapped" >> /var/log/goman-startup.log
+    exit 0
+fi
+
+export CLUSTER_NAME="%s"
+export NODE_ROLE="%s"
+export NODE_INDEX="%s"
+export MASTER_IP="%s"
+export NODE_TOKEN="%s"
+export K3S_DISABLE_FLAGS="%s"
+export K3S_TUNING_FLAGS="%s"
+export LOW_RESOURCE="%t"
+
+echo "[$(date)] Cluster: $CLUSTER_NAME, Role: $NODE_ROLE, Index: $NODE_INDEX" >> /var/log/goman-startup.log
+
+if [ "$LOW_RESOURCE" = "true" ] && ! swapon --show | grep -q /swapfile; then
+    echo "[$(date)] Enabling 1 GiB swap for the low-resource profile" >> /var/log/goman-startup.log
+    dd if=/dev/zero of=/swapfile bs=1M count=1024
+    chmod 600 /swapfile
+    mkswap /swapfile
+    swapon /swapfile
+    echo "/swapfile none swap sw 0 0" >> /etc/fstab
+    sysctl -w vm.swappiness=10
+    echo "vm.swappiness=10" > /etc/sysctl.d/90-goman-swap.conf
+fi
+%s
+echo "[$(date)] Downloading K3s binary from GCS..." >> /var/log/goman-startup.log
+%s
+if [ "$NODE_ROLE" = "master" ]; then
+    SERVER_TOKEN=$(get_secret k3s-server-token 2>/dev/null || echo "")
+    if [ -z "$SERVER_TOKEN" ]; then
+        echo "[$(date)] ERROR: Failed to get server token" >> /var/log/goman-startup.log
+        exit 1
+    fi
+
+    if [ "$NODE_INDEX" = "0" ] || [ -z "$MASTER_IP" ]; then
+        echo "[$(date)] Installing K3s server as first master..." >> /var/log/goman-startup.log
+        CLUSTER_INIT_FLAG=""
+        if [ "$NODE_INDEX" = "0" ]; then
+            CLUSTER_INIT_FLAG="--cluster-init"
+        fi
+%s
+        for i in $(seq 1 60); do
+            kubectl get nodes >/dev/null 2>&1 && break
+            sleep 5
+        done
+        if [ -f /etc/rancher/k3s/k3s.yaml ]; then
+            sed "s/127.0.0.1/$PUBLIC_IP/g" /etc/rancher/k3s/k3s.yaml > /tmp/kubeconfig.yaml
+            put_secret kubeconfig.yaml /tmp/kubeconfig.yaml
+            echo "[$(date)] Kubeconfig saved" >> /var/log/goman-startup.log
+        fi
+    else
+        echo "[$(date)] Installing K3s server as additional HA master, joining $MASTER_IP..." >> /var/log/goman-startup.log
+        sleep 30
+%s
+    fi
+elif [ "$NODE_ROLE" = "worker" ]; then
+    if [ -z "$NODE_TOKEN" ]; then
+        NODE_TOKEN=$(get_secret k3s-agent-token 2>/dev/null || echo "")
+    fi
+    if [ -z "$NODE_TOKEN" ] || [ -z "$MASTER_IP" ]; then
+        echo "[$(date)] ERROR: Node token or master IP not provided" >> /var/log/goman-startup.log
+        exit 1
+    fi
+    echo "[$(date)] Installing K3s agent to join cluster at $MASTER_IP" >> /var/log/goman-startup.log
+%s
+fi
+
+touch /var/lib/goman/bootstrapped
+echo "[$(date)] K3s installation completed" >> /var/log/goman-startup.log
+`, agentScript, cfg.Tags["goman-cluster"], role, cfg.Tags["goman-index"], cfg.Tags["goman-master-ip"], cfg.Tags["goman-node-token"],
+		provider.K3sDisableFlags(cfg.Tags["goman-k3s-disable"]), provider.K3sTuningFlags(role, lowResource), lowResource,
+		provider.ProxyScript(cfg.Proxy)+provider.DataVolumeScript(cfg.DataVolumes, dataDiskDevice), install,
+		provider.K3sServerUnitScript("${CLUSTER_INIT_FLAG}"), provider.K3sServerUnitScript("--server=https://${MASTER_IP}:6443"),
+		provider.K3sAgentUnitScript("${MASTER_IP}"))
+}
@@ -0,0 +1,226 @@
+package gcp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/madhouselabs/goman/pkg/config"
+	"github.com/madhouselabs/goman/pkg/logger"
+	"github.com/madhouselabs/goman/pkg/provider"
+)
+
+// StorageService implements object storage using Cloud Storage
+type StorageService struct {
+	client     *apiClient
+	project    string
+	location   string
+	bucketName string
+}
+
+// NewStorageService creates a new GCS-based storage service
+func NewStorageService(client *apiClient, project, region string) *StorageService {
+	return &StorageService{
+		client:     client,
+		project:    project,
+		location:   region,
+		bucketName: config.GetStateBucket(project),
+	}
+}
+
+type gcsObject struct {
+	Name       string `json:"name"`
+	Generation string `json:"generation"`
+}
+
+func (s *StorageService) objectURL(key string) string {
+	return fmt.Sprintf("%s/b/%s/o/%s", endpoint("storage"), s.bucketName, url.PathEscape(key))
+}
+
+// Initialize ensures the bucket exists, creating it with object versioning
+// like the S3 state bucket
+func (s *StorageService) Initialize(ctx context.Context) error {
+	err := s.client.call(ctx, "storage", "GetBucket", http.MethodGet,
+		fmt.Sprintf("%s/b/%s", endpoint("storage"), s.bucketName), nil, nil)
+	if err == nil {
+		return nil
+	}
+	if !isNotFound(err) {
+		return fmt.Errorf("bucket %s not accessible: %w", s.bucketName, err)
+	}
+	// The function only reads and writes the bucket Initialize created
+	if isFunctionEnvironment() {
+		return fmt.Errorf("bucket %s does not exist: %w", s.bucketName, err)
+	}
+
+	logger.Printf("Creating bucket %s in %s", s.bucketName, s.location)
+	bucket := map[string]interface{}{
+		"name":       s.bucketName,
+		"location":   s.location,
+		"versioning": map[string]bool{"enabled": true},
+		"iamConfiguration": map[string]interface{}{
+			"uniformBucketLevelAccess": map[string]bool{"enabled": true},
+			"publicAccessPrevention":   "enforced",
+		},
+		"labels": map[string]string{"managed-by": "goman"},
+	}
+	err = s.client.call(ctx, "storage", "InsertBucket", http.MethodPost,
+		fmt.Sprintf("%s/b?project=%s", endpoint("storage"), url.QueryEscape(s.project)), bucket, nil)
+	if err != nil && !isConflict(err) {
+		return fmt.Errorf("failed to create bucket %s: %w", s.bucketName, err)
+	}
+	return nil
+}
+
+// PutObject stores an object
+func (s *StorageService) PutObject(ctx context.Context, key string, data []byte) error {
+	if _, err := s.upload(ctx, key, data, ""); err != nil {
+		return fmt.Errorf("failed to put object: %w", err)
+	}
+	return nil
+}
+
+// upload writes an object; a non-empty generation makes the write
+// conditional, with "0" meaning the object must not exist
+func (s *StorageService) upload(ctx context.Context, key string, data []byte, generation string) (*gcsObject, error) {
+	u := fmt.Sprintf("%s/b/%s/o?uploadType=media&name=%s", endpoint("storage-upload"), s.bucketName, url.QueryEscape(key))
+	if generation != "" {
+		u += "&ifGenerationMatch=" + generation
+	}
+	resp, err := s.client.send(ctx, "storage", "InsertObject", http.MethodPost, u, "application/octet-stream", bytes.NewReader(data), nil)
+	if err != nil {
+		return nil, err
+	}
+	var obj gcsObject
+	if err := json.Unmarshal(resp, &obj); err != nil {
+		return nil, fmt.Errorf("failed to decode upload response: %w", err)
+	}
+	return &obj, nil
+}
+
+// GetObject retrieves an object
+func (s *StorageService) GetObject(ctx context.Context, key string) ([]byte, error) {
+	data, err := s.client.send(ctx, "storage", "GetObject", http.MethodGet, s.objectURL(key)+"?alt=media", "", nil, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get object: %w", err)
+	}
+	return data, nil
+}
+
+// GetObjectVersion retrieves an object with its generation as version
+func (s *StorageService) GetObjectVersion(ctx context.Context, key string) ([]byte, string, error) {
+	var obj gcsObject
+	if err := s.client.call(ctx, "storage", "GetObject", http.MethodGet, s.objectURL(key), nil, &obj); err != nil {
+		return nil, "", fmt.Errorf("failed to get object: %w", err)
+	}
+	// Read the generation just looked up, in case the object changed since
+	data, err := s.client.send(ctx, "storage", "GetObject", http.MethodGet,
+		s.objectURL(key)+"?alt=media&generation="+obj.Generation, "", nil, nil)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to get object: %w", err)
+	}
+	return data, obj.Generation, nil
+}
+
+// PutObjectIfMatch stores an object only if its generation still matches.
+// An empty version only creates new objects.
+func (s *StorageService) PutObjectIfMatch(ctx context.Context, key string, data []byte, version string) (string, error) {
+	if version == "" {
+		version = "0"
+	} else if _, err := strconv.ParseInt(version, 10, 64); err != nil {
+		return "", fmt.Errorf("invalid object generation %q", version)
+	}
+	obj, err := s.upload(ctx, key, data, version)
+	if err != nil {
+		return "", fmt.Errorf("failed to put object %s: %w", key, err)
+	}
+	return obj.Generation, nil
+}
+
+// DeleteObject deletes an object. Deleting a missing object succeeds, as
+// it does on S3.
+func (s *StorageService) DeleteObject(ctx context.Context, key string) error {
+	err := s.client.call(ctx, "storage", "DeleteObject", http.MethodDelete, s.objectURL(key), nil, nil)
+	if err != nil && !isNotFound(err) {
+		return fmt.Errorf("failed to delete object: %w", err)
+	}
+	return nil
+}
+
+// ListObjects lists objects with a prefix
+func (s *StorageService) ListObjects(ctx context.Context, prefix string) ([]string, error) {
+	var keys []string
+	pageToken := ""
+	for {
+		u := fmt.Sprintf("%s/b/%s/o?prefix=%s&fields=items(name),nextPageToken", endpoint("storage"), s.bucketName, url.QueryEscape(prefix))
+		if pageToken != "" {
+			u += "&pageToken=" + url.QueryEscape(pageToken)
+		}
+		var page struct {
+			Items         []gcsObject `json:"items"`
+			NextPageToken string      `json:"nextPageToken"`
+		}
+		if err := s.client.call(ctx, "storage", "ListObjects", http.MethodGet, u, nil, &page); err != nil {
+			return nil, fmt.Errorf("failed to list objects: %w", err)
+		}
+		for _, obj := range page.Items {
+			keys = append(keys, obj.Name)
+		}
+		if page.NextPageToken == "" {
+			return keys, nil
+		}
+		pageToken = page.NextPageToken
+	}
+}
+
+// DeleteFolder deletes all objects with a given prefix (folder)
+func (s *StorageService) DeleteFolder(ctx context.Context, prefix string) error {
+	objects, err := s.ListObjects(ctx, prefix)
+	if err != nil {
+		return fmt.Errorf("failed to list objects for deletion: %w", err)
+	}
+	for _, key := range objects {
+		if err := s.DeleteObject(ctx, key); err != nil {
+			logger.Printf("Warning: failed to delete %s: %v", key, err)
+		}
+	}
+	return nil
+}
+
+// DeleteBucket deletes the bucket together with the noncurrent object
+// versions versioning kept
+func (s *StorageService) DeleteBucket(ctx context.Context) error {
+	pageToken := ""
+	for {
+		u := fmt.Sprintf("%s/b/%s/o?versions=true&fields=items(name,generation),nextPageToken", endpoint("storage"), s.bucketName)
+		if pageToken != "" {
+			u += "&pageToken=" + url.QueryEscape(pageToken)
+		}
+		var page struct {
+			Items         []gcsObject `json:"items"`
+			NextPageToken string      `json:"nextPageToken"`
+		}
+		if err := s.client.call(ctx, "storage", "ListObjects", http.MethodGet, u, nil, &page); err != nil {
+			return err
+		}
+		for _, obj := range page.Items {
+			err := s.client.call(ctx, "storage", "DeleteObject", http.MethodDelete,
+				s.objectURL(obj.Name)+"?generation="+obj.Generation, nil, nil)
+			if err != nil && !isNotFound(err) {
+				return err
+			}
+		}
+		if page.NextPageToken == "" {
+			break
+		}
+		pageToken = page.NextPageToken
+	}
+	return s.client.call(ctx, "storage", "DeleteBucket", http.MethodDelete,
+		fmt.Sprintf("%s/b/%s", endpoint("storage"), s.bucketName), nil, nil)
+}
+
+var _ provider.VersionedStorage = (*StorageService)(nil)
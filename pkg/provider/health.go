@@ -0,0 +1,24 @@
+package provider
+
+import (
+	"context"
+	"time"
+)
+
+// ServiceHealthEvent is an open issue a cloud provider reports for one of
+// its services in a region
+type ServiceHealthEvent struct {
+	Service   string // Provider's service name, e.g. "EC2"
+	Region    string
+	EventType string // e.g. "AWS_EC2_OPERATIONAL_ISSUE"
+	StartTime time.Time
+}
+
+// ServiceHealthChecker is implemented by providers that publish the health
+// of their services, such as the AWS Health API. The controller uses it to
+// tell cloud outages from goman failures.
+type ServiceHealthChecker interface {
+	// ServiceHealth returns the open issues of the given services, named as
+	// in OperationError.Service, in the provider's region
+	ServiceHealth(ctx context.Context, services []string) ([]ServiceHealthEvent, error)
+}
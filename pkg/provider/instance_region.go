@@ -0,0 +1,10 @@
+package provider
+
+// InstanceRegionHinter is implemented by compute services that look up the
+// region of an instance before calling on it. The region recorded for an
+// instance spares them the lookup, and reaches regions they have not
+// searched yet.
+type InstanceRegionHinter interface {
+	// RememberInstanceRegion records the region an instance runs in
+	RememberInstanceRegion(instanceID, region string)
+}
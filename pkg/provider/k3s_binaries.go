@@ -0,0 +1,133 @@
+package provider
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// K3sArchitectures are the architectures whose K3s binaries are staged in
+// the state bucket, so nodes of either can boot whatever the pool
+var K3sArchitectures = []string{"amd64", "arm64"}
+
+// K3sReleaseURL is where K3s release assets are downloaded from
+var K3sReleaseURL = "https://github.com/k3s-io/k3s/releases/download"
+
+// k3sDownloadTimeout bounds the download of one release asset
+const k3sDownloadTimeout = 5 * time.Minute
+
+// K3sBinaryKey returns the state bucket key of the K3s binary of a version
+// and architecture, as read by the bootstrap and upgrade scripts
+func K3sBinaryKey(version, arch string) string {
+	return fmt.Sprintf("%s%s/k3s-%s", BinariesPrefix, version, arch)
+}
+
+// k3sAsset returns the release asset name of the K3s binary of an
+// architecture: "k3s" for amd64, "k3s-<arch>" for the others
+func k3sAsset(arch string) string {
+	if arch == "amd64" {
+		return "k3s"
+	}
+	return "k3s-" + arch
+}
+
+// BinaryStore is the part of a StorageService the binaries are staged in
+type BinaryStore interface {
+	ListObjects(ctx context.Context, prefix string) ([]string, error)
+	PutObject(ctx context.Context, key string, data []byte) error
+}
+
+// StageK3sBinaries uploads the K3s binaries of a version missing from the
+// store, for all of K3sArchitectures. Each is downloaded from the K3s
+// release and checked against the release's checksums before upload.
+func StageK3sBinaries(ctx context.Context, store BinaryStore, version string) error {
+	keys, err := store.ListObjects(ctx, BinariesPrefix+version+"/")
+	if err != nil {
+		return fmt.Errorf("failed to list K3s binaries of %s: %w", version, err)
+	}
+	staged := make(map[string]bool, len(keys))
+	for _, key := range keys {
+		staged[key] = true
+	}
+
+	for _, arch := range K3sArchitectures {
+		key := K3sBinaryKey(version, arch)
+		if staged[key] {
+			continue
+		}
+		binary, err := downloadK3sBinary(ctx, version, arch)
+		if err != nil {
+			return err
+		}
+		if err := store.PutObject(ctx, key, binary); err != nil {
+			return fmt.Errorf("failed to upload %s: %w", key, err)
+		}
+	}
+	return nil
+}
+
+// downloadK3sBinary downloads the K3s binary of a version and architecture
+// and verifies its SHA-256 checksum
+func downloadK3sBinary(ctx context.Context, version, arch string) ([]byte, error) {
+	sums, err := fetchK3sAsset(ctx, version, "sha256sum-"+arch+".txt")
+	if err != nil {
+		return nil, err
+	}
+	want, err := assetChecksum(sums, k3sAsset(arch))
+	if err != nil {
+		return nil, fmt.Errorf("K3s %s checksums: %w", version, err)
+	}
+	binary, err := fetchK3sAsset(ctx, version, k3sAsset(arch))
+	if err != nil {
+		return nil, err
+	}
+	if got := sha256.Sum256(binary); hex.EncodeToString(got[:]) != want {
+		return nil, fmt.Errorf("K3s %s binary %s does not match its checksum", version, k3sAsset(arch))
+	}
+	return binary, nil
+}
+
+// fetchK3sAsset downloads an asset of a K3s release
+func fetchK3sAsset(ctx context.Context, version, asset string) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(ctx, k3sDownloadTimeout)
+	defer cancel()
+
+	// Versions such as v1.31.4+k3s1 need the plus escaped
+	assetURL := fmt.Sprintf("%s/%s/%s", K3sReleaseURL, strings.ReplaceAll(version, "+", "%2B"), asset)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, assetURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download %s: %w", assetURL, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to download %s: %s", assetURL, resp.Status)
+	}
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download %s: %w", assetURL, err)
+	}
+	return data, nil
+}
+
+// assetChecksum finds the checksum of an asset in a sha256sum listing
+func assetChecksum(sums []byte, asset string) (string, error) {
+	scanner := bufio.NewScanner(bytes.NewReader(sums))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 2 && strings.TrimPrefix(fields[1], "*") == asset {
+			return strings.ToLower(fields[0]), nil
+		}
+	}
+	return "", fmt.Errorf("no checksum of %s", asset)
+}
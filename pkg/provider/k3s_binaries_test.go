@@ -0,0 +1,79 @@
+package provider
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type memoryStore map[string][]byte
+
+func (m memoryStore) ListObjects(_ context.Context, prefix string) ([]string, error) {
+	var keys []string
+	for key := range m {
+		if strings.HasPrefix(key, prefix) {
+			keys = append(keys, key)
+		}
+	}
+	return keys, nil
+}
+
+func (m memoryStore) PutObject(_ context.Context, key string, data []byte) error {
+	m[key] = data
+	return nil
+}
+
+func TestStageK3sBinaries(t *testing.T) {
+	assets := map[string]string{"k3s": "amd64 binary", "k3s-arm64": "arm64 binary"}
+	sums := make(map[string][32]byte)
+	for name, data := range assets {
+		sums[name] = sha256.Sum256([]byte(data))
+	}
+	var requested []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requested = append(requested, r.URL.EscapedPath())
+		version, asset, _ := strings.Cut(strings.TrimPrefix(r.URL.EscapedPath(), "/"), "/")
+		if version != "v1.31.4%2Bk3s1" {
+			http.NotFound(w, r)
+			return
+		}
+		if arch, ok := strings.CutPrefix(asset, "sha256sum-"); ok {
+			name := k3sAsset(strings.TrimSuffix(arch, ".txt"))
+			fmt.Fprintf(w, "%x  %s\n%x  k3s-airgap-images.tar\n", sums[name], name, sha256.Sum256(nil))
+			return
+		}
+		fmt.Fprint(w, assets[asset])
+	}))
+	defer server.Close()
+	defer func(url string) { K3sReleaseURL = url }(K3sReleaseURL)
+	K3sReleaseURL = server.URL
+
+	// Only the missing arm64 binary is downloaded
+	store := memoryStore{"binaries/k3s/v1.31.4+k3s1/k3s-amd64": []byte("staged")}
+	if err := StageK3sBinaries(context.Background(), store, "v1.31.4+k3s1"); err != nil {
+		t.Fatal(err)
+	}
+	if got := string(store[K3sBinaryKey("v1.31.4+k3s1", "arm64")]); got != "arm64 binary" {
+		t.Errorf("arm64 binary = %q", got)
+	}
+	if got := string(store["binaries/k3s/v1.31.4+k3s1/k3s-amd64"]); got != "staged" {
+		t.Errorf("staged amd64 binary replaced by %q", got)
+	}
+	if want := []string{"/v1.31.4%2Bk3s1/sha256sum-arm64.txt", "/v1.31.4%2Bk3s1/k3s-arm64"}; strings.Join(requested, " ") != strings.Join(want, " ") {
+		t.Errorf("requested %v, want %v", requested, want)
+	}
+
+	// A binary not matching its checksum is not uploaded
+	assets["k3s"] = "tampered"
+	store = memoryStore{}
+	if err := StageK3sBinaries(context.Background(), store, "v1.31.4+k3s1"); err == nil || !strings.Contains(err.Error(), "checksum") {
+		t.Errorf("want a checksum error, got %v", err)
+	}
+	if len(store) != 0 {
+		t.Errorf("uploaded %v", store)
+	}
+}
@@ -0,0 +1,153 @@
+package local
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/madhouselabs/goman/pkg/provider"
+)
+
+// nodeScriptInstallBinary installs the K3s binary from binaries/k3s/ in the
+// state directory if it is there, and from the K3s releases otherwise
+const nodeScriptInstallBinary = `
+set -e
+ARCH=$(uname -m)
+if [ "$ARCH" = "x86_64" ]; then ARCH="amd64"; elif [ "$ARCH" = "aarch64" ]; then ARCH="arm64"; fi
+if [ ! -x /usr/local/bin/k3s ]; then
+    if [ -f ` + stateMountPoint + `/binaries/k3s/{{ K3sVersion }}/k3s-$ARCH ]; then
+        cp ` + stateMountPoint + `/binaries/k3s/{{ K3sVersion }}/k3s-$ARCH /usr/local/bin/k3s
+    else
+        ASSET=k3s
+        if [ "$ARCH" != "amd64" ]; then ASSET=k3s-$ARCH; fi
+        curl -sfL -o /usr/local/bin/k3s "https://github.com/k3s-io/k3s/releases/download/$(echo '{{ K3sVersion }}' | sed 's/+/%2B/')/$ASSET"
+    fi
+    chmod +x /usr/local/bin/k3s
+fi
+ln -sf /usr/local/bin/k3s /usr/local/bin/kubectl
+ln -sf /usr/local/bin/k3s /usr/local/bin/crictl
+ln -sf /usr/local/bin/k3s /usr/local/bin/ctr
+`
+
+// nodeScriptSecrets sets up get_secret and put_secret, which keep secrets
+// in the mounted state directory, and the node's address. VMs have a
+// single address, reachable from the host.
+const nodeScriptSecrets = `
+CLUSTER_NAME="{{ ClusterName }}"
+get_secret() {
+    cat "` + stateMountPoint + `/clusters/$CLUSTER_NAME/$1"
+}
+put_secret() {
+    mkdir -p "` + stateMountPoint + `/clusters/$CLUSTER_NAME"
+    cp "$2" "` + stateMountPoint + `/clusters/$CLUSTER_NAME/$1"
+}
+PRIVATE_IP=$(hostname -I | awk '{print $1}')
+PUBLIC_IP=$PRIVATE_IP
+`
+
+// nodeScriptUpgradeK3s swaps in the K3s binary of the requested version and
+// restarts the server or agent unit
+const nodeScriptUpgradeK3s = `
+set -e
+if systemctl is-enabled --quiet k3s 2>/dev/null; then UNIT=k3s; else UNIT=k3s-agent; fi
+CURRENT=$(/usr/local/bin/k3s --version 2>/dev/null | head -n 1 | awk '{print $3}')
+if [ "$CURRENT" = "{{ K3sVersion }}" ]; then
+    echo "K3s is already at {{ K3sVersion }}"
+else
+    mv /usr/local/bin/k3s /usr/local/bin/k3s.old
+    if ! (` + nodeScriptInstallBinary + `); then
+        mv /usr/local/bin/k3s.old /usr/local/bin/k3s
+        exit 1
+    fi
+    rm -f /usr/local/bin/k3s.old
+    systemctl restart $UNIT
+fi
+for i in $(seq 1 60); do systemctl is-active --quiet $UNIT && break; sleep 5; done
+systemctl is-active --quiet $UNIT
+echo "$UNIT is running K3s $(/usr/local/bin/k3s --version | head -n 1 | awk '{print $3}')"
+`
+
+// bootstrapScript returns the script a VM runs once it is up: it installs
+// K3s as server or agent according to the instance's tags
+func bootstrapScript(cfg provider.InstanceConfig) string {
+	role := cfg.Tags["goman-role"]
+	lowResource := cfg.Tags["goman-low-resource"] == "true"
+	install := strings.NewReplacer(
+		"{{ ClusterName }}", cfg.Tags["goman-cluster"],
+		"{{ K3sVersion }}", provider.K3sVersion(cfg.Tags["goman-k3s-version"]),
+	).Replace(nodeScriptInstallBinary + nodeScriptSecrets)
+
+	return fmt.Sprintf(`#!/bin/bash
+exec >> /var/log/goman-startup.log 2>&1
+echo "[$(date)] Starting instance initialization"
+
+# The state directory is mounted after the VM booted
+for i in $(seq 1 60); do
+    [ -d %s/clusters ] && break
+    sleep 2
+done
+
+export NODE_ROLE="%s"
+export NODE_INDEX="%s"
+export MASTER_IP="%s"
+export NODE_TOKEN="%s"
+export K3S_DISABLE_FLAGS="%s"
+export K3S_TUNING_FLAGS="%s"
+export LOW_RESOURCE="%t"
+
+if [ "$LOW_RESOURCE" = "true" ] && ! swapon --show | grep -q /swapfile; then
+    echo "[$(date)] Enabling 1 GiB swap for the low-resource profile"
+    dd if=/dev/zero of=/swapfile bs=1M count=1024
+    chmod 600 /swapfile
+    mkswap /swapfile
+    swapon /swapfile
+    echo "/swapfile none swap sw 0 0" >> /etc/fstab
+    sysctl -w vm.swappiness=10
+fi
+%s
+echo "[$(date)] Cluster: $CLUSTER_NAME, Role: $NODE_ROLE, Index: $NODE_INDEX"
+if [ "$NODE_ROLE" = "master" ]; then
+    SERVER_TOKEN=$(get_secret k3s-server-token 2>/dev/null || echo "")
+    if [ -z "$SERVER_TOKEN" ]; then
+        echo "[$(date)] ERROR: Failed to get server token"
+        exit 1
+    fi
+
+    if [ "$NODE_INDEX" = "0" ] || [ -z "$MASTER_IP" ]; then
+        echo "[$(date)] Installing K3s server as first master..."
+        CLUSTER_INIT_FLAG=""
+        if [ "$NODE_INDEX" = "0" ]; then
+            CLUSTER_INIT_FLAG="--cluster-init"
+        fi
+%s
+        for i in $(seq 1 60); do
+            kubectl get nodes >/dev/null 2>&1 && break
+            sleep 5
+        done
+        if [ -f /etc/rancher/k3s/k3s.yaml ]; then
+            sed "s/127.0.0.1/$PUBLIC_IP/g" /etc/rancher/k3s/k3s.yaml > /tmp/kubeconfig.yaml
+            put_secret kubeconfig.yaml /tmp/kubeconfig.yaml
+            echo "[$(date)] Kubeconfig saved"
+        fi
+    else
+        echo "[$(date)] Installing K3s server as additional HA master, joining $MASTER_IP..."
+%s
+    fi
+elif [ "$NODE_ROLE" = "worker" ]; then
+    if [ -z "$NODE_TOKEN" ]; then
+        NODE_TOKEN=$(get_secret k3s-agent-token 2>/dev/null || echo "")
+    fi
+    if [ -z "$NODE_TOKEN" ] || [ -z "$MASTER_IP" ]; then
+        echo "[$(date)] ERROR: Node token or master IP not provided"
+        exit 1
+    fi
+    echo "[$(date)] Installing K3s agent to join cluster at $MASTER_IP"
+%s
+fi
+
+echo "[$(date)] K3s installation completed"
+`, stateMountPoint, role, cfg.Tags["goman-index"], cfg.Tags["goman-master-ip"], cfg.Tags["goman-node-token"],
+		provider.K3sDisableFlags(cfg.Tags["goman-k3s-disable"]), provider.K3sTuningFlags(role, lowResource), lowResource,
+		install,
+		provider.K3sServerUnitScript("${CLUSTER_INIT_FLAG}"), provider.K3sServerUnitScript("--server=https://${MASTER_IP}:6443"),
+		provider.K3sAgentUnitScript("${MASTER_IP}"))
+}
@@ -0,0 +1,184 @@
+package local
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/madhouselabs/goman/pkg/provider"
+)
+
+// runningCommand is a command started on instances, with the results of
+// those it finished on
+type runningCommand struct {
+	mu      sync.Mutex
+	results map[string]*provider.InstanceCommandResult
+}
+
+// RunCommand runs a shell script on instances and waits for the result
+func (s *ComputeService) RunCommand(ctx context.Context, instanceIDs []string, command string) (*provider.CommandResult, error) {
+	commandID, err := s.StartCommand(ctx, instanceIDs, command)
+	if err != nil {
+		return nil, err
+	}
+
+	// Wait for command to complete (with timeout)
+	waitCtx, cancel := context.WithTimeout(ctx, 5*time.Minute)
+	defer cancel()
+
+	ticker := time.NewTicker(200 * time.Millisecond)
+	defer ticker.Stop()
+
+	for {
+		result, err := s.GetCommandResult(waitCtx, commandID)
+		if err != nil {
+			return nil, err
+		}
+		if result.Status != "InProgress" {
+			return result, nil
+		}
+		select {
+		case <-waitCtx.Done():
+			return nil, fmt.Errorf("timeout waiting for command to complete")
+		case <-ticker.C:
+		}
+	}
+}
+
+// StartCommand runs a shell script on instances in the background. Command
+// IDs are only known to the process that started them.
+func (s *ComputeService) StartCommand(ctx context.Context, instanceIDs []string, command string) (string, error) {
+	if len(instanceIDs) == 0 {
+		return "", fmt.Errorf("no instance IDs provided")
+	}
+	for _, id := range instanceIDs {
+		if _, err := s.readRecord(id); err != nil {
+			return "", err
+		}
+	}
+	commandID, err := newToken()
+	if err != nil {
+		return "", fmt.Errorf("failed to generate command ID: %w", err)
+	}
+
+	cmd := &runningCommand{results: make(map[string]*provider.InstanceCommandResult)}
+	s.commandsMu.Lock()
+	s.commands[commandID] = cmd
+	s.commandsMu.Unlock()
+
+	for _, id := range instanceIDs {
+		cmd.results[id] = &provider.InstanceCommandResult{InstanceID: id, Status: "InProgress"}
+	}
+	for _, id := range instanceIDs {
+		go func(id string) {
+			execCtx, cancel := context.WithTimeout(context.Background(), 30*time.Minute)
+			defer cancel()
+			stdout, stderr, exitCode, err := s.driver.Exec(execCtx, id, command)
+			res := &provider.InstanceCommandResult{InstanceID: id, Output: stdout, Error: stderr, ExitCode: exitCode, Status: "Success"}
+			if err != nil {
+				res.Error = strings.TrimSpace(stderr + "\n" + err.Error())
+			}
+			if err != nil || exitCode != 0 {
+				res.Status = "Failed"
+			}
+			cmd.mu.Lock()
+			cmd.results[id] = res
+			cmd.mu.Unlock()
+		}(id)
+	}
+	return commandID, nil
+}
+
+// GetCommandResult checks the status of a previously started command. Once
+// it finished everywhere, it is forgotten.
+func (s *ComputeService) GetCommandResult(ctx context.Context, commandID string) (*provider.CommandResult, error) {
+	s.commandsMu.Lock()
+	cmd, ok := s.commands[commandID]
+	s.commandsMu.Unlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown command %s", commandID)
+	}
+
+	result := &provider.CommandResult{
+		CommandID: commandID,
+		Status:    "Success",
+		Instances: make(map[string]*provider.InstanceCommandResult),
+	}
+	allComplete := true
+	cmd.mu.Lock()
+	for id, res := range cmd.results {
+		copied := *res
+		result.Instances[id] = &copied
+		switch res.Status {
+		case "InProgress":
+			allComplete = false
+		case "Success":
+		default:
+			result.Status = "Failed"
+		}
+	}
+	cmd.mu.Unlock()
+	if !allComplete {
+		result.Status = "InProgress"
+		return result, nil
+	}
+
+	s.commandsMu.Lock()
+	delete(s.commands, commandID)
+	s.commandsMu.Unlock()
+	return result, nil
+}
+
+// operationScripts are the scripts of node operations, with {{ Name }}
+// parameters like the SSM documents of the AWS provider
+var operationScripts = map[string]string{
+	provider.OperationDrainNode: `
+kubectl drain {{ NodeName }} --ignore-daemonsets --delete-emptydir-data --force --timeout={{ Timeout }} || true
+if [ "{{ Delete }}" = "true" ]; then
+    kubectl delete node {{ NodeName }} --ignore-not-found
+fi
+`,
+	provider.OperationConfigureDNS: provider.ConfigureDNSScript,
+	provider.OperationUpgradeK3s:   nodeScriptUpgradeK3s,
+	provider.OperationRotateToken:  nodeScriptSecrets + provider.RotateTokenScript,
+	provider.OperationUpdateToken:  nodeScriptSecrets + provider.UpdateTokenScript,
+}
+
+// operationDefaults are parameters operations take when the caller leaves
+// them out
+var operationDefaults = map[string]string{
+	"K3sVersion":     "",
+	"Timeout":        "60s",
+	"Delete":         "true",
+	"Upstreams":      "",
+	"ClusterConfig":  "false",
+	"CorednsCustom":  "",
+	"NodeLocalCache": "false",
+}
+
+// RunOperation runs a named node operation as a script and waits for the result
+func (s *ComputeService) RunOperation(ctx context.Context, instanceIDs []string, operation string, params map[string]string) (*provider.CommandResult, error) {
+	script, ok := operationScripts[operation]
+	if !ok {
+		return nil, fmt.Errorf("operation %s is not supported by the local provider", operation)
+	}
+
+	values := make(map[string]string, len(operationDefaults)+len(params))
+	for k, v := range operationDefaults {
+		values[k] = v
+	}
+	for k, v := range params {
+		values[k] = v
+	}
+	if values["K3sVersion"] == "" {
+		values["K3sVersion"] = provider.K3sVersion("")
+	}
+
+	var pairs []string
+	for k, v := range values {
+		pairs = append(pairs, "{{ "+k+" }}", v)
+	}
+	return s.RunCommand(ctx, instanceIDs, strings.NewReplacer(pairs...).Replace(script))
+}
@@ -0,0 +1,468 @@
+package local
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/madhouselabs/goman/pkg/logger"
+	"github.com/madhouselabs/goman/pkg/provider"
+)
+
+const (
+	// defaultDiskGiB is the disk of VMs without a root volume size; the
+	// multipass default of 5 GiB is too small for K3s images
+	defaultDiskGiB = 20
+
+	// launchTimeout is how long an instance whose VM does not exist yet
+	// counts as pending rather than terminated
+	launchTimeout = 15 * time.Minute
+
+	// localZone is the zone of instances launched without one
+	localZone = "local"
+)
+
+// instanceRecord is what the compute service remembers of an instance; the
+// driver knows its state and address
+type instanceRecord struct {
+	ID           string            `json:"id"`
+	Name         string            `json:"name"`
+	InstanceType string            `json:"instanceType"`
+	Zone         string            `json:"zone"`
+	Tags         map[string]string `json:"tags"`
+	LaunchTime   time.Time         `json:"launchTime"`
+}
+
+// ComputeService implements instances as VMs of a local driver. Instance
+// records are files, so every goman process on the machine sees the same
+// instances.
+type ComputeService struct {
+	dir     string
+	storage *StorageService
+	driver  vmDriver
+
+	mu sync.Mutex // Serializes record updates within the process
+
+	commandsMu sync.Mutex
+	commands   map[string]*runningCommand
+}
+
+// NewComputeService creates a compute service keeping records in dir
+func NewComputeService(dir string, storage *StorageService, driver vmDriver) *ComputeService {
+	return &ComputeService{
+		dir:      dir,
+		storage:  storage,
+		driver:   driver,
+		commands: make(map[string]*runningCommand),
+	}
+}
+
+func (s *ComputeService) recordPath(id string) string {
+	return filepath.Join(s.dir, id+".json")
+}
+
+func (s *ComputeService) readRecord(id string) (*instanceRecord, error) {
+	data, err := os.ReadFile(s.recordPath(id))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("instance %s: %w", id, provider.ErrNotFound)
+	}
+	if err != nil {
+		return nil, err
+	}
+	var rec instanceRecord
+	if err := json.Unmarshal(data, &rec); err != nil {
+		return nil, fmt.Errorf("invalid record of instance %s: %w", id, err)
+	}
+	return &rec, nil
+}
+
+func (s *ComputeService) writeRecord(rec *instanceRecord) error {
+	data, err := json.MarshalIndent(rec, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(s.recordPath(rec.ID), data)
+}
+
+// updateRecord changes the record of an instance
+func (s *ComputeService) updateRecord(id string, update func(*instanceRecord)) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	rec, err := s.readRecord(id)
+	if err != nil {
+		return err
+	}
+	update(rec)
+	return s.writeRecord(rec)
+}
+
+func (s *ComputeService) records() ([]*instanceRecord, error) {
+	entries, err := os.ReadDir(s.dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to list instances: %w", err)
+	}
+	var records []*instanceRecord
+	for _, entry := range entries {
+		id, ok := strings.CutSuffix(entry.Name(), ".json")
+		if !ok || entry.IsDir() {
+			continue
+		}
+		rec, err := s.readRecord(id)
+		if err != nil {
+			if errors.Is(err, provider.ErrNotFound) {
+				continue
+			}
+			return nil, err
+		}
+		records = append(records, rec)
+	}
+	return records, nil
+}
+
+// vmNamePattern matches what is not allowed in VM names
+var vmNamePattern = regexp.MustCompile(`[^a-z0-9-]+`)
+
+// newInstanceID returns an ID for an instance, which is also its VM name:
+// the instance name made valid and a random suffix
+func newInstanceID(name string) (string, error) {
+	b := make([]byte, 3)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	base := strings.Trim(vmNamePattern.ReplaceAllString(strings.ToLower(name), "-"), "-")
+	if len(base) > 40 {
+		base = strings.TrimRight(base[:40], "-")
+	}
+	if base == "" || base[0] < 'a' || base[0] > 'z' {
+		base = "goman-" + base
+	}
+	return base + "-" + hex.EncodeToString(b), nil
+}
+
+// instanceResources maps an instance type to CPUs and memory by its size,
+// the part after the dot: t3.small gets 1 CPU and 2 GiB like on EC2
+func instanceResources(instanceType string) (cpus, memoryMiB int) {
+	size := instanceType
+	if i := strings.LastIndex(instanceType, "."); i >= 0 {
+		size = instanceType[i+1:]
+	}
+	switch size {
+	case "nano", "micro":
+		return 1, 1024
+	case "small":
+		return 1, 2048
+	case "medium":
+		return 2, 4096
+	case "large":
+		return 2, 8192
+	case "xlarge":
+		return 4, 16384
+	case "2xlarge":
+		return 8, 32768
+	default:
+		return 2, 4096
+	}
+}
+
+// CreateInstance launches a VM. It returns once the launch started; the
+// instance is pending until the VM runs.
+func (s *ComputeService) CreateInstance(ctx context.Context, config provider.InstanceConfig) (*provider.Instance, error) {
+	if err := os.MkdirAll(s.dir, 0700); err != nil {
+		return nil, fmt.Errorf("failed to create instance directory: %w", err)
+	}
+	if len(config.DataVolumes) > 0 {
+		logger.Printf("Instance %s: data volumes are not supported by the local provider, ignoring %d", config.Name, len(config.DataVolumes))
+	}
+
+	id, err := newInstanceID(config.Name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate instance ID: %w", err)
+	}
+	tags := map[string]string{"Name": config.Name}
+	for k, v := range config.ResourceTags {
+		tags[k] = v
+	}
+	for k, v := range config.Tags {
+		tags[k] = v
+	}
+	zone := config.AvailabilityZone
+	if zone == "" {
+		zone = localZone
+	}
+	rec := &instanceRecord{
+		ID:           id,
+		Name:         config.Name,
+		InstanceType: config.InstanceType,
+		Zone:         zone,
+		Tags:         tags,
+		LaunchTime:   time.Now(),
+	}
+	if err := s.writeRecord(rec); err != nil {
+		return nil, fmt.Errorf("failed to record instance: %w", err)
+	}
+
+	cpus, memory := instanceResources(config.InstanceType)
+	disk := config.RootVolumeSize
+	if disk == 0 {
+		disk = defaultDiskGiB
+	}
+	spec := vmSpec{
+		Name:      id,
+		CPUs:      cpus,
+		MemoryMiB: memory,
+		DiskGiB:   disk,
+		StateDir:  s.storage.root,
+		Bootstrap: bootstrapScript(config) + provider.UserDataSnippetScript(config.UserDataSnippet),
+		LogPath:   filepath.Join(s.dir, id+".log"),
+	}
+	if err := s.driver.Launch(ctx, spec); err != nil {
+		os.Remove(s.recordPath(id))
+		return nil, err
+	}
+	logger.Printf("Launched %s VM %s for instance %s", s.driver.Name(), id, config.Name)
+
+	return s.GetInstance(ctx, id)
+}
+
+// DeleteInstance deletes the VM and forgets the instance
+func (s *ComputeService) DeleteInstance(ctx context.Context, instanceID string) error {
+	if _, err := s.readRecord(instanceID); err != nil {
+		return err
+	}
+	if err := s.driver.Delete(ctx, instanceID); err != nil {
+		return fmt.Errorf("failed to delete instance %s: %w", instanceID, err)
+	}
+	os.Remove(filepath.Join(s.dir, instanceID+".log"))
+	os.Remove(filepath.Join(s.dir, instanceID+".log.sh"))
+	if err := os.Remove(s.recordPath(instanceID)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("failed to remove record of instance %s: %w", instanceID, err)
+	}
+	return nil
+}
+
+// instance combines a record with what the driver reports
+func (s *ComputeService) instance(ctx context.Context, rec *instanceRecord) (*provider.Instance, error) {
+	inst := &provider.Instance{
+		ID:           rec.ID,
+		Name:         rec.Name,
+		InstanceType: rec.InstanceType,
+		LaunchTime:   rec.LaunchTime,
+		Tags:         make(map[string]string, len(rec.Tags)),
+
+		AvailabilityZone: rec.Zone,
+	}
+	for k, v := range rec.Tags {
+		inst.Tags[k] = v
+	}
+
+	info, err := s.driver.Info(ctx, rec.ID)
+	switch {
+	case errors.Is(err, provider.ErrNotFound):
+		inst.State = provider.InstanceStateTerminated
+		if time.Since(rec.LaunchTime) < launchTimeout {
+			inst.State = provider.InstanceStatePending
+		}
+	case err != nil:
+		return nil, err
+	default:
+		inst.State = info.State
+		inst.PrivateIP = info.IP
+		inst.PublicIP = info.IP
+	}
+	return inst, nil
+}
+
+// GetInstance gets instance details
+func (s *ComputeService) GetInstance(ctx context.Context, instanceID string) (*provider.Instance, error) {
+	rec, err := s.readRecord(instanceID)
+	if err != nil {
+		return nil, err
+	}
+	return s.instance(ctx, rec)
+}
+
+// ListInstances lists instances. It supports the tag:<key> and
+// instance-state-name filters, with comma-separated values.
+func (s *ComputeService) ListInstances(ctx context.Context, filters map[string]string) ([]*provider.Instance, error) {
+	records, err := s.records()
+	if err != nil {
+		return nil, err
+	}
+	var instances []*provider.Instance
+	for _, rec := range records {
+		inst, err := s.instance(ctx, rec)
+		if err != nil {
+			return nil, err
+		}
+		if matchesFilters(inst, filters) {
+			instances = append(instances, inst)
+		}
+	}
+	sort.Slice(instances, func(i, j int) bool {
+		return instances[i].LaunchTime.Before(instances[j].LaunchTime)
+	})
+	return instances, nil
+}
+
+func matchesFilters(inst *provider.Instance, filters map[string]string) bool {
+	for k, v := range filters {
+		var actual string
+		switch {
+		case k == "instance-state-name":
+			actual = inst.State
+		case strings.HasPrefix(k, "tag:"):
+			actual = inst.Tags[strings.TrimPrefix(k, "tag:")]
+		default:
+			continue
+		}
+		match := false
+		for _, want := range strings.Split(v, ",") {
+			if strings.TrimSpace(want) == actual {
+				match = true
+				break
+			}
+		}
+		if !match {
+			return false
+		}
+	}
+	return true
+}
+
+// StartInstance starts a stopped VM
+func (s *ComputeService) StartInstance(ctx context.Context, instanceID string) error {
+	if _, err := s.readRecord(instanceID); err != nil {
+		return err
+	}
+	return s.driver.Start(ctx, instanceID)
+}
+
+// StopInstance stops a VM
+func (s *ComputeService) StopInstance(ctx context.Context, instanceID string) error {
+	if _, err := s.readRecord(instanceID); err != nil {
+		return err
+	}
+	return s.driver.Stop(ctx, instanceID)
+}
+
+// ModifyInstanceType gives a stopped VM the CPUs and memory of another
+// instance type
+func (s *ComputeService) ModifyInstanceType(ctx context.Context, instanceID string, instanceType string) error {
+	if _, err := s.readRecord(instanceID); err != nil {
+		return err
+	}
+	cpus, memory := instanceResources(instanceType)
+	if err := s.driver.Resize(ctx, instanceID, cpus, memory); err != nil {
+		return fmt.Errorf("failed to resize instance %s: %w", instanceID, err)
+	}
+	return s.updateRecord(instanceID, func(rec *instanceRecord) {
+		rec.InstanceType = instanceType
+	})
+}
+
+// TagInstance adds or overwrites tags on an instance
+func (s *ComputeService) TagInstance(ctx context.Context, instanceID string, tags map[string]string) error {
+	return s.updateRecord(instanceID, func(rec *instanceRecord) {
+		for k, v := range tags {
+			rec.Tags[k] = v
+		}
+	})
+}
+
+// PrepareVirtualIP is not supported: VMs get their addresses from the
+// driver's network
+func (s *ComputeService) PrepareVirtualIP(ctx context.Context, clusterName string, instanceIDs []string, address string) (string, error) {
+	return "", provider.UserConfigErrorf("virtual IPs are not supported by the local provider; remove virtualIP from the spec of cluster %s", clusterName)
+}
+
+// SetInstanceProtection has nothing to protect: local VMs are only stopped
+// and deleted through goman or the driver's CLI
+func (s *ComputeService) SetInstanceProtection(ctx context.Context, instanceID string, shutdownBehavior string, stopProtection, deletionProtection bool) error {
+	if stopProtection {
+		logger.Printf("Instance %s: the local provider does not support stop protection, ignoring", instanceID)
+	}
+	return nil
+}
+
+// SyncClusterTags sets user tags on a cluster's instances and removes the
+// given keys
+func (s *ComputeService) SyncClusterTags(ctx context.Context, clusterName string, tags map[string]string, removed []string) (*provider.TagSyncResult, error) {
+	records, err := s.records()
+	if err != nil {
+		return nil, err
+	}
+	result := &provider.TagSyncResult{}
+	for _, rec := range records {
+		if rec.Tags["goman-cluster"] != clusterName {
+			continue
+		}
+		result.Resources++
+		changed := false
+		for k, v := range tags {
+			if rec.Tags[k] != v {
+				changed = true
+			}
+		}
+		for _, k := range removed {
+			if _, ok := rec.Tags[k]; ok {
+				changed = true
+			}
+		}
+		if !changed {
+			continue
+		}
+		err := s.updateRecord(rec.ID, func(rec *instanceRecord) {
+			for k, v := range tags {
+				rec.Tags[k] = v
+			}
+			for _, k := range removed {
+				delete(rec.Tags, k)
+			}
+		})
+		if err != nil {
+			return result, fmt.Errorf("failed to tag instance %s: %w", rec.ID, err)
+		}
+		result.Updated++
+	}
+	return result, nil
+}
+
+// LinkClusters has nothing to do: all local VMs share the driver's network
+func (s *ComputeService) LinkClusters(ctx context.Context, link provider.ClusterLinkConfig) (*provider.ClusterLinkResult, error) {
+	return &provider.ClusterLinkResult{Mode: provider.LinkModeSecurityGroup}, nil
+}
+
+// UnlinkClusters has nothing to undo
+func (s *ComputeService) UnlinkClusters(ctx context.Context, link provider.ClusterLinkConfig, peeringID string) error {
+	return nil
+}
+
+// EnsureClusterIdentity returns a placeholder: local VMs have no machine
+// identity, they reach the state directory through their mount
+func (s *ComputeService) EnsureClusterIdentity(ctx context.Context, clusterName string) (string, error) {
+	return "local", nil
+}
+
+// ListClusterIdentities returns no clusters, as there are no identities
+func (s *ComputeService) ListClusterIdentities(ctx context.Context) ([]string, error) {
+	return nil, nil
+}
+
+// DeleteClusterIdentity has nothing to delete
+func (s *ComputeService) DeleteClusterIdentity(ctx context.Context, clusterName string) error {
+	return nil
+}
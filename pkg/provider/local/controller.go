@@ -0,0 +1,179 @@
+package local
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/madhouselabs/goman/pkg/controller"
+	"github.com/madhouselabs/goman/pkg/provider"
+	"github.com/madhouselabs/goman/pkg/storage"
+)
+
+// pollInterval is how often the controller looks for spec changes
+const pollInterval = time.Second
+
+// Controller is the local counterpart of the controller function: it
+// reconciles clusters whose config.yaml changed and requeues them the way
+// the function's queue does
+type Controller struct {
+	provider   *LocalProvider
+	reconciler *controller.Reconciler
+
+	mu       sync.Mutex
+	seen     map[string]time.Time // Modification time of each config.yaml
+	due      map[string]time.Time // Requeued clusters and when
+	inFlight map[string]bool
+	polled   bool
+	wg       sync.WaitGroup
+}
+
+// NewController creates a controller reconciling the clusters of p
+func NewController(p *LocalProvider) (*Controller, error) {
+	ctx := context.Background()
+	if err := p.GetStorageService().Initialize(ctx); err != nil {
+		return nil, err
+	}
+	if err := p.GetLockService().Initialize(ctx); err != nil {
+		return nil, err
+	}
+
+	owner := fmt.Sprintf("local-controller-%d-%d", os.Getpid(), time.Now().UnixNano())
+	reconciler, err := controller.NewReconciler(p, owner)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create reconciler: %w", err)
+	}
+	if err := reconciler.SetSettings(controller.LoadSettings(ctx, p.GetStorageService())); err != nil {
+		log.Printf("Warning: %v", err)
+	}
+
+	return &Controller{
+		provider:   p,
+		reconciler: reconciler,
+		seen:       make(map[string]time.Time),
+		due:        make(map[string]time.Time),
+		inFlight:   make(map[string]bool),
+	}, nil
+}
+
+// Run reconciles changed and requeued clusters until ctx is done, then
+// waits for running reconciles to finish. Every existing cluster is
+// reconciled once at start.
+func (c *Controller) Run(ctx context.Context) error {
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+	for {
+		if err := c.poll(ctx); err != nil {
+			log.Printf("Warning: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			c.wg.Wait()
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// poll starts reconciles for clusters whose spec changed or whose requeue
+// is due
+func (c *Controller) poll(ctx context.Context) error {
+	configs, err := filepath.Glob(filepath.Join(c.provider.storageService.root, "clusters", "*", "config.yaml"))
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var changed []string
+	for _, path := range configs {
+		info, err := os.Stat(path)
+		if err != nil {
+			continue
+		}
+		name := filepath.Base(filepath.Dir(path))
+		if seen, ok := c.seen[name]; !ok || !info.ModTime().Equal(seen) {
+			c.seen[name] = info.ModTime()
+			changed = append(changed, name)
+		}
+	}
+	// Requeues do not survive a restart, so the first poll reconciles all
+	specChange := c.polled
+	c.polled = true
+	for _, name := range changed {
+		if c.inFlight[name] {
+			// Picked up once the running reconcile finished
+			c.due[name] = time.Now()
+			continue
+		}
+		c.start(ctx, name, specChange)
+	}
+	for name, at := range c.due {
+		if !c.inFlight[name] && !at.After(time.Now()) {
+			delete(c.due, name)
+			c.start(ctx, name, false)
+		}
+	}
+	return nil
+}
+
+// start reconciles a cluster in the background; c.mu must be held
+func (c *Controller) start(ctx context.Context, name string, specChange bool) {
+	c.inFlight[name] = true
+	c.wg.Add(1)
+	go func() {
+		defer c.wg.Done()
+		c.reconcile(ctx, name, specChange)
+		c.mu.Lock()
+		delete(c.inFlight, name)
+		c.mu.Unlock()
+	}()
+}
+
+// reconcile reconciles a cluster and schedules its requeue. Rapid edits
+// change config.yaml once each; a reconcile covers all of them, so spec
+// changes it already acted on are skipped.
+func (c *Controller) reconcile(ctx context.Context, name string, specChange bool) {
+	if specChange && !c.reconciler.SpecChangePending(ctx, name) {
+		return
+	}
+	requestID := fmt.Sprintf("local-%d", time.Now().UnixNano())
+	result, err := c.reconciler.ReconcileClusterWithRequestID(ctx, name, requestID)
+	if err != nil {
+		if strings.Contains(err.Error(), "is locked by") {
+			c.requeue(ctx, name, 10*time.Second)
+			return
+		}
+		log.Printf("Reconcile of cluster %s failed: %v", name, err)
+		c.requeue(ctx, name, 30*time.Second)
+		return
+	}
+	if result != nil && result.Requeue {
+		c.requeue(ctx, name, result.RequeueAfter)
+	}
+}
+
+// requeue reconciles a cluster again after a delay, unless it was deleted
+func (c *Controller) requeue(ctx context.Context, name string, after time.Duration) {
+	configKey := storage.ClusterConfigKey(name)
+	if _, err := c.provider.GetStorageService().GetObject(ctx, configKey); errors.Is(err, provider.ErrNotFound) {
+		log.Printf("Cluster %s no longer exists, not scheduling requeue", name)
+		c.mu.Lock()
+		delete(c.seen, name)
+		c.mu.Unlock()
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	at := time.Now().Add(after)
+	if due, ok := c.due[name]; !ok || at.Before(due) {
+		c.due[name] = at
+	}
+	log.Printf("Scheduled requeue for cluster %s in %s", name, after)
+}
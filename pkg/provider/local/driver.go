@@ -0,0 +1,302 @@
+package local
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/madhouselabs/goman/pkg/config"
+	"github.com/madhouselabs/goman/pkg/provider"
+)
+
+// stateMountPoint is where the state directory is mounted in the VMs
+const stateMountPoint = "/var/lib/goman/state"
+
+// vmSpec is what a VM is launched with
+type vmSpec struct {
+	Name      string
+	CPUs      int
+	MemoryMiB int
+	DiskGiB   int
+	StateDir  string // Mounted at stateMountPoint
+	Bootstrap string // Script run as root once the VM is up
+	LogPath   string // Output of the launch
+}
+
+// vmInfo is the state of a VM as the driver reports it
+type vmInfo struct {
+	State string // One of the provider.InstanceState* constants
+	IP    string
+}
+
+// vmDriver runs the VMs behind local instances. Info returns an error
+// matching provider.ErrNotFound for VMs that do not exist.
+type vmDriver interface {
+	Name() string
+	// Launch starts creating a VM and returns without waiting for it
+	Launch(ctx context.Context, spec vmSpec) error
+	Info(ctx context.Context, name string) (*vmInfo, error)
+	Start(ctx context.Context, name string) error
+	Stop(ctx context.Context, name string) error
+	Delete(ctx context.Context, name string) error
+	// Resize changes the CPUs and memory of a stopped VM
+	Resize(ctx context.Context, name string, cpus, memoryMiB int) error
+	// Exec runs a script as root and returns its output and exit code
+	Exec(ctx context.Context, name, script string) (stdout, stderr string, exitCode int, err error)
+}
+
+// newDriver returns the driver named by GOMAN_LOCAL_DRIVER
+func newDriver(name, dir string) (vmDriver, error) {
+	switch name {
+	case config.LocalDriverMultipass:
+		return &multipassDriver{}, nil
+	case config.LocalDriverFake:
+		return &fakeDriver{dir: filepath.Join(dir, "fake")}, nil
+	default:
+		return nil, fmt.Errorf("unknown local driver %q (use %s or %s)", name, config.LocalDriverMultipass, config.LocalDriverFake)
+	}
+}
+
+// multipassDriver runs Ubuntu VMs with the multipass CLI
+type multipassDriver struct{}
+
+func (d *multipassDriver) Name() string {
+	return config.LocalDriverMultipass
+}
+
+func (d *multipassDriver) run(ctx context.Context, stdin string, args ...string) (string, string, error) {
+	cmd := exec.CommandContext(ctx, "multipass", args...)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if stdin != "" {
+		cmd.Stdin = strings.NewReader(stdin)
+	}
+	err := cmd.Run()
+	if err != nil && errors.Is(err, exec.ErrNotFound) {
+		return "", "", provider.UserConfigErrorf("multipass is not installed; see https://multipass.run or set GOMAN_LOCAL_DRIVER=%s", config.LocalDriverFake)
+	}
+	return stdout.String(), stderr.String(), err
+}
+
+// Launch creates the VM, mounts the state directory and starts the
+// bootstrap as a systemd unit, in a process of its own. multipass waits for
+// the VM to boot, which would otherwise block the reconcile.
+func (d *multipassDriver) Launch(ctx context.Context, spec vmSpec) error {
+	if _, err := exec.LookPath("multipass"); err != nil {
+		return provider.UserConfigErrorf("multipass is not installed; see https://multipass.run or set GOMAN_LOCAL_DRIVER=%s", config.LocalDriverFake)
+	}
+	bootstrapPath := spec.LogPath + ".sh"
+	if err := os.WriteFile(bootstrapPath, []byte(spec.Bootstrap), 0600); err != nil {
+		return fmt.Errorf("failed to write bootstrap script: %w", err)
+	}
+	logFile, err := os.Create(spec.LogPath)
+	if err != nil {
+		return fmt.Errorf("failed to create launch log: %w", err)
+	}
+	defer logFile.Close()
+
+	script := fmt.Sprintf(`set -e
+multipass launch --name %[1]q --cpus %[2]d --memory %[3]dM --disk %[4]dG --mount %[5]q:%[6]s
+multipass exec %[1]q -- sudo tee /var/lib/goman-bootstrap.sh < %[7]q > /dev/null
+multipass exec %[1]q -- sudo systemd-run --unit goman-bootstrap bash /var/lib/goman-bootstrap.sh
+`, spec.Name, spec.CPUs, spec.MemoryMiB, spec.DiskGiB, spec.StateDir, stateMountPoint, bootstrapPath)
+	cmd := exec.Command("sh", "-c", script)
+	cmd.Stdout = logFile
+	cmd.Stderr = logFile
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("failed to launch %s: %w", spec.Name, err)
+	}
+	go cmd.Wait()
+	return nil
+}
+
+func (d *multipassDriver) Info(ctx context.Context, name string) (*vmInfo, error) {
+	stdout, stderr, err := d.run(ctx, "", "info", name, "--format", "json")
+	if err != nil {
+		if strings.Contains(stderr, "does not exist") {
+			return nil, fmt.Errorf("VM %s: %w", name, provider.ErrNotFound)
+		}
+		return nil, fmt.Errorf("multipass info %s: %v: %s", name, err, strings.TrimSpace(stderr))
+	}
+	var out struct {
+		Info map[string]struct {
+			State string   `json:"state"`
+			IPv4  []string `json:"ipv4"`
+		} `json:"info"`
+	}
+	if err := json.Unmarshal([]byte(stdout), &out); err != nil {
+		return nil, fmt.Errorf("failed to parse multipass info: %w", err)
+	}
+	vm, ok := out.Info[name]
+	if !ok {
+		return nil, fmt.Errorf("VM %s: %w", name, provider.ErrNotFound)
+	}
+	info := &vmInfo{}
+	if len(vm.IPv4) > 0 {
+		info.IP = vm.IPv4[0]
+	}
+	switch vm.State {
+	case "Running":
+		info.State = provider.InstanceStateRunning
+	case "Stopped", "Suspended":
+		info.State = provider.InstanceStateStopped
+	case "Suspending", "Delayed Shutdown":
+		info.State = provider.InstanceStateStopping
+	case "Deleted":
+		info.State = provider.InstanceStateTerminated
+	default:
+		info.State = provider.InstanceStatePending
+	}
+	return info, nil
+}
+
+func (d *multipassDriver) Start(ctx context.Context, name string) error {
+	if _, stderr, err := d.run(ctx, "", "start", name); err != nil {
+		return fmt.Errorf("multipass start %s: %v: %s", name, err, strings.TrimSpace(stderr))
+	}
+	return nil
+}
+
+func (d *multipassDriver) Stop(ctx context.Context, name string) error {
+	if _, stderr, err := d.run(ctx, "", "stop", name); err != nil {
+		return fmt.Errorf("multipass stop %s: %v: %s", name, err, strings.TrimSpace(stderr))
+	}
+	return nil
+}
+
+func (d *multipassDriver) Delete(ctx context.Context, name string) error {
+	if _, stderr, err := d.run(ctx, "", "delete", "--purge", name); err != nil && !strings.Contains(stderr, "does not exist") {
+		return fmt.Errorf("multipass delete %s: %v: %s", name, err, strings.TrimSpace(stderr))
+	}
+	return nil
+}
+
+func (d *multipassDriver) Resize(ctx context.Context, name string, cpus, memoryMiB int) error {
+	settings := []string{
+		fmt.Sprintf("local.%s.cpus=%d", name, cpus),
+		fmt.Sprintf("local.%s.memory=%dM", name, memoryMiB),
+	}
+	for _, setting := range settings {
+		if _, stderr, err := d.run(ctx, "", "set", setting); err != nil {
+			return fmt.Errorf("multipass set %s: %v: %s", setting, err, strings.TrimSpace(stderr))
+		}
+	}
+	return nil
+}
+
+func (d *multipassDriver) Exec(ctx context.Context, name, script string) (string, string, int, error) {
+	stdout, stderr, err := d.run(ctx, script, "exec", name, "--", "sudo", "bash", "-s")
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			return stdout, stderr, exitErr.ExitCode(), nil
+		}
+		return stdout, stderr, -1, err
+	}
+	return stdout, stderr, 0, nil
+}
+
+// fakeDriver keeps VM states in files instead of running VMs. VMs are
+// running as soon as they are launched and every script succeeds without
+// output, enough to drive the reconciler end to end in tests and CI.
+type fakeDriver struct {
+	dir string
+}
+
+// fakeVM is the file of a fake VM
+type fakeVM struct {
+	State string `json:"state"`
+	IP    string `json:"ip"`
+}
+
+func (d *fakeDriver) Name() string {
+	return config.LocalDriverFake
+}
+
+func (d *fakeDriver) path(name string) string {
+	return filepath.Join(d.dir, name+".json")
+}
+
+func (d *fakeDriver) read(name string) (*fakeVM, error) {
+	data, err := os.ReadFile(d.path(name))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("VM %s: %w", name, provider.ErrNotFound)
+	}
+	if err != nil {
+		return nil, err
+	}
+	var vm fakeVM
+	if err := json.Unmarshal(data, &vm); err != nil {
+		return nil, err
+	}
+	return &vm, nil
+}
+
+func (d *fakeDriver) write(name string, vm *fakeVM) error {
+	data, err := json.Marshal(vm)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(d.path(name), data)
+}
+
+func (d *fakeDriver) setState(name, state string) error {
+	vm, err := d.read(name)
+	if err != nil {
+		return err
+	}
+	vm.State = state
+	return d.write(name, vm)
+}
+
+func (d *fakeDriver) Launch(ctx context.Context, spec vmSpec) error {
+	if err := os.MkdirAll(d.dir, 0700); err != nil {
+		return err
+	}
+	entries, _ := os.ReadDir(d.dir)
+	ip := fmt.Sprintf("10.99.%d.%d", (len(entries)+1)/250, (len(entries)+1)%250+2)
+	return d.write(spec.Name, &fakeVM{State: provider.InstanceStateRunning, IP: ip})
+}
+
+func (d *fakeDriver) Info(ctx context.Context, name string) (*vmInfo, error) {
+	vm, err := d.read(name)
+	if err != nil {
+		return nil, err
+	}
+	return &vmInfo{State: vm.State, IP: vm.IP}, nil
+}
+
+func (d *fakeDriver) Start(ctx context.Context, name string) error {
+	return d.setState(name, provider.InstanceStateRunning)
+}
+
+func (d *fakeDriver) Stop(ctx context.Context, name string) error {
+	return d.setState(name, provider.InstanceStateStopped)
+}
+
+func (d *fakeDriver) Delete(ctx context.Context, name string) error {
+	if err := os.Remove(d.path(name)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+func (d *fakeDriver) Resize(ctx context.Context, name string, cpus, memoryMiB int) error {
+	_, err := d.read(name)
+	return err
+}
+
+func (d *fakeDriver) Exec(ctx context.Context, name, script string) (string, string, int, error) {
+	if _, err := d.read(name); err != nil {
+		return "", "", -1, err
+	}
+	return "", "", 0, nil
+}
@@ -0,0 +1,66 @@
+package local
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/madhouselabs/goman/pkg/controller"
+)
+
+// FunctionService stands in for the controller function. There is nothing
+// to deploy: 'goman local controller' runs the reconciler on this machine,
+// and invoking the function reconciles in the calling process.
+type FunctionService struct {
+	provider *LocalProvider
+}
+
+// functionEvent is the payload InvokeFunction accepts, as the AWS and GCP
+// functions do
+type functionEvent struct {
+	ClusterName string `json:"cluster_name"`
+}
+
+// Initialize has nothing to set up
+func (s *FunctionService) Initialize(ctx context.Context) error {
+	return nil
+}
+
+// DeployFunction has nothing to deploy
+func (s *FunctionService) DeployFunction(ctx context.Context, name string, packagePath string) error {
+	return nil
+}
+
+// InvokeFunction reconciles the cluster named in payload once and returns
+// the result
+func (s *FunctionService) InvokeFunction(ctx context.Context, name string, payload []byte) ([]byte, error) {
+	var event functionEvent
+	if err := json.Unmarshal(payload, &event); err != nil || event.ClusterName == "" {
+		return nil, fmt.Errorf("invalid event format or missing cluster name")
+	}
+	reconciler, err := controller.NewReconciler(s.provider, "local-invoke")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create reconciler: %w", err)
+	}
+	result, err := reconciler.ReconcileCluster(ctx, event.ClusterName)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(result)
+}
+
+// DeleteFunction has nothing to delete
+func (s *FunctionService) DeleteFunction(ctx context.Context, name string) error {
+	return nil
+}
+
+// FunctionExists reports true: the controller is part of the goman binary
+func (s *FunctionService) FunctionExists(ctx context.Context, name string) (bool, error) {
+	return true, nil
+}
+
+// GetFunctionURL returns no URL; the local controller is not reachable
+// over the network
+func (s *FunctionService) GetFunctionURL(ctx context.Context, name string) (string, error) {
+	return "", nil
+}
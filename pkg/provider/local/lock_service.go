@@ -0,0 +1,212 @@
+package local
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"net/url"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/madhouselabs/goman/pkg/provider"
+)
+
+// guardTimeout is how long a guard file may be held before it is considered
+// left behind by a crashed process
+const guardTimeout = 30 * time.Second
+
+// LockService implements locking with one file per locked resource. Changes
+// to a lock file happen under a guard file created exclusively, so the CLI,
+// the TUI and the local controller can share locks.
+type LockService struct {
+	dir string
+}
+
+// NewLockService creates a new file-based lock service
+func NewLockService(dir string) *LockService {
+	return &LockService{dir: dir}
+}
+
+// lockFile is the content of a lock file
+type lockFile struct {
+	ResourceID string                 `json:"resource_id"`
+	Owner      string                 `json:"owner"`
+	Token      string                 `json:"token"`
+	ExpiresAt  time.Time              `json:"expires_at"`
+	Metadata   *provider.LockMetadata `json:"metadata,omitempty"`
+}
+
+func (s *LockService) path(resourceID string) string {
+	return filepath.Join(s.dir, url.PathEscape(resourceID)+".json")
+}
+
+// Initialize creates the lock directory
+func (s *LockService) Initialize(ctx context.Context) error {
+	if err := os.MkdirAll(s.dir, 0700); err != nil {
+		return fmt.Errorf("failed to create lock directory: %w", err)
+	}
+	return nil
+}
+
+// guard runs fn while holding the guard file of a resource
+func (s *LockService) guard(ctx context.Context, resourceID string, fn func() error) error {
+	if err := os.MkdirAll(s.dir, 0700); err != nil {
+		return err
+	}
+	guardPath := s.path(resourceID) + ".guard"
+	for {
+		f, err := os.OpenFile(guardPath, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+		if err == nil {
+			f.Close()
+			break
+		}
+		if !errors.Is(err, fs.ErrExist) {
+			return err
+		}
+		if info, err := os.Stat(guardPath); err == nil && time.Since(info.ModTime()) > guardTimeout {
+			os.Remove(guardPath)
+			continue
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(20 * time.Millisecond):
+		}
+	}
+	defer os.Remove(guardPath)
+	return fn()
+}
+
+// read returns the lock of a resource, nil if there is none
+func (s *LockService) read(resourceID string) (*lockFile, error) {
+	data, err := os.ReadFile(s.path(resourceID))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var lock lockFile
+	if err := json.Unmarshal(data, &lock); err != nil {
+		// A corrupt lock protects nothing
+		return nil, nil
+	}
+	return &lock, nil
+}
+
+func (s *LockService) write(lock *lockFile) error {
+	data, err := json.Marshal(lock)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(s.path(lock.ResourceID), data)
+}
+
+// AcquireLock tries to acquire a lock for a resource
+func (s *LockService) AcquireLock(ctx context.Context, resourceID string, owner string, ttl time.Duration) (string, error) {
+	return s.acquire(ctx, resourceID, owner, ttl, nil)
+}
+
+// AcquireLockWithMetadata tries to acquire a lock with additional metadata
+func (s *LockService) AcquireLockWithMetadata(ctx context.Context, resourceID string, owner string, ttl time.Duration, metadata *provider.LockMetadata) (string, error) {
+	token, err := s.acquire(ctx, resourceID, owner, ttl, metadata)
+	if err != nil {
+		return "", err
+	}
+
+	phase, step, requestID := "unknown", "unknown", "unknown"
+	if metadata != nil {
+		phase, step, requestID = metadata.Phase, metadata.Step, metadata.RequestID
+	}
+	log.Printf("[LOCK] Acquired lock for %s (phase: %s, step: %s, requestId: %s)", resourceID, phase, step, requestID)
+	return token, nil
+}
+
+func (s *LockService) acquire(ctx context.Context, resourceID, owner string, ttl time.Duration, metadata *provider.LockMetadata) (string, error) {
+	token, err := newToken()
+	if err != nil {
+		return "", fmt.Errorf("failed to generate lock token: %w", err)
+	}
+	err = s.guard(ctx, resourceID, func() error {
+		current, err := s.read(resourceID)
+		if err != nil {
+			return err
+		}
+		if current != nil && current.ExpiresAt.After(time.Now()) {
+			return fmt.Errorf("resource %s is locked by %s", resourceID, current.Owner)
+		}
+		return s.write(&lockFile{
+			ResourceID: resourceID,
+			Owner:      owner,
+			Token:      token,
+			ExpiresAt:  time.Now().Add(ttl),
+			Metadata:   metadata,
+		})
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to acquire lock: %w", err)
+	}
+	return token, nil
+}
+
+// ReleaseLock releases a lock using the token
+func (s *LockService) ReleaseLock(ctx context.Context, resourceID string, token string) error {
+	err := s.guard(ctx, resourceID, func() error {
+		current, err := s.read(resourceID)
+		if err != nil {
+			return err
+		}
+		if current == nil || current.Token != token {
+			return fmt.Errorf("invalid token or lock already released")
+		}
+		return os.Remove(s.path(resourceID))
+	})
+	if err != nil {
+		log.Printf("[LOCK] Failed to release lock for %s: %v", resourceID, err)
+		return err
+	}
+	log.Printf("[LOCK] Released lock for %s", resourceID)
+	return nil
+}
+
+// RenewLock extends the TTL of an existing lock
+func (s *LockService) RenewLock(ctx context.Context, resourceID string, token string, ttl time.Duration) error {
+	return s.guard(ctx, resourceID, func() error {
+		current, err := s.read(resourceID)
+		if err != nil {
+			return fmt.Errorf("failed to renew lock: %w", err)
+		}
+		if current == nil || current.Token != token || !current.ExpiresAt.After(time.Now()) {
+			return fmt.Errorf("invalid token or lock expired")
+		}
+		current.ExpiresAt = time.Now().Add(ttl)
+		return s.write(current)
+	})
+}
+
+// IsLocked checks if a resource is currently locked
+func (s *LockService) IsLocked(ctx context.Context, resourceID string) (bool, string, error) {
+	current, err := s.read(resourceID)
+	if err != nil {
+		return false, "", fmt.Errorf("failed to read lock: %w", err)
+	}
+	if current == nil || !current.ExpiresAt.After(time.Now()) {
+		return false, "", nil
+	}
+	return true, current.Owner, nil
+}
+
+// newToken returns a random hex token
+func newToken() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
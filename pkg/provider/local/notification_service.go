@@ -0,0 +1,57 @@
+package local
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// NotificationService appends published messages to one log file per topic.
+// There are no subscribers to deliver to; the files are for inspection.
+type NotificationService struct {
+	dir string
+}
+
+// NewNotificationService creates a new file-based notification service
+func NewNotificationService(dir string) *NotificationService {
+	return &NotificationService{dir: dir}
+}
+
+// Initialize creates the notification directory
+func (s *NotificationService) Initialize(ctx context.Context) error {
+	if err := os.MkdirAll(s.dir, 0700); err != nil {
+		return fmt.Errorf("failed to create notification directory: %w", err)
+	}
+	return nil
+}
+
+// Publish appends a message to the topic's log
+func (s *NotificationService) Publish(ctx context.Context, topic string, message string) error {
+	if err := os.MkdirAll(s.dir, 0700); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(filepath.Join(s.dir, url.PathEscape(topic)+".log"), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to publish to %s: %w", topic, err)
+	}
+	defer f.Close()
+	line := strings.ReplaceAll(message, "\n", " ")
+	if _, err := fmt.Fprintf(f, "%s %s\n", time.Now().UTC().Format(time.RFC3339), line); err != nil {
+		return fmt.Errorf("failed to publish to %s: %w", topic, err)
+	}
+	return nil
+}
+
+// Subscribe returns the topic's log file as subscription ID
+func (s *NotificationService) Subscribe(ctx context.Context, topic string) (string, error) {
+	return filepath.Join(s.dir, url.PathEscape(topic)+".log"), nil
+}
+
+// Unsubscribe is a no-op
+func (s *NotificationService) Unsubscribe(ctx context.Context, subscriptionID string) error {
+	return nil
+}
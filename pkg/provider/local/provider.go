@@ -0,0 +1,297 @@
+package local
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/madhouselabs/goman/pkg/config"
+	"github.com/madhouselabs/goman/pkg/logger"
+	"github.com/madhouselabs/goman/pkg/provider"
+)
+
+// Region is the region of the local provider; clusters keep whatever region
+// their spec names
+const Region = "local"
+
+// LocalProvider implements the Provider interface on this machine: state in
+// a directory, nodes as VMs of a local driver. It lets the reconciler and
+// the TUI run end to end without a cloud account.
+type LocalProvider struct {
+	dir    string
+	driver vmDriver
+
+	// Services
+	lockService         *LockService
+	storageService      *StorageService
+	notificationService *NotificationService
+	functionService     *FunctionService
+	computeService      *ComputeService
+	secretService       provider.SecretService
+}
+
+// NewProvider creates a local provider keeping its files in dir, by
+// default GOMAN_LOCAL_DIR, with the driver named by GOMAN_LOCAL_DRIVER
+func NewProvider(dir string) (*LocalProvider, error) {
+	if dir == "" {
+		dir = config.GetLocalDir()
+	}
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, fmt.Errorf("invalid local provider directory: %w", err)
+	}
+	driver, err := newDriver(config.GetLocalDriver(), dir)
+	if err != nil {
+		return nil, err
+	}
+	logger.Printf("Local provider in %s, driver %s", dir, driver.Name())
+
+	p := &LocalProvider{
+		dir:    dir,
+		driver: driver,
+	}
+	p.storageService = NewStorageService(filepath.Join(dir, "state"))
+	p.lockService = NewLockService(filepath.Join(dir, "locks"))
+	p.notificationService = NewNotificationService(filepath.Join(dir, "notifications"))
+	p.functionService = &FunctionService{provider: p}
+	p.computeService = NewComputeService(filepath.Join(dir, "instances"), p.storageService, driver)
+
+	secretService, err := NewSecretService(p.storageService)
+	if err != nil {
+		return nil, err
+	}
+	p.secretService = secretService
+
+	return p, nil
+}
+
+var (
+	providerCache *LocalProvider
+	providerMu    sync.Mutex
+)
+
+// GetCachedProvider returns a cached local provider for dir, so commands
+// started by one process are tracked by one compute service
+func GetCachedProvider(dir string) (*LocalProvider, error) {
+	providerMu.Lock()
+	defer providerMu.Unlock()
+
+	if dir == "" {
+		dir = config.GetLocalDir()
+	}
+	if abs, err := filepath.Abs(dir); err == nil && providerCache != nil && providerCache.dir == abs {
+		return providerCache, nil
+	}
+
+	p, err := NewProvider(dir)
+	if err != nil {
+		return nil, err
+	}
+	providerCache = p
+	return p, nil
+}
+
+// GetLockService returns the lock service
+func (p *LocalProvider) GetLockService() provider.LockService {
+	return p.lockService
+}
+
+// GetStorageService returns the storage service
+func (p *LocalProvider) GetStorageService() provider.StorageService {
+	return p.storageService
+}
+
+// GetNotificationService returns the notification service
+func (p *LocalProvider) GetNotificationService() provider.NotificationService {
+	return p.notificationService
+}
+
+// GetFunctionService returns the function service
+func (p *LocalProvider) GetFunctionService() provider.FunctionService {
+	return p.functionService
+}
+
+// GetComputeService returns the compute service
+func (p *LocalProvider) GetComputeService() provider.ComputeService {
+	return p.computeService
+}
+
+// GetSecretService returns the secret service
+func (p *LocalProvider) GetSecretService() provider.SecretService {
+	return p.secretService
+}
+
+// Name returns the provider name
+func (p *LocalProvider) Name() string {
+	return "local"
+}
+
+// Region returns the provider region
+func (p *LocalProvider) Region() string {
+	return Region
+}
+
+// GetAccountID returns "local"; the directory scopes goman's state
+func (p *LocalProvider) GetAccountID() string {
+	return "local"
+}
+
+// CallerARN returns the local user, for the audit log
+func (p *LocalProvider) CallerARN(ctx context.Context) (string, error) {
+	if user := os.Getenv("USER"); user != "" {
+		return "local:" + user, nil
+	}
+	return "local", nil
+}
+
+// GetServiceName returns the local service name for a generic service type
+func (p *LocalProvider) GetServiceName(serviceType provider.ServiceType) string {
+	switch serviceType {
+	case provider.ServiceTypeCompute:
+		return p.driver.Name()
+	case provider.ServiceTypeStorage:
+		return "Filesystem"
+	case provider.ServiceTypeCommand:
+		return p.driver.Name() + " exec"
+	case provider.ServiceTypeLock:
+		return "Lock files"
+	case provider.ServiceTypeFunction:
+		return "Local controller"
+	case provider.ServiceTypeNotification:
+		return "Log files"
+	default:
+		return string(serviceType)
+	}
+}
+
+// GetProviderConfig returns local provider configuration
+func (p *LocalProvider) GetProviderConfig() provider.ProviderConfig {
+	return provider.ProviderConfig{
+		DefaultInstanceType: "t3.medium",
+		DefaultRegion:       Region,
+		DefaultTopics: map[string]string{
+			"notifications": "goman-cluster-events",
+		},
+		ServiceNames: map[provider.ServiceType]string{
+			provider.ServiceTypeCompute:      p.GetServiceName(provider.ServiceTypeCompute),
+			provider.ServiceTypeStorage:      p.GetServiceName(provider.ServiceTypeStorage),
+			provider.ServiceTypeCommand:      p.GetServiceName(provider.ServiceTypeCommand),
+			provider.ServiceTypeLock:         p.GetServiceName(provider.ServiceTypeLock),
+			provider.ServiceTypeFunction:     p.GetServiceName(provider.ServiceTypeFunction),
+			provider.ServiceTypeNotification: p.GetServiceName(provider.ServiceTypeNotification),
+		},
+		CustomSettings: map[string]interface{}{
+			"dir":    p.dir,
+			"driver": p.driver.Name(),
+		},
+	}
+}
+
+// GetServiceConfiguration returns service-specific configuration
+func (p *LocalProvider) GetServiceConfiguration(serviceType provider.ServiceType) provider.ServiceConfiguration {
+	cfg := provider.DefaultServiceConfiguration(serviceType)
+
+	switch serviceType {
+	case provider.ServiceTypeCompute:
+		cfg.ProviderSpecific = map[string]interface{}{
+			"driver":    p.driver.Name(),
+			"instances": p.computeService.dir,
+		}
+	case provider.ServiceTypeStorage:
+		cfg.ProviderSpecific = map[string]interface{}{
+			"directory": p.storageService.root,
+		}
+	case provider.ServiceTypeLock:
+		cfg.ProviderSpecific = map[string]interface{}{
+			"directory": p.lockService.dir,
+		}
+	}
+
+	return cfg
+}
+
+// Initialize creates the provider's directories
+func (p *LocalProvider) Initialize(ctx context.Context) (*provider.InitializeResult, error) {
+	result := &provider.InitializeResult{
+		ProviderType: "local",
+		Resources:    make(map[string]string),
+		Errors:       []string{},
+	}
+
+	if err := p.storageService.Initialize(ctx); err != nil {
+		result.Errors = append(result.Errors, fmt.Sprintf("Storage: %v", err))
+	} else {
+		result.StorageReady = true
+		result.Resources["state_dir"] = p.storageService.root
+	}
+
+	if err := p.lockService.Initialize(ctx); err != nil {
+		result.Errors = append(result.Errors, fmt.Sprintf("LockService: %v", err))
+	} else {
+		result.LockServiceReady = true
+		result.Resources["lock_dir"] = p.lockService.dir
+	}
+
+	if err := p.notificationService.Initialize(ctx); err != nil {
+		result.Errors = append(result.Errors, fmt.Sprintf("NotificationService: %v", err))
+	} else {
+		result.NotificationsReady = true
+	}
+
+	// The controller runs with 'goman local controller'
+	result.FunctionReady = true
+	result.AuthReady = true
+	result.Resources["driver"] = p.driver.Name()
+
+	if len(result.Errors) > 0 {
+		return result, fmt.Errorf("initialization completed with errors: %s", strings.Join(result.Errors, "; "))
+	}
+	return result, nil
+}
+
+// Cleanup deletes the VMs of all instances and removes the provider's
+// directory
+func (p *LocalProvider) Cleanup(ctx context.Context) error {
+	instances, err := p.computeService.ListInstances(ctx, nil)
+	if err != nil {
+		return err
+	}
+	var errors []string
+	for _, inst := range instances {
+		if err := p.computeService.DeleteInstance(ctx, inst.ID); err != nil {
+			errors = append(errors, err.Error())
+		}
+	}
+	if len(errors) > 0 {
+		return fmt.Errorf("some instances failed to delete: %s", strings.Join(errors, "; "))
+	}
+	if err := os.RemoveAll(p.dir); err != nil {
+		return fmt.Errorf("failed to remove %s: %w", p.dir, err)
+	}
+	return nil
+}
+
+// GetStatus reports whether the provider's directories exist
+func (p *LocalProvider) GetStatus(ctx context.Context) (*provider.InfrastructureStatus, error) {
+	status := &provider.InfrastructureStatus{
+		Resources: map[string]string{
+			"state_dir": p.storageService.root,
+			"driver":    p.driver.Name(),
+		},
+		StorageStatus:  "not_ready",
+		LockStatus:     "not_ready",
+		FunctionStatus: "ready",
+		AuthStatus:     "ready",
+	}
+	if _, err := os.Stat(p.storageService.root); err == nil {
+		status.StorageStatus = "ready"
+	}
+	if _, err := os.Stat(p.lockService.dir); err == nil {
+		status.LockStatus = "ready"
+	}
+	status.Initialized = status.StorageStatus == "ready" && status.LockStatus == "ready"
+	return status, nil
+}
@@ -0,0 +1,58 @@
+package local
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/madhouselabs/goman/pkg/config"
+	"github.com/madhouselabs/goman/pkg/provider"
+)
+
+// NewSecretService returns the secret service for GOMAN_SECRET_BACKEND.
+// Local nodes read secrets from the mounted state directory.
+func NewSecretService(storage *StorageService) (provider.SecretService, error) {
+	switch backend := config.GetSecretBackend(); backend {
+	case "", provider.SecretBackendStorage:
+		return &StorageSecretService{storage: storage}, nil
+	default:
+		return nil, fmt.Errorf("secret backend %q is not supported by the local provider (use %s)", backend, provider.SecretBackendStorage)
+	}
+}
+
+// StorageSecretService keeps secrets in the state directory under
+// clusters/<cluster>/, like the S3 backend of the AWS provider
+type StorageSecretService struct {
+	storage *StorageService
+}
+
+func storageSecretKey(clusterName, name string) string {
+	return fmt.Sprintf("clusters/%s/%s", clusterName, name)
+}
+
+// PutSecret stores a secret
+func (s *StorageSecretService) PutSecret(ctx context.Context, clusterName, name string, value []byte) error {
+	return s.storage.PutObject(ctx, storageSecretKey(clusterName, name), value)
+}
+
+// GetSecret retrieves a secret
+func (s *StorageSecretService) GetSecret(ctx context.Context, clusterName, name string) ([]byte, error) {
+	data, err := s.storage.GetObject(ctx, storageSecretKey(clusterName, name))
+	if err != nil {
+		if errors.Is(err, provider.ErrNotFound) {
+			return nil, fmt.Errorf("%w: %s/%s", provider.ErrSecretNotFound, clusterName, name)
+		}
+		return nil, err
+	}
+	return data, nil
+}
+
+// DeleteSecret removes a secret
+func (s *StorageSecretService) DeleteSecret(ctx context.Context, clusterName, name string) error {
+	return s.storage.DeleteObject(ctx, storageSecretKey(clusterName, name))
+}
+
+// Backend returns the backend name
+func (s *StorageSecretService) Backend() string {
+	return provider.SecretBackendStorage
+}
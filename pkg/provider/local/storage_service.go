@@ -0,0 +1,228 @@
+package local
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/madhouselabs/goman/pkg/provider"
+)
+
+// StorageService keeps objects as files under a directory, the key being
+// the path. The directory is mounted into the VMs, so node scripts read and
+// write secrets there directly.
+type StorageService struct {
+	root string
+
+	// mu serializes conditional writes within the process; writes go
+	// through a rename, so readers never see partial objects
+	mu sync.Mutex
+}
+
+// NewStorageService creates a new filesystem-based storage service
+func NewStorageService(root string) *StorageService {
+	return &StorageService{root: root}
+}
+
+// path returns the file of an object, refusing keys that leave the root
+func (s *StorageService) path(key string) (string, error) {
+	clean := filepath.Clean(filepath.FromSlash(key))
+	if key == "" || filepath.IsAbs(clean) || clean == ".." || strings.HasPrefix(clean, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("invalid object key %q", key)
+	}
+	return filepath.Join(s.root, clean), nil
+}
+
+// Initialize creates the storage directory
+func (s *StorageService) Initialize(ctx context.Context) error {
+	if err := os.MkdirAll(s.root, 0700); err != nil {
+		return fmt.Errorf("failed to create storage directory: %w", err)
+	}
+	return nil
+}
+
+// PutObject stores an object
+func (s *StorageService) PutObject(ctx context.Context, key string, data []byte) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := writeFileAtomic(path, data); err != nil {
+		return fmt.Errorf("failed to put object: %w", err)
+	}
+	return nil
+}
+
+// GetObject retrieves an object
+func (s *StorageService) GetObject(ctx context.Context, key string) ([]byte, error) {
+	path, err := s.path(key)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, fmt.Errorf("object %s: %w", key, provider.ErrNotFound)
+		}
+		return nil, fmt.Errorf("failed to get object: %w", err)
+	}
+	return data, nil
+}
+
+// objectVersion is the version of an object's content
+func objectVersion(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// GetObjectVersion retrieves an object with its version, a hash of its
+// content
+func (s *StorageService) GetObjectVersion(ctx context.Context, key string) ([]byte, string, error) {
+	data, err := s.GetObject(ctx, key)
+	if err != nil {
+		return nil, "", err
+	}
+	return data, objectVersion(data), nil
+}
+
+// PutObjectIfMatch writes an object only if its content is still that of
+// version. Conditions hold between goroutines, not between processes.
+func (s *StorageService) PutObjectIfMatch(ctx context.Context, key string, data []byte, version string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	current, err := s.GetObject(ctx, key)
+	switch {
+	case errors.Is(err, provider.ErrNotFound):
+		if version != "" {
+			return "", fmt.Errorf("object %s was deleted: %w", key, provider.ErrPreconditionFailed)
+		}
+	case err != nil:
+		return "", err
+	case version == "" || objectVersion(current) != version:
+		return "", fmt.Errorf("object %s changed: %w", key, provider.ErrPreconditionFailed)
+	}
+
+	if err := s.PutObject(ctx, key, data); err != nil {
+		return "", err
+	}
+	return objectVersion(data), nil
+}
+
+// DeleteObject removes an object and the directories it leaves empty
+func (s *StorageService) DeleteObject(ctx context.Context, key string) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("failed to delete object: %w", err)
+	}
+	for dir := filepath.Dir(path); dir != s.root && strings.HasPrefix(dir, s.root); dir = filepath.Dir(dir) {
+		if os.Remove(dir) != nil {
+			break
+		}
+	}
+	return nil
+}
+
+// ListObjects returns the keys starting with prefix, sorted
+func (s *StorageService) ListObjects(ctx context.Context, prefix string) ([]string, error) {
+	var keys []string
+	err := filepath.WalkDir(s.root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+		if d.IsDir() || strings.HasPrefix(d.Name(), ".goman-tmp-") {
+			return nil
+		}
+		rel, err := filepath.Rel(s.root, path)
+		if err != nil {
+			return err
+		}
+		if key := filepath.ToSlash(rel); strings.HasPrefix(key, prefix) {
+			keys = append(keys, key)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list objects: %w", err)
+	}
+	sort.Strings(keys)
+	return keys, nil
+}
+
+// ListObjectTimes returns the modification time of every object under prefix
+func (s *StorageService) ListObjectTimes(ctx context.Context, prefix string) (map[string]time.Time, error) {
+	keys, err := s.ListObjects(ctx, prefix)
+	if err != nil {
+		return nil, err
+	}
+	times := make(map[string]time.Time, len(keys))
+	for _, key := range keys {
+		path, err := s.path(key)
+		if err != nil {
+			return nil, err
+		}
+		info, err := os.Stat(path)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to stat object %s: %w", key, err)
+		}
+		times[key] = info.ModTime()
+	}
+	return times, nil
+}
+
+// DeleteFolder deletes all objects with the given prefix
+func (s *StorageService) DeleteFolder(ctx context.Context, prefix string) error {
+	keys, err := s.ListObjects(ctx, prefix)
+	if err != nil {
+		return err
+	}
+	for _, key := range keys {
+		if err := s.DeleteObject(ctx, key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeFileAtomic writes a file through a temporary file and a rename
+func writeFileAtomic(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".goman-tmp-*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return nil
+}
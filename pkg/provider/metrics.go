@@ -0,0 +1,18 @@
+package provider
+
+import "context"
+
+// Metric is a measurement the controller reports to the provider's
+// monitoring, where alarms can be set on it
+type Metric struct {
+	Name       string
+	Value      float64
+	Unit       string            // "Seconds" or "Count"
+	Dimensions map[string]string // e.g. {"Mode": "ha"}
+}
+
+// MetricPublisher is implemented by providers that take metrics, such as
+// CloudWatch on AWS. Metrics are published in the metric namespace "Goman".
+type MetricPublisher interface {
+	PublishMetric(ctx context.Context, metric Metric) error
+}
@@ -0,0 +1,61 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// Kinds of tagged resources
+const (
+	ResourceSecurityGroup    = "security-group"
+	ResourceNetworkInterface = "network-interface"
+	ResourceVolume           = "volume"
+	ResourceIdentity         = "identity" // Node identity, from ListClusterIdentities
+)
+
+// InstallTag names the goman install a resource was created by, so installs
+// sharing an account only collect their own orphans. Its value is the
+// install's state bucket.
+const InstallTag = "goman-install"
+
+// ErrResourceInUse is returned when a tagged resource cannot be deleted
+// because something still uses it
+var ErrResourceInUse = errors.New("resource is still in use")
+
+// TaggedResource is a cloud resource goman created for a cluster, found by
+// its tags
+type TaggedResource struct {
+	Kind    string    `json:"kind"`
+	ID      string    `json:"id"`
+	Name    string    `json:"name,omitempty"`
+	Region  string    `json:"region"`
+	Cluster string    `json:"cluster"`
+	Install string    `json:"install,omitempty"` // InstallTag value, empty for resources created before installs were tagged
+	InUse   bool      `json:"inUse,omitempty"`   // Attached to an instance or held by another resource
+	Created time.Time `json:"created"`           // Zero when the cloud does not tell
+}
+
+// OrphanCollector is implemented by compute services that can find the
+// resources goman created for clusters, so the ones of clusters that no
+// longer exist can be deleted
+type OrphanCollector interface {
+	// ListTaggedResources lists the security groups, network interfaces
+	// and volumes goman created for clusters in region
+	ListTaggedResources(ctx context.Context, region string) ([]TaggedResource, error)
+
+	// DeleteTaggedResource deletes a resource listed by ListTaggedResources.
+	// It returns ErrResourceInUse while the resource is in use; one already
+	// gone is no error.
+	DeleteTaggedResource(ctx context.Context, resource TaggedResource) error
+
+	// ListTaggedIdentities lists the node identities of clusters, with the
+	// cluster they serve now in Cluster and the name DeleteClusterIdentity
+	// takes in ID. A renamed cluster's nodes keep the identity of its
+	// former name.
+	ListTaggedIdentities(ctx context.Context) ([]TaggedResource, error)
+
+	// InstallID returns the InstallTag value of the resources this install
+	// creates
+	InstallID() string
+}
@@ -0,0 +1,19 @@
+package provider
+
+import (
+	"context"
+	"time"
+)
+
+// PresignedStorage is implemented by storage services that can hand out
+// URLs reading or writing a single object without cloud credentials, e.g.
+// for nodes that may only reach their own cluster's objects
+type PresignedStorage interface {
+	// PresignGetObject returns a URL downloading an existing object until
+	// ttl has passed, and when the URL expires
+	PresignGetObject(ctx context.Context, key string, ttl time.Duration) (string, time.Time, error)
+
+	// PresignPutObject returns a URL uploading an object with an HTTP PUT
+	// until ttl has passed, and when the URL expires
+	PresignPutObject(ctx context.Context, key string, ttl time.Duration) (string, time.Time, error)
+}
@@ -2,9 +2,14 @@ package provider
 
 import (
 	"context"
+	"errors"
 	"time"
 )
 
+// ErrIdentityInUse is returned when a cluster's node identity cannot be
+// deleted because instances still use it
+var ErrIdentityInUse = errors.New("node identity is still in use")
+
 // Provider defines the interface for cloud providers
 type Provider interface {
 	// Core services
@@ -13,6 +18,7 @@ type Provider interface {
 	GetNotificationService() NotificationService
 	GetFunctionService() FunctionService
 	GetComputeService() ComputeService
+	GetSecretService() SecretService
 
 	// Provider info
 	Name() string
@@ -90,6 +96,31 @@ type ComputeService interface {
 	StopInstance(ctx context.Context, instanceID string) error
 	ModifyInstanceType(ctx context.Context, instanceID string, instanceType string) error
 
+	// TagInstance adds or overwrites tags on an instance
+	TagInstance(ctx context.Context, instanceID string, tags map[string]string) error
+
+	// PrepareVirtualIP lets a cluster's instances take over a floating private
+	// IP. The address is assigned to the first instance unless one of them
+	// already holds it; an empty address allocates a free one. Returns the address.
+	PrepareVirtualIP(ctx context.Context, clusterName string, instanceIDs []string, address string) (string, error)
+
+	// SetInstanceProtection changes the shutdown behavior (stop or terminate),
+	// stop protection and deletion protection of an existing instance
+	SetInstanceProtection(ctx context.Context, instanceID string, shutdownBehavior string, stopProtection, deletionProtection bool) error
+
+	// SyncClusterTags sets user tags on all resources of a cluster (instances,
+	// their volumes and security groups) and removes the given keys. Only
+	// resources whose tags differ are changed, in batches per tag.
+	SyncClusterTags(ctx context.Context, clusterName string, tags map[string]string, removed []string) (*TagSyncResult, error)
+
+	// LinkClusters lets two clusters reach each other's nodes on the link's
+	// ports, in both directions. Clusters in different networks are peered
+	// when the link allows it.
+	LinkClusters(ctx context.Context, link ClusterLinkConfig) (*ClusterLinkResult, error)
+
+	// UnlinkClusters removes the connectivity LinkClusters set up
+	UnlinkClusters(ctx context.Context, link ClusterLinkConfig, peeringID string) error
+
 	// RunCommand executes a command on instances using cloud-native methods (e.g., SSM for AWS)
 	RunCommand(ctx context.Context, instanceIDs []string, command string) (*CommandResult, error)
 	
@@ -98,8 +129,35 @@ type ComputeService interface {
 	
 	// GetCommandResult checks the status of a previously started command
 	GetCommandResult(ctx context.Context, commandID string) (*CommandResult, error)
+
+	// RunOperation executes a named, versioned node operation (e.g., an SSM document for AWS)
+	// and waits for completion. See the Operation* constants for supported operations.
+	RunOperation(ctx context.Context, instanceIDs []string, operation string, params map[string]string) (*CommandResult, error)
+
+	// EnsureClusterIdentity creates the machine identity (an IAM role and instance
+	// profile for AWS) that scopes a cluster's nodes to that cluster's data.
+	// CreateInstance calls it for instances tagged with a cluster.
+	EnsureClusterIdentity(ctx context.Context, clusterName string) (string, error)
+
+	// ListClusterIdentities returns the names of the clusters that have a node identity
+	ListClusterIdentities(ctx context.Context) ([]string, error)
+
+	// DeleteClusterIdentity removes a cluster's node identity. It returns
+	// ErrIdentityInUse while instances still run with it.
+	DeleteClusterIdentity(ctx context.Context, clusterName string) error
 }
 
+// Node operations supported by RunOperation
+const (
+	OperationDrainNode    = "drain-node"
+	OperationConfigureDNS = "configure-dns"
+	OperationConfigureVIP = "configure-vip"
+	OperationAddTLSSAN    = "add-tls-san"
+	OperationUpgradeK3s   = "upgrade-k3s"
+	OperationRotateToken  = "rotate-token"
+	OperationUpdateToken  = "update-token"
+)
+
 // CommandResult represents the result of running a command on instances
 type CommandResult struct {
 	CommandID string
@@ -121,13 +179,94 @@ type InstanceConfig struct {
 	Name           string
 	Region         string // Region where instance should be created
 	InstanceType   string
-	ImageID        string
+	Architecture   string // CPU architecture of the instance type, x86_64 or arm64; picks the image
+	ImageID        string // Image to launch, the latest of OSFamily if empty
+	OSFamily       string // OS family of the image, picking the bootstrap variant; Amazon Linux 2 if empty
 	SubnetID       string
 	SecurityGroups []string
+	AvailabilityZone string // Zone to launch in, chosen by the provider if empty
 	// KeyName field removed - using Systems Manager for instance access
 	UserData        string
 	Tags            map[string]string
-	InstanceProfile string // IAM instance profile for SSM access
+	InstanceProfile string            // IAM instance profile for SSM access
+	RootVolumeSize  int               // Root volume size in GiB (0 = image default)
+	RootVolumeType  string            // Provider volume type of the root volume, e.g. gp3 (empty = gp3)
+	RootVolumeIOPS  int               // Provisioned IOPS of the root volume (0 = type default)
+	DataVolumes     []DataVolume      // Additional volumes, formatted and mounted at boot
+	Proxy           *ProxyConfig      // Outbound HTTP(S) proxy set up at boot, none if nil
+	Bootstrap       *BootstrapConfig  // Additions to the bootstrap script, none if nil
+	Registries      *RegistryConfig   // Registry mirrors and credentials, none if nil
+	UserDataSnippet string            // Pool's script or cloud-config, run after the bootstrap
+	ResourceTags    map[string]string // User tags for the instance and its volumes
+
+	ShutdownBehavior   string // Instance-initiated shutdown behavior: stop or terminate (default: stop)
+	StopProtection     bool   // Refuse stopping the instance through the API
+	DeletionProtection bool   // Refuse terminating the instance through the API; lifted by DeleteInstance
+	PrivateNetwork     bool   // No public IP; outbound traffic through NAT
+
+	VpcID               string   // VPC to launch in (empty = default VPC)
+	SubnetIDs           []string // Subnets of VpcID to pick from by zone
+	ExtraSecurityGroups []string // Security groups added to the cluster's own
+	DedicatedVPC        bool     // Launch in a VPC of the cluster's own, created on first use
+	VpcCIDR             string   // Address range of the dedicated VPC
+}
+
+// TagSyncResult reports what SyncClusterTags did
+type TagSyncResult struct {
+	Resources int // Resources checked
+	Updated   int // Resources whose tags were changed
+}
+
+// Ways two linked clusters are connected
+const (
+	LinkModeSecurityGroup = "security-group" // Same network, firewall rules only
+	LinkModePeering       = "peering"        // Peered networks with routes
+)
+
+// PortRange is a protocol and port range, inclusive
+type PortRange struct {
+	Protocol string // tcp or udp
+	FromPort int
+	ToPort   int
+}
+
+// ClusterLinkConfig describes private connectivity between two clusters
+type ClusterLinkConfig struct {
+	Cluster      string
+	Region       string
+	PeerCluster  string
+	PeerRegion   string
+	Ports        []PortRange
+	AllowPeering bool // Peer the networks if the clusters are not in the same one
+}
+
+// ClusterLinkResult reports how LinkClusters connected two clusters
+type ClusterLinkResult struct {
+	Mode      string // LinkModeSecurityGroup or LinkModePeering
+	PeeringID string // Network peering, for LinkModePeering
+}
+
+// DataVolume is an additional block volume deleted together with its instance
+type DataVolume struct {
+	SizeGiB    int
+	Type       string // Provider volume type, e.g. gp3
+	MountPoint string
+	Filesystem string // ext4 or xfs
+}
+
+// ProxyConfig is the outbound proxy of an instance, in the form of the
+// HTTP_PROXY, HTTPS_PROXY and NO_PROXY variables
+type ProxyConfig struct {
+	HTTPProxy  string
+	HTTPSProxy string
+	NoProxy    string // Comma-separated
+}
+
+// BootstrapConfig adds to the bootstrap script of an instance
+type BootstrapConfig struct {
+	PreInstall      string              // Shell script run before packages are installed
+	ExtraPackages   []string            // Installed along with the script's own packages
+	RegistryMirrors map[string][]string // Registry host, or * for all, to mirror endpoints
 }
 
 // Instance represents a compute instance
@@ -140,6 +279,9 @@ type Instance struct {
 	InstanceType string
 	LaunchTime   time.Time
 	Tags         map[string]string
+
+	AvailabilityZone string
+	Region           string // Region the instance was found in, if the provider is regional
 }
 
 // LockMetadata contains additional information about what is holding the lock
@@ -0,0 +1,187 @@
+package provider
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// RegistryConfig is the containerd registry configuration of an instance
+type RegistryConfig struct {
+	Mirrors map[string][]string     // Registry host, or * for all, to mirror endpoints
+	Auth    map[string]RegistryAuth // Registry host to where its credentials are read
+	Region  string                  // Region of the parameters and secrets, the provider's if empty
+}
+
+// RegistryAuth is where a node reads the credentials of a registry: one of
+// an ECR region, an SSM parameter or a Secrets Manager secret
+type RegistryAuth struct {
+	ECRRegion string
+	Parameter string
+	Secret    string
+}
+
+// registriesRefresh is how often nodes with registry credentials read them
+// again, well within the 12 hours ECR tokens last
+const registriesRefresh = "6h"
+
+// RegistriesScript returns the bootstrap step installing and running
+// goman-registries, which writes K3s's registries.yaml from the mirrors and
+// the credentials it reads with the node's role, and restarts K3s when the
+// file changed. With credentials it runs at every boot before K3s and
+// every 6 hours. A node whose credentials can't be read boots without
+// them. Values must be validated by models.ValidateRegistries. Empty for a
+// nil configuration.
+func RegistriesScript(config *RegistryConfig) string {
+	if config == nil {
+		return ""
+	}
+	return registriesScript(config, `if /usr/local/bin/goman-registries; then
+    echo "[$(date)] Registry configuration written" >> /var/log/goman-startup.log
+else
+    echo "[$(date)] WARNING: Failed to write the registry configuration" >> /var/log/goman-startup.log
+fi
+`)
+}
+
+// ApplyRegistriesScript returns the script applying a changed registry
+// configuration to a running node, failing if it can't be written. An
+// empty configuration removes the file and the units.
+func ApplyRegistriesScript(config RegistryConfig) string {
+	return "set -e\n" + registriesScript(&config, "/usr/local/bin/goman-registries\n")
+}
+
+// registriesScript installs goman-registries and its units, then runs it
+// with the run step
+func registriesScript(config *RegistryConfig, run string) string {
+	var b strings.Builder
+	b.WriteString(`
+# Write K3s's registries.yaml with the mirrors and registry credentials
+mkdir -p /etc/rancher/k3s
+cat > /usr/local/bin/goman-registries <<'GOMAN_REGISTRIES'
+#!/bin/bash
+# Writes /etc/rancher/k3s/registries.yaml with fresh registry credentials,
+# restarting K3s when it changed
+set -euo pipefail
+export PATH="$PATH:/snap/bin"
+file=/etc/rancher/k3s/registries.yaml
+new=$(mktemp)
+trap 'rm -f "$new"' EXIT
+
+# auth <registry> <username> <password> adds the credentials of a registry
+auth() {
+    printf '  %s:\n    auth:\n      username: %s\n      password: %s\n' \
+        "$(jq -n --arg v "$1" '$v')" "$(jq -n --arg v "$2" '$v')" "$(jq -n --arg v "$3" '$v')" >> "$new"
+}
+
+# auth_value <registry> <value> adds credentials stored as user:password or
+# as JSON with username and password
+auth_value() {
+    if jq -e '.username' <<< "$2" > /dev/null 2>&1; then
+        auth "$1" "$(jq -r .username <<< "$2")" "$(jq -r .password <<< "$2")"
+    else
+        auth "$1" "${2%%:*}" "${2#*:}"
+    fi
+}
+`)
+
+	if len(config.Mirrors) > 0 {
+		registries := make([]string, 0, len(config.Mirrors))
+		for registry := range config.Mirrors {
+			registries = append(registries, registry)
+		}
+		sort.Strings(registries)
+		b.WriteString("cat >> \"$new\" <<'MIRRORS'\nmirrors:\n")
+		for _, registry := range registries {
+			fmt.Fprintf(&b, "  %q:\n    endpoint:\n", registry)
+			for _, endpoint := range config.Mirrors[registry] {
+				fmt.Fprintf(&b, "      - %q\n", endpoint)
+			}
+		}
+		b.WriteString("MIRRORS\n")
+	}
+
+	if len(config.Auth) > 0 {
+		b.WriteString("echo configs: >> \"$new\"\n")
+		hosts := make([]string, 0, len(config.Auth))
+		for host := range config.Auth {
+			hosts = append(hosts, host)
+		}
+		sort.Strings(hosts)
+		for _, host := range hosts {
+			auth := config.Auth[host]
+			switch {
+			case auth.ECRRegion != "":
+				fmt.Fprintf(&b, "value=$(aws ecr get-login-password --region '%s')\nauth '%s' AWS \"$value\"\n", auth.ECRRegion, host)
+			case auth.Parameter != "":
+				fmt.Fprintf(&b, "value=$(aws ssm get-parameter --region '%s' --with-decryption --name '%s' --query Parameter.Value --output text)\nauth_value '%s' \"$value\"\n",
+					config.Region, auth.Parameter, host)
+			case auth.Secret != "":
+				fmt.Fprintf(&b, "value=$(aws secretsmanager get-secret-value --region '%s' --secret-id '%s' --query SecretString --output text)\nauth_value '%s' \"$value\"\n",
+					config.Region, auth.Secret, host)
+			}
+		}
+	}
+
+	b.WriteString(`
+if [ ! -s "$new" ]; then
+    [ -f "$file" ] || exit 0
+    rm -f "$file"
+elif cmp -s "$new" "$file"; then
+    exit 0
+else
+    install -m 600 "$new" "$file"
+fi
+for unit in k3s k3s-agent; do
+    if systemctl is-active --quiet "$unit"; then
+        systemctl restart "$unit"
+    fi
+done
+GOMAN_REGISTRIES
+chmod 755 /usr/local/bin/goman-registries
+`)
+
+	if len(config.Auth) > 0 {
+		fmt.Fprintf(&b, `cat > /etc/systemd/system/goman-registries.service <<'UNIT'
+[Unit]
+Description=Refresh registry credentials of K3s
+Wants=network-online.target
+After=network-online.target
+Before=k3s.service k3s-agent.service
+
+[Service]
+Type=oneshot
+EnvironmentFile=-/etc/goman-proxy.env
+ExecStart=/usr/local/bin/goman-registries
+
+[Install]
+WantedBy=multi-user.target
+UNIT
+cat > /etc/systemd/system/goman-registries.timer <<'UNIT'
+[Unit]
+Description=Refresh registry credentials of K3s every %[1]s
+
+[Timer]
+OnActiveSec=%[1]s
+OnUnitActiveSec=%[1]s
+RandomizedDelaySec=30m
+
+[Install]
+WantedBy=timers.target
+UNIT
+systemctl daemon-reload
+systemctl enable goman-registries.service
+systemctl enable --now goman-registries.timer
+`, registriesRefresh)
+	} else {
+		b.WriteString(`if [ -f /etc/systemd/system/goman-registries.timer ]; then
+    systemctl disable --now goman-registries.timer goman-registries.service || true
+    rm -f /etc/systemd/system/goman-registries.timer /etc/systemd/system/goman-registries.service
+    systemctl daemon-reload
+fi
+`)
+	}
+
+	b.WriteString(run)
+	return b.String()
+}
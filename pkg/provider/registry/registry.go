@@ -4,8 +4,11 @@ import (
 	"fmt"
 	"os"
 
+	"github.com/madhouselabs/goman/pkg/config"
 	"github.com/madhouselabs/goman/pkg/provider"
 	"github.com/madhouselabs/goman/pkg/provider/aws"
+	"github.com/madhouselabs/goman/pkg/provider/gcp"
+	"github.com/madhouselabs/goman/pkg/provider/local"
 )
 
 // GetProvider returns a provider instance based on type
@@ -15,8 +18,11 @@ func GetProvider(providerType, profile, region string) (provider.Provider, error
 		// Use cached provider to avoid repeated STS calls
 		return aws.GetCachedProvider(profile, region)
 	case "gcp":
-		// return gcp.NewProvider(profile, region)
-		return nil, fmt.Errorf("GCP provider not yet implemented")
+		// The profile names the project
+		return gcp.GetCachedProvider(profile, region)
+	case "local":
+		// VMs on this machine; the directory comes from GOMAN_LOCAL_DIR
+		return local.GetCachedProvider("")
 	case "azure":
 		// return azure.NewProvider(profile, region)
 		return nil, fmt.Errorf("Azure provider not yet implemented")
@@ -25,6 +31,18 @@ func GetProvider(providerType, profile, region string) (provider.Provider, error
 	}
 }
 
+// GetConfiguredProvider returns the provider goman is configured for
+// (GOMAN_PROVIDER), using the given AWS profile and region for AWS
+func GetConfiguredProvider(awsProfile, awsRegion string) (provider.Provider, error) {
+	if providerType := config.GetProviderType(); providerType != "aws" {
+		if providerType == "gcp" {
+			return GetProvider(providerType, config.GetGCPProject(), config.GetGCPRegion())
+		}
+		return GetProvider(providerType, "", "")
+	}
+	return GetProvider("aws", awsProfile, awsRegion)
+}
+
 // GetDefaultProvider returns the default provider based on environment
 func GetDefaultProvider() (provider.Provider, error) {
 	// Detect provider from environment
@@ -35,6 +53,9 @@ func GetDefaultProvider() (provider.Provider, error) {
 	if profile == "" && providerType == "aws" {
 		profile = os.Getenv("AWS_PROFILE")
 	}
+	if profile == "" && providerType == "gcp" {
+		profile = config.GetGCPProject()
+	}
 
 	// Get region
 	region := os.Getenv("CLOUD_REGION")
@@ -44,6 +65,9 @@ func GetDefaultProvider() (provider.Provider, error) {
 			region = "ap-south-1"
 		}
 	}
+	if region == "" && providerType == "gcp" {
+		region = config.GetGCPRegion()
+	}
 
 	return GetProvider(providerType, profile, region)
 }
@@ -51,8 +75,8 @@ func GetDefaultProvider() (provider.Provider, error) {
 // DetectProviderFromEnvironment detects the cloud provider from environment
 func DetectProviderFromEnvironment() string {
 	// Check explicit setting
-	if provider := os.Getenv("CLOUD_PROVIDER"); provider != "" {
-		return provider
+	if os.Getenv("GOMAN_PROVIDER") != "" || os.Getenv("CLOUD_PROVIDER") != "" {
+		return config.GetProviderType()
 	}
 
 	// Check for AWS
@@ -61,7 +85,7 @@ func DetectProviderFromEnvironment() string {
 	}
 
 	// Check for GCP
-	if os.Getenv("GOOGLE_APPLICATION_CREDENTIALS") != "" || os.Getenv("GCP_PROJECT") != "" {
+	if os.Getenv("GOOGLE_APPLICATION_CREDENTIALS") != "" || os.Getenv("GCP_PROJECT") != "" || os.Getenv("GOMAN_GCP_PROJECT") != "" {
 		return "gcp"
 	}
 
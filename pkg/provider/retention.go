@@ -0,0 +1,113 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// RetentionKey is the storage key of the retention settings. It lives
+// outside clusters/ so writing it does not trigger reconciles.
+const RetentionKey = "settings/retention.yaml"
+
+// Prefixes of the state bucket that retention applies to
+const (
+	DiagnosticsPrefix = "ssm-output/"   // Full output of remote commands
+	BinariesPrefix    = "binaries/k3s/" // K3s binaries, one folder per version
+)
+
+// RetentionConfig sets how many days artifacts are kept in the state bucket,
+// so it does not grow across many cluster lifetimes. Zero keeps them
+// forever. It is written by 'goman init' and read by the controller and
+// 'goman admin prune'.
+type RetentionConfig struct {
+	// DiagnosticsDays applies to the full output of remote commands
+	DiagnosticsDays int `json:"diagnosticsDays" yaml:"diagnosticsDays"`
+	// LogDays applies to the command history, session logs and the events
+	// of deleted clusters
+	LogDays int `json:"logDays" yaml:"logDays"`
+	// BinaryDays applies to K3s binaries of versions no cluster uses,
+	// counted from their upload
+	BinaryDays int `json:"binaryDays" yaml:"binaryDays"`
+}
+
+// DefaultRetention is used until 'goman init --retention' stores settings
+func DefaultRetention() RetentionConfig {
+	return RetentionConfig{DiagnosticsDays: 30, LogDays: 90, BinaryDays: 30}
+}
+
+// LoadRetention reads the stored retention settings. Missing settings mean
+// the defaults; unreadable ones keep everything, so nothing is pruned by
+// mistake.
+func LoadRetention(ctx context.Context, storageService StorageService) (RetentionConfig, error) {
+	data, err := storageService.GetObject(ctx, RetentionKey)
+	if errors.Is(err, ErrNotFound) {
+		return DefaultRetention(), nil
+	}
+	if err != nil {
+		return RetentionConfig{}, fmt.Errorf("failed to read retention settings: %w", err)
+	}
+	var cfg RetentionConfig
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return RetentionConfig{}, fmt.Errorf("invalid retention settings: %w", err)
+	}
+	return cfg, nil
+}
+
+// ParseRetention applies a comma-separated list such as
+// "diagnostics=14,logs=90,binaries=30" to cfg. "off" keeps everything
+// forever.
+func ParseRetention(cfg RetentionConfig, value string) (RetentionConfig, error) {
+	for _, item := range strings.Split(value, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		if strings.EqualFold(item, "off") {
+			cfg = RetentionConfig{}
+			continue
+		}
+		name, days, ok := strings.Cut(item, "=")
+		if !ok {
+			return cfg, fmt.Errorf("invalid retention %q (use diagnostics=<days>, logs=<days>, binaries=<days> or off)", item)
+		}
+		n, err := strconv.Atoi(strings.TrimSuffix(strings.TrimSpace(days), "d"))
+		if err != nil || n < 0 {
+			return cfg, fmt.Errorf("invalid retention %q: days must be a number of 0 or more", item)
+		}
+		switch strings.ToLower(strings.TrimSpace(name)) {
+		case "diagnostics":
+			cfg.DiagnosticsDays = n
+		case "logs":
+			cfg.LogDays = n
+		case "binaries":
+			cfg.BinaryDays = n
+		default:
+			return cfg, fmt.Errorf("unknown retention %q (use diagnostics, logs or binaries)", name)
+		}
+	}
+	return cfg, nil
+}
+
+// String describes the retention of each kind of artifact
+func (c RetentionConfig) String() string {
+	days := func(n int) string {
+		if n == 0 {
+			return "forever"
+		}
+		return fmt.Sprintf("%dd", n)
+	}
+	return fmt.Sprintf("diagnostics %s, logs %s, unused binaries %s", days(c.DiagnosticsDays), days(c.LogDays), days(c.BinaryDays))
+}
+
+// ObjectTimeLister is implemented by storage services that can tell when
+// objects were last written, so old ones can be pruned without reading them
+type ObjectTimeLister interface {
+	// ListObjectTimes returns the last write time of every object under prefix
+	ListObjectTimes(ctx context.Context, prefix string) (map[string]time.Time, error)
+}
@@ -0,0 +1,80 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// SecretService stores cluster secrets: K3s join tokens and kubeconfigs.
+// Secrets are addressed by cluster and name so backends can scope access
+// per cluster, the same way node identities are scoped.
+type SecretService interface {
+	PutSecret(ctx context.Context, clusterName, name string, value []byte) error
+	// GetSecret returns an error containing "not found" for missing secrets
+	GetSecret(ctx context.Context, clusterName, name string) ([]byte, error)
+	DeleteSecret(ctx context.Context, clusterName, name string) error
+
+	// Backend returns the backend in use, one of the SecretBackend* constants
+	Backend() string
+}
+
+// SecretSharer is implemented by secret services that can hand out a
+// time-limited download URL for a secret, usable without cloud credentials.
+// The URL stops working at expiresAt, earlier than ttl from now if the
+// credentials signing it expire first.
+type SecretSharer interface {
+	ShareSecret(ctx context.Context, clusterName, name string, ttl time.Duration) (url string, expiresAt time.Time, err error)
+}
+
+// MaxSecretShareTTL is the longest validity of a shared secret URL; S3
+// presigned URLs cannot outlive seven days
+const MaxSecretShareTTL = 7 * 24 * time.Hour
+
+// minSecretShareTTL is the shortest validity worth handing out a URL for
+const minSecretShareTTL = time.Minute
+
+// ShareLifetime returns how long a URL signed at now stays valid: ttl, cut
+// short to credentialsExpiry when the signing credentials expire first. A
+// zero credentialsExpiry means they do not expire.
+func ShareLifetime(now time.Time, ttl time.Duration, credentialsExpiry time.Time) (time.Duration, error) {
+	if credentialsExpiry.IsZero() || !credentialsExpiry.Before(now.Add(ttl)) {
+		return ttl, nil
+	}
+	left := credentialsExpiry.Sub(now)
+	if left < minSecretShareTTL {
+		return 0, fmt.Errorf("the credentials signing the URL expire at %s; refresh them and share again",
+			credentialsExpiry.Local().Format(time.RFC3339))
+	}
+	return left, nil
+}
+
+// Secret backends, selected with GOMAN_SECRET_BACKEND
+const (
+	SecretBackendStorage        = "s3"             // Object storage next to the cluster state (default)
+	SecretBackendSecretsManager = "secretsmanager" // AWS Secrets Manager
+	SecretBackendParameterStore = "ssm"            // SSM Parameter Store SecureString
+)
+
+// Names of the secrets goman keeps per cluster
+const (
+	SecretServerToken = "k3s-server-token"
+	SecretAgentToken  = "k3s-agent-token"
+	SecretNodeToken   = "k3s-node-token"
+	SecretKubeconfig  = "kubeconfig.yaml"
+
+	// SecretServerTokenNext holds the token a credential rotation moves the
+	// cluster to, until it is stored as the server and agent token
+	SecretServerTokenNext = "k3s-server-token-next"
+
+	// SecretKubeconfigReadOnly authenticates as a ServiceAccount bound to
+	// the view ClusterRole, with a token that expires
+	SecretKubeconfigReadOnly = "kubeconfig-readonly.yaml"
+)
+
+// ClusterSecrets lists the secrets removed with a cluster
+var ClusterSecrets = []string{SecretServerToken, SecretAgentToken, SecretNodeToken, SecretKubeconfig, SecretKubeconfigReadOnly, SecretServerTokenNext}
+
+// ErrSecretNotFound is returned by GetSecret for missing secrets. It
+// matches ErrNotFound.
+var ErrSecretNotFound = fmt.Errorf("secret %w", ErrNotFound)
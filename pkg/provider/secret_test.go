@@ -0,0 +1,31 @@
+package provider
+
+import (
+	"testing"
+	"time"
+)
+
+func TestShareLifetime(t *testing.T) {
+	now := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name    string
+		ttl     time.Duration
+		expiry  time.Time
+		want    time.Duration
+		wantErr bool
+	}{
+		{"long-lived credentials", 7 * 24 * time.Hour, time.Time{}, 7 * 24 * time.Hour, false},
+		{"credentials outlive the ttl", time.Hour, now.Add(2 * time.Hour), time.Hour, false},
+		{"credentials expire first", 24 * time.Hour, now.Add(45 * time.Minute), 45 * time.Minute, false},
+		{"credentials about to expire", time.Hour, now.Add(30 * time.Second), 0, true},
+		{"credentials expired", time.Hour, now.Add(-time.Minute), 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ShareLifetime(now, tt.ttl, tt.expiry)
+			if (err != nil) != tt.wantErr || got != tt.want {
+				t.Errorf("ShareLifetime(%s, %s) = %s, %v, want %s, error %v", tt.ttl, tt.expiry, got, err, tt.want, tt.wantErr)
+			}
+		})
+	}
+}
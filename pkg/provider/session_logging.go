@@ -0,0 +1,96 @@
+package provider
+
+import (
+	"fmt"
+	"strings"
+)
+
+// SessionLoggingKey is the storage key of the session logging settings.
+// It lives outside clusters/ so writing it does not trigger reconciles.
+const SessionLoggingKey = "settings/session-logging.yaml"
+
+// Default destinations for remote access logs
+const (
+	DefaultSessionLogPrefix      = "ssm-sessions"
+	DefaultSessionLogGroupPrefix = "/goman/ssm"
+)
+
+// SessionLoggingConfig controls compliance logging of remote access through
+// goman: remote command output and tunnel sessions. It is written once by
+// 'goman init' and read by the CLI and the controller.
+type SessionLoggingConfig struct {
+	// S3 stores full command output under <S3Prefix>/<cluster>/ in the goman bucket
+	S3 bool `json:"s3" yaml:"s3"`
+	// CloudWatch streams command output to the <LogGroupPrefix>/<cluster> log group
+	CloudWatch bool `json:"cloudWatch" yaml:"cloudWatch"`
+
+	S3Prefix       string `json:"s3Prefix,omitempty" yaml:"s3Prefix,omitempty"`
+	LogGroupPrefix string `json:"logGroupPrefix,omitempty" yaml:"logGroupPrefix,omitempty"`
+}
+
+// ParseSessionLogging parses a comma-separated destination list such as
+// "s3,cloudwatch". "off" or an empty string disables logging.
+func ParseSessionLogging(value string) (SessionLoggingConfig, error) {
+	var cfg SessionLoggingConfig
+	for _, dest := range strings.Split(value, ",") {
+		switch strings.ToLower(strings.TrimSpace(dest)) {
+		case "", "off", "none":
+		case "s3":
+			cfg.S3 = true
+		case "cloudwatch", "cw":
+			cfg.CloudWatch = true
+		default:
+			return cfg, fmt.Errorf("unknown session logging destination %q (use s3, cloudwatch or off)", dest)
+		}
+	}
+	return cfg, nil
+}
+
+// Enabled reports whether any destination is enabled
+func (c SessionLoggingConfig) Enabled() bool {
+	return c.S3 || c.CloudWatch
+}
+
+// ClusterS3Prefix returns the key prefix for a cluster's logs
+func (c SessionLoggingConfig) ClusterS3Prefix(cluster string) string {
+	prefix := strings.Trim(c.S3Prefix, "/")
+	if prefix == "" {
+		prefix = DefaultSessionLogPrefix
+	}
+	if cluster == "" {
+		return prefix
+	}
+	return prefix + "/" + cluster
+}
+
+// LogGroupBase returns the prefix shared by all cluster log groups
+func (c SessionLoggingConfig) LogGroupBase() string {
+	group := strings.TrimRight(c.LogGroupPrefix, "/")
+	if group == "" {
+		group = DefaultSessionLogGroupPrefix
+	}
+	return group
+}
+
+// ClusterLogGroup returns the CloudWatch log group for a cluster's logs
+func (c SessionLoggingConfig) ClusterLogGroup(cluster string) string {
+	if cluster == "" {
+		cluster = "unassigned"
+	}
+	return c.LogGroupBase() + "/" + cluster
+}
+
+// String describes the enabled destinations
+func (c SessionLoggingConfig) String() string {
+	var dests []string
+	if c.S3 {
+		dests = append(dests, "s3")
+	}
+	if c.CloudWatch {
+		dests = append(dests, "cloudwatch")
+	}
+	if len(dests) == 0 {
+		return "off"
+	}
+	return strings.Join(dests, ",")
+}
@@ -0,0 +1,23 @@
+package provider
+
+import (
+	"context"
+	"errors"
+)
+
+// VersionedStorage is implemented by storage services that can make writes
+// conditional on the stored version of an object, for optimistic concurrency
+type VersionedStorage interface {
+	// GetObjectVersion returns an object together with an opaque version
+	// that changes whenever the object is written
+	GetObjectVersion(ctx context.Context, key string) ([]byte, string, error)
+
+	// PutObjectIfMatch writes an object only if its stored version is still
+	// version, and returns the new version. An empty version only creates the
+	// object if it does not exist yet. Returns ErrPreconditionFailed otherwise.
+	PutObjectIfMatch(ctx context.Context, key string, data []byte, version string) (string, error)
+}
+
+// ErrPreconditionFailed is returned by conditional writes when the stored
+// object changed since it was read
+var ErrPreconditionFailed = errors.New("precondition failed")
@@ -0,0 +1,30 @@
+package storage
+
+import (
+	"fmt"
+	"strings"
+)
+
+// AliasPrefix is kept outside clusters/ so aliases don't trigger reconciles
+const AliasPrefix = "aliases/"
+
+// LegacyNamesAnnotation records the former names of a renamed cluster in
+// its config metadata, comma-separated. Cloud resources that cannot be
+// renamed, such as EC2 security group names, keep them.
+const LegacyNamesAnnotation = "goman.io/legacy-names"
+
+// AliasKey returns the storage key of the alias of a former cluster name
+func AliasKey(name string) string {
+	return fmt.Sprintf("%s%s.yaml", AliasPrefix, name)
+}
+
+// LegacyNames returns the former names recorded in a cluster's annotations
+func LegacyNames(annotations map[string]string) []string {
+	var names []string
+	for _, name := range strings.Split(annotations[LegacyNamesAnnotation], ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
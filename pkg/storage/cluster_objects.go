@@ -0,0 +1,47 @@
+package storage
+
+import (
+	"fmt"
+	"strings"
+)
+
+// A cluster is stored as two objects with one writer each, following the
+// Kubernetes spec/status contract:
+//
+//   - config.yaml holds the desired state. Only clients (CLI, TUI, API
+//     client) write it; the controller treats it as read-only.
+//   - status.yaml holds the observed state. Only the controller writes it;
+//     clients read it.
+//
+// As no object has two writers, spec edits and status updates never race.
+// The controller's deletion removes both objects once its finalizers are
+// done, and a rename moves them while holding the controller's locks.
+
+// ClusterConfigKey returns the storage key of a cluster's spec
+func ClusterConfigKey(clusterName string) string {
+	return fmt.Sprintf("clusters/%s/config.yaml", clusterName)
+}
+
+// ClusterStatusKey returns the storage key of a cluster's status
+func ClusterStatusKey(clusterName string) string {
+	return fmt.Sprintf("clusters/%s/status.yaml", clusterName)
+}
+
+// ClusterNameFromConfigKey returns the name of the cluster whose spec is
+// stored at key, and false for keys of other objects
+func ClusterNameFromConfigKey(key string) (string, bool) {
+	name, ok := strings.CutSuffix(strings.TrimPrefix(key, "clusters/"), "/config.yaml")
+	if !ok || name == "" || strings.Contains(name, "/") || ClusterConfigKey(name) != key {
+		return "", false
+	}
+	return name, true
+}
+
+// StatusBelongsTo reports whether a status recorded for statusClusterID is
+// the status of the cluster with clusterID. A status left by an earlier
+// cluster of the same name does not; clients ignore it and the controller
+// replaces it. Statuses written before the ID was recorded belong to any
+// cluster.
+func StatusBelongsTo(statusClusterID, clusterID string) bool {
+	return statusClusterID == "" || clusterID == "" || statusClusterID == clusterID
+}
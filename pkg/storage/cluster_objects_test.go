@@ -0,0 +1,25 @@
+package storage
+
+import "testing"
+
+func TestClusterNameFromConfigKey(t *testing.T) {
+	tests := []struct {
+		key  string
+		name string
+		ok   bool
+	}{
+		{ClusterConfigKey("prod"), "prod", true},
+		{ClusterStatusKey("prod"), "", false},
+		{"clusters/prod/events/1.yaml", "", false},
+		{"clusters/team/prod/config.yaml", "", false},
+		{"clusters//config.yaml", "", false},
+		{"aliases/prod/config.yaml", "", false},
+		{"prod/config.yaml", "", false},
+	}
+	for _, tt := range tests {
+		name, ok := ClusterNameFromConfigKey(tt.key)
+		if name != tt.name || ok != tt.ok {
+			t.Errorf("ClusterNameFromConfigKey(%q) = %q, %v, want %q, %v", tt.key, name, ok, tt.name, tt.ok)
+		}
+	}
+}
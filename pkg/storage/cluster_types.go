@@ -23,6 +23,7 @@ type ClusterMetadata struct {
 	Annotations        map[string]string `json:"annotations,omitempty" yaml:"annotations,omitempty"`
 	CreatedAt          time.Time         `json:"created_at" yaml:"createdAt"`
 	UpdatedAt          time.Time         `json:"updated_at" yaml:"updatedAt"`
+	Generation         int               `json:"generation,omitempty" yaml:"generation,omitempty"` // Bumped on every spec write
 	DeletionTimestamp  *time.Time        `json:"deletionTimestamp,omitempty" yaml:"deletionTimestamp,omitempty"`
 }
 
@@ -45,15 +46,78 @@ type ClusterSpec struct {
 	Tags           []string           `json:"tags,omitempty" yaml:"tags,omitempty"`
 	DesiredState   string             `json:"desired_state,omitempty" yaml:"desiredState,omitempty"` // "running" or "stopped"
 	NodePools      []NodePool         `json:"nodePools,omitempty" yaml:"nodePools,omitempty"`        // Worker node pools
+	Preset         string             `json:"preset,omitempty" yaml:"preset,omitempty"`              // Sizing preset, expanded by the controller
+	LowResource    bool               `json:"lowResource,omitempty" yaml:"lowResource,omitempty"`    // K3s tuning for very small instances
+	Architecture   string             `json:"architecture,omitempty" yaml:"architecture,omitempty"`  // CPU architecture of the masters
+	ImageID        string             `json:"imageID,omitempty" yaml:"imageID,omitempty"`            // Custom AMI of the nodes
+	OSFamily       string             `json:"osFamily,omitempty" yaml:"osFamily,omitempty"`          // OS family of the nodes' bootstrap variant
+	PrivateNetwork bool               `json:"privateNetwork,omitempty" yaml:"privateNetwork,omitempty"` // Nodes without public IPs
+	RootVolume     *models.RootVolume `json:"rootVolume,omitempty" yaml:"rootVolume,omitempty"`      // Root volume of the masters and of pools without their own
+
+	NodeReplacements []models.NodeReplacement `json:"nodeReplacements,omitempty" yaml:"nodeReplacements,omitempty"` // Worker nodes to replace
+
+	DriftPolicy  map[string]models.DriftPolicy `json:"driftPolicy,omitempty" yaml:"driftPolicy,omitempty"`   // Per-field drift handling
+	Rollout      *models.RolloutSpec           `json:"rollout,omitempty" yaml:"rollout,omitempty"`           // Rollout batching and pause
+	DNS          *models.DNSSpec               `json:"dns,omitempty" yaml:"dns,omitempty"`                   // CoreDNS customization
+	VirtualIP    *models.VirtualIPSpec         `json:"virtualIP,omitempty" yaml:"virtualIP,omitempty"`       // Floating API endpoint (HA only)
+	LoadBalancer *models.LoadBalancerSpec      `json:"loadBalancer,omitempty" yaml:"loadBalancer,omitempty"` // API load balancer (HA only)
+
+	InstanceProtection *models.InstanceProtectionSpec `json:"instanceProtection,omitempty" yaml:"instanceProtection,omitempty"` // Shutdown behavior and stop protection
+
+	ClusterLinks []models.ClusterLink `json:"clusterLinks,omitempty" yaml:"clusterLinks,omitempty"` // Private connectivity to other clusters
+
+	Addons []models.Addon `json:"addons,omitempty" yaml:"addons,omitempty"` // Helm charts and manifests installed into the cluster
+
+	Notifications *models.NotificationPolicy `json:"notifications,omitempty" yaml:"notifications,omitempty"` // Webhooks and SNS topics told about phase changes
+
+	Proxy *models.ProxySpec `json:"proxy,omitempty" yaml:"proxy,omitempty"` // Outbound HTTP(S) proxy of the nodes
+
+	Bootstrap *models.BootstrapSpec `json:"bootstrap,omitempty" yaml:"bootstrap,omitempty"` // Pre-install hook, extra packages and registry mirrors of new nodes
+
+	Registries *models.RegistrySpec `json:"registries,omitempty" yaml:"registries,omitempty"` // Registry mirrors and credentials of all nodes
+
+	VpcID            string   `json:"vpcID,omitempty" yaml:"vpcID,omitempty"`                       // VPC to launch in instead of the default one
+	SubnetIDs        []string `json:"subnetIDs,omitempty" yaml:"subnetIDs,omitempty"`               // Subnets of VpcID, one per zone used
+	SecurityGroupIDs []string `json:"securityGroupIDs,omitempty" yaml:"securityGroupIDs,omitempty"` // Security groups added to the cluster's own
+	DedicatedVPC     bool     `json:"dedicatedVPC,omitempty" yaml:"dedicatedVPC,omitempty"`         // A VPC created for the cluster alone
+	VpcCIDR          string   `json:"vpcCIDR,omitempty" yaml:"vpcCIDR,omitempty"`                   // Address range of the dedicated VPC
+
+	Schedule          *models.ScheduleSpec `json:"schedule,omitempty" yaml:"schedule,omitempty"`                   // Times at which the cluster is stopped and started
+	DesiredStateSetAt *time.Time           `json:"desiredStateSetAt,omitempty" yaml:"desiredStateSetAt,omitempty"` // Last stop or start by hand
+
+	RetagRequestedAt *time.Time `json:"retagRequestedAt,omitempty" yaml:"retagRequestedAt,omitempty"` // Re-apply tags to all resources
+
+	CertRotationRequestedAt *time.Time `json:"certRotationRequestedAt,omitempty" yaml:"certRotationRequestedAt,omitempty"` // Rotate the K3s certificates
+
+	CredentialRotationRequestedAt *time.Time `json:"credentialRotationRequestedAt,omitempty" yaml:"credentialRotationRequestedAt,omitempty"` // Rotate the token, client certificates and kubeconfigs
+
+	QuorumRecovery *models.QuorumRecoveryRequest `json:"quorumRecovery,omitempty" yaml:"quorumRecovery,omitempty"` // Rebuild the control plane after quorum loss
+
+	Restore *models.SnapshotRestoreRequest `json:"restore,omitempty" yaml:"restore,omitempty"` // Restore from a snapshot of another cluster at creation
+
+	ResyncRequestedAt *time.Time `json:"resyncRequestedAt,omitempty" yaml:"resyncRequestedAt,omitempty"` // Rebuild the status from the live state
+
+	DriftFixRequestedAt *time.Time `json:"driftFixRequestedAt,omitempty" yaml:"driftFixRequestedAt,omitempty"` // Revert all drift once
 }
 
 // NodePool defines a group of worker nodes with similar configuration
 type NodePool struct {
-	Name         string            `json:"name" yaml:"name"`
-	Count        int               `json:"count" yaml:"count"`
-	InstanceType string            `json:"instanceType" yaml:"instanceType"`
-	Labels       map[string]string `json:"labels,omitempty" yaml:"labels,omitempty"`
-	Taints       []Taint           `json:"taints,omitempty" yaml:"taints,omitempty"`
+	Name          string              `json:"name" yaml:"name"`
+	Count         int                 `json:"count" yaml:"count"`
+	InstanceType  string              `json:"instanceType" yaml:"instanceType"`
+	Labels        map[string]string   `json:"labels,omitempty" yaml:"labels,omitempty"`
+	Taints        []Taint             `json:"taints,omitempty" yaml:"taints,omitempty"`
+	Volumes       []models.DataVolume `json:"volumes,omitempty" yaml:"volumes,omitempty"`
+	InstanceStore bool                `json:"instanceStore,omitempty" yaml:"instanceStore,omitempty"`
+	Paused        bool                `json:"paused,omitempty" yaml:"paused,omitempty"`
+	RootVolume    *models.RootVolume  `json:"rootVolume,omitempty" yaml:"rootVolume,omitempty"`
+	UserData      string              `json:"userData,omitempty" yaml:"userData,omitempty"`
+	Architecture  string              `json:"architecture,omitempty" yaml:"architecture,omitempty"`
+	ImageID       string              `json:"imageID,omitempty" yaml:"imageID,omitempty"`
+	OSFamily      string              `json:"osFamily,omitempty" yaml:"osFamily,omitempty"`
+
+	ScaleDownBehavior string   `json:"scaleDownBehavior,omitempty" yaml:"scaleDownBehavior,omitempty"`
+	AvailabilityZones []string `json:"availabilityZones,omitempty" yaml:"availabilityZones,omitempty"`
 }
 
 // Taint represents a Kubernetes taint on nodes
@@ -79,6 +143,13 @@ type ClusterStatus struct {
 	APIEndpoint   string                 `json:"api_endpoint,omitempty" yaml:"apiEndpoint,omitempty"`
 	ClusterToken  string                 `json:"cluster_token,omitempty" yaml:"clusterToken,omitempty"`
 	Metadata      map[string]interface{} `json:"metadata,omitempty" yaml:"metadata,omitempty"`
+	Drift         []models.DriftStatus   `json:"drift,omitempty" yaml:"drift,omitempty"`
+	Rollout       *models.RolloutStatus  `json:"rollout,omitempty" yaml:"rollout,omitempty"`
+	EstimatedCompletion *time.Time       `json:"estimated_completion,omitempty" yaml:"estimatedCompletion,omitempty"`
+	ObservedGeneration  int              `json:"observed_generation,omitempty" yaml:"observedGeneration,omitempty"`
+	ClusterLinks        []models.ClusterLinkStatus `json:"cluster_links,omitempty" yaml:"clusterLinks,omitempty"`
+	Addons              []models.AddonStatus       `json:"addons,omitempty" yaml:"addons,omitempty"`
+	Certificates        *models.CertificateStatus  `json:"certificates,omitempty" yaml:"certificates,omitempty"`
 }
 
 // InstanceInfo contains EC2 instance information
@@ -127,6 +198,18 @@ func convertNodePoolsToStorage(nodePools []models.NodePool) []NodePool {
 			Count:        np.Count,
 			InstanceType: np.InstanceType,
 			Labels:       np.Labels,
+			Volumes:      np.Volumes,
+
+			InstanceStore: np.InstanceStore,
+			Paused:        np.Paused,
+			RootVolume:    np.RootVolume,
+			UserData:      np.UserData,
+			Architecture:  np.Architecture,
+			ImageID:       np.ImageID,
+			OSFamily:      np.OSFamily,
+
+			ScaleDownBehavior: np.ScaleDownBehavior,
+			AvailabilityZones: np.AvailabilityZones,
 		}
 		
 		// Convert taints
@@ -158,6 +241,18 @@ func convertNodePoolsFromStorage(nodePools []NodePool) []models.NodePool {
 			Count:        np.Count,
 			InstanceType: np.InstanceType,
 			Labels:       np.Labels,
+			Volumes:      np.Volumes,
+
+			InstanceStore: np.InstanceStore,
+			Paused:        np.Paused,
+			RootVolume:    np.RootVolume,
+			UserData:      np.UserData,
+			Architecture:  np.Architecture,
+			ImageID:       np.ImageID,
+			OSFamily:      np.OSFamily,
+
+			ScaleDownBehavior: np.ScaleDownBehavior,
+			AvailabilityZones: np.AvailabilityZones,
 		}
 		
 		// Convert taints
@@ -182,10 +277,11 @@ func ConvertToClusterConfig(cluster models.K3sCluster) *ClusterConfig {
 		APIVersion: "goman.io/v1",
 		Kind:       "K3sCluster",
 		Metadata: ClusterMetadata{
-			Name:      cluster.Name,
-			ID:        cluster.ID,
-			CreatedAt: cluster.CreatedAt,
-			UpdatedAt: cluster.UpdatedAt,
+			Name:        cluster.Name,
+			ID:          cluster.ID,
+			CreatedAt:   cluster.CreatedAt,
+			UpdatedAt:   cluster.UpdatedAt,
+			Annotations: cluster.Annotations,
 			Labels: map[string]string{
 				"mode":   string(cluster.Mode),
 				"region": cluster.Region,
@@ -209,6 +305,43 @@ func ConvertToClusterConfig(cluster models.K3sCluster) *ClusterConfig {
 			Tags:           cluster.Tags,
 			DesiredState:   determineDesiredState(cluster),
 			NodePools:      convertNodePoolsToStorage(cluster.NodePools),
+			Preset:         cluster.Preset,
+			LowResource:    cluster.LowResource,
+			Architecture:   cluster.Architecture,
+			ImageID:        cluster.ImageID,
+			OSFamily:       cluster.OSFamily,
+			PrivateNetwork: cluster.PrivateNetwork,
+			RootVolume:     cluster.RootVolume,
+			NodeReplacements: cluster.NodeReplacements,
+			DriftPolicy:      cluster.DriftPolicy,
+			Rollout:          cluster.Rollout,
+			DNS:              cluster.DNS,
+			VirtualIP:        cluster.VirtualIP,
+			LoadBalancer:     cluster.LoadBalancer,
+			RetagRequestedAt: cluster.RetagRequestedAt,
+
+			CertRotationRequestedAt:       cluster.CertRotationRequestedAt,
+			CredentialRotationRequestedAt: cluster.CredentialRotationRequestedAt,
+			QuorumRecovery:                cluster.QuorumRecovery,
+			Restore:                       cluster.Restore,
+			ResyncRequestedAt:             cluster.ResyncRequestedAt,
+			DriftFixRequestedAt:           cluster.DriftFixRequestedAt,
+
+			InstanceProtection: cluster.InstanceProtection,
+			ClusterLinks:       cluster.ClusterLinks,
+			Addons:             cluster.Addons,
+			Notifications:      cluster.Notifications,
+			Proxy:              cluster.Proxy,
+			Bootstrap:          cluster.Bootstrap,
+			Registries:         cluster.Registries,
+			Schedule:           cluster.Schedule,
+			DesiredStateSetAt:  cluster.DesiredStateSetAt,
+
+			VpcID:            cluster.VpcID,
+			SubnetIDs:        cluster.SubnetIDs,
+			SecurityGroupIDs: cluster.SecurityGroupIDs,
+			DedicatedVPC:     cluster.DedicatedVPC,
+			VpcCIDR:          cluster.VpcCIDR,
 		},
 	}
 }
@@ -236,6 +369,56 @@ func ConvertFromClusterConfig(config *ClusterConfig, status *ClusterStatus) mode
 		CreatedAt:      config.Metadata.CreatedAt,
 		UpdatedAt:      config.Metadata.UpdatedAt,
 		NodePools:      convertNodePoolsFromStorage(config.Spec.NodePools),
+		Preset:         config.Spec.Preset,
+		LowResource:    config.Spec.LowResource,
+		Architecture:   config.Spec.Architecture,
+		ImageID:        config.Spec.ImageID,
+		OSFamily:       config.Spec.OSFamily,
+		PrivateNetwork: config.Spec.PrivateNetwork,
+		RootVolume:     config.Spec.RootVolume,
+		NodeReplacements: config.Spec.NodeReplacements,
+		DriftPolicy:      config.Spec.DriftPolicy,
+		Rollout:          config.Spec.Rollout,
+		DNS:              config.Spec.DNS,
+		VirtualIP:        config.Spec.VirtualIP,
+		LoadBalancer:     config.Spec.LoadBalancer,
+		RetagRequestedAt: config.Spec.RetagRequestedAt,
+
+		CertRotationRequestedAt:       config.Spec.CertRotationRequestedAt,
+		CredentialRotationRequestedAt: config.Spec.CredentialRotationRequestedAt,
+		QuorumRecovery:                config.Spec.QuorumRecovery,
+		Restore:                       config.Spec.Restore,
+		ResyncRequestedAt:             config.Spec.ResyncRequestedAt,
+		DriftFixRequestedAt:           config.Spec.DriftFixRequestedAt,
+
+		InstanceProtection: config.Spec.InstanceProtection,
+		ClusterLinks:       config.Spec.ClusterLinks,
+		Addons:             config.Spec.Addons,
+		Notifications:      config.Spec.Notifications,
+		Proxy:              config.Spec.Proxy,
+		Bootstrap:          config.Spec.Bootstrap,
+		Registries:         config.Spec.Registries,
+		Schedule:           config.Spec.Schedule,
+		DesiredStateSetAt:  config.Spec.DesiredStateSetAt,
+
+		VpcID:            config.Spec.VpcID,
+		SubnetIDs:        config.Spec.SubnetIDs,
+		SecurityGroupIDs: config.Spec.SecurityGroupIDs,
+		DedicatedVPC:     config.Spec.DedicatedVPC,
+		VpcCIDR:          config.Spec.VpcCIDR,
+
+		Generation:  config.Metadata.Generation,
+		Annotations: config.Metadata.Annotations,
+	}
+
+	if status != nil {
+		cluster.Drift = status.Drift
+		cluster.RolloutStatus = status.Rollout
+		cluster.EstimatedCompletion = status.EstimatedCompletion
+		cluster.ObservedGeneration = status.ObservedGeneration
+		cluster.ClusterLinkStatus = status.ClusterLinks
+		cluster.AddonStatus = status.Addons
+		cluster.CertificatesExpireAt = status.Certificates.EarliestExpiry()
 	}
 
 	// Check if cluster is marked for deletion
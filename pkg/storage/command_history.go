@@ -0,0 +1,41 @@
+package storage
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// CommandHistoryPrefix is kept outside clusters/ so records don't trigger
+// reconciles
+const CommandHistoryPrefix = "commands/"
+
+// MaxCommandOutput is how much of a command's output is kept; the rest is
+// cut off
+const MaxCommandOutput = 16 * 1024
+
+// CommandRecord is a command the controller ran on the nodes of a cluster,
+// e.g. through SSM. The output is stored next to the record, at OutputKey.
+type CommandRecord struct {
+	Time            time.Time     `json:"time" yaml:"time"`
+	Cluster         string        `json:"cluster" yaml:"cluster"`
+	Purpose         string        `json:"purpose" yaml:"purpose"`                         // What the command was for, e.g. drain-node
+	CommandID       string        `json:"commandId,omitempty" yaml:"commandId,omitempty"` // Cloud command ID, for looking it up in the console
+	Instances       []string      `json:"instances" yaml:"instances"`
+	Status          string        `json:"status" yaml:"status"`
+	Duration        time.Duration `json:"duration" yaml:"duration"`
+	Error           string        `json:"error,omitempty" yaml:"error,omitempty"`
+	OutputKey       string        `json:"outputKey,omitempty" yaml:"outputKey,omitempty"` // Empty when the output was not kept
+	OutputTruncated bool          `json:"outputTruncated,omitempty" yaml:"outputTruncated,omitempty"`
+}
+
+// CommandRecordKey returns the storage key of a command record. The
+// timestamp comes first so keys sort chronologically within a cluster.
+func CommandRecordKey(clusterName string, t time.Time, purpose string) string {
+	return fmt.Sprintf("%s%s/%s-%s.yaml", CommandHistoryPrefix, clusterName, t.UTC().Format("20060102T150405.000000000Z"), purpose)
+}
+
+// CommandOutputKey returns the storage key of a record's output
+func CommandOutputKey(recordKey string) string {
+	return strings.TrimSuffix(recordKey, ".yaml") + ".log"
+}
@@ -0,0 +1,86 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/madhouselabs/goman/pkg/provider"
+)
+
+// objectUpdateRetries bounds the attempts of a conditional update racing
+// other writers
+const objectUpdateRetries = 5
+
+// ErrUpdateConflict is returned by UpdateObject when the object kept
+// changing between reading and writing it
+var ErrUpdateConflict = errors.New("object kept changing")
+
+// UpdateObject performs a read-modify-write of an object. mutate receives the
+// stored data, nil if the object does not exist, and returns the data to
+// write, or nil to leave the object alone. With versioned storage the write
+// only succeeds if nobody wrote the object since it was read; otherwise
+// mutate runs again on the newer data. Storage without versions falls back to
+// last writer wins.
+func UpdateObject(ctx context.Context, svc provider.StorageService, key string, mutate func(data []byte) ([]byte, error)) error {
+	vs, versioned := svc.(provider.VersionedStorage)
+
+	for attempt := 0; attempt < objectUpdateRetries; attempt++ {
+		var data []byte
+		var version string
+		var err error
+		if versioned {
+			data, version, err = vs.GetObjectVersion(ctx, key)
+		} else {
+			data, err = svc.GetObject(ctx, key)
+		}
+		if errors.Is(err, provider.ErrNotFound) {
+			data, version = nil, ""
+		} else if err != nil {
+			return fmt.Errorf("failed to load %s: %w", key, err)
+		}
+
+		out, err := mutate(data)
+		if err != nil {
+			return err
+		}
+		if out == nil {
+			return nil
+		}
+
+		if !versioned {
+			if err := svc.PutObject(ctx, key, out); err != nil {
+				return fmt.Errorf("failed to save %s: %w", key, err)
+			}
+			return nil
+		}
+		_, err = vs.PutObjectIfMatch(ctx, key, out, version)
+		if errors.Is(err, provider.ErrPreconditionFailed) {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("failed to save %s: %w", key, err)
+		}
+		return nil
+	}
+	return fmt.Errorf("%s: %w, giving up after %d attempts", key, ErrUpdateConflict, objectUpdateRetries)
+}
+
+// UpdateObject performs a conditional read-modify-write of an object of the
+// backend, see UpdateObject. Backends not built on a provider's storage
+// service fall back to last writer wins.
+func (s *Storage) UpdateObject(key string, mutate func(data []byte) ([]byte, error)) error {
+	if pb, ok := s.backend.(*ProviderBackend); ok {
+		return UpdateObject(context.Background(), pb.storageService, pb.getKey(key), mutate)
+	}
+
+	data, err := s.backend.GetObject(key)
+	if err != nil {
+		data = nil
+	}
+	out, err := mutate(data)
+	if err != nil || out == nil {
+		return err
+	}
+	return s.backend.PutObject(key, out)
+}
@@ -0,0 +1,122 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/madhouselabs/goman/pkg/provider"
+)
+
+// memStorage is a storage service without versions
+type memStorage struct {
+	objects map[string][]byte
+}
+
+func (s *memStorage) Initialize(ctx context.Context) error { return nil }
+
+func (s *memStorage) PutObject(ctx context.Context, key string, data []byte) error {
+	s.objects[key] = data
+	return nil
+}
+
+func (s *memStorage) GetObject(ctx context.Context, key string) ([]byte, error) {
+	data, ok := s.objects[key]
+	if !ok {
+		return nil, provider.ErrNotFound
+	}
+	return data, nil
+}
+
+func (s *memStorage) DeleteObject(ctx context.Context, key string) error {
+	delete(s.objects, key)
+	return nil
+}
+
+func (s *memStorage) ListObjects(ctx context.Context, prefix string) ([]string, error) {
+	return nil, nil
+}
+
+// versionedMemStorage lets another writer append to an object between the
+// read and the write of the next races updates
+type versionedMemStorage struct {
+	memStorage
+	versions map[string]int
+	races    int
+}
+
+func (s *versionedMemStorage) GetObjectVersion(ctx context.Context, key string) ([]byte, string, error) {
+	data, ok := s.objects[key]
+	if !ok {
+		return nil, "", provider.ErrNotFound
+	}
+	version := fmt.Sprint(s.versions[key])
+	if s.races > 0 {
+		s.races--
+		s.objects[key] = append(append([]byte{}, data...), "+other"...)
+		s.versions[key]++
+	}
+	return data, version, nil
+}
+
+func (s *versionedMemStorage) PutObjectIfMatch(ctx context.Context, key string, data []byte, version string) (string, error) {
+	if _, ok := s.objects[key]; ok && version != fmt.Sprint(s.versions[key]) || !ok && version != "" {
+		return "", provider.ErrPreconditionFailed
+	}
+	s.objects[key] = data
+	s.versions[key]++
+	return fmt.Sprint(s.versions[key]), nil
+}
+
+func TestUpdateObject(t *testing.T) {
+	const key = "clusters/demo/config.yaml"
+	tests := []struct {
+		name      string
+		versioned bool
+		stored    string // Empty if the object does not exist
+		races     int
+		skip      bool // mutate leaves the object alone
+		want      string
+		wantCalls int
+		wantErr   error
+	}{
+		{name: "unversioned", stored: "a", want: "a+ours", wantCalls: 1},
+		{name: "versioned", versioned: true, stored: "a", want: "a+ours", wantCalls: 1},
+		{name: "created", versioned: true, want: "+ours", wantCalls: 1},
+		{name: "retried on newer data", versioned: true, stored: "a", races: 1, want: "a+other+ours", wantCalls: 2},
+		{name: "gives up", versioned: true, stored: "a", races: objectUpdateRetries, want: "a" + strings.Repeat("+other", objectUpdateRetries), wantCalls: objectUpdateRetries, wantErr: ErrUpdateConflict},
+		{name: "left alone", versioned: true, stored: "a", skip: true, want: "a", wantCalls: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mem := memStorage{objects: map[string][]byte{}}
+			if tt.stored != "" {
+				mem.objects[key] = []byte(tt.stored)
+			}
+			var svc provider.StorageService = &mem
+			if tt.versioned {
+				svc = &versionedMemStorage{memStorage: mem, versions: map[string]int{}, races: tt.races}
+			}
+
+			calls := 0
+			err := UpdateObject(context.Background(), svc, key, func(data []byte) ([]byte, error) {
+				calls++
+				if tt.skip {
+					return nil, nil
+				}
+				return append(append([]byte{}, data...), "+ours"...), nil
+			})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if calls != tt.wantCalls {
+				t.Errorf("mutate ran %d times, want %d", calls, tt.wantCalls)
+			}
+			if got := string(mem.objects[key]); got != tt.want {
+				t.Errorf("stored %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
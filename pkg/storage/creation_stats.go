@@ -0,0 +1,127 @@
+package storage
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+)
+
+// CreationStatsPrefix holds one record per cluster creation. Kept outside
+// clusters/ so records don't trigger reconciles.
+const CreationStatsPrefix = "stats/creations/"
+
+// creationKeyTime is the timestamp layout at the start of creation record
+// keys
+const creationKeyTime = "20060102T150405Z"
+
+// CreationRecord is how long a new cluster took from being created to
+// Running
+type CreationRecord struct {
+	Cluster      string        `json:"cluster" yaml:"cluster"`
+	Mode         string        `json:"mode" yaml:"mode"`
+	Region       string        `json:"region" yaml:"region"`
+	InstanceType string        `json:"instanceType" yaml:"instanceType"`
+	CreatedAt    time.Time     `json:"createdAt" yaml:"createdAt"`
+	RunningAt    time.Time     `json:"runningAt" yaml:"runningAt"`
+	Duration     time.Duration `json:"duration" yaml:"duration"`
+	SLO          time.Duration `json:"slo,omitempty" yaml:"slo,omitempty"` // Objective when it was recorded, 0 for none
+}
+
+// WithinSLO reports whether the creation met the objective it was recorded
+// with
+func (r CreationRecord) WithinSLO() bool {
+	return r.SLO == 0 || r.Duration <= r.SLO
+}
+
+// CreationRecordKey returns the storage key of a creation record. The time
+// the cluster became Running comes first so keys sort chronologically.
+func CreationRecordKey(r CreationRecord) string {
+	return fmt.Sprintf("%s%s-%s.yaml", CreationStatsPrefix, r.RunningAt.UTC().Format(creationKeyTime), r.Cluster)
+}
+
+// CreationRecordTime returns the time a creation record key was written
+// for, so old records can be skipped without reading them
+func CreationRecordTime(key string) (time.Time, bool) {
+	name := strings.TrimPrefix(key, CreationStatsPrefix)
+	if len(name) < len(creationKeyTime) {
+		return time.Time{}, false
+	}
+	t, err := time.Parse(creationKeyTime, name[:len(creationKeyTime)])
+	return t, err == nil
+}
+
+// CreationSummary aggregates the creation times of clusters of one mode in
+// one region; the overall summary has Mode and Region "all"
+type CreationSummary struct {
+	Mode      string        `json:"mode"`
+	Region    string        `json:"region"`
+	Count     int           `json:"count"`
+	WithinSLO int           `json:"withinSLO"`
+	Mean      time.Duration `json:"mean"`
+	P50       time.Duration `json:"p50"`
+	P90       time.Duration `json:"p90"`
+	P95       time.Duration `json:"p95"`
+	Max       time.Duration `json:"max"`
+}
+
+// SummarizeCreations groups records by mode and region, sorted, followed by
+// the summary over all records. No records give no summaries.
+func SummarizeCreations(records []CreationRecord) []CreationSummary {
+	if len(records) == 0 {
+		return nil
+	}
+	groups := make(map[[2]string][]CreationRecord)
+	for _, r := range records {
+		key := [2]string{r.Mode, r.Region}
+		groups[key] = append(groups[key], r)
+	}
+	keys := make([][2]string, 0, len(groups))
+	for key := range groups {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i][0] != keys[j][0] {
+			return keys[i][0] < keys[j][0]
+		}
+		return keys[i][1] < keys[j][1]
+	})
+
+	summaries := make([]CreationSummary, 0, len(keys)+1)
+	for _, key := range keys {
+		summaries = append(summaries, summarize(key[0], key[1], groups[key]))
+	}
+	return append(summaries, summarize("all", "all", records))
+}
+
+// summarize aggregates one group of records
+func summarize(mode, region string, records []CreationRecord) CreationSummary {
+	s := CreationSummary{Mode: mode, Region: region, Count: len(records)}
+	durations := make([]time.Duration, 0, len(records))
+	var total time.Duration
+	for _, r := range records {
+		durations = append(durations, r.Duration)
+		total += r.Duration
+		if r.WithinSLO() {
+			s.WithinSLO++
+		}
+	}
+	sort.Slice(durations, func(i, j int) bool { return durations[i] < durations[j] })
+
+	s.Mean = total / time.Duration(len(durations))
+	s.P50 = percentile(durations, 50)
+	s.P90 = percentile(durations, 90)
+	s.P95 = percentile(durations, 95)
+	s.Max = durations[len(durations)-1]
+	return s
+}
+
+// percentile returns the nearest-rank percentile of sorted durations
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
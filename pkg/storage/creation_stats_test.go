@@ -0,0 +1,43 @@
+package storage
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSummarizeCreations(t *testing.T) {
+	var records []CreationRecord
+	for i := 1; i <= 10; i++ {
+		records = append(records, CreationRecord{Mode: "dev", Region: "ap-south-1", Duration: time.Duration(i) * time.Minute, SLO: 8 * time.Minute})
+	}
+	records = append(records, CreationRecord{Mode: "ha", Region: "ap-south-1", Duration: 20 * time.Minute})
+
+	summaries := SummarizeCreations(records)
+	if len(summaries) != 3 {
+		t.Fatalf("got %d summaries, want dev, ha and all", len(summaries))
+	}
+	dev := summaries[0]
+	if dev.Mode != "dev" || dev.Count != 10 || dev.WithinSLO != 8 {
+		t.Errorf("dev summary = %+v", dev)
+	}
+	if dev.P50 != 5*time.Minute || dev.P90 != 9*time.Minute || dev.P95 != 10*time.Minute || dev.Max != 10*time.Minute {
+		t.Errorf("dev percentiles = %s/%s/%s max %s", dev.P50, dev.P90, dev.P95, dev.Max)
+	}
+	if all := summaries[2]; all.Mode != "all" || all.Count != 11 || all.Max != 20*time.Minute {
+		t.Errorf("overall summary = %+v", all)
+	}
+	if SummarizeCreations(nil) != nil {
+		t.Error("summaries without records")
+	}
+}
+
+func TestCreationRecordTime(t *testing.T) {
+	at := time.Date(2026, 10, 1, 12, 30, 0, 0, time.UTC)
+	key := CreationRecordKey(CreationRecord{Cluster: "prod", RunningAt: at})
+	if got, ok := CreationRecordTime(key); !ok || !got.Equal(at) {
+		t.Errorf("CreationRecordTime(%q) = %s, %v", key, got, ok)
+	}
+	if _, ok := CreationRecordTime(CreationStatsPrefix + "junk.yaml"); ok {
+		t.Error("parsed a time from a malformed key")
+	}
+}
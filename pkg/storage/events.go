@@ -0,0 +1,42 @@
+package storage
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/madhouselabs/goman/pkg/models"
+)
+
+// EventsPrefix holds one object per cluster event. Kept outside clusters/
+// so recording events doesn't trigger reconciles.
+const EventsPrefix = "events/"
+
+// MaxClusterEvents is how many events are kept per cluster; older ones are
+// pruned
+const MaxClusterEvents = 500
+
+// eventKeyTime is the timestamp layout at the start of event keys
+const eventKeyTime = "20060102T150405.000000000Z"
+
+// ClusterEventsPrefix returns the key prefix of a cluster's events
+func ClusterEventsPrefix(clusterName string) string {
+	return EventsPrefix + clusterName + "/"
+}
+
+// EventKey returns the storage key of an event. The timestamp comes first
+// so keys sort chronologically within a cluster.
+func EventKey(clusterName string, e models.Event) string {
+	return fmt.Sprintf("%s%s-%s.yaml", ClusterEventsPrefix(clusterName), e.Timestamp.UTC().Format(eventKeyTime), e.Reason)
+}
+
+// EventTime returns the time an event key was written for, so old events
+// can be skipped without reading them
+func EventTime(key string) (time.Time, bool) {
+	name := key[strings.LastIndex(key, "/")+1:]
+	if len(name) < len(eventKeyTime) {
+		return time.Time{}, false
+	}
+	t, err := time.Parse(eventKeyTime, name[:len(eventKeyTime)])
+	return t, err == nil
+}
@@ -0,0 +1,28 @@
+package storage
+
+import (
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/madhouselabs/goman/pkg/models"
+)
+
+func TestEventKeys(t *testing.T) {
+	at := time.Date(2026, 10, 1, 12, 30, 0, 5, time.UTC)
+	key := EventKey("prod", models.Event{Reason: "PhaseChanged", Timestamp: at})
+	if got, ok := EventTime(key); !ok || !got.Equal(at) {
+		t.Errorf("EventTime(%q) = %s, %v", key, got, ok)
+	}
+	if _, ok := EventTime(ClusterEventsPrefix("prod") + "junk.yaml"); ok {
+		t.Error("EventTime accepted a key without a timestamp")
+	}
+
+	// Keys sort chronologically, also across reasons
+	later := EventKey("prod", models.Event{Reason: "Deleted", Timestamp: at.Add(time.Nanosecond)})
+	keys := []string{later, key}
+	sort.Strings(keys)
+	if keys[0] != key {
+		t.Errorf("keys sorted as %v, want the older first", keys)
+	}
+}
@@ -0,0 +1,75 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/madhouselabs/goman/pkg/models"
+	"github.com/madhouselabs/goman/pkg/provider"
+	"gopkg.in/yaml.v3"
+)
+
+// IntentQueuePrefix is kept outside clusters/ so queue updates don't trigger
+// reconciles
+const IntentQueuePrefix = "intents/"
+
+// IntentQueueKey returns the storage key of a cluster's intent queue
+func IntentQueueKey(clusterName string) string {
+	return fmt.Sprintf("%s%s.yaml", IntentQueuePrefix, clusterName)
+}
+
+// UpdateIntentQueue performs a read-modify-write of a cluster's intent
+// queue. With versioned storage the write is conditional and retried, so a
+// CLI enqueueing while the controller takes intents loses neither change.
+func UpdateIntentQueue(ctx context.Context, svc provider.StorageService, clusterName string, mutate func(q *models.IntentQueue)) (*models.IntentQueue, error) {
+	var queue *models.IntentQueue
+	err := UpdateObject(ctx, svc, IntentQueueKey(clusterName), func(data []byte) ([]byte, error) {
+		queue = &models.IntentQueue{}
+		if data != nil {
+			if err := yaml.Unmarshal(data, queue); err != nil {
+				return nil, fmt.Errorf("failed to parse intent queue of %s: %w", clusterName, err)
+			}
+		}
+		queue.Cluster = clusterName
+		mutate(queue)
+
+		out, err := yaml.Marshal(queue)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal intent queue of %s: %w", clusterName, err)
+		}
+		return out, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return queue, nil
+}
+
+// LoadIntentQueue reads a cluster's intent queue. A missing queue is empty.
+func LoadIntentQueue(ctx context.Context, svc provider.StorageService, clusterName string) (*models.IntentQueue, error) {
+	queue := &models.IntentQueue{Cluster: clusterName}
+	data, err := svc.GetObject(ctx, IntentQueueKey(clusterName))
+	if errors.Is(err, provider.ErrNotFound) {
+		return queue, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load intent queue of %s: %w", clusterName, err)
+	}
+	if err := yaml.Unmarshal(data, queue); err != nil {
+		return nil, fmt.Errorf("failed to parse intent queue of %s: %w", clusterName, err)
+	}
+	return queue, nil
+}
+
+// EnqueueIntent queues a spec change of a cluster for the controller
+func (s *Storage) EnqueueIntent(clusterName string, intent models.SpecIntent) error {
+	pb, ok := s.backend.(*ProviderBackend)
+	if !ok {
+		return fmt.Errorf("storage backend does not support intent queues")
+	}
+	_, err := UpdateIntentQueue(context.Background(), pb.storageService, clusterName, func(q *models.IntentQueue) {
+		q.Enqueue(intent)
+	})
+	return err
+}
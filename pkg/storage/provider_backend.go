@@ -3,6 +3,7 @@ package storage
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"strings"
 	"time"
@@ -56,7 +57,7 @@ func (pb *ProviderBackend) LoadClusters() ([]models.K3sCluster, error) {
 	key := pb.getKey("clusters/clusters.json")
 	data, err := pb.storageService.GetObject(context.Background(), key)
 	if err != nil {
-		if strings.Contains(err.Error(), "not found") {
+		if errors.Is(err, provider.ErrNotFound) {
 			return []models.K3sCluster{}, nil
 		}
 		return nil, err
@@ -92,7 +93,7 @@ func (pb *ProviderBackend) LoadClusterState(clusterName string) (*K3sClusterStat
 	ctx := context.Background()
 	
 	// Try to load the new YAML format first
-	configKey := pb.getKey(fmt.Sprintf("clusters/%s/config.yaml", clusterName))
+	configKey := pb.getKey(ClusterConfigKey(clusterName))
 	configData, err := pb.storageService.GetObject(ctx, configKey)
 	if err != nil {
 		return nil, fmt.Errorf("cluster %s config not found: %w", clusterName, err)
@@ -106,12 +107,16 @@ func (pb *ProviderBackend) LoadClusterState(clusterName string) (*K3sClusterStat
 	
 	// Load status file
 	var status *ClusterStatus
-	statusKey := pb.getKey(fmt.Sprintf("clusters/%s/status.yaml", clusterName))
+	statusKey := pb.getKey(ClusterStatusKey(clusterName))
 	statusData, err := pb.storageService.GetObject(ctx, statusKey)
 	if err == nil {
 		// First try to unmarshal to check format
 		var rawStatus map[string]interface{}
-		if err := yaml.Unmarshal(statusData, &rawStatus); err == nil {
+		err := yaml.Unmarshal(statusData, &rawStatus)
+		statusClusterID, _ := rawStatus["clusterId"].(string)
+		// A status left by an earlier cluster of the same name is ignored
+		// until the controller replaces it
+		if err == nil && StatusBelongsTo(statusClusterID, config.Metadata.ID) {
 			// Check if this is Lambda's format (with nested cluster.status)
 			if clusterData, ok := rawStatus["cluster"].(map[string]interface{}); ok {
 				status = &ClusterStatus{}
@@ -209,6 +214,12 @@ func (pb *ProviderBackend) LoadClusterState(clusterName string) (*K3sClusterStat
 						status.Phase = models.ClusterStatus("error")
 					case "Deleting":
 						status.Phase = models.ClusterStatus("deleting")
+					case "Stopping":
+						status.Phase = models.StatusStopping
+					case "Stopped":
+						status.Phase = models.StatusStopped
+					case "Starting":
+						status.Phase = models.StatusStarting
 					default:
 						status.Phase = models.ClusterStatus("creating")
 					}
@@ -227,7 +238,17 @@ func (pb *ProviderBackend) LoadClusterState(clusterName string) (*K3sClusterStat
 						}
 					}
 				}
-				
+
+				// Drift and rollout progress reported by the controller
+				var controllerStatus struct {
+					Drift   []models.DriftStatus  `yaml:"drift"`
+					Rollout *models.RolloutStatus `yaml:"rollout"`
+				}
+				if err := yaml.Unmarshal(statusData, &controllerStatus); err == nil {
+					status.Drift = controllerStatus.Drift
+					status.Rollout = controllerStatus.Rollout
+				}
+
 				// Initialize empty collections to avoid nil pointer issues
 				if status.InstanceIDs == nil {
 					status.InstanceIDs = make(map[string]string)
@@ -332,10 +353,10 @@ func (pb *ProviderBackend) DeleteClusterState(clusterName string) error {
 	ctx := context.Background()
 
 	// Delete config and status files
-	configKey := pb.getKey(fmt.Sprintf("clusters/%s/config.yaml", clusterName))
+	configKey := pb.getKey(ClusterConfigKey(clusterName))
 	pb.storageService.DeleteObject(ctx, configKey)
 	
-	statusKey := pb.getKey(fmt.Sprintf("clusters/%s/status.yaml", clusterName))
+	statusKey := pb.getKey(ClusterStatusKey(clusterName))
 	pb.storageService.DeleteObject(ctx, statusKey)
 
 	return nil
@@ -420,7 +441,7 @@ func (pb *ProviderBackend) LoadConfig() (map[string]interface{}, error) {
 	key := pb.getKey("config.json")
 	data, err := pb.storageService.GetObject(context.Background(), key)
 	if err != nil {
-		if strings.Contains(err.Error(), "not found") {
+		if errors.Is(err, provider.ErrNotFound) {
 			// Return default config
 			return map[string]interface{}{
 				"default_provider": "aws",
@@ -0,0 +1,140 @@
+package storage
+
+import (
+	"github.com/madhouselabs/goman/pkg/models"
+)
+
+// ClusterResourceFromConfig converts a stored config.yaml into a
+// ClusterResource. Presets are not expanded and the status is left empty.
+func ClusterResourceFromConfig(config *ClusterConfig) *models.ClusterResource {
+	cluster := &models.ClusterResource{
+		Name:              config.Metadata.Name,
+		ClusterID:         config.Metadata.ID,
+		Generation:        config.Metadata.Generation,
+		CreationTimestamp: config.Metadata.CreatedAt,
+		DeletionTimestamp: config.Metadata.DeletionTimestamp,
+		Labels:            config.Metadata.Labels,
+		Annotations:       config.Metadata.Annotations,
+		Spec: models.ClusterSpec{
+			Provider:     "aws",
+			Region:       config.Spec.Region,
+			InstanceType: config.Spec.InstanceType,
+			Mode:         string(config.Spec.Mode),
+			Tags:         models.ParseResourceTags(config.Spec.Tags),
+			K3sVersion:   config.Spec.K3sVersion,
+			DesiredState: config.Spec.DesiredState,
+			NodePools:    convertNodePoolsFromStorage(config.Spec.NodePools),
+			Preset:       config.Spec.Preset,
+			LowResource:  config.Spec.LowResource,
+			Architecture: config.Spec.Architecture,
+			ImageID:      config.Spec.ImageID,
+			OSFamily:     config.Spec.OSFamily,
+			RootVolume:   config.Spec.RootVolume,
+			Network: models.NetworkConfig{
+				Private:          config.Spec.PrivateNetwork,
+				VpcID:            config.Spec.VpcID,
+				SubnetIDs:        config.Spec.SubnetIDs,
+				SecurityGroupIDs: config.Spec.SecurityGroupIDs,
+				DedicatedVPC:     config.Spec.DedicatedVPC,
+				VpcCIDR:          config.Spec.VpcCIDR,
+			},
+
+			NodeReplacements: config.Spec.NodeReplacements,
+			DriftPolicy:      config.Spec.DriftPolicy,
+			Rollout:          config.Spec.Rollout,
+			DNS:              config.Spec.DNS,
+			VirtualIP:        config.Spec.VirtualIP,
+			LoadBalancer:     config.Spec.LoadBalancer,
+			RetagRequestedAt: config.Spec.RetagRequestedAt,
+
+			CertRotationRequestedAt:       config.Spec.CertRotationRequestedAt,
+			CredentialRotationRequestedAt: config.Spec.CredentialRotationRequestedAt,
+			QuorumRecovery:                config.Spec.QuorumRecovery,
+			Restore:                       config.Spec.Restore,
+			ResyncRequestedAt:             config.Spec.ResyncRequestedAt,
+			DriftFixRequestedAt:           config.Spec.DriftFixRequestedAt,
+
+			InstanceProtection: config.Spec.InstanceProtection,
+			ClusterLinks:       config.Spec.ClusterLinks,
+			Addons:             config.Spec.Addons,
+			Notifications:      config.Spec.Notifications,
+			Proxy:              config.Spec.Proxy,
+			Bootstrap:          config.Spec.Bootstrap,
+			Registries:         config.Spec.Registries,
+			Schedule:           config.Spec.Schedule,
+			DesiredStateSetAt:  config.Spec.DesiredStateSetAt,
+		},
+	}
+
+	// Set master count based on mode
+	if config.Spec.Mode == models.ModeHA {
+		cluster.Spec.MasterCount = 3
+	} else {
+		cluster.Spec.MasterCount = 1
+	}
+
+	return cluster
+}
+
+// ApplyClusterResource copies the metadata and spec fields a ClusterResource
+// carries onto a config. Fields the resource has no equivalent for (such as
+// the description or node lists) keep their stored values.
+func ApplyClusterResource(config *ClusterConfig, cluster *models.ClusterResource) {
+	if config.APIVersion == "" {
+		config.APIVersion = "goman.io/v1"
+		config.Kind = "K3sCluster"
+	}
+
+	config.Metadata.Name = cluster.Name
+	if cluster.ClusterID != "" {
+		config.Metadata.ID = cluster.ClusterID
+	}
+	if !cluster.CreationTimestamp.IsZero() {
+		config.Metadata.CreatedAt = cluster.CreationTimestamp
+	}
+	config.Metadata.Labels = cluster.Labels
+	config.Metadata.Annotations = cluster.Annotations
+	config.Metadata.DeletionTimestamp = cluster.DeletionTimestamp
+
+	config.Spec.Region = cluster.Spec.Region
+	config.Spec.InstanceType = cluster.Spec.InstanceType
+	config.Spec.Mode = models.ClusterMode(cluster.Spec.Mode)
+	config.Spec.Tags = models.FormatResourceTags(cluster.Spec.Tags)
+	config.Spec.K3sVersion = cluster.Spec.K3sVersion
+	config.Spec.DesiredState = cluster.Spec.DesiredState
+	config.Spec.NodePools = convertNodePoolsToStorage(cluster.Spec.NodePools)
+	config.Spec.Preset = cluster.Spec.Preset
+	config.Spec.LowResource = cluster.Spec.LowResource
+	config.Spec.Architecture = cluster.Spec.Architecture
+	config.Spec.ImageID = cluster.Spec.ImageID
+	config.Spec.OSFamily = cluster.Spec.OSFamily
+	config.Spec.PrivateNetwork = cluster.Spec.Network.Private
+	config.Spec.VpcID = cluster.Spec.Network.VpcID
+	config.Spec.SubnetIDs = cluster.Spec.Network.SubnetIDs
+	config.Spec.SecurityGroupIDs = cluster.Spec.Network.SecurityGroupIDs
+	config.Spec.DedicatedVPC = cluster.Spec.Network.DedicatedVPC
+	config.Spec.VpcCIDR = cluster.Spec.Network.VpcCIDR
+	config.Spec.RootVolume = cluster.Spec.RootVolume
+	config.Spec.NodeReplacements = cluster.Spec.NodeReplacements
+	config.Spec.DriftPolicy = cluster.Spec.DriftPolicy
+	config.Spec.Rollout = cluster.Spec.Rollout
+	config.Spec.DNS = cluster.Spec.DNS
+	config.Spec.VirtualIP = cluster.Spec.VirtualIP
+	config.Spec.LoadBalancer = cluster.Spec.LoadBalancer
+	config.Spec.RetagRequestedAt = cluster.Spec.RetagRequestedAt
+	config.Spec.CertRotationRequestedAt = cluster.Spec.CertRotationRequestedAt
+	config.Spec.CredentialRotationRequestedAt = cluster.Spec.CredentialRotationRequestedAt
+	config.Spec.QuorumRecovery = cluster.Spec.QuorumRecovery
+	config.Spec.Restore = cluster.Spec.Restore
+	config.Spec.ResyncRequestedAt = cluster.Spec.ResyncRequestedAt
+	config.Spec.DriftFixRequestedAt = cluster.Spec.DriftFixRequestedAt
+	config.Spec.InstanceProtection = cluster.Spec.InstanceProtection
+	config.Spec.ClusterLinks = cluster.Spec.ClusterLinks
+	config.Spec.Addons = cluster.Spec.Addons
+	config.Spec.Notifications = cluster.Spec.Notifications
+	config.Spec.Proxy = cluster.Spec.Proxy
+	config.Spec.Bootstrap = cluster.Spec.Bootstrap
+	config.Spec.Registries = cluster.Spec.Registries
+	config.Spec.Schedule = cluster.Spec.Schedule
+	config.Spec.DesiredStateSetAt = cluster.Spec.DesiredStateSetAt
+}
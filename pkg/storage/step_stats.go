@@ -0,0 +1,6 @@
+package storage
+
+// StepDurationsKey holds the step durations the controller uses to estimate
+// the time left for cluster setups and rollouts. Kept outside clusters/ so
+// updates don't trigger reconciles.
+const StepDurationsKey = "stats/step-durations.yaml"
@@ -0,0 +1,32 @@
+package storage
+
+import (
+	"fmt"
+	"time"
+)
+
+// TombstonePrefix is kept outside clusters/ so tombstones don't trigger reconciles
+const TombstonePrefix = "tombstones/"
+
+// TombstoneTTL is how long a tombstone blocks name reuse if the controller
+// never clears it (e.g., cleanup was abandoned)
+const TombstoneTTL = 24 * time.Hour
+
+// Tombstone marks a cluster name whose deletion has been requested but whose
+// cloud resources may still be cleaned up. The controller removes it once
+// deletion finishes; until then the name cannot be reused.
+type Tombstone struct {
+	Cluster   string    `json:"cluster" yaml:"cluster"`
+	ClusterID string    `json:"clusterId,omitempty" yaml:"clusterId,omitempty"`
+	DeletedAt time.Time `json:"deletedAt" yaml:"deletedAt"`
+}
+
+// TombstoneKey returns the storage key of a cluster's tombstone
+func TombstoneKey(clusterName string) string {
+	return fmt.Sprintf("%s%s.yaml", TombstonePrefix, clusterName)
+}
+
+// Expired reports whether the tombstone no longer blocks name reuse
+func (t Tombstone) Expired() bool {
+	return time.Since(t.DeletedAt) > TombstoneTTL
+}
@@ -0,0 +1,91 @@
+//go:build e2e
+
+// Package e2e runs the AWS provider against localstack. It is opt-in:
+//
+//	docker run -d -p 4566:4566 localstack/localstack
+//	go test -tags e2e ./test/e2e/
+//
+// AWS_ENDPOINT_URL selects another emulator endpoint. The tests are skipped
+// when the endpoint is not reachable.
+package e2e
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	gomanaws "github.com/madhouselabs/goman/pkg/provider/aws"
+)
+
+const (
+	defaultEndpoint = "http://localhost:4566"
+	testRegion      = "us-east-1"
+)
+
+// unavailable is why localstack cannot be used, empty when it can
+var unavailable string
+
+func TestMain(m *testing.M) {
+	setDefault("AWS_ENDPOINT_URL", defaultEndpoint)
+	setDefault("AWS_ACCESS_KEY_ID", "test")
+	setDefault("AWS_SECRET_ACCESS_KEY", "test")
+	setDefault("AWS_REGION", testRegion)
+	// A bucket per run keeps runs against a long-lived localstack apart
+	setDefault("GOMAN_STATE_BUCKET", fmt.Sprintf("goman-e2e-%d", time.Now().Unix()))
+	os.Unsetenv("AWS_PROFILE")
+
+	client := http.Client{Timeout: 3 * time.Second}
+	resp, err := client.Get(os.Getenv("AWS_ENDPOINT_URL") + "/_localstack/health")
+	if err != nil {
+		unavailable = fmt.Sprintf("localstack not reachable at %s: %v", os.Getenv("AWS_ENDPOINT_URL"), err)
+	} else {
+		resp.Body.Close()
+	}
+
+	os.Exit(m.Run())
+}
+
+func setDefault(key, value string) {
+	if os.Getenv(key) == "" {
+		os.Setenv(key, value)
+	}
+}
+
+// newProvider returns an AWS provider talking to localstack, with the state
+// bucket created. It skips the test when localstack is not available.
+func newProvider(t *testing.T) *gomanaws.AWSProvider {
+	t.Helper()
+	if unavailable != "" {
+		t.Skip(unavailable)
+	}
+
+	p, err := gomanaws.NewProvider("", os.Getenv("AWS_REGION"))
+	if err != nil {
+		t.Fatalf("NewProvider: %v", err)
+	}
+
+	// goman expects the state bucket to exist before it is initialized
+	ctx := testContext(t)
+	bucket := os.Getenv("GOMAN_STATE_BUCKET")
+	if _, err := p.GetS3Client().HeadBucket(ctx, &s3.HeadBucketInput{Bucket: aws.String(bucket)}); err != nil {
+		if _, err := p.GetS3Client().CreateBucket(ctx, &s3.CreateBucketInput{Bucket: aws.String(bucket)}); err != nil {
+			t.Fatalf("failed to create state bucket %s: %v", bucket, err)
+		}
+	}
+	if err := p.GetStorageService().Initialize(ctx); err != nil {
+		t.Fatalf("storage Initialize: %v", err)
+	}
+	return p
+}
+
+func testContext(t *testing.T) context.Context {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	t.Cleanup(cancel)
+	return ctx
+}
@@ -0,0 +1,182 @@
+//go:build e2e
+
+package e2e
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/madhouselabs/goman/pkg/provider"
+)
+
+func TestInitialize(t *testing.T) {
+	p := newProvider(t)
+
+	// The controller package is not built here, so deploying the function
+	// fails; the services it does not depend on must come up regardless
+	result, err := p.Initialize(testContext(t))
+	if err != nil {
+		t.Logf("Initialize: %v", err)
+	}
+	if result == nil {
+		t.Fatal("Initialize returned no result")
+	}
+	if !result.StorageReady {
+		t.Errorf("storage not ready: %v", result.Errors)
+	}
+	if !result.LockServiceReady {
+		t.Errorf("lock service not ready: %v", result.Errors)
+	}
+	if !result.NotificationsReady {
+		t.Errorf("notifications not ready: %v", result.Errors)
+	}
+	for _, msg := range result.Errors {
+		if !strings.HasPrefix(msg, "Function:") && !strings.HasPrefix(msg, "SSM documents:") {
+			t.Errorf("unexpected initialization error: %s", msg)
+		}
+	}
+}
+
+func TestStorage(t *testing.T) {
+	p := newProvider(t)
+	ctx := testContext(t)
+	svc := p.GetStorageService()
+
+	prefix := fmt.Sprintf("e2e/%d/", time.Now().UnixNano())
+	key := prefix + "config.yaml"
+	if err := svc.PutObject(ctx, key, []byte("name: e2e\n")); err != nil {
+		t.Fatalf("PutObject: %v", err)
+	}
+	data, err := svc.GetObject(ctx, key)
+	if err != nil {
+		t.Fatalf("GetObject: %v", err)
+	}
+	if string(data) != "name: e2e\n" {
+		t.Errorf("GetObject = %q", data)
+	}
+
+	keys, err := svc.ListObjects(ctx, prefix)
+	if err != nil {
+		t.Fatalf("ListObjects: %v", err)
+	}
+	if len(keys) != 1 || keys[0] != key {
+		t.Errorf("ListObjects(%s) = %v, want [%s]", prefix, keys, key)
+	}
+
+	if err := svc.DeleteObject(ctx, key); err != nil {
+		t.Fatalf("DeleteObject: %v", err)
+	}
+	if _, err := svc.GetObject(ctx, key); !errors.Is(err, provider.ErrNotFound) {
+		t.Errorf("GetObject after delete = %v, want ErrNotFound", err)
+	}
+}
+
+func TestConditionalWrites(t *testing.T) {
+	p := newProvider(t)
+	ctx := testContext(t)
+	svc, ok := p.GetStorageService().(provider.VersionedStorage)
+	if !ok {
+		t.Fatal("storage service does not support conditional writes")
+	}
+
+	key := fmt.Sprintf("e2e/%d/queue.yaml", time.Now().UnixNano())
+	version, err := svc.PutObjectIfMatch(ctx, key, []byte("a"), "")
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	if _, err := svc.PutObjectIfMatch(ctx, key, []byte("b"), ""); !errors.Is(err, provider.ErrPreconditionFailed) {
+		t.Errorf("second create = %v, want ErrPreconditionFailed", err)
+	}
+
+	if _, err := svc.PutObjectIfMatch(ctx, key, []byte("c"), version); err != nil {
+		t.Fatalf("update with current version: %v", err)
+	}
+	if _, err := svc.PutObjectIfMatch(ctx, key, []byte("d"), version); !errors.Is(err, provider.ErrPreconditionFailed) {
+		t.Errorf("update with stale version = %v, want ErrPreconditionFailed", err)
+	}
+
+	data, _, err := svc.GetObjectVersion(ctx, key)
+	if err != nil {
+		t.Fatalf("GetObjectVersion: %v", err)
+	}
+	if string(data) != "c" {
+		t.Errorf("stored %q, want %q", data, "c")
+	}
+}
+
+func TestLocks(t *testing.T) {
+	p := newProvider(t)
+	ctx := testContext(t)
+	locks := p.GetLockService()
+	if err := locks.Initialize(ctx); err != nil {
+		t.Fatalf("Initialize: %v", err)
+	}
+
+	resource := fmt.Sprintf("e2e-%d", time.Now().UnixNano())
+	token, err := locks.AcquireLockWithMetadata(ctx, resource, "e2e-a", time.Minute, &provider.LockMetadata{
+		Phase:     "Provisioning",
+		RequestID: "e2e",
+		StartedAt: time.Now(),
+	})
+	if err != nil {
+		t.Fatalf("AcquireLock: %v", err)
+	}
+	if _, err := locks.AcquireLock(ctx, resource, "e2e-b", time.Minute); err == nil {
+		t.Error("second owner acquired a held lock")
+	}
+
+	locked, owner, err := locks.IsLocked(ctx, resource)
+	if err != nil {
+		t.Fatalf("IsLocked: %v", err)
+	}
+	if !locked || owner != "e2e-a" {
+		t.Errorf("IsLocked = %v, %q, want true, e2e-a", locked, owner)
+	}
+
+	if err := locks.RenewLock(ctx, resource, token, 2*time.Minute); err != nil {
+		t.Errorf("RenewLock: %v", err)
+	}
+	if err := locks.ReleaseLock(ctx, resource, token); err != nil {
+		t.Fatalf("ReleaseLock: %v", err)
+	}
+
+	token, err = locks.AcquireLock(ctx, resource, "e2e-b", time.Minute)
+	if err != nil {
+		t.Fatalf("AcquireLock after release: %v", err)
+	}
+	if err := locks.ReleaseLock(ctx, resource, token); err != nil {
+		t.Errorf("ReleaseLock: %v", err)
+	}
+}
+
+func TestNotifications(t *testing.T) {
+	p := newProvider(t)
+	ctx := testContext(t)
+	notifications := p.GetNotificationService()
+	if err := notifications.Initialize(ctx); err != nil {
+		t.Fatalf("Initialize: %v", err)
+	}
+	if err := notifications.Publish(ctx, "goman-cluster-events", `{"cluster":"e2e","event":"test"}`); err != nil {
+		t.Errorf("Publish: %v", err)
+	}
+}
+
+func TestListInstances(t *testing.T) {
+	p := newProvider(t)
+
+	// Only the request shape matters: localstack's EC2 accepts the filters
+	// the controller sends when it looks up the instances of a cluster
+	instances, err := p.GetComputeService().ListInstances(testContext(t), map[string]string{
+		"tag:goman-cluster":   fmt.Sprintf("e2e-%d", time.Now().UnixNano()),
+		"instance-state-name": "running,pending,stopping,stopped",
+	})
+	if err != nil {
+		t.Fatalf("ListInstances: %v", err)
+	}
+	if len(instances) != 0 {
+		t.Errorf("ListInstances of an unknown cluster = %d instances", len(instances))
+	}
+}
@@ -0,0 +1,329 @@
+//go:build e2e
+
+package e2e
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/madhouselabs/goman/pkg/controller"
+	"github.com/madhouselabs/goman/pkg/models"
+	"github.com/madhouselabs/goman/pkg/provider"
+	gomanaws "github.com/madhouselabs/goman/pkg/provider/aws"
+	"github.com/madhouselabs/goman/pkg/storage"
+	"gopkg.in/yaml.v3"
+)
+
+// reconcileProvider is the localstack provider with instances and node
+// commands replaced by fakeCompute, as localstack neither boots instances
+// nor runs SSM commands
+type reconcileProvider struct {
+	*gomanaws.AWSProvider
+	compute *fakeCompute
+}
+
+func (p *reconcileProvider) GetComputeService() provider.ComputeService {
+	return p.compute
+}
+
+func TestReconcileLoop(t *testing.T) {
+	p := &reconcileProvider{AWSProvider: newProvider(t), compute: newFakeCompute()}
+	ctx := testContext(t)
+	if err := p.GetLockService().Initialize(ctx); err != nil {
+		t.Fatalf("lock Initialize: %v", err)
+	}
+
+	name := fmt.Sprintf("e2e-%d", time.Now().Unix())
+	config := storage.ConvertToClusterConfig(models.K3sCluster{
+		Name:         name,
+		Mode:         models.ModeDev,
+		Region:       testRegion,
+		InstanceType: "t3.medium",
+		CreatedAt:    time.Now(),
+		UpdatedAt:    time.Now(),
+		NodePools: []models.NodePool{
+			{Name: "workers", Count: 2, InstanceType: "t3.small"},
+		},
+	})
+	config.Metadata.Generation = 1
+	writeConfig(t, p, config)
+
+	reconciler, err := controller.NewReconciler(p, "e2e")
+	if err != nil {
+		t.Fatalf("NewReconciler: %v", err)
+	}
+
+	var status models.ClusterResourceStatus
+	for pass := 0; pass < 10 && status.Phase != string(models.ClusterPhaseRunning); pass++ {
+		if _, err := reconciler.ReconcileCluster(ctx, name); err != nil {
+			t.Fatalf("pass %d: %v", pass, err)
+		}
+		status = readStatus(t, p, name)
+		t.Logf("pass %d: phase %s: %s", pass, status.Phase, status.Message)
+	}
+	if status.Phase != string(models.ClusterPhaseRunning) {
+		t.Fatalf("cluster did not reach Running, phase %s: %s", status.Phase, status.Message)
+	}
+	if got := p.compute.count(name, "master"); got != 1 {
+		t.Errorf("masters = %d, want 1", got)
+	}
+	if got := p.compute.count(name, "worker"); got != 2 {
+		t.Errorf("workers = %d, want 2", got)
+	}
+	if locked, owner, err := p.GetLockService().IsLocked(ctx, "cluster-"+name); err != nil || locked {
+		t.Errorf("cluster lock still held by %q after reconcile (err %v)", owner, err)
+	}
+
+	// Deleting removes the instances and the cluster's files
+	now := time.Now()
+	config.Metadata.DeletionTimestamp = &now
+	writeConfig(t, p, config)
+	if _, err := reconciler.ReconcileCluster(ctx, name); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	if got := p.compute.count(name, ""); got != 0 {
+		t.Errorf("%d instances left after delete", got)
+	}
+	if _, err := p.GetStorageService().GetObject(ctx, fmt.Sprintf("clusters/%s/config.yaml", name)); !errors.Is(err, provider.ErrNotFound) {
+		t.Errorf("config after delete: %v, want ErrNotFound", err)
+	}
+}
+
+func writeConfig(t *testing.T, p provider.Provider, config *storage.ClusterConfig) {
+	t.Helper()
+	data, err := yaml.Marshal(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key := fmt.Sprintf("clusters/%s/config.yaml", config.Metadata.Name)
+	if err := p.GetStorageService().PutObject(testContext(t), key, data); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+}
+
+func readStatus(t *testing.T, p provider.Provider, name string) models.ClusterResourceStatus {
+	t.Helper()
+	var status models.ClusterResourceStatus
+	data, err := p.GetStorageService().GetObject(testContext(t), fmt.Sprintf("clusters/%s/status.yaml", name))
+	if err != nil {
+		t.Fatalf("failed to read status: %v", err)
+	}
+	if err := yaml.Unmarshal(data, &status); err != nil {
+		t.Fatalf("invalid status: %v", err)
+	}
+	return status
+}
+
+// fakeCompute keeps instances in memory. They are running as soon as they
+// are created, and every command succeeds without output.
+type fakeCompute struct {
+	mu        sync.Mutex
+	next      int
+	instances map[string]*provider.Instance
+}
+
+func newFakeCompute() *fakeCompute {
+	return &fakeCompute{instances: make(map[string]*provider.Instance)}
+}
+
+// count returns the instances of a cluster with a role, or all of them for
+// an empty role
+func (f *fakeCompute) count(cluster, role string) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	n := 0
+	for _, inst := range f.instances {
+		if inst.Tags["goman-cluster"] == cluster && (role == "" || inst.Tags["goman-role"] == role) {
+			n++
+		}
+	}
+	return n
+}
+
+func (f *fakeCompute) CreateInstance(ctx context.Context, config provider.InstanceConfig) (*provider.Instance, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.next++
+	tags := map[string]string{"Name": config.Name}
+	for k, v := range config.Tags {
+		tags[k] = v
+	}
+	inst := &provider.Instance{
+		ID:           fmt.Sprintf("i-e2e%012d", f.next),
+		Name:         config.Name,
+		State:        "running",
+		PrivateIP:    fmt.Sprintf("10.0.0.%d", f.next),
+		InstanceType: config.InstanceType,
+		LaunchTime:   time.Now(),
+		Tags:         tags,
+
+		AvailabilityZone: config.AvailabilityZone,
+	}
+	f.instances[inst.ID] = inst
+	copied := *inst
+	return &copied, nil
+}
+
+func (f *fakeCompute) DeleteInstance(ctx context.Context, instanceID string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	delete(f.instances, instanceID)
+	return nil
+}
+
+func (f *fakeCompute) GetInstance(ctx context.Context, instanceID string) (*provider.Instance, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	inst, ok := f.instances[instanceID]
+	if !ok {
+		return nil, fmt.Errorf("instance %s: %w", instanceID, provider.ErrNotFound)
+	}
+	copied := *inst
+	return &copied, nil
+}
+
+// ListInstances supports the tag:<key> and instance-state-name filters,
+// with comma-separated values
+func (f *fakeCompute) ListInstances(ctx context.Context, filters map[string]string) ([]*provider.Instance, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var result []*provider.Instance
+	for _, inst := range f.instances {
+		if matchesFilters(inst, filters) {
+			copied := *inst
+			result = append(result, &copied)
+		}
+	}
+	return result, nil
+}
+
+func matchesFilters(inst *provider.Instance, filters map[string]string) bool {
+	for key, values := range filters {
+		var actual string
+		switch {
+		case key == "instance-state-name":
+			actual = inst.State
+		case strings.HasPrefix(key, "tag:"):
+			actual = inst.Tags[strings.TrimPrefix(key, "tag:")]
+		default:
+			continue
+		}
+		matched := false
+		for _, value := range strings.Split(values, ",") {
+			if value == actual {
+				matched = true
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	return true
+}
+
+func (f *fakeCompute) setState(instanceID, state string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	inst, ok := f.instances[instanceID]
+	if !ok {
+		return fmt.Errorf("instance %s: %w", instanceID, provider.ErrNotFound)
+	}
+	inst.State = state
+	return nil
+}
+
+func (f *fakeCompute) StartInstance(ctx context.Context, instanceID string) error {
+	return f.setState(instanceID, "running")
+}
+
+func (f *fakeCompute) StopInstance(ctx context.Context, instanceID string) error {
+	return f.setState(instanceID, "stopped")
+}
+
+func (f *fakeCompute) ModifyInstanceType(ctx context.Context, instanceID string, instanceType string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if inst, ok := f.instances[instanceID]; ok {
+		inst.InstanceType = instanceType
+	}
+	return nil
+}
+
+func (f *fakeCompute) TagInstance(ctx context.Context, instanceID string, tags map[string]string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if inst, ok := f.instances[instanceID]; ok {
+		for k, v := range tags {
+			inst.Tags[k] = v
+		}
+	}
+	return nil
+}
+
+func (f *fakeCompute) PrepareVirtualIP(ctx context.Context, clusterName string, instanceIDs []string, address string) (string, error) {
+	if address == "" {
+		address = "10.0.0.250"
+	}
+	return address, nil
+}
+
+func (f *fakeCompute) SetInstanceProtection(ctx context.Context, instanceID string, shutdownBehavior string, stopProtection, deletionProtection bool) error {
+	return nil
+}
+
+func (f *fakeCompute) SyncClusterTags(ctx context.Context, clusterName string, tags map[string]string, removed []string) (*provider.TagSyncResult, error) {
+	return &provider.TagSyncResult{}, nil
+}
+
+func (f *fakeCompute) LinkClusters(ctx context.Context, link provider.ClusterLinkConfig) (*provider.ClusterLinkResult, error) {
+	return &provider.ClusterLinkResult{Mode: provider.LinkModeSecurityGroup}, nil
+}
+
+func (f *fakeCompute) UnlinkClusters(ctx context.Context, link provider.ClusterLinkConfig, peeringID string) error {
+	return nil
+}
+
+func (f *fakeCompute) RunCommand(ctx context.Context, instanceIDs []string, command string) (*provider.CommandResult, error) {
+	return succeeded(instanceIDs), nil
+}
+
+func (f *fakeCompute) StartCommand(ctx context.Context, instanceIDs []string, command string) (string, error) {
+	return "e2e-command", nil
+}
+
+func (f *fakeCompute) GetCommandResult(ctx context.Context, commandID string) (*provider.CommandResult, error) {
+	return &provider.CommandResult{CommandID: commandID, Status: "Success"}, nil
+}
+
+func (f *fakeCompute) RunOperation(ctx context.Context, instanceIDs []string, operation string, params map[string]string) (*provider.CommandResult, error) {
+	return succeeded(instanceIDs), nil
+}
+
+func (f *fakeCompute) EnsureClusterIdentity(ctx context.Context, clusterName string) (string, error) {
+	return "goman-" + clusterName, nil
+}
+
+func (f *fakeCompute) ListClusterIdentities(ctx context.Context) ([]string, error) {
+	return nil, nil
+}
+
+func (f *fakeCompute) DeleteClusterIdentity(ctx context.Context, clusterName string) error {
+	return nil
+}
+
+func succeeded(instanceIDs []string) *provider.CommandResult {
+	result := &provider.CommandResult{
+		CommandID: "e2e-command",
+		Status:    "Success",
+		Instances: make(map[string]*provider.InstanceCommandResult),
+	}
+	for _, id := range instanceIDs {
+		result.Instances[id] = &provider.InstanceCommandResult{InstanceID: id, Status: "Success"}
+	}
+	return result
+}
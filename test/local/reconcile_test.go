@@ -0,0 +1,255 @@
+// Package local runs the reconciler against the local provider with the
+// fake driver, which needs neither a cloud account nor VMs:
+//
+//	go test ./test/local/
+package local
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/madhouselabs/goman/pkg/controller"
+	"github.com/madhouselabs/goman/pkg/models"
+	"github.com/madhouselabs/goman/pkg/provider"
+	"github.com/madhouselabs/goman/pkg/provider/local"
+	"github.com/madhouselabs/goman/pkg/storage"
+	"gopkg.in/yaml.v3"
+)
+
+func newProvider(t *testing.T) *local.LocalProvider {
+	t.Helper()
+	t.Setenv("GOMAN_LOCAL_DRIVER", "fake")
+	p, err := local.NewProvider(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewProvider: %v", err)
+	}
+	if _, err := p.Initialize(context.Background()); err != nil {
+		t.Fatalf("Initialize: %v", err)
+	}
+	return p
+}
+
+func TestReconcileLoop(t *testing.T) {
+	p := newProvider(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+
+	name := "local-test"
+	config := storage.ConvertToClusterConfig(models.K3sCluster{
+		Name:         name,
+		Mode:         models.ModeDev,
+		Region:       local.Region,
+		InstanceType: "t3.medium",
+		CreatedAt:    time.Now(),
+		UpdatedAt:    time.Now(),
+		NodePools: []models.NodePool{
+			{Name: "workers", Count: 2, InstanceType: "t3.small"},
+		},
+	})
+	config.Metadata.Generation = 1
+	writeConfig(t, p, config)
+
+	reconciler, err := controller.NewReconciler(p, "local-test")
+	if err != nil {
+		t.Fatalf("NewReconciler: %v", err)
+	}
+
+	var status models.ClusterResourceStatus
+	for pass := 0; pass < 10 && status.Phase != string(models.ClusterPhaseRunning); pass++ {
+		if _, err := reconciler.ReconcileCluster(ctx, name); err != nil {
+			t.Fatalf("pass %d: %v", pass, err)
+		}
+		status = readStatus(t, p, name)
+		t.Logf("pass %d: phase %s: %s", pass, status.Phase, status.Message)
+	}
+	if status.Phase != string(models.ClusterPhaseRunning) {
+		t.Fatalf("cluster did not reach Running, phase %s: %s", status.Phase, status.Message)
+	}
+	if got := count(t, p, name, "master"); got != 1 {
+		t.Errorf("masters = %d, want 1", got)
+	}
+	if got := count(t, p, name, "worker"); got != 2 {
+		t.Errorf("workers = %d, want 2", got)
+	}
+	if locked, owner, err := p.GetLockService().IsLocked(ctx, "cluster-"+name); err != nil || locked {
+		t.Errorf("cluster lock still held by %q after reconcile (err %v)", owner, err)
+	}
+
+	// Every launched instance and the phase changes up to Running were recorded
+	events := readEvents(t, p, name)
+	reasons := make(map[string]int)
+	for _, e := range events {
+		reasons[e.Reason]++
+	}
+	if reasons[controller.EventInstanceProvisioned] != 3 {
+		t.Errorf("%d InstanceProvisioned events, want 3", reasons[controller.EventInstanceProvisioned])
+	}
+	if last := events[len(events)-1]; last.Reason != controller.EventPhaseChanged || !strings.Contains(last.Message, "to Running") {
+		t.Errorf("last event = %s: %s, want the change to Running", last.Reason, last.Message)
+	}
+
+	// A resync rebuilds a corrupted status from the instances
+	corrupted, err := yaml.Marshal(models.ClusterResourceStatus{Phase: string(models.ClusterPhaseFailed), Message: "lost track"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := p.GetStorageService().PutObject(ctx, fmt.Sprintf("clusters/%s/status.yaml", name), corrupted); err != nil {
+		t.Fatal(err)
+	}
+	requestedAt := time.Now().UTC().Truncate(time.Second)
+	config.Spec.ResyncRequestedAt = &requestedAt
+	writeConfig(t, p, config)
+	if _, err := reconciler.ReconcileCluster(ctx, name); err != nil {
+		t.Fatalf("resync: %v", err)
+	}
+	status = readStatus(t, p, name)
+	if status.Phase != string(models.ClusterPhaseRunning) || len(status.Instances) != 3 || status.ResyncedAt == nil {
+		t.Errorf("after resync: phase %s, %d instances, resynced at %v", status.Phase, len(status.Instances), status.ResyncedAt)
+	}
+	if got := count(t, p, name, ""); got != 3 {
+		t.Errorf("%d instances after resync, want the same 3", got)
+	}
+	resynced := false
+	for _, e := range readEvents(t, p, name) {
+		resynced = resynced || e.Reason == controller.EventResynced
+	}
+	if !resynced {
+		t.Errorf("no %s event recorded", controller.EventResynced)
+	}
+
+	// Deleting removes the instances and the cluster's files
+	now := time.Now()
+	config.Metadata.DeletionTimestamp = &now
+	writeConfig(t, p, config)
+	if _, err := reconciler.ReconcileCluster(ctx, name); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	if got := count(t, p, name, ""); got != 0 {
+		t.Errorf("%d instances left after delete", got)
+	}
+	if _, err := p.GetStorageService().GetObject(ctx, fmt.Sprintf("clusters/%s/config.yaml", name)); !errors.Is(err, provider.ErrNotFound) {
+		t.Errorf("config after delete: %v, want ErrNotFound", err)
+	}
+	// Events are kept after the cluster is gone
+	if events := readEvents(t, p, name); events[len(events)-1].Reason != controller.EventDeleted {
+		t.Errorf("last event after delete = %s, want %s", events[len(events)-1].Reason, controller.EventDeleted)
+	}
+}
+
+// readEvents returns the recorded events of a cluster, oldest first
+func readEvents(t *testing.T, p *local.LocalProvider, name string) []models.Event {
+	t.Helper()
+	ctx := context.Background()
+	keys, err := p.GetStorageService().ListObjects(ctx, storage.ClusterEventsPrefix(name))
+	if err != nil {
+		t.Fatalf("list events: %v", err)
+	}
+	sort.Strings(keys)
+	var events []models.Event
+	for _, key := range keys {
+		data, err := p.GetStorageService().GetObject(ctx, key)
+		if err != nil {
+			t.Fatalf("read event %s: %v", key, err)
+		}
+		var e models.Event
+		if err := yaml.Unmarshal(data, &e); err != nil {
+			t.Fatalf("parse event %s: %v", key, err)
+		}
+		events = append(events, e)
+	}
+	if len(events) == 0 {
+		t.Fatalf("no events recorded for %s", name)
+	}
+	return events
+}
+
+func TestVersionedStorage(t *testing.T) {
+	p := newProvider(t)
+	ctx := context.Background()
+	vs := p.GetStorageService().(provider.VersionedStorage)
+
+	version, err := vs.PutObjectIfMatch(ctx, "intents/a.yaml", []byte("one"), "")
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	if _, err := vs.PutObjectIfMatch(ctx, "intents/a.yaml", []byte("two"), ""); !errors.Is(err, provider.ErrPreconditionFailed) {
+		t.Errorf("create over existing object: %v, want ErrPreconditionFailed", err)
+	}
+	if _, err := vs.PutObjectIfMatch(ctx, "intents/a.yaml", []byte("two"), version); err != nil {
+		t.Errorf("update with current version: %v", err)
+	}
+	if _, err := vs.PutObjectIfMatch(ctx, "intents/a.yaml", []byte("three"), version); !errors.Is(err, provider.ErrPreconditionFailed) {
+		t.Errorf("update with stale version: %v, want ErrPreconditionFailed", err)
+	}
+}
+
+func TestLocks(t *testing.T) {
+	p := newProvider(t)
+	ctx := context.Background()
+	locks := p.GetLockService()
+
+	token, err := locks.AcquireLock(ctx, "cluster-a", "first", time.Minute)
+	if err != nil {
+		t.Fatalf("AcquireLock: %v", err)
+	}
+	if _, err := locks.AcquireLock(ctx, "cluster-a", "second", time.Minute); err == nil {
+		t.Error("second AcquireLock of a held lock succeeded")
+	}
+	if locked, owner, _ := locks.IsLocked(ctx, "cluster-a"); !locked || owner != "first" {
+		t.Errorf("IsLocked = %v, %q, want true, first", locked, owner)
+	}
+	if err := locks.ReleaseLock(ctx, "cluster-a", "wrong"); err == nil {
+		t.Error("ReleaseLock with a wrong token succeeded")
+	}
+	if err := locks.ReleaseLock(ctx, "cluster-a", token); err != nil {
+		t.Fatalf("ReleaseLock: %v", err)
+	}
+	if _, err := locks.AcquireLock(ctx, "cluster-a", "second", time.Minute); err != nil {
+		t.Errorf("AcquireLock after release: %v", err)
+	}
+}
+
+func writeConfig(t *testing.T, p provider.Provider, config *storage.ClusterConfig) {
+	t.Helper()
+	data, err := yaml.Marshal(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key := fmt.Sprintf("clusters/%s/config.yaml", config.Metadata.Name)
+	if err := p.GetStorageService().PutObject(context.Background(), key, data); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+}
+
+func readStatus(t *testing.T, p provider.Provider, name string) models.ClusterResourceStatus {
+	t.Helper()
+	var status models.ClusterResourceStatus
+	data, err := p.GetStorageService().GetObject(context.Background(), fmt.Sprintf("clusters/%s/status.yaml", name))
+	if err != nil {
+		t.Fatalf("failed to read status: %v", err)
+	}
+	if err := yaml.Unmarshal(data, &status); err != nil {
+		t.Fatalf("invalid status: %v", err)
+	}
+	return status
+}
+
+// count returns the instances of a cluster with a role, or all of them for
+// an empty role
+func count(t *testing.T, p provider.Provider, cluster, role string) int {
+	t.Helper()
+	filters := map[string]string{"tag:goman-cluster": cluster}
+	if role != "" {
+		filters["tag:goman-role"] = role
+	}
+	instances, err := p.GetComputeService().ListInstances(context.Background(), filters)
+	if err != nil {
+		t.Fatalf("ListInstances: %v", err)
+	}
+	return len(instances)
+}